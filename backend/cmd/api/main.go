@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"io"
 	"net/http"
 	"os"
 	"os/signal"
@@ -9,11 +10,13 @@ import (
 	"time"
 
 	_ "iafarma/docs" // Import swagger docsx
+	"iafarma/internal/ai"
 	"iafarma/internal/app"
 	"iafarma/internal/db"
 	"iafarma/internal/http/handlers"
 	"iafarma/internal/http/middleware"
 	"iafarma/internal/telemetry"
+	"iafarma/internal/utils"
 
 	"github.com/go-playground/validator/v10"
 	"github.com/joho/godotenv"
@@ -65,9 +68,15 @@ func main() {
 
 	// Setup logger
 	zerolog.TimeFieldFormat = time.RFC3339
+	var logOutput io.Writer = os.Stderr
 	if os.Getenv("ENV") == "development" {
-		log.Logger = log.Output(zerolog.ConsoleWriter{Out: os.Stderr})
+		logOutput = zerolog.ConsoleWriter{Out: os.Stderr}
 	}
+	// Mascarar telefones, nomes e conteúdo de mensagens (LOG_REDACT_PII; ligado fora de development)
+	if utils.PIIRedactionEnabled() {
+		logOutput = utils.NewPIIRedactingWriter(logOutput)
+	}
+	log.Logger = log.Output(logOutput)
 
 	// Initialize telemetry (optional service)
 	shutdown, enabled, err := telemetry.InitTelemetry()
@@ -81,6 +90,9 @@ func main() {
 	}
 	defer shutdown()
 
+	// Audio transcription depends on FFmpeg for some formats
+	ai.LogFFmpegAvailability()
+
 	// Initialize database
 	database, err := db.NewDatabase()
 	if err != nil {
@@ -114,6 +126,12 @@ func main() {
 			go services.UsageSyncService.Start(ctx)
 			log.Info().Msg("Usage sync service started")
 		}
+
+		// Start orphaned media cleanup
+		if services.MediaCleanupService != nil {
+			go services.MediaCleanupService.Start(ctx)
+			log.Info().Msg("Media cleanup service started")
+		}
 	} else {
 		log.Warn().Msg("Channel monitor service not available")
 	}
@@ -126,6 +144,12 @@ func main() {
 		log.Warn().Msg("Infrastructure monitoring service not available")
 	}
 
+	// Start recurring subscription orders scheduler
+	subscriptionCtx, cancelSubscriptions := context.WithCancel(context.Background())
+	defer cancelSubscriptions()
+	go ai.NewSubscriptionScheduler(database).Start(subscriptionCtx)
+	log.Info().Msg("Subscription scheduler started")
+
 	// Setup Echo
 	e := echo.New()
 	e.HideBanner = true
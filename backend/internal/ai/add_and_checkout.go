@@ -0,0 +1,226 @@
+package ai
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+	"github.com/sashabaranov/go-openai"
+)
+
+// addToCartTools são as ferramentas que colocam produtos no carrinho
+var addToCartTools = map[string]bool{
+	"adicionarAoCarrinho":       true,
+	"adicionarProdutoPorNome":   true,
+	"adicionarPorNumero":        true,
+	"adicionarItemDetalhado":    true,
+	"adicionarMaisItemCarrinho": true,
+}
+
+// addAndCheckoutItem é um produto pedido junto com a finalização
+type addAndCheckoutItem struct {
+	Product  string
+	Quantity int
+}
+
+// parseAddAndCheckoutItems lê os itens da ferramenta adicionarEFinalizar (quantidade 1 quando omitida)
+func parseAddAndCheckoutItems(args map[string]interface{}) []addAndCheckoutItem {
+	rawItems, _ := args["itens"].([]interface{})
+
+	var items []addAndCheckoutItem
+	for _, raw := range rawItems {
+		fields, ok := raw.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		product := ""
+		switch value := fields["produto"].(type) {
+		case string:
+			product = strings.TrimSpace(value)
+		case float64:
+			product = strconv.FormatFloat(value, 'f', -1, 64)
+		}
+		if product == "" {
+			continue
+		}
+
+		quantity := 1
+		switch value := fields["quantidade"].(type) {
+		case float64:
+			quantity = int(value)
+		case string:
+			if parsed, err := strconv.Atoi(strings.TrimSpace(value)); err == nil {
+				quantity = parsed
+			}
+		}
+		if quantity <= 0 {
+			quantity = 1
+		}
+
+		items = append(items, addAndCheckoutItem{Product: product, Quantity: quantity})
+	}
+	return items
+}
+
+// cartQuantities indexa a quantidade de cada produto no carrinho, para saber se uma adição entrou de fato
+func (s *AIService) cartQuantities(tenantID, cartID uuid.UUID) map[uuid.UUID]int {
+	quantities := make(map[uuid.UUID]int)
+	cart, err := s.cartService.GetCartWithItems(cartID, tenantID)
+	if err != nil || cart == nil {
+		return quantities
+	}
+	for _, item := range cart.Items {
+		if item.ProductID != nil {
+			quantities[*item.ProductID] += item.Quantity
+		}
+	}
+	return quantities
+}
+
+// addedCartItemName retorna o nome do produto cuja quantidade aumentou no carrinho
+func (s *AIService) addedCartItemName(tenantID, cartID uuid.UUID, before map[uuid.UUID]int) (string, bool) {
+	cart, err := s.cartService.GetCartWithItems(cartID, tenantID)
+	if err != nil || cart == nil {
+		return "", false
+	}
+	for _, item := range cart.Items {
+		if item.ProductID != nil && item.Quantity > before[*item.ProductID] {
+			return getItemName(item), true
+		}
+	}
+	return "", false
+}
+
+// productChoicesForName lista os produtos parecidos com o nome quando ele não identifica um único item
+func (s *AIService) productChoicesForName(ctx context.Context, tenantID uuid.UUID, customerPhone, name string) string {
+	products, err := s.searchProducts(ctx, tenantID, name, 5)
+	if err != nil || len(products) < 2 {
+		return ""
+	}
+
+	productRefs := s.memoryManager.StoreProductList(tenantID, customerPhone, products)
+	result := fmt.Sprintf("🔍 Encontrei %d produtos parecidos com '%s':\n\n", len(products), name)
+	for _, productRef := range productRefs {
+		result += fmt.Sprintf("%d. **%s**\n   💰 %s\n", productRef.SequentialID, productRef.Name, formatListPrice(productRef.Price, productRef.SalePrice))
+	}
+	return result + "\n📝 Me diga o número do item que você quer."
+}
+
+// formatAddedItems resume os itens colocados no carrinho antes do checkout
+func formatAddedItems(added []string) string {
+	return "✅ Adicionei ao seu carrinho:\n" + strings.Join(added, "\n")
+}
+
+// handleAdicionarEFinalizar adiciona os itens pedidos e segue direto para o checkout ("adiciona 2 dipironas e já finaliza"),
+// respondendo com o carrinho e a confirmação de endereço em uma única mensagem. Se algum item não entrar no carrinho,
+// o checkout fica para depois e o cliente vê o que faltou.
+func (s *AIService) handleAdicionarEFinalizar(ctx context.Context, tenantID, customerID uuid.UUID, customerPhone string, args map[string]interface{}) (string, error) {
+	items := parseAddAndCheckoutItems(args)
+	if len(items) == 0 {
+		return "❌ Me diga quais produtos e quantidades você quer para eu adicionar e finalizar o pedido.", nil
+	}
+
+	cart, err := s.cartService.GetOrCreateActiveCart(tenantID, customerID)
+	if err != nil {
+		return "❌ Erro ao acessar carrinho.", err
+	}
+
+	var added []string
+	for _, item := range items {
+		before := s.cartQuantities(tenantID, cart.ID)
+
+		result, err := s.addToCartWithFallback(ctx, tenantID, customerID, customerPhone, item.Product, item.Quantity)
+		if err != nil {
+			return "❌ Erro ao adicionar item ao carrinho.", err
+		}
+
+		name, ok := s.addedCartItemName(tenantID, cart.ID, before)
+		if !ok {
+			log.Info().
+				Str("tenant_id", tenantID.String()).
+				Str("product", item.Product).
+				Msg("🛒 Item não entrou no carrinho - checkout adiado")
+
+			// Nome que casa com vários produtos: listar as opções em vez da dica genérica
+			if _, err := strconv.Atoi(item.Product); err != nil {
+				if choices := s.productChoicesForName(ctx, tenantID, customerPhone, item.Product); choices != "" {
+					result = choices
+				}
+			}
+
+			reply := ""
+			if len(added) > 0 {
+				reply = formatAddedItems(added) + "\n\n"
+			}
+			return reply + result + "\n\n🛍️ Assim que resolvermos este item, é só dizer **'finalizar'** que eu fecho o pedido.", nil
+		}
+
+		added = append(added, fmt.Sprintf("• %dx **%s**", item.Quantity, name))
+	}
+
+	checkout, err := s.handleCheckout(ctx, tenantID, customerID, customerPhone)
+	if err != nil {
+		return checkout, err
+	}
+	return formatAddedItems(added) + "\n\n" + checkout, nil
+}
+
+// checkoutTools são as ferramentas que fecham o pedido e por isso rodam depois das adições do mesmo turno
+var checkoutTools = map[string]bool{
+	"checkout":             true,
+	"usarEnderecoDeSempre": true,
+}
+
+// orderAddsBeforeCheckout executa as adições antes do checkout quando o modelo pede as duas coisas no mesmo turno,
+// para que o checkout mostre o carrinho já atualizado
+func orderAddsBeforeCheckout(toolCalls []openai.ToolCall) []openai.ToolCall {
+	hasAdd := false
+	for _, toolCall := range toolCalls {
+		if addToCartTools[toolCall.Function.Name] {
+			hasAdd = true
+			break
+		}
+	}
+	if !hasAdd {
+		return toolCalls
+	}
+
+	ordered := make([]openai.ToolCall, 0, len(toolCalls))
+	var checkouts []openai.ToolCall
+	for _, toolCall := range toolCalls {
+		if checkoutTools[toolCall.Function.Name] {
+			checkouts = append(checkouts, toolCall)
+			continue
+		}
+		ordered = append(ordered, toolCall)
+	}
+	return append(ordered, checkouts...)
+}
+
+// combineAddAndCheckout junta adições seguidas de checkout em uma resposta só: o checkout já mostra o carrinho,
+// então das adições ficam apenas as que não entraram (produto não encontrado, sem estoque...)
+func combineAddAndCheckout(results []ToolExecutionResult) (string, bool) {
+	if len(results) < 2 || results[len(results)-1].ToolName != "checkout" {
+		return "", false
+	}
+
+	var failed []string
+	for _, result := range results[:len(results)-1] {
+		if !addToCartTools[result.ToolName] {
+			return "", false
+		}
+		if result.Error != "" || !strings.Contains(result.Result, "adicionado ao carrinho!") {
+			failed = append(failed, strings.TrimSpace(result.Result))
+		}
+	}
+
+	checkout := results[len(results)-1].Result
+	if len(failed) == 0 {
+		return "✅ Itens adicionados!\n\n" + checkout, true
+	}
+	return strings.Join(failed, "\n\n") + "\n\n" + checkout, true
+}
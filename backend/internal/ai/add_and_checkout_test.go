@@ -0,0 +1,193 @@
+package ai
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"iafarma/pkg/models"
+
+	"github.com/google/uuid"
+	"github.com/sashabaranov/go-openai"
+)
+
+// checkoutCartService coloca os itens adicionados no carrinho, como o serviço real
+type checkoutCartService struct {
+	fakeCartService
+	products []models.Product
+}
+
+func (f *checkoutCartService) AddItemToCart(cartID, tenantID, productID uuid.UUID, quantity int) error {
+	for i := range f.cart.Items {
+		if f.cart.Items[i].ProductID != nil && *f.cart.Items[i].ProductID == productID {
+			f.cart.Items[i].Quantity += quantity
+			return nil
+		}
+	}
+	for i := range f.products {
+		if f.products[i].ID == productID {
+			id := productID
+			f.cart.Items = append(f.cart.Items, models.CartItem{ProductID: &id, Quantity: quantity, Price: f.products[i].Price, Product: &f.products[i]})
+		}
+	}
+	return nil
+}
+
+// newCheckoutCartService retorna o carrinho vazio, já com forma de pagamento, que recebe os produtos do catálogo
+func newCheckoutCartService(products []models.Product) *checkoutCartService {
+	paymentMethodID := uuid.New()
+	return &checkoutCartService{
+		fakeCartService: fakeCartService{cart: &models.Cart{BaseTenantModel: models.BaseTenantModel{ID: uuid.New()}, PaymentMethodID: &paymentMethodID}},
+		products:        products,
+	}
+}
+
+// withAddAndCheckout liga o catálogo, o carrinho informado, o cliente "Maria" com endereço padrão e os pedidos:
+// o necessário para adicionar produtos e fechar o pedido
+func withAddAndCheckout(cart CartServiceInterface, products ...models.Product) testServiceOption {
+	address := models.Address{Street: "Rua das Flores", Number: "123", Neighborhood: "Centro", City: "Brasília", State: "DF", ZipCode: "70000-000", IsDefault: true}
+	return withOptions(
+		withProducts(products...),
+		withCustomer(&models.Customer{Name: "Maria"}),
+		withAddresses(address),
+		withOrders(),
+		withCartService(cart),
+	)
+}
+
+func newAddAndCheckoutTestProducts() []models.Product {
+	return []models.Product{
+		{BaseTenantModel: models.BaseTenantModel{ID: uuid.New()}, Name: "Dipirona 500mg", Price: "8.90", Available: true, StockQuantity: 10},
+		{BaseTenantModel: models.BaseTenantModel{ID: uuid.New()}, Name: "Vitamina C", Price: "25.00", Available: true, StockQuantity: 1},
+	}
+}
+
+func TestAdicionarEFinalizarAddsThenShowsCheckout(t *testing.T) {
+	tenantID, customerID, phone := uuid.New(), uuid.New(), "5527999990000"
+	cart := newCheckoutCartService(newAddAndCheckoutTestProducts())
+	s, _ := newTestService(nil, withAddAndCheckout(cart, cart.products...))
+
+	result, err := s.handleAdicionarEFinalizar(context.Background(), tenantID, customerID, phone, map[string]interface{}{
+		"itens": []interface{}{
+			map[string]interface{}{"produto": "dipirona", "quantidade": float64(2)},
+			map[string]interface{}{"produto": "vitamina"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("erro inesperado: %v", err)
+	}
+
+	if len(cart.cart.Items) != 2 || cart.cart.Items[0].Quantity != 2 || cart.cart.Items[1].Quantity != 1 {
+		t.Fatalf("esperado 2x Dipirona e 1x Vitamina C no carrinho, obtido %+v", cart.cart.Items)
+	}
+
+	for _, esperado := range []string{"Adicionei ao seu carrinho", "• 2x **Dipirona 500mg**", "• 1x **Vitamina C**", "Seu Carrinho", "Total: R$ 42,80", "Confirme o endereço de entrega", "Rua das Flores"} {
+		if !strings.Contains(result, esperado) {
+			t.Errorf("resposta deveria conter %q:\n%s", esperado, result)
+		}
+	}
+	// Uma resposta só: sem o convite para "digitar finalizar" de cada adição nem o carrinho repetido
+	if strings.Contains(result, "digite 'finalizar'") || strings.Count(result, "Seu Carrinho") != 1 {
+		t.Errorf("esperada uma única resposta de checkout, obtido:\n%s", result)
+	}
+}
+
+func TestAdicionarEFinalizarStopsWhenItemIsNotAdded(t *testing.T) {
+	tests := []struct {
+		name     string
+		itens    []interface{}
+		esperado []string
+	}{
+		{
+			name: "produto não encontrado",
+			itens: []interface{}{
+				map[string]interface{}{"produto": "dipirona", "quantidade": float64(1)},
+				map[string]interface{}{"produto": "xarope", "quantidade": float64(1)},
+			},
+			esperado: []string{"• 1x **Dipirona 500mg**", "Não consegui identificar", "dizer **'finalizar'**"},
+		},
+		{
+			name: "estoque insuficiente",
+			itens: []interface{}{
+				map[string]interface{}{"produto": "vitamina", "quantidade": float64(3)},
+			},
+			esperado: []string{"Estoque insuficiente para **Vitamina C**", "dizer **'finalizar'**"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cart := newCheckoutCartService(newAddAndCheckoutTestProducts())
+			s, _ := newTestService(nil, withAddAndCheckout(cart, cart.products...))
+
+			result, err := s.handleAdicionarEFinalizar(context.Background(), uuid.New(), uuid.New(), "5527999990000", map[string]interface{}{"itens": tt.itens})
+			if err != nil {
+				t.Fatalf("erro inesperado: %v", err)
+			}
+			for _, esperado := range tt.esperado {
+				if !strings.Contains(result, esperado) {
+					t.Errorf("resposta deveria conter %q:\n%s", esperado, result)
+				}
+			}
+			if strings.Contains(result, "Confirme o endereço") {
+				t.Errorf("checkout não deveria seguir com item pendente:\n%s", result)
+			}
+		})
+	}
+}
+
+func TestAdicionarEFinalizarWithoutItems(t *testing.T) {
+	cart := newCheckoutCartService(newAddAndCheckoutTestProducts())
+	s, _ := newTestService(nil, withAddAndCheckout(cart, cart.products...))
+
+	result, _ := s.handleAdicionarEFinalizar(context.Background(), uuid.New(), uuid.New(), "5527999990000", map[string]interface{}{"itens": []interface{}{}})
+	if len(cart.cart.Items) != 0 || !strings.Contains(result, "quais produtos") {
+		t.Errorf("esperado pedir os produtos, obtido:\n%s", result)
+	}
+}
+
+func TestSeparateAddAndCheckoutToolsProduceOneReply(t *testing.T) {
+	calls := []openai.ToolCall{
+		{Function: openai.FunctionCall{Name: "checkout", Arguments: "{}"}},
+		{Function: openai.FunctionCall{Name: "adicionarProdutoPorNome", Arguments: `{"nome_produto":"dipirona","quantidade":2}`}},
+	}
+
+	ordered := orderAddsBeforeCheckout(calls)
+	if ordered[0].Function.Name != "adicionarProdutoPorNome" || ordered[1].Function.Name != "checkout" {
+		t.Fatalf("esperado adicionar antes do checkout, obtido %s, %s", ordered[0].Function.Name, ordered[1].Function.Name)
+	}
+
+	tenantID, customerID, phone := uuid.New(), uuid.New(), "5527999990000"
+	cart := newCheckoutCartService(newAddAndCheckoutTestProducts())
+	s, _ := newTestService(nil, withAddAndCheckout(cart, cart.products...))
+
+	result, err := s.executeToolCallsWithResults(context.Background(), tenantID, customerID, phone, "adiciona 2 dipironas e já finaliza", calls)
+	if err != nil {
+		t.Fatalf("erro inesperado: %v", err)
+	}
+	for _, esperado := range []string{"Itens adicionados", "Dipirona 500mg", "Confirme o endereço de entrega"} {
+		if !strings.Contains(result, esperado) {
+			t.Errorf("resposta deveria conter %q:\n%s", esperado, result)
+		}
+	}
+	if strings.Contains(result, "digite 'finalizar'") {
+		t.Errorf("não deveria repetir o convite para finalizar:\n%s", result)
+	}
+}
+
+func TestCombineAddAndCheckoutKeepsFailedAdds(t *testing.T) {
+	results := []ToolExecutionResult{
+		{ToolName: "adicionarProdutoPorNome", Result: "✅ **Dipirona 500mg** adicionado ao carrinho!"},
+		{ToolName: "adicionarProdutoPorNome", Result: "❌ Estoque insuficiente para **Vitamina C**."},
+		{ToolName: "checkout", Result: "🛒 **Seu Carrinho:**"},
+	}
+	combined, ok := combineAddAndCheckout(results)
+	if !ok || !strings.HasPrefix(combined, "❌ Estoque insuficiente") || strings.Contains(combined, "Dipirona 500mg** adicionado") {
+		t.Errorf("esperado manter só a adição que falhou antes do checkout, obtido %v:\n%s", ok, combined)
+	}
+
+	// Outras combinações seguem o fluxo anterior
+	if _, ok := combineAddAndCheckout([]ToolExecutionResult{{ToolName: "verCarrinho"}, {ToolName: "checkout"}}); ok {
+		t.Errorf("não deveria combinar ferramentas que não são de adição")
+	}
+}
@@ -0,0 +1,52 @@
+package ai
+
+import (
+	"regexp"
+	"strings"
+)
+
+// apartmentComplementPattern identifica trechos de bloco/apartamento/torre no complemento
+var apartmentComplementPattern = regexp.MustCompile(`(?i)^(apto\.?|apt\.?|ap\.?|apartamento|bloco|bl\.?|torre)(\s|\d|$)`)
+
+// referenceComplementPattern identifica pontos de referência informados junto do complemento
+var referenceComplementPattern = regexp.MustCompile(`(?i)^(ponto de refer[eê]ncia|refer[eê]ncia|ref\.?|pr[oó]ximo|perto|em frente|ao lado|atr[aá]s|esquina)([\s:.\-]|$)`)
+
+// referencePrefixPattern remove o rótulo "referência:" mantendo só o ponto em si
+var referencePrefixPattern = regexp.MustCompile(`(?i)^(ponto de refer[eê]ncia|refer[eê]ncia|ref\.?)(\s*[:\-]\s*|\s+|$)`)
+
+// splitAddressComplement separa bloco/apartamento e ponto de referência que
+// ainda vieram misturados no complemento. Campos já preenchidos pela IA são
+// mantidos; nesse caso o trecho equivalente permanece no complemento.
+func splitAddressComplement(parsed *AIAddressParsing) {
+	parsed.Apartment = strings.TrimSpace(parsed.Apartment)
+	parsed.ReferencePoint = strings.TrimSpace(referencePrefixPattern.ReplaceAllString(strings.TrimSpace(parsed.ReferencePoint), ""))
+
+	segments := strings.FieldsFunc(parsed.Complement, func(r rune) bool {
+		return r == ',' || r == ';'
+	})
+
+	var apartment, reference, complement []string
+	for _, segment := range segments {
+		segment = strings.Trim(strings.TrimSpace(segment), "-")
+		segment = strings.TrimSpace(segment)
+		if segment == "" {
+			continue
+		}
+		switch {
+		case parsed.ReferencePoint == "" && referenceComplementPattern.MatchString(segment):
+			reference = append(reference, referencePrefixPattern.ReplaceAllString(segment, ""))
+		case parsed.Apartment == "" && apartmentComplementPattern.MatchString(segment):
+			apartment = append(apartment, segment)
+		default:
+			complement = append(complement, segment)
+		}
+	}
+
+	if len(apartment) > 0 {
+		parsed.Apartment = strings.Join(apartment, ", ")
+	}
+	if len(reference) > 0 {
+		parsed.ReferencePoint = strings.Join(reference, ", ")
+	}
+	parsed.Complement = strings.Join(complement, ", ")
+}
@@ -0,0 +1,126 @@
+package ai
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"iafarma/pkg/models"
+)
+
+func TestParseAddressWithAIApartmentAndReference(t *testing.T) {
+	client, _ := newToolCallingClient(t, "extrair_endereco", `{"rua":"Rua Sete de Setembro","numero":"45","complemento":"","apartamento":"Bloco B, apto 302","referencia":"Referência: em frente à padaria Pão Quente","bairro":"Centro","cidade":"Vitória","estado":"Espírito Santo","cep":"29015-000"}`)
+	s, _ := newTestService(nil, withClient(client))
+
+	parsed, err := s.parseAddressWithAI(context.Background(), "Rua Sete de Setembro, 45, bloco B apto 302, Centro, Vitória ES, referência: em frente à padaria Pão Quente")
+	if err != nil {
+		t.Fatalf("erro inesperado: %v", err)
+	}
+	if parsed.Apartment != "Bloco B, apto 302" {
+		t.Errorf("apartamento esperado %q, obtido %q", "Bloco B, apto 302", parsed.Apartment)
+	}
+	if parsed.ReferencePoint != "em frente à padaria Pão Quente" {
+		t.Errorf("referência esperada sem rótulo, obtido %q", parsed.ReferencePoint)
+	}
+	if parsed.Complement != "" {
+		t.Errorf("complemento esperado vazio, obtido %q", parsed.Complement)
+	}
+	if parsed.State != "ES" {
+		t.Errorf("UF esperada ES, obtido %q", parsed.State)
+	}
+}
+
+func TestParseAddressWithAISplitsLegacyComplement(t *testing.T) {
+	// Resposta no formato antigo: tudo no complemento
+	client, _ := newToolCallingClient(t, "extrair_endereco", `{"rua":"Avenida Hugo Musso","numero":"1333","complemento":"apartamento 300, fundos, próximo ao Shopping Praia da Costa","bairro":"Praia da Costa","cidade":"Vila Velha","estado":"ES","cep":"29101280"}`)
+	s, _ := newTestService(nil, withClient(client))
+
+	parsed, err := s.parseAddressWithAI(context.Background(), "Avenida Hugo Musso, 1333, apartamento 300, fundos, próximo ao Shopping Praia da Costa")
+	if err != nil {
+		t.Fatalf("erro inesperado: %v", err)
+	}
+	if parsed.Apartment != "apartamento 300" {
+		t.Errorf("apartamento esperado %q, obtido %q", "apartamento 300", parsed.Apartment)
+	}
+	if parsed.ReferencePoint != "próximo ao Shopping Praia da Costa" {
+		t.Errorf("referência esperada %q, obtido %q", "próximo ao Shopping Praia da Costa", parsed.ReferencePoint)
+	}
+	if parsed.Complement != "fundos" {
+		t.Errorf("complemento esperado %q, obtido %q", "fundos", parsed.Complement)
+	}
+}
+
+func TestSplitAddressComplement(t *testing.T) {
+	tests := []struct {
+		name                             string
+		in                               AIAddressParsing
+		complement, apartment, reference string
+	}{
+		{
+			name:       "somente complemento livre",
+			in:         AIAddressParsing{Complement: "casa 2"},
+			complement: "casa 2",
+		},
+		{
+			name:      "bloco e apto separados por vírgula",
+			in:        AIAddressParsing{Complement: "bloco C, ap 12"},
+			apartment: "bloco C, ap 12",
+		},
+		{
+			name:      "referência com rótulo",
+			in:        AIAddressParsing{Complement: "torre 1 apto 804; ref: ao lado da farmácia"},
+			apartment: "torre 1 apto 804",
+			reference: "ao lado da farmácia",
+		},
+		{
+			name:       "campos já preenchidos pela IA não são sobrescritos",
+			in:         AIAddressParsing{Complement: "apto 5", Apartment: "Bloco A, apto 101", ReferencePoint: "esquina com a Rua 7"},
+			complement: "apto 5",
+			apartment:  "Bloco A, apto 101",
+			reference:  "esquina com a Rua 7",
+		},
+		{
+			name:      "palavra parecida com rótulo não é removida",
+			in:        AIAddressParsing{ReferencePoint: "Reformas Silva ao lado"},
+			reference: "Reformas Silva ao lado",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parsed := tt.in
+			splitAddressComplement(&parsed)
+			if parsed.Complement != tt.complement || parsed.Apartment != tt.apartment || parsed.ReferencePoint != tt.reference {
+				t.Errorf("esperado complemento=%q apartamento=%q referência=%q, obtido complemento=%q apartamento=%q referência=%q",
+					tt.complement, tt.apartment, tt.reference, parsed.Complement, parsed.Apartment, parsed.ReferencePoint)
+			}
+		})
+	}
+}
+
+func TestFormatAddressForDisplayStructuredComplement(t *testing.T) {
+	address := models.Address{
+		Street:         "Rua Sete de Setembro",
+		Number:         "45",
+		Apartment:      "Bloco B, apto 302",
+		Complement:     "interfone 302",
+		ReferencePoint: "em frente à padaria",
+		Neighborhood:   "Centro",
+		City:           "Vitória",
+		State:          "ES",
+	}
+
+	display := formatAddressForDisplay(address)
+	if !strings.Contains(display, "Rua Sete de Setembro, 45 - Bloco B, apto 302 - interfone 302") {
+		t.Errorf("linha da rua sem bloco/apto e complemento: %q", display)
+	}
+	if !strings.HasSuffix(display, "📍 Referência: em frente à padaria") {
+		t.Errorf("referência ausente no endereço: %q", display)
+	}
+
+	// Endereços antigos continuam exibindo apenas o complemento
+	legacy := formatAddressForDisplay(models.Address{Street: "Rua A", Number: "1", Complement: "apto 3", City: "Serra", State: "ES"})
+	if !strings.HasPrefix(legacy, "Rua A, 1 - apto 3\n") || strings.Contains(legacy, "Referência") {
+		t.Errorf("endereço antigo formatado incorretamente: %q", legacy)
+	}
+}
@@ -0,0 +1,59 @@
+package ai
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"iafarma/pkg/models"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// MaxAddressesSettingKey define quantos endereços cada cliente pode cadastrar (0 desabilita o limite)
+	MaxAddressesSettingKey = "max_addresses_per_customer"
+
+	defaultMaxAddressesPerCustomer = 5
+)
+
+// getMaxAddressesPerCustomer retorna o limite de endereços por cliente configurado pelo tenant
+func (s *AIService) getMaxAddressesPerCustomer(ctx context.Context, tenantID uuid.UUID) int {
+	if s.settingsService == nil {
+		return defaultMaxAddressesPerCustomer
+	}
+
+	setting, err := s.settingsService.GetSetting(ctx, tenantID, MaxAddressesSettingKey)
+	if err != nil || setting == nil || setting.SettingValue == nil {
+		return defaultMaxAddressesPerCustomer
+	}
+
+	limit, err := strconv.Atoi(strings.TrimSpace(*setting.SettingValue))
+	if err != nil || limit < 0 {
+		return defaultMaxAddressesPerCustomer
+	}
+	return limit
+}
+
+// addressLimitMessage retorna a mensagem pedindo para apagar um endereço quando o limite foi atingido
+// (vazio se o cliente ainda pode cadastrar um novo endereço)
+func (s *AIService) addressLimitMessage(ctx context.Context, tenantID uuid.UUID, addresses []models.Address) string {
+	limit := s.getMaxAddressesPerCustomer(ctx, tenantID)
+	if limit == 0 || len(addresses) < limit {
+		return ""
+	}
+
+	return fmt.Sprintf("⚠️ **Você já tem %d endereços cadastrados** (limite de %d).\n\n%s\n\n🗑️ **Para cadastrar um novo endereço, primeiro apague um deles:** diga 'deletar endereço 2', por exemplo.",
+		len(addresses), limit, formatAddressesForSelection(addresses))
+}
+
+// hasDefaultAddress indica se algum dos endereços está marcado como padrão
+func hasDefaultAddress(addresses []models.Address) bool {
+	for _, address := range addresses {
+		if address.IsDefault {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,117 @@
+package ai
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"iafarma/pkg/models"
+
+	"github.com/google/uuid"
+)
+
+// recordingAddressService guarda os endereços cadastrados durante o teste
+type recordingAddressService struct {
+	fakeAddressService
+	created []models.Address
+}
+
+func (f *recordingAddressService) CreateAddress(tenantID uuid.UUID, address *models.Address) error {
+	f.created = append(f.created, *address)
+	f.addresses = append(f.addresses, *address)
+	return nil
+}
+
+func newAddressLimitTestAddresses(count int) []models.Address {
+	addresses := make([]models.Address, count)
+	for i := range addresses {
+		addresses[i] = models.Address{
+			Street:    fmt.Sprintf("Rua %d", i+1),
+			Number:    "10",
+			City:      "Vila Velha",
+			State:     "ES",
+			ZipCode:   "29101789",
+			IsDefault: i == 0,
+		}
+	}
+	return addresses
+}
+
+var addressLimitTestArgs = map[string]interface{}{
+	"endereco_completo": "Av Hugo Musso, 2380, Itapua, Vila Velha, ES, 29101789",
+}
+
+func TestCadastrarEnderecoAddressLimit(t *testing.T) {
+	tests := []struct {
+		name          string
+		limit         string
+		existing      int
+		expectCreated bool
+	}{
+		{"abaixo do limite", "3", 1, true},
+		{"último endereço permitido", "3", 2, true},
+		{"limite atingido", "3", 3, false},
+		{"acima do limite", "3", 4, false},
+		{"limite padrão", "", defaultMaxAddressesPerCustomer, false},
+		{"sem limite", "0", 10, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			addresses := &recordingAddressService{fakeAddressService: fakeAddressService{addresses: newAddressLimitTestAddresses(tt.existing)}}
+			settings := &fakeSettingsService{values: map[string]string{}}
+			if tt.limit != "" {
+				settings.values[MaxAddressesSettingKey] = tt.limit
+			}
+			s := &AIService{addressService: addresses, settingsService: settings}
+
+			result, err := s.handleCadastrarEndereco(context.Background(), uuid.New(), uuid.New(), addressLimitTestArgs)
+			if err != nil {
+				t.Fatalf("erro inesperado: %v", err)
+			}
+
+			if created := len(addresses.created) == 1; created != tt.expectCreated {
+				t.Fatalf("endereço criado = %v, esperado %v:\n%s", created, tt.expectCreated, result)
+			}
+			if tt.expectCreated {
+				if !strings.Contains(result, "Endereço cadastrado com sucesso") {
+					t.Errorf("esperado confirmação de cadastro, obtido:\n%s", result)
+				}
+				return
+			}
+			if !strings.Contains(result, "primeiro apague um deles") || !strings.Contains(result, "Rua 1") {
+				t.Errorf("esperado pedido para apagar um endereço listando os cadastrados, obtido:\n%s", result)
+			}
+		})
+	}
+}
+
+func TestCadastrarEnderecoKeepsSingleDefault(t *testing.T) {
+	tests := []struct {
+		name          string
+		existing      []models.Address
+		expectDefault bool
+	}{
+		{"primeiro endereço", nil, true},
+		{"já existe endereço padrão", newAddressLimitTestAddresses(2), false},
+		{"nenhum endereço padrão", []models.Address{{Street: "Rua 1", City: "Vila Velha", State: "ES", ZipCode: "29101789"}}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			addresses := &recordingAddressService{fakeAddressService: fakeAddressService{addresses: tt.existing}}
+			s := &AIService{addressService: addresses}
+
+			if _, err := s.handleCadastrarEndereco(context.Background(), uuid.New(), uuid.New(), addressLimitTestArgs); err != nil {
+				t.Fatalf("erro inesperado: %v", err)
+			}
+			if len(addresses.created) != 1 {
+				t.Fatalf("esperado 1 endereço criado, obtido %d", len(addresses.created))
+			}
+			if addresses.created[0].IsDefault != tt.expectDefault {
+				t.Errorf("IsDefault = %v, esperado %v", addresses.created[0].IsDefault, tt.expectDefault)
+			}
+		})
+	}
+}
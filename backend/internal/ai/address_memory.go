@@ -0,0 +1,112 @@
+package ai
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"iafarma/pkg/models"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+)
+
+// RememberDeliveryAddressDaysSettingKey define por quantos dias o endereço usado no último pedido dispensa
+// nova confirmação no checkout ("0" = sempre confirmar)
+const RememberDeliveryAddressDaysSettingKey = "ai_remember_delivery_address_days"
+
+// maxRememberDeliveryAddressDays limita a janela para não usar endereços antigos sem perguntar
+const maxRememberDeliveryAddressDays = 90
+
+// getRememberDeliveryAddressDays retorna a janela configurada pelo tenant (desativado por padrão)
+func (s *AIService) getRememberDeliveryAddressDays(ctx context.Context, tenantID uuid.UUID) int {
+	if s.settingsService == nil {
+		return 0
+	}
+
+	setting, err := s.settingsService.GetSetting(ctx, tenantID, RememberDeliveryAddressDaysSettingKey)
+	if err != nil || setting == nil || setting.SettingValue == nil {
+		return 0
+	}
+
+	days, err := strconv.Atoi(strings.TrimSpace(*setting.SettingValue))
+	if err != nil || days <= 0 {
+		return 0
+	}
+	if days > maxRememberDeliveryAddressDays {
+		days = maxRememberDeliveryAddressDays
+	}
+	return days
+}
+
+// defaultDeliveryAddress retorna o endereço padrão do cliente (ou o único cadastrado)
+func defaultDeliveryAddress(addresses []models.Address) *models.Address {
+	for i := range addresses {
+		if addresses[i].IsDefault {
+			return &addresses[i]
+		}
+	}
+	if len(addresses) == 1 {
+		return &addresses[0]
+	}
+	return nil
+}
+
+// rememberedDeliveryAddress retorna o endereço padrão quando o cliente já o usou no último pedido com entrega
+// dentro da janela configurada. Se o cliente escolher outro endereço, ele deixa de bater com o último pedido e
+// a confirmação volta a ser pedida.
+func (s *AIService) rememberedDeliveryAddress(ctx context.Context, tenantID, customerID uuid.UUID, addresses []models.Address, now time.Time) *models.Address {
+	days := s.getRememberDeliveryAddressDays(ctx, tenantID)
+	if days == 0 || s.orderService == nil {
+		return nil
+	}
+
+	address := defaultDeliveryAddress(addresses)
+	if address == nil || !isAddressComplete(*address) {
+		return nil
+	}
+
+	orders, err := s.orderService.GetOrdersByCustomer(tenantID, customerID)
+	if err != nil {
+		log.Warn().Err(err).Str("customer_id", customerID.String()).Msg("⚠️ Não foi possível consultar o último pedido para lembrar o endereço")
+		return nil
+	}
+
+	var lastDelivery *models.Order
+	for i := range orders {
+		order := &orders[i]
+		if order.IsPickup || order.Status == "cancelled" {
+			continue
+		}
+		if lastDelivery == nil || order.CreatedAt.After(lastDelivery.CreatedAt) {
+			lastDelivery = order
+		}
+	}
+
+	if lastDelivery == nil || lastDelivery.AddressID == nil || *lastDelivery.AddressID != address.ID {
+		return nil
+	}
+	if now.Sub(lastDelivery.CreatedAt) > time.Duration(days)*24*time.Hour {
+		return nil
+	}
+	return address
+}
+
+// checkoutWithRememberedAddress finaliza o pedido direto no endereço lembrado, explicando como trocar
+func (s *AIService) checkoutWithRememberedAddress(ctx context.Context, tenantID, customerID uuid.UUID, customerPhone string, address models.Address) (string, error) {
+	log.Info().
+		Str("tenant_id", tenantID.String()).
+		Str("customer_id", customerID.String()).
+		Str("address_id", address.ID.String()).
+		Msg("📍 Endereço do último pedido lembrado - confirmação dispensada")
+
+	checkoutResult, err := s.performFinalCheckout(ctx, tenantID, customerID, customerPhone)
+	if err != nil || !strings.Contains(checkoutResult, "Pedido registrado com sucesso") {
+		return checkoutResult, err
+	}
+
+	return fmt.Sprintf("📍 **Entrega no mesmo endereço do seu último pedido:**\n%s\n\n%s\n\n🔄 Endereço errado? Diga 'cancelar pedido' e depois 'usar endereço [número]' para escolher outro.",
+		formatAddressForDisplay(address), checkoutResult), nil
+}
@@ -0,0 +1,136 @@
+package ai
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"iafarma/pkg/models"
+
+	"github.com/google/uuid"
+)
+
+// defaultTrackingAddressService registra os endereços marcados como padrão
+type defaultTrackingAddressService struct {
+	fakeAddressService
+	defaults []uuid.UUID
+}
+
+func (f *defaultTrackingAddressService) SetDefaultAddress(tenantID, customerID, addressID uuid.UUID) error {
+	f.defaults = append(f.defaults, addressID)
+	return nil
+}
+
+func newRememberedAddress(isDefault bool) models.Address {
+	address := models.Address{Street: "Rua das Flores", Number: "123", Neighborhood: "Centro", City: "Brasília", State: "DF", ZipCode: "70000-000", IsDefault: isDefault}
+	address.ID = uuid.New()
+	return address
+}
+
+func newPreviousOrder(tenantID, customerID uuid.UUID, addressID *uuid.UUID, age time.Duration) models.Order {
+	order := models.Order{OrderNumber: "PED-ANTERIOR", Status: "pending", CustomerID: &customerID, AddressID: addressID}
+	order.TenantID = tenantID
+	order.CreatedAt = time.Now().Add(-age)
+	return order
+}
+
+// withRememberedAddresses liga os endereços do cliente, registrando os marcados como padrão, e uma entrega que
+// atende todos eles
+func withRememberedAddresses(addresses ...models.Address) testServiceOption {
+	return withOverride(func(s *AIService) {
+		s.deliveryService = &failingDeliveryService{}
+		s.addressService = &defaultTrackingAddressService{fakeAddressService: fakeAddressService{addresses: addresses}}
+	})
+}
+
+func TestCheckoutSkipsConfirmationForRememberedAddress(t *testing.T) {
+	tenantID, customerID := uuid.New(), uuid.New()
+	phone := "5561999999999"
+	home, work := newRememberedAddress(true), newRememberedAddress(false)
+	work.Street = "Av. Paulista"
+
+	s, fakes := newTestService(map[string]string{RememberDeliveryAddressDaysSettingKey: "30"}, withCheckout(newPickupTestCart(false)),
+		withRememberedAddresses(home, work), withOrders(newPreviousOrder(tenantID, customerID, &home.ID, 5*24*time.Hour)))
+	orders := fakes.orders
+
+	result, err := s.handleCheckout(context.Background(), tenantID, customerID, phone)
+	if err != nil {
+		t.Fatalf("erro inesperado: %v", err)
+	}
+
+	if !strings.Contains(result, "Pedido registrado com sucesso") || !strings.Contains(result, "mesmo endereço do seu último pedido") {
+		t.Errorf("checkout deveria finalizar direto no endereço lembrado, obtido:\n%s", result)
+	}
+	if !strings.Contains(result, "Rua das Flores") || !strings.Contains(result, "usar endereço [número]") {
+		t.Errorf("resposta deveria mostrar o endereço usado e como trocar, obtido:\n%s", result)
+	}
+	if len(orders.orders) != 2 {
+		t.Errorf("esperado novo pedido criado, total de pedidos: %d", len(orders.orders))
+	}
+	if pending := s.takePendingResponse(tenantID, phone); pending != nil && pending.HasButtons() {
+		t.Error("checkout com endereço lembrado não deveria pedir confirmação com botões")
+	}
+}
+
+func TestCheckoutStillConfirmsAddress(t *testing.T) {
+	tenantID, customerID := uuid.New(), uuid.New()
+	home, work := newRememberedAddress(true), newRememberedAddress(false)
+
+	pickupOrder := newPreviousOrder(tenantID, customerID, nil, 24*time.Hour)
+	pickupOrder.IsPickup = true
+	cancelled := newPreviousOrder(tenantID, customerID, &home.ID, 24*time.Hour)
+	cancelled.Status = "cancelled"
+
+	tests := []struct {
+		name     string
+		days     string
+		previous []models.Order
+	}{
+		{"desativado por padrão", "", []models.Order{newPreviousOrder(tenantID, customerID, &home.ID, time.Hour)}},
+		{"desativado com zero", "0", []models.Order{newPreviousOrder(tenantID, customerID, &home.ID, time.Hour)}},
+		{"último pedido fora da janela", "7", []models.Order{newPreviousOrder(tenantID, customerID, &home.ID, 8*24*time.Hour)}},
+		{"cliente trocou o endereço padrão", "30", []models.Order{newPreviousOrder(tenantID, customerID, &work.ID, time.Hour)}},
+		{"retirada recente não renova a janela", "7", []models.Order{newPreviousOrder(tenantID, customerID, &home.ID, 10*24*time.Hour), pickupOrder}},
+		{"último pedido cancelado", "30", []models.Order{cancelled}},
+		{"primeiro pedido", "30", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, fakes := newTestService(map[string]string{RememberDeliveryAddressDaysSettingKey: tt.days}, withCheckout(newPickupTestCart(false)),
+				withRememberedAddresses(home, work), withOrders(tt.previous...))
+			orders := fakes.orders
+
+			result, err := s.handleCheckout(context.Background(), tenantID, customerID, "5561999999999")
+			if err != nil {
+				t.Fatalf("erro inesperado: %v", err)
+			}
+			if !strings.Contains(result, "Confirme o endereço de entrega") {
+				t.Errorf("esperado pedido de confirmação do endereço, obtido:\n%s", result)
+			}
+			if len(orders.orders) != len(tt.previous) {
+				t.Errorf("nenhum pedido deveria ser criado antes da confirmação")
+			}
+		})
+	}
+}
+
+func TestFinalCheckoutMarksUsedAddressAsDefault(t *testing.T) {
+	first, second := newRememberedAddress(false), newRememberedAddress(false)
+	s, _ := newTestService(map[string]string{RememberDeliveryAddressDaysSettingKey: "30"}, withCheckout(newPickupTestCart(false)),
+		withRememberedAddresses(first, second))
+
+	result, err := s.performFinalCheckout(context.Background(), uuid.New(), uuid.New(), "5561999999999")
+	if err != nil {
+		t.Fatalf("erro inesperado: %v", err)
+	}
+	if !strings.Contains(result, "Pedido registrado com sucesso") {
+		t.Fatalf("pedido deveria ser criado, obtido:\n%s", result)
+	}
+
+	defaults := s.addressService.(*defaultTrackingAddressService).defaults
+	if len(defaults) != 1 || defaults[0] != first.ID {
+		t.Errorf("endereço usado no pedido deveria virar o padrão, obtido %v", defaults)
+	}
+}
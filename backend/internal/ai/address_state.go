@@ -0,0 +1,65 @@
+package ai
+
+import "strings"
+
+// brazilianStatesByName mapeia o nome do estado (minúsculo, sem acentos) para a sigla da UF
+var brazilianStatesByName = map[string]string{
+	"acre":                "AC",
+	"alagoas":             "AL",
+	"amapa":               "AP",
+	"amazonas":            "AM",
+	"bahia":               "BA",
+	"ceara":               "CE",
+	"distrito federal":    "DF",
+	"espirito santo":      "ES",
+	"goias":               "GO",
+	"maranhao":            "MA",
+	"mato grosso":         "MT",
+	"mato grosso do sul":  "MS",
+	"minas gerais":        "MG",
+	"para":                "PA",
+	"paraiba":             "PB",
+	"parana":              "PR",
+	"pernambuco":          "PE",
+	"piaui":               "PI",
+	"rio de janeiro":      "RJ",
+	"rio grande do norte": "RN",
+	"rio grande do sul":   "RS",
+	"rondonia":            "RO",
+	"roraima":             "RR",
+	"santa catarina":      "SC",
+	"sao paulo":           "SP",
+	"sergipe":             "SE",
+	"tocantins":           "TO",
+}
+
+// brazilianUFs contém as siglas válidas de UF
+var brazilianUFs = func() map[string]bool {
+	ufs := make(map[string]bool, len(brazilianStatesByName))
+	for _, uf := range brazilianStatesByName {
+		ufs[uf] = true
+	}
+	return ufs
+}()
+
+// invalidStateMessage pede ao cliente um estado válido quando a UF informada não foi reconhecida
+const invalidStateMessage = "❌ **Estado inválido.**\n\n💡 Informe a sigla do estado com 2 letras (ex: ES, SP, RJ) ou o nome completo (ex: Espírito Santo)."
+
+// normalizeBrazilianUF converte o estado informado (sigla ou nome completo, com ou sem acentos) para a sigla da UF.
+// Retorna false quando o valor não corresponde a nenhuma UF.
+func normalizeBrazilianUF(state string) (string, bool) {
+	normalized := strings.Join(strings.Fields(normalizeAllergenText(strings.Trim(state, " .-/"))), " ")
+	if normalized == "" {
+		return "", false
+	}
+
+	if uf := strings.ToUpper(normalized); brazilianUFs[uf] {
+		return uf, true
+	}
+	for _, prefix := range []string{"", "estado do ", "estado de ", "estado da "} {
+		if uf, ok := brazilianStatesByName[strings.TrimPrefix(normalized, prefix)]; ok {
+			return uf, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,79 @@
+package ai
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestNormalizeBrazilianUF(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+		valid    bool
+	}{
+		{"ES", "ES", true},
+		{"sp", "SP", true},
+		{" rj. ", "RJ", true},
+		{"Espírito Santo", "ES", true},
+		{"espirito santo", "ES", true},
+		{"São Paulo", "SP", true},
+		{"Mato Grosso do Sul", "MS", true},
+		{"Mato  Grosso", "MT", true},
+		{"Pará", "PA", true},
+		{"Estado do Rio de Janeiro", "RJ", true},
+		{"Distrito Federal", "DF", true},
+		{"XX", "", false},
+		{"Espírito", "", false},
+		{"Vila Velha", "", false},
+		{"", "", false},
+	}
+
+	for _, tt := range tests {
+		got, ok := normalizeBrazilianUF(tt.input)
+		if ok != tt.valid || got != tt.expected {
+			t.Errorf("normalizeBrazilianUF(%q): esperado %q/%v, obtido %q/%v", tt.input, tt.expected, tt.valid, got, ok)
+		}
+	}
+}
+
+func TestBrazilianUFsCoverAllStates(t *testing.T) {
+	if len(brazilianUFs) != 27 {
+		t.Errorf("esperado 27 UFs, obtido %d", len(brazilianUFs))
+	}
+}
+
+func TestCadastrarEnderecoNormalizesState(t *testing.T) {
+	tests := []struct {
+		name          string
+		address       string
+		expectedState string
+	}{
+		{"nome completo vira sigla", "Av Hugo Musso, 2380, Itapua, Vila Velha, Espírito Santo, 29101789", "ES"},
+		{"sigla minúscula", "Av Hugo Musso, 2380, Itapua, Vila Velha, es, 29101789", "ES"},
+		{"estado inválido é rejeitado", "Av Hugo Musso, 2380, Itapua, Vila Velha, Capixaba, 29101789", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			addresses := &recordingAddressService{}
+			s := &AIService{addressService: addresses, settingsService: &fakeSettingsService{values: map[string]string{}}}
+
+			result, err := s.handleCadastrarEndereco(context.Background(), uuid.New(), uuid.New(), map[string]interface{}{"endereco_completo": tt.address})
+			if err != nil {
+				t.Fatalf("erro inesperado: %v", err)
+			}
+
+			if tt.expectedState == "" {
+				if len(addresses.created) != 0 || result != invalidStateMessage {
+					t.Errorf("esperado rejeitar o estado inválido, obtido:\n%s", result)
+				}
+				return
+			}
+			if len(addresses.created) != 1 || addresses.created[0].State != tt.expectedState {
+				t.Errorf("esperado endereço com UF %s, obtido %+v:\n%s", tt.expectedState, addresses.created, result)
+			}
+		})
+	}
+}
@@ -0,0 +1,85 @@
+package ai
+
+import (
+	"fmt"
+	"iafarma/pkg/models"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+)
+
+const ageConfirmationQuestion = "🔞 Este produto é restrito a maiores de 18 anos. Você confirma que tem 18 anos ou mais?"
+
+// ageRestrictedItemNames lista os itens do carrinho que exigem confirmação de maioridade
+func ageRestrictedItemNames(cart *models.Cart) []string {
+	var names []string
+	for _, item := range cart.Items {
+		if item.Product == nil || !item.Product.AgeRestricted {
+			continue
+		}
+		name := item.Product.Name
+		if item.ProductName != nil && *item.ProductName != "" {
+			name = *item.ProductName
+		}
+		names = append(names, name)
+	}
+	return names
+}
+
+// cartRequiresAgeConfirmation indica se o carrinho tem produtos restritos e o cliente ainda não confirmou a idade
+func cartRequiresAgeConfirmation(cart *models.Cart) bool {
+	return cart.AgeConfirmedAt == nil && len(ageRestrictedItemNames(cart)) > 0
+}
+
+// ageConfirmationNotice retorna a pergunta de maioridade ao adicionar um produto restrito em um carrinho não confirmado
+func ageConfirmationNotice(cart *models.Cart, product *models.Product) string {
+	if product == nil || !product.AgeRestricted || (cart != nil && cart.AgeConfirmedAt != nil) {
+		return ""
+	}
+	return "\n\n" + ageConfirmationQuestion
+}
+
+// ageConfirmationBlockMessage explica por que o checkout não pode seguir sem a confirmação de idade
+func ageConfirmationBlockMessage(cart *models.Cart) string {
+	return fmt.Sprintf("🔞 Seu carrinho tem produtos restritos a maiores de 18 anos: **%s**.\n\nAntes de finalizar, você confirma que tem 18 anos ou mais?", strings.Join(ageRestrictedItemNames(cart), ", "))
+}
+
+// handleConfirmarIdade registra a declaração de maioridade do cliente no carrinho atual
+func (s *AIService) handleConfirmarIdade(tenantID, customerID uuid.UUID, args map[string]interface{}) (string, error) {
+	confirmed, ok := args["confirmado"].(bool)
+	if !ok {
+		return "❌ Preciso saber se você confirma ter 18 anos ou mais (sim ou não).", nil
+	}
+
+	cart, err := s.cartService.GetOrCreateActiveCart(tenantID, customerID)
+	if err != nil {
+		return "❌ Erro ao acessar carrinho.", err
+	}
+
+	if !confirmed {
+		cartWithItems, err := s.cartService.GetCartWithItems(cart.ID, tenantID)
+		if err != nil {
+			return "❌ Erro ao carregar carrinho.", err
+		}
+
+		names := ageRestrictedItemNames(cartWithItems)
+		if len(names) == 0 {
+			return "👍 Tudo bem! Seu carrinho não tem produtos restritos por idade.", nil
+		}
+		return fmt.Sprintf("🔞 Sem a confirmação de maioridade não podemos vender: **%s**.\n\n🗑️ Remova esses itens do carrinho para continuar com o pedido.", strings.Join(names, ", ")), nil
+	}
+
+	if err := s.cartService.ConfirmCartAge(cart.ID, tenantID, time.Now()); err != nil {
+		return "❌ Erro ao registrar a confirmação de idade.", err
+	}
+
+	log.Info().
+		Str("tenant_id", tenantID.String()).
+		Str("customer_id", customerID.String()).
+		Str("cart_id", cart.ID.String()).
+		Msg("🔞 Confirmação de maioridade registrada no carrinho")
+
+	return "✅ Obrigado! Sua confirmação de maioridade foi registrada.\n\nVocê pode continuar comprando ou digite 'finalizar' para fechar o pedido.", nil
+}
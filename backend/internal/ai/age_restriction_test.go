@@ -0,0 +1,179 @@
+package ai
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"iafarma/pkg/models"
+
+	"github.com/google/uuid"
+)
+
+// fakeCartService mantém um único carrinho em memória
+type fakeCartService struct {
+	CartServiceInterface
+	cart *models.Cart
+}
+
+func (f *fakeCartService) GetOrCreateActiveCart(tenantID, customerID uuid.UUID) (*models.Cart, error) {
+	return f.cart, nil
+}
+
+func (f *fakeCartService) GetCartWithItems(cartID, tenantID uuid.UUID) (*models.Cart, error) {
+	return f.cart, nil
+}
+
+func (f *fakeCartService) ConfirmCartAge(cartID, tenantID uuid.UUID, confirmedAt time.Time) error {
+	f.cart.AgeConfirmedAt = &confirmedAt
+	return nil
+}
+
+func (f *fakeCartService) AttachCartPrescription(cartID, tenantID uuid.UUID, imageURL string) error {
+	f.cart.PrescriptionURL = imageURL
+	return nil
+}
+
+func (f *fakeCartService) UpdateCartInstallments(cartID, tenantID uuid.UUID, installments int) error {
+	f.cart.InstallmentCount = installments
+	return nil
+}
+
+func (f *fakeCartService) SetCartPickup(cartID, tenantID uuid.UUID, pickup bool) error {
+	f.cart.IsPickup = pickup
+	return nil
+}
+
+func (f *fakeCartService) SetCartUrgent(cartID, tenantID uuid.UUID, urgent bool) error {
+	f.cart.IsUrgent = urgent
+	return nil
+}
+
+func (f *fakeCartService) SetCartDeposit(cartID, tenantID uuid.UUID, payDeposit bool) error {
+	f.cart.PayDeposit = payDeposit
+	return nil
+}
+
+func (f *fakeCartService) UpdateCartPaymentMethod(cartID, tenantID, paymentMethodID uuid.UUID) error {
+	f.cart.PaymentMethodID = &paymentMethodID
+	return nil
+}
+
+func (f *fakeCartService) ClearCart(cartID, tenantID uuid.UUID) error {
+	f.cart.Items = nil
+	return nil
+}
+
+func (f *fakeCartService) UpdateCartObservations(cartID, tenantID uuid.UUID, observations, changeFor string) error {
+	f.cart.Observations = observations
+	if changeFor != "" {
+		f.cart.ChangeFor = changeFor
+	}
+	return nil
+}
+
+// fakeAddressService retorna os endereços configurados (nenhum por padrão)
+type fakeAddressService struct {
+	AddressServiceInterface
+	addresses []models.Address
+}
+
+func (f *fakeAddressService) GetAddressesByCustomer(tenantID, customerID uuid.UUID) ([]models.Address, error) {
+	return f.addresses, nil
+}
+
+func newAgeTestCart(restricted bool) *models.Cart {
+	cart := &models.Cart{
+		Items: []models.CartItem{
+			{Quantity: 1, Price: "8.00", Product: &models.Product{Name: "Água Mineral"}},
+			{Quantity: 1, Price: "12.00", Product: &models.Product{Name: "Cerveja Lata", AgeRestricted: restricted}},
+		},
+	}
+	cart.ID = uuid.New()
+	return cart
+}
+
+func TestCartRequiresAgeConfirmation(t *testing.T) {
+	confirmedAt := time.Now()
+
+	unrestricted := newAgeTestCart(false)
+	restricted := newAgeTestCart(true)
+	confirmed := newAgeTestCart(true)
+	confirmed.AgeConfirmedAt = &confirmedAt
+
+	tests := []struct {
+		name     string
+		cart     *models.Cart
+		expected bool
+	}{
+		{"no restricted items", unrestricted, false},
+		{"restricted without confirmation", restricted, true},
+		{"restricted with confirmation", confirmed, false},
+	}
+
+	for _, test := range tests {
+		if result := cartRequiresAgeConfirmation(test.cart); result != test.expected {
+			t.Errorf("%s: cartRequiresAgeConfirmation = %t, expected %t", test.name, result, test.expected)
+		}
+	}
+}
+
+func TestAgeConfirmationNoticeOnAdd(t *testing.T) {
+	confirmedAt := time.Now()
+	restrictedProduct := &models.Product{Name: "Cerveja Lata", AgeRestricted: true}
+
+	if notice := ageConfirmationNotice(&models.Cart{}, restrictedProduct); !strings.Contains(notice, "18 anos") {
+		t.Errorf("expected age question when adding restricted item, got %q", notice)
+	}
+	if notice := ageConfirmationNotice(&models.Cart{AgeConfirmedAt: &confirmedAt}, restrictedProduct); notice != "" {
+		t.Errorf("should not ask again after confirmation, got %q", notice)
+	}
+	if notice := ageConfirmationNotice(&models.Cart{}, &models.Product{Name: "Água"}); notice != "" {
+		t.Errorf("unrestricted product should not ask for age, got %q", notice)
+	}
+}
+
+func TestFinalCheckoutBlockedWithoutAgeConfirmation(t *testing.T) {
+	tenantID, customerID := uuid.New(), uuid.New()
+	carts := &fakeCartService{cart: newAgeTestCart(true)}
+	s := &AIService{cartService: carts, addressService: &fakeAddressService{}}
+
+	result, err := s.performFinalCheckout(context.Background(), tenantID, customerID, "5527999999999")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(result, "🔞") || !strings.Contains(result, "Cerveja Lata") || strings.Contains(result, "Água Mineral") {
+		t.Errorf("expected age block listing only restricted items, got:\n%s", result)
+	}
+
+	// Cliente confirma a idade pela ferramenta e o checkout segue para as próximas validações
+	confirmation, err := s.handleConfirmarIdade(tenantID, customerID, map[string]interface{}{"confirmado": true})
+	if err != nil || carts.cart.AgeConfirmedAt == nil {
+		t.Fatalf("age confirmation not recorded: %q, %v", confirmation, err)
+	}
+
+	result, _ = s.performFinalCheckout(context.Background(), tenantID, customerID, "5527999999999")
+	if strings.Contains(result, "🔞") {
+		t.Errorf("checkout still blocked after confirmation:\n%s", result)
+	}
+	if !strings.Contains(result, "Nenhum endereço encontrado") {
+		t.Errorf("expected checkout to continue to address validation, got:\n%s", result)
+	}
+}
+
+func TestConfirmarIdadeDeclined(t *testing.T) {
+	carts := &fakeCartService{cart: newAgeTestCart(true)}
+	s := &AIService{cartService: carts}
+
+	result, err := s.handleConfirmarIdade(uuid.New(), uuid.New(), map[string]interface{}{"confirmado": false})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if carts.cart.AgeConfirmedAt != nil {
+		t.Error("declined confirmation must not be recorded")
+	}
+	if !strings.Contains(result, "Cerveja Lata") {
+		t.Errorf("expected restricted items in the answer, got:\n%s", result)
+	}
+}
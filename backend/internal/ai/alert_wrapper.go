@@ -8,8 +8,10 @@ import (
 
 // AlertServiceWrapper implements AlertServiceInterface to avoid circular dependencies
 type AlertServiceWrapper struct {
-	sendOrderAlertFunc        func(tenantID uuid.UUID, order *models.Order, customerPhone string) error
-	sendHumanSupportAlertFunc func(tenantID uuid.UUID, customerID uuid.UUID, customerPhone string, reason string) error
+	sendOrderAlertFunc         func(tenantID uuid.UUID, order *models.Order, customerPhone string) error
+	sendHumanSupportAlertFunc  func(tenantID uuid.UUID, customerID uuid.UUID, customerPhone string, reason string) error
+	sendSpecialOrderAlertFunc  func(tenantID uuid.UUID, customerID uuid.UUID, customerPhone string, description string) error
+	sendReturnRequestAlertFunc func(tenantID uuid.UUID, customerID uuid.UUID, customerPhone string, description string) error
 }
 
 func (a *AlertServiceWrapper) SendOrderAlert(tenantID uuid.UUID, order *models.Order, customerPhone string) error {
@@ -26,6 +28,20 @@ func (a *AlertServiceWrapper) SendHumanSupportAlert(tenantID uuid.UUID, customer
 	return nil
 }
 
+func (a *AlertServiceWrapper) SendSpecialOrderAlert(tenantID uuid.UUID, customerID uuid.UUID, customerPhone string, description string) error {
+	if a.sendSpecialOrderAlertFunc != nil {
+		return a.sendSpecialOrderAlertFunc(tenantID, customerID, customerPhone, description)
+	}
+	return nil
+}
+
+func (a *AlertServiceWrapper) SendReturnRequestAlert(tenantID uuid.UUID, customerID uuid.UUID, customerPhone string, description string) error {
+	if a.sendReturnRequestAlertFunc != nil {
+		return a.sendReturnRequestAlertFunc(tenantID, customerID, customerPhone, description)
+	}
+	return nil
+}
+
 // SetSendOrderAlertFunc sets the alert function after creation
 func (a *AlertServiceWrapper) SetSendOrderAlertFunc(fn func(tenantID uuid.UUID, order *models.Order, customerPhone string) error) {
 	a.sendOrderAlertFunc = fn
@@ -36,6 +52,16 @@ func (a *AlertServiceWrapper) SetSendHumanSupportAlertFunc(fn func(tenantID uuid
 	a.sendHumanSupportAlertFunc = fn
 }
 
+// SetSendSpecialOrderAlertFunc sets the special order alert function after creation
+func (a *AlertServiceWrapper) SetSendSpecialOrderAlertFunc(fn func(tenantID uuid.UUID, customerID uuid.UUID, customerPhone string, description string) error) {
+	a.sendSpecialOrderAlertFunc = fn
+}
+
+// SetSendReturnRequestAlertFunc sets the return request alert function after creation
+func (a *AlertServiceWrapper) SetSendReturnRequestAlertFunc(fn func(tenantID uuid.UUID, customerID uuid.UUID, customerPhone string, description string) error) {
+	a.sendReturnRequestAlertFunc = fn
+}
+
 // NewAlertServiceWrapper creates a new alert service wrapper
 func NewAlertServiceWrapper(sendOrderAlertFunc func(tenantID uuid.UUID, order *models.Order, customerPhone string) error) *AlertServiceWrapper {
 	return &AlertServiceWrapper{
@@ -0,0 +1,143 @@
+package ai
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"os/exec"
+	"path"
+	"strings"
+	"sync"
+
+	"github.com/rs/zerolog/log"
+)
+
+// ErrFFmpegUnavailable indica que o áudio precisa de conversão mas o FFmpeg não está instalado
+var ErrFFmpegUnavailable = errors.New("ffmpeg não disponível para converter este formato de áudio")
+
+// audioUnsupportedFormatMessage é enviada ao cliente quando o áudio não pode ser processado sem FFmpeg
+const audioUnsupportedFormatMessage = "😔 Não consegui ouvir seu áudio neste formato no momento. Pode me enviar sua mensagem por texto? 📝"
+
+// audioFormat descreve um formato de áudio aceito diretamente pelo Whisper
+type audioFormat struct {
+	Extension   string
+	ContentType string
+}
+
+// whisperSupportedFormats são os formatos que podem ser enviados ao Whisper sem conversão
+var whisperSupportedFormats = map[string]audioFormat{
+	"mp3": {Extension: ".mp3", ContentType: "audio/mpeg"},
+	"m4a": {Extension: ".m4a", ContentType: "audio/mp4"},
+	"wav": {Extension: ".wav", ContentType: "audio/wav"},
+	"ogg": {Extension: ".ogg", ContentType: "audio/ogg"},
+}
+
+var (
+	ffmpegOnce      sync.Once
+	ffmpegAvailable bool
+)
+
+// ffmpegBinary retorna o executável do FFmpeg (configurável via FFMPEG_PATH)
+func ffmpegBinary() string {
+	if binary := os.Getenv("FFMPEG_PATH"); binary != "" {
+		return binary
+	}
+	return "ffmpeg"
+}
+
+// IsFFmpegAvailable verifica (uma única vez) se o FFmpeg está instalado
+func IsFFmpegAvailable() bool {
+	ffmpegOnce.Do(func() {
+		_, err := exec.LookPath(ffmpegBinary())
+		ffmpegAvailable = err == nil
+	})
+	return ffmpegAvailable
+}
+
+// LogFFmpegAvailability registra na inicialização se o FFmpeg está disponível
+func LogFFmpegAvailability() {
+	if IsFFmpegAvailable() {
+		log.Info().Str("ffmpeg", ffmpegBinary()).Msg("🎵 FFmpeg disponível - áudios serão convertidos para MP3")
+		return
+	}
+	log.Warn().
+		Str("ffmpeg", ffmpegBinary()).
+		Msg("⚠️ FFmpeg não encontrado - áudios em mp3/m4a/wav/ogg serão enviados sem conversão; oga/opus não serão processados")
+}
+
+// detectAudioFormat identifica o formato do áudio pelo cabeçalho do arquivo, usando a extensão da URL como apoio.
+// Retorna "opus" para Ogg com codec Opus (notas de voz do WhatsApp), que exige conversão.
+func detectAudioFormat(header []byte, sourceURL string) string {
+	switch {
+	case bytes.HasPrefix(header, []byte("OggS")):
+		if bytes.Contains(header, []byte("OpusHead")) {
+			return "opus"
+		}
+		return "ogg"
+	case bytes.HasPrefix(header, []byte("ID3")):
+		return "mp3"
+	case len(header) >= 2 && header[0] == 0xFF && header[1]&0xE0 == 0xE0:
+		return "mp3"
+	case len(header) >= 12 && bytes.Equal(header[0:4], []byte("RIFF")) && bytes.Equal(header[8:12], []byte("WAVE")):
+		return "wav"
+	case len(header) >= 8 && bytes.Equal(header[4:8], []byte("ftyp")):
+		return "m4a"
+	}
+
+	// Cabeçalho desconhecido: usar a extensão da URL
+	if parsed, err := url.Parse(sourceURL); err == nil {
+		switch ext := strings.TrimPrefix(strings.ToLower(path.Ext(parsed.Path)), "."); ext {
+		case "oga", "opus":
+			return "opus"
+		case "mp3", "m4a", "wav", "ogg":
+			return ext
+		}
+	}
+	return ""
+}
+
+// detectAudioFileFormat lê o cabeçalho do arquivo local e identifica o formato
+func detectAudioFileFormat(filePath, sourceURL string) (string, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return "", fmt.Errorf("failed to open audio file: %w", err)
+	}
+	defer file.Close()
+
+	header := make([]byte, 64)
+	n, err := io.ReadFull(file, header)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		return "", fmt.Errorf("failed to read audio header: %w", err)
+	}
+
+	return detectAudioFormat(header[:n], sourceURL), nil
+}
+
+// planAudioConversion decide se o áudio deve ser convertido para MP3.
+// Sem FFmpeg, formatos aceitos pelo Whisper seguem sem conversão; os demais retornam ErrFFmpegUnavailable.
+func planAudioConversion(format string, ffmpegInstalled bool) (convert bool, passthrough audioFormat, err error) {
+	if ffmpegInstalled {
+		return true, audioFormat{}, nil
+	}
+	if supported, ok := whisperSupportedFormats[format]; ok {
+		return false, supported, nil
+	}
+	return false, audioFormat{}, ErrFFmpegUnavailable
+}
+
+// whisperFileName retorna o nome de arquivo enviado ao Whisper, preservando a extensão da URL
+// (o Whisper identifica o formato pela extensão)
+func whisperFileName(audioURL string) string {
+	if parsed, err := url.Parse(audioURL); err == nil {
+		ext := strings.ToLower(path.Ext(parsed.Path))
+		for _, supported := range whisperSupportedFormats {
+			if supported.Extension == ext {
+				return "audio" + ext
+			}
+		}
+	}
+	return "audio.mp3"
+}
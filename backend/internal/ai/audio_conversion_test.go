@@ -0,0 +1,99 @@
+package ai
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDetectAudioFormat(t *testing.T) {
+	tests := []struct {
+		name   string
+		header []byte
+		url    string
+		want   string
+	}{
+		{"mp3 com ID3", []byte("ID3\x04\x00"), "", "mp3"},
+		{"mp3 frame sync", []byte{0xFF, 0xFB, 0x90, 0x00}, "", "mp3"},
+		{"wav", []byte("RIFF\x24\x00\x00\x00WAVEfmt "), "", "wav"},
+		{"m4a", []byte("\x00\x00\x00\x20ftypM4A "), "", "m4a"},
+		{"ogg vorbis", []byte("OggS\x00\x02\x00\x00\x01vorbis"), "", "ogg"},
+		{"ogg opus", []byte("OggS\x00\x02\x00\x00OpusHead"), "", "opus"},
+		{"cabeçalho desconhecido usa extensão oga", []byte("????"), "https://cdn.example.com/voz.oga?x=1", "opus"},
+		{"cabeçalho desconhecido usa extensão wav", []byte("????"), "https://cdn.example.com/audio.WAV", "wav"},
+		{"desconhecido", []byte("????"), "https://cdn.example.com/arquivo", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := detectAudioFormat(tt.header, tt.url); got != tt.want {
+				t.Errorf("detectAudioFormat() = %q, esperado %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPlanAudioConversion(t *testing.T) {
+	tests := []struct {
+		name        string
+		format      string
+		ffmpeg      bool
+		wantConvert bool
+		wantExt     string
+		wantErr     error
+	}{
+		{"com ffmpeg sempre converte", "opus", true, true, "", nil},
+		{"sem ffmpeg mp3 segue original", "mp3", false, false, ".mp3", nil},
+		{"sem ffmpeg m4a segue original", "m4a", false, false, ".m4a", nil},
+		{"sem ffmpeg wav segue original", "wav", false, false, ".wav", nil},
+		{"sem ffmpeg ogg segue original", "ogg", false, false, ".ogg", nil},
+		{"sem ffmpeg opus exige conversão", "opus", false, false, "", ErrFFmpegUnavailable},
+		{"sem ffmpeg formato desconhecido exige conversão", "", false, false, "", ErrFFmpegUnavailable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			convert, passthrough, err := planAudioConversion(tt.format, tt.ffmpeg)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("erro = %v, esperado %v", err, tt.wantErr)
+			}
+			if convert != tt.wantConvert {
+				t.Errorf("convert = %v, esperado %v", convert, tt.wantConvert)
+			}
+			if passthrough.Extension != tt.wantExt {
+				t.Errorf("extensão = %q, esperado %q", passthrough.Extension, tt.wantExt)
+			}
+		})
+	}
+}
+
+func TestDetectAudioFileFormatShortFile(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "original")
+	if err := os.WriteFile(filePath, []byte("ID3"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	format, err := detectAudioFileFormat(filePath, "")
+	if err != nil {
+		t.Fatalf("erro inesperado: %v", err)
+	}
+	if format != "mp3" {
+		t.Errorf("formato = %q, esperado mp3", format)
+	}
+}
+
+func TestWhisperFileName(t *testing.T) {
+	tests := map[string]string{
+		"https://bucket.s3.amazonaws.com/t/conversations/c/audio_1.mp3": "audio.mp3",
+		"https://bucket.s3.amazonaws.com/t/conversations/c/audio_1.m4a": "audio.m4a",
+		"https://bucket.s3.amazonaws.com/t/conversations/c/audio_1.ogg": "audio.ogg",
+		"https://bucket.s3.amazonaws.com/t/conversations/c/audio_1":     "audio.mp3",
+	}
+
+	for audioURL, want := range tests {
+		if got := whisperFileName(audioURL); got != want {
+			t.Errorf("whisperFileName(%q) = %q, esperado %q", audioURL, got, want)
+		}
+	}
+}
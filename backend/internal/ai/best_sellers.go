@@ -0,0 +1,203 @@
+package ai
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"iafarma/pkg/models"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// BestSellersWindowDaysSettingKey define quantos dias de pedidos contam para o ranking de mais vendidos
+	BestSellersWindowDaysSettingKey = "ai_best_sellers_window_days"
+
+	defaultBestSellersWindowDays = 30
+	// minBestSellerOrders é o mínimo de pedidos para um produto entrar no ranking (uma única venda não é popularidade)
+	minBestSellerOrders = 2
+	// bestSellersCacheTTL evita refazer a agregação dos pedidos a cada pergunta
+	bestSellersCacheTTL = 30 * time.Minute
+
+	defaultBestSellersLimit = 5
+	maxBestSellersLimit     = 10
+)
+
+// ProductSales reúne as vendas de um produto na janela consultada
+type ProductSales struct {
+	Product  models.Product
+	Quantity int // unidades vendidas
+	Orders   int // pedidos distintos com o produto
+}
+
+// bestSellersCache guarda o ranking calculado por tenant e janela, recalculado após o TTL
+type bestSellersCache struct {
+	mu      sync.Mutex
+	entries map[string]cachedBestSellers
+	ttl     time.Duration
+	now     func() time.Time
+}
+
+type cachedBestSellers struct {
+	ranking  []ProductSales
+	loadedAt time.Time
+}
+
+func newBestSellersCache(ttl time.Duration) *bestSellersCache {
+	return &bestSellersCache{entries: make(map[string]cachedBestSellers), ttl: ttl, now: time.Now}
+}
+
+// sharedBestSellersCache é compartilhado pelo processo: o ranking muda devagar e a agregação é cara
+var sharedBestSellersCache = newBestSellersCache(bestSellersCacheTTL)
+
+// get retorna o ranking guardado ou o calcula com load (erros não são guardados)
+func (c *bestSellersCache) get(tenantID uuid.UUID, windowDays int, load func() ([]ProductSales, error)) ([]ProductSales, error) {
+	key := fmt.Sprintf("%s:%d", tenantID, windowDays)
+
+	c.mu.Lock()
+	entry, ok := c.entries[key]
+	c.mu.Unlock()
+	if ok && c.now().Sub(entry.loadedAt) <= c.ttl {
+		return entry.ranking, nil
+	}
+
+	ranking, err := load()
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	c.entries[key] = cachedBestSellers{ranking: ranking, loadedAt: c.now()}
+	c.mu.Unlock()
+	return ranking, nil
+}
+
+// rankBestSellers ordena os produtos por unidades vendidas, desempatando pelo número de pedidos e pelo nome.
+// Produtos com menos de minBestSellerOrders pedidos ficam de fora.
+func rankBestSellers(sales []ProductSales) []ProductSales {
+	ranking := make([]ProductSales, 0, len(sales))
+	for _, sale := range sales {
+		if sale.Orders >= minBestSellerOrders && sale.Quantity > 0 {
+			ranking = append(ranking, sale)
+		}
+	}
+
+	sort.SliceStable(ranking, func(i, j int) bool {
+		if ranking[i].Quantity != ranking[j].Quantity {
+			return ranking[i].Quantity > ranking[j].Quantity
+		}
+		if ranking[i].Orders != ranking[j].Orders {
+			return ranking[i].Orders > ranking[j].Orders
+		}
+		return ranking[i].Product.Name < ranking[j].Product.Name
+	})
+	return ranking
+}
+
+// getBestSellersWindowDays retorna a janela configurada pelo tenant (30 dias por padrão)
+func (s *AIService) getBestSellersWindowDays(ctx context.Context, tenantID uuid.UUID) int {
+	if s.settingsService == nil {
+		return defaultBestSellersWindowDays
+	}
+	setting, err := s.settingsService.GetSetting(ctx, tenantID, BestSellersWindowDaysSettingKey)
+	if err != nil || setting == nil || setting.SettingValue == nil {
+		return defaultBestSellersWindowDays
+	}
+	days, err := strconv.Atoi(strings.TrimSpace(*setting.SettingValue))
+	if err != nil || days <= 0 {
+		return defaultBestSellersWindowDays
+	}
+	return days
+}
+
+// bestSellersRanking retorna o ranking de mais vendidos do tenant na janela configurada, usando o cache
+func (s *AIService) bestSellersRanking(ctx context.Context, tenantID uuid.UUID) ([]ProductSales, int, error) {
+	windowDays := s.getBestSellersWindowDays(ctx, tenantID)
+	ranking, err := sharedBestSellersCache.get(tenantID, windowDays, func() ([]ProductSales, error) {
+		since := time.Now().AddDate(0, 0, -windowDays)
+		sales, err := s.productService.GetProductSalesSince(tenantID, since)
+		if err != nil {
+			return nil, err
+		}
+		return rankBestSellers(sales), nil
+	})
+	return ranking, windowDays, err
+}
+
+// bestSellersFallback oferece promoções (ou produtos variados) quando ainda não há pedidos suficientes para um ranking
+func (s *AIService) bestSellersFallback(tenantID uuid.UUID, limit int) ([]models.Product, string) {
+	if promotional, err := s.productService.GetPromotionalProducts(tenantID); err == nil && len(promotional) > 0 {
+		if len(promotional) > limit {
+			promotional = promotional[:limit]
+		}
+		return promotional, "🏷️ **Confira nossas promoções:**"
+	}
+
+	products, err := s.productService.GetProductsByTenantID(tenantID)
+	if err != nil {
+		log.Warn().Err(err).Str("tenant_id", tenantID.String()).Msg("⚠️ Erro ao buscar produtos para sugestão")
+		return nil, ""
+	}
+
+	var sellable []models.Product
+	for _, product := range products {
+		if product.Available && product.StockQuantity > 0 && hasValidPrice(&product) {
+			sellable = append(sellable, product)
+		}
+	}
+	rand.Shuffle(len(sellable), func(i, j int) { sellable[i], sellable[j] = sellable[j], sellable[i] })
+	if len(sellable) > limit {
+		sellable = sellable[:limit]
+	}
+	return sellable, "🛍️ **Algumas sugestões da loja:**"
+}
+
+// handleMaisVendidos lista os produtos mais vendidos do tenant, guardados na memória para seleção por número
+func (s *AIService) handleMaisVendidos(ctx context.Context, tenantID uuid.UUID, customerPhone string, args map[string]interface{}) (string, error) {
+	limit := defaultBestSellersLimit
+	if quantidade, ok := args["quantidade"].(float64); ok && quantidade > 0 {
+		limit = int(quantidade)
+	}
+	if limit > maxBestSellersLimit {
+		limit = maxBestSellersLimit
+	}
+
+	ranking, windowDays, err := s.bestSellersRanking(ctx, tenantID)
+	if err != nil {
+		log.Warn().Err(err).Str("tenant_id", tenantID.String()).Msg("⚠️ Erro ao calcular mais vendidos")
+	}
+
+	var result strings.Builder
+	var products []models.Product
+	if len(ranking) > 0 {
+		if len(ranking) > limit {
+			ranking = ranking[:limit]
+		}
+		for _, sale := range ranking {
+			products = append(products, sale.Product)
+		}
+		result.WriteString(fmt.Sprintf("🏆 **Mais vendidos dos últimos %d dias:**\n\n", windowDays))
+	} else {
+		var header string
+		products, header = s.bestSellersFallback(tenantID, limit)
+		if len(products) == 0 {
+			return "📦 Ainda não temos um ranking de mais vendidos. Me diga o que você procura que eu te ajudo a encontrar!", nil
+		}
+		result.WriteString("📊 Ainda não temos pedidos suficientes para montar o ranking de mais vendidos.\n\n")
+		result.WriteString(header + "\n\n")
+	}
+
+	productRefs := s.memoryManager.StoreProductList(tenantID, customerPhone, products)
+	for _, productRef := range productRefs {
+		result.WriteString(fmt.Sprintf("%d. **%s**\n   💰 %s\n", productRef.SequentialID, productRef.Name, formatListPrice(productRef.Price, productRef.SalePrice)))
+	}
+	result.WriteString("\n🛒 Para adicionar, me diga o número do item e a quantidade.")
+	return result.String(), nil
+}
@@ -0,0 +1,152 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"iafarma/pkg/models"
+
+	"github.com/google/uuid"
+)
+
+// salesProductService devolve as vendas configuradas e conta quantas vezes a agregação foi consultada
+type salesProductService struct {
+	fakeProductService
+	sales       []ProductSales
+	salesCalls  int
+	promotional []models.Product
+	all         []models.Product
+}
+
+func (f *salesProductService) GetProductSalesSince(tenantID uuid.UUID, since time.Time) ([]ProductSales, error) {
+	f.salesCalls++
+	return f.sales, nil
+}
+
+func (f *salesProductService) GetPromotionalProducts(tenantID uuid.UUID) ([]models.Product, error) {
+	return f.promotional, nil
+}
+
+func (f *salesProductService) GetProductsByTenantID(tenantID uuid.UUID) ([]models.Product, error) {
+	return f.all, nil
+}
+
+func newProductSales(name string, quantity, orders int) ProductSales {
+	return ProductSales{Product: newPricedTestProduct(name, "10.00"), Quantity: quantity, Orders: orders}
+}
+
+func TestRankBestSellers(t *testing.T) {
+	sales := []ProductSales{
+		newProductSales("Sabonete", 12, 6),
+		newProductSales("Dipirona", 30, 10),
+		newProductSales("Protetor Solar", 12, 9),
+		newProductSales("Vitamina C", 50, 1),
+		newProductSales("Álcool em Gel", 12, 6),
+		newProductSales("Fralda", 0, 3),
+	}
+
+	var nomes []string
+	for _, sale := range rankBestSellers(sales) {
+		nomes = append(nomes, sale.Product.Name)
+	}
+	if obtido := strings.Join(nomes, ","); obtido != "Dipirona,Protetor Solar,Sabonete,Álcool em Gel" {
+		t.Errorf("esperado ranking por unidades, pedidos e nome, obtido %q", obtido)
+	}
+
+	if ranking := rankBestSellers([]ProductSales{newProductSales("Vitamina C", 5, 1)}); len(ranking) != 0 {
+		t.Errorf("produto com um único pedido não deveria entrar no ranking, obtido %+v", ranking)
+	}
+}
+
+func TestBestSellersCacheReutilizaRanking(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	cache := newBestSellersCache(time.Hour)
+	cache.now = func() time.Time { return now }
+	tenantID := uuid.New()
+
+	calls := 0
+	load := func() ([]ProductSales, error) {
+		calls++
+		return []ProductSales{newProductSales("Dipirona", 10, 5)}, nil
+	}
+
+	cache.get(tenantID, 30, load)
+	cache.get(tenantID, 30, load)
+	if calls != 1 {
+		t.Errorf("esperado ranking calculado uma vez, calculado %d vezes", calls)
+	}
+
+	cache.get(tenantID, 7, load)
+	if calls != 2 {
+		t.Errorf("outra janela deveria calcular um novo ranking, calculado %d vezes", calls)
+	}
+
+	now = now.Add(2 * time.Hour)
+	cache.get(tenantID, 30, load)
+	if calls != 3 {
+		t.Errorf("ranking expirado deveria ser recalculado, calculado %d vezes", calls)
+	}
+
+	if _, err := cache.get(uuid.New(), 30, func() ([]ProductSales, error) { return nil, errors.New("falha") }); err == nil {
+		t.Errorf("esperado erro da agregação")
+	}
+}
+
+func TestMaisVendidosListaRankingEGuardaNaMemoria(t *testing.T) {
+	tenantID := uuid.New()
+	phone := "5527999999999"
+	dipirona := newProductSales("Dipirona", 30, 10)
+	products := &salesProductService{sales: []ProductSales{newProductSales("Sabonete", 12, 6), dipirona}}
+	s := &AIService{
+		settingsService: &fakeSettingsService{values: map[string]string{BestSellersWindowDaysSettingKey: "15"}},
+		productService:  products,
+		memoryManager:   NewMemoryManager(),
+	}
+
+	obtido, err := s.handleMaisVendidos(context.Background(), tenantID, phone, map[string]interface{}{})
+	if err != nil {
+		t.Fatalf("erro inesperado: %v", err)
+	}
+	for _, esperado := range []string{"últimos 15 dias", "1. **Dipirona**", "2. **Sabonete**"} {
+		if !strings.Contains(obtido, esperado) {
+			t.Errorf("esperado %q em:\n%s", esperado, obtido)
+		}
+	}
+	if ref := s.memoryManager.GetProductBySequentialID(tenantID, phone, 1); ref == nil || ref.ProductID != dipirona.Product.ID {
+		t.Errorf("esperado Dipirona como item 1 da lista, obtido %+v", ref)
+	}
+
+	s.handleMaisVendidos(context.Background(), tenantID, phone, map[string]interface{}{"quantidade": float64(1)})
+	if products.salesCalls != 1 {
+		t.Errorf("ranking deveria vir do cache na segunda consulta, agregações = %d", products.salesCalls)
+	}
+}
+
+func TestMaisVendidosSemPedidosSuficientes(t *testing.T) {
+	promocao := newPricedTestProduct("Protetor Solar", "59.90")
+	promocao.SalePrice = "49.90"
+	s := &AIService{
+		productService: &salesProductService{sales: []ProductSales{newProductSales("Vitamina C", 3, 1)}, promotional: []models.Product{promocao}},
+		memoryManager:  NewMemoryManager(),
+	}
+
+	obtido, _ := s.handleMaisVendidos(context.Background(), uuid.New(), "5527999999999", map[string]interface{}{})
+	if !strings.Contains(obtido, "pedidos suficientes") || !strings.Contains(obtido, "promoções") || !strings.Contains(obtido, "1. **Protetor Solar**") {
+		t.Errorf("esperado fallback para promoções:\n%s", obtido)
+	}
+
+	pausado := newPricedTestProduct("Xarope", "20.00")
+	pausado.Available = false
+	sabonete := newPricedTestProduct("Sabonete", "5.00")
+	sabonete.StockQuantity = 10
+	pausado.StockQuantity = 10
+	s.productService = &salesProductService{all: []models.Product{pausado, sabonete}}
+
+	obtido, _ = s.handleMaisVendidos(context.Background(), uuid.New(), "5527999999999", map[string]interface{}{})
+	if !strings.Contains(obtido, "sugestões da loja") || !strings.Contains(obtido, "1. **Sabonete**") || strings.Contains(obtido, "Xarope") {
+		t.Errorf("esperado fallback para produtos disponíveis:\n%s", obtido)
+	}
+}
@@ -0,0 +1,62 @@
+package ai
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+)
+
+// AutoPauseOnHumanHandoffSettingKey define se o bot é pausado automaticamente quando o cliente pede atendimento humano
+const AutoPauseOnHumanHandoffSettingKey = "ai_auto_pause_on_human_handoff"
+
+// isBotPaused indica se um atendente assumiu a conversa e o bot deve ficar em silêncio
+func (s *AIService) isBotPaused(tenantID, conversationID uuid.UUID) bool {
+	if s.conversationService == nil || conversationID == uuid.Nil {
+		return false
+	}
+
+	paused, err := s.conversationService.IsBotPaused(tenantID, conversationID)
+	if err != nil {
+		log.Warn().Err(err).Str("conversation_id", conversationID.String()).Msg("⚠️ Não foi possível verificar se o bot está pausado")
+		return false
+	}
+	return paused
+}
+
+// isAutoPauseOnHumanHandoffEnabled indica se o tenant quer pausar o bot ao solicitar atendimento humano (habilitado por padrão)
+func (s *AIService) isAutoPauseOnHumanHandoffEnabled(ctx context.Context, tenantID uuid.UUID) bool {
+	if s.settingsService == nil {
+		return true
+	}
+
+	setting, err := s.settingsService.GetSetting(ctx, tenantID, AutoPauseOnHumanHandoffSettingKey)
+	if err != nil || setting == nil || setting.SettingValue == nil {
+		return true
+	}
+
+	enabled, err := strconv.ParseBool(strings.TrimSpace(*setting.SettingValue))
+	return err != nil || enabled
+}
+
+// pauseBotForHumanHandoff pausa o bot na conversa atual quando o atendimento humano é solicitado.
+// Retorna true se a conversa foi pausada.
+func (s *AIService) pauseBotForHumanHandoff(ctx context.Context, tenantID uuid.UUID, customerPhone string) bool {
+	conversationID := s.getConversationID(tenantID, customerPhone)
+	if s.conversationService == nil || conversationID == uuid.Nil || !s.isAutoPauseOnHumanHandoffEnabled(ctx, tenantID) {
+		return false
+	}
+
+	if err := s.conversationService.SetBotPaused(tenantID, conversationID, true); err != nil {
+		log.Error().Err(err).Str("conversation_id", conversationID.String()).Msg("❌ Erro ao pausar o bot para atendimento humano")
+		return false
+	}
+
+	log.Info().
+		Str("tenant_id", tenantID.String()).
+		Str("conversation_id", conversationID.String()).
+		Msg("🤫 Bot pausado automaticamente para atendimento humano")
+	return true
+}
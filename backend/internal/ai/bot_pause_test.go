@@ -0,0 +1,157 @@
+package ai
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+
+	"iafarma/pkg/models"
+
+	"github.com/google/uuid"
+)
+
+// fakeConversationService guarda o estado de pausa e os contatos abertos pela loja em memória
+type fakeConversationService struct {
+	mu       sync.Mutex
+	paused   map[uuid.UUID]bool
+	openings map[uuid.UUID]*BusinessOpening
+}
+
+func (f *fakeConversationService) IsBotPaused(tenantID, conversationID uuid.UUID) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.paused[conversationID], nil
+}
+
+func (f *fakeConversationService) SetBotPaused(tenantID, conversationID uuid.UUID, paused bool) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.paused[conversationID] = paused
+	return nil
+}
+
+func (f *fakeConversationService) GetBusinessOpening(tenantID, conversationID uuid.UUID) (*BusinessOpening, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.openings[conversationID], nil
+}
+
+// fakeAlertService ignora os alertas enviados ao grupo da loja
+type fakeAlertService struct{}
+
+func (fakeAlertService) SendOrderAlert(tenantID uuid.UUID, order *models.Order, customerPhone string) error {
+	return nil
+}
+
+func (fakeAlertService) SendHumanSupportAlert(tenantID uuid.UUID, customerID uuid.UUID, customerPhone string, reason string) error {
+	return nil
+}
+
+func (fakeAlertService) SendSpecialOrderAlert(tenantID uuid.UUID, customerID uuid.UUID, customerPhone string, description string) error {
+	return nil
+}
+
+func (fakeAlertService) SendReturnRequestAlert(tenantID uuid.UUID, customerID uuid.UUID, customerPhone string, description string) error {
+	return nil
+}
+
+// phoneCustomerService retorna sempre o mesmo cliente na busca por telefone
+type phoneCustomerService struct {
+	CustomerServiceInterface
+	customer *models.Customer
+}
+
+func (f *phoneCustomerService) GetOrCreateCustomerByPhone(tenantID uuid.UUID, phone string) (*models.Customer, error) {
+	return f.customer, nil
+}
+
+func TestProcessMessageSilentWhilePaused(t *testing.T) {
+	tenantID := uuid.New()
+	conversationID := uuid.New()
+	phone := "5527999999999"
+	conversations := &fakeConversationService{paused: map[uuid.UUID]bool{conversationID: true}}
+	s := &AIService{conversationService: conversations, memoryManager: NewMemoryManager()}
+
+	for _, message := range []string{"oi", "quero um protetor solar", "cadê meu pedido?"} {
+		response, err := s.ProcessMessageWithConversation(context.Background(), tenantID, phone, message, conversationID)
+		if err != nil {
+			t.Fatalf("erro inesperado: %v", err)
+		}
+		if response != "" {
+			t.Errorf("bot pausado não deveria responder %q, obtido %q", message, response)
+		}
+	}
+
+	if history := s.memoryManager.GetConversationHistory(tenantID, phone); len(history) != 0 {
+		t.Errorf("bot pausado não deveria registrar histórico, obtido %d mensagens", len(history))
+	}
+}
+
+func TestHumanHandoffAutoPausesBot(t *testing.T) {
+	tests := []struct {
+		name        string
+		setting     map[string]string
+		expectPause bool
+	}{
+		{"padrão pausa", map[string]string{}, true},
+		{"configurado para pausar", map[string]string{AutoPauseOnHumanHandoffSettingKey: "true"}, true},
+		{"pausa desabilitada", map[string]string{AutoPauseOnHumanHandoffSettingKey: "false"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tenantID := uuid.New()
+			conversationID := uuid.New()
+			phone := "5527999999999"
+			customer := &models.Customer{Name: "João", Phone: phone}
+			customer.ID = uuid.New()
+
+			conversations := &fakeConversationService{paused: map[uuid.UUID]bool{}}
+			s := &AIService{
+				conversationService: conversations,
+				customerService:     &phoneCustomerService{customer: customer},
+				alertService:        fakeAlertService{},
+				settingsService:     &fakeSettingsService{values: tt.setting},
+				memoryManager:       NewMemoryManager(),
+			}
+			s.conversationContext.Store(tenantID.String()+"-"+phone, conversationID)
+
+			response, err := s.handleSolicitarAtendimentoHumano(context.Background(), tenantID, customer.ID, phone, map[string]interface{}{"motivo": "reclamação"})
+			if err != nil {
+				t.Fatalf("erro inesperado: %v", err)
+			}
+
+			if paused := s.isBotPaused(tenantID, conversationID); paused != tt.expectPause {
+				t.Fatalf("bot pausado = %v, esperado %v", paused, tt.expectPause)
+			}
+			if continues := strings.Contains(response, "posso continuar te ajudando"); continues == tt.expectPause {
+				t.Errorf("resposta não condiz com a pausa (%v):\n%s", tt.expectPause, response)
+			}
+
+			if tt.expectPause {
+				reply, err := s.ProcessMessageWithConversation(context.Background(), tenantID, phone, "alô?", conversationID)
+				if err != nil || reply != "" {
+					t.Errorf("bot pausado não deveria responder após o pedido de atendimento, obtido %q (%v)", reply, err)
+				}
+			}
+		})
+	}
+}
+
+func TestResumedBotIsNotPaused(t *testing.T) {
+	tenantID := uuid.New()
+	conversationID := uuid.New()
+	conversations := &fakeConversationService{paused: map[uuid.UUID]bool{conversationID: true}}
+	s := &AIService{conversationService: conversations}
+
+	if err := conversations.SetBotPaused(tenantID, conversationID, false); err != nil {
+		t.Fatalf("erro inesperado: %v", err)
+	}
+	if s.isBotPaused(tenantID, conversationID) {
+		t.Error("bot retomado não deveria estar pausado")
+	}
+	if s.isBotPaused(tenantID, uuid.Nil) {
+		t.Error("mensagens sem conversa nunca são pausadas")
+	}
+}
@@ -0,0 +1,84 @@
+package ai
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+	"github.com/sashabaranov/go-openai"
+)
+
+const (
+	// BusinessInitiatedWindowHoursSettingKey define por quantas horas após um contato aberto pela loja a resposta do cliente
+	// é tratada no contexto desse contato, sem boas-vindas (0 = desativado)
+	BusinessInitiatedWindowHoursSettingKey = "ai_business_initiated_window_hours"
+
+	defaultBusinessInitiatedWindowHours = 72
+)
+
+// BusinessOpening descreve o contato aberto pela loja (campanha, follow-up, mensagem do atendente)
+type BusinessOpening struct {
+	At      time.Time
+	Message string // última mensagem enviada pela loja, usada como contexto da resposta do cliente
+}
+
+// isBusinessOpeningActive indica se a resposta do cliente ainda pertence ao contato aberto pela loja
+func isBusinessOpeningActive(opening *BusinessOpening, now time.Time, windowHours int) bool {
+	if opening == nil || windowHours <= 0 {
+		return false
+	}
+	return now.Sub(opening.At) < time.Duration(windowHours)*time.Hour
+}
+
+// getBusinessInitiatedWindowHours retorna a janela configurada pelo tenant para contatos abertos pela loja
+func (s *AIService) getBusinessInitiatedWindowHours(ctx context.Context, tenantID uuid.UUID) int {
+	if s.settingsService == nil {
+		return defaultBusinessInitiatedWindowHours
+	}
+
+	setting, err := s.settingsService.GetSetting(ctx, tenantID, BusinessInitiatedWindowHoursSettingKey)
+	if err != nil || setting == nil || setting.SettingValue == nil {
+		return defaultBusinessInitiatedWindowHours
+	}
+
+	hours, err := strconv.Atoi(strings.TrimSpace(*setting.SettingValue))
+	if err != nil || hours < 0 {
+		return defaultBusinessInitiatedWindowHours
+	}
+	return hours
+}
+
+// applyBusinessOpening verifica se a conversa foi aberta pela loja dentro da janela configurada. Nesse caso a
+// mensagem enviada pela loja entra no histórico (quando ainda não há conversa na memória), para que o bot
+// responda no contexto dela em vez de tratar a resposta como uma primeira saudação.
+func (s *AIService) applyBusinessOpening(ctx context.Context, tenantID uuid.UUID, customerPhone string, conversationID uuid.UUID, historyLen int) bool {
+	if s.conversationService == nil || conversationID == uuid.Nil {
+		return false
+	}
+
+	opening, err := s.conversationService.GetBusinessOpening(tenantID, conversationID)
+	if err != nil {
+		log.Warn().Err(err).Str("conversation_id", conversationID.String()).Msg("⚠️ Não foi possível verificar quem iniciou a conversa")
+		return false
+	}
+	if !isBusinessOpeningActive(opening, time.Now(), s.getBusinessInitiatedWindowHours(ctx, tenantID)) {
+		return false
+	}
+
+	if historyLen == 0 && opening.Message != "" {
+		s.memoryManager.AddToConversationHistory(tenantID, customerPhone, openai.ChatCompletionMessage{
+			Role:    openai.ChatMessageRoleAssistant,
+			Content: opening.Message,
+		})
+	}
+
+	log.Info().
+		Str("tenant_id", tenantID.String()).
+		Str("conversation_id", conversationID.String()).
+		Time("business_initiated_at", opening.At).
+		Msg("📣 Conversa iniciada pela loja - respondendo no contexto, sem boas-vindas")
+	return true
+}
@@ -0,0 +1,93 @@
+package ai
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"iafarma/pkg/models"
+
+	"github.com/google/uuid"
+)
+
+func TestIsBusinessOpeningActive(t *testing.T) {
+	now := time.Date(2025, 3, 10, 9, 0, 0, 0, time.UTC)
+	hoursAgo := func(hours int) *BusinessOpening {
+		return &BusinessOpening{At: now.Add(-time.Duration(hours) * time.Hour)}
+	}
+
+	tests := []struct {
+		name        string
+		opening     *BusinessOpening
+		windowHours int
+		expected    bool
+	}{
+		{"conversa iniciada pelo cliente", nil, 72, false},
+		{"campanha de ontem", hoursAgo(20), 72, true},
+		{"campanha fora da janela", hoursAgo(72), 72, false},
+		{"supressão desativada", hoursAgo(1), 0, false},
+	}
+
+	for _, tt := range tests {
+		if result := isBusinessOpeningActive(tt.opening, now, tt.windowHours); result != tt.expected {
+			t.Errorf("%s: esperado %t, obtido %t", tt.name, tt.expected, result)
+		}
+	}
+}
+
+// welcomeDueFor reproduz a decisão de ProcessMessageWithConversation antes da saudação
+func welcomeDueFor(s *AIService, tenantID, conversationID uuid.UUID, customer *models.Customer) bool {
+	history := s.memoryManager.GetConversationHistory(tenantID, customer.Phone)
+	if s.applyBusinessOpening(context.Background(), tenantID, customer.Phone, conversationID, len(history)) {
+		return false
+	}
+	return s.isWelcomeDue(context.Background(), tenantID, customer, customer.LastSeenAt, len(history))
+}
+
+func TestBusinessInitiatedConversationSkipsWelcome(t *testing.T) {
+	tenantID := uuid.New()
+	campaignID, followUpID, inboundID := uuid.New(), uuid.New(), uuid.New()
+	conversations := &fakeConversationService{openings: map[uuid.UUID]*BusinessOpening{
+		campaignID: {At: time.Now().Add(-2 * time.Hour), Message: "🎉 Semana do protetor solar: 20% de desconto em todas as marcas!"},
+		followUpID: {At: time.Now().Add(-5 * 24 * time.Hour), Message: "Oi! Seu pedido foi entregue?"},
+	}}
+
+	newService := func() *AIService {
+		return &AIService{
+			conversationService: conversations,
+			settingsService:     &fakeSettingsService{values: map[string]string{}},
+			memoryManager:       NewMemoryManager(),
+		}
+	}
+	newCustomer := func() *models.Customer {
+		customer := &models.Customer{Phone: "5527999999999"}
+		customer.ID = uuid.New()
+		return customer
+	}
+
+	// Resposta à campanha: sem boas-vindas, com a mensagem da loja como contexto
+	s := newService()
+	customer := newCustomer()
+	if welcomeDueFor(s, tenantID, campaignID, customer) {
+		t.Errorf("resposta a uma campanha não deveria receber boas-vindas")
+	}
+	history := s.memoryManager.GetConversationHistory(tenantID, customer.Phone)
+	if len(history) != 1 || history[0].Content != "🎉 Semana do protetor solar: 20% de desconto em todas as marcas!" {
+		t.Errorf("esperada a mensagem da campanha no histórico, obtido %+v", history)
+	}
+
+	// Contato da loja fora da janela (72h) e conversa iniciada pelo cliente seguem com boas-vindas
+	for _, conversationID := range []uuid.UUID{followUpID, inboundID} {
+		s := newService()
+		if !welcomeDueFor(s, tenantID, conversationID, newCustomer()) {
+			t.Errorf("conversa %s deveria receber boas-vindas", conversationID)
+		}
+	}
+
+	// Supressão desativada pelo tenant
+	s = newService()
+	s.settingsService = &fakeSettingsService{values: map[string]string{BusinessInitiatedWindowHoursSettingKey: "0"}}
+	if !welcomeDueFor(s, tenantID, campaignID, newCustomer()) {
+		t.Errorf("com a janela em 0 a boas-vindas deveria ser enviada")
+	}
+}
@@ -0,0 +1,140 @@
+package ai
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"iafarma/pkg/models"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+)
+
+// cartLineMerge agrupa as linhas do carrinho que representam o mesmo item: a primeira é mantida e as demais somadas a ela
+type cartLineMerge struct {
+	Keep       models.CartItem
+	Duplicates []uuid.UUID
+	Quantity   int
+}
+
+// cartLineKey identifica o item da linha: produto, variante e opções escolhidas.
+// Variantes ou opções diferentes do mesmo produto continuam em linhas separadas.
+func cartLineKey(item models.CartItem) string {
+	if item.ProductID == nil {
+		return ""
+	}
+
+	key := item.ProductID.String()
+	if item.VariantID != nil {
+		key += "|" + item.VariantID.String()
+	}
+
+	if len(item.Attributes) > 0 {
+		options := make([]string, 0, len(item.Attributes))
+		for _, attribute := range item.Attributes {
+			options = append(options, attribute.AttributeID.String()+"="+attribute.OptionID.String())
+		}
+		sort.Strings(options)
+		key += "|" + strings.Join(options, ",")
+	}
+	return key
+}
+
+// duplicateCartLines encontra as linhas repetidas do carrinho, na ordem em que o item apareceu primeiro
+func duplicateCartLines(items []models.CartItem) []cartLineMerge {
+	var merges []cartLineMerge
+	position := map[string]int{}
+
+	for _, item := range items {
+		key := cartLineKey(item)
+		if key == "" {
+			continue
+		}
+
+		i, seen := position[key]
+		if !seen {
+			position[key] = len(merges)
+			merges = append(merges, cartLineMerge{Keep: item, Quantity: item.Quantity})
+			continue
+		}
+		merges[i].Duplicates = append(merges[i].Duplicates, item.ID)
+		merges[i].Quantity += item.Quantity
+	}
+
+	duplicated := merges[:0]
+	for _, merge := range merges {
+		if len(merge.Duplicates) > 0 {
+			duplicated = append(duplicated, merge)
+		}
+	}
+	return duplicated
+}
+
+// consolidateCart junta as linhas repetidas do carrinho e retorna os itens que foram unificados
+func (s *AIService) consolidateCart(tenantID uuid.UUID, cart *models.Cart) ([]cartLineMerge, error) {
+	merges := duplicateCartLines(cart.Items)
+	for _, merge := range merges {
+		if err := s.cartService.MergeCartItems(cart.ID, tenantID, merge.Keep.ID, merge.Duplicates); err != nil {
+			return nil, err
+		}
+		log.Info().
+			Str("tenant_id", tenantID.String()).
+			Str("cart_id", cart.ID.String()).
+			Str("item", getItemName(merge.Keep)).
+			Int("lines_merged", len(merge.Duplicates)+1).
+			Int("quantity", merge.Quantity).
+			Msg("🧹 Linhas repetidas do carrinho unificadas")
+	}
+	return merges, nil
+}
+
+// consolidateActiveCart unifica as linhas repetidas do carrinho ativo antes do checkout; falhas não impedem o pedido
+func (s *AIService) consolidateActiveCart(tenantID, customerID uuid.UUID) {
+	cart, err := s.cartService.GetOrCreateActiveCart(tenantID, customerID)
+	if err != nil {
+		return
+	}
+	cartWithItems, err := s.cartService.GetCartWithItems(cart.ID, tenantID)
+	if err != nil {
+		return
+	}
+	if _, err := s.consolidateCart(tenantID, cartWithItems); err != nil {
+		log.Warn().Err(err).Str("tenant_id", tenantID.String()).Str("cart_id", cart.ID.String()).Msg("⚠️ Erro ao unificar itens repetidos do carrinho")
+	}
+}
+
+// handleConsolidarCarrinho unifica os itens repetidos do carrinho e mostra o carrinho atualizado
+func (s *AIService) handleConsolidarCarrinho(ctx context.Context, tenantID, customerID uuid.UUID) (string, error) {
+	cart, err := s.cartService.GetOrCreateActiveCart(tenantID, customerID)
+	if err != nil {
+		return "❌ Erro ao acessar carrinho.", err
+	}
+	cartWithItems, err := s.cartService.GetCartWithItems(cart.ID, tenantID)
+	if err != nil {
+		return "❌ Erro ao carregar itens do carrinho.", err
+	}
+
+	merges, err := s.consolidateCart(tenantID, cartWithItems)
+	if err != nil {
+		return "❌ Erro ao organizar o carrinho.", err
+	}
+
+	cartMessage, err := s.handleVerCarrinhoWithOptions(ctx, tenantID, customerID, true)
+	if err != nil {
+		return cartMessage, err
+	}
+	if len(merges) == 0 {
+		return "✅ Seu carrinho não tem itens repetidos.\n\n" + cartMessage, nil
+	}
+
+	var result strings.Builder
+	result.WriteString("🧹 **Juntei os itens repetidos do seu carrinho:**\n")
+	for _, merge := range merges {
+		result.WriteString(fmt.Sprintf("• %s: %d unidades em um só item\n", getItemName(merge.Keep), merge.Quantity))
+	}
+	result.WriteString("\n")
+	result.WriteString(cartMessage)
+	return result.String(), nil
+}
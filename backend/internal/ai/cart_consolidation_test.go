@@ -0,0 +1,141 @@
+package ai
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"iafarma/pkg/models"
+
+	"github.com/google/uuid"
+)
+
+// mergingCartService aplica em memória a unificação das linhas do carrinho
+type mergingCartService struct {
+	fakeCartService
+	merges int
+}
+
+func (f *mergingCartService) MergeCartItems(cartID, tenantID, keepItemID uuid.UUID, duplicateItemIDs []uuid.UUID) error {
+	f.merges++
+	remove := map[uuid.UUID]bool{}
+	for _, id := range duplicateItemIDs {
+		remove[id] = true
+	}
+
+	var items []models.CartItem
+	var keep *models.CartItem
+	for _, item := range f.cart.Items {
+		if remove[item.ID] {
+			if keep != nil {
+				keep.Quantity += item.Quantity
+			}
+			continue
+		}
+		items = append(items, item)
+		if item.ID == keepItemID {
+			keep = &items[len(items)-1]
+		}
+	}
+	f.cart.Items = items
+	return nil
+}
+
+func newCartLine(productID uuid.UUID, variantID *uuid.UUID, name string, quantity int) models.CartItem {
+	item := models.CartItem{ProductID: &productID, VariantID: variantID, Quantity: quantity, Price: "10.00", ProductName: &name}
+	item.ID = uuid.New()
+	return item
+}
+
+func TestDuplicateCartLinesSomaQuantidades(t *testing.T) {
+	dipirona, sabonete := uuid.New(), uuid.New()
+	items := []models.CartItem{
+		newCartLine(dipirona, nil, "Dipirona", 1),
+		newCartLine(sabonete, nil, "Sabonete", 1),
+		newCartLine(dipirona, nil, "Dipirona", 2),
+		newCartLine(dipirona, nil, "Dipirona", 3),
+	}
+
+	merges := duplicateCartLines(items)
+	if len(merges) != 1 {
+		t.Fatalf("esperado um item repetido, obtido %d", len(merges))
+	}
+	if merges[0].Keep.ID != items[0].ID || merges[0].Quantity != 6 {
+		t.Errorf("esperado manter a primeira linha com 6 unidades, obtido %+v", merges[0])
+	}
+	if len(merges[0].Duplicates) != 2 || merges[0].Duplicates[0] != items[2].ID || merges[0].Duplicates[1] != items[3].ID {
+		t.Errorf("esperado remover as duas linhas repetidas, obtido %v", merges[0].Duplicates)
+	}
+}
+
+func TestDuplicateCartLinesPreservaVariantesDiferentes(t *testing.T) {
+	camiseta := uuid.New()
+	pequena, grande := uuid.New(), uuid.New()
+	semAtributo := newCartLine(camiseta, &pequena, "Camiseta P", 1)
+	comOpcao := newCartLine(camiseta, &pequena, "Camiseta P", 1)
+	comOpcao.Attributes = []models.CartItemAttribute{{AttributeID: uuid.New(), OptionID: uuid.New()}}
+
+	items := []models.CartItem{
+		semAtributo,
+		newCartLine(camiseta, &grande, "Camiseta G", 1),
+		newCartLine(camiseta, nil, "Camiseta", 1),
+		comOpcao,
+	}
+	if merges := duplicateCartLines(items); len(merges) != 0 {
+		t.Errorf("variantes e opções diferentes não deveriam ser unificadas, obtido %+v", merges)
+	}
+
+	items = append(items, newCartLine(camiseta, &grande, "Camiseta G", 2))
+	merges := duplicateCartLines(items)
+	if len(merges) != 1 || merges[0].Keep.ID != items[1].ID || merges[0].Quantity != 3 {
+		t.Errorf("esperado unificar apenas a mesma variante, obtido %+v", merges)
+	}
+}
+
+func TestConsolidarCarrinhoUnificaEMostraCarrinho(t *testing.T) {
+	dipirona := uuid.New()
+	cart := &models.Cart{Items: []models.CartItem{
+		newCartLine(dipirona, nil, "Dipirona", 1),
+		newCartLine(dipirona, nil, "Dipirona", 2),
+	}}
+	carts := &mergingCartService{fakeCartService: fakeCartService{cart: cart}}
+	s := &AIService{cartService: carts, memoryManager: NewMemoryManager()}
+
+	obtido, err := s.handleConsolidarCarrinho(context.Background(), uuid.New(), uuid.New())
+	if err != nil {
+		t.Fatalf("erro inesperado: %v", err)
+	}
+	for _, esperado := range []string{"Juntei os itens repetidos", "Dipirona: 3 unidades", "R$ 10,00 x 3 = R$ 30,00"} {
+		if !strings.Contains(obtido, esperado) {
+			t.Errorf("esperado %q em:\n%s", esperado, obtido)
+		}
+	}
+	if len(cart.Items) != 1 {
+		t.Errorf("esperado carrinho com uma linha, obtido %d", len(cart.Items))
+	}
+
+	obtido, _ = s.handleConsolidarCarrinho(context.Background(), uuid.New(), uuid.New())
+	if !strings.Contains(obtido, "não tem itens repetidos") || carts.merges != 1 {
+		t.Errorf("carrinho sem repetidos não deveria ser alterado:\n%s", obtido)
+	}
+}
+
+func TestCheckoutUnificaItensRepetidos(t *testing.T) {
+	cart := newPickupTestCart(true)
+	productID := uuid.New()
+	cart.Items = []models.CartItem{newCartLine(productID, nil, "Sabonete", 1), newCartLine(productID, nil, "Sabonete", 1)}
+
+	s, _ := newTestService(map[string]string{AllowPickupSettingKey: "true"}, withCheckout(cart))
+	s.cartService = &mergingCartService{fakeCartService: fakeCartService{cart: cart}}
+
+	checkout, err := s.handleCheckout(context.Background(), uuid.New(), uuid.New(), "5561999999999")
+	if err != nil {
+		t.Fatalf("erro inesperado no checkout: %v", err)
+	}
+	if len(cart.Items) != 1 || cart.Items[0].Quantity != 2 {
+		t.Errorf("esperado checkout com uma linha de 2 unidades, obtido %+v", cart.Items)
+	}
+	if strings.Count(checkout, "Sabonete") != 1 {
+		t.Errorf("resumo do checkout não deveria repetir o item:\n%s", checkout)
+	}
+}
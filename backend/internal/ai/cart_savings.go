@@ -0,0 +1,62 @@
+package ai
+
+import (
+	"fmt"
+
+	"iafarma/internal/utils"
+	"iafarma/pkg/models"
+
+	"github.com/google/uuid"
+)
+
+// calculateCartSavings soma a diferença entre o preço regular e o preço efetivo (promoção ou atacado) dos itens do carrinho
+func calculateCartSavings(items []models.CartItem) float64 {
+	var savings utils.Cents
+	for _, item := range items {
+		if item.Product == nil {
+			continue
+		}
+		regularPrice, ok := utils.ParseCents(item.Product.Price)
+		if !ok {
+			continue
+		}
+		effectivePrice, ok := utils.ParseCents(UnitPriceForQuantity(item.Product, item.Quantity))
+		if !ok || effectivePrice >= regularPrice {
+			continue
+		}
+		savings += (regularPrice - effectivePrice).Times(item.Quantity)
+	}
+	return savings.Float()
+}
+
+// formatCartSavings retorna a linha de economia do carrinho (vazia quando não há economia)
+func formatCartSavings(savings float64) string {
+	if savings < 0.005 {
+		return ""
+	}
+	return fmt.Sprintf("🎉 **Você está economizando R$ %s** com as promoções!", formatCurrency(utils.FormatMoney(savings)))
+}
+
+// handleCalcularEconomia informa quanto o cliente está economizando com as promoções do carrinho
+func (s *AIService) handleCalcularEconomia(tenantID, customerID uuid.UUID) (string, error) {
+	cart, err := s.cartService.GetOrCreateActiveCart(tenantID, customerID)
+	if err != nil {
+		return "❌ Erro ao acessar carrinho.", err
+	}
+
+	cartWithItems, err := s.cartService.GetCartWithItems(cart.ID, tenantID)
+	if err != nil {
+		return "❌ Erro ao carregar itens do carrinho.", err
+	}
+
+	if len(cartWithItems.Items) == 0 {
+		return "🛒 Seu carrinho está vazio!\n\n💡 Explore nossos produtos e adicione alguns itens.", nil
+	}
+
+	savingsLine := formatCartSavings(calculateCartSavings(cartWithItems.Items))
+	if savingsLine == "" {
+		return "🏷️ Nenhum item do seu carrinho está em promoção no momento.", nil
+	}
+
+	return savingsLine, nil
+}
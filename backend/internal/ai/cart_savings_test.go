@@ -0,0 +1,95 @@
+package ai
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"iafarma/pkg/models"
+
+	"github.com/google/uuid"
+)
+
+func TestCalculateCartSavings(t *testing.T) {
+	onSale := &models.Product{Name: "Shampoo", Price: "20.00", SalePrice: "15.00"}
+	regular := &models.Product{Name: "Sabonete", Price: "5.00"}
+	zeroSale := &models.Product{Name: "Creme", Price: "12.00", SalePrice: "0.00"}
+
+	tests := []struct {
+		name  string
+		items []models.CartItem
+		want  float64
+	}{
+		{"carrinho vazio", nil, 0},
+		{"sem promoções", []models.CartItem{{Quantity: 3, Price: "5.00", Product: regular}}, 0},
+		{"preço promocional zerado é ignorado", []models.CartItem{{Quantity: 1, Price: "12.00", Product: zeroSale}}, 0},
+		{"carrinho misto", []models.CartItem{
+			{Quantity: 2, Price: "15.00", Product: onSale},
+			{Quantity: 1, Price: "5.00", Product: regular},
+		}, 10},
+		{"item sem produto carregado", []models.CartItem{{Quantity: 1, Price: "15.00"}}, 0},
+		{"diferença sem erro de ponto flutuante", []models.CartItem{
+			{Quantity: 3, Price: "19.89", Product: &models.Product{Name: "Protetor", Price: "19.99", SalePrice: "19.89"}},
+		}, 0.3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := calculateCartSavings(tt.items); got != tt.want {
+				t.Errorf("calculateCartSavings() = %v, esperado %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCartViewShowsSavingsOnlyWhenPositive(t *testing.T) {
+	tenantID := uuid.New()
+	customerID := uuid.New()
+
+	tests := []struct {
+		name        string
+		items       []models.CartItem
+		wantSavings string
+	}{
+		{
+			name: "carrinho misto mostra economia",
+			items: []models.CartItem{
+				{Quantity: 2, Price: "15.00", Product: &models.Product{Name: "Shampoo", Price: "20.00", SalePrice: "15.00"}},
+				{Quantity: 1, Price: "5.00", Product: &models.Product{Name: "Sabonete", Price: "5.00"}},
+			},
+			wantSavings: "R$ 10,00",
+		},
+		{
+			name: "sem promoções não mostra economia",
+			items: []models.CartItem{
+				{Quantity: 1, Price: "5.00", Product: &models.Product{Name: "Sabonete", Price: "5.00"}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &AIService{cartService: &fakeCartService{cart: &models.Cart{Items: tt.items}}}
+
+			view, err := s.handleVerCarrinhoWithOptions(context.Background(), tenantID, customerID, false)
+			if err != nil {
+				t.Fatalf("erro inesperado: %v", err)
+			}
+			hasSavings := strings.Contains(view, "economizando")
+			if hasSavings != (tt.wantSavings != "") {
+				t.Fatalf("linha de economia presente = %v no carrinho:\n%s", hasSavings, view)
+			}
+			if tt.wantSavings != "" && !strings.Contains(view, tt.wantSavings) {
+				t.Errorf("esperado %q no carrinho:\n%s", tt.wantSavings, view)
+			}
+
+			toolResult, _ := s.handleCalcularEconomia(tenantID, customerID)
+			if tt.wantSavings != "" && !strings.Contains(toolResult, tt.wantSavings) {
+				t.Errorf("calcularEconomia = %q, esperado %q", toolResult, tt.wantSavings)
+			}
+			if tt.wantSavings == "" && strings.Contains(toolResult, "economizando") {
+				t.Errorf("calcularEconomia não deveria informar economia: %q", toolResult)
+			}
+		})
+	}
+}
@@ -0,0 +1,196 @@
+package ai
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"iafarma/pkg/models"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// DeliveryMaxWeightSettingKey define o peso máximo (kg) aceito na entrega padrão ("0" = sem limite)
+	DeliveryMaxWeightSettingKey = "ai_delivery_max_weight_kg"
+	// UrgentDeliveryMaxWeightSettingKey define o peso máximo (kg) da entrega urgente, feita por motoboy ("0" = usa o limite da entrega padrão)
+	UrgentDeliveryMaxWeightSettingKey = "ai_urgent_delivery_max_weight_kg"
+)
+
+// cartWeight é o peso total estimado do carrinho
+type cartWeight struct {
+	Grams     float64
+	Estimated []string // produtos sem peso cadastrado contados com o peso padrão
+	Missing   []string // produtos sem peso cadastrado que ficaram fora da soma
+}
+
+// computeCartWeight soma o peso dos itens, usando o peso padrão do tenant para os produtos sem peso cadastrado
+func (c deliveryFeeConfig) computeCartWeight(cart *models.Cart) cartWeight {
+	var weight cartWeight
+	if cart == nil {
+		return weight
+	}
+
+	for _, item := range cart.Items {
+		name := "produto"
+		if item.Product != nil {
+			name = item.Product.Name
+		}
+
+		grams, estimated, ok := c.productShippingWeight(item.Product)
+		if !ok {
+			weight.Missing = append(weight.Missing, name)
+			continue
+		}
+		if estimated {
+			weight.Estimated = append(weight.Estimated, name)
+		}
+		weight.Grams += grams * float64(item.Quantity)
+	}
+	return weight
+}
+
+// deliveryWeightLimit é o limite de peso da forma de entrega do carrinho
+type deliveryWeightLimit struct {
+	Grams float64 // 0 = sem limite
+	Mode  string  // forma de entrega exibida ao cliente
+}
+
+// getDeliveryWeightLimit lê o limite de peso da forma de entrega do carrinho (retirada na loja não tem limite)
+func (s *AIService) getDeliveryWeightLimit(ctx context.Context, tenantID uuid.UUID, cart *models.Cart) deliveryWeightLimit {
+	if s.settingsService == nil || s.isPickupCart(ctx, tenantID, cart) {
+		return deliveryWeightLimit{}
+	}
+
+	readKg := func(key string) float64 {
+		setting, err := s.settingsService.GetSetting(ctx, tenantID, key)
+		if err != nil || setting == nil || setting.SettingValue == nil {
+			return 0
+		}
+		value, err := strconv.ParseFloat(strings.Replace(strings.TrimSpace(*setting.SettingValue), ",", ".", 1), 64)
+		if err != nil || value < 0 {
+			return 0
+		}
+		return value
+	}
+
+	if s.isUrgentCart(ctx, tenantID, cart) {
+		if limit := readKg(UrgentDeliveryMaxWeightSettingKey); limit > 0 {
+			return deliveryWeightLimit{Grams: limit * 1000, Mode: "entrega urgente (motoboy)"}
+		}
+	}
+	if limit := readKg(DeliveryMaxWeightSettingKey); limit > 0 {
+		return deliveryWeightLimit{Grams: limit * 1000, Mode: "entrega"}
+	}
+	return deliveryWeightLimit{}
+}
+
+// exceeds indica se o peso conhecido do carrinho passa do limite
+func (l deliveryWeightLimit) exceeds(weight cartWeight) bool {
+	return l.Grams > 0 && weight.Grams > l.Grams
+}
+
+// formatCartWeight mostra o peso total do pedido e sinaliza os produtos sem peso cadastrado
+func formatCartWeight(weight cartWeight) string {
+	if weight.Grams <= 0 {
+		return ""
+	}
+
+	label := formatWeightGrams(weight.Grams)
+	switch {
+	case len(weight.Missing) > 0:
+		label = "pelo menos " + label
+	case len(weight.Estimated) > 0:
+		label += " (estimado)"
+	}
+
+	lines := []string{fmt.Sprintf("⚖️ Peso do pedido: **%s**", label)}
+	if len(weight.Missing) > 0 {
+		lines = append(lines, fmt.Sprintf("⚠️ Sem peso cadastrado: %s.", strings.Join(weight.Missing, ", ")))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// weightLimitBlockMessage explica que o pedido passou do limite e sugere dividir ou retirar na loja
+func weightLimitBlockMessage(weight cartWeight, limit deliveryWeightLimit, pickupAllowed bool) string {
+	var result strings.Builder
+	result.WriteString(fmt.Sprintf("⚖️ Seu pedido pesa **%s**, acima do limite de **%s** da %s.\n\n",
+		formatWeightGrams(weight.Grams), formatWeightGrams(limit.Grams), limit.Mode))
+	result.WriteString("💡 **Como seguir:**\n")
+	result.WriteString("📦 Dividir em dois pedidos: remova alguns itens agora e peça o restante em seguida\n")
+	if pickupAllowed {
+		result.WriteString("🏪 Retirar na loja: sem limite de peso - é só dizer 'quero retirar na loja'\n")
+	}
+	return strings.TrimRight(result.String(), "\n")
+}
+
+// cartWeightBlockMessage bloqueia o checkout quando o carrinho passa do limite de peso da entrega (vazio se estiver dentro)
+func (s *AIService) cartWeightBlockMessage(ctx context.Context, tenantID uuid.UUID, cart *models.Cart) string {
+	limit := s.getDeliveryWeightLimit(ctx, tenantID, cart)
+	if limit.Grams <= 0 {
+		return ""
+	}
+
+	weight := s.getDeliveryFeeConfig(ctx, tenantID).computeCartWeight(cart)
+	if !limit.exceeds(weight) {
+		return ""
+	}
+
+	log.Info().
+		Str("tenant_id", tenantID.String()).
+		Float64("weight_grams", weight.Grams).
+		Float64("limit_grams", limit.Grams).
+		Str("mode", limit.Mode).
+		Msg("⚖️ Pedido acima do limite de peso da entrega")
+
+	return weightLimitBlockMessage(weight, limit, s.isPickupAllowed(ctx, tenantID))
+}
+
+// checkoutWeightLine mostra o peso do pedido no checkout das entregas (vazio sem peso conhecido ou na retirada)
+func (s *AIService) checkoutWeightLine(ctx context.Context, tenantID uuid.UUID, cart *models.Cart) string {
+	if s.isPickupCart(ctx, tenantID, cart) {
+		return ""
+	}
+	return formatCartWeight(s.getDeliveryFeeConfig(ctx, tenantID).computeCartWeight(cart))
+}
+
+// handleConsultarPesoCarrinho informa o peso estimado do carrinho e se ele cabe no limite da entrega
+func (s *AIService) handleConsultarPesoCarrinho(ctx context.Context, tenantID, customerID uuid.UUID) (string, error) {
+	cart, err := s.cartService.GetOrCreateActiveCart(tenantID, customerID)
+	if err != nil {
+		return "❌ Erro ao acessar carrinho.", err
+	}
+
+	cartWithItems, err := s.cartService.GetCartWithItems(cart.ID, tenantID)
+	if err != nil {
+		return "❌ Erro ao carregar carrinho.", err
+	}
+	if len(cartWithItems.Items) == 0 {
+		return "🛒 Seu carrinho está vazio. Adicione produtos para eu calcular o peso do pedido.", nil
+	}
+
+	weight := s.getDeliveryFeeConfig(ctx, tenantID).computeCartWeight(cartWithItems)
+	if weight.Grams <= 0 {
+		return fmt.Sprintf("⚠️ Não temos o peso cadastrado de: %s. A loja confirma a entrega no fechamento do pedido.", strings.Join(weight.Missing, ", ")), nil
+	}
+
+	result := formatCartWeight(weight)
+
+	if s.isPickupCart(ctx, tenantID, cartWithItems) {
+		return result + "\n\n🏪 Seu pedido está marcado para retirada na loja, sem limite de peso.", nil
+	}
+
+	limit := s.getDeliveryWeightLimit(ctx, tenantID, cartWithItems)
+	switch {
+	case limit.exceeds(weight):
+		result += "\n\n" + weightLimitBlockMessage(weight, limit, s.isPickupAllowed(ctx, tenantID))
+	case limit.Grams > 0:
+		result += fmt.Sprintf("\n\n✅ Dentro do limite de **%s** da %s.", formatWeightGrams(limit.Grams), limit.Mode)
+		if len(weight.Missing) > 0 {
+			result += "\n⚠️ Como alguns produtos não têm peso cadastrado, a loja pode confirmar a entrega no fechamento."
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,150 @@
+package ai
+
+import (
+	"context"
+	"math"
+	"strings"
+	"testing"
+
+	"iafarma/pkg/models"
+
+	"github.com/google/uuid"
+)
+
+// newWeightTestCart monta um carrinho com 2x ração de 5 kg e um item sem peso cadastrado
+func newWeightTestCart(pickup, urgent bool) *models.Cart {
+	cart := newPickupTestCart(pickup)
+	cart.IsUrgent = urgent
+	cart.Items = append(cart.Items, models.CartItem{
+		Quantity: 2, Price: "89.90", Product: &models.Product{Name: "Ração 5kg", Price: "89.90", Weight: "5 kg"},
+	})
+	return cart
+}
+
+func TestComputeCartWeight(t *testing.T) {
+	cart := &models.Cart{Items: []models.CartItem{
+		{Quantity: 2, Product: &models.Product{Name: "Ração 1kg", Weight: "1000"}},
+		{Quantity: 1, Product: &models.Product{Name: "Areia 4kg", Weight: "4 kg"}},
+		{Quantity: 3, Product: &models.Product{Name: "Petisco"}},
+	}}
+
+	tests := []struct {
+		name      string
+		config    deliveryFeeConfig
+		gramas    float64
+		estimados []string
+		semPeso   []string
+	}{
+		{"sem peso padrão sinaliza o produto", deliveryFeeConfig{}, 6000, nil, []string{"Petisco"}},
+		{"com peso padrão estima o produto", deliveryFeeConfig{DefaultWeight: 250}, 6750, []string{"Petisco"}, nil},
+	}
+
+	for _, tt := range tests {
+		weight := tt.config.computeCartWeight(cart)
+		if math.Abs(weight.Grams-tt.gramas) > 0.001 ||
+			strings.Join(weight.Estimated, ",") != strings.Join(tt.estimados, ",") ||
+			strings.Join(weight.Missing, ",") != strings.Join(tt.semPeso, ",") {
+			t.Errorf("%s: obtido %+v", tt.name, weight)
+		}
+	}
+}
+
+func TestFormatCartWeight(t *testing.T) {
+	tests := []struct {
+		name     string
+		weight   cartWeight
+		esperado string
+	}{
+		{"sem peso conhecido", cartWeight{Missing: []string{"Petisco"}}, ""},
+		{"peso cadastrado", cartWeight{Grams: 10500}, "⚖️ Peso do pedido: **10,5 kg**"},
+		{"peso estimado", cartWeight{Grams: 750, Estimated: []string{"Petisco"}}, "⚖️ Peso do pedido: **750 g (estimado)**"},
+		{"produto sem peso", cartWeight{Grams: 2000, Missing: []string{"Petisco"}}, "⚖️ Peso do pedido: **pelo menos 2 kg**\n⚠️ Sem peso cadastrado: Petisco."},
+	}
+
+	for _, tt := range tests {
+		if obtido := formatCartWeight(tt.weight); obtido != tt.esperado {
+			t.Errorf("%s: esperado %q, obtido %q", tt.name, tt.esperado, obtido)
+		}
+	}
+}
+
+func TestCheckoutDeliveryWeightLimit(t *testing.T) {
+	tests := []struct {
+		name      string
+		pickup    bool
+		urgent    bool
+		settings  map[string]string
+		bloqueado bool
+	}{
+		{"sem limite configurado", false, false, map[string]string{}, false},
+		{"abaixo do limite", false, false, map[string]string{DeliveryMaxWeightSettingKey: "15"}, false},
+		{"acima do limite", false, false, map[string]string{DeliveryMaxWeightSettingKey: "8"}, true},
+		{"urgente acima do limite do motoboy", false, true, map[string]string{
+			AllowUrgentOrderSettingKey: "true", DeliveryMaxWeightSettingKey: "15", UrgentDeliveryMaxWeightSettingKey: "8",
+		}, true},
+		{"urgente sem limite próprio usa o da entrega", false, true, map[string]string{
+			AllowUrgentOrderSettingKey: "true", DeliveryMaxWeightSettingKey: "15",
+		}, false},
+		{"retirada na loja não tem limite", true, false, map[string]string{AllowPickupSettingKey: "true", DeliveryMaxWeightSettingKey: "8"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, _ := newTestService(tt.settings, withCheckout(newWeightTestCart(tt.pickup, tt.urgent)))
+
+			checkout, err := s.handleCheckout(context.Background(), uuid.New(), uuid.New(), "5561999999999")
+			if err != nil {
+				t.Fatalf("erro inesperado no checkout: %v", err)
+			}
+			if bloqueado := strings.Contains(checkout, "acima do limite"); bloqueado != tt.bloqueado {
+				t.Errorf("esperado bloqueado=%v:\n%s", tt.bloqueado, checkout)
+			}
+			if tt.bloqueado && !strings.Contains(checkout, "Dividir em dois pedidos") {
+				t.Errorf("checkout bloqueado deveria sugerir dividir o pedido:\n%s", checkout)
+			}
+			if !tt.bloqueado && !tt.pickup && !strings.Contains(checkout, "Peso do pedido: **pelo menos 10 kg**") {
+				t.Errorf("checkout deveria mostrar o peso do pedido:\n%s", checkout)
+			}
+		})
+	}
+}
+
+func TestFinalCheckoutBlockedAboveWeightLimit(t *testing.T) {
+	s, _ := newTestService(map[string]string{
+		DeliveryMaxWeightSettingKey: "8",
+		AllowPickupSettingKey:       "true",
+	}, withCheckout(newWeightTestCart(false, false)))
+
+	result, err := s.performFinalCheckout(context.Background(), uuid.New(), uuid.New(), "5561999999999")
+	if err != nil {
+		t.Fatalf("erro inesperado: %v", err)
+	}
+	if !strings.Contains(result, "acima do limite de **8 kg**") || !strings.Contains(result, "Retirar na loja") {
+		t.Errorf("esperado bloqueio sugerindo retirada na loja:\n%s", result)
+	}
+	if orders := s.orderService.(*fakeOrderService); len(orders.orders) > 0 {
+		t.Errorf("nenhum pedido deveria ser criado acima do limite de peso")
+	}
+}
+
+func TestConsultarPesoCarrinho(t *testing.T) {
+	tests := []struct {
+		name     string
+		settings map[string]string
+		esperado string
+	}{
+		{"dentro do limite", map[string]string{DeliveryMaxWeightSettingKey: "15"}, "Dentro do limite de **15 kg** da entrega"},
+		{"acima do limite", map[string]string{DeliveryMaxWeightSettingKey: "8"}, "acima do limite de **8 kg** da entrega"},
+	}
+
+	for _, tt := range tests {
+		s, _ := newTestService(tt.settings, withCheckout(newWeightTestCart(false, false)))
+		result, err := s.handleConsultarPesoCarrinho(context.Background(), uuid.New(), uuid.New())
+		if err != nil {
+			t.Fatalf("%s: erro inesperado: %v", tt.name, err)
+		}
+		if !strings.Contains(result, tt.esperado) || !strings.Contains(result, "Sabonete") {
+			t.Errorf("%s: esperado %q e o aviso do produto sem peso:\n%s", tt.name, tt.esperado, result)
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package ai
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"iafarma/internal/utils"
+	"iafarma/pkg/models"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+)
+
+// parseTenderedAmount interpreta o valor em dinheiro informado pelo cliente ("100", "R$ 100,00", "1.000")
+func parseTenderedAmount(value string) (utils.Cents, bool) {
+	value = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(value), "R$"))
+	value = strings.ReplaceAll(value, " ", "")
+	if strings.Contains(value, ",") {
+		value = strings.ReplaceAll(value, ".", "")
+	} else if dot := strings.LastIndex(value, "."); dot >= 0 && len(value)-dot-1 == 3 {
+		// "1.000" é separador de milhar, não centavos
+		value = strings.ReplaceAll(value, ".", "")
+	}
+
+	amount, ok := utils.ParseCents(value)
+	if !ok || amount <= 0 {
+		return 0, false
+	}
+	return amount, true
+}
+
+// formatCashChange descreve o troco para o valor entregue; covered=false quando o valor não cobre o total
+func formatCashChange(total, tendered utils.Cents) (message string, covered bool) {
+	change := tendered - total
+	switch {
+	case change < 0:
+		return fmt.Sprintf("⚠️ R$ %s não cobre o total de R$ %s (faltam R$ %s). Para quanto você precisa de troco?",
+			formatCurrency(tendered.String()), formatCurrency(total.String()), formatCurrency((-change).String())), false
+	case change == 0:
+		return fmt.Sprintf("💵 Pagando com R$ %s, o valor é exato: não precisa de troco.", formatCurrency(tendered.String())), true
+	default:
+		return fmt.Sprintf("💵 Pagando com R$ %s, seu troco será de **R$ %s** (total R$ %s).",
+			formatCurrency(tendered.String()), formatCurrency(change.String()), formatCurrency(total.String())), true
+	}
+}
+
+// cartChangeNotice recalcula o troco registrado no carrinho com o total atual (vazio quando não há troco registrado),
+// para que o valor informado continue correto depois de o cliente mudar o carrinho
+func (s *AIService) cartChangeNotice(ctx context.Context, tenantID uuid.UUID, cart *models.Cart) string {
+	if cart == nil || strings.TrimSpace(cart.ChangeFor) == "" || len(cart.Items) == 0 {
+		return ""
+	}
+	tendered, ok := parseTenderedAmount(cart.ChangeFor)
+	if !ok {
+		return ""
+	}
+	message, _ := formatCashChange(s.cartTotalBreakdown(ctx, tenantID, cart).Total, tendered)
+	return message
+}
+
+// handleCalcularTroco calcula o troco para o valor em dinheiro informado (ou o já registrado) com o total atual do
+// carrinho e registra o valor quando ele cobre o total
+func (s *AIService) handleCalcularTroco(ctx context.Context, tenantID, customerID uuid.UUID, args map[string]interface{}) (string, error) {
+	activeCart, err := s.cartService.GetOrCreateActiveCart(tenantID, customerID)
+	if err != nil {
+		return "❌ Erro ao acessar carrinho.", err
+	}
+	cart, err := s.cartService.GetCartWithItems(activeCart.ID, tenantID)
+	if err != nil {
+		return "❌ Erro ao carregar carrinho.", err
+	}
+	if len(cart.Items) == 0 {
+		return "🛒 Seu carrinho está vazio. Adicione produtos para eu calcular o troco.", nil
+	}
+
+	amount, _ := args["valor_pago"].(string)
+	if strings.TrimSpace(amount) == "" {
+		amount = cart.ChangeFor
+	}
+	if strings.TrimSpace(amount) == "" {
+		return "💵 Com quanto você vai pagar em dinheiro? Assim eu já calculo o troco.", nil
+	}
+
+	tendered, ok := parseTenderedAmount(amount)
+	if !ok {
+		return fmt.Sprintf("❌ Não entendi o valor '%s'. Informe só o valor, por exemplo: 100 ou 50,00.", amount), nil
+	}
+
+	message, covered := formatCashChange(s.cartTotalBreakdown(ctx, tenantID, cart).Total, tendered)
+	if covered && tendered.String() != cart.ChangeFor {
+		if err := s.cartService.UpdateCartObservations(cart.ID, tenantID, cart.Observations, tendered.String()); err != nil {
+			log.Warn().Err(err).Str("cart_id", cart.ID.String()).Msg("Erro ao salvar valor para troco")
+		}
+	}
+	return message, nil
+}
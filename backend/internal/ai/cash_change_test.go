@@ -0,0 +1,98 @@
+package ai
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"iafarma/internal/utils"
+	"iafarma/pkg/models"
+
+	"github.com/google/uuid"
+)
+
+func TestParseTenderedAmount(t *testing.T) {
+	tests := []struct {
+		value    string
+		expected utils.Cents
+		ok       bool
+	}{
+		{"100", 10000, true},
+		{"R$ 50,00", 5000, true},
+		{"50.5", 5050, true},
+		{"1.000", 100000, true},
+		{"1.000,50", 100050, true},
+		{"cem reais", 0, false},
+		{"0", 0, false},
+	}
+
+	for _, tt := range tests {
+		amount, ok := parseTenderedAmount(tt.value)
+		if ok != tt.ok || amount != tt.expected {
+			t.Errorf("%q: esperado (%d, %v), obtido (%d, %v)", tt.value, tt.expected, tt.ok, amount, ok)
+		}
+	}
+}
+
+func TestCalcularTroco(t *testing.T) {
+	tenantID, customerID := uuid.New(), uuid.New()
+
+	tests := []struct {
+		name      string
+		tendered  string
+		expected  string
+		changeFor string
+	}{
+		{"valor exato", "36,70", "o valor é exato: não precisa de troco", "36.70"},
+		{"valor maior", "50", "seu troco será de **R$ 13,30** (total R$ 36,70)", "50.00"},
+		{"valor insuficiente", "20", "R$ 20,00 não cobre o total de R$ 36,70 (faltam R$ 16,70)", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cart := newPickupTestCart(true)
+			cart.Items = append(cart.Items, models.CartItem{Quantity: 3, Price: "8.90", Product: &models.Product{Name: "Dipirona 500mg", Price: "8.90"}})
+			s, _ := newTestService(map[string]string{AllowPickupSettingKey: "true"}, withCheckout(cart))
+
+			result, err := s.executeTool(context.Background(), tenantID, customerID, "5561999999999", "calcularTroco", map[string]interface{}{"valor_pago": tt.tendered})
+			if err != nil {
+				t.Fatalf("erro inesperado: %v", err)
+			}
+			if !strings.Contains(result, tt.expected) {
+				t.Errorf("esperado %q, obtido:\n%s", tt.expected, result)
+			}
+			if cart.ChangeFor != tt.changeFor {
+				t.Errorf("valor para troco registrado deveria ser %q, obtido %q", tt.changeFor, cart.ChangeFor)
+			}
+		})
+	}
+}
+
+func TestChangeRecomputedWhenCartChanges(t *testing.T) {
+	tenantID, customerID := uuid.New(), uuid.New()
+	cart := newPickupTestCart(true)
+	s, _ := newTestService(map[string]string{AllowPickupSettingKey: "true"}, withCheckout(cart))
+
+	if _, err := s.executeTool(context.Background(), tenantID, customerID, "5561999999999", "calcularTroco", map[string]interface{}{"valor_pago": "50"}); err != nil {
+		t.Fatalf("erro inesperado: %v", err)
+	}
+
+	// O cliente aumenta o pedido depois de informar o valor: o troco registrado é recalculado
+	cart.Items = append(cart.Items, models.CartItem{Quantity: 2, Price: "25.00", Product: &models.Product{Name: "Protetor Solar", Price: "25.00"}})
+	view, err := s.executeTool(context.Background(), tenantID, customerID, "5561999999999", "verCarrinho", map[string]interface{}{})
+	if err != nil {
+		t.Fatalf("erro inesperado: %v", err)
+	}
+	if !strings.Contains(view, "R$ 50,00 não cobre o total de R$ 60,00") {
+		t.Errorf("carrinho deveria avisar que o valor não cobre mais o total:\n%s", view)
+	}
+
+	// Sem novo valor, calcularTroco usa o já registrado com o total atual
+	result, err := s.executeTool(context.Background(), tenantID, customerID, "5561999999999", "calcularTroco", map[string]interface{}{})
+	if err != nil {
+		t.Fatalf("erro inesperado: %v", err)
+	}
+	if !strings.Contains(result, "faltam R$ 10,00") {
+		t.Errorf("esperado o troco recalculado com o total atual, obtido:\n%s", result)
+	}
+}
@@ -0,0 +1,125 @@
+package ai
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+
+	"iafarma/pkg/models"
+
+	"github.com/google/uuid"
+)
+
+// CatalogGroupingSettingKey define como o catálogo completo ("produtos", "cardápio") é organizado
+const CatalogGroupingSettingKey = "catalog_grouping"
+
+const (
+	// CatalogGroupingCategory agrupa os produtos por categoria (padrão)
+	CatalogGroupingCategory = "category"
+	// CatalogGroupingAlphabetical lista os produtos em ordem alfabética, separados pela letra inicial
+	CatalogGroupingAlphabetical = "alphabetical"
+	// CatalogGroupingPrice lista os produtos do menor para o maior preço
+	CatalogGroupingPrice = "price"
+)
+
+// getCatalogGrouping lê a organização do catálogo configurada pelo tenant (categoria por padrão)
+func (s *AIService) getCatalogGrouping(ctx context.Context, tenantID uuid.UUID) string {
+	if s.settingsService == nil {
+		return CatalogGroupingCategory
+	}
+	setting, err := s.settingsService.GetSetting(ctx, tenantID, CatalogGroupingSettingKey)
+	if err != nil || setting == nil || setting.SettingValue == nil {
+		return CatalogGroupingCategory
+	}
+
+	switch grouping := strings.ToLower(strings.TrimSpace(*setting.SettingValue)); grouping {
+	case CatalogGroupingAlphabetical, CatalogGroupingPrice:
+		return grouping
+	default:
+		return CatalogGroupingCategory
+	}
+}
+
+// formatCatalogComplete formata o catálogo completo com a organização escolhida pelo tenant
+func (s *AIService) formatCatalogComplete(ctx context.Context, tenantID uuid.UUID, customerPhone string, products []models.Product) (string, error) {
+	grouping := s.getCatalogGrouping(ctx, tenantID)
+	if grouping == CatalogGroupingCategory {
+		return s.formatProductsByCategoryComplete(tenantID, customerPhone, products)
+	}
+
+	if len(products) > 100 {
+		return "❌ Nosso catálogo é muito grande para ser exibido completo. Por favor, pesquise por um produto específico ou categoria para ver os itens disponíveis.", nil
+	}
+
+	ordered := sortCatalogProducts(products, grouping)
+
+	// A numeração da memória segue a mesma ordem exibida
+	productRefs := s.memoryManager.StoreProductList(tenantID, customerPhone, ordered)
+
+	var result strings.Builder
+	if grouping == CatalogGroupingPrice {
+		result.WriteString("🛍️ **Catálogo Completo - Do Menor para o Maior Preço**\n\n")
+	} else {
+		result.WriteString("🛍️ **Catálogo Completo - Ordem Alfabética**\n\n")
+	}
+
+	currentLetter := ""
+	for _, productRef := range productRefs {
+		if grouping == CatalogGroupingAlphabetical {
+			if letter := catalogInitial(productRef.Name); letter != currentLetter {
+				if currentLetter != "" {
+					result.WriteString("\n")
+				}
+				result.WriteString(fmt.Sprintf("🔤 **%s**\n\n", letter))
+				currentLetter = letter
+			}
+		}
+
+		result.WriteString(fmt.Sprintf("   %d. **%s**\n", productRef.SequentialID, productRef.Name))
+		result.WriteString(fmt.Sprintf("      💰 %s\n", formatListPrice(productRef.Price, productRef.SalePrice)))
+	}
+
+	result.WriteString("\n💡 Para ver detalhes: 'produto [número]' ou 'produto [nome]'\n")
+	result.WriteString("🛒 Para adicionar ao carrinho: 'adicionar [número] quantidade [X]'")
+	return result.String(), nil
+}
+
+// sortCatalogProducts ordena uma cópia dos produtos por nome (sem diferenciar acentos) ou pelo preço efetivo;
+// produtos sem preço válido vão para o final da lista por preço
+func sortCatalogProducts(products []models.Product, grouping string) []models.Product {
+	ordered := append([]models.Product(nil), products...)
+
+	nameKey := func(product models.Product) string {
+		return accentReplacer.Replace(strings.ToLower(strings.TrimSpace(product.Name)))
+	}
+	priceKey := func(product models.Product) float64 {
+		if price, ok := parsePrice(getEffectivePrice(&product)); ok {
+			return price
+		}
+		return math.Inf(1)
+	}
+
+	sort.SliceStable(ordered, func(i, j int) bool {
+		if grouping == CatalogGroupingPrice {
+			if priceI, priceJ := priceKey(ordered[i]), priceKey(ordered[j]); priceI != priceJ {
+				return priceI < priceJ
+			}
+		}
+		return nameKey(ordered[i]) < nameKey(ordered[j])
+	})
+	return ordered
+}
+
+// catalogInitial é a letra que agrupa o produto na ordem alfabética ("#" para nomes que começam com número ou símbolo)
+func catalogInitial(name string) string {
+	for _, char := range accentReplacer.Replace(strings.ToLower(strings.TrimSpace(name))) {
+		if unicode.IsLetter(char) {
+			return strings.ToUpper(string(char))
+		}
+		return "#"
+	}
+	return "#"
+}
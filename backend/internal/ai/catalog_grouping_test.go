@@ -0,0 +1,133 @@
+package ai
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"iafarma/pkg/models"
+
+	"github.com/google/uuid"
+)
+
+// newCatalogGroupingTestCatalog monta o catálogo usado em todos os modos de organização
+func newCatalogGroupingTestCatalog() ([]models.Category, []models.Product) {
+	medicamentos := uuid.New()
+	higiene := uuid.New()
+
+	products := []models.Product{
+		{BaseTenantModel: models.BaseTenantModel{ID: uuid.New()}, Name: "Dipirona 500mg", Price: "12.90", CategoryID: &medicamentos},
+		{BaseTenantModel: models.BaseTenantModel{ID: uuid.New()}, Name: "Sabonete", Price: "4.50", CategoryID: &higiene},
+		{BaseTenantModel: models.BaseTenantModel{ID: uuid.New()}, Name: "Álcool 70%", Price: "9.90", CategoryID: &higiene},
+		{BaseTenantModel: models.BaseTenantModel{ID: uuid.New()}, Name: "Amoxicilina", Price: "39.90", SalePrice: "29.90", CategoryID: &medicamentos},
+		{BaseTenantModel: models.BaseTenantModel{ID: uuid.New()}, Name: "Brinde", Price: ""},
+	}
+
+	categories := []models.Category{
+		{BaseTenantModel: models.BaseTenantModel{ID: medicamentos}, Name: "Medicamentos", SortOrder: 1},
+		{BaseTenantModel: models.BaseTenantModel{ID: higiene}, Name: "Higiene", SortOrder: 2},
+	}
+	return categories, products
+}
+
+func TestFormatCatalogCompleteGroupingModes(t *testing.T) {
+	tests := []struct {
+		name     string
+		grouping string
+		header   string
+		sections []string
+		order    []string
+	}{
+		{
+			name:     "padrão por categoria",
+			grouping: "",
+			header:   "Organizado por Categorias",
+			sections: []string{"📂 **Medicamentos**", "📂 **Higiene**", "📂 **Outros Produtos**"},
+			order:    []string{"Amoxicilina", "Dipirona 500mg", "Sabonete", "Álcool 70%", "Brinde"},
+		},
+		{
+			name:     "valor desconhecido usa categoria",
+			grouping: "marca",
+			header:   "Organizado por Categorias",
+			sections: []string{"📂 **Medicamentos**"},
+			order:    []string{"Amoxicilina", "Dipirona 500mg", "Sabonete", "Álcool 70%", "Brinde"},
+		},
+		{
+			name:     "ordem alfabética ignora acentos",
+			grouping: "Alphabetical",
+			header:   "Ordem Alfabética",
+			sections: []string{"🔤 **A**", "🔤 **B**", "🔤 **D**", "🔤 **S**"},
+			order:    []string{"Álcool 70%", "Amoxicilina", "Brinde", "Dipirona 500mg", "Sabonete"},
+		},
+		{
+			name:     "preço promocional e sem preço no final",
+			grouping: "price",
+			header:   "Do Menor para o Maior Preço",
+			order:    []string{"Sabonete", "Álcool 70%", "Dipirona 500mg", "Amoxicilina", "Brinde"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tenantID := uuid.New()
+			phone := "5527999990000"
+			categories, products := newCatalogGroupingTestCatalog()
+			s, _ := newTestService(optionalSettings(CatalogGroupingSettingKey, tt.grouping), withCategories(categories...))
+
+			result, err := s.formatCatalogComplete(context.Background(), tenantID, phone, products)
+			if err != nil {
+				t.Fatalf("erro inesperado: %v", err)
+			}
+			if !strings.Contains(result, tt.header) {
+				t.Errorf("esperado cabeçalho %q:\n%s", tt.header, result)
+			}
+			for _, section := range tt.sections {
+				if !strings.Contains(result, section) {
+					t.Errorf("esperada seção %q:\n%s", section, result)
+				}
+			}
+
+			// A numeração exibida segue a ordem da lista, sem saltos, e bate com a memória da conversa
+			lastIndex := -1
+			for i, name := range tt.order {
+				line := fmt.Sprintf("   %d. **%s**", i+1, name)
+				index := strings.Index(result, line)
+				if index < 0 {
+					t.Fatalf("esperada linha %q:\n%s", line, result)
+				}
+				if index < lastIndex {
+					t.Errorf("esperado %q depois do item anterior:\n%s", name, result)
+				}
+				lastIndex = index
+			}
+
+			refs := s.memoryManager.GetCurrentProductList(tenantID, phone)
+			if len(refs) != len(tt.order) {
+				t.Fatalf("esperados %d produtos na memória, obtido %d", len(tt.order), len(refs))
+			}
+			for i, ref := range refs {
+				if ref.SequentialID != i+1 || ref.Name != tt.order[i] {
+					t.Errorf("memória: esperado %d. %s, obtido %d. %s", i+1, tt.order[i], ref.SequentialID, ref.Name)
+				}
+			}
+		})
+	}
+}
+
+func TestFormatCatalogCompleteTooLargeInFlatModes(t *testing.T) {
+	var products []models.Product
+	for i := 0; i < 101; i++ {
+		products = append(products, models.Product{BaseTenantModel: models.BaseTenantModel{ID: uuid.New()}, Name: "Produto", Price: "1.00"})
+	}
+
+	categories, _ := newCatalogGroupingTestCatalog()
+	s, _ := newTestService(map[string]string{CatalogGroupingSettingKey: CatalogGroupingPrice}, withCategories(categories...))
+	result, err := s.formatCatalogComplete(context.Background(), uuid.New(), "5527999990000", products)
+	if err != nil {
+		t.Fatalf("erro inesperado: %v", err)
+	}
+	if !strings.Contains(result, "muito grande") {
+		t.Errorf("esperado aviso de catálogo grande, obtido:\n%s", result)
+	}
+}
@@ -0,0 +1,70 @@
+package ai
+
+import (
+	"context"
+	"fmt"
+
+	"iafarma/internal/repo"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	// DefaultCatalogPreviewLimit é o número de itens da lista de busca, o mesmo padrão do consultarItens
+	DefaultCatalogPreviewLimit = 10
+
+	catalogPreviewPhone = "catalog-preview"
+)
+
+// CatalogPreview mostra como o bot formata o catálogo do tenant
+type CatalogPreview struct {
+	ProductCount int `json:"product_count"`
+	// GenericListing é a lista numerada exibida nas buscas por produto
+	GenericListing string `json:"generic_listing"`
+	// Grouping é a organização do catálogo completo configurada no tenant (category, alphabetical ou price)
+	Grouping string `json:"grouping"`
+	// FullCatalog é o catálogo completo exibido nas consultas genéricas ("produtos", "cardápio")
+	FullCatalog string `json:"full_catalog"`
+}
+
+// NewCatalogPreviewService cria um AIService só com o necessário para formatar listas de produtos,
+// sem cliente da OpenAI e com memória própria para não mexer nas listas das conversas dos clientes
+func NewCatalogPreviewService(db *gorm.DB) *AIService {
+	return &AIService{
+		productService:  NewProductService(db),
+		settingsService: NewTenantSettingsService(db),
+		categoryService: &categoryServiceImpl{repo: repo.NewCategoryRepository(db)},
+		memoryManager:   NewMemoryManager(),
+	}
+}
+
+// PreviewCatalog formata os produtos reais do tenant exatamente como o consultarItens os envia ao cliente
+func (s *AIService) PreviewCatalog(ctx context.Context, tenantID uuid.UUID, limit int) (*CatalogPreview, error) {
+	if limit <= 0 {
+		limit = DefaultCatalogPreviewLimit
+	}
+
+	// Mesma busca de uma consulta genérica ("produtos"): sem limite e com o filtro de estoque do tenant
+	products, _, err := s.searchProductsForQuery(ctx, tenantID, ProductSearchFilters{
+		SortBy:            "relevance",
+		IncludeOutOfStock: !s.resolveInStockOnly(ctx, tenantID, "", nil),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to load products for catalog preview: %w", err)
+	}
+
+	preview := &CatalogPreview{ProductCount: len(products), Grouping: s.getCatalogGrouping(ctx, tenantID)}
+	if len(products) == 0 {
+		return preview, nil
+	}
+
+	preview.GenericListing, _ = s.formatProductListing(ctx, tenantID, catalogPreviewPhone, products, "", limit)
+
+	preview.FullCatalog, err = s.formatCatalogComplete(ctx, tenantID, catalogPreviewPhone, products)
+	if err != nil {
+		return nil, fmt.Errorf("failed to format full catalog: %w", err)
+	}
+
+	return preview, nil
+}
@@ -0,0 +1,113 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"iafarma/pkg/models"
+
+	"github.com/google/uuid"
+)
+
+// fakeCategoryService retorna as categorias cadastradas do tenant
+type fakeCategoryService struct {
+	CategoryServiceInterface
+	categories []models.Category
+}
+
+func (f *fakeCategoryService) ListCategories(tenantID uuid.UUID) ([]models.Category, error) {
+	return f.categories, nil
+}
+
+func (f *fakeCategoryService) GetCategoryByID(tenantID, id uuid.UUID) (*models.Category, error) {
+	for i := range f.categories {
+		if f.categories[i].ID == id {
+			return &f.categories[i], nil
+		}
+	}
+	return nil, errors.New("record not found")
+}
+
+func TestPreviewCatalogRendersBothFormats(t *testing.T) {
+	tenantID := uuid.New()
+	categoryID := uuid.New()
+	products := []models.Product{
+		{BaseTenantModel: models.BaseTenantModel{ID: uuid.New()}, Name: "Dipirona 500mg", Price: "12.90", StockQuantity: 30, CategoryID: &categoryID,
+			Description: "Analgésico e antitérmico indicado para dores e febre, em comprimidos de 500mg, caixa com 10 unidades."},
+		{BaseTenantModel: models.BaseTenantModel{ID: uuid.New()}, Name: "Protetor Solar FPS 50", Price: "59.90", SalePrice: "44.90", StockQuantity: 5},
+	}
+
+	s := &AIService{
+		productService:  &fakeProductService{advancedResult: products},
+		categoryService: &fakeCategoryService{categories: []models.Category{{BaseTenantModel: models.BaseTenantModel{ID: categoryID}, Name: "Medicamentos"}}},
+		settingsService: &fakeSettingsService{values: map[string]string{}},
+		memoryManager:   NewMemoryManager(),
+	}
+
+	preview, err := s.PreviewCatalog(context.Background(), tenantID, 0)
+	if err != nil {
+		t.Fatalf("erro inesperado: %v", err)
+	}
+	if preview.ProductCount != 2 {
+		t.Errorf("esperado 2 produtos, obtido %d", preview.ProductCount)
+	}
+
+	for _, esperado := range []string{"Produtos disponíveis", "1. **Dipirona 500mg**", "📝 Analgésico", "...", "2. **Protetor Solar FPS 50**"} {
+		if !strings.Contains(preview.GenericListing, esperado) {
+			t.Errorf("lista genérica deveria conter %q:\n%s", esperado, preview.GenericListing)
+		}
+	}
+	for _, esperado := range []string{"Organizado por Categorias", "📂 **Medicamentos**", "📂 **Outros Produtos**"} {
+		if !strings.Contains(preview.FullCatalog, esperado) {
+			t.Errorf("catálogo por categoria deveria conter %q:\n%s", esperado, preview.FullCatalog)
+		}
+	}
+
+	// A prévia não pode alterar a lista numerada de nenhuma conversa real
+	if refs := s.memoryManager.GetCurrentProductList(tenantID, "5527999990000"); len(refs) > 0 {
+		t.Errorf("prévia não deveria gravar listas para clientes")
+	}
+}
+
+func TestPreviewCatalogRespectsListingLimit(t *testing.T) {
+	var products []models.Product
+	for _, name := range []string{"Álcool 70%", "Band-aid", "Curativo", "Dipirona"} {
+		products = append(products, models.Product{BaseTenantModel: models.BaseTenantModel{ID: uuid.New()}, Name: name, Price: "5.00", StockQuantity: 10})
+	}
+
+	s := &AIService{
+		productService:  &fakeProductService{advancedResult: products},
+		categoryService: &fakeCategoryService{},
+		settingsService: &fakeSettingsService{values: map[string]string{}},
+		memoryManager:   NewMemoryManager(),
+	}
+
+	preview, err := s.PreviewCatalog(context.Background(), uuid.New(), 2)
+	if err != nil {
+		t.Fatalf("erro inesperado: %v", err)
+	}
+	if !strings.Contains(preview.GenericListing, "2. **Band-aid**") || strings.Contains(preview.GenericListing, "3. **Curativo**") {
+		t.Errorf("lista genérica deveria parar no limite de 2 itens:\n%s", preview.GenericListing)
+	}
+	if strings.Count(preview.FullCatalog, "💰") != 4 {
+		t.Errorf("catálogo completo deveria listar todos os produtos:\n%s", preview.FullCatalog)
+	}
+}
+
+func TestPreviewCatalogWithoutProducts(t *testing.T) {
+	s := &AIService{
+		productService:  &fakeProductService{},
+		settingsService: &fakeSettingsService{values: map[string]string{}},
+		memoryManager:   NewMemoryManager(),
+	}
+
+	preview, err := s.PreviewCatalog(context.Background(), uuid.New(), 0)
+	if err != nil {
+		t.Fatalf("erro inesperado: %v", err)
+	}
+	if preview.ProductCount != 0 || preview.GenericListing != "" || preview.FullCatalog != "" {
+		t.Errorf("esperado prévia vazia, obtido %+v", preview)
+	}
+}
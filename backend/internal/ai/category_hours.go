@@ -0,0 +1,176 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"iafarma/pkg/models"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+)
+
+// CategoryBusinessHoursSettingKey guarda horários próprios por categoria/setor, em JSON:
+// {"<category_id>": {"monday": {"enabled": true, "open": "08:00", "close": "18:00"}, ...}}.
+// Categorias fora do mapa seguem o horário da loja.
+const CategoryBusinessHoursSettingKey = "category_business_hours"
+
+// parseCategoryBusinessHours decodifica os horários por categoria, ignorando chaves que não são IDs de categoria
+func parseCategoryBusinessHours(value string) (map[uuid.UUID]BusinessHours, error) {
+	var raw map[string]BusinessHours
+	if err := json.Unmarshal([]byte(value), &raw); err != nil {
+		return nil, err
+	}
+
+	hours := make(map[uuid.UUID]BusinessHours, len(raw))
+	for key, categoryHours := range raw {
+		categoryID, err := uuid.Parse(strings.TrimSpace(key))
+		if err != nil {
+			continue
+		}
+		hours[categoryID] = categoryHours
+	}
+	return hours, nil
+}
+
+// resolveCategoryHours retorna o horário que vale para a categoria: o próprio, quando configurado (herdando o fuso
+// da loja), ou o horário da loja. own=false indica que a categoria segue a loja.
+func resolveCategoryHours(store BusinessHours, byCategory map[uuid.UUID]BusinessHours, categoryID *uuid.UUID) (hours BusinessHours, own bool) {
+	if categoryID == nil {
+		return store, false
+	}
+	categoryHours, ok := byCategory[*categoryID]
+	if !ok {
+		return store, false
+	}
+	if categoryHours.Timezone == "" {
+		categoryHours.Timezone = store.Timezone
+	}
+	return categoryHours, true
+}
+
+// businessHoursLocation retorna o fuso dos horários (padrão: America/Sao_Paulo)
+func businessHoursLocation(hours BusinessHours) *time.Location {
+	timezone := hours.Timezone
+	if timezone == "" {
+		timezone = "America/Sao_Paulo"
+	}
+	location, err := time.LoadLocation(timezone)
+	if err != nil {
+		return time.UTC
+	}
+	return location
+}
+
+// loadCategoryBusinessHours lê os horários por categoria configurados pelo tenant (nil quando não há)
+func (s *AIService) loadCategoryBusinessHours(ctx context.Context, tenantID uuid.UUID) map[uuid.UUID]BusinessHours {
+	if s.settingsService == nil {
+		return nil
+	}
+	setting, err := s.settingsService.GetSetting(ctx, tenantID, CategoryBusinessHoursSettingKey)
+	if err != nil || setting == nil || setting.SettingValue == nil || strings.TrimSpace(*setting.SettingValue) == "" {
+		return nil
+	}
+	hours, err := parseCategoryBusinessHours(*setting.SettingValue)
+	if err != nil {
+		log.Error().Err(err).Str("tenant_id", tenantID.String()).Msg("Erro ao decodificar horários por categoria")
+		return nil
+	}
+	return hours
+}
+
+// categoryName retorna o nome da categoria para as mensagens ao cliente ("" quando não encontrada)
+func (s *AIService) categoryName(tenantID, categoryID uuid.UUID) string {
+	if s.categoryService == nil {
+		return ""
+	}
+	category, err := s.categoryService.GetCategoryByID(tenantID, categoryID)
+	if err != nil || category == nil {
+		return ""
+	}
+	return category.Name
+}
+
+// categoryClosedMessage impede adicionar ao carrinho produtos de um setor com horário próprio que está fechado agora;
+// retorna "" quando o setor está aberto ou segue o horário da loja
+func (s *AIService) categoryClosedMessage(ctx context.Context, tenantID uuid.UUID, product *models.Product) string {
+	if product.CategoryID == nil {
+		return ""
+	}
+	byCategory := s.loadCategoryBusinessHours(ctx, tenantID)
+	if len(byCategory) == 0 {
+		return ""
+	}
+
+	store, _, storeConfigured := s.loadBusinessHours(ctx, tenantID)
+	hours, own := resolveCategoryHours(store, byCategory, product.CategoryID)
+	if !own {
+		return ""
+	}
+	isOpen, nextOpen := s.isStoreOpen(hours, time.Now().In(businessHoursLocation(hours)))
+	if isOpen {
+		return ""
+	}
+
+	log.Info().
+		Str("tenant_id", tenantID.String()).
+		Str("product_id", product.ID.String()).
+		Str("category_id", product.CategoryID.String()).
+		Msg("🔴 Setor fechado - produto não adicionado")
+
+	message := "🔴 O setor deste produto está fechado agora"
+	if name := s.categoryName(tenantID, *product.CategoryID); name != "" {
+		message = fmt.Sprintf("🔴 O setor de **%s** está fechado agora", name)
+	}
+	if nextOpen != "" {
+		message += fmt.Sprintf(" e abre %s", nextOpen)
+	}
+	message += ", então não consigo incluir **" + product.Name + "** no pedido."
+
+	storeOpen := !storeConfigured
+	if storeConfigured {
+		storeOpen, _ = s.isStoreOpen(store, time.Now().In(businessHoursLocation(store)))
+	}
+	if storeOpen {
+		message += "\n\n🛒 Mas você pode pedir normalmente os produtos dos outros setores da loja."
+	}
+	return message
+}
+
+// categoryHoursInfo resume, para o prompt do sistema, os setores com horário próprio e se estão abertos agora
+func (s *AIService) categoryHoursInfo(ctx context.Context, tenantID uuid.UUID, store BusinessHours) string {
+	byCategory := s.loadCategoryBusinessHours(ctx, tenantID)
+	if len(byCategory) == 0 {
+		return ""
+	}
+
+	var lines []string
+	for categoryID := range byCategory {
+		name := s.categoryName(tenantID, categoryID)
+		if name == "" {
+			continue
+		}
+		id := categoryID
+		hours, _ := resolveCategoryHours(store, byCategory, &id)
+		status := "🟢 aberto"
+		isOpen, next := s.isStoreOpen(hours, time.Now().In(businessHoursLocation(hours)))
+		if !isOpen {
+			status = "🔴 fechado"
+			if next != "" {
+				status += " (abre " + next + ")"
+			}
+		}
+		lines = append(lines, fmt.Sprintf("• %s: %s", name, status))
+	}
+	if len(lines) == 0 {
+		return ""
+	}
+	sort.Strings(lines)
+
+	return "\n\nSETORES COM HORÁRIO PRÓPRIO (os demais seguem o horário da loja; produtos de setor fechado não podem ser pedidos agora):\n" +
+		strings.Join(lines, "\n")
+}
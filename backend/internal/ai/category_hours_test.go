@@ -0,0 +1,101 @@
+package ai
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"iafarma/pkg/models"
+
+	"github.com/google/uuid"
+)
+
+func TestResolveCategoryHours(t *testing.T) {
+	pharmacy, grocery := uuid.New(), uuid.New()
+	store := BusinessHours{Monday: DayHours{Enabled: true, Open: "07:00", Close: "22:00"}, Timezone: "America/Manaus"}
+	byCategory, err := parseCategoryBusinessHours(fmt.Sprintf(`{"%s": {"monday": {"enabled": true, "open": "08:00", "close": "18:00"}}, "farmacia": {}}`, pharmacy))
+	if err != nil {
+		t.Fatalf("erro inesperado: %v", err)
+	}
+	if len(byCategory) != 1 {
+		t.Fatalf("chaves que não são IDs de categoria deveriam ser ignoradas, obtido %d categorias", len(byCategory))
+	}
+
+	hours, own := resolveCategoryHours(store, byCategory, &pharmacy)
+	if !own || hours.Monday.Close != "18:00" || hours.Timezone != "America/Manaus" {
+		t.Errorf("setor deveria usar o próprio horário com o fuso da loja, obtido %+v (próprio=%v)", hours, own)
+	}
+
+	for name, categoryID := range map[string]*uuid.UUID{"categoria sem horário próprio": &grocery, "produto sem categoria": nil} {
+		hours, own := resolveCategoryHours(store, byCategory, categoryID)
+		if own || hours.Monday.Close != "22:00" {
+			t.Errorf("%s: deveria seguir o horário da loja, obtido %+v (próprio=%v)", name, hours, own)
+		}
+	}
+}
+
+func TestCategoryHoursOpenClosed(t *testing.T) {
+	tenantID, customerID := uuid.New(), uuid.New()
+	pharmacy, grocery := uuid.New(), uuid.New()
+	products := []models.Product{
+		newStockTestProduct("Dipirona 500mg", "Medley", &pharmacy, 10),
+		newStockTestProduct("Arroz 5kg", "Tio João", &grocery, 10),
+	}
+	categories := &fakeCategoryService{categories: []models.Category{
+		{BaseTenantModel: models.BaseTenantModel{ID: pharmacy}, Name: "Farmácia"},
+		{BaseTenantModel: models.BaseTenantModel{ID: grocery}, Name: "Mercearia"},
+	}}
+
+	tests := []struct {
+		name          string
+		storeHours    string
+		pharmacyHours string
+		added         []string
+		expected      string
+	}{
+		{"setor fechado com a loja aberta", alwaysOpenHours, alwaysClosedHours, []string{"Arroz 5kg"}, "Mas você pode pedir normalmente os produtos dos outros setores"},
+		{"setor fechado com a loja fechada", alwaysClosedHours, alwaysClosedHours, []string{"Arroz 5kg"}, "não consigo incluir **Dipirona 500mg**"},
+		{"setor aberto", alwaysOpenHours, alwaysOpenHours, []string{"Dipirona 500mg", "Arroz 5kg"}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cart := newCheckoutCartService(products)
+			s, _ := newTestService(map[string]string{
+				"business_hours":                tt.storeHours,
+				CategoryBusinessHoursSettingKey: fmt.Sprintf(`{"%s": %s}`, pharmacy, tt.pharmacyHours),
+			}, withAddAndCheckout(cart, products...), withCatalogFilter(products...), withOverride(func(s *AIService) { s.categoryService = categories }))
+
+			var messages []string
+			for _, product := range products {
+				result, err := s.tryAddProductToCart(context.Background(), tenantID, customerID, "5561999999999", product.ID, 1)
+				if err != nil {
+					t.Fatalf("erro inesperado: %v", err)
+				}
+				messages = append(messages, result)
+			}
+
+			if len(cart.cart.Items) != len(tt.added) {
+				t.Fatalf("esperados %v no carrinho, obtido %d itens\n%s", tt.added, len(cart.cart.Items), strings.Join(messages, "\n"))
+			}
+			if tt.expected != "" {
+				if !strings.Contains(messages[0], "O setor de **Farmácia** está fechado agora") || !strings.Contains(messages[0], tt.expected) {
+					t.Errorf("esperado aviso de setor fechado com %q, obtido:\n%s", tt.expected, messages[0])
+				}
+				if tt.storeHours == alwaysClosedHours && strings.Contains(messages[0], "outros setores") {
+					t.Errorf("com a loja fechada não deveria oferecer outros setores:\n%s", messages[0])
+				}
+			}
+
+			info := s.categoryHoursInfo(context.Background(), tenantID, BusinessHours{})
+			status := "• Farmácia: 🟢 aberto"
+			if tt.pharmacyHours == alwaysClosedHours {
+				status = "• Farmácia: 🔴 fechado"
+			}
+			if !strings.Contains(info, status) || strings.Contains(info, "Mercearia") {
+				t.Errorf("prompt deveria listar só o setor com horário próprio (%q), obtido:\n%s", status, info)
+			}
+		})
+	}
+}
@@ -0,0 +1,71 @@
+package ai
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// AcceptOrdersWhenClosedSettingKey define se o bot atende normalmente fora do horário de funcionamento;
+	// desligado, áudios e imagens recebidos com a loja fechada só têm o recebimento confirmado
+	AcceptOrdersWhenClosedSettingKey = "accept_orders_when_closed"
+	// ClosedMediaMessageSettingKey personaliza a confirmação de mídia fora do horário ({{media}} = "seu áudio"/"sua imagem")
+	ClosedMediaMessageSettingKey = "ai_closed_media_message"
+)
+
+// Tipos de mídia confirmados com a loja fechada
+const (
+	closedMediaAudio = "seu áudio"
+	closedMediaImage = "sua imagem"
+)
+
+// acceptsOrdersWhenClosed indica se o tenant atende fora do horário (padrão: sim, como antes da configuração)
+func (s *AIService) acceptsOrdersWhenClosed(ctx context.Context, tenantID uuid.UUID) bool {
+	if s.settingsService == nil {
+		return true
+	}
+	setting, err := s.settingsService.GetSetting(ctx, tenantID, AcceptOrdersWhenClosedSettingKey)
+	if err != nil || setting == nil || setting.SettingValue == nil {
+		return true
+	}
+	accept, err := strconv.ParseBool(strings.TrimSpace(*setting.SettingValue))
+	return err != nil || accept
+}
+
+// closedStoreMediaAck retorna a confirmação de recebimento quando a loja está fechada e não atende fora do horário;
+// closed=false quando a mídia deve ser processada normalmente (Whisper/Vision)
+func (s *AIService) closedStoreMediaAck(ctx context.Context, tenantID uuid.UUID, media string) (string, bool) {
+	if s.acceptsOrdersWhenClosed(ctx, tenantID) {
+		return "", false
+	}
+	businessHours, now, ok := s.loadBusinessHours(ctx, tenantID)
+	if !ok {
+		return "", false
+	}
+	isOpen, nextOpen := s.isStoreOpen(businessHours, now)
+	if isOpen {
+		return "", false
+	}
+
+	log.Info().
+		Str("tenant_id", tenantID.String()).
+		Str("media", media).
+		Msg("🌙 Loja fechada - mídia recebida sem análise da IA")
+
+	if setting, err := s.settingsService.GetSetting(ctx, tenantID, ClosedMediaMessageSettingKey); err == nil && setting != nil && setting.SettingValue != nil {
+		if custom := strings.TrimSpace(*setting.SettingValue); custom != "" {
+			return strings.ReplaceAll(custom, "{{media}}", media), true
+		}
+	}
+
+	message := fmt.Sprintf("📩 Recebi %s! No momento estamos fechados e respondemos no horário de funcionamento.", media)
+	if nextOpen != "" {
+		message += fmt.Sprintf("\n\n🕐 Abrimos %s.", nextOpen)
+	}
+	return message, true
+}
@@ -0,0 +1,111 @@
+package ai
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"iafarma/pkg/models"
+
+	"github.com/google/uuid"
+	"github.com/sashabaranov/go-openai"
+)
+
+const (
+	alwaysClosedHours = `{"monday":{"enabled":false},"tuesday":{"enabled":false},"wednesday":{"enabled":false},"thursday":{"enabled":false},"friday":{"enabled":false},"saturday":{"enabled":false},"sunday":{"enabled":false}}`
+	alwaysOpenHours   = `{"monday":{"enabled":true,"open":"00:00","close":"24:00"},"tuesday":{"enabled":true,"open":"00:00","close":"24:00"},"wednesday":{"enabled":true,"open":"00:00","close":"24:00"},"thursday":{"enabled":true,"open":"00:00","close":"24:00"},"friday":{"enabled":true,"open":"00:00","close":"24:00"},"saturday":{"enabled":true,"open":"00:00","close":"24:00"},"sunday":{"enabled":true,"open":"00:00","close":"24:00"}}`
+)
+
+// newFailingOpenAIClient simula a OpenAI fora do ar e conta as chamadas recebidas
+func newFailingOpenAIClient(t *testing.T) (*openai.Client, *int) {
+	t.Helper()
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	t.Cleanup(server.Close)
+
+	config := openai.DefaultConfig("test")
+	config.BaseURL = server.URL + "/v1"
+	return openai.NewClientWithConfig(config), &calls
+}
+
+// withClosedMediaChat liga a OpenAI fora do ar e a cliente Maria
+func withClosedMediaChat(client *openai.Client) testServiceOption {
+	customer := &models.Customer{Name: "Maria"}
+	customer.ID = uuid.New()
+	return withOptions(withClient(client), withCustomerService(&phoneCustomerService{customer: customer}))
+}
+
+func TestClosedStoreMediaSkipsAI(t *testing.T) {
+	tenantID := uuid.New()
+	client, calls := newFailingOpenAIClient(t)
+	s, _ := newTestService(map[string]string{
+		"business_hours":                 alwaysClosedHours,
+		AcceptOrdersWhenClosedSettingKey: "false",
+	}, withClosedMediaChat(client))
+
+	audio, err := s.ProcessAudioMessage(context.Background(), tenantID, "5527999999999", "https://example.com/audio.ogg", "msg-1")
+	if err != nil {
+		t.Fatalf("erro inesperado no áudio: %v", err)
+	}
+	if !strings.Contains(audio, "Recebi seu áudio") || !strings.Contains(audio, "horário de funcionamento") {
+		t.Errorf("esperada confirmação do áudio fora do horário, obtido:\n%s", audio)
+	}
+
+	image, err := s.ProcessImageMessage(context.Background(), tenantID, "5527999999999", "https://example.com/foto.jpg", "msg-2")
+	if err != nil {
+		t.Fatalf("erro inesperado na imagem: %v", err)
+	}
+	if !strings.Contains(image, "Recebi sua imagem") {
+		t.Errorf("esperada confirmação da imagem fora do horário, obtido:\n%s", image)
+	}
+
+	if *calls != 0 {
+		t.Errorf("nenhuma chamada à OpenAI deveria ser feita com a loja fechada, obtido %d", *calls)
+	}
+}
+
+func TestClosedStoreMediaCustomMessage(t *testing.T) {
+	client, _ := newFailingOpenAIClient(t)
+	s, _ := newTestService(map[string]string{
+		"business_hours":                 alwaysClosedHours,
+		AcceptOrdersWhenClosedSettingKey: "false",
+		ClosedMediaMessageSettingKey:     "Oi! Guardamos {{media}} e respondemos amanhã cedo.",
+	}, withClosedMediaChat(client))
+
+	ack, closed := s.closedStoreMediaAck(context.Background(), uuid.New(), closedMediaAudio)
+	if !closed || ack != "Oi! Guardamos seu áudio e respondemos amanhã cedo." {
+		t.Errorf("esperada mensagem personalizada, obtido %q (fechada=%v)", ack, closed)
+	}
+}
+
+func TestClosedStoreMediaProcessedNormally(t *testing.T) {
+	tests := []struct {
+		name     string
+		settings map[string]string
+	}{
+		{"aceita pedidos fora do horário", map[string]string{"business_hours": alwaysClosedHours, AcceptOrdersWhenClosedSettingKey: "true"}},
+		{"configuração padrão", map[string]string{"business_hours": alwaysClosedHours}},
+		{"loja aberta", map[string]string{"business_hours": alwaysOpenHours, AcceptOrdersWhenClosedSettingKey: "false"}},
+		{"sem horários configurados", map[string]string{AcceptOrdersWhenClosedSettingKey: "false"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, _ := newFailingOpenAIClient(t)
+			s, _ := newTestService(tt.settings, withClosedMediaChat(client))
+			if ack, closed := s.closedStoreMediaAck(context.Background(), uuid.New(), closedMediaAudio); closed {
+				t.Errorf("mídia deveria seguir para a IA, obtido confirmação %q", ack)
+			}
+
+			// Sem S3 o áudio para logo depois da verificação de horário, provando que não foi interceptado
+			if _, err := s.ProcessAudioMessage(context.Background(), uuid.New(), "5527999999999", "https://example.com/audio.ogg", "msg-1"); err == nil || !strings.Contains(err.Error(), "S3") {
+				t.Errorf("esperado o processamento normal do áudio (erro de S3), obtido %v", err)
+			}
+		})
+	}
+}
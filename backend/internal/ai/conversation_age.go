@@ -0,0 +1,66 @@
+package ai
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	"iafarma/pkg/models"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// ConversationMaxAgeSettingKey define após quantas horas sem mensagens a conversa recomeça do zero (0 = nunca)
+	ConversationMaxAgeSettingKey = "ai_conversation_max_age_hours"
+
+	defaultConversationMaxAgeHours = 48
+)
+
+// isConversationStale indica se a última mensagem do cliente é mais antiga que o limite configurado
+func isConversationStale(lastSeenAt *time.Time, now time.Time, maxAge time.Duration) bool {
+	if lastSeenAt == nil || maxAge <= 0 {
+		return false
+	}
+	return now.Sub(*lastSeenAt) >= maxAge
+}
+
+// getConversationMaxAge retorna o limite configurado pelo tenant para continuar a conversa anterior
+func (s *AIService) getConversationMaxAge(ctx context.Context, tenantID uuid.UUID) time.Duration {
+	hours := defaultConversationMaxAgeHours
+	if s.settingsService != nil {
+		setting, err := s.settingsService.GetSetting(ctx, tenantID, ConversationMaxAgeSettingKey)
+		if err == nil && setting != nil && setting.SettingValue != nil {
+			if value, err := strconv.Atoi(strings.TrimSpace(*setting.SettingValue)); err == nil && value >= 0 {
+				hours = value
+			}
+		}
+	}
+	return time.Duration(hours) * time.Hour
+}
+
+// resetStaleConversation descarta o contexto antigo (histórico, listas e carrinho montado pela metade) quando o
+// cliente volta depois do limite configurado. Deve rodar antes de registrar a visita, que atualiza LastSeenAt.
+func (s *AIService) resetStaleConversation(ctx context.Context, tenantID uuid.UUID, customer *models.Customer, customerPhone string) bool {
+	if !isConversationStale(customer.LastSeenAt, time.Now(), s.getConversationMaxAge(ctx, tenantID)) {
+		return false
+	}
+
+	s.memoryManager.ClearMemory(tenantID, customerPhone)
+	if s.cartService != nil {
+		if cart, err := s.cartService.GetOrCreateActiveCart(tenantID, customer.ID); err != nil {
+			log.Warn().Err(err).Str("customer_id", customer.ID.String()).Msg("⚠️ Erro ao buscar carrinho antigo do cliente")
+		} else if err := s.cartService.ClearCart(cart.ID, tenantID); err != nil {
+			log.Warn().Err(err).Str("customer_id", customer.ID.String()).Msg("⚠️ Erro ao limpar carrinho antigo do cliente")
+		}
+	}
+
+	log.Info().
+		Str("tenant_id", tenantID.String()).
+		Str("customer_id", customer.ID.String()).
+		Time("last_seen_at", *customer.LastSeenAt).
+		Msg("🌅 Conversa antiga demais - recomeçando do zero")
+	return true
+}
@@ -0,0 +1,104 @@
+package ai
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"iafarma/pkg/models"
+
+	"github.com/google/uuid"
+	"github.com/sashabaranov/go-openai"
+)
+
+// welcomeSettingsService adiciona a mensagem de boas-vindas configurada às configurações fixas
+type welcomeSettingsService struct {
+	*fakeSettingsService
+}
+
+func (f *welcomeSettingsService) GetWelcomeMessage(ctx context.Context, tenantID uuid.UUID) (string, error) {
+	return "Olá! Bem-vindo à Farmácia Central.", nil
+}
+
+func TestIsConversationStale(t *testing.T) {
+	now := time.Date(2024, 6, 10, 12, 0, 0, 0, time.UTC)
+	hoursAgo := func(hours int) *time.Time {
+		at := now.Add(-time.Duration(hours) * time.Hour)
+		return &at
+	}
+
+	tests := []struct {
+		name       string
+		lastSeenAt *time.Time
+		maxAge     time.Duration
+		expected   bool
+	}{
+		{"primeiro contato", nil, 48 * time.Hour, false},
+		{"dentro do limite", hoursAgo(47), 48 * time.Hour, false},
+		{"no limite", hoursAgo(48), 48 * time.Hour, true},
+		{"dias depois", hoursAgo(24 * 5), 48 * time.Hour, true},
+		{"limite desativado", hoursAgo(24 * 30), 0, false},
+	}
+
+	for _, tt := range tests {
+		if result := isConversationStale(tt.lastSeenAt, now, tt.maxAge); result != tt.expected {
+			t.Errorf("%s: isConversationStale = %t, esperado %t", tt.name, result, tt.expected)
+		}
+	}
+}
+
+func TestConversationAgeContinuationAndReset(t *testing.T) {
+	tests := []struct {
+		name       string
+		settings   map[string]string
+		silence    time.Duration
+		freshStart bool
+	}{
+		{"continua dentro do limite", map[string]string{ConversationMaxAgeSettingKey: "48"}, 2 * time.Hour, false},
+		{"recomeça depois do limite", map[string]string{ConversationMaxAgeSettingKey: "48"}, 72 * time.Hour, true},
+		{"limite padrão", map[string]string{}, 72 * time.Hour, true},
+		{"limite desativado", map[string]string{ConversationMaxAgeSettingKey: "0"}, 72 * time.Hour, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tenantID := uuid.New()
+			client, _ := newPromptCapturingClient(t)
+			customer := newLanguageTestCustomer("")
+			s, fakes := newTestService(tt.settings, withLanguageChat(client, customer))
+			s.settingsService = &welcomeSettingsService{fakeSettingsService: fakes.settings}
+
+			// Cliente já recebido e com um carrinho montado pela metade na última conversa
+			lastSeen, welcomed := time.Now().Add(-tt.silence), time.Now().Add(-tt.silence)
+			customer.LastSeenAt, customer.LastWelcomedAt = &lastSeen, &welcomed
+			cart := &models.Cart{Items: []models.CartItem{{Quantity: 2}}}
+			s.cartService = &fakeCartService{cart: cart}
+			s.memoryManager.AddToConversationHistory(tenantID, customer.Phone, openai.ChatCompletionMessage{Role: openai.ChatMessageRoleUser, Content: "quero dipirona"})
+			s.memoryManager.StoreProductList(tenantID, customer.Phone, []models.Product{newPricedTestProduct("Dipirona 500mg", "8.90")})
+
+			response, err := s.ProcessMessage(context.Background(), tenantID, customer.Phone, "oi")
+			if err != nil {
+				t.Fatalf("erro inesperado: %v", err)
+			}
+
+			history := s.memoryManager.GetConversationHistory(tenantID, customer.Phone)
+			productList := s.memoryManager.GetCurrentProductList(tenantID, customer.Phone)
+			if tt.freshStart {
+				if response != "Olá! Bem-vindo à Farmácia Central." {
+					t.Errorf("esperadas novas boas-vindas, obtido %q", response)
+				}
+				if len(cart.Items) != 0 || len(productList) != 0 || len(history) != 2 {
+					t.Errorf("contexto antigo deveria ser descartado: carrinho %d itens, lista %d, histórico %d", len(cart.Items), len(productList), len(history))
+				}
+				return
+			}
+
+			if response != "ok" {
+				t.Errorf("esperada resposta da IA continuando a conversa, obtido %q", response)
+			}
+			if len(cart.Items) != 1 || len(productList) != 1 || history[0].Content != "quero dipirona" {
+				t.Errorf("contexto deveria ser mantido: carrinho %d itens, lista %d, histórico %+v", len(cart.Items), len(productList), history)
+			}
+		})
+	}
+}
@@ -0,0 +1,139 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+	"github.com/sashabaranov/go-openai"
+)
+
+const (
+	// ConversationSummarySettingKey habilita o resumo automático de conversas longas (desabilitado por padrão)
+	ConversationSummarySettingKey = "ai_conversation_summary_enabled"
+
+	// conversationHistoryWindow é a quantidade de mensagens recentes enviadas ao modelo (3 pares de pergunta/resposta)
+	conversationHistoryWindow = 6
+	// conversationSummaryThreshold é o tamanho do histórico que dispara o resumo das mensagens mais antigas
+	conversationSummaryThreshold = 10
+)
+
+// conversationSummarizer resume mensagens antigas da conversa em uma nota de contexto compacta
+type conversationSummarizer interface {
+	SummarizeConversation(ctx context.Context, previousSummary string, messages []openai.ChatCompletionMessage) (string, error)
+}
+
+// openAIConversationSummarizer usa um modelo barato para gerar o resumo
+type openAIConversationSummarizer struct {
+	client *openai.Client
+}
+
+const conversationSummaryPrompt = `Você resume conversas de atendimento de uma loja via WhatsApp para que o assistente mantenha o contexto.
+
+Gere um resumo curto (no máximo 8 linhas, em tópicos) preservando APENAS fatos importantes:
+- Nome e preferências do cliente
+- Endereço escolhido ou informado
+- Produtos pesquisados, escolhidos ou adicionados ao carrinho (com quantidades)
+- Forma de pagamento, troco e pedidos especiais (ex: "sem cebola", "entregar após 18h")
+- Dúvidas ou pendências ainda não resolvidas
+
+Se houver um resumo anterior, incorpore-o. Não invente informações e não inclua saudações.`
+
+func (o *openAIConversationSummarizer) SummarizeConversation(ctx context.Context, previousSummary string, messages []openai.ChatCompletionMessage) (string, error) {
+	if o.client == nil {
+		return "", errors.New("cliente OpenAI não configurado")
+	}
+
+	resp, err := o.client.CreateChatCompletion(ctx, openai.ChatCompletionRequest{
+		Model: openai.GPT4oMini,
+		Messages: []openai.ChatCompletionMessage{
+			{Role: openai.ChatMessageRoleSystem, Content: conversationSummaryPrompt},
+			{Role: openai.ChatMessageRoleUser, Content: formatConversationForSummary(previousSummary, messages)},
+		},
+		MaxCompletionTokens: 400,
+		Temperature:         0,
+	})
+	if err != nil {
+		return "", fmt.Errorf("erro ao resumir conversa: %w", err)
+	}
+	if len(resp.Choices) == 0 {
+		return "", errors.New("resposta vazia ao resumir conversa")
+	}
+	return strings.TrimSpace(resp.Choices[0].Message.Content), nil
+}
+
+// formatConversationForSummary monta o texto enviado ao modelo de resumo
+func formatConversationForSummary(previousSummary string, messages []openai.ChatCompletionMessage) string {
+	var builder strings.Builder
+	if previousSummary != "" {
+		builder.WriteString("RESUMO ANTERIOR:\n")
+		builder.WriteString(previousSummary)
+		builder.WriteString("\n\n")
+	}
+
+	builder.WriteString("MENSAGENS:\n")
+	for _, message := range messages {
+		speaker := "Assistente"
+		if message.Role == openai.ChatMessageRoleUser {
+			speaker = "Cliente"
+		}
+		builder.WriteString(fmt.Sprintf("%s: %s\n", speaker, message.Content))
+	}
+	return builder.String()
+}
+
+// isConversationSummaryEnabled indica se o tenant habilitou o resumo de conversas longas
+func (s *AIService) isConversationSummaryEnabled(ctx context.Context, tenantID uuid.UUID) bool {
+	if s.settingsService == nil {
+		return false
+	}
+
+	setting, err := s.settingsService.GetSetting(ctx, tenantID, ConversationSummarySettingKey)
+	if err != nil || setting == nil || setting.SettingValue == nil {
+		return false
+	}
+
+	enabled, err := strconv.ParseBool(strings.TrimSpace(*setting.SettingValue))
+	return err == nil && enabled
+}
+
+// getConversationSummarizer retorna o summarizer configurado (OpenAI por padrão)
+func (s *AIService) getConversationSummarizer() conversationSummarizer {
+	if s.summarizer != nil {
+		return s.summarizer
+	}
+	return &openAIConversationSummarizer{client: s.client}
+}
+
+// summarizeConversationIfNeeded resume as mensagens fora da janela de contexto quando o histórico fica longo
+// (o chamador verifica se o tenant habilitou o resumo). Retorna o histórico a ser usado no prompt (compactado se o resumo foi gerado).
+func (s *AIService) summarizeConversationIfNeeded(ctx context.Context, tenantID uuid.UUID, customerPhone string, history []openai.ChatCompletionMessage) []openai.ChatCompletionMessage {
+	if len(history) < conversationSummaryThreshold {
+		return history
+	}
+
+	olderTurns := history[:len(history)-conversationHistoryWindow]
+	previousSummary := s.memoryManager.GetConversationSummary(tenantID, customerPhone)
+
+	summary, err := s.getConversationSummarizer().SummarizeConversation(ctx, previousSummary, olderTurns)
+	if err != nil || strings.TrimSpace(summary) == "" {
+		// Sem resumo, segue com a janela de mensagens recentes normalmente
+		log.Warn().Err(err).Str("tenant_id", tenantID.String()).Msg("⚠️ Não foi possível resumir a conversa")
+		return history
+	}
+
+	s.memoryManager.CompactConversationHistory(tenantID, customerPhone, strings.TrimSpace(summary), conversationHistoryWindow)
+	return history[len(history)-conversationHistoryWindow:]
+}
+
+// withConversationSummary inclui o resumo da conversa anterior no prompt do sistema
+func withConversationSummary(systemPrompt, summary string) string {
+	if strings.TrimSpace(summary) == "" {
+		return systemPrompt
+	}
+	return fmt.Sprintf("%s\n\n📝 RESUMO DA CONVERSA ATÉ AQUI (use para manter o contexto, sem repetir ao cliente):\n%s", systemPrompt, summary)
+}
@@ -0,0 +1,136 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/sashabaranov/go-openai"
+)
+
+// fakeConversationSummarizer registra as mensagens recebidas e retorna um resumo fixo
+type fakeConversationSummarizer struct {
+	summary  string
+	err      error
+	calls    int
+	received []openai.ChatCompletionMessage
+	previous string
+}
+
+func (f *fakeConversationSummarizer) SummarizeConversation(ctx context.Context, previousSummary string, messages []openai.ChatCompletionMessage) (string, error) {
+	f.calls++
+	f.previous = previousSummary
+	f.received = messages
+	return f.summary, f.err
+}
+
+// addConversationTestMessages preenche o histórico alternando mensagens do cliente e do assistente
+func addConversationTestMessages(s *AIService, tenantID uuid.UUID, phone string, messages int) {
+	for i := 0; i < messages; i++ {
+		role := openai.ChatMessageRoleUser
+		if i%2 == 1 {
+			role = openai.ChatMessageRoleAssistant
+		}
+		s.memoryManager.AddToConversationHistory(tenantID, phone, openai.ChatCompletionMessage{Role: role, Content: fmt.Sprintf("mensagem %d", i+1)})
+	}
+}
+
+func TestSummarizeConversationIfNeeded(t *testing.T) {
+	summarizer := &fakeConversationSummarizer{summary: "- Cliente quer entrega na Rua 1, sem cebola"}
+	tenantID, phone := uuid.New(), "5527999999999"
+	s, _ := newTestService(map[string]string{ConversationSummarySettingKey: "true"}, withOverride(func(s *AIService) { s.summarizer = summarizer }))
+	addConversationTestMessages(s, tenantID, phone, conversationSummaryThreshold)
+
+	if !s.isConversationSummaryEnabled(context.Background(), tenantID) {
+		t.Fatal("esperado resumo habilitado")
+	}
+
+	history := s.summarizeConversationIfNeeded(context.Background(), tenantID, phone, s.memoryManager.GetConversationHistory(tenantID, phone))
+
+	if summarizer.calls != 1 {
+		t.Fatalf("esperado 1 chamada ao summarizer, obtido %d", summarizer.calls)
+	}
+	if len(summarizer.received) != conversationSummaryThreshold-conversationHistoryWindow {
+		t.Errorf("esperado %d mensagens antigas resumidas, obtido %d", conversationSummaryThreshold-conversationHistoryWindow, len(summarizer.received))
+	}
+	if summarizer.received[0].Content != "mensagem 1" {
+		t.Errorf("esperado resumo a partir da mensagem mais antiga, obtido %q", summarizer.received[0].Content)
+	}
+	if len(history) != conversationHistoryWindow || history[0].Content != "mensagem 5" {
+		t.Errorf("esperado histórico com as %d mensagens recentes, obtido %+v", conversationHistoryWindow, history)
+	}
+	if stored := s.memoryManager.GetConversationHistory(tenantID, phone); len(stored) != conversationHistoryWindow {
+		t.Errorf("esperado histórico compactado na memória com %d mensagens, obtido %d", conversationHistoryWindow, len(stored))
+	}
+	if got := s.memoryManager.GetConversationSummary(tenantID, phone); got != summarizer.summary {
+		t.Errorf("esperado resumo salvo %q, obtido %q", summarizer.summary, got)
+	}
+
+	prompt := withConversationSummary("PROMPT", s.memoryManager.GetConversationSummary(tenantID, phone))
+	if !strings.Contains(prompt, "RESUMO DA CONVERSA") || !strings.Contains(prompt, "sem cebola") {
+		t.Errorf("esperado resumo incluído no prompt, obtido:\n%s", prompt)
+	}
+}
+
+func TestSummarizeConversationIncludesPreviousSummary(t *testing.T) {
+	summarizer := &fakeConversationSummarizer{summary: "- resumo novo"}
+	tenantID, phone := uuid.New(), "5527999999999"
+	s, _ := newTestService(map[string]string{ConversationSummarySettingKey: "true"}, withOverride(func(s *AIService) { s.summarizer = summarizer }))
+	addConversationTestMessages(s, tenantID, phone, conversationSummaryThreshold)
+	s.memoryManager.CompactConversationHistory(tenantID, phone, "- resumo antigo", conversationSummaryThreshold)
+
+	s.summarizeConversationIfNeeded(context.Background(), tenantID, phone, s.memoryManager.GetConversationHistory(tenantID, phone))
+
+	if summarizer.previous != "- resumo antigo" {
+		t.Errorf("esperado resumo anterior repassado ao summarizer, obtido %q", summarizer.previous)
+	}
+	if got := s.memoryManager.GetConversationSummary(tenantID, phone); got != "- resumo novo" {
+		t.Errorf("esperado resumo atualizado, obtido %q", got)
+	}
+}
+
+func TestSummarizeConversationNotTriggered(t *testing.T) {
+	tests := []struct {
+		name     string
+		enabled  string
+		messages int
+		err      error
+	}{
+		{"desabilitado", "false", conversationSummaryThreshold, nil},
+		{"histórico curto", "true", conversationSummaryThreshold - 1, nil},
+		{"erro no summarizer", "true", conversationSummaryThreshold, errors.New("timeout")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			summarizer := &fakeConversationSummarizer{summary: "- resumo", err: tt.err}
+			tenantID, phone := uuid.New(), "5527999999999"
+			s, _ := newTestService(map[string]string{ConversationSummarySettingKey: tt.enabled}, withOverride(func(s *AIService) { s.summarizer = summarizer }))
+			addConversationTestMessages(s, tenantID, phone, tt.messages)
+
+			history := s.memoryManager.GetConversationHistory(tenantID, phone)
+			if s.isConversationSummaryEnabled(context.Background(), tenantID) {
+				history = s.summarizeConversationIfNeeded(context.Background(), tenantID, phone, history)
+			}
+
+			if tt.err == nil && summarizer.calls != 0 {
+				t.Errorf("summarizer não deveria ser chamado, obtido %d chamadas", summarizer.calls)
+			}
+			if len(history) != tt.messages {
+				t.Errorf("esperado histórico inalterado com %d mensagens, obtido %d", tt.messages, len(history))
+			}
+			if got := s.memoryManager.GetConversationSummary(tenantID, phone); got != "" {
+				t.Errorf("nenhum resumo deveria ser salvo, obtido %q", got)
+			}
+		})
+	}
+}
+
+func TestWithConversationSummaryEmpty(t *testing.T) {
+	if got := withConversationSummary("PROMPT", "  "); got != "PROMPT" {
+		t.Errorf("esperado prompt inalterado, obtido %q", got)
+	}
+}
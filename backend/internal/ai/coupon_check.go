@@ -0,0 +1,142 @@
+package ai
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"iafarma/internal/utils"
+	"iafarma/pkg/models"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+)
+
+// couponEvaluation é o resultado da verificação de um cupom contra o subtotal do carrinho
+type couponEvaluation struct {
+	Valid    bool
+	Discount float64
+	// Problems lista as condições que impedem o uso do cupom agora
+	Problems []string
+	// MissingAmount é quanto falta para atingir o pedido mínimo do cupom
+	MissingAmount float64
+}
+
+// parseCouponAmount converte os valores do cupom (texto) para número
+func parseCouponAmount(value string) float64 {
+	amount, err := strconv.ParseFloat(strings.Replace(strings.TrimSpace(value), ",", ".", 1), 64)
+	if err != nil || amount < 0 {
+		return 0
+	}
+	return amount
+}
+
+// couponDiscount calcula o desconto do cupom sobre o subtotal (nunca maior que o próprio subtotal)
+func couponDiscount(coupon *models.Coupon, subtotal float64) float64 {
+	value := parseCouponAmount(coupon.Value)
+	discount := value
+	if coupon.Type == "percentage" {
+		discount = subtotal * math.Min(value, 100) / 100
+	}
+	return utils.RoundMoney(math.Min(discount, subtotal))
+}
+
+// evaluateCoupon verifica, sem aplicar, se o cupom vale para o subtotal informado
+func evaluateCoupon(coupon *models.Coupon, subtotal float64, now time.Time) couponEvaluation {
+	var evaluation couponEvaluation
+
+	if !coupon.IsActive {
+		evaluation.Problems = append(evaluation.Problems, "o cupom não está mais ativo")
+	}
+	if coupon.ExpiresAt != nil && !now.Before(*coupon.ExpiresAt) {
+		evaluation.Problems = append(evaluation.Problems,
+			fmt.Sprintf("o cupom expirou em %s", coupon.ExpiresAt.In(storeLocation).Format("02/01/2006")))
+	}
+	if coupon.UsageLimit != nil && coupon.UsageCount >= *coupon.UsageLimit {
+		evaluation.Problems = append(evaluation.Problems, "o limite de usos do cupom foi atingido")
+	}
+	if minimum := parseCouponAmount(coupon.MinimumOrderAmount); minimum > 0 && subtotal < minimum {
+		evaluation.MissingAmount = (utils.CentsFromFloat(minimum) - utils.CentsFromFloat(subtotal)).Float()
+		evaluation.Problems = append(evaluation.Problems,
+			fmt.Sprintf("o pedido mínimo é de R$ %s (faltam R$ %s)",
+				formatCurrency(utils.FormatMoney(minimum)), formatCurrency(utils.FormatMoney(evaluation.MissingAmount))))
+	}
+
+	evaluation.Discount = couponDiscount(coupon, subtotal)
+	evaluation.Valid = len(evaluation.Problems) == 0
+	return evaluation
+}
+
+// formatCouponEvaluation explica ao cliente se o cupom vale e quanto ele economizaria
+func formatCouponEvaluation(coupon *models.Coupon, evaluation couponEvaluation, subtotal float64) string {
+	code := strings.ToUpper(coupon.Code)
+	benefit := fmt.Sprintf("R$ %s de desconto", formatCurrency(utils.FormatMoney(parseCouponAmount(coupon.Value))))
+	if coupon.Type == "percentage" {
+		benefit = fmt.Sprintf("%s%% de desconto", strings.TrimSuffix(strings.TrimSuffix(coupon.Value, ".00"), ",00"))
+	}
+
+	if !evaluation.Valid {
+		var result strings.Builder
+		result.WriteString(fmt.Sprintf("⚠️ O cupom **%s** (%s) não pode ser usado no seu carrinho agora:\n", code, benefit))
+		for _, problem := range evaluation.Problems {
+			result.WriteString("• " + problem + "\n")
+		}
+		if evaluation.MissingAmount > 0 && len(evaluation.Problems) == 1 {
+			result.WriteString("\n💡 Adicionando mais itens ao carrinho o cupom passa a valer!")
+		}
+		return strings.TrimRight(result.String(), "\n")
+	}
+
+	subtotalCents := utils.CentsFromFloat(subtotal)
+	discount := utils.CentsFromFloat(evaluation.Discount)
+	return fmt.Sprintf("✅ O cupom **%s** é válido para o seu carrinho! (%s)\n\n🧾 Subtotal: R$ %s\n🏷️ Desconto: **R$ %s**\n💰 Ficaria: **R$ %s**\n\nℹ️ O cupom ainda não foi aplicado.",
+		code, benefit,
+		formatCurrency(subtotalCents.String()),
+		formatCurrency(discount.String()),
+		formatCurrency((subtotalCents - discount).String()))
+}
+
+// handleVerificarCupom informa se um cupom vale para o carrinho atual, sem aplicá-lo
+func (s *AIService) handleVerificarCupom(tenantID, customerID uuid.UUID, args map[string]interface{}) (string, error) {
+	code, _ := args["codigo"].(string)
+	code = strings.TrimSpace(code)
+	if code == "" {
+		return "❌ Informe o código do cupom que você quer verificar.", nil
+	}
+	if s.couponService == nil {
+		return "❌ No momento não consigo verificar cupons.", nil
+	}
+
+	coupon, err := s.couponService.GetCouponByCode(tenantID, code)
+	if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && coupon == nil) {
+		return fmt.Sprintf("❌ Não encontrei o cupom **%s**. Confira se o código foi digitado corretamente.", strings.ToUpper(code)), nil
+	}
+	if err != nil {
+		return "❌ Erro ao verificar o cupom.", err
+	}
+
+	subtotal := 0.0
+	if cart, err := s.cartService.GetOrCreateActiveCart(tenantID, customerID); err == nil {
+		if cartWithItems, err := s.cartService.GetCartWithItems(cart.ID, tenantID); err == nil {
+			subtotal = cartSubtotal(cartWithItems)
+		}
+	}
+
+	evaluation := evaluateCoupon(coupon, subtotal, time.Now())
+	log.Info().
+		Str("tenant_id", tenantID.String()).
+		Str("coupon_code", coupon.Code).
+		Bool("valid", evaluation.Valid).
+		Float64("subtotal", subtotal).
+		Float64("discount", evaluation.Discount).
+		Msg("🏷️ Cupom verificado (sem aplicar)")
+
+	if subtotal == 0 && evaluation.Valid {
+		return fmt.Sprintf("✅ O cupom **%s** é válido! Adicione produtos ao carrinho para ver quanto ele desconta.", strings.ToUpper(coupon.Code)), nil
+	}
+	return formatCouponEvaluation(coupon, evaluation, subtotal), nil
+}
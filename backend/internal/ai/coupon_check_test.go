@@ -0,0 +1,97 @@
+package ai
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"iafarma/pkg/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// fakeCouponService devolve os cupons cadastrados pelo código
+type fakeCouponService struct {
+	coupons map[string]*models.Coupon
+}
+
+func (f *fakeCouponService) GetCouponByCode(tenantID uuid.UUID, code string) (*models.Coupon, error) {
+	if coupon, ok := f.coupons[strings.ToUpper(code)]; ok {
+		return coupon, nil
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func TestEvaluateCoupon(t *testing.T) {
+	now := time.Date(2024, time.June, 10, 12, 0, 0, 0, time.UTC)
+	yesterday := now.AddDate(0, 0, -1)
+	nextWeek := now.AddDate(0, 0, 7)
+	limit := 5
+
+	tests := []struct {
+		name     string
+		coupon   models.Coupon
+		subtotal float64
+		valid    bool
+		discount float64
+		problem  string
+	}{
+		{"percentual válido", models.Coupon{Type: "percentage", Value: "10", IsActive: true, ExpiresAt: &nextWeek}, 200, true, 20, ""},
+		{"valor fixo válido", models.Coupon{Type: "fixed_amount", Value: "15.00", IsActive: true}, 80, true, 15, ""},
+		{"valor fixo limitado ao subtotal", models.Coupon{Type: "fixed_amount", Value: "50", IsActive: true}, 30, true, 30, ""},
+		{"expirado", models.Coupon{Type: "percentage", Value: "10", IsActive: true, ExpiresAt: &yesterday}, 200, false, 20, "expirou"},
+		{"pedido mínimo não atingido", models.Coupon{Type: "percentage", Value: "10", IsActive: true, MinimumOrderAmount: "100"}, 60, false, 6, "faltam R$ 40,00"},
+		{"inativo", models.Coupon{Type: "percentage", Value: "10", IsActive: false}, 200, false, 20, "não está mais ativo"},
+		{"limite de usos", models.Coupon{Type: "percentage", Value: "10", IsActive: true, UsageLimit: &limit, UsageCount: 5}, 200, false, 20, "limite de usos"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			evaluation := evaluateCoupon(&tt.coupon, tt.subtotal, now)
+			if evaluation.Valid != tt.valid || evaluation.Discount != tt.discount {
+				t.Errorf("esperado válido=%v desconto=%.2f, obtido %+v", tt.valid, tt.discount, evaluation)
+			}
+			if tt.problem != "" && !strings.Contains(strings.Join(evaluation.Problems, "; "), tt.problem) {
+				t.Errorf("esperado problema %q, obtido %v", tt.problem, evaluation.Problems)
+			}
+		})
+	}
+}
+
+func TestVerificarCupomDoesNotApply(t *testing.T) {
+	expired := time.Now().AddDate(0, 0, -2)
+	coupons := &fakeCouponService{coupons: map[string]*models.Coupon{
+		"DESC10": {Code: "DESC10", Type: "percentage", Value: "10", IsActive: true},
+		"VELHO":  {Code: "VELHO", Type: "percentage", Value: "10", IsActive: true, ExpiresAt: &expired},
+		"MINIMO": {Code: "MINIMO", Type: "fixed_amount", Value: "20", IsActive: true, MinimumOrderAmount: "300"},
+	}}
+
+	tests := []struct {
+		code     string
+		expected string
+	}{
+		{"desc10", "Desconto: **R$ 10,00**"},
+		{"VELHO", "expirou"},
+		{"MINIMO", "faltam R$ 200,00"},
+		{"NAOEXISTE", "Não encontrei o cupom **NAOEXISTE**"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.code, func(t *testing.T) {
+			cart := &fakeCartService{cart: &models.Cart{Items: []models.CartItem{{Quantity: 2, Price: "50.00"}}}}
+			s := &AIService{cartService: cart, couponService: coupons}
+
+			result, err := s.handleVerificarCupom(uuid.New(), uuid.New(), map[string]interface{}{"codigo": tt.code})
+			if err != nil {
+				t.Fatalf("erro inesperado: %v", err)
+			}
+			if !strings.Contains(result, tt.expected) {
+				t.Errorf("esperado %q, obtido:\n%s", tt.expected, result)
+			}
+			if cart.cart.DiscountCode != "" {
+				t.Errorf("a verificação não deve aplicar o cupom ao carrinho")
+			}
+		})
+	}
+}
@@ -0,0 +1,351 @@
+package ai
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"syscall"
+	"time"
+
+	"iafarma/pkg/models"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+	"github.com/sashabaranov/go-openai"
+)
+
+const (
+	// MaxCustomToolsPerTenant limita as ferramentas próprias enviadas ao GPT em cada chamada
+	MaxCustomToolsPerTenant = 20
+	// CustomToolSignatureHeader leva a assinatura HMAC-SHA256 do corpo enviado ao webhook
+	CustomToolSignatureHeader = "X-IAFarma-Signature"
+
+	defaultCustomToolTimeout     = 5 * time.Second
+	maxCustomToolTimeout         = 15 * time.Second
+	maxCustomToolResponseBytes   = 4 * 1024
+	maxCustomToolDescriptionSize = 1024
+)
+
+// customToolNamePattern define os nomes aceitos pela OpenAI para ferramentas (sem espaços ou acentos)
+var customToolNamePattern = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9_]{2,63}$`)
+
+// IsBuiltinToolName indica se o nome já pertence a uma ferramenta nativa do assistente
+func IsBuiltinToolName(name string) bool {
+	for _, tool := range (&AIService{}).getAvailableTools() {
+		if tool.Function != nil && strings.EqualFold(tool.Function.Name, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateCustomToolDefinition confere a definição de uma ferramenta própria do tenant antes de salvá-la
+func ValidateCustomToolDefinition(name, description, parameters, webhookURL string) error {
+	if !customToolNamePattern.MatchString(name) {
+		return errors.New("name must start with a letter and contain only letters, digits and underscores (3-64 characters)")
+	}
+	if IsBuiltinToolName(name) {
+		return fmt.Errorf("name %s is reserved by a built-in tool", name)
+	}
+	if strings.TrimSpace(description) == "" {
+		return errors.New("description is required")
+	}
+	if len(description) > maxCustomToolDescriptionSize {
+		return fmt.Errorf("description must have at most %d characters", maxCustomToolDescriptionSize)
+	}
+	if _, err := parseCustomToolParameters(parameters); err != nil {
+		return err
+	}
+	return validateCustomToolWebhookURL(webhookURL)
+}
+
+// parseCustomToolParameters interpreta o JSON schema dos argumentos (vazio = ferramenta sem argumentos)
+func parseCustomToolParameters(parameters string) (map[string]interface{}, error) {
+	if strings.TrimSpace(parameters) == "" {
+		return map[string]interface{}{"type": "object", "properties": map[string]interface{}{}}, nil
+	}
+
+	var schema map[string]interface{}
+	if err := json.Unmarshal([]byte(parameters), &schema); err != nil {
+		return nil, errors.New("parameters must be a valid JSON object")
+	}
+	if schemaType, _ := schema["type"].(string); schemaType != "object" {
+		return nil, errors.New(`parameters must be a JSON schema with "type": "object"`)
+	}
+	if properties, present := schema["properties"]; present {
+		if _, ok := properties.(map[string]interface{}); !ok {
+			return nil, errors.New("parameters.properties must be an object")
+		}
+	} else {
+		schema["properties"] = map[string]interface{}{}
+	}
+
+	// validateToolArguments espera a lista de obrigatórios como []string
+	if rawRequired, present := schema["required"]; present {
+		list, ok := rawRequired.([]interface{})
+		if !ok {
+			return nil, errors.New("parameters.required must be an array of strings")
+		}
+		required := make([]string, 0, len(list))
+		for _, item := range list {
+			field, ok := item.(string)
+			if !ok {
+				return nil, errors.New("parameters.required must be an array of strings")
+			}
+			required = append(required, field)
+		}
+		schema["required"] = required
+	}
+
+	return schema, nil
+}
+
+// validateCustomToolWebhookURL exige HTTPS e recusa destinos internos informados diretamente no link
+func validateCustomToolWebhookURL(webhookURL string) error {
+	parsed, err := url.Parse(strings.TrimSpace(webhookURL))
+	if err != nil || parsed.Host == "" {
+		return errors.New("webhook_url must be a valid URL")
+	}
+	if parsed.Scheme != "https" {
+		return errors.New("webhook_url must use https")
+	}
+	if parsed.User != nil {
+		return errors.New("webhook_url must not contain credentials")
+	}
+
+	host := strings.ToLower(parsed.Hostname())
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") || strings.HasSuffix(host, ".internal") {
+		return errors.New("webhook_url must point to a public host")
+	}
+	if ip := net.ParseIP(host); ip != nil && !isPublicIP(ip) {
+		return errors.New("webhook_url must point to a public host")
+	}
+	return nil
+}
+
+// isPublicIP indica se o endereço pode ser acessado pelos webhooks (sem rede interna, loopback ou metadata)
+func isPublicIP(ip net.IP) bool {
+	return !(ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsMulticast())
+}
+
+// newCustomToolHTTPClient cria o cliente isolado usado nos webhooks: só conecta em IPs públicos
+// (inclusive após a resolução de DNS), ignora proxies do ambiente e não segue redirecionamentos
+func newCustomToolHTTPClient() *http.Client {
+	dialer := &net.Dialer{
+		Timeout: defaultCustomToolTimeout,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			ip := net.ParseIP(host)
+			if ip == nil || !isPublicIP(ip) {
+				return fmt.Errorf("custom tool webhook address %s is not allowed", host)
+			}
+			return nil
+		},
+	}
+
+	return &http.Client{
+		Transport: &http.Transport{
+			Proxy:               nil,
+			DialContext:         dialer.DialContext,
+			TLSHandshakeTimeout: defaultCustomToolTimeout,
+		},
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// customToolFunction converte a definição salva no formato de ferramenta da OpenAI
+func customToolFunction(tool models.TenantCustomTool) (openai.Tool, error) {
+	parameters, err := parseCustomToolParameters(tool.Parameters)
+	if err != nil {
+		return openai.Tool{}, err
+	}
+	return openai.Tool{
+		Type: openai.ToolTypeFunction,
+		Function: &openai.FunctionDefinition{
+			Name:        tool.Name,
+			Description: tool.Description,
+			Parameters:  parameters,
+		},
+	}, nil
+}
+
+// getActiveCustomTools retorna as ferramentas próprias válidas do tenant (no máximo MaxCustomToolsPerTenant)
+func (s *AIService) getActiveCustomTools(tenantID uuid.UUID) []models.TenantCustomTool {
+	if s.customToolService == nil {
+		return nil
+	}
+
+	tools, err := s.customToolService.GetActiveCustomTools(tenantID)
+	if err != nil {
+		log.Error().Err(err).Str("tenant_id", tenantID.String()).Msg("Erro ao buscar ferramentas próprias do tenant")
+		return nil
+	}
+
+	valid := make([]models.TenantCustomTool, 0, len(tools))
+	for _, tool := range tools {
+		if len(valid) == MaxCustomToolsPerTenant {
+			break
+		}
+		if err := ValidateCustomToolDefinition(tool.Name, tool.Description, tool.Parameters, tool.WebhookURL); err != nil {
+			log.Warn().
+				Err(err).
+				Str("tenant_id", tenantID.String()).
+				Str("tool_name", tool.Name).
+				Msg("⚠️ Ferramenta própria inválida ignorada")
+			continue
+		}
+		valid = append(valid, tool)
+	}
+	return valid
+}
+
+// getAvailableToolsForTenant soma às ferramentas nativas as ferramentas próprias cadastradas pelo tenant
+func (s *AIService) getAvailableToolsForTenant(tenantID uuid.UUID) []openai.Tool {
+	tools := s.getAvailableTools()
+	for _, customTool := range s.getActiveCustomTools(tenantID) {
+		tool, err := customToolFunction(customTool)
+		if err != nil {
+			continue
+		}
+		tools = append(tools, tool)
+	}
+	return tools
+}
+
+// findCustomTool localiza uma ferramenta própria ativa do tenant pelo nome chamado pelo GPT
+func (s *AIService) findCustomTool(tenantID uuid.UUID, toolName string) (*models.TenantCustomTool, bool) {
+	for _, tool := range s.getActiveCustomTools(tenantID) {
+		if tool.Name == toolName {
+			return &tool, true
+		}
+	}
+	return nil, false
+}
+
+// customToolPayload é o corpo enviado ao webhook do tenant
+type customToolPayload struct {
+	Tool          string                 `json:"tool"`
+	TenantID      string                 `json:"tenant_id"`
+	CustomerID    string                 `json:"customer_id"`
+	CustomerPhone string                 `json:"customer_phone"`
+	Arguments     map[string]interface{} `json:"arguments"`
+}
+
+// signCustomToolPayload assina o corpo com o segredo da ferramenta para o tenant validar a origem
+func signCustomToolPayload(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// customToolTimeout limita o tempo de espera configurado pelo tenant
+func customToolTimeout(tool *models.TenantCustomTool) time.Duration {
+	if tool.TimeoutSeconds <= 0 {
+		return defaultCustomToolTimeout
+	}
+	timeout := time.Duration(tool.TimeoutSeconds) * time.Second
+	if timeout > maxCustomToolTimeout {
+		return maxCustomToolTimeout
+	}
+	return timeout
+}
+
+// parseCustomToolResponse extrai o texto da resposta do webhook ({"resposta": "..."} ou texto puro)
+func parseCustomToolResponse(body []byte) string {
+	text := strings.TrimSpace(string(body))
+
+	var structured map[string]interface{}
+	if json.Unmarshal(body, &structured) == nil {
+		for _, key := range []string{"resposta", "message", "text"} {
+			if value, ok := structured[key].(string); ok && strings.TrimSpace(value) != "" {
+				return strings.TrimSpace(value)
+			}
+		}
+	}
+
+	if text == "" {
+		return "✅ Pronto! Sua solicitação foi registrada."
+	}
+	return text
+}
+
+// executeCustomTool envia os argumentos ao webhook do tenant e devolve a resposta para o cliente
+func (s *AIService) executeCustomTool(ctx context.Context, tenantID, customerID uuid.UUID, customerPhone string, tool *models.TenantCustomTool, args map[string]interface{}) (string, error) {
+	failure := "❌ Não consegui concluir essa solicitação agora. Tente novamente em instantes."
+
+	if err := validateCustomToolWebhookURL(tool.WebhookURL); err != nil {
+		return failure, fmt.Errorf("custom tool %s: %w", tool.Name, err)
+	}
+
+	body, err := json.Marshal(customToolPayload{
+		Tool:          tool.Name,
+		TenantID:      tenantID.String(),
+		CustomerID:    customerID.String(),
+		CustomerPhone: customerPhone,
+		Arguments:     args,
+	})
+	if err != nil {
+		return failure, fmt.Errorf("custom tool %s: failed to encode payload: %w", tool.Name, err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, customToolTimeout(tool))
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, tool.WebhookURL, bytes.NewReader(body))
+	if err != nil {
+		return failure, fmt.Errorf("custom tool %s: failed to build request: %w", tool.Name, err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if tool.Secret != "" {
+		req.Header.Set(CustomToolSignatureHeader, signCustomToolPayload(tool.Secret, body))
+	}
+
+	client := s.customToolClient
+	if client == nil {
+		client = newCustomToolHTTPClient()
+	}
+
+	startedAt := time.Now()
+	resp, err := client.Do(req)
+	if err != nil {
+		return failure, fmt.Errorf("custom tool %s: webhook request failed: %w", tool.Name, err)
+	}
+	defer resp.Body.Close()
+
+	responseBody, err := io.ReadAll(io.LimitReader(resp.Body, maxCustomToolResponseBytes+1))
+	if err != nil {
+		return failure, fmt.Errorf("custom tool %s: failed to read webhook response: %w", tool.Name, err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return failure, fmt.Errorf("custom tool %s: webhook returned status %d", tool.Name, resp.StatusCode)
+	}
+	if len(responseBody) > maxCustomToolResponseBytes {
+		return failure, fmt.Errorf("custom tool %s: webhook response exceeds %d bytes", tool.Name, maxCustomToolResponseBytes)
+	}
+
+	log.Info().
+		Str("tenant_id", tenantID.String()).
+		Str("tool_name", tool.Name).
+		Int("status", resp.StatusCode).
+		Dur("duration", time.Since(startedAt)).
+		Msg("🔌 Ferramenta própria do tenant executada")
+
+	return parseCustomToolResponse(responseBody), nil
+}
@@ -0,0 +1,48 @@
+package ai
+
+import (
+	"strings"
+	"unicode"
+)
+
+// placeholderCustomerNames são nomes que não identificam o cliente: push-names padrão do WhatsApp
+// e textos que a própria IA às vezes envia quando o cliente ainda não disse o nome
+var placeholderCustomerNames = map[string]bool{
+	"whatsapp user":            true,
+	"whatsapp":                 true,
+	"usuário whatsapp":         true,
+	"usuario whatsapp":         true,
+	"usuário do whatsapp":      true,
+	"usuario do whatsapp":      true,
+	"user":                     true,
+	"unknown":                  true,
+	"cliente":                  true,
+	"cliente não identificado": true,
+	"cliente nao identificado": true,
+	"sem nome":                 true,
+	"nome":                     true,
+	"null":                     true,
+	"undefined":                true,
+	"n/a":                      true,
+}
+
+// normalizeCustomerName remove espaços extras do nome informado (ex: "  Maria   Silva " vira "Maria Silva")
+func normalizeCustomerName(name string) string {
+	return strings.Join(strings.Fields(name), " ")
+}
+
+// isValidCustomerName indica se o nome identifica o cliente: não vazio, com letras
+// (não só o telefone ou emojis) e diferente dos nomes genéricos conhecidos
+func isValidCustomerName(name string) bool {
+	name = normalizeCustomerName(name)
+	if name == "" || placeholderCustomerNames[strings.ToLower(name)] {
+		return false
+	}
+
+	for _, r := range name {
+		if unicode.IsLetter(r) {
+			return true
+		}
+	}
+	return false
+}
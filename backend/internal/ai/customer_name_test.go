@@ -0,0 +1,72 @@
+package ai
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"iafarma/pkg/models"
+
+	"github.com/google/uuid"
+)
+
+func TestIsValidCustomerName(t *testing.T) {
+	tests := []struct {
+		name     string
+		esperado bool
+	}{
+		{"", false},
+		{"   ", false},
+		{"\t\n", false},
+		{"WhatsApp User", false},
+		{"  whatsapp   user ", false},
+		{"Cliente não identificado", false},
+		{"5527999999999", false},
+		{"😊", false},
+		{"Maria", true},
+		{"  João   da Silva ", true},
+		{"Ana Cliente", true},
+	}
+
+	for _, tt := range tests {
+		if obtido := isValidCustomerName(tt.name); obtido != tt.esperado {
+			t.Errorf("isValidCustomerName(%q): esperado %v, obtido %v", tt.name, tt.esperado, obtido)
+		}
+	}
+}
+
+func TestNormalizeCustomerName(t *testing.T) {
+	if obtido := normalizeCustomerName("  João \t da   Silva\n"); obtido != "João da Silva" {
+		t.Errorf("esperado nome sem espaços extras, obtido %q", obtido)
+	}
+}
+
+func TestCheckoutPedeNomeQuandoNomeInvalido(t *testing.T) {
+	for _, nome := range []string{"   ", "WhatsApp User"} {
+		s, _ := newTestService(map[string]string{AllowPickupSettingKey: "true"}, withCheckout(newPickupTestCart(true)))
+		s.customerService = &fakeCustomerService{customer: &models.Customer{Name: nome}}
+
+		checkout, err := s.handleCheckout(context.Background(), uuid.New(), uuid.New(), "5561999999999")
+		if err != nil {
+			t.Fatalf("erro inesperado: %v", err)
+		}
+		if !strings.Contains(checkout, "me informe seu nome completo") {
+			t.Errorf("nome %q: esperado pedido do nome completo:\n%s", nome, checkout)
+		}
+		if strings.Contains(checkout, "Av. Central, 100") {
+			t.Errorf("nome %q: checkout não deveria seguir para a confirmação:\n%s", nome, checkout)
+		}
+	}
+}
+
+func TestAtualizarCadastroIgnoraNomeGenerico(t *testing.T) {
+	s := &AIService{}
+
+	obtido, err := s.handleAtualizarCadastro(context.Background(), uuid.New(), uuid.New(), "5561999999999", map[string]interface{}{"nome": "  WhatsApp  User "})
+	if err != nil {
+		t.Fatalf("erro inesperado: %v", err)
+	}
+	if !strings.Contains(obtido, "me informe seu nome completo") {
+		t.Errorf("esperado pedido do nome real:\n%s", obtido)
+	}
+}
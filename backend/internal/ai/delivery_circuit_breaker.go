@@ -0,0 +1,255 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/metric"
+)
+
+const (
+	// DeliveryFallbackModeSettingKey define o que fazer no checkout quando a validação de entrega está indisponível
+	DeliveryFallbackModeSettingKey = "delivery_validation_fallback"
+
+	// DeliveryFallbackBlock mantém o checkout bloqueado (com mensagem amigável) até o serviço voltar
+	DeliveryFallbackBlock = "block"
+	// DeliveryFallbackManual registra o pedido e a entrega é confirmada manualmente pela loja
+	DeliveryFallbackManual = "manual_confirmation"
+
+	defaultDeliveryFallbackMode = DeliveryFallbackBlock
+
+	deliveryValidationMaxAttempts   = 3
+	deliveryValidationRetryBackoff  = 200 * time.Millisecond
+	deliveryBreakerFailureThreshold = 5
+	deliveryBreakerCooldown         = 30 * time.Second
+)
+
+// ErrDeliveryCircuitOpen indica que o circuit breaker está aberto e a validação de entrega não foi tentada
+var ErrDeliveryCircuitOpen = errors.New("serviço de validação de entrega temporariamente indisponível")
+
+// circuitState representa o estado do circuit breaker
+type circuitState int
+
+const (
+	circuitClosed circuitState = iota
+	circuitOpen
+	circuitHalfOpen
+)
+
+func (c circuitState) String() string {
+	switch c {
+	case circuitOpen:
+		return "open"
+	case circuitHalfOpen:
+		return "half-open"
+	default:
+		return "closed"
+	}
+}
+
+// circuitBreaker abre após falhas consecutivas, bloqueia chamadas durante o cooldown
+// e libera uma única chamada de teste (half-open) antes de fechar novamente
+type circuitBreaker struct {
+	mu               sync.Mutex
+	state            circuitState
+	failures         int
+	openedAt         time.Time
+	probeInFlight    bool
+	failureThreshold int
+	cooldown         time.Duration
+	now              func() time.Time
+	onOpen           func()
+}
+
+func newCircuitBreaker(failureThreshold int, cooldown time.Duration, onOpen func()) *circuitBreaker {
+	return &circuitBreaker{
+		failureThreshold: failureThreshold,
+		cooldown:         cooldown,
+		now:              time.Now,
+		onOpen:           onOpen,
+	}
+}
+
+// State retorna o estado atual, considerando a expiração do cooldown
+func (b *circuitBreaker) State() circuitState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == circuitOpen && b.now().Sub(b.openedAt) >= b.cooldown {
+		return circuitHalfOpen
+	}
+	return b.state
+}
+
+// Allow indica se a chamada pode ser feita. Após o cooldown, apenas uma chamada de teste é liberada.
+func (b *circuitBreaker) Allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case circuitOpen:
+		if b.now().Sub(b.openedAt) < b.cooldown {
+			return false
+		}
+		b.state = circuitHalfOpen
+		b.probeInFlight = true
+		return true
+	case circuitHalfOpen:
+		if b.probeInFlight {
+			return false
+		}
+		b.probeInFlight = true
+		return true
+	default:
+		return true
+	}
+}
+
+// RecordSuccess fecha o circuito e zera o contador de falhas
+func (b *circuitBreaker) RecordSuccess() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.state = circuitClosed
+	b.failures = 0
+	b.probeInFlight = false
+}
+
+// RecordFailure contabiliza a falha e abre o circuito ao atingir o limite (ou se a chamada de teste falhar)
+func (b *circuitBreaker) RecordFailure() {
+	b.mu.Lock()
+
+	b.failures++
+	b.probeInFlight = false
+	shouldOpen := b.state == circuitHalfOpen || (b.state == circuitClosed && b.failures >= b.failureThreshold)
+	if shouldOpen {
+		b.state = circuitOpen
+		b.openedAt = b.now()
+	}
+	onOpen := b.onOpen
+	b.mu.Unlock()
+
+	if shouldOpen && onOpen != nil {
+		onOpen()
+	}
+}
+
+// ResilientDeliveryService adiciona retry e circuit breaker à validação de entrega
+type ResilientDeliveryService struct {
+	inner       DeliveryServiceInterface
+	breaker     *circuitBreaker
+	maxAttempts int
+	backoff     time.Duration
+	sleep       func(time.Duration)
+}
+
+// NewResilientDeliveryService envolve o serviço de entrega com retry e circuit breaker
+func NewResilientDeliveryService(inner DeliveryServiceInterface) *ResilientDeliveryService {
+	return &ResilientDeliveryService{
+		inner:       inner,
+		breaker:     newCircuitBreaker(deliveryBreakerFailureThreshold, deliveryBreakerCooldown, recordDeliveryBreakerOpened),
+		maxAttempts: deliveryValidationMaxAttempts,
+		backoff:     deliveryValidationRetryBackoff,
+		sleep:       time.Sleep,
+	}
+}
+
+// ValidateDeliveryAddress tenta validar o endereço com retry; com o circuito aberto retorna ErrDeliveryCircuitOpen sem chamar o serviço
+func (r *ResilientDeliveryService) ValidateDeliveryAddress(tenantID uuid.UUID, street, number, neighborhood, city, state string) (*DeliveryValidationResult, error) {
+	if !r.breaker.Allow() {
+		return nil, ErrDeliveryCircuitOpen
+	}
+
+	var lastErr error
+	for attempt := 1; attempt <= r.maxAttempts; attempt++ {
+		result, err := r.inner.ValidateDeliveryAddress(tenantID, street, number, neighborhood, city, state)
+		if err == nil {
+			r.breaker.RecordSuccess()
+			return result, nil
+		}
+		lastErr = err
+
+		log.Warn().
+			Err(err).
+			Str("tenant_id", tenantID.String()).
+			Int("attempt", attempt).
+			Int("max_attempts", r.maxAttempts).
+			Msg("🔁 Falha ao validar endereço de entrega")
+
+		// Em half-open só há uma tentativa de teste
+		if attempt < r.maxAttempts && r.breaker.State() == circuitClosed {
+			r.sleep(r.backoff * time.Duration(attempt))
+			continue
+		}
+		break
+	}
+
+	r.breaker.RecordFailure()
+	return nil, fmt.Errorf("validação de entrega falhou: %w", lastErr)
+}
+
+// GetStoreLocation repassa a consulta ao serviço original
+func (r *ResilientDeliveryService) GetStoreLocation(tenantID uuid.UUID) (*StoreLocationInfo, error) {
+	return r.inner.GetStoreLocation(tenantID)
+}
+
+var (
+	deliveryBreakerMetricOnce    sync.Once
+	deliveryBreakerOpenedCounter metric.Int64Counter
+)
+
+// recordDeliveryBreakerOpened emite a métrica de abertura do circuit breaker de entrega
+func recordDeliveryBreakerOpened() {
+	deliveryBreakerMetricOnce.Do(func() {
+		counter, err := otel.Meter("iafarma/ai").Int64Counter(
+			"delivery_validation_circuit_breaker_opened_total",
+			metric.WithDescription("Número de vezes que o circuit breaker da validação de entrega abriu"),
+		)
+		if err != nil {
+			log.Error().Err(err).Msg("Erro ao criar métrica do circuit breaker de entrega")
+			return
+		}
+		deliveryBreakerOpenedCounter = counter
+	})
+
+	if deliveryBreakerOpenedCounter != nil {
+		deliveryBreakerOpenedCounter.Add(context.Background(), 1)
+	}
+	log.Error().
+		Str("metric", "delivery_validation_circuit_breaker_opened_total").
+		Dur("cooldown", deliveryBreakerCooldown).
+		Msg("🚨 Circuit breaker da validação de entrega ABERTO - serviço falhando repetidamente")
+}
+
+// getDeliveryFallbackMode retorna o comportamento do checkout quando a validação de entrega falha
+func (s *AIService) getDeliveryFallbackMode(ctx context.Context, tenantID uuid.UUID) string {
+	if s.settingsService == nil {
+		return defaultDeliveryFallbackMode
+	}
+
+	setting, err := s.settingsService.GetSetting(ctx, tenantID, DeliveryFallbackModeSettingKey)
+	if err != nil || setting == nil || setting.SettingValue == nil {
+		return defaultDeliveryFallbackMode
+	}
+
+	if strings.TrimSpace(*setting.SettingValue) == DeliveryFallbackManual {
+		return DeliveryFallbackManual
+	}
+	return DeliveryFallbackBlock
+}
+
+// deliveryUnavailableMessage é enviada quando a validação de entrega está indisponível e o tenant bloqueia o checkout
+func (s *AIService) deliveryUnavailableMessage(ctx context.Context, tenantID uuid.UUID) string {
+	message := "⚠️ **Não conseguimos confirmar a entrega no seu endereço agora.**\n\nNosso sistema de entregas está instável no momento. Seu carrinho continua salvo - tente finalizar novamente em alguns minutos."
+	if s.isPickupAllowed(ctx, tenantID) {
+		message += "\n\n🏪 Se preferir, você pode **retirar o pedido na loja** - é só pedir."
+	}
+	return message
+}
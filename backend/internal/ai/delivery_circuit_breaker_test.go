@@ -0,0 +1,184 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"iafarma/pkg/models"
+
+	"github.com/google/uuid"
+)
+
+// failingDeliveryService falha nas primeiras chamadas e depois valida normalmente
+type failingDeliveryService struct {
+	DeliveryServiceInterface
+	failures int
+	calls    int
+}
+
+func (f *failingDeliveryService) ValidateDeliveryAddress(tenantID uuid.UUID, street, number, neighborhood, city, state string) (*DeliveryValidationResult, error) {
+	f.calls++
+	if f.calls <= f.failures {
+		return nil, errors.New("geocoding timeout")
+	}
+	return &DeliveryValidationResult{CanDeliver: true, Reason: "within_radius"}, nil
+}
+
+func newTestBreaker(threshold int, cooldown time.Duration) (*circuitBreaker, *time.Time, *int) {
+	current := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	opened := 0
+	breaker := newCircuitBreaker(threshold, cooldown, func() { opened++ })
+	breaker.now = func() time.Time { return current }
+	return breaker, &current, &opened
+}
+
+func TestCircuitBreakerOpensAfterThreshold(t *testing.T) {
+	breaker, _, opened := newTestBreaker(3, time.Minute)
+
+	for i := 0; i < 2; i++ {
+		breaker.RecordFailure()
+	}
+	if breaker.State() != circuitClosed || !breaker.Allow() {
+		t.Fatalf("circuito deveria continuar fechado abaixo do limite, estado = %s", breaker.State())
+	}
+
+	breaker.RecordFailure()
+	if breaker.State() != circuitOpen {
+		t.Fatalf("esperado circuito aberto, obtido %s", breaker.State())
+	}
+	if breaker.Allow() {
+		t.Errorf("circuito aberto não deveria liberar chamadas")
+	}
+	if *opened != 1 {
+		t.Errorf("métrica de abertura deveria ser emitida uma vez, obtido %d", *opened)
+	}
+}
+
+func TestCircuitBreakerSuccessResetsFailures(t *testing.T) {
+	breaker, _, _ := newTestBreaker(2, time.Minute)
+
+	breaker.RecordFailure()
+	breaker.RecordSuccess()
+	breaker.RecordFailure()
+	if breaker.State() != circuitClosed {
+		t.Errorf("falhas não consecutivas não deveriam abrir o circuito, estado = %s", breaker.State())
+	}
+}
+
+func TestCircuitBreakerHalfOpenTransitions(t *testing.T) {
+	breaker, current, opened := newTestBreaker(1, time.Minute)
+
+	breaker.RecordFailure()
+	*current = current.Add(59 * time.Second)
+	if breaker.Allow() {
+		t.Fatalf("chamadas não deveriam ser liberadas antes do cooldown")
+	}
+
+	*current = current.Add(time.Second)
+	if breaker.State() != circuitHalfOpen {
+		t.Fatalf("esperado half-open após o cooldown, obtido %s", breaker.State())
+	}
+	if !breaker.Allow() {
+		t.Fatalf("half-open deveria liberar a chamada de teste")
+	}
+	if breaker.Allow() {
+		t.Errorf("half-open deveria liberar apenas uma chamada de teste por vez")
+	}
+
+	// Chamada de teste falhou: volta a abrir
+	breaker.RecordFailure()
+	if breaker.State() != circuitOpen || *opened != 2 {
+		t.Fatalf("falha em half-open deveria reabrir o circuito: estado = %s, aberturas = %d", breaker.State(), *opened)
+	}
+
+	// Nova chamada de teste com sucesso: fecha
+	*current = current.Add(time.Minute)
+	if !breaker.Allow() {
+		t.Fatalf("half-open deveria liberar nova chamada de teste")
+	}
+	breaker.RecordSuccess()
+	if breaker.State() != circuitClosed || !breaker.Allow() {
+		t.Errorf("sucesso em half-open deveria fechar o circuito, estado = %s", breaker.State())
+	}
+}
+
+func TestResilientDeliveryServiceRetries(t *testing.T) {
+	inner := &failingDeliveryService{failures: 2}
+	service := NewResilientDeliveryService(inner)
+	var waits []time.Duration
+	service.sleep = func(d time.Duration) { waits = append(waits, d) }
+
+	result, err := service.ValidateDeliveryAddress(uuid.New(), "Rua A", "1", "Centro", "Brasília", "DF")
+	if err != nil || result == nil || !result.CanDeliver {
+		t.Fatalf("esperado sucesso após retry, obtido result=%+v err=%v", result, err)
+	}
+	if inner.calls != 3 || len(waits) != 2 {
+		t.Errorf("esperado 3 tentativas e 2 esperas, obtido %d tentativas e %d esperas", inner.calls, len(waits))
+	}
+}
+
+func TestResilientDeliveryServiceShortCircuitsWhenOpen(t *testing.T) {
+	inner := &failingDeliveryService{failures: 1000}
+	service := NewResilientDeliveryService(inner)
+	service.sleep = func(time.Duration) {}
+	opened := 0
+	service.breaker.onOpen = func() { opened++ }
+
+	for i := 0; i < deliveryBreakerFailureThreshold; i++ {
+		if _, err := service.ValidateDeliveryAddress(uuid.New(), "Rua A", "1", "Centro", "Brasília", "DF"); err == nil {
+			t.Fatalf("esperado erro na tentativa %d", i+1)
+		}
+	}
+	callsBeforeOpen := inner.calls
+
+	_, err := service.ValidateDeliveryAddress(uuid.New(), "Rua A", "1", "Centro", "Brasília", "DF")
+	if !errors.Is(err, ErrDeliveryCircuitOpen) {
+		t.Fatalf("esperado ErrDeliveryCircuitOpen, obtido %v", err)
+	}
+	if inner.calls != callsBeforeOpen {
+		t.Errorf("circuito aberto não deveria chamar o serviço de entrega")
+	}
+	if opened != 1 {
+		t.Errorf("esperado 1 abertura do circuito, obtido %d", opened)
+	}
+}
+
+func TestCheckoutDeliveryFallback(t *testing.T) {
+	addresses := []models.Address{{Street: "Rua das Flores", Number: "123", City: "Brasília", State: "DF", IsDefault: true}}
+
+	tests := []struct {
+		name         string
+		mode         string
+		expectOrder  bool
+		expectInText string
+	}{
+		{"bloqueio gracioso por padrão", "", false, "sistema de entregas está instável"},
+		{"bloqueio configurado", DeliveryFallbackBlock, false, "tente finalizar novamente"},
+		{"confirmação manual", DeliveryFallbackManual, true, "vamos confirmar manualmente"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, fakes := newTestService(map[string]string{AllowPickupSettingKey: "false"}, withCheckout(newPickupTestCart(false), addresses...))
+			orders := fakes.orders
+			s.deliveryService = &failingDeliveryService{failures: 1000}
+			if tt.mode != "" {
+				s.settingsService.(*fakeSettingsService).values[DeliveryFallbackModeSettingKey] = tt.mode
+			}
+
+			result, _ := s.performFinalCheckout(context.Background(), uuid.New(), uuid.New(), "5561999999999")
+			if !strings.Contains(result, tt.expectInText) {
+				t.Errorf("mensagem esperada contendo %q, obtido:\n%s", tt.expectInText, result)
+			}
+			if created := len(orders.orders) == 1; created != tt.expectOrder {
+				t.Errorf("pedido criado = %v, esperado %v", created, tt.expectOrder)
+			}
+			if tt.expectOrder && orders.orders[0].ShippingStreet == nil {
+				t.Errorf("pedido com confirmação manual deveria manter o endereço de entrega")
+			}
+		})
+	}
+}
@@ -0,0 +1,271 @@
+package ai
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"iafarma/internal/utils"
+	"iafarma/pkg/models"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// AllowDepositPaymentSettingKey habilita o pagamento com sinal (parte antecipada e o restante na entrega)
+	AllowDepositPaymentSettingKey = "allow_deposit_payment"
+	// DepositPercentSettingKey define o percentual do total cobrado como sinal
+	DepositPercentSettingKey = "deposit_percent"
+	// DepositFixedAmountSettingKey define um valor fixo de sinal, que substitui o percentual ("0" = usa o percentual)
+	DepositFixedAmountSettingKey = "deposit_fixed_amount"
+	// DepositPaymentMethodSettingKey define como o sinal é pago antecipadamente
+	DepositPaymentMethodSettingKey = "deposit_payment_method"
+	// DepositRemainderMethodSettingKey define a forma de pagamento do restante na entrega
+	DepositRemainderMethodSettingKey = "deposit_remainder_payment_method"
+)
+
+// depositConfig reúne as regras do pagamento com sinal do tenant
+type depositConfig struct {
+	Percent         float64 // em %, ex.: 30
+	FixedAmount     float64
+	Method          string
+	RemainderMethod string
+}
+
+// depositSplit é a divisão do total entre o sinal e o restante
+type depositSplit struct {
+	Deposit float64
+	Due     float64
+}
+
+// getDepositConfig lê as regras de sinal do tenant (nil quando a opção está desabilitada ou sem valor configurado)
+func (s *AIService) getDepositConfig(ctx context.Context, tenantID uuid.UUID) *depositConfig {
+	if s.settingsService == nil {
+		return nil
+	}
+
+	read := func(key string) string {
+		setting, err := s.settingsService.GetSetting(ctx, tenantID, key)
+		if err != nil || setting == nil || setting.SettingValue == nil {
+			return ""
+		}
+		return strings.TrimSpace(*setting.SettingValue)
+	}
+
+	if !strings.EqualFold(read(AllowDepositPaymentSettingKey), "true") {
+		return nil
+	}
+
+	config := depositConfig{Method: "PIX", RemainderMethod: "Dinheiro"}
+	if value, err := strconv.ParseFloat(strings.Replace(read(DepositPercentSettingKey), ",", ".", 1), 64); err == nil && value > 0 && value < 100 {
+		config.Percent = value
+	}
+	if value, err := strconv.ParseFloat(strings.Replace(read(DepositFixedAmountSettingKey), ",", ".", 1), 64); err == nil && value > 0 {
+		config.FixedAmount = value
+	}
+	if method := read(DepositPaymentMethodSettingKey); method != "" {
+		config.Method = method
+	}
+	if method := read(DepositRemainderMethodSettingKey); method != "" {
+		config.RemainderMethod = method
+	}
+
+	if config.Percent <= 0 && config.FixedAmount <= 0 {
+		return nil
+	}
+	return &config
+}
+
+// split divide o total entre o sinal (valor fixo ou percentual) e o restante a pagar na entrega
+func (c depositConfig) split(total float64) (depositSplit, bool) {
+	if total <= 0 {
+		return depositSplit{}, false
+	}
+
+	deposit := utils.RoundMoney(total * c.Percent / 100)
+	if c.FixedAmount > 0 {
+		deposit = c.FixedAmount
+	}
+	// Um sinal igual ou maior que o total é pagamento integral, não sinal
+	if deposit <= 0 || deposit >= total {
+		return depositSplit{}, false
+	}
+	return depositSplit{Deposit: deposit, Due: utils.RoundMoney(total - deposit)}, true
+}
+
+// describe mostra como o sinal é calculado ("30% do total" ou "R$ 50,00")
+func (c depositConfig) describe() string {
+	if c.FixedAmount > 0 {
+		return "R$ " + formatCurrency(fmt.Sprintf("%.2f", c.FixedAmount))
+	}
+	return strconv.FormatFloat(c.Percent, 'f', -1, 64) + "% do total"
+}
+
+// isDepositCart indica se o carrinho vai pagar com sinal e o tenant ainda oferece a opção
+func (s *AIService) isDepositCart(ctx context.Context, tenantID uuid.UUID, cart *models.Cart) bool {
+	return cart != nil && cart.PayDeposit && s.getDepositConfig(ctx, tenantID) != nil
+}
+
+// remainderMethodName é a forma de pagamento do restante: a escolhida no carrinho ou a configurada pelo tenant
+func remainderMethodName(config depositConfig, cart *models.Cart) string {
+	if cart != nil && cart.PaymentMethod != nil && cart.PaymentMethod.Name != "" &&
+		!strings.EqualFold(cart.PaymentMethod.Name, config.Method) {
+		return cart.PaymentMethod.Name
+	}
+	return config.RemainderMethod
+}
+
+// formatDepositSplit explica a divisão do pagamento entre o sinal e o restante
+func formatDepositSplit(config depositConfig, split depositSplit, remainderMethod string, pickup bool) string {
+	moment := "na entrega"
+	if pickup {
+		moment = "na retirada"
+	}
+	return fmt.Sprintf("💵 **Sinal:** R$ %s via %s (pago agora)\n🤝 **Restante %s:** R$ %s em %s",
+		formatCurrency(fmt.Sprintf("%.2f", split.Deposit)), config.Method,
+		moment, formatCurrency(fmt.Sprintf("%.2f", split.Due)), remainderMethod)
+}
+
+// cartDepositLines mostra a divisão do pagamento no carrinho marcado para pagar com sinal
+func (s *AIService) cartDepositLines(ctx context.Context, tenantID uuid.UUID, cart *models.Cart) string {
+	if !cart.PayDeposit {
+		return ""
+	}
+	config := s.getDepositConfig(ctx, tenantID)
+	if config == nil {
+		return ""
+	}
+	split, ok := config.split(s.cartInstallmentTotal(ctx, tenantID, cart))
+	if !ok {
+		return ""
+	}
+	return formatDepositSplit(*config, split, remainderMethodName(*config, cart), s.isPickupCart(ctx, tenantID, cart))
+}
+
+// depositPaymentHint oferece o pagamento com sinal junto das formas de pagamento do checkout
+func (s *AIService) depositPaymentHint(ctx context.Context, tenantID uuid.UUID, cart *models.Cart) string {
+	if cart == nil || cart.PayDeposit {
+		return ""
+	}
+	config := s.getDepositConfig(ctx, tenantID)
+	if config == nil {
+		return ""
+	}
+	return fmt.Sprintf("\n\n💵 Também dá para pagar um **sinal de %s via %s** agora e o restante na entrega em %s - é só pedir!",
+		config.describe(), config.Method, config.RemainderMethod)
+}
+
+// applyCartDeposit grava no pedido o sinal e o restante, calculados sobre o total final.
+// Retorna a explicação da divisão para a confirmação (vazia quando o pedido é pago integralmente).
+func (s *AIService) applyCartDeposit(ctx context.Context, tenantID uuid.UUID, cart *models.Cart, order *models.Order) (*models.Order, string) {
+	if !cart.PayDeposit {
+		return order, ""
+	}
+	config := s.getDepositConfig(ctx, tenantID)
+	if config == nil {
+		return order, ""
+	}
+
+	total, _ := strconv.ParseFloat(order.TotalAmount, 64)
+	split, ok := config.split(total)
+	if !ok {
+		log.Warn().
+			Str("order_id", order.ID.String()).
+			Float64("total", total).
+			Msg("⚠️ Sinal não se aplica ao total do pedido - pagamento integral")
+		return order, ""
+	}
+
+	updated, err := s.orderService.ApplyDeposit(tenantID, order.ID, config.Method, split.Deposit, split.Due)
+	if err != nil {
+		log.Error().Err(err).Str("order_id", order.ID.String()).Msg("Erro ao registrar sinal no pedido")
+		return order, ""
+	}
+	return updated, formatDepositSplit(*config, split, remainderMethodName(*config, cart), s.isPickupCart(ctx, tenantID, cart))
+}
+
+// selectRemainderPaymentMethod registra no carrinho a forma de pagamento do restante quando o cliente ainda não escolheu
+func (s *AIService) selectRemainderPaymentMethod(tenantID uuid.UUID, cart *models.Cart, config depositConfig) {
+	if cart.PaymentMethodID != nil {
+		return
+	}
+
+	options, err := s.orderService.GetPaymentOptions(tenantID)
+	if err != nil {
+		return
+	}
+	for _, option := range options {
+		if !strings.EqualFold(strings.TrimSpace(option.Name), config.RemainderMethod) {
+			continue
+		}
+		id, err := uuid.Parse(option.ID)
+		if err != nil {
+			return
+		}
+		if err := s.cartService.UpdateCartPaymentMethod(cart.ID, tenantID, id); err != nil {
+			log.Warn().Err(err).Msg("Erro ao registrar a forma de pagamento do restante")
+			return
+		}
+		cart.PaymentMethodID = &id
+		cart.PaymentMethod = &models.PaymentMethod{Name: option.Name}
+		return
+	}
+}
+
+// handlePagarComSinal marca (ou desmarca) o pedido em andamento para pagamento com sinal
+func (s *AIService) handlePagarComSinal(ctx context.Context, tenantID, customerID uuid.UUID, args map[string]interface{}) (string, error) {
+	config := s.getDepositConfig(ctx, tenantID)
+	if config == nil {
+		return "❌ No momento não trabalhamos com pagamento de sinal. O pedido é pago integralmente na forma de pagamento escolhida.", nil
+	}
+
+	payDeposit := true
+	if value, ok := args["sinal"].(bool); ok {
+		payDeposit = value
+	}
+
+	cart, err := s.cartService.GetOrCreateActiveCart(tenantID, customerID)
+	if err != nil {
+		return "❌ Erro ao acessar carrinho.", err
+	}
+	cartWithItems, err := s.cartService.GetCartWithItems(cart.ID, tenantID)
+	if err != nil {
+		return "❌ Erro ao carregar carrinho.", err
+	}
+
+	if payDeposit && len(cartWithItems.Items) == 0 {
+		return "🛒 Seu carrinho está vazio. Adicione os produtos e eu calculo o sinal do pedido!", nil
+	}
+
+	if err := s.cartService.SetCartDeposit(cartWithItems.ID, tenantID, payDeposit); err != nil {
+		return "❌ Erro ao atualizar a forma de pagamento do pedido.", err
+	}
+	cartWithItems.PayDeposit = payDeposit
+
+	log.Info().
+		Str("tenant_id", tenantID.String()).
+		Str("customer_id", customerID.String()).
+		Bool("pay_deposit", payDeposit).
+		Msg("💵 Pagamento com sinal atualizado")
+
+	if !payDeposit {
+		return "👍 Combinado! O pedido será pago **integralmente** na forma de pagamento escolhida.", nil
+	}
+
+	split, ok := config.split(s.cartInstallmentTotal(ctx, tenantID, cartWithItems))
+	if !ok {
+		if err := s.cartService.SetCartDeposit(cartWithItems.ID, tenantID, false); err != nil {
+			log.Warn().Err(err).Msg("❌ Falha ao desmarcar sinal do carrinho")
+		}
+		return fmt.Sprintf("💵 O sinal é de %s, que já cobre o valor do seu pedido. Nesse caso o pagamento é feito integralmente via %s.",
+			config.describe(), config.Method), nil
+	}
+
+	// O restante é pago com a forma escolhida no checkout ou com a configurada para o sinal
+	s.selectRemainderPaymentMethod(tenantID, cartWithItems, *config)
+
+	return fmt.Sprintf("💵 Pronto! Seu pedido será pago com **sinal**:\n\n%s\n\n🛍️ Quando quiser, é só pedir para finalizar.",
+		formatDepositSplit(*config, split, remainderMethodName(*config, cartWithItems), s.isPickupCart(ctx, tenantID, cartWithItems))), nil
+}
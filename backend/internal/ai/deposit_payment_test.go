@@ -0,0 +1,190 @@
+package ai
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"iafarma/pkg/models"
+
+	"github.com/google/uuid"
+)
+
+func (f *fakeOrderService) ApplyDeposit(tenantID, orderID uuid.UUID, depositMethod string, amountPaid, amountDue float64) (*models.Order, error) {
+	for i := range f.orders {
+		if f.orders[i].ID == orderID {
+			f.orders[i].HasDeposit = true
+			f.orders[i].DepositPaymentMethod = depositMethod
+			f.orders[i].AmountPaid = fmt.Sprintf("%.2f", amountPaid)
+			f.orders[i].AmountDue = fmt.Sprintf("%.2f", amountDue)
+			return &f.orders[i], nil
+		}
+	}
+	return nil, fmt.Errorf("pedido %s não encontrado", orderID)
+}
+
+// depositTestOrderService cria os pedidos com o total do carrinho e oferece as formas de pagamento do tenant
+type depositTestOrderService struct {
+	*fakeOrderService
+	total   string
+	options []PaymentOption
+}
+
+func (f *depositTestOrderService) CreateOrderFromCartWithAddress(tenantID, cartID uuid.UUID, deliveryAddress *models.Address) (*models.Order, error) {
+	order, err := f.fakeOrderService.CreateOrderFromCartWithAddress(tenantID, cartID, deliveryAddress)
+	if err != nil {
+		return nil, err
+	}
+	order.TotalAmount = f.total
+	return order, nil
+}
+
+func (f *depositTestOrderService) GetPaymentOptions(tenantID uuid.UUID) ([]PaymentOption, error) {
+	return f.options, nil
+}
+
+// newDepositTestOrders retorna o serviço de pedidos com PIX e dinheiro como formas de pagamento
+func newDepositTestOrders() *depositTestOrderService {
+	return &depositTestOrderService{
+		fakeOrderService: &fakeOrderService{},
+		total:            "10.00",
+		options: []PaymentOption{
+			{ID: uuid.New().String(), Name: "PIX"},
+			{ID: uuid.New().String(), Name: "Dinheiro"},
+		},
+	}
+}
+
+func TestDepositSplit(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   depositConfig
+		total    float64
+		sinal    float64
+		restante float64
+		aplica   bool
+	}{
+		{"percentual", depositConfig{Percent: 30}, 200, 60, 140, true},
+		{"percentual arredonda centavos", depositConfig{Percent: 30}, 99.99, 30, 69.99, true},
+		{"valor fixo substitui o percentual", depositConfig{Percent: 30, FixedAmount: 50}, 200, 50, 150, true},
+		{"valor fixo cobre o pedido", depositConfig{FixedAmount: 50}, 40, 0, 0, false},
+		{"pedido sem valor", depositConfig{Percent: 30}, 0, 0, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			split, ok := tt.config.split(tt.total)
+			if ok != tt.aplica || split.Deposit != tt.sinal || split.Due != tt.restante {
+				t.Errorf("esperado sinal %.2f e restante %.2f (aplica=%v), obtido %+v (aplica=%v)", tt.sinal, tt.restante, tt.aplica, split, ok)
+			}
+		})
+	}
+}
+
+func TestGetDepositConfig(t *testing.T) {
+	tests := []struct {
+		name       string
+		settings   map[string]string
+		habilitado bool
+	}{
+		{"desabilitado por padrão", map[string]string{DepositPercentSettingKey: "30"}, false},
+		{"habilitado com percentual", map[string]string{AllowDepositPaymentSettingKey: "true", DepositPercentSettingKey: "30"}, true},
+		{"habilitado com valor fixo", map[string]string{AllowDepositPaymentSettingKey: "true", DepositFixedAmountSettingKey: "25,00"}, true},
+		{"habilitado sem valor configurado", map[string]string{AllowDepositPaymentSettingKey: "true", DepositPercentSettingKey: "0"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &AIService{settingsService: &fakeSettingsService{values: tt.settings}}
+			if config := s.getDepositConfig(context.Background(), uuid.New()); (config != nil) != tt.habilitado {
+				t.Errorf("esperado habilitado=%v, obtido %+v", tt.habilitado, config)
+			}
+		})
+	}
+}
+
+func TestDepositPropagatesToCreatedOrder(t *testing.T) {
+	tenantID := uuid.New()
+	customerID := uuid.New()
+	cart := newPickupTestCart(true)
+	cart.PaymentMethodID = nil
+	orders := newDepositTestOrders()
+	s, _ := newTestService(map[string]string{
+		AllowPickupSettingKey:         "true",
+		AllowDepositPaymentSettingKey: "true",
+		DepositPercentSettingKey:      "30",
+	}, withCheckout(cart), withOverride(func(s *AIService) { s.orderService = orders }))
+
+	message, err := s.handlePagarComSinal(context.Background(), tenantID, customerID, map[string]interface{}{"sinal": true})
+	if err != nil {
+		t.Fatalf("erro inesperado: %v", err)
+	}
+	if !cart.PayDeposit || !strings.Contains(message, "R$ 3,00 via PIX") || !strings.Contains(message, "R$ 7,00 em Dinheiro") {
+		t.Fatalf("carrinho deveria ser marcado para pagar com sinal: deposit=%v\n%s", cart.PayDeposit, message)
+	}
+	if cart.PaymentMethodID == nil || cart.PaymentMethodID.String() != orders.options[1].ID {
+		t.Errorf("o restante deveria ser registrado como pagamento em Dinheiro")
+	}
+
+	result, err := s.performFinalCheckout(context.Background(), tenantID, customerID, "5561999999999")
+	if err != nil {
+		t.Fatalf("erro inesperado no checkout final: %v", err)
+	}
+	if len(orders.orders) != 1 {
+		t.Fatalf("esperado 1 pedido, obtido %d:\n%s", len(orders.orders), result)
+	}
+
+	order := orders.orders[0]
+	if !order.HasDeposit || order.DepositPaymentMethod != "PIX" || order.AmountPaid != "3.00" || order.AmountDue != "7.00" {
+		t.Errorf("pedido deveria registrar sinal de 3.00 via PIX e 7.00 a receber, obtido %+v", order)
+	}
+	if !strings.Contains(result, "**Sinal:** R$ 3,00 via PIX") || !strings.Contains(result, "**Restante na retirada:** R$ 7,00") {
+		t.Errorf("confirmação deveria explicar a divisão do pagamento:\n%s", result)
+	}
+	if cart.PayDeposit {
+		t.Errorf("o sinal deveria valer só para o pedido criado")
+	}
+}
+
+func TestDepositNotOfferedWhenDisabled(t *testing.T) {
+	cart := newPickupTestCart(true)
+	orders := newDepositTestOrders()
+	s, _ := newTestService(map[string]string{AllowPickupSettingKey: "true", DepositPercentSettingKey: "30"},
+		withCheckout(cart), withOverride(func(s *AIService) { s.orderService = orders }))
+
+	message, err := s.handlePagarComSinal(context.Background(), uuid.New(), uuid.New(), map[string]interface{}{"sinal": true})
+	if err != nil {
+		t.Fatalf("erro inesperado: %v", err)
+	}
+	if cart.PayDeposit || !strings.Contains(message, "não trabalhamos com pagamento de sinal") {
+		t.Errorf("sinal não deveria ser aceito com a opção desabilitada:\n%s", message)
+	}
+
+	// Carrinho marcado antes de o tenant desabilitar a opção paga o valor integral
+	cart.PayDeposit = true
+	if _, err := s.performFinalCheckout(context.Background(), uuid.New(), uuid.New(), "5561999999999"); err != nil {
+		t.Fatalf("erro inesperado no checkout final: %v", err)
+	}
+	if len(orders.orders) != 1 || orders.orders[0].HasDeposit {
+		t.Errorf("pedido não deveria registrar sinal com a opção desabilitada: %+v", orders.orders)
+	}
+}
+
+func TestCheckoutOffersDepositWithPaymentOptions(t *testing.T) {
+	cart := newPickupTestCart(false)
+	cart.PaymentMethodID = nil
+	s, _ := newTestService(map[string]string{
+		AllowPickupSettingKey:         "true",
+		AllowDepositPaymentSettingKey: "true",
+		DepositFixedAmountSettingKey:  "5",
+	}, withCheckout(cart), withOverride(func(s *AIService) { s.orderService = newDepositTestOrders() }))
+
+	checkout, err := s.handleCheckout(context.Background(), uuid.New(), uuid.New(), "5561999999999")
+	if err != nil {
+		t.Fatalf("erro inesperado no checkout: %v", err)
+	}
+	if !strings.Contains(checkout, "Escolha a forma de pagamento") || !strings.Contains(checkout, "sinal de R$ 5,00 via PIX") {
+		t.Errorf("checkout deveria oferecer o sinal junto das formas de pagamento:\n%s", checkout)
+	}
+}
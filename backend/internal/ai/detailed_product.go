@@ -0,0 +1,75 @@
+package ai
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+)
+
+// lastDetailedProductKey guarda na memória da conversa o produto exibido pelo último detalharItem
+const lastDetailedProductKey = "last_detailed_product"
+
+// rememberDetailedProduct registra o produto que o cliente acabou de ver em detalhes
+func (s *AIService) rememberDetailedProduct(tenantID uuid.UUID, customerPhone string, productID uuid.UUID) {
+	if s.memoryManager == nil {
+		return
+	}
+	s.memoryManager.StoreTempData(tenantID, customerPhone, map[string]interface{}{
+		lastDetailedProductKey: productID.String(),
+		// Um número isolado agora é a quantidade desejada do produto detalhado
+		awaitingSelectionKey: string(awaitingQuantitySelection),
+	})
+}
+
+// lastDetailedProductID retorna o produto do último detalharItem da conversa
+func (s *AIService) lastDetailedProductID(tenantID uuid.UUID, customerPhone string) (uuid.UUID, bool) {
+	if s.memoryManager == nil {
+		return uuid.Nil, false
+	}
+	value, found := s.memoryManager.GetTempData(tenantID, customerPhone, lastDetailedProductKey)
+	if !found {
+		return uuid.Nil, false
+	}
+	text, _ := value.(string)
+	productID, err := uuid.Parse(text)
+	if err != nil {
+		return uuid.Nil, false
+	}
+	return productID, true
+}
+
+// handleAdicionarItemDetalhado adiciona ao carrinho o produto que o cliente acabou de detalhar ("quero esse"),
+// assumindo 1 unidade quando a quantidade não foi informada
+func (s *AIService) handleAdicionarItemDetalhado(ctx context.Context, tenantID, customerID uuid.UUID, customerPhone string, args map[string]interface{}) (string, error) {
+	productID, ok := s.lastDetailedProductID(tenantID, customerPhone)
+	if !ok {
+		return "🤔 Qual produto você quer? Me diga o número da lista ou o nome do produto.", nil
+	}
+
+	quantity := 1
+	assumed := true
+	if value, ok := args["quantidade"].(float64); ok && value >= 1 {
+		quantity = int(value)
+		assumed = false
+	}
+
+	log.Info().
+		Str("tenant_id", tenantID.String()).
+		Str("product_id", productID.String()).
+		Int("quantity", quantity).
+		Bool("quantity_assumed", assumed).
+		Msg("🛒 Adicionando o produto detalhado ao carrinho")
+
+	result, err := s.tryAddProductToCart(ctx, tenantID, customerID, customerPhone, productID, quantity)
+	if err != nil {
+		return "❌ Não consegui adicionar esse produto. Use 'produtos' para ver a lista atualizada.", nil
+	}
+
+	if assumed && strings.HasPrefix(result, "✅") {
+		result = fmt.Sprintf("%s\n\n💡 Considerei **1 unidade**. Se quiser mais, é só me dizer a quantidade!", result)
+	}
+	return result, nil
+}
@@ -0,0 +1,75 @@
+package ai
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"iafarma/pkg/models"
+
+	"github.com/google/uuid"
+)
+
+// addingCartService registra os itens adicionados ao carrinho
+type addingCartService struct {
+	fakeCartService
+	added map[uuid.UUID]int
+}
+
+func (f *addingCartService) AddItemToCart(cartID, tenantID, productID uuid.UUID, quantity int) error {
+	f.added[productID] += quantity
+	return nil
+}
+
+// newAddingCartService retorna o carrinho vazio que registra os produtos adicionados
+func newAddingCartService() *addingCartService {
+	return &addingCartService{
+		fakeCartService: fakeCartService{cart: &models.Cart{BaseTenantModel: models.BaseTenantModel{ID: uuid.New()}}},
+		added:           map[uuid.UUID]int{},
+	}
+}
+
+func TestQueroEsseAddsLastDetailedProduct(t *testing.T) {
+	tenantID, customerID, phone := uuid.New(), uuid.New(), "5527999990000"
+	dipirona := models.Product{BaseTenantModel: models.BaseTenantModel{ID: uuid.New()}, Name: "Dipirona 500mg", Price: "8.90", Available: true, StockQuantity: 10}
+	vitamina := models.Product{BaseTenantModel: models.BaseTenantModel{ID: uuid.New()}, Name: "Vitamina C", Price: "25.00", Available: true, StockQuantity: 10}
+	cart := newAddingCartService()
+	s, _ := newTestService(nil, withProducts(dipirona, vitamina), withCartService(cart))
+
+	for _, product := range []models.Product{dipirona, vitamina} {
+		if _, err := s.handleDetalharItem(context.Background(), tenantID, phone, map[string]interface{}{"identifier": product.ID.String()}); err != nil {
+			t.Fatalf("erro inesperado ao detalhar: %v", err)
+		}
+	}
+
+	result, err := s.handleAdicionarItemDetalhado(context.Background(), tenantID, customerID, phone, map[string]interface{}{})
+	if err != nil {
+		t.Fatalf("erro inesperado: %v", err)
+	}
+
+	if cart.added[vitamina.ID] != 1 || cart.added[dipirona.ID] != 0 {
+		t.Errorf("esperado 1 unidade do último produto detalhado, obtido %v", cart.added)
+	}
+	if !strings.Contains(result, "Vitamina C") || !strings.Contains(result, "Considerei **1 unidade**") {
+		t.Errorf("esperado confirmar a quantidade assumida, obtido:\n%s", result)
+	}
+}
+
+func TestQueroEsseWithQuantityAndWithoutDetail(t *testing.T) {
+	tenantID, customerID, phone := uuid.New(), uuid.New(), "5527999990000"
+	product := models.Product{BaseTenantModel: models.BaseTenantModel{ID: uuid.New()}, Name: "Dipirona 500mg", Price: "8.90", Available: true, StockQuantity: 10}
+	cart := newAddingCartService()
+	s, _ := newTestService(nil, withProducts(product), withCartService(cart))
+
+	// Sem produto detalhado, pergunta qual produto em vez de adivinhar
+	result, _ := s.handleAdicionarItemDetalhado(context.Background(), tenantID, customerID, phone, map[string]interface{}{})
+	if len(cart.added) != 0 || !strings.Contains(result, "Qual produto") {
+		t.Errorf("esperado perguntar o produto, obtido %v:\n%s", cart.added, result)
+	}
+
+	s.handleDetalharItem(context.Background(), tenantID, phone, map[string]interface{}{"identifier": product.ID.String()})
+	result, _ = s.handleAdicionarItemDetalhado(context.Background(), tenantID, customerID, phone, map[string]interface{}{"quantidade": float64(3)})
+	if cart.added[product.ID] != 3 || strings.Contains(result, "Considerei") {
+		t.Errorf("esperado usar a quantidade informada sem aviso, obtido %v:\n%s", cart.added, result)
+	}
+}
@@ -0,0 +1,153 @@
+package ai
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"iafarma/pkg/models"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// EmptySearchBehaviorSettingKey define o que mostrar quando a busca não encontra nada:
+	// "suggestions" (termos parecidos), "categories" (principais categorias) ou "best_sellers" (mais vendidos)
+	EmptySearchBehaviorSettingKey = "ai_empty_search_behavior"
+
+	EmptySearchSuggestions = "suggestions"
+	EmptySearchCategories  = "categories"
+	EmptySearchBestSellers = "best_sellers"
+
+	// maxEmptySearchCategories e maxEmptySearchBestSellers limitam a lista oferecida ao cliente
+	maxEmptySearchCategories  = 6
+	maxEmptySearchBestSellers = 5
+)
+
+// BestSellersJoinSQL junta aos produtos a quantidade vendida em pedidos não cancelados do tenant (coluna sales.sold)
+const BestSellersJoinSQL = `JOIN (
+	SELECT oi.product_id, SUM(oi.quantity) AS sold
+	FROM order_items oi
+	JOIN orders o ON o.id = oi.order_id
+	WHERE o.tenant_id = ? AND o.status NOT IN ('cancelled', 'refunded') AND o.deleted_at IS NULL
+	GROUP BY oi.product_id
+) sales ON sales.product_id = products.id`
+
+// getEmptySearchBehavior retorna o comportamento configurado pelo tenant (sugestões por padrão)
+func (s *AIService) getEmptySearchBehavior(ctx context.Context, tenantID uuid.UUID) string {
+	if s.settingsService == nil {
+		return EmptySearchSuggestions
+	}
+
+	setting, err := s.settingsService.GetSetting(ctx, tenantID, EmptySearchBehaviorSettingKey)
+	if err != nil || setting == nil || setting.SettingValue == nil {
+		return EmptySearchSuggestions
+	}
+
+	switch behavior := strings.ToLower(strings.TrimSpace(*setting.SettingValue)); behavior {
+	case EmptySearchCategories, EmptySearchBestSellers:
+		return behavior
+	}
+	return EmptySearchSuggestions
+}
+
+// topCategories retorna as categorias principais (ativas e sem categoria pai) na ordem definida pela loja
+func topCategories(categories []models.Category) []models.Category {
+	var top []models.Category
+	for _, category := range categories {
+		if category.IsActive && category.ParentID == nil {
+			top = append(top, category)
+		}
+	}
+
+	sort.SliceStable(top, func(i, j int) bool {
+		if top[i].SortOrder != top[j].SortOrder {
+			return top[i].SortOrder < top[j].SortOrder
+		}
+		return top[i].Name < top[j].Name
+	})
+
+	if len(top) > maxEmptySearchCategories {
+		top = top[:maxEmptySearchCategories]
+	}
+	return top
+}
+
+// emptySearchCategories oferece as principais categorias da loja; vazio se não houver categorias
+func (s *AIService) emptySearchCategories(tenantID uuid.UUID) string {
+	if s.categoryService == nil {
+		return ""
+	}
+
+	categories, err := s.categoryService.ListCategories(tenantID)
+	if err != nil {
+		log.Warn().Err(err).Str("tenant_id", tenantID.String()).Msg("⚠️ Erro ao listar categorias para busca sem resultado")
+		return ""
+	}
+
+	top := topCategories(categories)
+	if len(top) == 0 {
+		return ""
+	}
+
+	var result strings.Builder
+	result.WriteString("\n\n📂 **Veja nossas principais categorias:**\n")
+	for _, category := range top {
+		result.WriteString(fmt.Sprintf("• %s\n", category.Name))
+	}
+	result.WriteString("\n📝 Me diga qual categoria te interessa ou use 'produtos' para ver nosso catálogo completo.")
+	return result.String()
+}
+
+// emptySearchBestSellers oferece os produtos mais vendidos, guardados na memória para seleção por número
+func (s *AIService) emptySearchBestSellers(tenantID uuid.UUID, customerPhone string) string {
+	products, err := s.productService.GetBestSellingProducts(tenantID, maxEmptySearchBestSellers)
+	if err != nil {
+		log.Warn().Err(err).Str("tenant_id", tenantID.String()).Msg("⚠️ Erro ao buscar mais vendidos para busca sem resultado")
+		return ""
+	}
+	if len(products) == 0 {
+		return ""
+	}
+
+	var result strings.Builder
+	result.WriteString("\n\n🔥 **Que tal conferir nossos mais vendidos?**\n\n")
+	productRefs := s.memoryManager.StoreProductList(tenantID, customerPhone, products)
+	for _, productRef := range productRefs {
+		result.WriteString(fmt.Sprintf("%d. **%s**\n   💰 %s\n", productRef.SequentialID, productRef.Name, formatListPrice(productRef.Price, productRef.SalePrice)))
+	}
+	result.WriteString("\n🛒 Para adicionar, me diga o número do item e a quantidade.")
+	return result.String()
+}
+
+// emptySearchSuggestions sugere termos parecidos com base nos produtos do tenant
+func (s *AIService) emptySearchSuggestions(ctx context.Context, tenantID uuid.UUID, query, marca, tags string) string {
+	suggestions := s.generateDynamicSearchSuggestions(ctx, tenantID, query, marca, tags)
+	if len(suggestions) == 0 {
+		return "\n\n💡 **Dica:** Tente termos mais específicos ou use 'produtos' para ver nosso catálogo."
+	}
+
+	response := "\n\n💡 **Você quis dizer:**\n"
+	for _, suggestion := range suggestions {
+		response += fmt.Sprintf("• %s\n", suggestion)
+	}
+	return response + "\n📝 Tente um desses termos ou use 'produtos' para ver nosso catálogo completo."
+}
+
+// emptySearchGuidance monta o complemento da resposta de uma busca sem resultado conforme a configuração do tenant.
+// Categorias e mais vendidos voltam para as sugestões quando a loja ainda não tem o que mostrar.
+func (s *AIService) emptySearchGuidance(ctx context.Context, tenantID uuid.UUID, customerPhone, query, marca, tags string) string {
+	switch s.getEmptySearchBehavior(ctx, tenantID) {
+	case EmptySearchCategories:
+		if guidance := s.emptySearchCategories(tenantID); guidance != "" {
+			return guidance
+		}
+	case EmptySearchBestSellers:
+		if guidance := s.emptySearchBestSellers(tenantID, customerPhone); guidance != "" {
+			return guidance
+		}
+	}
+	return s.emptySearchSuggestions(ctx, tenantID, query, marca, tags)
+}
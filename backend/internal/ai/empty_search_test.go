@@ -0,0 +1,113 @@
+package ai
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"iafarma/pkg/models"
+
+	"github.com/google/uuid"
+)
+
+// bestSellerProductService não encontra nada na busca e devolve os mais vendidos configurados
+type bestSellerProductService struct {
+	fakeProductService
+	bestSellers []models.Product
+}
+
+func (f *bestSellerProductService) GetBestSellingProducts(tenantID uuid.UUID, limit int) ([]models.Product, error) {
+	if len(f.bestSellers) > limit {
+		return f.bestSellers[:limit], nil
+	}
+	return f.bestSellers, nil
+}
+
+// withBestSellers liga o catálogo que devolve os mais vendidos informados
+func withBestSellers(bestSellers ...models.Product) testServiceOption {
+	return withOverride(func(s *AIService) { s.productService = &bestSellerProductService{bestSellers: bestSellers} })
+}
+
+func TestTopCategories(t *testing.T) {
+	parentID := uuid.New()
+	categories := []models.Category{
+		{Name: "Perfumaria", IsActive: true, SortOrder: 2},
+		{Name: "Medicamentos", IsActive: true, SortOrder: 1},
+		{Name: "Analgésicos", IsActive: true, SortOrder: 0, ParentID: &parentID},
+		{Name: "Inativa", IsActive: false},
+		{Name: "Bebês", IsActive: true, SortOrder: 2},
+	}
+
+	var nomes []string
+	for _, category := range topCategories(categories) {
+		nomes = append(nomes, category.Name)
+	}
+	if obtido := strings.Join(nomes, ","); obtido != "Medicamentos,Bebês,Perfumaria" {
+		t.Errorf("esperado categorias principais em ordem, obtido %q", obtido)
+	}
+}
+
+func TestConsultarItensSemResultadoPorComportamento(t *testing.T) {
+	categories := []models.Category{{Name: "Medicamentos", IsActive: true}, {Name: "Dermocosméticos", IsActive: true, SortOrder: 1}}
+	bestSellers := []models.Product{
+		newPricedTestProduct("Dipirona 500mg", "12.90"),
+		newPricedTestProduct("Protetor Solar FPS 50", "59.90"),
+	}
+
+	tests := []struct {
+		name        string
+		behavior    string
+		categories  []models.Category
+		bestSellers []models.Product
+		esperado    []string
+		proibidos   []string
+	}{
+		{"padrão mostra sugestões", "", categories, bestSellers, []string{"Você quis dizer"}, []string{"principais categorias", "mais vendidos"}},
+		{"sugestões", EmptySearchSuggestions, categories, bestSellers, []string{"Você quis dizer"}, []string{"principais categorias"}},
+		{"categorias", EmptySearchCategories, categories, bestSellers, []string{"principais categorias", "• Medicamentos", "• Dermocosméticos"}, []string{"Você quis dizer", "mais vendidos"}},
+		{"mais vendidos", EmptySearchBestSellers, categories, bestSellers, []string{"mais vendidos", "1. **Dipirona 500mg**", "2. **Protetor Solar FPS 50**", "R$ 12,90"}, []string{"Você quis dizer", "principais categorias"}},
+		{"categorias sem cadastro volta para sugestões", EmptySearchCategories, nil, bestSellers, []string{"Você quis dizer"}, []string{"principais categorias"}},
+		{"sem vendas volta para sugestões", EmptySearchBestSellers, categories, nil, []string{"Você quis dizer"}, []string{"mais vendidos"}},
+		{"valor desconhecido usa sugestões", "destaques", categories, bestSellers, []string{"Você quis dizer"}, []string{"principais categorias", "mais vendidos"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, _ := newTestService(optionalSettings(EmptySearchBehaviorSettingKey, tt.behavior), withCategories(tt.categories...), withBestSellers(tt.bestSellers...))
+
+			obtido, err := s.handleConsultarItens(context.Background(), uuid.New(), uuid.New(), "5527999999999", map[string]interface{}{"query": "Ozempic"})
+			if err != nil {
+				t.Fatalf("erro inesperado: %v", err)
+			}
+			if !strings.Contains(obtido, "Nenhum produto encontrado com 'Ozempic'") {
+				t.Errorf("busca sem resultado deveria continuar informando o cliente:\n%s", obtido)
+			}
+			for _, esperado := range tt.esperado {
+				if !strings.Contains(obtido, esperado) {
+					t.Errorf("esperado %q em:\n%s", esperado, obtido)
+				}
+			}
+			for _, proibido := range tt.proibidos {
+				if strings.Contains(obtido, proibido) {
+					t.Errorf("não esperado %q em:\n%s", proibido, obtido)
+				}
+			}
+		})
+	}
+}
+
+func TestMaisVendidosFicamNaMemoriaParaSelecao(t *testing.T) {
+	tenantID := uuid.New()
+	customerPhone := "5527999999999"
+	dipirona := newPricedTestProduct("Dipirona 500mg", "12.90")
+	s, _ := newTestService(map[string]string{EmptySearchBehaviorSettingKey: EmptySearchBestSellers}, withBestSellers(dipirona))
+
+	if _, err := s.handleConsultarItens(context.Background(), tenantID, uuid.New(), customerPhone, map[string]interface{}{"query": "Ozempic"}); err != nil {
+		t.Fatalf("erro inesperado: %v", err)
+	}
+
+	ref := s.memoryManager.GetProductBySequentialID(tenantID, customerPhone, 1)
+	if ref == nil || ref.ProductID != dipirona.ID {
+		t.Errorf("esperado mais vendido como item 1 da lista, obtido %+v", ref)
+	}
+}
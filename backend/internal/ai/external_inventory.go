@@ -0,0 +1,513 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"iafarma/pkg/models"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+)
+
+// Configurações do estoque externo (ERP do tenant)
+const (
+	// InventorySourceSettingKey define de onde vem o estoque: "local" (padrão), "external" (buscas e estoque
+	// vêm do ERP) ou "hybrid" (catálogo local com o estoque do ERP, mais o que só a busca do ERP encontrar)
+	InventorySourceSettingKey = "inventory_source"
+	// InventoryAPIURLSettingKey é a URL base da API de estoque do ERP (HTTPS)
+	InventoryAPIURLSettingKey = "inventory_api_url"
+	// InventoryAPITokenSettingKey é enviado como "Authorization: Bearer" nas chamadas ao ERP
+	InventoryAPITokenSettingKey = "inventory_api_token"
+)
+
+const (
+	InventorySourceLocal    = "local"
+	InventorySourceExternal = "external"
+	InventorySourceHybrid   = "hybrid"
+)
+
+const (
+	// externalInventoryCacheTTL limita a latência: buscas e consultas repetidas não voltam ao ERP nesse intervalo
+	externalInventoryCacheTTL = time.Minute
+	// externalInventoryTimeout limita quanto uma busca ou consulta espera pelo ERP (todas as chamadas juntas)
+	// antes de usar o catálogo local
+	externalInventoryTimeout = 3 * time.Second
+	// externalInventoryFailureTTL é quanto tempo o ERP que falhou deixa de ser consultado pelo tenant
+	// (as mensagens seguintes usam o catálogo local em vez de esperar o timeout de novo)
+	externalInventoryFailureTTL = 30 * time.Second
+	// externalInventoryConcurrency limita as consultas simultâneas de estoque ao ERP em uma mesma listagem
+	externalInventoryConcurrency = 8
+)
+
+// errExternalInventoryUnavailable indica que o ERP falhou há pouco e está sendo poupado
+var errExternalInventoryUnavailable = errors.New("estoque externo indisponível (falha recente)")
+
+// ExternalInventoryItem é o estoque de um produto informado pelo ERP, identificado pelo SKU do catálogo
+type ExternalInventoryItem struct {
+	SKU           string `json:"sku"`
+	Name          string `json:"name"`
+	StockQuantity int    `json:"stock_quantity"`
+	Available     *bool  `json:"available"` // nil mantém a disponibilidade do catálogo
+}
+
+// InventoryAdapter consulta o estoque de um sistema externo. Os produtos continuam cadastrados no catálogo
+// local (carrinho e pedidos dependem dele); o ERP é a fonte do estoque e da disponibilidade.
+type InventoryAdapter interface {
+	SearchItems(ctx context.Context, query string, limit int) ([]ExternalInventoryItem, error)
+	// GetItem retorna nil, nil quando o ERP não conhece o SKU
+	GetItem(ctx context.Context, sku string) (*ExternalInventoryItem, error)
+}
+
+// externalInventoryConfig é a configuração do ERP de um tenant
+type externalInventoryConfig struct {
+	Source string
+	URL    string
+	Token  string
+}
+
+// productSKULookup localiza no catálogo local os produtos retornados pelo ERP
+type productSKULookup interface {
+	GetProductsBySKU(tenantID uuid.UUID, skus []string) ([]models.Product, error)
+}
+
+// ExternalInventoryProductService aplica o estoque do ERP do tenant às buscas e consultas de produtos.
+// Tenants sem ERP configurado usam o catálogo local sem nenhuma chamada externa.
+type ExternalInventoryProductService struct {
+	ProductServiceInterface
+	settings   TenantSettingsServiceInterface
+	catalog    productSKULookup
+	newAdapter func(config externalInventoryConfig) InventoryAdapter
+	cache      *externalInventoryCache
+}
+
+// NewExternalInventoryProductService envolve o serviço de produtos com o estoque externo configurável por tenant
+func NewExternalInventoryProductService(inner ProductServiceInterface, settings TenantSettingsServiceInterface) *ExternalInventoryProductService {
+	catalog, _ := inner.(productSKULookup)
+	client := newCustomToolHTTPClient()
+	client.Timeout = externalInventoryTimeout
+	return &ExternalInventoryProductService{
+		ProductServiceInterface: inner,
+		settings:                settings,
+		catalog:                 catalog,
+		newAdapter: func(config externalInventoryConfig) InventoryAdapter {
+			return &httpInventoryAdapter{baseURL: config.URL, token: config.Token, client: client}
+		},
+		cache: newExternalInventoryCache(externalInventoryCacheTTL),
+	}
+}
+
+// inventoryConfig lê a configuração do tenant (do snapshot da mensagem, quando o contexto tiver um);
+// URLs inválidas mantêm o catálogo local
+func (s *ExternalInventoryProductService) inventoryConfig(ctx context.Context, tenantID uuid.UUID) externalInventoryConfig {
+	config := externalInventoryConfig{Source: InventorySourceLocal}
+	if s.settings == nil {
+		return config
+	}
+
+	read := func(key string) string {
+		setting, err := s.settings.GetSetting(ctx, tenantID, key)
+		if err != nil || setting == nil || setting.SettingValue == nil {
+			return ""
+		}
+		return strings.TrimSpace(*setting.SettingValue)
+	}
+
+	source := strings.ToLower(read(InventorySourceSettingKey))
+	if source != InventorySourceExternal && source != InventorySourceHybrid {
+		return config
+	}
+	apiURL := read(InventoryAPIURLSettingKey)
+	if err := validateCustomToolWebhookURL(apiURL); err != nil {
+		log.Warn().Err(err).Str("tenant_id", tenantID.String()).Msg("⚠️ URL do estoque externo inválida - usando o catálogo local")
+		return config
+	}
+
+	config.Source = source
+	config.URL = strings.TrimRight(apiURL, "/")
+	config.Token = read(InventoryAPITokenSettingKey)
+	return config
+}
+
+// SearchProducts busca no ERP (modo external) ou no catálogo com o estoque do ERP (modo hybrid)
+func (s *ExternalInventoryProductService) SearchProducts(tenantID uuid.UUID, query string, limit int) ([]models.Product, error) {
+	return s.SearchProductsContext(context.Background(), tenantID, query, limit)
+}
+
+// SearchProductsContext é SearchProducts com o contexto da requisição
+func (s *ExternalInventoryProductService) SearchProductsContext(ctx context.Context, tenantID uuid.UUID, query string, limit int) ([]models.Product, error) {
+	return s.SearchProductsAdvancedContext(ctx, tenantID, ProductSearchFilters{Query: query, Limit: limit})
+}
+
+// SearchProductsAdvanced aplica o estoque do ERP antes do filtro de estoque; o estoque local pode estar desatualizado
+func (s *ExternalInventoryProductService) SearchProductsAdvanced(tenantID uuid.UUID, filters ProductSearchFilters) ([]models.Product, error) {
+	return s.SearchProductsAdvancedContext(context.Background(), tenantID, filters)
+}
+
+// SearchProductsAdvancedContext é SearchProductsAdvanced com o contexto da requisição: as configurações vêm do
+// snapshot da mensagem e todas as chamadas ao ERP dividem um único prazo
+func (s *ExternalInventoryProductService) SearchProductsAdvancedContext(ctx context.Context, tenantID uuid.UUID, filters ProductSearchFilters) ([]models.Product, error) {
+	config := s.inventoryConfig(ctx, tenantID)
+	if config.Source == InventorySourceLocal {
+		return s.ProductServiceInterface.SearchProductsAdvanced(tenantID, filters)
+	}
+	adapter := s.newAdapter(config)
+	ctx, cancel := context.WithTimeout(ctx, externalInventoryTimeout)
+	defer cancel()
+
+	// Busca livre no modo external: o ERP decide quais produtos correspondem
+	if config.Source == InventorySourceExternal && s.catalog != nil && filters.Query != "" && filters.Brand == "" && filters.Tags == "" && filters.CategoryID == nil && filters.MinPrice == 0 && filters.MaxPrice == 0 {
+		products, err := s.searchExternal(ctx, tenantID, adapter, filters)
+		if err == nil {
+			return products, nil
+		}
+		log.Warn().Err(err).Str("tenant_id", tenantID.String()).Msg("⚠️ Busca no estoque externo falhou - usando o catálogo local")
+	}
+
+	localFilters := filters
+	localFilters.IncludeOutOfStock = true
+	products, err := s.ProductServiceInterface.SearchProductsAdvanced(tenantID, localFilters)
+	if err != nil {
+		return nil, err
+	}
+	products = s.applyExternalStock(ctx, tenantID, adapter, products)
+
+	// Modo hybrid: inclui o que só a busca do ERP encontrou (ex: nome comercial diferente no ERP)
+	if config.Source == InventorySourceHybrid && s.catalog != nil && filters.Query != "" {
+		if extra, err := s.searchExternal(ctx, tenantID, adapter, filters); err == nil {
+			products = appendMissingProducts(products, extra)
+		}
+	}
+
+	return filterByStock(products, filters), nil
+}
+
+// GetProductByID retorna o produto do catálogo com o estoque atual do ERP
+func (s *ExternalInventoryProductService) GetProductByID(tenantID, productID uuid.UUID) (*models.Product, error) {
+	return s.GetProductByIDContext(context.Background(), tenantID, productID)
+}
+
+// GetProductByIDContext é GetProductByID com o contexto da requisição
+func (s *ExternalInventoryProductService) GetProductByIDContext(ctx context.Context, tenantID, productID uuid.UUID) (*models.Product, error) {
+	product, err := s.ProductServiceInterface.GetProductByID(tenantID, productID)
+	if err != nil || product == nil {
+		return product, err
+	}
+
+	config := s.inventoryConfig(ctx, tenantID)
+	if config.Source == InventorySourceLocal {
+		return product, nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, externalInventoryTimeout)
+	defer cancel()
+	updated := s.applyExternalStock(ctx, tenantID, s.newAdapter(config), []models.Product{*product})
+	return &updated[0], nil
+}
+
+// loadFromERP consulta o ERP pelo cache. Uma falha poupa o ERP do tenant por externalInventoryFailureTTL: as
+// consultas seguintes usam o catálogo local na hora, em vez de esperar o timeout de novo.
+func (s *ExternalInventoryProductService) loadFromERP(tenantID uuid.UUID, key string, load func() (interface{}, error)) (interface{}, error) {
+	failureKey := fmt.Sprintf("%s|unavailable", tenantID)
+	if s.cache.failedRecently(failureKey) {
+		return nil, errExternalInventoryUnavailable
+	}
+	value, err := s.cache.getOrLoad(key, load)
+	if err != nil {
+		s.cache.setFailure(failureKey)
+	}
+	return value, err
+}
+
+// searchExternal busca no ERP e resolve os itens no catálogo local, na ordem de relevância do ERP
+func (s *ExternalInventoryProductService) searchExternal(ctx context.Context, tenantID uuid.UUID, adapter InventoryAdapter, filters ProductSearchFilters) ([]models.Product, error) {
+	limit := filters.Limit
+	if limit <= 0 {
+		limit = 10
+	}
+
+	key := fmt.Sprintf("%s|search|%s|%d", tenantID, strings.ToLower(strings.TrimSpace(filters.Query)), limit)
+	value, err := s.loadFromERP(tenantID, key, func() (interface{}, error) {
+		return adapter.SearchItems(ctx, filters.Query, limit)
+	})
+	if err != nil {
+		return nil, err
+	}
+	items := value.([]ExternalInventoryItem)
+	if len(items) == 0 {
+		return []models.Product{}, nil
+	}
+
+	skus := make([]string, 0, len(items))
+	for _, item := range items {
+		if item.SKU != "" {
+			skus = append(skus, item.SKU)
+		}
+	}
+	local, err := s.catalog.GetProductsBySKU(tenantID, skus)
+	if err != nil {
+		return nil, err
+	}
+	bySKU := make(map[string]models.Product, len(local))
+	for _, product := range local {
+		bySKU[product.SKU] = product
+	}
+
+	products := make([]models.Product, 0, len(items))
+	for _, item := range items {
+		product, ok := bySKU[item.SKU]
+		if !ok {
+			// Sem cadastro no catálogo o produto não pode ir para o carrinho
+			log.Debug().Str("tenant_id", tenantID.String()).Str("sku", item.SKU).Msg("Produto do ERP sem cadastro no catálogo local")
+			continue
+		}
+		s.cache.set(fmt.Sprintf("%s|sku|%s", tenantID, item.SKU), &item)
+		products = append(products, applyInventoryItem(product, &item))
+	}
+	return filterByStock(products, filters), nil
+}
+
+// applyExternalStock atualiza estoque e disponibilidade pelo ERP, consultando os produtos em paralelo dentro do
+// prazo de ctx. Se o ERP falhar, o produto fica com os dados do catálogo (melhor responder com o estoque local do
+// que não responder)
+func (s *ExternalInventoryProductService) applyExternalStock(ctx context.Context, tenantID uuid.UUID, adapter InventoryAdapter, products []models.Product) []models.Product {
+	var wg sync.WaitGroup
+	slots := make(chan struct{}, externalInventoryConcurrency)
+	for i := range products {
+		if products[i].SKU == "" {
+			continue
+		}
+		wg.Add(1)
+		slots <- struct{}{}
+		go func(i int) {
+			defer wg.Done()
+			defer func() { <-slots }()
+
+			sku := products[i].SKU
+			value, err := s.loadFromERP(tenantID, fmt.Sprintf("%s|sku|%s", tenantID, sku), func() (interface{}, error) {
+				return adapter.GetItem(ctx, sku)
+			})
+			if err != nil {
+				log.Warn().Err(err).Str("tenant_id", tenantID.String()).Str("sku", sku).Msg("⚠️ Estoque externo indisponível - usando o estoque local")
+				return
+			}
+			if item := value.(*ExternalInventoryItem); item != nil {
+				products[i] = applyInventoryItem(products[i], item)
+			}
+		}(i)
+	}
+	wg.Wait()
+	return products
+}
+
+// applyInventoryItem copia o estoque do ERP para o produto do catálogo
+func applyInventoryItem(product models.Product, item *ExternalInventoryItem) models.Product {
+	product.StockQuantity = item.StockQuantity
+	if item.Available != nil {
+		product.Available = *item.Available
+	}
+	return product
+}
+
+// appendMissingProducts acrescenta os produtos de extra que ainda não estão na lista
+func appendMissingProducts(products, extra []models.Product) []models.Product {
+	seen := make(map[uuid.UUID]bool, len(products))
+	for _, product := range products {
+		seen[product.ID] = true
+	}
+	for _, product := range extra {
+		if !seen[product.ID] {
+			seen[product.ID] = true
+			products = append(products, product)
+		}
+	}
+	return products
+}
+
+// filterByStock reaplica os filtros de estoque e disponibilidade depois de o ERP atualizar os produtos
+func filterByStock(products []models.Product, filters ProductSearchFilters) []models.Product {
+	filtered := make([]models.Product, 0, len(products))
+	for _, product := range products {
+		if !filters.IncludeOutOfStock && product.StockQuantity <= 0 {
+			continue
+		}
+		if !filters.IncludeUnavailable && !product.Available {
+			continue
+		}
+		filtered = append(filtered, product)
+	}
+	if filters.Limit > 0 && len(filtered) > filters.Limit {
+		filtered = filtered[:filters.Limit]
+	}
+	return filtered
+}
+
+// externalInventoryCache guarda as respostas do ERP por um curto período (inclusive "SKU não encontrado")
+type externalInventoryCache struct {
+	mu      sync.Mutex
+	entries map[string]externalInventoryCacheEntry
+	ttl     time.Duration
+	now     func() time.Time
+}
+
+type externalInventoryCacheEntry struct {
+	value    interface{}
+	storedAt time.Time
+}
+
+func newExternalInventoryCache(ttl time.Duration) *externalInventoryCache {
+	return &externalInventoryCache{
+		entries: make(map[string]externalInventoryCacheEntry),
+		ttl:     ttl,
+		now:     time.Now,
+	}
+}
+
+func (c *externalInventoryCache) set(key string, value interface{}) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[key] = externalInventoryCacheEntry{value: value, storedAt: c.now()}
+}
+
+// setFailure registra uma falha, lembrada por externalInventoryFailureTTL
+func (c *externalInventoryCache) setFailure(key string) {
+	c.set(key, nil)
+}
+
+// failedRecently indica se houve falha registrada em key há menos de externalInventoryFailureTTL
+func (c *externalInventoryCache) failedRecently(key string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.entries[key]
+	return ok && c.now().Sub(entry.storedAt) <= externalInventoryFailureTTL
+}
+
+// getOrLoad retorna a resposta guardada ou chama load; erros não são guardados (as falhas são registradas à parte)
+func (c *externalInventoryCache) getOrLoad(key string, load func() (interface{}, error)) (interface{}, error) {
+	c.mu.Lock()
+	entry, ok := c.entries[key]
+	if ok && c.now().Sub(entry.storedAt) <= c.ttl {
+		c.mu.Unlock()
+		return entry.value, nil
+	}
+	// Limpa entradas vencidas para o cache não crescer indefinidamente
+	for k, e := range c.entries {
+		if c.now().Sub(e.storedAt) > c.ttl {
+			delete(c.entries, k)
+		}
+	}
+	c.mu.Unlock()
+
+	value, err := load()
+	if err != nil {
+		return nil, err
+	}
+	c.set(key, value)
+	return value, nil
+}
+
+// httpInventoryAdapter consulta a API de estoque do ERP:
+//
+//	GET {url}/products?q=<busca>&limit=<n>  -> {"items": [{"sku", "name", "stock_quantity", "available"}]}
+//	GET {url}/products/{sku}                -> {"sku", "name", "stock_quantity", "available"} (404 = não encontrado)
+type httpInventoryAdapter struct {
+	baseURL string
+	token   string
+	client  *http.Client
+}
+
+var errInventoryNotFound = errors.New("produto não encontrado no estoque externo")
+
+func (a *httpInventoryAdapter) SearchItems(ctx context.Context, query string, limit int) ([]ExternalInventoryItem, error) {
+	params := url.Values{"q": {query}, "limit": {strconv.Itoa(limit)}}
+	var response struct {
+		Items []ExternalInventoryItem `json:"items"`
+	}
+	if err := a.get(ctx, "/products?"+params.Encode(), &response); err != nil {
+		return nil, err
+	}
+	return response.Items, nil
+}
+
+func (a *httpInventoryAdapter) GetItem(ctx context.Context, sku string) (*ExternalInventoryItem, error) {
+	var item ExternalInventoryItem
+	err := a.get(ctx, "/products/"+url.PathEscape(sku), &item)
+	if errors.Is(err, errInventoryNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if item.SKU == "" {
+		item.SKU = sku
+	}
+	return &item, nil
+}
+
+func (a *httpInventoryAdapter) get(ctx context.Context, path string, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, a.baseURL+path, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if a.token != "" {
+		req.Header.Set("Authorization", "Bearer "+a.token)
+	}
+
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("erro ao consultar estoque externo: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return errInventoryNotFound
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("estoque externo respondeu com status %d", resp.StatusCode)
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(out); err != nil {
+		return fmt.Errorf("resposta inválida do estoque externo: %w", err)
+	}
+	return nil
+}
+
+// contextProductService é implementado pelos serviços de produtos que usam o contexto da requisição (snapshot das
+// configurações e prazo da mensagem), como o estoque externo
+type contextProductService interface {
+	SearchProductsContext(ctx context.Context, tenantID uuid.UUID, query string, limit int) ([]models.Product, error)
+	SearchProductsAdvancedContext(ctx context.Context, tenantID uuid.UUID, filters ProductSearchFilters) ([]models.Product, error)
+	GetProductByIDContext(ctx context.Context, tenantID, productID uuid.UUID) (*models.Product, error)
+}
+
+// searchProducts busca produtos repassando o contexto da requisição quando o serviço o aceita
+func (s *AIService) searchProducts(ctx context.Context, tenantID uuid.UUID, query string, limit int) ([]models.Product, error) {
+	if products, ok := s.productService.(contextProductService); ok {
+		return products.SearchProductsContext(ctx, tenantID, query, limit)
+	}
+	return s.productService.SearchProducts(tenantID, query, limit)
+}
+
+// searchProductsAdvanced busca produtos com filtros repassando o contexto da requisição quando o serviço o aceita
+func (s *AIService) searchProductsAdvanced(ctx context.Context, tenantID uuid.UUID, filters ProductSearchFilters) ([]models.Product, error) {
+	if products, ok := s.productService.(contextProductService); ok {
+		return products.SearchProductsAdvancedContext(ctx, tenantID, filters)
+	}
+	return s.productService.SearchProductsAdvanced(tenantID, filters)
+}
+
+// getProductByID busca o produto repassando o contexto da requisição quando o serviço o aceita
+func (s *AIService) getProductByID(ctx context.Context, tenantID, productID uuid.UUID) (*models.Product, error) {
+	if products, ok := s.productService.(contextProductService); ok {
+		return products.GetProductByIDContext(ctx, tenantID, productID)
+	}
+	return s.productService.GetProductByID(tenantID, productID)
+}
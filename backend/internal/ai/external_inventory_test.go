@@ -0,0 +1,423 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"iafarma/pkg/models"
+
+	"github.com/google/uuid"
+)
+
+// catalogProductService é o catálogo local: busca pelo nome e resolve SKUs
+type catalogProductService struct {
+	ProductServiceInterface
+	products []models.Product
+}
+
+func (f *catalogProductService) SearchProductsAdvanced(tenantID uuid.UUID, filters ProductSearchFilters) ([]models.Product, error) {
+	var result []models.Product
+	for _, product := range f.products {
+		if !strings.Contains(strings.ToLower(product.Name), strings.ToLower(filters.Query)) {
+			continue
+		}
+		if !filters.IncludeOutOfStock && product.StockQuantity <= 0 {
+			continue
+		}
+		result = append(result, product)
+	}
+	return result, nil
+}
+
+func (f *catalogProductService) GetProductByID(tenantID, productID uuid.UUID) (*models.Product, error) {
+	for i := range f.products {
+		if f.products[i].ID == productID {
+			product := f.products[i]
+			return &product, nil
+		}
+	}
+	return nil, errors.New("record not found")
+}
+
+func (f *catalogProductService) GetProductsBySKU(tenantID uuid.UUID, skus []string) ([]models.Product, error) {
+	var result []models.Product
+	for _, product := range f.products {
+		for _, sku := range skus {
+			if product.SKU == sku {
+				result = append(result, product)
+			}
+		}
+	}
+	return result, nil
+}
+
+// fakeInventoryAdapter simula o ERP do tenant; delay atrasa cada consulta (respeitando o prazo do contexto)
+type fakeInventoryAdapter struct {
+	mu          sync.Mutex
+	items       map[string]ExternalInventoryItem
+	search      []string
+	err         error
+	delay       time.Duration
+	searchCalls int
+	getCalls    int
+}
+
+func (f *fakeInventoryAdapter) SearchItems(ctx context.Context, query string, limit int) ([]ExternalInventoryItem, error) {
+	f.searchCalls++
+	if f.err != nil {
+		return nil, f.err
+	}
+	var result []ExternalInventoryItem
+	for _, sku := range f.search {
+		result = append(result, f.items[sku])
+	}
+	return result, nil
+}
+
+func (f *fakeInventoryAdapter) GetItem(ctx context.Context, sku string) (*ExternalInventoryItem, error) {
+	f.mu.Lock()
+	f.getCalls++
+	f.mu.Unlock()
+	if f.delay > 0 {
+		select {
+		case <-time.After(f.delay):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	if f.err != nil {
+		return nil, f.err
+	}
+	item, ok := f.items[sku]
+	if !ok {
+		return nil, nil
+	}
+	return &item, nil
+}
+
+func newInventoryTestProduct(name, sku string, stock int) models.Product {
+	product := newPricedTestProduct(name, "10.00")
+	product.SKU = sku
+	product.StockQuantity = stock
+	return product
+}
+
+func newExternalInventoryTestService(source string, adapter *fakeInventoryAdapter, products ...models.Product) (*ExternalInventoryProductService, *int) {
+	settings := &fakeSettingsService{values: map[string]string{
+		InventorySourceSettingKey:   source,
+		InventoryAPIURLSettingKey:   "https://erp.example.com/api",
+		InventoryAPITokenSettingKey: "segredo",
+	}}
+	s := NewExternalInventoryProductService(&catalogProductService{products: products}, settings)
+	created := 0
+	s.newAdapter = func(config externalInventoryConfig) InventoryAdapter {
+		created++
+		return adapter
+	}
+	return s, &created
+}
+
+func TestExternalInventoryDisabledByDefault(t *testing.T) {
+	tenantID := uuid.New()
+	adapter := &fakeInventoryAdapter{}
+
+	for _, source := range []string{"", InventorySourceLocal, "erp"} {
+		s, created := newExternalInventoryTestService(source, adapter, newInventoryTestProduct("Dipirona 500mg", "DIP500", 4))
+
+		products, err := s.SearchProducts(tenantID, "dipirona", 10)
+		if err != nil {
+			t.Fatalf("erro inesperado: %v", err)
+		}
+		if len(products) != 1 || products[0].StockQuantity != 4 {
+			t.Errorf("origem %q: esperado o produto do catálogo local, obtido %+v", source, products)
+		}
+		if *created != 0 {
+			t.Errorf("origem %q: o ERP não deveria ser consultado", source)
+		}
+	}
+}
+
+func TestExternalInventorySearchUsesERP(t *testing.T) {
+	tenantID := uuid.New()
+	dipirona := newInventoryTestProduct("Dipirona 500mg", "DIP500", 0) // sem estoque no banco, com estoque no ERP
+	paracetamol := newInventoryTestProduct("Paracetamol 750mg", "PAR750", 8)
+	novalgina := newInventoryTestProduct("Novalgina 1g", "NOV1G", 3)
+
+	adapter := &fakeInventoryAdapter{
+		items: map[string]ExternalInventoryItem{
+			"NOV1G":  {SKU: "NOV1G", StockQuantity: 12},
+			"DIP500": {SKU: "DIP500", StockQuantity: 30},
+			"PAR750": {SKU: "PAR750", StockQuantity: 0},
+			"SEMCAD": {SKU: "SEMCAD", StockQuantity: 5},
+		},
+		search: []string{"NOV1G", "SEMCAD", "DIP500", "PAR750"},
+	}
+	s, _ := newExternalInventoryTestService(InventorySourceExternal, adapter, dipirona, paracetamol, novalgina)
+
+	products, err := s.SearchProducts(tenantID, "dor de cabeça", 10)
+	if err != nil {
+		t.Fatalf("erro inesperado: %v", err)
+	}
+
+	// Ordem do ERP, só produtos cadastrados no catálogo e com estoque no ERP
+	if len(products) != 2 || products[0].SKU != "NOV1G" || products[1].SKU != "DIP500" {
+		t.Fatalf("esperado Novalgina e Dipirona (ordem do ERP), obtido %+v", products)
+	}
+	if products[0].StockQuantity != 12 || products[1].StockQuantity != 30 {
+		t.Errorf("estoque deveria vir do ERP, obtido %d e %d", products[0].StockQuantity, products[1].StockQuantity)
+	}
+
+	// O estoque da busca vale para a consulta seguinte do mesmo produto (sem nova chamada ao ERP)
+	product, err := s.GetProductByID(tenantID, dipirona.ID)
+	if err != nil {
+		t.Fatalf("erro inesperado: %v", err)
+	}
+	if product.StockQuantity != 30 || adapter.getCalls != 0 {
+		t.Errorf("esperado estoque do ERP em cache, estoque %d, chamadas %d", product.StockQuantity, adapter.getCalls)
+	}
+}
+
+func TestExternalInventoryStockCheckIsCached(t *testing.T) {
+	tenantID := uuid.New()
+	unavailable := false
+	product := newInventoryTestProduct("Vitamina C", "VITC", 10)
+	adapter := &fakeInventoryAdapter{items: map[string]ExternalInventoryItem{
+		"VITC": {SKU: "VITC", StockQuantity: 2, Available: &unavailable},
+	}}
+	s, _ := newExternalInventoryTestService(InventorySourceHybrid, adapter, product)
+
+	current := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	s.cache.now = func() time.Time { return current }
+
+	for i := 0; i < 3; i++ {
+		found, err := s.GetProductByID(tenantID, product.ID)
+		if err != nil {
+			t.Fatalf("erro inesperado: %v", err)
+		}
+		if found.StockQuantity != 2 || found.Available {
+			t.Fatalf("esperado estoque e disponibilidade do ERP, obtido %+v", found)
+		}
+	}
+	if adapter.getCalls != 1 {
+		t.Errorf("consultas repetidas deveriam usar o cache, chamadas ao ERP: %d", adapter.getCalls)
+	}
+
+	current = current.Add(externalInventoryCacheTTL + time.Second)
+	if _, err := s.GetProductByID(tenantID, product.ID); err != nil {
+		t.Fatalf("erro inesperado: %v", err)
+	}
+	if adapter.getCalls != 2 {
+		t.Errorf("cache vencido deveria consultar o ERP de novo, chamadas: %d", adapter.getCalls)
+	}
+}
+
+func TestExternalInventoryHybridAddsERPMatches(t *testing.T) {
+	tenantID := uuid.New()
+	dipirona := newInventoryTestProduct("Dipirona 500mg", "DIP500", 0)
+	generico := newInventoryTestProduct("Metamizol Sódico 500mg", "MET500", 0)
+	adapter := &fakeInventoryAdapter{
+		items: map[string]ExternalInventoryItem{
+			"DIP500": {SKU: "DIP500", StockQuantity: 7},
+			"MET500": {SKU: "MET500", StockQuantity: 4},
+		},
+		search: []string{"MET500"},
+	}
+	s, _ := newExternalInventoryTestService(InventorySourceHybrid, adapter, dipirona, generico)
+
+	products, err := s.SearchProducts(tenantID, "dipirona", 10)
+	if err != nil {
+		t.Fatalf("erro inesperado: %v", err)
+	}
+	if len(products) != 2 || products[0].SKU != "DIP500" || products[0].StockQuantity != 7 || products[1].SKU != "MET500" {
+		t.Errorf("esperado resultado local com estoque do ERP mais o genérico encontrado pelo ERP, obtido %+v", products)
+	}
+}
+
+func TestExternalInventoryFallsBackToLocalCatalog(t *testing.T) {
+	tenantID := uuid.New()
+	adapter := &fakeInventoryAdapter{err: errors.New("ERP fora do ar")}
+	product := newInventoryTestProduct("Dipirona 500mg", "DIP500", 4)
+	s, _ := newExternalInventoryTestService(InventorySourceExternal, adapter, product)
+
+	products, err := s.SearchProducts(tenantID, "dipirona", 10)
+	if err != nil {
+		t.Fatalf("falha do ERP não deveria virar erro para o cliente: %v", err)
+	}
+	if len(products) != 1 || products[0].StockQuantity != 4 {
+		t.Errorf("esperado o catálogo local com o estoque local, obtido %+v", products)
+	}
+
+	found, err := s.GetProductByID(tenantID, product.ID)
+	if err != nil || found.StockQuantity != 4 {
+		t.Errorf("consulta deveria usar o estoque local, obtido %+v, erro %v", found, err)
+	}
+}
+
+func TestExternalInventoryStockLookupsRunConcurrently(t *testing.T) {
+	tenantID := uuid.New()
+	adapter := &fakeInventoryAdapter{items: map[string]ExternalInventoryItem{}, delay: 200 * time.Millisecond}
+	var products []models.Product
+	for i := 0; i < externalInventoryConcurrency; i++ {
+		sku := "SKU" + string(rune('A'+i))
+		products = append(products, newInventoryTestProduct("Vitamina "+sku, sku, 1))
+		adapter.items[sku] = ExternalInventoryItem{SKU: sku, StockQuantity: 20}
+	}
+	s, _ := newExternalInventoryTestService(InventorySourceHybrid, adapter, products...)
+
+	started := time.Now()
+	found, err := s.SearchProducts(tenantID, "vitamina", 20)
+	if err != nil {
+		t.Fatalf("erro inesperado: %v", err)
+	}
+	if elapsed := time.Since(started); elapsed > 4*adapter.delay {
+		t.Errorf("consultas ao ERP deveriam ser paralelas, levou %s para %d produtos", elapsed, len(products))
+	}
+	if len(found) != len(products) || found[0].StockQuantity != 20 {
+		t.Errorf("esperado estoque do ERP em todos os produtos, obtido %+v", found)
+	}
+}
+
+func TestExternalInventorySharesRequestDeadline(t *testing.T) {
+	tenantID := uuid.New()
+	adapter := &fakeInventoryAdapter{items: map[string]ExternalInventoryItem{}, delay: time.Minute}
+	var products []models.Product
+	for i := 0; i < 3*externalInventoryConcurrency; i++ {
+		sku := "SKU" + string(rune('A'+i))
+		products = append(products, newInventoryTestProduct("Vitamina "+sku, sku, 2))
+	}
+	s, _ := newExternalInventoryTestService(InventorySourceHybrid, adapter, products...)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	started := time.Now()
+	found, err := s.SearchProductsAdvancedContext(ctx, tenantID, ProductSearchFilters{Query: "vitamina", Limit: 50})
+	if err != nil {
+		t.Fatalf("erro inesperado: %v", err)
+	}
+	if elapsed := time.Since(started); elapsed > time.Second {
+		t.Errorf("todas as consultas deveriam respeitar o mesmo prazo, levou %s", elapsed)
+	}
+	if len(found) != len(products) || found[0].StockQuantity != 2 {
+		t.Errorf("ERP lento deveria manter o estoque local, obtido %+v", found)
+	}
+}
+
+func TestExternalInventoryBacksOffAfterFailure(t *testing.T) {
+	tenantID := uuid.New()
+	adapter := &fakeInventoryAdapter{err: errors.New("ERP fora do ar")}
+	product := newInventoryTestProduct("Dipirona 500mg", "DIP500", 4)
+	s, _ := newExternalInventoryTestService(InventorySourceHybrid, adapter, product)
+
+	current := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	s.cache.now = func() time.Time { return current }
+
+	for i := 0; i < 3; i++ {
+		found, err := s.GetProductByID(tenantID, product.ID)
+		if err != nil || found.StockQuantity != 4 {
+			t.Fatalf("esperado estoque local, obtido %+v, erro %v", found, err)
+		}
+	}
+	if adapter.getCalls != 1 {
+		t.Errorf("ERP com falha recente não deveria ser consultado de novo, chamadas: %d", adapter.getCalls)
+	}
+
+	current = current.Add(externalInventoryFailureTTL + time.Second)
+	if _, err := s.GetProductByID(tenantID, product.ID); err != nil {
+		t.Fatalf("erro inesperado: %v", err)
+	}
+	if adapter.getCalls != 2 {
+		t.Errorf("depois do intervalo o ERP deveria ser consultado de novo, chamadas: %d", adapter.getCalls)
+	}
+}
+
+type inventoryRequestKey struct{}
+
+// requestSettingsService registra se as configurações foram lidas com o contexto da requisição
+type requestSettingsService struct {
+	*fakeSettingsService
+	withRequestContext bool
+}
+
+func (f *requestSettingsService) GetSetting(ctx context.Context, tenantID uuid.UUID, key string) (*models.TenantSetting, error) {
+	if ctx.Value(inventoryRequestKey{}) != nil {
+		f.withRequestContext = true
+	}
+	return f.fakeSettingsService.GetSetting(ctx, tenantID, key)
+}
+
+func TestExternalInventoryReadsSettingsFromRequestContext(t *testing.T) {
+	adapter := &fakeInventoryAdapter{items: map[string]ExternalInventoryItem{"DIP500": {SKU: "DIP500", StockQuantity: 9}}}
+	product := newInventoryTestProduct("Dipirona 500mg", "DIP500", 4)
+	s, _ := newExternalInventoryTestService(InventorySourceHybrid, adapter, product)
+	settings := &requestSettingsService{fakeSettingsService: s.settings.(*fakeSettingsService)}
+	s.settings = settings
+
+	service := &AIService{productService: s}
+	ctx := context.WithValue(context.Background(), inventoryRequestKey{}, true)
+	found, err := service.getProductByID(ctx, uuid.New(), product.ID)
+	if err != nil || found.StockQuantity != 9 {
+		t.Fatalf("esperado estoque do ERP, obtido %+v, erro %v", found, err)
+	}
+	if !settings.withRequestContext {
+		t.Error("configuração do ERP deveria ser lida com o contexto da requisição (snapshot da mensagem)")
+	}
+}
+
+func TestExternalInventoryRejectsUnsafeURL(t *testing.T) {
+	tenantID := uuid.New()
+	s, created := newExternalInventoryTestService(InventorySourceExternal, &fakeInventoryAdapter{}, newInventoryTestProduct("Dipirona 500mg", "DIP500", 4))
+	s.settings = &fakeSettingsService{values: map[string]string{
+		InventorySourceSettingKey: InventorySourceExternal,
+		InventoryAPIURLSettingKey: "http://10.0.0.5/estoque",
+	}}
+
+	if _, err := s.SearchProducts(tenantID, "dipirona", 10); err != nil {
+		t.Fatalf("erro inesperado: %v", err)
+	}
+	if *created != 0 {
+		t.Error("URL sem HTTPS ou interna não deveria ser consultada")
+	}
+}
+
+func TestHTTPInventoryAdapter(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer segredo" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.URL.Path == "/products" && r.URL.Query().Get("q") == "dipirona" && r.URL.Query().Get("limit") == "5":
+			json.NewEncoder(w).Encode(map[string]interface{}{"items": []ExternalInventoryItem{{SKU: "DIP500", StockQuantity: 9}}})
+		case r.URL.Path == "/products/DIP500":
+			w.Write([]byte(`{"sku":"DIP500","name":"Dipirona","stock_quantity":9,"available":true}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	adapter := &httpInventoryAdapter{baseURL: server.URL, token: "segredo", client: server.Client()}
+
+	items, err := adapter.SearchItems(context.Background(), "dipirona", 5)
+	if err != nil || len(items) != 1 || items[0].SKU != "DIP500" || items[0].StockQuantity != 9 {
+		t.Errorf("busca no ERP: esperado DIP500 com 9 unidades, obtido %+v, erro %v", items, err)
+	}
+
+	item, err := adapter.GetItem(context.Background(), "DIP500")
+	if err != nil || item == nil || item.StockQuantity != 9 || item.Available == nil || !*item.Available {
+		t.Errorf("consulta no ERP: esperado DIP500 disponível, obtido %+v, erro %v", item, err)
+	}
+
+	item, err = adapter.GetItem(context.Background(), "NAOEXISTE")
+	if err != nil || item != nil {
+		t.Errorf("SKU desconhecido pelo ERP deveria retornar nil sem erro, obtido %+v, erro %v", item, err)
+	}
+}
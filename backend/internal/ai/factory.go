@@ -100,6 +100,8 @@ func NewAIServiceFactoryComplete(db *gorm.DB, openaiAPIKey string, deliveryServi
 	customerService := NewCustomerService(db)
 	addressService := NewAddressService(db)
 	settingsService := NewTenantSettingsService(db)
+	// Estoque do ERP do tenant (quando configurado) nas buscas e consultas de produtos
+	productService = NewExternalInventoryProductService(productService, settingsService)
 	errorHandler := NewErrorHandler(db)
 
 	// Create category service
@@ -117,11 +119,23 @@ func NewAIServiceFactoryComplete(db *gorm.DB, openaiAPIKey string, deliveryServi
 		return sendHumanSupportAlertWithWebSocket(db, wsHandler, tenantID, customerID, customerPhone, reason)
 	})
 
+	// Set the special order alert function
+	alertService.SetSendSpecialOrderAlertFunc(func(tenantID uuid.UUID, customerID uuid.UUID, customerPhone string, description string) error {
+		return sendSpecialOrderAlertWithWebSocket(db, wsHandler, tenantID, customerID, customerPhone, description)
+	})
+
+	// Set the return request alert function
+	alertService.SetSendReturnRequestAlertFunc(func(tenantID uuid.UUID, customerID uuid.UUID, customerPhone string, description string) error {
+		return sendReturnRequestAlertWithWebSocket(db, wsHandler, tenantID, customerID, customerPhone, description)
+	})
+
 	// If no delivery service provided, create a default one
 	if deliveryService == nil {
 		// Create a default implementation that returns "not configured"
 		deliveryService = &defaultDeliveryService{}
 	}
+	// Retry e circuit breaker para não bloquear o checkout quando o serviço de entrega estiver instável
+	deliveryService = NewResilientDeliveryService(deliveryService)
 
 	// Initialize S3 client for storage
 	var s3Client *s3.S3
@@ -165,24 +179,33 @@ func NewAIServiceFactoryComplete(db *gorm.DB, openaiAPIKey string, deliveryServi
 
 	// Create and return AI service
 	aiService := &AIService{
-		client:           client,
-		productService:   productService,
-		cartService:      cartService,
-		orderService:     orderService,
-		customerService:  customerService,
-		messageService:   NewMessageService(db),
-		addressService:   addressService,
-		settingsService:  settingsService,
-		municipioService: nil, // Será implementado posteriormente
-		categoryService:  categoryService,
-		memoryManager:    GetGlobalMemoryManagerWithDB(db), // Use singleton with DB persistence
-		errorHandler:     errorHandler,
-		alertService:     alertService,
-		deliveryService:  deliveryService,
-		embeddingService: embeddingService,
-		s3Client:         s3Client,
-		s3Bucket:         s3Bucket,
-		s3BaseURL:        s3BaseURL,
+		client:               client,
+		productService:       productService,
+		cartService:          cartService,
+		orderService:         orderService,
+		customerService:      customerService,
+		messageService:       NewMessageService(db),
+		addressService:       addressService,
+		settingsService:      settingsService,
+		municipioService:     nil, // Será implementado posteriormente
+		categoryService:      categoryService,
+		memoryManager:        GetGlobalMemoryManagerWithDB(db), // Use singleton with DB persistence
+		errorHandler:         errorHandler,
+		alertService:         alertService,
+		deliveryService:      deliveryService,
+		embeddingService:     embeddingService,
+		faqService:           NewFAQService(db),
+		customToolService:    NewCustomToolService(db),
+		conversationService:  NewConversationService(db),
+		toolMetricsService:   NewToolMetricsService(db),
+		missingDemandService: NewMissingDemandService(db),
+		subscriptionService:  NewSubscriptionService(db),
+		couponService:        NewCouponService(db),
+		returnRequestService: NewReturnRequestService(db),
+		s3Client:             s3Client,
+		s3Bucket:             s3Bucket,
+		s3BaseURL:            s3BaseURL,
+		unhelpfulTracker:     NewUnhelpfulResponseTracker(),
 	}
 
 	return aiService
@@ -244,6 +267,58 @@ func sendHumanSupportAlertWithWebSocket(db *gorm.DB, wsHandler WebSocketBroadcas
 	return err
 }
 
+// sendSpecialOrderAlertWithWebSocket sends alerts when customer requests a product outside the catalog (with WebSocket support)
+func sendSpecialOrderAlertWithWebSocket(db *gorm.DB, wsHandler WebSocketBroadcaster, tenantID uuid.UUID, customerID uuid.UUID, customerPhone string, description string) error {
+	notificationService := zapplus.NewNotificationService(db)
+
+	err := notificationService.SendSpecialOrderAlert(tenantID, customerID, customerPhone, description)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to send special order alert via ZapPlus")
+	}
+
+	if wsHandler != nil {
+		var customer models.Customer
+		if err := db.Where("id = ? AND tenant_id = ?", customerID, tenantID).First(&customer).Error; err == nil {
+			alertData := map[string]interface{}{
+				"customer_id":    customerID.String(),
+				"customer_name":  customer.Name,
+				"customer_phone": customerPhone,
+				"product":        description,
+				"timestamp":      time.Now().Format("02/01/2006 15:04"),
+			}
+			wsHandler.BroadcastToTenant(tenantID.String(), "special_order_request", alertData)
+		}
+	}
+
+	return err
+}
+
+// sendReturnRequestAlertWithWebSocket sends alerts when customer requests a return or exchange of a delivered order (with WebSocket support)
+func sendReturnRequestAlertWithWebSocket(db *gorm.DB, wsHandler WebSocketBroadcaster, tenantID uuid.UUID, customerID uuid.UUID, customerPhone string, description string) error {
+	notificationService := zapplus.NewNotificationService(db)
+
+	err := notificationService.SendReturnRequestAlert(tenantID, customerID, customerPhone, description)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to send return request alert via ZapPlus")
+	}
+
+	if wsHandler != nil {
+		var customer models.Customer
+		if err := db.Where("id = ? AND tenant_id = ?", customerID, tenantID).First(&customer).Error; err == nil {
+			alertData := map[string]interface{}{
+				"customer_id":    customerID.String(),
+				"customer_name":  customer.Name,
+				"customer_phone": customerPhone,
+				"request":        description,
+				"timestamp":      time.Now().Format("02/01/2006 15:04"),
+			}
+			wsHandler.BroadcastToTenant(tenantID.String(), "return_request", alertData)
+		}
+	}
+
+	return err
+}
+
 // MockLocationService implements LocationServiceInterface for scheduling
 type MockLocationService struct {
 	db *gorm.DB
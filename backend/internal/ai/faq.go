@@ -0,0 +1,149 @@
+package ai
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"iafarma/pkg/models"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// faqMinMatchScore é a fração mínima dos termos da pergunta que precisam aparecer na FAQ
+	faqMinMatchScore = 0.5
+	// faqStemLength é o prefixo usado para aproximar variações (devolução/devoluções, entrega/entregam)
+	faqStemLength = 6
+
+	// faqNoMatchMessage segue a limitação de contexto padrão quando nenhuma FAQ responde a pergunta
+	faqNoMatchMessage = "🤔 Não encontrei essa informação nas perguntas frequentes da loja.\n\nSou um assistente focado em vendas da nossa loja. Como posso ajudá-lo com nossos produtos ou serviços?"
+)
+
+// faqStopWords são palavras comuns ignoradas na comparação da pergunta com a FAQ
+var faqStopWords = map[string]bool{
+	"voces": true, "voce": true, "vcs": true, "tem": true, "que": true, "qual": true, "quais": true,
+	"como": true, "onde": true, "quando": true, "uma": true, "uns": true, "umas": true, "para": true,
+	"pra": true, "com": true, "sem": true, "por": true, "sao": true, "esta": true, "estao": true,
+	"aqui": true, "loja": true, "meu": true, "minha": true, "isso": true, "essa": true, "esse": true,
+	"posso": true, "pode": true, "podem": true, "gostaria": true, "saber": true, "queria": true,
+	"sobre": true, "dos": true, "das": true, "nos": true, "nas": true, "ate": true, "mais": true,
+	"fazer": true, "faz": true, "aceita": true, "aceitam": true,
+}
+
+// faqWords normaliza o texto (minúsculas, sem acentos) e divide em palavras
+func faqWords(text string) []string {
+	return strings.FieldsFunc(accentReplacer.Replace(strings.ToLower(text)), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// faqTokens retorna os termos relevantes do texto, sem stop words e sem repetições
+func faqTokens(text string) []string {
+	var tokens []string
+	seen := make(map[string]bool)
+	for _, word := range faqWords(text) {
+		if len(word) < 3 || faqStopWords[word] || seen[word] {
+			continue
+		}
+		seen[word] = true
+		tokens = append(tokens, word)
+	}
+	return tokens
+}
+
+// faqTokenMatches compara termos aceitando variações com o mesmo radical
+func faqTokenMatches(a, b string) bool {
+	if a == b {
+		return true
+	}
+	if len(a) < faqStemLength || len(b) < faqStemLength {
+		return false
+	}
+	return a[:faqStemLength] == b[:faqStemLength]
+}
+
+// scoreFAQEntry calcula a fração dos termos da pergunta encontrados na FAQ (pergunta cadastrada + palavras-chave).
+// Uma palavra-chave contida na pergunta é considerada correspondência total.
+func scoreFAQEntry(entry models.FAQEntry, question string, questionTokens []string) float64 {
+	normalizedQuestion := " " + strings.Join(faqWords(question), " ") + " "
+	for _, keyword := range strings.FieldsFunc(entry.Keywords, func(r rune) bool { return r == ',' || r == ';' }) {
+		if words := faqWords(keyword); len(words) > 0 && strings.Contains(normalizedQuestion, " "+strings.Join(words, " ")+" ") {
+			return 1
+		}
+	}
+
+	if len(questionTokens) == 0 {
+		return 0
+	}
+
+	entryTokens := faqTokens(entry.Question + " " + entry.Keywords)
+	matches := 0
+	for _, questionToken := range questionTokens {
+		for _, entryToken := range entryTokens {
+			if faqTokenMatches(questionToken, entryToken) {
+				matches++
+				break
+			}
+		}
+	}
+	return float64(matches) / float64(len(questionTokens))
+}
+
+// matchFAQ retorna a FAQ que melhor responde a pergunta (nil se nenhuma atingir a pontuação mínima)
+func matchFAQ(entries []models.FAQEntry, question string) *models.FAQEntry {
+	questionTokens := faqTokens(question)
+
+	var best *models.FAQEntry
+	bestScore := 0.0
+	for i := range entries {
+		score := scoreFAQEntry(entries[i], question, questionTokens)
+		if score > bestScore {
+			best = &entries[i]
+			bestScore = score
+		}
+	}
+
+	if best == nil || bestScore < faqMinMatchScore {
+		return nil
+	}
+	return best
+}
+
+// handleConsultarFAQ responde dúvidas sobre a loja com base nas perguntas frequentes cadastradas pelo tenant
+func (s *AIService) handleConsultarFAQ(tenantID uuid.UUID, args map[string]interface{}) (string, error) {
+	question, _ := args["pergunta"].(string)
+	question = strings.TrimSpace(question)
+	if question == "" {
+		return "❌ Informe a dúvida do cliente para consultar as perguntas frequentes.", nil
+	}
+
+	if s.faqService == nil {
+		return faqNoMatchMessage, nil
+	}
+
+	entries, err := s.faqService.GetActiveFAQs(tenantID)
+	if err != nil {
+		log.Error().Err(err).Str("tenant_id", tenantID.String()).Msg("Erro ao buscar perguntas frequentes")
+		return faqNoMatchMessage, nil
+	}
+
+	entry := matchFAQ(entries, question)
+	if entry == nil {
+		log.Info().
+			Str("tenant_id", tenantID.String()).
+			Str("question", question).
+			Int("faq_entries", len(entries)).
+			Msg("❓ Nenhuma pergunta frequente corresponde à dúvida do cliente")
+		return faqNoMatchMessage, nil
+	}
+
+	log.Info().
+		Str("tenant_id", tenantID.String()).
+		Str("question", question).
+		Str("faq_id", entry.ID.String()).
+		Msg("💡 Dúvida respondida pelas perguntas frequentes")
+
+	return fmt.Sprintf("💡 %s", entry.Answer), nil
+}
@@ -0,0 +1,96 @@
+package ai
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"iafarma/pkg/models"
+
+	"github.com/google/uuid"
+)
+
+// fakeFAQService retorna as perguntas frequentes configuradas no teste
+type fakeFAQService struct {
+	entries []models.FAQEntry
+	err     error
+}
+
+func (f *fakeFAQService) GetActiveFAQs(tenantID uuid.UUID) ([]models.FAQEntry, error) {
+	return f.entries, f.err
+}
+
+func newFAQTestEntries() []models.FAQEntry {
+	return []models.FAQEntry{
+		{Question: "Vocês têm estacionamento?", Answer: "Sim! Temos estacionamento gratuito para clientes."},
+		{Question: "Qual a política de devolução?", Answer: "Aceitamos devoluções em até 7 dias com a nota fiscal."},
+		{Question: "Emitem nota fiscal?", Answer: "Sim, emitimos NF-e em todos os pedidos.", Keywords: "cupom fiscal, cpf na nota"},
+	}
+}
+
+func TestMatchFAQ(t *testing.T) {
+	entries := newFAQTestEntries()
+
+	tests := []struct {
+		question string
+		esperado string
+	}{
+		{"têm estacionamento?", "estacionamento gratuito"},
+		{"Aceitam devolução?", "devoluções em até 7 dias"},
+		{"posso trocar ou fazer devolucoes?", "devoluções em até 7 dias"},
+		{"Dá pra colocar CPF na nota?", "NF-e"},
+		{"quem ganhou o jogo ontem?", ""},
+		{"qual a previsão do tempo amanhã?", ""},
+		{"???", ""},
+	}
+
+	for _, tt := range tests {
+		entry := matchFAQ(entries, tt.question)
+		if tt.esperado == "" {
+			if entry != nil {
+				t.Errorf("%q: esperado sem correspondência, obtido %q", tt.question, entry.Question)
+			}
+			continue
+		}
+		if entry == nil || !strings.Contains(entry.Answer, tt.esperado) {
+			t.Errorf("%q: esperado resposta contendo %q, obtido %+v", tt.question, tt.esperado, entry)
+		}
+	}
+}
+
+func TestHandleConsultarFAQ(t *testing.T) {
+	tenantID := uuid.New()
+
+	tests := []struct {
+		name     string
+		service  FAQServiceInterface
+		pergunta interface{}
+		esperado string
+	}{
+		{"pergunta respondida", &fakeFAQService{entries: newFAQTestEntries()}, "vocês têm estacionamento?", "💡 Sim! Temos estacionamento gratuito"},
+		{"sem correspondência aplica limitação de contexto", &fakeFAQService{entries: newFAQTestEntries()}, "quem vai ganhar a eleição?", "assistente focado em vendas"},
+		{"tenant sem FAQ", &fakeFAQService{}, "têm estacionamento?", "assistente focado em vendas"},
+		{"erro ao buscar FAQ", &fakeFAQService{err: errors.New("db down")}, "têm estacionamento?", "assistente focado em vendas"},
+		{"sem serviço de FAQ", nil, "têm estacionamento?", "assistente focado em vendas"},
+		{"pergunta vazia", &fakeFAQService{entries: newFAQTestEntries()}, " ", "❌"},
+		{"pergunta ausente", &fakeFAQService{entries: newFAQTestEntries()}, nil, "❌"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &AIService{faqService: tt.service}
+			args := map[string]interface{}{}
+			if tt.pergunta != nil {
+				args["pergunta"] = tt.pergunta
+			}
+
+			result, err := s.handleConsultarFAQ(tenantID, args)
+			if err != nil {
+				t.Fatalf("erro inesperado: %v", err)
+			}
+			if !strings.Contains(result, tt.esperado) {
+				t.Errorf("esperado resposta contendo %q, obtido %q", tt.esperado, result)
+			}
+		})
+	}
+}
@@ -0,0 +1,195 @@
+package ai
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"iafarma/internal/utils"
+	"iafarma/pkg/models"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// DeliveryFeeSettingKey define a taxa de entrega fixa cobrada nos pedidos com entrega ("0" = sem taxa)
+	DeliveryFeeSettingKey = "ai_delivery_fee"
+	// FreeShippingMinAmountSettingKey define o valor mínimo do pedido para frete grátis ("0" = sem campanha)
+	FreeShippingMinAmountSettingKey = "ai_free_shipping_min_amount"
+	// FreeShippingStartsAtSettingKey e FreeShippingEndsAtSettingKey limitam a campanha de frete grátis (YYYY-MM-DD, opcionais e inclusivos)
+	FreeShippingStartsAtSettingKey = "ai_free_shipping_starts_at"
+	FreeShippingEndsAtSettingKey   = "ai_free_shipping_ends_at"
+
+	freeShippingDateLayout = "2006-01-02"
+)
+
+// storeLocation é o fuso padrão das lojas, usado para interpretar datas (campanhas, número do pedido)
+var storeLocation = func() *time.Location {
+	location, err := time.LoadLocation("America/Sao_Paulo")
+	if err != nil {
+		return time.UTC
+	}
+	return location
+}()
+
+// deliveryFeeConfig reúne a taxa de entrega e a campanha de frete grátis do tenant
+type deliveryFeeConfig struct {
+	Fee             float64
+	FreeShippingMin float64
+	StartsAt        time.Time // zero = sem data de início
+	EndsAt          time.Time // exclusivo (dia seguinte à data final); zero = sem data de fim
+	PerKg           float64   // frete por quilo dos produtos (0 = sem cobrança por peso)
+	DefaultWeight   float64   // peso em gramas assumido para produtos sem peso cadastrado (0 = sinalizar)
+}
+
+// deliveryFeeQuote é a taxa calculada para um subtotal de carrinho
+type deliveryFeeQuote struct {
+	Fee          float64
+	FreeShipping bool
+	Remaining    float64 // quanto falta para o frete grátis (0 quando não há campanha ativa)
+	// MissingWeight lista (separados por vírgula) os produtos sem peso cadastrado que ficaram fora do frete por peso
+	MissingWeight string
+}
+
+// getDeliveryFeeConfig lê a taxa de entrega e a campanha de frete grátis do tenant (sem taxa por padrão)
+func (s *AIService) getDeliveryFeeConfig(ctx context.Context, tenantID uuid.UUID) deliveryFeeConfig {
+	var config deliveryFeeConfig
+	if s.settingsService == nil {
+		return config
+	}
+
+	read := func(key string) string {
+		setting, err := s.settingsService.GetSetting(ctx, tenantID, key)
+		if err != nil || setting == nil || setting.SettingValue == nil {
+			return ""
+		}
+		return strings.TrimSpace(*setting.SettingValue)
+	}
+	amount := func(key string) float64 {
+		value, err := strconv.ParseFloat(strings.Replace(read(key), ",", ".", 1), 64)
+		if err != nil || value < 0 {
+			return 0
+		}
+		return value
+	}
+
+	config.Fee = amount(DeliveryFeeSettingKey)
+	config.FreeShippingMin = amount(FreeShippingMinAmountSettingKey)
+	config.PerKg = amount(ShippingFeePerKgSettingKey)
+	config.DefaultWeight = amount(ShippingDefaultWeightSettingKey)
+
+	if startsAt := read(FreeShippingStartsAtSettingKey); startsAt != "" {
+		if date, err := time.ParseInLocation(freeShippingDateLayout, startsAt, storeLocation); err == nil {
+			config.StartsAt = date
+		} else {
+			log.Warn().Str("tenant_id", tenantID.String()).Str("starts_at", startsAt).Msg("⚠️ Data de início do frete grátis inválida")
+		}
+	}
+	if endsAt := read(FreeShippingEndsAtSettingKey); endsAt != "" {
+		if date, err := time.ParseInLocation(freeShippingDateLayout, endsAt, storeLocation); err == nil {
+			config.EndsAt = date.AddDate(0, 0, 1)
+		} else {
+			log.Warn().Str("tenant_id", tenantID.String()).Str("ends_at", endsAt).Msg("⚠️ Data final do frete grátis inválida")
+		}
+	}
+
+	return config
+}
+
+// freeShippingActive indica se a campanha de frete grátis vale no momento informado
+func (c deliveryFeeConfig) freeShippingActive(now time.Time) bool {
+	if c.FreeShippingMin <= 0 {
+		return false
+	}
+	if !c.StartsAt.IsZero() && now.Before(c.StartsAt) {
+		return false
+	}
+	if !c.EndsAt.IsZero() && !now.Before(c.EndsAt) {
+		return false
+	}
+	return true
+}
+
+// quote calcula a taxa de entrega de um carrinho, zerando-a quando ele atinge o frete grátis
+func (c deliveryFeeConfig) quote(subtotal float64, now time.Time) deliveryFeeQuote {
+	if c.Fee <= 0 {
+		return deliveryFeeQuote{}
+	}
+	if !c.freeShippingActive(now) {
+		return deliveryFeeQuote{Fee: c.Fee}
+	}
+	if subtotal >= c.FreeShippingMin {
+		return deliveryFeeQuote{FreeShipping: true}
+	}
+	return deliveryFeeQuote{Fee: c.Fee, Remaining: (utils.CentsFromFloat(c.FreeShippingMin) - utils.CentsFromFloat(subtotal)).Float()}
+}
+
+// freeShippingNudge é o lembrete de quanto falta para o frete grátis (ou que ele já foi alcançado)
+func (q deliveryFeeQuote) freeShippingNudge() string {
+	if q.FreeShipping {
+		return "🎉 Seu pedido já ganhou **frete grátis**!"
+	}
+	if q.Remaining > 0 {
+		return fmt.Sprintf("🚚 Faltam **R$ %s** para ganhar frete grátis!", formatCurrency(utils.FormatMoney(q.Remaining)))
+	}
+	return ""
+}
+
+// cartItemTotal calcula o total do item em centavos (preço unitário arredondado x quantidade)
+func cartItemTotal(item models.CartItem) utils.Cents {
+	price, _ := utils.ParseCents(item.Price)
+	return price.Times(item.Quantity)
+}
+
+// cartSubtotal soma os itens do carrinho em centavos, sem acumular erro de ponto flutuante
+func cartSubtotal(cart *models.Cart) float64 {
+	var subtotal utils.Cents
+	if cart == nil {
+		return 0
+	}
+	for _, item := range cart.Items {
+		subtotal += cartItemTotal(item)
+	}
+	return subtotal.Float()
+}
+
+// quoteCartDeliveryFee calcula a taxa de entrega do carrinho (taxa fixa + frete por peso); retirada na loja não paga entrega
+func (s *AIService) quoteCartDeliveryFee(ctx context.Context, tenantID uuid.UUID, cart *models.Cart) deliveryFeeQuote {
+	if s.isPickupCart(ctx, tenantID, cart) {
+		return deliveryFeeQuote{}
+	}
+	config := s.getDeliveryFeeConfig(ctx, tenantID)
+	weightFee, missing := config.cartWeightFee(cart)
+	config.Fee += weightFee
+
+	quote := config.quote(cartSubtotal(cart), time.Now())
+	if quote.Fee > 0 {
+		quote.MissingWeight = strings.Join(missing, ", ")
+	}
+	return quote
+}
+
+// formatCartDeliveryFee monta as linhas de entrega do resumo do carrinho
+func formatCartDeliveryFee(quote deliveryFeeQuote, subtotal float64) string {
+	var lines []string
+	switch {
+	case quote.FreeShipping:
+		lines = append(lines, "🚚 Entrega: **grátis**")
+	case quote.Fee > 0:
+		fee := utils.CentsFromFloat(quote.Fee)
+		lines = append(lines,
+			fmt.Sprintf("🚚 Entrega: R$ %s", formatCurrency(fee.String())),
+			fmt.Sprintf("💳 **Total com entrega: R$ %s**", formatCurrency((utils.CentsFromFloat(subtotal)+fee).String())),
+		)
+	}
+	if quote.MissingWeight != "" {
+		lines = append(lines, missingWeightNote(quote.MissingWeight))
+	}
+	if quote.Remaining > 0 {
+		lines = append(lines, quote.freeShippingNudge())
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,101 @@
+package ai
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"iafarma/pkg/models"
+
+	"github.com/google/uuid"
+)
+
+func TestDeliveryFeeQuote(t *testing.T) {
+	date := func(month time.Month, day int) time.Time {
+		return time.Date(2024, month, day, 0, 0, 0, 0, storeLocation)
+	}
+	campaign := deliveryFeeConfig{Fee: 8, FreeShippingMin: 100, StartsAt: date(11, 1), EndsAt: date(12, 1)}
+	during := date(11, 15).Add(14 * time.Hour)
+
+	tests := []struct {
+		name     string
+		config   deliveryFeeConfig
+		subtotal float64
+		now      time.Time
+		esperado deliveryFeeQuote
+	}{
+		{"abaixo do mínimo na campanha paga taxa", campaign, 70, during, deliveryFeeQuote{Fee: 8, Remaining: 30}},
+		{"acima do mínimo na campanha tem frete grátis", campaign, 120, during, deliveryFeeQuote{FreeShipping: true}},
+		{"exatamente o mínimo tem frete grátis", campaign, 100, during, deliveryFeeQuote{FreeShipping: true}},
+		{"último dia da campanha ainda vale", campaign, 120, date(11, 30).Add(23 * time.Hour), deliveryFeeQuote{FreeShipping: true}},
+		{"acima do mínimo antes da campanha paga taxa", campaign, 120, date(10, 31), deliveryFeeQuote{Fee: 8}},
+		{"acima do mínimo depois da campanha paga taxa", campaign, 120, date(12, 1), deliveryFeeQuote{Fee: 8}},
+		{"abaixo do mínimo fora da campanha não cobra o que falta", campaign, 70, date(12, 5), deliveryFeeQuote{Fee: 8}},
+		{"campanha sem datas vale sempre", deliveryFeeConfig{Fee: 8, FreeShippingMin: 100}, 150, during, deliveryFeeQuote{FreeShipping: true}},
+		{"sem campanha", deliveryFeeConfig{Fee: 8}, 500, during, deliveryFeeQuote{Fee: 8}},
+		{"sem taxa de entrega", deliveryFeeConfig{FreeShippingMin: 100}, 50, during, deliveryFeeQuote{}},
+	}
+
+	for _, tt := range tests {
+		if got := tt.config.quote(tt.subtotal, tt.now); got != tt.esperado {
+			t.Errorf("%s: esperado %+v, obtido %+v", tt.name, tt.esperado, got)
+		}
+	}
+}
+
+func TestGetDeliveryFeeConfig(t *testing.T) {
+	s := &AIService{settingsService: &fakeSettingsService{values: map[string]string{
+		DeliveryFeeSettingKey:           "7,50",
+		FreeShippingMinAmountSettingKey: "99.90",
+		FreeShippingStartsAtSettingKey:  "2024-11-01",
+		FreeShippingEndsAtSettingKey:    "2024-11-30",
+	}}}
+
+	config := s.getDeliveryFeeConfig(context.Background(), uuid.New())
+	if config.Fee != 7.5 || config.FreeShippingMin != 99.9 {
+		t.Errorf("valores inesperados: %+v", config)
+	}
+	if !config.StartsAt.Equal(time.Date(2024, 11, 1, 0, 0, 0, 0, storeLocation)) {
+		t.Errorf("início inesperado: %s", config.StartsAt)
+	}
+	// A data final é inclusiva
+	if !config.EndsAt.Equal(time.Date(2024, 12, 1, 0, 0, 0, 0, storeLocation)) {
+		t.Errorf("fim inesperado: %s", config.EndsAt)
+	}
+
+	if config := (&AIService{settingsService: &fakeSettingsService{}}).getDeliveryFeeConfig(context.Background(), uuid.New()); config != (deliveryFeeConfig{}) {
+		t.Errorf("sem configuração não deveria haver taxa, obtido %+v", config)
+	}
+}
+
+func TestVerCarrinhoMostraQuantoFaltaParaFreteGratis(t *testing.T) {
+	productID := uuid.New()
+	cart := &models.Cart{Items: []models.CartItem{{ProductID: &productID, Quantity: 2, Price: "35.00"}}}
+	s := &AIService{
+		cartService: &fakeCartService{cart: cart},
+		settingsService: &fakeSettingsService{values: map[string]string{
+			DeliveryFeeSettingKey:           "8",
+			FreeShippingMinAmountSettingKey: "100",
+		}},
+	}
+
+	result, err := s.handleVerCarrinhoWithOptions(context.Background(), uuid.New(), uuid.New(), false)
+	if err != nil {
+		t.Fatalf("erro inesperado: %v", err)
+	}
+	for _, expected := range []string{"🚚 Entrega: R$ 8,00", "Total com entrega: R$ 78,00", "Faltam **R$ 30,00** para ganhar frete grátis"} {
+		if !strings.Contains(result, expected) {
+			t.Errorf("esperado %q no carrinho:\n%s", expected, result)
+		}
+	}
+
+	cart.Items[0].Quantity = 3
+	result, err = s.handleVerCarrinhoWithOptions(context.Background(), uuid.New(), uuid.New(), false)
+	if err != nil {
+		t.Fatalf("erro inesperado: %v", err)
+	}
+	if !strings.Contains(result, "Entrega: **grátis**") || strings.Contains(result, "Faltam") {
+		t.Errorf("carrinho acima do mínimo deveria ter frete grátis:\n%s", result)
+	}
+}
@@ -7,7 +7,9 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"time"
 
+	"iafarma/internal/utils"
 	"iafarma/internal/zapplus"
 	"iafarma/pkg/models"
 
@@ -21,14 +23,14 @@ func formatCurrency(priceStr string) string {
 		return "0,00"
 	}
 
-	// Parse the price as float
-	price, err := strconv.ParseFloat(priceStr, 64)
-	if err != nil {
+	// Parse the price as cents, rounding half-up to 2 decimal places
+	price, ok := utils.ParseCents(priceStr)
+	if !ok {
 		return priceStr // Return original if parsing fails
 	}
 
-	// Format to 2 decimal places with Brazilian formatting
-	formatted := fmt.Sprintf("%.2f", price)
+	// Brazilian formatting
+	formatted := price.String()
 
 	// Replace dot with comma for decimal separator
 	formatted = strings.ReplaceAll(formatted, ".", ",")
@@ -53,15 +55,15 @@ func formatCurrency(priceStr string) string {
 	return integerPart + "," + decimalPart
 }
 
-// getEffectivePrice returns the sale price if available, otherwise the regular price
+// getEffectivePrice returns the sale price if it is a valid price, otherwise the regular price
 func getEffectivePrice(product *models.Product) string {
-	if product.SalePrice != "" && product.SalePrice != "0" && product.SalePrice != "0.00" {
+	if isValidPrice(product.SalePrice) {
 		return product.SalePrice
 	}
 	return product.Price
 }
 
-func (s *AIService) handleConsultarItens(tenantID uuid.UUID, customerPhone string, args map[string]interface{}) (string, error) {
+func (s *AIService) handleConsultarItens(ctx context.Context, tenantID, customerID uuid.UUID, customerPhone string, args map[string]interface{}) (string, error) {
 	query := ""
 	if q, ok := args["query"].(string); ok {
 		query = q
@@ -74,6 +76,12 @@ func (s *AIService) handleConsultarItens(tenantID uuid.UUID, customerPhone strin
 		Interface("args", args).
 		Msg("🔍 DEBUG: handleConsultarItens called")
 
+	// Estoque: esconder esgotados conforme parâmetro/preferência do tenant, exceto se o cliente pedir por eles
+	inStockOnly := s.resolveInStockOnly(ctx, tenantID, query, args)
+	if mentionsOutOfStock(query) {
+		query = stripOutOfStockKeywords(query)
+	}
+
 	promocional := false
 	if p, ok := args["promocional"].(bool); ok {
 		promocional = p
@@ -132,20 +140,8 @@ func (s *AIService) handleConsultarItens(tenantID uuid.UUID, customerPhone strin
 		ordenarPor = op
 	}
 
-	// Conversão para formato interno do SortBy
-	var sortBy string
-	switch ordenarPor {
-	case "preco_menor":
-		sortBy = "price_asc"
-	case "preco_maior":
-		sortBy = "price_desc"
-	case "nome":
-		sortBy = "name_asc"
-	case "relevancia":
-		sortBy = "relevance"
-	default:
-		sortBy = "relevance" // padrão
-	}
+	// Conversão para formato interno do SortBy (sem pedido explícito, vale o padrão do tenant)
+	sortBy := s.resolveSearchSort(ctx, tenantID, ordenarPor)
 
 	var products []models.Product
 	var err error
@@ -153,94 +149,18 @@ func (s *AIService) handleConsultarItens(tenantID uuid.UUID, customerPhone strin
 	if promocional {
 		products, err = s.productService.GetPromotionalProducts(tenantID)
 	} else {
-		// 🎯 PRIORIDADE: Se há ordenação por preço, usar busca SQL tradicional para garantir ordenação correta
-		if sortBy == "price_asc" || sortBy == "price_desc" {
-			log.Info().Msgf("🔍 Price Sort Priority: Using SQL search for price ordering '%s'", sortBy)
-			filters := ProductSearchFilters{
-				Query:    query,
-				Brand:    marca,
-				Tags:     tags,
-				MinPrice: precoMin,
-				MaxPrice: precoMax,
-				SortBy:   sortBy,
-				Limit:    limite,
-			}
-			products, err = s.productService.SearchProductsAdvanced(tenantID, filters)
-		} else if query != "" && s.embeddingService != nil {
-			// 🎯 RAG/EMBEDDING para busca semântica (quando não há ordenação por preço)
-			log.Info().Msgf("🔍 RAG Priority: Using semantic search for query='%s'", query)
-
-			ragResults, ragErr := s.embeddingService.SearchSimilarProducts(query, tenantID.String(), limite)
-			if ragErr == nil && len(ragResults) > 0 {
-				log.Info().Msgf("🔍 RAG Success: Found %d products via semantic search", len(ragResults))
-
-				// Converter ResultSet do RAG para []models.Product
-				productIDs := make([]uuid.UUID, 0, len(ragResults))
-				for _, result := range ragResults {
-					if productID, parseErr := uuid.Parse(result.ID); parseErr == nil {
-						productIDs = append(productIDs, productID)
-					}
-				}
-
-				// Buscar produtos completos usando os IDs encontrados pelo RAG
-				if len(productIDs) > 0 {
-					ragProducts := make([]models.Product, 0, len(productIDs))
-					failedProducts := 0
-					for _, productID := range productIDs {
-						if product, getErr := s.productService.GetProductByID(tenantID, productID); getErr == nil {
-							ragProducts = append(ragProducts, *product)
-						} else {
-							failedProducts++
-							log.Warn().
-								Err(getErr).
-								Str("product_id", productID.String()).
-								Str("tenant_id", tenantID.String()).
-								Msg("🚨 RAG Product Not Found: Product ID from RAG doesn't exist in database")
-						}
-					}
-
-					if failedProducts > 0 {
-						log.Warn().
-							Int("total_rag_results", len(productIDs)).
-							Int("failed_products", failedProducts).
-							Int("successful_products", len(ragProducts)).
-							Msg("🔍 RAG Sync Issue: Some RAG results not found in database")
-					}
-
-					if len(ragProducts) > 0 {
-						products = ragProducts
-						log.Info().Msgf("🔍 RAG Complete: Successfully retrieved %d products", len(products))
-					}
-				}
-			} else {
-				log.Warn().Err(ragErr).Msgf("🔍 RAG Failed: %v, falling back to database search", ragErr)
-			}
-		}
-
-		// FALLBACK: Se RAG não funcionou ou não retornou resultados, usar busca no banco
-		if len(products) == 0 {
-			log.Info().Msg("🔍 Database Fallback: Using traditional database search")
-			filters := ProductSearchFilters{
-				Query:    query,
-				Brand:    marca,
-				Tags:     tags,
-				MinPrice: precoMin,
-				MaxPrice: precoMax,
-				Limit:    limite,
-				SortBy:   sortBy,
-			}
-			log.Info().
-				Interface("filters", filters).
-				Str("tenant_id", tenantID.String()).
-				Msg("🔍 DEBUG: SearchProductsAdvanced filters")
-
-			products, err = s.productService.SearchProductsAdvanced(tenantID, filters)
+		filters := ProductSearchFilters{
+			Query:    query,
+			Brand:    marca,
+			Tags:     tags,
+			MinPrice: precoMin,
+			MaxPrice: precoMax,
+			Limit:    limite,
+			SortBy:   sortBy,
 
-			log.Info().
-				Int("products_found", len(products)).
-				Err(err).
-				Msg("🔍 DEBUG: SearchProductsAdvanced result")
+			IncludeOutOfStock: !inStockOnly,
 		}
+		products, _, err = s.searchProductsForQuery(ctx, tenantID, filters)
 	}
 
 	if err != nil {
@@ -248,18 +168,22 @@ func (s *AIService) handleConsultarItens(tenantID uuid.UUID, customerPhone strin
 	}
 
 	// Se não há filtros específicos (só query vazia ou genérica) e é uma consulta genérica,
-	// mostrar catálogo completo organizado por categorias
+	// mostrar catálogo completo na organização configurada pelo tenant
 	if isGenericProductQuery && marca == "" && tags == "" && precoMin == 0 && precoMax == 0 {
 		log.Info().
 			Int("products_count", len(products)).
 			Bool("is_generic_query", isGenericProductQuery).
-			Msg("🔍 DEBUG: Calling formatProductsByCategoryComplete")
-		return s.formatProductsByCategoryComplete(tenantID, customerPhone, products)
+			Msg("🔍 DEBUG: Calling formatCatalogComplete")
+		return s.formatCatalogComplete(ctx, tenantID, customerPhone, products)
 	}
 
 	if len(products) == 0 {
-		// Tentar sugestões alternativas baseadas nos produtos do tenant
-		suggestions := s.generateDynamicSearchSuggestions(tenantID, query, marca, tags)
+		// 🚫 Produto pausado pela loja: avisar que está indisponível em vez de dizer que não existe
+		if query != "" && !promocional && precoMin == 0 && precoMax == 0 {
+			if unavailable := s.findUnavailableProducts(ctx, tenantID, query); len(unavailable) > 0 {
+				return formatUnavailableProducts(unavailable), nil
+			}
+		}
 
 		filterDesc := ""
 		if query != "" {
@@ -281,29 +205,22 @@ func (s *AIService) handleConsultarItens(tenantID uuid.UUID, customerPhone strin
 			}
 		}
 
+		// Sugestões, categorias ou mais vendidos, conforme configurado pelo tenant
 		response := fmt.Sprintf("❌ Nenhum produto encontrado%s.", filterDesc)
+		response += s.emptySearchGuidance(ctx, tenantID, customerPhone, query, marca, tags)
 
-		if len(suggestions) > 0 {
-			response += "\n\n💡 **Você quis dizer:**\n"
-			for _, suggestion := range suggestions {
-				response += fmt.Sprintf("• %s\n", suggestion)
-			}
-			response += "\n📝 Tente um desses termos ou use 'produtos' para ver nosso catálogo completo."
-		} else {
-			response += "\n\n� **Dica:** Tente termos mais específicos ou use 'produtos' para ver nosso catálogo."
+		// 📉 Demanda por produto que a loja não trabalha (opcional por tenant); filtros de preço não indicam falta do produto
+		if query != "" && !promocional && precoMin == 0 && precoMax == 0 {
+			response += s.recordMissingDemand(ctx, tenantID, customerID, customerPhone, query)
 		}
 
 		return response, nil
 	}
 
-	// Armazenar produtos na memória com numeração sequencial
-	productRefs := s.memoryManager.StoreProductList(tenantID, customerPhone, products)
-
-	result := "🛍️ **Produtos disponíveis:**\n\n"
-
 	// Mostrar filtros aplicados se houver
+	filtersLine := ""
 	if query != "" || marca != "" || tags != "" || precoMin > 0 || precoMax > 0 {
-		result += "🔍 **Filtros aplicados:** "
+		filtersLine = "🔍 **Filtros aplicados:** "
 		filters := []string{}
 		if query != "" {
 			filters = append(filters, fmt.Sprintf("Busca: '%s'", query))
@@ -321,40 +238,58 @@ func (s *AIService) handleConsultarItens(tenantID uuid.UUID, customerPhone strin
 		} else if precoMax > 0 {
 			filters = append(filters, fmt.Sprintf("Preço máx: R$ %.2f", precoMax))
 		}
-		result += strings.Join(filters, ", ") + "\n\n"
+		filtersLine += strings.Join(filters, ", ") + "\n\n"
 	}
 
-	for _, productRef := range productRefs {
+	result, productRefs := s.formatProductListing(ctx, tenantID, customerPhone, products, filtersLine, limite)
+
+	// 🖼️ Imagens dos primeiros produtos listados (opcional por tenant)
+	return s.respondWithSearchResultImages(ctx, tenantID, customerPhone, result, productRefs, limite), nil
+}
+
+// formatProductListing numera os produtos na memória da conversa e monta a lista exibida nas buscas
+// (até limite itens, com os campos configurados pelo tenant: por padrão preço, estoque e descrição resumida)
+func (s *AIService) formatProductListing(ctx context.Context, tenantID uuid.UUID, customerPhone string, products []models.Product, filtersLine string, limite int) (string, []ProductReference) {
+	// Armazenar produtos na memória com numeração sequencial
+	productRefs := s.memoryManager.StoreProductList(tenantID, customerPhone, products)
+
+	outOfStock := make(map[uuid.UUID]bool)
+	lowStock := make(map[uuid.UUID]string)
+	lowStockConfig := s.getLowStockUrgencyConfig(ctx, tenantID)
+	for _, product := range products {
+		if product.StockQuantity <= 0 {
+			outOfStock[product.ID] = true
+		}
+		if urgency := lowStockConfig.lowStockUrgencyText(product.StockQuantity); urgency != "" {
+			lowStock[product.ID] = urgency
+		}
+	}
+	fields := s.productDisplayFields(ctx, tenantID, defaultListingFields)
+
+	result := "🛍️ **Produtos disponíveis:**\n\n" + filtersLine
+
+	for i, productRef := range productRefs {
 		if productRef.SequentialID > limite {
 			break
 		}
 
-		var price string
-		if productRef.SalePrice != "" && productRef.SalePrice != "0" {
-			price = fmt.Sprintf("~~R$ %s~~ **R$ %s**", formatCurrency(productRef.Price), formatCurrency(productRef.SalePrice))
-		} else {
-			price = fmt.Sprintf("**R$ %s**", formatCurrency(productRef.Price))
+		stockLine := lowStock[productRef.ProductID]
+		if outOfStock[productRef.ProductID] {
+			stockLine = "🚫 Esgotado no momento"
 		}
 
 		result += fmt.Sprintf("%d. **%s**\n", productRef.SequentialID, productRef.Name)
-		result += fmt.Sprintf("   💰 %s\n", price)
-		if productRef.Description != "" {
-			desc := productRef.Description
-			if len(desc) > 100 {
-				desc = desc[:100] + "..."
-			}
-			result += fmt.Sprintf("   📝 %s\n", desc)
-		}
+		result += formatListingFields(&products[i], fields, stockLine)
 		result += "\n"
 	}
 
 	result += "💡 Para ver detalhes, diga: 'produto [número]' ou 'produto [nome]'\n"
 	result += "🛒 Para adicionar ao carrinho: 'adicionar [número] quantidade [X]'"
 
-	return result, nil
+	return result, productRefs
 }
 
-func (s *AIService) handleMostrarOpcoesCategoria(tenantID uuid.UUID, customerPhone string, args map[string]interface{}) (string, error) {
+func (s *AIService) handleMostrarOpcoesCategoria(ctx context.Context, tenantID uuid.UUID, customerPhone string, args map[string]interface{}) (string, error) {
 	categoria, ok := args["categoria"].(string)
 	if !ok {
 		return "❌ Categoria é obrigatória.", nil
@@ -371,7 +306,7 @@ func (s *AIService) handleMostrarOpcoesCategoria(tenantID uuid.UUID, customerPho
 		Msg("🍕 Mostrando opções de categoria")
 
 	// Buscar produtos da categoria
-	products, err := s.productService.SearchProducts(tenantID, categoria, limite)
+	products, err := s.searchProducts(ctx, tenantID, categoria, limite)
 	if err != nil {
 		return "❌ Erro ao buscar produtos.", err
 	}
@@ -389,12 +324,7 @@ func (s *AIService) handleMostrarOpcoesCategoria(tenantID uuid.UUID, customerPho
 	result := fmt.Sprintf("%s que temos:\n\n", titulo)
 
 	for _, productRef := range productRefs {
-		var price string
-		if productRef.SalePrice != "" && productRef.SalePrice != "0" {
-			price = fmt.Sprintf("~~R$ %s~~ **R$ %s**", formatCurrency(productRef.Price), formatCurrency(productRef.SalePrice))
-		} else {
-			price = fmt.Sprintf("**R$ %s**", formatCurrency(productRef.Price))
-		}
+		price := formatListPrice(productRef.Price, productRef.SalePrice)
 
 		result += fmt.Sprintf("%d. **%s**\n", productRef.SequentialID, productRef.Name)
 		result += fmt.Sprintf("   💰 %s\n", price)
@@ -415,7 +345,7 @@ func (s *AIService) handleMostrarOpcoesCategoria(tenantID uuid.UUID, customerPho
 	return result, nil
 }
 
-func (s *AIService) handleDetalharItem(tenantID uuid.UUID, customerPhone string, args map[string]interface{}) (string, error) {
+func (s *AIService) handleDetalharItem(ctx context.Context, tenantID uuid.UUID, customerPhone string, args map[string]interface{}) (string, error) {
 	identifier, ok := args["identifier"].(string)
 	if !ok {
 		return "❌ Identificador do produto é obrigatório (número ou nome).", nil
@@ -429,7 +359,7 @@ func (s *AIService) handleDetalharItem(tenantID uuid.UUID, customerPhone string,
 		// É um número - buscar na memória
 		productRef := s.memoryManager.GetProductBySequentialID(tenantID, customerPhone, sequentialID)
 		if productRef != nil {
-			product, err = s.productService.GetProductByID(tenantID, productRef.ProductID)
+			product, err = s.getProductByID(ctx, tenantID, productRef.ProductID)
 		} else {
 			return "❌ Produto não encontrado. Use 'produtos' para ver a lista atualizada.", nil
 		}
@@ -437,11 +367,11 @@ func (s *AIService) handleDetalharItem(tenantID uuid.UUID, customerPhone string,
 		// Não é número - tentar buscar por nome na memória
 		productRef := s.memoryManager.GetProductByName(tenantID, customerPhone, identifier)
 		if productRef != nil {
-			product, err = s.productService.GetProductByID(tenantID, productRef.ProductID)
+			product, err = s.getProductByID(ctx, tenantID, productRef.ProductID)
 		} else {
 			// Tentar como UUID se não encontrou na memória
 			if productID, uuidErr := uuid.Parse(identifier); uuidErr == nil {
-				product, err = s.productService.GetProductByID(tenantID, productID)
+				product, err = s.getProductByID(ctx, tenantID, productID)
 			} else {
 				return "❌ Produto não encontrado. Use 'produtos' para ver a lista atualizada.", nil
 			}
@@ -452,44 +382,26 @@ func (s *AIService) handleDetalharItem(tenantID uuid.UUID, customerPhone string,
 		return "❌ Produto não encontrado.", err
 	}
 
-	result := "🔍 **Detalhes do Produto**\n\n"
-	result += fmt.Sprintf("📦 **Nome:** %s\n", product.Name)
-
-	if product.SalePrice != "" && product.SalePrice != "0" {
-		result += fmt.Sprintf("💰 **Preço:** ~~R$ %s~~ **R$ %s** (PROMOÇÃO! 🎉)\n", formatCurrency(product.Price), formatCurrency(product.SalePrice))
-	} else {
-		result += fmt.Sprintf("💰 **Preço:** R$ %s\n", formatCurrency(product.Price))
-	}
-
-	if product.Description != "" {
-		result += fmt.Sprintf("📝 **Descrição:** %s\n", product.Description)
-	}
-
-	if product.SKU != "" {
-		result += fmt.Sprintf("🏷️ **SKU:** %s\n", product.SKU)
+	if !product.Available {
+		return unavailableProductMessage(product), nil
 	}
 
-	if product.StockQuantity > 0 {
-		result += fmt.Sprintf("📊 **Estoque:** %d unidades disponíveis\n", product.StockQuantity)
-	} else {
-		result += "⚠️ **Estoque:** Produto indisponível\n"
-	}
+	// "quero esse" logo depois dos detalhes se refere a este produto
+	s.rememberDetailedProduct(tenantID, customerPhone, product.ID)
 
-	if product.Brand != "" {
-		result += fmt.Sprintf("🏭 **Marca:** %s\n", product.Brand)
-	}
+	result := "🔍 **Detalhes do Produto**\n\n"
+	result += fmt.Sprintf("📦 **Nome:** %s\n", product.Name)
 
-	if product.Weight != "" {
-		result += fmt.Sprintf("⚖️ **Peso:** %s\n", product.Weight)
-	}
+	fields := s.productDisplayFields(ctx, tenantID, defaultDetailFields)
+	result += formatDetailFields(product, fields, s.getLowStockUrgencyConfig(ctx, tenantID).stockDetailText(product.StockQuantity))
 
-	result += "\n🛒 Para adicionar ao carrinho, diga: 'adicionar ao carrinho quantidade [X]'"
+	result += "\n🛒 Para adicionar ao carrinho, diga 'quero esse' ou a quantidade desejada (ex: 'quero 2')"
 
 	return result, nil
 }
 
 // addToCartWithFallback tenta múltiplas estratégias para adicionar produto ao carrinho
-func (s *AIService) addToCartWithFallback(tenantID, customerID uuid.UUID, customerPhone, identifier string, quantidade int) (string, error) {
+func (s *AIService) addToCartWithFallback(ctx context.Context, tenantID, customerID uuid.UUID, customerPhone, identifier string, quantidade int) (string, error) {
 	log.Info().
 		Str("identifier", identifier).
 		Int("quantidade", quantidade).
@@ -499,7 +411,7 @@ func (s *AIService) addToCartWithFallback(tenantID, customerID uuid.UUID, custom
 	if sequentialID, parseErr := strconv.Atoi(identifier); parseErr == nil {
 		productRef := s.memoryManager.GetProductBySequentialID(tenantID, customerPhone, sequentialID)
 		if productRef != nil {
-			if result, err := s.tryAddProductToCart(tenantID, customerID, productRef.ProductID, quantidade); err == nil {
+			if result, err := s.tryAddProductToCart(ctx, tenantID, customerID, customerPhone, productRef.ProductID, quantidade); err == nil {
 				log.Info().Msg("✅ Sucesso: Produto adicionado por número sequencial")
 				return result, nil
 			}
@@ -509,7 +421,7 @@ func (s *AIService) addToCartWithFallback(tenantID, customerID uuid.UUID, custom
 
 	// Estratégia 2: Tentar por UUID
 	if productID, uuidErr := uuid.Parse(identifier); uuidErr == nil {
-		if result, err := s.tryAddProductToCart(tenantID, customerID, productID, quantidade); err == nil {
+		if result, err := s.tryAddProductToCart(ctx, tenantID, customerID, customerPhone, productID, quantidade); err == nil {
 			log.Info().Msg("✅ Sucesso: Produto adicionado por UUID")
 			return result, nil
 		}
@@ -517,14 +429,14 @@ func (s *AIService) addToCartWithFallback(tenantID, customerID uuid.UUID, custom
 	}
 
 	// Estratégia 3: Tentar por nome (busca fuzzy)
-	if result, err := s.tryAddProductByName(tenantID, customerID, customerPhone, identifier, quantidade); err == nil {
+	if result, err := s.tryAddProductByName(ctx, tenantID, customerID, customerPhone, identifier, quantidade); err == nil {
 		log.Info().Msg("✅ Sucesso: Produto adicionado por nome")
 		return result, nil
 	}
 	log.Info().Msg("❌ Falha: Produto não encontrado por nome")
 
 	// Estratégia 4: Tentar buscar no contexto da conversa recente
-	if result, err := s.tryAddFromRecentContext(tenantID, customerID, customerPhone, identifier, quantidade); err == nil {
+	if result, err := s.tryAddFromRecentContext(ctx, tenantID, customerID, customerPhone, identifier, quantidade); err == nil {
 		log.Info().Msg("✅ Sucesso: Produto adicionado do contexto recente")
 		return result, nil
 	}
@@ -535,14 +447,32 @@ func (s *AIService) addToCartWithFallback(tenantID, customerID uuid.UUID, custom
 }
 
 // tryAddProductToCart tenta adicionar um produto específico ao carrinho
-func (s *AIService) tryAddProductToCart(tenantID, customerID, productID uuid.UUID, quantidade int) (string, error) {
-	product, err := s.productService.GetProductByID(tenantID, productID)
+func (s *AIService) tryAddProductToCart(ctx context.Context, tenantID, customerID uuid.UUID, customerPhone string, productID uuid.UUID, quantidade int) (string, error) {
+	product, err := s.getProductByID(ctx, tenantID, productID)
 	if err != nil || product == nil {
 		return "", fmt.Errorf("produto não encontrado")
 	}
 
+	if !product.Available {
+		return unavailableProductMessage(product), nil
+	}
+
 	if product.StockQuantity < quantidade {
-		return fmt.Sprintf("❌ Estoque insuficiente para **%s**. Disponível: %d unidades.", product.Name, product.StockQuantity), nil
+		message := fmt.Sprintf("❌ Estoque insuficiente para **%s**. Disponível: %d unidades.", product.Name, product.StockQuantity)
+		return s.withStockAlternatives(ctx, tenantID, customerPhone, product, quantidade, message), nil
+	}
+
+	// 💲 Produto sem preço válido não entra no carrinho (totais ficariam errados)
+	if !hasValidPrice(product) {
+		return invalidPriceMessage(product), nil
+	}
+
+	if message := s.categoryClosedMessage(ctx, tenantID, product); message != "" {
+		return message, nil
+	}
+
+	if message := s.readdGuardMessage(ctx, tenantID, customerID, product, quantidade); message != "" {
+		return message, nil
 	}
 
 	cart, err := s.cartService.GetOrCreateActiveCart(tenantID, customerID)
@@ -555,29 +485,55 @@ func (s *AIService) tryAddProductToCart(tenantID, customerID, productID uuid.UUI
 		return "", fmt.Errorf("erro ao adicionar item ao carrinho")
 	}
 
-	adicional := "\n\nVocê pode continuar comprando ou digite 'finalizar' para fechar o pedido."
+	// Preço conforme a quantidade total do item no carrinho (faixas de atacado)
+	unitPrice := UnitPriceForQuantity(product, quantidade)
+	totalQuantity := quantidade
+	freeShippingNudge, changeNotice := "", ""
+	if cartWithItems, err := s.cartService.GetCartWithItems(cart.ID, tenantID); err == nil && cartWithItems != nil {
+		for _, item := range cartWithItems.Items {
+			if item.ProductID != nil && *item.ProductID == product.ID {
+				unitPrice = item.Price
+				totalQuantity = item.Quantity
+				s.rememberCartAdd(tenantID, customerID, cart.ID, item, product.Name, quantidade)
+				break
+			}
+		}
+		freeShippingNudge = s.quoteCartDeliveryFee(ctx, tenantID, cartWithItems).freeShippingNudge()
+		changeNotice = s.cartChangeNotice(ctx, tenantID, cartWithItems)
+	}
+
+	adicional := priceTierHint(product, totalQuantity)
+	if freeShippingNudge != "" {
+		adicional += "\n\n" + freeShippingNudge
+	}
+	if changeNotice != "" {
+		adicional += "\n\n" + changeNotice
+	}
+	adicional += "\n\nVocê pode continuar comprando ou digite 'finalizar' para fechar o pedido."
+	adicional += ageConfirmationNotice(cart, product)
+	adicional += prescriptionNotice(cart, product)
 
 	return fmt.Sprintf("✅ **%s** adicionado ao carrinho!\n🔢 Quantidade: %d\n💰 Valor: R$ %s",
-		product.Name, quantidade, formatCurrency(getEffectivePrice(product))) + adicional, nil
+		product.Name, quantidade, formatCurrency(unitPrice)) + adicional, nil
 }
 
 // tryAddProductByName tenta adicionar produto pelo nome
-func (s *AIService) tryAddProductByName(tenantID, customerID uuid.UUID, customerPhone, nomeProduto string, quantidade int) (string, error) {
-	products, err := s.productService.SearchProducts(tenantID, nomeProduto, 5)
+func (s *AIService) tryAddProductByName(ctx context.Context, tenantID, customerID uuid.UUID, customerPhone, nomeProduto string, quantidade int) (string, error) {
+	products, err := s.searchProducts(ctx, tenantID, nomeProduto, 5)
 	if err != nil || len(products) == 0 {
 		return "", fmt.Errorf("nenhum produto encontrado")
 	}
 
 	// Se encontrou exatamente 1 produto, adicionar
 	if len(products) == 1 {
-		return s.tryAddProductToCart(tenantID, customerID, products[0].ID, quantidade)
+		return s.tryAddProductToCart(ctx, tenantID, customerID, customerPhone, products[0].ID, quantidade)
 	}
 
 	// Se encontrou múltiplos, verificar se há match exato
 	nomeLower := strings.ToLower(nomeProduto)
 	for _, product := range products {
 		if strings.ToLower(product.Name) == nomeLower {
-			return s.tryAddProductToCart(tenantID, customerID, product.ID, quantidade)
+			return s.tryAddProductToCart(ctx, tenantID, customerID, customerPhone, product.ID, quantidade)
 		}
 	}
 
@@ -585,7 +541,7 @@ func (s *AIService) tryAddProductByName(tenantID, customerID uuid.UUID, customer
 }
 
 // tryAddFromRecentContext tenta encontrar produto no contexto da conversa recente
-func (s *AIService) tryAddFromRecentContext(tenantID, customerID uuid.UUID, customerPhone, identifier string, quantidade int) (string, error) {
+func (s *AIService) tryAddFromRecentContext(ctx context.Context, tenantID, customerID uuid.UUID, customerPhone, identifier string, quantidade int) (string, error) {
 	// Buscar nas mensagens recentes por produtos mencionados
 	conversationHistory := s.memoryManager.GetConversationHistory(tenantID, customerPhone)
 	if len(conversationHistory) == 0 {
@@ -609,10 +565,10 @@ func (s *AIService) tryAddFromRecentContext(tenantID, customerID uuid.UUID, cust
 			// Procurar por números seguidos de produtos mencionados
 			if strings.Contains(messageLower, identifierLower) {
 				// Tentar extrair produtos mencionados na mensagem
-				if products, err := s.extractProductsFromMessage(tenantID, message.Content); err == nil && len(products) > 0 {
+				if products, err := s.extractProductsFromMessage(ctx, tenantID, message.Content); err == nil && len(products) > 0 {
 					// Se o identificador é um número, usar como índice
 					if idx, parseErr := strconv.Atoi(identifier); parseErr == nil && idx > 0 && idx <= len(products) {
-						return s.tryAddProductToCart(tenantID, customerID, products[idx-1].ID, quantidade)
+						return s.tryAddProductToCart(ctx, tenantID, customerID, customerPhone, products[idx-1].ID, quantidade)
 					}
 				}
 			}
@@ -623,7 +579,7 @@ func (s *AIService) tryAddFromRecentContext(tenantID, customerID uuid.UUID, cust
 }
 
 // extractProductsFromMessage extrai produtos mencionados em uma mensagem
-func (s *AIService) extractProductsFromMessage(tenantID uuid.UUID, message string) ([]*models.Product, error) {
+func (s *AIService) extractProductsFromMessage(ctx context.Context, tenantID uuid.UUID, message string) ([]*models.Product, error) {
 	var products []*models.Product
 
 	// Buscar por padrões como "1. Nome do Produto" ou "1⁠. Nome:"
@@ -637,7 +593,7 @@ func (s *AIService) extractProductsFromMessage(tenantID uuid.UUID, message strin
 			matches := re.FindStringSubmatch(line)
 			if len(matches) > 1 {
 				productName := strings.TrimSpace(matches[1])
-				if foundProducts, err := s.productService.SearchProducts(tenantID, productName, 1); err == nil && len(foundProducts) > 0 {
+				if foundProducts, err := s.searchProducts(ctx, tenantID, productName, 1); err == nil && len(foundProducts) > 0 {
 					products = append(products, &foundProducts[0])
 				}
 			}
@@ -647,7 +603,7 @@ func (s *AIService) extractProductsFromMessage(tenantID uuid.UUID, message strin
 	return products, nil
 }
 
-func (s *AIService) handleAdicionarAoCarrinho(tenantID, customerID uuid.UUID, customerPhone string, args map[string]interface{}) (string, error) {
+func (s *AIService) handleAdicionarAoCarrinho(ctx context.Context, tenantID, customerID uuid.UUID, customerPhone string, args map[string]interface{}) (string, error) {
 	identifier, ok := args["identifier"].(string)
 	if !ok {
 		return "❌ Identificador do produto é obrigatório (número ou ID).", nil
@@ -664,10 +620,10 @@ func (s *AIService) handleAdicionarAoCarrinho(tenantID, customerID uuid.UUID, cu
 	}
 
 	// Usar o sistema de fallback
-	return s.addToCartWithFallback(tenantID, customerID, customerPhone, identifier, quantidade)
+	return s.addToCartWithFallback(ctx, tenantID, customerID, customerPhone, identifier, quantidade)
 }
 
-func (s *AIService) handleAdicionarProdutoPorNome(tenantID, customerID uuid.UUID, customerPhone string, args map[string]interface{}) (string, error) {
+func (s *AIService) handleAdicionarProdutoPorNome(ctx context.Context, tenantID, customerID uuid.UUID, customerPhone string, args map[string]interface{}) (string, error) {
 	nomeProduto, ok := args["nome_produto"].(string)
 	if !ok {
 		return "❌ Nome do produto é obrigatório.", nil
@@ -684,12 +640,15 @@ func (s *AIService) handleAdicionarProdutoPorNome(tenantID, customerID uuid.UUID
 	}
 
 	// Buscar produtos pelo nome com busca mais flexível
-	products, err := s.productService.SearchProducts(tenantID, nomeProduto, 10)
+	products, err := s.searchProducts(ctx, tenantID, nomeProduto, 10)
 	if err != nil {
 		return "❌ Erro ao buscar produtos.", err
 	}
 
 	if len(products) == 0 {
+		if unavailable := s.findUnavailableProducts(ctx, tenantID, nomeProduto); len(unavailable) > 0 {
+			return formatUnavailableProducts(unavailable), nil
+		}
 		// return fmt.Sprintf("❌ Nenhum produto encontrado com '%s'. \n\n💡 **Dica:** Tente termos mais específicos ou use 'produtos' para ver nosso catálogo.", nomeProduto), nil
 		return fmt.Sprintf("🔍 *Ops! Não encontramos '%s'*\n\n💡 Que tal tentar:\n• Termos mais curtos ou específicos\n• Digitar 'produtos' para ver tudo o que temos\n• Procurar por categoria\n\nEstamos juntos nessa! 💪", nomeProduto), nil
 	}
@@ -699,7 +658,20 @@ func (s *AIService) handleAdicionarProdutoPorNome(tenantID, customerID uuid.UUID
 		product := &products[0]
 
 		if product.StockQuantity < quantidade {
-			return fmt.Sprintf("❌ Estoque insuficiente para **%s**. Disponível: %d unidades.", product.Name, product.StockQuantity), nil
+			message := fmt.Sprintf("❌ Estoque insuficiente para **%s**. Disponível: %d unidades.", product.Name, product.StockQuantity)
+			return s.withStockAlternatives(ctx, tenantID, customerPhone, product, quantidade, message), nil
+		}
+
+		if !hasValidPrice(product) {
+			return invalidPriceMessage(product), nil
+		}
+
+		if message := s.categoryClosedMessage(ctx, tenantID, product); message != "" {
+			return message, nil
+		}
+
+		if message := s.readdGuardMessage(ctx, tenantID, customerID, product, quantidade); message != "" {
+			return message, nil
 		}
 
 		// Obter ou criar carrinho ativo
@@ -713,8 +685,11 @@ func (s *AIService) handleAdicionarProdutoPorNome(tenantID, customerID uuid.UUID
 		if err != nil {
 			return "❌ Erro ao adicionar item ao carrinho.", err
 		}
+		s.rememberProductAdd(tenantID, customerID, cart.ID, product, quantidade)
 
 		adicional := "\n\nVocê pode continuar comprando ou digite 'finalizar' para fechar o pedido."
+		adicional += ageConfirmationNotice(cart, product)
+		adicional += prescriptionNotice(cart, product)
 		return fmt.Sprintf("✅ **%s** adicionado ao carrinho!\n🔢 Quantidade: %d\n💰 Valor: R$ %s",
 			product.Name, quantidade, formatCurrency(getEffectivePrice(product))) + adicional, nil
 	}
@@ -725,12 +700,7 @@ func (s *AIService) handleAdicionarProdutoPorNome(tenantID, customerID uuid.UUID
 	result := fmt.Sprintf("🔍 Encontrei %d produtos similares a '%s':\n\n", len(products), nomeProduto)
 	for _, productRef := range productRefs {
 		// Usar nosso padrão de formatação
-		var priceStr string
-		if productRef.SalePrice != "" && productRef.SalePrice != "0" {
-			priceStr = fmt.Sprintf("~~R$ %s~~ **R$ %s**", formatCurrency(productRef.Price), formatCurrency(productRef.SalePrice))
-		} else {
-			priceStr = fmt.Sprintf("**R$ %s**", formatCurrency(productRef.Price))
-		}
+		priceStr := formatListPrice(productRef.Price, productRef.SalePrice)
 
 		result += fmt.Sprintf("%d. **%s**\n   💰 %s\n", productRef.SequentialID, productRef.Name, priceStr)
 	}
@@ -761,6 +731,7 @@ func (s *AIService) handleRemoverDoCarrinho(tenantID, customerID uuid.UUID, args
 		if err != nil {
 			return "❌ Erro ao remover item do carrinho.", err
 		}
+		s.rememberCartRemoval(tenantID, customerID, *cartItem)
 
 		return fmt.Sprintf("✅ **%s** removido do carrinho com sucesso!", getItemName(*cartItem)), nil
 	}
@@ -782,17 +753,31 @@ func (s *AIService) handleRemoverDoCarrinho(tenantID, customerID uuid.UUID, args
 		return "❌ Erro ao acessar carrinho.", err
 	}
 
+	// Guardar o item antes de remover, para vigiar uma readição acidental
+	var removed *models.CartItem
+	if cartWithItems, err := s.cartService.GetCartWithItems(cart.ID, tenantID); err == nil && cartWithItems != nil {
+		for i := range cartWithItems.Items {
+			if cartWithItems.Items[i].ID == itemID {
+				removed = &cartWithItems.Items[i]
+				break
+			}
+		}
+	}
+
 	// Remover item do carrinho
 	err = s.cartService.RemoveItemFromCart(cart.ID, tenantID, itemID)
 	if err != nil {
 		return "❌ Erro ao remover item do carrinho.", err
 	}
+	if removed != nil {
+		s.rememberCartRemoval(tenantID, customerID, *removed)
+	}
 
 	return "✅ Item removido do carrinho com sucesso!", nil
 }
 
 // handleVerCarrinhoWithOptions permite controlar se mostra as instruções de gerenciamento
-func (s *AIService) handleVerCarrinhoWithOptions(tenantID, customerID uuid.UUID, showManagementInstructions bool) (string, error) {
+func (s *AIService) handleVerCarrinhoWithOptions(ctx context.Context, tenantID, customerID uuid.UUID, showManagementInstructions bool) (string, error) {
 	// Obter carrinho com itens
 	cart, err := s.cartService.GetOrCreateActiveCart(tenantID, customerID)
 	if err != nil {
@@ -809,18 +794,38 @@ func (s *AIService) handleVerCarrinhoWithOptions(tenantID, customerID uuid.UUID,
 	}
 
 	result := "🛒 **Seu Carrinho:**\n\n"
-	total := 0.0
 
 	for i, item := range cartWithItems.Items {
-		itemPrice, _ := strconv.ParseFloat(item.Price, 64)
-		itemTotal := itemPrice * float64(item.Quantity)
-		total += itemTotal
-
 		result += fmt.Sprintf("%d. **%s**\n", i+1, getItemName(item))
-		result += fmt.Sprintf("   💰 R$ %s x %d = R$ %s\n\n", formatCurrency(item.Price), item.Quantity, formatCurrency(fmt.Sprintf("%.2f", itemTotal)))
+		result += fmt.Sprintf("   💰 R$ %s x %d = R$ %s\n\n", formatCurrency(item.Price), item.Quantity, formatCurrency(cartItemTotal(item).String()))
+	}
+
+	total := cartSubtotal(cartWithItems)
+	result += fmt.Sprintf("💳 **Total: R$ %s**", formatCurrency(utils.FormatMoney(total)))
+
+	// 🚚 Taxa de entrega e quanto falta para o frete grátis
+	if deliveryLines := formatCartDeliveryFee(s.quoteCartDeliveryFee(ctx, tenantID, cartWithItems), total); deliveryLines != "" {
+		result += "\n" + deliveryLines
+	}
+
+	// ⚡ Pedido urgente e taxa expressa
+	if s.isUrgentCart(ctx, tenantID, cartWithItems) {
+		result += "\n" + formatCartUrgency(s.getUrgentOrderFee(ctx, tenantID), total+s.quoteCartDeliveryFee(ctx, tenantID, cartWithItems).Fee)
+	}
+
+	// 💵 Pagamento com sinal: parte agora e o restante na entrega
+	if depositLines := s.cartDepositLines(ctx, tenantID, cartWithItems); depositLines != "" {
+		result += "\n" + depositLines
 	}
 
-	result += fmt.Sprintf("💳 **Total: R$ %s**", formatCurrency(fmt.Sprintf("%.2f", total)))
+	if savingsLine := formatCartSavings(calculateCartSavings(cartWithItems.Items)); savingsLine != "" {
+		result += "\n" + savingsLine
+	}
+
+	// 💵 Troco recalculado com o total atual
+	if changeNotice := s.cartChangeNotice(ctx, tenantID, cartWithItems); changeNotice != "" {
+		result += "\n" + changeNotice
+	}
 
 	// Adicionar instruções de gerenciamento apenas quando solicitado
 	if showManagementInstructions {
@@ -910,7 +915,7 @@ func (s *AIService) handleAtualizarQuantidade(tenantID, customerID uuid.UUID, ar
 	return fmt.Sprintf("✅ Quantidade atualizada para %d unidades!\n\n🛒 Use 'ver carrinho' para conferir.", quantidade), nil
 }
 
-func (s *AIService) performFinalCheckout(tenantID, customerID uuid.UUID, customerPhone string) (string, error) {
+func (s *AIService) performFinalCheckout(ctx context.Context, tenantID, customerID uuid.UUID, customerPhone string) (string, error) {
 	// Obter carrinho
 	cart, err := s.cartService.GetOrCreateActiveCart(tenantID, customerID)
 	if err != nil {
@@ -926,24 +931,229 @@ func (s *AIService) performFinalCheckout(tenantID, customerID uuid.UUID, custome
 		return "❌ Carrinho vazio! Adicione alguns produtos antes de finalizar.", nil
 	}
 
+	delivery, blockMessage, err := s.validateCheckoutGates(ctx, tenantID, customerID, cartWithItems)
+	if blockMessage != "" {
+		return blockMessage, err
+	}
+	pickup, deliveryAddress, manualDeliveryConfirmation := delivery.pickup, delivery.address, delivery.manualConfirmation
+
+	// 🎁 Itens destinados a outros endereços viram pedidos separados, um por endereço
+	if !pickup && cartHasSplitDelivery(cartWithItems, deliveryAddress) && s.isSplitDeliveryEnabled(ctx, tenantID) {
+		return s.performSplitCheckout(ctx, tenantID, customerID, customerPhone, cartWithItems, deliveryAddress, manualDeliveryConfirmation)
+	}
+
+	// Buscar conversation ID armazenado para esta sessão
+	conversationID := s.getConversationID(tenantID, customerPhone)
+
+	// Criar pedido com status pendente incluindo o endereço de entrega e conversation ID
+	var order *models.Order
+
+	if conversationID != uuid.Nil {
+		log.Info().Str("conversation_id", conversationID.String()).Msg("🔗 Criando pedido com conversation ID")
+		order, err = s.orderService.CreateOrderFromCartWithConversation(tenantID, cart.ID, conversationID, deliveryAddress)
+	} else {
+		log.Warn().Msg("⚠️ Nenhum conversation ID encontrado, criando pedido sem conversation ID")
+		order, err = s.orderService.CreateOrderFromCartWithAddress(tenantID, cart.ID, deliveryAddress)
+	}
+	if err != nil {
+		log.Error().Err(err).Msg("Erro ao criar pedido no checkout final")
+		return "❌ Erro ao criar pedido.", err
+	}
+
+	// Taxas e condições escolhidas no carrinho (entrega, urgência, parcelamento, sinal, confirmação manual)
+	urgent := s.isUrgentCart(ctx, tenantID, cartWithItems)
+	order, depositText, manualReview := s.applyCheckoutCharges(ctx, tenantID, customerID, customerPhone, cartWithItems, order)
+
+	// 📍 O endereço usado no pedido passa a ser o padrão do cliente
+	if deliveryAddress != nil && !deliveryAddress.IsDefault {
+		if err := s.addressService.SetDefaultAddress(tenantID, customerID, deliveryAddress.ID); err != nil {
+			log.Warn().Err(err).Str("address_id", deliveryAddress.ID.String()).Msg("⚠️ Não foi possível definir o endereço do pedido como padrão")
+		}
+	}
+
+	deliveryDescription := "retirada na loja"
+	if deliveryAddress != nil {
+		deliveryDescription = fmt.Sprintf("%s, %s, %s, %s", deliveryAddress.Street, deliveryAddress.Number, deliveryAddress.Neighborhood, deliveryAddress.City)
+	}
+	log.Info().
+		Str("order_number", order.OrderNumber).
+		Str("total", order.TotalAmount).
+		Str("delivery_address", deliveryDescription).
+		Msg("Pedido criado com sucesso no checkout final")
+
+	// ⏱️ Tempo estimado de preparo (maior tempo entre os itens) - calcular antes de limpar o carrinho
+	prepTimeText := ""
+	if prepMinutes, found := s.getCartPrepTime(tenantID, s.getPrepTimeConfig(ctx, tenantID), cartWithItems); found {
+		prepTimeText = fmt.Sprintf("⏱️ **Tempo estimado de preparo:** %s\n", formatPrepTime(prepMinutes))
+	}
+
+	// 🧹 LIMPEZA COMPLETA APÓS PEDIDO CRIADO
+	s.resetCartAfterCheckout(tenantID, customerID, customerPhone, cartWithItems, pickup)
+
+	// Send alert notification if configured
+	if s.alertService != nil {
+		if err := s.alertService.SendOrderAlert(tenantID, order, customerPhone); err != nil {
+			log.Error().Err(err).Msg("Erro ao enviar alerta do pedido")
+		}
+	}
+
+	fulfillmentText := "entrega"
+	if pickup {
+		fulfillmentText = "retirada"
+		prepTimeText += s.formatPickupDetails(ctx, tenantID) + "\n"
+	}
+	if manualDeliveryConfirmation {
+		prepTimeText += "🚚 **Entrega:** vamos confirmar manualmente se atendemos o seu endereço.\n"
+	}
+	if urgent {
+		prepTimeText += "⚡ **Pedido urgente:** nossa equipe vai priorizar o seu pedido.\n"
+	}
+	if depositText != "" {
+		prepTimeText += depositText + "\n"
+	}
+	if manualReview {
+		prepTimeText += "🔎 **Confirmação manual:** por ser um pedido de valor mais alto, nossa equipe vai conferir e confirmar com você em instantes.\n"
+	}
+
+	// 🧾 Total detalhado (subtotal, entrega, urgência, descontos) quando há algo além dos produtos
+	totalText := fmt.Sprintf("💰 **Total:** R$ %s", formatCurrency(order.TotalAmount))
+	if breakdown := orderTotalBreakdown(order); breakdown.hasCharges() {
+		totalText = formatTotalBreakdown(breakdown)
+	}
+
+	return fmt.Sprintf("🎉 **Pedido registrado com sucesso!**\n\n📋 **Número do Pedido:** %s\n%s\n📦 **Status:** Pendente\n%s\n✅ **Seu pedido foi registrado em nosso sistema!**\n\n👥 Um de nossos operadores irá revisar e confirmar seu pedido em breve.\n📞 Você será contatado para confirmar os detalhes da %s e pagamento.\n\n🔍 Acompanhe seu pedido pelo número: **%s**",
+		order.OrderNumber,
+		totalText,
+		prepTimeText,
+		fulfillmentText,
+		order.OrderNumber), nil
+}
+
+// checkoutDelivery é como o pedido aprovado pelas verificações do checkout será entregue
+type checkoutDelivery struct {
+	pickup             bool
+	address            *models.Address
+	manualConfirmation bool
+}
+
+// validateCheckoutGates aplica ao carrinho as verificações que impedem criar o pedido (maioridade, receita, peso e
+// área de entrega). Quando o pedido não pode seguir, retorna a mensagem a ser enviada ao cliente. Usada pelo checkout
+// e pela confirmação dos pedidos de assinatura, para que os dois caminhos sigam as mesmas regras.
+func (s *AIService) validateCheckoutGates(ctx context.Context, tenantID, customerID uuid.UUID, cart *models.Cart) (checkoutDelivery, string, error) {
+	// 🔞 Produtos restritos por idade exigem confirmação de maioridade antes de criar o pedido
+	if cartRequiresAgeConfirmation(cart) {
+		return checkoutDelivery{}, ageConfirmationBlockMessage(cart), nil
+	}
+
+	// 📄 Medicamentos controlados exigem a foto da receita antes de criar o pedido
+	if cartMissingPrescription(cart) {
+		return checkoutDelivery{}, prescriptionBlockMessage(cart), nil
+	}
+
+	// ⚖️ Pedidos acima do limite de peso da entrega precisam ser divididos ou retirados na loja
+	if blockMessage := s.cartWeightBlockMessage(ctx, tenantID, cart); blockMessage != "" {
+		return checkoutDelivery{}, blockMessage, nil
+	}
+
+	// 🏪 Retirada na loja dispensa endereço e validação de entrega
+	if s.isPickupCart(ctx, tenantID, cart) {
+		log.Info().
+			Str("tenant_id", tenantID.String()).
+			Str("customer_id", customerID.String()).
+			Msg("🏪 Pedido para retirada na loja - validação de entrega ignorada")
+		return checkoutDelivery{pickup: true}, "", nil
+	}
+
+	address, manual, blockMessage, err := s.validateCheckoutDeliveryAddress(ctx, tenantID, customerID)
+	if blockMessage != "" {
+		return checkoutDelivery{}, blockMessage, err
+	}
+	return checkoutDelivery{address: address, manualConfirmation: manual}, "", nil
+}
+
+// applyCheckoutCharges aplica ao pedido recém-criado as taxas e condições escolhidas no carrinho. Retorna o pedido
+// atualizado, o texto do sinal (se houver) e se o pedido aguarda confirmação manual.
+func (s *AIService) applyCheckoutCharges(ctx context.Context, tenantID, customerID uuid.UUID, customerPhone string, cart *models.Cart, order *models.Order) (*models.Order, string, bool) {
+	// 🚚 Taxa de entrega (zerada pela campanha de frete grátis quando o carrinho atinge o valor mínimo)
+	if quote := s.quoteCartDeliveryFee(ctx, tenantID, cart); quote.Fee > 0 {
+		if updated, err := s.orderService.ApplyShippingAmount(tenantID, order.ID, quote.Fee); err != nil {
+			log.Error().Err(err).Str("order_id", order.ID.String()).Msg("Erro ao aplicar taxa de entrega ao pedido")
+		} else {
+			order = updated
+		}
+	}
+
+	// ⚡ Pedido urgente: prioridade para os operadores e taxa expressa (se configurada)
+	order = s.applyCartUrgency(ctx, tenantID, cart, order)
+
+	// 💳 Parcelamento escolhido no carrinho, validado contra o total final do pedido
+	order = s.applyCartInstallments(ctx, tenantID, cart, order)
+
+	// 💵 Sinal e restante calculados sobre o total final do pedido
+	order, depositText := s.applyCartDeposit(ctx, tenantID, cart, order)
+
+	// 🔎 Pedidos acima do valor limite do tenant aguardam a confirmação de um operador
+	manualReview := s.applyManualReview(ctx, tenantID, customerID, customerPhone, order)
+
+	return order, depositText, manualReview
+}
+
+// resetCartAfterCheckout limpa o carrinho e desfaz as opções que valem só para o pedido recém-criado
+func (s *AIService) resetCartAfterCheckout(tenantID, customerID uuid.UUID, customerPhone string, cart *models.Cart, pickup bool) {
+	s.cleanupAfterOrderCreation(tenantID, customerID, customerPhone, cart.ID)
+	if pickup {
+		// O próximo pedido volta a ser entrega por padrão
+		if err := s.cartService.SetCartPickup(cart.ID, tenantID, false); err != nil {
+			log.Warn().Err(err).Msg("❌ Falha ao resetar retirada na loja do carrinho")
+		}
+	}
+	if cart.IsUrgent {
+		// A urgência vale só para este pedido
+		if err := s.cartService.SetCartUrgent(cart.ID, tenantID, false); err != nil {
+			log.Warn().Err(err).Msg("❌ Falha ao resetar urgência do carrinho")
+		}
+	}
+	if cart.PayDeposit {
+		// O sinal vale só para este pedido
+		if err := s.cartService.SetCartDeposit(cart.ID, tenantID, false); err != nil {
+			log.Warn().Err(err).Msg("❌ Falha ao resetar sinal do carrinho")
+		}
+	}
+}
+
+// validateCheckoutDeliveryAddress escolhe o endereço de entrega do cliente e valida se a loja atende o local.
+// Quando o pedido não pode seguir, retorna a mensagem a ser enviada ao cliente. Se o serviço de entrega estiver
+// indisponível e o tenant aceitar confirmação manual, retorna o endereço com manualConfirmation = true.
+func (s *AIService) validateCheckoutDeliveryAddress(ctx context.Context, tenantID, customerID uuid.UUID) (address *models.Address, manualConfirmation bool, blockMessage string, err error) {
 	// 🚚 VALIDAR SE FAZEMOS ENTREGA NO ENDEREÇO DO CLIENTE ANTES DE CRIAR O PEDIDO
 	addresses, err := s.addressService.GetAddressesByCustomer(tenantID, customerID)
 	if err != nil || len(addresses) == 0 {
-		return "❌ Nenhum endereço encontrado. Por favor, cadastre um endereço de entrega.\n\n🏠 **Para cadastrar, informe seu endereço completo:**\n\n💡 **Exemplo:** Rua das Flores, 123, Centro, Brasília, DF, CEP 70000-000, Complemento (se houver)", err
+		return nil, false, "❌ Nenhum endereço encontrado. Por favor, cadastre um endereço de entrega.\n\n🏠 **Para cadastrar, informe seu endereço completo:**\n\n💡 **Exemplo:** Rua das Flores, 123, Centro, Brasília, DF, CEP 70000-000, Complemento (se houver)", err
 	}
 
 	// Encontrar o endereço padrão ou usar o primeiro
-	var deliveryAddress *models.Address
-	for _, addr := range addresses {
-		if addr.IsDefault {
-			deliveryAddress = &addr
-			break
-		}
+	deliveryAddress := mainDeliveryAddress(addresses)
+
+	manualConfirmation, blockMessage, err = s.validateDeliveryToAddress(ctx, tenantID, customerID, deliveryAddress)
+	if blockMessage != "" {
+		return nil, false, blockMessage, err
 	}
-	if deliveryAddress == nil {
-		deliveryAddress = &addresses[0]
+	return deliveryAddress, manualConfirmation, "", nil
+}
+
+// mainDeliveryAddress retorna o endereço padrão do cliente ou, sem padrão, o primeiro cadastrado
+func mainDeliveryAddress(addresses []models.Address) *models.Address {
+	for i := range addresses {
+		if addresses[i].IsDefault {
+			return &addresses[i]
+		}
 	}
+	return &addresses[0]
+}
 
+// validateDeliveryToAddress verifica se a loja entrega no endereço. Quando não entrega, retorna a mensagem a ser
+// enviada ao cliente; com o serviço de entrega indisponível e confirmação manual aceita, retorna manualConfirmation = true.
+func (s *AIService) validateDeliveryToAddress(ctx context.Context, tenantID, customerID uuid.UUID, deliveryAddress *models.Address) (manualConfirmation bool, blockMessage string, err error) {
 	// Validar se fazemos entrega neste endereço
 	log.Info().
 		Str("tenant_id", tenantID.String()).
@@ -960,8 +1170,16 @@ func (s *AIService) performFinalCheckout(tenantID, customerID uuid.UUID, custome
 		deliveryAddress.State,
 	)
 	if err != nil {
+		if s.getDeliveryFallbackMode(ctx, tenantID) == DeliveryFallbackManual {
+			log.Warn().
+				Err(err).
+				Str("tenant_id", tenantID.String()).
+				Str("customer_id", customerID.String()).
+				Msg("⚠️ Validação de entrega indisponível - pedido seguirá com confirmação manual da entrega")
+			return true, "", nil
+		}
 		log.Error().Err(err).Msg("Erro ao validar endereço de entrega")
-		return "❌ Erro ao validar endereço de entrega. Tente novamente ou entre em contato conosco.", err
+		return false, s.deliveryUnavailableMessage(ctx, tenantID), err
 	}
 
 	// Se não fazemos entrega neste endereço, oferecer opção de cadastrar novo
@@ -980,55 +1198,18 @@ func (s *AIService) performFinalCheckout(tenantID, customerID uuid.UUID, custome
 			reason = "Não conseguimos atender este endereço no momento."
 		}
 
-		return fmt.Sprintf("🚫 **Não fazemos entrega neste endereço:**\n\n📍 **Endereço atual:**\n%s\n\n⚠️ **Motivo:** %s\n\n🏠 **Opções:**\n1️⃣ **Cadastrar novo endereço:** Informe um endereço completo onde fazemos entrega\n2️⃣ **Gerenciar endereços:** Digite 'meus endereços' para ver/alterar\n3️⃣ **Verificar área:** Digite 'fazem entrega em [local]?' para verificar outras regiões\n\n💡 **Para continuar, informe um novo endereço de entrega.**",
+		return false, fmt.Sprintf("🚫 **Não fazemos entrega neste endereço:**\n\n📍 **Endereço atual:**\n%s\n\n⚠️ **Motivo:** %s\n\n🏠 **Opções:**\n1️⃣ **Cadastrar novo endereço:** Informe um endereço completo onde fazemos entrega\n2️⃣ **Gerenciar endereços:** Digite 'meus endereços' para ver/alterar\n3️⃣ **Verificar área:** Digite 'fazem entrega em [local]?' para verificar outras regiões\n\n💡 **Para continuar, informe um novo endereço de entrega.**",
 			addressText, reason), nil
 	}
 
-	// Endereço validado - prosseguir com a criação do pedido
+	// Endereço validado
 	log.Info().
 		Str("delivery_reason", deliveryResult.Reason).
 		Str("distance", deliveryResult.Distance).
 		Bool("can_deliver", deliveryResult.CanDeliver).
 		Msg("✅ Endereço de entrega validado com sucesso")
 
-	// Buscar conversation ID armazenado para esta sessão
-	conversationID := s.getConversationID(tenantID, customerPhone)
-
-	// Criar pedido com status pendente incluindo o endereço de entrega e conversation ID
-	var order *models.Order
-
-	if conversationID != uuid.Nil {
-		log.Info().Str("conversation_id", conversationID.String()).Msg("🔗 Criando pedido com conversation ID")
-		order, err = s.orderService.CreateOrderFromCartWithConversation(tenantID, cart.ID, conversationID, deliveryAddress)
-	} else {
-		log.Warn().Msg("⚠️ Nenhum conversation ID encontrado, criando pedido sem conversation ID")
-		order, err = s.orderService.CreateOrderFromCartWithAddress(tenantID, cart.ID, deliveryAddress)
-	}
-	if err != nil {
-		log.Error().Err(err).Msg("Erro ao criar pedido no checkout final")
-		return "❌ Erro ao criar pedido.", err
-	}
-
-	log.Info().
-		Str("order_number", order.OrderNumber).
-		Str("total", order.TotalAmount).
-		Str("delivery_address", fmt.Sprintf("%s, %s, %s, %s", deliveryAddress.Street, deliveryAddress.Number, deliveryAddress.Neighborhood, deliveryAddress.City)).
-		Msg("Pedido criado com sucesso no checkout final")
-
-	// 🧹 LIMPEZA COMPLETA APÓS PEDIDO CRIADO
-	s.cleanupAfterOrderCreation(tenantID, customerID, customerPhone, cart.ID)
-
-	// Send alert notification if configured
-	if s.alertService != nil {
-		if err := s.alertService.SendOrderAlert(tenantID, order, customerPhone); err != nil {
-			log.Error().Err(err).Msg("Erro ao enviar alerta do pedido")
-		}
-	}
-
-	return fmt.Sprintf("🎉 **Pedido registrado com sucesso!**\n\n📋 **Número do Pedido:** %s\n💰 **Total:** R$ %s\n📦 **Status:** Pendente\n\n✅ **Seu pedido foi registrado em nosso sistema!**\n\n👥 Um de nossos operadores irá revisar e confirmar seu pedido em breve.\n📞 Você será contatado para confirmar os detalhes da entrega e pagamento.\n\n🔍 Acompanhe seu pedido pelo número: **%s**",
-		order.OrderNumber,
-		formatCurrency(order.TotalAmount),
-		order.OrderNumber), nil
+	return false, "", nil
 }
 
 // cleanupAfterOrderCreation limpa carrinho, memória e dados do RAG após pedido criado
@@ -1056,9 +1237,12 @@ func (s *AIService) cleanupAfterOrderCreation(tenantID, customerID uuid.UUID, cu
 	log.Info().Msg("🧹 ✅ Limpeza completa finalizada - sistema pronto para novo pedido")
 }
 
-func (s *AIService) handleCheckout(tenantID, customerID uuid.UUID, customerPhone string) (string, error) {
+func (s *AIService) handleCheckout(ctx context.Context, tenantID, customerID uuid.UUID, customerPhone string) (string, error) {
+	// 🧹 Unificar linhas repetidas do mesmo produto antes de mostrar o resumo do pedido
+	s.consolidateActiveCart(tenantID, customerID)
+
 	// PRIMEIRO: Sempre mostrar o carrinho para o cliente conferir (sem instruções de gerenciamento)
-	cartMessage, err := s.handleVerCarrinhoWithOptions(tenantID, customerID, false)
+	cartMessage, err := s.handleVerCarrinhoWithOptions(ctx, tenantID, customerID, false)
 	if err != nil {
 		return "❌ Erro ao verificar carrinho.", err
 	}
@@ -1077,7 +1261,7 @@ func (s *AIService) handleCheckout(tenantID, customerID uuid.UUID, customerPhone
 		return "❌ Erro ao verificar dados do cliente.", err
 	}
 
-	if customer.Name == "" {
+	if !isValidCustomerName(customer.Name) {
 		return fmt.Sprintf("%s\n\n📝 Para seguir com seu pedido, precisamos completar o seu cadastro.\n\n🙋‍♂️ **Por favor, me informe seu nome completo:**", cartMessage), nil
 	}
 
@@ -1094,6 +1278,24 @@ func (s *AIService) handleCheckout(tenantID, customerID uuid.UUID, customerPhone
 		return "❌ Erro ao verificar carrinho.", err
 	}
 
+	// 🔞 Produtos restritos por idade: pedir confirmação de maioridade antes de seguir
+	if cartRequiresAgeConfirmation(cart) {
+		return fmt.Sprintf("%s\n\n%s", cartMessage, ageConfirmationBlockMessage(cart)), nil
+	}
+
+	// 📄 Medicamentos controlados: pedir a foto da receita antes de seguir
+	if cartMissingPrescription(cart) {
+		return fmt.Sprintf("%s\n\n%s", cartMessage, prescriptionBlockMessage(cart)), nil
+	}
+
+	// ⚖️ Limite de peso da entrega: sugerir dividir o pedido ou retirar na loja
+	if blockMessage := s.cartWeightBlockMessage(ctx, tenantID, cart); blockMessage != "" {
+		return fmt.Sprintf("%s\n\n%s", cartMessage, blockMessage), nil
+	}
+	if weightLine := s.checkoutWeightLine(ctx, tenantID, cart); weightLine != "" {
+		cartMessage += "\n" + weightLine
+	}
+
 	if cart.PaymentMethodID == nil {
 		// Buscar formas de pagamento disponíveis
 		paymentOptions, err := s.orderService.GetPaymentOptions(tenantID)
@@ -1108,15 +1310,31 @@ func (s *AIService) handleCheckout(tenantID, customerID uuid.UUID, customerPhone
 			}
 			result += "\n💬 **Como você quer pagar?** Me diga o número ou nome da forma de pagamento.\n"
 			result += "\n💡 **Exemplo:** 'quero pagar com PIX' ou 'número 1'"
+			result += s.depositPaymentHint(ctx, tenantID, cart)
 
+			s.setAwaitingSelection(tenantID, customerPhone, awaitingPaymentSelection)
 			return result, nil
 		}
 	}
 
+	// 🏪 Retirada na loja: confirmar endereço da loja e prazo, sem pedir endereço do cliente
+	if s.isPickupCart(ctx, tenantID, cart) {
+		return s.respondWithButtons(tenantID, customerPhone, s.pickupCheckoutMessage(ctx, tenantID, cartMessage), pickupConfirmationButtons), nil
+	}
+
 	// Verificar se tem endereços
 	addresses, err := s.addressService.GetAddressesByCustomer(tenantID, customerID)
 	if err != nil || len(addresses) == 0 {
-		return fmt.Sprintf("%s\n\n📝 Para finalizar o pedido, precisamos do seu endereço de entrega.\n\n🏠 **Por favor, me informe seu endereço completo:**\n\n💡 **Exemplo:** Rua das Flores, 123, Centro, Brasília, DF, CEP 70000-000, Complemento (se houver)", cartMessage), nil
+		pickupHint := ""
+		if s.isPickupAllowed(ctx, tenantID) {
+			pickupHint = "\n\n🏪 Prefere **retirar na loja**? É só me avisar!"
+		}
+		return fmt.Sprintf("%s\n\n📝 Para finalizar o pedido, precisamos do seu endereço de entrega.\n\n🏠 **Por favor, me informe seu endereço completo:**\n\n💡 **Exemplo:** Rua das Flores, 123, Centro, Brasília, DF, CEP 70000-000, Complemento (se houver)%s", cartMessage, pickupHint), nil
+	}
+
+	// 📍 Endereço lembrado: usado no último pedido dentro da janela configurada, dispensa nova confirmação
+	if remembered := s.rememberedDeliveryAddress(ctx, tenantID, customerID, addresses, time.Now()); remembered != nil {
+		return s.checkoutWithRememberedAddress(ctx, tenantID, customerID, customerPhone, *remembered)
 	}
 
 	// Se tem endereços, verificar se há múltiplos endereços
@@ -1133,11 +1351,13 @@ func (s *AIService) handleCheckout(tenantID, customerID uuid.UUID, customerPhone
 		// Se já há um endereço padrão, mostrar para confirmação antes de finalizar
 		if defaultAddress != nil {
 			addressText := formatAddressForDisplay(*defaultAddress)
-			return fmt.Sprintf("%s\n\n📦 **Confirme o endereço de entrega:**\n\n📍 **Endereço padrão:**\n%s\n\n✅ **Este endereço está correto para a entrega?**\n\n💬 Responda:\n🟢 **'sim'** ou **'confirmar'** - para finalizar o pedido\n🔄 **'não'** ou **'alterar'** - para escolher outro endereço\n📝 **'editar endereço'** - para modificar este endereço", cartMessage, addressText), nil
+			confirmation := fmt.Sprintf("%s\n\n📦 **Confirme o endereço de entrega:**\n\n📍 **Endereço padrão:**\n%s\n\n✅ **Este endereço está correto para a entrega?**\n\n💬 Responda:\n🟢 **'sim'** ou **'confirmar'** - para finalizar o pedido\n🔄 **'não'** ou **'alterar'** - para escolher outro endereço\n📝 **'editar endereço'** - para modificar este endereço", cartMessage, addressText)
+			return s.respondWithButtons(tenantID, customerPhone, confirmation, checkoutConfirmationButtons), nil
 		}
 
 		// Se não há endereço padrão, mostrar lista para seleção
 		addressesText := formatAddressesForSelection(addresses)
+		s.setAwaitingSelection(tenantID, customerPhone, awaitingAddressSelection)
 		return fmt.Sprintf("%s\n\n%s\n\n✅ **Qual endereço deseja usar para esta entrega?**\n\n💬 Responda com o número do endereço ou 'confirmar' para usar o padrão.", cartMessage, addressesText), nil
 	}
 
@@ -1153,7 +1373,8 @@ func (s *AIService) handleCheckout(tenantID, customerID uuid.UUID, customerPhone
 
 		// 🚨 CORREÇÃO: Mostrar carrinho junto com o endereço para confirmação antes de finalizar
 		addressText := formatAddressForDisplay(defaultAddress)
-		return fmt.Sprintf("%s\n\n📦 **Confirme o endereço de entrega:**\n\n📍 **Endereço cadastrado:**\n%s\n\n✅ **Este endereço está correto para a entrega?**\n\n💬 Responda:\n🟢 **'sim'** ou **'confirmar'** - para finalizar o pedido\n🔄 **'não'** ou **'alterar'** - para cadastrar outro endereço\n📝 **'editar endereço'** - para modificar este endereço", cartMessage, addressText), nil
+		confirmation := fmt.Sprintf("%s\n\n📦 **Confirme o endereço de entrega:**\n\n📍 **Endereço cadastrado:**\n%s\n\n✅ **Este endereço está correto para a entrega?**\n\n💬 Responda:\n🟢 **'sim'** ou **'confirmar'** - para finalizar o pedido\n🔄 **'não'** ou **'alterar'** - para cadastrar outro endereço\n📝 **'editar endereço'** - para modificar este endereço", cartMessage, addressText)
+		return s.respondWithButtons(tenantID, customerPhone, confirmation, checkoutConfirmationButtons), nil
 	}
 
 	return "❌ Erro inesperado no checkout.", nil
@@ -1181,74 +1402,18 @@ func (s *AIService) handleCancelarPedido(tenantID uuid.UUID, args map[string]int
 			Str("order_id_str", orderIDStr).
 			Msg("🔍 Não é UUID, tentando buscar na memória")
 
-		// Se não for UUID, tentar encontrar na memória por número sequencial
-		customerID, exists := args["customer_id"]
-		if exists {
-			customerIDStr, ok := customerID.(string)
-			if ok {
-				log.Info().
-					Str("customer_id", customerIDStr).
-					Msg("🔍 Buscando na memória do cliente")
-
-				// Buscar na memória os pedidos armazenados
-				ordersListData, found := s.memoryManager.GetTempData(tenantID, customerIDStr, "orders_list")
-				if found {
-					log.Info().Msg("🔍 Dados encontrados na memória")
-					ordersList, ok := ordersListData.([]map[string]interface{})
-					if ok {
-						log.Info().
-							Int("orders_count", len(ordersList)).
-							Msg("🔍 Lista de pedidos recuperada")
-
-						// Tentar encontrar por número sequencial
-						sequentialNum := orderIDStr
-						for _, orderData := range ordersList {
-							if sequential, seqOk := orderData["sequential"].(int); seqOk {
-								if fmt.Sprintf("%d", sequential) == sequentialNum {
-									if idStr, idOk := orderData["id"].(string); idOk {
-										if parsedUUID, parseUUIDErr := uuid.Parse(idStr); parseUUIDErr == nil {
-											orderID = parsedUUID
-											log.Info().
-												Str("found_uuid", orderID.String()).
-												Msg("✅ Pedido encontrado por número sequencial")
-											goto foundOrder
-										}
-									}
-								}
-							}
-						}
-
-						// Tentar encontrar por código do pedido
-						for _, orderData := range ordersList {
-							if orderNumber, numOk := orderData["number"].(string); numOk {
-								if orderNumber == orderIDStr {
-									if idStr, idOk := orderData["id"].(string); idOk {
-										if parsedUUID, parseUUIDErr := uuid.Parse(idStr); parseUUIDErr == nil {
-											orderID = parsedUUID
-											log.Info().
-												Str("found_uuid", orderID.String()).
-												Msg("✅ Pedido encontrado por código")
-											goto foundOrder
-										}
-									}
-								}
-							}
-						}
-					}
-				} else {
-					log.Warn().Msg("❌ Dados não encontrados na memória")
-				}
-			}
+		// Se não for UUID, tentar encontrar na memória por número sequencial ou código
+		customerIDStr, _ := args["customer_id"].(string)
+		resolvedID, found := s.findOrderInMemory(tenantID, customerIDStr, orderIDStr)
+		if !found {
+			log.Warn().
+				Str("order_id_str", orderIDStr).
+				Msg("❌ Pedido não encontrado em nenhum método")
+			return "❌ Pedido não encontrado. Use 'histórico de pedidos' primeiro e depois 'cancelar pedido [número]'.", nil
 		}
-
-		// Se chegou até aqui, não encontrou o pedido
-		log.Warn().
-			Str("order_id_str", orderIDStr).
-			Msg("❌ Pedido não encontrado em nenhum método")
-		return "❌ Pedido não encontrado. Use 'histórico de pedidos' primeiro e depois 'cancelar pedido [número]'.", nil
+		orderID = resolvedID
 	}
 
-foundOrder:
 	log.Info().
 		Str("order_uuid", orderID.String()).
 		Msg("🔄 Tentando cancelar pedido")
@@ -1286,7 +1451,7 @@ func (s *AIService) handleHistoricoPedidos(tenantID, customerID uuid.UUID) (stri
 	ordersList := make([]map[string]interface{}, 0)
 
 	for i, order := range orders {
-		if i >= 10 { // Limitar a 10 pedidos mais recentes
+		if i >= maxOrderHistoryEntries { // Limitar aos pedidos mais recentes
 			break
 		}
 
@@ -1296,24 +1461,7 @@ func (s *AIService) handleHistoricoPedidos(tenantID, customerID uuid.UUID) (stri
 		result += fmt.Sprintf("   📅 Data: %s\n", order.CreatedAt.Format("02/01/2006"))
 
 		// Adicionar status sempre
-		statusText := "Pendente"
-		switch order.Status {
-		case "pending":
-			statusText = "Pendente"
-		case "confirmed":
-			statusText = "Confirmado"
-		case "processing":
-			statusText = "Processando"
-		case "shipped":
-			statusText = "Enviado"
-		case "delivered":
-			statusText = "Entregue"
-		case "cancelled":
-			statusText = "Cancelado"
-		default:
-			statusText = order.Status
-		}
-		result += fmt.Sprintf("   📦 Status: %s\n", statusText)
+		result += fmt.Sprintf("   📦 Status: %s\n", getOrderStatusText(order.Status))
 
 		// Buscar quantidade de itens do pedido se disponível
 		// Por agora, adicionaremos informação genérica
@@ -1326,15 +1474,7 @@ func (s *AIService) handleHistoricoPedidos(tenantID, customerID uuid.UUID) (stri
 		result += "\n"
 
 		// Adicionar à lista para memória sequencial
-		orderData := map[string]interface{}{
-			"id":         order.ID.String(),
-			"number":     order.OrderNumber,
-			"sequential": i + 1,
-			"status":     order.Status,
-			"total":      formatCurrency(order.TotalAmount),
-			"created_at": order.CreatedAt.Format("02/01/2006"),
-		}
-		ordersList = append(ordersList, orderData)
+		ordersList = append(ordersList, orderMemoryEntry(order, i+1))
 	}
 
 	// Armazenar na memória para permitir cancelamento por número
@@ -1347,11 +1487,142 @@ func (s *AIService) handleHistoricoPedidos(tenantID, customerID uuid.UUID) (stri
 	return result, nil
 }
 
+// resolveProductIdentifier resolve um produto pelo número da última lista exibida, pelo nome ou pelo ID
+func (s *AIService) resolveProductIdentifier(ctx context.Context, tenantID uuid.UUID, customerPhone, identifier string) (*models.Product, error) {
+	var productRef *ProductReference
+	if sequentialID, err := strconv.Atoi(identifier); err == nil {
+		productRef = s.memoryManager.GetProductBySequentialID(tenantID, customerPhone, sequentialID)
+	} else {
+		productRef = s.memoryManager.GetProductByName(tenantID, customerPhone, identifier)
+	}
+
+	if productRef != nil {
+		return s.getProductByID(ctx, tenantID, productRef.ProductID)
+	}
+	if productID, err := uuid.Parse(identifier); err == nil {
+		return s.getProductByID(ctx, tenantID, productID)
+	}
+
+	products, err := s.searchProducts(ctx, tenantID, identifier, 1)
+	if err != nil {
+		return nil, err
+	}
+	if len(products) == 0 {
+		return nil, nil
+	}
+	return &products[0], nil
+}
+
+// orderMemoryEntry monta o registro do pedido guardado na memória sequencial do histórico
+func orderMemoryEntry(order models.Order, sequential int) map[string]interface{} {
+	return map[string]interface{}{
+		"id":         order.ID.String(),
+		"number":     order.OrderNumber,
+		"sequential": sequential,
+		"status":     order.Status,
+		"total":      formatCurrency(order.TotalAmount),
+		"created_at": order.CreatedAt.Format("02/01/2006"),
+	}
+}
+
+// getOrderStatusText traduz o status do pedido para exibição ao cliente
+func getOrderStatusText(status string) string {
+	switch status {
+	case "pending":
+		return "Pendente"
+	case "confirmed":
+		return "Confirmado"
+	case "processing":
+		return "Processando"
+	case "shipped":
+		return "Enviado"
+	case "delivered":
+		return "Entregue"
+	case "cancelled":
+		return "Cancelado"
+	default:
+		return status
+	}
+}
+
+// findOrderIDInList procura um pedido na lista do histórico por número sequencial ou por código
+func findOrderIDInList(ordersList []map[string]interface{}, identifier string) (uuid.UUID, bool) {
+	identifier = strings.TrimSpace(identifier)
+
+	// Tentar encontrar por número sequencial
+	for _, orderData := range ordersList {
+		if sequential, seqOk := orderData["sequential"].(int); seqOk && fmt.Sprintf("%d", sequential) == identifier {
+			if idStr, idOk := orderData["id"].(string); idOk {
+				if parsedUUID, parseErr := uuid.Parse(idStr); parseErr == nil {
+					return parsedUUID, true
+				}
+			}
+		}
+	}
+
+	// Tentar encontrar por código do pedido
+	for _, orderData := range ordersList {
+		if orderNumber, numOk := orderData["number"].(string); numOk && strings.EqualFold(orderNumber, identifier) {
+			if idStr, idOk := orderData["id"].(string); idOk {
+				if parsedUUID, parseErr := uuid.Parse(idStr); parseErr == nil {
+					return parsedUUID, true
+				}
+			}
+		}
+	}
+
+	return uuid.Nil, false
+}
+
+// findOrderInMemory resolve o pedido usando a lista armazenada pelo histórico de pedidos
+func (s *AIService) findOrderInMemory(tenantID uuid.UUID, customerIDStr, identifier string) (uuid.UUID, bool) {
+	if customerIDStr == "" {
+		return uuid.Nil, false
+	}
+
+	log.Info().
+		Str("customer_id", customerIDStr).
+		Msg("🔍 Buscando na memória do cliente")
+
+	ordersListData, found := s.memoryManager.GetTempData(tenantID, customerIDStr, "orders_list")
+	if !found {
+		log.Warn().Msg("❌ Dados não encontrados na memória")
+		return uuid.Nil, false
+	}
+
+	ordersList, ok := ordersListData.([]map[string]interface{})
+	if !ok {
+		return uuid.Nil, false
+	}
+
+	orderID, found := findOrderIDInList(ordersList, identifier)
+	if found {
+		log.Info().
+			Str("found_uuid", orderID.String()).
+			Msg("✅ Pedido encontrado na memória")
+	}
+	return orderID, found
+}
+
 // handleSelecionarFormaPagamento registra a forma de pagamento escolhida pelo cliente
-func (s *AIService) handleSelecionarFormaPagamento(tenantID, customerID uuid.UUID, args map[string]interface{}) (string, error) {
+func (s *AIService) handleSelecionarFormaPagamento(ctx context.Context, tenantID, customerID uuid.UUID, args map[string]interface{}) (string, error) {
 	var paymentMethodID uuid.UUID
 	var paymentMethodName string
 
+	// Número da opção na lista de formas de pagamento exibida no checkout
+	if number, ok := args["numero_opcao"].(float64); ok {
+		paymentOptions, err := s.orderService.GetPaymentOptions(tenantID)
+		if err != nil {
+			return "❌ Erro ao buscar formas de pagamento.", err
+		}
+		index := int(number)
+		if index < 1 || index > len(paymentOptions) {
+			return fmt.Sprintf("❌ Opção %d não encontrada. Escolha um número de 1 a %d.", index, len(paymentOptions)), nil
+		}
+		paymentMethodID, _ = uuid.Parse(paymentOptions[index-1].ID)
+		paymentMethodName = paymentOptions[index-1].Name
+	}
+
 	// Tentar obter ID ou nome do método de pagamento
 	if paymentMethodIDStr, ok := args["payment_method_id"].(string); ok && paymentMethodIDStr != "" {
 		// Tentar como UUID primeiro
@@ -1426,6 +1697,20 @@ func (s *AIService) handleSelecionarFormaPagamento(tenantID, customerID uuid.UUI
 	changeForAmount, _ := args["change_for_amount"].(string)
 	observations, _ := args["observations"].(string)
 
+	// Troco calculado com o total atual; valor que não cobre o total não é registrado
+	changeMessage := ""
+	if needsChange && changeForAmount != "" {
+		if tendered, ok := parseTenderedAmount(changeForAmount); ok {
+			var covered bool
+			changeMessage, covered = formatCashChange(s.cartTotalBreakdown(ctx, tenantID, cart).Total, tendered)
+			if covered {
+				changeForAmount = tendered.String()
+			} else {
+				changeForAmount = ""
+			}
+		}
+	}
+
 	// Se precisa de troco, atualizar observações
 	if needsChange && changeForAmount != "" {
 		err = s.cartService.UpdateCartObservations(cart.ID, tenantID, observations, changeForAmount)
@@ -1455,7 +1740,9 @@ func (s *AIService) handleSelecionarFormaPagamento(tenantID, customerID uuid.UUI
 
 	result := fmt.Sprintf("✅ **Forma de pagamento registrada:**\n\n💳 %s\n", paymentMethodName)
 
-	if needsChange && changeForAmount != "" {
+	if changeMessage != "" {
+		result += "\n" + changeMessage + "\n"
+	} else if needsChange && changeForAmount != "" {
 		result += fmt.Sprintf("\n💵 Troco para: R$ %s\n", changeForAmount)
 	}
 
@@ -1469,12 +1756,12 @@ func (s *AIService) handleSelecionarFormaPagamento(tenantID, customerID uuid.UUI
 }
 
 // handleTrocarFormaPagamento permite alterar a forma de pagamento já selecionada
-func (s *AIService) handleTrocarFormaPagamento(tenantID, customerID uuid.UUID, args map[string]interface{}) (string, error) {
+func (s *AIService) handleTrocarFormaPagamento(ctx context.Context, tenantID, customerID uuid.UUID, args map[string]interface{}) (string, error) {
 	// Reutiliza a mesma lógica de seleção
-	return s.handleSelecionarFormaPagamento(tenantID, customerID, args)
+	return s.handleSelecionarFormaPagamento(ctx, tenantID, customerID, args)
 }
 
-func (s *AIService) handleAtualizarCadastro(tenantID, customerID uuid.UUID, customerPhone string, args map[string]interface{}) (string, error) {
+func (s *AIService) handleAtualizarCadastro(ctx context.Context, tenantID, customerID uuid.UUID, customerPhone string, args map[string]interface{}) (string, error) {
 	var updates CustomerUpdateData
 	var updatedFields []string
 
@@ -1482,12 +1769,22 @@ func (s *AIService) handleAtualizarCadastro(tenantID, customerID uuid.UUID, cust
 		Interface("args", args).
 		Msg("🔍 DEBUG: handleAtualizarCadastro received args")
 
+	invalidName := false
 	if nome, ok := args["nome"].(string); ok && nome != "" {
-		updates.Name = nome
-		updatedFields = append(updatedFields, "nome")
-		log.Info().
-			Str("nome", nome).
-			Msg("🏷️ DEBUG: Name parameter received")
+		nome = normalizeCustomerName(nome)
+		if isValidCustomerName(nome) {
+			updates.Name = nome
+			updatedFields = append(updatedFields, "nome")
+			log.Info().
+				Str("nome", nome).
+				Msg("🏷️ DEBUG: Name parameter received")
+		} else {
+			// Nomes genéricos (ex: "WhatsApp User") não são salvos para não identificar pedidos com nomes sem sentido
+			invalidName = true
+			log.Info().
+				Str("nome", nome).
+				Msg("🏷️ Ignoring placeholder customer name")
+		}
 	}
 
 	if email, ok := args["email"].(string); ok && email != "" {
@@ -1512,7 +1809,7 @@ func (s *AIService) handleAtualizarCadastro(tenantID, customerID uuid.UUID, cust
 			// Cliente confirmou o endereço existente, prosseguir com checkout final
 			response := "✅ **Endereço confirmado!**\n\n🎯 Prosseguindo com o pedido...\n\n"
 
-			checkoutResult, checkoutErr := s.performFinalCheckout(tenantID, customerID, customerPhone)
+			checkoutResult, checkoutErr := s.performFinalCheckout(ctx, tenantID, customerID, customerPhone)
 			if checkoutErr == nil {
 				response += checkoutResult
 				return response, nil
@@ -1556,7 +1853,7 @@ func (s *AIService) handleAtualizarCadastro(tenantID, customerID uuid.UUID, cust
 						response := fmt.Sprintf("✅ **Endereço %d selecionado como padrão!**\n\n📋 **Endereço de entrega:**\n%s\n\n🎯 Prosseguindo com o pedido...\n\n",
 							addressNum, formatAddressForDisplay(selectedAddress))
 
-						checkoutResult, checkoutErr := s.performFinalCheckout(tenantID, customerID, customerPhone)
+						checkoutResult, checkoutErr := s.performFinalCheckout(ctx, tenantID, customerID, customerPhone)
 						if checkoutErr == nil {
 							response += checkoutResult
 							return response, nil
@@ -1620,7 +1917,6 @@ func (s *AIService) handleAtualizarCadastro(tenantID, customerID uuid.UUID, cust
 			Msg("🧠 Processing address with AI parsing")
 
 		// Usar IA para extrair campos do endereço
-		ctx := context.Background()
 		parsedAddress, err := s.parseAddressWithAI(ctx, endereco)
 		if err != nil {
 			log.Error().
@@ -1640,6 +1936,11 @@ func (s *AIService) handleAtualizarCadastro(tenantID, customerID uuid.UUID, cust
 			// Remover padrão de todos os endereços existentes
 			existingAddresses, err := s.addressService.GetAddressesByCustomer(tenantID, customerID)
 			if err == nil && len(existingAddresses) > 0 {
+				// Limite de endereços por cliente: pedir para apagar um antes de cadastrar outro
+				if limitMessage := s.addressLimitMessage(ctx, tenantID, existingAddresses); limitMessage != "" {
+					return limitMessage, nil
+				}
+
 				// Remove o padrão de todos os endereços existentes
 				for _, existingAddr := range existingAddresses {
 					if existingAddr.IsDefault {
@@ -1656,18 +1957,24 @@ func (s *AIService) handleAtualizarCadastro(tenantID, customerID uuid.UUID, cust
 				return "❌ **Cidade obrigatória!**\n\n🏙️ Por favor, informe a cidade no seu endereço.\n\n📝 Exemplo: 'Avenida Hugo Musso, 1333, Praia da Costa, Vila Velha, ES'", nil
 			}
 
+			if parsedAddress.State == "" {
+				return invalidStateMessage, nil
+			}
+
 			// Criar novo endereço com campos estruturados da IA
 			address := &models.Address{
-				CustomerID:   customerID,
-				Street:       parsedAddress.Street,
-				Number:       parsedAddress.Number,
-				Complement:   parsedAddress.Complement,
-				Neighborhood: parsedAddress.Neighborhood,
-				City:         parsedAddress.City,
-				State:        parsedAddress.State,
-				ZipCode:      parsedAddress.ZipCode,
-				Country:      "BR",
-				IsDefault:    true,
+				CustomerID:     customerID,
+				Street:         parsedAddress.Street,
+				Number:         parsedAddress.Number,
+				Complement:     parsedAddress.Complement,
+				Apartment:      parsedAddress.Apartment,
+				ReferencePoint: parsedAddress.ReferencePoint,
+				Neighborhood:   parsedAddress.Neighborhood,
+				City:           parsedAddress.City,
+				State:          parsedAddress.State,
+				ZipCode:        parsedAddress.ZipCode,
+				Country:        "BR",
+				IsDefault:      true,
 			}
 
 			err = s.addressService.CreateAddress(tenantID, address)
@@ -1687,6 +1994,9 @@ func (s *AIService) handleAtualizarCadastro(tenantID, customerID uuid.UUID, cust
 	}
 
 	if len(updatedFields) == 0 {
+		if invalidName {
+			return "🙋‍♂️ **Por favor, me informe seu nome completo** para seguirmos com o cadastro.", nil
+		}
 		return "❌ Nenhum dado válido para atualizar.", nil
 	}
 
@@ -1720,7 +2030,7 @@ func (s *AIService) handleAtualizarCadastro(tenantID, customerID uuid.UUID, cust
 			Str("updates_name", updates.Name).
 			Str("customer_name", customerName).
 			Msg("🏷️ Using name from updates")
-	} else if customer != nil && customer.Name != "" {
+	} else if customer != nil && isValidCustomerName(customer.Name) {
 		// Se não há nome novo, usar o nome existente
 		names := strings.Fields(strings.TrimSpace(customer.Name))
 		if len(names) > 0 {
@@ -1741,7 +2051,7 @@ func (s *AIService) handleAtualizarCadastro(tenantID, customerID uuid.UUID, cust
 	// Tentar finalizar pedido automaticamente se dados estão completos
 	if len(updatedFields) > 0 {
 		// Tentar finalizar pedido diretamente
-		checkoutResult, checkoutErr := s.performFinalCheckout(tenantID, customerID, customerPhone)
+		checkoutResult, checkoutErr := s.performFinalCheckout(ctx, tenantID, customerID, customerPhone)
 		if checkoutErr == nil {
 			// Se finalizou com sucesso, mostrar mensagem de sucesso personalizada
 			response := fmt.Sprintf("✅ **%s**, seu cadastro foi atualizado com sucesso!\n\n", customerName)
@@ -1762,7 +2072,7 @@ func (s *AIService) handleAtualizarCadastro(tenantID, customerID uuid.UUID, cust
 			}
 
 			// Se for outro tipo de erro, mostrar o carrinho
-			cartResult, cartErr := s.handleCheckout(tenantID, customerID, customerPhone)
+			cartResult, cartErr := s.handleCheckout(ctx, tenantID, customerID, customerPhone)
 			if cartErr == nil {
 				response += cartResult
 			} else {
@@ -1776,7 +2086,7 @@ func (s *AIService) handleAtualizarCadastro(tenantID, customerID uuid.UUID, cust
 	return fmt.Sprintf("✅ **%s**, seu cadastro foi atualizado com sucesso!", customerName), nil
 }
 
-func (s *AIService) handleGerenciarEnderecos(tenantID, customerID uuid.UUID, args map[string]interface{}) (string, error) {
+func (s *AIService) handleGerenciarEnderecos(tenantID, customerID uuid.UUID, customerPhone string, args map[string]interface{}) (string, error) {
 	acao, ok := args["acao"].(string)
 	if !ok {
 		return "❌ Ação não especificada.", nil
@@ -1794,6 +2104,7 @@ func (s *AIService) handleGerenciarEnderecos(tenantID, customerID uuid.UUID, arg
 		}
 
 		addressesText := formatAddressesForSelection(addresses)
+		s.setAwaitingSelection(tenantID, customerPhone, awaitingAddressSelection)
 		return fmt.Sprintf("%s\n\n💡 **Para usar um endereço específico, diga:** 'usar endereço 2' ou 'endereço 1'\n🏠 **Para adicionar novo endereço, apenas informe o endereço completo.**\n🗑️ **Para deletar:** 'deletar endereço 2' ou 'deletar todos'\n\n**Exemplo de endereço completo:** Rua das Flores, 123, Centro, Brasília, DF, CEP 70000-000, Apto 101", addressesText), nil
 
 	case "selecionar":
@@ -1859,7 +2170,7 @@ func (s *AIService) handleGerenciarEnderecos(tenantID, customerID uuid.UUID, arg
 	}
 }
 
-func (s *AIService) handleCadastrarEndereco(tenantID, customerID uuid.UUID, args map[string]interface{}) (string, error) {
+func (s *AIService) handleCadastrarEndereco(ctx context.Context, tenantID, customerID uuid.UUID, args map[string]interface{}) (string, error) {
 	// Parse endereço completo se fornecido
 	enderecoCompleto, hasCompleto := args["endereco_completo"].(string)
 
@@ -1885,6 +2196,9 @@ func (s *AIService) handleCadastrarEndereco(tenantID, customerID uuid.UUID, args
 
 			if len(parts) >= 5 {
 				address.State = strings.TrimSpace(parts[4])
+				if uf, ok := normalizeBrazilianUF(address.State); ok {
+					address.State = uf
+				}
 
 				// Validar se a cidade existe no banco de dados
 				if s.municipioService != nil {
@@ -1945,6 +2259,11 @@ func (s *AIService) handleCadastrarEndereco(tenantID, customerID uuid.UUID, args
 	if address.State == "" {
 		return "❌ **Estado é obrigatório.**\n\n💡 **Informe o endereço completo:** Rua, Número, Bairro, Cidade, Estado, CEP, Complemento (se houver)", nil
 	}
+	uf, validState := normalizeBrazilianUF(address.State)
+	if !validState {
+		return invalidStateMessage, nil
+	}
+	address.State = uf
 	if address.ZipCode == "" {
 		return "❌ **CEP é obrigatório.**\n\n💡 **Informe o endereço completo:** Rua, Número, Bairro, Cidade, Estado, CEP, Complemento (se houver)", nil
 	}
@@ -1955,8 +2274,13 @@ func (s *AIService) handleCadastrarEndereco(tenantID, customerID uuid.UUID, args
 		return "❌ Erro ao verificar endereços existentes.", err
 	}
 
-	// Se é o primeiro endereço, marcar como padrão
-	if len(existingAddresses) == 0 {
+	// Limite de endereços por cliente: pedir para apagar um antes de cadastrar outro
+	if limitMessage := s.addressLimitMessage(ctx, tenantID, existingAddresses); limitMessage != "" {
+		return limitMessage, nil
+	}
+
+	// Se é o primeiro endereço (ou nenhum está marcado como padrão), marcar como padrão
+	if !hasDefaultAddress(existingAddresses) {
 		address.IsDefault = true
 	}
 
@@ -2019,7 +2343,7 @@ func getStatusEmoji(status string) string {
 	}
 }
 
-func (s *AIService) handleBuscarMultiplosProdutos(tenantID, customerID uuid.UUID, customerPhone string, args map[string]interface{}) (string, error) {
+func (s *AIService) handleBuscarMultiplosProdutos(ctx context.Context, tenantID, customerID uuid.UUID, customerPhone string, args map[string]interface{}) (string, error) {
 	produtosInterface, ok := args["produtos"].([]interface{})
 	if !ok {
 		return "❌ Lista de produtos é obrigatória.", nil
@@ -2055,7 +2379,7 @@ func (s *AIService) handleBuscarMultiplosProdutos(tenantID, customerID uuid.UUID
 
 	for i, nomeProduto := range produtos {
 		// Search for each product
-		searchResults, err := s.productService.SearchProducts(tenantID, nomeProduto, 5)
+		searchResults, err := s.searchProducts(ctx, tenantID, nomeProduto, 5)
 		if err != nil {
 			result += fmt.Sprintf("%d. ❌ Erro ao buscar '%s'\n\n", i+1, nomeProduto)
 			continue
@@ -2077,12 +2401,7 @@ func (s *AIService) handleBuscarMultiplosProdutos(tenantID, customerID uuid.UUID
 
 		result += fmt.Sprintf("%d. 🔍 **%s** (%d %s):\n", i+1, nomeProduto, len(searchResults), opcaoOuOpcoes)
 		for _, ref := range productRefs {
-			var price string
-			if ref.SalePrice != "" && ref.SalePrice != "0" {
-				price = fmt.Sprintf("~~R$ %s~~ **R$ %s**", formatCurrency(ref.Price), formatCurrency(ref.SalePrice))
-			} else {
-				price = fmt.Sprintf("**R$ %s**", formatCurrency(ref.Price))
-			}
+			price := formatListPrice(ref.Price, ref.SalePrice)
 			result += fmt.Sprintf("   %d. **%s**\n", ref.SequentialID, ref.Name)
 			result += fmt.Sprintf("      💰 %s\n", price)
 		}
@@ -2153,12 +2472,15 @@ func (s *AIService) handleAdicionarMaisItemCarrinho(tenantID, customerID uuid.UU
 	if err != nil {
 		return "❌ Erro ao atualizar quantidade do item.", err
 	}
+	updated := *foundItem
+	updated.Quantity = novaQuantidade
+	s.rememberCartAdd(tenantID, customerID, cart.ID, updated, getItemName(*foundItem), quantidadeAdicional)
 
 	return fmt.Sprintf("✅ **%s** - adicionadas mais %d unidades!\n📦 Nova quantidade: %d unidades",
 		getItemName(*foundItem), quantidadeAdicional, novaQuantidade), nil
 }
 
-func (s *AIService) handleAdicionarPorNumero(tenantID, customerID uuid.UUID, customerPhone string, args map[string]interface{}) (string, error) {
+func (s *AIService) handleAdicionarPorNumero(ctx context.Context, tenantID, customerID uuid.UUID, customerPhone string, args map[string]interface{}) (string, error) {
 	numeroFloat, ok := args["numero"].(float64)
 	if !ok {
 		return "❌ Número do produto é obrigatório.", nil
@@ -2182,13 +2504,30 @@ func (s *AIService) handleAdicionarPorNumero(tenantID, customerID uuid.UUID, cus
 	}
 
 	// Get full product details
-	product, err := s.productService.GetProductByID(tenantID, productRef.ProductID)
+	product, err := s.getProductByID(ctx, tenantID, productRef.ProductID)
 	if err != nil || product == nil {
 		return "❌ Produto não encontrado.", err
 	}
 
+	if !product.Available {
+		return unavailableProductMessage(product), nil
+	}
+
 	if product.StockQuantity < quantidade {
-		return fmt.Sprintf("❌ Estoque insuficiente. Disponível: %d unidades.", product.StockQuantity), nil
+		message := fmt.Sprintf("❌ Estoque insuficiente. Disponível: %d unidades.", product.StockQuantity)
+		return s.withStockAlternatives(ctx, tenantID, customerPhone, product, quantidade, message), nil
+	}
+
+	if !hasValidPrice(product) {
+		return invalidPriceMessage(product), nil
+	}
+
+	if message := s.categoryClosedMessage(ctx, tenantID, product); message != "" {
+		return message, nil
+	}
+
+	if message := s.readdGuardMessage(ctx, tenantID, customerID, product, quantidade); message != "" {
+		return message, nil
 	}
 
 	// Get or create cart
@@ -2202,8 +2541,11 @@ func (s *AIService) handleAdicionarPorNumero(tenantID, customerID uuid.UUID, cus
 	if err != nil {
 		return "❌ Erro ao adicionar item ao carrinho.", err
 	}
+	s.rememberProductAdd(tenantID, customerID, cart.ID, product, quantidade)
 
 	adicional := "\n\nVocê pode continuar comprando ou digite 'finalizar' para fechar o pedido."
+	adicional += ageConfirmationNotice(cart, product)
+	adicional += prescriptionNotice(cart, product)
 	return fmt.Sprintf("✅ **%s** adicionado ao carrinho!\n🔢 Quantidade: %d\n💰 Valor: R$ %s",
 		product.Name, quantidade, formatCurrency(getEffectivePrice(product))) + adicional, nil
 }
@@ -2228,11 +2570,11 @@ func (s *AIService) getCartItemByNumber(tenantID, customerID uuid.UUID, itemNumb
 }
 
 // generateDynamicSearchSuggestions gera sugestões alternativas baseadas nos produtos reais do tenant
-func (s *AIService) generateDynamicSearchSuggestions(tenantID uuid.UUID, query, marca, tags string) []string {
+func (s *AIService) generateDynamicSearchSuggestions(ctx context.Context, tenantID uuid.UUID, query, marca, tags string) []string {
 	suggestions := []string{}
 
 	// Buscar produtos populares para análise
-	products, err := s.productService.SearchProductsAdvanced(tenantID, ProductSearchFilters{
+	products, err := s.searchProductsAdvanced(ctx, tenantID, ProductSearchFilters{
 		Query: "",
 		Limit: 50, // Buscar um sample dos produtos para análise
 	})
@@ -2383,8 +2725,8 @@ func formatAddressForDisplay(address models.Address) string {
 		if address.Number != "" {
 			streetPart += ", " + address.Number
 		}
-		if address.Complement != "" {
-			streetPart += " - " + address.Complement
+		if complement := address.DeliveryComplement(); complement != "" {
+			streetPart += " - " + complement
 		}
 		parts = append(parts, streetPart)
 	}
@@ -2429,6 +2771,10 @@ func formatAddressForDisplay(address models.Address) string {
 		parts = append(parts, "CEP: "+address.ZipCode)
 	}
 
+	if address.ReferencePoint != "" {
+		parts = append(parts, "📍 Referência: "+address.ReferencePoint)
+	}
+
 	return strings.Join(parts, "\n")
 }
 
@@ -2699,7 +3045,7 @@ func (s *AIService) getChannelByCustomer(tenantID uuid.UUID, customerPhone strin
 	// Tentar usar customerService para acessar o banco
 	if customerSvc, ok := s.customerService.(*CustomerServiceImpl); ok {
 		// Primeiro, buscar o customer
-		customer, err := s.customerService.GetCustomerByPhone(tenantID, customerPhone)
+		customer, err := s.customerService.GetOrCreateCustomerByPhone(tenantID, customerPhone)
 		if err != nil {
 			log.Debug().Err(err).Msg("Não foi possível encontrar customer")
 			return nil, err
@@ -2743,7 +3089,7 @@ func (s *AIService) getChannelByCustomer(tenantID uuid.UUID, customerPhone strin
 }
 
 // handleSolicitarAtendimentoHumano processa solicitações de atendimento humano
-func (s *AIService) handleSolicitarAtendimentoHumano(tenantID, customerID uuid.UUID, customerPhone string, args map[string]interface{}) (string, error) {
+func (s *AIService) handleSolicitarAtendimentoHumano(ctx context.Context, tenantID, customerID uuid.UUID, customerPhone string, args map[string]interface{}) (string, error) {
 	log.Info().
 		Str("tenant_id", tenantID.String()).
 		Str("customer_id", customerID.String()).
@@ -2757,7 +3103,7 @@ func (s *AIService) handleSolicitarAtendimentoHumano(tenantID, customerID uuid.U
 	}
 
 	// Buscar informações do cliente
-	customer, err := s.customerService.GetCustomerByPhone(tenantID, customerPhone)
+	customer, err := s.customerService.GetOrCreateCustomerByPhone(tenantID, customerPhone)
 	if err != nil {
 		log.Error().Err(err).Msg("Erro ao buscar informações do cliente")
 		customer = &models.Customer{
@@ -2790,6 +3136,14 @@ func (s *AIService) handleSolicitarAtendimentoHumano(tenantID, customerID uuid.U
 		}
 	}()
 
+	// Pausar o bot para que o atendente assuma sem interrupções
+	if s.pauseBotForHumanHandoff(ctx, tenantID, customerPhone) {
+		return "👋 **Atendimento Humano Solicitado**\n\n" +
+			"Entendi que você gostaria de falar com um atendente humano.\n\n" +
+			"✅ **Sua solicitação foi encaminhada para nossa equipe!**\n\n" +
+			"🕐 **Um de nossos atendentes vai continuar a conversa por aqui em breve.**", nil
+	}
+
 	// Resposta amigável para o cliente
 	response := "👋 **Atendimento Humano Solicitado**\n\n" +
 		"Entendi que você gostaria de falar com um atendente humano.\n\n" +
@@ -2949,12 +3303,7 @@ func (s *AIService) formatProductsByCategoryComplete(tenantID uuid.UUID, custome
 			currentCategoryID = productCategoryID
 		}
 
-		var price string
-		if productRef.SalePrice != "" && productRef.SalePrice != "0" {
-			price = fmt.Sprintf("~~R$ %s~~ **R$ %s**", formatCurrency(productRef.Price), formatCurrency(productRef.SalePrice))
-		} else {
-			price = fmt.Sprintf("**R$ %s**", formatCurrency(productRef.Price))
-		}
+		price := formatListPrice(productRef.Price, productRef.SalePrice)
 
 		result += fmt.Sprintf("   %d. **%s**\n", productRef.SequentialID, productRef.Name)
 		result += fmt.Sprintf("      💰 %s\n", price)
@@ -2973,12 +3322,7 @@ func (s *AIService) formatProductsStandard(tenantID uuid.UUID, customerPhone str
 	result := "🛍️ **Produtos disponíveis:**\n\n"
 
 	for _, productRef := range productRefs {
-		var price string
-		if productRef.SalePrice != "" && productRef.SalePrice != "0" {
-			price = fmt.Sprintf("~~R$ %s~~ **R$ %s**", formatCurrency(productRef.Price), formatCurrency(productRef.SalePrice))
-		} else {
-			price = fmt.Sprintf("**R$ %s**", formatCurrency(productRef.Price))
-		}
+		price := formatListPrice(productRef.Price, productRef.SalePrice)
 
 		result += fmt.Sprintf("%d. **%s**\n", productRef.SequentialID, productRef.Name)
 		result += fmt.Sprintf("   💰 %s\n", price)
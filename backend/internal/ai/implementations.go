@@ -1,11 +1,14 @@
 package ai
 
 import (
+	"errors"
 	"fmt"
+	"iafarma/internal/repo"
+	"iafarma/internal/utils"
 	"iafarma/pkg/models"
 	"regexp"
-	"strconv"
 	"strings"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/rs/zerolog/log"
@@ -32,8 +35,9 @@ func (s *ProductServiceImpl) SearchProducts(tenantID uuid.UUID, query string, li
 func (s *ProductServiceImpl) SearchProductsAdvanced(tenantID uuid.UUID, filters ProductSearchFilters) ([]models.Product, error) {
 	var products []models.Product
 
-	// FILTRO OBRIGATÓRIO: Apenas produtos com estoque > 0 (disponíveis para venda)
-	dbQuery := s.db.Where("tenant_id = ? AND stock_quantity > 0", tenantID)
+	// Por padrão apenas produtos com estoque > 0 (disponíveis para venda)
+	baseCondition := filters.BaseCondition()
+	dbQuery := s.db.Where(baseCondition, tenantID)
 
 	// Full Text Search usando PostgreSQL FTS
 	if filters.Query != "" {
@@ -52,7 +56,7 @@ func (s *ProductServiceImpl) SearchProductsAdvanced(tenantID uuid.UUID, filters
 		log.Info().Msgf("🔍 FTS Debug: tsqueryFormat='%s'", tsqueryFormat)
 
 		// Testar FTS diretamente com operador AND/OR expandido
-		ftsQuery := s.db.Where("tenant_id = ? AND stock_quantity > 0", tenantID).
+		ftsQuery := s.db.Where(baseCondition, tenantID).
 			Where("search_text @@ to_tsquery('portuguese', ?)", tsqueryFormat)
 
 		// Verificar se FTS encontrou resultados
@@ -69,9 +73,9 @@ func (s *ProductServiceImpl) SearchProductsAdvanced(tenantID uuid.UUID, filters
 			// Aplicar ordenação conforme solicitado
 			switch filters.SortBy {
 			case "price_asc":
-				dbQuery = dbQuery.Order("CAST(price AS DECIMAL) ASC")
+				dbQuery = dbQuery.Order(ProductPriceSQL + " ASC")
 			case "price_desc":
-				dbQuery = dbQuery.Order("CAST(price AS DECIMAL) DESC")
+				dbQuery = dbQuery.Order(ProductPriceSQL + " DESC")
 			case "name_asc":
 				dbQuery = dbQuery.Order("name ASC")
 			case "name_desc":
@@ -83,7 +87,7 @@ func (s *ProductServiceImpl) SearchProductsAdvanced(tenantID uuid.UUID, filters
 			// PRIORIDADE 2: Fallback para busca simples AND se query expandida não funcionou
 			simpleAndQuery := strings.ReplaceAll(searchQuery, " ", " & ")
 
-			simpleFtsQuery := s.db.Where("tenant_id = ? AND stock_quantity > 0", tenantID).
+			simpleFtsQuery := s.db.Where(baseCondition, tenantID).
 				Where("search_text @@ to_tsquery('portuguese', ?)", simpleAndQuery)
 
 			var simpleFtsCount int64
@@ -98,9 +102,9 @@ func (s *ProductServiceImpl) SearchProductsAdvanced(tenantID uuid.UUID, filters
 				// Aplicar ordenação conforme solicitado
 				switch filters.SortBy {
 				case "price_asc":
-					dbQuery = dbQuery.Order("CAST(price AS DECIMAL) ASC")
+					dbQuery = dbQuery.Order(ProductPriceSQL + " ASC")
 				case "price_desc":
-					dbQuery = dbQuery.Order("CAST(price AS DECIMAL) DESC")
+					dbQuery = dbQuery.Order(ProductPriceSQL + " DESC")
 				case "name_asc":
 					dbQuery = dbQuery.Order("name ASC")
 				case "name_desc":
@@ -110,7 +114,7 @@ func (s *ProductServiceImpl) SearchProductsAdvanced(tenantID uuid.UUID, filters
 				}
 			} else {
 				// PRIORIDADE 3: Busca exata no nome se FTS não encontrou
-				exactNameQuery := s.db.Where("tenant_id = ? AND stock_quantity > 0", tenantID).
+				exactNameQuery := s.db.Where(baseCondition, tenantID).
 					Where("LOWER(name) LIKE LOWER(?)", "%"+searchQuery+"%")
 
 				var exactNameCount int64
@@ -126,9 +130,9 @@ func (s *ProductServiceImpl) SearchProductsAdvanced(tenantID uuid.UUID, filters
 					// Aplicar ordenação conforme solicitado
 					switch filters.SortBy {
 					case "price_asc":
-						dbQuery = dbQuery.Order("CAST(price AS DECIMAL) ASC")
+						dbQuery = dbQuery.Order(ProductPriceSQL + " ASC")
 					case "price_desc":
-						dbQuery = dbQuery.Order("CAST(price AS DECIMAL) DESC")
+						dbQuery = dbQuery.Order(ProductPriceSQL + " DESC")
 					case "name_asc":
 						dbQuery = dbQuery.Order("name ASC")
 					case "name_desc":
@@ -180,9 +184,9 @@ func (s *ProductServiceImpl) SearchProductsAdvanced(tenantID uuid.UUID, filters
 					// Aplicar ordenação conforme solicitado
 					switch filters.SortBy {
 					case "price_asc":
-						dbQuery = dbQuery.Order("CAST(price AS DECIMAL) ASC")
+						dbQuery = dbQuery.Order(ProductPriceSQL + " ASC")
 					case "price_desc":
-						dbQuery = dbQuery.Order("CAST(price AS DECIMAL) DESC")
+						dbQuery = dbQuery.Order(ProductPriceSQL + " DESC")
 					case "name_asc":
 						dbQuery = dbQuery.Order("name ASC")
 					case "name_desc":
@@ -200,6 +204,11 @@ func (s *ProductServiceImpl) SearchProductsAdvanced(tenantID uuid.UUID, filters
 		dbQuery = dbQuery.Where("LOWER(brand) LIKE LOWER(?)", "%"+filters.Brand+"%")
 	}
 
+	// Filtro por categoria
+	if filters.CategoryID != nil {
+		dbQuery = dbQuery.Where("category_id = ?", *filters.CategoryID)
+	}
+
 	// Filtro por tags
 	if filters.Tags != "" {
 		dbQuery = dbQuery.Where("LOWER(tags) LIKE LOWER(?)", "%"+filters.Tags+"%")
@@ -207,12 +216,12 @@ func (s *ProductServiceImpl) SearchProductsAdvanced(tenantID uuid.UUID, filters
 
 	// Filtro por preço mínimo
 	if filters.MinPrice > 0 {
-		dbQuery = dbQuery.Where("CAST(price AS DECIMAL) >= ?", filters.MinPrice)
+		dbQuery = dbQuery.Where(ProductPriceSQL+" >= ?", filters.MinPrice)
 	}
 
 	// Filtro por preço máximo
 	if filters.MaxPrice > 0 {
-		dbQuery = dbQuery.Where("CAST(price AS DECIMAL) <= ?", filters.MaxPrice)
+		dbQuery = dbQuery.Where(ProductPriceSQL+" <= ?", filters.MaxPrice)
 	}
 
 	// Aplicar limite
@@ -226,7 +235,7 @@ func (s *ProductServiceImpl) SearchProductsAdvanced(tenantID uuid.UUID, filters
 
 func (s *ProductServiceImpl) GetPromotionalProducts(tenantID uuid.UUID) ([]models.Product, error) {
 	var products []models.Product
-	err := s.db.Where("tenant_id = ? AND stock_quantity > 0 AND sale_price IS NOT NULL AND sale_price != '' AND sale_price != '0'",
+	err := s.db.Where("tenant_id = ? AND available = true AND stock_quantity > 0 AND sale_price IS NOT NULL AND sale_price != '' AND sale_price != '0'",
 		tenantID).Find(&products).Error
 	return products, err
 }
@@ -240,6 +249,16 @@ func (s *ProductServiceImpl) GetProductByID(tenantID, productID uuid.UUID) (*mod
 	return &product, nil
 }
 
+// GetProductsBySKU busca os produtos do catálogo pelos SKUs (usado para resolver os itens do estoque externo)
+func (s *ProductServiceImpl) GetProductsBySKU(tenantID uuid.UUID, skus []string) ([]models.Product, error) {
+	var products []models.Product
+	if len(skus) == 0 {
+		return products, nil
+	}
+	err := s.db.Where("tenant_id = ? AND sku IN ?", tenantID, skus).Find(&products).Error
+	return products, err
+}
+
 func (s *ProductServiceImpl) GetAllTenants() ([]models.Tenant, error) {
 	var tenants []models.Tenant
 	err := s.db.Find(&tenants).Error
@@ -261,6 +280,112 @@ func (s *ProductServiceImpl) GetProductsByTenantID(tenantID uuid.UUID) ([]models
 	return products, err
 }
 
+// GetProductImageURLs retorna a primeira imagem (menor sort_order) de cada produto informado
+func (s *ProductServiceImpl) GetProductImageURLs(tenantID uuid.UUID, productIDs []uuid.UUID) (map[uuid.UUID]string, error) {
+	images := make(map[uuid.UUID]string)
+	if len(productIDs) == 0 {
+		return images, nil
+	}
+
+	var media []models.ProductMedia
+	err := s.db.Where("tenant_id = ? AND product_id IN ? AND type = ?", tenantID, productIDs, "image").
+		Order("sort_order ASC, created_at ASC").Find(&media).Error
+	if err != nil {
+		return nil, err
+	}
+
+	for _, item := range media {
+		if _, exists := images[item.ProductID]; !exists && item.URL != "" {
+			images[item.ProductID] = item.URL
+		}
+	}
+	return images, nil
+}
+
+// GetProductAccessories retorna os acessórios cadastrados pela loja para o produto, na ordem de exibição
+func (s *ProductServiceImpl) GetProductAccessories(tenantID, productID uuid.UUID) ([]models.ProductAccessory, error) {
+	return repo.NewProductAccessoryRepository(s.db).GetByProductID(tenantID, productID)
+}
+
+// GetProductsByCategory retorna produtos à venda (disponíveis e com estoque) de uma categoria
+func (s *ProductServiceImpl) GetProductsByCategory(tenantID, categoryID uuid.UUID, limit int) ([]models.Product, error) {
+	var products []models.Product
+	err := s.db.Where(ProductSearchFilters{}.BaseCondition(), tenantID).
+		Where("category_id = ?", categoryID).
+		Order("name ASC").
+		Limit(limit).
+		Find(&products).Error
+	return products, err
+}
+
+// GetBestSellingProducts retorna os produtos vendáveis com mais unidades vendidas
+func (s *ProductServiceImpl) GetBestSellingProducts(tenantID uuid.UUID, limit int) ([]models.Product, error) {
+	var products []models.Product
+	err := s.db.Joins(BestSellersJoinSQL, tenantID).
+		Where(ProductSearchFilters{}.BaseCondition(), tenantID).
+		Order("sales.sold DESC, products.name ASC").
+		Limit(limit).
+		Find(&products).Error
+	return products, err
+}
+
+// GetProductSalesSince retorna as vendas por produto vendável nos pedidos não cancelados desde since
+func (s *ProductServiceImpl) GetProductSalesSince(tenantID uuid.UUID, since time.Time) ([]ProductSales, error) {
+	return LoadProductSales(s.db, tenantID, since)
+}
+
+// LoadProductSales soma as unidades e os pedidos de cada produto nos pedidos não cancelados do tenant desde since.
+// Só retorna produtos que ainda podem ser vendidos (disponíveis e em estoque).
+func LoadProductSales(db *gorm.DB, tenantID uuid.UUID, since time.Time) ([]ProductSales, error) {
+	var rows []struct {
+		ProductID uuid.UUID
+		Quantity  int
+		Orders    int
+	}
+	err := db.Table("order_items AS oi").
+		Select("oi.product_id, SUM(oi.quantity) AS quantity, COUNT(DISTINCT oi.order_id) AS orders").
+		Joins("JOIN orders o ON o.id = oi.order_id").
+		Where("o.tenant_id = ? AND o.status NOT IN ('cancelled', 'refunded') AND o.deleted_at IS NULL AND o.created_at >= ?", tenantID, since).
+		Where("oi.product_id IS NOT NULL AND oi.deleted_at IS NULL").
+		Group("oi.product_id").
+		Scan(&rows).Error
+	if err != nil || len(rows) == 0 {
+		return nil, err
+	}
+
+	ids := make([]uuid.UUID, 0, len(rows))
+	for _, row := range rows {
+		ids = append(ids, row.ProductID)
+	}
+	var products []models.Product
+	if err := db.Where(ProductSearchFilters{}.BaseCondition(), tenantID).Where("id IN ?", ids).Find(&products).Error; err != nil {
+		return nil, err
+	}
+
+	byID := make(map[uuid.UUID]models.Product, len(products))
+	for _, product := range products {
+		byID[product.ID] = product
+	}
+	sales := make([]ProductSales, 0, len(products))
+	for _, row := range rows {
+		if product, ok := byID[row.ProductID]; ok {
+			sales = append(sales, ProductSales{Product: product, Quantity: row.Quantity, Orders: row.Orders})
+		}
+	}
+	return sales, nil
+}
+
+// GetProductsChangedSince retorna os produtos vendáveis cadastrados ou alterados com preço promocional depois de since
+func (s *ProductServiceImpl) GetProductsChangedSince(tenantID uuid.UUID, since time.Time, limit int) ([]models.Product, error) {
+	var products []models.Product
+	err := s.db.Where(ProductSearchFilters{}.BaseCondition(), tenantID).
+		Where("created_at > ? OR (sale_price IS NOT NULL AND sale_price <> '' AND updated_at > ?)", since, since).
+		Order("created_at DESC, name ASC").
+		Limit(limit).
+		Find(&products).Error
+	return products, err
+}
+
 // CartServiceImpl implementa CartServiceInterface
 type CartServiceImpl struct {
 	db *gorm.DB
@@ -299,6 +424,12 @@ func (s *CartServiceImpl) AddItemToCart(cartID, tenantID uuid.UUID, productID uu
 		if err := s.db.Where("id = ? AND tenant_id = ?", productID, tenantID).First(&product).Error; err != nil {
 			return err
 		}
+		if !product.Available {
+			return fmt.Errorf("produto indisponível no momento")
+		}
+		if !hasValidPrice(&product) {
+			return fmt.Errorf("produto sem preço válido")
+		}
 
 		item := models.CartItem{
 			BaseTenantModel: models.BaseTenantModel{
@@ -308,7 +439,7 @@ func (s *CartServiceImpl) AddItemToCart(cartID, tenantID uuid.UUID, productID uu
 			CartID:      cartID,
 			ProductID:   &productID,
 			Quantity:    quantity,
-			Price:       getEffectivePrice(&product),
+			Price:       UnitPriceForQuantity(&product, quantity),
 			ProductName: &product.Name,
 			ProductSKU:  &product.SKU,
 		}
@@ -317,6 +448,7 @@ func (s *CartServiceImpl) AddItemToCart(cartID, tenantID uuid.UUID, productID uu
 		return err
 	} else {
 		existingItem.Quantity += quantity
+		s.repriceCartItem(&existingItem, tenantID)
 		return s.db.Save(&existingItem).Error
 	}
 }
@@ -330,9 +462,66 @@ func (s *CartServiceImpl) ClearCart(cartID, tenantID uuid.UUID) error {
 }
 
 func (s *CartServiceImpl) UpdateCartItemQuantity(cartID, tenantID, itemID uuid.UUID, quantity int) error {
+	var item models.CartItem
+	if err := s.db.Where("cart_id = ? AND id = ?", cartID, itemID).First(&item).Error; err != nil {
+		return err
+	}
+
+	item.Quantity = quantity
+	s.repriceCartItem(&item, tenantID)
+
 	return s.db.Model(&models.CartItem{}).
 		Where("cart_id = ? AND id = ?", cartID, itemID).
-		Update("quantity", quantity).Error
+		Updates(map[string]interface{}{"quantity": item.Quantity, "price": item.Price}).Error
+}
+
+// MergeCartItems soma nas quantidades do item mantido as linhas duplicadas do mesmo produto e remove as duplicatas
+func (s *CartServiceImpl) MergeCartItems(cartID, tenantID, keepItemID uuid.UUID, duplicateItemIDs []uuid.UUID) error {
+	if len(duplicateItemIDs) == 0 {
+		return nil
+	}
+
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		var item models.CartItem
+		if err := tx.Where("cart_id = ? AND id = ?", cartID, keepItemID).First(&item).Error; err != nil {
+			return err
+		}
+
+		var duplicates []models.CartItem
+		if err := tx.Where("cart_id = ? AND id IN ?", cartID, duplicateItemIDs).Find(&duplicates).Error; err != nil {
+			return err
+		}
+		if len(duplicates) == 0 {
+			return nil
+		}
+
+		ids := make([]uuid.UUID, 0, len(duplicates))
+		for _, duplicate := range duplicates {
+			item.Quantity += duplicate.Quantity
+			ids = append(ids, duplicate.ID)
+		}
+		s.repriceCartItem(&item, tenantID)
+
+		if err := tx.Where("cart_id = ? AND id IN ?", cartID, ids).Delete(&models.CartItem{}).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.CartItem{}).
+			Where("cart_id = ? AND id = ?", cartID, keepItemID).
+			Updates(map[string]interface{}{"quantity": item.Quantity, "price": item.Price}).Error
+	})
+}
+
+// repriceCartItem recalcula o preço unitário do item conforme a quantidade (faixas de atacado)
+func (s *CartServiceImpl) repriceCartItem(item *models.CartItem, tenantID uuid.UUID) {
+	if item.ProductID == nil {
+		return
+	}
+
+	var product models.Product
+	if err := s.db.Where("id = ? AND tenant_id = ?", *item.ProductID, tenantID).First(&product).Error; err != nil {
+		return
+	}
+	item.Price = UnitPriceForQuantity(&product, item.Quantity)
 }
 
 func (s *CartServiceImpl) GetCartWithItems(cartID, tenantID uuid.UUID) (*models.Cart, error) {
@@ -360,6 +549,54 @@ func (s *CartServiceImpl) UpdateCartObservations(cartID, tenantID uuid.UUID, obs
 		Updates(updates).Error
 }
 
+// ConfirmCartAge registra a declaração de maioridade do cliente no carrinho
+func (s *CartServiceImpl) ConfirmCartAge(cartID, tenantID uuid.UUID, confirmedAt time.Time) error {
+	return s.db.Model(&models.Cart{}).
+		Where("id = ? AND tenant_id = ?", cartID, tenantID).
+		Update("age_confirmed_at", confirmedAt).Error
+}
+
+func (s *CartServiceImpl) SetCartPickup(cartID, tenantID uuid.UUID, pickup bool) error {
+	return s.db.Model(&models.Cart{}).
+		Where("id = ? AND tenant_id = ?", cartID, tenantID).
+		Update("is_pickup", pickup).Error
+}
+
+// AttachCartPrescription guarda no carrinho a foto da receita enviada pelo cliente
+func (s *CartServiceImpl) AttachCartPrescription(cartID, tenantID uuid.UUID, imageURL string) error {
+	return s.db.Model(&models.Cart{}).
+		Where("id = ? AND tenant_id = ?", cartID, tenantID).
+		Update("prescription_url", imageURL).Error
+}
+
+// UpdateCartInstallments guarda no carrinho o número de parcelas escolhido pelo cliente
+func (s *CartServiceImpl) UpdateCartInstallments(cartID, tenantID uuid.UUID, installments int) error {
+	return s.db.Model(&models.Cart{}).
+		Where("id = ? AND tenant_id = ?", cartID, tenantID).
+		Update("installment_count", installments).Error
+}
+
+// SetCartUrgent marca o carrinho como pedido urgente (prioridade na separação e entrega)
+func (s *CartServiceImpl) SetCartUrgent(cartID, tenantID uuid.UUID, urgent bool) error {
+	return s.db.Model(&models.Cart{}).
+		Where("id = ? AND tenant_id = ?", cartID, tenantID).
+		Update("is_urgent", urgent).Error
+}
+
+// SetCartDeposit marca o carrinho para pagamento com sinal (parte antecipada e o restante na entrega)
+func (s *CartServiceImpl) SetCartDeposit(cartID, tenantID uuid.UUID, payDeposit bool) error {
+	return s.db.Model(&models.Cart{}).
+		Where("id = ? AND tenant_id = ?", cartID, tenantID).
+		Update("pay_deposit", payDeposit).Error
+}
+
+// SetCartItemAddress define o endereço de entrega de um item do carrinho (nil = endereço principal do pedido)
+func (s *CartServiceImpl) SetCartItemAddress(cartID, tenantID, itemID uuid.UUID, addressID *uuid.UUID) error {
+	return s.db.Model(&models.CartItem{}).
+		Where("id = ? AND cart_id = ? AND tenant_id = ?", itemID, cartID, tenantID).
+		Update("delivery_address_id", addressID).Error
+}
+
 // OrderServiceImpl implementa OrderServiceInterface
 type OrderServiceImpl struct {
 	db *gorm.DB
@@ -378,22 +615,82 @@ func (s *OrderServiceImpl) CreateOrderFromCartWithAddress(tenantID, cartID uuid.
 }
 
 func (s *OrderServiceImpl) CreateOrderFromCartWithConversation(tenantID, cartID, conversationID uuid.UUID, deliveryAddress *models.Address) (*models.Order, error) {
-	// Debug logging
-	fmt.Printf("DEBUG CreateOrderFromCart - tenantID: %s, cartID: %s\n", tenantID, cartID)
+	return s.CreateOrderFromCartItems(tenantID, cartID, conversationID, nil, deliveryAddress)
+}
+
+// CreateOrderFromCartItems cria o pedido com os itens informados do carrinho (itemIDs nil = todos os itens)
+func (s *OrderServiceImpl) CreateOrderFromCartItems(tenantID, cartID, conversationID uuid.UUID, itemIDs []uuid.UUID, deliveryAddress *models.Address) (*models.Order, error) {
+	orders, err := s.CreateOrdersFromCartGroups(tenantID, cartID, conversationID, []CartOrderGroup{{ItemIDs: itemIDs, DeliveryAddress: deliveryAddress}})
+	if err != nil {
+		return nil, err
+	}
+	return orders[0], nil
+}
 
+// CreateOrdersFromCartGroups cria um pedido por grupo de itens do carrinho numa única transação: se algum pedido
+// falhar, nenhum é criado e o carrinho continua aberto
+func (s *OrderServiceImpl) CreateOrdersFromCartGroups(tenantID, cartID, conversationID uuid.UUID, groups []CartOrderGroup) ([]*models.Order, error) {
 	// Obter carrinho com itens e cliente
 	var cart models.Cart
 	err := s.db.Preload("Items").Preload("Items.Product").Preload("Items.Attributes").Preload("Customer").
 		Where("id = ? AND tenant_id = ?", cartID, tenantID).First(&cart).Error
 	if err != nil {
-		fmt.Printf("DEBUG CreateOrderFromCart - Error loading cart: %v\n", err)
 		return nil, err
 	}
 
-	fmt.Printf("DEBUG CreateOrderFromCart - Cart found with %d items\n", len(cart.Items))
-	for i, item := range cart.Items {
-		fmt.Printf("DEBUG CreateOrderFromCart - Item %d: ProductID=%s, Quantity=%d, Price=%s\n",
-			i+1, item.ProductID, item.Quantity, item.Price)
+	log.Debug().
+		Str("tenant_id", tenantID.String()).
+		Str("cart_id", cartID.String()).
+		Int("items", len(cart.Items)).
+		Int("orders", len(groups)).
+		Msg("Criando pedido a partir do carrinho")
+
+	// Iniciar transação para garantir consistência
+	tx := s.db.Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	orders := make([]*models.Order, 0, len(groups))
+	for _, group := range groups {
+		order, err := s.createCartOrder(tx, tenantID, cart, conversationID, group)
+		if err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+		orders = append(orders, order)
+	}
+
+	// Marcar carrinho como processado
+	err = tx.Model(&cart).Update("status", "processed").Error
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	// Confirmar transação
+	err = tx.Commit().Error
+	if err != nil {
+		return nil, err
+	}
+
+	// Recarregar os pedidos com todos os dados
+	for _, order := range orders {
+		err = s.db.Preload("Items").Where("id = ?", order.ID).First(order).Error
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return orders, nil
+}
+
+// createCartOrder grava na transação tx o pedido com os itens do grupo, copiando do carrinho cliente e pagamento
+func (s *OrderServiceImpl) createCartOrder(tx *gorm.DB, tenantID uuid.UUID, cart models.Cart, conversationID uuid.UUID, group CartOrderGroup) (*models.Order, error) {
+	if group.ItemIDs != nil {
+		cart.Items = SelectCartItems(cart.Items, group.ItemIDs)
 	}
 
 	if len(cart.Items) == 0 {
@@ -401,25 +698,29 @@ func (s *OrderServiceImpl) CreateOrderFromCartWithConversation(tenantID, cartID,
 	}
 
 	// Calcular total e subtotal
-	subtotal := 0.0
+	var subtotal utils.Cents
 	for _, item := range cart.Items {
-		price, _ := strconv.ParseFloat(item.Price, 64)
-		subtotal += price * float64(item.Quantity)
+		price, _ := utils.ParseCents(item.Price)
+		subtotal += price.Times(item.Quantity)
 	}
 
 	// Criar pedido com status pendente
+	// Número no formato configurado pelo tenant (ex: "PH-{seq}") ou no formato padrão
+	nextNumber := func() string { return NextOrderNumber(s.db, tenantID, time.Now(), generateOrderNumber) }
+	orderNumber := nextNumber()
+
 	order := models.Order{
 		BaseTenantModel: models.BaseTenantModel{
 			ID:       uuid.New(),
 			TenantID: tenantID,
 		},
 		CustomerID:        &cart.CustomerID,
-		OrderNumber:       generateOrderNumber(),
+		OrderNumber:       orderNumber,
 		Status:            "pending",
 		PaymentStatus:     "pending",
 		FulfillmentStatus: "pending",
-		TotalAmount:       fmt.Sprintf("%.2f", subtotal),
-		Subtotal:          fmt.Sprintf("%.2f", subtotal),
+		TotalAmount:       subtotal.String(),
+		Subtotal:          subtotal.String(),
 		TaxAmount:         "0.00",
 		ShippingAmount:    "0.00",
 		DiscountAmount:    "0.00",
@@ -440,21 +741,7 @@ func (s *OrderServiceImpl) CreateOrderFromCartWithConversation(tenantID, cartID,
 	}
 
 	// 🏠 Copiar dados do endereço de entrega se fornecido
-	if deliveryAddress != nil {
-		order.AddressID = &deliveryAddress.ID
-		order.ShippingName = &deliveryAddress.Name
-		order.ShippingStreet = &deliveryAddress.Street
-		order.ShippingNumber = &deliveryAddress.Number
-		order.ShippingComplement = &deliveryAddress.Complement
-		order.ShippingNeighborhood = &deliveryAddress.Neighborhood
-		order.ShippingCity = &deliveryAddress.City
-		order.ShippingState = &deliveryAddress.State
-		order.ShippingZipcode = &deliveryAddress.ZipCode
-		order.ShippingCountry = &deliveryAddress.Country
-
-		fmt.Printf("DEBUG CreateOrderFromCart - Endereço de entrega copiado: %s, %s, %s, %s\n",
-			deliveryAddress.Street, deliveryAddress.Number, deliveryAddress.Neighborhood, deliveryAddress.City)
-	}
+	applyOrderShippingAddress(&order, group.DeliveryAddress)
 
 	// 💳 Copiar dados de pagamento do carrinho para o pedido
 	if cart.PaymentMethodID != nil {
@@ -466,26 +753,23 @@ func (s *OrderServiceImpl) CreateOrderFromCartWithConversation(tenantID, cartID,
 	if cart.ChangeFor != "" {
 		order.ChangeFor = cart.ChangeFor
 	}
+	if cart.AgeConfirmedAt != nil {
+		order.AgeConfirmedAt = cart.AgeConfirmedAt
+	}
+	order.IsPickup = cart.IsPickup
+	order.PrescriptionURL = cart.PrescriptionURL
+	order.PrescriptionRequired = CartRequiresPrescription(&cart)
 
-	// Iniciar transação para garantir consistência
-	tx := s.db.Begin()
-	defer func() {
-		if r := recover(); r != nil {
-			tx.Rollback()
-		}
-	}()
-
-	// Criar pedido
-	err = tx.Create(&order).Error
+	// Criar pedido (com outro número se um checkout simultâneo usou o mesmo)
+	err := CreateOrderWithUniqueNumber(tx, &order, nextNumber)
 	if err != nil {
-		tx.Rollback()
 		return nil, err
 	}
 
 	// Criar itens do pedido copiando do carrinho
 	for _, cartItem := range cart.Items {
-		itemPrice, _ := strconv.ParseFloat(cartItem.Price, 64)
-		itemTotal := itemPrice * float64(cartItem.Quantity)
+		itemPrice, _ := utils.ParseCents(cartItem.Price)
+		itemTotal := itemPrice.Times(cartItem.Quantity)
 
 		orderItem := models.OrderItem{
 			BaseTenantModel: models.BaseTenantModel{
@@ -496,7 +780,7 @@ func (s *OrderServiceImpl) CreateOrderFromCartWithConversation(tenantID, cartID,
 			ProductID: cartItem.ProductID,
 			Quantity:  cartItem.Quantity,
 			Price:     cartItem.Price,
-			Total:     fmt.Sprintf("%.2f", itemTotal),
+			Total:     itemTotal.String(),
 		}
 
 		// Copiar dados históricos do produto
@@ -509,7 +793,6 @@ func (s *OrderServiceImpl) CreateOrderFromCartWithConversation(tenantID, cartID,
 
 		err = tx.Create(&orderItem).Error
 		if err != nil {
-			tx.Rollback()
 			return nil, err
 		}
 
@@ -530,43 +813,139 @@ func (s *OrderServiceImpl) CreateOrderFromCartWithConversation(tenantID, cartID,
 
 			err = tx.Create(&orderItemAttr).Error
 			if err != nil {
-				tx.Rollback()
 				return nil, err
 			}
 		}
 	}
 
-	// Marcar carrinho como processado
-	err = tx.Model(&cart).Update("status", "processed").Error
+	return &order, nil
+}
+
+func (s *OrderServiceImpl) GetOrderByID(tenantID, orderID uuid.UUID) (*models.Order, error) {
+	var order models.Order
+	err := s.db.Preload("Items").Preload("Items.Attributes").Preload("PaymentMethod").
+		Where("id = ? AND tenant_id = ?", orderID, tenantID).First(&order).Error
 	if err != nil {
-		tx.Rollback()
 		return nil, err
 	}
+	return &order, nil
+}
 
-	// Confirmar transação
-	err = tx.Commit().Error
-	if err != nil {
+func (s *OrderServiceImpl) CancelOrder(tenantID, orderID uuid.UUID) error {
+	return s.db.Model(&models.Order{}).
+		Where("id = ? AND tenant_id = ?", orderID, tenantID).
+		Update("status", "cancelled").Error
+}
+
+// ApplyShippingAmount define a taxa de entrega do pedido e recalcula o total
+func (s *OrderServiceImpl) ApplyShippingAmount(tenantID, orderID uuid.UUID, shippingAmount float64) (*models.Order, error) {
+	var order models.Order
+	if err := s.db.Where("id = ? AND tenant_id = ?", orderID, tenantID).First(&order).Error; err != nil {
 		return nil, err
 	}
 
-	// Recarregar o pedido com todos os dados
-	err = s.db.Preload("Items").Where("id = ?", order.ID).First(&order).Error
+	subtotal, _ := utils.ParseCents(order.Subtotal)
+	tax, _ := utils.ParseCents(order.TaxAmount)
+	discount, _ := utils.ParseCents(order.DiscountAmount)
+	urgencyFee, _ := utils.ParseCents(order.UrgencyFee)
+	shipping := utils.CentsFromFloat(shippingAmount)
+
+	order.ShippingAmount = shipping.String()
+	order.TotalAmount = (subtotal + tax + shipping + urgencyFee - discount).String()
+
+	err := s.db.Model(&order).Updates(map[string]interface{}{
+		"shipping_amount": order.ShippingAmount,
+		"total_amount":    order.TotalAmount,
+	}).Error
 	if err != nil {
 		return nil, err
 	}
+	return &order, nil
+}
 
+// ApplyInstallmentPlan registra no pedido o parcelamento escolhido (os juros do cartão ficam no valor da parcela)
+func (s *OrderServiceImpl) ApplyInstallmentPlan(tenantID, orderID uuid.UUID, installments int, installmentAmount float64) (*models.Order, error) {
+	var order models.Order
+	if err := s.db.Where("id = ? AND tenant_id = ?", orderID, tenantID).First(&order).Error; err != nil {
+		return nil, err
+	}
+
+	order.InstallmentCount = installments
+	order.InstallmentAmount = utils.FormatMoney(installmentAmount)
+
+	err := s.db.Model(&order).Updates(map[string]interface{}{
+		"installment_count":  order.InstallmentCount,
+		"installment_amount": order.InstallmentAmount,
+	}).Error
+	if err != nil {
+		return nil, err
+	}
 	return &order, nil
 }
 
-func (s *OrderServiceImpl) CancelOrder(tenantID, orderID uuid.UUID) error {
+// MarkOrderForReview sinaliza que o pedido precisa da confirmação manual de um operador
+func (s *OrderServiceImpl) MarkOrderForReview(tenantID, orderID uuid.UUID) error {
 	return s.db.Model(&models.Order{}).
 		Where("id = ? AND tenant_id = ?", orderID, tenantID).
-		Update("status", "cancelled").Error
+		Update("requires_review", true).Error
+}
+
+// ApplyUrgency marca o pedido como urgente e soma a taxa expressa ao total
+func (s *OrderServiceImpl) ApplyUrgency(tenantID, orderID uuid.UUID, urgencyFee float64) (*models.Order, error) {
+	var order models.Order
+	if err := s.db.Where("id = ? AND tenant_id = ?", orderID, tenantID).First(&order).Error; err != nil {
+		return nil, err
+	}
+
+	subtotal, _ := utils.ParseCents(order.Subtotal)
+	tax, _ := utils.ParseCents(order.TaxAmount)
+	shipping, _ := utils.ParseCents(order.ShippingAmount)
+	discount, _ := utils.ParseCents(order.DiscountAmount)
+	fee := utils.CentsFromFloat(urgencyFee)
+
+	order.IsUrgent = true
+	order.UrgencyFee = fee.String()
+	order.TotalAmount = (subtotal + tax + shipping + fee - discount).String()
+
+	err := s.db.Model(&order).Updates(map[string]interface{}{
+		"is_urgent":    order.IsUrgent,
+		"urgency_fee":  order.UrgencyFee,
+		"total_amount": order.TotalAmount,
+	}).Error
+	if err != nil {
+		return nil, err
+	}
+	return &order, nil
+}
+
+// ApplyDeposit registra no pedido o sinal pago antecipadamente e o restante a receber na entrega
+func (s *OrderServiceImpl) ApplyDeposit(tenantID, orderID uuid.UUID, depositMethod string, amountPaid, amountDue float64) (*models.Order, error) {
+	var order models.Order
+	if err := s.db.Where("id = ? AND tenant_id = ?", orderID, tenantID).First(&order).Error; err != nil {
+		return nil, err
+	}
+
+	order.HasDeposit = true
+	order.DepositPaymentMethod = depositMethod
+	order.AmountPaid = utils.FormatMoney(amountPaid)
+	order.AmountDue = utils.FormatMoney(amountDue)
+
+	err := s.db.Model(&order).Updates(map[string]interface{}{
+		"has_deposit":            order.HasDeposit,
+		"deposit_payment_method": order.DepositPaymentMethod,
+		"amount_paid":            order.AmountPaid,
+		"amount_due":             order.AmountDue,
+	}).Error
+	if err != nil {
+		return nil, err
+	}
+	return &order, nil
 }
 
 func (s *OrderServiceImpl) GetOrdersByCustomer(tenantID, customerID uuid.UUID) ([]models.Order, error) {
 	var orders []models.Order
-	err := s.db.Where("tenant_id = ? AND customer_id = ?", tenantID, customerID).
+	err := s.db.Preload("PaymentMethod").
+		Where("tenant_id = ? AND customer_id = ?", tenantID, customerID).
 		Order("created_at DESC").Find(&orders).Error
 	return orders, err
 }
@@ -588,7 +967,7 @@ func (s *OrderServiceImpl) GetPaymentOptions(tenantID uuid.UUID) ([]PaymentOptio
 		options[i] = PaymentOption{
 			ID:           pm.ID.String(),
 			Name:         pm.Name,
-			Instructions: "",
+			Instructions: pm.Instructions,
 		}
 	}
 	return options, nil
@@ -603,31 +982,14 @@ func NewCustomerService(db *gorm.DB) CustomerServiceInterface {
 	return &CustomerServiceImpl{db: db}
 }
 
-func (s *CustomerServiceImpl) GetCustomerByPhone(tenantID uuid.UUID, phone string) (*models.Customer, error) {
-	var customer models.Customer
-	err := s.db.Where("tenant_id = ? AND phone = ?", tenantID, phone).First(&customer).Error
-
-	if err == gorm.ErrRecordNotFound {
-		// Cliente não existe, criar novo
-		customer = models.Customer{
-			BaseTenantModel: models.BaseTenantModel{
-				ID:       uuid.New(),
-				TenantID: tenantID,
-			},
-			Phone:    phone,
-			Name:     "", // Nome será preenchido depois
-			IsActive: true,
-		}
-
-		err = s.db.Create(&customer).Error
-		if err != nil {
-			return nil, fmt.Errorf("erro ao criar cliente: %w", err)
-		}
-	} else if err != nil {
-		return nil, fmt.Errorf("erro ao buscar cliente: %w", err)
+// GetOrCreateCustomerByPhone busca o cliente por qualquer variação do telefone ou cria com o número normalizado,
+// sem duplicar o cliente quando as primeiras mensagens chegam ao mesmo tempo
+func (s *CustomerServiceImpl) GetOrCreateCustomerByPhone(tenantID uuid.UUID, phone string) (*models.Customer, error) {
+	customer, err := repo.NewCustomerRepository(s.db).GetOrCreateByPhone(tenantID, phone)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar ou criar cliente: %w", err)
 	}
-
-	return &customer, nil
+	return customer, nil
 }
 
 func (s *CustomerServiceImpl) GetCustomerByID(tenantID, customerID uuid.UUID) (*models.Customer, error) {
@@ -639,6 +1001,31 @@ func (s *CustomerServiceImpl) GetCustomerByID(tenantID, customerID uuid.UUID) (*
 	return &customer, nil
 }
 
+// MarkCustomerWelcomed registra o envio da mensagem de boas-vindas para o cliente
+func (s *CustomerServiceImpl) MarkCustomerWelcomed(tenantID, customerID uuid.UUID, welcomedAt time.Time) error {
+	return s.db.Model(&models.Customer{}).
+		Where("id = ? AND tenant_id = ?", customerID, tenantID).
+		Update("last_welcomed_at", welcomedAt).Error
+}
+
+// SetCustomerLanguage grava o idioma escolhido pelo cliente
+func (s *CustomerServiceImpl) SetCustomerLanguage(tenantID, customerID uuid.UUID, language string) error {
+	return s.db.Model(&models.Customer{}).
+		Where("id = ? AND tenant_id = ?", customerID, tenantID).
+		Update("language", language).Error
+}
+
+// MarkCustomerSeen registra a mensagem mais recente do cliente e, ao iniciar uma nova visita, o fim da anterior
+func (s *CustomerServiceImpl) MarkCustomerSeen(tenantID, customerID uuid.UUID, seenAt time.Time, previousVisitAt *time.Time) error {
+	updates := map[string]interface{}{"last_seen_at": seenAt}
+	if previousVisitAt != nil {
+		updates["previous_visit_at"] = *previousVisitAt
+	}
+	return s.db.Model(&models.Customer{}).
+		Where("id = ? AND tenant_id = ?", customerID, tenantID).
+		Updates(updates).Error
+}
+
 func (s *CustomerServiceImpl) UpdateCustomerProfile(tenantID, customerID uuid.UUID, data CustomerUpdateData) error {
 	updates := make(map[string]interface{})
 
@@ -779,24 +1166,23 @@ func (s *AddressServiceImpl) SetDefaultAddress(tenantID, customerID, addressID u
 }
 
 func (s *AddressServiceImpl) DeleteAddress(tenantID, customerID, addressID uuid.UUID) error {
-	// Verificar se é o último endereço
-	var count int64
-	err := s.db.Model(&models.Address{}).
-		Where("customer_id = ? AND tenant_id = ?", customerID, tenantID).
-		Count(&count).Error
+	// Deletar o endereço
+	err := s.db.Where("id = ? AND customer_id = ? AND tenant_id = ?", addressID, customerID, tenantID).
+		Delete(&models.Address{}).Error
 	if err != nil {
 		return err
 	}
 
-	// Deletar o endereço
-	err = s.db.Where("id = ? AND customer_id = ? AND tenant_id = ?", addressID, customerID, tenantID).
-		Delete(&models.Address{}).Error
+	// Se não restou endereço padrão (ex: deletou o padrão), definir o mais antigo como padrão
+	var defaultCount int64
+	err = s.db.Model(&models.Address{}).
+		Where("customer_id = ? AND tenant_id = ? AND is_default = ?", customerID, tenantID, true).
+		Count(&defaultCount).Error
 	if err != nil {
 		return err
 	}
 
-	// Se deletou um endereço padrão e ainda há outros, definir o primeiro como padrão
-	if count > 1 {
+	if defaultCount == 0 {
 		var firstAddress models.Address
 		err = s.db.Where("customer_id = ? AND tenant_id = ?", customerID, tenantID).
 			Order("created_at ASC").First(&firstAddress).Error
@@ -1011,3 +1397,345 @@ func (s *MessageServiceImpl) UpdateMessageContentAndMediaURL(messageID, content,
 	}
 	return nil
 }
+
+// FAQServiceImpl implementa FAQServiceInterface
+type FAQServiceImpl struct {
+	db *gorm.DB
+}
+
+func NewFAQService(db *gorm.DB) FAQServiceInterface {
+	return &FAQServiceImpl{db: db}
+}
+
+func (s *FAQServiceImpl) GetActiveFAQs(tenantID uuid.UUID) ([]models.FAQEntry, error) {
+	var entries []models.FAQEntry
+	if err := s.db.Where("tenant_id = ? AND is_active = ?", tenantID, true).Order("question ASC").Find(&entries).Error; err != nil {
+		return nil, fmt.Errorf("failed to list FAQ entries: %w", err)
+	}
+	return entries, nil
+}
+
+// CustomToolServiceImpl implementa CustomToolServiceInterface
+type CustomToolServiceImpl struct {
+	db *gorm.DB
+}
+
+func NewCustomToolService(db *gorm.DB) CustomToolServiceInterface {
+	return &CustomToolServiceImpl{db: db}
+}
+
+func (s *CustomToolServiceImpl) GetActiveCustomTools(tenantID uuid.UUID) ([]models.TenantCustomTool, error) {
+	var tools []models.TenantCustomTool
+	if err := s.db.Where("tenant_id = ? AND is_active = ?", tenantID, true).Order("name ASC").Find(&tools).Error; err != nil {
+		return nil, fmt.Errorf("failed to list custom tools: %w", err)
+	}
+	return tools, nil
+}
+
+// ConversationServiceImpl implementa ConversationServiceInterface
+type ConversationServiceImpl struct {
+	db *gorm.DB
+}
+
+func NewConversationService(db *gorm.DB) ConversationServiceInterface {
+	return &ConversationServiceImpl{db: db}
+}
+
+func (s *ConversationServiceImpl) IsBotPaused(tenantID, conversationID uuid.UUID) (bool, error) {
+	var conversation models.Conversation
+	err := s.db.Select("id, bot_paused").Where("id = ? AND tenant_id = ?", conversationID, tenantID).First(&conversation).Error
+	if err != nil {
+		return false, err
+	}
+	return conversation.BotPaused, nil
+}
+
+func (s *ConversationServiceImpl) SetBotPaused(tenantID, conversationID uuid.UUID, paused bool) error {
+	updates := map[string]interface{}{"bot_paused": paused, "bot_paused_at": nil}
+	if paused {
+		updates["bot_paused_at"] = time.Now()
+	}
+	return s.db.Model(&models.Conversation{}).
+		Where("id = ? AND tenant_id = ?", conversationID, tenantID).
+		Updates(updates).Error
+}
+
+// GetBusinessOpening retorna o contato aberto pela loja na conversa, ou nil quando foi o cliente quem iniciou
+func (s *ConversationServiceImpl) GetBusinessOpening(tenantID, conversationID uuid.UUID) (*BusinessOpening, error) {
+	var conversation models.Conversation
+	err := s.db.Select("id, initiated_by, business_initiated_at").Where("id = ? AND tenant_id = ?", conversationID, tenantID).First(&conversation).Error
+	if err != nil {
+		return nil, err
+	}
+	if conversation.InitiatedBy != repo.ConversationInitiatedByBusiness || conversation.BusinessInitiatedAt == nil {
+		return nil, nil
+	}
+
+	opening := &BusinessOpening{At: *conversation.BusinessInitiatedAt}
+
+	// Última mensagem enviada pela loja (ignorando registros internos do sistema)
+	var message models.Message
+	err = s.db.Select("content").
+		Where("tenant_id = ? AND conversation_id = ? AND direction = ? AND source <> ?", tenantID, conversationID, "out", "system").
+		Order("created_at DESC").
+		First(&message).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	opening.Message = strings.TrimSpace(message.Content)
+
+	return opening, nil
+}
+
+// ToolMetricsServiceImpl implementa ToolMetricsServiceInterface
+type ToolMetricsServiceImpl struct {
+	db *gorm.DB
+}
+
+func NewToolMetricsService(db *gorm.DB) ToolMetricsServiceInterface {
+	return &ToolMetricsServiceImpl{db: db}
+}
+
+func (s *ToolMetricsServiceImpl) RecordToolExecutions(tenantID, customerID, conversationID uuid.UUID, results []ToolExecutionResult) error {
+	if len(results) == 0 {
+		return nil
+	}
+
+	var conversation *uuid.UUID
+	if conversationID != uuid.Nil {
+		conversation = &conversationID
+	}
+
+	executions := make([]models.AIToolExecution, 0, len(results))
+	for _, result := range results {
+		executions = append(executions, models.AIToolExecution{
+			BaseTenantModel: models.BaseTenantModel{ID: uuid.New(), TenantID: tenantID},
+			CustomerID:      customerID,
+			ConversationID:  conversation,
+			ToolName:        result.ToolName,
+			Success:         result.Error == "",
+		})
+	}
+	return s.db.Create(&executions).Error
+}
+
+// MissingDemandServiceImpl implementa MissingDemandServiceInterface
+type MissingDemandServiceImpl struct {
+	db *gorm.DB
+}
+
+func NewMissingDemandService(db *gorm.DB) MissingDemandServiceInterface {
+	return &MissingDemandServiceImpl{db: db}
+}
+
+// missingDemandDedupWindow evita contar várias vezes o mesmo pedido do cliente (ex: buscas repetidas na mesma conversa)
+const missingDemandDedupWindow = 24 * time.Hour
+
+// recentDemand retorna o registro mais recente do mesmo cliente para o mesmo termo dentro da janela
+func (s *MissingDemandServiceImpl) recentDemand(demand *models.MissingProductDemand) (*models.MissingProductDemand, error) {
+	var existing models.MissingProductDemand
+	err := s.db.Where("tenant_id = ? AND customer_id = ? AND normalized_query = ? AND created_at >= ?",
+		demand.TenantID, demand.CustomerID, demand.NormalizedQuery, time.Now().Add(-missingDemandDedupWindow)).
+		Order("created_at DESC").
+		First(&existing).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &existing, nil
+}
+
+func (s *MissingDemandServiceImpl) RecordMissingDemand(demand *models.MissingProductDemand) error {
+	existing, err := s.recentDemand(demand)
+	if err != nil || existing != nil {
+		return err
+	}
+
+	if demand.ID == uuid.Nil {
+		demand.ID = uuid.New()
+	}
+	return s.db.Create(demand).Error
+}
+
+func (s *MissingDemandServiceImpl) RequestRestockNotification(demand *models.MissingProductDemand) error {
+	existing, err := s.recentDemand(demand)
+	if err != nil {
+		return err
+	}
+	if existing != nil {
+		return s.db.Model(existing).Update("notify_requested", true).Error
+	}
+
+	if demand.ID == uuid.Nil {
+		demand.ID = uuid.New()
+	}
+	demand.NotifyRequested = true
+	return s.db.Create(demand).Error
+}
+
+// RecordSpecialOrder registra o pedido explícito do cliente; se a mesma busca já virou demanda na janela,
+// promove o registro para pedido especial em vez de contar duas vezes
+func (s *MissingDemandServiceImpl) RecordSpecialOrder(demand *models.MissingProductDemand) error {
+	demand.Source = models.MissingDemandSourceSpecialOrder
+
+	existing, err := s.recentDemand(demand)
+	if err != nil {
+		return err
+	}
+	if existing != nil {
+		updates := map[string]interface{}{"source": demand.Source}
+		if demand.Details != "" {
+			updates["details"] = demand.Details
+		}
+		return s.db.Model(existing).Updates(updates).Error
+	}
+
+	if demand.ID == uuid.Nil {
+		demand.ID = uuid.New()
+	}
+	return s.db.Create(demand).Error
+}
+
+// SubscriptionServiceImpl implementa SubscriptionServiceInterface
+type SubscriptionServiceImpl struct {
+	db *gorm.DB
+}
+
+func NewSubscriptionService(db *gorm.DB) SubscriptionServiceInterface {
+	return &SubscriptionServiceImpl{db: db}
+}
+
+func (s *SubscriptionServiceImpl) CreateSubscription(subscription *models.Subscription) error {
+	if subscription.ID == uuid.Nil {
+		subscription.ID = uuid.New()
+	}
+	return s.db.Omit("Customer", "Product").Create(subscription).Error
+}
+
+// GetSubscriptionsByCustomer retorna as assinaturas não canceladas do cliente, na ordem de criação (numeração da lista)
+func (s *SubscriptionServiceImpl) GetSubscriptionsByCustomer(tenantID, customerID uuid.UUID) ([]models.Subscription, error) {
+	var subscriptions []models.Subscription
+	err := s.db.Preload("Product").
+		Where("tenant_id = ? AND customer_id = ? AND status <> ?", tenantID, customerID, SubscriptionStatusCancelled).
+		Order("created_at ASC").
+		Find(&subscriptions).Error
+	return subscriptions, err
+}
+
+func (s *SubscriptionServiceImpl) UpdateSubscriptionStatus(tenantID, subscriptionID uuid.UUID, status string, nextRunAt time.Time) error {
+	return s.db.Model(&models.Subscription{}).
+		Where("id = ? AND tenant_id = ?", subscriptionID, tenantID).
+		Updates(map[string]interface{}{"status": status, "next_run_at": nextRunAt}).Error
+}
+
+// applyOrderShippingAddress copia para o pedido os dados históricos do endereço de entrega (nil mantém o pedido sem endereço)
+func applyOrderShippingAddress(order *models.Order, address *models.Address) {
+	if address == nil {
+		return
+	}
+	order.AddressID = &address.ID
+	order.ShippingName = &address.Name
+	order.ShippingStreet = &address.Street
+	order.ShippingNumber = &address.Number
+	shippingComplement := address.DeliveryComplement()
+	order.ShippingComplement = &shippingComplement
+	order.ShippingReference = &address.ReferencePoint
+	order.ShippingNeighborhood = &address.Neighborhood
+	order.ShippingCity = &address.City
+	order.ShippingState = &address.State
+	order.ShippingZipcode = &address.ZipCode
+	order.ShippingCountry = &address.Country
+}
+
+// ResolvePendingOrder registra a resposta do cliente ao pedido gerado pela assinatura: confirma (com o endereço
+// aprovado pelas verificações do checkout; nil = retirada) ou cancela (pula o ciclo)
+func (s *SubscriptionServiceImpl) ResolvePendingOrder(tenantID, subscriptionID uuid.UUID, confirm bool, deliveryAddress *models.Address) (*models.Order, error) {
+	var order models.Order
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var subscription models.Subscription
+		if err := tx.Where("id = ? AND tenant_id = ?", subscriptionID, tenantID).First(&subscription).Error; err != nil {
+			return err
+		}
+		if subscription.PendingOrderID == nil {
+			return fmt.Errorf("assinatura sem pedido aguardando confirmação")
+		}
+		if err := tx.Where("id = ? AND tenant_id = ?", *subscription.PendingOrderID, tenantID).First(&order).Error; err != nil {
+			return err
+		}
+
+		if !confirm {
+			order.Status = "cancelled"
+			if err := tx.Model(&order).Update("status", order.Status).Error; err != nil {
+				return err
+			}
+		} else {
+			applyOrderShippingAddress(&order, deliveryAddress)
+			order.IsPickup = deliveryAddress == nil
+			if err := tx.Model(&order).Select("AddressID", "ShippingName", "ShippingStreet", "ShippingNumber", "ShippingComplement",
+				"ShippingReference", "ShippingNeighborhood", "ShippingCity", "ShippingState", "ShippingZipcode", "ShippingCountry", "IsPickup").
+				Updates(&order).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Model(&subscription).Update("pending_order_id", nil).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &order, nil
+}
+
+// CouponServiceImpl implementa CouponServiceInterface
+type CouponServiceImpl struct {
+	db *gorm.DB
+}
+
+func NewCouponService(db *gorm.DB) CouponServiceInterface {
+	return &CouponServiceImpl{db: db}
+}
+
+// GetCouponByCode busca o cupom do tenant pelo código, sem diferenciar maiúsculas
+func (s *CouponServiceImpl) GetCouponByCode(tenantID uuid.UUID, code string) (*models.Coupon, error) {
+	var coupon models.Coupon
+	err := s.db.Where("tenant_id = ? AND UPPER(code) = ?", tenantID, strings.ToUpper(strings.TrimSpace(code))).
+		First(&coupon).Error
+	if err != nil {
+		return nil, err
+	}
+	return &coupon, nil
+}
+
+// ReturnRequestServiceImpl implementa ReturnRequestServiceInterface
+type ReturnRequestServiceImpl struct {
+	db *gorm.DB
+}
+
+func NewReturnRequestService(db *gorm.DB) ReturnRequestServiceInterface {
+	return &ReturnRequestServiceImpl{db: db}
+}
+
+// CreateReturnRequest registra a solicitação de devolução ou troca do pedido
+func (s *ReturnRequestServiceImpl) CreateReturnRequest(request *models.ReturnRequest) error {
+	if request.ID == uuid.Nil {
+		request.ID = uuid.New()
+	}
+	return s.db.Create(request).Error
+}
+
+// GetOpenReturnRequest busca a solicitação do pedido que ainda aguarda a análise da equipe
+func (s *ReturnRequestServiceImpl) GetOpenReturnRequest(tenantID, orderID uuid.UUID) (*models.ReturnRequest, error) {
+	var request models.ReturnRequest
+	err := s.db.Where("tenant_id = ? AND order_id = ? AND status IN ?", tenantID, orderID,
+		[]string{models.ReturnRequestStatusRequested, models.ReturnRequestStatusApproved}).
+		Order("created_at DESC").
+		First(&request).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &request, nil
+}
@@ -0,0 +1,219 @@
+package ai
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"iafarma/internal/utils"
+	"iafarma/pkg/models"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// InstallmentsMaxSettingKey define o número máximo de parcelas no cartão ("1" = sem parcelamento)
+	InstallmentsMaxSettingKey = "ai_installments_max"
+	// InstallmentsMinOrderSettingKey define o valor mínimo do pedido para parcelar ("0" = qualquer valor)
+	InstallmentsMinOrderSettingKey = "ai_installments_min_order"
+	// InstallmentsInterestFreeSettingKey define até quantas parcelas não há juros
+	InstallmentsInterestFreeSettingKey = "ai_installments_interest_free"
+	// InstallmentsInterestRateSettingKey define a taxa de juros mensal (%) das parcelas acima das sem juros
+	InstallmentsInterestRateSettingKey = "ai_installments_interest_rate"
+)
+
+// installmentConfig reúne as regras de parcelamento do tenant
+type installmentConfig struct {
+	MaxInstallments int
+	MinOrder        float64
+	InterestFree    int
+	MonthlyRate     float64 // em %, ex.: 1.99
+}
+
+// installmentPlan é uma opção de parcelamento para o total informado
+type installmentPlan struct {
+	Count        int
+	Amount       float64
+	Total        float64
+	WithInterest bool
+}
+
+// getInstallmentConfig lê as regras de parcelamento configuradas pelo tenant
+func (s *AIService) getInstallmentConfig(ctx context.Context, tenantID uuid.UUID) installmentConfig {
+	config := installmentConfig{MaxInstallments: 1}
+	if s.settingsService == nil {
+		return config
+	}
+
+	read := func(key string) string {
+		setting, err := s.settingsService.GetSetting(ctx, tenantID, key)
+		if err != nil || setting == nil || setting.SettingValue == nil {
+			return ""
+		}
+		return strings.Replace(strings.TrimSpace(*setting.SettingValue), ",", ".", 1)
+	}
+
+	if value, err := strconv.Atoi(read(InstallmentsMaxSettingKey)); err == nil && value > 1 {
+		config.MaxInstallments = value
+	}
+	if value, err := strconv.Atoi(read(InstallmentsInterestFreeSettingKey)); err == nil && value > 0 {
+		config.InterestFree = value
+	}
+	if value, err := strconv.ParseFloat(read(InstallmentsMinOrderSettingKey), 64); err == nil && value > 0 {
+		config.MinOrder = value
+	}
+	if value, err := strconv.ParseFloat(read(InstallmentsInterestRateSettingKey), 64); err == nil && value > 0 {
+		config.MonthlyRate = value
+	}
+	return config
+}
+
+// eligible indica se o total permite parcelamento
+func (c installmentConfig) eligible(total float64) bool {
+	return c.MaxInstallments > 1 && total > 0 && total >= c.MinOrder
+}
+
+// plan calcula uma opção de parcelamento; acima das parcelas sem juros aplica a Tabela Price com a taxa mensal
+func (c installmentConfig) plan(total float64, count int) installmentPlan {
+	if count <= 1 || count <= c.InterestFree || c.MonthlyRate <= 0 {
+		amount := utils.RoundMoney(total / float64(count))
+		return installmentPlan{Count: count, Amount: amount, Total: total}
+	}
+
+	rate := c.MonthlyRate / 100
+	amount := utils.RoundMoney(total * rate / (1 - math.Pow(1+rate, -float64(count))))
+	return installmentPlan{
+		Count:        count,
+		Amount:       amount,
+		Total:        utils.CentsFromFloat(amount).Times(count).Float(),
+		WithInterest: true,
+	}
+}
+
+// plans lista todas as opções de parcelamento para o total (vazio quando não atinge o mínimo)
+func (c installmentConfig) plans(total float64) []installmentPlan {
+	if !c.eligible(total) {
+		return nil
+	}
+	plans := make([]installmentPlan, 0, c.MaxInstallments)
+	for count := 1; count <= c.MaxInstallments; count++ {
+		plans = append(plans, c.plan(total, count))
+	}
+	return plans
+}
+
+// formatInstallmentPlan descreve uma opção de parcelamento
+func formatInstallmentPlan(plan installmentPlan) string {
+	amount := formatCurrency(utils.FormatMoney(plan.Amount))
+	if plan.Count == 1 {
+		return fmt.Sprintf("1x de R$ %s (à vista)", amount)
+	}
+	if plan.WithInterest {
+		return fmt.Sprintf("%dx de R$ %s com juros (total R$ %s)", plan.Count, amount, formatCurrency(utils.FormatMoney(plan.Total)))
+	}
+	return fmt.Sprintf("%dx de R$ %s sem juros", plan.Count, amount)
+}
+
+// formatInstallmentOptions apresenta as opções de parcelamento do total do carrinho
+func formatInstallmentOptions(config installmentConfig, total float64) string {
+	totalCents := utils.CentsFromFloat(total)
+	totalText := formatCurrency(totalCents.String())
+	if config.MaxInstallments <= 1 {
+		return fmt.Sprintf("💳 No momento não trabalhamos com parcelamento. O total do seu pedido é **R$ %s**.", totalText)
+	}
+	if !config.eligible(total) {
+		minOrder := utils.CentsFromFloat(config.MinOrder)
+		return fmt.Sprintf("💳 O parcelamento vale para pedidos a partir de **R$ %s**. Seu pedido está em R$ %s - faltam **R$ %s** para poder parcelar.",
+			formatCurrency(minOrder.String()), totalText, formatCurrency((minOrder - totalCents).String()))
+	}
+
+	var result strings.Builder
+	result.WriteString(fmt.Sprintf("💳 **Parcelamento do seu pedido (R$ %s):**\n\n", totalText))
+	for _, plan := range config.plans(total) {
+		result.WriteString("• " + formatInstallmentPlan(plan) + "\n")
+	}
+	result.WriteString("\n💬 Em quantas vezes você quer pagar?")
+	return result.String()
+}
+
+// cartInstallmentTotal é o valor parcelado: itens do carrinho mais a entrega e a taxa de urgência
+func (s *AIService) cartInstallmentTotal(ctx context.Context, tenantID uuid.UUID, cart *models.Cart) float64 {
+	return cartSubtotal(cart) + s.quoteCartDeliveryFee(ctx, tenantID, cart).Fee + s.cartUrgencyFee(ctx, tenantID, cart)
+}
+
+// applyCartInstallments grava no pedido o parcelamento escolhido no carrinho, recalculado sobre o total final.
+// Se o total final não atingir mais o mínimo ou a opção não existir mais, o pedido fica à vista.
+func (s *AIService) applyCartInstallments(ctx context.Context, tenantID uuid.UUID, cart *models.Cart, order *models.Order) *models.Order {
+	if cart.InstallmentCount <= 1 {
+		return order
+	}
+
+	total, _ := strconv.ParseFloat(order.TotalAmount, 64)
+	config := s.getInstallmentConfig(ctx, tenantID)
+	if !config.eligible(total) || cart.InstallmentCount > config.MaxInstallments {
+		log.Warn().
+			Str("order_id", order.ID.String()).
+			Int("installments", cart.InstallmentCount).
+			Float64("total", total).
+			Msg("⚠️ Parcelamento escolhido não é mais válido para o total do pedido")
+		return order
+	}
+
+	plan := config.plan(total, cart.InstallmentCount)
+	updated, err := s.orderService.ApplyInstallmentPlan(tenantID, order.ID, plan.Count, plan.Amount)
+	if err != nil {
+		log.Error().Err(err).Str("order_id", order.ID.String()).Msg("Erro ao registrar parcelamento no pedido")
+		return order
+	}
+	return updated
+}
+
+// handleConsultarParcelamento mostra as opções de parcelamento do carrinho e, se informado, registra a escolha do cliente
+func (s *AIService) handleConsultarParcelamento(ctx context.Context, tenantID, customerID uuid.UUID, args map[string]interface{}) (string, error) {
+	cart, err := s.cartService.GetOrCreateActiveCart(tenantID, customerID)
+	if err != nil {
+		return "❌ Erro ao acessar carrinho.", err
+	}
+	cartWithItems, err := s.cartService.GetCartWithItems(cart.ID, tenantID)
+	if err != nil {
+		return "❌ Erro ao carregar carrinho.", err
+	}
+	if len(cartWithItems.Items) == 0 {
+		return "🛒 Seu carrinho está vazio. Adicione os produtos e eu te mostro as opções de parcelamento!", nil
+	}
+
+	config := s.getInstallmentConfig(ctx, tenantID)
+	total := s.cartInstallmentTotal(ctx, tenantID, cartWithItems)
+
+	count := 0
+	if value, ok := args["parcelas"].(float64); ok {
+		count = int(value)
+	}
+	if count <= 0 {
+		return formatInstallmentOptions(config, total), nil
+	}
+
+	if !config.eligible(total) || count > config.MaxInstallments {
+		if config.eligible(total) {
+			return fmt.Sprintf("❌ Parcelamos em até **%dx**.\n\n%s", config.MaxInstallments, formatInstallmentOptions(config, total)), nil
+		}
+		return formatInstallmentOptions(config, total), nil
+	}
+
+	if err := s.cartService.UpdateCartInstallments(cartWithItems.ID, tenantID, count); err != nil {
+		return "❌ Erro ao registrar o parcelamento.", err
+	}
+
+	plan := config.plan(total, count)
+	log.Info().
+		Str("tenant_id", tenantID.String()).
+		Str("cart_id", cartWithItems.ID.String()).
+		Int("installments", plan.Count).
+		Float64("amount", plan.Amount).
+		Msg("💳 Parcelamento escolhido pelo cliente")
+
+	return fmt.Sprintf("✅ Anotado! Pagamento em **%s**.\n\n💡 O valor final é confirmado no fechamento do pedido.", formatInstallmentPlan(plan)), nil
+}
@@ -0,0 +1,114 @@
+package ai
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"iafarma/pkg/models"
+
+	"github.com/google/uuid"
+)
+
+func TestInstallmentPlan(t *testing.T) {
+	config := installmentConfig{MaxInstallments: 12, InterestFree: 3, MonthlyRate: 2}
+
+	tests := []struct {
+		name         string
+		total        float64
+		count        int
+		amount       float64
+		planTotal    float64
+		withInterest bool
+	}{
+		{"à vista", 300, 1, 300, 300, false},
+		{"sem juros", 300, 3, 100, 300, false},
+		{"sem juros arredonda centavos", 100, 3, 33.33, 100, false},
+		{"com juros tabela price", 1000, 12, 94.56, 1134.72, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plan := config.plan(tt.total, tt.count)
+			if plan.Count != tt.count || plan.Amount != tt.amount || plan.Total != tt.planTotal || plan.WithInterest != tt.withInterest {
+				t.Errorf("esperado %dx %.2f (total %.2f, juros %v), obtido %+v", tt.count, tt.amount, tt.planTotal, tt.withInterest, plan)
+			}
+		})
+	}
+}
+
+func TestInstallmentPlansRespectMinOrder(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   installmentConfig
+		total    float64
+		expected int
+	}{
+		{"abaixo do mínimo", installmentConfig{MaxInstallments: 6, MinOrder: 150}, 149.99, 0},
+		{"no mínimo", installmentConfig{MaxInstallments: 6, MinOrder: 150}, 150, 6},
+		{"sem mínimo", installmentConfig{MaxInstallments: 3}, 20, 3},
+		{"parcelamento desativado", installmentConfig{MaxInstallments: 1}, 500, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := len(tt.config.plans(tt.total)); got != tt.expected {
+				t.Errorf("esperado %d opções, obtido %d", tt.expected, got)
+			}
+		})
+	}
+}
+
+// newInstallmentTestCart retorna um carrinho com duas unidades do preço informado
+func newInstallmentTestCart(price string) *models.Cart {
+	return &models.Cart{
+		BaseTenantModel: models.BaseTenantModel{ID: uuid.New()},
+		Items:           []models.CartItem{{Quantity: 2, Price: price}},
+	}
+}
+
+func TestConsultarParcelamento(t *testing.T) {
+	settings := map[string]string{
+		InstallmentsMaxSettingKey:          "6",
+		InstallmentsMinOrderSettingKey:     "100",
+		InstallmentsInterestFreeSettingKey: "3",
+		InstallmentsInterestRateSettingKey: "1,99",
+	}
+
+	t.Run("lista as opções", func(t *testing.T) {
+		s, _ := newTestService(settings, withCart(newInstallmentTestCart("75.00")))
+		result, err := s.handleConsultarParcelamento(context.Background(), uuid.New(), uuid.New(), map[string]interface{}{})
+		if err != nil {
+			t.Fatalf("erro inesperado: %v", err)
+		}
+		if !strings.Contains(result, "3x de R$ 50,00 sem juros") || !strings.Contains(result, "6x de R$") {
+			t.Errorf("esperado opções de parcelamento, obtido:\n%s", result)
+		}
+	})
+
+	t.Run("registra a escolha", func(t *testing.T) {
+		s, fakes := newTestService(settings, withCart(newInstallmentTestCart("75.00")))
+		if _, err := s.handleConsultarParcelamento(context.Background(), uuid.New(), uuid.New(), map[string]interface{}{"parcelas": float64(3)}); err != nil {
+			t.Fatalf("erro inesperado: %v", err)
+		}
+		if fakes.cart.cart.InstallmentCount != 3 {
+			t.Errorf("esperado 3 parcelas no carrinho, obtido %d", fakes.cart.cart.InstallmentCount)
+		}
+	})
+
+	t.Run("rejeita acima do máximo", func(t *testing.T) {
+		s, fakes := newTestService(settings, withCart(newInstallmentTestCart("75.00")))
+		result, _ := s.handleConsultarParcelamento(context.Background(), uuid.New(), uuid.New(), map[string]interface{}{"parcelas": float64(10)})
+		if fakes.cart.cart.InstallmentCount != 0 || !strings.Contains(result, "até **6x**") {
+			t.Errorf("esperado recusar 10 parcelas, obtido %d:\n%s", fakes.cart.cart.InstallmentCount, result)
+		}
+	})
+
+	t.Run("abaixo do mínimo não parcela", func(t *testing.T) {
+		s, fakes := newTestService(settings, withCart(newInstallmentTestCart("40.00")))
+		result, _ := s.handleConsultarParcelamento(context.Background(), uuid.New(), uuid.New(), map[string]interface{}{"parcelas": float64(2)})
+		if fakes.cart.cart.InstallmentCount != 0 || !strings.Contains(result, "faltam **R$ 20,00**") {
+			t.Errorf("esperado recusar parcelamento abaixo do mínimo, obtido %d:\n%s", fakes.cart.cart.InstallmentCount, result)
+		}
+	})
+}
@@ -0,0 +1,198 @@
+package ai
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"iafarma/pkg/models"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+)
+
+// DefaultLanguageSettingKey define o idioma padrão do atendimento do tenant ("pt", "en" ou "es")
+const DefaultLanguageSettingKey = "ai_default_language"
+
+// defaultLanguage é o idioma usado quando nem o cliente nem o tenant escolheram outro
+const defaultLanguage = "pt"
+
+// supportedLanguages são os idiomas atendidos, com o nome usado na instrução do prompt
+var supportedLanguages = map[string]string{
+	"pt": "português do Brasil",
+	"en": "inglês (English)",
+	"es": "espanhol (español)",
+}
+
+// languageAliases aceita o código ou o nome do idioma em qualquer um dos idiomas atendidos
+var languageAliases = map[string]string{
+	"pt": "pt", "pt-br": "pt", "portugues": "pt", "português": "pt", "portuguese": "pt", "portugués": "pt",
+	"en": "en", "en-us": "en", "ingles": "en", "inglês": "en", "english": "en", "inglés": "en",
+	"es": "es", "es-es": "es", "espanhol": "es", "spanish": "es", "espanol": "es", "español": "es",
+}
+
+// languageSwitchPhrases são pedidos óbvios de troca de idioma reconhecidos na própria mensagem
+var languageSwitchPhrases = []struct {
+	phrase   string
+	language string
+}{
+	{"english please", "en"},
+	{"in english", "en"},
+	{"speak english", "en"},
+	{"em inglês", "en"},
+	{"em ingles", "en"},
+	{"en inglés", "en"},
+	{"en español", "es"},
+	{"en espanol", "es"},
+	{"español por favor", "es"},
+	{"habla español", "es"},
+	{"hablas español", "es"},
+	{"in spanish", "es"},
+	{"em espanhol", "es"},
+	{"em português", "pt"},
+	{"em portugues", "pt"},
+	{"in portuguese", "pt"},
+	{"fala português", "pt"},
+	{"en portugués", "pt"},
+}
+
+// messageCatalog guarda as mensagens fixas do bot em cada idioma atendido
+var messageCatalog = map[string]map[string]string{
+	"welcome": {
+		"pt": "Oi! Como posso ajudar você hoje?",
+		"en": "Hi! How can I help you today?",
+		"es": "¡Hola! ¿Cómo puedo ayudarte hoy?",
+	},
+	"returning_cart": {
+		"pt": "👋 Bem-vindo de volta! Você tinha itens no carrinho:",
+		"en": "👋 Welcome back! You had items in your cart:",
+		"es": "👋 ¡Bienvenido de nuevo! Tenías productos en tu carrito:",
+	},
+	"returning_cart_question": {
+		"pt": "Quer continuar de onde parou ou prefere esvaziar o carrinho e começar de novo?",
+		"en": "Would you like to pick up where you left off or clear the cart and start over?",
+		"es": "¿Quieres continuar donde lo dejaste o prefieres vaciar el carrito y empezar de nuevo?",
+	},
+	"language_set": {
+		"pt": "✅ Pronto! Vou continuar o atendimento em português.",
+		"en": "✅ Done! I'll keep helping you in English.",
+		"es": "✅ ¡Listo! Seguiré atendiéndote en español.",
+	},
+	"language_unsupported": {
+		"pt": "❌ Ainda não atendo nesse idioma. Posso falar em português, inglês ou espanhol.",
+		"en": "❌ I can't help in that language yet. I can speak Portuguese, English or Spanish.",
+		"es": "❌ Todavía no atiendo en ese idioma. Puedo hablar portugués, inglés o español.",
+	},
+}
+
+// normalizeLanguage converte o código ou nome do idioma para o código atendido
+func normalizeLanguage(value string) (string, bool) {
+	language, ok := languageAliases[strings.ToLower(strings.TrimSpace(value))]
+	return language, ok
+}
+
+// detectLanguageSwitch reconhece pedidos óbvios de troca de idioma ("English please", "en español")
+func detectLanguageSwitch(message string) (string, bool) {
+	text := strings.ToLower(strings.Join(strings.Fields(message), " "))
+	if language, ok := normalizeLanguage(strings.Trim(text, "!.?")); ok && len(text) > 2 {
+		return language, true
+	}
+	for _, entry := range languageSwitchPhrases {
+		if strings.Contains(text, entry.phrase) {
+			return entry.language, true
+		}
+	}
+	return "", false
+}
+
+// catalogMessage retorna a mensagem fixa no idioma pedido, com português como reserva
+func catalogMessage(language, key string) string {
+	messages := messageCatalog[key]
+	if message, ok := messages[language]; ok {
+		return message
+	}
+	return messages[defaultLanguage]
+}
+
+// tenantDefaultLanguage retorna o idioma padrão configurado pelo tenant (padrão: português)
+func (s *AIService) tenantDefaultLanguage(ctx context.Context, tenantID uuid.UUID) string {
+	if s.settingsService == nil {
+		return defaultLanguage
+	}
+	setting, err := s.settingsService.GetSetting(ctx, tenantID, DefaultLanguageSettingKey)
+	if err != nil || setting == nil || setting.SettingValue == nil {
+		return defaultLanguage
+	}
+	if language, ok := normalizeLanguage(*setting.SettingValue); ok {
+		return language
+	}
+	return defaultLanguage
+}
+
+// customerLanguage retorna o idioma escolhido pelo cliente ou, sem escolha, o padrão do tenant
+func (s *AIService) customerLanguage(ctx context.Context, tenantID uuid.UUID, customer *models.Customer) string {
+	if customer != nil {
+		if language, ok := normalizeLanguage(customer.Language); ok {
+			return language
+		}
+	}
+	return s.tenantDefaultLanguage(ctx, tenantID)
+}
+
+// setCustomerLanguage persiste a preferência de idioma no cadastro do cliente
+func (s *AIService) setCustomerLanguage(tenantID uuid.UUID, customer *models.Customer, language string) error {
+	if customer.Language == language {
+		return nil
+	}
+	if err := s.customerService.SetCustomerLanguage(tenantID, customer.ID, language); err != nil {
+		return err
+	}
+	customer.Language = language
+
+	log.Info().
+		Str("tenant_id", tenantID.String()).
+		Str("customer_id", customer.ID.String()).
+		Str("language", language).
+		Msg("🌐 Idioma do cliente atualizado")
+	return nil
+}
+
+// applyLanguageSwitch grava o idioma quando a mensagem pede a troca de forma explícita
+func (s *AIService) applyLanguageSwitch(tenantID uuid.UUID, customer *models.Customer, message string) {
+	language, ok := detectLanguageSwitch(message)
+	if !ok {
+		return
+	}
+	if err := s.setCustomerLanguage(tenantID, customer, language); err != nil {
+		log.Error().Err(err).Str("customer_id", customer.ID.String()).Msg("Erro ao salvar idioma do cliente")
+	}
+}
+
+// withLanguageInstruction acrescenta ao prompt o idioma das respostas quando não é o português do prompt padrão
+func withLanguageInstruction(systemPrompt, language string) string {
+	if language == defaultLanguage {
+		return systemPrompt
+	}
+	return systemPrompt + fmt.Sprintf("\n\n🌐 IDIOMA DO CLIENTE: responda SEMPRE em %s, inclusive listas, totais e confirmações. "+
+		"Mantenha nomes de produtos como estão no catálogo.", supportedLanguages[language])
+}
+
+// handleDefinirIdioma grava o idioma escolhido pelo cliente para as próximas mensagens
+func (s *AIService) handleDefinirIdioma(ctx context.Context, tenantID, customerID uuid.UUID, args map[string]interface{}) (string, error) {
+	customer, err := s.customerService.GetCustomerByID(tenantID, customerID)
+	if err != nil || customer == nil {
+		return "❌ Não consegui encontrar seu cadastro para salvar o idioma.", nil
+	}
+
+	requested, _ := args["idioma"].(string)
+	language, ok := normalizeLanguage(requested)
+	if !ok {
+		return catalogMessage(s.customerLanguage(ctx, tenantID, customer), "language_unsupported"), nil
+	}
+
+	if err := s.setCustomerLanguage(tenantID, customer, language); err != nil {
+		log.Error().Err(err).Str("customer_id", customerID.String()).Msg("Erro ao salvar idioma do cliente")
+		return "❌ Não consegui salvar o idioma agora. Tente novamente.", nil
+	}
+	return catalogMessage(language, "language_set"), nil
+}
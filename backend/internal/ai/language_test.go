@@ -0,0 +1,172 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"iafarma/pkg/models"
+
+	"github.com/google/uuid"
+	"github.com/sashabaranov/go-openai"
+)
+
+// languageCustomerService guarda o idioma escolhido no próprio cliente, como o cadastro real
+type languageCustomerService struct {
+	visitingCustomerService
+}
+
+func (f *languageCustomerService) GetCustomerByID(tenantID, customerID uuid.UUID) (*models.Customer, error) {
+	return f.customer, nil
+}
+
+func (f *languageCustomerService) MarkCustomerWelcomed(tenantID, customerID uuid.UUID, welcomedAt time.Time) error {
+	return nil
+}
+
+func (f *languageCustomerService) SetCustomerLanguage(tenantID, customerID uuid.UUID, language string) error {
+	f.customer.Language = language
+	return nil
+}
+
+// newPromptCapturingClient simula a OpenAI respondendo "ok" e guarda o prompt do sistema recebido
+func newPromptCapturingClient(t *testing.T) (*openai.Client, *string) {
+	t.Helper()
+	systemPrompt := new(string)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req openai.ChatCompletionRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		if len(req.Messages) > 0 {
+			*systemPrompt = req.Messages[0].Content
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(openai.ChatCompletionResponse{
+			Choices: []openai.ChatCompletionChoice{{Message: openai.ChatCompletionMessage{Role: openai.ChatMessageRoleAssistant, Content: "ok"}}},
+		})
+	}))
+	t.Cleanup(server.Close)
+
+	config := openai.DefaultConfig("test")
+	config.BaseURL = server.URL + "/v1"
+	return openai.NewClientWithConfig(config), systemPrompt
+}
+
+// newLanguageTestCustomer retorna a cliente Maria com o idioma escolhido
+func newLanguageTestCustomer(language string) *models.Customer {
+	customer := &models.Customer{Name: "Maria", Phone: "5527999999999", Language: language}
+	customer.ID = uuid.New()
+	return customer
+}
+
+// withLanguageChat liga a OpenAI simulada, o cliente que guarda o idioma escolhido e o prompt da Farmácia Central
+func withLanguageChat(client *openai.Client, customer *models.Customer) testServiceOption {
+	return func(s *AIService, fakes *testFakes) {
+		fakes.settings.values["ai_system_prompt_template"] = "Você é o atendente da Farmácia Central."
+		s.client = client
+		s.customerService = &languageCustomerService{visitingCustomerService{phoneCustomerService{customer: customer}}}
+	}
+}
+
+func TestDetectLanguageSwitch(t *testing.T) {
+	tests := []struct {
+		message  string
+		language string
+		found    bool
+	}{
+		{"English please", "en", true},
+		{"Hi, can you speak English?", "en", true},
+		{"English", "en", true},
+		{"¿Me atiendes en español?", "es", true},
+		{"pode falar em português", "pt", true},
+		{"quero protetor solar", "", false},
+		{"es", "", false},
+		{"tem sabonete inglês?", "", false},
+	}
+
+	for _, tt := range tests {
+		language, found := detectLanguageSwitch(tt.message)
+		if found != tt.found || language != tt.language {
+			t.Errorf("%q: esperado (%q, %v), obtido (%q, %v)", tt.message, tt.language, tt.found, language, found)
+		}
+	}
+}
+
+func TestCustomerLanguageOverridesTenantDefault(t *testing.T) {
+	tests := []struct {
+		name             string
+		customerLanguage string
+		expected         string
+		unexpected       string
+	}{
+		{"idioma escolhido pelo cliente", "en", "responda SEMPRE em inglês (English)", "espanhol"},
+		{"sem escolha usa o padrão do tenant", "", "responda SEMPRE em espanhol (español)", "inglês"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, systemPrompt := newPromptCapturingClient(t)
+			customer := newLanguageTestCustomer(tt.customerLanguage)
+			s, _ := newTestService(map[string]string{DefaultLanguageSettingKey: "es"}, withLanguageChat(client, customer))
+			now := time.Now()
+			customer.LastWelcomedAt = &now
+
+			if _, err := s.ProcessMessage(context.Background(), uuid.New(), customer.Phone, "I need something for a headache"); err != nil {
+				t.Fatalf("erro inesperado: %v", err)
+			}
+			if !strings.Contains(*systemPrompt, tt.expected) || strings.Contains(*systemPrompt, tt.unexpected) {
+				t.Errorf("instrução de idioma errada no prompt:\n%s", *systemPrompt)
+			}
+		})
+	}
+}
+
+func TestCustomerLanguageWelcomeAndSwitch(t *testing.T) {
+	tenantID := uuid.New()
+	client, systemPrompt := newPromptCapturingClient(t)
+	customer := newLanguageTestCustomer("en")
+	s, _ := newTestService(map[string]string{}, withLanguageChat(client, customer))
+
+	// Boas-vindas do catálogo no idioma do cliente, não a mensagem em português do tenant
+	welcome, err := s.ProcessMessage(context.Background(), tenantID, customer.Phone, "hi")
+	if err != nil {
+		t.Fatalf("erro inesperado: %v", err)
+	}
+	if welcome != catalogMessage("en", "welcome") {
+		t.Errorf("esperadas boas-vindas em inglês, obtido %q", welcome)
+	}
+
+	// Pedido explícito na mensagem troca o idioma das próximas respostas
+	if _, err := s.ProcessMessage(context.Background(), tenantID, customer.Phone, "pode falar em português? quero dipirona"); err != nil {
+		t.Fatalf("erro inesperado: %v", err)
+	}
+	if customer.Language != "pt" || strings.Contains(*systemPrompt, "IDIOMA DO CLIENTE") {
+		t.Errorf("cliente deveria voltar ao português (idioma %q), prompt:\n%s", customer.Language, *systemPrompt)
+	}
+}
+
+func TestDefinirIdiomaTool(t *testing.T) {
+	tenantID := uuid.New()
+	client, _ := newPromptCapturingClient(t)
+	customer := newLanguageTestCustomer("")
+	s, _ := newTestService(map[string]string{}, withLanguageChat(client, customer))
+
+	result, err := s.executeTool(context.Background(), tenantID, customer.ID, customer.Phone, "definirIdioma", map[string]interface{}{"idioma": "es"})
+	if err != nil {
+		t.Fatalf("erro inesperado: %v", err)
+	}
+	if customer.Language != "es" || result != catalogMessage("es", "language_set") {
+		t.Errorf("idioma deveria ser salvo como espanhol (%q), obtido %q", customer.Language, result)
+	}
+
+	result, err = s.executeTool(context.Background(), tenantID, customer.ID, customer.Phone, "definirIdioma", map[string]interface{}{"idioma": "fr"})
+	if err != nil {
+		t.Fatalf("erro inesperado: %v", err)
+	}
+	if customer.Language != "es" || result != catalogMessage("es", "language_unsupported") {
+		t.Errorf("idioma não atendido deveria manter o espanhol (%q), obtido %q", customer.Language, result)
+	}
+}
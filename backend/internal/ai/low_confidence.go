@@ -0,0 +1,147 @@
+package ai
+
+import (
+	"context"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+	"github.com/sashabaranov/go-openai"
+)
+
+// LowConfidenceFallbackSettingKey define o que fazer quando a IA responde de forma vaga a um pedido claro de compra:
+// "off" (envia a resposta), "retry" (tenta de novo exigindo uma ferramenta), "handoff" (transfere para um atendente)
+// ou "retry_handoff" (tenta de novo e, se continuar vaga, transfere)
+const LowConfidenceFallbackSettingKey = "ai_low_confidence_fallback"
+
+const (
+	lowConfidenceOff          = "off"
+	lowConfidenceRetry        = "retry"
+	lowConfidenceHandoff      = "handoff"
+	lowConfidenceRetryHandoff = "retry_handoff"
+)
+
+// lowConfidenceRetryInstruction reforça o prompt na nova tentativa
+const lowConfidenceRetryInstruction = "⚠️ A última mensagem do cliente é um pedido claro de compra ou de produto. " +
+	"Não responda de forma genérica: use as ferramentas (buscar, consultar ou adicionar produtos) para atender o pedido agora."
+
+// purchaseIntentPhrases indicam que o cliente pediu um produto ou uma compra de forma direta
+var purchaseIntentPhrases = []string{
+	"quero ", "queria ", "comprar", "preciso de", "gostaria de", "me vê", "me ve ", "manda ", "adiciona", "coloca ",
+	"põe ", "poe ", "vocês têm", "voces tem", "vcs tem", "tem ", "quanto custa", "qual o preço", "qual o valor", "preço d",
+}
+
+// vagueResponsePhrases são respostas genéricas que não avançam o atendimento
+var vagueResponsePhrases = []string{
+	"como posso ajudar",
+	"em que posso ajudar",
+	"posso te ajudar com mais",
+	"estou aqui para ajudar",
+	"me conte mais",
+	"me diga mais",
+	"mais detalhes",
+	"poderia especificar",
+	"pode especificar",
+	"o que você gostaria",
+	"o que você procura",
+}
+
+// hasPurchaseIntent indica se a mensagem é um pedido claro de produto ou compra
+func hasPurchaseIntent(message string) bool {
+	text := strings.ToLower(strings.Join(strings.Fields(message), " ")) + " "
+	for _, phrase := range purchaseIntentPhrases {
+		if strings.HasPrefix(text, phrase) || strings.Contains(text, " "+phrase) {
+			return true
+		}
+	}
+	return false
+}
+
+// isVagueResponse indica uma resposta sem ferramenta que não traz produto, preço ou próximo passo concreto
+func isVagueResponse(response string) bool {
+	text := strings.ToLower(strings.TrimSpace(response))
+	if text == "" {
+		return true
+	}
+	if strings.Contains(text, "r$") {
+		return false
+	}
+	for _, phrase := range vagueResponsePhrases {
+		if strings.Contains(text, phrase) {
+			return true
+		}
+	}
+	return false
+}
+
+// getLowConfidenceMode retorna o modo configurado pelo tenant (padrão: nova tentativa)
+func (s *AIService) getLowConfidenceMode(ctx context.Context, tenantID uuid.UUID) string {
+	if s.settingsService == nil {
+		return lowConfidenceRetry
+	}
+	setting, err := s.settingsService.GetSetting(ctx, tenantID, LowConfidenceFallbackSettingKey)
+	if err != nil || setting == nil || setting.SettingValue == nil {
+		return lowConfidenceRetry
+	}
+	switch mode := strings.ToLower(strings.TrimSpace(*setting.SettingValue)); mode {
+	case lowConfidenceOff, lowConfidenceRetry, lowConfidenceHandoff, lowConfidenceRetryHandoff:
+		return mode
+	default:
+		return lowConfidenceRetry
+	}
+}
+
+// recoverLowConfidenceResponse trata a resposta direta (sem ferramentas) da IA: se for vaga para um pedido claro de
+// compra, tenta de novo exigindo uma ferramenta e/ou transfere para um atendente, conforme configurado.
+// Retorna a resposta final e se alguma ferramenta foi executada.
+func (s *AIService) recoverLowConfidenceResponse(ctx context.Context, tenantID, customerID uuid.UUID, customerPhone, message string, req openai.ChatCompletionRequest, response string) (string, bool, error) {
+	if !hasPurchaseIntent(message) || !isVagueResponse(response) {
+		return response, false, nil
+	}
+	mode := s.getLowConfidenceMode(ctx, tenantID)
+	if mode == lowConfidenceOff {
+		return response, false, nil
+	}
+
+	log.Warn().
+		Str("tenant_id", tenantID.String()).
+		Str("customer_phone", customerPhone).
+		Str("mode", mode).
+		Str("response", response).
+		Msg("🤷 Resposta vaga para pedido de compra")
+
+	if mode == lowConfidenceRetry || mode == lowConfidenceRetryHandoff {
+		retry := req
+		retry.Messages = append(append([]openai.ChatCompletionMessage{}, req.Messages...), openai.ChatCompletionMessage{
+			Role:    openai.ChatMessageRoleSystem,
+			Content: lowConfidenceRetryInstruction,
+		})
+		retry.ToolChoice = "required"
+
+		resp, err := s.createChatCompletionWithFallback(ctx, tenantID, retry)
+		if err != nil {
+			log.Error().Err(err).Msg("Erro na nova tentativa após resposta vaga")
+		} else if len(resp.Choices) > 0 {
+			choice := resp.Choices[0]
+			if len(choice.Message.ToolCalls) > 0 {
+				result, err := s.executeToolCalls(ctx, tenantID, customerID, customerPhone, message, choice.Message.ToolCalls)
+				return result, err == nil, err
+			}
+			if !isVagueResponse(choice.Message.Content) {
+				return choice.Message.Content, false, nil
+			}
+		}
+		if mode == lowConfidenceRetry {
+			return response, false, nil
+		}
+	}
+
+	handoff, err := s.handleSolicitarAtendimentoHumano(ctx, tenantID, customerID, customerPhone, map[string]interface{}{
+		"motivo": "IA respondeu de forma vaga a um pedido de compra: " + message,
+	})
+	if err != nil {
+		log.Error().Err(err).Msg("Erro ao transferir para atendimento humano após resposta vaga")
+		return response, false, nil
+	}
+	return handoff, false, nil
+}
@@ -0,0 +1,134 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"iafarma/pkg/models"
+
+	"github.com/google/uuid"
+	"github.com/sashabaranov/go-openai"
+)
+
+// newScriptedClient aponta o cliente OpenAI para um servidor local que devolve as respostas na ordem informada
+// (repetindo a última); as requisições recebidas ficam disponíveis para conferência
+func newScriptedClient(t *testing.T, responses ...openai.ChatCompletionMessage) (*openai.Client, *[]openai.ChatCompletionRequest) {
+	t.Helper()
+	received := &[]openai.ChatCompletionRequest{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req openai.ChatCompletionRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		*received = append(*received, req)
+		message := responses[min(len(*received), len(responses))-1]
+		message.Role = openai.ChatMessageRoleAssistant
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(openai.ChatCompletionResponse{Choices: []openai.ChatCompletionChoice{{Message: message}}})
+	}))
+	t.Cleanup(server.Close)
+
+	config := openai.DefaultConfig("test")
+	config.BaseURL = server.URL + "/v1"
+	return openai.NewClientWithConfig(config), received
+}
+
+func TestLowConfidenceDetection(t *testing.T) {
+	for _, message := range []string{"quero dipirona", "Tem protetor solar?", "preciso de um xarope para tosse", "quanto custa o Neosaldina?"} {
+		if !hasPurchaseIntent(message) {
+			t.Errorf("%q deveria ser um pedido de compra", message)
+		}
+	}
+	for _, message := range []string{"oi", "bom dia!", "obrigado", "qual o horário de vocês?"} {
+		if hasPurchaseIntent(message) {
+			t.Errorf("%q não deveria ser um pedido de compra", message)
+		}
+	}
+
+	for _, response := range []string{"", "Claro! Como posso ajudar você hoje?", "Poderia me dar mais detalhes sobre o que você procura?"} {
+		if !isVagueResponse(response) {
+			t.Errorf("%q deveria ser uma resposta vaga", response)
+		}
+	}
+	for _, response := range []string{"A Dipirona 500mg sai por R$ 8,90. Posso adicionar?", "Não trabalhamos com esse produto."} {
+		if isVagueResponse(response) {
+			t.Errorf("%q não deveria ser uma resposta vaga", response)
+		}
+	}
+}
+
+func TestLowConfidenceFallback(t *testing.T) {
+	vague := openai.ChatCompletionMessage{Content: "Claro! Como posso ajudar você hoje?"}
+	search := openai.ChatCompletionMessage{ToolCalls: []openai.ToolCall{{
+		ID:       "call_1",
+		Type:     openai.ToolTypeFunction,
+		Function: openai.FunctionCall{Name: "buscarMultiplosProdutos", Arguments: `{"produtos":["dipirona"]}`},
+	}}}
+
+	tests := []struct {
+		name      string
+		mode      string
+		message   string
+		responses []openai.ChatCompletionMessage
+		requests  int
+		expected  string
+		handoff   bool
+	}{
+		{"nova tentativa usa ferramenta", "", "quero dipirona", []openai.ChatCompletionMessage{vague, search}, 2, "Dipirona 500mg", false},
+		{"desativado envia a resposta vaga", lowConfidenceOff, "quero dipirona", []openai.ChatCompletionMessage{vague}, 1, vague.Content, false},
+		{"transferência direta", lowConfidenceHandoff, "quero dipirona", []openai.ChatCompletionMessage{vague}, 1, "Atendimento Humano Solicitado", true},
+		{"nova tentativa vaga transfere", lowConfidenceRetryHandoff, "quero dipirona", []openai.ChatCompletionMessage{vague, vague}, 2, "Atendimento Humano Solicitado", true},
+		{"nova tentativa vaga sem transferência", lowConfidenceRetry, "quero dipirona", []openai.ChatCompletionMessage{vague, vague}, 2, vague.Content, false},
+		{"mensagem sem pedido de compra", lowConfidenceRetryHandoff, "e a entrega funciona como?", []openai.ChatCompletionMessage{vague}, 1, vague.Content, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tenantID := uuid.New()
+			phone := "5561999999999"
+			customer := &models.Customer{Name: "Maria", Phone: phone}
+			customer.ID = uuid.New()
+			settings := map[string]string{}
+			if tt.mode != "" {
+				settings[LowConfidenceFallbackSettingKey] = tt.mode
+			}
+
+			client, received := newScriptedClient(t, tt.responses...)
+			conversationID := uuid.New()
+			conversations := &fakeConversationService{paused: map[uuid.UUID]bool{}}
+			s := &AIService{
+				client:              client,
+				conversationService: conversations,
+				customerService:     &visitingCustomerService{phoneCustomerService: phoneCustomerService{customer: customer}},
+				orderService:        promptOrderService{fakeOrderService: &fakeOrderService{}},
+				productService:      &fakeProductService{products: []models.Product{newPricedTestProduct("Dipirona 500mg", "8.90")}},
+				alertService:        fakeAlertService{},
+				settingsService:     &fakeSettingsService{values: settings},
+				memoryManager:       NewMemoryManager(),
+			}
+			s.conversationContext.Store(tenantID.String()+"-"+phone, conversationID)
+
+			response, err := s.ProcessMessage(context.Background(), tenantID, phone, tt.message)
+			if err != nil {
+				t.Fatalf("erro inesperado: %v", err)
+			}
+			if !strings.Contains(response, tt.expected) {
+				t.Errorf("esperado %q na resposta, obtido:\n%s", tt.expected, response)
+			}
+			if len(*received) != tt.requests {
+				t.Fatalf("esperadas %d chamadas à IA, obtido %d", tt.requests, len(*received))
+			}
+			if tt.requests > 1 {
+				retry := (*received)[1]
+				if retry.ToolChoice != "required" || retry.Messages[len(retry.Messages)-1].Content != lowConfidenceRetryInstruction {
+					t.Errorf("nova tentativa deveria exigir ferramenta com o prompt reforçado, obtido tool_choice=%v", retry.ToolChoice)
+				}
+			}
+			if paused := s.isBotPaused(tenantID, conversationID); paused != tt.handoff {
+				t.Errorf("bot pausado = %v, esperado %v", paused, tt.handoff)
+			}
+		})
+	}
+}
@@ -0,0 +1,83 @@
+package ai
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// LowStockUrgencyThresholdSettingKey define até quantas unidades em estoque o bot destaca a escassez (0 = desativado)
+	LowStockUrgencyThresholdSettingKey = "ai_low_stock_urgency_threshold"
+	// LowStockShowCountSettingKey define se o bot informa a quantidade exata restante ("restam só 3!")
+	LowStockShowCountSettingKey = "ai_low_stock_show_count"
+
+	// defaultLowStockUrgencyThreshold mantém o destaque de escassez desligado até o tenant configurar um limite
+	defaultLowStockUrgencyThreshold = 0
+)
+
+// lowStockUrgencyConfig reúne as preferências do tenant para destacar estoque baixo
+type lowStockUrgencyConfig struct {
+	Threshold int
+	ShowCount bool
+}
+
+// getLowStockUrgencyConfig retorna o limite de estoque baixo e se a quantidade exata pode ser exibida
+func (s *AIService) getLowStockUrgencyConfig(ctx context.Context, tenantID uuid.UUID) lowStockUrgencyConfig {
+	config := lowStockUrgencyConfig{Threshold: defaultLowStockUrgencyThreshold, ShowCount: true}
+	if s.settingsService == nil {
+		return config
+	}
+
+	setting, err := s.settingsService.GetSetting(ctx, tenantID, LowStockUrgencyThresholdSettingKey)
+	if err == nil && setting != nil && setting.SettingValue != nil {
+		if threshold, parseErr := strconv.Atoi(strings.TrimSpace(*setting.SettingValue)); parseErr == nil && threshold >= 0 {
+			config.Threshold = threshold
+		}
+	}
+
+	setting, err = s.settingsService.GetSetting(ctx, tenantID, LowStockShowCountSettingKey)
+	if err == nil && setting != nil && setting.SettingValue != nil {
+		if showCount, parseErr := strconv.ParseBool(strings.TrimSpace(*setting.SettingValue)); parseErr == nil {
+			config.ShowCount = showCount
+		}
+	}
+
+	return config
+}
+
+// isLowStock indica se o estoque está baixo o suficiente para destacar a escassez (nunca para estoque zerado ou amplo)
+func (c lowStockUrgencyConfig) isLowStock(stock int) bool {
+	return c.Threshold > 0 && stock > 0 && stock <= c.Threshold
+}
+
+// lowStockUrgencyText retorna o aviso de escassez para o estoque informado (vazio se o estoque não estiver baixo)
+func (c lowStockUrgencyConfig) lowStockUrgencyText(stock int) string {
+	if !c.isLowStock(stock) {
+		return ""
+	}
+	if !c.ShowCount {
+		return "🔥 **Últimas unidades!**"
+	}
+	if stock == 1 {
+		return "🔥 **Resta só 1 unidade!**"
+	}
+	return fmt.Sprintf("🔥 **Restam só %d unidades!**", stock)
+}
+
+// stockDetailText monta a linha de estoque do detalhe do produto, respeitando a preferência de exibir quantidades
+func (c lowStockUrgencyConfig) stockDetailText(stock int) string {
+	if stock <= 0 {
+		return "⚠️ **Estoque:** Produto indisponível\n"
+	}
+	if urgency := c.lowStockUrgencyText(stock); urgency != "" {
+		return fmt.Sprintf("📊 **Estoque:** %s Garanta já o seu.\n", urgency)
+	}
+	if !c.ShowCount {
+		return "📊 **Estoque:** Disponível\n"
+	}
+	return fmt.Sprintf("📊 **Estoque:** %d unidades disponíveis\n", stock)
+}
@@ -0,0 +1,104 @@
+package ai
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"iafarma/pkg/models"
+
+	"github.com/google/uuid"
+)
+
+func TestLowStockUrgencyText(t *testing.T) {
+	withCount := lowStockUrgencyConfig{Threshold: 5, ShowCount: true}
+	withoutCount := lowStockUrgencyConfig{Threshold: 5, ShowCount: false}
+	disabled := lowStockUrgencyConfig{Threshold: 0, ShowCount: true}
+
+	tests := []struct {
+		name     string
+		config   lowStockUrgencyConfig
+		stock    int
+		esperado string
+	}{
+		{"abaixo do limite", withCount, 3, "🔥 **Restam só 3 unidades!**"},
+		{"no limite", withCount, 5, "🔥 **Restam só 5 unidades!**"},
+		{"uma unidade", withCount, 1, "🔥 **Resta só 1 unidade!**"},
+		{"acima do limite", withCount, 6, ""},
+		{"estoque amplo", withCount, 500, ""},
+		{"esgotado", withCount, 0, ""},
+		{"estoque negativo", withCount, -2, ""},
+		{"sem quantidade exata", withoutCount, 2, "🔥 **Últimas unidades!**"},
+		{"sem quantidade exata acima do limite", withoutCount, 50, ""},
+		{"desativado", disabled, 1, ""},
+	}
+
+	for _, tt := range tests {
+		if got := tt.config.lowStockUrgencyText(tt.stock); got != tt.esperado {
+			t.Errorf("%s: esperado %q, obtido %q", tt.name, tt.esperado, got)
+		}
+	}
+}
+
+func TestGetLowStockUrgencyConfig(t *testing.T) {
+	tests := []struct {
+		name     string
+		values   map[string]string
+		esperado lowStockUrgencyConfig
+	}{
+		{"padrão desativado", map[string]string{}, lowStockUrgencyConfig{Threshold: 0, ShowCount: true}},
+		{"configurado", map[string]string{LowStockUrgencyThresholdSettingKey: "10", LowStockShowCountSettingKey: "false"}, lowStockUrgencyConfig{Threshold: 10, ShowCount: false}},
+		{"valores inválidos", map[string]string{LowStockUrgencyThresholdSettingKey: "-1", LowStockShowCountSettingKey: "talvez"}, lowStockUrgencyConfig{Threshold: defaultLowStockUrgencyThreshold, ShowCount: true}},
+	}
+
+	for _, tt := range tests {
+		s := &AIService{settingsService: &fakeSettingsService{values: tt.values}}
+		if got := s.getLowStockUrgencyConfig(context.Background(), uuid.New()); got != tt.esperado {
+			t.Errorf("%s: esperado %+v, obtido %+v", tt.name, tt.esperado, got)
+		}
+	}
+}
+
+func TestDetalharItemLowStockUrgency(t *testing.T) {
+	tests := []struct {
+		name       string
+		threshold  string
+		stock      int
+		showCount  string
+		contains   string
+		notContain string
+	}{
+		{"estoque baixo", "5", 2, "true", "Restam só 2 unidades", ""},
+		{"estoque amplo", "5", 40, "true", "40 unidades disponíveis", "🔥"},
+		{"estoque baixo sem quantidade", "5", 2, "false", "Últimas unidades", "2 unidades"},
+		{"estoque amplo sem quantidade", "5", 40, "false", "**Estoque:** Disponível", "40"},
+		{"padrão desativado", "", 2, "true", "2 unidades disponíveis", "🔥"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			product := models.Product{Name: "Protetor Solar", Price: "59.90", StockQuantity: tt.stock, Available: true}
+			product.ID = uuid.New()
+			settings := map[string]string{LowStockShowCountSettingKey: tt.showCount}
+			if tt.threshold != "" {
+				settings[LowStockUrgencyThresholdSettingKey] = tt.threshold
+			}
+			s := &AIService{
+				productService:  &fakeProductService{products: []models.Product{product}},
+				settingsService: &fakeSettingsService{values: settings},
+				memoryManager:   NewMemoryManager(),
+			}
+
+			result, err := s.handleDetalharItem(context.Background(), uuid.New(), "5527999999999", map[string]interface{}{"identifier": product.ID.String()})
+			if err != nil {
+				t.Fatalf("erro inesperado: %v", err)
+			}
+			if !strings.Contains(result, tt.contains) {
+				t.Errorf("esperado detalhe contendo %q, obtido:\n%s", tt.contains, result)
+			}
+			if tt.notContain != "" && strings.Contains(result, tt.notContain) {
+				t.Errorf("detalhe não deveria conter %q:\n%s", tt.notContain, result)
+			}
+		})
+	}
+}
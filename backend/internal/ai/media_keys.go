@@ -0,0 +1,55 @@
+package ai
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// ConversationMediaAudio e ConversationMediaImage são os tipos de mídia de conversa salvos no S3
+	ConversationMediaAudio = "audio"
+	ConversationMediaImage = "image"
+)
+
+// conversationMediaKeyPattern reconhece as chaves geradas por ConversationMediaKey
+var conversationMediaKeyPattern = regexp.MustCompile(`^([0-9a-fA-F-]{36})/conversations/([0-9a-fA-F-]{36})/(audio|image)_([0-9a-fA-F-]{36})(\.[A-Za-z0-9]+)?$`)
+
+// ConversationMediaRef identifica a mídia de uma mensagem da conversa a partir da chave no S3
+type ConversationMediaRef struct {
+	TenantID   uuid.UUID
+	CustomerID uuid.UUID
+	MessageID  uuid.UUID
+	Kind       string
+}
+
+// ConversationMediaKey gera a chave S3 da mídia de uma mensagem: tenant_id/conversations/customer_id/kind_messageID.ext
+func ConversationMediaKey(tenantID, customerID, kind, messageID, ext string) string {
+	if ext != "" && !strings.HasPrefix(ext, ".") {
+		ext = "." + ext
+	}
+	return fmt.Sprintf("%s/conversations/%s/%s_%s%s", tenantID, customerID, kind, messageID, ext)
+}
+
+// ParseConversationMediaKey extrai tenant, cliente e mensagem de uma chave gerada por ConversationMediaKey
+func ParseConversationMediaKey(key string) (ConversationMediaRef, bool) {
+	match := conversationMediaKeyPattern.FindStringSubmatch(key)
+	if match == nil {
+		return ConversationMediaRef{}, false
+	}
+	tenantID, err := uuid.Parse(match[1])
+	if err != nil {
+		return ConversationMediaRef{}, false
+	}
+	customerID, err := uuid.Parse(match[2])
+	if err != nil {
+		return ConversationMediaRef{}, false
+	}
+	messageID, err := uuid.Parse(match[4])
+	if err != nil {
+		return ConversationMediaRef{}, false
+	}
+	return ConversationMediaRef{TenantID: tenantID, CustomerID: customerID, MessageID: messageID, Kind: match[3]}, true
+}
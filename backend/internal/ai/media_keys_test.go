@@ -0,0 +1,58 @@
+package ai
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestConversationMediaKeyRoundTrip(t *testing.T) {
+	tenantID := uuid.New()
+	customerID := uuid.New()
+	messageID := uuid.New()
+
+	tests := []struct {
+		name     string
+		kind     string
+		ext      string
+		esperado string
+	}{
+		{"áudio com extensão", ConversationMediaAudio, ".ogg", tenantID.String() + "/conversations/" + customerID.String() + "/audio_" + messageID.String() + ".ogg"},
+		{"imagem sem ponto na extensão", ConversationMediaImage, "jpg", tenantID.String() + "/conversations/" + customerID.String() + "/image_" + messageID.String() + ".jpg"},
+		{"sem extensão", ConversationMediaImage, "", tenantID.String() + "/conversations/" + customerID.String() + "/image_" + messageID.String()},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key := ConversationMediaKey(tenantID.String(), customerID.String(), tt.kind, messageID.String(), tt.ext)
+			if key != tt.esperado {
+				t.Fatalf("esperado %q, obtido %q", tt.esperado, key)
+			}
+
+			ref, ok := ParseConversationMediaKey(key)
+			if !ok {
+				t.Fatalf("chave %q deveria ser reconhecida", key)
+			}
+			if ref.TenantID != tenantID || ref.CustomerID != customerID || ref.MessageID != messageID || ref.Kind != tt.kind {
+				t.Errorf("referência incorreta: %+v", ref)
+			}
+		})
+	}
+}
+
+func TestParseConversationMediaKeyRejectsOtherKeys(t *testing.T) {
+	tenantID := uuid.New().String()
+
+	keys := []string{
+		tenantID + "/products/" + uuid.New().String() + ".jpg",
+		tenantID + "/conversations/" + uuid.New().String() + "/video_" + uuid.New().String() + ".mp4",
+		tenantID + "/conversations/not-a-uuid/audio_" + uuid.New().String() + ".ogg",
+		"logo.png",
+	}
+
+	for _, key := range keys {
+		if _, ok := ParseConversationMediaKey(key); ok {
+			t.Errorf("chave %q não deveria ser reconhecida como mídia de conversa", key)
+		}
+	}
+}
@@ -0,0 +1,125 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// ErrMediaBusy indica que todas as vagas de processamento de mídia estão ocupadas e a espera expirou
+var ErrMediaBusy = errors.New("processamento de mídia ocupado")
+
+// mediaBusyMessage é enviada ao cliente quando a mídia não pôde ser processada por excesso de demanda
+const mediaBusyMessage = "⏳ Estou processando muitas mídias agora, um momento... Pode reenviar em instantes ou me mandar sua mensagem por texto? 📝"
+
+const (
+	// defaultMediaMaxConcurrency é o número padrão de conversões/uploads de mídia simultâneos no processo
+	defaultMediaMaxConcurrency = 4
+	// defaultMediaQueueTimeout é quanto uma mídia espera na fila por uma vaga antes de desistir
+	defaultMediaQueueTimeout = 30 * time.Second
+)
+
+// mediaLimiter limita as conversões (FFmpeg) e uploads de mídia simultâneos; o excesso aguarda na fila
+type mediaLimiter struct {
+	slots   chan struct{}
+	timeout time.Duration
+	waiting atomic.Int64
+}
+
+func newMediaLimiter(maxConcurrency int, timeout time.Duration) *mediaLimiter {
+	if maxConcurrency <= 0 {
+		maxConcurrency = defaultMediaMaxConcurrency
+	}
+	if timeout <= 0 {
+		timeout = defaultMediaQueueTimeout
+	}
+	return &mediaLimiter{slots: make(chan struct{}, maxConcurrency), timeout: timeout}
+}
+
+// acquire ocupa uma vaga, esperando na fila até o timeout. A função retornada libera a vaga.
+func (l *mediaLimiter) acquire(ctx context.Context) (func(), error) {
+	select {
+	case l.slots <- struct{}{}:
+		return l.release, nil
+	default:
+	}
+
+	recordMediaQueueDepth(l.waiting.Add(1), 1)
+	defer func() { recordMediaQueueDepth(l.waiting.Add(-1), -1) }()
+
+	timer := time.NewTimer(l.timeout)
+	defer timer.Stop()
+
+	select {
+	case l.slots <- struct{}{}:
+		return l.release, nil
+	case <-timer.C:
+		return nil, ErrMediaBusy
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (l *mediaLimiter) release() {
+	<-l.slots
+}
+
+var (
+	mediaLimiterOnce     sync.Once
+	sharedMediaLimiter   *mediaLimiter
+	mediaQueueMetricOnce sync.Once
+	mediaQueueDepthGauge metric.Int64UpDownCounter
+)
+
+// defaultMediaLimiter retorna o limitador do processo, configurado por MEDIA_MAX_CONCURRENCY e MEDIA_QUEUE_TIMEOUT_SECONDS
+func defaultMediaLimiter() *mediaLimiter {
+	mediaLimiterOnce.Do(func() {
+		maxConcurrency, _ := strconv.Atoi(os.Getenv("MEDIA_MAX_CONCURRENCY"))
+		timeoutSeconds, _ := strconv.Atoi(os.Getenv("MEDIA_QUEUE_TIMEOUT_SECONDS"))
+		sharedMediaLimiter = newMediaLimiter(maxConcurrency, time.Duration(timeoutSeconds)*time.Second)
+		log.Info().
+			Int("max_concurrency", cap(sharedMediaLimiter.slots)).
+			Dur("queue_timeout", sharedMediaLimiter.timeout).
+			Msg("🎞️ Limite de processamento de mídia configurado")
+	})
+	return sharedMediaLimiter
+}
+
+// AcquireMediaSlot ocupa uma vaga de processamento de mídia (conversão/upload) compartilhada por todo o processo.
+// Retorna ErrMediaBusy quando a espera na fila expira; a função retornada deve ser chamada para liberar a vaga.
+func AcquireMediaSlot(ctx context.Context) (func(), error) {
+	return defaultMediaLimiter().acquire(ctx)
+}
+
+// recordMediaQueueDepth emite a métrica de mídias aguardando vaga
+func recordMediaQueueDepth(depth, delta int64) {
+	mediaQueueMetricOnce.Do(func() {
+		gauge, err := otel.Meter("iafarma/ai").Int64UpDownCounter(
+			"media_processing_queue_depth",
+			metric.WithDescription("Número de mídias aguardando vaga para conversão/upload"),
+		)
+		if err != nil {
+			log.Error().Err(err).Msg("Erro ao criar métrica da fila de mídia")
+			return
+		}
+		mediaQueueDepthGauge = gauge
+	})
+
+	if mediaQueueDepthGauge != nil {
+		mediaQueueDepthGauge.Add(context.Background(), delta)
+	}
+	if delta > 0 {
+		log.Warn().
+			Str("metric", "media_processing_queue_depth").
+			Int64("depth", depth).
+			Msg("⏳ Mídia aguardando vaga de processamento")
+	}
+}
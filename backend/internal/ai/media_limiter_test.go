@@ -0,0 +1,71 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestMediaLimiterBoundsConcurrency(t *testing.T) {
+	const limite = 3
+	limiter := newMediaLimiter(limite, time.Second)
+
+	var atual, maximo atomic.Int64
+	var wg sync.WaitGroup
+	for i := 0; i < 12; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			release, err := limiter.acquire(context.Background())
+			if err != nil {
+				t.Errorf("erro inesperado: %v", err)
+				return
+			}
+			defer release()
+
+			n := atual.Add(1)
+			for {
+				m := maximo.Load()
+				if n <= m || maximo.CompareAndSwap(m, n) {
+					break
+				}
+			}
+			time.Sleep(10 * time.Millisecond)
+			atual.Add(-1)
+		}()
+	}
+	wg.Wait()
+
+	if maximo.Load() > limite {
+		t.Errorf("esperado no máximo %d processamentos simultâneos, obtido %d", limite, maximo.Load())
+	}
+	if maximo.Load() < limite {
+		t.Errorf("esperado usar as %d vagas, obtido %d", limite, maximo.Load())
+	}
+	if limiter.waiting.Load() != 0 {
+		t.Errorf("fila deveria estar vazia, obtido %d", limiter.waiting.Load())
+	}
+}
+
+func TestMediaLimiterTimeoutQuandoOcupado(t *testing.T) {
+	limiter := newMediaLimiter(1, 20*time.Millisecond)
+
+	release, err := limiter.acquire(context.Background())
+	if err != nil {
+		t.Fatalf("erro inesperado: %v", err)
+	}
+
+	if _, err := limiter.acquire(context.Background()); !errors.Is(err, ErrMediaBusy) {
+		t.Errorf("esperado ErrMediaBusy com todas as vagas ocupadas, obtido %v", err)
+	}
+
+	release()
+	release, err = limiter.acquire(context.Background())
+	if err != nil {
+		t.Fatalf("vaga liberada deveria ser reutilizada: %v", err)
+	}
+	release()
+}
@@ -0,0 +1,206 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"iafarma/internal/zapplus"
+
+	"github.com/rs/zerolog/log"
+)
+
+// ErrMediaURLNotAllowed indica que a URL de mídia recebida não está na lista de hosts permitidos
+var ErrMediaURLNotAllowed = errors.New("media URL not allowed")
+
+// defaultMediaHostPatterns são os domínios de mídia do WhatsApp aceitos quando MEDIA_ALLOWED_HOSTS não é informado
+var defaultMediaHostPatterns = []string{"*.whatsapp.net", "*.whatsapp.com", "*.fbcdn.net"}
+
+// maxMediaRedirects limita os redirecionamentos seguidos ao baixar uma mídia
+const maxMediaRedirects = 5
+
+// mediaURLPolicy restringe de quais hosts o servidor baixa mídias recebidas nas mensagens.
+// Padrões "*.dominio" aceitam apenas subdomínios; os demais exigem o host exato. internalHosts são os serviços
+// configurados pelo operador (ZapPlus, S3) que podem estar na rede interna e por isso ficam fora do bloqueio de IPs privados.
+type mediaURLPolicy struct {
+	patterns      []string
+	internalHosts map[string]bool
+}
+
+func newMediaURLPolicy(patterns []string) *mediaURLPolicy {
+	policy := &mediaURLPolicy{}
+	for _, pattern := range patterns {
+		if host := mediaHostPattern(pattern); host != "" {
+			policy.patterns = append(policy.patterns, host)
+		}
+	}
+	return policy
+}
+
+// withInternalHosts permite os hosts exatos das URLs configuradas pelo operador mesmo quando resolvem para a rede
+// interna (ex: ZAPPLUS_BASE_URL=http://10.0.0.12:3000). Só o host exato é liberado, nunca curingas.
+func (p *mediaURLPolicy) withInternalHosts(entries ...string) *mediaURLPolicy {
+	for _, entry := range entries {
+		host := mediaHostPattern(entry)
+		if host == "" || strings.HasPrefix(host, "*.") {
+			continue
+		}
+		if p.internalHosts == nil {
+			p.internalHosts = make(map[string]bool)
+		}
+		p.internalHosts[host] = true
+		p.patterns = append(p.patterns, host)
+	}
+	return p
+}
+
+// isInternalHost indica se o host é um dos serviços internos configurados
+func (p *mediaURLPolicy) isInternalHost(host string) bool {
+	return p.internalHosts[strings.ToLower(strings.TrimSuffix(host, "."))]
+}
+
+// mediaHostPattern normaliza uma entrada da lista, aceitando também URLs completas (ex: base do S3)
+func mediaHostPattern(entry string) string {
+	entry = strings.ToLower(strings.TrimSpace(entry))
+	if strings.Contains(entry, "://") {
+		parsed, err := url.Parse(entry)
+		if err != nil {
+			return ""
+		}
+		return parsed.Hostname()
+	}
+	return strings.TrimSuffix(entry, "/")
+}
+
+func (p *mediaURLPolicy) allowsHost(host string) bool {
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	for _, pattern := range p.patterns {
+		if suffix, ok := strings.CutPrefix(pattern, "*."); ok {
+			if strings.HasSuffix(host, "."+suffix) {
+				return true
+			}
+			continue
+		}
+		if host == pattern {
+			return true
+		}
+	}
+	return false
+}
+
+// validate recusa esquemas não HTTP, credenciais na URL, hosts fora da lista e IPs de rede interna (exceto os
+// serviços internos configurados)
+func (p *mediaURLPolicy) validate(rawURL string) error {
+	parsed, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil || parsed.Host == "" {
+		return fmt.Errorf("%w: invalid URL", ErrMediaURLNotAllowed)
+	}
+	if parsed.Scheme != "https" && parsed.Scheme != "http" {
+		return fmt.Errorf("%w: scheme %q", ErrMediaURLNotAllowed, parsed.Scheme)
+	}
+	if parsed.User != nil {
+		return fmt.Errorf("%w: credentials in URL", ErrMediaURLNotAllowed)
+	}
+
+	host := strings.ToLower(parsed.Hostname())
+	if p.isInternalHost(host) {
+		return nil
+	}
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") || strings.HasSuffix(host, ".internal") {
+		return fmt.Errorf("%w: host %s", ErrMediaURLNotAllowed, host)
+	}
+	if ip := net.ParseIP(host); ip != nil && !isPublicIP(ip) {
+		return fmt.Errorf("%w: private address %s", ErrMediaURLNotAllowed, host)
+	}
+	if !p.allowsHost(host) {
+		return fmt.Errorf("%w: host %s", ErrMediaURLNotAllowed, host)
+	}
+	return nil
+}
+
+// httpClient cria o cliente usado nos downloads: só conecta em IPs públicos (inclusive após a
+// resolução de DNS), salvo nos serviços internos configurados, ignora proxies do ambiente e revalida cada redirecionamento
+func (p *mediaURLPolicy) httpClient(timeout time.Duration) *http.Client {
+	internalDialer := &net.Dialer{Timeout: timeout}
+	dialer := &net.Dialer{
+		Timeout: timeout,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			ip := net.ParseIP(host)
+			if ip == nil || !isPublicIP(ip) {
+				return fmt.Errorf("%w: private address %s", ErrMediaURLNotAllowed, host)
+			}
+			return nil
+		},
+	}
+
+	return &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			Proxy: nil,
+			DialContext: func(ctx context.Context, network, address string) (net.Conn, error) {
+				if host, _, err := net.SplitHostPort(address); err == nil && p.isInternalHost(host) {
+					return internalDialer.DialContext(ctx, network, address)
+				}
+				return dialer.DialContext(ctx, network, address)
+			},
+			TLSHandshakeTimeout: timeout,
+		},
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= maxMediaRedirects {
+				return errors.New("too many redirects")
+			}
+			return p.validate(req.URL.String())
+		},
+	}
+}
+
+var (
+	mediaURLPolicyOnce   sync.Once
+	sharedMediaURLPolicy *mediaURLPolicy
+)
+
+// defaultMediaURLPolicy monta a lista do processo: MEDIA_ALLOWED_HOSTS (separado por vírgula) substitui os
+// domínios padrão do WhatsApp; os hosts do ZapPlus e do S3 configurados são sempre permitidos, inclusive na rede interna
+func defaultMediaURLPolicy() *mediaURLPolicy {
+	mediaURLPolicyOnce.Do(func() {
+		patterns := defaultMediaHostPatterns
+		if configured := strings.TrimSpace(os.Getenv("MEDIA_ALLOWED_HOSTS")); configured != "" {
+			patterns = strings.Split(configured, ",")
+		}
+		patterns = append(append([]string{}, patterns...), os.Getenv("S3_BUCKET"))
+		sharedMediaURLPolicy = newMediaURLPolicy(patterns).
+			withInternalHosts(zapplus.GetClient().BaseURL(), os.Getenv("S3_ENDPOINT"))
+		internalHosts := make([]string, 0, len(sharedMediaURLPolicy.internalHosts))
+		for host := range sharedMediaURLPolicy.internalHosts {
+			internalHosts = append(internalHosts, host)
+		}
+		log.Info().
+			Strs("allowed_hosts", sharedMediaURLPolicy.patterns).
+			Strs("internal_hosts", internalHosts).
+			Msg("🛡️ Hosts permitidos para download de mídia configurados")
+	})
+	return sharedMediaURLPolicy
+}
+
+// ValidateMediaURL confere se a URL de mídia pode ser baixada pelo servidor (lista de hosts permitidos e
+// bloqueio de redes internas). Retorna um erro que envolve ErrMediaURLNotAllowed quando recusada.
+func ValidateMediaURL(rawURL string) error {
+	return defaultMediaURLPolicy().validate(rawURL)
+}
+
+// NewMediaHTTPClient retorna o cliente HTTP protegido para baixar mídias das mensagens
+func NewMediaHTTPClient(timeout time.Duration) *http.Client {
+	return defaultMediaURLPolicy().httpClient(timeout)
+}
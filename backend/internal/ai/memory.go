@@ -22,6 +22,7 @@ type ConversationMemory struct {
 	ConversationHistory []openai.ChatCompletionMessage
 	LastUpdateTime      time.Time
 	SequentialNumber    int
+	Summary             string                 // Summary of older turns removed from ConversationHistory
 	TempData            map[string]interface{} // For storing temporary data like order lists
 }
 
@@ -186,6 +187,7 @@ func (m *MemoryManager) AddToConversationHistory(tenantID uuid.UUID, customerPho
 			CustomerPhone:       memory.CustomerPhone,
 			LastUpdateTime:      memory.LastUpdateTime,
 			SequentialNumber:    memory.SequentialNumber,
+			Summary:             memory.Summary,
 			ConversationHistory: make([]openai.ChatCompletionMessage, len(memory.ConversationHistory)),
 			ProductList:         make([]ProductReference, len(memory.ProductList)),
 		}
@@ -200,6 +202,53 @@ func (m *MemoryManager) AddToConversationHistory(tenantID uuid.UUID, customerPho
 	}
 }
 
+// GetConversationSummary returns the summary of older turns for a customer
+func (m *MemoryManager) GetConversationSummary(tenantID uuid.UUID, customerPhone string) string {
+	memory := m.GetOrCreateMemory(tenantID, customerPhone)
+
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	return memory.Summary
+}
+
+// CompactConversationHistory replaces older turns with a summary, keeping only the last keep messages
+func (m *MemoryManager) CompactConversationHistory(tenantID uuid.UUID, customerPhone string, summary string, keep int) {
+	memory := m.GetOrCreateMemory(tenantID, customerPhone)
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if len(memory.ConversationHistory) > keep {
+		recent := make([]openai.ChatCompletionMessage, keep)
+		copy(recent, memory.ConversationHistory[len(memory.ConversationHistory)-keep:])
+		memory.ConversationHistory = recent
+	}
+	memory.Summary = summary
+	memory.LastUpdateTime = time.Now()
+
+	log.Info().
+		Str("tenant_id", tenantID.String()).
+		Str("customer_phone", customerPhone).
+		Int("history_after", len(memory.ConversationHistory)).
+		Int("summary_length", len(summary)).
+		Msg("📝 Conversation history compacted into summary")
+
+	memoryCopy := &ConversationMemory{
+		TenantID:            memory.TenantID,
+		CustomerPhone:       memory.CustomerPhone,
+		LastUpdateTime:      memory.LastUpdateTime,
+		SequentialNumber:    memory.SequentialNumber,
+		Summary:             memory.Summary,
+		ConversationHistory: make([]openai.ChatCompletionMessage, len(memory.ConversationHistory)),
+		ProductList:         make([]ProductReference, len(memory.ProductList)),
+	}
+	copy(memoryCopy.ConversationHistory, memory.ConversationHistory)
+	copy(memoryCopy.ProductList, memory.ProductList)
+
+	go m.saveMemoryToDB(memoryCopy)
+}
+
 // StoreProductList stores a list of products with sequential numbering
 func (m *MemoryManager) StoreProductList(tenantID uuid.UUID, customerPhone string, products []models.Product) []ProductReference {
 	memory := m.GetOrCreateMemory(tenantID, customerPhone)
@@ -227,6 +276,13 @@ func (m *MemoryManager) StoreProductList(tenantID uuid.UUID, customerPhone strin
 		refs[i] = ref
 	}
 
+	// A nova lista passa a receber os números isolados enviados pelo cliente
+	if len(products) > 0 {
+		memory.TempData[awaitingSelectionKey] = string(awaitingProductSelection)
+	} else if memory.TempData[awaitingSelectionKey] == string(awaitingProductSelection) {
+		delete(memory.TempData, awaitingSelectionKey)
+	}
+
 	memory.LastUpdateTime = time.Now()
 
 	// Save to database asynchronously
@@ -258,6 +314,10 @@ func (m *MemoryManager) AppendProductList(tenantID uuid.UUID, customerPhone stri
 		newRefs[i] = ref
 	}
 
+	if len(products) > 0 {
+		memory.TempData[awaitingSelectionKey] = string(awaitingProductSelection)
+	}
+
 	memory.LastUpdateTime = time.Now()
 
 	// Save to database asynchronously
@@ -319,6 +379,7 @@ func (m *MemoryManager) loadMemoriesFromDB() {
 			ConversationHistory: history,
 			LastUpdateTime:      dbMem.UpdatedAt,
 			SequentialNumber:    dbMem.SequentialNumber,
+			Summary:             dbMem.Summary,
 		}
 
 		m.memories[key] = memory
@@ -376,6 +437,7 @@ func (m *MemoryManager) saveMemoryToDB(memory *ConversationMemory) {
 		ProductList:         productList,
 		ConversationHistory: history,
 		SequentialNumber:    memory.SequentialNumber,
+		Summary:             memory.Summary,
 	}
 
 	// Use UPSERT - primeiro tenta atualizar, se não existe, cria
@@ -392,6 +454,7 @@ func (m *MemoryManager) saveMemoryToDB(memory *ConversationMemory) {
 			"product_list":         dbMemory.ProductList,
 			"conversation_history": dbMemory.ConversationHistory,
 			"sequential_number":    dbMemory.SequentialNumber,
+			"summary":              dbMemory.Summary,
 			"updated_at":           time.Now(),
 		}).Error
 	}
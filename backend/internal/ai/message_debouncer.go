@@ -0,0 +1,177 @@
+package ai
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// MessageDebounceSettingKey é a configuração do tenant com a janela de debounce em milissegundos (0 = desativado)
+	MessageDebounceSettingKey = "ai_message_debounce_ms"
+
+	// maxMessageDebounceWindow limita a janela para não atrasar demais a resposta ao cliente
+	maxMessageDebounceWindow = 10 * time.Second
+)
+
+// messageTerminators são frases de fechamento: encerram o debounce quando são a mensagem inteira ou as últimas
+// palavras dela ("2 caixas, só isso"). Pontuação não encerra: o cliente costuma continuar na mensagem seguinte, e
+// o resto fica com a janela do debounce.
+var messageTerminators = []string{
+	"só isso",
+	"so isso",
+	"é isso",
+	"era isso",
+	"é só isso",
+	"é so isso",
+}
+
+// messageTerminatorWords encerram o debounce só quando são a mensagem inteira ("pronto", mas não "pronto socorro")
+var messageTerminatorWords = []string{
+	"pronto",
+	"fim",
+}
+
+// pendingMessages agrupa as mensagens de um mesmo cliente aguardando processamento
+type pendingMessages struct {
+	parts []string
+	timer *time.Timer
+	flush func(combined string)
+}
+
+// MessageDebouncer agrupa mensagens rápidas e sucessivas do mesmo telefone
+// e entrega um único texto combinado quando a janela expira ou o cliente sinaliza que terminou.
+type MessageDebouncer struct {
+	mu      sync.Mutex
+	pending map[string]*pendingMessages
+}
+
+// NewMessageDebouncer cria um novo debouncer de mensagens
+func NewMessageDebouncer() *MessageDebouncer {
+	return &MessageDebouncer{
+		pending: make(map[string]*pendingMessages),
+	}
+}
+
+// Add adiciona uma mensagem ao buffer do telefone. O flush é chamado uma única vez
+// com todas as mensagens acumuladas; o callback registrado por último é o utilizado.
+// Em um flush antecipado (terminador ou janela zero) o callback roda de forma síncrona.
+func (d *MessageDebouncer) Add(tenantID uuid.UUID, phone, text string, window time.Duration, flush func(combined string)) {
+	key := tenantID.String() + ":" + phone
+
+	d.mu.Lock()
+	entry, exists := d.pending[key]
+	if !exists {
+		entry = &pendingMessages{}
+		d.pending[key] = entry
+	}
+
+	if trimmed := strings.TrimSpace(text); trimmed != "" {
+		entry.parts = append(entry.parts, trimmed)
+	}
+	entry.flush = flush
+
+	if entry.timer != nil {
+		entry.timer.Stop()
+	}
+
+	if window <= 0 || isMessageTerminator(text) {
+		d.mu.Unlock()
+		d.Flush(tenantID, phone)
+		return
+	}
+
+	entry.timer = time.AfterFunc(window, func() {
+		d.Flush(tenantID, phone)
+	})
+	d.mu.Unlock()
+}
+
+// Flush processa imediatamente as mensagens pendentes do telefone, se houver
+func (d *MessageDebouncer) Flush(tenantID uuid.UUID, phone string) {
+	key := tenantID.String() + ":" + phone
+
+	d.mu.Lock()
+	entry, exists := d.pending[key]
+	if !exists {
+		d.mu.Unlock()
+		return
+	}
+	delete(d.pending, key)
+	if entry.timer != nil {
+		entry.timer.Stop()
+	}
+	d.mu.Unlock()
+
+	if len(entry.parts) == 0 || entry.flush == nil {
+		return
+	}
+
+	entry.flush(strings.Join(entry.parts, "\n"))
+}
+
+// PendingCount retorna quantas mensagens estão aguardando para o telefone
+func (d *MessageDebouncer) PendingCount(tenantID uuid.UUID, phone string) int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if entry, exists := d.pending[tenantID.String()+":"+phone]; exists {
+		return len(entry.parts)
+	}
+	return 0
+}
+
+// isMessageTerminator verifica se a mensagem indica que o cliente terminou o pensamento
+func isMessageTerminator(text string) bool {
+	normalized := strings.TrimRight(strings.ToLower(strings.TrimSpace(text)), " .!?")
+	if normalized == "" {
+		return false
+	}
+
+	for _, word := range messageTerminatorWords {
+		if normalized == word {
+			return true
+		}
+	}
+
+	words := strings.FieldsFunc(normalized, func(r rune) bool {
+		return unicode.IsSpace(r) || r == ',' || r == ';'
+	})
+	for _, terminator := range messageTerminators {
+		terminatorWords := strings.Fields(terminator)
+		if len(words) >= len(terminatorWords) && strings.Join(words[len(words)-len(terminatorWords):], " ") == terminator {
+			return true
+		}
+	}
+
+	return false
+}
+
+// GetMessageDebounceWindow retorna a janela de debounce configurada para o tenant (0 = desativado)
+func (s *TenantSettingsService) GetMessageDebounceWindow(ctx context.Context, tenantID uuid.UUID) time.Duration {
+	setting, err := s.GetSetting(ctx, tenantID, MessageDebounceSettingKey)
+	if err != nil || setting == nil || setting.SettingValue == nil {
+		return 0
+	}
+
+	return parseMessageDebounceWindow(*setting.SettingValue)
+}
+
+// parseMessageDebounceWindow converte o valor em milissegundos da configuração em duração
+func parseMessageDebounceWindow(value string) time.Duration {
+	ms, err := strconv.Atoi(strings.TrimSpace(value))
+	if err != nil || ms <= 0 {
+		return 0
+	}
+
+	window := time.Duration(ms) * time.Millisecond
+	if window > maxMessageDebounceWindow {
+		return maxMessageDebounceWindow
+	}
+	return window
+}
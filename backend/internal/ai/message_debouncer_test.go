@@ -0,0 +1,162 @@
+package ai
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestIsMessageTerminator(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected bool
+	}{
+		{"", false},
+		{"quero", false},
+		{"dipirona", false},
+		{"2 caixas", false},
+		{"2 caixas.", false},
+		{"vocês têm dipirona?", false},
+		{"obrigado!", false},
+		{"quero dipirona e isso", false},
+		{"só isso", true},
+		{"Pronto", true},
+		{"pronto!", true},
+		{"2 caixas, é isso", true},
+		{"2 caixas, só isso.", true},
+		{"prontos para entrega", false},
+		{"pronto socorro fica onde", false},
+		{"fim de semana vocês abrem", false},
+	}
+
+	for _, test := range tests {
+		result := isMessageTerminator(test.input)
+		if result != test.expected {
+			t.Errorf("isMessageTerminator(%q) = %t, expected %t", test.input, result, test.expected)
+		}
+	}
+}
+
+func TestParseMessageDebounceWindow(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected time.Duration
+	}{
+		{"", 0},
+		{"0", 0},
+		{"-100", 0},
+		{"abc", 0},
+		{"3000", 3 * time.Second},
+		{" 2500 ", 2500 * time.Millisecond},
+		{"60000", maxMessageDebounceWindow},
+	}
+
+	for _, test := range tests {
+		result := parseMessageDebounceWindow(test.input)
+		if result != test.expected {
+			t.Errorf("parseMessageDebounceWindow(%q) = %s, expected %s", test.input, result, test.expected)
+		}
+	}
+}
+
+func TestMessageDebouncerCombinesMessagesAfterWindow(t *testing.T) {
+	debouncer := NewMessageDebouncer()
+	tenantID := uuid.New()
+	results := make(chan string, 2)
+	flush := func(combined string) { results <- combined }
+
+	debouncer.Add(tenantID, "5527999999999", "quero", 50*time.Millisecond, flush)
+	debouncer.Add(tenantID, "5527999999999", "dipirona", 50*time.Millisecond, flush)
+	debouncer.Add(tenantID, "5527999999999", "2 caixas", 50*time.Millisecond, flush)
+
+	if count := debouncer.PendingCount(tenantID, "5527999999999"); count != 3 {
+		t.Errorf("PendingCount = %d, expected 3", count)
+	}
+
+	select {
+	case combined := <-results:
+		if combined != "quero\ndipirona\n2 caixas" {
+			t.Errorf("combined = %q, expected %q", combined, "quero\ndipirona\n2 caixas")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("debouncer did not flush after the window expired")
+	}
+
+	select {
+	case extra := <-results:
+		t.Errorf("unexpected second flush: %q", extra)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestMessageDebouncerFlushesEarlyOnTerminator(t *testing.T) {
+	debouncer := NewMessageDebouncer()
+	tenantID := uuid.New()
+	var got []string
+	flush := func(combined string) { got = append(got, combined) }
+
+	debouncer.Add(tenantID, "5527999999999", "quero", time.Hour, flush)
+	if len(got) != 0 {
+		t.Fatalf("flush called before terminator: %v", got)
+	}
+
+	debouncer.Add(tenantID, "5527999999999", "2 caixas de dipirona, só isso", time.Hour, flush)
+	if len(got) != 1 || got[0] != "quero\n2 caixas de dipirona, só isso" {
+		t.Errorf("got %v, expected a single combined flush", got)
+	}
+
+	if count := debouncer.PendingCount(tenantID, "5527999999999"); count != 0 {
+		t.Errorf("PendingCount after flush = %d, expected 0", count)
+	}
+}
+
+func TestMessageDebouncerKeepsPhonesAndTenantsSeparate(t *testing.T) {
+	debouncer := NewMessageDebouncer()
+	tenantA := uuid.New()
+	tenantB := uuid.New()
+
+	var mu sync.Mutex
+	got := make(map[string]string)
+	flushFor := func(label string) func(string) {
+		return func(combined string) {
+			mu.Lock()
+			defer mu.Unlock()
+			got[label] = combined
+		}
+	}
+
+	debouncer.Add(tenantA, "111", "oi", time.Hour, flushFor("a-111"))
+	debouncer.Add(tenantA, "222", "olá", time.Hour, flushFor("a-222"))
+	debouncer.Add(tenantB, "111", "bom dia", time.Hour, flushFor("b-111"))
+
+	debouncer.Flush(tenantA, "111")
+	debouncer.Flush(tenantB, "111")
+	debouncer.Flush(tenantA, "222")
+
+	expected := map[string]string{"a-111": "oi", "a-222": "olá", "b-111": "bom dia"}
+	for label, text := range expected {
+		if got[label] != text {
+			t.Errorf("flush %s = %q, expected %q", label, got[label], text)
+		}
+	}
+}
+
+func TestMessageDebouncerZeroWindowFlushesImmediately(t *testing.T) {
+	debouncer := NewMessageDebouncer()
+	tenantID := uuid.New()
+	var got []string
+
+	debouncer.Add(tenantID, "111", "quero", 0, func(combined string) { got = append(got, combined) })
+
+	if len(got) != 1 || got[0] != "quero" {
+		t.Errorf("got %v, expected immediate flush of %q", got, "quero")
+	}
+
+	// Flush sem mensagens pendentes não deve fazer nada
+	debouncer.Flush(tenantID, "111")
+	if len(got) != 1 {
+		t.Errorf("unexpected flush without pending messages: %v", got)
+	}
+}
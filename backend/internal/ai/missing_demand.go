@@ -0,0 +1,130 @@
+package ai
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"iafarma/pkg/models"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// MissingProductDemandSettingKey define o que fazer quando o cliente pede um produto que a loja não tem:
+	// "off" (só informa), "log" (registra a demanda) ou "notify" (registra e oferece aviso de reposição)
+	MissingProductDemandSettingKey = "ai_missing_product_demand"
+
+	MissingProductDemandOff    = "off"
+	MissingProductDemandLog    = "log"
+	MissingProductDemandNotify = "notify"
+
+	// maxMissingDemandQueryLength respeita o tamanho da coluna e descarta frases longas demais
+	maxMissingDemandQueryLength = 255
+)
+
+// getMissingProductDemandMode retorna o comportamento configurado pelo tenant (desativado por padrão)
+func (s *AIService) getMissingProductDemandMode(ctx context.Context, tenantID uuid.UUID) string {
+	if s.settingsService == nil {
+		return MissingProductDemandOff
+	}
+
+	setting, err := s.settingsService.GetSetting(ctx, tenantID, MissingProductDemandSettingKey)
+	if err != nil || setting == nil || setting.SettingValue == nil {
+		return MissingProductDemandOff
+	}
+
+	switch mode := strings.ToLower(strings.TrimSpace(*setting.SettingValue)); mode {
+	case MissingProductDemandLog, MissingProductDemandNotify:
+		return mode
+	}
+	return MissingProductDemandOff
+}
+
+// normalizeDemandQuery agrupa variações do mesmo pedido ("Dipirona ", "dipirona") no relatório
+func normalizeDemandQuery(query string) string {
+	return strings.Join(strings.Fields(accentReplacer.Replace(strings.ToLower(query))), " ")
+}
+
+// newMissingDemand monta o registro de demanda da conversa atual; retorna nil se o termo não serve para o relatório
+func (s *AIService) newMissingDemand(tenantID, customerID uuid.UUID, customerPhone, query string) *models.MissingProductDemand {
+	query = strings.TrimSpace(query)
+	normalized := normalizeDemandQuery(query)
+	if normalized == "" || len(query) > maxMissingDemandQueryLength {
+		return nil
+	}
+
+	demand := &models.MissingProductDemand{
+		BaseTenantModel: models.BaseTenantModel{TenantID: tenantID},
+		CustomerID:      customerID,
+		Query:           query,
+		NormalizedQuery: normalized,
+	}
+	if conversationID := s.getConversationID(tenantID, customerPhone); conversationID != uuid.Nil {
+		demand.ConversationID = &conversationID
+	}
+	return demand
+}
+
+// recordMissingDemand registra a busca sem resultado para análise de demanda e retorna o
+// complemento da resposta (oferta de aviso de reposição) quando o tenant habilitou
+func (s *AIService) recordMissingDemand(ctx context.Context, tenantID, customerID uuid.UUID, customerPhone, query string) string {
+	if s.missingDemandService == nil {
+		return ""
+	}
+
+	mode := s.getMissingProductDemandMode(ctx, tenantID)
+	if mode == MissingProductDemandOff {
+		return ""
+	}
+
+	demand := s.newMissingDemand(tenantID, customerID, customerPhone, query)
+	if demand == nil {
+		return ""
+	}
+
+	if err := s.missingDemandService.RecordMissingDemand(demand); err != nil {
+		log.Warn().Err(err).Str("tenant_id", tenantID.String()).Str("query", query).Msg("⚠️ Não foi possível registrar a demanda por produto em falta")
+		return ""
+	}
+
+	log.Info().
+		Str("tenant_id", tenantID.String()).
+		Str("query", demand.NormalizedQuery).
+		Msg("📉 Demanda por produto em falta registrada")
+
+	if mode != MissingProductDemandNotify {
+		return ""
+	}
+	return fmt.Sprintf("\n\n🔔 Quer que eu te avise quando *%s* chegar? É só responder \"me avise\".", demand.Query)
+}
+
+// handleAvisarQuandoChegar registra o interesse do cliente em ser avisado da reposição de um produto em falta
+func (s *AIService) handleAvisarQuandoChegar(ctx context.Context, tenantID, customerID uuid.UUID, customerPhone string, args map[string]interface{}) (string, error) {
+	produto, _ := args["produto"].(string)
+	if strings.TrimSpace(produto) == "" {
+		return "❌ Qual produto você quer que eu avise quando chegar?", nil
+	}
+
+	if s.missingDemandService == nil || s.getMissingProductDemandMode(ctx, tenantID) != MissingProductDemandNotify {
+		return "😕 No momento não consigo registrar avisos de reposição. Você pode perguntar novamente em outro dia ou digitar 'produtos' para ver o que temos disponível.", nil
+	}
+
+	demand := s.newMissingDemand(tenantID, customerID, customerPhone, produto)
+	if demand == nil {
+		return "❌ Qual produto você quer que eu avise quando chegar?", nil
+	}
+
+	if err := s.missingDemandService.RequestRestockNotification(demand); err != nil {
+		return "❌ Não consegui registrar o aviso agora. Tente novamente em instantes.", err
+	}
+
+	log.Info().
+		Str("tenant_id", tenantID.String()).
+		Str("customer_id", customerID.String()).
+		Str("query", demand.NormalizedQuery).
+		Msg("🔔 Cliente pediu aviso de reposição")
+
+	return fmt.Sprintf("✅ Combinado! Vou te avisar por aqui quando *%s* chegar. 🔔\n\nPosso ajudar com mais alguma coisa?", demand.Query), nil
+}
@@ -0,0 +1,139 @@
+package ai
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"iafarma/pkg/models"
+
+	"github.com/google/uuid"
+)
+
+// fakeMissingDemandService guarda as demandas registradas e os pedidos de aviso
+type fakeMissingDemandService struct {
+	recorded []*models.MissingProductDemand
+	notify   []*models.MissingProductDemand
+	special  []*models.MissingProductDemand
+}
+
+func (f *fakeMissingDemandService) RecordMissingDemand(demand *models.MissingProductDemand) error {
+	f.recorded = append(f.recorded, demand)
+	return nil
+}
+
+func (f *fakeMissingDemandService) RequestRestockNotification(demand *models.MissingProductDemand) error {
+	f.notify = append(f.notify, demand)
+	return nil
+}
+
+func (f *fakeMissingDemandService) RecordSpecialOrder(demand *models.MissingProductDemand) error {
+	f.special = append(f.special, demand)
+	return nil
+}
+
+// withMissingDemands liga o catálogo vazio e o registro de procuras sem resultado
+func withMissingDemands(demands *fakeMissingDemandService) testServiceOption {
+	return withOptions(withProducts(), withOverride(func(s *AIService) { s.missingDemandService = demands }))
+}
+
+func TestNormalizeDemandQuery(t *testing.T) {
+	tests := []struct {
+		query    string
+		esperado string
+	}{
+		{"Dipirona", "dipirona"},
+		{"  Protetor   Solar  FPS 50 ", "protetor solar fps 50"},
+		{"Pomada Cicatrizante Açaí", "pomada cicatrizante acai"},
+		{"   ", ""},
+	}
+
+	for _, tt := range tests {
+		if got := normalizeDemandQuery(tt.query); got != tt.esperado {
+			t.Errorf("%q: esperado %q, obtido %q", tt.query, tt.esperado, got)
+		}
+	}
+}
+
+func TestConsultarItensSemResultadoRegistraDemanda(t *testing.T) {
+	tests := []struct {
+		mode         string
+		recorded     bool
+		offersNotify bool
+	}{
+		{"", false, false},
+		{MissingProductDemandOff, false, false},
+		{MissingProductDemandLog, true, false},
+		{MissingProductDemandNotify, true, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.mode, func(t *testing.T) {
+			tenantID, customerID := uuid.New(), uuid.New()
+			demands := &fakeMissingDemandService{}
+			s, _ := newTestService(optionalSettings(MissingProductDemandSettingKey, tt.mode), withMissingDemands(demands))
+
+			response, err := s.handleConsultarItens(context.Background(), tenantID, customerID, "5527999999999", map[string]interface{}{"query": "Ozempic"})
+			if err != nil {
+				t.Fatalf("erro inesperado: %v", err)
+			}
+			if !strings.Contains(response, "Nenhum produto encontrado") {
+				t.Errorf("busca sem resultado deveria continuar informando o cliente, obtido:\n%s", response)
+			}
+
+			if got := len(demands.recorded) == 1; got != tt.recorded {
+				t.Fatalf("esperado registro %v, obtido %d registros", tt.recorded, len(demands.recorded))
+			}
+			if tt.recorded {
+				demand := demands.recorded[0]
+				if demand.TenantID != tenantID || demand.CustomerID != customerID || demand.Query != "Ozempic" || demand.NormalizedQuery != "ozempic" {
+					t.Errorf("demanda registrada incorreta: %+v", demand)
+				}
+			}
+
+			if got := strings.Contains(response, "Quer que eu te avise"); got != tt.offersNotify {
+				t.Errorf("esperado oferta de aviso %v, obtido:\n%s", tt.offersNotify, response)
+			}
+		})
+	}
+}
+
+func TestConsultarItensComFiltroDePrecoNaoRegistraDemanda(t *testing.T) {
+	demands := &fakeMissingDemandService{}
+	s, _ := newTestService(map[string]string{MissingProductDemandSettingKey: MissingProductDemandLog}, withMissingDemands(demands))
+
+	_, err := s.handleConsultarItens(context.Background(), uuid.New(), uuid.New(), "5527999999999", map[string]interface{}{"query": "dipirona", "preco_max": float64(2)})
+	if err != nil {
+		t.Fatalf("erro inesperado: %v", err)
+	}
+	if len(demands.recorded) != 0 {
+		t.Errorf("filtro de preço sem resultado não indica produto em falta, obtido %+v", demands.recorded)
+	}
+}
+
+func TestAvisarQuandoChegar(t *testing.T) {
+	tenantID, customerID := uuid.New(), uuid.New()
+
+	demands := &fakeMissingDemandService{}
+	s, _ := newTestService(map[string]string{MissingProductDemandSettingKey: MissingProductDemandNotify}, withMissingDemands(demands))
+	response, err := s.handleAvisarQuandoChegar(context.Background(), tenantID, customerID, "5527999999999", map[string]interface{}{"produto": "Ozempic"})
+	if err != nil {
+		t.Fatalf("erro inesperado: %v", err)
+	}
+	if len(demands.notify) != 1 || demands.notify[0].NormalizedQuery != "ozempic" || demands.notify[0].CustomerID != customerID {
+		t.Fatalf("pedido de aviso não registrado corretamente: %+v", demands.notify)
+	}
+	if !strings.Contains(response, "Ozempic") {
+		t.Errorf("confirmação deveria citar o produto, obtido:\n%s", response)
+	}
+
+	// Sem o modo notify o interesse não é capturado
+	demands = &fakeMissingDemandService{}
+	s, _ = newTestService(map[string]string{MissingProductDemandSettingKey: MissingProductDemandLog}, withMissingDemands(demands))
+	if _, err := s.handleAvisarQuandoChegar(context.Background(), tenantID, customerID, "5527999999999", map[string]interface{}{"produto": "Ozempic"}); err != nil {
+		t.Fatalf("erro inesperado: %v", err)
+	}
+	if len(demands.notify) != 0 {
+		t.Errorf("aviso não deveria ser registrado fora do modo notify, obtido %+v", demands.notify)
+	}
+}
@@ -0,0 +1,84 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+	"github.com/sashabaranov/go-openai"
+)
+
+const (
+	// AIModelSettingKey define o modelo usado nas conversas com o cliente
+	AIModelSettingKey = "ai_model"
+	// AIFallbackModelSettingKey define o modelo usado quando o principal está indisponível (ex: descontinuado);
+	// vazio desativa a troca
+	AIFallbackModelSettingKey = "ai_fallback_model"
+
+	defaultChatModel         = openai.GPT4oMini
+	defaultChatFallbackModel = openai.GPT4o
+)
+
+// chatModels retorna o modelo principal e o reserva configurados para o tenant
+func (s *AIService) chatModels(ctx context.Context, tenantID uuid.UUID) (primary, fallback string) {
+	primary, fallback = defaultChatModel, defaultChatFallbackModel
+	if s.settingsService == nil {
+		return primary, fallback
+	}
+
+	if setting, err := s.settingsService.GetSetting(ctx, tenantID, AIModelSettingKey); err == nil && setting != nil && setting.SettingValue != nil {
+		if value := strings.TrimSpace(*setting.SettingValue); value != "" {
+			primary = value
+		}
+	}
+	if setting, err := s.settingsService.GetSetting(ctx, tenantID, AIFallbackModelSettingKey); err == nil && setting != nil && setting.SettingValue != nil {
+		fallback = strings.TrimSpace(*setting.SettingValue)
+	}
+	return primary, fallback
+}
+
+// isModelUnavailableError identifica erros de modelo inexistente, descontinuado ou sem acesso; outros erros
+// (limite de taxa, falha de rede) não justificam trocar de modelo
+func isModelUnavailableError(err error) bool {
+	var apiErr *openai.APIError
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+	if code, ok := apiErr.Code.(string); ok && (code == "model_not_found" || code == "model_deprecated") {
+		return true
+	}
+
+	message := strings.ToLower(apiErr.Message)
+	if !strings.Contains(message, "model") {
+		return false
+	}
+	return apiErr.HTTPStatusCode == http.StatusNotFound ||
+		strings.Contains(message, "does not exist") ||
+		strings.Contains(message, "deprecated") ||
+		strings.Contains(message, "decommissioned")
+}
+
+// createChatCompletionWithFallback chama a OpenAI com o modelo do tenant e, se ele estiver indisponível, repete
+// uma única vez com o modelo reserva, registrando a troca
+func (s *AIService) createChatCompletionWithFallback(ctx context.Context, tenantID uuid.UUID, req openai.ChatCompletionRequest) (openai.ChatCompletionResponse, error) {
+	primary, fallback := s.chatModels(ctx, tenantID)
+	req.Model = primary
+
+	resp, err := s.client.CreateChatCompletion(ctx, req)
+	if err == nil || fallback == "" || fallback == primary || !isModelUnavailableError(err) {
+		return resp, err
+	}
+
+	log.Warn().
+		Err(err).
+		Str("tenant_id", tenantID.String()).
+		Str("model", primary).
+		Str("fallback_model", fallback).
+		Msg("⚠️ Modelo de IA indisponível, usando o modelo reserva")
+
+	req.Model = fallback
+	return s.client.CreateChatCompletion(ctx, req)
+}
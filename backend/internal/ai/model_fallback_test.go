@@ -0,0 +1,106 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/sashabaranov/go-openai"
+)
+
+// newRetiredModelClient simula a OpenAI com um modelo descontinuado: pedidos para ele recebem model_not_found
+func newRetiredModelClient(t *testing.T, retiredModel string) (*openai.Client, *[]string) {
+	t.Helper()
+	var requested []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req openai.ChatCompletionRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		requested = append(requested, req.Model)
+
+		w.Header().Set("Content-Type", "application/json")
+		if req.Model == retiredModel {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"error":{"message":"The model '` + retiredModel + `' does not exist or you do not have access to it.","type":"invalid_request_error","code":"model_not_found"}}`))
+			return
+		}
+		json.NewEncoder(w).Encode(openai.ChatCompletionResponse{
+			Model: req.Model,
+			Choices: []openai.ChatCompletionChoice{{
+				Message: openai.ChatCompletionMessage{Role: openai.ChatMessageRoleAssistant, Content: "Olá! Como posso ajudar?"},
+			}},
+		})
+	}))
+	t.Cleanup(server.Close)
+
+	config := openai.DefaultConfig("test")
+	config.BaseURL = server.URL + "/v1"
+	return openai.NewClientWithConfig(config), &requested
+}
+
+func TestCreateChatCompletionFallsBackWhenModelNotFound(t *testing.T) {
+	tenantID := uuid.New()
+	client, requested := newRetiredModelClient(t, "gpt-retired")
+	s := &AIService{
+		client: client,
+		settingsService: &fakeSettingsService{values: map[string]string{
+			AIModelSettingKey:         "gpt-retired",
+			AIFallbackModelSettingKey: "gpt-4o-mini",
+		}},
+	}
+
+	resp, err := s.createChatCompletionWithFallback(context.Background(), tenantID, openai.ChatCompletionRequest{
+		Messages: []openai.ChatCompletionMessage{{Role: openai.ChatMessageRoleUser, Content: "oi"}},
+	})
+	if err != nil {
+		t.Fatalf("modelo reserva deveria atender a requisição, erro: %v", err)
+	}
+	if resp.Model != "gpt-4o-mini" || resp.Choices[0].Message.Content == "" {
+		t.Errorf("esperada resposta do modelo reserva, obtido %+v", resp)
+	}
+	if len(*requested) != 2 || (*requested)[0] != "gpt-retired" || (*requested)[1] != "gpt-4o-mini" {
+		t.Errorf("esperada uma tentativa com o principal e uma com o reserva, obtido %v", *requested)
+	}
+}
+
+func TestCreateChatCompletionWithoutFallback(t *testing.T) {
+	tenantID := uuid.New()
+	client, requested := newRetiredModelClient(t, "gpt-retired")
+	s := &AIService{
+		client: client,
+		settingsService: &fakeSettingsService{values: map[string]string{
+			AIModelSettingKey:         "gpt-retired",
+			AIFallbackModelSettingKey: "",
+		}},
+	}
+
+	_, err := s.createChatCompletionWithFallback(context.Background(), tenantID, openai.ChatCompletionRequest{})
+	if !isModelUnavailableError(err) {
+		t.Errorf("sem modelo reserva o erro de modelo indisponível deveria ser devolvido, obtido %v", err)
+	}
+	if len(*requested) != 1 {
+		t.Errorf("esperada uma única tentativa, obtido %v", *requested)
+	}
+}
+
+func TestIsModelUnavailableError(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		esperado bool
+	}{
+		{"modelo inexistente", &openai.APIError{Code: "model_not_found", HTTPStatusCode: 404, Message: "The model does not exist"}, true},
+		{"modelo descontinuado", &openai.APIError{HTTPStatusCode: 400, Message: "The model gpt-4-0314 has been deprecated"}, true},
+		{"limite de taxa", &openai.APIError{Code: "rate_limit_exceeded", HTTPStatusCode: 429, Message: "Rate limit reached"}, false},
+		{"erro de rede", context.DeadlineExceeded, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isModelUnavailableError(tt.err); got != tt.esperado {
+				t.Errorf("esperado %v, obtido %v", tt.esperado, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,138 @@
+package ai
+
+import (
+	"context"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+)
+
+// NumericReplyRoutingSettingKey habilita o encaminhamento direto de respostas que são só um número ("2"),
+// conforme a lista que o cliente está vendo, sem depender da interpretação do GPT
+const NumericReplyRoutingSettingKey = "ai_numeric_reply_routing"
+
+// awaitingSelectionKey guarda na memória da conversa qual lista numerada foi exibida por último
+const awaitingSelectionKey = "awaiting_selection"
+
+// selectionState indica o que um número isolado enviado pelo cliente escolhe
+type selectionState string
+
+const (
+	awaitingProductSelection  selectionState = "awaiting_product_selection"
+	awaitingAddressSelection  selectionState = "awaiting_address_selection"
+	awaitingPaymentSelection  selectionState = "awaiting_payment_selection"
+	awaitingQuantitySelection selectionState = "awaiting_quantity_selection"
+)
+
+// bareNumberPattern reconhece respostas que são apenas um número ("2", "2.", "nº 2", "opção 2", "#2")
+var bareNumberPattern = regexp.MustCompile(`(?i)^\s*(?:n[º°o]\.?\s*|#|op[çc][ãa]o\s*|n[úu]mero\s*)?(\d{1,3})\s*[.)!]?\s*$`)
+
+// parseBareNumber extrai o número de uma resposta que contém só o número
+func parseBareNumber(message string) (int, bool) {
+	match := bareNumberPattern.FindStringSubmatch(message)
+	if match == nil {
+		return 0, false
+	}
+	number, err := strconv.Atoi(match[1])
+	if err != nil || number < 1 {
+		return 0, false
+	}
+	return number, true
+}
+
+// numericReplyTool decide qual ferramenta um número isolado aciona no estado atual da conversa
+func numericReplyTool(state selectionState, number int) (string, map[string]interface{}, bool) {
+	switch state {
+	case awaitingProductSelection:
+		return "adicionarPorNumero", map[string]interface{}{"numero": float64(number)}, true
+	case awaitingAddressSelection:
+		return "gerenciarEnderecos", map[string]interface{}{"acao": "selecionar", "numero_endereco": float64(number)}, true
+	case awaitingPaymentSelection:
+		return "selecionarFormaPagamento", map[string]interface{}{"numero_opcao": float64(number)}, true
+	case awaitingQuantitySelection:
+		return "adicionarItemDetalhado", map[string]interface{}{"quantidade": float64(number)}, true
+	}
+	return "", nil, false
+}
+
+// setAwaitingSelection registra a lista numerada que o cliente acabou de receber
+func (s *AIService) setAwaitingSelection(tenantID uuid.UUID, customerPhone string, state selectionState) {
+	if s.memoryManager == nil {
+		return
+	}
+	s.memoryManager.StoreTempData(tenantID, customerPhone, map[string]interface{}{
+		awaitingSelectionKey: string(state),
+	})
+}
+
+// awaitingSelection retorna o estado de seleção atual da conversa (vazio se nenhuma lista está aberta)
+func (s *AIService) awaitingSelection(tenantID uuid.UUID, customerPhone string) selectionState {
+	if s.memoryManager == nil {
+		return ""
+	}
+	value, found := s.memoryManager.GetTempData(tenantID, customerPhone, awaitingSelectionKey)
+	if !found {
+		return ""
+	}
+	state, _ := value.(string)
+	return selectionState(state)
+}
+
+// isNumericReplyRoutingEnabled indica se o tenant usa o encaminhamento direto de números (habilitado por padrão)
+func (s *AIService) isNumericReplyRoutingEnabled(ctx context.Context, tenantID uuid.UUID) bool {
+	if s.settingsService == nil {
+		return true
+	}
+	setting, err := s.settingsService.GetSetting(ctx, tenantID, NumericReplyRoutingSettingKey)
+	if err != nil || setting == nil || setting.SettingValue == nil {
+		return true
+	}
+	return !strings.EqualFold(strings.TrimSpace(*setting.SettingValue), "false")
+}
+
+// routeNumericReply trata um número isolado de forma determinística conforme a lista exibida por último.
+// Retorna handled = false quando a mensagem não é só um número ou não há lista aberta (segue para o GPT).
+func (s *AIService) routeNumericReply(ctx context.Context, tenantID, customerID uuid.UUID, customerPhone, message string) (string, bool) {
+	number, ok := parseBareNumber(message)
+	if !ok || !s.isNumericReplyRoutingEnabled(ctx, tenantID) {
+		return "", false
+	}
+
+	state := s.awaitingSelection(tenantID, customerPhone)
+	toolName, args, ok := numericReplyTool(state, number)
+	if !ok {
+		return "", false
+	}
+
+	log.Info().
+		Str("tenant_id", tenantID.String()).
+		Str("state", string(state)).
+		Int("number", number).
+		Str("tool_name", toolName).
+		Msg("🔢 Número isolado encaminhado pelo estado da conversa")
+
+	// Endereço, pagamento e quantidade são escolhas únicas. A lista de produtos continua valendo para novos itens,
+	// inclusive depois de informar a quantidade de um produto detalhado a partir dela.
+	switch state {
+	case awaitingAddressSelection, awaitingPaymentSelection:
+		s.setAwaitingSelection(tenantID, customerPhone, "")
+	case awaitingQuantitySelection:
+		next := selectionState("")
+		if len(s.memoryManager.GetCurrentProductList(tenantID, customerPhone)) > 0 {
+			next = awaitingProductSelection
+		}
+		s.setAwaitingSelection(tenantID, customerPhone, next)
+	}
+
+	result, err := s.executeTool(ctx, tenantID, customerID, customerPhone, toolName, args)
+	if err != nil {
+		log.Error().Err(err).Str("tool_name", toolName).Msg("Erro ao tratar número isolado")
+		if result == "" {
+			return "", false
+		}
+	}
+	return result, true
+}
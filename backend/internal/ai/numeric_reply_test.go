@@ -0,0 +1,168 @@
+package ai
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"iafarma/pkg/models"
+
+	"github.com/google/uuid"
+)
+
+// paymentCartService registra a forma de pagamento escolhida além dos itens adicionados
+type paymentCartService struct {
+	addingCartService
+	paymentMethodID uuid.UUID
+}
+
+func (f *paymentCartService) UpdateCartPaymentMethod(cartID, tenantID, paymentMethodID uuid.UUID) error {
+	f.paymentMethodID = paymentMethodID
+	return nil
+}
+
+// paymentOrderService expõe as formas de pagamento do tenant
+type paymentOrderService struct {
+	fakeOrderService
+	options []PaymentOption
+}
+
+func (f *paymentOrderService) GetPaymentOptions(tenantID uuid.UUID) ([]PaymentOption, error) {
+	return f.options, nil
+}
+
+func TestParseBareNumber(t *testing.T) {
+	tests := []struct {
+		message  string
+		esperado int
+		ok       bool
+	}{
+		{"2", 2, true},
+		{" 2. ", 2, true},
+		{"nº 3", 3, true},
+		{"opção 1", 1, true},
+		{"#12", 12, true},
+		{"0", 0, false},
+		{"quero 2", 0, false},
+		{"2 unidades", 0, false},
+		{"70000-000", 0, false},
+	}
+
+	for _, tt := range tests {
+		number, ok := parseBareNumber(tt.message)
+		if ok != tt.ok || number != tt.esperado {
+			t.Errorf("parseBareNumber(%q): esperado (%d, %v), obtido (%d, %v)", tt.message, tt.esperado, tt.ok, number, ok)
+		}
+	}
+}
+
+func TestNumericReplyToolPerState(t *testing.T) {
+	tests := []struct {
+		state    selectionState
+		tool     string
+		argKey   string
+		esperado interface{}
+	}{
+		{awaitingProductSelection, "adicionarPorNumero", "numero", float64(2)},
+		{awaitingAddressSelection, "gerenciarEnderecos", "numero_endereco", float64(2)},
+		{awaitingPaymentSelection, "selecionarFormaPagamento", "numero_opcao", float64(2)},
+		{awaitingQuantitySelection, "adicionarItemDetalhado", "quantidade", float64(2)},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.state), func(t *testing.T) {
+			tool, args, ok := numericReplyTool(tt.state, 2)
+			if !ok || tool != tt.tool || args[tt.argKey] != tt.esperado {
+				t.Errorf("esperado %s(%s=%v), obtido %s(%v) ok=%v", tt.tool, tt.argKey, tt.esperado, tool, args, ok)
+			}
+		})
+	}
+
+	if _, _, ok := numericReplyTool("", 2); ok {
+		t.Errorf("sem lista aberta o número deveria seguir para o GPT")
+	}
+}
+
+// newNumericReplyTestLists retorna os produtos, endereços e formas de pagamento das listas numeradas
+func newNumericReplyTestLists() ([]models.Product, []models.Address, []PaymentOption) {
+	products := []models.Product{
+		{BaseTenantModel: models.BaseTenantModel{ID: uuid.New()}, Name: "Dipirona 500mg", Price: "8.90", Available: true, StockQuantity: 10},
+		{BaseTenantModel: models.BaseTenantModel{ID: uuid.New()}, Name: "Vitamina C", Price: "25.00", Available: true, StockQuantity: 10},
+	}
+	addresses := []models.Address{newRememberedAddress(false), newRememberedAddress(false)}
+	addresses[1].Street = "Avenida Central"
+	options := []PaymentOption{{ID: uuid.New().String(), Name: "PIX"}, {ID: uuid.New().String(), Name: "Cartão de Crédito"}}
+	return products, addresses, options
+}
+
+func TestRouteNumericReplyResolvesSameNumberPerState(t *testing.T) {
+	ctx := context.Background()
+	tenantID, customerID, phone := uuid.New(), uuid.New(), "5527999990000"
+	products, addresses, options := newNumericReplyTestLists()
+	cart := &paymentCartService{addingCartService: *newAddingCartService()}
+	addressService := &defaultTrackingAddressService{fakeAddressService: fakeAddressService{addresses: addresses}}
+	s, _ := newTestService(nil, withProducts(products...), withCartService(cart), withOverride(func(s *AIService) {
+		s.addressService = addressService
+		s.orderService = &paymentOrderService{options: options}
+	}))
+
+	// Lista de produtos: "2" adiciona o produto 2
+	s.memoryManager.StoreProductList(tenantID, phone, products)
+	result, handled := s.routeNumericReply(ctx, tenantID, customerID, phone, "2")
+	if !handled || cart.added[products[1].ID] != 1 || !strings.Contains(result, "Vitamina C") {
+		t.Fatalf("esperado adicionar o produto 2, obtido %v handled=%v:\n%s", cart.added, handled, result)
+	}
+
+	// Lista de endereços: "2" seleciona o endereço 2
+	s.setAwaitingSelection(tenantID, phone, awaitingAddressSelection)
+	result, handled = s.routeNumericReply(ctx, tenantID, customerID, phone, "2")
+	if !handled || len(addressService.defaults) != 1 || addressService.defaults[0] != addresses[1].ID {
+		t.Fatalf("esperado selecionar o endereço 2, obtido %v handled=%v:\n%s", addressService.defaults, handled, result)
+	}
+
+	// Formas de pagamento: "2" escolhe a opção 2
+	s.setAwaitingSelection(tenantID, phone, awaitingPaymentSelection)
+	result, handled = s.routeNumericReply(ctx, tenantID, customerID, phone, "2")
+	if !handled || cart.paymentMethodID.String() != options[1].ID || !strings.Contains(result, "Cartão de Crédito") {
+		t.Fatalf("esperado escolher a forma de pagamento 2, obtido %s handled=%v:\n%s", cart.paymentMethodID, handled, result)
+	}
+	if state := s.awaitingSelection(tenantID, phone); state != "" {
+		t.Errorf("escolha do pagamento deveria encerrar a seleção, estado = %q", state)
+	}
+
+	// Produto detalhado: "2" é a quantidade
+	s.rememberDetailedProduct(tenantID, phone, products[0].ID)
+	result, handled = s.routeNumericReply(ctx, tenantID, customerID, phone, "2")
+	if !handled || cart.added[products[0].ID] != 2 {
+		t.Fatalf("esperado adicionar 2 unidades do produto detalhado, obtido %v handled=%v:\n%s", cart.added, handled, result)
+	}
+	if state := s.awaitingSelection(tenantID, phone); state != awaitingProductSelection {
+		t.Errorf("depois da quantidade a lista de produtos deveria voltar a valer, estado = %q", state)
+	}
+}
+
+func TestRouteNumericReplyFallsBackToGPT(t *testing.T) {
+	ctx := context.Background()
+	tenantID, customerID, phone := uuid.New(), uuid.New(), "5527999990000"
+
+	// Sem lista aberta
+	products, _, _ := newNumericReplyTestLists()
+	s, _ := newTestService(nil, withProducts(products...), withCartService(newAddingCartService()))
+	if _, handled := s.routeNumericReply(ctx, tenantID, customerID, phone, "2"); handled {
+		t.Errorf("sem lista aberta o número deveria seguir para o GPT")
+	}
+
+	// Mensagem que não é só um número
+	s.memoryManager.StoreProductList(tenantID, phone, products)
+	if _, handled := s.routeNumericReply(ctx, tenantID, customerID, phone, "quero 2 do primeiro"); handled {
+		t.Errorf("mensagens com texto deveriam seguir para o GPT")
+	}
+
+	// Tenant desabilitou o encaminhamento
+	cart := newAddingCartService()
+	s, _ = newTestService(map[string]string{NumericReplyRoutingSettingKey: "false"}, withProducts(products...), withCartService(cart))
+	s.memoryManager.StoreProductList(tenantID, phone, products)
+	if _, handled := s.routeNumericReply(ctx, tenantID, customerID, phone, "2"); handled || len(cart.added) != 0 {
+		t.Errorf("com o encaminhamento desabilitado o número deveria seguir para o GPT")
+	}
+}
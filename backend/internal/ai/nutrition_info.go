@@ -0,0 +1,243 @@
+package ai
+
+import (
+	"context"
+	"fmt"
+	"iafarma/pkg/models"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// NutritionDisclaimerSettingKey define o aviso anexado às respostas com valores nutricionais ("" = sem aviso)
+	NutritionDisclaimerSettingKey = "ai_nutrition_disclaimer"
+	// NutritionOfferHumanSettingKey define se o bot oferece um atendente quando o produto não tem tabela nutricional
+	NutritionOfferHumanSettingKey = "ai_nutrition_offer_human"
+
+	defaultNutritionDisclaimer = "ℹ️ Valores aproximados, conforme o cadastro do produto. Podem variar conforme a porção e o preparo."
+	// nutritionOfferHumanNote é anexada quando falta a tabela nutricional e o tenant oferece atendimento
+	nutritionOfferHumanNote = "🙋 Se quiser, posso chamar um atendente para confirmar essa informação."
+
+	nutritionTopicAll         = "todos"
+	nutritionTopicAllergens   = "alergenos"
+	nutritionTopicIngredients = "ingredientes"
+	nutritionTopicNutrition   = "nutricao"
+
+	// nutritionSafetyNote é anexada sempre que a informação está ausente ou incompleta
+	nutritionSafetyNote = "⚠️ Por segurança, confira a embalagem ou fale com nossa equipe antes de consumir."
+)
+
+// accentReplacer remove acentos para comparar nomes de alérgenos ("glúten" = "gluten")
+var accentReplacer = strings.NewReplacer(
+	"á", "a", "à", "a", "â", "a", "ã", "a", "ä", "a",
+	"é", "e", "è", "e", "ê", "e", "ë", "e",
+	"í", "i", "ì", "i", "î", "i", "ï", "i",
+	"ó", "o", "ò", "o", "ô", "o", "õ", "o", "ö", "o",
+	"ú", "u", "ù", "u", "û", "u", "ü", "u",
+	"ç", "c",
+)
+
+func normalizeAllergenText(text string) string {
+	return strings.TrimSpace(accentReplacer.Replace(strings.ToLower(text)))
+}
+
+// parseAllergens converte a lista cadastrada (separada por vírgula ou ponto e vírgula) em itens
+func parseAllergens(allergens string) []string {
+	var result []string
+	for _, part := range strings.FieldsFunc(allergens, func(r rune) bool { return r == ',' || r == ';' }) {
+		if trimmed := strings.TrimSpace(part); trimmed != "" {
+			result = append(result, trimmed)
+		}
+	}
+	return result
+}
+
+// formatNutritionFacts lista apenas os valores cadastrados da tabela nutricional
+func formatNutritionFacts(facts *models.NutritionFacts) string {
+	if facts == nil {
+		return ""
+	}
+
+	rows := []struct{ label, value string }{
+		{"Porção", facts.ServingSize},
+		{"Valor energético", facts.Calories},
+		{"Carboidratos", facts.Carbohydrates},
+		{"Açúcares", facts.Sugars},
+		{"Proteínas", facts.Proteins},
+		{"Gorduras totais", facts.TotalFat},
+		{"Gorduras saturadas", facts.SaturatedFat},
+		{"Gorduras trans", facts.TransFat},
+		{"Fibras", facts.Fiber},
+		{"Sódio", facts.Sodium},
+	}
+
+	var result strings.Builder
+	for _, row := range rows {
+		if value := strings.TrimSpace(row.value); value != "" {
+			result.WriteString(fmt.Sprintf("• %s: %s\n", row.label, value))
+		}
+	}
+	return result.String()
+}
+
+// formatAllergenAnswer responde sobre alérgenos usando somente o cadastro; nunca presume ausência sem lista conferida
+func formatAllergenAnswer(product *models.Product, allergen string) string {
+	allergens := parseAllergens(product.Allergens)
+
+	if len(allergens) == 0 && !product.AllergenInfoVerified {
+		return fmt.Sprintf("⚠️ Não temos informação de alérgenos cadastrada para **%s**, então não posso confirmar se contém ou não.\n%s", product.Name, nutritionSafetyNote)
+	}
+
+	if allergen == "" {
+		if len(allergens) == 0 {
+			return fmt.Sprintf("✅ Segundo o cadastro conferido pela loja, **%s** não contém alérgenos declarados.", product.Name)
+		}
+		result := fmt.Sprintf("⚠️ **%s** contém: %s", product.Name, strings.Join(allergens, ", "))
+		if !product.AllergenInfoVerified {
+			result += "\n" + "A lista pode estar incompleta. " + nutritionSafetyNote
+		}
+		return result
+	}
+
+	wanted := normalizeAllergenText(allergen)
+	for _, item := range allergens {
+		normalized := normalizeAllergenText(item)
+		if strings.Contains(normalized, wanted) || strings.Contains(wanted, normalized) {
+			return fmt.Sprintf("⚠️ Sim, **%s** contém **%s**.", product.Name, item)
+		}
+	}
+
+	// Ingrediente citado explicitamente também conta como presença
+	if product.Ingredients != "" && strings.Contains(normalizeAllergenText(product.Ingredients), wanted) {
+		return fmt.Sprintf("⚠️ Os ingredientes de **%s** incluem **%s**.", product.Name, allergen)
+	}
+
+	if product.AllergenInfoVerified {
+		return fmt.Sprintf("✅ Segundo o cadastro conferido pela loja, **%s** não contém **%s**.", product.Name, allergen)
+	}
+
+	return fmt.Sprintf("⚠️ **%s** não aparece na lista de alérgenos cadastrada para **%s**, mas essa lista não foi confirmada como completa.\n%s", allergen, product.Name, nutritionSafetyNote)
+}
+
+// formatNutritionInfo monta a resposta com os dados cadastrados do produto para o tópico pedido
+func formatNutritionInfo(product *models.Product, topic, allergen string) string {
+	if allergen != "" {
+		topic = nutritionTopicAllergens
+	}
+
+	switch topic {
+	case nutritionTopicAllergens:
+		return formatAllergenAnswer(product, allergen)
+
+	case nutritionTopicIngredients:
+		if strings.TrimSpace(product.Ingredients) == "" {
+			return fmt.Sprintf("⚠️ Não temos a lista de ingredientes cadastrada para **%s**.\n%s", product.Name, nutritionSafetyNote)
+		}
+		return fmt.Sprintf("🧾 **Ingredientes de %s:**\n%s", product.Name, strings.TrimSpace(product.Ingredients))
+
+	case nutritionTopicNutrition:
+		facts := formatNutritionFacts(product.NutritionFacts)
+		if facts == "" {
+			return fmt.Sprintf("⚠️ Não tenho essa informação. Não temos a tabela nutricional cadastrada para **%s**, então não consigo informar calorias ou nutrientes.\n%s", product.Name, nutritionSafetyNote)
+		}
+		return fmt.Sprintf("🥗 **Informação nutricional de %s:**\n%s", product.Name, strings.TrimRight(facts, "\n"))
+	}
+
+	// Todos os tópicos
+	var result strings.Builder
+	result.WriteString(fmt.Sprintf("🥗 **Informações de %s**\n\n", product.Name))
+
+	if facts := formatNutritionFacts(product.NutritionFacts); facts != "" {
+		result.WriteString("📊 **Tabela nutricional:**\n" + facts + "\n")
+	} else {
+		result.WriteString("📊 Tabela nutricional: não cadastrada.\n\n")
+	}
+
+	if ingredients := strings.TrimSpace(product.Ingredients); ingredients != "" {
+		result.WriteString("🧾 **Ingredientes:** " + ingredients + "\n\n")
+	} else {
+		result.WriteString("🧾 Ingredientes: não cadastrados.\n\n")
+	}
+
+	result.WriteString(formatAllergenAnswer(product, ""))
+	return result.String()
+}
+
+// nutritionFactsAnswered indica se a resposta do tópico traz a tabela nutricional: true quando há valores cadastrados,
+// false quando a tabela falta. ok=false para tópicos que não tratam de valores nutricionais.
+func nutritionFactsAnswered(product *models.Product, topic, allergen string) (answered, ok bool) {
+	if allergen != "" || (topic != nutritionTopicNutrition && topic != nutritionTopicAll) {
+		return false, false
+	}
+	return formatNutritionFacts(product.NutritionFacts) != "", true
+}
+
+// getNutritionDisclaimer retorna o aviso configurado pelo tenant (padrão: "valores aproximados")
+func (s *AIService) getNutritionDisclaimer(ctx context.Context, tenantID uuid.UUID) string {
+	if s.settingsService == nil {
+		return defaultNutritionDisclaimer
+	}
+	setting, err := s.settingsService.GetSetting(ctx, tenantID, NutritionDisclaimerSettingKey)
+	if err != nil || setting == nil || setting.SettingValue == nil {
+		return defaultNutritionDisclaimer
+	}
+	return strings.TrimSpace(*setting.SettingValue)
+}
+
+// shouldOfferHumanForNutrition indica se o tenant oferece atendente quando falta a tabela nutricional (padrão: sim)
+func (s *AIService) shouldOfferHumanForNutrition(ctx context.Context, tenantID uuid.UUID) bool {
+	if s.settingsService == nil {
+		return true
+	}
+	setting, err := s.settingsService.GetSetting(ctx, tenantID, NutritionOfferHumanSettingKey)
+	if err != nil || setting == nil || setting.SettingValue == nil {
+		return true
+	}
+	enabled, err := strconv.ParseBool(strings.TrimSpace(*setting.SettingValue))
+	return err != nil || enabled
+}
+
+// handleConsultarInfoNutricional responde perguntas sobre nutrição, ingredientes e alérgenos usando apenas dados cadastrados
+func (s *AIService) handleConsultarInfoNutricional(ctx context.Context, tenantID uuid.UUID, customerPhone string, args map[string]interface{}) (string, error) {
+	identifier, _ := args["identifier"].(string)
+	identifier = strings.TrimSpace(identifier)
+	if identifier == "" {
+		return "❌ Informe o produto (nome ou número da lista) para consultar as informações nutricionais.", nil
+	}
+
+	topic, _ := args["topico"].(string)
+	if topic == "" {
+		topic = nutritionTopicAll
+	}
+	allergen, _ := args["alergeno"].(string)
+
+	product, err := s.resolveProductIdentifier(ctx, tenantID, customerPhone, identifier)
+	if err != nil || product == nil {
+		return "❌ Produto não encontrado. Use 'produtos' para ver a lista atualizada.", nil
+	}
+
+	log.Info().
+		Str("tenant_id", tenantID.String()).
+		Str("product_id", product.ID.String()).
+		Str("topic", topic).
+		Str("allergen", allergen).
+		Msg("🥗 Consultando informações nutricionais")
+
+	allergen = strings.TrimSpace(allergen)
+	result := formatNutritionInfo(product, topic, allergen)
+
+	// Valores mostrados levam o aviso do tenant; sem tabela, nada de estimativas - no máximo um atendente
+	answered, ok := nutritionFactsAnswered(product, topic, allergen)
+	switch {
+	case ok && answered:
+		if disclaimer := s.getNutritionDisclaimer(ctx, tenantID); disclaimer != "" {
+			result += "\n\n" + disclaimer
+		}
+	case ok && s.shouldOfferHumanForNutrition(ctx, tenantID):
+		result += "\n" + nutritionOfferHumanNote
+	}
+	return result, nil
+}
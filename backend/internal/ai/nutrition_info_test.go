@@ -0,0 +1,252 @@
+package ai
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"iafarma/pkg/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// fakeProductService resolve produtos por nome/ID para os testes de ferramentas e registra as buscas SQL
+type fakeProductService struct {
+	ProductServiceInterface
+	products       []models.Product
+	advancedCalls  int
+	advancedResult []models.Product
+}
+
+func (f *fakeProductService) GetProductByID(tenantID, productID uuid.UUID) (*models.Product, error) {
+	for i := range f.products {
+		if f.products[i].ID == productID {
+			return &f.products[i], nil
+		}
+	}
+	return nil, errors.New("record not found")
+}
+
+func (f *fakeProductService) SearchProductsAdvanced(tenantID uuid.UUID, filters ProductSearchFilters) ([]models.Product, error) {
+	f.advancedCalls++
+	return f.advancedResult, nil
+}
+
+func (f *fakeProductService) SearchProducts(tenantID uuid.UUID, query string, limit int) ([]models.Product, error) {
+	for _, product := range f.products {
+		if strings.Contains(strings.ToLower(product.Name), strings.ToLower(query)) {
+			return []models.Product{product}, nil
+		}
+	}
+	return nil, nil
+}
+
+func TestNutritionInfoNeverGuessesWithoutData(t *testing.T) {
+	product := &models.Product{Name: "Biscoito Integral"}
+
+	tests := []struct {
+		topic    string
+		allergen string
+	}{
+		{nutritionTopicAllergens, ""},
+		{nutritionTopicAllergens, "glúten"},
+		{nutritionTopicIngredients, ""},
+		{nutritionTopicNutrition, ""},
+	}
+
+	for _, test := range tests {
+		result := formatNutritionInfo(product, test.topic, test.allergen)
+		if !strings.Contains(result, "Não temos") {
+			t.Errorf("topic %q allergen %q: expected missing-data answer, got:\n%s", test.topic, test.allergen, result)
+		}
+		if strings.Contains(result, "✅") || strings.Contains(result, "não contém") {
+			t.Errorf("topic %q allergen %q: must not claim absence without data:\n%s", test.topic, test.allergen, result)
+		}
+	}
+
+	all := formatNutritionInfo(product, nutritionTopicAll, "")
+	if !strings.Contains(all, "não cadastrada") || !strings.Contains(all, nutritionSafetyNote) {
+		t.Errorf("full answer without data should flag missing info:\n%s", all)
+	}
+}
+
+func TestNutritionInfoAllergens(t *testing.T) {
+	tests := []struct {
+		name     string
+		product  models.Product
+		allergen string
+		contains string
+	}{
+		{"listed allergen", models.Product{Name: "Pão", Allergens: "Glúten, Lactose"}, "gluten", "Sim, **Pão** contém **Glúten**"},
+		{"allergen in ingredients", models.Product{Name: "Bolo", Ingredients: "farinha, ovos, leite", Allergens: "glúten"}, "ovos", "ingredientes de **Bolo** incluem **ovos**"},
+		{"verified absence", models.Product{Name: "Arroz", AllergenInfoVerified: true}, "glúten", "não contém **glúten**"},
+		{"unverified absence", models.Product{Name: "Granola", Allergens: "aveia"}, "amendoim", "não foi confirmada como completa"},
+		{"verified list without question", models.Product{Name: "Arroz", AllergenInfoVerified: true}, "", "não contém alérgenos declarados"},
+		{"unverified list without question", models.Product{Name: "Granola", Allergens: "aveia; castanhas"}, "", "aveia, castanhas"},
+	}
+
+	for _, test := range tests {
+		result := formatNutritionInfo(&test.product, nutritionTopicAll, test.allergen)
+		if test.allergen == "" {
+			result = formatNutritionInfo(&test.product, nutritionTopicAllergens, "")
+		}
+		if !strings.Contains(result, test.contains) {
+			t.Errorf("%s: expected %q in:\n%s", test.name, test.contains, result)
+		}
+	}
+}
+
+func TestNutritionInfoFacts(t *testing.T) {
+	product := &models.Product{
+		Name:           "Whey Protein",
+		NutritionFacts: &models.NutritionFacts{ServingSize: "30g", Calories: "120 kcal", Proteins: "24g"},
+	}
+
+	result := formatNutritionInfo(product, nutritionTopicNutrition, "")
+	for _, expected := range []string{"Porção: 30g", "Valor energético: 120 kcal", "Proteínas: 24g"} {
+		if !strings.Contains(result, expected) {
+			t.Errorf("expected %q in:\n%s", expected, result)
+		}
+	}
+	// Campos não cadastrados não aparecem (nada de valores inventados)
+	if strings.Contains(result, "Sódio") || strings.Contains(result, "Carboidratos") {
+		t.Errorf("unexpected empty fields in:\n%s", result)
+	}
+}
+
+func TestConsultarInfoNutricionalResolvesProduct(t *testing.T) {
+	tenantID := uuid.New()
+	s := &AIService{
+		productService: &fakeProductService{products: []models.Product{{Name: "Pão de Forma", Allergens: "glúten"}}},
+		memoryManager:  NewMemoryManager(),
+	}
+
+	result, err := s.handleConsultarInfoNutricional(context.Background(), tenantID, "5527999999999", map[string]interface{}{"identifier": "pão de forma", "alergeno": "glúten"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(result, "Sim, **Pão de Forma** contém") {
+		t.Errorf("unexpected answer:\n%s", result)
+	}
+
+	result, _ = s.handleConsultarInfoNutricional(context.Background(), tenantID, "5527999999999", map[string]interface{}{"identifier": "chocolate"})
+	if !strings.HasPrefix(result, "❌") {
+		t.Errorf("unknown product should return not found, got:\n%s", result)
+	}
+}
+
+func TestConsultarInfoNutricionalDisclaimer(t *testing.T) {
+	tenantID := uuid.New()
+	product := models.Product{Name: "Granola", NutritionFacts: &models.NutritionFacts{ServingSize: "40g", Calories: "160 kcal"}}
+	settings := &fakeSettingsService{values: map[string]string{}}
+	s := &AIService{
+		productService:  &fakeProductService{products: []models.Product{product}},
+		settingsService: settings,
+		memoryManager:   NewMemoryManager(),
+	}
+	args := map[string]interface{}{"identifier": "granola", "topico": nutritionTopicNutrition}
+
+	result, _ := s.handleConsultarInfoNutricional(context.Background(), tenantID, "5527999999999", args)
+	if !strings.Contains(result, "Valor energético: 160 kcal") || !strings.HasSuffix(result, defaultNutritionDisclaimer) {
+		t.Errorf("expected facts followed by the default disclaimer:\n%s", result)
+	}
+
+	settings.values[NutritionDisclaimerSettingKey] = "Valores aproximados."
+	result, _ = s.handleConsultarInfoNutricional(context.Background(), tenantID, "5527999999999", args)
+	if !strings.HasSuffix(result, "\n\nValores aproximados.") {
+		t.Errorf("expected tenant disclaimer:\n%s", result)
+	}
+
+	settings.values[NutritionDisclaimerSettingKey] = ""
+	result, _ = s.handleConsultarInfoNutricional(context.Background(), tenantID, "5527999999999", args)
+	if strings.Contains(result, "aproximados") {
+		t.Errorf("empty setting should disable the disclaimer:\n%s", result)
+	}
+
+	// Alérgenos não trazem valores nutricionais, então não levam o aviso
+	settings.values[NutritionDisclaimerSettingKey] = "Valores aproximados."
+	result, _ = s.handleConsultarInfoNutricional(context.Background(), tenantID, "5527999999999", map[string]interface{}{"identifier": "granola", "alergeno": "amendoim"})
+	if strings.Contains(result, "Valores aproximados.") {
+		t.Errorf("allergen answer should not carry the nutrition disclaimer:\n%s", result)
+	}
+}
+
+func TestConsultarInfoNutricionalNeverFabricatesMissingFacts(t *testing.T) {
+	tenantID := uuid.New()
+	settings := &fakeSettingsService{values: map[string]string{}}
+	s := &AIService{
+		productService:  &fakeProductService{products: []models.Product{{Name: "Pão de Queijo", NutritionFacts: &models.NutritionFacts{}}}},
+		settingsService: settings,
+		memoryManager:   NewMemoryManager(),
+	}
+
+	for _, topic := range []string{nutritionTopicNutrition, nutritionTopicAll} {
+		args := map[string]interface{}{"identifier": "pão de queijo", "topico": topic}
+		result, err := s.handleConsultarInfoNutricional(context.Background(), tenantID, "5527999999999", args)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if strings.Contains(result, "kcal") || strings.Contains(result, "Valor energético") || strings.Contains(result, defaultNutritionDisclaimer) {
+			t.Errorf("topic %q: must not present nutrition values without data:\n%s", topic, result)
+		}
+		if !strings.Contains(result, nutritionOfferHumanNote) {
+			t.Errorf("topic %q: expected offer of human contact:\n%s", topic, result)
+		}
+	}
+
+	result, _ := s.handleConsultarInfoNutricional(context.Background(), tenantID, "5527999999999", map[string]interface{}{"identifier": "pão de queijo", "topico": nutritionTopicNutrition})
+	if !strings.Contains(result, "Não tenho essa informação") {
+		t.Errorf("expected explicit missing-data answer:\n%s", result)
+	}
+
+	settings.values[NutritionOfferHumanSettingKey] = "false"
+	result, _ = s.handleConsultarInfoNutricional(context.Background(), tenantID, "5527999999999", map[string]interface{}{"identifier": "pão de queijo", "topico": nutritionTopicNutrition})
+	if strings.Contains(result, nutritionOfferHumanNote) {
+		t.Errorf("human offer should be disabled by the setting:\n%s", result)
+	}
+}
+
+func TestProductNutritionColumnsMigration(t *testing.T) {
+	db, _ := newDryRunDB(t)
+
+	product := models.Product{
+		Name:           "Whey Protein",
+		Price:          "99.90",
+		Allergens:      "lactose",
+		NutritionFacts: &models.NutritionFacts{Calories: "120 kcal"},
+	}
+	tx := db.Session(&gorm.Session{DryRun: true, SkipDefaultTransaction: true}).Create(&product)
+	if tx.Error != nil {
+		t.Fatalf("dry-run insert failed: %v", tx.Error)
+	}
+	stmt := tx.Statement
+
+	sql := stmt.SQL.String()
+	for _, column := range []string{`"ingredients"`, `"allergens"`, `"allergen_info_verified"`, `"nutrition_facts"`} {
+		if !strings.Contains(sql, column) {
+			t.Errorf("column %s missing from insert: %s", column, sql)
+		}
+	}
+
+	found := false
+	for _, v := range stmt.Vars {
+		valuer, ok := v.(driver.Valuer)
+		if !ok || (reflect.ValueOf(v).Kind() == reflect.Ptr && reflect.ValueOf(v).IsNil()) {
+			continue
+		}
+		value, err := valuer.Value()
+		if err != nil {
+			continue
+		}
+		if serialized, ok := value.(string); ok && strings.Contains(serialized, `"calories":"120 kcal"`) {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("nutrition facts not serialized as JSON: %v", stmt.Vars)
+	}
+}
@@ -0,0 +1,206 @@
+package ai
+
+import (
+	"fmt"
+	"iafarma/pkg/models"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+)
+
+// maxOrderHistoryEntries é o limite de pedidos exibidos/indexados pelo histórico
+const maxOrderHistoryEntries = 10
+
+// resolveCustomerOrderID resolve o identificador informado pelo cliente (UUID, número sequencial do histórico ou código)
+func (s *AIService) resolveCustomerOrderID(tenantID, customerID uuid.UUID, identifier string) (uuid.UUID, bool) {
+	identifier = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(identifier), "#"))
+	if identifier == "" {
+		return uuid.Nil, false
+	}
+
+	if orderID, err := uuid.Parse(identifier); err == nil {
+		return orderID, true
+	}
+
+	// Histórico exibido recentemente (mesma numeração que o cliente viu)
+	if orderID, found := s.findOrderInMemory(tenantID, customerID.String(), identifier); found {
+		return orderID, true
+	}
+
+	// Sem histórico na memória: indexar os pedidos do cliente como no histórico
+	orders, err := s.orderService.GetOrdersByCustomer(tenantID, customerID)
+	if err != nil {
+		log.Error().Err(err).Str("customer_id", customerID.String()).Msg("Erro ao buscar pedidos do cliente")
+		return uuid.Nil, false
+	}
+
+	ordersList := make([]map[string]interface{}, 0, len(orders))
+	for i, order := range orders {
+		if i >= maxOrderHistoryEntries {
+			break
+		}
+		ordersList = append(ordersList, orderMemoryEntry(order, i+1))
+	}
+	if orderID, found := findOrderIDInList(ordersList, identifier); found {
+		return orderID, true
+	}
+
+	// Código de pedido mais antigo que não aparece no histórico resumido
+	for _, order := range orders {
+		if strings.EqualFold(order.OrderNumber, identifier) {
+			return order.ID, true
+		}
+	}
+
+	return uuid.Nil, false
+}
+
+// handleDetalharPedido mostra os itens, valores, status e endereço de entrega de um pedido do cliente (somente leitura)
+func (s *AIService) handleDetalharPedido(tenantID, customerID uuid.UUID, args map[string]interface{}) (string, error) {
+	identifier, _ := args["order_id"].(string)
+	if strings.TrimSpace(identifier) == "" {
+		return "❌ Informe o número ou código do pedido. Use 'histórico de pedidos' para ver seus pedidos.", nil
+	}
+
+	notFound := "❌ Pedido não encontrado. Use 'histórico de pedidos' para ver seus pedidos e depois 'detalhar pedido [número]'."
+
+	orderID, found := s.resolveCustomerOrderID(tenantID, customerID, identifier)
+	if !found {
+		return notFound, nil
+	}
+
+	order, err := s.orderService.GetOrderByID(tenantID, orderID)
+	if err != nil || order == nil {
+		return notFound, nil
+	}
+
+	// Não revelar pedidos de outros clientes
+	if order.CustomerID == nil || *order.CustomerID != customerID {
+		log.Warn().
+			Str("tenant_id", tenantID.String()).
+			Str("customer_id", customerID.String()).
+			Str("order_id", orderID.String()).
+			Msg("🚫 Tentativa de acessar pedido de outro cliente")
+		return notFound, nil
+	}
+
+	return formatOrderDetails(order), nil
+}
+
+// formatOrderDetails monta a mensagem com os detalhes do pedido
+func formatOrderDetails(order *models.Order) string {
+	var result strings.Builder
+
+	result.WriteString(fmt.Sprintf("📋 **Pedido %s** %s\n", order.OrderNumber, getStatusEmoji(order.Status)))
+	result.WriteString(fmt.Sprintf("📦 Status: %s\n", getOrderStatusText(order.Status)))
+	if order.IsUrgent {
+		result.WriteString("⚡ Prioridade: **urgente**\n")
+	}
+	result.WriteString(fmt.Sprintf("📅 Data: %s\n\n", order.CreatedAt.Format("02/01/2006 15:04")))
+
+	result.WriteString("🛒 **Itens:**\n")
+	if len(order.Items) == 0 {
+		result.WriteString("   (sem itens registrados)\n")
+	}
+	for i, item := range order.Items {
+		name := "Produto"
+		if item.ProductName != nil && *item.ProductName != "" {
+			name = *item.ProductName
+		}
+
+		unitPrice := item.Price
+		if item.UnitPrice != nil && *item.UnitPrice != "" {
+			unitPrice = *item.UnitPrice
+		}
+
+		result.WriteString(fmt.Sprintf("%d. %s\n", i+1, name))
+		result.WriteString(fmt.Sprintf("   %d x R$ %s = R$ %s\n", item.Quantity, formatCurrency(unitPrice), formatCurrency(item.Total)))
+		for _, attr := range item.Attributes {
+			result.WriteString(fmt.Sprintf("   • %s: %s\n", attr.AttributeName, attr.OptionName))
+		}
+	}
+
+	result.WriteString("\n")
+	if order.Subtotal != "" && order.Subtotal != "0" {
+		result.WriteString(fmt.Sprintf("🧾 Subtotal: R$ %s\n", formatCurrency(order.Subtotal)))
+	}
+	if order.ShippingAmount != "" && order.ShippingAmount != "0" {
+		result.WriteString(fmt.Sprintf("🚚 Entrega: R$ %s\n", formatCurrency(order.ShippingAmount)))
+	}
+	if order.IsUrgent && order.UrgencyFee != "" && order.UrgencyFee != "0" && order.UrgencyFee != "0.00" {
+		result.WriteString(fmt.Sprintf("⚡ Taxa de urgência: R$ %s\n", formatCurrency(order.UrgencyFee)))
+	}
+	if order.DiscountAmount != "" && order.DiscountAmount != "0" {
+		result.WriteString(fmt.Sprintf("🏷️ Desconto: R$ %s\n", formatCurrency(order.DiscountAmount)))
+	}
+	result.WriteString(fmt.Sprintf("💰 **Total: R$ %s**\n", formatCurrency(order.TotalAmount)))
+
+	if order.PaymentMethod != nil && order.PaymentMethod.Name != "" {
+		result.WriteString(fmt.Sprintf("💳 Pagamento: %s\n", order.PaymentMethod.Name))
+	}
+	if order.InstallmentCount > 1 {
+		result.WriteString(fmt.Sprintf("🧮 Parcelamento: %dx de R$ %s\n", order.InstallmentCount, formatCurrency(order.InstallmentAmount)))
+	}
+	if order.HasDeposit {
+		result.WriteString(fmt.Sprintf("💵 Sinal: R$ %s via %s\n", formatCurrency(order.AmountPaid), order.DepositPaymentMethod))
+		result.WriteString(fmt.Sprintf("🤝 Restante na entrega: R$ %s\n", formatCurrency(order.AmountDue)))
+	}
+
+	if address := formatOrderShippingAddress(order); address != "" {
+		result.WriteString(fmt.Sprintf("\n📍 **Endereço de entrega:**\n%s\n", address))
+	}
+
+	if order.Observations != "" {
+		result.WriteString(fmt.Sprintf("\n📝 Observações: %s\n", order.Observations))
+	}
+
+	return strings.TrimRight(result.String(), "\n")
+}
+
+// formatOrderShippingAddress formata o endereço de entrega gravado no pedido
+func formatOrderShippingAddress(order *models.Order) string {
+	value := func(field *string) string {
+		if field == nil {
+			return ""
+		}
+		return strings.TrimSpace(*field)
+	}
+
+	street := value(order.ShippingStreet)
+	if street == "" {
+		return ""
+	}
+
+	line := street
+	if number := value(order.ShippingNumber); number != "" {
+		line += ", " + number
+	}
+	if complement := value(order.ShippingComplement); complement != "" {
+		line += " - " + complement
+	}
+
+	lines := []string{line}
+	if neighborhood := value(order.ShippingNeighborhood); neighborhood != "" {
+		lines = append(lines, neighborhood)
+	}
+
+	cityState := value(order.ShippingCity)
+	if state := value(order.ShippingState); state != "" {
+		if cityState != "" {
+			cityState += "/"
+		}
+		cityState += state
+	}
+	if cityState != "" {
+		lines = append(lines, cityState)
+	}
+	if zipcode := value(order.ShippingZipcode); zipcode != "" {
+		lines = append(lines, "CEP: "+zipcode)
+	}
+	if reference := value(order.ShippingReference); reference != "" {
+		lines = append(lines, "📍 Referência: "+reference)
+	}
+
+	return strings.Join(lines, "\n")
+}
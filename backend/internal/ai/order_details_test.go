@@ -0,0 +1,175 @@
+package ai
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"iafarma/pkg/models"
+
+	"github.com/google/uuid"
+)
+
+// fakeOrderService implementa as consultas usadas por detalharPedido e registra os pedidos criados no checkout
+type fakeOrderService struct {
+	OrderServiceInterface
+	orders []models.Order
+}
+
+func (f *fakeOrderService) CreateOrderFromCartWithAddress(tenantID, cartID uuid.UUID, deliveryAddress *models.Address) (*models.Order, error) {
+	order := models.Order{OrderNumber: fmt.Sprintf("PED-%03d", len(f.orders)+1), TotalAmount: "0.00"}
+	order.TenantID = tenantID
+	if deliveryAddress != nil {
+		order.ShippingStreet = &deliveryAddress.Street
+	}
+	f.orders = append(f.orders, order)
+	return &f.orders[len(f.orders)-1], nil
+}
+
+func (f *fakeOrderService) GetOrdersByCustomer(tenantID, customerID uuid.UUID) ([]models.Order, error) {
+	var result []models.Order
+	for _, order := range f.orders {
+		if order.TenantID == tenantID && order.CustomerID != nil && *order.CustomerID == customerID {
+			result = append(result, order)
+		}
+	}
+	return result, nil
+}
+
+func (f *fakeOrderService) GetOrderByID(tenantID, orderID uuid.UUID) (*models.Order, error) {
+	for i := range f.orders {
+		if f.orders[i].TenantID == tenantID && f.orders[i].ID == orderID {
+			return &f.orders[i], nil
+		}
+	}
+	return nil, errors.New("record not found")
+}
+
+func newTestOrder(tenantID, customerID uuid.UUID, number, productName string) models.Order {
+	order := models.Order{
+		CustomerID:     &customerID,
+		OrderNumber:    number,
+		Status:         "confirmed",
+		TotalAmount:    "25.80",
+		Subtotal:       "20.80",
+		ShippingAmount: "5.00",
+		ShippingStreet: strPtrAI("Rua das Flores"),
+		ShippingNumber: strPtrAI("123"),
+		ShippingCity:   strPtrAI("Vitória"),
+		ShippingState:  strPtrAI("ES"),
+		Items: []models.OrderItem{
+			{Quantity: 2, Price: "10.40", Total: "20.80", ProductName: strPtrAI(productName), UnitPrice: strPtrAI("10.40")},
+		},
+	}
+	order.ID = uuid.New()
+	order.TenantID = tenantID
+	order.CreatedAt = time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	return order
+}
+
+func strPtrAI(s string) *string {
+	return &s
+}
+
+func TestFindOrderIDInList(t *testing.T) {
+	first, second := uuid.New(), uuid.New()
+	ordersList := []map[string]interface{}{
+		{"id": first.String(), "number": "PED100", "sequential": 1},
+		{"id": second.String(), "number": "PED200", "sequential": 2},
+	}
+
+	tests := []struct {
+		identifier string
+		expected   uuid.UUID
+		found      bool
+	}{
+		{"1", first, true},
+		{"2", second, true},
+		{" 2 ", second, true},
+		{"PED200", second, true},
+		{"ped100", first, true},
+		{"3", uuid.Nil, false},
+		{"PED999", uuid.Nil, false},
+	}
+
+	for _, test := range tests {
+		id, found := findOrderIDInList(ordersList, test.identifier)
+		if found != test.found || id != test.expected {
+			t.Errorf("findOrderIDInList(%q) = (%s, %t), expected (%s, %t)", test.identifier, id, found, test.expected, test.found)
+		}
+	}
+}
+
+func TestDetalharPedidoResolution(t *testing.T) {
+	tenantID, customerID := uuid.New(), uuid.New()
+	newest := newTestOrder(tenantID, customerID, "PED200", "Dipirona 500mg")
+	oldest := newTestOrder(tenantID, customerID, "PED100", "Paracetamol 750mg")
+
+	s := &AIService{
+		orderService:  &fakeOrderService{orders: []models.Order{newest, oldest}},
+		memoryManager: NewMemoryManager(),
+	}
+
+	tests := []struct {
+		name       string
+		identifier string
+		expected   string
+	}{
+		{"by sequential number", "2", "Paracetamol 750mg"},
+		{"by code", "PED200", "Dipirona 500mg"},
+		{"by code with hash", "#ped100", "Paracetamol 750mg"},
+	}
+
+	for _, test := range tests {
+		result, err := s.handleDetalharPedido(tenantID, customerID, map[string]interface{}{"order_id": test.identifier})
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", test.name, err)
+		}
+		if !strings.Contains(result, test.expected) {
+			t.Errorf("%s: result does not contain %q:\n%s", test.name, test.expected, result)
+		}
+	}
+
+	// Com o histórico na memória, o número sequencial segue a lista exibida ao cliente
+	s.memoryManager.StoreTempData(tenantID, customerID.String(), map[string]interface{}{
+		"orders_list": []map[string]interface{}{orderMemoryEntry(oldest, 1), orderMemoryEntry(newest, 2)},
+	})
+	result, _ := s.handleDetalharPedido(tenantID, customerID, map[string]interface{}{"order_id": "1"})
+	if !strings.Contains(result, "Paracetamol 750mg") {
+		t.Errorf("memory resolution: expected PED100, got:\n%s", result)
+	}
+}
+
+func TestDetalharPedidoContent(t *testing.T) {
+	tenantID, customerID := uuid.New(), uuid.New()
+	order := newTestOrder(tenantID, customerID, "PED300", "Dipirona 500mg")
+
+	result := formatOrderDetails(&order)
+	for _, expected := range []string{"PED300", "Confirmado", "2 x R$ 10,40 = R$ 20,80", "R$ 25,80", "Rua das Flores, 123", "Vitória/ES"} {
+		if !strings.Contains(result, expected) {
+			t.Errorf("details missing %q:\n%s", expected, result)
+		}
+	}
+}
+
+func TestDetalharPedidoNotFoundAndCrossCustomer(t *testing.T) {
+	tenantID, customerID, otherCustomerID := uuid.New(), uuid.New(), uuid.New()
+	otherOrder := newTestOrder(tenantID, otherCustomerID, "PED900", "Produto de outro cliente")
+
+	s := &AIService{
+		orderService:  &fakeOrderService{orders: []models.Order{otherOrder}},
+		memoryManager: NewMemoryManager(),
+	}
+
+	for _, identifier := range []string{"1", "PED900", otherOrder.ID.String(), "PED404"} {
+		result, err := s.handleDetalharPedido(tenantID, customerID, map[string]interface{}{"order_id": identifier})
+		if err != nil {
+			t.Fatalf("unexpected error for %q: %v", identifier, err)
+		}
+		if !strings.HasPrefix(result, "❌") || strings.Contains(result, "Produto de outro cliente") {
+			t.Errorf("identifier %q should not expose the order, got:\n%s", identifier, result)
+		}
+	}
+}
@@ -0,0 +1,198 @@
+package ai
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"iafarma/pkg/models"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+)
+
+// OrderNumberFormatSettingKey define o formato do número dos pedidos do tenant (ex: "PH-{seq}", "ORD-{date}-{seq}").
+// Vazio mantém o formato padrão do sistema.
+const OrderNumberFormatSettingKey = "order_number_format"
+
+// OrderNumberUniqueIndex é o índice único de (tenant_id, order_number) criado na migração
+const OrderNumberUniqueIndex = "idx_orders_tenant_order_number_unique"
+
+const (
+	// maxOrderNumberFormatLength limita o tamanho do formato configurado
+	maxOrderNumberFormatLength = 32
+	// orderNumberSeqWidth é a quantidade mínima de dígitos do sequencial ({seq} = 0001, 0002...)
+	orderNumberSeqWidth = 4
+	// maxOrderNumberAttempts limita as tentativas quando o número gerado já existe (ex: formato alterado)
+	maxOrderNumberAttempts = 20
+)
+
+var (
+	orderNumberTokenPattern   = regexp.MustCompile(`\{[^{}]*\}`)
+	orderNumberLiteralPattern = regexp.MustCompile(`^[A-Za-z0-9._/-]*$`)
+	orderNumberNonDigit       = regexp.MustCompile(`[^0-9]`)
+)
+
+// orderNumberTokens são os marcadores aceitos no formato
+var orderNumberTokens = map[string]func(seq int64, now time.Time) string{
+	"{seq}":   func(seq int64, now time.Time) string { return fmt.Sprintf("%0*d", orderNumberSeqWidth, seq) },
+	"{date}":  func(seq int64, now time.Time) string { return now.Format("20060102") },
+	"{year}":  func(seq int64, now time.Time) string { return now.Format("2006") },
+	"{month}": func(seq int64, now time.Time) string { return now.Format("01") },
+	"{day}":   func(seq int64, now time.Time) string { return now.Format("02") },
+}
+
+// ValidateOrderNumberFormat verifica se o formato gera números únicos e que não se confundem com a numeração
+// sequencial do histórico de pedidos ("cancelar pedido 2")
+func ValidateOrderNumberFormat(format string) error {
+	format = strings.TrimSpace(format)
+	if format == "" {
+		return nil
+	}
+	if len(format) > maxOrderNumberFormatLength {
+		return fmt.Errorf("o formato deve ter no máximo %d caracteres", maxOrderNumberFormatLength)
+	}
+
+	seqCount := 0
+	for _, token := range orderNumberTokenPattern.FindAllString(format, -1) {
+		if _, ok := orderNumberTokens[token]; !ok {
+			return fmt.Errorf("marcador desconhecido %s (use {seq}, {date}, {year}, {month} ou {day})", token)
+		}
+		if token == "{seq}" {
+			seqCount++
+		}
+	}
+	if seqCount != 1 {
+		return errors.New("o formato deve conter {seq} exatamente uma vez")
+	}
+
+	literal := orderNumberTokenPattern.ReplaceAllString(format, "")
+	if !orderNumberLiteralPattern.MatchString(literal) {
+		return errors.New("use apenas letras, números, '-', '_', '.' ou '/' fora dos marcadores")
+	}
+	if !orderNumberNonDigit.MatchString(literal) {
+		return errors.New("o formato deve ter um prefixo ou separador (ex: \"PED-{seq}\") para não ser confundido com o número do histórico")
+	}
+	return nil
+}
+
+// formatOrderNumber aplica o sequencial e a data ao formato
+func formatOrderNumber(format string, seq int64, now time.Time) string {
+	return orderNumberTokenPattern.ReplaceAllStringFunc(format, func(token string) string {
+		if render, ok := orderNumberTokens[token]; ok {
+			return render(seq, now)
+		}
+		return token
+	})
+}
+
+// nextAvailableOrderNumber gera números com o próximo sequencial até encontrar um que ainda não existe no tenant
+func nextAvailableOrderNumber(format string, now time.Time, nextSeq func() (int64, error), exists func(string) (bool, error)) (string, error) {
+	for attempt := 0; attempt < maxOrderNumberAttempts; attempt++ {
+		seq, err := nextSeq()
+		if err != nil {
+			return "", err
+		}
+
+		number := formatOrderNumber(format, seq, now)
+		taken, err := exists(number)
+		if err != nil {
+			return "", err
+		}
+		if !taken {
+			return number, nil
+		}
+	}
+	return "", fmt.Errorf("não foi possível gerar um número de pedido livre após %d tentativas", maxOrderNumberAttempts)
+}
+
+// nextOrderSequence incrementa atomicamente o sequencial de pedidos do tenant. O upsert trava a linha do tenant em
+// order_number_sequences, então checkouts simultâneos sempre recebem valores distintos, em qualquer instância da API.
+// O valor é reservado fora da transação do pedido: um checkout que falha depois deixa um buraco na numeração, mas um
+// número nunca é reutilizado.
+func nextOrderSequence(db *gorm.DB, tenantID uuid.UUID) (int64, error) {
+	var value int64
+	err := db.Raw(`INSERT INTO order_number_sequences (tenant_id, last_value, updated_at) VALUES (?, 1, NOW())
+		ON CONFLICT (tenant_id) DO UPDATE SET last_value = order_number_sequences.last_value + 1, updated_at = NOW()
+		RETURNING last_value`, tenantID).Scan(&value).Error
+	return value, err
+}
+
+// ConfiguredOrderNumber gera o número do pedido no formato configurado pelo tenant. Números já usados, inclusive por
+// pedidos excluídos, são pulados. Retorna false quando o tenant não configurou um formato válido (o chamador mantém o formato padrão).
+func ConfiguredOrderNumber(db *gorm.DB, tenantID uuid.UUID, now time.Time) (string, bool) {
+	var setting models.TenantSetting
+	err := db.Where("tenant_id = ? AND setting_key = ? AND is_active = true", tenantID, OrderNumberFormatSettingKey).
+		First(&setting).Error
+	if err != nil || setting.SettingValue == nil || strings.TrimSpace(*setting.SettingValue) == "" {
+		return "", false
+	}
+
+	format := strings.TrimSpace(*setting.SettingValue)
+	if err := ValidateOrderNumberFormat(format); err != nil {
+		log.Warn().Err(err).Str("tenant_id", tenantID.String()).Str("format", format).Msg("⚠️ Formato de número de pedido inválido, usando o padrão")
+		return "", false
+	}
+
+	number, err := nextAvailableOrderNumber(format, now.In(storeLocation),
+		func() (int64, error) { return nextOrderSequence(db, tenantID) },
+		func(number string) (bool, error) {
+			var count int64
+			err := db.Unscoped().Model(&models.Order{}).Where("tenant_id = ? AND order_number = ?", tenantID, number).Count(&count).Error
+			return count > 0, err
+		})
+	if err != nil {
+		log.Error().Err(err).Str("tenant_id", tenantID.String()).Msg("❌ Erro ao gerar número de pedido configurado, usando o padrão")
+		return "", false
+	}
+	return number, true
+}
+
+// NextOrderNumber gera o número do próximo pedido no formato configurado pelo tenant ou, sem formato, com fallback
+func NextOrderNumber(db *gorm.DB, tenantID uuid.UUID, now time.Time, fallback func() string) string {
+	if number, ok := ConfiguredOrderNumber(db, tenantID, now); ok {
+		return number
+	}
+	return fallback()
+}
+
+// IsOrderNumberConflict indica se o erro é a violação do índice único do número do pedido
+func IsOrderNumberConflict(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23505" && pgErr.ConstraintName == OrderNumberUniqueIndex
+}
+
+// CreateWithUniqueOrderNumber grava o pedido com create. A verificação de ConfiguredOrderNumber não trava nada: se
+// outro checkout gravou o mesmo número nesse meio tempo, o índice único rejeita o insert e um novo número é gerado com
+// nextNumber. Dentro de uma transação, create deve usar um savepoint (ver CreateOrderWithUniqueNumber).
+func CreateWithUniqueOrderNumber(order *models.Order, nextNumber func() string, create func(*models.Order) error) error {
+	for attempt := 0; ; attempt++ {
+		err := create(order)
+		if err == nil || !IsOrderNumberConflict(err) {
+			return err
+		}
+		if attempt+1 >= maxOrderNumberAttempts {
+			return fmt.Errorf("não foi possível gravar o pedido com um número livre após %d tentativas: %w", maxOrderNumberAttempts, err)
+		}
+
+		log.Warn().
+			Str("tenant_id", order.TenantID.String()).
+			Str("order_number", order.OrderNumber).
+			Msg("⚠️ Número de pedido usado por outro checkout, gerando outro")
+		order.OrderNumber = nextNumber()
+	}
+}
+
+// CreateOrderWithUniqueNumber grava o pedido na transação tx, num savepoint para que um conflito de número não aborte
+// a transação, e gera outro número em caso de conflito
+func CreateOrderWithUniqueNumber(tx *gorm.DB, order *models.Order, nextNumber func() string) error {
+	return CreateWithUniqueOrderNumber(order, nextNumber, func(order *models.Order) error {
+		return tx.Transaction(func(savepoint *gorm.DB) error {
+			return savepoint.Create(order).Error
+		})
+	})
+}
@@ -0,0 +1,149 @@
+package ai
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"iafarma/pkg/models"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/stdlib"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// newOrderNumberTestDB conecta ao Postgres de TEST_DATABASE_URL num schema descartável com as tabelas usadas na
+// numeração dos pedidos (sequencial, configuração do formato e o índice único da migração)
+func newOrderNumberTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URL não definido: teste com o Postgres real ignorado")
+	}
+
+	config, err := pgx.ParseConfig(dsn)
+	if err != nil {
+		t.Fatalf("TEST_DATABASE_URL inválido: %v", err)
+	}
+	schema := "order_number_test_" + uuid.NewString()[:8]
+	config.RuntimeParams["search_path"] = schema
+
+	sqlDB := stdlib.OpenDB(*config)
+	sqlDB.SetMaxOpenConns(20)
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: sqlDB}), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatalf("erro ao conectar ao Postgres: %v", err)
+	}
+	t.Cleanup(func() {
+		db.Exec(fmt.Sprintf(`DROP SCHEMA IF EXISTS %q CASCADE`, schema))
+		sqlDB.Close()
+	})
+
+	for _, statement := range []string{
+		fmt.Sprintf(`CREATE SCHEMA %q`, schema),
+		`CREATE TABLE order_number_sequences (tenant_id uuid PRIMARY KEY, last_value bigint NOT NULL DEFAULT 0, updated_at timestamptz)`,
+		`CREATE TABLE tenant_settings (id uuid PRIMARY KEY, tenant_id uuid NOT NULL, setting_key varchar(100) NOT NULL,
+			setting_value text, setting_type varchar(50), description text, is_active boolean DEFAULT true,
+			created_at timestamptz, updated_at timestamptz, deleted_at timestamptz)`,
+		`CREATE TABLE orders (id uuid PRIMARY KEY, tenant_id uuid NOT NULL, order_number text NOT NULL, deleted_at timestamptz)`,
+		`CREATE UNIQUE INDEX ` + OrderNumberUniqueIndex + ` ON orders(tenant_id, order_number)`,
+	} {
+		if err := db.Exec(statement).Error; err != nil {
+			t.Fatalf("erro ao preparar o schema de teste: %v", err)
+		}
+	}
+	return db
+}
+
+// insertOrderNumber grava só o número do pedido, como o insert do checkout faria
+func insertOrderNumber(db *gorm.DB, tenantID uuid.UUID) func(*models.Order) error {
+	return func(order *models.Order) error {
+		return db.Exec(`INSERT INTO orders (id, tenant_id, order_number) VALUES (?, ?, ?)`, uuid.New(), tenantID, order.OrderNumber).Error
+	}
+}
+
+func TestConfiguredOrderNumberConcorrentePostgres(t *testing.T) {
+	db := newOrderNumberTestDB(t)
+	tenantID := uuid.New()
+	now := time.Date(2024, 3, 7, 10, 0, 0, 0, storeLocation)
+
+	format := "PH-{seq}"
+	if err := db.Exec(`INSERT INTO tenant_settings (id, tenant_id, setting_key, setting_value, is_active) VALUES (?, ?, ?, ?, true)`,
+		uuid.New(), tenantID, OrderNumberFormatSettingKey, format).Error; err != nil {
+		t.Fatalf("erro ao configurar o formato: %v", err)
+	}
+	// Pedido antigo que coincide com um número do sequencial
+	if err := insertOrderNumber(db, tenantID)(&models.Order{OrderNumber: "PH-0005"}); err != nil {
+		t.Fatalf("erro ao gravar pedido existente: %v", err)
+	}
+
+	const checkouts = 50
+	nextNumber := func() string {
+		return NextOrderNumber(db, tenantID, now, func() string { t.Error("formato configurado não foi usado"); return "" })
+	}
+	errs := make([]error, checkouts)
+	var wg sync.WaitGroup
+	for i := 0; i < checkouts; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			order := models.Order{OrderNumber: nextNumber()}
+			order.TenantID = tenantID
+			// Checkout que grava dentro de uma transação: o conflito fica no savepoint e a transação segue válida
+			errs[i] = db.Transaction(func(tx *gorm.DB) error {
+				return CreateWithUniqueOrderNumber(&order, nextNumber, func(order *models.Order) error {
+					return tx.Transaction(func(savepoint *gorm.DB) error { return insertOrderNumber(savepoint, tenantID)(order) })
+				})
+			})
+		}(i)
+	}
+	wg.Wait()
+	for i, err := range errs {
+		if err != nil {
+			t.Fatalf("checkout %d: erro inesperado: %v", i, err)
+		}
+	}
+
+	var numbers []string
+	db.Raw(`SELECT order_number FROM orders WHERE tenant_id = ? ORDER BY order_number`, tenantID).Scan(&numbers)
+	if len(numbers) != checkouts+1 {
+		t.Fatalf("esperado %d pedidos, obtido %d", checkouts+1, len(numbers))
+	}
+	for i, number := range numbers {
+		if expected := fmt.Sprintf("PH-%04d", i+1); number != expected {
+			t.Fatalf("esperado numeração contínua sem repetição, posição %d: %q (esperado %q)", i, number, expected)
+		}
+	}
+}
+
+func TestCreateWithUniqueOrderNumberConflitoPostgres(t *testing.T) {
+	db := newOrderNumberTestDB(t)
+	tenantID := uuid.New()
+	now := time.Now()
+	var fallback int
+	nextNumber := func() string {
+		return NextOrderNumber(db, tenantID, now, func() string { fallback++; return fmt.Sprintf("PED%d", fallback) })
+	}
+
+	// O número foi verificado como livre, mas outro checkout o gravou antes do insert
+	order := models.Order{OrderNumber: nextNumber()}
+	order.TenantID = tenantID
+	if err := insertOrderNumber(db, tenantID)(&models.Order{OrderNumber: order.OrderNumber}); err != nil {
+		t.Fatalf("erro ao gravar o pedido concorrente: %v", err)
+	}
+	if err := insertOrderNumber(db, tenantID)(&order); !IsOrderNumberConflict(err) {
+		t.Fatalf("esperado conflito do índice único, obtido %v", err)
+	}
+
+	if err := CreateWithUniqueOrderNumber(&order, nextNumber, insertOrderNumber(db, tenantID)); err != nil {
+		t.Fatalf("erro inesperado: %v", err)
+	}
+	if order.OrderNumber != "PED2" {
+		t.Errorf("esperado novo número PED2, obtido %q", order.OrderNumber)
+	}
+}
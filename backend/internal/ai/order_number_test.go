@@ -0,0 +1,222 @@
+package ai
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"iafarma/pkg/models"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+func TestValidateOrderNumberFormat(t *testing.T) {
+	tests := []struct {
+		format string
+		valido bool
+	}{
+		{"", true},
+		{"PH-{seq}", true},
+		{"ORD-{date}-{seq}", true},
+		{"{year}/{month}/{day}.{seq}", true},
+		{"{date}-{seq}", true},
+		{"PED", false},
+		{"{seq}", false},
+		{"{date}{seq}", false},
+		{"PH-{seq}-{seq}", false},
+		{"PH-{sequencia}", false},
+		{"PH {seq}", false},
+		{"PH-{seq", false},
+		{"PEDIDO-DA-FARMACIA-CENTRAL-SUL-{seq}", false},
+	}
+
+	for _, tt := range tests {
+		if err := ValidateOrderNumberFormat(tt.format); (err == nil) != tt.valido {
+			t.Errorf("%q: esperado válido=%v, obtido erro %v", tt.format, tt.valido, err)
+		}
+	}
+}
+
+func TestFormatOrderNumber(t *testing.T) {
+	now := time.Date(2024, 3, 7, 10, 0, 0, 0, storeLocation)
+
+	tests := []struct {
+		format   string
+		seq      int64
+		esperado string
+	}{
+		{"PH-{seq}", 7, "PH-0007"},
+		{"PH-{seq}", 123456, "PH-123456"},
+		{"ORD-{date}-{seq}", 42, "ORD-20240307-0042"},
+		{"{year}/{month}/{day}.{seq}", 1, "2024/03/07.0001"},
+	}
+
+	for _, tt := range tests {
+		if got := formatOrderNumber(tt.format, tt.seq, now); got != tt.esperado {
+			t.Errorf("%q (%d): esperado %q, obtido %q", tt.format, tt.seq, tt.esperado, got)
+		}
+	}
+}
+
+func TestNextAvailableOrderNumberSemColisao(t *testing.T) {
+	now := time.Date(2024, 3, 7, 10, 0, 0, 0, storeLocation)
+
+	// Pedidos já existentes no tenant, inclusive um que coincide com o formato novo
+	existing := map[string]bool{"PED123456": true, "PH-0003": true}
+	var seq int64
+	nextSeq := func() (int64, error) { seq++; return seq, nil }
+	exists := func(number string) (bool, error) { return existing[number], nil }
+
+	var orders []models.Order
+	for _, format := range []string{"PH-{seq}", "ORD-{date}-{seq}"} {
+		for i := 0; i < 25; i++ {
+			number, err := nextAvailableOrderNumber(format, now, nextSeq, exists)
+			if err != nil {
+				t.Fatalf("%q: erro inesperado: %v", format, err)
+			}
+			if existing[number] {
+				t.Fatalf("%q: número repetido %q", format, number)
+			}
+			existing[number] = true
+
+			order := models.Order{OrderNumber: number}
+			order.ID = uuid.New()
+			orders = append(orders, order)
+		}
+	}
+
+	if orders[2].OrderNumber != "PH-0004" {
+		t.Errorf("número já existente deveria ser pulado, obtido %q", orders[2].OrderNumber)
+	}
+	if last := orders[len(orders)-1].OrderNumber; last != "ORD-20240307-0051" {
+		t.Errorf("sequencial deveria continuar após a troca de formato, obtido %q", last)
+	}
+
+	// Número sequencial do histórico e código do pedido continuam resolvendo o pedido certo
+	ordersList := make([]map[string]interface{}, 0, maxOrderHistoryEntries)
+	for i := 0; i < maxOrderHistoryEntries; i++ {
+		ordersList = append(ordersList, orderMemoryEntry(orders[i], i+1))
+	}
+	if orderID, found := findOrderIDInList(ordersList, "2"); !found || orderID != orders[1].ID {
+		t.Errorf("'2' deveria resolver o segundo pedido do histórico")
+	}
+	if orderID, found := findOrderIDInList(ordersList, "ph-0005"); !found || orderID != orders[3].ID {
+		t.Errorf("código do pedido deveria resolver o pedido correspondente")
+	}
+}
+
+func TestNextAvailableOrderNumberDesisteAposTentativas(t *testing.T) {
+	var seq int64
+	_, err := nextAvailableOrderNumber("PH-{seq}", time.Now(),
+		func() (int64, error) { seq++; return seq, nil },
+		func(string) (bool, error) { return true, nil })
+	if err == nil {
+		t.Error("esperado erro quando todos os números já existem")
+	}
+	if seq != maxOrderNumberAttempts {
+		t.Errorf("esperado %d tentativas, obtido %d", maxOrderNumberAttempts, seq)
+	}
+}
+
+func TestNextAvailableOrderNumberConcorrente(t *testing.T) {
+	const checkouts = 500
+	now := time.Date(2024, 3, 7, 10, 0, 0, 0, storeLocation)
+	pattern := regexp.MustCompile(`^ORD-20240307-\d{4,}$`)
+
+	// O sequencial avança atomicamente como o upsert em order_number_sequences; a tabela de pedidos já tem alguns números
+	var seq int64
+	var mu sync.Mutex
+	orders := map[string]bool{"ORD-20240307-0010": true, "ORD-20240307-0100": true}
+	nextSeq := func() (int64, error) { return atomic.AddInt64(&seq, 1), nil }
+	exists := func(number string) (bool, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		return orders[number], nil
+	}
+
+	numbers := make([]string, checkouts)
+	errs := make([]error, checkouts)
+	var wg sync.WaitGroup
+	for i := 0; i < checkouts; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			numbers[i], errs[i] = nextAvailableOrderNumber("ORD-{date}-{seq}", now, nextSeq, exists)
+			if errs[i] == nil {
+				mu.Lock()
+				orders[numbers[i]] = true
+				mu.Unlock()
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	seen := make(map[string]bool, checkouts)
+	for i, number := range numbers {
+		if errs[i] != nil {
+			t.Fatalf("checkout %d: erro inesperado: %v", i, errs[i])
+		}
+		if !pattern.MatchString(number) {
+			t.Errorf("número fora do formato: %q", number)
+		}
+		if seen[number] || number == "ORD-20240307-0010" || number == "ORD-20240307-0100" {
+			t.Errorf("número repetido: %q", number)
+		}
+		seen[number] = true
+	}
+
+	// Os números já existentes são pulados e deixam buracos; nenhum outro valor do sequencial se perde
+	if seq != checkouts+2 {
+		t.Errorf("esperado sequencial final %d, obtido %d", checkouts+2, seq)
+	}
+}
+
+func TestCreateWithUniqueOrderNumberRetentaEmConflito(t *testing.T) {
+	conflict := &pgconn.PgError{Code: "23505", ConstraintName: OrderNumberUniqueIndex}
+	// Outro checkout gravou PH-0001 e PH-0002 entre a verificação e o insert
+	taken := map[string]bool{"PH-0001": true, "PH-0002": true}
+	var seq int64 = 1
+	nextNumber := func() string { seq++; return fmt.Sprintf("PH-%04d", seq) }
+
+	var attempts []string
+	order := models.Order{OrderNumber: "PH-0001"}
+	err := CreateWithUniqueOrderNumber(&order, nextNumber, func(order *models.Order) error {
+		attempts = append(attempts, order.OrderNumber)
+		if taken[order.OrderNumber] {
+			return fmt.Errorf("insert: %w", conflict)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("erro inesperado: %v", err)
+	}
+	if order.OrderNumber != "PH-0003" || len(attempts) != 3 {
+		t.Errorf("esperado gravar PH-0003 na terceira tentativa, obtido %q após %v", order.OrderNumber, attempts)
+	}
+
+	t.Run("outros erros não geram novo número", func(t *testing.T) {
+		otherUnique := &pgconn.PgError{Code: "23505", ConstraintName: "orders_pkey"}
+		for _, failure := range []error{otherUnique, errors.New("conexão perdida")} {
+			calls := 0
+			order := models.Order{OrderNumber: "PH-0001"}
+			err := CreateWithUniqueOrderNumber(&order, nextNumber, func(*models.Order) error { calls++; return failure })
+			if !errors.Is(err, failure) || calls != 1 || order.OrderNumber != "PH-0001" {
+				t.Errorf("%v: esperado uma tentativa com o número original, obtido %d tentativas, número %q, erro %v", failure, calls, order.OrderNumber, err)
+			}
+		}
+	})
+
+	t.Run("desiste após as tentativas", func(t *testing.T) {
+		calls := 0
+		order := models.Order{OrderNumber: "PH-0001"}
+		err := CreateWithUniqueOrderNumber(&order, nextNumber, func(*models.Order) error { calls++; return conflict })
+		if !IsOrderNumberConflict(err) || calls != maxOrderNumberAttempts {
+			t.Errorf("esperado conflito após %d tentativas, obtido %d tentativas e erro %v", maxOrderNumberAttempts, calls, err)
+		}
+	})
+}
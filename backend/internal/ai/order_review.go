@@ -0,0 +1,71 @@
+package ai
+
+import (
+	"context"
+	"fmt"
+
+	"iafarma/internal/utils"
+	"iafarma/pkg/models"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+)
+
+// ManualReviewThresholdSettingKey define o valor (R$) acima do qual o pedido aguarda a confirmação de um operador ("0" = desabilitado)
+const ManualReviewThresholdSettingKey = "manual_review_order_threshold"
+
+// getManualReviewThreshold retorna o valor limite configurado pelo tenant (0 quando desabilitado)
+func (s *AIService) getManualReviewThreshold(ctx context.Context, tenantID uuid.UUID) utils.Cents {
+	if s.settingsService == nil {
+		return 0
+	}
+	setting, err := s.settingsService.GetSetting(ctx, tenantID, ManualReviewThresholdSettingKey)
+	if err != nil || setting == nil || setting.SettingValue == nil {
+		return 0
+	}
+	threshold, ok := utils.ParseCents(*setting.SettingValue)
+	if !ok || threshold < 0 {
+		return 0
+	}
+	return threshold
+}
+
+// requiresManualReview indica se o total do pedido ultrapassa o valor limite (limite 0 desabilita a revisão)
+func requiresManualReview(totalAmount string, threshold utils.Cents) bool {
+	if threshold <= 0 {
+		return false
+	}
+	total, ok := utils.ParseCents(totalAmount)
+	return ok && total > threshold
+}
+
+// applyManualReview encaminha pedidos de valor alto para a confirmação manual: marca o pedido e avisa os operadores.
+// Retorna true quando o pedido ficou aguardando revisão.
+func (s *AIService) applyManualReview(ctx context.Context, tenantID, customerID uuid.UUID, customerPhone string, order *models.Order) bool {
+	threshold := s.getManualReviewThreshold(ctx, tenantID)
+	if !requiresManualReview(order.TotalAmount, threshold) {
+		return false
+	}
+
+	if err := s.orderService.MarkOrderForReview(tenantID, order.ID); err != nil {
+		log.Error().Err(err).Str("order_id", order.ID.String()).Msg("Erro ao marcar pedido para confirmação manual")
+	} else {
+		order.RequiresReview = true
+	}
+
+	if s.alertService != nil {
+		reason := fmt.Sprintf("Pedido %s de R$ %s acima do limite de R$ %s - confirmar manualmente com o cliente",
+			order.OrderNumber, formatCurrency(order.TotalAmount), formatCurrency(threshold.String()))
+		if err := s.alertService.SendHumanSupportAlert(tenantID, customerID, customerPhone, reason); err != nil {
+			log.Error().Err(err).Str("order_id", order.ID.String()).Msg("Erro ao avisar operadores sobre pedido para confirmação manual")
+		}
+	}
+
+	log.Info().
+		Str("tenant_id", tenantID.String()).
+		Str("order_number", order.OrderNumber).
+		Str("total", order.TotalAmount).
+		Str("threshold", threshold.String()).
+		Msg("🔎 Pedido de valor alto encaminhado para confirmação manual")
+	return true
+}
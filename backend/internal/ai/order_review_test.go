@@ -0,0 +1,112 @@
+package ai
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"iafarma/internal/utils"
+	"iafarma/pkg/models"
+
+	"github.com/google/uuid"
+)
+
+// reviewOrderService cria pedidos com o total configurado e registra os encaminhados para revisão
+type reviewOrderService struct {
+	fakeOrderService
+	total    string
+	reviewed []uuid.UUID
+}
+
+func (f *reviewOrderService) CreateOrderFromCartWithAddress(tenantID, cartID uuid.UUID, deliveryAddress *models.Address) (*models.Order, error) {
+	order, err := f.fakeOrderService.CreateOrderFromCartWithAddress(tenantID, cartID, deliveryAddress)
+	if err != nil {
+		return nil, err
+	}
+	order.ID = uuid.New()
+	order.TotalAmount = f.total
+	return order, nil
+}
+
+func (f *reviewOrderService) MarkOrderForReview(tenantID, orderID uuid.UUID) error {
+	f.reviewed = append(f.reviewed, orderID)
+	return nil
+}
+
+// supportAlertRecorder registra os motivos enviados ao grupo de atendimento humano
+type supportAlertRecorder struct {
+	fakeAlertService
+	reasons []string
+}
+
+func (r *supportAlertRecorder) SendHumanSupportAlert(tenantID uuid.UUID, customerID uuid.UUID, customerPhone string, reason string) error {
+	r.reasons = append(r.reasons, reason)
+	return nil
+}
+
+func TestRequiresManualReview(t *testing.T) {
+	tests := []struct {
+		total     string
+		threshold utils.Cents
+		esperado  bool
+	}{
+		{"1500.00", 0, false},
+		{"999.99", 100000, false},
+		{"1000.00", 100000, false},
+		{"1000.01", 100000, true},
+		{"2500,00", 100000, true},
+		{"", 100000, false},
+	}
+
+	for _, tt := range tests {
+		if obtido := requiresManualReview(tt.total, tt.threshold); obtido != tt.esperado {
+			t.Errorf("total %q, limite %s: esperado %v, obtido %v", tt.total, tt.threshold, tt.esperado, obtido)
+		}
+	}
+}
+
+func TestCheckoutFinalEncaminhaPedidoAcimaDoLimite(t *testing.T) {
+	tests := []struct {
+		name      string
+		total     string
+		threshold string
+		revisao   bool
+	}{
+		{"abaixo do limite segue normal", "450.00", "1000", false},
+		{"acima do limite aguarda confirmação", "1250.00", "1000", true},
+		{"limite desabilitado", "5000.00", "0", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, _ := newTestService(map[string]string{AllowPickupSettingKey: "true"}, withCheckout(newPickupTestCart(true)))
+			s.settingsService.(*fakeSettingsService).values[ManualReviewThresholdSettingKey] = tt.threshold
+			orders := &reviewOrderService{total: tt.total}
+			alerts := &supportAlertRecorder{}
+			s.orderService = orders
+			s.alertService = alerts
+
+			result, err := s.performFinalCheckout(context.Background(), uuid.New(), uuid.New(), "5561999999999")
+			if err != nil {
+				t.Fatalf("erro inesperado: %v", err)
+			}
+			if !strings.Contains(result, "Pedido registrado") {
+				t.Fatalf("pedido deveria ser registrado:\n%s", result)
+			}
+
+			if tt.revisao {
+				if len(orders.reviewed) != 1 || len(alerts.reasons) != 1 || !strings.Contains(alerts.reasons[0], "R$ 1.250,00") {
+					t.Errorf("esperado pedido marcado para revisão e operadores avisados, obtido %v / %v", orders.reviewed, alerts.reasons)
+				}
+				if !strings.Contains(result, "Confirmação manual") {
+					t.Errorf("cliente deveria saber que o pedido será confirmado em instantes:\n%s", result)
+				}
+				return
+			}
+
+			if len(orders.reviewed) != 0 || len(alerts.reasons) != 0 || strings.Contains(result, "Confirmação manual") {
+				t.Errorf("pedido abaixo do limite não deveria ir para revisão:\n%s", result)
+			}
+		})
+	}
+}
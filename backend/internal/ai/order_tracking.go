@@ -0,0 +1,115 @@
+package ai
+
+import (
+	"fmt"
+	"strings"
+
+	"iafarma/pkg/models"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+)
+
+// orderTrackingStatus resolve a etapa de entrega do pedido a partir do status do pedido e do status de entrega
+// marcados pelos operadores (qualquer um dos dois pode indicar o envio ou a entrega)
+func orderTrackingStatus(order *models.Order) string {
+	switch {
+	case order.Status == "cancelled" || order.FulfillmentStatus == "cancelled":
+		return "cancelled"
+	case order.Status == "delivered" || order.FulfillmentStatus == "delivered" || order.DeliveredAt != nil:
+		return "delivered"
+	case order.Status == "shipped" || order.FulfillmentStatus == "shipped" || order.ShippedAt != nil:
+		return "shipped"
+	default:
+		return order.Status
+	}
+}
+
+// formatOrderTracking responde "já saiu pra entrega?" com a etapa do pedido e, depois do envio, o rastreio ou a previsão
+func formatOrderTracking(order *models.Order) string {
+	var result strings.Builder
+
+	switch orderTrackingStatus(order) {
+	case "shipped":
+		result.WriteString(fmt.Sprintf("🚚 Seu pedido **%s** já saiu para entrega!", order.OrderNumber))
+		if order.ShippedAt != nil {
+			result.WriteString(fmt.Sprintf(" (enviado em %s)", order.ShippedAt.Format("02/01/2006 15:04")))
+		}
+		result.WriteString("\n")
+		if order.TrackingCode != "" {
+			result.WriteString(fmt.Sprintf("\n📦 Código de rastreio: **%s**", order.TrackingCode))
+		}
+		if order.TrackingURL != "" {
+			result.WriteString(fmt.Sprintf("\n🔗 Acompanhe a entrega: %s", order.TrackingURL))
+		}
+		if order.EstimatedDeliveryAt != nil {
+			result.WriteString(fmt.Sprintf("\n🕒 Previsão de entrega: **%s**", order.EstimatedDeliveryAt.Format("02/01/2006 15:04")))
+		}
+		if order.TrackingCode == "" && order.TrackingURL == "" && order.EstimatedDeliveryAt == nil {
+			result.WriteString("\n📞 Ainda não temos o rastreio deste envio. Se precisar, a nossa equipe informa a previsão de chegada.")
+		}
+	case "delivered":
+		result.WriteString(fmt.Sprintf("✅ Seu pedido **%s** já foi entregue", order.OrderNumber))
+		if order.DeliveredAt != nil {
+			result.WriteString(fmt.Sprintf(" em %s", order.DeliveredAt.Format("02/01/2006 15:04")))
+		}
+		result.WriteString(".\n\n💬 Se não recebeu ou teve algum problema, é só me avisar que chamo a nossa equipe.")
+	case "cancelled":
+		result.WriteString(fmt.Sprintf("❌ O pedido **%s** foi cancelado e não será enviado.\n\n💬 Se tiver dúvidas, posso chamar a nossa equipe.", order.OrderNumber))
+	case "confirmed":
+		result.WriteString(fmt.Sprintf("✅ Seu pedido **%s** foi confirmado e logo entra em separação. %s", order.OrderNumber, notShippedYet(order)))
+	case "processing", "preparing":
+		result.WriteString(fmt.Sprintf("⚙️ Seu pedido **%s** está sendo separado. %s", order.OrderNumber, notShippedYet(order)))
+	default:
+		result.WriteString(fmt.Sprintf("⏳ Seu pedido **%s** foi recebido e aguarda a confirmação da loja. %s", order.OrderNumber, notShippedYet(order)))
+	}
+	return result.String()
+}
+
+// notShippedYet explica que o pedido ainda não saiu, conforme a forma de entrega
+func notShippedYet(order *models.Order) string {
+	if order.IsPickup {
+		return "Ainda não está pronto para **retirada na loja** - aviso assim que estiver."
+	}
+	return "Ainda não saiu para entrega - aviso assim que sair."
+}
+
+// handleRastrearPedido informa se o pedido já saiu para entrega e mostra o rastreio ou a previsão de chegada.
+// Sem identificador, usa o pedido mais recente do cliente.
+func (s *AIService) handleRastrearPedido(tenantID, customerID uuid.UUID, args map[string]interface{}) (string, error) {
+	notFound := "❌ Pedido não encontrado. Use 'histórico de pedidos' para ver seus pedidos e depois 'rastrear pedido [número]'."
+
+	var order *models.Order
+	if identifier, _ := args["order_id"].(string); strings.TrimSpace(identifier) != "" {
+		orderID, found := s.resolveCustomerOrderID(tenantID, customerID, identifier)
+		if !found {
+			return notFound, nil
+		}
+		loaded, err := s.orderService.GetOrderByID(tenantID, orderID)
+		if err != nil || loaded == nil {
+			return notFound, nil
+		}
+		order = loaded
+	} else {
+		orders, err := s.orderService.GetOrdersByCustomer(tenantID, customerID)
+		if err != nil {
+			return "❌ Erro ao buscar seus pedidos.", err
+		}
+		if len(orders) == 0 {
+			return "📦 Você ainda não tem pedidos. Quando fizer um, posso te dizer quando ele sair para entrega!", nil
+		}
+		order = &orders[0]
+	}
+
+	// Não revelar pedidos de outros clientes
+	if order.CustomerID == nil || *order.CustomerID != customerID {
+		log.Warn().
+			Str("tenant_id", tenantID.String()).
+			Str("customer_id", customerID.String()).
+			Str("order_id", order.ID.String()).
+			Msg("🚫 Tentativa de rastrear pedido de outro cliente")
+		return notFound, nil
+	}
+
+	return formatOrderTracking(order), nil
+}
@@ -0,0 +1,114 @@
+package ai
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"iafarma/pkg/models"
+
+	"github.com/google/uuid"
+)
+
+func TestFormatOrderTrackingByStatus(t *testing.T) {
+	shippedAt := time.Date(2026, 3, 10, 14, 30, 0, 0, time.UTC)
+	deliveredAt := time.Date(2026, 3, 10, 16, 5, 0, 0, time.UTC)
+	eta := time.Date(2026, 3, 10, 17, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		order     models.Order
+		esperado  []string
+		proibidos []string
+	}{
+		{"pendente", models.Order{Status: "pending"}, []string{"⏳", "aguarda a confirmação", "Ainda não saiu para entrega"}, []string{"já saiu"}},
+		{"confirmado", models.Order{Status: "confirmed"}, []string{"foi confirmado", "Ainda não saiu para entrega"}, []string{"já saiu"}},
+		{"em separação", models.Order{Status: "processing"}, []string{"está sendo separado", "Ainda não saiu para entrega"}, []string{"já saiu"}},
+		{"preparando", models.Order{Status: "preparing"}, []string{"está sendo separado"}, []string{"já saiu"}},
+		{"retirada em separação", models.Order{Status: "processing", IsPickup: true}, []string{"pronto para **retirada na loja**"}, []string{"saiu para entrega"}},
+		{"enviado com rastreio", models.Order{
+			Status: "confirmed", FulfillmentStatus: "shipped", ShippedAt: &shippedAt,
+			TrackingCode: "BR123456789", TrackingURL: "https://rastreio.exemplo.com/BR123456789", EstimatedDeliveryAt: &eta,
+		}, []string{"já saiu para entrega", "10/03/2026 14:30", "**BR123456789**", "https://rastreio.exemplo.com/BR123456789", "Previsão de entrega: **10/03/2026 17:00**"}, []string{"Ainda não temos o rastreio"}},
+		{"enviado sem rastreio", models.Order{Status: "shipped"}, []string{"já saiu para entrega", "Ainda não temos o rastreio"}, []string{"Código de rastreio"}},
+		{"entregue", models.Order{Status: "shipped", FulfillmentStatus: "delivered", DeliveredAt: &deliveredAt}, []string{"já foi entregue em 10/03/2026 16:05"}, []string{"saiu para entrega"}},
+		{"cancelado", models.Order{Status: "cancelled", FulfillmentStatus: "shipped"}, []string{"foi cancelado"}, []string{"saiu para entrega"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.order.OrderNumber = "PED-001"
+			obtido := formatOrderTracking(&tt.order)
+			if !strings.Contains(obtido, "PED-001") {
+				t.Errorf("resposta deveria citar o pedido:\n%s", obtido)
+			}
+			for _, esperado := range tt.esperado {
+				if !strings.Contains(obtido, esperado) {
+					t.Errorf("esperado %q em:\n%s", esperado, obtido)
+				}
+			}
+			for _, proibido := range tt.proibidos {
+				if strings.Contains(obtido, proibido) {
+					t.Errorf("não esperado %q em:\n%s", proibido, obtido)
+				}
+			}
+		})
+	}
+}
+
+func TestRastrearPedidoUsesMostRecentOrder(t *testing.T) {
+	tenantID := uuid.New()
+	customerID := uuid.New()
+
+	recente := newTestOrder(tenantID, customerID, "PED-002", "Dipirona")
+	recente.Status = "shipped"
+	recente.TrackingCode = "BR999"
+	antigo := newTestOrder(tenantID, customerID, "PED-001", "Sabonete")
+	antigo.Status = "delivered"
+
+	s := &AIService{orderService: &fakeOrderService{orders: []models.Order{recente, antigo}}, memoryManager: NewMemoryManager()}
+
+	obtido, err := s.handleRastrearPedido(tenantID, customerID, map[string]interface{}{})
+	if err != nil {
+		t.Fatalf("erro inesperado: %v", err)
+	}
+	if !strings.Contains(obtido, "PED-002") || !strings.Contains(obtido, "BR999") {
+		t.Errorf("esperado rastreio do pedido mais recente:\n%s", obtido)
+	}
+
+	obtido, err = s.handleRastrearPedido(tenantID, customerID, map[string]interface{}{"order_id": "PED-001"})
+	if err != nil {
+		t.Fatalf("erro inesperado: %v", err)
+	}
+	if !strings.Contains(obtido, "PED-001") || !strings.Contains(obtido, "já foi entregue") {
+		t.Errorf("esperado status do pedido informado:\n%s", obtido)
+	}
+}
+
+func TestRastrearPedidoHidesOtherCustomersOrders(t *testing.T) {
+	tenantID := uuid.New()
+	outroCliente := newTestOrder(tenantID, uuid.New(), "PED-009", "Dipirona")
+	outroCliente.Status = "shipped"
+
+	s := &AIService{orderService: &fakeOrderService{orders: []models.Order{outroCliente}}, memoryManager: NewMemoryManager()}
+
+	obtido, err := s.handleRastrearPedido(tenantID, uuid.New(), map[string]interface{}{"order_id": outroCliente.ID.String()})
+	if err != nil {
+		t.Fatalf("erro inesperado: %v", err)
+	}
+	if !strings.Contains(obtido, "Pedido não encontrado") || strings.Contains(obtido, "PED-009") {
+		t.Errorf("pedido de outro cliente não deveria ser exibido:\n%s", obtido)
+	}
+}
+
+func TestRastrearPedidoWithoutOrders(t *testing.T) {
+	s := &AIService{orderService: &fakeOrderService{}, memoryManager: NewMemoryManager()}
+
+	obtido, err := s.handleRastrearPedido(uuid.New(), uuid.New(), map[string]interface{}{})
+	if err != nil {
+		t.Fatalf("erro inesperado: %v", err)
+	}
+	if !strings.Contains(obtido, "ainda não tem pedidos") {
+		t.Errorf("esperado aviso de que não há pedidos:\n%s", obtido)
+	}
+}
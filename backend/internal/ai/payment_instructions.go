@@ -0,0 +1,69 @@
+package ai
+
+import (
+	"fmt"
+	"strings"
+
+	"iafarma/pkg/models"
+
+	"github.com/google/uuid"
+)
+
+// awaitingPaymentOrder retorna o pedido mais recente do cliente que ainda aguarda pagamento.
+// A lista vem do mais recente para o mais antigo; pedidos cancelados ou já entregues não cobram mais nada.
+func awaitingPaymentOrder(orders []models.Order) *models.Order {
+	for i := range orders {
+		if orders[i].Status == "cancelled" || orders[i].Status == "delivered" || orders[i].PaymentStatus == "paid" {
+			continue
+		}
+		return &orders[i]
+	}
+	return nil
+}
+
+// formatPaymentInstructions monta a resposta com a forma de pagamento, o valor e as instruções do pedido
+func formatPaymentInstructions(order *models.Order) string {
+	if order.PaymentMethod == nil {
+		return fmt.Sprintf("💳 Seu pedido **%s** não tem uma forma de pagamento registrada. Nossa equipe vai combinar o pagamento com você por aqui.", order.OrderNumber)
+	}
+
+	var result strings.Builder
+	result.WriteString(fmt.Sprintf("💳 **Pagamento do pedido %s**\n\n", order.OrderNumber))
+	result.WriteString(fmt.Sprintf("Forma de pagamento: **%s**\n", order.PaymentMethod.Name))
+	if order.HasDeposit && isValidPrice(order.AmountPaid) {
+		result.WriteString(fmt.Sprintf("Sinal a pagar agora: **R$ %s** (restante de R$ %s na entrega)\n", formatCurrency(order.AmountPaid), formatCurrency(order.AmountDue)))
+	} else {
+		result.WriteString(fmt.Sprintf("Valor: **R$ %s**\n", formatCurrency(order.TotalAmount)))
+	}
+
+	instructions := strings.TrimSpace(order.PaymentMethod.Instructions)
+	if instructions == "" {
+		result.WriteString(fmt.Sprintf("\nℹ️ Não há instruções cadastradas para pagamento com %s. Se precisar de algum dado, nossa equipe te ajuda por aqui.", order.PaymentMethod.Name))
+		return result.String()
+	}
+	result.WriteString("\n📋 **Instruções:**\n")
+	result.WriteString(instructions)
+	return result.String()
+}
+
+// handleConsultarInstrucoesPagamento reenvia as instruções da forma de pagamento do pedido mais recente
+// que ainda aguarda pagamento (ex: cliente pedindo a chave PIX de novo depois do checkout)
+func (s *AIService) handleConsultarInstrucoesPagamento(tenantID, customerID uuid.UUID) (string, error) {
+	orders, err := s.orderService.GetOrdersByCustomer(tenantID, customerID)
+	if err != nil {
+		return "❌ Erro ao buscar seus pedidos.", err
+	}
+	if len(orders) == 0 {
+		return "📦 Você ainda não tem pedidos. Quando finalizar uma compra, te passo os dados de pagamento!", nil
+	}
+
+	order := awaitingPaymentOrder(orders)
+	if order == nil {
+		if orders[0].PaymentStatus == "paid" {
+			return fmt.Sprintf("✅ O pagamento do seu pedido **%s** já foi confirmado. Não há nada pendente!", orders[0].OrderNumber), nil
+		}
+		return "✅ Você não tem pedidos aguardando pagamento.", nil
+	}
+
+	return formatPaymentInstructions(order), nil
+}
@@ -0,0 +1,134 @@
+package ai
+
+import (
+	"strings"
+	"testing"
+
+	"iafarma/pkg/models"
+
+	"github.com/google/uuid"
+)
+
+func newPixPaymentMethod(instructions string) *models.PaymentMethod {
+	method := &models.PaymentMethod{Name: "PIX", Instructions: instructions, IsActive: true}
+	method.ID = uuid.New()
+	return method
+}
+
+func TestAwaitingPaymentOrder(t *testing.T) {
+	pendente := models.Order{OrderNumber: "PED-001", Status: "pending", PaymentStatus: "pending"}
+	pago := models.Order{OrderNumber: "PED-002", Status: "confirmed", PaymentStatus: "paid"}
+	cancelado := models.Order{OrderNumber: "PED-003", Status: "cancelled", PaymentStatus: "pending"}
+	entregue := models.Order{OrderNumber: "PED-004", Status: "delivered", PaymentStatus: "pending"}
+
+	tests := []struct {
+		name     string
+		orders   []models.Order
+		esperado string
+	}{
+		{"sem pedidos", nil, ""},
+		{"mais recente pendente", []models.Order{pendente, pago}, "PED-001"},
+		{"ignora pagos, cancelados e entregues", []models.Order{pago, cancelado, entregue, pendente}, "PED-001"},
+		{"nada pendente", []models.Order{pago, cancelado}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			obtido := ""
+			if order := awaitingPaymentOrder(tt.orders); order != nil {
+				obtido = order.OrderNumber
+			}
+			if obtido != tt.esperado {
+				t.Errorf("esperado %q, obtido %q", tt.esperado, obtido)
+			}
+		})
+	}
+}
+
+func TestConsultarInstrucoesPagamentoReenviaInstrucoes(t *testing.T) {
+	tenantID := uuid.New()
+	customerID := uuid.New()
+
+	recente := newTestOrder(tenantID, customerID, "PED-002", "Dipirona")
+	recente.Status = "pending"
+	recente.PaymentStatus = "pending"
+	recente.PaymentMethod = newPixPaymentMethod("Chave PIX (CNPJ): 12.345.678/0001-90")
+	antigo := newTestOrder(tenantID, customerID, "PED-001", "Sabonete")
+	antigo.PaymentStatus = "paid"
+
+	s := &AIService{orderService: &fakeOrderService{orders: []models.Order{recente, antigo}}}
+
+	obtido, err := s.handleConsultarInstrucoesPagamento(tenantID, customerID)
+	if err != nil {
+		t.Fatalf("erro inesperado: %v", err)
+	}
+	for _, esperado := range []string{"PED-002", "PIX", "R$ 25,80", "Chave PIX (CNPJ): 12.345.678/0001-90"} {
+		if !strings.Contains(obtido, esperado) {
+			t.Errorf("esperado %q em:\n%s", esperado, obtido)
+		}
+	}
+}
+
+func TestConsultarInstrucoesPagamentoComSinal(t *testing.T) {
+	tenantID := uuid.New()
+	customerID := uuid.New()
+
+	order := newTestOrder(tenantID, customerID, "PED-001", "Nebulizador")
+	order.PaymentStatus = "pending"
+	order.PaymentMethod = newPixPaymentMethod("Chave PIX: loja@exemplo.com")
+	order.HasDeposit = true
+	order.AmountPaid = "10.00"
+	order.AmountDue = "15.80"
+
+	s := &AIService{orderService: &fakeOrderService{orders: []models.Order{order}}}
+
+	obtido, _ := s.handleConsultarInstrucoesPagamento(tenantID, customerID)
+	if !strings.Contains(obtido, "Sinal a pagar agora: **R$ 10,00**") || !strings.Contains(obtido, "R$ 15,80") {
+		t.Errorf("esperado valor do sinal e do restante:\n%s", obtido)
+	}
+}
+
+func TestConsultarInstrucoesPagamentoSemInstrucoes(t *testing.T) {
+	tenantID := uuid.New()
+	customerID := uuid.New()
+
+	semForma := newTestOrder(tenantID, customerID, "PED-001", "Dipirona")
+	semForma.PaymentStatus = "pending"
+
+	s := &AIService{orderService: &fakeOrderService{orders: []models.Order{semForma}}}
+	obtido, _ := s.handleConsultarInstrucoesPagamento(tenantID, customerID)
+	if !strings.Contains(obtido, "não tem uma forma de pagamento registrada") {
+		t.Errorf("esperado aviso de pedido sem forma de pagamento:\n%s", obtido)
+	}
+
+	semForma.PaymentMethod = newPixPaymentMethod("")
+	s = &AIService{orderService: &fakeOrderService{orders: []models.Order{semForma}}}
+	obtido, _ = s.handleConsultarInstrucoesPagamento(tenantID, customerID)
+	if !strings.Contains(obtido, "Não há instruções cadastradas para pagamento com PIX") {
+		t.Errorf("esperado aviso de forma de pagamento sem instruções:\n%s", obtido)
+	}
+}
+
+func TestConsultarInstrucoesPagamentoPedidoPago(t *testing.T) {
+	tenantID := uuid.New()
+	customerID := uuid.New()
+
+	pago := newTestOrder(tenantID, customerID, "PED-003", "Dipirona")
+	pago.PaymentStatus = "paid"
+	pago.PaymentMethod = newPixPaymentMethod("Chave PIX: loja@exemplo.com")
+
+	s := &AIService{orderService: &fakeOrderService{orders: []models.Order{pago}}}
+	obtido, err := s.handleConsultarInstrucoesPagamento(tenantID, customerID)
+	if err != nil {
+		t.Fatalf("erro inesperado: %v", err)
+	}
+	if !strings.Contains(obtido, "PED-003") || !strings.Contains(obtido, "já foi confirmado") || strings.Contains(obtido, "loja@exemplo.com") {
+		t.Errorf("pedido pago não deveria reenviar instruções:\n%s", obtido)
+	}
+
+	s = &AIService{orderService: &fakeOrderService{}}
+	obtido, _ = s.handleConsultarInstrucoesPagamento(tenantID, customerID)
+	if !strings.Contains(obtido, "ainda não tem pedidos") {
+		t.Errorf("esperado aviso de cliente sem pedidos:\n%s", obtido)
+	}
+}
@@ -0,0 +1,123 @@
+package ai
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"iafarma/pkg/models"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// AllowPickupSettingKey habilita a opção de retirada na loja (sem entrega)
+	AllowPickupSettingKey = "allow_pickup"
+	// PickupTimeWindowSettingKey define o prazo de retirada informado ao cliente
+	PickupTimeWindowSettingKey = "pickup_time_window"
+
+	defaultPickupTimeWindow = "a partir de 30 minutos após a confirmação do pedido"
+)
+
+// pickupConfirmationButtons são os botões exibidos na confirmação de retirada na loja
+var pickupConfirmationButtons = []ResponseButton{
+	{ID: "sim", Title: "Sim"},
+	{ID: "nao", Title: "Não"},
+}
+
+// isPickupAllowed indica se o tenant oferece retirada na loja (desabilitado por padrão)
+func (s *AIService) isPickupAllowed(ctx context.Context, tenantID uuid.UUID) bool {
+	if s.settingsService == nil {
+		return false
+	}
+
+	setting, err := s.settingsService.GetSetting(ctx, tenantID, AllowPickupSettingKey)
+	if err != nil || setting == nil || setting.SettingValue == nil {
+		return false
+	}
+
+	allowed, err := strconv.ParseBool(strings.TrimSpace(*setting.SettingValue))
+	return err == nil && allowed
+}
+
+// getPickupTimeWindow retorna o prazo de retirada configurado pelo tenant
+func (s *AIService) getPickupTimeWindow(ctx context.Context, tenantID uuid.UUID) string {
+	if s.settingsService == nil {
+		return defaultPickupTimeWindow
+	}
+
+	setting, err := s.settingsService.GetSetting(ctx, tenantID, PickupTimeWindowSettingKey)
+	if err != nil || setting == nil || setting.SettingValue == nil || strings.TrimSpace(*setting.SettingValue) == "" {
+		return defaultPickupTimeWindow
+	}
+	return strings.TrimSpace(*setting.SettingValue)
+}
+
+// getPickupStoreAddress retorna o endereço da loja para retirada (vazio se não configurado)
+func (s *AIService) getPickupStoreAddress(tenantID uuid.UUID) string {
+	if s.deliveryService == nil {
+		return ""
+	}
+
+	store, err := s.deliveryService.GetStoreLocation(tenantID)
+	if err != nil || store == nil {
+		log.Warn().Err(err).Str("tenant_id", tenantID.String()).Msg("Endereço da loja não disponível para retirada")
+		return ""
+	}
+	return store.Address
+}
+
+// formatPickupDetails monta o endereço da loja e o prazo de retirada
+func (s *AIService) formatPickupDetails(ctx context.Context, tenantID uuid.UUID) string {
+	storeAddress := s.getPickupStoreAddress(tenantID)
+	if storeAddress == "" {
+		storeAddress = "Endereço da loja será informado na confirmação do pedido"
+	}
+	return fmt.Sprintf("🏪 **Retirada na loja:**\n📍 %s\n🕐 **Prazo para retirada:** %s", storeAddress, s.getPickupTimeWindow(ctx, tenantID))
+}
+
+// pickupCheckoutMessage monta a confirmação do checkout para retirada na loja
+func (s *AIService) pickupCheckoutMessage(ctx context.Context, tenantID uuid.UUID, cartMessage string) string {
+	return fmt.Sprintf("%s\n\n%s\n\n✅ **Confirma a retirada do pedido na loja?**\n\n💬 Responda:\n🟢 **'sim'** ou **'confirmar'** - para finalizar o pedido\n🚚 **'quero entrega'** - para receber em casa", cartMessage, s.formatPickupDetails(ctx, tenantID))
+}
+
+// handleRetiradaNaLoja alterna o carrinho entre retirada na loja e entrega
+func (s *AIService) handleRetiradaNaLoja(ctx context.Context, tenantID, customerID uuid.UUID, customerPhone string, args map[string]interface{}) (string, error) {
+	if !s.isPickupAllowed(ctx, tenantID) {
+		return "❌ No momento não oferecemos retirada na loja. Seu pedido será entregue no endereço cadastrado.", nil
+	}
+
+	pickup := true
+	if value, ok := args["retirada"].(bool); ok {
+		pickup = value
+	}
+
+	cart, err := s.cartService.GetOrCreateActiveCart(tenantID, customerID)
+	if err != nil {
+		return "❌ Erro ao acessar carrinho.", err
+	}
+
+	if err := s.cartService.SetCartPickup(cart.ID, tenantID, pickup); err != nil {
+		return "❌ Erro ao atualizar a forma de recebimento do pedido.", err
+	}
+
+	log.Info().
+		Str("tenant_id", tenantID.String()).
+		Str("customer_id", customerID.String()).
+		Bool("pickup", pickup).
+		Msg("🏪 Forma de recebimento do pedido atualizada")
+
+	if !pickup {
+		return "🚚 Combinado! Seu pedido será **entregue** no seu endereço. Quando quiser, é só pedir para finalizar.", nil
+	}
+
+	// Seguir direto para o checkout com a confirmação da retirada
+	return s.handleCheckout(ctx, tenantID, customerID, customerPhone)
+}
+
+// isPickupCart indica se o carrinho deve seguir o fluxo de retirada (ignorando endereço e validação de entrega)
+func (s *AIService) isPickupCart(ctx context.Context, tenantID uuid.UUID, cart *models.Cart) bool {
+	return cart != nil && cart.IsPickup && s.isPickupAllowed(ctx, tenantID)
+}
@@ -0,0 +1,123 @@
+package ai
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"iafarma/pkg/models"
+
+	"github.com/google/uuid"
+)
+
+// fakeDeliveryService registra as validações de entrega solicitadas
+type fakeDeliveryService struct {
+	DeliveryServiceInterface
+	validations int
+}
+
+func (f *fakeDeliveryService) ValidateDeliveryAddress(tenantID uuid.UUID, street, number, neighborhood, city, state string) (*DeliveryValidationResult, error) {
+	f.validations++
+	return &DeliveryValidationResult{CanDeliver: false, Reason: "outside_radius"}, nil
+}
+
+func (f *fakeDeliveryService) GetStoreLocation(tenantID uuid.UUID) (*StoreLocationInfo, error) {
+	return &StoreLocationInfo{Address: "Av. Central, 100, Centro, Brasília, DF"}, nil
+}
+
+func newPickupTestCart(pickup bool) *models.Cart {
+	paymentMethodID := uuid.New()
+	return &models.Cart{
+		PaymentMethodID: &paymentMethodID,
+		IsPickup:        pickup,
+		Items: []models.CartItem{
+			{Quantity: 1, Price: "10.00", Product: &models.Product{Name: "Sabonete", Price: "10.00"}},
+		},
+	}
+}
+
+func TestPickupCheckoutSkipsDeliveryValidation(t *testing.T) {
+	tenantID := uuid.New()
+	customerID := uuid.New()
+	phone := "5561999999999"
+
+	// Sem endereço cadastrado: só é possível finalizar por retirada
+	s, fakes := newTestService(map[string]string{AllowPickupSettingKey: "true"}, withCheckout(newPickupTestCart(true)))
+	delivery, orders := fakes.delivery, fakes.orders
+
+	checkout, err := s.handleCheckout(context.Background(), tenantID, customerID, phone)
+	if err != nil {
+		t.Fatalf("erro inesperado no checkout: %v", err)
+	}
+	if !strings.Contains(checkout, "Av. Central, 100") || !strings.Contains(checkout, defaultPickupTimeWindow) {
+		t.Errorf("checkout deveria confirmar endereço da loja e prazo de retirada:\n%s", checkout)
+	}
+	if strings.Contains(checkout, "precisamos do seu endereço") {
+		t.Errorf("checkout de retirada não deveria pedir endereço:\n%s", checkout)
+	}
+	if response := buildAIResponse(checkout, s.takePendingResponse(tenantID, phone)); !response.HasButtons() {
+		t.Errorf("confirmação de retirada deveria exibir botões")
+	}
+
+	result, err := s.performFinalCheckout(context.Background(), tenantID, customerID, phone)
+	if err != nil {
+		t.Fatalf("erro inesperado no checkout final: %v", err)
+	}
+	if !strings.Contains(result, "Pedido registrado") {
+		t.Fatalf("pedido de retirada deveria ser criado:\n%s", result)
+	}
+	if delivery.validations != 0 {
+		t.Errorf("retirada não deveria validar entrega, validações = %d", delivery.validations)
+	}
+	if len(orders.orders) != 1 || orders.orders[0].ShippingStreet != nil {
+		t.Errorf("pedido de retirada não deveria ter endereço de entrega: %+v", orders.orders)
+	}
+}
+
+func TestPickupIgnoredWhenTenantDisallows(t *testing.T) {
+	tenantID := uuid.New()
+	customerID := uuid.New()
+	addresses := []models.Address{{Street: "Rua das Flores", Number: "123", City: "Brasília", State: "DF", ZipCode: "70000-000"}}
+
+	s, fakes := newTestService(map[string]string{AllowPickupSettingKey: "false"}, withCheckout(newPickupTestCart(true), addresses...))
+
+	delivery, orders := fakes.delivery, fakes.orders
+
+	result, err := s.performFinalCheckout(context.Background(), tenantID, customerID, "5561999999999")
+	if err != nil {
+		t.Fatalf("erro inesperado: %v", err)
+	}
+	if delivery.validations != 1 {
+		t.Errorf("sem retirada habilitada a entrega deve ser validada, validações = %d", delivery.validations)
+	}
+	if !strings.Contains(result, "Não fazemos entrega") || len(orders.orders) != 0 {
+		t.Errorf("pedido não deveria ser criado fora da área de entrega:\n%s", result)
+	}
+
+	message, _ := s.handleRetiradaNaLoja(context.Background(), tenantID, customerID, "5561999999999", map[string]interface{}{"retirada": true})
+	if !strings.Contains(message, "não oferecemos retirada") {
+		t.Errorf("retiradaNaLoja deveria ser recusada: %q", message)
+	}
+}
+
+func TestHandleRetiradaNaLojaTogglesCart(t *testing.T) {
+	tenantID := uuid.New()
+	customerID := uuid.New()
+	cart := newPickupTestCart(false)
+	s, _ := newTestService(map[string]string{AllowPickupSettingKey: "true"}, withCheckout(cart))
+
+	message, err := s.handleRetiradaNaLoja(context.Background(), tenantID, customerID, "5561999999999", map[string]interface{}{"retirada": true})
+	if err != nil {
+		t.Fatalf("erro inesperado: %v", err)
+	}
+	if !cart.IsPickup || !strings.Contains(message, "Confirma a retirada") {
+		t.Errorf("carrinho deveria seguir para confirmação de retirada: pickup=%v\n%s", cart.IsPickup, message)
+	}
+
+	if _, err := s.handleRetiradaNaLoja(context.Background(), tenantID, customerID, "5561999999999", map[string]interface{}{"retirada": false}); err != nil {
+		t.Fatalf("erro inesperado: %v", err)
+	}
+	if cart.IsPickup {
+		t.Errorf("carrinho deveria voltar para entrega")
+	}
+}
@@ -0,0 +1,210 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"iafarma/pkg/models"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+)
+
+// PrepTimeSettingKey é a configuração do tenant com os tempos de preparo (JSON)
+const PrepTimeSettingKey = "prep_time_config"
+
+// PrepTimeConfig representa os tempos de preparo configurados pelo tenant, em minutos.
+// Produtos podem ser identificados por ID ou SKU e categorias por ID ou nome.
+type PrepTimeConfig struct {
+	DefaultMinutes int            `json:"default_minutes"`
+	Categories     map[string]int `json:"categories"`
+	Products       map[string]int `json:"products"`
+}
+
+// prepTimeItem contém os dados de um item necessários para resolver o tempo de preparo
+type prepTimeItem struct {
+	ProductID    string
+	SKU          string
+	CategoryID   string
+	CategoryName string
+}
+
+// resolvePrepTime retorna o tempo de preparo de um item, priorizando produto, depois categoria e por fim o padrão
+func resolvePrepTime(config *PrepTimeConfig, item prepTimeItem) (int, bool) {
+	if config == nil {
+		return 0, false
+	}
+
+	for _, key := range []string{item.ProductID, item.SKU} {
+		if key == "" {
+			continue
+		}
+		if minutes, ok := config.Products[key]; ok && minutes > 0 {
+			return minutes, true
+		}
+	}
+
+	if item.CategoryID != "" {
+		if minutes, ok := config.Categories[item.CategoryID]; ok && minutes > 0 {
+			return minutes, true
+		}
+	}
+
+	if item.CategoryName != "" {
+		for name, minutes := range config.Categories {
+			if minutes > 0 && strings.EqualFold(strings.TrimSpace(name), strings.TrimSpace(item.CategoryName)) {
+				return minutes, true
+			}
+		}
+	}
+
+	if config.DefaultMinutes > 0 {
+		return config.DefaultMinutes, true
+	}
+
+	return 0, false
+}
+
+// aggregatePrepTime retorna o maior tempo de preparo entre os itens (itens são preparados em paralelo)
+func aggregatePrepTime(config *PrepTimeConfig, items []prepTimeItem) (int, bool) {
+	maxMinutes := 0
+	found := false
+
+	for _, item := range items {
+		if minutes, ok := resolvePrepTime(config, item); ok {
+			found = true
+			if minutes > maxMinutes {
+				maxMinutes = minutes
+			}
+		}
+	}
+
+	return maxMinutes, found
+}
+
+// parsePrepTimeConfig converte o JSON da configuração, retornando nil quando vazio ou inválido
+func parsePrepTimeConfig(value string) *PrepTimeConfig {
+	if strings.TrimSpace(value) == "" {
+		return nil
+	}
+
+	var config PrepTimeConfig
+	if err := json.Unmarshal([]byte(value), &config); err != nil {
+		log.Warn().Err(err).Msg("⚠️ Configuração de tempo de preparo inválida")
+		return nil
+	}
+
+	return &config
+}
+
+// formatPrepTime formata o tempo de preparo para exibição ao cliente
+func formatPrepTime(minutes int) string {
+	if minutes < 60 {
+		return fmt.Sprintf("~%d min", minutes)
+	}
+
+	hours := minutes / 60
+	remaining := minutes % 60
+	if remaining == 0 {
+		return fmt.Sprintf("~%dh", hours)
+	}
+	return fmt.Sprintf("~%dh%02d", hours, remaining)
+}
+
+// getPrepTimeConfig busca a configuração de tempo de preparo do tenant
+func (s *AIService) getPrepTimeConfig(ctx context.Context, tenantID uuid.UUID) *PrepTimeConfig {
+	setting, err := s.settingsService.GetSetting(ctx, tenantID, PrepTimeSettingKey)
+	if err != nil || setting == nil || setting.SettingValue == nil {
+		return nil
+	}
+
+	return parsePrepTimeConfig(*setting.SettingValue)
+}
+
+// buildPrepTimeItem monta o item de preparo a partir do produto, buscando o nome da categoria quando possível
+func (s *AIService) buildPrepTimeItem(tenantID uuid.UUID, product *models.Product) prepTimeItem {
+	item := prepTimeItem{
+		ProductID: product.ID.String(),
+		SKU:       product.SKU,
+	}
+
+	if product.CategoryID != nil {
+		item.CategoryID = product.CategoryID.String()
+		if s.categoryService != nil {
+			if category, err := s.categoryService.GetCategoryByID(tenantID, *product.CategoryID); err == nil && category != nil {
+				item.CategoryName = category.Name
+			}
+		}
+	}
+
+	return item
+}
+
+// getCartPrepTime calcula o tempo de preparo do carrinho (maior tempo entre os itens)
+func (s *AIService) getCartPrepTime(tenantID uuid.UUID, config *PrepTimeConfig, cart *models.Cart) (int, bool) {
+	if config == nil || cart == nil {
+		return 0, false
+	}
+
+	items := make([]prepTimeItem, 0, len(cart.Items))
+	for _, cartItem := range cart.Items {
+		if cartItem.Product == nil {
+			continue
+		}
+		items = append(items, s.buildPrepTimeItem(tenantID, cartItem.Product))
+	}
+
+	return aggregatePrepTime(config, items)
+}
+
+// handleConsultarTempoPreparo informa o tempo estimado de preparo de um produto ou do carrinho atual
+func (s *AIService) handleConsultarTempoPreparo(ctx context.Context, tenantID, customerID uuid.UUID, customerPhone string, args map[string]interface{}) (string, error) {
+	config := s.getPrepTimeConfig(ctx, tenantID)
+	if config == nil {
+		return "⏱️ Ainda não temos uma estimativa de tempo de preparo configurada. Assim que seu pedido for confirmado, nossa equipe informa o prazo! 😊", nil
+	}
+
+	identifier, _ := args["identifier"].(string)
+	identifier = strings.TrimSpace(identifier)
+
+	// Sem produto informado: calcular para o carrinho atual
+	if identifier == "" {
+		cart, err := s.cartService.GetOrCreateActiveCart(tenantID, customerID)
+		if err != nil {
+			return "❌ Erro ao acessar carrinho.", err
+		}
+
+		cartWithItems, err := s.cartService.GetCartWithItems(cart.ID, tenantID)
+		if err != nil {
+			return "❌ Erro ao carregar carrinho.", err
+		}
+
+		if len(cartWithItems.Items) == 0 {
+			if config.DefaultMinutes > 0 {
+				return fmt.Sprintf("⏱️ Nosso tempo médio de preparo é de **%s**.\n\n🛒 Adicione itens ao carrinho para uma estimativa do seu pedido.", formatPrepTime(config.DefaultMinutes)), nil
+			}
+			return "🛒 Seu carrinho está vazio. Me diga qual produto você quer e eu informo o tempo de preparo!", nil
+		}
+
+		minutes, found := s.getCartPrepTime(tenantID, config, cartWithItems)
+		if !found {
+			return "⏱️ Não temos uma estimativa de preparo para os itens do seu carrinho. Nossa equipe informa o prazo ao confirmar o pedido! 😊", nil
+		}
+
+		return fmt.Sprintf("⏱️ **Tempo estimado de preparo do seu pedido:** %s\n\n💡 O tempo considera o item mais demorado do carrinho.", formatPrepTime(minutes)), nil
+	}
+
+	// Produto informado: resolver por número sequencial, nome ou ID
+	product, err := s.resolveProductIdentifier(ctx, tenantID, customerPhone, identifier)
+	if err != nil || product == nil {
+		return "❌ Produto não encontrado. Use 'produtos' para ver a lista atualizada.", nil
+	}
+
+	minutes, found := resolvePrepTime(config, s.buildPrepTimeItem(tenantID, product))
+	if !found {
+		return fmt.Sprintf("⏱️ Não temos uma estimativa de preparo para **%s**. Nossa equipe informa o prazo ao confirmar o pedido! 😊", product.Name), nil
+	}
+
+	return fmt.Sprintf("⏱️ **%s** fica pronto em %s.", product.Name, formatPrepTime(minutes)), nil
+}
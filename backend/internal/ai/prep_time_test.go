@@ -0,0 +1,107 @@
+package ai
+
+import (
+	"testing"
+)
+
+func TestResolvePrepTimeSingleItem(t *testing.T) {
+	config := &PrepTimeConfig{
+		DefaultMinutes: 20,
+		Categories:     map[string]int{"cat-pizza": 40, "Lanches": 25},
+		Products:       map[string]int{"prod-calzone": 50, "SKU-ACAI": 10},
+	}
+
+	tests := []struct {
+		name     string
+		item     prepTimeItem
+		expected int
+		found    bool
+	}{
+		{"product by id", prepTimeItem{ProductID: "prod-calzone", CategoryID: "cat-pizza"}, 50, true},
+		{"product by sku", prepTimeItem{ProductID: "x", SKU: "SKU-ACAI"}, 10, true},
+		{"category by id", prepTimeItem{ProductID: "x", CategoryID: "cat-pizza"}, 40, true},
+		{"category by name ignoring case", prepTimeItem{ProductID: "x", CategoryName: "lanches"}, 25, true},
+		{"default fallback", prepTimeItem{ProductID: "x", CategoryName: "Bebidas"}, 20, true},
+	}
+
+	for _, test := range tests {
+		minutes, found := resolvePrepTime(config, test.item)
+		if minutes != test.expected || found != test.found {
+			t.Errorf("%s: resolvePrepTime = (%d, %t), expected (%d, %t)", test.name, minutes, found, test.expected, test.found)
+		}
+	}
+}
+
+func TestAggregatePrepTimeMultiItem(t *testing.T) {
+	config := &PrepTimeConfig{
+		Categories: map[string]int{"Pizzas": 40, "Bebidas": 2},
+		Products:   map[string]int{"prod-brownie": 15},
+	}
+
+	items := []prepTimeItem{
+		{ProductID: "prod-coca", CategoryName: "Bebidas"},
+		{ProductID: "prod-margherita", CategoryName: "Pizzas"},
+		{ProductID: "prod-brownie"},
+		{ProductID: "prod-sem-config"},
+	}
+
+	minutes, found := aggregatePrepTime(config, items)
+	if !found || minutes != 40 {
+		t.Errorf("aggregatePrepTime = (%d, %t), expected (40, true)", minutes, found)
+	}
+}
+
+func TestPrepTimeMissingConfig(t *testing.T) {
+	item := prepTimeItem{ProductID: "prod-1", CategoryName: "Pizzas"}
+
+	if minutes, found := resolvePrepTime(nil, item); found || minutes != 0 {
+		t.Errorf("resolvePrepTime(nil) = (%d, %t), expected (0, false)", minutes, found)
+	}
+
+	if minutes, found := aggregatePrepTime(nil, []prepTimeItem{item}); found || minutes != 0 {
+		t.Errorf("aggregatePrepTime(nil) = (%d, %t), expected (0, false)", minutes, found)
+	}
+
+	// Configuração sem padrão e sem correspondência para o item
+	config := &PrepTimeConfig{Categories: map[string]int{"Lanches": 25}}
+	if minutes, found := resolvePrepTime(config, item); found || minutes != 0 {
+		t.Errorf("resolvePrepTime(no match) = (%d, %t), expected (0, false)", minutes, found)
+	}
+
+	if config := parsePrepTimeConfig(""); config != nil {
+		t.Errorf("parsePrepTimeConfig(\"\") = %+v, expected nil", config)
+	}
+
+	if config := parsePrepTimeConfig("{invalid"); config != nil {
+		t.Errorf("parsePrepTimeConfig(invalid) = %+v, expected nil", config)
+	}
+}
+
+func TestParsePrepTimeConfig(t *testing.T) {
+	config := parsePrepTimeConfig(`{"default_minutes": 30, "categories": {"Pizzas": 45}, "products": {"abc": 10}}`)
+	if config == nil {
+		t.Fatal("parsePrepTimeConfig returned nil for valid JSON")
+	}
+	if config.DefaultMinutes != 30 || config.Categories["Pizzas"] != 45 || config.Products["abc"] != 10 {
+		t.Errorf("parsePrepTimeConfig = %+v, unexpected values", config)
+	}
+}
+
+func TestFormatPrepTime(t *testing.T) {
+	tests := []struct {
+		minutes  int
+		expected string
+	}{
+		{30, "~30 min"},
+		{60, "~1h"},
+		{75, "~1h15"},
+		{125, "~2h05"},
+	}
+
+	for _, test := range tests {
+		result := formatPrepTime(test.minutes)
+		if result != test.expected {
+			t.Errorf("formatPrepTime(%d) = %q, expected %q", test.minutes, result, test.expected)
+		}
+	}
+}
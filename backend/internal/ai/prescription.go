@@ -0,0 +1,90 @@
+package ai
+
+import (
+	"fmt"
+	"iafarma/pkg/models"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+)
+
+// prescriptionAnalysisMarker é a primeira linha da análise da imagem quando ela é uma receita médica
+const prescriptionAnalysisMarker = "RECEITA"
+
+const prescriptionRequestText = "📄 Este medicamento exige a retenção da receita. Envie uma foto da receita aqui na conversa antes de finalizar o pedido."
+
+// prescriptionItemNames lista os itens do carrinho que são medicamentos controlados
+func prescriptionItemNames(cart *models.Cart) []string {
+	var names []string
+	for _, item := range cart.Items {
+		if item.Product == nil || !item.Product.RequiresPrescription {
+			continue
+		}
+		name := item.Product.Name
+		if item.ProductName != nil && *item.ProductName != "" {
+			name = *item.ProductName
+		}
+		names = append(names, name)
+	}
+	return names
+}
+
+// CartRequiresPrescription indica se o carrinho tem medicamentos controlados (o pedido fica marcado como com receita)
+func CartRequiresPrescription(cart *models.Cart) bool {
+	return cart != nil && len(prescriptionItemNames(cart)) > 0
+}
+
+// cartMissingPrescription indica se o carrinho tem medicamentos controlados e a receita ainda não foi enviada
+func cartMissingPrescription(cart *models.Cart) bool {
+	return cart.PrescriptionURL == "" && CartRequiresPrescription(cart)
+}
+
+// prescriptionNotice pede a receita ao adicionar um medicamento controlado em um carrinho ainda sem receita
+func prescriptionNotice(cart *models.Cart, product *models.Product) string {
+	if product == nil || !product.RequiresPrescription || (cart != nil && cart.PrescriptionURL != "") {
+		return ""
+	}
+	return "\n\n" + prescriptionRequestText
+}
+
+// prescriptionBlockMessage explica por que o checkout não pode seguir sem a receita
+func prescriptionBlockMessage(cart *models.Cart) string {
+	return fmt.Sprintf("📄 Seu carrinho tem medicamentos que exigem a retenção da receita: **%s**.\n\nEnvie uma foto da receita aqui na conversa e depois digite 'finalizar' para fechar o pedido.", strings.Join(prescriptionItemNames(cart), ", "))
+}
+
+// parsePrescriptionAnalysis separa o marcador de receita da análise da imagem
+func parsePrescriptionAnalysis(analysis string) (bool, string) {
+	trimmed := strings.TrimSpace(analysis)
+	firstLine, rest, _ := strings.Cut(trimmed, "\n")
+	if strings.ToUpper(strings.Trim(strings.TrimSpace(firstLine), "*.:")) != prescriptionAnalysisMarker {
+		return false, analysis
+	}
+	return true, strings.TrimSpace(rest)
+}
+
+// attachPrescriptionImage guarda a foto da receita no carrinho em andamento e retorna a confirmação para o cliente
+func (s *AIService) attachPrescriptionImage(tenantID, customerID uuid.UUID, imageURL string) string {
+	if s.cartService == nil {
+		return ""
+	}
+
+	cart, err := s.cartService.GetOrCreateActiveCart(tenantID, customerID)
+	if err != nil {
+		log.Error().Err(err).Str("customer_id", customerID.String()).Msg("Erro ao acessar carrinho para guardar a receita")
+		return ""
+	}
+
+	if err := s.cartService.AttachCartPrescription(cart.ID, tenantID, imageURL); err != nil {
+		log.Error().Err(err).Str("cart_id", cart.ID.String()).Msg("Erro ao guardar a receita no carrinho")
+		return ""
+	}
+
+	log.Info().
+		Str("tenant_id", tenantID.String()).
+		Str("customer_id", customerID.String()).
+		Str("cart_id", cart.ID.String()).
+		Msg("📄 Receita registrada no carrinho")
+
+	return "📄 Receita recebida! Ela ficará anexada ao seu pedido para a farmácia conferir."
+}
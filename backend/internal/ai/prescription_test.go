@@ -0,0 +1,111 @@
+package ai
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"iafarma/pkg/models"
+
+	"github.com/google/uuid"
+)
+
+func newPrescriptionTestCart() *models.Cart {
+	cart := &models.Cart{
+		Items: []models.CartItem{
+			{Quantity: 1, Price: "15.00", Product: &models.Product{Name: "Dipirona 500mg"}},
+			{Quantity: 1, Price: "42.00", Product: &models.Product{Name: "Amoxicilina 500mg", RequiresPrescription: true}},
+		},
+	}
+	cart.ID = uuid.New()
+	return cart
+}
+
+func TestCartMissingPrescription(t *testing.T) {
+	withoutControlled := &models.Cart{Items: []models.CartItem{{Quantity: 1, Product: &models.Product{Name: "Dipirona 500mg"}}}}
+	missing := newPrescriptionTestCart()
+	attached := newPrescriptionTestCart()
+	attached.PrescriptionURL = "https://cdn.example.com/receita.jpg"
+
+	tests := []struct {
+		name     string
+		cart     *models.Cart
+		expected bool
+	}{
+		{"no controlled items", withoutControlled, false},
+		{"controlled without prescription", missing, true},
+		{"controlled with prescription", attached, false},
+	}
+
+	for _, test := range tests {
+		if result := cartMissingPrescription(test.cart); result != test.expected {
+			t.Errorf("%s: cartMissingPrescription = %t, expected %t", test.name, result, test.expected)
+		}
+	}
+
+	if !CartRequiresPrescription(attached) {
+		t.Error("order should stay flagged as requiring a prescription after it is attached")
+	}
+}
+
+func TestPrescriptionNoticeOnAdd(t *testing.T) {
+	controlled := &models.Product{Name: "Amoxicilina 500mg", RequiresPrescription: true}
+
+	if notice := prescriptionNotice(&models.Cart{}, controlled); !strings.Contains(notice, "receita") {
+		t.Errorf("expected prescription request when adding controlled item, got %q", notice)
+	}
+	if notice := prescriptionNotice(&models.Cart{PrescriptionURL: "https://cdn.example.com/r.jpg"}, controlled); notice != "" {
+		t.Errorf("should not ask again once the prescription is attached, got %q", notice)
+	}
+	if notice := prescriptionNotice(&models.Cart{}, &models.Product{Name: "Dipirona 500mg"}); notice != "" {
+		t.Errorf("regular product should not ask for a prescription, got %q", notice)
+	}
+}
+
+func TestParsePrescriptionAnalysis(t *testing.T) {
+	tests := []struct {
+		analysis string
+		isRx     bool
+		rest     string
+	}{
+		{"RECEITA\nReceita de Amoxicilina 500mg", true, "Receita de Amoxicilina 500mg"},
+		{"**Receita:**\nAmoxicilina", true, "Amoxicilina"},
+		{"Caixa de Dipirona 500mg", false, "Caixa de Dipirona 500mg"},
+		{"Receita de bolo com chocolate", false, "Receita de bolo com chocolate"},
+	}
+
+	for _, test := range tests {
+		isRx, rest := parsePrescriptionAnalysis(test.analysis)
+		if isRx != test.isRx || rest != test.rest {
+			t.Errorf("%q: got (%t, %q), expected (%t, %q)", test.analysis, isRx, rest, test.isRx, test.rest)
+		}
+	}
+}
+
+func TestFinalCheckoutBlockedWithoutPrescription(t *testing.T) {
+	tenantID, customerID := uuid.New(), uuid.New()
+	carts := &fakeCartService{cart: newPrescriptionTestCart()}
+	s := &AIService{cartService: carts, addressService: &fakeAddressService{}}
+
+	result, err := s.performFinalCheckout(context.Background(), tenantID, customerID, "5527999999999")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(result, "📄") || !strings.Contains(result, "Amoxicilina 500mg") || strings.Contains(result, "Dipirona") {
+		t.Errorf("expected prescription block listing only controlled items, got:\n%s", result)
+	}
+
+	// Cliente envia a foto da receita e o checkout segue para as próximas validações
+	confirmation := s.attachPrescriptionImage(tenantID, customerID, "https://cdn.example.com/receita.jpg")
+	if confirmation == "" || carts.cart.PrescriptionURL != "https://cdn.example.com/receita.jpg" {
+		t.Fatalf("prescription not attached: %q", confirmation)
+	}
+
+	result, _ = s.performFinalCheckout(context.Background(), tenantID, customerID, "5527999999999")
+	if strings.Contains(result, "📄") {
+		t.Errorf("checkout still blocked after prescription:\n%s", result)
+	}
+	if !strings.Contains(result, "Nenhum endereço encontrado") {
+		t.Errorf("expected checkout to continue to address validation, got:\n%s", result)
+	}
+}
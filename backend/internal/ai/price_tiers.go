@@ -0,0 +1,187 @@
+package ai
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"iafarma/internal/utils"
+	"iafarma/pkg/models"
+
+	"github.com/google/uuid"
+)
+
+// validPriceTiers retorna as faixas de atacado válidas (quantidade > 1 e preço numérico), ordenadas pela quantidade mínima
+func validPriceTiers(product *models.Product) []models.PriceTier {
+	if product == nil {
+		return nil
+	}
+
+	tiers := make([]models.PriceTier, 0, len(product.PriceTiers))
+	for _, tier := range product.PriceTiers {
+		if tier.MinQuantity <= 1 {
+			continue
+		}
+		if price, err := strconv.ParseFloat(tier.UnitPrice, 64); err != nil || price <= 0 {
+			continue
+		}
+		tiers = append(tiers, tier)
+	}
+
+	sort.Slice(tiers, func(i, j int) bool { return tiers[i].MinQuantity < tiers[j].MinQuantity })
+	return tiers
+}
+
+// applicablePriceTier retorna a faixa de atacado atingida pela quantidade (a de maior quantidade mínima)
+func applicablePriceTier(product *models.Product, quantity int) *models.PriceTier {
+	var applicable *models.PriceTier
+	tiers := validPriceTiers(product)
+	for i := range tiers {
+		if quantity >= tiers[i].MinQuantity {
+			applicable = &tiers[i]
+		}
+	}
+	return applicable
+}
+
+// UnitPriceForQuantity retorna o preço unitário para a quantidade, aplicando a faixa de atacado
+// quando ela for menor que o preço efetivo (promoção ou preço regular)
+func UnitPriceForQuantity(product *models.Product, quantity int) string {
+	effectivePrice := getEffectivePrice(product)
+
+	tier := applicablePriceTier(product, quantity)
+	if tier == nil {
+		return effectivePrice
+	}
+
+	tierPrice, _ := strconv.ParseFloat(tier.UnitPrice, 64)
+	if basePrice, err := strconv.ParseFloat(effectivePrice, 64); err == nil && basePrice <= tierPrice {
+		return effectivePrice
+	}
+	return tier.UnitPrice
+}
+
+// formatPriceTiers lista as faixas de atacado do produto (vazio se não houver)
+func formatPriceTiers(product *models.Product) string {
+	tiers := validPriceTiers(product)
+	if len(tiers) == 0 {
+		return ""
+	}
+
+	result := "📦 **Preços por quantidade:**\n"
+	for _, tier := range tiers {
+		result += fmt.Sprintf("   • A partir de %d unidades: R$ %s cada\n", tier.MinQuantity, formatCurrency(tier.UnitPrice))
+	}
+	return result
+}
+
+// priceTierHint informa a faixa aplicada e/ou quanto falta para a próxima faixa
+func priceTierHint(product *models.Product, quantity int) string {
+	hint := ""
+	if tier := applicablePriceTier(product, quantity); tier != nil && UnitPriceForQuantity(product, quantity) == tier.UnitPrice {
+		hint += fmt.Sprintf("\n🏷️ Preço de atacado aplicado: R$ %s cada (a partir de %d unidades)", formatCurrency(tier.UnitPrice), tier.MinQuantity)
+	}
+
+	for _, tier := range validPriceTiers(product) {
+		if tier.MinQuantity > quantity {
+			hint += fmt.Sprintf("\n💡 Levando %d unidades, o preço cai para R$ %s cada!", tier.MinQuantity, formatCurrency(tier.UnitPrice))
+			break
+		}
+	}
+	return hint
+}
+
+// handleConsultarPrecoQuantidade informa o preço unitário e total de um produto para uma quantidade
+func (s *AIService) handleConsultarPrecoQuantidade(ctx context.Context, tenantID uuid.UUID, customerPhone string, args map[string]interface{}) (string, error) {
+	identifier, _ := args["identifier"].(string)
+	if identifier == "" {
+		return "❌ Informe o produto (número da lista ou nome).", nil
+	}
+
+	quantity := 1
+	if value, ok := args["quantidade"].(float64); ok && value >= 1 {
+		quantity = int(value)
+	}
+
+	product, err := s.resolveProductIdentifier(ctx, tenantID, customerPhone, identifier)
+	if err != nil || product == nil {
+		return "❌ Produto não encontrado. Use 'produtos' para ver a lista atualizada.", nil
+	}
+
+	unitPrice := UnitPriceForQuantity(product, quantity)
+	unitValue, _ := utils.ParseCents(unitPrice)
+
+	result := fmt.Sprintf("💰 **%s**\n🔢 Quantidade: %d\n💵 Preço unitário: R$ %s\n💳 Total: R$ %s",
+		product.Name, quantity, formatCurrency(unitPrice), formatCurrency(unitValue.Times(quantity).String()))
+	result += priceTierHint(product, quantity)
+
+	if tiers := formatPriceTiers(product); tiers != "" {
+		result += "\n\n" + tiers
+	} else {
+		result += "\n\nℹ️ Este produto não tem desconto por quantidade."
+	}
+
+	return result, nil
+}
+
+// quantityDiscountTiers retorna as faixas de atacado que realmente baixam o preço efetivo do produto
+// (uma promoção menor que a faixa anula o desconto por quantidade)
+func quantityDiscountTiers(product *models.Product) []models.PriceTier {
+	var tiers []models.PriceTier
+	for _, tier := range validPriceTiers(product) {
+		if UnitPriceForQuantity(product, tier.MinQuantity) == tier.UnitPrice {
+			tiers = append(tiers, tier)
+		}
+	}
+	return tiers
+}
+
+// discountPercent calcula o desconto percentual (arredondado) de um preço em relação ao preço base
+func discountPercent(basePrice, price string) int {
+	base, ok := utils.ParseCents(basePrice)
+	discounted, discountedOK := utils.ParseCents(price)
+	if !ok || !discountedOK || base <= 0 || discounted >= base {
+		return 0
+	}
+	return int(math.Round(float64(base-discounted) * 100 / float64(base)))
+}
+
+// handleConsultarDescontoQuantidade responde se o produto fica mais barato levando mais unidades e a partir de quantas
+func (s *AIService) handleConsultarDescontoQuantidade(ctx context.Context, tenantID uuid.UUID, customerPhone string, args map[string]interface{}) (string, error) {
+	identifier, _ := args["identifier"].(string)
+	if strings.TrimSpace(identifier) == "" {
+		return "❌ Informe o produto (número da lista ou nome) para eu ver se tem desconto por quantidade.", nil
+	}
+
+	product, err := s.resolveProductIdentifier(ctx, tenantID, customerPhone, identifier)
+	if err != nil || product == nil {
+		return "❌ Produto não encontrado. Use 'produtos' para ver a lista atualizada.", nil
+	}
+
+	effectivePrice := getEffectivePrice(product)
+	if !isValidPrice(effectivePrice) {
+		return fmt.Sprintf("ℹ️ O preço de **%s** é sob consulta. Nossa equipe pode te passar condições para quantidades maiores.", product.Name), nil
+	}
+
+	tiers := quantityDiscountTiers(product)
+	if len(tiers) == 0 {
+		return fmt.Sprintf("😊 No momento **%s** não tem desconto por quantidade: sai por R$ %s cada, seja qual for a quantidade.\n\nPosso ajudar com mais alguma coisa?",
+			product.Name, formatCurrency(effectivePrice)), nil
+	}
+
+	var result strings.Builder
+	result.WriteString(fmt.Sprintf("🏷️ **Sim! %s fica mais barato levando mais:**\n\n", product.Name))
+	result.WriteString(fmt.Sprintf("💵 Preço unitário: R$ %s\n", formatCurrency(effectivePrice)))
+	for _, tier := range tiers {
+		result.WriteString(fmt.Sprintf("• A partir de %d unidades, sai por **R$ %s** cada", tier.MinQuantity, formatCurrency(tier.UnitPrice)))
+		if percent := discountPercent(effectivePrice, tier.UnitPrice); percent > 0 {
+			result.WriteString(fmt.Sprintf(" (%d%% de desconto)", percent))
+		}
+		result.WriteString("\n")
+	}
+	result.WriteString("\n🛒 Me diga quantas unidades você quer que eu calculo o total.")
+	return result.String(), nil
+}
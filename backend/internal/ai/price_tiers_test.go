@@ -0,0 +1,178 @@
+package ai
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"iafarma/pkg/models"
+
+	"github.com/google/uuid"
+)
+
+func newTieredProduct() models.Product {
+	product := models.Product{
+		Name:  "Caixa de Luvas",
+		Price: "10.00",
+		PriceTiers: []models.PriceTier{
+			{MinQuantity: 50, UnitPrice: "7.00"},
+			{MinQuantity: 10, UnitPrice: "8.00"},
+			{MinQuantity: 1, UnitPrice: "1.00"}, // faixa inválida: quantidade mínima 1
+			{MinQuantity: 5, UnitPrice: "abc"},  // faixa inválida: preço não numérico
+		},
+	}
+	product.ID = uuid.New()
+	return product
+}
+
+func TestUnitPriceForQuantityCrossesTierBoundaries(t *testing.T) {
+	product := newTieredProduct()
+
+	// Sobe e desce as faixas, como acontece ao alterar a quantidade no carrinho
+	steps := []struct {
+		quantity int
+		want     string
+	}{
+		{1, "10.00"},
+		{9, "10.00"},
+		{10, "8.00"},
+		{49, "8.00"},
+		{50, "7.00"},
+		{120, "7.00"},
+		{49, "8.00"},
+		{10, "8.00"},
+		{9, "10.00"},
+		{5, "10.00"},
+	}
+
+	for _, step := range steps {
+		if got := UnitPriceForQuantity(&product, step.quantity); got != step.want {
+			t.Errorf("UnitPriceForQuantity(%d) = %s, esperado %s", step.quantity, got, step.want)
+		}
+	}
+}
+
+func TestUnitPriceForQuantityKeepsLowerSalePrice(t *testing.T) {
+	product := newTieredProduct()
+	product.SalePrice = "7.50"
+
+	tests := []struct {
+		quantity int
+		want     string
+	}{
+		{1, "7.50"},
+		{10, "7.50"}, // promoção menor que a faixa de 10 unidades
+		{50, "7.00"}, // faixa de 50 unidades menor que a promoção
+	}
+
+	for _, tt := range tests {
+		if got := UnitPriceForQuantity(&product, tt.quantity); got != tt.want {
+			t.Errorf("UnitPriceForQuantity(%d) = %s, esperado %s", tt.quantity, got, tt.want)
+		}
+	}
+}
+
+func TestPriceTierHint(t *testing.T) {
+	product := newTieredProduct()
+
+	tests := []struct {
+		quantity    int
+		wantApplied bool
+		wantNext    string
+	}{
+		{3, false, "Levando 10 unidades"},
+		{10, true, "Levando 50 unidades"},
+		{50, true, ""},
+	}
+
+	for _, tt := range tests {
+		hint := priceTierHint(&product, tt.quantity)
+		if strings.Contains(hint, "atacado aplicado") != tt.wantApplied {
+			t.Errorf("quantidade %d: dica de faixa aplicada inesperada: %q", tt.quantity, hint)
+		}
+		if tt.wantNext != "" && !strings.Contains(hint, tt.wantNext) {
+			t.Errorf("quantidade %d: esperado %q em %q", tt.quantity, tt.wantNext, hint)
+		}
+		if tt.wantNext == "" && strings.Contains(hint, "Levando") {
+			t.Errorf("quantidade %d: não deveria sugerir próxima faixa: %q", tt.quantity, hint)
+		}
+	}
+}
+
+func TestCartSavingsIncludesPriceTiers(t *testing.T) {
+	product := newTieredProduct()
+	items := []models.CartItem{{Quantity: 10, Price: "8.00", Product: &product}}
+
+	if got := calculateCartSavings(items); got != 20 {
+		t.Errorf("calculateCartSavings() = %v, esperado 20", got)
+	}
+}
+
+func TestHandleConsultarPrecoQuantidade(t *testing.T) {
+	product := newTieredProduct()
+	s := &AIService{
+		productService: &fakeProductService{products: []models.Product{product}},
+		memoryManager:  NewMemoryManager(),
+	}
+
+	result, err := s.handleConsultarPrecoQuantidade(context.Background(), uuid.New(), "5561999999999", map[string]interface{}{
+		"identifier": product.ID.String(),
+		"quantidade": float64(12),
+	})
+	if err != nil {
+		t.Fatalf("erro inesperado: %v", err)
+	}
+
+	for _, expected := range []string{"Preço unitário: R$ 8,00", "Total: R$ 96,00", "A partir de 10 unidades", "A partir de 50 unidades"} {
+		if !strings.Contains(result, expected) {
+			t.Errorf("esperado %q em:\n%s", expected, result)
+		}
+	}
+	if strings.Contains(result, "A partir de 5 unidades") {
+		t.Errorf("faixas inválidas não deveriam ser exibidas:\n%s", result)
+	}
+}
+
+func TestQuantityDiscountTiersIgnoresTiersAboveSalePrice(t *testing.T) {
+	product := newTieredProduct()
+	product.SalePrice = "7.50"
+
+	tiers := quantityDiscountTiers(&product)
+	if len(tiers) != 1 || tiers[0].MinQuantity != 50 {
+		t.Errorf("esperada só a faixa de 50 unidades (abaixo da promoção), obtido %+v", tiers)
+	}
+}
+
+func TestHandleConsultarDescontoQuantidade(t *testing.T) {
+	tiered := newTieredProduct()
+	regular := newPricedTestProduct("Sabonete", "5.00")
+	s := &AIService{
+		productService: &fakeProductService{products: []models.Product{tiered, regular}},
+		memoryManager:  NewMemoryManager(),
+	}
+
+	result, err := s.handleConsultarDescontoQuantidade(context.Background(), uuid.New(), "5561999999999", map[string]interface{}{"identifier": tiered.ID.String()})
+	if err != nil {
+		t.Fatalf("erro inesperado: %v", err)
+	}
+	for _, expected := range []string{
+		"Preço unitário: R$ 10,00",
+		"A partir de 10 unidades, sai por **R$ 8,00** cada (20% de desconto)",
+		"A partir de 50 unidades, sai por **R$ 7,00** cada (30% de desconto)",
+	} {
+		if !strings.Contains(result, expected) {
+			t.Errorf("esperado %q em:\n%s", expected, result)
+		}
+	}
+	if strings.Contains(result, "A partir de 5 unidades") {
+		t.Errorf("faixas inválidas não deveriam ser exibidas:\n%s", result)
+	}
+
+	result, err = s.handleConsultarDescontoQuantidade(context.Background(), uuid.New(), "5561999999999", map[string]interface{}{"identifier": regular.ID.String()})
+	if err != nil {
+		t.Fatalf("erro inesperado: %v", err)
+	}
+	if !strings.Contains(result, "não tem desconto por quantidade") || !strings.Contains(result, "R$ 5,00 cada") {
+		t.Errorf("esperado aviso educado de produto sem desconto:\n%s", result)
+	}
+}
@@ -0,0 +1,71 @@
+package ai
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"iafarma/pkg/models"
+)
+
+// priceOnRequestLabel é exibido no lugar do preço quando o produto não tem um preço válido cadastrado
+const priceOnRequestLabel = "Consulte o preço"
+
+// ProductPriceSQL converte o preço textual do produto em número no SQL. Preços inválidos ("", "grátis")
+// viram NULL em vez de quebrar a consulta, e ficam de fora das buscas filtradas ou ordenadas por preço.
+const ProductPriceSQL = `CAST(CASE WHEN TRIM(price) ~ '^[0-9]+([.][0-9]+)?$' THEN TRIM(price) END AS DECIMAL)`
+
+// parsePrice interpreta um preço cadastrado ("12.50" ou "12,50"); retorna false se ele não for um valor positivo
+func parsePrice(price string) (float64, bool) {
+	price = strings.TrimSpace(price)
+	if !strings.Contains(price, ".") {
+		price = strings.Replace(price, ",", ".", 1)
+	}
+
+	value, err := strconv.ParseFloat(price, 64)
+	if err != nil || math.IsNaN(value) || math.IsInf(value, 0) || value <= 0 {
+		return 0, false
+	}
+	return value, true
+}
+
+// isValidPrice indica se o preço pode ser exibido, somado e comparado
+func isValidPrice(price string) bool {
+	_, ok := parsePrice(price)
+	return ok
+}
+
+// hasValidPrice indica se o produto tem um preço de venda válido
+func hasValidPrice(product *models.Product) bool {
+	return product != nil && isValidPrice(getEffectivePrice(product))
+}
+
+// formatListPrice formata o preço de um item em listas, mostrando a promoção quando houver
+func formatListPrice(price, salePrice string) string {
+	switch {
+	case isValidPrice(salePrice) && isValidPrice(price):
+		return fmt.Sprintf("~~R$ %s~~ **R$ %s**", formatCurrency(price), formatCurrency(salePrice))
+	case isValidPrice(salePrice):
+		return fmt.Sprintf("**R$ %s**", formatCurrency(salePrice))
+	case isValidPrice(price):
+		return fmt.Sprintf("**R$ %s**", formatCurrency(price))
+	}
+	return priceOnRequestLabel
+}
+
+// formatPriceLabel formata o preço efetivo como "R$ 12,50" ou, se inválido, como "Consulte o preço"
+func formatPriceLabel(price, salePrice string) string {
+	if isValidPrice(salePrice) {
+		return fmt.Sprintf("R$ %s", formatCurrency(salePrice))
+	}
+	if isValidPrice(price) {
+		return fmt.Sprintf("R$ %s", formatCurrency(price))
+	}
+	return priceOnRequestLabel
+}
+
+// invalidPriceMessage explica ao cliente por que um produto sem preço não pode ir para o carrinho
+func invalidPriceMessage(product *models.Product) string {
+	return fmt.Sprintf("❌ **%s** está sem preço cadastrado no momento, por isso não consigo adicioná-lo ao carrinho.\n\n📞 Fale com a loja para consultar o valor ou escolha outro produto.", product.Name)
+}
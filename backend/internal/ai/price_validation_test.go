@@ -0,0 +1,190 @@
+package ai
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"iafarma/pkg/models"
+
+	"github.com/google/uuid"
+)
+
+func newPricedTestProduct(name, price string) models.Product {
+	product := models.Product{Name: name, Price: price, StockQuantity: 10, Available: true}
+	product.ID = uuid.New()
+	return product
+}
+
+func TestParsePrice(t *testing.T) {
+	tests := []struct {
+		price    string
+		esperado float64
+		valido   bool
+	}{
+		{"12.50", 12.5, true},
+		{"12,50", 12.5, true},
+		{" 7 ", 7, true},
+		{"", 0, false},
+		{"grátis", 0, false},
+		{"0", 0, false},
+		{"0.00", 0, false},
+		{"-3", 0, false},
+		{"NaN", 0, false},
+	}
+
+	for _, tt := range tests {
+		got, ok := parsePrice(tt.price)
+		if got != tt.esperado || ok != tt.valido {
+			t.Errorf("%q: esperado (%v, %v), obtido (%v, %v)", tt.price, tt.esperado, tt.valido, got, ok)
+		}
+	}
+}
+
+func TestFormatListPrice(t *testing.T) {
+	tests := []struct {
+		price, salePrice string
+		esperado         string
+	}{
+		{"10.00", "", "**R$ 10,00**"},
+		{"10.00", "0", "**R$ 10,00**"},
+		{"10.00", "8.00", "~~R$ 10,00~~ **R$ 8,00**"},
+		{"", "8.00", "**R$ 8,00**"},
+		{"10.00", "promoção", "**R$ 10,00**"},
+		{"", "", priceOnRequestLabel},
+		{"grátis", "", priceOnRequestLabel},
+		{"0", "0", priceOnRequestLabel},
+	}
+
+	for _, tt := range tests {
+		if got := formatListPrice(tt.price, tt.salePrice); got != tt.esperado {
+			t.Errorf("(%q, %q): esperado %q, obtido %q", tt.price, tt.salePrice, tt.esperado, got)
+		}
+	}
+}
+
+func TestFormatCurrency(t *testing.T) {
+	tests := []struct {
+		price    string
+		esperado string
+	}{
+		{"12.5", "12,50"},
+		{"1234.56", "1.234,56"},
+		{"1.005", "1,01"},
+		{"2.675", "2,68"},
+		{"29.9999998", "30,00"},
+		{"", "0,00"},
+		{"grátis", "grátis"},
+	}
+
+	for _, tt := range tests {
+		if got := formatCurrency(tt.price); got != tt.esperado {
+			t.Errorf("%q: esperado %q, obtido %q", tt.price, tt.esperado, got)
+		}
+	}
+}
+
+func TestCartSubtotalSomaEmCentavos(t *testing.T) {
+	// Somados em float, esses itens dão 0.30000000000000004 e 438.29999999999995
+	tests := []struct {
+		items    []models.CartItem
+		esperado float64
+	}{
+		{[]models.CartItem{{Price: "0.10", Quantity: 1}, {Price: "0.10", Quantity: 1}, {Price: "0.10", Quantity: 1}}, 0.3},
+		{[]models.CartItem{{Price: "19.99", Quantity: 3}}, 59.97},
+		{[]models.CartItem{{Price: "4.35", Quantity: 100}, {Price: "1.10", Quantity: 3}}, 438.3},
+	}
+
+	for _, tt := range tests {
+		if got := cartSubtotal(&models.Cart{Items: tt.items}); got != tt.esperado {
+			t.Errorf("esperado %v, obtido %v", tt.esperado, got)
+		}
+	}
+}
+
+func TestConsultarItensExibeConsulteParaPrecosInvalidos(t *testing.T) {
+	products := []models.Product{
+		newPricedTestProduct("Vitamina C", "25.90"),
+		newPricedTestProduct("Vitamina D", ""),
+		newPricedTestProduct("Vitamina E", "grátis"),
+		newPricedTestProduct("Vitamina B12", "0"),
+	}
+	s := &AIService{
+		settingsService: &fakeSettingsService{},
+		productService:  &fakeProductService{advancedResult: products},
+		memoryManager:   NewMemoryManager(),
+	}
+
+	result, err := s.handleConsultarItens(context.Background(), uuid.New(), uuid.New(), "5527999999999", map[string]interface{}{"query": "vitamina"})
+	if err != nil {
+		t.Fatalf("erro inesperado: %v", err)
+	}
+
+	if !strings.Contains(result, "**R$ 25,90**") {
+		t.Errorf("produto com preço válido deveria exibir o preço:\n%s", result)
+	}
+	if count := strings.Count(result, "💰 "+priceOnRequestLabel); count != 3 {
+		t.Errorf("esperado %q para os 3 produtos sem preço válido, obtido %d:\n%s", priceOnRequestLabel, count, result)
+	}
+	if strings.Contains(result, "R$ grátis") || strings.Contains(result, "R$ 0,00") || strings.Contains(result, "**R$ **") {
+		t.Errorf("preço inválido não deveria ser exibido como valor:\n%s", result)
+	}
+}
+
+func TestDetalharItemComPrecoInvalido(t *testing.T) {
+	tenantID := uuid.New()
+	phone := "5527999999999"
+	product := newPricedTestProduct("Vitamina E", "grátis")
+	s := &AIService{
+		productService: &fakeProductService{products: []models.Product{product}},
+		memoryManager:  NewMemoryManager(),
+	}
+	s.memoryManager.StoreProductList(tenantID, phone, []models.Product{product})
+
+	result, err := s.handleDetalharItem(context.Background(), tenantID, phone, map[string]interface{}{"identifier": "1"})
+	if err != nil {
+		t.Fatalf("erro inesperado: %v", err)
+	}
+	if !strings.Contains(result, "**Preço:** "+priceOnRequestLabel) {
+		t.Errorf("esperado %q nos detalhes:\n%s", priceOnRequestLabel, result)
+	}
+}
+
+func TestSortProductsByPriceExcluiPrecosInvalidos(t *testing.T) {
+	products := []models.Product{
+		newPricedTestProduct("Sem preço", ""),
+		newPricedTestProduct("Caro", "50.00"),
+		newPricedTestProduct("Grátis", "grátis"),
+		newPricedTestProduct("Barato", "5,00"),
+		newPricedTestProduct("Zerado", "0"),
+	}
+
+	sorted := sortProductsByPrice(products, "price_asc")
+	if len(sorted) != 2 || sorted[0].Name != "Barato" || sorted[1].Name != "Caro" {
+		t.Errorf("esperado [Barato Caro], obtido %+v", sorted)
+	}
+
+	filters := ProductSearchFilters{SortBy: "price_desc"}
+	if !strings.Contains(filters.BaseCondition(), ProductPriceSQL+" > 0") {
+		t.Errorf("busca ordenada por preço deveria excluir preços inválidos: %s", filters.BaseCondition())
+	}
+	if strings.Contains((ProductSearchFilters{Query: "vitamina"}).BaseCondition(), ProductPriceSQL) {
+		t.Error("busca sem preço não deveria filtrar preços inválidos")
+	}
+}
+
+func TestAdicionarProdutoComPrecoInvalidoNaoVaiAoCarrinho(t *testing.T) {
+	for _, price := range []string{"", "grátis", "0"} {
+		product := newPricedTestProduct("Vitamina E", price)
+		// Sem cartService: qualquer tentativa de usar o carrinho quebraria o teste
+		s := &AIService{productService: &fakeProductService{products: []models.Product{product}}}
+
+		result, err := s.tryAddProductToCart(context.Background(), uuid.New(), uuid.New(), "5527999999999", product.ID, 1)
+		if err != nil {
+			t.Fatalf("%q: erro inesperado: %v", price, err)
+		}
+		if !strings.Contains(result, "sem preço cadastrado") {
+			t.Errorf("%q: esperado aviso de produto sem preço, obtido:\n%s", price, result)
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package ai
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+)
+
+// ProcessingOperation identifica as operações demoradas que podem receber um aviso imediato ao cliente
+type ProcessingOperation string
+
+const (
+	ProcessingOperationImage  ProcessingOperation = "image"
+	ProcessingOperationAudio  ProcessingOperation = "audio"
+	ProcessingOperationSearch ProcessingOperation = "search"
+)
+
+// Configurações (booleanas, desativadas por padrão) que ligam o aviso de cada operação
+const (
+	ProcessingAckImageSettingKey  = "ai_processing_ack_image"
+	ProcessingAckAudioSettingKey  = "ai_processing_ack_audio"
+	ProcessingAckSearchSettingKey = "ai_processing_ack_search"
+)
+
+// processingAckSettings associa cada operação à configuração que a habilita e ao texto enviado
+var processingAckSettings = map[ProcessingOperation]struct {
+	settingKey string
+	message    string
+}{
+	ProcessingOperationImage:  {ProcessingAckImageSettingKey, "Analisando sua imagem... 🔍"},
+	ProcessingOperationAudio:  {ProcessingAckAudioSettingKey, "Ouvindo seu áudio... 🎧"},
+	ProcessingOperationSearch: {ProcessingAckSearchSettingKey, "Procurando os produtos para você... 🔎"},
+}
+
+// ProcessingAckFunc entrega ao cliente o aviso de processamento. É chamada pela camada de canal (webhook),
+// que decide como enviar; o aviso não entra no histórico da conversa usado como contexto da IA.
+type ProcessingAckFunc func(message string)
+
+type processingAckContextKey struct{}
+
+// processingAck garante no máximo um aviso por mensagem, mesmo quando várias operações demoradas se encadeiam
+// (ex: áudio transcrito que depois dispara uma busca grande)
+type processingAck struct {
+	send ProcessingAckFunc
+	once sync.Once
+}
+
+// WithProcessingAck anexa ao contexto da mensagem o callback usado para enviar o aviso de processamento
+func WithProcessingAck(ctx context.Context, send ProcessingAckFunc) context.Context {
+	if send == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, processingAckContextKey{}, &processingAck{send: send})
+}
+
+// isProcessingAckEnabled indica se o tenant habilitou o aviso para a operação
+func (s *AIService) isProcessingAckEnabled(ctx context.Context, tenantID uuid.UUID, settingKey string) bool {
+	if s.settingsService == nil {
+		return false
+	}
+
+	setting, err := s.settingsService.GetSetting(ctx, tenantID, settingKey)
+	if err != nil || setting == nil || setting.SettingValue == nil {
+		return false
+	}
+
+	enabled, err := strconv.ParseBool(strings.TrimSpace(*setting.SettingValue))
+	return err == nil && enabled
+}
+
+// sendProcessingAck envia o aviso imediato da operação quando habilitado e ainda não enviado para esta mensagem.
+// Retorna true se o aviso foi entregue ao callback.
+func (s *AIService) sendProcessingAck(ctx context.Context, tenantID uuid.UUID, operation ProcessingOperation) bool {
+	ack, ok := ctx.Value(processingAckContextKey{}).(*processingAck)
+	if !ok || ack == nil {
+		return false
+	}
+
+	config, ok := processingAckSettings[operation]
+	if !ok || !s.isProcessingAckEnabled(ctx, tenantID, config.settingKey) {
+		return false
+	}
+
+	sent := false
+	ack.once.Do(func() {
+		log.Info().
+			Str("tenant_id", tenantID.String()).
+			Str("operation", string(operation)).
+			Msg("⏳ Enviando aviso de processamento ao cliente")
+		ack.send(config.message)
+		sent = true
+	})
+	return sent
+}
@@ -0,0 +1,126 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"iafarma/pkg/models"
+
+	"github.com/google/uuid"
+	"github.com/sashabaranov/go-openai"
+)
+
+// newToolCallingClient aponta o cliente OpenAI para um servidor local que sempre responde chamando a ferramenta
+// informada; a última requisição recebida fica disponível para conferência
+func newToolCallingClient(t *testing.T, toolName, arguments string) (*openai.Client, *openai.ChatCompletionRequest) {
+	t.Helper()
+	received := &openai.ChatCompletionRequest{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(received)
+		resp := openai.ChatCompletionResponse{
+			Choices: []openai.ChatCompletionChoice{{
+				Message: openai.ChatCompletionMessage{
+					Role: openai.ChatMessageRoleAssistant,
+					ToolCalls: []openai.ToolCall{{
+						ID:       "call_1",
+						Type:     openai.ToolTypeFunction,
+						Function: openai.FunctionCall{Name: toolName, Arguments: arguments},
+					}},
+				},
+			}},
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(resp)
+	}))
+	t.Cleanup(server.Close)
+
+	config := openai.DefaultConfig("test")
+	config.BaseURL = server.URL + "/v1"
+	return openai.NewClientWithConfig(config), received
+}
+
+func TestSendProcessingAck(t *testing.T) {
+	tenantID := uuid.New()
+
+	t.Run("desativado por padrão", func(t *testing.T) {
+		s := &AIService{settingsService: &fakeSettingsService{values: map[string]string{}}}
+		var acks []string
+		ctx := WithProcessingAck(context.Background(), func(message string) { acks = append(acks, message) })
+
+		if s.sendProcessingAck(ctx, tenantID, ProcessingOperationImage) || len(acks) != 0 {
+			t.Errorf("aviso não deveria ser enviado sem configuração, obtido %v", acks)
+		}
+	})
+
+	t.Run("sem callback do canal", func(t *testing.T) {
+		s := &AIService{settingsService: &fakeSettingsService{values: map[string]string{ProcessingAckImageSettingKey: "true"}}}
+		if s.sendProcessingAck(context.Background(), tenantID, ProcessingOperationImage) {
+			t.Error("sem callback no contexto o aviso não pode ser enviado")
+		}
+	})
+
+	t.Run("configurado por operação e enviado uma vez por mensagem", func(t *testing.T) {
+		s := &AIService{settingsService: &fakeSettingsService{values: map[string]string{
+			ProcessingAckAudioSettingKey:  "false",
+			ProcessingAckSearchSettingKey: "true",
+		}}}
+		var acks []string
+		ctx := WithProcessingAck(context.Background(), func(message string) { acks = append(acks, message) })
+
+		if s.sendProcessingAck(ctx, tenantID, ProcessingOperationAudio) {
+			t.Error("aviso de áudio desativado não deveria ser enviado")
+		}
+		if !s.sendProcessingAck(ctx, tenantID, ProcessingOperationSearch) {
+			t.Error("aviso de busca habilitado deveria ser enviado")
+		}
+		if s.sendProcessingAck(ctx, tenantID, ProcessingOperationSearch) {
+			t.Error("a mesma mensagem não deveria receber um segundo aviso")
+		}
+		if len(acks) != 1 || acks[0] != processingAckSettings[ProcessingOperationSearch].message {
+			t.Errorf("esperado um único aviso de busca, obtido %v", acks)
+		}
+	})
+}
+
+func TestProcessingAckForSlowSearchStaysOutOfHistory(t *testing.T) {
+	tenantID := uuid.New()
+	phone := "5561999999999"
+	customer := &models.Customer{Name: "Maria", Phone: phone}
+	customer.ID = uuid.New()
+
+	client, _ := newToolCallingClient(t, "buscarMultiplosProdutos", `{"produtos":["dipirona","protetor solar"]}`)
+	s := &AIService{
+		client:          client,
+		customerService: &visitingCustomerService{phoneCustomerService: phoneCustomerService{customer: customer}},
+		orderService:    promptOrderService{fakeOrderService: &fakeOrderService{}},
+		productService:  &fakeProductService{products: []models.Product{newPricedTestProduct("Dipirona 500mg", "8.90")}},
+		settingsService: &fakeSettingsService{values: map[string]string{ProcessingAckSearchSettingKey: "true"}},
+		memoryManager:   NewMemoryManager(),
+	}
+
+	var acks []string
+	ctx := WithProcessingAck(context.Background(), func(message string) { acks = append(acks, message) })
+
+	response, err := s.ProcessMessage(ctx, tenantID, phone, "quero dipirona e protetor solar")
+	if err != nil {
+		t.Fatalf("erro inesperado: %v", err)
+	}
+
+	ack := processingAckSettings[ProcessingOperationSearch].message
+	if len(acks) != 1 || acks[0] != ack {
+		t.Fatalf("esperado aviso de busca antes da resposta, obtido %v", acks)
+	}
+	if strings.Contains(response, ack) {
+		t.Errorf("a resposta final não deveria repetir o aviso: %q", response)
+	}
+
+	for _, message := range s.memoryManager.GetConversationHistory(tenantID, phone) {
+		if strings.Contains(message.Content, ack) {
+			t.Errorf("aviso de processamento não deveria entrar no histórico da conversa: %+v", message)
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package ai
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"iafarma/pkg/models"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+)
+
+// maxAccessorySuggestions limita quantos produtos da mesma categoria são sugeridos quando não há acessórios cadastrados
+const maxAccessorySuggestions = 5
+
+// sellableAccessories mantém os acessórios que podem ser vendidos agora (disponíveis e com estoque)
+func sellableAccessories(accessories []models.ProductAccessory) ([]models.Product, map[uuid.UUID]string) {
+	var products []models.Product
+	notes := make(map[uuid.UUID]string)
+	for _, accessory := range accessories {
+		product := accessory.Accessory
+		if product == nil || !product.Available || product.StockQuantity <= 0 {
+			continue
+		}
+		products = append(products, *product)
+		if note := strings.TrimSpace(accessory.Note); note != "" {
+			notes[product.ID] = note
+		}
+	}
+	return products, notes
+}
+
+// categorySuggestions busca produtos da categoria do produto para quando a loja não cadastrou acessórios
+func (s *AIService) categorySuggestions(tenantID uuid.UUID, product *models.Product) []models.Product {
+	if product.CategoryID == nil {
+		return nil
+	}
+
+	// Um a mais para compensar o próprio produto, que sai da lista
+	products, err := s.productService.GetProductsByCategory(tenantID, *product.CategoryID, maxAccessorySuggestions+1)
+	if err != nil {
+		log.Warn().Err(err).Str("product_id", product.ID.String()).Msg("⚠️ Erro ao buscar produtos da categoria")
+		return nil
+	}
+
+	var suggestions []models.Product
+	for _, candidate := range products {
+		if candidate.ID != product.ID && len(suggestions) < maxAccessorySuggestions {
+			suggestions = append(suggestions, candidate)
+		}
+	}
+	return suggestions
+}
+
+// handleAcessoriosDoProduto lista os acessórios cadastrados pela loja para um produto ("com essa impressora, leve o
+// cartucho X"). Sem acessórios cadastrados, sugere produtos da mesma categoria. A lista fica na memória para seleção.
+func (s *AIService) handleAcessoriosDoProduto(ctx context.Context, tenantID uuid.UUID, customerPhone string, args map[string]interface{}) (string, error) {
+	identifier, _ := args["identifier"].(string)
+	identifier = strings.TrimSpace(identifier)
+	if identifier == "" {
+		return "❌ Informe o produto (nome ou número da lista) para ver os acessórios.", nil
+	}
+
+	product, err := s.resolveProductIdentifier(ctx, tenantID, customerPhone, identifier)
+	if err != nil || product == nil {
+		return "❌ Produto não encontrado. Use 'produtos' para ver a lista atualizada.", nil
+	}
+
+	accessories, err := s.productService.GetProductAccessories(tenantID, product.ID)
+	if err != nil {
+		return "❌ Erro ao buscar acessórios do produto.", err
+	}
+
+	products, notes := sellableAccessories(accessories)
+	curated := len(products) > 0
+	if !curated {
+		products = s.categorySuggestions(tenantID, product)
+	}
+
+	log.Info().
+		Str("tenant_id", tenantID.String()).
+		Str("product_id", product.ID.String()).
+		Bool("curated", curated).
+		Int("suggestions", len(products)).
+		Msg("🧩 Consultando acessórios do produto")
+
+	if len(products) == 0 {
+		return fmt.Sprintf("ℹ️ Não tenho acessórios para sugerir junto com **%s** no momento.", product.Name), nil
+	}
+
+	var result strings.Builder
+	if curated {
+		result.WriteString(fmt.Sprintf("🧩 **Acessórios para %s:**\n\n", product.Name))
+	} else {
+		result.WriteString(fmt.Sprintf("🧩 Não há acessórios específicos cadastrados para **%s**, mas estes produtos da mesma categoria podem te interessar:\n\n", product.Name))
+	}
+
+	productRefs := s.memoryManager.StoreProductList(tenantID, customerPhone, products)
+	for _, productRef := range productRefs {
+		result.WriteString(fmt.Sprintf("%d. **%s**\n   💰 %s\n", productRef.SequentialID, productRef.Name, formatListPrice(productRef.Price, productRef.SalePrice)))
+		if note := notes[productRef.ProductID]; note != "" {
+			result.WriteString("   💡 " + note + "\n")
+		}
+	}
+
+	result.WriteString("\n🛒 Para adicionar, me diga o número do item e a quantidade.")
+	return result.String(), nil
+}
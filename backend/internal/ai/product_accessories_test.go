@@ -0,0 +1,110 @@
+package ai
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"iafarma/pkg/models"
+
+	"github.com/google/uuid"
+)
+
+// accessoryProductService devolve os acessórios cadastrados e os produtos por categoria
+type accessoryProductService struct {
+	fakeProductService
+	accessories map[uuid.UUID][]models.ProductAccessory
+}
+
+func (f *accessoryProductService) GetProductAccessories(tenantID, productID uuid.UUID) ([]models.ProductAccessory, error) {
+	return f.accessories[productID], nil
+}
+
+func (f *accessoryProductService) GetProductsByCategory(tenantID, categoryID uuid.UUID, limit int) ([]models.Product, error) {
+	var products []models.Product
+	for _, product := range f.products {
+		if product.CategoryID != nil && *product.CategoryID == categoryID && product.Available && product.StockQuantity > 0 && len(products) < limit {
+			products = append(products, product)
+		}
+	}
+	return products, nil
+}
+
+func newAccessoryTestProduct(name, price string, categoryID *uuid.UUID) models.Product {
+	return models.Product{BaseTenantModel: models.BaseTenantModel{ID: uuid.New()}, Name: name, Price: price, CategoryID: categoryID, Available: true, StockQuantity: 5}
+}
+
+func TestAcessoriosDoProdutoCurated(t *testing.T) {
+	tenantID, phone := uuid.New(), "5527999990000"
+	informatica := uuid.New()
+	printer := newAccessoryTestProduct("Impressora HP 2774", "399.00", &informatica)
+	cartridge := newAccessoryTestProduct("Cartucho HP 667 Preto", "79.90", &informatica)
+	paper := newAccessoryTestProduct("Papel A4 500 folhas", "32.00", nil)
+	outOfStock := newAccessoryTestProduct("Cartucho HP 667 Colorido", "89.90", &informatica)
+	outOfStock.StockQuantity = 0
+	mouse := newAccessoryTestProduct("Mouse sem fio", "49.90", &informatica)
+
+	products := &accessoryProductService{
+		fakeProductService: fakeProductService{products: []models.Product{printer, cartridge, paper, outOfStock, mouse}},
+		accessories: map[uuid.UUID][]models.ProductAccessory{printer.ID: {
+			{AccessoryID: cartridge.ID, Accessory: &cartridge, Note: "Rende até 120 páginas"},
+			{AccessoryID: outOfStock.ID, Accessory: &outOfStock},
+			{AccessoryID: paper.ID, Accessory: &paper},
+		}},
+	}
+	s := &AIService{productService: products, memoryManager: NewMemoryManager()}
+
+	result, err := s.handleAcessoriosDoProduto(context.Background(), tenantID, phone, map[string]interface{}{"identifier": "impressora"})
+	if err != nil {
+		t.Fatalf("erro inesperado: %v", err)
+	}
+
+	for _, esperado := range []string{"Acessórios para Impressora HP 2774", "1. **Cartucho HP 667 Preto**", "💡 Rende até 120 páginas", "2. **Papel A4 500 folhas**"} {
+		if !strings.Contains(result, esperado) {
+			t.Errorf("resposta deveria conter %q:\n%s", esperado, result)
+		}
+	}
+	// Sem estoque fica de fora, e a categoria não é usada quando há acessórios cadastrados
+	for _, inesperado := range []string{"Colorido", "Mouse sem fio", "mesma categoria"} {
+		if strings.Contains(result, inesperado) {
+			t.Errorf("resposta não deveria conter %q:\n%s", inesperado, result)
+		}
+	}
+
+	// A lista fica na memória para o cliente escolher pelo número
+	if ref := s.memoryManager.GetProductBySequentialID(tenantID, phone, 2); ref == nil || ref.ProductID != paper.ID {
+		t.Errorf("esperado o papel como item 2 da lista, obtido %+v", ref)
+	}
+}
+
+func TestAcessoriosDoProdutoFallsBackToCategory(t *testing.T) {
+	tenantID, phone := uuid.New(), "5527999990000"
+	dermocosmeticos := uuid.New()
+	sunscreen := newAccessoryTestProduct("Protetor Solar FPS 50", "59.90", &dermocosmeticos)
+	aftersun := newAccessoryTestProduct("Pós-sol Aloe Vera", "34.90", &dermocosmeticos)
+	paused := newAccessoryTestProduct("Hidratante Facial", "45.00", &dermocosmeticos)
+	paused.Available = false
+	unrelated := newAccessoryTestProduct("Dipirona 500mg", "8.90", nil)
+
+	products := &accessoryProductService{fakeProductService: fakeProductService{products: []models.Product{sunscreen, aftersun, paused, unrelated}}}
+	s := &AIService{productService: products, memoryManager: NewMemoryManager()}
+
+	result, err := s.handleAcessoriosDoProduto(context.Background(), tenantID, phone, map[string]interface{}{"identifier": "protetor"})
+	if err != nil {
+		t.Fatalf("erro inesperado: %v", err)
+	}
+	if !strings.Contains(result, "mesma categoria") || !strings.Contains(result, "1. **Pós-sol Aloe Vera**") {
+		t.Errorf("esperadas sugestões da mesma categoria:\n%s", result)
+	}
+	for _, inesperado := range []string{"2. **Protetor Solar", "Hidratante", "Dipirona"} {
+		if strings.Contains(result, inesperado) {
+			t.Errorf("resposta não deveria conter %q:\n%s", inesperado, result)
+		}
+	}
+
+	// Sem acessórios e sem categoria: nada a sugerir
+	result, _ = s.handleAcessoriosDoProduto(context.Background(), tenantID, phone, map[string]interface{}{"identifier": "dipirona"})
+	if !strings.Contains(result, "Não tenho acessórios para sugerir junto com **Dipirona 500mg**") {
+		t.Errorf("esperado aviso sem sugestões, obtido:\n%s", result)
+	}
+}
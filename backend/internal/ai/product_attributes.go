@@ -0,0 +1,203 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"iafarma/pkg/models"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+)
+
+// ProductDisplayAttributesSettingKey define, em JSON, os campos exibidos nas listagens e no detalhe do produto, na
+// ordem desejada: campos fixos ("preco", "descricao", "sku", "estoque", "marca", "peso") ou chaves dos atributos
+// livres do produto (ex: ["preco", "dosagem", "descricao"]). Vazio mantém a exibição padrão.
+const ProductDisplayAttributesSettingKey = "product_display_attributes"
+
+const (
+	displayFieldPrice       = "preco"
+	displayFieldDescription = "descricao"
+	displayFieldSKU         = "sku"
+	displayFieldStock       = "estoque"
+	displayFieldBrand       = "marca"
+	displayFieldWeight      = "peso"
+)
+
+// displayFieldAliases aceita os campos fixos com ou sem acento e em inglês
+var displayFieldAliases = map[string]string{
+	"preco": displayFieldPrice, "preço": displayFieldPrice, "price": displayFieldPrice,
+	"descricao": displayFieldDescription, "descrição": displayFieldDescription, "description": displayFieldDescription,
+	"sku":     displayFieldSKU,
+	"estoque": displayFieldStock, "stock": displayFieldStock,
+	"marca": displayFieldBrand, "brand": displayFieldBrand,
+	"peso": displayFieldWeight, "weight": displayFieldWeight,
+}
+
+var (
+	// defaultListingFields mantém a listagem das buscas como sempre foi: preço, estoque e descrição resumida
+	defaultListingFields = []string{displayFieldPrice, displayFieldStock, displayFieldDescription}
+	// defaultDetailFields mantém o detalhe do produto como sempre foi
+	defaultDetailFields = []string{displayFieldPrice, displayFieldDescription, displayFieldSKU, displayFieldStock, displayFieldBrand, displayFieldWeight}
+)
+
+// normalizeDisplayField converte o nome configurado para o campo fixo correspondente ou para a chave do atributo livre
+func normalizeDisplayField(name string) string {
+	field := strings.ToLower(strings.TrimSpace(name))
+	if builtin, ok := displayFieldAliases[field]; ok {
+		return builtin
+	}
+	return field
+}
+
+// parseProductDisplayAttributes decodifica a lista configurada, sem campos vazios ou repetidos. O estoque é sempre
+// exibido (produto esgotado não pode parecer disponível): quando não configurado, vai ao final.
+func parseProductDisplayAttributes(value string) ([]string, error) {
+	var names []string
+	if err := json.Unmarshal([]byte(value), &names); err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool, len(names))
+	fields := make([]string, 0, len(names)+1)
+	for _, name := range names {
+		field := normalizeDisplayField(name)
+		if field == "" || seen[field] {
+			continue
+		}
+		seen[field] = true
+		fields = append(fields, field)
+	}
+	if len(fields) > 0 && !seen[displayFieldStock] {
+		fields = append(fields, displayFieldStock)
+	}
+	return fields, nil
+}
+
+// productDisplayFields retorna os campos configurados pelo tenant ou os padrões da tela
+func (s *AIService) productDisplayFields(ctx context.Context, tenantID uuid.UUID, defaults []string) []string {
+	if s.settingsService == nil {
+		return defaults
+	}
+	setting, err := s.settingsService.GetSetting(ctx, tenantID, ProductDisplayAttributesSettingKey)
+	if err != nil || setting == nil || setting.SettingValue == nil || strings.TrimSpace(*setting.SettingValue) == "" {
+		return defaults
+	}
+	fields, err := parseProductDisplayAttributes(*setting.SettingValue)
+	if err != nil {
+		log.Error().Err(err).Str("tenant_id", tenantID.String()).Msg("Erro ao decodificar atributos exibidos dos produtos")
+		return defaults
+	}
+	if len(fields) == 0 {
+		return defaults
+	}
+	return fields
+}
+
+// productAttributeValue busca o atributo livre do produto, sem diferenciar maiúsculas na chave
+func productAttributeValue(product *models.Product, key string) string {
+	for name, value := range product.Attributes {
+		if strings.EqualFold(strings.TrimSpace(name), key) {
+			return strings.TrimSpace(value)
+		}
+	}
+	return ""
+}
+
+// attributeLabel transforma a chave do atributo no rótulo exibido ("principio_ativo" → "Principio ativo")
+func attributeLabel(key string) string {
+	label := strings.NewReplacer("_", " ", "-", " ").Replace(key)
+	first, size := utf8.DecodeRuneInString(label)
+	if first == utf8.RuneError {
+		return label
+	}
+	return string(unicode.ToUpper(first)) + label[size:]
+}
+
+// formatListingFields monta as linhas de um produto na listagem das buscas, na ordem dos campos configurados;
+// stockLine é o aviso de esgotado ou de últimas unidades (vazio quando não há)
+func formatListingFields(product *models.Product, fields []string, stockLine string) string {
+	result := ""
+	for _, field := range fields {
+		switch field {
+		case displayFieldPrice:
+			result += fmt.Sprintf("   💰 %s\n", formatListPrice(product.Price, product.SalePrice))
+		case displayFieldStock:
+			if stockLine != "" {
+				result += fmt.Sprintf("   %s\n", stockLine)
+			}
+		case displayFieldDescription:
+			if product.Description != "" {
+				desc := product.Description
+				if len(desc) > 100 {
+					desc = desc[:100] + "..."
+				}
+				result += fmt.Sprintf("   📝 %s\n", desc)
+			}
+		case displayFieldSKU:
+			if product.SKU != "" {
+				result += fmt.Sprintf("   🏷️ SKU: %s\n", product.SKU)
+			}
+		case displayFieldBrand:
+			if product.Brand != "" {
+				result += fmt.Sprintf("   🏭 %s\n", product.Brand)
+			}
+		case displayFieldWeight:
+			if product.Weight != "" {
+				result += fmt.Sprintf("   ⚖️ %s\n", product.Weight)
+			}
+		default:
+			if value := productAttributeValue(product, field); value != "" {
+				result += fmt.Sprintf("   • %s: %s\n", attributeLabel(field), value)
+			}
+		}
+	}
+	return result
+}
+
+// formatDetailFields monta as linhas do detalhe do produto, na ordem dos campos configurados;
+// stockText é a linha de estoque já formatada
+func formatDetailFields(product *models.Product, fields []string, stockText string) string {
+	result := ""
+	for _, field := range fields {
+		switch field {
+		case displayFieldPrice:
+			switch {
+			case !hasValidPrice(product):
+				result += fmt.Sprintf("💰 **Preço:** %s\n", priceOnRequestLabel)
+			case isValidPrice(product.SalePrice) && isValidPrice(product.Price):
+				result += fmt.Sprintf("💰 **Preço:** ~~R$ %s~~ **R$ %s** (PROMOÇÃO! 🎉)\n", formatCurrency(product.Price), formatCurrency(product.SalePrice))
+			default:
+				result += fmt.Sprintf("💰 **Preço:** R$ %s\n", formatCurrency(getEffectivePrice(product)))
+			}
+			result += formatPriceTiers(product)
+		case displayFieldStock:
+			result += stockText
+		case displayFieldDescription:
+			if product.Description != "" {
+				result += fmt.Sprintf("📝 **Descrição:** %s\n", product.Description)
+			}
+		case displayFieldSKU:
+			if product.SKU != "" {
+				result += fmt.Sprintf("🏷️ **SKU:** %s\n", product.SKU)
+			}
+		case displayFieldBrand:
+			if product.Brand != "" {
+				result += fmt.Sprintf("🏭 **Marca:** %s\n", product.Brand)
+			}
+		case displayFieldWeight:
+			if product.Weight != "" {
+				result += fmt.Sprintf("⚖️ **Peso:** %s\n", product.Weight)
+			}
+		default:
+			if value := productAttributeValue(product, field); value != "" {
+				result += fmt.Sprintf("🔹 **%s:** %s\n", attributeLabel(field), value)
+			}
+		}
+	}
+	return result
+}
@@ -0,0 +1,133 @@
+package ai
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"iafarma/pkg/models"
+
+	"github.com/google/uuid"
+)
+
+func TestParseProductDisplayAttributes(t *testing.T) {
+	fields, err := parseProductDisplayAttributes(`["Preço", "dosagem", " descrição ", "price", ""]`)
+	if err != nil {
+		t.Fatalf("erro inesperado: %v", err)
+	}
+	expected := []string{displayFieldPrice, "dosagem", displayFieldDescription, displayFieldStock}
+	if strings.Join(fields, ",") != strings.Join(expected, ",") {
+		t.Errorf("esperado %v (sem repetidos e com o estoque ao final), obtido %v", expected, fields)
+	}
+
+	if _, err := parseProductDisplayAttributes(`{"preco": true}`); err == nil {
+		t.Error("configuração que não é uma lista deveria ser rejeitada")
+	}
+}
+
+func TestProductDisplayAttributes(t *testing.T) {
+	product := newPricedTestProduct("Dipirona 500mg", "8.90")
+	product.Description = "Analgésico e antitérmico"
+	product.Brand = "Medley"
+	product.Attributes = map[string]string{"Dosagem": "500mg", "principio_ativo": "Dipirona monoidratada", "tamanho": ""}
+
+	tests := []struct {
+		name      string
+		setting   string
+		listing   []string
+		detail    []string
+		unwanted  []string
+		firstLine string
+	}{
+		{
+			name:      "sem configuração mantém o padrão",
+			setting:   "",
+			listing:   []string{"💰 **R$ 8,90**", "📝 Analgésico e antitérmico"},
+			detail:    []string{"💰 **Preço:** R$ 8,90", "📝 **Descrição:** Analgésico e antitérmico", "🏭 **Marca:** Medley"},
+			unwanted:  []string{"Dosagem", "Principio ativo"},
+			firstLine: "💰 **R$ 8,90**",
+		},
+		{
+			name:      "lista vazia mantém o padrão",
+			setting:   "[]",
+			listing:   []string{"💰 **R$ 8,90**", "📝 Analgésico e antitérmico"},
+			detail:    []string{"💰 **Preço:** R$ 8,90", "🏭 **Marca:** Medley"},
+			unwanted:  []string{"Dosagem"},
+			firstLine: "💰 **R$ 8,90**",
+		},
+		{
+			name:      "farmácia mostra a dosagem",
+			setting:   `["dosagem", "preco", "principio_ativo"]`,
+			listing:   []string{"• Dosagem: 500mg", "💰 **R$ 8,90**", "• Principio ativo: Dipirona monoidratada"},
+			detail:    []string{"🔹 **Dosagem:** 500mg", "💰 **Preço:** R$ 8,90", "🔹 **Principio ativo:** Dipirona monoidratada", "📊 **Estoque:**"},
+			unwanted:  []string{"Analgésico", "Medley"},
+			firstLine: "• Dosagem: 500mg",
+		},
+		{
+			name:      "atributo sem valor no produto não aparece",
+			setting:   `["tamanho", "marca"]`,
+			listing:   []string{"🏭 Medley"},
+			detail:    []string{"🏭 **Marca:** Medley"},
+			unwanted:  []string{"Tamanho", "R$ 8,90", "Analgésico"},
+			firstLine: "🏭 Medley",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tenantID := uuid.New()
+			phone := "5527999999999"
+			settings := map[string]string{}
+			if tt.setting != "" {
+				settings[ProductDisplayAttributesSettingKey] = tt.setting
+			}
+			s := &AIService{
+				productService:  &fakeProductService{products: []models.Product{product}},
+				settingsService: &fakeSettingsService{values: settings},
+				memoryManager:   NewMemoryManager(),
+			}
+
+			listing, _ := s.formatProductListing(context.Background(), tenantID, phone, []models.Product{product}, "", 10)
+			lines := strings.Split(listing, "\n")
+			if len(lines) < 4 || strings.TrimSpace(lines[3]) != tt.firstLine {
+				t.Errorf("primeira linha do produto deveria ser %q, obtido:\n%s", tt.firstLine, listing)
+			}
+			for _, expected := range tt.listing {
+				if !strings.Contains(listing, expected) {
+					t.Errorf("listagem sem %q:\n%s", expected, listing)
+				}
+			}
+
+			detail, err := s.handleDetalharItem(context.Background(), tenantID, phone, map[string]interface{}{"identifier": product.ID.String()})
+			if err != nil {
+				t.Fatalf("erro inesperado: %v", err)
+			}
+			for _, expected := range tt.detail {
+				if !strings.Contains(detail, expected) {
+					t.Errorf("detalhe sem %q:\n%s", expected, detail)
+				}
+			}
+
+			for _, unwanted := range tt.unwanted {
+				if strings.Contains(listing, unwanted) || strings.Contains(detail, unwanted) {
+					t.Errorf("%q não está configurado e não deveria aparecer:\nlistagem:\n%s\ndetalhe:\n%s", unwanted, listing, detail)
+				}
+			}
+		})
+	}
+}
+
+func TestProductDisplayAttributesKeepsOutOfStockNotice(t *testing.T) {
+	product := newPricedTestProduct("Dipirona 500mg", "8.90")
+	product.StockQuantity = 0
+	product.Attributes = map[string]string{"dosagem": "500mg"}
+	s := &AIService{
+		settingsService: &fakeSettingsService{values: map[string]string{ProductDisplayAttributesSettingKey: `["dosagem"]`}},
+		memoryManager:   NewMemoryManager(),
+	}
+
+	listing, _ := s.formatProductListing(context.Background(), uuid.New(), "5527999999999", []models.Product{product}, "", 10)
+	if !strings.Contains(listing, "• Dosagem: 500mg\n   🚫 Esgotado no momento") {
+		t.Errorf("produto esgotado deveria continuar avisando mesmo sem o estoque configurado:\n%s", listing)
+	}
+}
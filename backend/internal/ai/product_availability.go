@@ -0,0 +1,76 @@
+package ai
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"iafarma/pkg/models"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+)
+
+// maxUnavailableMatches limita quantos produtos pausados são citados quando o cliente pergunta por eles
+const maxUnavailableMatches = 3
+
+// filterAvailableProducts remove os produtos pausados pela loja (usado nos resultados do RAG, que não conhece a pausa)
+func filterAvailableProducts(products []models.Product) []models.Product {
+	filtered := make([]models.Product, 0, len(products))
+	for _, product := range products {
+		if product.Available {
+			filtered = append(filtered, product)
+		}
+	}
+	return filtered
+}
+
+// unavailableProductMessage explica ao cliente que o produto foi pausado pela loja e não pode ir para o carrinho
+func unavailableProductMessage(product *models.Product) string {
+	return fmt.Sprintf("🚫 **%s** está indisponível no momento.\n\n💡 Posso te ajudar a encontrar outro produto?", product.Name)
+}
+
+// findUnavailableProducts busca produtos pausados que correspondem à pergunta do cliente, para avisar que estão
+// indisponíveis em vez de dizer que a loja não tem o produto
+func (s *AIService) findUnavailableProducts(ctx context.Context, tenantID uuid.UUID, query string) []models.Product {
+	if s.productService == nil || strings.TrimSpace(query) == "" {
+		return nil
+	}
+
+	products, err := s.searchProductsAdvanced(ctx, tenantID, ProductSearchFilters{
+		Query:              query,
+		Limit:              maxUnavailableMatches * 2,
+		IncludeOutOfStock:  true,
+		IncludeUnavailable: true,
+	})
+	if err != nil {
+		log.Warn().Err(err).Str("query", query).Msg("⚠️ Não foi possível verificar produtos indisponíveis")
+		return nil
+	}
+
+	var unavailable []models.Product
+	for _, product := range products {
+		if !product.Available {
+			unavailable = append(unavailable, product)
+			if len(unavailable) == maxUnavailableMatches {
+				break
+			}
+		}
+	}
+	return unavailable
+}
+
+// formatUnavailableProducts monta a resposta para produtos pausados encontrados na busca
+func formatUnavailableProducts(products []models.Product) string {
+	if len(products) == 1 {
+		return unavailableProductMessage(&products[0])
+	}
+
+	var builder strings.Builder
+	builder.WriteString("🚫 Estes produtos estão indisponíveis no momento:\n\n")
+	for _, product := range products {
+		builder.WriteString(fmt.Sprintf("• %s\n", product.Name))
+	}
+	builder.WriteString("\n💡 Posso te ajudar a encontrar outro produto?")
+	return builder.String()
+}
@@ -0,0 +1,122 @@
+package ai
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"iafarma/pkg/models"
+
+	"github.com/google/uuid"
+)
+
+// availabilityProductService simula o banco: a busca só devolve produtos pausados quando pedido explicitamente
+type availabilityProductService struct {
+	fakeProductService
+}
+
+func (f *availabilityProductService) SearchProductsAdvanced(tenantID uuid.UUID, filters ProductSearchFilters) ([]models.Product, error) {
+	f.advancedCalls++
+	var products []models.Product
+	for _, product := range f.products {
+		if product.Available || filters.IncludeUnavailable {
+			products = append(products, product)
+		}
+	}
+	return products, nil
+}
+
+func (f *availabilityProductService) SearchProducts(tenantID uuid.UUID, query string, limit int) ([]models.Product, error) {
+	products, _ := f.SearchProductsAdvanced(tenantID, ProductSearchFilters{Query: query, Limit: limit})
+	return products, nil
+}
+
+func newPausedTestProduct(name string) models.Product {
+	product := newPricedTestProduct(name, "6.90")
+	product.Available = false
+	return product
+}
+
+func TestBaseConditionExcluiProdutosPausados(t *testing.T) {
+	if condition := (ProductSearchFilters{Query: "tomate"}).BaseCondition(); !strings.Contains(condition, "available = true") {
+		t.Errorf("busca deveria excluir produtos pausados: %s", condition)
+	}
+	if condition := (ProductSearchFilters{Query: "tomate", IncludeUnavailable: true}).BaseCondition(); strings.Contains(condition, "available") {
+		t.Errorf("busca por pausados não deveria filtrar disponibilidade: %s", condition)
+	}
+}
+
+func TestBuscaRAGExcluiProdutosPausados(t *testing.T) {
+	paused := newPausedTestProduct("Tomate Italiano")
+	available := newPricedTestProduct("Tomate Cereja", "8.90")
+	s := &AIService{
+		productService:   &fakeProductService{products: []models.Product{paused, available}},
+		embeddingService: &fakeEmbeddingService{results: []ProductSearchResult{{ID: paused.ID.String()}, {ID: available.ID.String()}}},
+		settingsService:  &fakeSettingsService{values: map[string]string{SearchModeSettingKey: searchModeRAG}},
+	}
+
+	result, _, err := s.searchProductsForQuery(context.Background(), uuid.New(), ProductSearchFilters{Query: "tomate", Limit: 10})
+	if err != nil {
+		t.Fatalf("erro inesperado: %v", err)
+	}
+	if len(result) != 1 || result[0].ID != available.ID {
+		t.Errorf("esperado apenas %q, obtido %+v", available.Name, result)
+	}
+}
+
+func TestConsultarItensAvisaProdutoPausado(t *testing.T) {
+	s := &AIService{
+		settingsService: &fakeSettingsService{},
+		productService:  &availabilityProductService{fakeProductService{products: []models.Product{newPausedTestProduct("Tomate Italiano")}}},
+		memoryManager:   NewMemoryManager(),
+	}
+
+	result, err := s.handleConsultarItens(context.Background(), uuid.New(), uuid.New(), "5527999999999", map[string]interface{}{"query": "tomate"})
+	if err != nil {
+		t.Fatalf("erro inesperado: %v", err)
+	}
+	if !strings.Contains(result, "**Tomate Italiano** está indisponível no momento") {
+		t.Errorf("esperado aviso de produto indisponível, obtido:\n%s", result)
+	}
+	if strings.Contains(result, "Nenhum produto encontrado") {
+		t.Errorf("produto pausado não deveria ser tratado como inexistente:\n%s", result)
+	}
+}
+
+func TestProdutoPausadoNaoVaiAoCarrinho(t *testing.T) {
+	tenantID, customerID := uuid.New(), uuid.New()
+	phone := "5527999999999"
+	product := newPausedTestProduct("Tomate Italiano")
+
+	// Sem cartService: qualquer tentativa de usar o carrinho quebraria o teste
+	s := &AIService{
+		productService: &availabilityProductService{fakeProductService{products: []models.Product{product}}},
+		memoryManager:  NewMemoryManager(),
+	}
+	s.memoryManager.StoreProductList(tenantID, phone, []models.Product{product})
+
+	attempts := map[string]func() (string, error){
+		"por id": func() (string, error) {
+			return s.tryAddProductToCart(context.Background(), tenantID, customerID, phone, product.ID, 1)
+		},
+		"por número": func() (string, error) {
+			return s.handleAdicionarPorNumero(context.Background(), tenantID, customerID, phone, map[string]interface{}{"numero": float64(1), "quantidade": float64(1)})
+		},
+		"por nome": func() (string, error) {
+			return s.handleAdicionarProdutoPorNome(context.Background(), tenantID, customerID, phone, map[string]interface{}{"nome_produto": "tomate", "quantidade": float64(1)})
+		},
+		"detalhes": func() (string, error) {
+			return s.handleDetalharItem(context.Background(), tenantID, phone, map[string]interface{}{"identifier": "1"})
+		},
+	}
+
+	for name, attempt := range attempts {
+		result, err := attempt()
+		if err != nil {
+			t.Fatalf("%s: erro inesperado: %v", name, err)
+		}
+		if !strings.Contains(result, "indisponível no momento") {
+			t.Errorf("%s: esperado aviso de produto indisponível, obtido:\n%s", name, result)
+		}
+	}
+}
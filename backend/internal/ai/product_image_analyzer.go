@@ -7,6 +7,7 @@ import (
 	"regexp"
 	"strings"
 
+	"github.com/rs/zerolog/log"
 	"github.com/sashabaranov/go-openai"
 )
 
@@ -103,21 +104,21 @@ FORMATO OBRIGATÓRIO (resposta completa):
 	)
 
 	if err != nil {
-		fmt.Printf("OpenAI API call failed: %v\n", err)
+		log.Error().Err(err).Msg("OpenAI API call failed")
 		return nil, fmt.Errorf("failed to analyze image with AI: %w", err)
 	}
 
-	fmt.Printf("OpenAI Response received. Choices count: %d\n", len(resp.Choices))
+	log.Debug().Msgf("OpenAI Response received. Choices count: %d", len(resp.Choices))
 
 	if len(resp.Choices) == 0 {
 		return nil, fmt.Errorf("no response from AI")
 	}
 
 	content := resp.Choices[0].Message.Content
-	fmt.Printf("Raw AI Response Content (length=%d): %s\n", len(content), content)
+	log.Debug().Msgf("Raw AI Response Content (length=%d): %s", len(content), content)
 
 	content = strings.TrimSpace(content)
-	fmt.Printf("Trimmed AI Response Content (length=%d): %s\n", len(content), content)
+	log.Debug().Msgf("Trimmed AI Response Content (length=%d): %s", len(content), content)
 
 	if len(content) == 0 {
 		return nil, fmt.Errorf("empty response from AI")
@@ -126,21 +127,21 @@ FORMATO OBRIGATÓRIO (resposta completa):
 	// Try to extract JSON from the response
 	var result ProductImageAnalysisResponse
 	if err := json.Unmarshal([]byte(content), &result); err != nil {
-		fmt.Printf("Direct JSON unmarshal failed: %v\n", err)
+		log.Debug().Msgf("Direct JSON unmarshal failed: %v", err)
 
 		// If direct unmarshal fails, try to find JSON in the response
 		startIdx := strings.Index(content, "{")
 		endIdx := strings.LastIndex(content, "}") + 1
 
-		fmt.Printf("JSON extraction indices: start=%d, end=%d\n", startIdx, endIdx)
+		log.Debug().Msgf("JSON extraction indices: start=%d, end=%d", startIdx, endIdx)
 
 		if startIdx >= 0 && endIdx > startIdx {
 			jsonContent := content[startIdx:endIdx]
-			fmt.Printf("Extracted JSON (length=%d): %s\n", len(jsonContent), jsonContent)
+			log.Debug().Msgf("Extracted JSON (length=%d): %s", len(jsonContent), jsonContent)
 
 			// Try to fix incomplete JSON by ensuring it ends properly
 			if !strings.HasSuffix(strings.TrimSpace(jsonContent), "}") {
-				fmt.Printf("JSON appears incomplete, attempting to fix...\n")
+				log.Debug().Msgf("JSON appears incomplete, attempting to fix...")
 
 				// Try to find the last complete product entry
 				lastProductStart := strings.LastIndex(jsonContent, `"name":`)
@@ -150,36 +151,36 @@ FORMATO OBRIGATÓRIO (resposta completa):
 					if lastProductBrace > 0 {
 						// Truncate to the product before the incomplete one
 						jsonContent = jsonContent[:lastProductBrace] + "]}"
-						fmt.Printf("Fixed JSON (length=%d): %s\n", len(jsonContent), jsonContent)
+						log.Debug().Msgf("Fixed JSON (length=%d): %s", len(jsonContent), jsonContent)
 					}
 				}
 			}
 
 			if err := json.Unmarshal([]byte(jsonContent), &result); err != nil {
-				fmt.Printf("Extracted JSON unmarshal failed: %v\n", err)
+				log.Debug().Msgf("Extracted JSON unmarshal failed: %v", err)
 
 				// As a last resort, try to parse individual products from the content
 				products := extractProductsFromPartialJSON(content)
 				if len(products) > 0 {
-					fmt.Printf("Recovered %d products from partial JSON\n", len(products))
+					log.Debug().Msgf("Recovered %d products from partial JSON", len(products))
 					return &ProductImageAnalysisResponse{Products: products}, nil
 				}
 
 				return nil, fmt.Errorf("failed to parse AI response as JSON: %w\nOriginal content: %s", err, content)
 			}
 		} else {
-			fmt.Printf("No JSON boundaries found in response\n")
+			log.Debug().Msgf("No JSON boundaries found in response")
 			// Try to return empty result instead of error if no JSON found
 			return &ProductImageAnalysisResponse{Products: []DetectedProduct{}}, nil
 		}
 	}
 
 	// Validate the result
-	fmt.Printf("Successfully parsed AI response. Products found: %d\n", len(result.Products))
+	log.Debug().Msgf("Successfully parsed AI response. Products found: %d", len(result.Products))
 
 	// Log each product for debugging
 	for i, product := range result.Products {
-		fmt.Printf("Product %d: Name='%s', Price='%s', Tags=%v\n",
+		log.Debug().Msgf("Product %d: Name='%s', Price='%s', Tags=%v",
 			i+1, product.Name, product.Price, product.Tags)
 	}
 
@@ -0,0 +1,189 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"iafarma/pkg/models"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+	"github.com/sashabaranov/go-openai"
+)
+
+const (
+	// maxImageSearchTerms limita quantos termos extraídos da foto viram buscas no catálogo
+	maxImageSearchTerms = 3
+	// maxImageSearchResults limita quantos produtos parecidos são apresentados ao cliente
+	maxImageSearchResults = 5
+
+	productImageNotFoundMarker = "NAO_PRODUTO"
+)
+
+const productImageSearchPrompt = `Você ajuda uma loja a encontrar no catálogo o produto que o cliente enviou em uma foto.
+Descreva o produto principal da imagem como termos de busca curtos, do mais específico ao mais genérico
+(ex: "tênis nike air max", "tênis de corrida", "tênis"). Use no máximo 3 termos, um por linha, sem numeração.
+Se a imagem não mostrar um produto, responda apenas com 'NAO_PRODUTO'.`
+
+// imageAnalyzer descreve o conteúdo de uma imagem a partir de uma instrução
+type imageAnalyzer interface {
+	AnalyzeImage(ctx context.Context, imageURL, prompt string) (string, error)
+}
+
+// openAIImageAnalyzer usa o GPT-4 Vision para analisar imagens
+type openAIImageAnalyzer struct {
+	client *openai.Client
+}
+
+func (o *openAIImageAnalyzer) AnalyzeImage(ctx context.Context, imageURL, prompt string) (string, error) {
+	if o.client == nil {
+		return "", errors.New("cliente OpenAI não configurado")
+	}
+
+	resp, err := o.client.CreateChatCompletion(ctx, openai.ChatCompletionRequest{
+		Model:     openai.GPT4o,
+		MaxTokens: 300,
+		Messages: []openai.ChatCompletionMessage{
+			{
+				Role: openai.ChatMessageRoleUser,
+				MultiContent: []openai.ChatMessagePart{
+					{Type: openai.ChatMessagePartTypeText, Text: prompt},
+					{Type: openai.ChatMessagePartTypeImageURL, ImageURL: &openai.ChatMessageImageURL{URL: imageURL}},
+				},
+			},
+		},
+	})
+	if err != nil {
+		return "", fmt.Errorf("erro ao analisar imagem: %w", err)
+	}
+	if len(resp.Choices) == 0 {
+		return "", errors.New("resposta vazia ao analisar imagem")
+	}
+	return strings.TrimSpace(resp.Choices[0].Message.Content), nil
+}
+
+// getImageAnalyzer retorna o analisador de imagens configurado (OpenAI por padrão)
+func (s *AIService) getImageAnalyzer() imageAnalyzer {
+	if s.imageAnalyzer != nil {
+		return s.imageAnalyzer
+	}
+	return &openAIImageAnalyzer{client: s.client}
+}
+
+// isPharmacyCategory indica se a categoria do negócio é farmácia/drogaria
+func isPharmacyCategory(category string) bool {
+	normalized := strings.ToLower(strings.TrimSpace(category))
+	return strings.HasPrefix(normalized, "farm") || strings.HasPrefix(normalized, "drogaria")
+}
+
+// isPharmacyTenant indica se a imagem deve seguir o fluxo de medicamentos/receitas.
+// Na dúvida (tenant não encontrado), mantém o fluxo de farmácia.
+func (s *AIService) isPharmacyTenant(tenantID uuid.UUID) bool {
+	if s.productService == nil {
+		return true
+	}
+
+	tenant, err := s.productService.GetTenantByID(tenantID)
+	if err != nil || tenant == nil {
+		return true
+	}
+	return isPharmacyCategory(tenant.BusinessCategory)
+}
+
+// parseImageSearchTerms extrai os termos de busca da análise da imagem (vazio se não há produto)
+func parseImageSearchTerms(analysis string) []string {
+	if strings.Contains(strings.ToUpper(analysis), productImageNotFoundMarker) {
+		return nil
+	}
+
+	var terms []string
+	seen := make(map[string]bool)
+	for _, line := range strings.Split(analysis, "\n") {
+		// Remove marcadores de lista, numeração e aspas que o modelo às vezes acrescenta
+		term := strings.TrimLeft(strings.TrimSpace(line), "-•*0123456789.) ")
+		term = strings.TrimSpace(strings.Trim(term, "\"'*"))
+		key := strings.ToLower(term)
+		if term == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		terms = append(terms, term)
+		if len(terms) == maxImageSearchTerms {
+			break
+		}
+	}
+	return terms
+}
+
+// searchProductsByImageTerms busca no catálogo cada termo, do mais específico ao mais genérico, sem repetir produtos
+func (s *AIService) searchProductsByImageTerms(ctx context.Context, tenantID uuid.UUID, terms []string) []models.Product {
+	var products []models.Product
+	seen := make(map[uuid.UUID]bool)
+	for _, term := range terms {
+		results, err := s.searchProducts(ctx, tenantID, term, maxImageSearchResults)
+		if err != nil {
+			log.Error().Err(err).Str("term", term).Msg("Failed to search products for image term")
+			continue
+		}
+		for _, product := range results {
+			if seen[product.ID] {
+				continue
+			}
+			seen[product.ID] = true
+			products = append(products, product)
+			if len(products) == maxImageSearchResults {
+				return products
+			}
+		}
+	}
+	return products
+}
+
+// processProductImage trata a foto de um produto ("quero igual a foto") em lojas que não são farmácia,
+// apresentando os produtos parecidos do catálogo numerados para seleção
+func (s *AIService) processProductImage(ctx context.Context, tenantID uuid.UUID, customerPhone, imageURL string) (string, error) {
+	s.memoryManager.AddToConversationHistory(tenantID, customerPhone, openai.ChatCompletionMessage{
+		Role:    openai.ChatMessageRoleUser,
+		Content: "Enviei uma foto de um produto que quero",
+	})
+
+	reply := func(response string) (string, error) {
+		s.memoryManager.AddToConversationHistory(tenantID, customerPhone, openai.ChatCompletionMessage{
+			Role:    openai.ChatMessageRoleAssistant,
+			Content: response,
+		})
+		return response, nil
+	}
+
+	analysis, err := s.getImageAnalyzer().AnalyzeImage(ctx, imageURL, productImageSearchPrompt)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to analyze product image")
+		return reply("Não consegui analisar a foto agora. 😕\n\nPode me dizer o nome do produto que você procura? Vou buscar no nosso catálogo.")
+	}
+
+	terms := parseImageSearchTerms(analysis)
+	log.Info().Strs("terms", terms).Msg("Extracted search terms from product image")
+	if len(terms) == 0 {
+		return reply("Não consegui identificar um produto nesta foto. 🤔\n\nVocê pode:\n• Enviar outra foto mais próxima do produto\n• Digitar o nome do produto que procura")
+	}
+
+	products := s.searchProductsByImageTerms(ctx, tenantID, terms)
+	if len(products) == 0 {
+		return reply(fmt.Sprintf("📸 Pela foto, parece ser *%s*, mas não encontrei nada parecido no nosso catálogo no momento.\n\nDigite o nome do produto para eu buscar de outra forma.", terms[0]))
+	}
+
+	// Numeração oficial da memória, para o cliente escolher por número
+	productRefs := s.memoryManager.StoreProductList(tenantID, customerPhone, products)
+
+	var builder strings.Builder
+	builder.WriteString(fmt.Sprintf("📸 Pela foto, parece ser *%s*! Encontrei estas opções no nosso catálogo:\n\n", terms[0]))
+	for _, ref := range productRefs {
+		builder.WriteString(fmt.Sprintf("%d. %s - %s\n", ref.SequentialID, ref.Name, formatPriceLabel(ref.Price, ref.SalePrice)))
+	}
+	builder.WriteString("\n💡 Para ver detalhes, diga: \"produto [número]\"\n")
+	builder.WriteString("🛒 Para adicionar ao carrinho: \"adicionar [número] quantidade [X]\"")
+
+	return reply(builder.String())
+}
@@ -0,0 +1,146 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"iafarma/pkg/models"
+
+	"github.com/google/uuid"
+)
+
+// fakeImageAnalyzer retorna uma análise fixa e registra a instrução recebida
+type fakeImageAnalyzer struct {
+	analysis string
+	err      error
+	prompt   string
+}
+
+func (f *fakeImageAnalyzer) AnalyzeImage(ctx context.Context, imageURL, prompt string) (string, error) {
+	f.prompt = prompt
+	return f.analysis, f.err
+}
+
+// categoryProductService retorna o tenant com a categoria de negócio configurada
+type categoryProductService struct {
+	fakeProductService
+	category string
+}
+
+func (f *categoryProductService) GetTenantByID(tenantID uuid.UUID) (*models.Tenant, error) {
+	return &models.Tenant{BusinessCategory: f.category}, nil
+}
+
+// withImageSearch liga o cliente, o catálogo do segmento informado e o analisador de imagens
+func withImageSearch(category string, analyzer *fakeImageAnalyzer, products ...models.Product) testServiceOption {
+	customer := &models.Customer{Phone: "5527999999999"}
+	customer.ID = uuid.New()
+	return withOverride(func(s *AIService) {
+		s.customerService = &phoneCustomerService{customer: customer}
+		s.productService = &categoryProductService{fakeProductService: fakeProductService{products: products}, category: category}
+		s.imageAnalyzer = analyzer
+	})
+}
+
+func newImageSearchTestProduct(name, price string) models.Product {
+	product := models.Product{Name: name, Price: price, StockQuantity: 10, Available: true}
+	product.ID = uuid.New()
+	return product
+}
+
+func TestIsPharmacyCategory(t *testing.T) {
+	tests := []struct {
+		category string
+		esperado bool
+	}{
+		{"Farmacia", true},
+		{"farmácia", true},
+		{"Drogaria", true},
+		{"Loja de roupas", false},
+		{"Hamburgeria", false},
+		{"", false},
+	}
+
+	for _, tt := range tests {
+		if got := isPharmacyCategory(tt.category); got != tt.esperado {
+			t.Errorf("%q: esperado %v, obtido %v", tt.category, tt.esperado, got)
+		}
+	}
+}
+
+func TestParseImageSearchTerms(t *testing.T) {
+	tests := []struct {
+		analysis string
+		esperado []string
+	}{
+		{"tênis nike air max\ntênis de corrida\ntênis", []string{"tênis nike air max", "tênis de corrida", "tênis"}},
+		{"1. \"Camiseta polo azul\"\n2. camiseta polo\n- Camiseta Polo Azul", []string{"Camiseta polo azul", "camiseta polo"}},
+		{"a\nb\nc\nd", []string{"a", "b", "c"}},
+		{"NAO_PRODUTO", nil},
+	}
+
+	for _, tt := range tests {
+		if got := parseImageSearchTerms(tt.analysis); !reflect.DeepEqual(got, tt.esperado) {
+			t.Errorf("%q: esperado %v, obtido %v", tt.analysis, tt.esperado, got)
+		}
+	}
+}
+
+func TestProcessImageMessageNonPharmacyResolvesCatalogProducts(t *testing.T) {
+	tenantID := uuid.New()
+	phone := "5527999999999"
+	analyzer := &fakeImageAnalyzer{analysis: "tênis nike air max\ntênis de corrida\ntênis"}
+	airMax := newImageSearchTestProduct("Tênis Nike Air Max 90", "599.90")
+	running := newImageSearchTestProduct("Tênis de Corrida Olympikus", "249.90")
+	s, _ := newTestService(nil, withImageSearch("Loja de calçados", analyzer, airMax, running, newImageSearchTestProduct("Meia esportiva", "19.90")))
+
+	response, err := s.ProcessImageMessage(context.Background(), tenantID, phone, "https://cdn/foto.jpg", uuid.New().String())
+	if err != nil {
+		t.Fatalf("erro inesperado: %v", err)
+	}
+
+	if analyzer.prompt != productImageSearchPrompt {
+		t.Errorf("loja que não é farmácia deveria usar a análise de produto, obtido prompt %q", analyzer.prompt)
+	}
+	for _, expected := range []string{"1. Tênis Nike Air Max 90 - R$ 599,90", "2. Tênis de Corrida Olympikus - R$ 249,90"} {
+		if !strings.Contains(response, expected) {
+			t.Errorf("esperado %q na resposta:\n%s", expected, response)
+		}
+	}
+	if strings.Contains(response, "Meia") || strings.Contains(strings.ToLower(response), "medicamento") {
+		t.Errorf("resposta não deveria listar itens não relacionados nem falar de medicamentos:\n%s", response)
+	}
+
+	// A numeração exibida é a mesma da memória, permitindo "adicionar 2"
+	if ref := s.memoryManager.GetProductBySequentialID(tenantID, phone, 2); ref == nil || ref.ProductID != running.ID {
+		t.Errorf("produto 2 da memória deveria ser %s, obtido %+v", running.Name, ref)
+	}
+}
+
+func TestProcessImageMessageNonPharmacyFallbacks(t *testing.T) {
+	tests := []struct {
+		name     string
+		analyzer *fakeImageAnalyzer
+		contains string
+	}{
+		{"sem produto na foto", &fakeImageAnalyzer{analysis: "NAO_PRODUTO"}, "Não consegui identificar um produto"},
+		{"nada parecido no catálogo", &fakeImageAnalyzer{analysis: "bicicleta aro 29"}, "não encontrei nada parecido"},
+		{"erro na análise", &fakeImageAnalyzer{err: errors.New("timeout")}, "Não consegui analisar a foto"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, _ := newTestService(nil, withImageSearch("Loja de calçados", tt.analyzer, newImageSearchTestProduct("Tênis Nike Air Max 90", "599.90")))
+			response, err := s.ProcessImageMessage(context.Background(), uuid.New(), "5527999999999", "https://cdn/foto.jpg", uuid.New().String())
+			if err != nil {
+				t.Fatalf("erro inesperado: %v", err)
+			}
+			if !strings.Contains(response, tt.contains) {
+				t.Errorf("esperado %q na resposta:\n%s", tt.contains, response)
+			}
+		})
+	}
+}
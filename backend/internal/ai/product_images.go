@@ -0,0 +1,129 @@
+package ai
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// SearchResultImagesSettingKey define se a busca de produtos envia imagens: "off", "top" (só o primeiro resultado) ou "album"
+	SearchResultImagesSettingKey = "ai_search_result_images"
+	// SearchResultImagesMaxSettingKey define quantas imagens o álbum pode ter
+	SearchResultImagesMaxSettingKey = "ai_search_result_images_max"
+
+	SearchResultImagesOff   = "off"
+	SearchResultImagesTop   = "top"
+	SearchResultImagesAlbum = "album"
+
+	defaultSearchResultImagesMax = 3
+	// maxSearchResultImages limita o álbum para não lotar a conversa do cliente
+	maxSearchResultImages = 5
+)
+
+// searchResultImagesConfig reúne a preferência do tenant para imagens na busca
+type searchResultImagesConfig struct {
+	Mode      string
+	MaxImages int
+}
+
+// getSearchResultImagesConfig retorna o modo de imagens da busca configurado pelo tenant (desativado por padrão)
+func (s *AIService) getSearchResultImagesConfig(ctx context.Context, tenantID uuid.UUID) searchResultImagesConfig {
+	config := searchResultImagesConfig{Mode: SearchResultImagesOff, MaxImages: defaultSearchResultImagesMax}
+	if s.settingsService == nil {
+		return config
+	}
+
+	setting, err := s.settingsService.GetSetting(ctx, tenantID, SearchResultImagesSettingKey)
+	if err == nil && setting != nil && setting.SettingValue != nil {
+		switch mode := strings.ToLower(strings.TrimSpace(*setting.SettingValue)); mode {
+		case SearchResultImagesTop, SearchResultImagesAlbum:
+			config.Mode = mode
+		}
+	}
+
+	setting, err = s.settingsService.GetSetting(ctx, tenantID, SearchResultImagesMaxSettingKey)
+	if err == nil && setting != nil && setting.SettingValue != nil {
+		if max, parseErr := strconv.Atoi(strings.TrimSpace(*setting.SettingValue)); parseErr == nil && max > 0 {
+			config.MaxImages = max
+		}
+	}
+	if config.MaxImages > maxSearchResultImages {
+		config.MaxImages = maxSearchResultImages
+	}
+
+	return config
+}
+
+// selectSearchResultImages escolhe as imagens a enviar para os produtos listados.
+// Segue a numeração da lista (legenda "1. Nome") e só considera itens exibidos e com imagem cadastrada.
+func selectSearchResultImages(config searchResultImagesConfig, refs []ProductReference, images map[uuid.UUID]string, shown int) []ResponseMedia {
+	limit := 0
+	switch config.Mode {
+	case SearchResultImagesTop:
+		limit = 1
+	case SearchResultImagesAlbum:
+		limit = config.MaxImages
+	}
+	if limit <= 0 {
+		return nil
+	}
+
+	var media []ResponseMedia
+	for _, ref := range refs {
+		if ref.SequentialID > shown || len(media) >= limit {
+			break
+		}
+		url := images[ref.ProductID]
+		if url == "" {
+			if config.Mode == SearchResultImagesTop {
+				// Só o primeiro resultado interessa no modo "top"
+				break
+			}
+			continue
+		}
+		media = append(media, ResponseMedia{URL: url, Caption: fmt.Sprintf("%d. %s", ref.SequentialID, ref.Name)})
+	}
+	return media
+}
+
+// respondWithSearchResultImages anexa as imagens dos produtos listados à resposta atual (quando o tenant habilitou)
+// e retorna o texto inalterado, mantendo a assinatura string dos handlers de ferramentas
+func (s *AIService) respondWithSearchResultImages(ctx context.Context, tenantID uuid.UUID, customerPhone, text string, refs []ProductReference, shown int) string {
+	config := s.getSearchResultImagesConfig(ctx, tenantID)
+	if config.Mode == SearchResultImagesOff || len(refs) == 0 {
+		return text
+	}
+
+	productIDs := make([]uuid.UUID, 0, len(refs))
+	for _, ref := range refs {
+		if ref.SequentialID <= shown {
+			productIDs = append(productIDs, ref.ProductID)
+		}
+	}
+
+	images, err := s.productService.GetProductImageURLs(tenantID, productIDs)
+	if err != nil {
+		log.Warn().Err(err).Str("tenant_id", tenantID.String()).Msg("⚠️ Não foi possível buscar imagens dos produtos")
+		return text
+	}
+
+	media := selectSearchResultImages(config, refs, images, shown)
+	if len(media) == 0 {
+		return text
+	}
+
+	response := &AIResponse{Text: text}
+	if config.Mode == SearchResultImagesTop {
+		response.MediaURL = media[0].URL
+		response.MediaCaption = media[0].Caption
+	} else {
+		response.Images = media
+	}
+	s.pendingResponses.Store(interactiveResponseKey(tenantID, customerPhone), response)
+	return text
+}
@@ -0,0 +1,137 @@
+package ai
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+// imageProductService retorna imagens fixas por produto
+type imageProductService struct {
+	fakeProductService
+	images map[uuid.UUID]string
+}
+
+func (f *imageProductService) GetProductImageURLs(tenantID uuid.UUID, productIDs []uuid.UUID) (map[uuid.UUID]string, error) {
+	result := make(map[uuid.UUID]string)
+	for _, id := range productIDs {
+		if url, ok := f.images[id]; ok {
+			result[id] = url
+		}
+	}
+	return result, nil
+}
+
+func newSearchImageTestRefs(count int) []ProductReference {
+	refs := make([]ProductReference, count)
+	for i := range refs {
+		refs[i] = ProductReference{SequentialID: i + 1, ProductID: uuid.New(), Name: string(rune('A' + i))}
+	}
+	return refs
+}
+
+func TestSelectSearchResultImages(t *testing.T) {
+	refs := newSearchImageTestRefs(8)
+	allImages := make(map[uuid.UUID]string)
+	for _, ref := range refs {
+		allImages[ref.ProductID] = "https://cdn/" + ref.Name + ".jpg"
+	}
+	withoutFirst := make(map[uuid.UUID]string)
+	for id, url := range allImages {
+		if id != refs[0].ProductID {
+			withoutFirst[id] = url
+		}
+	}
+
+	tests := []struct {
+		name     string
+		config   searchResultImagesConfig
+		images   map[uuid.UUID]string
+		shown    int
+		esperado []ResponseMedia
+	}{
+		{"desativado", searchResultImagesConfig{Mode: SearchResultImagesOff, MaxImages: 3}, allImages, 10, nil},
+		{"primeiro resultado", searchResultImagesConfig{Mode: SearchResultImagesTop, MaxImages: 3}, allImages, 10,
+			[]ResponseMedia{{URL: "https://cdn/A.jpg", Caption: "1. A"}}},
+		{"primeiro resultado sem imagem", searchResultImagesConfig{Mode: SearchResultImagesTop, MaxImages: 3}, withoutFirst, 10, nil},
+		{"álbum limitado", searchResultImagesConfig{Mode: SearchResultImagesAlbum, MaxImages: 3}, allImages, 10,
+			[]ResponseMedia{{URL: "https://cdn/A.jpg", Caption: "1. A"}, {URL: "https://cdn/B.jpg", Caption: "2. B"}, {URL: "https://cdn/C.jpg", Caption: "3. C"}}},
+		{"álbum pula produto sem imagem mantendo a numeração", searchResultImagesConfig{Mode: SearchResultImagesAlbum, MaxImages: 2}, withoutFirst, 10,
+			[]ResponseMedia{{URL: "https://cdn/B.jpg", Caption: "2. B"}, {URL: "https://cdn/C.jpg", Caption: "3. C"}}},
+		{"álbum só com itens exibidos", searchResultImagesConfig{Mode: SearchResultImagesAlbum, MaxImages: 5}, allImages, 2,
+			[]ResponseMedia{{URL: "https://cdn/A.jpg", Caption: "1. A"}, {URL: "https://cdn/B.jpg", Caption: "2. B"}}},
+		{"sem imagens cadastradas", searchResultImagesConfig{Mode: SearchResultImagesAlbum, MaxImages: 3}, map[uuid.UUID]string{}, 10, nil},
+	}
+
+	for _, tt := range tests {
+		if got := selectSearchResultImages(tt.config, refs, tt.images, tt.shown); !reflect.DeepEqual(got, tt.esperado) {
+			t.Errorf("%s: esperado %+v, obtido %+v", tt.name, tt.esperado, got)
+		}
+	}
+}
+
+func TestGetSearchResultImagesConfig(t *testing.T) {
+	tests := []struct {
+		name     string
+		values   map[string]string
+		esperado searchResultImagesConfig
+	}{
+		{"padrão", map[string]string{}, searchResultImagesConfig{Mode: SearchResultImagesOff, MaxImages: defaultSearchResultImagesMax}},
+		{"álbum", map[string]string{SearchResultImagesSettingKey: "Album", SearchResultImagesMaxSettingKey: "4"}, searchResultImagesConfig{Mode: SearchResultImagesAlbum, MaxImages: 4}},
+		{"limite máximo", map[string]string{SearchResultImagesSettingKey: "album", SearchResultImagesMaxSettingKey: "50"}, searchResultImagesConfig{Mode: SearchResultImagesAlbum, MaxImages: maxSearchResultImages}},
+		{"modo inválido", map[string]string{SearchResultImagesSettingKey: "video"}, searchResultImagesConfig{Mode: SearchResultImagesOff, MaxImages: defaultSearchResultImagesMax}},
+	}
+
+	for _, tt := range tests {
+		s := &AIService{settingsService: &fakeSettingsService{values: tt.values}}
+		if got := s.getSearchResultImagesConfig(context.Background(), uuid.New()); got != tt.esperado {
+			t.Errorf("%s: esperado %+v, obtido %+v", tt.name, tt.esperado, got)
+		}
+	}
+}
+
+func TestRespondWithSearchResultImages(t *testing.T) {
+	tenantID := uuid.New()
+	phone := "5527999999999"
+	refs := newSearchImageTestRefs(3)
+	products := &imageProductService{images: map[uuid.UUID]string{refs[0].ProductID: "https://cdn/A.jpg", refs[1].ProductID: "https://cdn/B.jpg"}}
+
+	tests := []struct {
+		name       string
+		mode       string
+		finalText  string
+		expectTop  string
+		expectSize int
+	}{
+		{"primeiro resultado", SearchResultImagesTop, "LISTA", "https://cdn/A.jpg", 1},
+		{"álbum", SearchResultImagesAlbum, "LISTA", "", 2},
+		{"desativado", SearchResultImagesOff, "LISTA", "", 0},
+		{"texto reescrito pela IA", SearchResultImagesAlbum, "outra resposta", "", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &AIService{
+				productService:  products,
+				settingsService: &fakeSettingsService{values: map[string]string{SearchResultImagesSettingKey: tt.mode}},
+			}
+
+			if text := s.respondWithSearchResultImages(context.Background(), tenantID, phone, "LISTA", refs, 10); text != "LISTA" {
+				t.Fatalf("texto deveria ser mantido, obtido %q", text)
+			}
+
+			response := buildAIResponse(tt.finalText, s.takePendingResponse(tenantID, phone))
+			if response.MediaURL != tt.expectTop {
+				t.Errorf("MediaURL = %q, esperado %q", response.MediaURL, tt.expectTop)
+			}
+			if got := len(response.Media()); got != tt.expectSize {
+				t.Errorf("esperado %d imagens, obtido %d", tt.expectSize, got)
+			}
+			if response.String() != tt.finalText {
+				t.Errorf("fallback de texto deveria ser %q, obtido %q", tt.finalText, response.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,107 @@
+package ai
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// PromoFlyerURLSettingKey guarda o link da imagem do encarte de ofertas enviado pelo tenant
+	PromoFlyerURLSettingKey = "promo_flyer_url"
+	// PromoFlyerCaptionSettingKey define a legenda enviada junto com o encarte
+	PromoFlyerCaptionSettingKey = "promo_flyer_caption"
+
+	defaultPromoFlyerCaption = "🗞️ Encarte de ofertas"
+)
+
+// validPromoFlyerURL indica se o link do encarte pode ser enviado ao cliente (http/https com host)
+func validPromoFlyerURL(rawURL string) bool {
+	if rawURL == "" || strings.ContainsAny(rawURL, " \t\n") {
+		return false
+	}
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return false
+	}
+	return (parsed.Scheme == "https" || parsed.Scheme == "http") && parsed.Host != ""
+}
+
+// getPromoFlyer retorna o link e a legenda do encarte configurado (link vazio se não houver encarte válido)
+func (s *AIService) getPromoFlyer(ctx context.Context, tenantID uuid.UUID) (string, string) {
+	if s.settingsService == nil {
+		return "", ""
+	}
+
+	read := func(key string) string {
+		setting, err := s.settingsService.GetSetting(ctx, tenantID, key)
+		if err != nil || setting == nil || setting.SettingValue == nil {
+			return ""
+		}
+		return strings.TrimSpace(*setting.SettingValue)
+	}
+
+	flyerURL := read(PromoFlyerURLSettingKey)
+	if flyerURL == "" {
+		return "", ""
+	}
+	if !validPromoFlyerURL(flyerURL) {
+		log.Warn().
+			Str("tenant_id", tenantID.String()).
+			Str("flyer_url", flyerURL).
+			Msg("⚠️ Link do encarte de ofertas inválido - listando produtos em promoção")
+		return "", ""
+	}
+
+	caption := read(PromoFlyerCaptionSettingKey)
+	if caption == "" {
+		caption = defaultPromoFlyerCaption
+	}
+	return flyerURL, caption
+}
+
+// handleVerEncarte envia o encarte de ofertas do tenant; sem encarte configurado, lista os produtos em promoção
+func (s *AIService) handleVerEncarte(ctx context.Context, tenantID uuid.UUID, customerPhone string) (string, error) {
+	flyerURL, caption := s.getPromoFlyer(ctx, tenantID)
+	if flyerURL == "" {
+		return s.listPromotionalProducts(tenantID, customerPhone)
+	}
+
+	log.Info().
+		Str("tenant_id", tenantID.String()).
+		Msg("🗞️ Enviando encarte de ofertas")
+
+	text := "🗞️ **Confira o nosso encarte de ofertas!**\n\n🛒 Gostou de alguma oferta? É só me dizer o nome do produto que eu adiciono ao seu carrinho."
+	s.pendingResponses.Store(interactiveResponseKey(tenantID, customerPhone), &AIResponse{
+		Text:         text,
+		MediaURL:     flyerURL,
+		MediaCaption: caption,
+	})
+	return text, nil
+}
+
+// listPromotionalProducts lista os produtos em promoção numerados, para o cliente escolher pelo número
+func (s *AIService) listPromotionalProducts(tenantID uuid.UUID, customerPhone string) (string, error) {
+	products, err := s.productService.GetPromotionalProducts(tenantID)
+	if err != nil {
+		return "❌ Erro ao buscar as promoções. Tente novamente.", err
+	}
+	if len(products) == 0 {
+		return "🏷️ No momento não temos produtos em promoção.\n\n💡 Use 'produtos' para ver nosso catálogo completo.", nil
+	}
+
+	productRefs := s.memoryManager.StoreProductList(tenantID, customerPhone, products)
+
+	var result strings.Builder
+	result.WriteString("🏷️ **Promoções da semana:**\n\n")
+	for _, productRef := range productRefs {
+		result.WriteString(fmt.Sprintf("%d. **%s**\n", productRef.SequentialID, productRef.Name))
+		result.WriteString(fmt.Sprintf("   💰 %s\n\n", formatListPrice(productRef.Price, productRef.SalePrice)))
+	}
+	result.WriteString("🛒 Para adicionar ao carrinho: 'adicionar [número] quantidade [X]'")
+	return result.String(), nil
+}
@@ -0,0 +1,106 @@
+package ai
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"iafarma/pkg/models"
+
+	"github.com/google/uuid"
+)
+
+// promoProductService retorna os produtos em promoção configurados
+type promoProductService struct {
+	fakeProductService
+	promotional []models.Product
+}
+
+func (f *promoProductService) GetPromotionalProducts(tenantID uuid.UUID) ([]models.Product, error) {
+	return f.promotional, nil
+}
+
+// withPromotionalProducts liga o catálogo com dois produtos em promoção
+func withPromotionalProducts() testServiceOption {
+	promotional := []models.Product{
+		{BaseTenantModel: models.BaseTenantModel{ID: uuid.New()}, Name: "Protetor Solar FPS 50", Price: "59.90", SalePrice: "44.90"},
+		{BaseTenantModel: models.BaseTenantModel{ID: uuid.New()}, Name: "Fralda Infantil G", Price: "79.90", SalePrice: "64.90"},
+	}
+	return withOverride(func(s *AIService) { s.productService = &promoProductService{promotional: promotional} })
+}
+
+func TestValidPromoFlyerURL(t *testing.T) {
+	tests := []struct {
+		url      string
+		esperado bool
+	}{
+		{"https://cdn.loja.com.br/encarte-semana.jpg", true},
+		{"http://loja.com/encarte.png?v=2", true},
+		{"", false},
+		{"encarte.jpg", false},
+		{"ftp://loja.com/encarte.jpg", false},
+		{"javascript:alert(1)", false},
+		{"https://loja.com/encarte da semana.jpg", false},
+		{"https:///sem-host.jpg", false},
+	}
+
+	for _, tt := range tests {
+		if obtido := validPromoFlyerURL(tt.url); obtido != tt.esperado {
+			t.Errorf("validPromoFlyerURL(%q): esperado %v, obtido %v", tt.url, tt.esperado, obtido)
+		}
+	}
+}
+
+func TestVerEncarteSendsConfiguredFlyer(t *testing.T) {
+	tenantID, phone := uuid.New(), "5527999990000"
+	flyerURL := "https://cdn.loja.com.br/encarte-semana.jpg"
+	s, _ := newTestService(map[string]string{
+		PromoFlyerURLSettingKey:     flyerURL,
+		PromoFlyerCaptionSettingKey: "Ofertas válidas até domingo",
+	}, withPromotionalProducts())
+
+	result, err := s.handleVerEncarte(context.Background(), tenantID, phone)
+	if err != nil {
+		t.Fatalf("erro inesperado: %v", err)
+	}
+
+	response := buildAIResponse(result, s.takePendingResponse(tenantID, phone))
+	if response.MediaURL != flyerURL || response.MediaCaption != "Ofertas válidas até domingo" {
+		t.Errorf("esperado enviar o encarte com a legenda, obtido %+v", response)
+	}
+	if strings.Contains(result, "Protetor Solar") {
+		t.Errorf("com encarte configurado não deveria listar produtos:\n%s", result)
+	}
+}
+
+func TestVerEncarteFallsBackToPromotionalProducts(t *testing.T) {
+	tests := []struct {
+		name     string
+		settings map[string]string
+	}{
+		{"sem encarte", map[string]string{}},
+		{"link inválido", map[string]string{PromoFlyerURLSettingKey: "encarte.jpg"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tenantID, phone := uuid.New(), "5527999990000"
+			s, _ := newTestService(tt.settings, withPromotionalProducts())
+
+			result, err := s.handleVerEncarte(context.Background(), tenantID, phone)
+			if err != nil {
+				t.Fatalf("erro inesperado: %v", err)
+			}
+
+			if response := buildAIResponse(result, s.takePendingResponse(tenantID, phone)); response.HasMedia() {
+				t.Errorf("sem encarte válido não deveria enviar mídia: %+v", response)
+			}
+			if !strings.Contains(result, "1. **Protetor Solar FPS 50**") || !strings.Contains(result, "R$ 44,90") {
+				t.Errorf("esperado listar os produtos em promoção, obtido:\n%s", result)
+			}
+			if ref := s.memoryManager.GetProductBySequentialID(tenantID, phone, 2); ref == nil || ref.Name != "Fralda Infantil G" {
+				t.Errorf("produtos em promoção deveriam ficar disponíveis pelo número")
+			}
+		})
+	}
+}
@@ -0,0 +1,201 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"iafarma/internal/repo"
+	"iafarma/pkg/models"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+	"github.com/sashabaranov/go-openai"
+	"gorm.io/gorm"
+)
+
+const (
+	// PromptSamplesSettingKey guarda (em JSON) as mensagens de exemplo usadas para comparar versões do prompt
+	PromptSamplesSettingKey = "ai_prompt_sample_messages"
+	// MaxPromptSamples limita as mensagens de exemplo por tenant: cada uma gera duas chamadas à OpenAI
+	MaxPromptSamples = 10
+
+	maxPromptSampleLength    = 500
+	promptReplayCustomerName = "Cliente Teste"
+	promptReplayPhone        = "prompt-replay"
+)
+
+var (
+	// ErrPromptReplayTemplateRequired indica que não foi informado o prompt novo a comparar
+	ErrPromptReplayTemplateRequired = errors.New("prompt template is required")
+	// ErrPromptReplayNoSamples indica que não há mensagens de exemplo para rodar
+	ErrPromptReplayNoSamples = errors.New("no sample messages to replay")
+)
+
+// PromptReplayToolCall é uma ferramenta escolhida pela IA; no replay ela nunca é executada
+type PromptReplayToolCall struct {
+	Name      string `json:"name"`
+	Arguments string `json:"arguments"`
+}
+
+// PromptReplayOutput é a resposta da IA para uma mensagem de exemplo com um dos prompts
+type PromptReplayOutput struct {
+	Response  string                 `json:"response"`
+	ToolCalls []PromptReplayToolCall `json:"tool_calls"`
+	Error     string                 `json:"error,omitempty"`
+}
+
+// PromptReplayComparison coloca lado a lado o prompt atual e o proposto para uma mensagem de exemplo
+type PromptReplayComparison struct {
+	Message  string             `json:"message"`
+	Current  PromptReplayOutput `json:"current"`
+	Proposed PromptReplayOutput `json:"proposed"`
+	// ToolsChanged indica que os prompts levaram a IA a escolher ferramentas diferentes
+	ToolsChanged bool `json:"tools_changed"`
+	// ResponseChanged indica que o texto da resposta mudou
+	ResponseChanged bool `json:"response_changed"`
+}
+
+// NewPromptReplayService cria um AIService só com o necessário para montar o prompt e consultar a IA, sem carrinho,
+// pedidos de cliente nem alertas: as ferramentas escolhidas são apenas registradas, nunca executadas
+func NewPromptReplayService(db *gorm.DB, openaiAPIKey string) *AIService {
+	return &AIService{
+		client:            openai.NewClient(openaiAPIKey),
+		productService:    NewProductService(db),
+		orderService:      NewOrderService(db),
+		settingsService:   NewTenantSettingsService(db),
+		categoryService:   &categoryServiceImpl{repo: repo.NewCategoryRepository(db)},
+		customToolService: NewCustomToolService(db),
+		memoryManager:     NewMemoryManager(),
+	}
+}
+
+// NormalizePromptSamples remove mensagens vazias ou repetidas e valida os limites
+func NormalizePromptSamples(samples []string) ([]string, error) {
+	normalized := make([]string, 0, len(samples))
+	seen := make(map[string]bool)
+	for _, sample := range samples {
+		sample = strings.TrimSpace(sample)
+		if sample == "" || seen[sample] {
+			continue
+		}
+		if len([]rune(sample)) > maxPromptSampleLength {
+			return nil, fmt.Errorf("sample message exceeds %d characters", maxPromptSampleLength)
+		}
+		seen[sample] = true
+		normalized = append(normalized, sample)
+	}
+	if len(normalized) > MaxPromptSamples {
+		return nil, fmt.Errorf("at most %d sample messages are allowed", MaxPromptSamples)
+	}
+	return normalized, nil
+}
+
+// GetPromptSamples retorna as mensagens de exemplo salvas para o tenant
+func (s *AIService) GetPromptSamples(ctx context.Context, tenantID uuid.UUID) ([]string, error) {
+	setting, err := s.settingsService.GetSetting(ctx, tenantID, PromptSamplesSettingKey)
+	if err != nil || setting == nil || setting.SettingValue == nil || strings.TrimSpace(*setting.SettingValue) == "" {
+		return []string{}, nil
+	}
+
+	var samples []string
+	if err := json.Unmarshal([]byte(*setting.SettingValue), &samples); err != nil {
+		return nil, fmt.Errorf("invalid sample messages setting: %w", err)
+	}
+	return samples, nil
+}
+
+// SavePromptSamples substitui as mensagens de exemplo do tenant
+func (s *AIService) SavePromptSamples(ctx context.Context, tenantID uuid.UUID, samples []string) ([]string, error) {
+	normalized, err := NormalizePromptSamples(samples)
+	if err != nil {
+		return nil, err
+	}
+
+	encoded, err := json.Marshal(normalized)
+	if err != nil {
+		return nil, err
+	}
+	value := string(encoded)
+	if err := s.settingsService.SetSetting(ctx, tenantID, PromptSamplesSettingKey, &value, "json"); err != nil {
+		return nil, fmt.Errorf("failed to save sample messages: %w", err)
+	}
+	return normalized, nil
+}
+
+// ReplayPromptChange roda cada mensagem de exemplo com o prompt atual do tenant e com o proposto (sem salvá-lo) e
+// devolve as respostas e ferramentas escolhidas lado a lado. É um dry-run: nenhuma ferramenta é executada e nada
+// é gravado no histórico de conversas.
+func (s *AIService) ReplayPromptChange(ctx context.Context, tenantID uuid.UUID, proposedTemplate string, samples []string) ([]PromptReplayComparison, error) {
+	if strings.TrimSpace(proposedTemplate) == "" {
+		return nil, ErrPromptReplayTemplateRequired
+	}
+	samples, err := NormalizePromptSamples(samples)
+	if err != nil {
+		return nil, err
+	}
+	if len(samples) == 0 {
+		return nil, ErrPromptReplayNoSamples
+	}
+
+	customer := &models.Customer{Name: promptReplayCustomerName, Phone: promptReplayPhone}
+	customer.TenantID = tenantID
+	currentPrompt := s.getSystemPrompt(ctx, customer)
+	proposedPrompt := s.processCustomPrompt(proposedTemplate, customer)
+	tools := s.getAvailableToolsForTenant(tenantID)
+
+	log.Info().
+		Str("tenant_id", tenantID.String()).
+		Int("samples", len(samples)).
+		Msg("🧪 Comparando prompt atual e proposto com mensagens de exemplo")
+
+	comparisons := make([]PromptReplayComparison, 0, len(samples))
+	for _, sample := range samples {
+		current := s.replayPrompt(ctx, tenantID, currentPrompt, tools, sample)
+		proposed := s.replayPrompt(ctx, tenantID, proposedPrompt, tools, sample)
+		comparisons = append(comparisons, PromptReplayComparison{
+			Message:         sample,
+			Current:         current,
+			Proposed:        proposed,
+			ToolsChanged:    strings.Join(replayToolNames(current), ",") != strings.Join(replayToolNames(proposed), ","),
+			ResponseChanged: strings.TrimSpace(current.Response) != strings.TrimSpace(proposed.Response),
+		})
+	}
+	return comparisons, nil
+}
+
+// replayPrompt faz uma única chamada à IA, como no atendimento, e registra a resposta sem executar ferramentas
+func (s *AIService) replayPrompt(ctx context.Context, tenantID uuid.UUID, systemPrompt string, tools []openai.Tool, message string) PromptReplayOutput {
+	resp, err := s.createChatCompletionWithFallback(ctx, tenantID, openai.ChatCompletionRequest{
+		Messages: []openai.ChatCompletionMessage{
+			{Role: openai.ChatMessageRoleSystem, Content: systemPrompt},
+			{Role: openai.ChatMessageRoleUser, Content: message},
+		},
+		Tools:               tools,
+		ToolChoice:          "auto",
+		MaxCompletionTokens: 8000,
+	})
+	if err != nil {
+		return PromptReplayOutput{ToolCalls: []PromptReplayToolCall{}, Error: err.Error()}
+	}
+	if len(resp.Choices) == 0 {
+		return PromptReplayOutput{ToolCalls: []PromptReplayToolCall{}, Error: "empty response from AI"}
+	}
+
+	choice := resp.Choices[0].Message
+	output := PromptReplayOutput{Response: choice.Content, ToolCalls: make([]PromptReplayToolCall, 0, len(choice.ToolCalls))}
+	for _, call := range choice.ToolCalls {
+		output.ToolCalls = append(output.ToolCalls, PromptReplayToolCall{Name: call.Function.Name, Arguments: call.Function.Arguments})
+	}
+	return output
+}
+
+func replayToolNames(output PromptReplayOutput) []string {
+	names := make([]string, 0, len(output.ToolCalls))
+	for _, call := range output.ToolCalls {
+		names = append(names, call.Name)
+	}
+	return names
+}
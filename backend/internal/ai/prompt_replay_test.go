@@ -0,0 +1,123 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/sashabaranov/go-openai"
+)
+
+// savingSettingsService grava as configurações no mapa do fake
+type savingSettingsService struct {
+	*fakeSettingsService
+}
+
+func (f *savingSettingsService) SetSetting(ctx context.Context, tenantID uuid.UUID, key string, value *string, settingType string) error {
+	f.values[key] = *value
+	return nil
+}
+
+// newPromptReplayClient simula a OpenAI: com "busque sempre" no prompt a IA chama consultarItens, senão só responde
+func newPromptReplayClient(t *testing.T) (*openai.Client, *int) {
+	t.Helper()
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req openai.ChatCompletionRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		calls++
+
+		message := openai.ChatCompletionMessage{Role: openai.ChatMessageRoleAssistant, Content: "Olá! Temos sim, quer ver?"}
+		if strings.Contains(req.Messages[0].Content, "busque sempre") {
+			message = openai.ChatCompletionMessage{Role: openai.ChatMessageRoleAssistant, ToolCalls: []openai.ToolCall{{
+				ID:       "call_1",
+				Type:     openai.ToolTypeFunction,
+				Function: openai.FunctionCall{Name: "consultarItens", Arguments: `{"query":"` + req.Messages[1].Content + `"}`},
+			}}}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(openai.ChatCompletionResponse{Choices: []openai.ChatCompletionChoice{{Message: message}}})
+	}))
+	t.Cleanup(server.Close)
+
+	config := openai.DefaultConfig("test")
+	config.BaseURL = server.URL + "/v1"
+	return openai.NewClientWithConfig(config), &calls
+}
+
+func TestReplayPromptChangeComparesSideBySide(t *testing.T) {
+	tenantID := uuid.New()
+	client, calls := newPromptReplayClient(t)
+	settings := &fakeSettingsService{values: map[string]string{"ai_system_prompt_template": "Você atende {{customer_name}}."}}
+	// Sem carrinho nem pedidos: executar uma ferramenta no replay quebraria o teste
+	s := &AIService{client: client, settingsService: settings, memoryManager: NewMemoryManager()}
+
+	results, err := s.ReplayPromptChange(context.Background(), tenantID, "Você atende {{customer_name}} e busque sempre no catálogo.", []string{"tem dipirona?", " ", "tem dipirona?", "oi"})
+	if err != nil {
+		t.Fatalf("erro inesperado: %v", err)
+	}
+	if len(results) != 2 || *calls != 4 {
+		t.Fatalf("esperadas 2 mensagens (vazias e repetidas ignoradas) e 4 chamadas, obtido %d mensagens e %d chamadas", len(results), *calls)
+	}
+
+	first := results[0]
+	if first.Message != "tem dipirona?" || first.Current.Response == "" || len(first.Current.ToolCalls) != 0 {
+		t.Errorf("prompt atual deveria só responder: %+v", first.Current)
+	}
+	if len(first.Proposed.ToolCalls) != 1 || first.Proposed.ToolCalls[0].Name != "consultarItens" || !strings.Contains(first.Proposed.ToolCalls[0].Arguments, "dipirona") {
+		t.Errorf("prompt proposto deveria escolher consultarItens: %+v", first.Proposed)
+	}
+	if !first.ToolsChanged || !first.ResponseChanged {
+		t.Errorf("mudança de ferramenta e de resposta deveria ser sinalizada: %+v", first)
+	}
+
+	// O prompt proposto não é salvo
+	if settings.values["ai_system_prompt_template"] != "Você atende {{customer_name}}." {
+		t.Errorf("o replay não deveria alterar o prompt do tenant, obtido %q", settings.values["ai_system_prompt_template"])
+	}
+}
+
+func TestReplayPromptChangeValidation(t *testing.T) {
+	s := &AIService{settingsService: &fakeSettingsService{values: map[string]string{}}}
+
+	if _, err := s.ReplayPromptChange(context.Background(), uuid.New(), "  ", []string{"oi"}); err != ErrPromptReplayTemplateRequired {
+		t.Errorf("esperado erro de prompt obrigatório, obtido %v", err)
+	}
+	if _, err := s.ReplayPromptChange(context.Background(), uuid.New(), "Novo prompt", []string{" "}); err != ErrPromptReplayNoSamples {
+		t.Errorf("esperado erro de mensagens ausentes, obtido %v", err)
+	}
+}
+
+func TestPromptSamplesRoundTrip(t *testing.T) {
+	tenantID := uuid.New()
+	s := &AIService{settingsService: &savingSettingsService{fakeSettingsService: &fakeSettingsService{values: map[string]string{}}}}
+
+	samples, err := s.GetPromptSamples(context.Background(), tenantID)
+	if err != nil || len(samples) != 0 {
+		t.Fatalf("sem mensagens salvas deveria retornar lista vazia, obtido %v, %v", samples, err)
+	}
+
+	saved, err := s.SavePromptSamples(context.Background(), tenantID, []string{" quero 2 dipironas ", "", "finalizar"})
+	if err != nil {
+		t.Fatalf("erro inesperado: %v", err)
+	}
+	samples, _ = s.GetPromptSamples(context.Background(), tenantID)
+	if len(saved) != 2 || len(samples) != 2 || samples[0] != "quero 2 dipironas" || samples[1] != "finalizar" {
+		t.Errorf("esperadas as 2 mensagens normalizadas, obtido %v / %v", saved, samples)
+	}
+
+	tooMany := make([]string, MaxPromptSamples+1)
+	for i := range tooMany {
+		tooMany[i] = "mensagem " + string(rune('a'+i))
+	}
+	if _, err := s.SavePromptSamples(context.Background(), tenantID, tooMany); err == nil {
+		t.Errorf("deveria recusar mais de %d mensagens", MaxPromptSamples)
+	}
+	if _, err := s.SavePromptSamples(context.Background(), tenantID, []string{strings.Repeat("a", maxPromptSampleLength+1)}); err == nil {
+		t.Errorf("deveria recusar mensagem acima de %d caracteres", maxPromptSampleLength)
+	}
+}
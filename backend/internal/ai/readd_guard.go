@@ -0,0 +1,111 @@
+package ai
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"iafarma/pkg/models"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+)
+
+// ReaddGuardMinutesSettingKey define por quantos minutos um produto removido pelo cliente só volta ao carrinho
+// depois de confirmação ("0" = desativado)
+const ReaddGuardMinutesSettingKey = "ai_readd_guard_minutes"
+
+const (
+	defaultReaddGuardMinutes = 10
+	// maxReaddGuardMinutes limita a janela configurada pelo tenant
+	maxReaddGuardMinutes = 120
+)
+
+// pendingReadd guarda a adição que aguarda o cliente confirmar ("você tinha removido isso, quer adicionar de novo?")
+type pendingReadd struct {
+	ProductID   uuid.UUID
+	ProductName string
+	Quantity    int
+}
+
+func recentRemovalKey(tenantID, customerID, productID uuid.UUID) string {
+	return lastCartAddKey(tenantID, customerID) + "-" + productID.String()
+}
+
+// getReaddGuardWindow retorna a janela configurada pelo tenant (0 = guarda desativada)
+func (s *AIService) getReaddGuardWindow(ctx context.Context, tenantID uuid.UUID) time.Duration {
+	minutes := defaultReaddGuardMinutes
+	if s.settingsService != nil {
+		setting, err := s.settingsService.GetSetting(ctx, tenantID, ReaddGuardMinutesSettingKey)
+		if err == nil && setting != nil && setting.SettingValue != nil {
+			if parsed, err := strconv.Atoi(strings.TrimSpace(*setting.SettingValue)); err == nil && parsed >= 0 {
+				minutes = parsed
+			}
+		}
+	}
+	if minutes > maxReaddGuardMinutes {
+		minutes = maxReaddGuardMinutes
+	}
+	return time.Duration(minutes) * time.Minute
+}
+
+// rememberCartRemoval registra que o cliente tirou o item do carrinho
+func (s *AIService) rememberCartRemoval(tenantID, customerID uuid.UUID, item models.CartItem) {
+	if item.ProductID == nil {
+		return
+	}
+	s.recentRemovals.Store(recentRemovalKey(tenantID, customerID, *item.ProductID), time.Now())
+}
+
+// readdGuardMessage verifica se o produto foi removido pelo cliente há pouco. Nesse caso a adição fica pendente e
+// retorna a pergunta de confirmação; caso contrário retorna "".
+func (s *AIService) readdGuardMessage(ctx context.Context, tenantID, customerID uuid.UUID, product *models.Product, quantity int) string {
+	key := recentRemovalKey(tenantID, customerID, product.ID)
+	value, ok := s.recentRemovals.Load(key)
+	if !ok {
+		return ""
+	}
+	removedAt := value.(time.Time)
+	window := s.getReaddGuardWindow(ctx, tenantID)
+	if window == 0 || time.Since(removedAt) > window {
+		s.recentRemovals.Delete(key)
+		return ""
+	}
+
+	s.pendingReadds.Store(lastCartAddKey(tenantID, customerID), pendingReadd{
+		ProductID:   product.ID,
+		ProductName: product.Name,
+		Quantity:    quantity,
+	})
+	log.Info().
+		Str("tenant_id", tenantID.String()).
+		Str("customer_id", customerID.String()).
+		Str("product_id", product.ID.String()).
+		Msg("🛡️ Produto removido há pouco - confirmando antes de adicionar de novo")
+
+	return fmt.Sprintf("🤔 Você tinha removido **%s** do carrinho há pouco. Quer adicionar de novo?\n🔢 Quantidade: %d",
+		product.Name, quantity)
+}
+
+// handleConfirmarReadicao registra a resposta do cliente à pergunta sobre o produto removido: confirmando, o produto
+// volta ao carrinho e deixa de ser vigiado; negando, continua fora.
+func (s *AIService) handleConfirmarReadicao(ctx context.Context, tenantID, customerID uuid.UUID, customerPhone string, args map[string]interface{}) (string, error) {
+	value, ok := s.pendingReadds.LoadAndDelete(lastCartAddKey(tenantID, customerID))
+	if !ok {
+		return "🤔 Não há nenhuma adição aguardando confirmação. Me diga qual produto você quer adicionar.", nil
+	}
+	pending := value.(pendingReadd)
+
+	if confirmed, _ := args["confirmado"].(bool); !confirmed {
+		return fmt.Sprintf("👍 Combinado, **%s** continua fora do carrinho.", pending.ProductName), nil
+	}
+
+	s.recentRemovals.Delete(recentRemovalKey(tenantID, customerID, pending.ProductID))
+	result, err := s.tryAddProductToCart(ctx, tenantID, customerID, customerPhone, pending.ProductID, pending.Quantity)
+	if err != nil {
+		return fmt.Sprintf("❌ Não consegui adicionar **%s** de novo. Tente buscar o produto novamente.", pending.ProductName), err
+	}
+	return result, nil
+}
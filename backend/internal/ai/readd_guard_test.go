@@ -0,0 +1,107 @@
+package ai
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func removeFirstCartItem(t *testing.T, s *AIService, tenantID, customerID uuid.UUID) {
+	t.Helper()
+	args := map[string]interface{}{"item_number": float64(1)}
+	if _, err := s.executeTool(context.Background(), tenantID, customerID, "5561999999999", "removerDoCarrinho", args); err != nil {
+		t.Fatalf("erro inesperado ao remover: %v", err)
+	}
+}
+
+func TestReaddGuardAsksBeforeReaddingRemovedProduct(t *testing.T) {
+	tenantID, customerID := uuid.New(), uuid.New()
+	cart := newUndoCartService()
+	s, _ := newTestService(nil, withAddAndCheckout(cart, cart.products...))
+
+	addDipirona(t, s, tenantID, customerID, 2)
+	removeFirstCartItem(t, s, tenantID, customerID)
+
+	// A IA entende errado a próxima mensagem e tenta colocar o produto de volta
+	result, err := s.executeTool(context.Background(), tenantID, customerID, "5561999999999", "adicionarProdutoPorNome", map[string]interface{}{"nome_produto": "dipirona", "quantidade": float64(2)})
+	if err != nil {
+		t.Fatalf("erro inesperado: %v", err)
+	}
+	if !strings.Contains(result, "Você tinha removido **Dipirona 500mg**") {
+		t.Errorf("esperada pergunta de confirmação, obtido:\n%s", result)
+	}
+	if len(cart.cart.Items) != 0 {
+		t.Fatalf("produto removido não deveria voltar sem confirmação, itens: %+v", cart.cart.Items)
+	}
+
+	result, err = s.executeTool(context.Background(), tenantID, customerID, "5561999999999", "confirmarReadicao", map[string]interface{}{"confirmado": true})
+	if err != nil {
+		t.Fatalf("erro inesperado: %v", err)
+	}
+	if len(cart.cart.Items) != 1 || cart.cart.Items[0].Quantity != 2 {
+		t.Fatalf("produto deveria voltar ao carrinho após a confirmação, itens: %+v\n%s", cart.cart.Items, result)
+	}
+
+	// Depois de confirmado, novas adições não perguntam de novo
+	addDipirona(t, s, tenantID, customerID, 1)
+	if len(cart.cart.Items) != 1 || cart.cart.Items[0].Quantity != 3 {
+		t.Errorf("adição após a confirmação deveria ir direto ao carrinho, itens: %+v", cart.cart.Items)
+	}
+}
+
+func TestReaddGuardDeclinedKeepsProductOut(t *testing.T) {
+	tenantID, customerID := uuid.New(), uuid.New()
+	cart := newUndoCartService()
+	s, _ := newTestService(nil, withAddAndCheckout(cart, cart.products...))
+
+	addDipirona(t, s, tenantID, customerID, 1)
+	removeFirstCartItem(t, s, tenantID, customerID)
+	addDipirona(t, s, tenantID, customerID, 1)
+
+	result, err := s.handleConfirmarReadicao(context.Background(), tenantID, customerID, "5561999999999", map[string]interface{}{"confirmado": false})
+	if err != nil {
+		t.Fatalf("erro inesperado: %v", err)
+	}
+	if !strings.Contains(result, "continua fora do carrinho") || len(cart.cart.Items) != 0 {
+		t.Errorf("produto deveria continuar fora do carrinho, itens: %+v\n%s", cart.cart.Items, result)
+	}
+
+	// A guarda continua valendo para uma nova tentativa acidental
+	addDipirona(t, s, tenantID, customerID, 1)
+	if len(cart.cart.Items) != 0 {
+		t.Errorf("nova tentativa deveria pedir confirmação de novo, itens: %+v", cart.cart.Items)
+	}
+}
+
+func TestReaddGuardSkipped(t *testing.T) {
+	tests := []struct {
+		name    string
+		minutes string
+		age     time.Duration
+	}{
+		{"guarda desativada", "0", 0},
+		{"remoção fora da janela", "10", 11 * time.Minute},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tenantID, customerID := uuid.New(), uuid.New()
+			cart := newUndoCartService()
+			s, _ := newTestService(nil, withAddAndCheckout(cart, cart.products...))
+			s.settingsService.(*fakeSettingsService).values[ReaddGuardMinutesSettingKey] = tt.minutes
+
+			addDipirona(t, s, tenantID, customerID, 1)
+			productID := *cart.cart.Items[0].ProductID
+			removeFirstCartItem(t, s, tenantID, customerID)
+			s.recentRemovals.Store(recentRemovalKey(tenantID, customerID, productID), time.Now().Add(-tt.age))
+
+			addDipirona(t, s, tenantID, customerID, 1)
+			if len(cart.cart.Items) != 1 {
+				t.Errorf("produto deveria entrar direto no carrinho, itens: %+v", cart.cart.Items)
+			}
+		})
+	}
+}
@@ -0,0 +1,235 @@
+package ai
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"strings"
+
+	"iafarma/pkg/models"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+)
+
+// receiptLine é uma linha de item do recibo, com os valores já formatados
+type receiptLine struct {
+	Name      string
+	Quantity  int
+	UnitPrice string
+	Total     string
+}
+
+// orderReceipt reúne os dados do recibo usados no texto e na versão HTML compartilhável
+type orderReceipt struct {
+	OrderNumber   string
+	Date          string
+	Items         []receiptLine
+	Subtotal      string
+	Shipping      string
+	UrgencyFee    string
+	Discount      string
+	Total         string
+	PaymentMethod string
+	Installments  string
+	Address       []string
+}
+
+// buildOrderReceipt extrai do pedido os dados do recibo (valores zerados ficam de fora)
+func buildOrderReceipt(order *models.Order) orderReceipt {
+	amount := func(value string) string {
+		if value == "" || value == "0" || value == "0.00" {
+			return ""
+		}
+		return "R$ " + formatCurrency(value)
+	}
+
+	receipt := orderReceipt{
+		OrderNumber: order.OrderNumber,
+		Date:        order.CreatedAt.In(storeLocation).Format("02/01/2006 15:04"),
+		Subtotal:    amount(order.Subtotal),
+		Shipping:    amount(order.ShippingAmount),
+		Discount:    amount(order.DiscountAmount),
+		Total:       "R$ " + formatCurrency(order.TotalAmount),
+	}
+	if order.IsUrgent {
+		receipt.UrgencyFee = amount(order.UrgencyFee)
+	}
+
+	for _, item := range order.Items {
+		name := "Produto"
+		if item.ProductName != nil && *item.ProductName != "" {
+			name = *item.ProductName
+		}
+		unitPrice := item.Price
+		if item.UnitPrice != nil && *item.UnitPrice != "" {
+			unitPrice = *item.UnitPrice
+		}
+		receipt.Items = append(receipt.Items, receiptLine{
+			Name:      name,
+			Quantity:  item.Quantity,
+			UnitPrice: "R$ " + formatCurrency(unitPrice),
+			Total:     "R$ " + formatCurrency(item.Total),
+		})
+	}
+
+	if order.PaymentMethod != nil && order.PaymentMethod.Name != "" {
+		receipt.PaymentMethod = order.PaymentMethod.Name
+	}
+	if order.InstallmentCount > 1 {
+		receipt.Installments = fmt.Sprintf("%dx de R$ %s", order.InstallmentCount, formatCurrency(order.InstallmentAmount))
+	}
+	if address := formatOrderShippingAddress(order); address != "" {
+		receipt.Address = strings.Split(address, "\n")
+	}
+	return receipt
+}
+
+// formatReceiptText monta o recibo em texto, pronto para o cliente encaminhar no WhatsApp
+func formatReceiptText(receipt orderReceipt) string {
+	var result strings.Builder
+
+	result.WriteString(fmt.Sprintf("🧾 *RECIBO - PEDIDO %s*\n", receipt.OrderNumber))
+	result.WriteString(fmt.Sprintf("📅 %s\n", receipt.Date))
+	result.WriteString("━━━━━━━━━━━━━━━━\n")
+	for _, item := range receipt.Items {
+		result.WriteString(fmt.Sprintf("%dx %s\n   %s cada = %s\n", item.Quantity, item.Name, item.UnitPrice, item.Total))
+	}
+	result.WriteString("━━━━━━━━━━━━━━━━\n")
+
+	for _, line := range []struct{ label, value string }{
+		{"Subtotal", receipt.Subtotal},
+		{"Entrega", receipt.Shipping},
+		{"Taxa de urgência", receipt.UrgencyFee},
+		{"Desconto", receipt.Discount},
+	} {
+		if line.value != "" {
+			result.WriteString(fmt.Sprintf("%s: %s\n", line.label, line.value))
+		}
+	}
+	result.WriteString(fmt.Sprintf("*Total: %s*\n", receipt.Total))
+
+	if receipt.PaymentMethod != "" {
+		result.WriteString(fmt.Sprintf("\n💳 Pagamento: %s\n", receipt.PaymentMethod))
+	}
+	if receipt.Installments != "" {
+		result.WriteString(fmt.Sprintf("🧮 Parcelamento: %s\n", receipt.Installments))
+	}
+	if len(receipt.Address) > 0 {
+		result.WriteString(fmt.Sprintf("\n📍 Entrega em:\n%s\n", strings.Join(receipt.Address, "\n")))
+	}
+
+	return strings.TrimRight(result.String(), "\n")
+}
+
+// receiptHTMLTemplate é a versão do recibo publicada no S3 para compartilhar por link
+var receiptHTMLTemplate = template.Must(template.New("receipt").Parse(`<!DOCTYPE html>
+<html lang="pt-BR"><head><meta charset="utf-8"><meta name="viewport" content="width=device-width, initial-scale=1">
+<title>Recibo - Pedido {{.OrderNumber}}</title>
+<style>body{font-family:sans-serif;max-width:480px;margin:24px auto;color:#222}table{width:100%;border-collapse:collapse}td{padding:4px 0}td.v{text-align:right}.t{font-weight:bold;border-top:1px solid #ccc}</style>
+</head><body>
+<h2>Recibo - Pedido {{.OrderNumber}}</h2>
+<p>{{.Date}}</p>
+<table>
+{{range .Items}}<tr><td>{{.Quantity}}x {{.Name}} ({{.UnitPrice}} cada)</td><td class="v">{{.Total}}</td></tr>
+{{end}}{{if .Subtotal}}<tr><td>Subtotal</td><td class="v">{{.Subtotal}}</td></tr>
+{{end}}{{if .Shipping}}<tr><td>Entrega</td><td class="v">{{.Shipping}}</td></tr>
+{{end}}{{if .UrgencyFee}}<tr><td>Taxa de urgência</td><td class="v">{{.UrgencyFee}}</td></tr>
+{{end}}{{if .Discount}}<tr><td>Desconto</td><td class="v">{{.Discount}}</td></tr>
+{{end}}<tr class="t"><td>Total</td><td class="v">{{.Total}}</td></tr>
+</table>
+{{if .PaymentMethod}}<p>Pagamento: {{.PaymentMethod}}{{if .Installments}} ({{.Installments}}){{end}}</p>
+{{end}}{{if .Address}}<p>Entrega em:<br>{{range $i, $line := .Address}}{{if $i}}<br>{{end}}{{$line}}{{end}}</p>
+{{end}}</body></html>
+`))
+
+// renderReceiptHTML gera a página HTML do recibo
+func renderReceiptHTML(receipt orderReceipt) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := receiptHTMLTemplate.Execute(&buf, receipt); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// receiptKey gera a chave S3 do recibo: tenant_id/receipts/order_id.html
+func receiptKey(tenantID, orderID uuid.UUID) string {
+	return fmt.Sprintf("%s/receipts/%s.html", tenantID, orderID)
+}
+
+// uploadReceipt publica a versão HTML do recibo no S3 e retorna o link
+func (s *AIService) uploadReceipt(tenantID uuid.UUID, order *models.Order, receipt orderReceipt) (string, error) {
+	if s.s3Client == nil {
+		return "", fmt.Errorf("S3 não configurado")
+	}
+	body, err := renderReceiptHTML(receipt)
+	if err != nil {
+		return "", err
+	}
+
+	key := receiptKey(tenantID, order.ID)
+	_, err = s.s3Client.PutObject(&s3.PutObjectInput{
+		Bucket:        aws.String(s.s3Bucket),
+		Key:           aws.String(key),
+		Body:          bytes.NewReader(body),
+		ContentLength: aws.Int64(int64(len(body))),
+		ContentType:   aws.String("text/html; charset=utf-8"),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to upload receipt to S3: %w", err)
+	}
+	return fmt.Sprintf("%s/%s", s.s3BaseURL, key), nil
+}
+
+// handleGerarRecibo gera o recibo de um pedido do cliente (padrão: o mais recente) e, se pedido, o link compartilhável
+func (s *AIService) handleGerarRecibo(tenantID, customerID uuid.UUID, args map[string]interface{}) (string, error) {
+	notFound := "❌ Pedido não encontrado. Use 'histórico de pedidos' para ver seus pedidos e depois 'recibo do pedido [número]'."
+
+	var order *models.Order
+	if identifier, _ := args["order_id"].(string); strings.TrimSpace(identifier) != "" {
+		orderID, found := s.resolveCustomerOrderID(tenantID, customerID, identifier)
+		if !found {
+			return notFound, nil
+		}
+		loaded, err := s.orderService.GetOrderByID(tenantID, orderID)
+		if err != nil || loaded == nil {
+			return notFound, nil
+		}
+		order = loaded
+	} else {
+		orders, err := s.orderService.GetOrdersByCustomer(tenantID, customerID)
+		if err != nil {
+			return "❌ Erro ao buscar seus pedidos.", err
+		}
+		if len(orders) == 0 {
+			return "🧾 Você ainda não tem pedidos. Depois de finalizar uma compra posso gerar o recibo!", nil
+		}
+		order = &orders[0]
+	}
+
+	// Não revelar pedidos de outros clientes
+	if order.CustomerID == nil || *order.CustomerID != customerID {
+		log.Warn().
+			Str("tenant_id", tenantID.String()).
+			Str("customer_id", customerID.String()).
+			Str("order_id", order.ID.String()).
+			Msg("🚫 Tentativa de gerar recibo de pedido de outro cliente")
+		return notFound, nil
+	}
+
+	receipt := buildOrderReceipt(order)
+	text := formatReceiptText(receipt)
+
+	if wantsLink, _ := args["link"].(bool); wantsLink {
+		link, err := s.uploadReceipt(tenantID, order, receipt)
+		if err != nil {
+			log.Warn().Err(err).Str("order_id", order.ID.String()).Msg("⚠️ Não foi possível publicar o recibo")
+			return text + "\n\n⚠️ Não consegui gerar o link do recibo agora, mas você pode encaminhar esta mensagem.", nil
+		}
+		text += fmt.Sprintf("\n\n🔗 Recibo para compartilhar: %s", link)
+	}
+
+	return text, nil
+}
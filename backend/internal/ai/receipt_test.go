@@ -0,0 +1,88 @@
+package ai
+
+import (
+	"strings"
+	"testing"
+
+	"iafarma/pkg/models"
+
+	"github.com/google/uuid"
+)
+
+func TestReceiptContent(t *testing.T) {
+	tenantID, customerID := uuid.New(), uuid.New()
+	order := newTestOrder(tenantID, customerID, "PED300", "Dipirona 500mg")
+	order.DiscountAmount = "2.00"
+	order.PaymentMethod = &models.PaymentMethod{Name: "PIX"}
+
+	receipt := buildOrderReceipt(&order)
+	text := formatReceiptText(receipt)
+	for _, expected := range []string{
+		"RECIBO - PEDIDO PED300",
+		"01/03/2024",
+		"2x Dipirona 500mg",
+		"R$ 10,40 cada = R$ 20,80",
+		"Subtotal: R$ 20,80",
+		"Entrega: R$ 5,00",
+		"Desconto: R$ 2,00",
+		"*Total: R$ 25,80*",
+		"Pagamento: PIX",
+		"Rua das Flores, 123",
+		"Vitória/ES",
+	} {
+		if !strings.Contains(text, expected) {
+			t.Errorf("recibo sem %q:\n%s", expected, text)
+		}
+	}
+	if strings.Contains(text, "urgência") || strings.Contains(text, "Parcelamento") {
+		t.Errorf("valores que não se aplicam ao pedido não deveriam aparecer:\n%s", text)
+	}
+
+	html, err := renderReceiptHTML(receipt)
+	if err != nil {
+		t.Fatalf("erro inesperado ao gerar HTML: %v", err)
+	}
+	for _, expected := range []string{"Recibo - Pedido PED300", "2x Dipirona 500mg", "R$ 25,80", "Pagamento: PIX", "Rua das Flores, 123<br>"} {
+		if !strings.Contains(string(html), expected) {
+			t.Errorf("HTML do recibo sem %q:\n%s", expected, html)
+		}
+	}
+}
+
+func TestGerarReciboOrders(t *testing.T) {
+	tenantID, customerID, otherCustomerID := uuid.New(), uuid.New(), uuid.New()
+	newest := newTestOrder(tenantID, customerID, "PED200", "Dipirona 500mg")
+	oldest := newTestOrder(tenantID, customerID, "PED100", "Paracetamol 750mg")
+	other := newTestOrder(tenantID, otherCustomerID, "PED900", "Produto de outro cliente")
+	s := &AIService{
+		orderService:  &fakeOrderService{orders: []models.Order{newest, oldest, other}},
+		memoryManager: NewMemoryManager(),
+	}
+
+	tests := []struct {
+		name     string
+		args     map[string]interface{}
+		expected string
+	}{
+		{"pedido mais recente por padrão", map[string]interface{}{}, "PEDIDO PED200"},
+		{"pedido pelo código", map[string]interface{}{"order_id": "PED100"}, "PEDIDO PED100"},
+		{"pedido de outro cliente", map[string]interface{}{"order_id": other.ID.String()}, "Pedido não encontrado"},
+		{"código de outro cliente", map[string]interface{}{"order_id": "PED900"}, "Pedido não encontrado"},
+		{"link sem S3 mantém o texto", map[string]interface{}{"link": true}, "Não consegui gerar o link do recibo"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := s.handleGerarRecibo(tenantID, customerID, tt.args)
+			if err != nil {
+				t.Fatalf("erro inesperado: %v", err)
+			}
+			if !strings.Contains(result, tt.expected) {
+				t.Errorf("esperado %q, obtido:\n%s", tt.expected, result)
+			}
+			if strings.Contains(result, "Produto de outro cliente") {
+				t.Errorf("recibo não deveria mostrar pedido de outro cliente:\n%s", result)
+			}
+		})
+	}
+}
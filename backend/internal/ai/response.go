@@ -0,0 +1,127 @@
+package ai
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// ResponseButton representa um botão de resposta rápida.
+// Ao ser tocado, o Title volta para a IA como mensagem do cliente.
+type ResponseButton struct {
+	ID    string `json:"id"`
+	Title string `json:"title"`
+}
+
+// ResponseMedia representa uma imagem enviada junto com a resposta
+type ResponseMedia struct {
+	URL     string `json:"url"`
+	Caption string `json:"caption,omitempty"`
+}
+
+// AIResponse é a resposta estruturada entregue à camada de envio.
+// Canais sem suporte a elementos interativos usam apenas o Text (ver String).
+type AIResponse struct {
+	Text         string           `json:"text"`
+	MediaURL     string           `json:"media_url,omitempty"`
+	MediaCaption string           `json:"media_caption,omitempty"`
+	Images       []ResponseMedia  `json:"images,omitempty"` // álbum enviado após o texto
+	Buttons      []ResponseButton `json:"buttons,omitempty"`
+}
+
+// checkoutConfirmationButtons são os botões exibidos na confirmação de endereço do checkout
+var checkoutConfirmationButtons = []ResponseButton{
+	{ID: "sim", Title: "Sim"},
+	{ID: "nao", Title: "Não"},
+	{ID: "editar_endereco", Title: "Editar endereço"},
+}
+
+// NewTextResponse cria uma resposta somente texto
+func NewTextResponse(text string) *AIResponse {
+	return &AIResponse{Text: text}
+}
+
+// String retorna o texto da resposta (fallback para canais não interativos)
+func (r *AIResponse) String() string {
+	if r == nil {
+		return ""
+	}
+	return r.Text
+}
+
+// HasButtons indica se a resposta possui botões de resposta rápida
+func (r *AIResponse) HasButtons() bool {
+	return r != nil && len(r.Buttons) > 0
+}
+
+// HasMedia indica se a resposta possui mídia anexada
+func (r *AIResponse) HasMedia() bool {
+	return r != nil && (r.MediaURL != "" || len(r.Images) > 0)
+}
+
+// Media retorna todas as imagens da resposta na ordem de envio
+func (r *AIResponse) Media() []ResponseMedia {
+	if r == nil {
+		return nil
+	}
+	var media []ResponseMedia
+	if r.MediaURL != "" {
+		media = append(media, ResponseMedia{URL: r.MediaURL, Caption: r.MediaCaption})
+	}
+	return append(media, r.Images...)
+}
+
+func interactiveResponseKey(tenantID uuid.UUID, customerPhone string) string {
+	return fmt.Sprintf("%s-%s", tenantID.String(), customerPhone)
+}
+
+// respondWithButtons registra botões para a resposta atual da sessão e retorna o texto,
+// mantendo a assinatura string dos handlers de ferramentas
+func (s *AIService) respondWithButtons(tenantID uuid.UUID, customerPhone, text string, buttons []ResponseButton) string {
+	s.pendingResponses.Store(interactiveResponseKey(tenantID, customerPhone), &AIResponse{
+		Text:    text,
+		Buttons: buttons,
+	})
+	return text
+}
+
+// takePendingResponse retorna (e remove) os elementos interativos registrados para a sessão
+func (s *AIService) takePendingResponse(tenantID uuid.UUID, customerPhone string) *AIResponse {
+	value, ok := s.pendingResponses.LoadAndDelete(interactiveResponseKey(tenantID, customerPhone))
+	if !ok {
+		return nil
+	}
+	pending, _ := value.(*AIResponse)
+	return pending
+}
+
+// buildAIResponse monta a resposta estruturada a partir do texto final e do que os handlers registraram.
+// Os botões só são anexados se o texto do handler chegou à resposta final; caso contrário
+// (ex.: a IA reescreveu a mensagem) os botões não fariam sentido para o cliente.
+func buildAIResponse(text string, pending *AIResponse) *AIResponse {
+	response := NewTextResponse(text)
+	if pending == nil || pending.Text == "" || !strings.Contains(text, pending.Text) {
+		return response
+	}
+	response.Buttons = pending.Buttons
+	response.MediaURL = pending.MediaURL
+	response.MediaCaption = pending.MediaCaption
+	response.Images = pending.Images
+	return response
+}
+
+// ProcessMessageWithConversationResponse processa a mensagem como ProcessMessageWithConversation,
+// mas retorna a resposta estruturada com mídia e botões quando houver
+func (s *AIService) ProcessMessageWithConversationResponse(ctx context.Context, tenantID uuid.UUID, customerPhone, message string, conversationID uuid.UUID) (*AIResponse, error) {
+	// Descartar elementos interativos de uma interação anterior
+	s.takePendingResponse(tenantID, customerPhone)
+
+	text, err := s.ProcessMessageWithConversation(ctx, tenantID, customerPhone, message, conversationID)
+	if err != nil {
+		return nil, err
+	}
+
+	return buildAIResponse(text, s.takePendingResponse(tenantID, customerPhone)), nil
+}
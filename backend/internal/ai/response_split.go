@@ -0,0 +1,170 @@
+package ai
+
+import (
+	"context"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// MaxMessageLengthSettingKey define o tamanho máximo (em caracteres) de cada mensagem enviada ao cliente
+	MaxMessageLengthSettingKey = "ai_max_message_length"
+
+	// defaultMaxMessageLength fica abaixo do limite de 4096 caracteres do WhatsApp
+	defaultMaxMessageLength = 4000
+	// minMaxMessageLength evita configurações que picotariam a resposta em dezenas de mensagens
+	minMaxMessageLength = 500
+)
+
+// numberedItemPattern reconhece o início de um item numerado da lista ("1. ", "12) ", "*3.* ")
+var numberedItemPattern = regexp.MustCompile(`^\s*[*_]*\d+[.)][*_]*\s`)
+
+// responseUnit é um trecho indivisível da resposta (um item da lista com suas linhas, um parágrafo)
+type responseUnit struct {
+	text string
+	// separator é o que vinha antes do trecho no texto original ("\n" ou "\n\n")
+	separator string
+}
+
+// splitResponseUnits agrupa as linhas da resposta em trechos: cada item numerado leva junto as linhas
+// seguintes (preço, estoque...) e linhas em branco separam parágrafos
+func splitResponseUnits(text string) []responseUnit {
+	var units []responseUnit
+	var current []string
+	separator := ""
+	pendingSeparator := ""
+
+	flush := func() {
+		if len(current) > 0 {
+			units = append(units, responseUnit{text: strings.Join(current, "\n"), separator: separator})
+			current = nil
+		}
+	}
+
+	for _, line := range strings.Split(text, "\n") {
+		if strings.TrimSpace(line) == "" {
+			if len(current) > 0 {
+				flush()
+			}
+			if len(units) > 0 {
+				pendingSeparator = "\n\n"
+			}
+			continue
+		}
+		if pendingSeparator != "" || len(current) == 0 || numberedItemPattern.MatchString(line) {
+			flush()
+			separator = pendingSeparator
+			if separator == "" && len(units) > 0 {
+				separator = "\n"
+			}
+			pendingSeparator = ""
+		}
+		current = append(current, line)
+	}
+	flush()
+	return units
+}
+
+// splitOversizedText quebra um trecho maior que o limite: primeiro entre linhas, depois entre palavras
+func splitOversizedText(text string, maxLength int) []string {
+	var parts []string
+	var current strings.Builder
+
+	appendPiece := func(piece, separator string) {
+		if current.Len() > 0 && utf8.RuneCountInString(current.String())+utf8.RuneCountInString(separator+piece) > maxLength {
+			parts = append(parts, current.String())
+			current.Reset()
+		}
+		if current.Len() > 0 {
+			current.WriteString(separator)
+		}
+		current.WriteString(piece)
+	}
+
+	for _, line := range strings.Split(text, "\n") {
+		if utf8.RuneCountInString(line) <= maxLength {
+			appendPiece(line, "\n")
+			continue
+		}
+		// Linha gigante: quebra entre palavras (ou no limite, se não houver espaço)
+		for _, word := range strings.Fields(line) {
+			for utf8.RuneCountInString(word) > maxLength {
+				runes := []rune(word)
+				appendPiece(string(runes[:maxLength]), " ")
+				word = string(runes[maxLength:])
+			}
+			appendPiece(word, " ")
+		}
+	}
+	if current.Len() > 0 {
+		parts = append(parts, current.String())
+	}
+	return parts
+}
+
+// SplitResponse divide uma resposta longa em mensagens de até maxLength caracteres, sempre entre itens
+// da lista ou parágrafos (nunca no meio de uma linha, salvo linhas maiores que o limite).
+// A numeração da lista é mantida, então a segunda mensagem continua de onde a primeira parou.
+func SplitResponse(text string, maxLength int) []string {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return nil
+	}
+	if maxLength <= 0 || utf8.RuneCountInString(text) <= maxLength {
+		return []string{text}
+	}
+
+	var messages []string
+	var current strings.Builder
+	flush := func() {
+		if message := strings.TrimSpace(current.String()); message != "" {
+			messages = append(messages, message)
+		}
+		current.Reset()
+	}
+
+	for _, unit := range splitResponseUnits(text) {
+		if utf8.RuneCountInString(unit.text) > maxLength {
+			flush()
+			messages = append(messages, splitOversizedText(unit.text, maxLength)...)
+			continue
+		}
+		if current.Len() > 0 && utf8.RuneCountInString(current.String())+utf8.RuneCountInString(unit.separator+unit.text) > maxLength {
+			flush()
+		}
+		if current.Len() > 0 {
+			current.WriteString(unit.separator)
+		}
+		current.WriteString(unit.text)
+	}
+	flush()
+	return messages
+}
+
+// getMaxMessageLength retorna o tamanho máximo de mensagem configurado pelo tenant
+func (s *AIService) getMaxMessageLength(ctx context.Context, tenantID uuid.UUID) int {
+	if s.settingsService == nil {
+		return defaultMaxMessageLength
+	}
+	setting, err := s.settingsService.GetSetting(ctx, tenantID, MaxMessageLengthSettingKey)
+	if err != nil || setting == nil || setting.SettingValue == nil {
+		return defaultMaxMessageLength
+	}
+	value, err := strconv.Atoi(strings.TrimSpace(*setting.SettingValue))
+	if err != nil || value <= 0 {
+		return defaultMaxMessageLength
+	}
+	if value < minMaxMessageLength {
+		return minMaxMessageLength
+	}
+	return value
+}
+
+// SplitResponseMessages divide a resposta nas mensagens que devem ser enviadas, em ordem, respeitando o limite do tenant
+func (s *AIService) SplitResponseMessages(ctx context.Context, tenantID uuid.UUID, text string) []string {
+	return SplitResponse(text, s.getMaxMessageLength(ctx, tenantID))
+}
@@ -0,0 +1,106 @@
+package ai
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+)
+
+// buildLongProductList monta uma lista numerada no formato dos handlers de produtos
+func buildLongProductList(count int) string {
+	var list strings.Builder
+	list.WriteString("🛍️ **Produtos encontrados:**\n\n")
+	for i := 1; i <= count; i++ {
+		list.WriteString(fmt.Sprintf("%d. **Produto de teste número %d**\n   💰 R$ %d,90\n   📦 Em estoque\n", i, i, i))
+	}
+	list.WriteString("\n💬 Me diga o número do produto para adicionar ao carrinho.")
+	return list.String()
+}
+
+var itemNumberPattern = regexp.MustCompile(`(?m)^(\d+)\. `)
+
+func TestSplitResponseProductList(t *testing.T) {
+	text := buildLongProductList(60)
+	messages := SplitResponse(text, 800)
+
+	if len(messages) < 2 {
+		t.Fatalf("esperado dividir a lista em várias mensagens, obtido %d", len(messages))
+	}
+
+	next := 1
+	for i, message := range messages {
+		if length := utf8.RuneCountInString(message); length > 800 {
+			t.Errorf("mensagem %d com %d caracteres, acima do limite", i+1, length)
+		}
+		// Cada item segue completo (nome, preço e estoque) na mesma mensagem
+		lines := strings.Split(message, "\n")
+		if strings.HasPrefix(strings.TrimSpace(lines[0]), "💰") || strings.HasPrefix(strings.TrimSpace(lines[0]), "📦") {
+			t.Errorf("mensagem %d começa no meio de um item:\n%s", i+1, message)
+		}
+		if last := strings.TrimSpace(lines[len(lines)-1]); strings.HasPrefix(last, "💰") || numberedItemPattern.MatchString(lines[len(lines)-1]) {
+			t.Errorf("mensagem %d termina no meio de um item:\n%s", i+1, message)
+		}
+		for _, match := range itemNumberPattern.FindAllStringSubmatch(message, -1) {
+			number, _ := strconv.Atoi(match[1])
+			if number != next {
+				t.Fatalf("numeração quebrada: esperado item %d, obtido %d", next, number)
+			}
+			next++
+		}
+	}
+	if next != 61 {
+		t.Errorf("esperado os 60 itens distribuídos nas mensagens, obtido %d", next-1)
+	}
+	if !strings.HasPrefix(messages[0], "🛍️ **Produtos encontrados:**") || !strings.HasSuffix(messages[len(messages)-1], "adicionar ao carrinho.") {
+		t.Errorf("esperado cabeçalho na primeira mensagem e instrução na última")
+	}
+}
+
+func TestSplitResponseShortAndOversized(t *testing.T) {
+	if messages := SplitResponse("Olá! Como posso ajudar?", 4000); len(messages) != 1 || messages[0] != "Olá! Como posso ajudar?" {
+		t.Errorf("resposta curta não deve ser dividida, obtido %q", messages)
+	}
+	if messages := SplitResponse("  \n ", 4000); len(messages) != 0 {
+		t.Errorf("resposta vazia não gera mensagens, obtido %q", messages)
+	}
+
+	// Parágrafo único maior que o limite é quebrado entre palavras
+	paragraph := strings.Repeat("palavra ", 200)
+	messages := SplitResponse(paragraph, 500)
+	if len(messages) < 3 {
+		t.Fatalf("esperado quebrar o parágrafo gigante, obtido %d mensagens", len(messages))
+	}
+	for _, message := range messages {
+		if utf8.RuneCountInString(message) > 500 {
+			t.Errorf("mensagem acima do limite: %d caracteres", utf8.RuneCountInString(message))
+		}
+		if strings.Contains(message, "palavr ") || strings.HasPrefix(message, "alavra") {
+			t.Errorf("palavra cortada ao meio: %q", message)
+		}
+	}
+}
+
+func TestSplitResponseMessagesUsesTenantLimit(t *testing.T) {
+	text := buildLongProductList(60)
+
+	s := &AIService{settingsService: &fakeSettingsService{values: map[string]string{MaxMessageLengthSettingKey: "1000"}}}
+	if messages := s.SplitResponseMessages(context.Background(), uuid.New(), text); len(messages) < 3 {
+		t.Errorf("esperado dividir com o limite do tenant, obtido %d mensagens", len(messages))
+	}
+
+	s = &AIService{settingsService: &fakeSettingsService{values: map[string]string{}}}
+	if messages := s.SplitResponseMessages(context.Background(), uuid.New(), text); len(messages) != 1 {
+		t.Errorf("esperado uma única mensagem com o limite padrão, obtido %d", len(messages))
+	}
+
+	s = &AIService{settingsService: &fakeSettingsService{values: map[string]string{MaxMessageLengthSettingKey: "10"}}}
+	if got := s.getMaxMessageLength(context.Background(), uuid.New()); got != minMaxMessageLength {
+		t.Errorf("esperado limite mínimo %d, obtido %d", minMaxMessageLength, got)
+	}
+}
@@ -0,0 +1,109 @@
+package ai
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"iafarma/pkg/models"
+
+	"github.com/google/uuid"
+)
+
+func TestBuildAIResponse(t *testing.T) {
+	pending := &AIResponse{Text: "Confirme o endereço", Buttons: checkoutConfirmationButtons}
+
+	tests := []struct {
+		name        string
+		text        string
+		pending     *AIResponse
+		wantButtons bool
+	}{
+		{"sem elementos interativos", "Olá!", nil, false},
+		{"texto do handler preservado", "Confirme o endereço", pending, true},
+		{"texto do handler com complemento", "Confirme o endereço\n\nPosso ajudar em algo mais?", pending, true},
+		{"texto reescrito pela IA", "Quer confirmar o endereço?", pending, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			response := buildAIResponse(tt.text, tt.pending)
+			if response.String() != tt.text {
+				t.Errorf("String() = %q, esperado %q", response.String(), tt.text)
+			}
+			if response.HasButtons() != tt.wantButtons {
+				t.Errorf("HasButtons() = %v, esperado %v", response.HasButtons(), tt.wantButtons)
+			}
+		})
+	}
+}
+
+func TestAIResponseNilFallback(t *testing.T) {
+	var response *AIResponse
+	if response.String() != "" || response.HasButtons() || response.HasMedia() {
+		t.Errorf("resposta nil deveria se comportar como texto vazio")
+	}
+}
+
+func TestHandleCheckoutEmitsConfirmationButtons(t *testing.T) {
+	tenantID := uuid.New()
+	customerID := uuid.New()
+	phone := "5561999999999"
+	paymentMethodID := uuid.New()
+
+	cart := &models.Cart{
+		PaymentMethodID: &paymentMethodID,
+		Items: []models.CartItem{
+			{Quantity: 2, Price: "10.00", Product: &models.Product{Name: "Sabonete"}},
+		},
+	}
+
+	tests := []struct {
+		name        string
+		addresses   []models.Address
+		wantButtons bool
+	}{
+		{
+			name:        "endereço completo pede confirmação com botões",
+			addresses:   []models.Address{{Street: "Rua das Flores", Number: "123", City: "Brasília", State: "DF", ZipCode: "70000-000"}},
+			wantButtons: true,
+		},
+		{
+			name:        "endereço incompleto não exibe botões",
+			addresses:   []models.Address{{Street: "Rua das Flores"}},
+			wantButtons: false,
+		},
+		{
+			name:        "sem endereço não exibe botões",
+			addresses:   nil,
+			wantButtons: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &AIService{
+				cartService:     &fakeCartService{cart: cart},
+				customerService: &fakeCustomerService{customer: &models.Customer{Name: "Maria"}},
+				addressService:  &fakeAddressService{addresses: tt.addresses},
+			}
+
+			text, err := s.handleCheckout(context.Background(), tenantID, customerID, phone)
+			if err != nil {
+				t.Fatalf("erro inesperado: %v", err)
+			}
+
+			response := buildAIResponse(text, s.takePendingResponse(tenantID, phone))
+			if response.HasButtons() != tt.wantButtons {
+				t.Fatalf("HasButtons() = %v, esperado %v", response.HasButtons(), tt.wantButtons)
+			}
+			// O texto continua completo para canais sem suporte a botões
+			if tt.wantButtons && !strings.Contains(response.String(), "'sim'") {
+				t.Errorf("texto de fallback deveria manter as instruções de resposta: %q", response.String())
+			}
+			if tt.wantButtons && response.Buttons[0].Title != "Sim" {
+				t.Errorf("primeiro botão = %q, esperado Sim", response.Buttons[0].Title)
+			}
+		})
+	}
+}
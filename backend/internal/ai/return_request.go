@@ -0,0 +1,191 @@
+package ai
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"iafarma/pkg/models"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+)
+
+// ReturnWindowDaysSettingKey define por quantos dias após a entrega o cliente pode pedir devolução ou troca
+// ("0" = não aceitar pelo WhatsApp)
+const ReturnWindowDaysSettingKey = "ai_return_window_days"
+
+const (
+	// defaultReturnWindowDays segue o prazo de arrependimento do CDC para compras fora da loja
+	defaultReturnWindowDays = 7
+	// maxReturnWindowDays limita a janela configurada pelo tenant
+	maxReturnWindowDays = 90
+)
+
+// getReturnWindowDays retorna a janela de devolução configurada pelo tenant
+func (s *AIService) getReturnWindowDays(ctx context.Context, tenantID uuid.UUID) int {
+	if s.settingsService == nil {
+		return defaultReturnWindowDays
+	}
+
+	setting, err := s.settingsService.GetSetting(ctx, tenantID, ReturnWindowDaysSettingKey)
+	if err != nil || setting == nil || setting.SettingValue == nil {
+		return defaultReturnWindowDays
+	}
+
+	days, err := strconv.Atoi(strings.TrimSpace(*setting.SettingValue))
+	if err != nil || days < 0 {
+		return defaultReturnWindowDays
+	}
+	if days > maxReturnWindowDays {
+		days = maxReturnWindowDays
+	}
+	return days
+}
+
+// orderDeliveredAt retorna quando o pedido foi entregue; pedidos marcados como entregues sem data usam a última atualização
+func orderDeliveredAt(order *models.Order) time.Time {
+	if order.DeliveredAt != nil {
+		return *order.DeliveredAt
+	}
+	return order.UpdatedAt
+}
+
+// latestDeliveredOrder retorna o pedido entregue mais recente do cliente
+func latestDeliveredOrder(orders []models.Order) *models.Order {
+	var latest *models.Order
+	for i := range orders {
+		if orderTrackingStatus(&orders[i]) != "delivered" {
+			continue
+		}
+		if latest == nil || orderDeliveredAt(&orders[i]).After(orderDeliveredAt(latest)) {
+			latest = &orders[i]
+		}
+	}
+	return latest
+}
+
+// handleSolicitarDevolucao registra o pedido de devolução ou troca de um pedido entregue dentro da janela configurada
+// e avisa a equipe, que combina a coleta com o cliente. Sem identificador, usa o último pedido entregue.
+func (s *AIService) handleSolicitarDevolucao(ctx context.Context, tenantID, customerID uuid.UUID, customerPhone string, args map[string]interface{}) (string, error) {
+	reason, _ := args["motivo"].(string)
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return "❓ Qual o motivo da devolução ou troca? Ex: produto com defeito, veio errado, validade vencida.", nil
+	}
+	items, _ := args["itens"].(string)
+	items = strings.TrimSpace(items)
+
+	requestType := models.ReturnRequestTypeReturn
+	if kind, _ := args["tipo"].(string); strings.EqualFold(strings.TrimSpace(kind), "troca") {
+		requestType = models.ReturnRequestTypeExchange
+	}
+
+	notFound := "❌ Pedido não encontrado. Use 'histórico de pedidos' para ver seus pedidos e me diga qual deseja devolver."
+
+	var order *models.Order
+	if identifier, _ := args["order_id"].(string); strings.TrimSpace(identifier) != "" {
+		orderID, found := s.resolveCustomerOrderID(tenantID, customerID, identifier)
+		if !found {
+			return notFound, nil
+		}
+		loaded, err := s.orderService.GetOrderByID(tenantID, orderID)
+		if err != nil || loaded == nil {
+			return notFound, nil
+		}
+		order = loaded
+	} else {
+		orders, err := s.orderService.GetOrdersByCustomer(tenantID, customerID)
+		if err != nil {
+			return "❌ Erro ao buscar seus pedidos.", err
+		}
+		order = latestDeliveredOrder(orders)
+		if order == nil {
+			return "📦 Não encontrei nenhum pedido entregue para devolução. A devolução ou troca só pode ser pedida depois que o pedido chegar.", nil
+		}
+	}
+
+	// Não revelar pedidos de outros clientes
+	if order.CustomerID == nil || *order.CustomerID != customerID {
+		log.Warn().
+			Str("tenant_id", tenantID.String()).
+			Str("customer_id", customerID.String()).
+			Str("order_id", order.ID.String()).
+			Msg("🚫 Tentativa de devolver pedido de outro cliente")
+		return notFound, nil
+	}
+
+	if orderTrackingStatus(order) != "delivered" {
+		return fmt.Sprintf("📦 O pedido %s ainda não foi entregue. A devolução ou troca só pode ser pedida depois que ele chegar.\n\n🚚 Diga 'rastrear pedido' para acompanhar a entrega.", order.OrderNumber), nil
+	}
+
+	windowDays := s.getReturnWindowDays(ctx, tenantID)
+	deliveredAt := orderDeliveredAt(order)
+	if windowDays == 0 || time.Since(deliveredAt) > time.Duration(windowDays)*24*time.Hour {
+		log.Info().
+			Str("tenant_id", tenantID.String()).
+			Str("order_id", order.ID.String()).
+			Time("delivered_at", deliveredAt).
+			Int("window_days", windowDays).
+			Msg("↩️ Devolução recusada: fora do prazo")
+		if windowDays == 0 {
+			return fmt.Sprintf("😕 Não conseguimos registrar devoluções por aqui. Para falar sobre o pedido %s, peça para falar com um atendente.", order.OrderNumber), nil
+		}
+		return fmt.Sprintf("😕 O prazo para devolução ou troca é de %d dias após a entrega, e o pedido %s foi entregue em %s.\n\n🙋 Se o produto apresentou defeito, peça para falar com um atendente que vamos te ajudar.",
+			windowDays, order.OrderNumber, deliveredAt.Format("02/01/2006")), nil
+	}
+
+	if s.returnRequestService == nil {
+		return "❌ Não foi possível registrar a devolução agora. Tente novamente em instantes.", nil
+	}
+
+	existing, err := s.returnRequestService.GetOpenReturnRequest(tenantID, order.ID)
+	if err != nil {
+		return "❌ Erro ao verificar solicitações de devolução.", err
+	}
+	if existing != nil {
+		return fmt.Sprintf("📋 Já existe uma solicitação de devolução ou troca em andamento para o pedido %s. Nossa equipe vai entrar em contato para combinar os próximos passos.", order.OrderNumber), nil
+	}
+
+	request := &models.ReturnRequest{
+		OrderID:    order.ID,
+		CustomerID: &customerID,
+		Type:       requestType,
+		Reason:     reason,
+		Items:      items,
+		Status:     models.ReturnRequestStatusRequested,
+	}
+	request.TenantID = tenantID
+	if err := s.returnRequestService.CreateReturnRequest(request); err != nil {
+		return "❌ Erro ao registrar a solicitação de devolução.", err
+	}
+
+	log.Info().
+		Str("tenant_id", tenantID.String()).
+		Str("customer_id", customerID.String()).
+		Str("order_id", order.ID.String()).
+		Str("type", requestType).
+		Msg("↩️ Solicitação de devolução registrada")
+
+	action, nextStep := "Devolução", "a coleta"
+	if requestType == models.ReturnRequestTypeExchange {
+		action, nextStep = "Troca", "a coleta e a troca"
+	}
+	itemsText := items
+	if itemsText == "" {
+		itemsText = "pedido inteiro"
+	}
+
+	if s.alertService != nil {
+		description := fmt.Sprintf("🔄 *Tipo:* %s\n📦 *Pedido:* %s (entregue em %s)\n🛒 *Itens:* %s\n💬 *Motivo:* %s",
+			action, order.OrderNumber, deliveredAt.Format("02/01/2006"), itemsText, reason)
+		if err := s.alertService.SendReturnRequestAlert(tenantID, customerID, customerPhone, description); err != nil {
+			log.Error().Err(err).Str("order_id", order.ID.String()).Msg("Erro ao enviar alerta de devolução")
+		}
+	}
+
+	return fmt.Sprintf("✅ **Solicitação de %s registrada!**\n\n📦 Pedido: %s\n🛒 Itens: %s\n💬 Motivo: %s\n\n👥 Nossa equipe vai analisar e entrar em contato por aqui para combinar %s. Guarde o produto com a embalagem, se possível.",
+		strings.ToLower(action), order.OrderNumber, itemsText, reason, nextStep), nil
+}
@@ -0,0 +1,157 @@
+package ai
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"iafarma/pkg/models"
+
+	"github.com/google/uuid"
+)
+
+// fakeReturnRequestService guarda as solicitações de devolução em memória
+type fakeReturnRequestService struct {
+	requests []models.ReturnRequest
+}
+
+func (f *fakeReturnRequestService) CreateReturnRequest(request *models.ReturnRequest) error {
+	request.ID = uuid.New()
+	f.requests = append(f.requests, *request)
+	return nil
+}
+
+func (f *fakeReturnRequestService) GetOpenReturnRequest(tenantID, orderID uuid.UUID) (*models.ReturnRequest, error) {
+	for i := range f.requests {
+		if f.requests[i].TenantID == tenantID && f.requests[i].OrderID == orderID && f.requests[i].Status == models.ReturnRequestStatusRequested {
+			return &f.requests[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// returnAlertService guarda os alertas de devolução enviados à equipe
+type returnAlertService struct {
+	fakeAlertService
+	returns []string
+}
+
+func (r *returnAlertService) SendReturnRequestAlert(tenantID uuid.UUID, customerID uuid.UUID, customerPhone string, description string) error {
+	r.returns = append(r.returns, description)
+	return nil
+}
+
+func newDeliveredTestOrder(tenantID, customerID uuid.UUID, number string, deliveredAgo time.Duration) models.Order {
+	order := newTestOrder(tenantID, customerID, number, "Protetor Solar")
+	order.Status = "delivered"
+	deliveredAt := time.Now().Add(-deliveredAgo)
+	order.DeliveredAt = &deliveredAt
+	return order
+}
+
+// withReturns liga o registro de devoluções e os alertas da loja
+func withReturns(returns *fakeReturnRequestService, alerts *returnAlertService) testServiceOption {
+	return withOverride(func(s *AIService) {
+		s.returnRequestService = returns
+		s.alertService = alerts
+	})
+}
+
+func TestSolicitarDevolucaoWithinWindow(t *testing.T) {
+	tenantID, customerID := uuid.New(), uuid.New()
+	order := newDeliveredTestOrder(tenantID, customerID, "PED-010", 2*24*time.Hour)
+	returns, alerts := &fakeReturnRequestService{}, &returnAlertService{}
+	s, _ := newTestService(map[string]string{ReturnWindowDaysSettingKey: "7"}, withOrders(order), withReturns(returns, alerts))
+
+	obtido, err := s.executeTool(context.Background(), tenantID, customerID, "5511999999999", "solicitarDevolucao", map[string]interface{}{
+		"motivo": "  veio com a tampa quebrada ",
+		"itens":  "1 Protetor Solar",
+		"tipo":   "troca",
+	})
+	if err != nil {
+		t.Fatalf("erro inesperado: %v", err)
+	}
+	if !strings.Contains(obtido, "Solicitação de troca registrada") || !strings.Contains(obtido, "PED-010") {
+		t.Errorf("esperada confirmação da troca ao cliente:\n%s", obtido)
+	}
+
+	if len(returns.requests) != 1 {
+		t.Fatalf("esperada 1 solicitação registrada, obtido %d", len(returns.requests))
+	}
+	request := returns.requests[0]
+	if request.OrderID != order.ID || request.TenantID != tenantID || request.CustomerID == nil || *request.CustomerID != customerID {
+		t.Errorf("solicitação deveria estar ligada ao pedido e ao cliente: %+v", request)
+	}
+	if request.Type != models.ReturnRequestTypeExchange || request.Reason != "veio com a tampa quebrada" || request.Items != "1 Protetor Solar" {
+		t.Errorf("tipo, motivo ou itens incorretos: %+v", request)
+	}
+	if request.Status != models.ReturnRequestStatusRequested {
+		t.Errorf("esperado status %q, obtido %q", models.ReturnRequestStatusRequested, request.Status)
+	}
+
+	if len(alerts.returns) != 1 || !strings.Contains(alerts.returns[0], "PED-010") || !strings.Contains(alerts.returns[0], "tampa quebrada") {
+		t.Errorf("equipe deveria ser avisada com pedido e motivo, alertas: %v", alerts.returns)
+	}
+
+	// Segunda solicitação para o mesmo pedido não duplica o registro nem o alerta
+	obtido, err = s.handleSolicitarDevolucao(context.Background(), tenantID, customerID, "5511999999999", map[string]interface{}{"motivo": "defeito"})
+	if err != nil {
+		t.Fatalf("erro inesperado: %v", err)
+	}
+	if !strings.Contains(obtido, "Já existe uma solicitação") || len(returns.requests) != 1 || len(alerts.returns) != 1 {
+		t.Errorf("solicitação repetida não deveria ser registrada de novo:\n%s", obtido)
+	}
+}
+
+func TestSolicitarDevolucaoOutsideWindow(t *testing.T) {
+	tenantID, customerID := uuid.New(), uuid.New()
+	order := newDeliveredTestOrder(tenantID, customerID, "PED-011", 10*24*time.Hour)
+	returns, alerts := &fakeReturnRequestService{}, &returnAlertService{}
+	s, _ := newTestService(map[string]string{ReturnWindowDaysSettingKey: "7"}, withOrders(order), withReturns(returns, alerts))
+
+	obtido, err := s.handleSolicitarDevolucao(context.Background(), tenantID, customerID, "5511999999999", map[string]interface{}{
+		"motivo":   "não gostei",
+		"order_id": "PED-011",
+	})
+	if err != nil {
+		t.Fatalf("erro inesperado: %v", err)
+	}
+	if !strings.Contains(obtido, "prazo para devolução ou troca é de 7 dias") {
+		t.Errorf("esperada recusa por prazo:\n%s", obtido)
+	}
+	if len(returns.requests) != 0 || len(alerts.returns) != 0 {
+		t.Errorf("fora do prazo nada deveria ser registrado ou alertado: %d solicitações, %d alertas", len(returns.requests), len(alerts.returns))
+	}
+}
+
+func TestSolicitarDevolucaoRequiresDeliveredOrder(t *testing.T) {
+	tenantID, customerID := uuid.New(), uuid.New()
+	order := newTestOrder(tenantID, customerID, "PED-012", "Dipirona")
+	order.Status = "shipped"
+	returns := &fakeReturnRequestService{}
+	s, _ := newTestService(map[string]string{ReturnWindowDaysSettingKey: "7"}, withOrders(order), withReturns(returns, &returnAlertService{}))
+
+	obtido, err := s.handleSolicitarDevolucao(context.Background(), tenantID, customerID, "5511999999999", map[string]interface{}{
+		"motivo":   "veio errado",
+		"order_id": "PED-012",
+	})
+	if err != nil {
+		t.Fatalf("erro inesperado: %v", err)
+	}
+	if !strings.Contains(obtido, "ainda não foi entregue") {
+		t.Errorf("esperada recusa para pedido não entregue:\n%s", obtido)
+	}
+	if len(returns.requests) != 0 {
+		t.Errorf("pedido não entregue não deveria gerar solicitação, obtido %d", len(returns.requests))
+	}
+
+	// Sem número de pedido, só pedidos entregues são considerados
+	obtido, err = s.handleSolicitarDevolucao(context.Background(), tenantID, customerID, "5511999999999", map[string]interface{}{"motivo": "veio errado"})
+	if err != nil {
+		t.Fatalf("erro inesperado: %v", err)
+	}
+	if !strings.Contains(obtido, "Não encontrei nenhum pedido entregue") {
+		t.Errorf("esperado aviso de que não há pedido entregue:\n%s", obtido)
+	}
+}
@@ -0,0 +1,79 @@
+package ai
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"iafarma/pkg/models"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+)
+
+// returningCartOnFirstMessage retorna o carrinho ativo que o cliente deixou com itens na visita anterior, para a
+// primeira mensagem de uma nova sessão (sem histórico em memória). Conversas abertas pela loja e conversas recomeçadas
+// por inatividade (freshStart, que já esvazia o carrinho) não entram no fluxo. Retorna nil quando não há itens.
+func (s *AIService) returningCartOnFirstMessage(tenantID, customerID uuid.UUID, historyLen int, freshStart, businessInitiated bool) *models.Cart {
+	if historyLen > 0 || freshStart || businessInitiated || s.cartService == nil {
+		return nil
+	}
+
+	activeCart, err := s.cartService.GetOrCreateActiveCart(tenantID, customerID)
+	if err != nil {
+		log.Warn().Err(err).Str("customer_id", customerID.String()).Msg("⚠️ Erro ao buscar carrinho da visita anterior")
+		return nil
+	}
+	cart, err := s.cartService.GetCartWithItems(activeCart.ID, tenantID)
+	if err != nil || cart == nil || len(cart.Items) == 0 {
+		return nil
+	}
+
+	log.Info().
+		Str("tenant_id", tenantID.String()).
+		Str("customer_id", customerID.String()).
+		Int("items", len(cart.Items)).
+		Msg("🛒 Cliente voltou com itens no carrinho")
+	return cart
+}
+
+// formatReturningCartItems lista os itens do carrinho deixado na visita anterior
+func formatReturningCartItems(cart *models.Cart) string {
+	lines := make([]string, 0, len(cart.Items))
+	for _, item := range cart.Items {
+		lines = append(lines, fmt.Sprintf("• %dx %s", item.Quantity, getItemName(item)))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// formatReturningCartOffer monta as boas-vindas de retorno oferecendo continuar ou esvaziar o carrinho anterior
+func formatReturningCartOffer(cart *models.Cart, language string) string {
+	return catalogMessage(language, "returning_cart") + "\n\n" +
+		formatReturningCartItems(cart) + "\n\n" +
+		catalogMessage(language, "returning_cart_question")
+}
+
+// withReturningCartNotice avisa a IA, quando a primeira mensagem não é só uma saudação, que o cliente voltou com
+// itens no carrinho, para que ela ofereça continuar ou esvaziar
+func withReturningCartNotice(systemPrompt string, cart *models.Cart) string {
+	if cart == nil {
+		return systemPrompt
+	}
+	return systemPrompt + "\n\n🛒 CARRINHO DA VISITA ANTERIOR: o cliente voltou e ainda tem estes itens no carrinho:\n" +
+		formatReturningCartItems(cart) +
+		"\nAtenda o pedido atual e pergunte se ele quer continuar com esses itens ou esvaziar o carrinho (ferramenta retomarCarrinho)."
+}
+
+// handleRetomarCarrinho trata a resposta do cliente à oferta de retomar o carrinho da visita anterior:
+// "continuar" mostra o carrinho como está e "limpar" esvazia para começar de novo
+func (s *AIService) handleRetomarCarrinho(ctx context.Context, tenantID, customerID uuid.UUID, args map[string]interface{}) (string, error) {
+	action, _ := args["acao"].(string)
+	switch strings.ToLower(strings.TrimSpace(action)) {
+	case "continuar":
+		return s.handleVerCarrinhoWithOptions(ctx, tenantID, customerID, true)
+	case "limpar":
+		return s.handleLimparCarrinho(tenantID, customerID)
+	default:
+		return "❓ Você quer continuar com os itens do carrinho ou prefere esvaziar e começar de novo?", nil
+	}
+}
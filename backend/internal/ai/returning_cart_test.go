@@ -0,0 +1,107 @@
+package ai
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"iafarma/pkg/models"
+
+	"github.com/google/uuid"
+	"github.com/sashabaranov/go-openai"
+)
+
+func TestReturningCustomerCartOffer(t *testing.T) {
+	tests := []struct {
+		name       string
+		language   string
+		message    string
+		silence    time.Duration
+		items      int
+		history    bool
+		expected   string
+		promptCart bool
+	}{
+		{"volta com carrinho", "", "oi", 2 * time.Hour, 2, false, "👋 Bem-vindo de volta! Você tinha itens no carrinho:\n\n• 2x Dipirona 500mg\n• 1x Protetor Solar FPS 50", false},
+		{"volta com carrinho em inglês", "en", "hi", 2 * time.Hour, 2, false, "Welcome back! You had items in your cart", false},
+		{"volta sem carrinho", "", "oi", 2 * time.Hour, 0, false, "ok", false},
+		{"volta com carrinho e já pede algo", "", "quero um sabonete", 2 * time.Hour, 2, false, "ok", true},
+		{"conversa em andamento", "", "oi", 2 * time.Hour, 2, true, "ok", false},
+		{"volta depois do limite de inatividade", "", "oi", 72 * time.Hour, 2, false, "Olá! Bem-vindo à Farmácia Central.", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tenantID := uuid.New()
+			client, systemPrompt := newPromptCapturingClient(t)
+			customer := newLanguageTestCustomer(tt.language)
+			s, fakes := newTestService(map[string]string{}, withLanguageChat(client, customer))
+			s.settingsService = &welcomeSettingsService{fakeSettingsService: fakes.settings}
+
+			// Cliente já recebido antes, que saiu deixando o carrinho montado
+			lastSeen, welcomed := time.Now().Add(-tt.silence), time.Now().Add(-tt.silence)
+			customer.LastSeenAt, customer.LastWelcomedAt = &lastSeen, &welcomed
+			cart := &models.Cart{}
+			if tt.items > 0 {
+				cart.Items = []models.CartItem{
+					{Quantity: 2, Price: "8.90", Product: &models.Product{Name: "Dipirona 500mg", Price: "8.90"}},
+					{Quantity: 1, Price: "49.90", Product: &models.Product{Name: "Protetor Solar FPS 50", Price: "49.90"}},
+				}[:tt.items]
+			}
+			s.cartService = &fakeCartService{cart: cart}
+			if tt.history {
+				s.memoryManager.AddToConversationHistory(tenantID, customer.Phone, openai.ChatCompletionMessage{Role: openai.ChatMessageRoleUser, Content: "quero dipirona"})
+			}
+
+			response, err := s.ProcessMessage(context.Background(), tenantID, customer.Phone, tt.message)
+			if err != nil {
+				t.Fatalf("erro inesperado: %v", err)
+			}
+			if !strings.Contains(response, tt.expected) {
+				t.Errorf("esperado %q, obtido:\n%s", tt.expected, response)
+			}
+			if tt.expected != "ok" && tt.silence < 48*time.Hour {
+				if customer.LastWelcomedAt == nil || !customer.LastWelcomedAt.After(welcomed) {
+					t.Error("a oferta de retomar o carrinho conta como boas-vindas")
+				}
+			}
+			if hasNotice := strings.Contains(*systemPrompt, "CARRINHO DA VISITA ANTERIOR"); hasNotice != tt.promptCart {
+				t.Errorf("aviso do carrinho anterior no prompt = %v, esperado %v", hasNotice, tt.promptCart)
+			}
+			if tt.silence >= 48*time.Hour && len(cart.Items) != 0 {
+				t.Errorf("conversa recomeçada deveria esvaziar o carrinho, obtido %d itens", len(cart.Items))
+			}
+		})
+	}
+}
+
+func TestRetomarCarrinho(t *testing.T) {
+	tests := []struct {
+		action   string
+		expected string
+		items    int
+	}{
+		{"continuar", "Seu Carrinho", 1},
+		{"limpar", "Carrinho limpo com sucesso", 0},
+		{"", "Você quer continuar com os itens do carrinho", 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.action, func(t *testing.T) {
+			cart := newPickupTestCart(false)
+			s, _ := newTestService(map[string]string{AllowPickupSettingKey: "false"}, withCheckout(cart))
+
+			result, err := s.executeTool(context.Background(), uuid.New(), uuid.New(), "5561999999999", "retomarCarrinho", map[string]interface{}{"acao": tt.action})
+			if err != nil {
+				t.Fatalf("erro inesperado: %v", err)
+			}
+			if !strings.Contains(result, tt.expected) {
+				t.Errorf("esperado %q, obtido:\n%s", tt.expected, result)
+			}
+			if len(cart.Items) != tt.items {
+				t.Errorf("esperados %d itens no carrinho, obtido %d", tt.items, len(cart.Items))
+			}
+		})
+	}
+}
@@ -0,0 +1,83 @@
+package ai
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"iafarma/pkg/models"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+)
+
+// ReturningCustomerGreetingSettingKey habilita a saudação personalizada ("Oi, João! Bom te ver de novo") para clientes que já compraram
+const ReturningCustomerGreetingSettingKey = "ai_returning_customer_greeting"
+
+// isReturningCustomerGreetingEnabled indica se o tenant habilitou a saudação personalizada de retorno
+func (s *AIService) isReturningCustomerGreetingEnabled(ctx context.Context, tenantID uuid.UUID) bool {
+	if s.settingsService == nil {
+		return false
+	}
+
+	setting, err := s.settingsService.GetSetting(ctx, tenantID, ReturningCustomerGreetingSettingKey)
+	if err != nil || setting == nil || setting.SettingValue == nil {
+		return false
+	}
+
+	enabled, err := strconv.ParseBool(strings.TrimSpace(*setting.SettingValue))
+	return err == nil && enabled
+}
+
+// customerFirstName retorna o primeiro nome do cliente (vazio se o nome não foi informado, é só o telefone ou é um nome genérico)
+func customerFirstName(customer *models.Customer) string {
+	if customer == nil || !isValidCustomerName(customer.Name) {
+		return ""
+	}
+
+	fields := strings.Fields(customer.Name)
+	if len(fields) == 0 {
+		return ""
+	}
+
+	first := fields[0]
+	for _, r := range first {
+		if !unicode.IsLetter(r) {
+			return ""
+		}
+	}
+	return first
+}
+
+// isReturningCustomer indica se o cliente já fez algum pedido (cancelados não contam)
+func (s *AIService) isReturningCustomer(tenantID uuid.UUID, customer *models.Customer) bool {
+	if s.orderService == nil || customer == nil {
+		return false
+	}
+
+	orders, err := s.orderService.GetOrdersByCustomer(tenantID, customer.ID)
+	if err != nil {
+		log.Warn().Err(err).Str("customer_id", customer.ID.String()).Msg("⚠️ Não foi possível consultar o histórico de pedidos")
+		return false
+	}
+
+	for _, order := range orders {
+		if order.Status != "cancelled" {
+			return true
+		}
+	}
+	return false
+}
+
+// returningCustomerGreeting retorna a saudação personalizada para clientes que voltam
+// (vazio para clientes novos, sem nome ou quando o tenant não habilitou)
+func (s *AIService) returningCustomerGreeting(ctx context.Context, tenantID uuid.UUID, customer *models.Customer) string {
+	firstName := customerFirstName(customer)
+	if firstName == "" || !s.isReturningCustomerGreetingEnabled(ctx, tenantID) || !s.isReturningCustomer(tenantID, customer) {
+		return ""
+	}
+
+	return fmt.Sprintf("Oi, %s! Bom te ver de novo 😊\n\nComo posso ajudar você hoje?", firstName)
+}
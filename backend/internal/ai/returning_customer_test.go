@@ -0,0 +1,69 @@
+package ai
+
+import (
+	"context"
+	"testing"
+
+	"iafarma/pkg/models"
+
+	"github.com/google/uuid"
+)
+
+func TestCustomerFirstName(t *testing.T) {
+	tests := []struct {
+		name     string
+		esperado string
+	}{
+		{"João da Silva", "João"},
+		{"  maria  ", "maria"},
+		{"", ""},
+		{"5527999999999", ""},
+	}
+
+	for _, tt := range tests {
+		if got := customerFirstName(&models.Customer{Name: tt.name}); got != tt.esperado {
+			t.Errorf("%q: esperado %q, obtido %q", tt.name, tt.esperado, got)
+		}
+	}
+}
+
+func TestReturningCustomerGreeting(t *testing.T) {
+	tenantID := uuid.New()
+
+	tests := []struct {
+		name         string
+		customerName string
+		orderStatus  []string
+		enabled      string
+		esperado     string
+	}{
+		{"cliente que volta", "João Pereira", []string{"delivered"}, "true", "Oi, João! Bom te ver de novo 😊\n\nComo posso ajudar você hoje?"},
+		{"cliente novo", "João Pereira", nil, "true", ""},
+		{"só pedidos cancelados", "João Pereira", []string{"cancelled"}, "true", ""},
+		{"configuração desativada", "João Pereira", []string{"delivered"}, "false", ""},
+		{"cliente sem nome", "", []string{"delivered"}, "true", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			customer := &models.Customer{Name: tt.customerName, Phone: "5527999999999"}
+			customer.ID = uuid.New()
+
+			orders := &fakeOrderService{}
+			for _, status := range tt.orderStatus {
+				order := models.Order{Status: status, CustomerID: &customer.ID}
+				order.TenantID = tenantID
+				orders.orders = append(orders.orders, order)
+			}
+
+			s := &AIService{
+				orderService:    orders,
+				settingsService: &fakeSettingsService{values: map[string]string{ReturningCustomerGreetingSettingKey: tt.enabled}},
+			}
+
+			if got := s.returningCustomerGreeting(context.Background(), tenantID, customer); got != tt.esperado {
+				t.Errorf("esperado %q, obtido %q", tt.esperado, got)
+			}
+		})
+	}
+}
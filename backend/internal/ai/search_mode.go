@@ -0,0 +1,310 @@
+package ai
+
+import (
+	"context"
+	"iafarma/pkg/models"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// SearchModeSettingKey define como o consultarItens busca produtos: "rag", "sql" ou "hybrid" (padrão)
+	SearchModeSettingKey = "ai_search_mode"
+	// RAGMinScoreSettingKey define a similaridade mínima (0 a 1) para um resultado da busca semântica ser exibido ("0" = sem corte)
+	RAGMinScoreSettingKey = "ai_rag_min_score"
+
+	searchModeRAG    = "rag"
+	searchModeSQL    = "sql"
+	searchModeHybrid = "hybrid"
+
+	searchPathRAG         = "rag"
+	searchPathSQL         = "sql"
+	searchPathSQLFallback = "sql_fallback"
+	searchPathRAGTopUp    = "rag_sql_topup"
+)
+
+// getSearchMode retorna o modo de busca configurado para o tenant (hybrid quando ausente ou inválido)
+func (s *AIService) getSearchMode(ctx context.Context, tenantID uuid.UUID) string {
+	if s.settingsService == nil {
+		return searchModeHybrid
+	}
+
+	setting, err := s.settingsService.GetSetting(ctx, tenantID, SearchModeSettingKey)
+	if err != nil || setting == nil || setting.SettingValue == nil {
+		return searchModeHybrid
+	}
+
+	switch mode := strings.ToLower(strings.TrimSpace(*setting.SettingValue)); mode {
+	case searchModeRAG, searchModeSQL:
+		return mode
+	default:
+		return searchModeHybrid
+	}
+}
+
+// decideSearchPath escolhe o caminho da busca conforme o modo do tenant.
+// hybrid: SQL para ordenação por preço e RAG para buscas textuais; rag: RAG sempre que houver texto; sql: nunca usa RAG.
+func decideSearchPath(mode, query, sortBy string, hasEmbeddings bool) string {
+	if query == "" || !hasEmbeddings || mode == searchModeSQL {
+		return searchPathSQL
+	}
+
+	isPriceSort := sortBy == "price_asc" || sortBy == "price_desc"
+	if mode == searchModeHybrid && isPriceSort {
+		return searchPathSQL
+	}
+
+	return searchPathRAG
+}
+
+// sortProductsByPrice ordena resultados do RAG pelo preço efetivo (usado no modo rag com ordenação por preço).
+// Produtos sem preço válido ficam de fora, como na busca SQL, em vez de aparecerem como os mais baratos.
+func sortProductsByPrice(products []models.Product, sortBy string) []models.Product {
+	type pricedProduct struct {
+		product models.Product
+		price   float64
+	}
+
+	var priced []pricedProduct
+	for _, product := range products {
+		if value, ok := parsePrice(getEffectivePrice(&product)); ok {
+			priced = append(priced, pricedProduct{product: product, price: value})
+		}
+	}
+
+	sort.SliceStable(priced, func(i, j int) bool {
+		if sortBy == "price_desc" {
+			return priced[i].price > priced[j].price
+		}
+		return priced[i].price < priced[j].price
+	})
+
+	result := make([]models.Product, 0, len(priced))
+	for _, item := range priced {
+		result = append(result, item.product)
+	}
+	return result
+}
+
+// searchProductsForQuery executa a busca do consultarItens pelo caminho definido no modo do tenant,
+// caindo para SQL quando o RAG não retorna resultados
+func (s *AIService) searchProductsForQuery(ctx context.Context, tenantID uuid.UUID, filters ProductSearchFilters) ([]models.Product, string, error) {
+	mode := s.getSearchMode(ctx, tenantID)
+	path := decideSearchPath(mode, filters.Query, filters.SortBy, s.embeddingService != nil)
+
+	var products []models.Product
+	servedBy := path
+
+	var err error
+	if path == searchPathRAG {
+		var staleIDs int
+		products, staleIDs = s.searchProductsRAG(ctx, tenantID, filters.Query, filters.Limit, !filters.IncludeOutOfStock)
+
+		// IDs do índice semântico que não existem mais no catálogo: completar com a busca SQL até o limite pedido
+		if len(products) > 0 && staleIDs > 0 && filters.Limit > 0 && len(products) < filters.Limit {
+			var sqlProducts []models.Product
+			sqlProducts, err = s.searchProductsAdvanced(ctx, tenantID, filters)
+			if err == nil {
+				before := len(products)
+				products = mergeProductResults(products, sqlProducts, filters.Limit)
+				servedBy = searchPathRAGTopUp
+				log.Info().
+					Str("tenant_id", tenantID.String()).
+					Int("stale_ids", staleIDs).
+					Int("rag_products", before).
+					Int("topped_up", len(products)-before).
+					Msg("🔍 RAG Top-up: resultados completados com a busca SQL")
+			} else {
+				// A busca semântica já trouxe produtos válidos: a falha do complemento não derruba a consulta
+				log.Warn().Err(err).Str("tenant_id", tenantID.String()).Msg("⚠️ Erro ao completar resultados do RAG com a busca SQL")
+				err = nil
+			}
+		}
+
+		if len(products) > 0 && (filters.SortBy == "price_asc" || filters.SortBy == "price_desc") {
+			products = sortProductsByPrice(products, filters.SortBy)
+		}
+	}
+
+	if len(products) == 0 {
+		if path == searchPathRAG {
+			servedBy = searchPathSQLFallback
+		}
+
+		log.Info().
+			Interface("filters", filters).
+			Str("tenant_id", tenantID.String()).
+			Msg("🔍 DEBUG: SearchProductsAdvanced filters")
+
+		products, err = s.searchProductsAdvanced(ctx, tenantID, filters)
+	}
+
+	log.Info().
+		Str("tenant_id", tenantID.String()).
+		Str("search_mode", mode).
+		Str("served_by", servedBy).
+		Str("query", filters.Query).
+		Str("sort_by", filters.SortBy).
+		Int("products_found", len(products)).
+		Err(err).
+		Msg("🔍 Busca de produtos atendida")
+
+	return products, servedBy, err
+}
+
+// mergeProductResults acrescenta os produtos extras que ainda não estão na lista, até o limite
+func mergeProductResults(products, extra []models.Product, limit int) []models.Product {
+	seen := make(map[uuid.UUID]bool, len(products))
+	for _, product := range products {
+		seen[product.ID] = true
+	}
+	for _, product := range extra {
+		if limit > 0 && len(products) >= limit {
+			break
+		}
+		if seen[product.ID] {
+			continue
+		}
+		seen[product.ID] = true
+		products = append(products, product)
+	}
+	return products
+}
+
+// searchProductsRAG busca produtos via embeddings e carrega os registros completos do banco.
+// Retorna também quantos IDs do índice semântico não existem mais no catálogo.
+func (s *AIService) searchProductsRAG(ctx context.Context, tenantID uuid.UUID, query string, limit int, inStockOnly bool) ([]models.Product, int) {
+	log.Info().Msgf("🔍 RAG Priority: Using semantic search for query='%s'", query)
+
+	ragResults, ragErr := s.embeddingService.SearchSimilarProducts(query, tenantID.String(), limit)
+	if ragErr == nil && len(ragResults) > 0 {
+		log.Info().Msgf("🔍 RAG Success: Found %d products via semantic search", len(ragResults))
+
+		// Resultados pouco parecidos com a busca são descartados; sem nenhum relevante, a busca SQL assume
+		minScore := s.getRAGMinScore(ctx, tenantID)
+		logRAGScoreDistribution(tenantID, query, ragResults, minScore)
+		ragResults = filterRAGResultsByScore(ragResults, minScore)
+		if len(ragResults) == 0 {
+			return nil, 0
+		}
+
+		// Converter ResultSet do RAG para []models.Product
+		productIDs := make([]uuid.UUID, 0, len(ragResults))
+		for _, result := range ragResults {
+			if productID, parseErr := uuid.Parse(result.ID); parseErr == nil {
+				productIDs = append(productIDs, productID)
+			}
+		}
+
+		// Buscar produtos completos usando os IDs encontrados pelo RAG
+		if len(productIDs) > 0 {
+			ragProducts := make([]models.Product, 0, len(productIDs))
+			failedProducts := 0
+			for _, productID := range productIDs {
+				if product, getErr := s.getProductByID(ctx, tenantID, productID); getErr == nil {
+					ragProducts = append(ragProducts, *product)
+				} else {
+					failedProducts++
+					log.Warn().
+						Err(getErr).
+						Str("product_id", productID.String()).
+						Str("tenant_id", tenantID.String()).
+						Msg("🚨 RAG Product Not Found: Product ID from RAG doesn't exist in database")
+				}
+			}
+
+			if failedProducts > 0 {
+				log.Warn().
+					Int("total_rag_results", len(productIDs)).
+					Int("failed_products", failedProducts).
+					Int("successful_products", len(ragProducts)).
+					Msg("🔍 RAG Sync Issue: Some RAG results not found in database")
+			}
+
+			// O índice semântico não conhece o estoque atual nem os produtos pausados pela loja
+			ragProducts = filterAvailableProducts(ragProducts)
+			if inStockOnly {
+				ragProducts = filterInStockProducts(ragProducts)
+			}
+
+			if len(ragProducts) > 0 {
+				log.Info().Msgf("🔍 RAG Complete: Successfully retrieved %d products", len(ragProducts))
+				return ragProducts, failedProducts
+			}
+		}
+	} else {
+		log.Warn().Err(ragErr).Msgf("🔍 RAG Failed: %v, falling back to database search", ragErr)
+	}
+
+	return nil, 0
+}
+
+// getRAGMinScore retorna a similaridade mínima dos resultados da busca semântica (0 = sem corte)
+func (s *AIService) getRAGMinScore(ctx context.Context, tenantID uuid.UUID) float32 {
+	if s.settingsService == nil {
+		return 0
+	}
+
+	setting, err := s.settingsService.GetSetting(ctx, tenantID, RAGMinScoreSettingKey)
+	if err != nil || setting == nil || setting.SettingValue == nil {
+		return 0
+	}
+
+	value, err := strconv.ParseFloat(strings.Replace(strings.TrimSpace(*setting.SettingValue), ",", ".", 1), 32)
+	if err != nil || value < 0 || value > 1 {
+		return 0
+	}
+	return float32(value)
+}
+
+// filterRAGResultsByScore descarta os resultados abaixo da similaridade mínima, mantendo a ordem do índice
+func filterRAGResultsByScore(results []ProductSearchResult, minScore float32) []ProductSearchResult {
+	if minScore <= 0 {
+		return results
+	}
+
+	filtered := make([]ProductSearchResult, 0, len(results))
+	for _, result := range results {
+		if result.Score >= minScore {
+			filtered = append(filtered, result)
+		}
+	}
+	return filtered
+}
+
+// logRAGScoreDistribution registra a faixa de similaridade dos resultados para calibrar o corte do tenant
+func logRAGScoreDistribution(tenantID uuid.UUID, query string, results []ProductSearchResult, minScore float32) {
+	if len(results) == 0 {
+		return
+	}
+
+	lowest, highest, sum := results[0].Score, results[0].Score, float32(0)
+	belowMin := 0
+	for _, result := range results {
+		if result.Score < lowest {
+			lowest = result.Score
+		}
+		if result.Score > highest {
+			highest = result.Score
+		}
+		sum += result.Score
+		if minScore > 0 && result.Score < minScore {
+			belowMin++
+		}
+	}
+
+	log.Info().
+		Str("tenant_id", tenantID.String()).
+		Str("query", query).
+		Int("results", len(results)).
+		Float32("score_min", lowest).
+		Float32("score_max", highest).
+		Float32("score_avg", sum/float32(len(results))).
+		Float32("min_score", minScore).
+		Int("below_min_score", belowMin).
+		Msg("🔍 RAG score distribution")
+}
@@ -0,0 +1,236 @@
+package ai
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"iafarma/pkg/models"
+
+	"github.com/google/uuid"
+)
+
+// fakeEmbeddingService devolve resultados semânticos fixos e conta as chamadas
+type fakeEmbeddingService struct {
+	EmbeddingServiceInterface
+	results []ProductSearchResult
+	calls   int
+}
+
+func (f *fakeEmbeddingService) SearchSimilarProducts(query, tenantID string, limit int) ([]ProductSearchResult, error) {
+	f.calls++
+	return f.results, nil
+}
+
+func newSearchModeTestProduct(name, price string) models.Product {
+	product := models.Product{Name: name, Price: price, StockQuantity: 10, Available: true}
+	product.ID = uuid.New()
+	return product
+}
+
+func TestDecideSearchPath(t *testing.T) {
+	tests := []struct {
+		mode          string
+		query         string
+		sortBy        string
+		hasEmbeddings bool
+		expected      string
+	}{
+		{searchModeHybrid, "dipirona", "relevance", true, searchPathRAG},
+		{searchModeHybrid, "dipirona", "price_asc", true, searchPathSQL},
+		{searchModeHybrid, "", "relevance", true, searchPathSQL},
+		{searchModeHybrid, "dipirona", "relevance", false, searchPathSQL},
+		{searchModeRAG, "dipirona", "price_desc", true, searchPathRAG},
+		{searchModeRAG, "dipirona", "relevance", false, searchPathSQL},
+		{searchModeSQL, "dipirona", "relevance", true, searchPathSQL},
+	}
+
+	for _, test := range tests {
+		if result := decideSearchPath(test.mode, test.query, test.sortBy, test.hasEmbeddings); result != test.expected {
+			t.Errorf("decideSearchPath(%q, %q, %q, %t) = %q, expected %q", test.mode, test.query, test.sortBy, test.hasEmbeddings, result, test.expected)
+		}
+	}
+}
+
+func TestSearchProductsForQueryModes(t *testing.T) {
+	cheap := newSearchModeTestProduct("Dipirona Genérica", "5.00")
+	expensive := newSearchModeTestProduct("Dipirona Marca", "15.00")
+	sqlResult := newSearchModeTestProduct("Dipirona SQL", "9.00")
+
+	tests := []struct {
+		name          string
+		mode          string
+		sortBy        string
+		ragResults    []ProductSearchResult
+		expectedPath  string
+		expectedFirst string
+		expectRAG     bool
+		expectSQL     bool
+	}{
+		{"hybrid text query uses rag", searchModeHybrid, "relevance", []ProductSearchResult{{ID: expensive.ID.String()}, {ID: cheap.ID.String()}}, searchPathRAG, "Dipirona Marca", true, false},
+		{"hybrid price sort uses sql", searchModeHybrid, "price_asc", []ProductSearchResult{{ID: cheap.ID.String()}}, searchPathSQL, "Dipirona SQL", false, true},
+		{"rag price sort sorts rag results", searchModeRAG, "price_asc", []ProductSearchResult{{ID: expensive.ID.String()}, {ID: cheap.ID.String()}}, searchPathRAG, "Dipirona Genérica", true, false},
+		{"rag without results falls back to sql", searchModeRAG, "relevance", nil, searchPathSQLFallback, "Dipirona SQL", true, true},
+		{"sql never calls rag", searchModeSQL, "relevance", []ProductSearchResult{{ID: cheap.ID.String()}}, searchPathSQL, "Dipirona SQL", false, true},
+		{"invalid mode behaves as hybrid", "semantica", "relevance", []ProductSearchResult{{ID: cheap.ID.String()}}, searchPathRAG, "Dipirona Genérica", true, false},
+	}
+
+	for _, test := range tests {
+		products := &fakeProductService{products: []models.Product{cheap, expensive}, advancedResult: []models.Product{sqlResult}}
+		embeddings := &fakeEmbeddingService{results: test.ragResults}
+		s := &AIService{
+			productService:   products,
+			embeddingService: embeddings,
+			settingsService:  &fakeSettingsService{values: map[string]string{SearchModeSettingKey: test.mode}},
+		}
+
+		result, servedBy, err := s.searchProductsForQuery(context.Background(), uuid.New(), ProductSearchFilters{Query: "dipirona", SortBy: test.sortBy, Limit: 10})
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", test.name, err)
+		}
+		if servedBy != test.expectedPath {
+			t.Errorf("%s: served by %q, expected %q", test.name, servedBy, test.expectedPath)
+		}
+		if len(result) == 0 || result[0].Name != test.expectedFirst {
+			t.Errorf("%s: first product = %v, expected %q", test.name, result, test.expectedFirst)
+		}
+		if (embeddings.calls > 0) != test.expectRAG {
+			t.Errorf("%s: rag called = %t, expected %t", test.name, embeddings.calls > 0, test.expectRAG)
+		}
+		if (products.advancedCalls > 0) != test.expectSQL {
+			t.Errorf("%s: sql called = %t, expected %t", test.name, products.advancedCalls > 0, test.expectSQL)
+		}
+	}
+}
+
+func TestSearchModeDefaultsToHybrid(t *testing.T) {
+	if mode := (&AIService{}).getSearchMode(context.Background(), uuid.New()); mode != searchModeHybrid {
+		t.Errorf("mode without settings service = %q, expected %q", mode, searchModeHybrid)
+	}
+	s := &AIService{settingsService: &fakeSettingsService{values: map[string]string{}}}
+	if mode := s.getSearchMode(context.Background(), uuid.New()); mode != searchModeHybrid {
+		t.Errorf("mode without setting = %q, expected %q", mode, searchModeHybrid)
+	}
+}
+
+func TestSearchProductsRAGTopsUpStaleIDs(t *testing.T) {
+	first := newSearchModeTestProduct("Dipirona Gotas", "8.00")
+	second := newSearchModeTestProduct("Dipirona Comprimido", "6.00")
+	third := newSearchModeTestProduct("Dipirona Infantil", "9.00")
+	fourth := newSearchModeTestProduct("Dipirona Monoidratada", "7.00")
+	stale := []ProductSearchResult{{ID: uuid.New().String()}, {ID: uuid.New().String()}}
+
+	tests := []struct {
+		name          string
+		ragResults    []ProductSearchResult
+		limit         int
+		expectedPath  string
+		expectedNames []string
+		expectSQL     bool
+	}{
+		{"stale ids are topped up without duplicates", append([]ProductSearchResult{{ID: first.ID.String()}}, stale...), 3, searchPathRAGTopUp, []string{"Dipirona Gotas", "Dipirona Comprimido", "Dipirona Infantil"}, true},
+		{"top-up respects the limit", append([]ProductSearchResult{{ID: first.ID.String()}}, stale...), 2, searchPathRAGTopUp, []string{"Dipirona Gotas", "Dipirona Comprimido"}, true},
+		{"no stale ids keeps rag results", []ProductSearchResult{{ID: first.ID.String()}}, 3, searchPathRAG, []string{"Dipirona Gotas"}, false},
+		{"only stale ids falls back to sql", stale, 3, searchPathSQLFallback, []string{"Dipirona Gotas", "Dipirona Comprimido", "Dipirona Infantil", "Dipirona Monoidratada"}, true},
+	}
+
+	for _, test := range tests {
+		products := &fakeProductService{
+			products:       []models.Product{first, second, third, fourth},
+			advancedResult: []models.Product{first, second, third, fourth},
+		}
+		s := &AIService{
+			productService:   products,
+			embeddingService: &fakeEmbeddingService{results: test.ragResults},
+			settingsService:  &fakeSettingsService{values: map[string]string{SearchModeSettingKey: searchModeRAG}},
+		}
+
+		result, servedBy, err := s.searchProductsForQuery(context.Background(), uuid.New(), ProductSearchFilters{Query: "dipirona", SortBy: "relevance", Limit: test.limit})
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", test.name, err)
+		}
+		if servedBy != test.expectedPath {
+			t.Errorf("%s: served by %q, expected %q", test.name, servedBy, test.expectedPath)
+		}
+		var names []string
+		for _, product := range result {
+			names = append(names, product.Name)
+		}
+		if strings.Join(names, ",") != strings.Join(test.expectedNames, ",") {
+			t.Errorf("%s: products = %v, expected %v", test.name, names, test.expectedNames)
+		}
+		if (products.advancedCalls > 0) != test.expectSQL {
+			t.Errorf("%s: sql called = %t, expected %t", test.name, products.advancedCalls > 0, test.expectSQL)
+		}
+	}
+}
+
+func TestFilterRAGResultsByScore(t *testing.T) {
+	results := []ProductSearchResult{{ID: "a", Score: 0.91}, {ID: "b", Score: 0.42}, {ID: "c", Score: 0.75}}
+
+	tests := []struct {
+		name     string
+		minScore float32
+		expected string
+	}{
+		{"zero keeps every result", 0, "a,b,c"},
+		{"threshold drops low scores keeping order", 0.7, "a,c"},
+		{"score equal to threshold is kept", 0.42, "a,b,c"},
+		{"threshold above every score empties the list", 0.95, ""},
+	}
+
+	for _, test := range tests {
+		var ids []string
+		for _, result := range filterRAGResultsByScore(results, test.minScore) {
+			ids = append(ids, result.ID)
+		}
+		if strings.Join(ids, ",") != test.expected {
+			t.Errorf("%s: ids = %v, expected %q", test.name, ids, test.expected)
+		}
+	}
+}
+
+func TestSearchProductsRAGMinScore(t *testing.T) {
+	relevant := newSearchModeTestProduct("Dipirona Gotas", "8.00")
+	unrelated := newSearchModeTestProduct("Shampoo Anticaspa", "22.00")
+	sqlResult := newSearchModeTestProduct("Dipirona SQL", "9.00")
+	ragResults := []ProductSearchResult{{ID: relevant.ID.String(), Score: 0.82}, {ID: unrelated.ID.String(), Score: 0.31}}
+
+	tests := []struct {
+		name          string
+		minScore      string
+		expectedPath  string
+		expectedNames []string
+	}{
+		{"no threshold keeps low scores", "0", searchPathRAG, []string{"Dipirona Gotas", "Shampoo Anticaspa"}},
+		{"threshold drops irrelevant products", "0.5", searchPathRAG, []string{"Dipirona Gotas"}},
+		{"threshold emptying the list falls back to sql", "0.9", searchPathSQLFallback, []string{"Dipirona SQL"}},
+		{"invalid threshold is ignored", "alto", searchPathRAG, []string{"Dipirona Gotas", "Shampoo Anticaspa"}},
+	}
+
+	for _, test := range tests {
+		s := &AIService{
+			productService:   &fakeProductService{products: []models.Product{relevant, unrelated}, advancedResult: []models.Product{sqlResult}},
+			embeddingService: &fakeEmbeddingService{results: ragResults},
+			settingsService: &fakeSettingsService{values: map[string]string{
+				SearchModeSettingKey:  searchModeRAG,
+				RAGMinScoreSettingKey: test.minScore,
+			}},
+		}
+
+		result, servedBy, err := s.searchProductsForQuery(context.Background(), uuid.New(), ProductSearchFilters{Query: "dipirona", SortBy: "relevance", Limit: 10})
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", test.name, err)
+		}
+		if servedBy != test.expectedPath {
+			t.Errorf("%s: served by %q, expected %q", test.name, servedBy, test.expectedPath)
+		}
+		var names []string
+		for _, product := range result {
+			names = append(names, product.Name)
+		}
+		if strings.Join(names, ",") != strings.Join(test.expectedNames, ",") {
+			t.Errorf("%s: products = %v, expected %v", test.name, names, test.expectedNames)
+		}
+	}
+}
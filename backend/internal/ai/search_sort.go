@@ -0,0 +1,59 @@
+package ai
+
+import (
+	"context"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// DefaultSearchSortSettingKey define a ordenação das buscas quando o cliente não pede uma:
+	// "relevance" (padrão), "price_asc" (mais barato primeiro), "price_desc" ou "name_asc" (alfabética)
+	DefaultSearchSortSettingKey = "ai_default_search_sort"
+
+	defaultSearchSort = "relevance"
+)
+
+// searchSortByArg converte o parâmetro ordenar_por da ferramenta para o SortBy interno
+var searchSortByArg = map[string]string{
+	"preco_menor": "price_asc",
+	"preco_maior": "price_desc",
+	"nome":        "name_asc",
+	"relevancia":  "relevance",
+}
+
+// isValidSearchSort indica se o valor é um SortBy aceito pela busca de produtos
+func isValidSearchSort(sortBy string) bool {
+	for _, valid := range searchSortByArg {
+		if sortBy == valid {
+			return true
+		}
+	}
+	return false
+}
+
+// getDefaultSearchSort retorna a ordenação padrão configurada pelo tenant (relevância se não configurada ou inválida)
+func (s *AIService) getDefaultSearchSort(ctx context.Context, tenantID uuid.UUID) string {
+	if s.settingsService == nil {
+		return defaultSearchSort
+	}
+
+	setting, err := s.settingsService.GetSetting(ctx, tenantID, DefaultSearchSortSettingKey)
+	if err != nil || setting == nil || setting.SettingValue == nil {
+		return defaultSearchSort
+	}
+
+	if sortBy := strings.ToLower(strings.TrimSpace(*setting.SettingValue)); isValidSearchSort(sortBy) {
+		return sortBy
+	}
+	return defaultSearchSort
+}
+
+// resolveSearchSort usa a ordenação pedida explicitamente (ordenar_por) e, na falta dela, o padrão do tenant
+func (s *AIService) resolveSearchSort(ctx context.Context, tenantID uuid.UUID, ordenarPor string) string {
+	if sortBy, ok := searchSortByArg[strings.ToLower(strings.TrimSpace(ordenarPor))]; ok {
+		return sortBy
+	}
+	return s.getDefaultSearchSort(ctx, tenantID)
+}
@@ -0,0 +1,81 @@
+package ai
+
+import (
+	"context"
+	"testing"
+
+	"iafarma/pkg/models"
+
+	"github.com/google/uuid"
+)
+
+// sortRecordingProductService registra a ordenação usada nas buscas
+type sortRecordingProductService struct {
+	fakeProductService
+	sortBy []string
+}
+
+func (f *sortRecordingProductService) SearchProductsAdvanced(tenantID uuid.UUID, filters ProductSearchFilters) ([]models.Product, error) {
+	f.sortBy = append(f.sortBy, filters.SortBy)
+	return []models.Product{newPricedTestProduct("Dipirona 500mg", "12.90")}, nil
+}
+
+func TestResolveSearchSort(t *testing.T) {
+	tests := []struct {
+		name       string
+		tenantSort string
+		ordenarPor string
+		esperado   string
+	}{
+		{"sem configuração usa relevância", "", "", "relevance"},
+		{"padrão do tenant por preço", "price_asc", "", "price_asc"},
+		{"padrão do tenant alfabético", "name_asc", "", "name_asc"},
+		{"pedido explícito sobrepõe o padrão", "price_asc", "nome", "name_asc"},
+		{"relevância explícita sobrepõe o padrão", "price_asc", "relevancia", "relevance"},
+		{"ordem desconhecida usa o padrão", "price_asc", "mais_novo", "price_asc"},
+		{"configuração inválida usa relevância", "aleatorio", "", "relevance"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			values := map[string]string{}
+			if tt.tenantSort != "" {
+				values[DefaultSearchSortSettingKey] = tt.tenantSort
+			}
+			s := &AIService{settingsService: &fakeSettingsService{values: values}}
+
+			if obtido := s.resolveSearchSort(context.Background(), uuid.New(), tt.ordenarPor); obtido != tt.esperado {
+				t.Errorf("esperado %q, obtido %q", tt.esperado, obtido)
+			}
+		})
+	}
+}
+
+func TestConsultarItensAplicaOrdenacaoPadraoDoTenant(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     map[string]interface{}
+		esperado string
+	}{
+		{"sem ordem pedida", map[string]interface{}{"query": "dipirona"}, "price_asc"},
+		{"ordem pedida pelo cliente", map[string]interface{}{"query": "dipirona", "ordenar_por": "preco_maior"}, "price_desc"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			products := &sortRecordingProductService{}
+			s := &AIService{
+				settingsService: &fakeSettingsService{values: map[string]string{DefaultSearchSortSettingKey: "price_asc"}},
+				productService:  products,
+				memoryManager:   NewMemoryManager(),
+			}
+
+			if _, err := s.handleConsultarItens(context.Background(), uuid.New(), uuid.New(), "5527999999999", tt.args); err != nil {
+				t.Fatalf("erro inesperado: %v", err)
+			}
+			if len(products.sortBy) == 0 || products.sortBy[0] != tt.esperado {
+				t.Errorf("esperado busca ordenada por %q, obtido %v", tt.esperado, products.sortBy)
+			}
+		})
+	}
+}
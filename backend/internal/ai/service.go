@@ -5,6 +5,7 @@ import (
 	"context"
 	"crypto/tls"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"iafarma/pkg/models"
 	"io"
@@ -42,11 +43,37 @@ type AIService struct {
 	alertService     AlertServiceInterface
 	deliveryService  DeliveryServiceInterface
 	embeddingService EmbeddingServiceInterface
-	s3Client         *s3.S3
-	s3Bucket         string
-	s3BaseURL        string
+	faqService       FAQServiceInterface
+	// Ferramentas próprias do tenant despachadas para webhooks (nil customToolClient usa o cliente isolado)
+	customToolService CustomToolServiceInterface
+	customToolClient  *http.Client
+	// Controle de pausa do bot quando um atendente assume a conversa
+	conversationService ConversationServiceInterface
+	// Registro das ferramentas executadas para as métricas por tenant
+	toolMetricsService   ToolMetricsServiceInterface
+	missingDemandService MissingDemandServiceInterface
+	subscriptionService  SubscriptionServiceInterface
+	couponService        CouponServiceInterface
+	returnRequestService ReturnRequestServiceInterface
+	s3Client             *s3.S3
+	s3Bucket             string
+	s3BaseURL            string
+	// Contador de respostas sem sucesso consecutivas por sessão
+	unhelpfulTracker *UnhelpfulResponseTracker
+	// Resumo de conversas longas (nil usa o modelo da OpenAI)
+	summarizer conversationSummarizer
+	// Análise de fotos de produtos (nil usa o GPT-4 Vision)
+	imageAnalyzer imageAnalyzer
 	// Map temporário para armazenar conversationID por sessão
 	conversationContext sync.Map
+	// Elementos interativos (botões/mídia) registrados pelos handlers para a resposta atual
+	pendingResponses sync.Map
+	// Última adição ao carrinho por cliente, para o "desfazer" (chave tenant-cliente)
+	lastCartAdds sync.Map
+	// Produtos removidos há pouco pelo cliente (chave tenant-cliente-produto), para confirmar antes de readicionar
+	recentRemovals sync.Map
+	// Readição aguardando a confirmação do cliente (chave tenant-cliente)
+	pendingReadds sync.Map
 	// Armazenar resultados de funções da última execução
 	lastFunctionResults  []ToolExecutionResult
 	functionResultsMutex sync.RWMutex
@@ -59,17 +86,34 @@ type CartServiceInterface interface {
 	RemoveItemFromCart(cartID, tenantID, itemID uuid.UUID) error
 	ClearCart(cartID, tenantID uuid.UUID) error
 	UpdateCartItemQuantity(cartID, tenantID, itemID uuid.UUID, quantity int) error
+	MergeCartItems(cartID, tenantID, keepItemID uuid.UUID, duplicateItemIDs []uuid.UUID) error
 	GetCartWithItems(cartID, tenantID uuid.UUID) (*models.Cart, error)
 	UpdateCartPaymentMethod(cartID, tenantID, paymentMethodID uuid.UUID) error
 	UpdateCartObservations(cartID, tenantID uuid.UUID, observations, changeFor string) error
+	ConfirmCartAge(cartID, tenantID uuid.UUID, confirmedAt time.Time) error
+	SetCartPickup(cartID, tenantID uuid.UUID, pickup bool) error
+	AttachCartPrescription(cartID, tenantID uuid.UUID, imageURL string) error
+	UpdateCartInstallments(cartID, tenantID uuid.UUID, installments int) error
+	SetCartUrgent(cartID, tenantID uuid.UUID, urgent bool) error
+	SetCartDeposit(cartID, tenantID uuid.UUID, payDeposit bool) error
+	SetCartItemAddress(cartID, tenantID, itemID uuid.UUID, addressID *uuid.UUID) error
 }
 
 type OrderServiceInterface interface {
 	CreateOrderFromCart(tenantID, cartID uuid.UUID) (*models.Order, error)
 	CreateOrderFromCartWithAddress(tenantID, cartID uuid.UUID, deliveryAddress *models.Address) (*models.Order, error)
 	CreateOrderFromCartWithConversation(tenantID, cartID, conversationID uuid.UUID, deliveryAddress *models.Address) (*models.Order, error)
+	// CreateOrdersFromCartGroups cria um pedido por grupo de itens do carrinho (entrega dividida por endereço), todos
+	// na mesma transação: se um falhar, nenhum é criado
+	CreateOrdersFromCartGroups(tenantID, cartID, conversationID uuid.UUID, groups []CartOrderGroup) ([]*models.Order, error)
 	GetOrdersByCustomer(tenantID, customerID uuid.UUID) ([]models.Order, error)
+	GetOrderByID(tenantID, orderID uuid.UUID) (*models.Order, error)
 	CancelOrder(tenantID, orderID uuid.UUID) error
+	ApplyShippingAmount(tenantID, orderID uuid.UUID, shippingAmount float64) (*models.Order, error)
+	ApplyInstallmentPlan(tenantID, orderID uuid.UUID, installments int, installmentAmount float64) (*models.Order, error)
+	MarkOrderForReview(tenantID, orderID uuid.UUID) error
+	ApplyUrgency(tenantID, orderID uuid.UUID, urgencyFee float64) (*models.Order, error)
+	ApplyDeposit(tenantID, orderID uuid.UUID, depositMethod string, amountPaid, amountDue float64) (*models.Order, error)
 	GetPaymentOptions(tenantID uuid.UUID) ([]PaymentOption, error)
 }
 
@@ -81,6 +125,12 @@ type ProductServiceInterface interface {
 	GetAllTenants() ([]models.Tenant, error)
 	GetTenantByID(tenantID uuid.UUID) (*models.Tenant, error)
 	GetProductsByTenantID(tenantID uuid.UUID) ([]models.Product, error)
+	GetProductImageURLs(tenantID uuid.UUID, productIDs []uuid.UUID) (map[uuid.UUID]string, error)
+	GetProductAccessories(tenantID, productID uuid.UUID) ([]models.ProductAccessory, error)
+	GetProductsByCategory(tenantID, categoryID uuid.UUID, limit int) ([]models.Product, error)
+	GetBestSellingProducts(tenantID uuid.UUID, limit int) ([]models.Product, error)
+	GetProductsChangedSince(tenantID uuid.UUID, since time.Time, limit int) ([]models.Product, error)
+	GetProductSalesSince(tenantID uuid.UUID, since time.Time) ([]ProductSales, error)
 }
 
 // ProductSearchFilters represents advanced search filters
@@ -92,12 +142,21 @@ type ProductSearchFilters struct {
 	MaxPrice float64
 	Limit    int
 	SortBy   string // "price_asc", "price_desc", "name_asc", "name_desc", "relevance"
+	// CategoryID restringe a busca a uma categoria (ex: alternativas a um produto esgotado)
+	CategoryID *uuid.UUID
+	// IncludeOutOfStock inclui produtos com estoque zerado (por padrão apenas itens em estoque são retornados)
+	IncludeOutOfStock bool
+	// IncludeUnavailable inclui produtos pausados pela loja (usado só para avisar que estão indisponíveis)
+	IncludeUnavailable bool
 }
 
 type CustomerServiceInterface interface {
 	UpdateCustomerProfile(tenantID, customerID uuid.UUID, data CustomerUpdateData) error
-	GetCustomerByPhone(tenantID uuid.UUID, phone string) (*models.Customer, error)
+	GetOrCreateCustomerByPhone(tenantID uuid.UUID, phone string) (*models.Customer, error)
 	GetCustomerByID(tenantID, customerID uuid.UUID) (*models.Customer, error)
+	MarkCustomerWelcomed(tenantID, customerID uuid.UUID, welcomedAt time.Time) error
+	MarkCustomerSeen(tenantID, customerID uuid.UUID, seenAt time.Time, previousVisitAt *time.Time) error
+	SetCustomerLanguage(tenantID, customerID uuid.UUID, language string) error
 }
 
 type MessageServiceInterface interface {
@@ -136,6 +195,8 @@ type CategoryServiceInterface interface {
 type AlertServiceInterface interface {
 	SendOrderAlert(tenantID uuid.UUID, order *models.Order, customerPhone string) error
 	SendHumanSupportAlert(tenantID uuid.UUID, customerID uuid.UUID, customerPhone string, reason string) error
+	SendSpecialOrderAlert(tenantID uuid.UUID, customerID uuid.UUID, customerPhone string, description string) error
+	SendReturnRequestAlert(tenantID uuid.UUID, customerID uuid.UUID, customerPhone string, description string) error
 }
 
 type DeliveryServiceInterface interface {
@@ -143,6 +204,47 @@ type DeliveryServiceInterface interface {
 	GetStoreLocation(tenantID uuid.UUID) (*StoreLocationInfo, error)
 }
 
+type FAQServiceInterface interface {
+	GetActiveFAQs(tenantID uuid.UUID) ([]models.FAQEntry, error)
+}
+
+type CustomToolServiceInterface interface {
+	GetActiveCustomTools(tenantID uuid.UUID) ([]models.TenantCustomTool, error)
+}
+
+type ToolMetricsServiceInterface interface {
+	RecordToolExecutions(tenantID, customerID, conversationID uuid.UUID, results []ToolExecutionResult) error
+}
+
+type MissingDemandServiceInterface interface {
+	RecordMissingDemand(demand *models.MissingProductDemand) error
+	RequestRestockNotification(demand *models.MissingProductDemand) error
+	RecordSpecialOrder(demand *models.MissingProductDemand) error
+}
+
+type SubscriptionServiceInterface interface {
+	CreateSubscription(subscription *models.Subscription) error
+	GetSubscriptionsByCustomer(tenantID, customerID uuid.UUID) ([]models.Subscription, error)
+	UpdateSubscriptionStatus(tenantID, subscriptionID uuid.UUID, status string, nextRunAt time.Time) error
+	ResolvePendingOrder(tenantID, subscriptionID uuid.UUID, confirm bool, deliveryAddress *models.Address) (*models.Order, error)
+}
+
+type CouponServiceInterface interface {
+	GetCouponByCode(tenantID uuid.UUID, code string) (*models.Coupon, error)
+}
+
+type ReturnRequestServiceInterface interface {
+	CreateReturnRequest(request *models.ReturnRequest) error
+	// GetOpenReturnRequest retorna a solicitação ainda em análise do pedido (nil quando não há)
+	GetOpenReturnRequest(tenantID, orderID uuid.UUID) (*models.ReturnRequest, error)
+}
+
+type ConversationServiceInterface interface {
+	IsBotPaused(tenantID, conversationID uuid.UUID) (bool, error)
+	SetBotPaused(tenantID, conversationID uuid.UUID, paused bool) error
+	GetBusinessOpening(tenantID, conversationID uuid.UUID) (*BusinessOpening, error)
+}
+
 type EmbeddingServiceInterface interface {
 	SearchSimilarProducts(query, tenantID string, limit int) ([]ProductSearchResult, error)
 	SearchConversations(tenantID, customerID, query string, limit int) ([]ConversationSearchResult, error)
@@ -221,13 +323,15 @@ type CustomerUpdateData struct {
 
 // Estrutura para o resultado do parsing de endereço via IA
 type AIAddressParsing struct {
-	Street       string `json:"rua"`
-	Number       string `json:"numero"`
-	Complement   string `json:"complemento"`
-	Neighborhood string `json:"bairro"`
-	City         string `json:"cidade"`
-	State        string `json:"estado"`
-	ZipCode      string `json:"cep"`
+	Street         string `json:"rua"`
+	Number         string `json:"numero"`
+	Complement     string `json:"complemento"`
+	Apartment      string `json:"apartamento"`
+	ReferencePoint string `json:"referencia"`
+	Neighborhood   string `json:"bairro"`
+	City           string `json:"cidade"`
+	State          string `json:"estado"`
+	ZipCode        string `json:"cep"`
 }
 
 // Tool function definitions for OpenAI
@@ -296,6 +400,10 @@ func NewAIService(apiKey string, cartService CartServiceInterface, orderService
 		log.Warn().Msg("S3 configuration missing, storage features disabled")
 	}
 
+	if deliveryService != nil {
+		deliveryService = NewResilientDeliveryService(deliveryService)
+	}
+
 	return &AIService{
 		client:           client,
 		cartService:      cartService,
@@ -312,6 +420,7 @@ func NewAIService(apiKey string, cartService CartServiceInterface, orderService
 		s3Client:         s3Client,
 		s3Bucket:         s3Bucket,
 		s3BaseURL:        s3BaseURL,
+		unhelpfulTracker: NewUnhelpfulResponseTracker(),
 	}
 }
 
@@ -332,11 +441,15 @@ REGRAS IMPORTANTES:
 3. Para campos não encontrados, retorne string vazia
 4. CEP deve conter apenas números (remova hífens e espaços)
 5. Estado deve ser a sigla de 2 letras (ex: ES, SP, RJ)
-6. Números de apartamento/complemento devem ir no campo "complemento"
+6. Bloco, apartamento e torre vão no campo "apartamento" (ex: "Bloco B, apto 302")
+7. Ponto de referência (próximo a, em frente a, ao lado de...) vai no campo "referencia", sem o rótulo "referência"
+8. Demais complementos (casa, fundos, andar, loja) vão no campo "complemento"
 
 EXEMPLOS:
 - "Avenida Hugo Musso, número 1333, no bairro Praia da Costa, Vila Velha Espírito Santo. O CEP lá é 29-101-280, no apartamento 300"
-  → rua: "Avenida Hugo Musso", numero: "1333", bairro: "Praia da Costa", cidade: "Vila Velha", estado: "ES", cep: "29101280", complemento: "apartamento 300"
+  → rua: "Avenida Hugo Musso", numero: "1333", bairro: "Praia da Costa", cidade: "Vila Velha", estado: "ES", cep: "29101280", apartamento: "apartamento 300"
+- "Rua Sete de Setembro, 45, bloco B apto 302, Centro, Vitória ES, referência: em frente à padaria Pão Quente"
+  → rua: "Rua Sete de Setembro", numero: "45", apartamento: "bloco B apto 302", referencia: "em frente à padaria Pão Quente", bairro: "Centro", cidade: "Vitória", estado: "ES"
 
 Extraia os campos do endereço fornecido usando a função disponível.`
 
@@ -372,7 +485,15 @@ Extraia os campos do endereço fornecido usando a função disponível.`
 						},
 						"complemento": map[string]interface{}{
 							"type":        "string",
-							"description": "Apartamento, casa, bloco, andar, etc. (ex: 'apartamento 300', 'casa 2')",
+							"description": "Complemento que não seja bloco/apartamento nem referência (ex: 'casa 2', 'fundos', 'loja 3')",
+						},
+						"apartamento": map[string]interface{}{
+							"type":        "string",
+							"description": "Bloco, torre e/ou apartamento (ex: 'Bloco B, apto 302')",
+						},
+						"referencia": map[string]interface{}{
+							"type":        "string",
+							"description": "Ponto de referência para o entregador (ex: 'em frente à padaria')",
 						},
 						"bairro": map[string]interface{}{
 							"type":        "string",
@@ -429,6 +550,19 @@ Extraia os campos do endereço fornecido usando a função disponível.`
 		return nil, fmt.Errorf("erro ao fazer parse do JSON do endereço: %w", err)
 	}
 
+	// Apto/bloco e referência que vierem misturados no complemento são separados
+	splitAddressComplement(&parsedAddress)
+
+	// Garante a sigla da UF: nomes completos viram sigla e valores desconhecidos são descartados
+	if uf, ok := normalizeBrazilianUF(parsedAddress.State); ok {
+		parsedAddress.State = uf
+	} else {
+		if parsedAddress.State != "" {
+			log.Warn().Str("state", parsedAddress.State).Msg("⚠️ Estado inválido no endereço extraído pela IA")
+		}
+		parsedAddress.State = ""
+	}
+
 	log.Info().
 		Interface("parsed_address", parsedAddress).
 		Msg("✅ Address parsed successfully with AI")
@@ -572,8 +706,20 @@ func (s *AIService) ProcessMessageWithConversation(ctx context.Context, tenantID
 		log.Info().Str("session_key", sessionKey).Str("conversation_id", conversationID.String()).Msg("📝 Stored conversation ID for session")
 	}
 
+	// 📸 Configurações do tenant lidas uma única vez: toda a resposta usa os mesmos valores
+	ctx = s.withSettingsSnapshot(ctx, tenantID)
+
+	// 🤫 Atendente humano assumiu a conversa: o bot não responde
+	if s.isBotPaused(tenantID, conversationID) {
+		log.Info().
+			Str("tenant_id", tenantID.String()).
+			Str("conversation_id", conversationID.String()).
+			Msg("🤫 Bot pausado para a conversa - mensagem deixada para o atendente")
+		return "", nil
+	}
+
 	// Buscar ou criar cliente
-	customer, err := s.customerService.GetCustomerByPhone(tenantID, customerPhone)
+	customer, err := s.customerService.GetOrCreateCustomerByPhone(tenantID, customerPhone)
 	if err != nil {
 		log.Error().
 			Err(err).
@@ -588,14 +734,47 @@ func (s *AIService) ProcessMessageWithConversation(ctx context.Context, tenantID
 		Str("customer_name", customer.Name).
 		Msg("Customer found/created successfully")
 
+	// 🌅 Cliente voltou depois de muito tempo: contexto antigo descartado e boas-vindas de novo
+	freshStart := s.resetStaleConversation(ctx, tenantID, customer, customerPhone)
+
+	// 👣 Registrar a visita para calcular as novidades desde a última visita
+	lastActivityAt := customer.LastSeenAt
+	s.trackCustomerVisit(tenantID, customer)
+
+	// 🌐 Pedido explícito de troca de idioma ("English please") vale para esta e as próximas mensagens
+	s.applyLanguageSwitch(tenantID, customer, message)
+	language := s.customerLanguage(ctx, tenantID, customer)
+
 	// Obter histórico da conversa para manter contexto
 	conversationHistory := s.memoryManager.GetConversationHistory(tenantID, customerPhone)
 
-	// 🎯 NOVA LÓGICA: Verificar se é a primeira mensagem e se é uma saudação simples
-	isFirstMessage := len(conversationHistory) == 0
+	// 📣 Conversa aberta pela loja (campanha, follow-up): a resposta do cliente segue o contexto, sem boas-vindas
+	businessInitiated := s.applyBusinessOpening(ctx, tenantID, customerPhone, conversationID, len(conversationHistory))
+	if businessInitiated {
+		conversationHistory = s.memoryManager.GetConversationHistory(tenantID, customerPhone)
+	}
+
+	// 🎯 NOVA LÓGICA: Verificar se a boas-vindas é devida (registro do cliente) e se é uma saudação simples
+	isWelcomeDue := !businessInitiated && (freshStart || s.isWelcomeDue(ctx, tenantID, customer, lastActivityAt, len(conversationHistory)))
 	isSimpleGreeting := s.isSimpleGreeting(message)
 
-	if isFirstMessage && isSimpleGreeting {
+	// 🛒 Primeira mensagem de uma nova sessão com itens no carrinho: oferecer continuar ou esvaziar
+	returningCart := s.returningCartOnFirstMessage(tenantID, customer.ID, len(conversationHistory), freshStart, businessInitiated)
+	if returningCart != nil && isSimpleGreeting {
+		offer := formatReturningCartOffer(returningCart, language)
+		s.memoryManager.AddToConversationHistory(tenantID, customerPhone, openai.ChatCompletionMessage{
+			Role:    openai.ChatMessageRoleUser,
+			Content: message,
+		})
+		s.memoryManager.AddToConversationHistory(tenantID, customerPhone, openai.ChatCompletionMessage{
+			Role:    openai.ChatMessageRoleAssistant,
+			Content: offer,
+		})
+		s.markCustomerWelcomed(tenantID, customer)
+		return offer, nil
+	}
+
+	if isWelcomeDue && isSimpleGreeting {
 		log.Info().
 			Str("tenant_id", tenantID.String()).
 			Str("customer_phone", customerPhone).
@@ -609,13 +788,19 @@ func (s *AIService) ProcessMessageWithConversation(ctx context.Context, tenantID
 			// Loja fechada - resposta específica
 			log.Info().Msg("🕐 Store is closed - sending hours information in greeting")
 			welcomeMessage = s.generateClosedStoreGreeting(hoursInfo)
+		} else if language != s.tenantDefaultLanguage(ctx, tenantID) {
+			// Cliente escolheu outro idioma - boas-vindas do catálogo no idioma dele
+			welcomeMessage = catalogMessage(language, "welcome")
+		} else if greeting := s.returningCustomerGreeting(ctx, tenantID, customer); greeting != "" {
+			// Cliente que já comprou - saudação personalizada de retorno
+			welcomeMessage = greeting
 		} else {
 			// Loja aberta - mensagem normal de boas-vindas
 			var err error
 			welcomeMessage, err = s.settingsService.GetWelcomeMessage(ctx, tenantID)
 			if err != nil {
 				log.Error().Err(err).Msg("Failed to get welcome message, using fallback")
-				welcomeMessage = "Oi! Como posso ajudar você hoje?"
+				welcomeMessage = catalogMessage(language, "welcome")
 			}
 		}
 
@@ -629,20 +814,42 @@ func (s *AIService) ProcessMessageWithConversation(ctx context.Context, tenantID
 			Content: welcomeMessage,
 		})
 
+		s.markCustomerWelcomed(tenantID, customer)
+
 		return welcomeMessage, nil
 	}
 
+	// 🔢 Número isolado ("2"): encaminhar direto conforme a lista que o cliente está vendo
+	if response, handled := s.routeNumericReply(ctx, tenantID, customer.ID, customerPhone, message); handled {
+		s.memoryManager.AddToConversationHistory(tenantID, customerPhone, openai.ChatCompletionMessage{
+			Role:    openai.ChatMessageRoleUser,
+			Content: message,
+		})
+		s.memoryManager.AddToConversationHistory(tenantID, customerPhone, openai.ChatCompletionMessage{
+			Role:    openai.ChatMessageRoleAssistant,
+			Content: response,
+		})
+		return response, nil
+	}
+
+	// 📝 Resumir turnos antigos quando o histórico fica longo (opcional por tenant)
+	systemPrompt := withReturningCartNotice(withLanguageInstruction(s.getSystemPrompt(ctx, customer), language), returningCart)
+	if s.isConversationSummaryEnabled(ctx, tenantID) {
+		conversationHistory = s.summarizeConversationIfNeeded(ctx, tenantID, customerPhone, conversationHistory)
+		systemPrompt = withConversationSummary(systemPrompt, s.memoryManager.GetConversationSummary(tenantID, customerPhone))
+	}
+
 	// Preparar mensagens para o chat incluindo o contexto
 	messages := []openai.ChatCompletionMessage{
 		{
 			Role:    openai.ChatMessageRoleSystem,
-			Content: s.getSystemPrompt(customer),
+			Content: systemPrompt,
 		},
 	}
 
 	// Adicionar histórico das últimas 3 interações para contexto
 	if len(conversationHistory) > 0 {
-		historyLimit := 6 // 3 pares de pergunta/resposta
+		historyLimit := conversationHistoryWindow // 3 pares de pergunta/resposta
 		startIndex := 0
 		if len(conversationHistory) > historyLimit {
 			startIndex = len(conversationHistory) - historyLimit
@@ -672,7 +879,7 @@ func (s *AIService) ProcessMessageWithConversation(ctx context.Context, tenantID
 
 	// // Enriquecer o prompt do sistema com contexto RAG se disponível
 	// if productContext != "" {
-	// 	enhancedSystemPrompt := s.getSystemPrompt(customer)
+	// 	enhancedSystemPrompt := s.getSystemPrompt(ctx, customer)
 
 	// 	if productContext != "" {
 	// 		enhancedSystemPrompt += "\n\n" + productContext
@@ -690,8 +897,8 @@ func (s *AIService) ProcessMessageWithConversation(ctx context.Context, tenantID
 	// ABORDAGEM SIMPLIFICADA: UMA ÚNICA CHAMADA PARA A IA, SEM PATTERN MATCHING
 	// Deixar a IA decidir quais ferramentas usar baseado em compreensão natural
 
-	// Definir tools disponíveis
-	tools := s.getAvailableTools()
+	// Definir tools disponíveis (nativas + próprias do tenant)
+	tools := s.getAvailableToolsForTenant(tenantID)
 
 	log.Info().
 		Int("tools_count", len(tools)).
@@ -699,15 +906,14 @@ func (s *AIService) ProcessMessageWithConversation(ctx context.Context, tenantID
 		Msg("Making SINGLE OpenAI API call - trusting AI to understand naturally")
 
 	req := openai.ChatCompletionRequest{
-		Model:               openai.GPT4oMini, // Modelo mais atual e inteligente
 		Messages:            messages,
 		Tools:               tools,
 		ToolChoice:          "auto",
 		MaxCompletionTokens: 8000,
 	}
 
-	// Fazer UMA ÚNICA chamada para OpenAI - deixar a IA ser inteligente
-	resp, err := s.client.CreateChatCompletion(ctx, req)
+	// Fazer UMA ÚNICA chamada para OpenAI - deixar a IA ser inteligente (modelo do tenant, com reserva)
+	resp, err := s.createChatCompletionWithFallback(ctx, tenantID, req)
 
 	if err != nil {
 		log.Error().
@@ -723,9 +929,10 @@ func (s *AIService) ProcessMessageWithConversation(ctx context.Context, tenantID
 
 	choice := resp.Choices[0]
 	var aiResponse string
+	usedTools := len(choice.Message.ToolCalls) > 0
 
 	// Se há tool calls, executar (sem validações complexas)
-	if len(choice.Message.ToolCalls) > 0 {
+	if usedTools {
 		log.Info().
 			Int("tool_calls_count", len(choice.Message.ToolCalls)).
 			Msg("🔧 AI chose to use tools - executing naturally")
@@ -739,8 +946,17 @@ func (s *AIService) ProcessMessageWithConversation(ctx context.Context, tenantID
 		log.Info().
 			Str("direct_response", aiResponse).
 			Msg("💬 AI provided direct response - accepting naturally")
+
+		// 🤷 Resposta vaga para um pedido claro de compra: nova tentativa ou atendente, conforme o tenant
+		aiResponse, usedTools, err = s.recoverLowConfidenceResponse(ctx, tenantID, customer.ID, customerPhone, message, req, aiResponse)
+		if err != nil {
+			return "", err
+		}
 	}
 
+	// 🙋 Oferecer atendente humano após várias respostas sem sucesso seguidas
+	aiResponse = s.applyUnhelpfulEscalation(ctx, tenantID, customer.ID, customerPhone, aiResponse, usedTools)
+
 	// Salvar a conversa no histórico para manter contexto
 	s.memoryManager.AddToConversationHistory(tenantID, customerPhone, userMessage)
 	s.memoryManager.AddToConversationHistory(tenantID, customerPhone, openai.ChatCompletionMessage{
@@ -784,7 +1000,7 @@ func (s *AIService) ProcessImageMessage(ctx context.Context, tenantID uuid.UUID,
 		Msg("AI ProcessImageMessage started - analyzing image for medications")
 
 	// Buscar ou criar cliente
-	customer, err := s.customerService.GetCustomerByPhone(tenantID, customerPhone)
+	customer, err := s.customerService.GetOrCreateCustomerByPhone(tenantID, customerPhone)
 	if err != nil {
 		log.Error().
 			Err(err).
@@ -799,6 +1015,14 @@ func (s *AIService) ProcessImageMessage(ctx context.Context, tenantID uuid.UUID,
 		Str("customer_name", customer.Name).
 		Msg("Customer found for image analysis")
 
+	// 🌙 Loja fechada e sem pedidos fora do horário: só confirmar o recebimento, sem gastar com a análise
+	if ack, closed := s.closedStoreMediaAck(ctx, tenantID, closedMediaImage); closed {
+		return ack, nil
+	}
+
+	// ⏳ Aviso imediato enquanto a imagem é baixada e analisada (opcional por tenant)
+	s.sendProcessingAck(ctx, tenantID, ProcessingOperationImage)
+
 	// Verificar se S3 está disponível
 	if s.s3Client == nil {
 		log.Error().Msg("S3 storage not available - using original URL for image analysis")
@@ -832,6 +1056,11 @@ func (s *AIService) ProcessImageMessage(ctx context.Context, tenantID uuid.UUID,
 		}
 	}
 
+	// 🏷️ Lojas que não são farmácia: buscar no catálogo o produto da foto ("quero igual a foto")
+	if !s.isPharmacyTenant(tenantID) {
+		return s.processProductImage(ctx, tenantID, customerPhone, imageURL)
+	}
+
 	// Adicionar mensagem de imagem ao histórico
 	s.memoryManager.AddToConversationHistory(tenantID, customerPhone, openai.ChatCompletionMessage{
 		Role:    openai.ChatMessageRoleUser,
@@ -851,7 +1080,7 @@ func (s *AIService) ProcessImageMessage(ctx context.Context, tenantID uuid.UUID,
 				MultiContent: []openai.ChatMessagePart{
 					{
 						Type: openai.ChatMessagePartTypeText,
-						Text: "Analise esta imagem e identifique se contém medicamentos, receita médica ou prescrição. Se encontrar nomes de medicamentos, liste-os no formato exato que aparecem na imagem (um por linha, apenas os nomes). Se não for uma imagem de medicamentos ou receita médica, responda apenas com 'NAO_MEDICAMENTO'.",
+						Text: "Analise esta imagem e identifique se contém medicamentos, receita médica ou prescrição. Se encontrar nomes de medicamentos, liste-os no formato exato que aparecem na imagem (um por linha, apenas os nomes). Se a imagem for uma receita médica ou prescrição, comece a resposta com uma linha contendo apenas 'RECEITA'. Se não for uma imagem de medicamentos ou receita médica, responda apenas com 'NAO_MEDICAMENTO'.",
 					},
 					{
 						Type: openai.ChatMessagePartTypeImageURL,
@@ -899,6 +1128,13 @@ Se preferir falar com um atendente humano, digite "sim".`
 	aiAnalysis := resp.Choices[0].Message.Content
 	log.Info().Str("ai_analysis", aiAnalysis).Msg("GPT-4 Vision analysis result")
 
+	// 📄 Receita médica: guardar a foto no pedido em andamento (retenção de receita de medicamentos controlados)
+	isPrescription, aiAnalysis := parsePrescriptionAnalysis(aiAnalysis)
+	prescriptionNote := ""
+	if isPrescription {
+		prescriptionNote = s.attachPrescriptionImage(tenantID, customer.ID, imageURL)
+	}
+
 	// Verificar se não é uma imagem de medicamento
 	if strings.Contains(strings.ToUpper(aiAnalysis), "NAO_MEDICAMENTO") {
 		response := `Não foi possível identificar medicamentos nesta imagem.
@@ -928,7 +1164,7 @@ Se você tem uma receita médica ou lista de medicamentos, você pode:
 	productCounter := 1
 
 	for _, medication := range medications {
-		products, err := s.productService.SearchProducts(tenantID, medication, 3)
+		products, err := s.searchProducts(ctx, tenantID, medication, 3)
 		if err != nil {
 			log.Error().Err(err).Str("medication", medication).Msg("Failed to search products for medication")
 			continue
@@ -1010,6 +1246,10 @@ Para continuar, você pode:
 Digite o nome de algum medicamento que você procura para eu buscar em nosso catálogo.`
 	}
 
+	if prescriptionNote != "" {
+		response = prescriptionNote + "\n\n" + response
+	}
+
 	s.memoryManager.AddToConversationHistory(tenantID, customerPhone, openai.ChatCompletionMessage{
 		Role:    openai.ChatMessageRoleAssistant,
 		Content: response,
@@ -1028,7 +1268,7 @@ func (s *AIService) ProcessAudioMessage(ctx context.Context, tenantID uuid.UUID,
 		Msg("AI ProcessAudioMessage started - transcribing and analyzing audio")
 
 	// Buscar ou criar cliente
-	customer, err := s.customerService.GetCustomerByPhone(tenantID, customerPhone)
+	customer, err := s.customerService.GetOrCreateCustomerByPhone(tenantID, customerPhone)
 	if err != nil {
 		log.Error().
 			Err(err).
@@ -1043,15 +1283,36 @@ func (s *AIService) ProcessAudioMessage(ctx context.Context, tenantID uuid.UUID,
 		Str("customer_name", customer.Name).
 		Msg("Customer found for audio analysis")
 
+	// 🌙 Loja fechada e sem pedidos fora do horário: só confirmar o recebimento, sem transcrever
+	if ack, closed := s.closedStoreMediaAck(ctx, tenantID, closedMediaAudio); closed {
+		return ack, nil
+	}
+
 	// Verificar se S3 está disponível
 	if s.s3Client == nil {
 		log.Error().Msg("S3 storage not available - cannot process audio")
 		return "", fmt.Errorf("serviço de storage S3 não disponível")
 	}
 
+	// ⏳ Aviso imediato enquanto o áudio é convertido e transcrito (opcional por tenant)
+	s.sendProcessingAck(ctx, tenantID, ProcessingOperationAudio)
+
 	// Upload do arquivo de áudio para S3 (download, conversão e upload)
 	publicAudioURL, err := s.uploadAudioFileToS3(audioURL, tenantID.String(), customer.ID.String(), messageID)
 	if err != nil {
+		if errors.Is(err, ErrFFmpegUnavailable) {
+			log.Warn().
+				Err(err).
+				Str("original_audio_url", audioURL).
+				Msg("Audio format requires FFmpeg, which is not installed")
+			return audioUnsupportedFormatMessage, nil
+		}
+		if errors.Is(err, ErrMediaBusy) {
+			log.Warn().
+				Str("original_audio_url", audioURL).
+				Msg("Media processing busy - audio not processed")
+			return mediaBusyMessage, nil
+		}
 		log.Error().
 			Err(err).
 			Str("original_audio_url", audioURL).
@@ -1125,7 +1386,10 @@ func (s *AIService) transcribeAudioFromURL(ctx context.Context, audioURL string)
 		Msg("Starting audio transcription from S3 URL with OpenAI Whisper")
 
 	// Download audio file from public URL
-	resp, err := http.Get(audioURL)
+	if err := ValidateMediaURL(audioURL); err != nil {
+		return "", fmt.Errorf("URL de áudio recusada: %w", err)
+	}
+	resp, err := NewMediaHTTPClient(30 * time.Second).Get(audioURL)
 	if err != nil {
 		return "", fmt.Errorf("erro ao baixar arquivo do S3 via HTTP: %w", err)
 	}
@@ -1139,7 +1403,7 @@ func (s *AIService) transcribeAudioFromURL(ctx context.Context, audioURL string)
 	req := openai.AudioRequest{
 		Model:    openai.Whisper1,
 		Reader:   resp.Body,
-		FilePath: "audio.mp3", // Adicionar extensão para identificação de formato
+		FilePath: whisperFileName(audioURL), // Extensão usada pelo Whisper para identificar o formato
 		Prompt:   "Transcreva este áudio em português brasileiro. O cliente está fazendo pedidos de produtos ou serviços.",
 		Language: "pt",
 	}
@@ -1164,6 +1428,13 @@ func (s *AIService) transcribeAudioFromURL(ctx context.Context, audioURL string)
 
 // uploadAudioFileToS3 baixa, converte e faz upload de arquivo de áudio para S3
 func (s *AIService) uploadAudioFileToS3(mediaURL, tenantID, customerID, messageID string) (string, error) {
+	// Limitar conversões/uploads simultâneos no processo
+	release, err := AcquireMediaSlot(context.Background())
+	if err != nil {
+		return "", err
+	}
+	defer release()
+
 	log.Printf("Starting audio file upload process for message: %s", messageID)
 
 	// Create temporary directory
@@ -1180,18 +1451,37 @@ func (s *AIService) uploadAudioFileToS3(mediaURL, tenantID, customerID, messageI
 		return "", fmt.Errorf("failed to download audio file: %w", err)
 	}
 
-	// Convert to MP3
-	convertedPath := filepath.Join(tempDir, "converted.mp3")
-	err = s.convertAudioFileToMP3(originalPath, convertedPath)
+	// Sem FFmpeg, formatos aceitos pelo Whisper seguem sem conversão
+	format, err := detectAudioFileFormat(originalPath, mediaURL)
+	if err != nil {
+		return "", err
+	}
+	convert, passthrough, err := planAudioConversion(format, IsFFmpegAvailable())
 	if err != nil {
-		return "", fmt.Errorf("failed to convert audio file: %w", err)
+		return "", fmt.Errorf("audio format %q requires conversion: %w", format, err)
 	}
 
-	// Generate S3 key with structure: tenant_id/conversations/customer_id/audio_messageID.mp3
-	s3Key := fmt.Sprintf("%s/conversations/%s/audio_%s.mp3", tenantID, customerID, messageID)
+	uploadPath := originalPath
+	extension := passthrough.Extension
+	contentType := passthrough.ContentType
+	if convert {
+		// Convert to MP3
+		uploadPath = filepath.Join(tempDir, "converted.mp3")
+		err = s.convertAudioFileToMP3(originalPath, uploadPath)
+		if err != nil {
+			return "", fmt.Errorf("failed to convert audio file: %w", err)
+		}
+		extension = ".mp3"
+		contentType = "audio/mp3"
+	} else {
+		log.Printf("FFmpeg unavailable - uploading %s audio without conversion", format)
+	}
+
+	// Generate S3 key with structure: tenant_id/conversations/customer_id/audio_messageID.ext
+	s3Key := ConversationMediaKey(tenantID, customerID, ConversationMediaAudio, messageID, extension)
 
 	// Upload to S3
-	publicURL, err := s.uploadFileToS3(convertedPath, s3Key, "audio/mp3")
+	publicURL, err := s.uploadFileToS3(uploadPath, s3Key, contentType)
 	if err != nil {
 		return "", fmt.Errorf("failed to upload to S3: %w", err)
 	}
@@ -1202,6 +1492,13 @@ func (s *AIService) uploadAudioFileToS3(mediaURL, tenantID, customerID, messageI
 
 // uploadImageFileToS3 faz upload de arquivo de imagem para S3
 func (s *AIService) uploadImageFileToS3(mediaURL, tenantID, customerID, messageID string) (string, error) {
+	// Limitar conversões/uploads simultâneos no processo
+	release, err := AcquireMediaSlot(context.Background())
+	if err != nil {
+		return "", err
+	}
+	defer release()
+
 	log.Printf("Starting image file upload process for message: %s", messageID)
 
 	// Create temporary directory
@@ -1253,7 +1550,7 @@ func (s *AIService) uploadImageFileToS3(mediaURL, tenantID, customerID, messageI
 	}
 
 	// Generate S3 key with structure: tenant_id/conversations/customer_id/image_messageID.ext
-	s3Key := fmt.Sprintf("%s/conversations/%s/image_%s%s", tenantID, customerID, messageID, ext)
+	s3Key := ConversationMediaKey(tenantID, customerID, ConversationMediaImage, messageID, ext)
 
 	// Upload to S3
 	publicURL, err := s.uploadFileToS3(originalPath, s3Key, contentType)
@@ -1269,11 +1566,14 @@ func (s *AIService) uploadImageFileToS3(mediaURL, tenantID, customerID, messageI
 func (s *AIService) downloadFileToPath(url, filepath string) error {
 	log.Printf("Downloading file from: %s", url)
 
-	// Create HTTP client with timeout
-	client := &http.Client{
-		Timeout: 30 * time.Second,
+	// Recusa hosts fora da lista de mídia e endereços internos (SSRF)
+	if err := ValidateMediaURL(url); err != nil {
+		return err
 	}
 
+	// Create HTTP client with timeout
+	client := NewMediaHTTPClient(30 * time.Second)
+
 	resp, err := client.Get(url)
 	if err != nil {
 		return fmt.Errorf("failed to download file: %w", err)
@@ -1306,7 +1606,7 @@ func (s *AIService) convertAudioFileToMP3(inputPath, outputPath string) error {
 	log.Printf("Converting audio file to MP3: %s -> %s", inputPath, outputPath)
 
 	// FFmpeg command to convert to MP3
-	cmd := exec.Command("ffmpeg",
+	cmd := exec.Command(ffmpegBinary(),
 		"-i", inputPath, // Input file
 		"-acodec", "mp3", // Audio codec
 		"-ab", "128k", // Audio bitrate
@@ -1434,9 +1734,7 @@ func (s *AIService) updateMessageWithS3URL(messageID, s3URL string) error {
 }
 
 // Audio transcription functions removed (unused)
-func (s *AIService) getSystemPrompt(customer *models.Customer) string {
-	ctx := context.Background()
-
+func (s *AIService) getSystemPrompt(ctx context.Context, customer *models.Customer) string {
 	log.Info().
 		Str("tenant_id", customer.TenantID.String()).
 		Msg("Getting system prompt - checking for custom prompt")
@@ -1528,6 +1826,7 @@ COMPORTAMENTO NATURAL:
 - Se a loja estiver fechada, informe educadamente os horários de funcionamento
 - Se estiver aberto, atenda normalmente e processe pedidos
 - Se o cliente se apresentar com seu nome, use atualizarCadastro para salvar
+- Para dúvidas sobre a loja (estacionamento, devolução, nota fiscal, etc.), use 'consultarFAQ' antes de aplicar a limitação de contexto
 - Para personalizar o atendimento, pergunte o nome do cliente se ainda não souber
 
 🎯 REGRAS OBRIGATÓRIAS DE ORDENAÇÃO/PREÇOS:
@@ -1701,7 +2000,7 @@ func (s *AIService) getBusinessInfo(ctx context.Context, tenantID uuid.UUID) (*B
 	examples := s.generateExamplesByBusinessType(businessType)
 
 	// Buscar categorias de produtos reais do tenant (mantém funcionalidade existente)
-	products, err := s.productService.SearchProducts(tenantID, "", 50)
+	products, err := s.searchProducts(ctx, tenantID, "", 50)
 	var topCategories []string
 	if err == nil && len(products) > 0 {
 		categories := make(map[string]int)
@@ -1821,7 +2120,11 @@ func (s *AIService) getAvailableTools() []openai.Tool {
 						},
 						"ordenar_por": map[string]interface{}{
 							"type":        "string",
-							"description": "Critério de ordenação: 'preco_menor' (mais barato primeiro), 'preco_maior' (mais caro primeiro), 'nome' (alfabética), 'relevancia' (padrão)",
+							"description": "Critério de ordenação: 'preco_menor' (mais barato primeiro), 'preco_maior' (mais caro primeiro), 'nome' (alfabética), 'relevancia'. Só informe quando o cliente pedir uma ordem; sem ela vale o padrão configurado pela loja",
+						},
+						"apenas_em_estoque": map[string]interface{}{
+							"type":        "boolean",
+							"description": "Se true, esconde produtos sem estoque. Use false apenas quando o cliente perguntar explicitamente por itens esgotados (ex: 'tem esgotado?'). Omita para usar a preferência da loja",
 						},
 					},
 				},
@@ -1887,6 +2190,23 @@ func (s *AIService) getAvailableTools() []openai.Tool {
 				},
 			},
 		},
+		{
+			Type: openai.ToolTypeFunction,
+			Function: &openai.FunctionDefinition{
+				Name:        "adicionarItemDetalhado",
+				Description: "🛒 Adiciona ao carrinho o produto que o cliente ACABOU de ver com detalharItem, quando ele demonstra intenção de compra sem citar número ou nome (ex.: 'quero esse', 'vou levar', 'pode colocar'). Se a quantidade não foi dita, NÃO pergunte: omita 'quantidade' que será considerada 1 unidade.",
+				Parameters: map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"quantidade": map[string]interface{}{
+							"type":        "integer",
+							"description": "Quantidade, somente se o cliente informou (padrão 1)",
+							"minimum":     1,
+						},
+					},
+				},
+			},
+		},
 		{
 			Type: openai.ToolTypeFunction,
 			Function: &openai.FunctionDefinition{
@@ -1934,6 +2254,30 @@ func (s *AIService) getAvailableTools() []openai.Tool {
 				},
 			},
 		},
+		{
+			Type: openai.ToolTypeFunction,
+			Function: &openai.FunctionDefinition{
+				Name:        "detalharTotal",
+				Description: "Mostra o total detalhado (subtotal dos produtos, entrega, taxa de urgência, descontos e impostos) do carrinho atual ou, com o carrinho vazio, do último pedido. Use quando o cliente perguntar 'por que deu esse valor?', 'como chegou nesse total?' ou pedir o valor detalhado",
+				Parameters: map[string]interface{}{
+					"type":       "object",
+					"properties": map[string]interface{}{},
+					"required":   []string{},
+				},
+			},
+		},
+		{
+			Type: openai.ToolTypeFunction,
+			Function: &openai.FunctionDefinition{
+				Name:        "desfazerUltimaAdicao",
+				Description: "Desfaz a última adição ao carrinho quando o cliente se arrepende logo em seguida (ex: 'ops, tira o que acabei de adicionar', 'desfaz isso', 'não era esse'). Remove só a quantidade que acabou de ser adicionada",
+				Parameters: map[string]interface{}{
+					"type":       "object",
+					"properties": map[string]interface{}{},
+					"required":   []string{},
+				},
+			},
+		},
 		{
 			Type: openai.ToolTypeFunction,
 			Function: &openai.FunctionDefinition{
@@ -1955,6 +2299,17 @@ func (s *AIService) getAvailableTools() []openai.Tool {
 				},
 			},
 		},
+		{
+			Type: openai.ToolTypeFunction,
+			Function: &openai.FunctionDefinition{
+				Name:        "consolidarCarrinho",
+				Description: "🧹 Junta em um só item as linhas repetidas do mesmo produto no carrinho, somando as quantidades (variantes diferentes continuam separadas). Use quando o cliente reclamar de item duplicado: 'tá aparecendo duas vezes', 'junta os itens iguais'. Repasse exatamente a resposta da função.",
+				Parameters: map[string]interface{}{
+					"type":       "object",
+					"properties": map[string]interface{}{},
+				},
+			},
+		},
 		{
 			Type: openai.ToolTypeFunction,
 			Function: &openai.FunctionDefinition{
@@ -1977,6 +2332,56 @@ func (s *AIService) getAvailableTools() []openai.Tool {
 				},
 			},
 		},
+		{
+			Type: openai.ToolTypeFunction,
+			Function: &openai.FunctionDefinition{
+				Name:        "retomarCarrinho",
+				Description: "🛒 Use quando o cliente responder à oferta de retomar o carrinho deixado na visita anterior ('bem-vindo de volta, você tinha itens no carrinho'): 'continuar' mostra o carrinho como está, 'limpar' esvazia para começar de novo",
+				Parameters: map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"acao": map[string]interface{}{
+							"type":        "string",
+							"enum":        []string{"continuar", "limpar"},
+							"description": "continuar com os itens anteriores ou limpar o carrinho",
+						},
+					},
+					"required": []string{"acao"},
+				},
+			},
+		},
+		{
+			Type: openai.ToolTypeFunction,
+			Function: &openai.FunctionDefinition{
+				Name:        "adicionarEFinalizar",
+				Description: "🛒➡️ Use quando o cliente pedir para ADICIONAR produtos E FINALIZAR na mesma mensagem ('adiciona 2 dipironas e já finaliza', 'coloca o 3 e fecha o pedido'). Adiciona todos os itens e já segue para o checkout em uma única resposta. NÃO combine com adicionarProdutoPorNome, adicionarAoCarrinho ou checkout no mesmo turno.",
+				Parameters: map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"itens": map[string]interface{}{
+							"type":        "array",
+							"description": "Produtos a adicionar antes de finalizar",
+							"items": map[string]interface{}{
+								"type": "object",
+								"properties": map[string]interface{}{
+									"produto": map[string]interface{}{
+										"type":        "string",
+										"description": "Número sequencial da última lista (ex: '3') ou nome do produto dito pelo cliente (ex: 'dipirona')",
+									},
+									"quantidade": map[string]interface{}{
+										"type":        "integer",
+										"description": "Quantidade (padrão 1)",
+										"minimum":     1,
+									},
+								},
+								"required": []string{"produto"},
+							},
+						},
+					},
+					"required": []string{"itens"},
+				},
+			},
+		},
 		{
 			Type: openai.ToolTypeFunction,
 			Function: &openai.FunctionDefinition{
@@ -1988,6 +2393,17 @@ func (s *AIService) getAvailableTools() []openai.Tool {
 				},
 			},
 		},
+		{
+			Type: openai.ToolTypeFunction,
+			Function: &openai.FunctionDefinition{
+				Name:        "usarEnderecoDeSempre",
+				Description: "📍 Use quando o cliente pedir para entregar no endereço de sempre/de costume/cadastrado ('manda pro meu endereço de sempre', 'no mesmo endereço de sempre', 'entrega lá em casa como sempre'), inclusive em áudios transcritos. Usa o endereço padrão do cliente e finaliza o pedido sem perguntar o endereço de novo. Se o cliente também pediu produtos na mesma mensagem, adicione-os antes.",
+				Parameters: map[string]interface{}{
+					"type":       "object",
+					"properties": map[string]interface{}{},
+				},
+			},
+		},
 		{
 			Type: openai.ToolTypeFunction,
 			Function: &openai.FunctionDefinition{
@@ -2121,6 +2537,24 @@ func (s *AIService) getAvailableTools() []openai.Tool {
 				},
 			},
 		},
+		{
+			Type: openai.ToolTypeFunction,
+			Function: &openai.FunctionDefinition{
+				Name:        "definirIdioma",
+				Description: "Define o idioma em que o cliente quer ser atendido. Use quando o cliente pedir para trocar de idioma ('English please', 'pode falar em espanhol?', 'volta pro português'). Depois disso responda sempre no idioma escolhido.",
+				Parameters: map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"idioma": map[string]interface{}{
+							"type":        "string",
+							"enum":        []string{"pt", "en", "es"},
+							"description": "Código do idioma: pt (português), en (inglês) ou es (espanhol)",
+						},
+					},
+					"required": []string{"idioma"},
+				},
+			},
+		},
 		{
 			Type: openai.ToolTypeFunction,
 			Function: &openai.FunctionDefinition{
@@ -2144,6 +2578,29 @@ func (s *AIService) getAvailableTools() []openai.Tool {
 				},
 			},
 		},
+		{
+			Type: openai.ToolTypeFunction,
+			Function: &openai.FunctionDefinition{
+				Name:        "dividirEntrega",
+				Description: "🎁 Envia itens do carrinho para OUTRO endereço cadastrado, dividindo o pedido em entregas separadas (um pedido por endereço). Use quando cliente disser: 'manda o perfume para a casa da minha mãe', 'parte vai para outro endereço', 'é presente, entrega em outro lugar'. Os itens não indicados continuam no endereço principal.",
+				Parameters: map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"itens": map[string]interface{}{
+							"type":        "array",
+							"items":       map[string]interface{}{"type": "integer", "minimum": 1},
+							"description": "Números dos itens no carrinho (como em 'ver carrinho') que vão para o endereço informado",
+						},
+						"numero_endereco": map[string]interface{}{
+							"type":        "integer",
+							"description": "Número do endereço na lista de endereços do cliente ('meus endereços')",
+							"minimum":     1,
+						},
+					},
+					"required": []string{"itens", "numero_endereco"},
+				},
+			},
+		},
 		{
 			Type: openai.ToolTypeFunction,
 			Function: &openai.FunctionDefinition{
@@ -2250,6 +2707,22 @@ func (s *AIService) getAvailableTools() []openai.Tool {
 				},
 			},
 		},
+		{
+			Type: openai.ToolTypeFunction,
+			Function: &openai.FunctionDefinition{
+				Name:        "calcularTroco",
+				Description: "💵 Calcula o troco para pagamento em DINHEIRO com o total atual do carrinho e confere se o valor cobre o total. Use quando cliente disser 'vou pagar com 100', 'troco pra 50', 'quanto vai dar de troco?'. Sem valor, usa o valor de troco já informado.",
+				Parameters: map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"valor_pago": map[string]interface{}{
+							"type":        "string",
+							"description": "Valor em dinheiro que o cliente vai entregar (ex: '100', '50,00')",
+						},
+					},
+				},
+			},
+		},
 		{
 			Type: openai.ToolTypeFunction,
 			Function: &openai.FunctionDefinition{
@@ -2296,42 +2769,541 @@ func (s *AIService) getAvailableTools() []openai.Tool {
 				},
 			},
 		},
-	}
-}
-
-// ToolExecutionResult represents the result of executing a single tool/function
-type ToolExecutionResult struct {
-	ToolName   string                 `json:"tool_name"`
-	Parameters map[string]interface{} `json:"parameters"`
-	Result     string                 `json:"result"`
-	Error      string                 `json:"error,omitempty"`
-}
-
-// ToolExecutionResults represents results from multiple tool executions
-type ToolExecutionResults struct {
-	CombinedResponse  string                `json:"combined_response"`
-	IndividualResults []ToolExecutionResult `json:"individual_results"`
-}
-
-func (s *AIService) executeToolCalls(ctx context.Context, tenantID, customerID uuid.UUID, customerPhone, userMessage string, toolCalls []openai.ToolCall) (string, error) {
-	return s.executeToolCallsWithResults(ctx, tenantID, customerID, customerPhone, userMessage, toolCalls)
-}
-
-func (s *AIService) executeToolCallsWithResults(ctx context.Context, tenantID, customerID uuid.UUID, customerPhone, userMessage string, toolCalls []openai.ToolCall) (string, error) {
-	var results []string
-	var individualResults []ToolExecutionResult
-
-	// // 🚨 CORREÇÃO: Detectar se são ações conflitantes ou repetitivas
-	// if len(toolCalls) > 1 {
-	// 	// Analisar se há ações duplicadas ou conflitantes
-	// 	toolNames := make([]string, len(toolCalls))
-	// 	for i, toolCall := range toolCalls {
-	// 		toolNames[i] = toolCall.Function.Name
-	// 	}
-
-	// 	// Se há múltiplas ações relacionadas ao carrinho, executar apenas a mais específica
-	// 	if s.shouldFilterMultipleCartActions(toolNames, userMessage) {
-	// 		log.Warn().
+		{
+			Type: openai.ToolTypeFunction,
+			Function: &openai.FunctionDefinition{
+				Name:        "consultarTempoPreparo",
+				Description: "⏱️ Informa o tempo estimado de preparo. Use quando cliente perguntar: 'quanto tempo demora?', 'fica pronto em quanto tempo?', 'demora muito?'. Sem produto informado, calcula para o carrinho atual (considera o item mais demorado).",
+				Parameters: map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"identifier": map[string]interface{}{
+							"type":        "string",
+							"description": "Número ou nome do produto (opcional). Deixe vazio para consultar o tempo do carrinho",
+						},
+					},
+					"required": []string{},
+				},
+			},
+		},
+		{
+			Type: openai.ToolTypeFunction,
+			Function: &openai.FunctionDefinition{
+				Name:        "detalharPedido",
+				Description: "🧾 Mostra os detalhes de um pedido do cliente: itens, quantidades, preços, total, status e endereço de entrega. Use quando cliente pedir: 'detalhes do pedido 2', 'o que tinha no pedido PED123?', 'ver pedido'. Apenas consulta, não altera o pedido.",
+				Parameters: map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"order_id": map[string]interface{}{
+							"type":        "string",
+							"description": "Número sequencial do histórico (1, 2, 3...) ou código do pedido",
+						},
+					},
+					"required": []string{"order_id"},
+				},
+			},
+		},
+		{
+			Type: openai.ToolTypeFunction,
+			Function: &openai.FunctionDefinition{
+				Name:        "gerarRecibo",
+				Description: "🧾 Gera o recibo de um pedido do cliente para ele encaminhar: número do pedido, itens, totais, pagamento, endereço e data. Use quando cliente pedir: 'manda o recibo', 'quero o comprovante do pedido', 'recibo do pedido 2'. Sem número de pedido, usa o pedido mais recente.",
+				Parameters: map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"order_id": map[string]interface{}{
+							"type":        "string",
+							"description": "Número sequencial do histórico (1, 2, 3...) ou código do pedido (opcional - padrão é o pedido mais recente)",
+						},
+						"link": map[string]interface{}{
+							"type":        "boolean",
+							"description": "true quando o cliente pedir um link ou arquivo do recibo para compartilhar",
+						},
+					},
+				},
+			},
+		},
+		{
+			Type: openai.ToolTypeFunction,
+			Function: &openai.FunctionDefinition{
+				Name:        "rastrearPedido",
+				Description: "🚚 Informa se o pedido já saiu para entrega, com código de rastreio, link e previsão de chegada quando disponíveis. Use quando cliente perguntar: 'já saiu pra entrega?', 'cadê meu pedido?', 'quando chega?', 'tem rastreio?'. Sem número de pedido, consulta o pedido mais recente.",
+				Parameters: map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"order_id": map[string]interface{}{
+							"type":        "string",
+							"description": "Número sequencial do histórico (1, 2, 3...) ou código do pedido (opcional - padrão é o pedido mais recente)",
+						},
+					},
+					"required": []string{},
+				},
+			},
+		},
+		{
+			Type: openai.ToolTypeFunction,
+			Function: &openai.FunctionDefinition{
+				Name:        "solicitarDevolucao",
+				Description: "↩️ Registra pedido de DEVOLUÇÃO ou TROCA de um pedido já entregue e avisa a equipe. Use quando cliente disser: 'quero devolver', 'veio errado', 'veio com defeito', 'quero trocar'. Pergunte o motivo antes de chamar. Só vale para pedidos entregues dentro do prazo de devolução - repasse exatamente a resposta da função.",
+				Parameters: map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"motivo": map[string]interface{}{
+							"type":        "string",
+							"description": "Motivo informado pelo cliente (ex: 'produto com defeito', 'veio o tamanho errado')",
+						},
+						"itens": map[string]interface{}{
+							"type":        "string",
+							"description": "Itens a devolver ou trocar (opcional - vazio = pedido inteiro)",
+						},
+						"tipo": map[string]interface{}{
+							"type":        "string",
+							"enum":        []string{"devolucao", "troca"},
+							"description": "Se o cliente quer devolver ou trocar (padrão: devolucao)",
+						},
+						"order_id": map[string]interface{}{
+							"type":        "string",
+							"description": "Número sequencial do histórico (1, 2, 3...) ou código do pedido (opcional - padrão é o último pedido entregue)",
+						},
+					},
+					"required": []string{"motivo"},
+				},
+			},
+		},
+		{
+			Type: openai.ToolTypeFunction,
+			Function: &openai.FunctionDefinition{
+				Name:        "consultarInfoNutricional",
+				Description: "🥗 OBRIGATÓRIO para perguntas sobre calorias, tabela nutricional, ingredientes ou alérgenos de um produto: 'tem glúten?', 'quantas calorias?', 'contém lactose?', 'quais os ingredientes?'. NUNCA responda essas perguntas sem esta função e NUNCA presuma a ausência de um alérgeno - repasse exatamente a resposta da função. Se a função disser que não tem a informação, NUNCA estime nem invente calorias ou nutrientes (nem com base em produtos parecidos).",
+				Parameters: map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"identifier": map[string]interface{}{
+							"type":        "string",
+							"description": "Número do produto na última lista ou nome do produto",
+						},
+						"topico": map[string]interface{}{
+							"type":        "string",
+							"description": "O que o cliente quer saber: 'alergenos', 'ingredientes', 'nutricao' (calorias e nutrientes) ou 'todos'",
+							"enum":        []string{nutritionTopicAll, nutritionTopicAllergens, nutritionTopicIngredients, nutritionTopicNutrition},
+						},
+						"alergeno": map[string]interface{}{
+							"type":        "string",
+							"description": "Alérgeno específico perguntado pelo cliente (ex: 'glúten', 'lactose', 'amendoim')",
+						},
+					},
+					"required": []string{"identifier"},
+				},
+			},
+		},
+		{
+			Type: openai.ToolTypeFunction,
+			Function: &openai.FunctionDefinition{
+				Name:        "confirmarIdade",
+				Description: "🔞 Registra a resposta do cliente à pergunta de maioridade feita para produtos restritos (bebidas, tabaco, etc). Use quando o cliente responder 'sim, tenho mais de 18', 'confirmo' ou 'não' logo após a pergunta de idade. NUNCA confirme a idade sem o cliente declarar explicitamente.",
+				Parameters: map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"confirmado": map[string]interface{}{
+							"type":        "boolean",
+							"description": "true se o cliente declarou ter 18 anos ou mais, false caso contrário",
+						},
+					},
+					"required": []string{"confirmado"},
+				},
+			},
+		},
+		{
+			Type: openai.ToolTypeFunction,
+			Function: &openai.FunctionDefinition{
+				Name:        "confirmarReadicao",
+				Description: "🛡️ Registra a resposta do cliente quando o sistema perguntar se quer adicionar de novo um produto que ele removeu do carrinho há pouco. Use quando o cliente responder 'sim, pode adicionar' ou 'não' logo após essa pergunta. NUNCA confirme sem o cliente responder.",
+				Parameters: map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"confirmado": map[string]interface{}{
+							"type":        "boolean",
+							"description": "true se o cliente quer o produto de volta no carrinho, false caso contrário",
+						},
+					},
+					"required": []string{"confirmado"},
+				},
+			},
+		},
+		{
+			Type: openai.ToolTypeFunction,
+			Function: &openai.FunctionDefinition{
+				Name:        "calcularEconomia",
+				Description: "🏷️ Calcula quanto o cliente está economizando com as promoções dos itens do carrinho. Use quando o cliente perguntar 'quanto estou economizando?' ou 'tem desconto no meu carrinho?'",
+				Parameters: map[string]interface{}{
+					"type":       "object",
+					"properties": map[string]interface{}{},
+				},
+			},
+		},
+		{
+			Type: openai.ToolTypeFunction,
+			Function: &openai.FunctionDefinition{
+				Name:        "verEncarte",
+				Description: "🗞️ Envia o encarte (folheto) de ofertas da loja. Use quando o cliente pedir 'encarte', 'folheto', 'panfleto de ofertas', 'ofertas da semana' ou 'quais as promoções?'. Sem encarte cadastrado, lista os produtos em promoção. Repasse exatamente a resposta da função.",
+				Parameters: map[string]interface{}{
+					"type":       "object",
+					"properties": map[string]interface{}{},
+				},
+			},
+		},
+		{
+			Type: openai.ToolTypeFunction,
+			Function: &openai.FunctionDefinition{
+				Name:        "retiradaNaLoja",
+				Description: "🏪 Define se o cliente vai RETIRAR o pedido na loja (sem entrega). Use quando o cliente disser 'vou buscar', 'retiro na loja', 'não precisa entregar'. Use retirada=false se ele voltar a querer entrega. Dispensa endereço de entrega e segue para a confirmação do pedido.",
+				Parameters: map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"retirada": map[string]interface{}{
+							"type":        "boolean",
+							"description": "true para retirar na loja, false para voltar a receber por entrega",
+						},
+					},
+					"required": []string{"retirada"},
+				},
+			},
+		},
+		{
+			Type: openai.ToolTypeFunction,
+			Function: &openai.FunctionDefinition{
+				Name:        "pagarComSinal",
+				Description: "💵 Marca o pedido em andamento para pagamento com SINAL: uma parte paga agora (ex.: PIX) e o restante na entrega (ex.: dinheiro). Use quando o cliente perguntar 'posso pagar uma parte agora e o resto na entrega?', 'quero dar um sinal'. Use sinal=false se ele preferir pagar tudo de uma vez. Repasse exatamente a divisão de valores da resposta da função.",
+				Parameters: map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"sinal": map[string]interface{}{
+							"type":        "boolean",
+							"description": "true para pagar com sinal, false para pagar o valor integral",
+						},
+					},
+					"required": []string{"sinal"},
+				},
+			},
+		},
+		{
+			Type: openai.ToolTypeFunction,
+			Function: &openai.FunctionDefinition{
+				Name:        "marcarUrgente",
+				Description: "⚡ Marca o pedido em andamento como URGENTE (prioridade na separação e entrega). Use quando o cliente disser 'é urgente', 'preciso com pressa', 'tem entrega expressa?'. Use urgente=false se ele desistir da urgência. Pode haver taxa de urgência: repasse exatamente a resposta da função.",
+				Parameters: map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"urgente": map[string]interface{}{
+							"type":        "boolean",
+							"description": "true para marcar como urgente, false para voltar ao prazo normal",
+						},
+					},
+					"required": []string{"urgente"},
+				},
+			},
+		},
+		{
+			Type: openai.ToolTypeFunction,
+			Function: &openai.FunctionDefinition{
+				Name:        "consultarDescontoQuantidade",
+				Description: "🏷️ Responde se um produto tem desconto levando mais unidades e a partir de quantas (ex: 'tem desconto se eu levar mais?', 'faz um preço melhor em quantidade?', 'comprando mais sai mais barato?'). Sem quantidade definida; para calcular o total de uma quantidade use consultarPrecoQuantidade. Repasse exatamente a resposta da função.",
+				Parameters: map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"identifier": map[string]interface{}{
+							"type":        "string",
+							"description": "Número do produto na lista, nome ou ID",
+						},
+					},
+					"required": []string{"identifier"},
+				},
+			},
+		},
+		{
+			Type: openai.ToolTypeFunction,
+			Function: &openai.FunctionDefinition{
+				Name:        "consultarPrecoQuantidade",
+				Description: "📦 Consulta o preço de um produto para uma quantidade, aplicando descontos de atacado (ex: 'quanto fica 20 unidades?', 'e se eu levar 10?'). Mostra as faixas de preço por quantidade.",
+				Parameters: map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"identifier": map[string]interface{}{
+							"type":        "string",
+							"description": "Número do produto na lista, nome ou ID",
+						},
+						"quantidade": map[string]interface{}{
+							"type":        "integer",
+							"description": "Quantidade desejada (padrão 1)",
+							"minimum":     1,
+						},
+					},
+					"required": []string{"identifier"},
+				},
+			},
+		},
+		{
+			Type: openai.ToolTypeFunction,
+			Function: &openai.FunctionDefinition{
+				Name:        "consultarFAQ",
+				Description: "❓ Responde dúvidas sobre a loja que não são sobre produtos, usando as perguntas frequentes cadastradas (ex: 'têm estacionamento?', 'aceitam devolução?', 'emitem nota fiscal?'). Use ANTES de recusar uma pergunta como fora do contexto.",
+				Parameters: map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"pergunta": map[string]interface{}{
+							"type":        "string",
+							"description": "Dúvida do cliente, com as palavras dele",
+						},
+					},
+					"required": []string{"pergunta"},
+				},
+			},
+		},
+		{
+			Type: openai.ToolTypeFunction,
+			Function: &openai.FunctionDefinition{
+				Name:        "avisarQuandoChegar",
+				Description: "🔔 Registra que o cliente quer ser avisado quando um produto que a loja não tem chegar. Use SOMENTE depois de uma busca sem resultado em que foi oferecido o aviso e o cliente aceitou ('sim, me avise', 'quero ser avisado', 'pode avisar').",
+				Parameters: map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"produto": map[string]interface{}{
+							"type":        "string",
+							"description": "Produto que o cliente procurou e não encontrou",
+						},
+					},
+					"required": []string{"produto"},
+				},
+			},
+		},
+		{
+			Type: openai.ToolTypeFunction,
+			Function: &openai.FunctionDefinition{
+				Name:        "maisVendidos",
+				Description: "🏆 Lista os produtos mais vendidos da loja, calculados a partir dos pedidos recentes. Use quando o cliente perguntar 'qual o mais vendido?', 'qual o mais pedido?', 'o que o pessoal mais compra?'. Repasse exatamente a resposta da função.",
+				Parameters: map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"quantidade": map[string]interface{}{
+							"type":        "integer",
+							"description": "Quantos produtos mostrar (padrão 5, máximo 10)",
+						},
+					},
+				},
+			},
+		},
+		{
+			Type: openai.ToolTypeFunction,
+			Function: &openai.FunctionDefinition{
+				Name:        "novidades",
+				Description: "✨ Lista os produtos lançados ou que entraram em promoção desde a última visita ou pedido do cliente. Use quando o cliente perguntar 'o que tem de novo?', 'chegou novidade?', 'novidades desde a última vez'. Para todas as ofertas da semana use verEncarte. Repasse exatamente a resposta da função.",
+				Parameters: map[string]interface{}{
+					"type":       "object",
+					"properties": map[string]interface{}{},
+				},
+			},
+		},
+		{
+			Type: openai.ToolTypeFunction,
+			Function: &openai.FunctionDefinition{
+				Name:        "consultarInstrucoesPagamento",
+				Description: "💳 Reenvia os dados de pagamento (ex: chave PIX) do pedido mais recente do cliente que ainda aguarda pagamento. Use quando o cliente pedir depois do pedido finalizado: 'me manda a chave pix de novo', 'qual o pix mesmo?', 'como eu pago?'. Repasse exatamente a resposta da função.",
+				Parameters: map[string]interface{}{
+					"type":       "object",
+					"properties": map[string]interface{}{},
+				},
+			},
+		},
+		{
+			Type: openai.ToolTypeFunction,
+			Function: &openai.FunctionDefinition{
+				Name:        "solicitarProdutoEspecial",
+				Description: "📦 Registra o pedido de um produto que a loja NÃO tem no catálogo para a equipe verificar se consegue trazer (encomenda). Use quando o cliente pedir explicitamente para a loja providenciar o item ('vocês conseguem trazer?', 'dá pra encomendar?', 'consegue pra mim?'), depois de confirmar com uma busca que o produto não está disponível. Repasse exatamente a resposta da função.",
+				Parameters: map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"produto": map[string]interface{}{
+							"type":        "string",
+							"description": "Nome do produto como o cliente pediu",
+						},
+						"observacoes": map[string]interface{}{
+							"type":        "string",
+							"description": "Detalhes opcionais informados pelo cliente: marca, apresentação, quantidade, prazo",
+						},
+					},
+					"required": []string{"produto"},
+				},
+			},
+		},
+		{
+			Type: openai.ToolTypeFunction,
+			Function: &openai.FunctionDefinition{
+				Name:        "acessoriosDoProduto",
+				Description: "🧩 Lista os acessórios e complementos indicados para um produto específico (ex: 'o que combina com essa impressora?', 'tem cartucho pra ela?', 'o que levo junto com o nebulizador?'). Mostra os acessórios cadastrados pela loja ou, sem eles, produtos da mesma categoria. Repasse exatamente a resposta da função.",
+				Parameters: map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"identifier": map[string]interface{}{
+							"type":        "string",
+							"description": "Número do produto na última lista ou nome do produto",
+						},
+					},
+					"required": []string{"identifier"},
+				},
+			},
+		},
+		{
+			Type: openai.ToolTypeFunction,
+			Function: &openai.FunctionDefinition{
+				Name:        "consultarFreteProduto",
+				Description: "⚖️ Estima quanto um produto acrescenta ao frete (cobrança por peso) e mostra o frete atual do carrinho. Use quando o cliente perguntar 'quanto fica o frete desse produto?' ou 'o frete aumenta se eu levar mais?'. Repasse exatamente a resposta da função.",
+				Parameters: map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"identifier": map[string]interface{}{
+							"type":        "string",
+							"description": "Número do produto na última lista ou nome do produto",
+						},
+						"quantidade": map[string]interface{}{
+							"type":        "number",
+							"description": "Quantidade de unidades que o cliente pretende levar (padrão 1)",
+						},
+					},
+					"required": []string{"identifier"},
+				},
+			},
+		},
+		{
+			Type: openai.ToolTypeFunction,
+			Function: &openai.FunctionDefinition{
+				Name:        "consultarPesoCarrinho",
+				Description: "⚖️ Calcula o peso total do carrinho e confere se cabe no limite de peso da entrega (ex: motoboy). Use quando o cliente perguntar 'quanto pesa meu pedido?' ou 'a entrega leva tudo isso?'. Repasse exatamente a resposta da função.",
+				Parameters: map[string]interface{}{
+					"type":       "object",
+					"properties": map[string]interface{}{},
+				},
+			},
+		},
+		{
+			Type: openai.ToolTypeFunction,
+			Function: &openai.FunctionDefinition{
+				Name:        "verificarCupom",
+				Description: "🏷️ Verifica, SEM aplicar, se um cupom de desconto vale para o carrinho atual: desconto que daria e condições não atendidas (pedido mínimo, validade, limite de usos). Use quando o cliente perguntar 'esse cupom funciona?' ou 'quanto dá de desconto com o cupom X?'. Repasse exatamente a resposta da função.",
+				Parameters: map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"codigo": map[string]interface{}{
+							"type":        "string",
+							"description": "Código do cupom informado pelo cliente",
+						},
+					},
+					"required": []string{"codigo"},
+				},
+			},
+		},
+		{
+			Type: openai.ToolTypeFunction,
+			Function: &openai.FunctionDefinition{
+				Name:        "consultarParcelamento",
+				Description: "💳 Mostra as opções de parcelamento do total do carrinho (ex.: 'dá pra parcelar?', 'em quantas vezes?') e registra a escolha quando o cliente disser o número de parcelas. Repasse exatamente a resposta da função.",
+				Parameters: map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"parcelas": map[string]interface{}{
+							"type":        "number",
+							"description": "Número de parcelas escolhido pelo cliente (omitir para apenas listar as opções)",
+						},
+					},
+				},
+			},
+		},
+		{
+			Type: openai.ToolTypeFunction,
+			Function: &openai.FunctionDefinition{
+				Name:        "criarAssinatura",
+				Description: "🔁 Cria uma assinatura de pedido recorrente (ex.: 'quero receber esse remédio todo mês', 'me manda 2 caixas a cada 15 dias'). Na data, o pedido é gerado e o cliente precisa confirmar. Repasse exatamente a resposta da função.",
+				Parameters: map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"identifier": map[string]interface{}{
+							"type":        "string",
+							"description": "Número do produto na última lista ou nome do produto",
+						},
+						"quantidade": map[string]interface{}{
+							"type":        "number",
+							"description": "Quantidade por pedido (padrão 1)",
+						},
+						"frequencia": map[string]interface{}{
+							"type":        "string",
+							"description": "Frequência: 'semanal', 'quinzenal', 'mensal' ou 'dias' (com intervalo_dias)",
+						},
+						"intervalo_dias": map[string]interface{}{
+							"type":        "number",
+							"description": "Intervalo em dias, quando a frequência for 'dias' (ex.: a cada 20 dias)",
+						},
+					},
+					"required": []string{"identifier", "frequencia"},
+				},
+			},
+		},
+		{
+			Type: openai.ToolTypeFunction,
+			Function: &openai.FunctionDefinition{
+				Name:        "gerenciarAssinatura",
+				Description: "🔁 Lista, pausa, retoma ou cancela as assinaturas do cliente, e confirma ou pula o pedido recorrente gerado. Use quando o cliente responder 'confirmar'/'pular' a um pedido recorrente ou pedir 'minhas assinaturas'. Repasse exatamente a resposta da função.",
+				Parameters: map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"acao": map[string]interface{}{
+							"type":        "string",
+							"enum":        []string{"listar", "pausar", "retomar", "cancelar", "confirmar", "pular"},
+							"description": "Ação desejada",
+						},
+						"assinatura": map[string]interface{}{
+							"type":        "string",
+							"description": "Número da assinatura na lista ou nome do produto (opcional quando o cliente só tem uma)",
+						},
+					},
+					"required": []string{"acao"},
+				},
+			},
+		},
+	}
+}
+
+// ToolExecutionResult represents the result of executing a single tool/function
+type ToolExecutionResult struct {
+	ToolName   string                 `json:"tool_name"`
+	Parameters map[string]interface{} `json:"parameters"`
+	Result     string                 `json:"result"`
+	Error      string                 `json:"error,omitempty"`
+}
+
+// ToolExecutionResults represents results from multiple tool executions
+type ToolExecutionResults struct {
+	CombinedResponse  string                `json:"combined_response"`
+	IndividualResults []ToolExecutionResult `json:"individual_results"`
+}
+
+func (s *AIService) executeToolCalls(ctx context.Context, tenantID, customerID uuid.UUID, customerPhone, userMessage string, toolCalls []openai.ToolCall) (string, error) {
+	return s.executeToolCallsWithResults(ctx, tenantID, customerID, customerPhone, userMessage, toolCalls)
+}
+
+func (s *AIService) executeToolCallsWithResults(ctx context.Context, tenantID, customerID uuid.UUID, customerPhone, userMessage string, toolCalls []openai.ToolCall) (string, error) {
+	var results []string
+	var individualResults []ToolExecutionResult
+
+	// // 🚨 CORREÇÃO: Detectar se são ações conflitantes ou repetitivas
+	// if len(toolCalls) > 1 {
+	// 	// Analisar se há ações duplicadas ou conflitantes
+	// 	toolNames := make([]string, len(toolCalls))
+	// 	for i, toolCall := range toolCalls {
+	// 		toolNames[i] = toolCall.Function.Name
+	// 	}
+
+	// 	// Se há múltiplas ações relacionadas ao carrinho, executar apenas a mais específica
+	// 	if s.shouldFilterMultipleCartActions(toolNames, userMessage) {
+	// 		log.Warn().
 	// 			Strs("tool_names", toolNames).
 	// 			Str("user_message", userMessage).
 	// 			Msg("🚨 Detectadas múltiplas ações de carrinho conflitantes - filtrando para uma ação")
@@ -2341,6 +3313,11 @@ func (s *AIService) executeToolCallsWithResults(ctx context.Context, tenantID, c
 	// 	}
 	// }
 
+	// 🛒 "adiciona e finaliza" em ferramentas separadas: adicionar antes para o checkout mostrar o carrinho atualizado
+	toolCalls = orderAddsBeforeCheckout(toolCalls)
+
+	availableTools := s.getAvailableToolsForTenant(tenantID)
+
 	for _, toolCall := range toolCalls {
 		var args map[string]interface{}
 		if err := json.Unmarshal([]byte(toolCall.Function.Arguments), &args); err != nil {
@@ -2359,6 +3336,30 @@ func (s *AIService) executeToolCallsWithResults(ctx context.Context, tenantID, c
 			})
 			continue
 		}
+		if args == nil {
+			args = make(map[string]interface{})
+		}
+
+		// Validar argumentos contra o schema declarado antes de despachar
+		if err := validateToolArguments(availableTools, toolCall.Function.Name, args); err != nil {
+			log.Warn().
+				Err(err).
+				Str("tool_name", toolCall.Function.Name).
+				Str("tenant_id", tenantID.String()).
+				Interface("args", args).
+				Msg("🚫 Argumentos da ferramenta não correspondem ao schema")
+
+			friendlyMessage := s.errorHandler.LogAIError(tenantID, customerID, customerPhone, userMessage, toolCall.Function.Name, args, err)
+			results = append(results, friendlyMessage)
+
+			individualResults = append(individualResults, ToolExecutionResult{
+				ToolName:   toolCall.Function.Name,
+				Parameters: args,
+				Result:     friendlyMessage,
+				Error:      err.Error(),
+			})
+			continue
+		}
 
 		result, err := s.executeTool(ctx, tenantID, customerID, customerPhone, toolCall.Function.Name, args)
 		if err != nil {
@@ -2391,6 +3392,9 @@ func (s *AIService) executeToolCallsWithResults(ctx context.Context, tenantID, c
 		}
 	}
 
+	// 📊 Registrar as ferramentas executadas para as métricas do tenant
+	s.recordToolExecutions(tenantID, customerID, customerPhone, individualResults)
+
 	// Debug log to see results before conditional logic
 
 	if len(results) == 1 {
@@ -2404,6 +3408,14 @@ func (s *AIService) executeToolCallsWithResults(ctx context.Context, tenantID, c
 	// 🚨 CORREÇÃO: Se há múltiplos resultados válidos, combinar em uma resposta
 	// Isso permite feedback de múltiplos itens adicionados em uma única operação
 	if len(results) > 1 {
+		// Adições seguidas de checkout: uma resposta só, com o carrinho e a confirmação do checkout
+		if combinedMessage, ok := combineAddAndCheckout(individualResults); ok {
+			s.functionResultsMutex.Lock()
+			s.lastFunctionResults = individualResults
+			s.functionResultsMutex.Unlock()
+			return combinedMessage, nil
+		}
+
 		// Verificar se são ações de adicionar produtos - detectar pela mensagem de resposta
 		addActions := 0
 		searchActions := 0
@@ -2575,41 +3587,60 @@ func (s *AIService) executeToolCallsWithResults(ctx context.Context, tenantID, c
 func (s *AIService) executeTool(ctx context.Context, tenantID, customerID uuid.UUID, customerPhone string, toolName string, args map[string]interface{}) (string, error) {
 	switch toolName {
 	case "consultarItens":
-		return s.handleConsultarItens(tenantID, customerPhone, args)
+		return s.handleConsultarItens(ctx, tenantID, customerID, customerPhone, args)
 	case "mostrarOpcoesCategoria":
-		return s.handleMostrarOpcoesCategoria(tenantID, customerPhone, args)
+		return s.handleMostrarOpcoesCategoria(ctx, tenantID, customerPhone, args)
 	case "detalharItem":
-		return s.handleDetalharItem(tenantID, customerPhone, args)
+		return s.handleDetalharItem(ctx, tenantID, customerPhone, args)
 	case "adicionarAoCarrinho":
-		return s.handleAdicionarAoCarrinho(tenantID, customerID, customerPhone, args)
+		return s.handleAdicionarAoCarrinho(ctx, tenantID, customerID, customerPhone, args)
+	case "adicionarItemDetalhado":
+		return s.handleAdicionarItemDetalhado(ctx, tenantID, customerID, customerPhone, args)
 	case "buscarMultiplosProdutos":
-		return s.handleBuscarMultiplosProdutos(tenantID, customerID, customerPhone, args)
+		s.sendProcessingAck(ctx, tenantID, ProcessingOperationSearch)
+		return s.handleBuscarMultiplosProdutos(ctx, tenantID, customerID, customerPhone, args)
 	case "adicionarProdutoPorNome":
-		return s.handleAdicionarProdutoPorNome(tenantID, customerID, customerPhone, args)
+		return s.handleAdicionarProdutoPorNome(ctx, tenantID, customerID, customerPhone, args)
 	case "adicionarPorNumero":
-		return s.handleAdicionarPorNumero(tenantID, customerID, customerPhone, args)
+		return s.handleAdicionarPorNumero(ctx, tenantID, customerID, customerPhone, args)
 	case "adicionarMaisItemCarrinho":
 		return s.handleAdicionarMaisItemCarrinho(tenantID, customerID, args)
 	case "atualizarQuantidade":
 		return s.handleAtualizarQuantidade(tenantID, customerID, args)
+	case "detalharTotal":
+		return s.handleDetalharTotal(ctx, tenantID, customerID)
+	case "desfazerUltimaAdicao":
+		return s.handleDesfazerUltimaAdicao(tenantID, customerID)
 	case "removerDoCarrinho":
 		return s.handleRemoverDoCarrinho(tenantID, customerID, args)
+	case "confirmarReadicao":
+		return s.handleConfirmarReadicao(ctx, tenantID, customerID, customerPhone, args)
+	case "consolidarCarrinho":
+		return s.handleConsolidarCarrinho(ctx, tenantID, customerID)
 	case "verCarrinho":
-		return s.handleVerCarrinhoWithOptions(tenantID, customerID, true) // Full instructions for view cart
+		return s.handleVerCarrinhoWithOptions(ctx, tenantID, customerID, true) // Full instructions for view cart
 	case "limparCarrinho":
 		return s.handleLimparCarrinho(tenantID, customerID)
+	case "retomarCarrinho":
+		return s.handleRetomarCarrinho(ctx, tenantID, customerID, args)
 	case "selecionarFormaPagamento":
 		log.Info().Str("tool_name", "selecionarFormaPagamento").Msg("💳 EXECUTING SELECIONAR FORMA PAGAMENTO FUNCTION")
-		return s.handleSelecionarFormaPagamento(tenantID, customerID, args)
+		return s.handleSelecionarFormaPagamento(ctx, tenantID, customerID, args)
+	case "calcularTroco":
+		return s.handleCalcularTroco(ctx, tenantID, customerID, args)
 	case "trocarFormaPagamento":
 		log.Info().Str("tool_name", "trocarFormaPagamento").Msg("💳 EXECUTING TROCAR FORMA PAGAMENTO FUNCTION")
-		return s.handleTrocarFormaPagamento(tenantID, customerID, args)
+		return s.handleTrocarFormaPagamento(ctx, tenantID, customerID, args)
 	case "checkout":
 		log.Info().Str("tool_name", "checkout").Msg("🎯 EXECUTING CHECKOUT FUNCTION")
-		return s.handleCheckout(tenantID, customerID, customerPhone)
+		return s.handleCheckout(ctx, tenantID, customerID, customerPhone)
+	case "adicionarEFinalizar":
+		return s.handleAdicionarEFinalizar(ctx, tenantID, customerID, customerPhone, args)
+	case "usarEnderecoDeSempre":
+		return s.handleUsarEnderecoDeSempre(ctx, tenantID, customerID, customerPhone)
 	case "finalizarPedido":
 		log.Info().Str("tool_name", "finalizarPedido").Msg("🚀 EXECUTING FINALIZAR PEDIDO FUNCTION")
-		return s.performFinalCheckout(tenantID, customerID, customerPhone)
+		return s.performFinalCheckout(ctx, tenantID, customerID, customerPhone)
 	case "cancelarPedido":
 		// Adicionar customer_id aos argumentos para permitir busca na memória
 		args["customer_id"] = customerID.String()
@@ -2618,44 +3649,105 @@ func (s *AIService) executeTool(ctx context.Context, tenantID, customerID uuid.U
 		return s.handleHistoricoPedidos(tenantID, customerID)
 	case "atualizarCadastro":
 		log.Info().Str("tool_name", "atualizarCadastro").Interface("args", args).Msg("🔄 EXECUTING ATUALIZAR CADASTRO FUNCTION")
-		return s.handleAtualizarCadastro(tenantID, customerID, customerPhone, args)
+		return s.handleAtualizarCadastro(ctx, tenantID, customerID, customerPhone, args)
+	case "definirIdioma":
+		return s.handleDefinirIdioma(ctx, tenantID, customerID, args)
 	case "gerenciarEnderecos":
-		return s.handleGerenciarEnderecos(tenantID, customerID, args)
+		return s.handleGerenciarEnderecos(tenantID, customerID, customerPhone, args)
 	case "cadastrarEndereco":
-		return s.handleCadastrarEndereco(tenantID, customerID, args)
+		return s.handleCadastrarEndereco(ctx, tenantID, customerID, args)
 	case "verificarEntrega":
 		return s.handleVerificarEntrega(tenantID, args)
 	case "consultarEnderecoEmpresa":
 		return s.handleConsultarEnderecoEmpresa(tenantID, customerID, customerPhone)
 	case "solicitarAtendimentoHumano":
-		return s.handleSolicitarAtendimentoHumano(tenantID, customerID, customerPhone, args)
+		return s.handleSolicitarAtendimentoHumano(ctx, tenantID, customerID, customerPhone, args)
+	case "consultarTempoPreparo":
+		return s.handleConsultarTempoPreparo(ctx, tenantID, customerID, customerPhone, args)
+	case "detalharPedido":
+		return s.handleDetalharPedido(tenantID, customerID, args)
+	case "gerarRecibo":
+		return s.handleGerarRecibo(tenantID, customerID, args)
+	case "rastrearPedido":
+		return s.handleRastrearPedido(tenantID, customerID, args)
+	case "dividirEntrega":
+		return s.handleDividirEntrega(ctx, tenantID, customerID, args)
+	case "solicitarDevolucao":
+		return s.handleSolicitarDevolucao(ctx, tenantID, customerID, customerPhone, args)
+	case "consultarInfoNutricional":
+		return s.handleConsultarInfoNutricional(ctx, tenantID, customerPhone, args)
+	case "confirmarIdade":
+		return s.handleConfirmarIdade(tenantID, customerID, args)
+	case "calcularEconomia":
+		return s.handleCalcularEconomia(tenantID, customerID)
+	case "verEncarte":
+		return s.handleVerEncarte(ctx, tenantID, customerPhone)
+	case "retiradaNaLoja":
+		return s.handleRetiradaNaLoja(ctx, tenantID, customerID, customerPhone, args)
+	case "marcarUrgente":
+		return s.handleMarcarUrgente(ctx, tenantID, customerID, args)
+	case "pagarComSinal":
+		return s.handlePagarComSinal(ctx, tenantID, customerID, args)
+	case "consultarDescontoQuantidade":
+		return s.handleConsultarDescontoQuantidade(ctx, tenantID, customerPhone, args)
+	case "consultarPrecoQuantidade":
+		return s.handleConsultarPrecoQuantidade(ctx, tenantID, customerPhone, args)
+	case "consultarFAQ":
+		return s.handleConsultarFAQ(tenantID, args)
+	case "avisarQuandoChegar":
+		return s.handleAvisarQuandoChegar(ctx, tenantID, customerID, customerPhone, args)
+	case "maisVendidos":
+		return s.handleMaisVendidos(ctx, tenantID, customerPhone, args)
+	case "novidades":
+		return s.handleNovidades(tenantID, customerID, customerPhone)
+	case "consultarInstrucoesPagamento":
+		return s.handleConsultarInstrucoesPagamento(tenantID, customerID)
+	case "solicitarProdutoEspecial":
+		return s.handleSolicitarProdutoEspecial(ctx, tenantID, customerID, customerPhone, args)
+	case "acessoriosDoProduto":
+		return s.handleAcessoriosDoProduto(ctx, tenantID, customerPhone, args)
+	case "consultarFreteProduto":
+		return s.handleConsultarFreteProduto(ctx, tenantID, customerID, customerPhone, args)
+	case "consultarPesoCarrinho":
+		return s.handleConsultarPesoCarrinho(ctx, tenantID, customerID)
+	case "verificarCupom":
+		return s.handleVerificarCupom(tenantID, customerID, args)
+	case "consultarParcelamento":
+		return s.handleConsultarParcelamento(ctx, tenantID, customerID, args)
+	case "criarAssinatura":
+		return s.handleCriarAssinatura(ctx, tenantID, customerID, customerPhone, args)
+	case "gerenciarAssinatura":
+		return s.handleGerenciarAssinatura(ctx, tenantID, customerID, customerPhone, args)
 	default:
+		if customTool, found := s.findCustomTool(tenantID, toolName); found {
+			return s.executeCustomTool(ctx, tenantID, customerID, customerPhone, customTool, args)
+		}
 		return "", fmt.Errorf("ferramenta não reconhecida: %s", toolName)
 	}
 }
 
-// getBusinessHoursInfo retorna informações sobre horários de funcionamento
-func (s *AIService) getBusinessHoursInfo(ctx context.Context, tenantID uuid.UUID) string {
-	log.Info().Str("tenant_id", tenantID.String()).Msg("🕐 Verificando horários de funcionamento")
+// loadBusinessHours lê os horários de funcionamento do tenant e o horário atual no fuso da loja;
+// ok=false quando não há horários configurados
+func (s *AIService) loadBusinessHours(ctx context.Context, tenantID uuid.UUID) (BusinessHours, time.Time, bool) {
+	var businessHours BusinessHours
 
 	// Buscar configuração de horários
 	setting, err := s.settingsService.GetSetting(ctx, tenantID, "business_hours")
 	if err != nil {
 		log.Debug().Err(err).Msg("Horários não configurados")
-		return ""
+		return businessHours, time.Time{}, false
 	}
 
 	if setting.SettingValue == nil {
 		log.Debug().Msg("SettingValue é nil")
-		return ""
+		return businessHours, time.Time{}, false
 	}
 
 	log.Info().Str("setting_value", *setting.SettingValue).Msg("🕐 Horários encontrados")
 
-	var businessHours BusinessHours
 	if err := json.Unmarshal([]byte(*setting.SettingValue), &businessHours); err != nil {
 		log.Error().Err(err).Msg("Erro ao decodificar horários")
-		return ""
+		return businessHours, time.Time{}, false
 	}
 
 	// Determinar timezone
@@ -2671,7 +3763,17 @@ func (s *AIService) getBusinessHoursInfo(ctx context.Context, tenantID uuid.UUID
 		location = time.UTC
 	}
 
-	now := time.Now().In(location)
+	return businessHours, time.Now().In(location), true
+}
+
+// getBusinessHoursInfo retorna informações sobre horários de funcionamento
+func (s *AIService) getBusinessHoursInfo(ctx context.Context, tenantID uuid.UUID) string {
+	log.Info().Str("tenant_id", tenantID.String()).Msg("🕐 Verificando horários de funcionamento")
+
+	businessHours, now, ok := s.loadBusinessHours(ctx, tenantID)
+	if !ok {
+		return ""
+	}
 
 	// Verificar se está aberto agora
 	isOpen, nextTime := s.isStoreOpen(businessHours, now)
@@ -2693,6 +3795,7 @@ func (s *AIService) getBusinessHoursInfo(ctx context.Context, tenantID uuid.UUID
 	// Adicionar horários da semana
 	hoursInfo += "\n\nHORÁRIOS DE FUNCIONAMENTO:\n"
 	hoursInfo += s.formatWeeklyHours(businessHours)
+	hoursInfo += s.categoryHoursInfo(ctx, tenantID, businessHours)
 
 	log.Info().Str("hours_info", hoursInfo).Msg("🕐 Informações de horário geradas")
 
@@ -0,0 +1,143 @@
+package ai
+
+import (
+	"iafarma/pkg/models"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+// optionalSettings retorna as configurações com a chave informada só quando há valor (vazio = não configurado)
+func optionalSettings(key, value string) map[string]string {
+	settings := map[string]string{}
+	if value != "" {
+		settings[key] = value
+	}
+	return settings
+}
+
+// testFakes são os fakes ligados ao AIService de um teste, para asserções (nil quando o teste não usa o serviço)
+type testFakes struct {
+	settings  *fakeSettingsService
+	cart      *fakeCartService
+	customer  *fakeCustomerService
+	addresses *fakeAddressService
+	delivery  *fakeDeliveryService
+	orders    *fakeOrderService
+	products  *fakeProductService
+}
+
+// testServiceOption liga uma dependência ao AIService do teste
+type testServiceOption func(s *AIService, fakes *testFakes)
+
+// newTestService monta o AIService dos testes com as configurações do tenant em memória e a memória de conversa.
+// As demais dependências entram pelas opções, na ordem informada: cada teste liga só o que usa e substitui o que
+// precisa com withOverride.
+func newTestService(settings map[string]string, options ...testServiceOption) (*AIService, *testFakes) {
+	if settings == nil {
+		settings = map[string]string{}
+	}
+	fakes := &testFakes{settings: &fakeSettingsService{values: settings}}
+	s := &AIService{
+		settingsService: fakes.settings,
+		memoryManager:   NewMemoryManager(),
+	}
+	for _, option := range options {
+		option(s, fakes)
+	}
+	return s, fakes
+}
+
+// withCart liga um carrinho fixo
+func withCart(cart *models.Cart) testServiceOption {
+	return func(s *AIService, fakes *testFakes) {
+		fakes.cart = &fakeCartService{cart: cart}
+		s.cartService = fakes.cart
+	}
+}
+
+// withCartService liga um carrinho com comportamento próprio do teste
+func withCartService(cart CartServiceInterface) testServiceOption {
+	return withOverride(func(s *AIService) { s.cartService = cart })
+}
+
+// withCustomerService liga um serviço de clientes com comportamento próprio do teste
+func withCustomerService(customers CustomerServiceInterface) testServiceOption {
+	return withOverride(func(s *AIService) { s.customerService = customers })
+}
+
+// withClient liga a OpenAI simulada do teste
+func withClient(client *openai.Client) testServiceOption {
+	return withOverride(func(s *AIService) { s.client = client })
+}
+
+// withCustomer liga o cliente retornado pelo serviço de clientes
+func withCustomer(customer *models.Customer) testServiceOption {
+	return func(s *AIService, fakes *testFakes) {
+		fakes.customer = &fakeCustomerService{customer: customer}
+		s.customerService = fakes.customer
+	}
+}
+
+// withAddresses liga os endereços cadastrados do cliente
+func withAddresses(addresses ...models.Address) testServiceOption {
+	return func(s *AIService, fakes *testFakes) {
+		fakes.addresses = &fakeAddressService{addresses: addresses}
+		s.addressService = fakes.addresses
+	}
+}
+
+// withDelivery liga o serviço de entrega que recusa todo endereço (fora do raio)
+func withDelivery() testServiceOption {
+	return func(s *AIService, fakes *testFakes) {
+		fakes.delivery = &fakeDeliveryService{}
+		s.deliveryService = fakes.delivery
+	}
+}
+
+// withOrders liga o serviço de pedidos com os pedidos anteriores do cliente
+func withOrders(orders ...models.Order) testServiceOption {
+	return func(s *AIService, fakes *testFakes) {
+		fakes.orders = &fakeOrderService{orders: orders}
+		s.orderService = fakes.orders
+	}
+}
+
+// withProducts liga o catálogo de produtos
+func withProducts(products ...models.Product) testServiceOption {
+	return func(s *AIService, fakes *testFakes) {
+		fakes.products = &fakeProductService{products: products}
+		s.productService = fakes.products
+	}
+}
+
+// withCategories liga as categorias do catálogo
+func withCategories(categories ...models.Category) testServiceOption {
+	return withOverride(func(s *AIService) { s.categoryService = &fakeCategoryService{categories: categories} })
+}
+
+// withCheckout liga o necessário para fechar um pedido: carrinho, cliente "Maria", endereços, entrega e pedidos
+func withCheckout(cart *models.Cart, addresses ...models.Address) testServiceOption {
+	return withOptions(
+		withCart(cart),
+		withCustomer(&models.Customer{Name: "Maria"}),
+		withAddresses(addresses...),
+		withDelivery(),
+		withOrders(),
+	)
+}
+
+// withOptions agrupa várias opções em uma
+func withOptions(options ...testServiceOption) testServiceOption {
+	return func(s *AIService, fakes *testFakes) {
+		for _, option := range options {
+			option(s, fakes)
+		}
+	}
+}
+
+// withOverride aplica as dependências próprias do teste (fakes específicos, cliente da OpenAI etc.)
+func withOverride(override func(s *AIService)) testServiceOption {
+	return func(s *AIService, fakes *testFakes) {
+		override(s)
+	}
+}
@@ -0,0 +1,110 @@
+package ai
+
+import (
+	"sync"
+	"time"
+
+	"iafarma/pkg/models"
+
+	"github.com/google/uuid"
+)
+
+// settingsCacheTTL limita por quanto tempo as configurações pré-carregadas valem, cobrindo alterações
+// gravadas por outras instâncias da API (as desta instância invalidam o cache na hora)
+const settingsCacheTTL = 5 * time.Minute
+
+// tenantSettingsCache guarda todas as configurações ativas de cada tenant, carregadas de uma vez,
+// para que a primeira mensagem não dispare uma consulta por configuração
+type tenantSettingsCache struct {
+	mu          sync.RWMutex
+	tenants     map[uuid.UUID]cachedTenantSettings
+	generations map[uuid.UUID]uint64
+	ttl         time.Duration
+	now         func() time.Time
+}
+
+type cachedTenantSettings struct {
+	settings map[string]models.TenantSetting
+	loadedAt time.Time
+}
+
+func newTenantSettingsCache(ttl time.Duration) *tenantSettingsCache {
+	return &tenantSettingsCache{
+		tenants:     make(map[uuid.UUID]cachedTenantSettings),
+		generations: make(map[uuid.UUID]uint64),
+		ttl:         ttl,
+		now:         time.Now,
+	}
+}
+
+// sharedSettingsCache é compartilhado por todos os TenantSettingsService do processo, assim a gravação
+// feita pelo painel invalida o que o pipeline da IA lê
+var sharedSettingsCache = newTenantSettingsCache(settingsCacheTTL)
+
+// get retorna a configuração do cache. O segundo retorno indica se o tenant está carregado: nesse caso
+// uma configuração nil significa que ela não existe (ou está inativa), sem precisar consultar o banco.
+func (c *tenantSettingsCache) get(tenantID uuid.UUID, key string) (*models.TenantSetting, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	entry, ok := c.tenants[tenantID]
+	if !ok || c.now().Sub(entry.loadedAt) > c.ttl {
+		return nil, false
+	}
+	setting, ok := entry.settings[key]
+	if !ok {
+		return nil, true
+	}
+	// Cópia para que o chamador não altere o valor guardado
+	if setting.SettingValue != nil {
+		value := *setting.SettingValue
+		setting.SettingValue = &value
+	}
+	return &setting, true
+}
+
+// all retorna todas as configurações carregadas do tenant. O mapa nunca é alterado depois de guardado
+// (uma nova carga substitui a entrada inteira), então pode ser compartilhado sem cópia.
+func (c *tenantSettingsCache) all(tenantID uuid.UUID) (map[string]models.TenantSetting, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	entry, ok := c.tenants[tenantID]
+	if !ok || c.now().Sub(entry.loadedAt) > c.ttl {
+		return nil, false
+	}
+	return entry.settings, true
+}
+
+// warmUp carrega todas as configurações do tenant. Se o tenant for invalidado durante a carga,
+// o resultado é descartado para não guardar valores anteriores à gravação.
+func (c *tenantSettingsCache) warmUp(tenantID uuid.UUID, load func() ([]models.TenantSetting, error)) error {
+	c.mu.RLock()
+	generation := c.generations[tenantID]
+	c.mu.RUnlock()
+
+	settings, err := load()
+	if err != nil {
+		return err
+	}
+
+	entry := cachedTenantSettings{settings: make(map[string]models.TenantSetting, len(settings)), loadedAt: c.now()}
+	for _, setting := range settings {
+		entry.settings[setting.SettingKey] = setting
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.generations[tenantID] == generation {
+		c.tenants[tenantID] = entry
+	}
+	return nil
+}
+
+// invalidate descarta as configurações do tenant; a próxima leitura carrega os valores atualizados
+func (c *tenantSettingsCache) invalidate(tenantID uuid.UUID) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.tenants, tenantID)
+	c.generations[tenantID]++
+}
@@ -0,0 +1,155 @@
+package ai
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"iafarma/pkg/models"
+
+	"github.com/google/uuid"
+)
+
+// countingSettingsLoader simula a consulta das configurações do tenant e conta quantas vezes o banco foi lido
+type countingSettingsLoader struct {
+	calls    int
+	settings []models.TenantSetting
+	err      error
+}
+
+func (l *countingSettingsLoader) load() ([]models.TenantSetting, error) {
+	l.calls++
+	return l.settings, l.err
+}
+
+func newSetting(key, value string) models.TenantSetting {
+	return models.TenantSetting{SettingKey: key, SettingValue: &value, IsActive: true}
+}
+
+func TestTenantSettingsCacheHitsAfterWarmUp(t *testing.T) {
+	tenantID := uuid.New()
+	cache := newTenantSettingsCache(time.Minute)
+	loader := &countingSettingsLoader{settings: []models.TenantSetting{
+		newSetting("ai_system_prompt_template", "Você é a atendente da farmácia"),
+		newSetting("business_hours", "08:00-18:00"),
+	}}
+
+	if _, cached := cache.get(tenantID, "ai_system_prompt_template"); cached {
+		t.Fatalf("tenant não deveria estar em cache antes do warm-up")
+	}
+	if err := cache.warmUp(tenantID, loader.load); err != nil {
+		t.Fatalf("erro inesperado: %v", err)
+	}
+
+	for i := 0; i < 3; i++ {
+		setting, cached := cache.get(tenantID, "ai_system_prompt_template")
+		if !cached || setting == nil || *setting.SettingValue != "Você é a atendente da farmácia" {
+			t.Fatalf("esperado prompt em cache, obtido %+v (cached=%v)", setting, cached)
+		}
+	}
+	// Configuração ausente também é respondida pelo cache, sem consultar o banco
+	if setting, cached := cache.get(tenantID, "ai_context_limitation_custom"); !cached || setting != nil {
+		t.Errorf("esperada configuração ausente respondida pelo cache, obtido %+v (cached=%v)", setting, cached)
+	}
+	if loader.calls != 1 {
+		t.Errorf("esperada 1 consulta ao banco, obtido %d", loader.calls)
+	}
+
+	// Outros tenants continuam frios
+	if _, cached := cache.get(uuid.New(), "ai_system_prompt_template"); cached {
+		t.Errorf("outro tenant não deveria estar em cache")
+	}
+}
+
+func TestTenantSettingsCacheReturnsCopies(t *testing.T) {
+	tenantID := uuid.New()
+	cache := newTenantSettingsCache(time.Minute)
+	loader := &countingSettingsLoader{settings: []models.TenantSetting{newSetting("ai_global_enabled", "true")}}
+	if err := cache.warmUp(tenantID, loader.load); err != nil {
+		t.Fatalf("erro inesperado: %v", err)
+	}
+
+	setting, _ := cache.get(tenantID, "ai_global_enabled")
+	*setting.SettingValue = "false"
+
+	if again, _ := cache.get(tenantID, "ai_global_enabled"); *again.SettingValue != "true" {
+		t.Errorf("alterar o valor retornado não deveria mudar o cache, obtido %q", *again.SettingValue)
+	}
+}
+
+func TestTenantSettingsCacheInvalidateOnUpdate(t *testing.T) {
+	tenantID := uuid.New()
+	cache := newTenantSettingsCache(time.Minute)
+	loader := &countingSettingsLoader{settings: []models.TenantSetting{newSetting("business_hours", "08:00-18:00")}}
+	if err := cache.warmUp(tenantID, loader.load); err != nil {
+		t.Fatalf("erro inesperado: %v", err)
+	}
+
+	// SetSetting grava o novo valor e invalida o tenant
+	loader.settings = []models.TenantSetting{newSetting("business_hours", "09:00-20:00")}
+	cache.invalidate(tenantID)
+
+	if _, cached := cache.get(tenantID, "business_hours"); cached {
+		t.Fatalf("tenant deveria sair do cache depois da gravação")
+	}
+	if err := cache.warmUp(tenantID, loader.load); err != nil {
+		t.Fatalf("erro inesperado: %v", err)
+	}
+	if setting, _ := cache.get(tenantID, "business_hours"); setting == nil || *setting.SettingValue != "09:00-20:00" {
+		t.Errorf("esperado valor atualizado, obtido %+v", setting)
+	}
+	if loader.calls != 2 {
+		t.Errorf("esperadas 2 consultas ao banco, obtido %d", loader.calls)
+	}
+}
+
+func TestTenantSettingsCacheDropsLoadRacingInvalidation(t *testing.T) {
+	tenantID := uuid.New()
+	cache := newTenantSettingsCache(time.Minute)
+
+	// A gravação acontece enquanto o warm-up ainda lê os valores antigos
+	stale := func() ([]models.TenantSetting, error) {
+		cache.invalidate(tenantID)
+		return []models.TenantSetting{newSetting("business_hours", "08:00-18:00")}, nil
+	}
+	if err := cache.warmUp(tenantID, stale); err != nil {
+		t.Fatalf("erro inesperado: %v", err)
+	}
+	if _, cached := cache.get(tenantID, "business_hours"); cached {
+		t.Errorf("valores lidos antes da gravação não deveriam ficar em cache")
+	}
+}
+
+func TestTenantSettingsCacheExpires(t *testing.T) {
+	tenantID := uuid.New()
+	now := time.Date(2025, 3, 10, 9, 0, 0, 0, time.UTC)
+	cache := newTenantSettingsCache(5 * time.Minute)
+	cache.now = func() time.Time { return now }
+
+	loader := &countingSettingsLoader{settings: []models.TenantSetting{newSetting("ai_global_enabled", "true")}}
+	if err := cache.warmUp(tenantID, loader.load); err != nil {
+		t.Fatalf("erro inesperado: %v", err)
+	}
+
+	now = now.Add(4 * time.Minute)
+	if _, cached := cache.get(tenantID, "ai_global_enabled"); !cached {
+		t.Errorf("cache deveria valer dentro do TTL")
+	}
+	now = now.Add(2 * time.Minute)
+	if _, cached := cache.get(tenantID, "ai_global_enabled"); cached {
+		t.Errorf("cache deveria expirar depois do TTL")
+	}
+}
+
+func TestTenantSettingsCacheWarmUpError(t *testing.T) {
+	tenantID := uuid.New()
+	cache := newTenantSettingsCache(time.Minute)
+	loader := &countingSettingsLoader{err: errors.New("connection refused")}
+
+	if err := cache.warmUp(tenantID, loader.load); err == nil {
+		t.Fatalf("esperado erro do banco")
+	}
+	if _, cached := cache.get(tenantID, "ai_global_enabled"); cached {
+		t.Errorf("falha no warm-up não deveria marcar o tenant como carregado")
+	}
+}
@@ -0,0 +1,68 @@
+package ai
+
+import (
+	"context"
+
+	"iafarma/pkg/models"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+)
+
+// settingsSnapshot guarda as configurações do tenant lidas no início do processamento de uma mensagem.
+// Todas as leituras da mesma requisição usam esses valores, mesmo que o painel altere algo no meio do caminho.
+type settingsSnapshot struct {
+	tenantID uuid.UUID
+	settings map[string]models.TenantSetting
+}
+
+type settingsSnapshotContextKey struct{}
+
+// settingsSnapshotter fixa as configurações do tenant no contexto de uma requisição
+type settingsSnapshotter interface {
+	WithSnapshot(ctx context.Context, tenantID uuid.UUID) (context.Context, error)
+}
+
+// withSettingsSnapshot anexa o snapshot ao contexto da requisição
+func withSettingsSnapshot(ctx context.Context, snapshot *settingsSnapshot) context.Context {
+	return context.WithValue(ctx, settingsSnapshotContextKey{}, snapshot)
+}
+
+// settingsSnapshotFromContext retorna o snapshot da requisição para o tenant, ou nil quando não há um
+func settingsSnapshotFromContext(ctx context.Context, tenantID uuid.UUID) *settingsSnapshot {
+	snapshot, ok := ctx.Value(settingsSnapshotContextKey{}).(*settingsSnapshot)
+	if !ok || snapshot == nil || snapshot.tenantID != tenantID {
+		return nil
+	}
+	return snapshot
+}
+
+// get retorna uma cópia da configuração; ausente (ou inativa) responde como o banco, com ErrRecordNotFound
+func (s *settingsSnapshot) get(key string) (*models.TenantSetting, error) {
+	setting, ok := s.settings[key]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	if setting.SettingValue != nil {
+		value := *setting.SettingValue
+		setting.SettingValue = &value
+	}
+	return &setting, nil
+}
+
+// withSettingsSnapshot carrega as configurações do tenant uma única vez para a mensagem em processamento.
+// Sem snapshot (falha na carga ou serviço sem suporte), as leituras seguem consultando o serviço normalmente.
+func (s *AIService) withSettingsSnapshot(ctx context.Context, tenantID uuid.UUID) context.Context {
+	snapshotter, ok := s.settingsService.(settingsSnapshotter)
+	if !ok {
+		return ctx
+	}
+
+	snapshotCtx, err := snapshotter.WithSnapshot(ctx, tenantID)
+	if err != nil {
+		log.Warn().Err(err).Str("tenant_id", tenantID.String()).Msg("⚠️ Não foi possível carregar o snapshot das configurações")
+		return ctx
+	}
+	return snapshotCtx
+}
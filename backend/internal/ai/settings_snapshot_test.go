@@ -0,0 +1,126 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"iafarma/pkg/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// newSnapshotTestService cria o serviço de configurações com o cache já carregado (sem banco)
+func newSnapshotTestService(t *testing.T, tenantID uuid.UUID, settings ...models.TenantSetting) (*TenantSettingsService, *countingSettingsLoader) {
+	t.Helper()
+	loader := &countingSettingsLoader{settings: settings}
+	service := &TenantSettingsService{cache: newTenantSettingsCache(time.Minute)}
+	if err := service.cache.warmUp(tenantID, loader.load); err != nil {
+		t.Fatalf("erro inesperado: %v", err)
+	}
+	return service, loader
+}
+
+// updateSetting simula a gravação feita pelo painel: invalida o cache e recarrega com o novo valor
+func updateSetting(t *testing.T, service *TenantSettingsService, loader *countingSettingsLoader, tenantID uuid.UUID, settings ...models.TenantSetting) {
+	t.Helper()
+	loader.settings = settings
+	service.cache.invalidate(tenantID)
+	if err := service.cache.warmUp(tenantID, loader.load); err != nil {
+		t.Fatalf("erro inesperado: %v", err)
+	}
+}
+
+func TestSettingsSnapshotPinsValuesForRequest(t *testing.T) {
+	tenantID := uuid.New()
+	service, loader := newSnapshotTestService(t, tenantID,
+		newSetting("ai_system_prompt_template", "Você é a atendente da farmácia"),
+		newSetting("business_hours", "08:00-18:00"),
+	)
+
+	ctx, err := service.WithSnapshot(context.Background(), tenantID)
+	if err != nil {
+		t.Fatalf("erro inesperado: %v", err)
+	}
+
+	// O admin altera o horário enquanto a mensagem ainda está sendo processada
+	updateSetting(t, service, loader, tenantID,
+		newSetting("ai_system_prompt_template", "Você é a atendente da farmácia"),
+		newSetting("business_hours", "09:00-20:00"),
+		newSetting("ai_context_limitation_custom", "Fale apenas de produtos"),
+	)
+
+	if setting, err := service.GetSetting(ctx, tenantID, "business_hours"); err != nil || *setting.SettingValue != "08:00-18:00" {
+		t.Errorf("requisição em andamento deveria manter o horário antigo, obtido %+v (err=%v)", setting, err)
+	}
+	// Configuração criada no meio do caminho também não aparece para a requisição em andamento
+	if _, err := service.GetSetting(ctx, tenantID, "ai_context_limitation_custom"); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Errorf("esperado ErrRecordNotFound para configuração criada depois do snapshot, obtido %v", err)
+	}
+
+	// A próxima mensagem já enxerga o novo valor
+	if setting, err := service.GetSetting(context.Background(), tenantID, "business_hours"); err != nil || *setting.SettingValue != "09:00-20:00" {
+		t.Errorf("nova requisição deveria ver o horário atualizado, obtido %+v (err=%v)", setting, err)
+	}
+}
+
+func TestSettingsSnapshotReturnsCopiesAndIgnoresOtherTenants(t *testing.T) {
+	tenantID, otherTenantID := uuid.New(), uuid.New()
+	service, _ := newSnapshotTestService(t, tenantID, newSetting("ai_global_enabled", "true"))
+	if err := service.cache.warmUp(otherTenantID, (&countingSettingsLoader{settings: []models.TenantSetting{newSetting("ai_global_enabled", "false")}}).load); err != nil {
+		t.Fatalf("erro inesperado: %v", err)
+	}
+
+	ctx, err := service.WithSnapshot(context.Background(), tenantID)
+	if err != nil {
+		t.Fatalf("erro inesperado: %v", err)
+	}
+
+	setting, _ := service.GetSetting(ctx, tenantID, "ai_global_enabled")
+	*setting.SettingValue = "false"
+	if again, _ := service.GetSetting(ctx, tenantID, "ai_global_enabled"); *again.SettingValue != "true" {
+		t.Errorf("alterar o valor retornado não deveria mudar o snapshot, obtido %q", *again.SettingValue)
+	}
+
+	// O snapshot vale só para o tenant da mensagem
+	if other, err := service.GetSetting(ctx, otherTenantID, "ai_global_enabled"); err != nil || *other.SettingValue != "false" {
+		t.Errorf("outro tenant deveria ler os próprios valores, obtido %+v (err=%v)", other, err)
+	}
+}
+
+func TestInFlightRequestKeepsConfigWhenSettingChanges(t *testing.T) {
+	tenantID := uuid.New()
+	service, loader := newSnapshotTestService(t, tenantID,
+		newSetting(DeliveryFeeSettingKey, "8.00"),
+		newSetting(FreeShippingMinAmountSettingKey, "100.00"),
+	)
+	s := &AIService{settingsService: service}
+
+	// Início do processamento: o pipeline fixa as configurações
+	ctx := s.withSettingsSnapshot(context.Background(), tenantID)
+	before := s.getDeliveryFeeConfig(ctx, tenantID)
+
+	updateSetting(t, service, loader, tenantID,
+		newSetting(DeliveryFeeSettingKey, "12.00"),
+		newSetting(FreeShippingMinAmountSettingKey, "150.00"),
+	)
+
+	// Leituras seguintes da mesma mensagem (carrinho, checkout) veem os mesmos valores
+	if during := s.getDeliveryFeeConfig(ctx, tenantID); during.Fee != before.Fee || during.FreeShippingMin != before.FreeShippingMin || during.Fee != 8 {
+		t.Errorf("configuração mudou no meio da requisição: antes %+v, depois %+v", before, during)
+	}
+	if next := s.getDeliveryFeeConfig(s.withSettingsSnapshot(context.Background(), tenantID), tenantID); next.Fee != 12 || next.FreeShippingMin != 150 {
+		t.Errorf("próxima mensagem deveria usar os valores novos, obtido %+v", next)
+	}
+}
+
+func TestWithSettingsSnapshotWithoutSupport(t *testing.T) {
+	// Serviços sem snapshot (como os fakes dos testes) seguem lendo direto
+	s := &AIService{settingsService: &fakeSettingsService{values: map[string]string{"business_hours": "08:00-18:00"}}}
+	ctx := context.Background()
+	if got := s.withSettingsSnapshot(ctx, uuid.New()); got != ctx {
+		t.Errorf("contexto não deveria mudar quando o serviço não suporta snapshot")
+	}
+}
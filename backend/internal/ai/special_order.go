@@ -0,0 +1,63 @@
+package ai
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"iafarma/pkg/models"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+)
+
+// maxSpecialOrderDetailsLength respeita o tamanho da coluna de observações da demanda
+const maxSpecialOrderDetailsLength = 500
+
+// specialOrderDescription junta produto e observações para o alerta dos operadores
+func specialOrderDescription(demand *models.MissingProductDemand) string {
+	if demand.Details == "" {
+		return demand.Query
+	}
+	return fmt.Sprintf("%s (%s)", demand.Query, demand.Details)
+}
+
+// handleSolicitarProdutoEspecial registra o pedido de um produto que a loja não tem no catálogo e avisa
+// a equipe. Diferente da busca sem resultado, é um pedido explícito e independe da configuração de demanda.
+func (s *AIService) handleSolicitarProdutoEspecial(ctx context.Context, tenantID, customerID uuid.UUID, customerPhone string, args map[string]interface{}) (string, error) {
+	produto, _ := args["produto"].(string)
+	observacoes, _ := args["observacoes"].(string)
+
+	demand := s.newMissingDemand(tenantID, customerID, customerPhone, produto)
+	if demand == nil {
+		return "❌ Qual produto você gostaria que a gente providenciasse? Me diga o nome (e, se souber, marca ou apresentação).", nil
+	}
+	demand.Source = models.MissingDemandSourceSpecialOrder
+	demand.Details = strings.TrimSpace(observacoes)
+	if runes := []rune(demand.Details); len(runes) > maxSpecialOrderDetailsLength {
+		demand.Details = string(runes[:maxSpecialOrderDetailsLength])
+	}
+
+	if s.missingDemandService != nil {
+		if err := s.missingDemandService.RecordSpecialOrder(demand); err != nil {
+			return "❌ Não consegui registrar seu pedido agora. Tente novamente em instantes.", err
+		}
+	}
+
+	if s.alertService != nil {
+		if err := s.alertService.SendSpecialOrderAlert(tenantID, customerID, customerPhone, specialOrderDescription(demand)); err != nil {
+			log.Error().Err(err).
+				Str("tenant_id", tenantID.String()).
+				Str("customer_phone", customerPhone).
+				Msg("❌ Erro ao enviar alerta de pedido especial")
+		}
+	}
+
+	log.Info().
+		Str("tenant_id", tenantID.String()).
+		Str("customer_id", customerID.String()).
+		Str("query", demand.NormalizedQuery).
+		Msg("📦 Pedido especial registrado")
+
+	return fmt.Sprintf("📝 Anotei seu pedido especial: *%s*.\n\nNossa equipe vai verificar se conseguimos trazer esse produto e te dá um retorno por aqui. 🙂\n\nPosso ajudar com mais alguma coisa?", demand.Query), nil
+}
@@ -0,0 +1,98 @@
+package ai
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"iafarma/pkg/models"
+
+	"github.com/google/uuid"
+)
+
+// recordingAlertService guarda os alertas de pedido especial enviados à equipe
+type recordingAlertService struct {
+	fakeAlertService
+	specialOrders []string
+}
+
+func (r *recordingAlertService) SendSpecialOrderAlert(tenantID uuid.UUID, customerID uuid.UUID, customerPhone string, description string) error {
+	r.specialOrders = append(r.specialOrders, description)
+	return nil
+}
+
+// withSpecialOrders liga o registro de encomendas e os alertas da loja
+func withSpecialOrders(demands *fakeMissingDemandService, alerts *recordingAlertService) testServiceOption {
+	return withOptions(withMissingDemands(demands), withOverride(func(s *AIService) { s.alertService = alerts }))
+}
+
+func TestSolicitarProdutoEspecialRegistraENotifica(t *testing.T) {
+	demands := &fakeMissingDemandService{}
+	alerts := &recordingAlertService{}
+	service, _ := newTestService(nil, withSpecialOrders(demands, alerts))
+
+	response, err := service.handleSolicitarProdutoEspecial(context.Background(), uuid.New(), uuid.New(), "5511999999999", map[string]interface{}{
+		"produto":     "  Colírio Lacrifilm ",
+		"observacoes": "frasco de 15ml, 2 unidades",
+	})
+	if err != nil {
+		t.Fatalf("erro inesperado: %v", err)
+	}
+
+	// Pedido explícito é registrado mesmo com o log de demanda desativado
+	if len(demands.special) != 1 || len(demands.recorded) != 0 {
+		t.Fatalf("esperado 1 pedido especial e nenhuma busca sem resultado, obtido %d e %d", len(demands.special), len(demands.recorded))
+	}
+	demand := demands.special[0]
+	if demand.Source != models.MissingDemandSourceSpecialOrder {
+		t.Errorf("esperado origem %q, obtido %q", models.MissingDemandSourceSpecialOrder, demand.Source)
+	}
+	if demand.Query != "Colírio Lacrifilm" || demand.NormalizedQuery != "colirio lacrifilm" {
+		t.Errorf("termo inesperado: %q / %q", demand.Query, demand.NormalizedQuery)
+	}
+	if demand.Details != "frasco de 15ml, 2 unidades" {
+		t.Errorf("observações inesperadas: %q", demand.Details)
+	}
+
+	if len(alerts.specialOrders) != 1 || alerts.specialOrders[0] != "Colírio Lacrifilm (frasco de 15ml, 2 unidades)" {
+		t.Errorf("alerta inesperado: %v", alerts.specialOrders)
+	}
+	if !strings.Contains(response, "pedido especial") || !strings.Contains(response, "verificar") {
+		t.Errorf("resposta deveria confirmar o pedido ao cliente, obtido %q", response)
+	}
+}
+
+func TestSolicitarProdutoEspecialSemProduto(t *testing.T) {
+	demands := &fakeMissingDemandService{}
+	alerts := &recordingAlertService{}
+	service, _ := newTestService(nil, withSpecialOrders(demands, alerts))
+
+	response, err := service.handleSolicitarProdutoEspecial(context.Background(), uuid.New(), uuid.New(), "5511999999999", map[string]interface{}{
+		"produto": "   ",
+	})
+	if err != nil {
+		t.Fatalf("erro inesperado: %v", err)
+	}
+	if len(demands.special) != 0 || len(alerts.specialOrders) != 0 {
+		t.Errorf("nada deveria ser registrado sem produto, obtido %d pedidos e %d alertas", len(demands.special), len(alerts.specialOrders))
+	}
+	if !strings.Contains(response, "Qual produto") {
+		t.Errorf("esperado pedido do nome do produto, obtido %q", response)
+	}
+}
+
+func TestSpecialOrderDescription(t *testing.T) {
+	tests := []struct {
+		demand   models.MissingProductDemand
+		esperado string
+	}{
+		{models.MissingProductDemand{Query: "Ozempic"}, "Ozempic"},
+		{models.MissingProductDemand{Query: "Ozempic", Details: "caneta 1mg"}, "Ozempic (caneta 1mg)"},
+	}
+
+	for _, tt := range tests {
+		if got := specialOrderDescription(&tt.demand); got != tt.esperado {
+			t.Errorf("esperado %q, obtido %q", tt.esperado, got)
+		}
+	}
+}
@@ -0,0 +1,298 @@
+package ai
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"iafarma/internal/utils"
+	"iafarma/pkg/models"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+)
+
+// SplitDeliverySettingKey habilita dividir um pedido entre endereços (ex: parte para presente), gerando um pedido por
+// endereço. Desabilitado por padrão.
+const SplitDeliverySettingKey = "allow_split_delivery"
+
+// deliveryGroup reúne os itens do carrinho que vão para o mesmo endereço
+type deliveryGroup struct {
+	Address *models.Address
+	Items   []models.CartItem
+}
+
+// CartOrderGroup são os itens do carrinho que viram um pedido para o endereço informado (ItemIDs nil = todos os itens)
+type CartOrderGroup struct {
+	ItemIDs         []uuid.UUID
+	DeliveryAddress *models.Address
+}
+
+// SelectCartItems mantém só os itens informados, na ordem do carrinho
+func SelectCartItems(items []models.CartItem, itemIDs []uuid.UUID) []models.CartItem {
+	wanted := make(map[uuid.UUID]bool, len(itemIDs))
+	for _, id := range itemIDs {
+		wanted[id] = true
+	}
+	var selected []models.CartItem
+	for _, item := range items {
+		if wanted[item.ID] {
+			selected = append(selected, item)
+		}
+	}
+	return selected
+}
+
+// isSplitDeliveryEnabled indica se o tenant permite dividir o pedido entre endereços
+func (s *AIService) isSplitDeliveryEnabled(ctx context.Context, tenantID uuid.UUID) bool {
+	if s.settingsService == nil {
+		return false
+	}
+
+	setting, err := s.settingsService.GetSetting(ctx, tenantID, SplitDeliverySettingKey)
+	if err != nil || setting == nil || setting.SettingValue == nil {
+		return false
+	}
+
+	enabled, err := strconv.ParseBool(strings.TrimSpace(*setting.SettingValue))
+	return err == nil && enabled
+}
+
+// cartHasSplitDelivery indica se algum item do carrinho vai para um endereço diferente do principal
+func cartHasSplitDelivery(cart *models.Cart, main *models.Address) bool {
+	for _, item := range cart.Items {
+		if item.DeliveryAddressID != nil && (main == nil || *item.DeliveryAddressID != main.ID) {
+			return true
+		}
+	}
+	return false
+}
+
+// splitCartByAddress agrupa os itens por endereço de entrega. Itens sem endereço próprio (ou cujo endereço foi
+// removido) vão para o endereço principal, que vem primeiro; os demais seguem a ordem dos endereços do cliente.
+func splitCartByAddress(cart *models.Cart, main *models.Address, addresses []models.Address) []deliveryGroup {
+	groups := []deliveryGroup{{Address: main}}
+	index := map[uuid.UUID]int{main.ID: 0}
+	for i := range addresses {
+		if _, ok := index[addresses[i].ID]; !ok {
+			index[addresses[i].ID] = len(groups)
+			groups = append(groups, deliveryGroup{Address: &addresses[i]})
+		}
+	}
+
+	for _, item := range cart.Items {
+		target := 0
+		if item.DeliveryAddressID != nil {
+			if i, ok := index[*item.DeliveryAddressID]; ok {
+				target = i
+			}
+		}
+		groups[target].Items = append(groups[target].Items, item)
+	}
+
+	var result []deliveryGroup
+	for _, group := range groups {
+		if len(group.Items) > 0 {
+			result = append(result, group)
+		}
+	}
+	return result
+}
+
+// formatShortAddress mostra o endereço em uma linha
+func formatShortAddress(address models.Address) string {
+	return strings.ReplaceAll(formatAddressForDisplay(address), "\n", ", ")
+}
+
+// formatDeliveryGroups lista para onde vai cada item
+func formatDeliveryGroups(groups []deliveryGroup) string {
+	var result strings.Builder
+	for i, group := range groups {
+		result.WriteString(fmt.Sprintf("📍 **Entrega %d:** %s\n", i+1, formatShortAddress(*group.Address)))
+		for _, item := range group.Items {
+			result.WriteString(fmt.Sprintf("   • %dx %s\n", item.Quantity, getItemName(item)))
+		}
+	}
+	return strings.TrimSuffix(result.String(), "\n")
+}
+
+// handleDividirEntrega manda itens do carrinho para outro endereço cadastrado ("manda o perfume para a casa da minha
+// mãe"). Na finalização, cada endereço vira um pedido separado.
+func (s *AIService) handleDividirEntrega(ctx context.Context, tenantID, customerID uuid.UUID, args map[string]interface{}) (string, error) {
+	if !s.isSplitDeliveryEnabled(ctx, tenantID) {
+		return "🚚 No momento não conseguimos dividir um pedido entre endereços. Você pode fazer um pedido para cada endereço.", nil
+	}
+
+	cart, err := s.cartService.GetOrCreateActiveCart(tenantID, customerID)
+	if err != nil {
+		return "❌ Erro ao acessar carrinho.", err
+	}
+	cartWithItems, err := s.cartService.GetCartWithItems(cart.ID, tenantID)
+	if err != nil {
+		return "❌ Erro ao carregar carrinho.", err
+	}
+	if len(cartWithItems.Items) == 0 {
+		return "🛒 Seu carrinho está vazio! Adicione produtos antes de escolher os endereços de entrega.", nil
+	}
+	if s.isPickupCart(ctx, tenantID, cartWithItems) {
+		return "🏪 Seu pedido está marcado para retirada na loja. Para dividir a entrega entre endereços, escolha entrega em vez de retirada.", nil
+	}
+
+	addresses, err := s.addressService.GetAddressesByCustomer(tenantID, customerID)
+	if err != nil {
+		return "❌ Erro ao buscar endereços.", err
+	}
+	if len(addresses) < 2 {
+		return "📍 Para dividir a entrega, cadastre também o outro endereço. Informe o endereço completo (rua, número, bairro, cidade, estado e CEP).", nil
+	}
+
+	addressNumber, ok := args["numero_endereco"].(float64)
+	if !ok || int(addressNumber) < 1 || int(addressNumber) > len(addresses) {
+		return fmt.Sprintf("❌ Informe para qual endereço enviar os itens:\n\n%s", formatAddressesForSelection(addresses)), nil
+	}
+	address := addresses[int(addressNumber)-1]
+
+	rawItems, _ := args["itens"].([]interface{})
+	if len(rawItems) == 0 {
+		return "❌ Informe os números dos itens do carrinho que vão para esse endereço. Use 'ver carrinho' para conferir os números.", nil
+	}
+	var selected []models.CartItem
+	for _, raw := range rawItems {
+		number, ok := raw.(float64)
+		if !ok || int(number) < 1 || int(number) > len(cartWithItems.Items) {
+			return fmt.Sprintf("❌ Item %v não encontrado no carrinho. Use 'ver carrinho' para conferir os números.", raw), nil
+		}
+		selected = append(selected, cartWithItems.Items[int(number)-1])
+	}
+
+	for _, item := range selected {
+		if err := s.cartService.SetCartItemAddress(cart.ID, tenantID, item.ID, &address.ID); err != nil {
+			return "❌ Erro ao definir o endereço dos itens.", err
+		}
+	}
+
+	updated, err := s.cartService.GetCartWithItems(cart.ID, tenantID)
+	if err != nil {
+		return "❌ Erro ao carregar carrinho.", err
+	}
+
+	log.Info().
+		Str("tenant_id", tenantID.String()).
+		Str("customer_id", customerID.String()).
+		Str("address_id", address.ID.String()).
+		Int("items", len(selected)).
+		Msg("🎁 Itens do carrinho direcionados para outro endereço")
+
+	groups := splitCartByAddress(updated, mainDeliveryAddress(addresses), addresses)
+	if len(groups) == 1 {
+		return fmt.Sprintf("✅ Todos os itens serão entregues em:\n📍 %s\n\n🛍️ Quando quiser, é só finalizar o pedido.", formatShortAddress(*groups[0].Address)), nil
+	}
+	return fmt.Sprintf("✅ **Entrega dividida!** Cada endereço será um pedido separado, com sua própria taxa de entrega:\n\n%s\n\n🛍️ Quando quiser, é só finalizar o pedido.", formatDeliveryGroups(groups)), nil
+}
+
+// performSplitCheckout finaliza o carrinho com um pedido por endereço. Todos os endereços são validados antes de
+// criar qualquer pedido; o endereço principal já chega validado.
+func (s *AIService) performSplitCheckout(ctx context.Context, tenantID, customerID uuid.UUID, customerPhone string, cart *models.Cart, main *models.Address, mainManual bool) (string, error) {
+	addresses, err := s.addressService.GetAddressesByCustomer(tenantID, customerID)
+	if err != nil {
+		return "❌ Erro ao buscar endereços.", err
+	}
+	groups := splitCartByAddress(cart, main, addresses)
+
+	manual := make([]bool, len(groups))
+	for i, group := range groups {
+		if group.Address.ID == main.ID {
+			manual[i] = mainManual
+			continue
+		}
+		groupManual, blockMessage, err := s.validateDeliveryToAddress(ctx, tenantID, customerID, group.Address)
+		if blockMessage != "" {
+			return fmt.Sprintf("%s\n\n🎁 Itens que iriam para este endereço:\n%s\n\n💡 Você também pode mandar esses itens para outro endereço cadastrado.",
+				blockMessage, formatDeliveryGroups([]deliveryGroup{group})), err
+		}
+		manual[i] = groupManual
+	}
+
+	conversationID := s.getConversationID(tenantID, customerPhone)
+	urgent := s.isUrgentCart(ctx, tenantID, cart)
+
+	orderGroups := make([]CartOrderGroup, 0, len(groups))
+	for _, group := range groups {
+		itemIDs := make([]uuid.UUID, 0, len(group.Items))
+		for _, item := range group.Items {
+			itemIDs = append(itemIDs, item.ID)
+		}
+		orderGroups = append(orderGroups, CartOrderGroup{ItemIDs: itemIDs, DeliveryAddress: group.Address})
+	}
+
+	// Todos os pedidos são criados juntos: se um falhar, nenhum fica gravado e o carrinho continua como estava
+	created, err := s.orderService.CreateOrdersFromCartGroups(tenantID, cart.ID, conversationID, orderGroups)
+	if err != nil {
+		log.Error().Err(err).Int("orders", len(orderGroups)).Msg("Erro ao criar pedidos da entrega dividida")
+		return "❌ Erro ao criar pedido.", err
+	}
+
+	var orders []*models.Order
+	var details strings.Builder
+	var grandTotal utils.Cents
+	for i, group := range groups {
+		order := created[i]
+
+		// Cada pedido tem a própria entrega: taxas e condições calculadas sobre os itens daquele endereço
+		groupCart := *cart
+		groupCart.Items = group.Items
+		order, depositText, manualReview := s.applyCheckoutCharges(ctx, tenantID, customerID, customerPhone, &groupCart, order)
+		orders = append(orders, order)
+
+		total, _ := utils.ParseCents(order.TotalAmount)
+		grandTotal += total
+
+		details.WriteString(fmt.Sprintf("📋 **Pedido %s**\n📍 %s\n", order.OrderNumber, formatShortAddress(*group.Address)))
+		for _, item := range group.Items {
+			details.WriteString(fmt.Sprintf("   • %dx %s\n", item.Quantity, getItemName(item)))
+		}
+		details.WriteString(fmt.Sprintf("💰 Total: R$ %s\n", formatCurrency(order.TotalAmount)))
+		if manual[i] {
+			details.WriteString("🚚 Vamos confirmar manualmente se atendemos este endereço.\n")
+		}
+		if depositText != "" {
+			details.WriteString(depositText + "\n")
+		}
+		if manualReview {
+			details.WriteString("🔎 Por ser de valor mais alto, nossa equipe vai conferir e confirmar este pedido com você.\n")
+		}
+		details.WriteString("\n")
+
+		log.Info().
+			Str("order_number", order.OrderNumber).
+			Str("total", order.TotalAmount).
+			Str("address_id", group.Address.ID.String()).
+			Msg("Pedido da entrega dividida criado")
+	}
+
+	// 📍 O endereço principal continua sendo o padrão do cliente
+	if !main.IsDefault {
+		if err := s.addressService.SetDefaultAddress(tenantID, customerID, main.ID); err != nil {
+			log.Warn().Err(err).Str("address_id", main.ID.String()).Msg("⚠️ Não foi possível definir o endereço do pedido como padrão")
+		}
+	}
+
+	s.resetCartAfterCheckout(tenantID, customerID, customerPhone, cart, false)
+
+	if s.alertService != nil {
+		for _, order := range orders {
+			if err := s.alertService.SendOrderAlert(tenantID, order, customerPhone); err != nil {
+				log.Error().Err(err).Str("order_id", order.ID.String()).Msg("Erro ao enviar alerta do pedido")
+			}
+		}
+	}
+
+	urgentText := ""
+	if urgent {
+		urgentText = "⚡ **Pedidos urgentes:** nossa equipe vai priorizar as entregas.\n"
+	}
+
+	return fmt.Sprintf("🎉 **Pedidos registrados com sucesso!**\n\n🎁 Seu pedido foi dividido em %d entregas:\n\n%s💰 **Total geral:** R$ %s\n📦 **Status:** Pendente\n%s\n👥 Um de nossos operadores irá revisar e confirmar seus pedidos em breve.\n📞 Você será contatado para confirmar os detalhes das entregas e pagamento.",
+		len(orders), details.String(), formatCurrency(grandTotal.String()), urgentText), nil
+}
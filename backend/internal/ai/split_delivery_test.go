@@ -0,0 +1,241 @@
+package ai
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"iafarma/internal/utils"
+	"iafarma/pkg/models"
+
+	"github.com/google/uuid"
+)
+
+// splitCartService guarda o endereço escolhido para cada item, como o serviço real
+type splitCartService struct {
+	fakeCartService
+}
+
+func (f *splitCartService) SetCartItemAddress(cartID, tenantID, itemID uuid.UUID, addressID *uuid.UUID) error {
+	for i := range f.cart.Items {
+		if f.cart.Items[i].ID == itemID {
+			f.cart.Items[i].DeliveryAddressID = addressID
+			return nil
+		}
+	}
+	return fmt.Errorf("item %s não encontrado", itemID)
+}
+
+// splitOrderService cria pedidos só com os itens informados do carrinho. Como a transação real, grava todos os pedidos
+// ou nenhum: failAt > 0 faz o pedido desse grupo falhar
+type splitOrderService struct {
+	*fakeOrderService
+	cart   *models.Cart
+	failAt int
+}
+
+func (f *splitOrderService) CreateOrdersFromCartGroups(tenantID, cartID, conversationID uuid.UUID, groups []CartOrderGroup) ([]*models.Order, error) {
+	var created []models.Order
+	for i, group := range groups {
+		if i+1 == f.failAt {
+			return nil, fmt.Errorf("falha ao gravar o pedido %d", i+1)
+		}
+		order := models.Order{OrderNumber: fmt.Sprintf("PED-%03d", len(f.orders)+len(created)+1), Status: "pending"}
+		order.ID = uuid.New()
+		order.TenantID = tenantID
+		var total utils.Cents
+		for _, item := range SelectCartItems(f.cart.Items, group.ItemIDs) {
+			order.Items = append(order.Items, models.OrderItem{ProductID: item.ProductID, Quantity: item.Quantity, ProductName: strPtrAI(getItemName(item))})
+			price, _ := utils.ParseCents(item.Price)
+			total += price.Times(item.Quantity)
+		}
+		order.TotalAmount = total.String()
+		if group.DeliveryAddress != nil {
+			order.AddressID = &group.DeliveryAddress.ID
+			order.ShippingStreet = &group.DeliveryAddress.Street
+		}
+		created = append(created, order)
+	}
+
+	f.orders = append(f.orders, created...)
+	orders := make([]*models.Order, len(created))
+	for i := range created {
+		orders[i] = &created[i]
+	}
+	return orders, nil
+}
+
+// streetDeliveryService não entrega nas ruas informadas e conta as validações feitas
+type streetDeliveryService struct {
+	DeliveryServiceInterface
+	notServed   map[string]bool
+	validations []string
+}
+
+func (f *streetDeliveryService) ValidateDeliveryAddress(tenantID uuid.UUID, street, number, neighborhood, city, state string) (*DeliveryValidationResult, error) {
+	f.validations = append(f.validations, street)
+	if f.notServed[street] {
+		return &DeliveryValidationResult{CanDeliver: false, Reason: "outside_radius"}, nil
+	}
+	return &DeliveryValidationResult{CanDeliver: true, Reason: "within_radius"}, nil
+}
+
+func newSplitTestCart() *models.Cart {
+	cart := newPickupTestCart(false)
+	cart.ID = uuid.New()
+	cart.Items = []models.CartItem{
+		{Quantity: 2, Price: "10.00", ProductName: strPtrAI("Sabonete")},
+		{Quantity: 1, Price: "89.90", ProductName: strPtrAI("Perfume")},
+		{Quantity: 1, Price: "5.50", ProductName: strPtrAI("Escova de Dentes")},
+	}
+	for i := range cart.Items {
+		cart.Items[i].ID = uuid.New()
+	}
+	return cart
+}
+
+// newSplitTestAddresses retorna o endereço de casa (padrão) e o da mãe do cliente
+func newSplitTestAddresses() []models.Address {
+	home := newRememberedAddress(true)
+	mother := newRememberedAddress(false)
+	mother.Street = "Rua das Acácias"
+	return []models.Address{home, mother}
+}
+
+// withSplitDelivery liga o carrinho, os pedidos e a entrega por rua usados na divisão da entrega
+func withSplitDelivery(cart *models.Cart, orders *splitOrderService, delivery *streetDeliveryService) testServiceOption {
+	return withOverride(func(s *AIService) {
+		s.cartService = &splitCartService{fakeCartService: fakeCartService{cart: cart}}
+		s.orderService = orders
+		s.deliveryService = delivery
+	})
+}
+
+func TestSplitDeliveryCreatesOrderPerAddress(t *testing.T) {
+	tenantID, customerID := uuid.New(), uuid.New()
+	cart, addresses := newSplitTestCart(), newSplitTestAddresses()
+	orders, delivery := &splitOrderService{fakeOrderService: &fakeOrderService{}, cart: cart}, &streetDeliveryService{notServed: map[string]bool{}}
+	s, _ := newTestService(map[string]string{AllowPickupSettingKey: "false", SplitDeliverySettingKey: "true"},
+		withCheckout(cart, addresses...), withSplitDelivery(cart, orders, delivery))
+
+	obtido, err := s.executeTool(context.Background(), tenantID, customerID, "5561999999999", "dividirEntrega", map[string]interface{}{
+		"itens":           []interface{}{float64(2)},
+		"numero_endereco": float64(2),
+	})
+	if err != nil {
+		t.Fatalf("erro inesperado: %v", err)
+	}
+	if !strings.Contains(obtido, "Entrega dividida") || !strings.Contains(obtido, "Rua das Acácias") {
+		t.Fatalf("esperada confirmação da divisão com o segundo endereço:\n%s", obtido)
+	}
+	if cart.Items[1].DeliveryAddressID == nil || *cart.Items[1].DeliveryAddressID != addresses[1].ID {
+		t.Fatalf("perfume deveria ir para o segundo endereço, obtido %v", cart.Items[1].DeliveryAddressID)
+	}
+
+	checkout, err := s.performFinalCheckout(context.Background(), tenantID, customerID, "5561999999999")
+	if err != nil {
+		t.Fatalf("erro inesperado no checkout: %v", err)
+	}
+	if len(orders.orders) != 2 {
+		t.Fatalf("esperados 2 pedidos (um por endereço), obtido %d:\n%s", len(orders.orders), checkout)
+	}
+
+	principal, presente := orders.orders[0], orders.orders[1]
+	if principal.AddressID == nil || *principal.AddressID != addresses[0].ID || len(principal.Items) != 2 {
+		t.Errorf("primeiro pedido deveria ter os 2 itens do endereço principal: %+v", principal)
+	}
+	if principal.TotalAmount != "25.50" {
+		t.Errorf("esperado total 25.50 no endereço principal, obtido %s", principal.TotalAmount)
+	}
+	if presente.AddressID == nil || *presente.AddressID != addresses[1].ID || len(presente.Items) != 1 || *presente.Items[0].ProductName != "Perfume" {
+		t.Errorf("segundo pedido deveria ter só o perfume no segundo endereço: %+v", presente)
+	}
+
+	if len(delivery.validations) != 2 || delivery.validations[0] != "Rua das Flores" || delivery.validations[1] != "Rua das Acácias" {
+		t.Errorf("cada endereço deveria ser validado uma vez, validações: %v", delivery.validations)
+	}
+	for _, esperado := range []string{"dividido em 2 entregas", "PED-001", "PED-002", "Total geral:** R$ 115,40"} {
+		if !strings.Contains(checkout, esperado) {
+			t.Errorf("esperado %q na confirmação:\n%s", esperado, checkout)
+		}
+	}
+}
+
+func TestSplitDeliveryBlocksWhenAddressNotServed(t *testing.T) {
+	tenantID, customerID := uuid.New(), uuid.New()
+	cart, addresses := newSplitTestCart(), newSplitTestAddresses()
+	orders, delivery := &splitOrderService{fakeOrderService: &fakeOrderService{}, cart: cart}, &streetDeliveryService{notServed: map[string]bool{}}
+	s, _ := newTestService(map[string]string{AllowPickupSettingKey: "false", SplitDeliverySettingKey: "true"},
+		withCheckout(cart, addresses...), withSplitDelivery(cart, orders, delivery))
+	delivery.notServed["Rua das Acácias"] = true
+	cart.Items[1].DeliveryAddressID = &addresses[1].ID
+
+	checkout, err := s.performFinalCheckout(context.Background(), tenantID, customerID, "5561999999999")
+	if err != nil {
+		t.Fatalf("erro inesperado no checkout: %v", err)
+	}
+	if !strings.Contains(checkout, "Não fazemos entrega neste endereço") || !strings.Contains(checkout, "Perfume") {
+		t.Errorf("deveria informar o endereço não atendido e os itens afetados:\n%s", checkout)
+	}
+	if len(orders.orders) != 0 {
+		t.Errorf("nenhum pedido deveria ser criado com um endereço não atendido, obtido %d", len(orders.orders))
+	}
+}
+
+func TestSplitDeliveryFailureCreatesNoOrder(t *testing.T) {
+	tenantID, customerID := uuid.New(), uuid.New()
+	cart, addresses := newSplitTestCart(), newSplitTestAddresses()
+	orders, delivery := &splitOrderService{fakeOrderService: &fakeOrderService{}, cart: cart, failAt: 2}, &streetDeliveryService{notServed: map[string]bool{}}
+	s, _ := newTestService(map[string]string{AllowPickupSettingKey: "false", SplitDeliverySettingKey: "true"},
+		withCheckout(cart, addresses...), withSplitDelivery(cart, orders, delivery))
+	cart.Items[1].DeliveryAddressID = &addresses[1].ID
+
+	checkout, err := s.performFinalCheckout(context.Background(), tenantID, customerID, "5561999999999")
+	if err == nil || !strings.Contains(checkout, "Erro ao criar pedido") {
+		t.Fatalf("esperado erro ao criar os pedidos, obtido %v:\n%s", err, checkout)
+	}
+	// Nenhum pedido fica gravado e o carrinho continua inteiro: tentar de novo não duplica pedidos
+	if len(orders.orders) != 0 {
+		t.Errorf("nenhum pedido deveria ficar gravado após a falha, obtido %d", len(orders.orders))
+	}
+	if len(cart.Items) != 3 || cart.Items[1].DeliveryAddressID == nil {
+		t.Errorf("carrinho deveria continuar com os itens e a divisão, obtido %+v", cart.Items)
+	}
+
+	orders.failAt = 0
+	if _, err := s.performFinalCheckout(context.Background(), tenantID, customerID, "5561999999999"); err != nil {
+		t.Fatalf("erro inesperado na nova tentativa: %v", err)
+	}
+	if len(orders.orders) != 2 {
+		t.Errorf("nova tentativa deveria criar os 2 pedidos uma única vez, obtido %d", len(orders.orders))
+	}
+}
+
+func TestSplitDeliveryDisabled(t *testing.T) {
+	tenantID, customerID := uuid.New(), uuid.New()
+	cart, addresses := newSplitTestCart(), newSplitTestAddresses()
+	orders, delivery := &splitOrderService{fakeOrderService: &fakeOrderService{}, cart: cart}, &streetDeliveryService{notServed: map[string]bool{}}
+	s, _ := newTestService(map[string]string{AllowPickupSettingKey: "false", SplitDeliverySettingKey: "false"},
+		withCheckout(cart, addresses...), withSplitDelivery(cart, orders, delivery))
+
+	obtido, err := s.handleDividirEntrega(context.Background(), tenantID, customerID, map[string]interface{}{
+		"itens":           []interface{}{float64(2)},
+		"numero_endereco": float64(2),
+	})
+	if err != nil {
+		t.Fatalf("erro inesperado: %v", err)
+	}
+	if !strings.Contains(obtido, "não conseguimos dividir") || cart.Items[1].DeliveryAddressID != nil {
+		t.Errorf("com a configuração desligada a divisão deveria ser recusada:\n%s", obtido)
+	}
+
+	// Itens marcados antes de desligar a configuração seguem todos para o endereço principal
+	cart.Items[1].DeliveryAddressID = &addresses[1].ID
+	if _, err := s.performFinalCheckout(context.Background(), tenantID, customerID, "5561999999999"); err != nil {
+		t.Fatalf("erro inesperado no checkout: %v", err)
+	}
+	if len(orders.orders) != 1 {
+		t.Errorf("esperado 1 pedido único, obtido %d", len(orders.orders))
+	}
+}
@@ -0,0 +1,93 @@
+package ai
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"iafarma/pkg/models"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+)
+
+// StockAlternativesSettingKey define se o bot oferece produtos parecidos em estoque quando o pedido passa do estoque
+const StockAlternativesSettingKey = "ai_out_of_stock_alternatives"
+
+// maxStockAlternatives limita as alternativas oferecidas junto com o aviso de estoque
+const maxStockAlternatives = 3
+
+// isStockAlternativesEnabled indica se o tenant oferece alternativas (padrão: sim)
+func (s *AIService) isStockAlternativesEnabled(ctx context.Context, tenantID uuid.UUID) bool {
+	if s.settingsService == nil {
+		return true
+	}
+	setting, err := s.settingsService.GetSetting(ctx, tenantID, StockAlternativesSettingKey)
+	if err != nil || setting == nil || setting.SettingValue == nil {
+		return true
+	}
+	enabled, err := strconv.ParseBool(strings.TrimSpace(*setting.SettingValue))
+	return err != nil || enabled
+}
+
+// findStockAlternatives busca produtos da mesma categoria e, depois, da mesma marca com estoque para a quantidade pedida
+func (s *AIService) findStockAlternatives(ctx context.Context, tenantID uuid.UUID, product *models.Product, quantity int) []models.Product {
+	var searches []ProductSearchFilters
+	if product.CategoryID != nil {
+		searches = append(searches, ProductSearchFilters{CategoryID: product.CategoryID, Limit: maxStockAlternatives * 3})
+	}
+	if strings.TrimSpace(product.Brand) != "" {
+		searches = append(searches, ProductSearchFilters{Brand: product.Brand, Limit: maxStockAlternatives * 3})
+	}
+
+	seen := map[uuid.UUID]bool{product.ID: true}
+	var alternatives []models.Product
+	for _, filters := range searches {
+		products, err := s.searchProductsAdvanced(ctx, tenantID, filters)
+		if err != nil {
+			log.Warn().Err(err).Str("product_id", product.ID.String()).Msg("⚠️ Erro ao buscar alternativas para produto sem estoque")
+			continue
+		}
+		for _, candidate := range products {
+			if seen[candidate.ID] || !candidate.Available || candidate.StockQuantity < quantity || !hasValidPrice(&candidate) {
+				continue
+			}
+			seen[candidate.ID] = true
+			alternatives = append(alternatives, candidate)
+			if len(alternatives) == maxStockAlternatives {
+				return alternatives
+			}
+		}
+	}
+	return alternatives
+}
+
+// withStockAlternatives mantém o aviso de estoque e acrescenta as alternativas em estoque, guardadas na memória para
+// o cliente escolher pelo número; sem alternativas (ou com a opção desligada) retorna só o aviso
+func (s *AIService) withStockAlternatives(ctx context.Context, tenantID uuid.UUID, customerPhone string, product *models.Product, quantity int, message string) string {
+	if customerPhone == "" || !s.isStockAlternativesEnabled(ctx, tenantID) {
+		return message
+	}
+
+	alternatives := s.findStockAlternatives(ctx, tenantID, product, quantity)
+	if len(alternatives) == 0 {
+		return message
+	}
+
+	log.Info().
+		Str("tenant_id", tenantID.String()).
+		Str("product_id", product.ID.String()).
+		Int("alternatives", len(alternatives)).
+		Msg("🔄 Oferecendo alternativas em estoque")
+
+	var result strings.Builder
+	result.WriteString(message)
+	result.WriteString("\n\n💡 **Temos estas opções parecidas em estoque:**\n\n")
+	productRefs := s.memoryManager.StoreProductList(tenantID, customerPhone, alternatives)
+	for _, productRef := range productRefs {
+		result.WriteString(fmt.Sprintf("%d. **%s**\n   💰 %s\n", productRef.SequentialID, productRef.Name, formatListPrice(productRef.Price, productRef.SalePrice)))
+	}
+	result.WriteString("\n🛒 Para adicionar, me diga o número do item e a quantidade.")
+	return result.String()
+}
@@ -0,0 +1,121 @@
+package ai
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"iafarma/pkg/models"
+
+	"github.com/google/uuid"
+)
+
+// catalogFilterProductService filtra por categoria e marca, só com itens em estoque, como a busca real
+type catalogFilterProductService struct {
+	fakeProductService
+}
+
+func (f *catalogFilterProductService) SearchProductsAdvanced(tenantID uuid.UUID, filters ProductSearchFilters) ([]models.Product, error) {
+	var result []models.Product
+	for _, product := range f.products {
+		if filters.CategoryID != nil && (product.CategoryID == nil || *product.CategoryID != *filters.CategoryID) {
+			continue
+		}
+		if filters.Brand != "" && !strings.EqualFold(product.Brand, filters.Brand) {
+			continue
+		}
+		if !filters.IncludeOutOfStock && product.StockQuantity <= 0 {
+			continue
+		}
+		result = append(result, product)
+	}
+	return result, nil
+}
+
+// withCatalogFilter troca o catálogo por um que aplica os filtros da busca avançada
+func withCatalogFilter(products ...models.Product) testServiceOption {
+	return withOverride(func(s *AIService) {
+		s.productService = &catalogFilterProductService{fakeProductService: fakeProductService{products: products}}
+	})
+}
+
+func newStockTestProduct(name, brand string, categoryID *uuid.UUID, stock int) models.Product {
+	product := newPricedTestProduct(name, "12.90")
+	product.Brand = brand
+	product.CategoryID = categoryID
+	product.StockQuantity = stock
+	return product
+}
+
+func TestOutOfStockOffersAlternatives(t *testing.T) {
+	tenantID, customerID := uuid.New(), uuid.New()
+	analgesicos, vitaminas := uuid.New(), uuid.New()
+	products := []models.Product{
+		newStockTestProduct("Dipirona 500mg", "Medley", &analgesicos, 1),
+		newStockTestProduct("Paracetamol 750mg", "EMS", &analgesicos, 20),
+		newStockTestProduct("Ibuprofeno 400mg", "EMS", &analgesicos, 0),
+		newStockTestProduct("Vitamina C Medley", "Medley", &vitaminas, 15),
+		newStockTestProduct("Vitamina D", "Sanofi", &vitaminas, 30),
+	}
+	cart := newCheckoutCartService(products)
+	s, _ := newTestService(nil, withAddAndCheckout(cart, products...), withCatalogFilter(products...))
+
+	result, err := s.tryAddProductToCart(context.Background(), tenantID, customerID, "5561999999999", products[0].ID, 3)
+	if err != nil {
+		t.Fatalf("erro inesperado: %v", err)
+	}
+	if !strings.Contains(result, "Estoque insuficiente para **Dipirona 500mg**. Disponível: 1 unidades.") {
+		t.Errorf("o aviso de estoque deveria continuar claro:\n%s", result)
+	}
+	if !strings.Contains(result, "1. **Paracetamol 750mg**") || !strings.Contains(result, "2. **Vitamina C Medley**") {
+		t.Errorf("esperadas alternativas da mesma categoria e depois da mesma marca:\n%s", result)
+	}
+	for _, ausente := range []string{"Ibuprofeno", "Vitamina D", "2. **Dipirona"} {
+		if strings.Contains(result, ausente) {
+			t.Errorf("%q não deveria ser oferecido (sem estoque, sem relação ou o próprio produto):\n%s", ausente, result)
+		}
+	}
+	if len(cart.cart.Items) != 0 {
+		t.Fatalf("nada deveria entrar no carrinho, itens: %+v", cart.cart.Items)
+	}
+
+	// As alternativas ficam na memória para o cliente escolher pelo número
+	if _, err := s.executeTool(context.Background(), tenantID, customerID, "5561999999999", "adicionarPorNumero", map[string]interface{}{"numero": float64(1), "quantidade": float64(3)}); err != nil {
+		t.Fatalf("erro inesperado ao escolher a alternativa: %v", err)
+	}
+	if len(cart.cart.Items) != 1 || *cart.cart.Items[0].ProductID != products[1].ID {
+		t.Errorf("a alternativa escolhida deveria entrar no carrinho, itens: %+v", cart.cart.Items)
+	}
+}
+
+func TestOutOfStockWithoutAlternatives(t *testing.T) {
+	tenantID, customerID := uuid.New(), uuid.New()
+	categoria := uuid.New()
+	products := []models.Product{
+		newStockTestProduct("Dipirona 500mg", "Medley", &categoria, 1),
+		newStockTestProduct("Outro Analgésico", "EMS", &categoria, 2),
+	}
+
+	tests := []struct {
+		name     string
+		settings map[string]string
+		quantity int
+	}{
+		{"nenhuma alternativa com estoque suficiente", map[string]string{}, 3},
+		{"opção desligada pelo tenant", map[string]string{StockAlternativesSettingKey: "false"}, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, _ := newTestService(tt.settings, withAddAndCheckout(newCheckoutCartService(products), products...), withCatalogFilter(products...))
+
+			result, err := s.tryAddProductToCart(context.Background(), tenantID, customerID, "5561999999999", products[0].ID, tt.quantity)
+			if err != nil {
+				t.Fatalf("erro inesperado: %v", err)
+			}
+			if result != "❌ Estoque insuficiente para **Dipirona 500mg**. Disponível: 1 unidades." {
+				t.Errorf("esperado só o aviso de estoque, obtido:\n%s", result)
+			}
+		})
+	}
+}
@@ -0,0 +1,94 @@
+package ai
+
+import (
+	"context"
+	"iafarma/pkg/models"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// SearchInStockOnlySettingKey define se as buscas de produtos escondem itens sem estoque por padrão
+const SearchInStockOnlySettingKey = "ai_search_in_stock_only"
+
+// outOfStockKeywords indicam que o cliente quer ver explicitamente os itens esgotados
+var outOfStockKeywords = []string{"esgotados", "esgotadas", "esgotado", "esgotada", "sem estoque", "fora de estoque", "indisponíveis", "indisponível"}
+
+// BaseCondition retorna a condição SQL base da busca (tenant, produto não pausado e, se aplicável, estoque
+// disponível e preço válido quando a busca filtra ou ordena por preço)
+func (f ProductSearchFilters) BaseCondition() string {
+	condition := "tenant_id = ?"
+	if !f.IncludeUnavailable {
+		condition += " AND available = true"
+	}
+	if !f.IncludeOutOfStock {
+		condition += " AND stock_quantity > 0"
+	}
+	if f.UsesPrice() {
+		condition += " AND " + ProductPriceSQL + " > 0"
+	}
+	return condition
+}
+
+// UsesPrice indica se a busca filtra ou ordena por preço
+func (f ProductSearchFilters) UsesPrice() bool {
+	return f.MinPrice > 0 || f.MaxPrice > 0 || f.SortBy == "price_asc" || f.SortBy == "price_desc"
+}
+
+// mentionsOutOfStock verifica se a busca pede explicitamente produtos esgotados (ex: "tem esgotado?")
+func mentionsOutOfStock(query string) bool {
+	lower := strings.ToLower(query)
+	for _, keyword := range outOfStockKeywords {
+		if strings.Contains(lower, keyword) {
+			return true
+		}
+	}
+	return false
+}
+
+// stripOutOfStockKeywords remove os termos de estoque da busca para não poluir a pesquisa por nome
+func stripOutOfStockKeywords(query string) string {
+	lower := strings.ToLower(query)
+	for _, keyword := range outOfStockKeywords {
+		lower = strings.ReplaceAll(lower, keyword, " ")
+	}
+	for _, filler := range []string{"tem ", "algum ", "alguma ", "?"} {
+		lower = strings.ReplaceAll(lower, filler, " ")
+	}
+	return strings.Join(strings.Fields(lower), " ")
+}
+
+// resolveInStockOnly decide se a busca deve esconder produtos sem estoque.
+// Ordem: pedido explícito de esgotados > parâmetro apenas_em_estoque > preferência do tenant (padrão: true).
+func (s *AIService) resolveInStockOnly(ctx context.Context, tenantID uuid.UUID, query string, args map[string]interface{}) bool {
+	if mentionsOutOfStock(query) {
+		return false
+	}
+
+	if inStockOnly, ok := args["apenas_em_estoque"].(bool); ok {
+		return inStockOnly
+	}
+
+	if s.settingsService != nil {
+		setting, err := s.settingsService.GetSetting(ctx, tenantID, SearchInStockOnlySettingKey)
+		if err == nil && setting != nil && setting.SettingValue != nil {
+			if value, parseErr := strconv.ParseBool(strings.TrimSpace(*setting.SettingValue)); parseErr == nil {
+				return value
+			}
+		}
+	}
+
+	return true
+}
+
+// filterInStockProducts remove produtos sem estoque (usado nos resultados do RAG, que não filtra estoque)
+func filterInStockProducts(products []models.Product) []models.Product {
+	filtered := make([]models.Product, 0, len(products))
+	for _, product := range products {
+		if product.StockQuantity > 0 {
+			filtered = append(filtered, product)
+		}
+	}
+	return filtered
+}
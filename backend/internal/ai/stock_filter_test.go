@@ -0,0 +1,107 @@
+package ai
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/google/uuid"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// newDryRunDB cria um *gorm.DB que apenas gera o SQL, sem conectar ao banco, e registra as queries geradas
+func newDryRunDB(t *testing.T) (*gorm.DB, func() []string) {
+	t.Helper()
+
+	db, err := gorm.Open(postgres.New(postgres.Config{DSN: "host=localhost dbname=dryrun sslmode=disable"}), &gorm.Config{
+		DryRun:               true,
+		DisableAutomaticPing: true,
+		Logger:               logger.Discard,
+	})
+	if err != nil {
+		t.Fatalf("failed to open dry-run db: %v", err)
+	}
+
+	var mu sync.Mutex
+	var statements []string
+	capture := func(tx *gorm.DB) {
+		mu.Lock()
+		defer mu.Unlock()
+		statements = append(statements, tx.Statement.SQL.String())
+	}
+	if err := db.Callback().Query().After("gorm:query").Register("test:capture_sql", capture); err != nil {
+		t.Fatalf("failed to register callback: %v", err)
+	}
+
+	return db, func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string(nil), statements...)
+	}
+}
+
+func TestSearchProductsAdvancedStockFilterSQL(t *testing.T) {
+	tests := []struct {
+		name              string
+		filters           ProductSearchFilters
+		expectStockFilter bool
+	}{
+		{"default listing", ProductSearchFilters{}, true},
+		{"default search", ProductSearchFilters{Query: "dipirona"}, true},
+		{"include out of stock listing", ProductSearchFilters{IncludeOutOfStock: true}, false},
+		{"include out of stock search", ProductSearchFilters{Query: "dipirona", IncludeOutOfStock: true}, false},
+	}
+
+	for _, test := range tests {
+		db, statements := newDryRunDB(t)
+		service := NewProductService(db)
+
+		if _, err := service.SearchProductsAdvanced(uuid.New(), test.filters); err != nil {
+			t.Fatalf("%s: unexpected error: %v", test.name, err)
+		}
+
+		executed := statements()
+		if len(executed) == 0 {
+			t.Fatalf("%s: no SQL generated", test.name)
+		}
+
+		for _, sql := range executed {
+			if !strings.Contains(sql, "tenant_id = $1") {
+				t.Errorf("%s: query without tenant filter: %s", test.name, sql)
+			}
+			if hasStockFilter := strings.Contains(sql, "stock_quantity > 0"); hasStockFilter != test.expectStockFilter {
+				t.Errorf("%s: stock filter present = %t, expected %t in: %s", test.name, hasStockFilter, test.expectStockFilter, sql)
+			}
+		}
+	}
+}
+
+func TestResolveInStockOnly(t *testing.T) {
+	s := &AIService{}
+	tenantID := uuid.New()
+
+	tests := []struct {
+		query    string
+		args     map[string]interface{}
+		expected bool
+	}{
+		{"dipirona", map[string]interface{}{}, true},
+		{"dipirona", map[string]interface{}{"apenas_em_estoque": true}, true},
+		{"dipirona", map[string]interface{}{"apenas_em_estoque": false}, false},
+		{"tem esgotado?", map[string]interface{}{}, false},
+		{"dipirona sem estoque", map[string]interface{}{"apenas_em_estoque": true}, false},
+	}
+
+	for _, test := range tests {
+		if result := s.resolveInStockOnly(context.Background(), tenantID, test.query, test.args); result != test.expected {
+			t.Errorf("resolveInStockOnly(%q, %v) = %t, expected %t", test.query, test.args, result, test.expected)
+		}
+	}
+
+	if stripped := stripOutOfStockKeywords("Tem dipironas esgotadas?"); stripped != "dipironas" {
+		t.Errorf("stripOutOfStockKeywords = %q, expected %q", stripped, "dipironas")
+	}
+}
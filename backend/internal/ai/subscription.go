@@ -0,0 +1,377 @@
+package ai
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"iafarma/pkg/models"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	SubscriptionCadenceWeekly   = "weekly"
+	SubscriptionCadenceBiweekly = "biweekly"
+	SubscriptionCadenceMonthly  = "monthly"
+	SubscriptionCadenceDays     = "days"
+
+	SubscriptionStatusActive    = "active"
+	SubscriptionStatusPaused    = "paused"
+	SubscriptionStatusCancelled = "cancelled"
+
+	// maxSubscriptionIntervalDays limita o intervalo personalizado ("a cada N dias")
+	maxSubscriptionIntervalDays = 180
+	// maxSubscriptionQuantity evita assinaturas com quantidades absurdas
+	maxSubscriptionQuantity = 50
+)
+
+// subscriptionCadenceAliases traduz a frequência pedida pelo cliente para a cadência armazenada
+var subscriptionCadenceAliases = map[string]string{
+	"semanal":     SubscriptionCadenceWeekly,
+	"quinzenal":   SubscriptionCadenceBiweekly,
+	"mensal":      SubscriptionCadenceMonthly,
+	"dias":        SubscriptionCadenceDays,
+	"weekly":      SubscriptionCadenceWeekly,
+	"biweekly":    SubscriptionCadenceBiweekly,
+	"monthly":     SubscriptionCadenceMonthly,
+	"days":        SubscriptionCadenceDays,
+	"todo mes":    SubscriptionCadenceMonthly,
+	"toda semana": SubscriptionCadenceWeekly,
+}
+
+// parseSubscriptionCadence valida a frequência e o intervalo informados na criação da assinatura
+func parseSubscriptionCadence(frequency string, intervalDays int) (string, int, error) {
+	cadence, ok := subscriptionCadenceAliases[normalizeAllergenText(frequency)]
+	if !ok {
+		return "", 0, fmt.Errorf("frequência inválida: %q", frequency)
+	}
+	if cadence != SubscriptionCadenceDays {
+		return cadence, 0, nil
+	}
+	if intervalDays < 1 || intervalDays > maxSubscriptionIntervalDays {
+		return "", 0, fmt.Errorf("o intervalo deve ser entre 1 e %d dias", maxSubscriptionIntervalDays)
+	}
+	return cadence, intervalDays, nil
+}
+
+// nextSubscriptionRun calcula a próxima geração de pedido a partir da data informada.
+// Na cadência mensal o dia é mantido, limitado ao último dia do mês (31/01 -> 29/02, sem pular para março).
+func nextSubscriptionRun(from time.Time, cadence string, intervalDays int) time.Time {
+	switch cadence {
+	case SubscriptionCadenceWeekly:
+		return from.AddDate(0, 0, 7)
+	case SubscriptionCadenceBiweekly:
+		return from.AddDate(0, 0, 14)
+	case SubscriptionCadenceDays:
+		if intervalDays < 1 {
+			intervalDays = 1
+		}
+		return from.AddDate(0, 0, intervalDays)
+	}
+
+	year, month, day := from.Date()
+	firstOfNext := time.Date(year, month+1, 1, from.Hour(), from.Minute(), from.Second(), from.Nanosecond(), from.Location())
+	lastDay := firstOfNext.AddDate(0, 1, -1).Day()
+	if day > lastDay {
+		day = lastDay
+	}
+	return firstOfNext.AddDate(0, 0, day-1)
+}
+
+// subscriptionCadenceText descreve a frequência da assinatura para o cliente
+func subscriptionCadenceText(subscription *models.Subscription) string {
+	switch subscription.Cadence {
+	case SubscriptionCadenceWeekly:
+		return "toda semana"
+	case SubscriptionCadenceBiweekly:
+		return "a cada 15 dias"
+	case SubscriptionCadenceDays:
+		return fmt.Sprintf("a cada %d dias", subscription.IntervalDays)
+	}
+	return "todo mês"
+}
+
+// subscriptionStatusText traduz o status da assinatura para exibição ao cliente
+func subscriptionStatusText(status string) string {
+	switch status {
+	case SubscriptionStatusActive:
+		return "Ativa"
+	case SubscriptionStatusPaused:
+		return "Pausada"
+	case SubscriptionStatusCancelled:
+		return "Cancelada"
+	}
+	return status
+}
+
+// subscriptionProductName retorna o nome do produto da assinatura
+func subscriptionProductName(subscription *models.Subscription) string {
+	if subscription.Product != nil {
+		return subscription.Product.Name
+	}
+	return "produto"
+}
+
+// formatSubscriptionList lista as assinaturas do cliente numeradas para as ações de pausar/cancelar
+func formatSubscriptionList(subscriptions []models.Subscription) string {
+	var result strings.Builder
+	result.WriteString("🔁 **Suas assinaturas:**\n\n")
+	for i := range subscriptions {
+		subscription := &subscriptions[i]
+		result.WriteString(fmt.Sprintf("%d. **%s** - %dx, %s (%s)\n", i+1, subscriptionProductName(subscription),
+			subscription.Quantity, subscriptionCadenceText(subscription), subscriptionStatusText(subscription.Status)))
+		switch {
+		case subscription.PendingOrderID != nil:
+			result.WriteString("   ⏳ Pedido gerado aguardando sua confirmação\n")
+		case subscription.Status == SubscriptionStatusActive:
+			result.WriteString(fmt.Sprintf("   📅 Próximo pedido: %s\n", subscription.NextRunAt.In(storeLocation).Format("02/01/2006")))
+		}
+	}
+	return strings.TrimRight(result.String(), "\n")
+}
+
+// resolveSubscription encontra a assinatura pelo número da lista ou nome do produto; sem identificador, só resolve se houver uma
+func resolveSubscription(subscriptions []models.Subscription, identifier string) *models.Subscription {
+	identifier = strings.TrimSpace(identifier)
+	if identifier == "" {
+		if len(subscriptions) == 1 {
+			return &subscriptions[0]
+		}
+		return nil
+	}
+
+	if index, err := strconv.Atoi(identifier); err == nil {
+		if index >= 1 && index <= len(subscriptions) {
+			return &subscriptions[index-1]
+		}
+		return nil
+	}
+
+	wanted := normalizeAllergenText(identifier)
+	for i := range subscriptions {
+		if strings.Contains(normalizeAllergenText(subscriptionProductName(&subscriptions[i])), wanted) {
+			return &subscriptions[i]
+		}
+	}
+	return nil
+}
+
+// subscriptionRestricted indica produtos que não podem ser pedidos automaticamente: maioridade e receita são
+// confirmadas pelo cliente no checkout normal, a cada pedido
+func subscriptionRestricted(product *models.Product) bool {
+	return product.AgeRestricted || product.RequiresPrescription
+}
+
+// subscriptionCheckoutCart monta o carrinho equivalente ao pedido da assinatura, para passar pelas mesmas
+// verificações e taxas do checkout
+func subscriptionCheckoutCart(tenantID, customerID uuid.UUID, subscription *models.Subscription) *models.Cart {
+	productID := subscription.ProductID
+	return &models.Cart{
+		BaseTenantModel: models.BaseTenantModel{TenantID: tenantID},
+		CustomerID:      customerID,
+		Items: []models.CartItem{{
+			BaseTenantModel: models.BaseTenantModel{TenantID: tenantID},
+			ProductID:       &productID,
+			Quantity:        subscription.Quantity,
+			Price:           getEffectivePrice(subscription.Product),
+			Product:         subscription.Product,
+		}},
+	}
+}
+
+// handleCriarAssinatura cria um pedido recorrente de um produto para o cliente
+func (s *AIService) handleCriarAssinatura(ctx context.Context, tenantID, customerID uuid.UUID, customerPhone string, args map[string]interface{}) (string, error) {
+	if s.subscriptionService == nil {
+		return "😕 No momento não consigo criar pedidos recorrentes. Fale com nossa equipe para combinar as entregas.", nil
+	}
+
+	identifier, _ := args["identifier"].(string)
+	if strings.TrimSpace(identifier) == "" {
+		return "❌ Informe o produto (nome ou número da lista) que você quer receber de forma recorrente.", nil
+	}
+
+	quantity := 1
+	if value, ok := args["quantidade"].(float64); ok {
+		quantity = int(value)
+	}
+	if quantity < 1 || quantity > maxSubscriptionQuantity {
+		return fmt.Sprintf("❌ A quantidade deve ser entre 1 e %d unidades.", maxSubscriptionQuantity), nil
+	}
+
+	frequency, _ := args["frequencia"].(string)
+	intervalDays := 0
+	if value, ok := args["intervalo_dias"].(float64); ok {
+		intervalDays = int(value)
+	}
+	cadence, intervalDays, err := parseSubscriptionCadence(frequency, intervalDays)
+	if err != nil {
+		return fmt.Sprintf("❌ Não entendi a frequência. Você quer receber toda semana, a cada 15 dias, todo mês ou a cada quantos dias? (máximo %d dias)", maxSubscriptionIntervalDays), nil
+	}
+
+	product, err := s.resolveProductIdentifier(ctx, tenantID, customerPhone, identifier)
+	if err != nil || product == nil {
+		return "❌ Produto não encontrado. Use 'produtos' para ver a lista atualizada.", nil
+	}
+	if !product.Available {
+		return unavailableProductMessage(product), nil
+	}
+	if subscriptionRestricted(product) {
+		return fmt.Sprintf("😕 **%s** exige confirmação de maioridade ou receita a cada compra, então não pode virar pedido recorrente. Quando precisar, é só pedir por aqui que eu te ajudo.", product.Name), nil
+	}
+
+	subscription := &models.Subscription{
+		BaseTenantModel: models.BaseTenantModel{ID: uuid.New(), TenantID: tenantID},
+		CustomerID:      customerID,
+		ProductID:       product.ID,
+		Quantity:        quantity,
+		Cadence:         cadence,
+		IntervalDays:    intervalDays,
+		Status:          SubscriptionStatusActive,
+		Product:         product,
+	}
+	subscription.NextRunAt = nextSubscriptionRun(time.Now(), cadence, intervalDays)
+
+	if err := s.subscriptionService.CreateSubscription(subscription); err != nil {
+		return "❌ Não consegui criar a assinatura agora. Tente novamente em instantes.", err
+	}
+
+	log.Info().
+		Str("tenant_id", tenantID.String()).
+		Str("customer_id", customerID.String()).
+		Str("product_id", product.ID.String()).
+		Str("cadence", cadence).
+		Int("quantity", quantity).
+		Msg("🔁 Assinatura criada")
+
+	return fmt.Sprintf("✅ Assinatura criada! Vou gerar um pedido de **%dx %s** %s.\n📅 Primeiro pedido: %s\n\nAntes de cada envio eu te aviso por aqui para você confirmar. Para pausar ou cancelar, é só pedir.",
+		quantity, product.Name, subscriptionCadenceText(subscription), subscription.NextRunAt.In(storeLocation).Format("02/01/2006")), nil
+}
+
+// handleGerenciarAssinatura lista, pausa, retoma, cancela ou confirma/pula o pedido gerado de uma assinatura
+func (s *AIService) handleGerenciarAssinatura(ctx context.Context, tenantID, customerID uuid.UUID, customerPhone string, args map[string]interface{}) (string, error) {
+	if s.subscriptionService == nil {
+		return "😕 No momento não consigo acessar seus pedidos recorrentes. Fale com nossa equipe.", nil
+	}
+
+	subscriptions, err := s.subscriptionService.GetSubscriptionsByCustomer(tenantID, customerID)
+	if err != nil {
+		return "❌ Erro ao buscar suas assinaturas.", err
+	}
+	if len(subscriptions) == 0 {
+		return "🔁 Você não tem pedidos recorrentes. Para criar, diga por exemplo: \"quero receber 1 caixa de Losartana todo mês\".", nil
+	}
+
+	action, _ := args["acao"].(string)
+	if action == "" || action == "listar" {
+		return formatSubscriptionList(subscriptions), nil
+	}
+
+	identifier, _ := args["assinatura"].(string)
+	subscription := resolveSubscription(subscriptions, identifier)
+	if subscription == nil {
+		return formatSubscriptionList(subscriptions) + "\n\n❓ Qual assinatura? Informe o número da lista.", nil
+	}
+	name := subscriptionProductName(subscription)
+
+	switch action {
+	case "pausar":
+		if subscription.Status != SubscriptionStatusActive {
+			return fmt.Sprintf("ℹ️ A assinatura de **%s** não está ativa.", name), nil
+		}
+		if err := s.subscriptionService.UpdateSubscriptionStatus(tenantID, subscription.ID, SubscriptionStatusPaused, subscription.NextRunAt); err != nil {
+			return "❌ Não consegui pausar a assinatura.", err
+		}
+		return fmt.Sprintf("⏸️ Assinatura de **%s** pausada. Quando quiser voltar a receber, é só pedir para retomar.", name), nil
+
+	case "retomar":
+		if subscription.Status != SubscriptionStatusPaused {
+			return fmt.Sprintf("ℹ️ A assinatura de **%s** não está pausada.", name), nil
+		}
+		nextRunAt := subscription.NextRunAt
+		if now := time.Now(); !nextRunAt.After(now) {
+			nextRunAt = nextSubscriptionRun(now, subscription.Cadence, subscription.IntervalDays)
+		}
+		if err := s.subscriptionService.UpdateSubscriptionStatus(tenantID, subscription.ID, SubscriptionStatusActive, nextRunAt); err != nil {
+			return "❌ Não consegui retomar a assinatura.", err
+		}
+		return fmt.Sprintf("▶️ Assinatura de **%s** retomada! 📅 Próximo pedido: %s", name, nextRunAt.In(storeLocation).Format("02/01/2006")), nil
+
+	case "cancelar":
+		if subscription.Status == SubscriptionStatusCancelled {
+			return fmt.Sprintf("ℹ️ A assinatura de **%s** já está cancelada.", name), nil
+		}
+		if err := s.subscriptionService.UpdateSubscriptionStatus(tenantID, subscription.ID, SubscriptionStatusCancelled, subscription.NextRunAt); err != nil {
+			return "❌ Não consegui cancelar a assinatura.", err
+		}
+		return fmt.Sprintf("🛑 Assinatura de **%s** cancelada. Você não receberá mais pedidos automáticos deste produto.", name), nil
+
+	case "pular":
+		if subscription.PendingOrderID == nil {
+			return fmt.Sprintf("ℹ️ Não há pedido da assinatura de **%s** aguardando confirmação.", name), nil
+		}
+		if _, err := s.subscriptionService.ResolvePendingOrder(tenantID, subscription.ID, false, nil); err != nil {
+			return "❌ Não consegui atualizar o pedido da assinatura.", err
+		}
+		return fmt.Sprintf("⏭️ Pedido de **%s** cancelado. A assinatura continua e eu te aviso no próximo envio.", name), nil
+
+	case "confirmar":
+		if subscription.PendingOrderID == nil {
+			return fmt.Sprintf("ℹ️ Não há pedido da assinatura de **%s** aguardando confirmação.", name), nil
+		}
+		return s.confirmSubscriptionOrder(ctx, tenantID, customerID, customerPhone, subscription)
+	}
+
+	return "❓ Posso listar, pausar, retomar ou cancelar suas assinaturas, ou confirmar/pular o pedido gerado.", nil
+}
+
+// confirmSubscriptionOrder confirma o pedido gerado pela assinatura passando pelas mesmas verificações do checkout
+// (maioridade, receita, peso e área de entrega) e aplicando a taxa de entrega e a confirmação manual por valor.
+// Se alguma verificação bloquear, o pedido continua aguardando e o cliente recebe o motivo.
+func (s *AIService) confirmSubscriptionOrder(ctx context.Context, tenantID, customerID uuid.UUID, customerPhone string, subscription *models.Subscription) (string, error) {
+	name := subscriptionProductName(subscription)
+	if subscription.Product == nil {
+		return fmt.Sprintf("😕 O produto da assinatura de **%s** não está mais disponível. Fale com nossa equipe.", name), nil
+	}
+
+	cart := subscriptionCheckoutCart(tenantID, customerID, subscription)
+	delivery, blockMessage, err := s.validateCheckoutGates(ctx, tenantID, customerID, cart)
+	if blockMessage != "" {
+		return blockMessage, err
+	}
+
+	order, err := s.subscriptionService.ResolvePendingOrder(tenantID, subscription.ID, true, delivery.address)
+	if err != nil {
+		return "❌ Não consegui atualizar o pedido da assinatura.", err
+	}
+
+	order, _, manualReview := s.applyCheckoutCharges(ctx, tenantID, customerID, customerPhone, cart, order)
+
+	if s.alertService != nil {
+		if err := s.alertService.SendOrderAlert(tenantID, order, customerPhone); err != nil {
+			log.Warn().Err(err).Str("order_id", order.ID.String()).Msg("⚠️ Não foi possível alertar a loja sobre o pedido da assinatura")
+		}
+	}
+
+	details := ""
+	if delivery.pickup {
+		details += s.formatPickupDetails(ctx, tenantID) + "\n"
+	}
+	if delivery.manualConfirmation {
+		details += "🚚 **Entrega:** vamos confirmar manualmente se atendemos o seu endereço.\n"
+	}
+	if manualReview {
+		details += "🔎 **Confirmação manual:** por ser um pedido de valor mais alto, nossa equipe vai conferir e confirmar com você em instantes.\n"
+	}
+
+	totalText := fmt.Sprintf("💰 **Total:** R$ %s", formatCurrency(order.TotalAmount))
+	if breakdown := orderTotalBreakdown(order); breakdown.hasCharges() {
+		totalText = formatTotalBreakdown(breakdown)
+	}
+
+	return strings.TrimRight(fmt.Sprintf("✅ Pedido **%s** confirmado! Já avisamos a loja para separar seu **%s**.\n%s\n%s",
+		order.OrderNumber, name, totalText, details), "\n"), nil
+}
@@ -0,0 +1,270 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"iafarma/internal/utils"
+	"iafarma/internal/zapplus"
+	"iafarma/pkg/models"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+)
+
+// subscriptionRunAction é o que fazer com uma assinatura que chegou na data do pedido
+type subscriptionRunAction int
+
+const (
+	subscriptionRunCreateOrder subscriptionRunAction = iota
+	// subscriptionRunAwaitingConfirmation: o pedido anterior ainda espera a resposta do cliente, o ciclo é pulado
+	subscriptionRunAwaitingConfirmation
+	subscriptionRunOutOfStock
+	subscriptionRunUnavailable
+	// subscriptionRunRestricted: o produto passou a exigir maioridade ou receita e precisa ser pedido pelo checkout normal
+	subscriptionRunRestricted
+)
+
+// planSubscriptionRun decide se o pedido da assinatura pode ser gerado, respeitando disponibilidade e estoque
+func planSubscriptionRun(subscription *models.Subscription, product *models.Product) subscriptionRunAction {
+	if subscription.PendingOrderID != nil {
+		return subscriptionRunAwaitingConfirmation
+	}
+	if product == nil || !product.Available || !hasValidPrice(product) {
+		return subscriptionRunUnavailable
+	}
+	if subscriptionRestricted(product) {
+		return subscriptionRunRestricted
+	}
+	if product.StockQuantity < subscription.Quantity {
+		return subscriptionRunOutOfStock
+	}
+	return subscriptionRunCreateOrder
+}
+
+// nextScheduledRun avança a assinatura a partir da data agendada (sem deslocar o dia combinado);
+// após uma parada longa do serviço, recalcula a partir de agora para não gerar pedidos atrasados em sequência
+func nextScheduledRun(subscription *models.Subscription, now time.Time) time.Time {
+	next := nextSubscriptionRun(subscription.NextRunAt, subscription.Cadence, subscription.IntervalDays)
+	if !next.After(now) {
+		next = nextSubscriptionRun(now, subscription.Cadence, subscription.IntervalDays)
+	}
+	return next
+}
+
+// buildSubscriptionOrder monta o pedido pendente da assinatura com os dados históricos do cliente, produto e endereço padrão
+func buildSubscriptionOrder(subscription *models.Subscription, product *models.Product, customer *models.Customer, address *models.Address, orderNumber string) (models.Order, models.OrderItem) {
+	unitPrice := UnitPriceForQuantity(product, subscription.Quantity)
+	price, _ := utils.ParseCents(unitPrice)
+	total := price.Times(subscription.Quantity).String()
+
+	order := models.Order{
+		BaseTenantModel:   models.BaseTenantModel{ID: uuid.New(), TenantID: subscription.TenantID},
+		CustomerID:        &subscription.CustomerID,
+		OrderNumber:       orderNumber,
+		Status:            "pending",
+		PaymentStatus:     "pending",
+		FulfillmentStatus: "pending",
+		TotalAmount:       total,
+		Subtotal:          total,
+		TaxAmount:         "0.00",
+		ShippingAmount:    "0.00",
+		DiscountAmount:    "0.00",
+		Currency:          "BRL",
+		Notes:             "Pedido recorrente (assinatura) - aguardando confirmação do cliente",
+	}
+
+	if customer != nil {
+		order.CustomerName = &customer.Name
+		order.CustomerEmail = &customer.Email
+		order.CustomerPhone = &customer.Phone
+		order.CustomerDocument = &customer.Document
+	}
+
+	applyOrderShippingAddress(&order, address)
+
+	item := models.OrderItem{
+		BaseTenantModel:    models.BaseTenantModel{ID: uuid.New(), TenantID: subscription.TenantID},
+		OrderID:            order.ID,
+		ProductID:          &subscription.ProductID,
+		Quantity:           subscription.Quantity,
+		Price:              unitPrice,
+		Total:              total,
+		ProductName:        &product.Name,
+		ProductDescription: &product.Description,
+		ProductSKU:         &product.SKU,
+		UnitPrice:          &product.Price,
+	}
+
+	return order, item
+}
+
+// subscriptionOrderMessage pede ao cliente a confirmação do pedido gerado pela assinatura; a entrega e as demais
+// verificações do checkout são aplicadas na confirmação
+func subscriptionOrderMessage(order *models.Order, subscription *models.Subscription, product *models.Product) string {
+	return fmt.Sprintf("🔁 Chegou a data do seu pedido recorrente!\n\n📋 Pedido **%s**\n• %dx %s\n💰 Produtos: R$ %s (a entrega é calculada na confirmação)\n\nResponda *confirmar* para enviarmos ou *pular* para não receber desta vez.",
+		order.OrderNumber, subscription.Quantity, product.Name, formatCurrency(order.TotalAmount))
+}
+
+// subscriptionSkippedMessage avisa o cliente que o pedido do ciclo não pôde ser gerado
+func subscriptionSkippedMessage(action subscriptionRunAction, subscription *models.Subscription, product *models.Product, nextRunAt time.Time) string {
+	name := subscriptionProductName(subscription)
+	if product != nil {
+		name = product.Name
+	}
+	reason := "está indisponível no momento"
+	switch action {
+	case subscriptionRunOutOfStock:
+		reason = "está sem estoque suficiente"
+	case subscriptionRunRestricted:
+		return fmt.Sprintf("🔁 O pedido recorrente de **%s** foi encerrado porque o produto agora exige confirmação de maioridade ou receita a cada compra. 📄\nPara receber, faça o pedido normalmente por aqui que eu te ajudo.", name)
+	}
+	return fmt.Sprintf("🔁 O pedido recorrente de **%s** não foi gerado porque o produto %s. 😕\n📅 Tentaremos novamente em %s. Se precisar antes, é só pedir por aqui!",
+		name, reason, nextRunAt.In(storeLocation).Format("02/01/2006"))
+}
+
+// SubscriptionScheduler gera periodicamente os pedidos das assinaturas que chegaram na data e avisa os clientes
+type SubscriptionScheduler struct {
+	db            *gorm.DB
+	checkInterval time.Duration
+	// send envia a mensagem ao cliente (WhatsApp pela sessão do canal da última conversa)
+	send func(tenantID uuid.UUID, customer *models.Customer, message string) error
+}
+
+// NewSubscriptionScheduler cria o agendador de pedidos recorrentes
+func NewSubscriptionScheduler(db *gorm.DB) *SubscriptionScheduler {
+	scheduler := &SubscriptionScheduler{db: db, checkInterval: 15 * time.Minute}
+	scheduler.send = scheduler.sendWhatsApp
+	return scheduler
+}
+
+// Start processa as assinaturas vencidas até o contexto ser cancelado
+func (s *SubscriptionScheduler) Start(ctx context.Context) {
+	ticker := time.NewTicker(s.checkInterval)
+	defer ticker.Stop()
+
+	s.processDueSubscriptions(time.Now())
+	for {
+		select {
+		case <-ticker.C:
+			s.processDueSubscriptions(time.Now())
+		case <-ctx.Done():
+			log.Info().Msg("🔁 Agendador de assinaturas encerrado")
+			return
+		}
+	}
+}
+
+// processDueSubscriptions gera os pedidos de todas as assinaturas ativas com data vencida
+func (s *SubscriptionScheduler) processDueSubscriptions(now time.Time) {
+	var subscriptions []models.Subscription
+	err := s.db.Where("status = ? AND next_run_at <= ?", SubscriptionStatusActive, now).
+		Order("next_run_at ASC").
+		Limit(200).
+		Find(&subscriptions).Error
+	if err != nil {
+		log.Error().Err(err).Msg("❌ Erro ao buscar assinaturas vencidas")
+		return
+	}
+
+	for i := range subscriptions {
+		if err := s.runSubscription(&subscriptions[i], now); err != nil {
+			log.Error().Err(err).Str("subscription_id", subscriptions[i].ID.String()).Msg("❌ Erro ao gerar pedido da assinatura")
+		}
+	}
+}
+
+// runSubscription gera o pedido de uma assinatura (ou pula o ciclo) e agenda a próxima data
+func (s *SubscriptionScheduler) runSubscription(subscription *models.Subscription, now time.Time) error {
+	var customer models.Customer
+	if err := s.db.Where("id = ? AND tenant_id = ?", subscription.CustomerID, subscription.TenantID).First(&customer).Error; err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("erro ao buscar cliente da assinatura: %w", err)
+		}
+		// Cliente removido: a assinatura é encerrada para não voltar a cada ciclo
+		log.Info().Str("subscription_id", subscription.ID.String()).Msg("🔁 Assinatura encerrada: cliente removido")
+		return s.db.Model(subscription).Update("status", SubscriptionStatusCancelled).Error
+	}
+
+	var product *models.Product
+	var loaded models.Product
+	if err := s.db.Where("id = ? AND tenant_id = ?", subscription.ProductID, subscription.TenantID).First(&loaded).Error; err == nil {
+		product = &loaded
+	}
+
+	nextRunAt := nextScheduledRun(subscription, now)
+	action := planSubscriptionRun(subscription, product)
+
+	if action != subscriptionRunCreateOrder {
+		updates := map[string]interface{}{"next_run_at": nextRunAt, "last_run_at": now}
+		if action == subscriptionRunRestricted {
+			// Produto restrito não volta a ser pedido automaticamente: a assinatura é encerrada
+			updates["status"] = SubscriptionStatusCancelled
+		}
+		if err := s.db.Model(subscription).Updates(updates).Error; err != nil {
+			return err
+		}
+		log.Info().
+			Str("subscription_id", subscription.ID.String()).
+			Int("action", int(action)).
+			Time("next_run_at", nextRunAt).
+			Msg("🔁 Ciclo da assinatura pulado")
+		if action == subscriptionRunAwaitingConfirmation {
+			return nil
+		}
+		return s.send(subscription.TenantID, &customer, subscriptionSkippedMessage(action, subscription, product, nextRunAt))
+	}
+
+	var address *models.Address
+	var defaultAddress models.Address
+	if err := s.db.Where("tenant_id = ? AND customer_id = ? AND is_default = ?", subscription.TenantID, subscription.CustomerID, true).First(&defaultAddress).Error; err == nil {
+		address = &defaultAddress
+	}
+
+	nextNumber := func() string { return NextOrderNumber(s.db, subscription.TenantID, now, generateOrderNumber) }
+	order, item := buildSubscriptionOrder(subscription, product, &customer, address, nextNumber())
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := CreateOrderWithUniqueNumber(tx, &order, nextNumber); err != nil {
+			return err
+		}
+		if err := tx.Create(&item).Error; err != nil {
+			return err
+		}
+		return tx.Model(subscription).Updates(map[string]interface{}{
+			"next_run_at":      nextRunAt,
+			"last_run_at":      now,
+			"pending_order_id": order.ID,
+		}).Error
+	})
+	if err != nil {
+		return err
+	}
+
+	log.Info().
+		Str("subscription_id", subscription.ID.String()).
+		Str("order_id", order.ID.String()).
+		Str("order_number", order.OrderNumber).
+		Time("next_run_at", nextRunAt).
+		Msg("🔁 Pedido da assinatura gerado, aguardando confirmação do cliente")
+
+	return s.send(subscription.TenantID, &customer, subscriptionOrderMessage(&order, subscription, product))
+}
+
+// sendWhatsApp envia a mensagem pela sessão do canal da conversa mais recente do cliente
+func (s *SubscriptionScheduler) sendWhatsApp(tenantID uuid.UUID, customer *models.Customer, message string) error {
+	var conversation models.Conversation
+	if err := s.db.Where("tenant_id = ? AND customer_id = ?", tenantID, customer.ID).Order("updated_at DESC").First(&conversation).Error; err != nil {
+		return fmt.Errorf("conversa do cliente não encontrada: %w", err)
+	}
+
+	var channel models.Channel
+	if err := s.db.Where("id = ?", conversation.ChannelID).First(&channel).Error; err != nil || channel.Session == "" {
+		return fmt.Errorf("canal da conversa não encontrado: %v", err)
+	}
+
+	return zapplus.GetClient().SendTextMessage(channel.Session, fmt.Sprintf("%s@c.us", customer.Phone), message)
+}
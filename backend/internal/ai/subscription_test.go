@@ -0,0 +1,303 @@
+package ai
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"iafarma/pkg/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+func TestNextSubscriptionRun(t *testing.T) {
+	base := time.Date(2024, time.January, 31, 10, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name         string
+		from         time.Time
+		cadence      string
+		intervalDays int
+		expected     time.Time
+	}{
+		{"semanal", base, SubscriptionCadenceWeekly, 0, time.Date(2024, time.February, 7, 10, 0, 0, 0, time.UTC)},
+		{"quinzenal", base, SubscriptionCadenceBiweekly, 0, time.Date(2024, time.February, 14, 10, 0, 0, 0, time.UTC)},
+		{"a cada 20 dias", base, SubscriptionCadenceDays, 20, time.Date(2024, time.February, 20, 10, 0, 0, 0, time.UTC)},
+		{"mensal limita ao fim de fevereiro", base, SubscriptionCadenceMonthly, 0, time.Date(2024, time.February, 29, 10, 0, 0, 0, time.UTC)},
+		{"mensal mantém o dia", time.Date(2024, time.March, 15, 10, 0, 0, 0, time.UTC), SubscriptionCadenceMonthly, 0, time.Date(2024, time.April, 15, 10, 0, 0, 0, time.UTC)},
+		{"mensal vira o ano", time.Date(2024, time.December, 31, 10, 0, 0, 0, time.UTC), SubscriptionCadenceMonthly, 0, time.Date(2025, time.January, 31, 10, 0, 0, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := nextSubscriptionRun(tt.from, tt.cadence, tt.intervalDays)
+			if !got.Equal(tt.expected) {
+				t.Errorf("esperado %s, obtido %s", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestParseSubscriptionCadence(t *testing.T) {
+	tests := []struct {
+		frequency    string
+		intervalDays int
+		cadence      string
+		interval     int
+		wantErr      bool
+	}{
+		{"Mensal", 0, SubscriptionCadenceMonthly, 0, false},
+		{"todo mês", 0, SubscriptionCadenceMonthly, 0, false},
+		{"quinzenal", 10, SubscriptionCadenceBiweekly, 0, false},
+		{"dias", 20, SubscriptionCadenceDays, 20, false},
+		{"dias", 0, "", 0, true},
+		{"dias", 365, "", 0, true},
+		{"anual", 0, "", 0, true},
+	}
+
+	for _, tt := range tests {
+		cadence, interval, err := parseSubscriptionCadence(tt.frequency, tt.intervalDays)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%q/%d: erro esperado=%v, obtido %v", tt.frequency, tt.intervalDays, tt.wantErr, err)
+			continue
+		}
+		if cadence != tt.cadence || interval != tt.interval {
+			t.Errorf("%q/%d: esperado %s/%d, obtido %s/%d", tt.frequency, tt.intervalDays, tt.cadence, tt.interval, cadence, interval)
+		}
+	}
+}
+
+func TestNextScheduledRunSkipsMissedCycles(t *testing.T) {
+	now := time.Date(2024, time.June, 10, 12, 0, 0, 0, time.UTC)
+
+	onTime := &models.Subscription{Cadence: SubscriptionCadenceWeekly, NextRunAt: time.Date(2024, time.June, 10, 9, 0, 0, 0, time.UTC)}
+	if got := nextScheduledRun(onTime, now); !got.Equal(time.Date(2024, time.June, 17, 9, 0, 0, 0, time.UTC)) {
+		t.Errorf("esperado manter o horário agendado, obtido %s", got)
+	}
+
+	late := &models.Subscription{Cadence: SubscriptionCadenceWeekly, NextRunAt: time.Date(2024, time.May, 1, 9, 0, 0, 0, time.UTC)}
+	if got := nextScheduledRun(late, now); !got.Equal(now.AddDate(0, 0, 7)) {
+		t.Errorf("esperado recalcular a partir de agora, obtido %s", got)
+	}
+}
+
+func TestPlanSubscriptionRun(t *testing.T) {
+	pendingID := uuid.New()
+	available := &models.Product{Name: "Losartana", Price: "12.50", Available: true, StockQuantity: 10}
+	noStock := &models.Product{Name: "Losartana", Price: "12.50", Available: true, StockQuantity: 1}
+	disabled := &models.Product{Name: "Losartana", Price: "12.50", Available: false, StockQuantity: 10}
+	noPrice := &models.Product{Name: "Losartana", Price: "0", Available: true, StockQuantity: 10}
+	prescription := &models.Product{Name: "Losartana", Price: "12.50", Available: true, StockQuantity: 10, RequiresPrescription: true}
+	adult := &models.Product{Name: "Cerveja", Price: "6.00", Available: true, StockQuantity: 10, AgeRestricted: true}
+
+	tests := []struct {
+		name         string
+		subscription *models.Subscription
+		product      *models.Product
+		expected     subscriptionRunAction
+	}{
+		{"gera pedido", &models.Subscription{Quantity: 2}, available, subscriptionRunCreateOrder},
+		{"pedido anterior pendente", &models.Subscription{Quantity: 2, PendingOrderID: &pendingID}, available, subscriptionRunAwaitingConfirmation},
+		{"sem estoque suficiente", &models.Subscription{Quantity: 2}, noStock, subscriptionRunOutOfStock},
+		{"produto indisponível", &models.Subscription{Quantity: 2}, disabled, subscriptionRunUnavailable},
+		{"produto sem preço", &models.Subscription{Quantity: 2}, noPrice, subscriptionRunUnavailable},
+		{"produto removido", &models.Subscription{Quantity: 2}, nil, subscriptionRunUnavailable},
+		{"produto passou a exigir receita", &models.Subscription{Quantity: 2}, prescription, subscriptionRunRestricted},
+		{"produto restrito por idade", &models.Subscription{Quantity: 2}, adult, subscriptionRunRestricted},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := planSubscriptionRun(tt.subscription, tt.product); got != tt.expected {
+				t.Errorf("esperado %d, obtido %d", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestBuildSubscriptionOrder(t *testing.T) {
+	tenantID := uuid.New()
+	subscription := &models.Subscription{
+		BaseTenantModel: models.BaseTenantModel{ID: uuid.New(), TenantID: tenantID},
+		CustomerID:      uuid.New(),
+		ProductID:       uuid.New(),
+		Quantity:        3,
+	}
+	product := &models.Product{Name: "Losartana 50mg", Price: "12.50", Available: true, StockQuantity: 10}
+	customer := &models.Customer{Name: "Maria", Phone: "5511999999999"}
+	address := &models.Address{Street: "Rua A", Number: "10", City: "São Paulo", State: "SP"}
+
+	order, item := buildSubscriptionOrder(subscription, product, customer, address, "PED-1")
+
+	if order.TenantID != tenantID || order.OrderNumber != "PED-1" || order.Status != "pending" {
+		t.Errorf("pedido com dados inesperados: %+v", order)
+	}
+	if order.TotalAmount != "37.50" || item.Total != "37.50" || item.Price != "12.50" {
+		t.Errorf("esperado total 37.50 e preço 12.50, obtido total %s/%s preço %s", order.TotalAmount, item.Total, item.Price)
+	}
+	if item.OrderID != order.ID || item.Quantity != 3 || *item.ProductID != subscription.ProductID {
+		t.Errorf("item não vinculado ao pedido: %+v", item)
+	}
+	if order.ShippingStreet == nil || *order.ShippingStreet != "Rua A" || order.CustomerName == nil || *order.CustomerName != "Maria" {
+		t.Errorf("esperado endereço e cliente copiados para o pedido")
+	}
+}
+
+func TestResolveSubscription(t *testing.T) {
+	subscriptions := []models.Subscription{
+		{Product: &models.Product{Name: "Losartana 50mg"}},
+		{Product: &models.Product{Name: "Vitamina D"}},
+	}
+
+	if got := resolveSubscription(subscriptions, "2"); got != &subscriptions[1] {
+		t.Errorf("esperado resolver pelo número da lista")
+	}
+	if got := resolveSubscription(subscriptions, "losartana"); got != &subscriptions[0] {
+		t.Errorf("esperado resolver pelo nome do produto")
+	}
+	if got := resolveSubscription(subscriptions, ""); got != nil {
+		t.Errorf("esperado nil sem identificador com mais de uma assinatura")
+	}
+	if got := resolveSubscription(subscriptions[:1], ""); got != &subscriptions[0] {
+		t.Errorf("esperado resolver a única assinatura sem identificador")
+	}
+	if got := resolveSubscription(subscriptions, "9"); got != nil {
+		t.Errorf("esperado nil para número fora da lista")
+	}
+}
+
+// fakeSubscriptionService registra os pedidos de assinatura confirmados ou pulados
+type fakeSubscriptionService struct {
+	SubscriptionServiceInterface
+	subscriptions []models.Subscription
+	resolved      []bool
+	address       *models.Address
+}
+
+func (f *fakeSubscriptionService) GetSubscriptionsByCustomer(tenantID, customerID uuid.UUID) ([]models.Subscription, error) {
+	return f.subscriptions, nil
+}
+
+func (f *fakeSubscriptionService) ResolvePendingOrder(tenantID, subscriptionID uuid.UUID, confirm bool, deliveryAddress *models.Address) (*models.Order, error) {
+	f.resolved = append(f.resolved, confirm)
+	f.address = deliveryAddress
+	return &models.Order{BaseTenantModel: models.BaseTenantModel{ID: uuid.New(), TenantID: tenantID}, OrderNumber: "PED-7", TotalAmount: "25.00", Status: "pending"}, nil
+}
+
+// newPendingSubscriptions retorna uma assinatura ativa com pedido aguardando confirmação do cliente
+func newPendingSubscriptions(product *models.Product) *fakeSubscriptionService {
+	pendingID := uuid.New()
+	return &fakeSubscriptionService{subscriptions: []models.Subscription{{
+		BaseTenantModel: models.BaseTenantModel{ID: uuid.New()},
+		ProductID:       uuid.New(),
+		Quantity:        2,
+		Status:          SubscriptionStatusActive,
+		PendingOrderID:  &pendingID,
+		Product:         product,
+	}}}
+}
+
+// withSubscriptions liga as assinaturas e o serviço de entrega que valida o endereço
+func withSubscriptions(subscriptions *fakeSubscriptionService, delivery DeliveryServiceInterface) testServiceOption {
+	return withOverride(func(s *AIService) {
+		s.subscriptionService = subscriptions
+		s.deliveryService = delivery
+	})
+}
+
+func TestConfirmSubscriptionOrderRunsCheckoutGates(t *testing.T) {
+	tenantID := uuid.New()
+	customerID := uuid.New()
+	product := &models.Product{Name: "Losartana 50mg", Price: "12.50", Available: true, StockQuantity: 10}
+	addresses := []models.Address{{BaseTenantModel: models.BaseTenantModel{ID: uuid.New()}, Street: "Rua A", Number: "10", City: "Brasília", State: "DF", IsDefault: true}}
+	confirm := map[string]interface{}{"acao": "confirmar"}
+
+	t.Run("sem endereço o pedido continua aguardando", func(t *testing.T) {
+		subscriptions := newPendingSubscriptions(product)
+		s, _ := newTestService(nil, withAddresses(), withOrders(), withSubscriptions(subscriptions, &streetDeliveryService{}))
+		result, _ := s.handleGerenciarAssinatura(context.Background(), tenantID, customerID, "5561999999999", confirm)
+		if !strings.Contains(result, "Nenhum endereço") || len(subscriptions.resolved) != 0 {
+			t.Errorf("esperado pedido bloqueado pela falta de endereço, obtido %q (resolvido: %v)", result, subscriptions.resolved)
+		}
+	})
+
+	t.Run("endereço fora da área de entrega", func(t *testing.T) {
+		subscriptions := newPendingSubscriptions(product)
+		s, _ := newTestService(nil, withAddresses(addresses...), withOrders(), withSubscriptions(subscriptions, &fakeDeliveryService{}))
+		s.handleGerenciarAssinatura(context.Background(), tenantID, customerID, "5561999999999", confirm)
+		if len(subscriptions.resolved) != 0 {
+			t.Errorf("pedido fora da área de entrega não deveria ser confirmado")
+		}
+	})
+
+	t.Run("produto passou a exigir receita", func(t *testing.T) {
+		restricted := *product
+		restricted.RequiresPrescription = true
+		subscriptions := newPendingSubscriptions(&restricted)
+		s, _ := newTestService(nil, withAddresses(addresses...), withOrders(), withSubscriptions(subscriptions, &streetDeliveryService{}))
+		result, _ := s.handleGerenciarAssinatura(context.Background(), tenantID, customerID, "5561999999999", confirm)
+		if len(subscriptions.resolved) != 0 || !strings.Contains(result, "receita") {
+			t.Errorf("esperado pedido bloqueado pela receita, obtido %q", result)
+		}
+	})
+
+	t.Run("confirma com o endereço validado", func(t *testing.T) {
+		subscriptions := newPendingSubscriptions(product)
+		s, _ := newTestService(nil, withAddresses(addresses...), withOrders(), withSubscriptions(subscriptions, &streetDeliveryService{}))
+		result, err := s.handleGerenciarAssinatura(context.Background(), tenantID, customerID, "5561999999999", confirm)
+		if err != nil {
+			t.Fatalf("erro inesperado: %v", err)
+		}
+		if len(subscriptions.resolved) != 1 || !subscriptions.resolved[0] || subscriptions.address == nil || subscriptions.address.Street != "Rua A" {
+			t.Fatalf("esperado pedido confirmado com o endereço validado, obtido %v / %+v", subscriptions.resolved, subscriptions.address)
+		}
+		if !strings.Contains(result, "PED-7") || !strings.Contains(result, "confirmado") {
+			t.Errorf("resposta inesperada: %q", result)
+		}
+	})
+}
+
+func TestRunSubscriptionCancelsWhenCustomerRemoved(t *testing.T) {
+	db, _ := newDryRunDB(t)
+	var updates []string
+	notFound := func(tx *gorm.DB) {
+		if _, ok := tx.Statement.Dest.(*models.Customer); ok {
+			tx.AddError(gorm.ErrRecordNotFound)
+		}
+	}
+	captureUpdate := func(tx *gorm.DB) {
+		updates = append(updates, tx.Statement.SQL.String())
+	}
+	if err := db.Callback().Query().After("gorm:query").Register("test:customer_removed", notFound); err != nil {
+		t.Fatalf("failed to register callback: %v", err)
+	}
+	if err := db.Callback().Update().After("gorm:update").Register("test:capture_update_sql", captureUpdate); err != nil {
+		t.Fatalf("failed to register callback: %v", err)
+	}
+
+	scheduler := NewSubscriptionScheduler(db.Session(&gorm.Session{SkipDefaultTransaction: true}))
+	sent := 0
+	scheduler.send = func(tenantID uuid.UUID, customer *models.Customer, message string) error {
+		sent++
+		return nil
+	}
+
+	subscription := &models.Subscription{
+		BaseTenantModel: models.BaseTenantModel{ID: uuid.New(), TenantID: uuid.New()},
+		CustomerID:      uuid.New(),
+		ProductID:       uuid.New(),
+		Quantity:        1,
+		Status:          SubscriptionStatusActive,
+	}
+	if err := scheduler.runSubscription(subscription, time.Now()); err != nil {
+		t.Fatalf("cliente removido não deveria gerar erro: %v", err)
+	}
+	if sent != 0 {
+		t.Errorf("nenhuma mensagem deveria ser enviada ao cliente removido")
+	}
+	if len(updates) != 1 || !strings.Contains(updates[0], `UPDATE "subscriptions" SET "status"=`) {
+		t.Errorf("esperado encerramento da assinatura, obtido %v", updates)
+	}
+}
@@ -3,6 +3,7 @@ package ai
 import (
 	"context"
 	"fmt"
+	"iafarma/internal/zapplus"
 	"iafarma/pkg/models"
 	"math/rand"
 	"strings"
@@ -12,25 +13,76 @@ import (
 )
 
 type TenantSettingsService struct {
-	db *gorm.DB
+	db    *gorm.DB
+	cache *tenantSettingsCache
 }
 
 func NewTenantSettingsService(db *gorm.DB) *TenantSettingsService {
-	return &TenantSettingsService{db: db}
+	return &TenantSettingsService{db: db, cache: sharedSettingsCache}
 }
 
-// GetSetting retrieves a specific setting for a tenant
+// WarmUp preloads all active settings of a tenant, so the first message does not wait for one query per setting
+func (s *TenantSettingsService) WarmUp(ctx context.Context, tenantID uuid.UUID) error {
+	return s.cache.warmUp(tenantID, func() ([]models.TenantSetting, error) {
+		return s.GetAllSettings(ctx, tenantID)
+	})
+}
+
+// WithSnapshot loads all of the tenant settings once and pins them to the request context, so every read
+// while processing a message sees the same values even if an admin updates a setting mid-request
+func (s *TenantSettingsService) WithSnapshot(ctx context.Context, tenantID uuid.UUID) (context.Context, error) {
+	if settingsSnapshotFromContext(ctx, tenantID) != nil {
+		return ctx, nil
+	}
+
+	settings, cached := s.cache.all(tenantID)
+	if !cached && s.WarmUp(ctx, tenantID) == nil {
+		settings, cached = s.cache.all(tenantID)
+	}
+	if !cached {
+		// Cache unavailable (loading failed): read the settings straight from the database
+		stored, err := s.GetAllSettings(ctx, tenantID)
+		if err != nil {
+			return ctx, err
+		}
+		settings = make(map[string]models.TenantSetting, len(stored))
+		for _, setting := range stored {
+			settings[setting.SettingKey] = setting
+		}
+	}
+
+	return withSettingsSnapshot(ctx, &settingsSnapshot{tenantID: tenantID, settings: settings}), nil
+}
+
+// GetSetting retrieves a specific setting for a tenant, loading all of the tenant settings on the first read.
+// Inside a request with a settings snapshot the pinned value is returned.
 func (s *TenantSettingsService) GetSetting(ctx context.Context, tenantID uuid.UUID, key string) (*models.TenantSetting, error) {
-	var setting models.TenantSetting
+	if snapshot := settingsSnapshotFromContext(ctx, tenantID); snapshot != nil {
+		return snapshot.get(key)
+	}
+
+	setting, cached := s.cache.get(tenantID, key)
+	if !cached && s.WarmUp(ctx, tenantID) == nil {
+		setting, cached = s.cache.get(tenantID, key)
+	}
+	if cached {
+		if setting == nil {
+			return nil, gorm.ErrRecordNotFound
+		}
+		return setting, nil
+	}
+
+	// Cache unavailable (loading failed): query only the requested setting
+	var stored models.TenantSetting
 	err := s.db.WithContext(ctx).
 		Where("tenant_id = ? AND setting_key = ? AND is_active = true", tenantID, key).
-		First(&setting).Error
+		First(&stored).Error
 
 	if err != nil {
 		return nil, err
 	}
 
-	return &setting, nil
+	return &stored, nil
 }
 
 // GetAllSettings retrieves all settings for a tenant
@@ -53,6 +105,7 @@ func (s *TenantSettingsService) SetSetting(ctx context.Context, tenantID uuid.UU
 		IsActive:     true,
 	}
 
+	defer s.cache.invalidate(tenantID)
 	return s.db.WithContext(ctx).
 		Where("tenant_id = ? AND setting_key = ?", tenantID, key).
 		Assign(setting).
@@ -94,8 +147,599 @@ func (s *TenantSettingsService) CreateDefaultSettings(ctx context.Context, tenan
 			Description:  "Temperatura para respostas da IA",
 			IsActive:     true,
 		},
+		{
+			TenantID:     tenantID,
+			SettingKey:   AIModelSettingKey,
+			SettingValue: func(s string) *string { return &s }(defaultChatModel),
+			SettingType:  "string",
+			Description:  "Modelo da OpenAI usado nas conversas com o cliente",
+			IsActive:     true,
+		},
+		{
+			TenantID:     tenantID,
+			SettingKey:   AIFallbackModelSettingKey,
+			SettingValue: func(s string) *string { return &s }(defaultChatFallbackModel),
+			SettingType:  "string",
+			Description:  "Modelo usado quando o principal está indisponível ou descontinuado (vazio desativa)",
+			IsActive:     true,
+		},
+		{
+			TenantID:     tenantID,
+			SettingKey:   MessageDebounceSettingKey,
+			SettingValue: func(s string) *string { return &s }("0"),
+			SettingType:  "integer",
+			Description:  "Janela (ms) para agrupar mensagens rápidas do mesmo cliente antes de responder (0 = desativado)",
+			IsActive:     true,
+		},
+		{
+			TenantID:     tenantID,
+			SettingKey:   UnhelpfulThresholdSettingKey,
+			SettingValue: func(s string) *string { return &s }("3"),
+			SettingType:  "integer",
+			Description:  "Número de respostas sem sucesso seguidas antes de oferecer atendente humano (0 = desativado)",
+			IsActive:     true,
+		},
+		{
+			TenantID:     tenantID,
+			SettingKey:   UnhelpfulModeSettingKey,
+			SettingValue: func(s string) *string { return &s }("offer"),
+			SettingType:  "string",
+			Description:  "Ação ao atingir o limite: 'offer' (perguntar ao cliente) ou 'handoff' (transferir automaticamente)",
+			IsActive:     true,
+		},
+		{
+			TenantID:     tenantID,
+			SettingKey:   LowConfidenceFallbackSettingKey,
+			SettingValue: func(s string) *string { return &s }("retry"),
+			SettingType:  "string",
+			Description:  "Resposta vaga da IA a um pedido claro de compra: off (envia assim mesmo), retry (tenta de novo usando as ferramentas), handoff (transfere para atendente) ou retry_handoff (tenta de novo e, se não resolver, transfere)",
+			IsActive:     true,
+		},
+		{
+			TenantID:     tenantID,
+			SettingKey:   SearchInStockOnlySettingKey,
+			SettingValue: func(s string) *string { return &s }("true"),
+			SettingType:  "boolean",
+			Description:  "Esconder produtos sem estoque nas buscas da IA (o cliente ainda pode pedir itens esgotados)",
+			IsActive:     true,
+		},
+		{
+			TenantID:     tenantID,
+			SettingKey:   SearchModeSettingKey,
+			SettingValue: func(s string) *string { return &s }("hybrid"),
+			SettingType:  "string",
+			Description:  "Modo de busca de produtos da IA: 'hybrid' (semântica + SQL para ordenação por preço), 'rag' (sempre semântica) ou 'sql' (sempre banco de dados)",
+			IsActive:     true,
+		},
+		{
+			TenantID:     tenantID,
+			SettingKey:   CatalogGroupingSettingKey,
+			SettingValue: func(s string) *string { return &s }(CatalogGroupingCategory),
+			SettingType:  "string",
+			Description:  "Organização do catálogo completo enviado pela IA: 'category' (por categoria), 'alphabetical' (ordem alfabética) ou 'price' (do menor para o maior preço)",
+			IsActive:     true,
+		},
+		{
+			TenantID:     tenantID,
+			SettingKey:   RAGMinScoreSettingKey,
+			SettingValue: func(s string) *string { return &s }("0"),
+			SettingType:  "float",
+			Description:  "Similaridade mínima (0 a 1) dos resultados da busca semântica; abaixo dela o produto não é exibido e, sem resultados, a busca usa o banco de dados (0 = sem corte)",
+			IsActive:     true,
+		},
+		{
+			TenantID:     tenantID,
+			SettingKey:   RewelcomeAfterDaysSettingKey,
+			SettingValue: func(s string) *string { return &s }("30"),
+			SettingType:  "integer",
+			Description:  "Dias sem contato para enviar novamente a mensagem de boas-vindas a um cliente que já foi recebido (0 = nunca)",
+			IsActive:     true,
+		},
+		{
+			TenantID:     tenantID,
+			SettingKey:   ConversationMaxAgeSettingKey,
+			SettingValue: func(s string) *string { return &s }("48"),
+			SettingType:  "integer",
+			Description:  "Horas sem mensagens do cliente após as quais a conversa recomeça do zero, limpando o carrinho e repetindo as boas-vindas (0 = nunca)",
+			IsActive:     true,
+		},
+		{
+			TenantID:     tenantID,
+			SettingKey:   BusinessInitiatedWindowHoursSettingKey,
+			SettingValue: func(s string) *string { return &s }("72"),
+			SettingType:  "integer",
+			Description:  "Horas após uma mensagem enviada pela loja (campanha, follow-up) em que a resposta do cliente segue o contexto, sem mensagem de boas-vindas (0 = sempre enviar boas-vindas)",
+			IsActive:     true,
+		},
+		{
+			TenantID:     tenantID,
+			SettingKey:   ReturningCustomerGreetingSettingKey,
+			SettingValue: func(s string) *string { return &s }("false"),
+			SettingType:  "boolean",
+			Description:  "Cumprimentar pelo nome os clientes que já fizeram pedidos (\"Oi, João! Bom te ver de novo\")",
+			IsActive:     true,
+		},
+		{
+			TenantID:     tenantID,
+			SettingKey:   AllowPickupSettingKey,
+			SettingValue: func(s string) *string { return &s }("false"),
+			SettingType:  "boolean",
+			Description:  "Permitir que o cliente retire o pedido na loja em vez de receber por entrega",
+			IsActive:     true,
+		},
+		{
+			TenantID:     tenantID,
+			SettingKey:   SplitDeliverySettingKey,
+			SettingValue: func(s string) *string { return &s }("false"),
+			SettingType:  "boolean",
+			Description:  "Permite dividir um pedido entre endereços de entrega (ex: parte para presente), gerando um pedido por endereço",
+			IsActive:     true,
+		},
+		{
+			TenantID:     tenantID,
+			SettingKey:   ProcessingAckImageSettingKey,
+			SettingValue: func(s string) *string { return &s }("false"),
+			SettingType:  "boolean",
+			Description:  "Enviar aviso imediato ('Analisando sua imagem...') enquanto a foto enviada pelo cliente é analisada",
+			IsActive:     true,
+		},
+		{
+			TenantID:     tenantID,
+			SettingKey:   ProcessingAckAudioSettingKey,
+			SettingValue: func(s string) *string { return &s }("false"),
+			SettingType:  "boolean",
+			Description:  "Enviar aviso imediato ('Ouvindo seu áudio...') enquanto o áudio do cliente é transcrito",
+			IsActive:     true,
+		},
+		{
+			TenantID:     tenantID,
+			SettingKey:   ProcessingAckSearchSettingKey,
+			SettingValue: func(s string) *string { return &s }("false"),
+			SettingType:  "boolean",
+			Description:  "Enviar aviso imediato ('Procurando os produtos...') antes de buscas com vários produtos",
+			IsActive:     true,
+		},
+		{
+			TenantID:     tenantID,
+			SettingKey:   PickupTimeWindowSettingKey,
+			SettingValue: func(s string) *string { return &s }(defaultPickupTimeWindow),
+			SettingType:  "string",
+			Description:  "Prazo de retirada informado ao cliente (ex: 'a partir de 30 minutos após a confirmação do pedido')",
+			IsActive:     true,
+		},
+		{
+			TenantID:     tenantID,
+			SettingKey:   AllowUrgentOrderSettingKey,
+			SettingValue: func(s string) *string { return &s }("false"),
+			SettingType:  "boolean",
+			Description:  "Permitir que o cliente marque o pedido como urgente (prioridade na separação e entrega)",
+			IsActive:     true,
+		},
+		{
+			TenantID:     tenantID,
+			SettingKey:   UrgentOrderFeeSettingKey,
+			SettingValue: func(s string) *string { return &s }("0"),
+			SettingType:  "float",
+			Description:  "Taxa de urgência (R$) somada aos pedidos urgentes (0 = sem taxa)",
+			IsActive:     true,
+		},
+		{
+			TenantID:     tenantID,
+			SettingKey:   ManualReviewThresholdSettingKey,
+			SettingValue: func(s string) *string { return &s }("0"),
+			SettingType:  "float",
+			Description:  "Pedidos acima deste valor (R$) aguardam confirmação manual de um operador (0 = desabilitado)",
+			IsActive:     true,
+		},
+		{
+			TenantID:     tenantID,
+			SettingKey:   AllowDepositPaymentSettingKey,
+			SettingValue: func(s string) *string { return &s }("false"),
+			SettingType:  "bool",
+			Description:  "Permite pagar um sinal antecipado e o restante na entrega",
+			IsActive:     true,
+		},
+		{
+			TenantID:     tenantID,
+			SettingKey:   DepositPercentSettingKey,
+			SettingValue: func(s string) *string { return &s }("30"),
+			SettingType:  "float",
+			Description:  "Percentual do total cobrado como sinal",
+			IsActive:     true,
+		},
+		{
+			TenantID:     tenantID,
+			SettingKey:   DepositFixedAmountSettingKey,
+			SettingValue: func(s string) *string { return &s }("0"),
+			SettingType:  "float",
+			Description:  "Valor fixo do sinal em R$ (0 = usa o percentual)",
+			IsActive:     true,
+		},
+		{
+			TenantID:     tenantID,
+			SettingKey:   DepositPaymentMethodSettingKey,
+			SettingValue: func(s string) *string { return &s }("PIX"),
+			SettingType:  "string",
+			Description:  "Forma de pagamento do sinal",
+			IsActive:     true,
+		},
+		{
+			TenantID:     tenantID,
+			SettingKey:   DepositRemainderMethodSettingKey,
+			SettingValue: func(s string) *string { return &s }("Dinheiro"),
+			SettingType:  "string",
+			Description:  "Forma de pagamento padrão do restante na entrega",
+			IsActive:     true,
+		},
+		{
+			TenantID:     tenantID,
+			SettingKey:   DeliveryFallbackModeSettingKey,
+			SettingValue: func(s string) *string { return &s }(defaultDeliveryFallbackMode),
+			SettingType:  "string",
+			Description:  "Quando a validação de entrega estiver indisponível: 'block' (pede para tentar mais tarde) ou 'manual_confirmation' (registra o pedido e a loja confirma a entrega manualmente)",
+			IsActive:     true,
+		},
+		{
+			TenantID:     tenantID,
+			SettingKey:   MaxAddressesSettingKey,
+			SettingValue: func(s string) *string { return &s }("5"),
+			SettingType:  "integer",
+			Description:  "Quantidade máxima de endereços cadastrados por cliente (0 = sem limite)",
+			IsActive:     true,
+		},
+		{
+			TenantID:     tenantID,
+			SettingKey:   LowStockUrgencyThresholdSettingKey,
+			SettingValue: func(s string) *string { return &s }("0"),
+			SettingType:  "integer",
+			Description:  "Destacar escassez ('restam só 3!') quando o estoque do produto estiver até este valor (0 = desativado)",
+			IsActive:     true,
+		},
+		{
+			TenantID:     tenantID,
+			SettingKey:   LowStockShowCountSettingKey,
+			SettingValue: func(s string) *string { return &s }("true"),
+			SettingType:  "boolean",
+			Description:  "Informar ao cliente a quantidade exata em estoque (false = apenas 'últimas unidades')",
+			IsActive:     true,
+		},
+		{
+			TenantID:     tenantID,
+			SettingKey:   ConversationSummarySettingKey,
+			SettingValue: func(s string) *string { return &s }("false"),
+			SettingType:  "boolean",
+			Description:  "Resumir automaticamente as mensagens antigas de conversas longas para manter o contexto (endereço, pedidos especiais)",
+			IsActive:     true,
+		},
+		{
+			TenantID:     tenantID,
+			SettingKey:   AutoPauseOnHumanHandoffSettingKey,
+			SettingValue: func(s string) *string { return &s }("true"),
+			SettingType:  "boolean",
+			Description:  "Pausar o bot na conversa quando o cliente pedir atendimento humano, até um atendente retomar",
+			IsActive:     true,
+		},
+		{
+			TenantID:     tenantID,
+			SettingKey:   SearchResultImagesSettingKey,
+			SettingValue: func(s string) *string { return &s }(SearchResultImagesOff),
+			SettingType:  "string",
+			Description:  "Imagens na busca de produtos: off (somente texto), top (imagem do primeiro resultado) ou album (primeiros resultados)",
+			IsActive:     true,
+		},
+		{
+			TenantID:     tenantID,
+			SettingKey:   SearchResultImagesMaxSettingKey,
+			SettingValue: func(s string) *string { return &s }("3"),
+			SettingType:  "integer",
+			Description:  "Quantidade máxima de imagens no álbum da busca de produtos (até 5)",
+			IsActive:     true,
+		},
+		{
+			TenantID:     tenantID,
+			SettingKey:   PromoFlyerURLSettingKey,
+			SettingValue: func(s string) *string { return &s }(""),
+			SettingType:  "string",
+			Description:  "Link (http/https) da imagem do encarte de ofertas enviado quando o cliente pede as promoções (vazio = listar produtos em promoção)",
+			IsActive:     true,
+		},
+		{
+			TenantID:     tenantID,
+			SettingKey:   PromoFlyerCaptionSettingKey,
+			SettingValue: func(s string) *string { return &s }(defaultPromoFlyerCaption),
+			SettingType:  "string",
+			Description:  "Legenda enviada junto com o encarte de ofertas",
+			IsActive:     true,
+		},
+		{
+			TenantID:     tenantID,
+			SettingKey:   MissingProductDemandSettingKey,
+			SettingValue: func(s string) *string { return &s }("off"),
+			SettingType:  "string",
+			Description:  "Produtos pedidos que a loja não tem: off (só informa), log (registra a demanda) ou notify (registra e oferece aviso de reposição)",
+			IsActive:     true,
+		},
+		{
+			TenantID:     tenantID,
+			SettingKey:   EmptySearchBehaviorSettingKey,
+			SettingValue: func(s string) *string { return &s }(EmptySearchSuggestions),
+			SettingType:  "string",
+			Description:  "Busca sem resultado: suggestions (termos parecidos), categories (principais categorias) ou best_sellers (mais vendidos)",
+			IsActive:     true,
+		},
+		{
+			TenantID:     tenantID,
+			SettingKey:   BestSellersWindowDaysSettingKey,
+			SettingValue: func(s string) *string { return &s }("30"),
+			SettingType:  "integer",
+			Description:  "Quantos dias de pedidos contam para o ranking de mais vendidos",
+			IsActive:     true,
+		},
+		{
+			TenantID:     tenantID,
+			SettingKey:   DefaultSearchSortSettingKey,
+			SettingValue: func(s string) *string { return &s }(defaultSearchSort),
+			SettingType:  "string",
+			Description:  "Ordenação padrão das buscas: relevance, price_asc (mais barato primeiro), price_desc ou name_asc (alfabética)",
+			IsActive:     true,
+		},
+		{
+			TenantID:     tenantID,
+			SettingKey:   DeliveryFeeSettingKey,
+			SettingValue: func(s string) *string { return &s }("0"),
+			SettingType:  "float",
+			Description:  "Taxa de entrega fixa cobrada nos pedidos com entrega (0 = sem taxa)",
+			IsActive:     true,
+		},
+		{
+			TenantID:     tenantID,
+			SettingKey:   FreeShippingMinAmountSettingKey,
+			SettingValue: func(s string) *string { return &s }("0"),
+			SettingType:  "float",
+			Description:  "Valor mínimo do pedido para frete grátis (0 = sem campanha de frete grátis)",
+			IsActive:     true,
+		},
+		{
+			TenantID:     tenantID,
+			SettingKey:   ShippingFeePerKgSettingKey,
+			SettingValue: func(s string) *string { return &s }("0"),
+			SettingType:  "float",
+			Description:  "Frete por quilo dos produtos, somado à taxa de entrega fixa (0 = sem cobrança por peso)",
+			IsActive:     true,
+		},
+		{
+			TenantID:     tenantID,
+			SettingKey:   ShippingDefaultWeightSettingKey,
+			SettingValue: func(s string) *string { return &s }("0"),
+			SettingType:  "float",
+			Description:  "Peso em gramas assumido no frete para produtos sem peso cadastrado (0 = não estimar e avisar o cliente)",
+			IsActive:     true,
+		},
+		{
+			TenantID:     tenantID,
+			SettingKey:   DeliveryMaxWeightSettingKey,
+			SettingValue: func(s string) *string { return &s }("0"),
+			SettingType:  "float",
+			Description:  "Peso máximo (kg) aceito na entrega; acima dele o cliente deve dividir o pedido ou retirar na loja (0 = sem limite)",
+			IsActive:     true,
+		},
+		{
+			TenantID:     tenantID,
+			SettingKey:   UrgentDeliveryMaxWeightSettingKey,
+			SettingValue: func(s string) *string { return &s }("0"),
+			SettingType:  "float",
+			Description:  "Peso máximo (kg) da entrega urgente feita por motoboy (0 = usa o limite da entrega padrão)",
+			IsActive:     true,
+		},
+		{
+			TenantID:     tenantID,
+			SettingKey:   MaxMessageLengthSettingKey,
+			SettingValue: func(s string) *string { return &s }("4000"),
+			SettingType:  "integer",
+			Description:  "Tamanho máximo de cada mensagem enviada ao cliente; respostas maiores são divididas entre os itens da lista",
+			IsActive:     true,
+		},
+		{
+			TenantID:     tenantID,
+			SettingKey:   NumericReplyRoutingSettingKey,
+			SettingValue: func(s string) *string { return &s }("true"),
+			SettingType:  "boolean",
+			Description:  "Interpretar respostas só com um número ('2') conforme a última lista exibida (produtos, endereços, formas de pagamento ou quantidade)",
+			IsActive:     true,
+		},
+		{
+			TenantID:     tenantID,
+			SettingKey:   InstallmentsMaxSettingKey,
+			SettingValue: func(s string) *string { return &s }("1"),
+			SettingType:  "integer",
+			Description:  "Número máximo de parcelas no cartão (1 = sem parcelamento)",
+			IsActive:     true,
+		},
+		{
+			TenantID:     tenantID,
+			SettingKey:   InstallmentsMinOrderSettingKey,
+			SettingValue: func(s string) *string { return &s }("0"),
+			SettingType:  "float",
+			Description:  "Valor mínimo do pedido para permitir parcelamento (0 = qualquer valor)",
+			IsActive:     true,
+		},
+		{
+			TenantID:     tenantID,
+			SettingKey:   InstallmentsInterestFreeSettingKey,
+			SettingValue: func(s string) *string { return &s }("0"),
+			SettingType:  "integer",
+			Description:  "Até quantas parcelas não há cobrança de juros",
+			IsActive:     true,
+		},
+		{
+			TenantID:     tenantID,
+			SettingKey:   InstallmentsInterestRateSettingKey,
+			SettingValue: func(s string) *string { return &s }("0"),
+			SettingType:  "float",
+			Description:  "Taxa de juros mensal (%) das parcelas acima das sem juros",
+			IsActive:     true,
+		},
+		{
+			TenantID:     tenantID,
+			SettingKey:   FreeShippingStartsAtSettingKey,
+			SettingValue: func(s string) *string { return &s }(""),
+			SettingType:  "string",
+			Description:  "Início da campanha de frete grátis (YYYY-MM-DD, vazio = já vale)",
+			IsActive:     true,
+		},
+		{
+			TenantID:     tenantID,
+			SettingKey:   FreeShippingEndsAtSettingKey,
+			SettingValue: func(s string) *string { return &s }(""),
+			SettingType:  "string",
+			Description:  "Fim da campanha de frete grátis (YYYY-MM-DD, inclusivo; vazio = sem data de fim)",
+			IsActive:     true,
+		},
+		{
+			TenantID:     tenantID,
+			SettingKey:   RememberDeliveryAddressDaysSettingKey,
+			SettingValue: func(s string) *string { return &s }("0"),
+			SettingType:  "integer",
+			Description:  "Dias em que o endereço do último pedido é usado no checkout sem pedir nova confirmação (0 = sempre confirmar, máximo 90)",
+			IsActive:     true,
+		},
+		{
+			TenantID:     tenantID,
+			SettingKey:   ReturnWindowDaysSettingKey,
+			SettingValue: func(s string) *string { return &s }("7"),
+			SettingType:  "integer",
+			Description:  "Dias após a entrega em que o cliente pode pedir devolução ou troca pelo WhatsApp (0 = não aceitar, máximo 90)",
+			IsActive:     true,
+		},
+		{
+			TenantID:     tenantID,
+			SettingKey:   ReaddGuardMinutesSettingKey,
+			SettingValue: func(s string) *string { return &s }("10"),
+			SettingType:  "integer",
+			Description:  "Minutos em que um produto removido pelo cliente só volta ao carrinho depois de confirmação (0 = desativado, máximo 120)",
+			IsActive:     true,
+		},
+		{
+			TenantID:     tenantID,
+			SettingKey:   NutritionDisclaimerSettingKey,
+			SettingValue: func(s string) *string { return &s }(defaultNutritionDisclaimer),
+			SettingType:  "string",
+			Description:  "Aviso anexado às respostas com valores nutricionais (vazio = sem aviso)",
+			IsActive:     true,
+		},
+		{
+			TenantID:     tenantID,
+			SettingKey:   NutritionOfferHumanSettingKey,
+			SettingValue: func(s string) *string { return &s }("true"),
+			SettingType:  "boolean",
+			Description:  "Oferecer atendente quando o cliente pedir informação nutricional de um produto sem tabela cadastrada",
+			IsActive:     true,
+		},
+		{
+			TenantID:     tenantID,
+			SettingKey:   StockAlternativesSettingKey,
+			SettingValue: func(s string) *string { return &s }("true"),
+			SettingType:  "boolean",
+			Description:  "Oferecer produtos parecidos (mesma categoria ou marca) em estoque quando o cliente pede mais do que há disponível",
+			IsActive:     true,
+		},
+		{
+			TenantID:     tenantID,
+			SettingKey:   DefaultLanguageSettingKey,
+			SettingValue: func(s string) *string { return &s }("pt"),
+			SettingType:  "string",
+			Description:  "Idioma padrão do atendimento (pt, en ou es); o cliente pode escolher outro durante a conversa",
+			IsActive:     true,
+		},
+		{
+			TenantID:     tenantID,
+			SettingKey:   CategoryBusinessHoursSettingKey,
+			SettingValue: func(s string) *string { return &s }("{}"),
+			SettingType:  "json",
+			Description:  "Horários próprios por categoria/setor (ID da categoria → horários da semana); categorias fora da lista seguem o horário da loja",
+			IsActive:     true,
+		},
+		{
+			TenantID:     tenantID,
+			SettingKey:   ProductDisplayAttributesSettingKey,
+			SettingValue: func(s string) *string { return &s }("[]"),
+			SettingType:  "json",
+			Description:  "Campos exibidos nas listagens e no detalhe do produto, na ordem: fixos (preco, descricao, sku, estoque, marca, peso) ou atributos do produto (ex: [\"preco\", \"dosagem\"]). Vazio mantém o padrão",
+			IsActive:     true,
+		},
+		{
+			TenantID:     tenantID,
+			SettingKey:   AcceptOrdersWhenClosedSettingKey,
+			SettingValue: func(s string) *string { return &s }("true"),
+			SettingType:  "boolean",
+			Description:  "Atender normalmente fora do horário de funcionamento (desligado, áudios e imagens só têm o recebimento confirmado, sem análise da IA)",
+			IsActive:     true,
+		},
+		{
+			TenantID:     tenantID,
+			SettingKey:   ClosedMediaMessageSettingKey,
+			SettingValue: func(s string) *string { return &s }(""),
+			SettingType:  "string",
+			Description:  "Mensagem para áudios e imagens recebidos com a loja fechada ({{media}} = 'seu áudio'/'sua imagem'; vazio = mensagem padrão)",
+			IsActive:     true,
+		},
+		{
+			TenantID:     tenantID,
+			SettingKey:   InventorySourceSettingKey,
+			SettingValue: func(s string) *string { return &s }(InventorySourceLocal),
+			SettingType:  "string",
+			Description:  "Origem do estoque: local (catálogo), external (buscas e estoque do ERP) ou hybrid (catálogo com o estoque do ERP)",
+			IsActive:     true,
+		},
+		{
+			TenantID:     tenantID,
+			SettingKey:   InventoryAPIURLSettingKey,
+			SettingValue: func(s string) *string { return &s }(""),
+			SettingType:  "string",
+			Description:  "URL base (HTTPS) da API de estoque do ERP",
+			IsActive:     true,
+		},
+		{
+			TenantID:     tenantID,
+			SettingKey:   InventoryAPITokenSettingKey,
+			SettingValue: func(s string) *string { return &s }(""),
+			SettingType:  "string",
+			Description:  "Token enviado como Bearer nas chamadas à API de estoque do ERP",
+			IsActive:     true,
+		},
+		{
+			TenantID:     tenantID,
+			SettingKey:   OrderNumberFormatSettingKey,
+			SettingValue: func(s string) *string { return &s }(""),
+			SettingType:  "string",
+			Description:  "Formato do número do pedido, ex: \"PH-{seq}\" ou \"ORD-{date}-{seq}\" (marcadores: {seq}, {date}, {year}, {month}, {day}; vazio = formato padrão)",
+			IsActive:     true,
+		},
 	}
 
+	// Avisos ao cliente quando o operador avança o status do pedido (cada etapa pode ser desligada)
+	for _, status := range zapplus.NotifiedOrderStatuses {
+		defaultSettings = append(defaultSettings,
+			models.TenantSetting{
+				TenantID:     tenantID,
+				SettingKey:   zapplus.OrderStatusNotificationEnabledSettingKey(status),
+				SettingValue: func(s string) *string { return &s }("true"),
+				SettingType:  "boolean",
+				Description:  fmt.Sprintf("Avisar o cliente por WhatsApp quando o pedido mudar para \"%s\"", status),
+				IsActive:     true,
+			},
+			models.TenantSetting{
+				TenantID:     tenantID,
+				SettingKey:   zapplus.OrderStatusNotificationTemplateSettingKey(status),
+				SettingValue: func(s string) *string { return &s }(zapplus.DefaultOrderStatusTemplates[status]),
+				SettingType:  "string",
+				Description:  fmt.Sprintf("Mensagem enviada quando o pedido muda para \"%s\" (variáveis: {{order_number}}, {{customer_name}}, {{total}}, {{date}}, {{eta}}, {{tracking_code}}, {{tracking_url}}, {{tracking}})", status),
+				IsActive:     true,
+			},
+		)
+	}
+
+	defer s.cache.invalidate(tenantID)
 	for _, setting := range defaultSettings {
 		if err := s.db.WithContext(ctx).Create(&setting).Error; err != nil {
 			return fmt.Errorf("failed to create setting %s: %w", setting.SettingKey, err)
@@ -397,6 +1041,10 @@ Para FINALIZAR PEDIDOS, aceite qualquer variação como:
 - "quero pagar", "vamos finalizar", "pode fechar"
 → Todas essas variações devem usar a função "checkout"
 
+Para ADICIONAR E FINALIZAR na mesma mensagem:
+- "adiciona 2 dipironas e já finaliza", "coloca o 3 e fecha o pedido"
+→ Use a função "adicionarEFinalizar" com todos os itens (não chame também "checkout")
+
 Para VER CARRINHO, aceite variações como:
 - "ver carrinho", "mostrar carrinho", "meu carrinho"
 - "o que tenho", "itens", "produtos no carrinho"
@@ -0,0 +1,189 @@
+package ai
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+// ToolArgumentError indica que os argumentos enviados pela IA não respeitam o schema declarado da ferramenta
+type ToolArgumentError struct {
+	ToolName string
+	Field    string
+	Reason   string
+}
+
+func (e *ToolArgumentError) Error() string {
+	if e.Field == "" {
+		return fmt.Sprintf("invalid arguments for tool %s: %s", e.ToolName, e.Reason)
+	}
+	return fmt.Sprintf("invalid arguments for tool %s: field '%s' %s", e.ToolName, e.Field, e.Reason)
+}
+
+// validateToolArguments confere os argumentos contra o schema declarado em getAvailableTools.
+// Campos obrigatórios precisam estar presentes e os tipos precisam bater; conversões sem perda
+// (ex: "2" para integer, 3 para string) são normalizadas no próprio mapa para que os handlers
+// sempre recebam float64 em campos numéricos.
+func validateToolArguments(tools []openai.Tool, toolName string, args map[string]interface{}) error {
+	var parameters map[string]interface{}
+	for _, tool := range tools {
+		if tool.Function != nil && tool.Function.Name == toolName {
+			parameters, _ = tool.Function.Parameters.(map[string]interface{})
+			break
+		}
+	}
+
+	// Ferramentas sem schema declarado são tratadas pelo próprio executeTool
+	if parameters == nil {
+		return nil
+	}
+
+	properties, _ := parameters["properties"].(map[string]interface{})
+
+	if required, ok := parameters["required"].([]string); ok {
+		for _, field := range required {
+			value, present := args[field]
+			if !present || value == nil {
+				return &ToolArgumentError{ToolName: toolName, Field: field, Reason: "is required"}
+			}
+		}
+	}
+
+	for field, value := range args {
+		if value == nil {
+			continue
+		}
+
+		schema, ok := properties[field].(map[string]interface{})
+		if !ok {
+			// Campos extras são ignorados (ex: customer_id injetado internamente)
+			continue
+		}
+
+		normalized, err := normalizeToolArgument(schema, value)
+		if err != nil {
+			return &ToolArgumentError{ToolName: toolName, Field: field, Reason: err.Error()}
+		}
+		args[field] = normalized
+	}
+
+	return nil
+}
+
+// normalizeToolArgument valida um valor contra o schema da propriedade e retorna o valor normalizado
+func normalizeToolArgument(schema map[string]interface{}, value interface{}) (interface{}, error) {
+	expectedType, _ := schema["type"].(string)
+
+	switch expectedType {
+	case "string":
+		switch v := value.(type) {
+		case string:
+			value = v
+		case float64:
+			value = strconv.FormatFloat(v, 'f', -1, 64)
+		default:
+			return nil, fmt.Errorf("must be a string, got %s", jsonTypeName(value))
+		}
+
+		if enum, ok := schema["enum"].([]string); ok {
+			for _, option := range enum {
+				if option == value.(string) {
+					return value, nil
+				}
+			}
+			return nil, fmt.Errorf("must be one of [%s], got %q", strings.Join(enum, ", "), value)
+		}
+		return value, nil
+
+	case "number", "integer":
+		typeLabel := "a number"
+		if expectedType == "integer" {
+			typeLabel = "an integer"
+		}
+
+		var number float64
+		switch v := value.(type) {
+		case float64:
+			number = v
+		case string:
+			parsed, err := strconv.ParseFloat(strings.ReplaceAll(strings.TrimSpace(v), ",", "."), 64)
+			if err != nil {
+				return nil, fmt.Errorf("must be %s, got %q", typeLabel, v)
+			}
+			number = parsed
+		default:
+			return nil, fmt.Errorf("must be %s, got %s", typeLabel, jsonTypeName(value))
+		}
+
+		if expectedType == "integer" && number != math.Trunc(number) {
+			return nil, fmt.Errorf("must be an integer, got %v", number)
+		}
+		if minimum, ok := schema["minimum"].(int); ok && number < float64(minimum) {
+			return nil, fmt.Errorf("must be >= %d, got %v", minimum, number)
+		}
+		return number, nil
+
+	case "boolean":
+		switch v := value.(type) {
+		case bool:
+			return v, nil
+		case string:
+			parsed, err := strconv.ParseBool(strings.TrimSpace(v))
+			if err != nil {
+				return nil, fmt.Errorf("must be a boolean, got %q", v)
+			}
+			return parsed, nil
+		default:
+			return nil, fmt.Errorf("must be a boolean, got %s", jsonTypeName(value))
+		}
+
+	case "array":
+		items, ok := value.([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("must be an array, got %s", jsonTypeName(value))
+		}
+		itemSchema, ok := schema["items"].(map[string]interface{})
+		if !ok {
+			return items, nil
+		}
+		for i, item := range items {
+			normalized, err := normalizeToolArgument(itemSchema, item)
+			if err != nil {
+				return nil, fmt.Errorf("item %d %s", i, err.Error())
+			}
+			items[i] = normalized
+		}
+		return items, nil
+
+	case "object":
+		if _, ok := value.(map[string]interface{}); !ok {
+			return nil, fmt.Errorf("must be an object, got %s", jsonTypeName(value))
+		}
+		return value, nil
+	}
+
+	return value, nil
+}
+
+// jsonTypeName retorna o nome do tipo JSON correspondente ao valor decodificado
+func jsonTypeName(value interface{}) string {
+	switch value.(type) {
+	case string:
+		return "string"
+	case float64:
+		return "number"
+	case bool:
+		return "boolean"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	case nil:
+		return "null"
+	default:
+		return fmt.Sprintf("%T", value)
+	}
+}
@@ -0,0 +1,94 @@
+package ai
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestValidateToolArgumentsMalformed(t *testing.T) {
+	tools := (&AIService{}).getAvailableTools()
+
+	tests := []struct {
+		name     string
+		tool     string
+		args     map[string]interface{}
+		field    string
+		contains string
+	}{
+		{"missing required", "adicionarProdutoPorNome", map[string]interface{}{"quantidade": float64(2)}, "nome_produto", "is required"},
+		{"null required", "detalharPedido", map[string]interface{}{"order_id": nil}, "order_id", "is required"},
+		{"quantity as word", "adicionarProdutoPorNome", map[string]interface{}{"nome_produto": "dipirona", "quantidade": "duas"}, "quantidade", "must be an integer, got \"duas\""},
+		{"fractional integer", "adicionarProdutoPorNome", map[string]interface{}{"nome_produto": "dipirona", "quantidade": 1.5}, "quantidade", "must be an integer"},
+		{"below minimum", "buscarMultiplosProdutos", map[string]interface{}{"produtos": []interface{}{"a"}, "quantidade": float64(0)}, "quantidade", "must be >= 1"},
+		{"string as object", "detalharPedido", map[string]interface{}{"order_id": map[string]interface{}{"id": "1"}}, "order_id", "must be a string, got object"},
+		{"array expected", "buscarMultiplosProdutos", map[string]interface{}{"produtos": "dipirona e shampoo"}, "produtos", "must be an array"},
+		{"array item type", "buscarMultiplosProdutos", map[string]interface{}{"produtos": []interface{}{"dipirona", true}}, "produtos", "item 1 must be a string"},
+		{"enum mismatch", "gerenciarEnderecos", map[string]interface{}{"acao": "apagar"}, "acao", "must be one of"},
+	}
+
+	for _, test := range tests {
+		err := validateToolArguments(tools, test.tool, test.args)
+		if err == nil {
+			t.Errorf("%s: expected error, got nil", test.name)
+			continue
+		}
+
+		var argErr *ToolArgumentError
+		if !errors.As(err, &argErr) {
+			t.Errorf("%s: expected *ToolArgumentError, got %T", test.name, err)
+			continue
+		}
+		if argErr.ToolName != test.tool || argErr.Field != test.field {
+			t.Errorf("%s: error for %s.%s, expected %s.%s", test.name, argErr.ToolName, argErr.Field, test.tool, test.field)
+		}
+		if !strings.Contains(err.Error(), test.contains) {
+			t.Errorf("%s: error %q does not contain %q", test.name, err.Error(), test.contains)
+		}
+		// Mensagem uniforme, categorizada como validação pelo ErrorHandler
+		if !strings.HasPrefix(err.Error(), "invalid arguments for tool ") {
+			t.Errorf("%s: non-uniform error message %q", test.name, err.Error())
+		}
+	}
+}
+
+func TestValidateToolArgumentsNormalizesTypes(t *testing.T) {
+	tools := (&AIService{}).getAvailableTools()
+
+	args := map[string]interface{}{"nome_produto": "dipirona", "quantidade": "3"}
+	if err := validateToolArguments(tools, "adicionarProdutoPorNome", args); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if quantity, ok := args["quantidade"].(float64); !ok || quantity != 3 {
+		t.Errorf("quantidade = %#v, expected float64(3)", args["quantidade"])
+	}
+
+	args = map[string]interface{}{"order_id": float64(2)}
+	if err := validateToolArguments(tools, "detalharPedido", args); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if orderID, ok := args["order_id"].(string); !ok || orderID != "2" {
+		t.Errorf("order_id = %#v, expected \"2\"", args["order_id"])
+	}
+
+	// Campos extras e ferramentas sem schema não são rejeitados
+	args = map[string]interface{}{"order_id": "PED1", "customer_id": "abc"}
+	if err := validateToolArguments(tools, "detalharPedido", args); err != nil {
+		t.Errorf("extra field rejected: %v", err)
+	}
+	if err := validateToolArguments(tools, "ferramentaInexistente", map[string]interface{}{}); err != nil {
+		t.Errorf("unknown tool should be left to executeTool: %v", err)
+	}
+}
+
+func TestToolSchemasDeclareRequiredAsStringSlice(t *testing.T) {
+	for _, tool := range (&AIService{}).getAvailableTools() {
+		parameters, ok := tool.Function.Parameters.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if _, ok := parameters["required"].([]string); !ok && parameters["required"] != nil {
+			t.Errorf("tool %s: required must be []string, got %T", tool.Function.Name, parameters["required"])
+		}
+	}
+}
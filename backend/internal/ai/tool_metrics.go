@@ -0,0 +1,23 @@
+package ai
+
+import (
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+)
+
+// recordToolExecutions grava as ferramentas executadas em segundo plano para as métricas do tenant
+func (s *AIService) recordToolExecutions(tenantID, customerID uuid.UUID, customerPhone string, results []ToolExecutionResult) {
+	if s.toolMetricsService == nil || len(results) == 0 {
+		return
+	}
+
+	conversationID := s.getConversationID(tenantID, customerPhone)
+	executions := make([]ToolExecutionResult, len(results))
+	copy(executions, results)
+
+	go func() {
+		if err := s.toolMetricsService.RecordToolExecutions(tenantID, customerID, conversationID, executions); err != nil {
+			log.Warn().Err(err).Str("tenant_id", tenantID.String()).Msg("⚠️ Não foi possível registrar as ferramentas executadas")
+		}
+	}()
+}
@@ -0,0 +1,148 @@
+package ai
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"iafarma/internal/utils"
+	"iafarma/pkg/models"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+)
+
+// totalBreakdownLine é uma parcela do total; descontos entram com valor negativo
+type totalBreakdownLine struct {
+	Label  string
+	Amount utils.Cents
+}
+
+// totalBreakdown detalha como se chega ao total ("por que deu esse valor?"). A soma das linhas é sempre igual a Total.
+type totalBreakdown struct {
+	Lines        []totalBreakdownLine
+	Total        utils.Cents
+	FreeShipping bool
+	Pickup       bool
+}
+
+// hasCharges indica se há algo além do subtotal dos produtos (taxas, descontos, impostos)
+func (b totalBreakdown) hasCharges() bool {
+	return len(b.Lines) > 1
+}
+
+// orderTotalBreakdown detalha o total gravado no pedido. Se as parcelas gravadas não fecharem com o total
+// (ex: pedido ajustado manualmente por um operador), a diferença aparece como ajuste para a conta bater.
+func orderTotalBreakdown(order *models.Order) totalBreakdown {
+	subtotal, _ := utils.ParseCents(order.Subtotal)
+	tax, _ := utils.ParseCents(order.TaxAmount)
+	shipping, _ := utils.ParseCents(order.ShippingAmount)
+	urgencyFee, _ := utils.ParseCents(order.UrgencyFee)
+	discount, _ := utils.ParseCents(order.DiscountAmount)
+	total, _ := utils.ParseCents(order.TotalAmount)
+
+	breakdown := totalBreakdown{Total: total, Pickup: order.IsPickup}
+	breakdown.Lines = append(breakdown.Lines, totalBreakdownLine{"🧾 Subtotal dos produtos", subtotal})
+	if shipping > 0 {
+		breakdown.Lines = append(breakdown.Lines, totalBreakdownLine{"🚚 Entrega", shipping})
+	}
+	if urgencyFee > 0 {
+		breakdown.Lines = append(breakdown.Lines, totalBreakdownLine{"⚡ Taxa de urgência", urgencyFee})
+	}
+	if tax > 0 {
+		breakdown.Lines = append(breakdown.Lines, totalBreakdownLine{"🏛️ Impostos", tax})
+	}
+	if discount > 0 {
+		breakdown.Lines = append(breakdown.Lines, totalBreakdownLine{"🏷️ Desconto", -discount})
+	}
+
+	if adjustment := total - (subtotal + shipping + urgencyFee + tax - discount); adjustment != 0 {
+		log.Warn().
+			Str("order_id", order.ID.String()).
+			Str("total", order.TotalAmount).
+			Str("adjustment", adjustment.String()).
+			Msg("⚠️ Parcelas do pedido não fecham com o total gravado")
+		breakdown.Lines = append(breakdown.Lines, totalBreakdownLine{"✏️ Ajuste", adjustment})
+	}
+	return breakdown
+}
+
+// cartTotalBreakdown detalha o total que o carrinho terá ao ser finalizado, com as mesmas regras do checkout
+// (taxa de entrega com frete grátis e frete por peso, taxa de urgência). Cupons só são verificados, não aplicados,
+// por isso não entram aqui.
+func (s *AIService) cartTotalBreakdown(ctx context.Context, tenantID uuid.UUID, cart *models.Cart) totalBreakdown {
+	var subtotal utils.Cents
+	for _, item := range cart.Items {
+		subtotal += cartItemTotal(item)
+	}
+	quote := s.quoteCartDeliveryFee(ctx, tenantID, cart)
+	shipping := utils.CentsFromFloat(quote.Fee)
+	urgencyFee := utils.CentsFromFloat(s.cartUrgencyFee(ctx, tenantID, cart))
+
+	breakdown := totalBreakdown{
+		Total:        subtotal + shipping + urgencyFee,
+		FreeShipping: quote.FreeShipping,
+		Pickup:       s.isPickupCart(ctx, tenantID, cart),
+	}
+	breakdown.Lines = append(breakdown.Lines, totalBreakdownLine{"🧾 Subtotal dos produtos", subtotal})
+	if shipping > 0 {
+		breakdown.Lines = append(breakdown.Lines, totalBreakdownLine{"🚚 Entrega", shipping})
+	}
+	if urgencyFee > 0 {
+		breakdown.Lines = append(breakdown.Lines, totalBreakdownLine{"⚡ Taxa de urgência", urgencyFee})
+	}
+	return breakdown
+}
+
+// formatTotalBreakdown monta as linhas do detalhamento terminando no total
+func formatTotalBreakdown(breakdown totalBreakdown) string {
+	var result strings.Builder
+	for _, line := range breakdown.Lines {
+		if line.Amount < 0 {
+			result.WriteString(fmt.Sprintf("%s: -R$ %s\n", line.Label, formatCurrency((-line.Amount).String())))
+			continue
+		}
+		result.WriteString(fmt.Sprintf("%s: R$ %s\n", line.Label, formatCurrency(line.Amount.String())))
+	}
+	switch {
+	case breakdown.Pickup:
+		result.WriteString("🏪 Retirada na loja: sem taxa de entrega\n")
+	case breakdown.FreeShipping:
+		result.WriteString("🚚 Entrega: **grátis**\n")
+	}
+	result.WriteString(fmt.Sprintf("💰 **Total:** R$ %s", formatCurrency(breakdown.Total.String())))
+	return result.String()
+}
+
+// handleDetalharTotal explica o valor do carrinho atual ou, com o carrinho vazio, do último pedido do cliente
+func (s *AIService) handleDetalharTotal(ctx context.Context, tenantID, customerID uuid.UUID) (string, error) {
+	cart, err := s.cartService.GetOrCreateActiveCart(tenantID, customerID)
+	if err != nil {
+		return "❌ Erro ao acessar carrinho.", err
+	}
+	cartWithItems, err := s.cartService.GetCartWithItems(cart.ID, tenantID)
+	if err != nil {
+		return "❌ Erro ao carregar carrinho.", err
+	}
+
+	if len(cartWithItems.Items) > 0 {
+		return fmt.Sprintf("🧾 **Como chegamos ao total do seu carrinho:**\n\n%s\n\nℹ️ Os valores são conferidos novamente ao finalizar o pedido.",
+			formatTotalBreakdown(s.cartTotalBreakdown(ctx, tenantID, cartWithItems))), nil
+	}
+
+	orders, err := s.orderService.GetOrdersByCustomer(tenantID, customerID)
+	if err != nil {
+		return "❌ Erro ao consultar seus pedidos.", err
+	}
+	var last *models.Order
+	for i := range orders {
+		if last == nil || orders[i].CreatedAt.After(last.CreatedAt) {
+			last = &orders[i]
+		}
+	}
+	if last == nil {
+		return "🛒 Seu carrinho está vazio e você ainda não tem pedidos. Adicione produtos para ver o total detalhado.", nil
+	}
+
+	return fmt.Sprintf("🧾 **Como chegamos ao total do pedido %s:**\n\n%s", last.OrderNumber, formatTotalBreakdown(orderTotalBreakdown(last))), nil
+}
@@ -0,0 +1,156 @@
+package ai
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"iafarma/internal/utils"
+	"iafarma/pkg/models"
+
+	"github.com/google/uuid"
+)
+
+// pricingOrderService calcula os valores do pedido a partir do carrinho, como o serviço real
+type pricingOrderService struct {
+	*fakeOrderService
+	cart *models.Cart
+}
+
+func (f *pricingOrderService) CreateOrderFromCartWithAddress(tenantID, cartID uuid.UUID, deliveryAddress *models.Address) (*models.Order, error) {
+	order, err := f.fakeOrderService.CreateOrderFromCartWithAddress(tenantID, cartID, deliveryAddress)
+	if err != nil {
+		return nil, err
+	}
+	var subtotal utils.Cents
+	for _, item := range f.cart.Items {
+		subtotal += cartItemTotal(item)
+	}
+	order.ID = uuid.New()
+	order.Subtotal, order.TotalAmount = subtotal.String(), subtotal.String()
+	order.TaxAmount, order.ShippingAmount, order.DiscountAmount = "0.00", "0.00", "0.00"
+	return order, nil
+}
+
+func (f *pricingOrderService) ApplyShippingAmount(tenantID, orderID uuid.UUID, shippingAmount float64) (*models.Order, error) {
+	for i := range f.orders {
+		if f.orders[i].ID == orderID {
+			total, _ := utils.ParseCents(f.orders[i].TotalAmount)
+			shipping := utils.CentsFromFloat(shippingAmount)
+			f.orders[i].ShippingAmount = shipping.String()
+			f.orders[i].TotalAmount = (total + shipping).String()
+			return &f.orders[i], nil
+		}
+	}
+	return nil, fmt.Errorf("pedido %s não encontrado", orderID)
+}
+
+// breakdownSum soma as linhas do detalhamento, como o cliente faria ao conferir a conta
+func breakdownSum(breakdown totalBreakdown) utils.Cents {
+	var sum utils.Cents
+	for _, line := range breakdown.Lines {
+		sum += line.Amount
+	}
+	return sum
+}
+
+func TestOrderTotalBreakdownReconciles(t *testing.T) {
+	tests := []struct {
+		name     string
+		order    models.Order
+		esperado []string
+	}{
+		{
+			name:     "entrega, urgência, impostos e desconto",
+			order:    models.Order{Subtotal: "36.70", ShippingAmount: "7.50", UrgencyFee: "4.35", TaxAmount: "1.10", DiscountAmount: "5.00", TotalAmount: "44.65"},
+			esperado: []string{"Subtotal dos produtos: R$ 36,70", "Entrega: R$ 7,50", "Taxa de urgência: R$ 4,35", "Impostos: R$ 1,10", "Desconto: -R$ 5,00", "**Total:** R$ 44,65"},
+		},
+		{
+			name:     "retirada na loja",
+			order:    models.Order{Subtotal: "10.00", ShippingAmount: "0.00", DiscountAmount: "0.00", TotalAmount: "10.00", IsPickup: true},
+			esperado: []string{"Subtotal dos produtos: R$ 10,00", "Retirada na loja", "**Total:** R$ 10,00"},
+		},
+		{
+			name:     "total ajustado manualmente",
+			order:    models.Order{Subtotal: "20.00", ShippingAmount: "5.00", TotalAmount: "23.00"},
+			esperado: []string{"Entrega: R$ 5,00", "Ajuste: -R$ 2,00", "**Total:** R$ 23,00"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			breakdown := orderTotalBreakdown(&tt.order)
+
+			stored, _ := utils.ParseCents(tt.order.TotalAmount)
+			if sum := breakdownSum(breakdown); sum != stored || breakdown.Total != stored {
+				t.Errorf("detalhamento deveria fechar com o total gravado %s, soma %s, total %s", stored, sum, breakdown.Total)
+			}
+
+			text := formatTotalBreakdown(breakdown)
+			for _, esperado := range tt.esperado {
+				if !strings.Contains(text, esperado) {
+					t.Errorf("detalhamento deveria conter %q:\n%s", esperado, text)
+				}
+			}
+		})
+	}
+}
+
+func TestCheckoutBreakdownMatchesStoredOrderTotal(t *testing.T) {
+	tenantID, customerID := uuid.New(), uuid.New()
+	cart := newPickupTestCart(false)
+	cart.IsUrgent = true
+	cart.Items = []models.CartItem{
+		{Quantity: 3, Price: "8.90", Product: &models.Product{Name: "Dipirona 500mg", Price: "8.90"}},
+		{Quantity: 1, Price: "10.00", Product: &models.Product{Name: "Sabonete", Price: "10.00"}},
+	}
+
+	s, fakes := newTestService(map[string]string{AllowPickupSettingKey: "false"}, withCheckout(cart, newRememberedAddress(true)))
+
+	orders := fakes.orders
+	s.deliveryService = &failingDeliveryService{}
+	s.orderService = &pricingOrderService{fakeOrderService: orders, cart: cart}
+	s.settingsService = &fakeSettingsService{values: map[string]string{
+		DeliveryFeeSettingKey:      "7,50",
+		AllowUrgentOrderSettingKey: "true",
+		UrgentOrderFeeSettingKey:   "4.35",
+	}}
+
+	// Detalhamento pedido antes de finalizar ("por que deu esse valor?")
+	preview, err := s.executeTool(context.Background(), tenantID, customerID, "5561999999999", "detalharTotal", map[string]interface{}{})
+	if err != nil {
+		t.Fatalf("erro inesperado: %v", err)
+	}
+	for _, esperado := range []string{"Subtotal dos produtos: R$ 36,70", "Entrega: R$ 7,50", "Taxa de urgência: R$ 4,35", "**Total:** R$ 48,55"} {
+		if !strings.Contains(preview, esperado) {
+			t.Errorf("detalhamento do carrinho deveria conter %q:\n%s", esperado, preview)
+		}
+	}
+	cartTotal := s.cartTotalBreakdown(context.Background(), tenantID, cart).Total
+
+	confirmation, err := s.performFinalCheckout(context.Background(), tenantID, customerID, "5561999999999")
+	if err != nil {
+		t.Fatalf("erro inesperado no checkout final: %v", err)
+	}
+	if len(orders.orders) != 1 {
+		t.Fatalf("esperado 1 pedido, obtido %d:\n%s", len(orders.orders), confirmation)
+	}
+
+	order := orders.orders[0]
+	stored, _ := utils.ParseCents(order.TotalAmount)
+	if cartTotal != stored {
+		t.Errorf("total detalhado do carrinho (%s) deveria ser o total gravado no pedido (%s)", cartTotal, stored)
+	}
+	if sum := breakdownSum(orderTotalBreakdown(&order)); sum != stored {
+		t.Errorf("detalhamento do pedido soma %s, total gravado %s", sum, stored)
+	}
+	for _, esperado := range []string{"Subtotal dos produtos: R$ 36,70", "Entrega: R$ 7,50", "Taxa de urgência: R$ 4,35", "**Total:** R$ 48,55"} {
+		if !strings.Contains(confirmation, esperado) {
+			t.Errorf("confirmação do pedido deveria conter %q:\n%s", esperado, confirmation)
+		}
+	}
+	if strings.Contains(confirmation, "Ajuste") {
+		t.Errorf("pedido criado pelo checkout não deveria precisar de ajuste:\n%s", confirmation)
+	}
+}
@@ -0,0 +1,124 @@
+package ai
+
+import (
+	"fmt"
+	"time"
+
+	"iafarma/pkg/models"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+)
+
+// undoLastAddWindow limita o "desfazer" a adições recentes; depois disso o cliente remove o item pelo carrinho
+const undoLastAddWindow = 30 * time.Minute
+
+// lastCartAdd registra a adição mais recente ao carrinho do cliente para permitir desfazê-la
+type lastCartAdd struct {
+	CartID        uuid.UUID
+	ItemID        uuid.UUID
+	ProductName   string
+	Added         int
+	QuantityAfter int
+	At            time.Time
+}
+
+func lastCartAddKey(tenantID, customerID uuid.UUID) string {
+	return tenantID.String() + "-" + customerID.String()
+}
+
+// rememberCartAdd guarda a adição que acabou de acontecer; item é a linha do carrinho já com a nova quantidade
+func (s *AIService) rememberCartAdd(tenantID, customerID, cartID uuid.UUID, item models.CartItem, productName string, added int) {
+	if added <= 0 {
+		return
+	}
+	s.lastCartAdds.Store(lastCartAddKey(tenantID, customerID), lastCartAdd{
+		CartID:        cartID,
+		ItemID:        item.ID,
+		ProductName:   productName,
+		Added:         added,
+		QuantityAfter: item.Quantity,
+		At:            time.Now(),
+	})
+}
+
+// rememberProductAdd localiza no carrinho a linha do produto adicionado e registra a adição
+func (s *AIService) rememberProductAdd(tenantID, customerID, cartID uuid.UUID, product *models.Product, added int) {
+	cart, err := s.cartService.GetCartWithItems(cartID, tenantID)
+	if err != nil || cart == nil {
+		return
+	}
+	for _, item := range cart.Items {
+		if item.ProductID != nil && *item.ProductID == product.ID {
+			s.rememberCartAdd(tenantID, customerID, cartID, item, product.Name, added)
+			return
+		}
+	}
+}
+
+// handleDesfazerUltimaAdicao remove do carrinho o que o cliente acabou de adicionar ("ops, tira o que acabei de
+// adicionar"). Se o item foi alterado depois da adição, nada é mexido e o cliente recebe a situação atual.
+func (s *AIService) handleDesfazerUltimaAdicao(tenantID, customerID uuid.UUID) (string, error) {
+	key := lastCartAddKey(tenantID, customerID)
+	value, ok := s.lastCartAdds.LoadAndDelete(key)
+	if !ok {
+		return "🤔 Não encontrei nenhuma adição recente para desfazer.\n\n🛒 Diga 'ver carrinho' para conferir os itens e remover o que quiser.", nil
+	}
+	last := value.(lastCartAdd)
+	if time.Since(last.At) > undoLastAddWindow {
+		return "🤔 Não encontrei nenhuma adição recente para desfazer.\n\n🛒 Diga 'ver carrinho' para conferir os itens e remover o que quiser.", nil
+	}
+
+	cart, err := s.cartService.GetOrCreateActiveCart(tenantID, customerID)
+	if err != nil {
+		return "❌ Erro ao acessar carrinho.", err
+	}
+	if cart.ID != last.CartID {
+		return "🤔 O carrinho em que esse item foi adicionado já foi fechado, então não há o que desfazer.", nil
+	}
+
+	cartWithItems, err := s.cartService.GetCartWithItems(cart.ID, tenantID)
+	if err != nil {
+		return "❌ Erro ao carregar carrinho.", err
+	}
+
+	var item *models.CartItem
+	for i := range cartWithItems.Items {
+		if cartWithItems.Items[i].ID == last.ItemID {
+			item = &cartWithItems.Items[i]
+			break
+		}
+	}
+	if item == nil {
+		return fmt.Sprintf("👍 **%s** já não está mais no seu carrinho.", last.ProductName), nil
+	}
+
+	// Quantidade alterada depois da adição: não dá para saber o que desfazer sem arriscar remover demais
+	if item.Quantity != last.QuantityAfter {
+		return fmt.Sprintf("⚠️ A quantidade de **%s** foi alterada depois da última adição e agora está em %d.\n\n✏️ Me diga a quantidade que você quer ou peça para remover o item.", last.ProductName, item.Quantity), nil
+	}
+
+	previous := last.QuantityAfter - last.Added
+	if previous <= 0 {
+		err = s.cartService.RemoveItemFromCart(cart.ID, tenantID, item.ID)
+	} else {
+		err = s.cartService.UpdateCartItemQuantity(cart.ID, tenantID, item.ID, previous)
+	}
+	if err != nil {
+		return "❌ Erro ao desfazer a última adição.", err
+	}
+
+	log.Info().
+		Str("tenant_id", tenantID.String()).
+		Str("customer_id", customerID.String()).
+		Str("item_id", item.ID.String()).
+		Int("removed", last.Added).
+		Int("remaining", previous).
+		Msg("↩️ Última adição ao carrinho desfeita")
+
+	if previous <= 0 {
+		s.rememberCartRemoval(tenantID, customerID, *item)
+		return fmt.Sprintf("↩️ Pronto! Tirei **%s** do seu carrinho.\n\n🛒 Diga 'ver carrinho' para conferir ou continue comprando.", last.ProductName), nil
+	}
+	return fmt.Sprintf("↩️ Pronto! Tirei as %d unidades de **%s** que você acabou de adicionar. Ficaram %d no carrinho.\n\n🛒 Diga 'ver carrinho' para conferir ou continue comprando.", last.Added, last.ProductName, previous), nil
+}
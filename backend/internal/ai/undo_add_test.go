@@ -0,0 +1,149 @@
+package ai
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"iafarma/pkg/models"
+
+	"github.com/google/uuid"
+)
+
+// undoCartService identifica os itens do carrinho e permite removê-los ou alterar a quantidade
+type undoCartService struct {
+	checkoutCartService
+}
+
+func (f *undoCartService) AddItemToCart(cartID, tenantID, productID uuid.UUID, quantity int) error {
+	if err := f.checkoutCartService.AddItemToCart(cartID, tenantID, productID, quantity); err != nil {
+		return err
+	}
+	for i := range f.cart.Items {
+		if f.cart.Items[i].ID == uuid.Nil {
+			f.cart.Items[i].ID = uuid.New()
+		}
+	}
+	return nil
+}
+
+func (f *undoCartService) RemoveItemFromCart(cartID, tenantID, itemID uuid.UUID) error {
+	for i := range f.cart.Items {
+		if f.cart.Items[i].ID == itemID {
+			f.cart.Items = append(f.cart.Items[:i], f.cart.Items[i+1:]...)
+			return nil
+		}
+	}
+	return nil
+}
+
+func (f *undoCartService) UpdateCartItemQuantity(cartID, tenantID, itemID uuid.UUID, quantity int) error {
+	for i := range f.cart.Items {
+		if f.cart.Items[i].ID == itemID {
+			f.cart.Items[i].Quantity = quantity
+		}
+	}
+	return nil
+}
+
+// newUndoCartService retorna o carrinho que desfaz adições, com a Dipirona no catálogo
+func newUndoCartService() *undoCartService {
+	return &undoCartService{checkoutCartService: *newCheckoutCartService([]models.Product{newPricedTestProduct("Dipirona 500mg", "8.90")})}
+}
+
+func addDipirona(t *testing.T, s *AIService, tenantID, customerID uuid.UUID, quantidade float64) {
+	t.Helper()
+	args := map[string]interface{}{"nome_produto": "dipirona", "quantidade": quantidade}
+	if _, err := s.executeTool(context.Background(), tenantID, customerID, "5561999999999", "adicionarProdutoPorNome", args); err != nil {
+		t.Fatalf("erro inesperado ao adicionar: %v", err)
+	}
+}
+
+func TestDesfazerUltimaAdicaoRemovesSingleAdd(t *testing.T) {
+	tenantID, customerID := uuid.New(), uuid.New()
+	cart := newUndoCartService()
+	s, _ := newTestService(nil, withAddAndCheckout(cart, cart.products...))
+
+	addDipirona(t, s, tenantID, customerID, 2)
+
+	result, err := s.executeTool(context.Background(), tenantID, customerID, "5561999999999", "desfazerUltimaAdicao", map[string]interface{}{})
+	if err != nil {
+		t.Fatalf("erro inesperado: %v", err)
+	}
+	if !strings.Contains(result, "Tirei **Dipirona 500mg**") {
+		t.Errorf("esperado confirmação da remoção, obtido:\n%s", result)
+	}
+	if len(cart.cart.Items) != 0 {
+		t.Errorf("item recém-adicionado deveria sair do carrinho, itens: %+v", cart.cart.Items)
+	}
+
+	// A mesma adição não pode ser desfeita duas vezes
+	result, _ = s.handleDesfazerUltimaAdicao(tenantID, customerID)
+	if !strings.Contains(result, "Não encontrei nenhuma adição recente") {
+		t.Errorf("segundo desfazer não deveria encontrar adição, obtido:\n%s", result)
+	}
+}
+
+func TestDesfazerUltimaAdicaoRestoresPreviousQuantity(t *testing.T) {
+	tenantID, customerID := uuid.New(), uuid.New()
+	cart := newUndoCartService()
+	s, _ := newTestService(nil, withAddAndCheckout(cart, cart.products...))
+
+	addDipirona(t, s, tenantID, customerID, 1)
+	addDipirona(t, s, tenantID, customerID, 3)
+
+	result, err := s.handleDesfazerUltimaAdicao(tenantID, customerID)
+	if err != nil {
+		t.Fatalf("erro inesperado: %v", err)
+	}
+	if len(cart.cart.Items) != 1 || cart.cart.Items[0].Quantity != 1 {
+		t.Fatalf("desfazer deveria tirar só as 3 unidades da última adição, itens: %+v", cart.cart.Items)
+	}
+	if !strings.Contains(result, "Ficaram 1 no carrinho") {
+		t.Errorf("esperado informar a quantidade restante, obtido:\n%s", result)
+	}
+}
+
+func TestDesfazerUltimaAdicaoAfterQuantityChange(t *testing.T) {
+	tenantID, customerID := uuid.New(), uuid.New()
+
+	t.Run("mais unidades do mesmo item viram a última adição", func(t *testing.T) {
+		cart := newUndoCartService()
+		s, _ := newTestService(nil, withAddAndCheckout(cart, cart.products...))
+		addDipirona(t, s, tenantID, customerID, 1)
+
+		args := map[string]interface{}{"produto_nome": "dipirona", "quantidade_adicional": float64(2)}
+		if _, err := s.executeTool(context.Background(), tenantID, customerID, "5561999999999", "adicionarMaisItemCarrinho", args); err != nil {
+			t.Fatalf("erro inesperado: %v", err)
+		}
+
+		if _, err := s.handleDesfazerUltimaAdicao(tenantID, customerID); err != nil {
+			t.Fatalf("erro inesperado: %v", err)
+		}
+		if len(cart.cart.Items) != 1 || cart.cart.Items[0].Quantity != 1 {
+			t.Errorf("desfazer deveria tirar as 2 unidades adicionadas depois, itens: %+v", cart.cart.Items)
+		}
+	})
+
+	t.Run("quantidade alterada depois da adição não é mexida", func(t *testing.T) {
+		cart := newUndoCartService()
+		s, _ := newTestService(nil, withAddAndCheckout(cart, cart.products...))
+		addDipirona(t, s, tenantID, customerID, 2)
+
+		args := map[string]interface{}{"item_number": float64(1), "quantidade": float64(5)}
+		if _, err := s.executeTool(context.Background(), tenantID, customerID, "5561999999999", "atualizarQuantidade", args); err != nil {
+			t.Fatalf("erro inesperado: %v", err)
+		}
+
+		result, err := s.handleDesfazerUltimaAdicao(tenantID, customerID)
+		if err != nil {
+			t.Fatalf("erro inesperado: %v", err)
+		}
+		if !strings.Contains(result, "foi alterada depois da última adição") {
+			t.Errorf("esperado aviso de item alterado, obtido:\n%s", result)
+		}
+		if len(cart.cart.Items) != 1 || cart.cart.Items[0].Quantity != 5 {
+			t.Errorf("carrinho alterado não deveria mudar, itens: %+v", cart.cart.Items)
+		}
+	})
+}
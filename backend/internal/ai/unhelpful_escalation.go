@@ -0,0 +1,172 @@
+package ai
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// UnhelpfulThresholdSettingKey define quantas respostas sem sucesso seguidas disparam a oferta de atendente (0 = desativado)
+	UnhelpfulThresholdSettingKey = "ai_unhelpful_escalation_threshold"
+	// UnhelpfulModeSettingKey define a ação ao atingir o limite: "offer" (pergunta ao cliente) ou "handoff" (transfere automaticamente)
+	UnhelpfulModeSettingKey = "ai_unhelpful_escalation_mode"
+
+	defaultUnhelpfulThreshold = 3
+	unhelpfulModeOffer        = "offer"
+	unhelpfulModeHandoff      = "handoff"
+
+	humanOfferMessage = "🙋 Parece que não estou conseguindo te ajudar. Quer que eu te transfira para um atendente?"
+)
+
+// unhelpfulPrefixes são os inícios de resposta usados pelos handlers e pelo ErrorHandler para falhas
+var unhelpfulPrefixes = []string{"❌", "😔", "🤔"}
+
+// unhelpfulPhrases indicam que a IA não entendeu ou não conseguiu atender
+var unhelpfulPhrases = []string{
+	"não foi possível processar",
+	"não entendi",
+	"não consegui entender",
+	"pode reformular",
+	"poderia reformular",
+}
+
+// UnhelpfulResponseTracker conta respostas sem sucesso consecutivas por sessão (tenant + telefone)
+type UnhelpfulResponseTracker struct {
+	mu     sync.Mutex
+	counts map[string]int
+}
+
+// NewUnhelpfulResponseTracker cria um novo contador de respostas sem sucesso
+func NewUnhelpfulResponseTracker() *UnhelpfulResponseTracker {
+	return &UnhelpfulResponseTracker{
+		counts: make(map[string]int),
+	}
+}
+
+func unhelpfulTrackerKey(tenantID uuid.UUID, customerPhone string) string {
+	return tenantID.String() + ":" + customerPhone
+}
+
+// RecordUnhelpful incrementa o contador da sessão e retorna o novo valor
+func (t *UnhelpfulResponseTracker) RecordUnhelpful(tenantID uuid.UUID, customerPhone string) int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	key := unhelpfulTrackerKey(tenantID, customerPhone)
+	t.counts[key]++
+	return t.counts[key]
+}
+
+// Reset zera o contador da sessão
+func (t *UnhelpfulResponseTracker) Reset(tenantID uuid.UUID, customerPhone string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	delete(t.counts, unhelpfulTrackerKey(tenantID, customerPhone))
+}
+
+// Count retorna o valor atual do contador da sessão
+func (t *UnhelpfulResponseTracker) Count(tenantID uuid.UUID, customerPhone string) int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.counts[unhelpfulTrackerKey(tenantID, customerPhone)]
+}
+
+// isUnhelpfulResponse identifica respostas de erro/fallback que não ajudaram o cliente
+func isUnhelpfulResponse(response string) bool {
+	trimmed := strings.TrimSpace(response)
+	if trimmed == "" {
+		return true
+	}
+
+	for _, prefix := range unhelpfulPrefixes {
+		if strings.HasPrefix(trimmed, prefix) {
+			return true
+		}
+	}
+
+	lower := strings.ToLower(trimmed)
+	for _, phrase := range unhelpfulPhrases {
+		if strings.Contains(lower, phrase) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// trackResponseOutcome atualiza o contador conforme a resposta e indica se o limite foi atingido.
+// Uma execução de ferramenta bem-sucedida zera o contador; respostas diretas úteis não alteram o contador.
+func (t *UnhelpfulResponseTracker) trackResponseOutcome(tenantID uuid.UUID, customerPhone, response string, usedTools bool, threshold int) bool {
+	if !isUnhelpfulResponse(response) {
+		if usedTools {
+			t.Reset(tenantID, customerPhone)
+		}
+		return false
+	}
+
+	count := t.RecordUnhelpful(tenantID, customerPhone)
+	if threshold <= 0 || count < threshold {
+		return false
+	}
+
+	// Limite atingido: zerar para não repetir a oferta a cada mensagem
+	t.Reset(tenantID, customerPhone)
+	return true
+}
+
+// getUnhelpfulEscalationSettings retorna o limite e o modo configurados para o tenant
+func (s *AIService) getUnhelpfulEscalationSettings(ctx context.Context, tenantID uuid.UUID) (int, string) {
+	threshold := defaultUnhelpfulThreshold
+	if setting, err := s.settingsService.GetSetting(ctx, tenantID, UnhelpfulThresholdSettingKey); err == nil && setting != nil && setting.SettingValue != nil {
+		if value, parseErr := strconv.Atoi(strings.TrimSpace(*setting.SettingValue)); parseErr == nil {
+			threshold = value
+		}
+	}
+
+	mode := unhelpfulModeOffer
+	if setting, err := s.settingsService.GetSetting(ctx, tenantID, UnhelpfulModeSettingKey); err == nil && setting != nil && setting.SettingValue != nil {
+		if strings.TrimSpace(*setting.SettingValue) == unhelpfulModeHandoff {
+			mode = unhelpfulModeHandoff
+		}
+	}
+
+	return threshold, mode
+}
+
+// applyUnhelpfulEscalation registra o resultado da resposta e, ao atingir o limite, oferece ou transfere para um atendente
+func (s *AIService) applyUnhelpfulEscalation(ctx context.Context, tenantID, customerID uuid.UUID, customerPhone, response string, usedTools bool) string {
+	if s.unhelpfulTracker == nil {
+		return response
+	}
+
+	threshold, mode := s.getUnhelpfulEscalationSettings(ctx, tenantID)
+	if !s.unhelpfulTracker.trackResponseOutcome(tenantID, customerPhone, response, usedTools, threshold) {
+		return response
+	}
+
+	log.Warn().
+		Str("tenant_id", tenantID.String()).
+		Str("customer_phone", customerPhone).
+		Int("threshold", threshold).
+		Str("mode", mode).
+		Msg("🙋 Limite de respostas sem sucesso atingido - escalando para atendimento humano")
+
+	if mode == unhelpfulModeHandoff {
+		handoffResponse, err := s.handleSolicitarAtendimentoHumano(ctx, tenantID, customerID, customerPhone, map[string]interface{}{
+			"motivo": "IA não conseguiu ajudar após várias tentativas seguidas",
+		})
+		if err == nil {
+			return handoffResponse
+		}
+		log.Error().Err(err).Msg("Erro ao transferir automaticamente para atendimento humano")
+	}
+
+	return response + "\n\n" + humanOfferMessage
+}
@@ -0,0 +1,103 @@
+package ai
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestIsUnhelpfulResponse(t *testing.T) {
+	tests := []struct {
+		response string
+		expected bool
+	}{
+		{"", true},
+		{"❌ Produto não encontrado.", true},
+		{"😔 Algo não saiu como esperado.", true},
+		{"🤔 Verifique se as informações estão corretas e tente novamente.", true},
+		{"❌ Não foi possível processar a solicitação.", true},
+		{"Desculpe, não entendi. Pode reformular?", true},
+		{"✅ Dipirona adicionado ao carrinho!", false},
+		{"🛍️ Produtos disponíveis:\n1. Dipirona", false},
+		{"Olá! Como posso ajudar?", false},
+	}
+
+	for _, test := range tests {
+		result := isUnhelpfulResponse(test.response)
+		if result != test.expected {
+			t.Errorf("isUnhelpfulResponse(%q) = %t, expected %t", test.response, result, test.expected)
+		}
+	}
+}
+
+func TestUnhelpfulEscalationAfterNConsecutiveFailures(t *testing.T) {
+	for _, threshold := range []int{1, 2, 3, 5} {
+		tracker := NewUnhelpfulResponseTracker()
+		tenantID := uuid.New()
+
+		for i := 1; i < threshold; i++ {
+			if tracker.trackResponseOutcome(tenantID, "111", "❌ Produto não encontrado.", true, threshold) {
+				t.Errorf("threshold %d: escalated early at failure %d", threshold, i)
+			}
+		}
+
+		if !tracker.trackResponseOutcome(tenantID, "111", "❌ Produto não encontrado.", true, threshold) {
+			t.Errorf("threshold %d: expected escalation at failure %d", threshold, threshold)
+		}
+
+		if count := tracker.Count(tenantID, "111"); count != 0 {
+			t.Errorf("threshold %d: counter after escalation = %d, expected 0", threshold, count)
+		}
+	}
+}
+
+func TestUnhelpfulCounterResetsOnToolSuccess(t *testing.T) {
+	tracker := NewUnhelpfulResponseTracker()
+	tenantID := uuid.New()
+
+	tracker.trackResponseOutcome(tenantID, "111", "❌ Erro", true, 3)
+	tracker.trackResponseOutcome(tenantID, "111", "😔 Não encontrei", true, 3)
+	if count := tracker.Count(tenantID, "111"); count != 2 {
+		t.Fatalf("counter = %d, expected 2", count)
+	}
+
+	// Resposta direta útil (sem ferramenta) não zera o contador
+	tracker.trackResponseOutcome(tenantID, "111", "Olá! Como posso ajudar?", false, 3)
+	if count := tracker.Count(tenantID, "111"); count != 2 {
+		t.Errorf("counter after direct response = %d, expected 2", count)
+	}
+
+	// Execução de ferramenta bem-sucedida zera
+	tracker.trackResponseOutcome(tenantID, "111", "✅ Dipirona adicionado ao carrinho!", true, 3)
+	if count := tracker.Count(tenantID, "111"); count != 0 {
+		t.Errorf("counter after tool success = %d, expected 0", count)
+	}
+
+	// Após o reset, são necessárias N novas falhas
+	if tracker.trackResponseOutcome(tenantID, "111", "❌ Erro", true, 3) {
+		t.Error("escalated right after reset")
+	}
+}
+
+func TestUnhelpfulEscalationDisabledAndSessionsIsolated(t *testing.T) {
+	tracker := NewUnhelpfulResponseTracker()
+	tenantID := uuid.New()
+
+	for i := 0; i < 10; i++ {
+		if tracker.trackResponseOutcome(tenantID, "111", "❌ Erro", true, 0) {
+			t.Fatal("escalation should be disabled when threshold is 0")
+		}
+	}
+
+	tracker.Reset(tenantID, "111")
+	tracker.trackResponseOutcome(tenantID, "111", "❌ Erro", true, 2)
+	if tracker.trackResponseOutcome(tenantID, "222", "❌ Erro", true, 2) {
+		t.Error("failures from another phone should not count toward this session")
+	}
+	if tracker.trackResponseOutcome(uuid.New(), "111", "❌ Erro", true, 2) {
+		t.Error("failures from another tenant should not count toward this session")
+	}
+	if !tracker.trackResponseOutcome(tenantID, "111", "❌ Erro", true, 2) {
+		t.Error("expected escalation on second failure of the same session")
+	}
+}
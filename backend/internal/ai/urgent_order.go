@@ -0,0 +1,125 @@
+package ai
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"iafarma/internal/utils"
+	"iafarma/pkg/models"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// AllowUrgentOrderSettingKey habilita a opção de pedido urgente (prioridade na separação e entrega)
+	AllowUrgentOrderSettingKey = "allow_urgent_order"
+	// UrgentOrderFeeSettingKey define a taxa expressa cobrada nos pedidos urgentes ("0" = sem taxa)
+	UrgentOrderFeeSettingKey = "urgent_order_fee"
+)
+
+// isUrgentOrderAllowed indica se o tenant oferece pedidos urgentes (desabilitado por padrão)
+func (s *AIService) isUrgentOrderAllowed(ctx context.Context, tenantID uuid.UUID) bool {
+	if s.settingsService == nil {
+		return false
+	}
+	setting, err := s.settingsService.GetSetting(ctx, tenantID, AllowUrgentOrderSettingKey)
+	if err != nil || setting == nil || setting.SettingValue == nil {
+		return false
+	}
+	return strings.EqualFold(strings.TrimSpace(*setting.SettingValue), "true")
+}
+
+// getUrgentOrderFee retorna a taxa expressa configurada pelo tenant (0 quando não há taxa)
+func (s *AIService) getUrgentOrderFee(ctx context.Context, tenantID uuid.UUID) float64 {
+	if s.settingsService == nil {
+		return 0
+	}
+	setting, err := s.settingsService.GetSetting(ctx, tenantID, UrgentOrderFeeSettingKey)
+	if err != nil || setting == nil || setting.SettingValue == nil {
+		return 0
+	}
+	fee, err := strconv.ParseFloat(strings.Replace(strings.TrimSpace(*setting.SettingValue), ",", ".", 1), 64)
+	if err != nil || fee < 0 {
+		return 0
+	}
+	return fee
+}
+
+// isUrgentCart indica se o carrinho foi marcado como urgente e o tenant ainda oferece a opção
+func (s *AIService) isUrgentCart(ctx context.Context, tenantID uuid.UUID, cart *models.Cart) bool {
+	return cart != nil && cart.IsUrgent && s.isUrgentOrderAllowed(ctx, tenantID)
+}
+
+// cartUrgencyFee é a taxa expressa que o carrinho pagará ao virar pedido
+func (s *AIService) cartUrgencyFee(ctx context.Context, tenantID uuid.UUID, cart *models.Cart) float64 {
+	if !s.isUrgentCart(ctx, tenantID, cart) {
+		return 0
+	}
+	return s.getUrgentOrderFee(ctx, tenantID)
+}
+
+// formatCartUrgency mostra no carrinho que o pedido será urgente e a taxa expressa
+func formatCartUrgency(fee, subtotal float64) string {
+	if fee <= 0 {
+		return "⚡ Pedido **urgente** (sem taxa adicional)"
+	}
+	feeCents := utils.CentsFromFloat(fee)
+	return fmt.Sprintf("⚡ Taxa de urgência: R$ %s\n💳 **Total com urgência: R$ %s**",
+		formatCurrency(feeCents.String()), formatCurrency((utils.CentsFromFloat(subtotal) + feeCents).String()))
+}
+
+// applyCartUrgency marca o pedido como urgente e soma a taxa expressa ao total
+func (s *AIService) applyCartUrgency(ctx context.Context, tenantID uuid.UUID, cart *models.Cart, order *models.Order) *models.Order {
+	if !s.isUrgentCart(ctx, tenantID, cart) {
+		return order
+	}
+
+	updated, err := s.orderService.ApplyUrgency(tenantID, order.ID, s.getUrgentOrderFee(ctx, tenantID))
+	if err != nil {
+		log.Error().Err(err).Str("order_id", order.ID.String()).Msg("Erro ao marcar pedido como urgente")
+		return order
+	}
+	return updated
+}
+
+// handleMarcarUrgente marca (ou desmarca) o pedido em andamento como urgente
+func (s *AIService) handleMarcarUrgente(ctx context.Context, tenantID, customerID uuid.UUID, args map[string]interface{}) (string, error) {
+	if !s.isUrgentOrderAllowed(ctx, tenantID) {
+		return "❌ No momento não oferecemos pedidos urgentes. Seu pedido seguirá o prazo normal de entrega.", nil
+	}
+
+	urgent := true
+	if value, ok := args["urgente"].(bool); ok {
+		urgent = value
+	}
+
+	cart, err := s.cartService.GetOrCreateActiveCart(tenantID, customerID)
+	if err != nil {
+		return "❌ Erro ao acessar carrinho.", err
+	}
+
+	if err := s.cartService.SetCartUrgent(cart.ID, tenantID, urgent); err != nil {
+		return "❌ Erro ao atualizar a prioridade do pedido.", err
+	}
+
+	fee := s.getUrgentOrderFee(ctx, tenantID)
+	log.Info().
+		Str("tenant_id", tenantID.String()).
+		Str("customer_id", customerID.String()).
+		Bool("urgent", urgent).
+		Float64("fee", fee).
+		Msg("⚡ Prioridade do pedido atualizada")
+
+	if !urgent {
+		return "👍 Combinado! Seu pedido seguirá o **prazo normal**, sem taxa de urgência.", nil
+	}
+
+	feeText := "sem custo adicional"
+	if fee > 0 {
+		feeText = fmt.Sprintf("com taxa de urgência de **R$ %s**", formatCurrency(utils.FormatMoney(fee)))
+	}
+	return fmt.Sprintf("⚡ Pronto! Seu pedido foi marcado como **urgente** (%s) e será priorizado pela nossa equipe.\n\n🛍️ Quando quiser, é só pedir para finalizar.", feeText), nil
+}
@@ -0,0 +1,155 @@
+package ai
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"testing"
+
+	"iafarma/pkg/models"
+
+	"github.com/google/uuid"
+)
+
+func (f *fakeOrderService) ApplyUrgency(tenantID, orderID uuid.UUID, urgencyFee float64) (*models.Order, error) {
+	for i := range f.orders {
+		if f.orders[i].ID == orderID {
+			total, _ := strconv.ParseFloat(f.orders[i].TotalAmount, 64)
+			f.orders[i].IsUrgent = true
+			f.orders[i].UrgencyFee = fmt.Sprintf("%.2f", urgencyFee)
+			f.orders[i].TotalAmount = fmt.Sprintf("%.2f", total+urgencyFee)
+			return &f.orders[i], nil
+		}
+	}
+	return nil, fmt.Errorf("pedido %s não encontrado", orderID)
+}
+
+// urgentOrderSettings habilita a retirada e configura o pedido urgente
+func urgentOrderSettings(allowUrgent, fee string) map[string]string {
+	return map[string]string{
+		AllowPickupSettingKey:      "true",
+		AllowUrgentOrderSettingKey: allowUrgent,
+		UrgentOrderFeeSettingKey:   fee,
+	}
+}
+
+func TestUrgentOrderPropagatesToCreatedOrder(t *testing.T) {
+	tests := []struct {
+		name          string
+		fee           string
+		esperadoTaxa  string
+		esperadoTotal string
+	}{
+		{"com taxa de urgência", "15,50", "15.50", "15.50"},
+		{"sem taxa", "0", "0.00", "0.00"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tenantID := uuid.New()
+			customerID := uuid.New()
+			cart := newPickupTestCart(true)
+			s, fakes := newTestService(urgentOrderSettings("true", tt.fee), withCheckout(cart))
+			orders := fakes.orders
+
+			message, err := s.handleMarcarUrgente(context.Background(), tenantID, customerID, map[string]interface{}{"urgente": true})
+			if err != nil {
+				t.Fatalf("erro inesperado: %v", err)
+			}
+			if !cart.IsUrgent || !strings.Contains(message, "urgente") {
+				t.Fatalf("carrinho deveria ser marcado como urgente: urgent=%v\n%s", cart.IsUrgent, message)
+			}
+
+			result, err := s.performFinalCheckout(context.Background(), tenantID, customerID, "5561999999999")
+			if err != nil {
+				t.Fatalf("erro inesperado no checkout final: %v", err)
+			}
+			if len(orders.orders) != 1 {
+				t.Fatalf("esperado 1 pedido, obtido %d:\n%s", len(orders.orders), result)
+			}
+
+			order := orders.orders[0]
+			if !order.IsUrgent {
+				t.Errorf("pedido deveria ser urgente")
+			}
+			if order.UrgencyFee != tt.esperadoTaxa {
+				t.Errorf("taxa de urgência: esperado %s, obtido %s", tt.esperadoTaxa, order.UrgencyFee)
+			}
+			if order.TotalAmount != tt.esperadoTotal {
+				t.Errorf("total: esperado %s, obtido %s", tt.esperadoTotal, order.TotalAmount)
+			}
+			if !strings.Contains(result, "Pedido urgente") {
+				t.Errorf("confirmação deveria mencionar a urgência:\n%s", result)
+			}
+			if cart.IsUrgent {
+				t.Errorf("a urgência não deveria passar para o próximo pedido")
+			}
+		})
+	}
+}
+
+func TestUrgentOrderDisabledByTenant(t *testing.T) {
+	tenantID := uuid.New()
+	customerID := uuid.New()
+	cart := newPickupTestCart(true)
+	s, fakes := newTestService(urgentOrderSettings("false", "10"), withCheckout(cart))
+	orders := fakes.orders
+
+	message, err := s.handleMarcarUrgente(context.Background(), tenantID, customerID, map[string]interface{}{"urgente": true})
+	if err != nil {
+		t.Fatalf("erro inesperado: %v", err)
+	}
+	if cart.IsUrgent || !strings.Contains(message, "não oferecemos pedidos urgentes") {
+		t.Errorf("marcarUrgente deveria ser recusado: urgent=%v\n%s", cart.IsUrgent, message)
+	}
+
+	// Carrinho marcado antes de o tenant desabilitar a opção não gera pedido urgente nem cobra taxa
+	cart.IsUrgent = true
+	if _, err := s.performFinalCheckout(context.Background(), tenantID, customerID, "5561999999999"); err != nil {
+		t.Fatalf("erro inesperado no checkout final: %v", err)
+	}
+	if len(orders.orders) != 1 || orders.orders[0].IsUrgent || orders.orders[0].TotalAmount != "0.00" {
+		t.Errorf("pedido não deveria ser urgente: %+v", orders.orders)
+	}
+}
+
+func TestMarcarUrgenteCanBeUndone(t *testing.T) {
+	tenantID := uuid.New()
+	customerID := uuid.New()
+	cart := newPickupTestCart(false)
+	cart.IsUrgent = true
+	s, _ := newTestService(urgentOrderSettings("true", "10"), withCheckout(cart))
+
+	message, err := s.handleMarcarUrgente(context.Background(), tenantID, customerID, map[string]interface{}{"urgente": false})
+	if err != nil {
+		t.Fatalf("erro inesperado: %v", err)
+	}
+	if cart.IsUrgent || !strings.Contains(message, "prazo normal") {
+		t.Errorf("urgência deveria ser removida: urgent=%v\n%s", cart.IsUrgent, message)
+	}
+}
+
+func TestFormatCartUrgencyRoundsLikeTheOrder(t *testing.T) {
+	tests := []struct {
+		name     string
+		fee      float64
+		subtotal float64
+		expected []string
+	}{
+		{"sem taxa", 0, 30, []string{"sem taxa adicional"}},
+		{"taxa com centavos", 15.5, 30, []string{"R$ 15,50", "R$ 45,50"}},
+		{"meio centavo arredonda para cima", 2.675, 10, []string{"R$ 2,68", "R$ 12,68"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			message := formatCartUrgency(tt.fee, tt.subtotal)
+			for _, expected := range tt.expected {
+				if !strings.Contains(message, expected) {
+					t.Errorf("esperado %q em:\n%s", expected, message)
+				}
+			}
+		})
+	}
+}
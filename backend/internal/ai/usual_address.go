@@ -0,0 +1,99 @@
+package ai
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"iafarma/pkg/models"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+)
+
+// usualDeliveryAddress resolve o "endereço de sempre" do cliente: o padrão (ou o único cadastrado) e, sem padrão,
+// o endereço usado no último pedido com entrega. Retorna nil quando não há como saber qual é.
+func (s *AIService) usualDeliveryAddress(tenantID, customerID uuid.UUID, addresses []models.Address) *models.Address {
+	if address := defaultDeliveryAddress(addresses); address != nil {
+		return address
+	}
+	if s.orderService == nil {
+		return nil
+	}
+
+	orders, err := s.orderService.GetOrdersByCustomer(tenantID, customerID)
+	if err != nil {
+		log.Warn().Err(err).Str("customer_id", customerID.String()).Msg("⚠️ Não foi possível consultar pedidos para achar o endereço de sempre")
+		return nil
+	}
+
+	var lastDelivery *models.Order
+	for i := range orders {
+		order := &orders[i]
+		if order.IsPickup || order.Status == "cancelled" || order.AddressID == nil {
+			continue
+		}
+		if lastDelivery == nil || order.CreatedAt.After(lastDelivery.CreatedAt) {
+			lastDelivery = order
+		}
+	}
+	if lastDelivery == nil {
+		return nil
+	}
+	for i := range addresses {
+		if addresses[i].ID == *lastDelivery.AddressID {
+			return &addresses[i]
+		}
+	}
+	return nil
+}
+
+// handleUsarEnderecoDeSempre finaliza o pedido no endereço de sempre do cliente ("manda pro meu endereço de sempre"),
+// sem pedir a confirmação do endereço. Funciona igual para mensagens de texto e áudios transcritos.
+func (s *AIService) handleUsarEnderecoDeSempre(ctx context.Context, tenantID, customerID uuid.UUID, customerPhone string) (string, error) {
+	addresses, err := s.addressService.GetAddressesByCustomer(tenantID, customerID)
+	if err != nil || len(addresses) == 0 {
+		return "📍 Ainda não tenho um endereço seu cadastrado.\n\n🏠 **Me informe o endereço completo para a entrega:**\n\n💡 **Exemplo:** Rua das Flores, 123, Centro, Brasília, DF, CEP 70000-000, Complemento (se houver)", err
+	}
+
+	address := s.usualDeliveryAddress(tenantID, customerID, addresses)
+	if address == nil {
+		s.setAwaitingSelection(tenantID, customerPhone, awaitingAddressSelection)
+		return fmt.Sprintf("%s\n\n🤔 Qual desses é o seu endereço de sempre? Responda com o número do endereço.", formatAddressesForSelection(addresses)), nil
+	}
+
+	if !isAddressComplete(*address) {
+		return fmt.Sprintf("📍 Seu endereço de sempre está incompleto:\n%s\n\n📝 Por favor, me envie o endereço completo (rua, número, bairro, cidade, estado e CEP).", formatAddressForDisplay(*address)), nil
+	}
+
+	// O checkout entrega no endereço padrão: o endereço de sempre passa a ser o padrão
+	if !address.IsDefault {
+		if err := s.addressService.SetDefaultAddress(tenantID, customerID, address.ID); err != nil {
+			return "❌ Não consegui usar o seu endereço de sempre agora. Tente novamente em instantes.", err
+		}
+	}
+
+	// Pedido para entrega: desfaz uma retirada na loja escolhida antes
+	cart, err := s.cartService.GetOrCreateActiveCart(tenantID, customerID)
+	if err != nil {
+		return "❌ Erro ao acessar carrinho.", err
+	}
+	if cart.IsPickup {
+		if err := s.cartService.SetCartPickup(cart.ID, tenantID, false); err != nil {
+			return "❌ Erro ao atualizar a forma de entrega do pedido.", err
+		}
+	}
+
+	log.Info().
+		Str("tenant_id", tenantID.String()).
+		Str("customer_id", customerID.String()).
+		Str("address_id", address.ID.String()).
+		Msg("📍 Pedido para o endereço de sempre do cliente")
+
+	checkoutResult, err := s.performFinalCheckout(ctx, tenantID, customerID, customerPhone)
+	if err != nil || !strings.Contains(checkoutResult, "Pedido registrado com sucesso") {
+		return checkoutResult, err
+	}
+
+	return fmt.Sprintf("📍 **Entrega no seu endereço de sempre:**\n%s\n\n%s", formatAddressForDisplay(*address), checkoutResult), nil
+}
@@ -0,0 +1,164 @@
+package ai
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"iafarma/pkg/models"
+
+	"github.com/google/uuid"
+	"github.com/sashabaranov/go-openai"
+)
+
+// defaultSwitchingAddressService marca o endereço escolhido como padrão, como o serviço real
+type defaultSwitchingAddressService struct {
+	fakeAddressService
+}
+
+func (f *defaultSwitchingAddressService) SetDefaultAddress(tenantID, customerID, addressID uuid.UUID) error {
+	for i := range f.addresses {
+		f.addresses[i].IsDefault = f.addresses[i].ID == addressID
+	}
+	return nil
+}
+
+// visitingCustomerService ignora o registro de visitas feito a cada mensagem
+type visitingCustomerService struct {
+	phoneCustomerService
+}
+
+func (f *visitingCustomerService) MarkCustomerSeen(tenantID, customerID uuid.UUID, seenAt time.Time, previousVisitAt *time.Time) error {
+	return nil
+}
+
+// promptOrderService atende as consultas feitas ao montar o prompt do sistema
+type promptOrderService struct {
+	*fakeOrderService
+}
+
+func (f promptOrderService) GetPaymentOptions(tenantID uuid.UUID) ([]PaymentOption, error) {
+	return nil, nil
+}
+
+// withUsualAddresses liga os endereços do cliente, trocando o padrão como o serviço real, e uma entrega que atende todos
+func withUsualAddresses(addresses ...models.Address) testServiceOption {
+	return withOverride(func(s *AIService) {
+		s.deliveryService = &failingDeliveryService{}
+		s.addressService = &defaultSwitchingAddressService{fakeAddressService: fakeAddressService{addresses: addresses}}
+	})
+}
+
+func TestUsarEnderecoDeSempreUsesDefaultAddress(t *testing.T) {
+	tenantID, customerID := uuid.New(), uuid.New()
+	home, work := newRememberedAddress(true), newRememberedAddress(false)
+	work.Street = "Av. Paulista"
+
+	s, fakes := newTestService(nil, withCheckout(newPickupTestCart(false)), withUsualAddresses(work, home))
+	orders := fakes.orders
+
+	result, err := s.executeTool(context.Background(), tenantID, customerID, "5561999999999", "usarEnderecoDeSempre", map[string]interface{}{})
+	if err != nil {
+		t.Fatalf("erro inesperado: %v", err)
+	}
+	if !strings.Contains(result, "Pedido registrado com sucesso") || !strings.Contains(result, "endereço de sempre") {
+		t.Fatalf("pedido deveria ser finalizado no endereço de sempre, obtido:\n%s", result)
+	}
+	if len(orders.orders) != 1 || orders.orders[0].ShippingStreet == nil || *orders.orders[0].ShippingStreet != "Rua das Flores" {
+		t.Errorf("pedido deveria ser entregue no endereço padrão, pedidos: %+v", orders.orders)
+	}
+}
+
+func TestUsarEnderecoDeSempreFallsBackToLastDeliveryAddress(t *testing.T) {
+	tenantID, customerID := uuid.New(), uuid.New()
+	home, work := newRememberedAddress(false), newRememberedAddress(false)
+	work.Street = "Av. Paulista"
+	previous := newPreviousOrder(tenantID, customerID, &work.ID, 10*24*time.Hour)
+
+	s, fakes := newTestService(nil, withCheckout(newPickupTestCart(false)), withUsualAddresses(home, work), withOrders(previous))
+	orders, cart := fakes.orders, fakes.cart
+	cart.cart.IsPickup = true
+
+	result, err := s.handleUsarEnderecoDeSempre(context.Background(), tenantID, customerID, "5561999999999")
+	if err != nil {
+		t.Fatalf("erro inesperado: %v", err)
+	}
+	if !strings.Contains(result, "Av. Paulista") || !strings.Contains(result, "Pedido registrado com sucesso") {
+		t.Fatalf("sem endereço padrão, deveria usar o do último pedido, obtido:\n%s", result)
+	}
+	if cart.cart.IsPickup {
+		t.Error("pedido para o endereço de sempre deveria deixar de ser retirada na loja")
+	}
+	last := orders.orders[len(orders.orders)-1]
+	if last.ShippingStreet == nil || *last.ShippingStreet != "Av. Paulista" {
+		t.Errorf("pedido deveria ser entregue no endereço do último pedido, obtido %+v", last.ShippingStreet)
+	}
+}
+
+func TestUsarEnderecoDeSempreAsksWhenUnknown(t *testing.T) {
+	tenantID, customerID := uuid.New(), uuid.New()
+	phone := "5561999999999"
+	home, work := newRememberedAddress(false), newRememberedAddress(false)
+
+	s, fakes := newTestService(nil, withCheckout(newPickupTestCart(false)), withUsualAddresses(home, work))
+	orders := fakes.orders
+
+	result, err := s.handleUsarEnderecoDeSempre(context.Background(), tenantID, customerID, phone)
+	if err != nil {
+		t.Fatalf("erro inesperado: %v", err)
+	}
+	if !strings.Contains(result, "Qual desses é o seu endereço de sempre") {
+		t.Errorf("deveria perguntar qual é o endereço de sempre, obtido:\n%s", result)
+	}
+	if len(orders.orders) != 0 {
+		t.Error("nenhum pedido deveria ser criado sem saber o endereço")
+	}
+	if state := s.awaitingSelection(tenantID, phone); state != awaitingAddressSelection {
+		t.Errorf("esperado aguardando seleção de endereço, obtido %q", state)
+	}
+}
+
+func TestAudioOrderUsesUsualAddress(t *testing.T) {
+	tenantID, customerID := uuid.New(), uuid.New()
+	phone := "5561999999999"
+	transcription := "pode mandar pro meu endereço de sempre"
+
+	// O modelo responde à transcrição escolhendo a ferramenta do endereço de sempre
+	client, received := newToolCallingClient(t, "usarEnderecoDeSempre", "{}")
+
+	home := newRememberedAddress(true)
+	s, fakes := newTestService(nil, withCheckout(newPickupTestCart(false)), withUsualAddresses(home))
+	orders := fakes.orders
+	customer := &models.Customer{Name: "Maria", Phone: phone}
+	customer.ID = customerID
+	s.customerService = &visitingCustomerService{phoneCustomerService: phoneCustomerService{customer: customer}}
+	s.client = client
+	s.orderService = promptOrderService{fakeOrderService: orders}
+
+	// Mesmo caminho de ProcessAudioMessage após a transcrição
+	s.memoryManager.AddToConversationHistory(tenantID, phone, openai.ChatCompletionMessage{
+		Role:    openai.ChatMessageRoleUser,
+		Content: "🎙️ [Áudio transcrito]: " + transcription,
+	})
+	result, err := s.ProcessMessage(context.Background(), tenantID, phone, transcription)
+	if err != nil {
+		t.Fatalf("erro inesperado: %v", err)
+	}
+
+	offered := false
+	for _, tool := range received.Tools {
+		if tool.Function != nil && tool.Function.Name == "usarEnderecoDeSempre" {
+			offered = true
+		}
+	}
+	if !offered {
+		t.Error("ferramenta usarEnderecoDeSempre deveria estar disponível no fluxo normal de mensagens")
+	}
+	if !strings.Contains(result, "Pedido registrado com sucesso") || !strings.Contains(result, "Rua das Flores") {
+		t.Errorf("pedido do áudio deveria ser finalizado no endereço padrão, obtido:\n%s", result)
+	}
+	if len(orders.orders) != 1 {
+		t.Errorf("esperado 1 pedido criado, obtido %d", len(orders.orders))
+	}
+}
@@ -0,0 +1,181 @@
+package ai
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"iafarma/pkg/models"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// ShippingFeePerKgSettingKey define o frete cobrado por quilo dos produtos, somado à taxa fixa ("0" = sem cobrança por peso)
+	ShippingFeePerKgSettingKey = "ai_shipping_fee_per_kg"
+	// ShippingDefaultWeightSettingKey define o peso em gramas assumido para produtos sem peso cadastrado ("0" = não estimar)
+	ShippingDefaultWeightSettingKey = "ai_shipping_default_weight_grams"
+)
+
+// productWeightPattern reconhece o peso cadastrado: "500", "500g", "1,5 kg", "0.75kg"
+var productWeightPattern = regexp.MustCompile(`^(\d+(?:[.,]\d+)?)\s*(kg|g)?$`)
+
+// parseProductWeightGrams converte o peso cadastrado do produto (em gramas por padrão) para gramas
+func parseProductWeightGrams(weight string) (float64, bool) {
+	match := productWeightPattern.FindStringSubmatch(strings.ToLower(strings.TrimSpace(weight)))
+	if match == nil {
+		return 0, false
+	}
+	value, err := strconv.ParseFloat(strings.Replace(match[1], ",", ".", 1), 64)
+	if err != nil || value <= 0 {
+		return 0, false
+	}
+	if match[2] == "kg" {
+		value *= 1000
+	}
+	return value, true
+}
+
+// productShippingWeight retorna o peso usado no frete do produto; estimated indica que o peso padrão foi usado
+func (c deliveryFeeConfig) productShippingWeight(product *models.Product) (grams float64, estimated, ok bool) {
+	if product != nil {
+		if grams, ok := parseProductWeightGrams(product.Weight); ok {
+			return grams, false, true
+		}
+	}
+	if c.DefaultWeight > 0 {
+		return c.DefaultWeight, true, true
+	}
+	return 0, false, false
+}
+
+// productWeightFee calcula o frete por peso de uma unidade do produto
+func (c deliveryFeeConfig) productWeightFee(grams float64) float64 {
+	return grams / 1000 * c.PerKg
+}
+
+// cartWeightFee soma o frete por peso dos itens do carrinho e lista os produtos sem peso para estimar
+func (c deliveryFeeConfig) cartWeightFee(cart *models.Cart) (float64, []string) {
+	if c.PerKg <= 0 || cart == nil {
+		return 0, nil
+	}
+
+	total := 0.0
+	var missing []string
+	for _, item := range cart.Items {
+		grams, _, ok := c.productShippingWeight(item.Product)
+		if !ok {
+			name := "produto"
+			if item.Product != nil {
+				name = item.Product.Name
+			}
+			missing = append(missing, name)
+			continue
+		}
+		total += c.productWeightFee(grams) * float64(item.Quantity)
+	}
+	return total, missing
+}
+
+// missingWeightNote avisa que o frete pode mudar porque alguns produtos não têm peso cadastrado
+func missingWeightNote(names string) string {
+	return fmt.Sprintf("⚠️ Sem peso cadastrado para o frete: %s (a loja pode ajustar o valor da entrega).", names)
+}
+
+// formatWeightGrams exibe o peso em g ou kg
+func formatWeightGrams(grams float64) string {
+	if grams >= 1000 {
+		return strings.Replace(strconv.FormatFloat(grams/1000, 'f', -1, 64), ".", ",", 1) + " kg"
+	}
+	return strconv.FormatFloat(grams, 'f', 0, 64) + " g"
+}
+
+// formatProductShippingEstimate explica quanto o produto acrescenta ao frete
+func formatProductShippingEstimate(config deliveryFeeConfig, product *models.Product, quantity int) string {
+	var lines []string
+
+	if config.PerKg <= 0 {
+		if config.Fee > 0 {
+			lines = append(lines, fmt.Sprintf("🚚 A entrega tem taxa fixa de **R$ %s** por pedido - o frete não muda com **%s**.",
+				formatCurrency(fmt.Sprintf("%.2f", config.Fee)), product.Name))
+		} else {
+			lines = append(lines, fmt.Sprintf("🚚 **%s** não acrescenta nada ao frete - não cobramos taxa de entrega.", product.Name))
+		}
+		return strings.Join(lines, "\n")
+	}
+
+	grams, estimated, ok := config.productShippingWeight(product)
+	if !ok {
+		lines = append(lines, fmt.Sprintf("⚠️ Não temos o peso cadastrado de **%s**, então não consigo estimar o frete dele. A loja confirma o valor da entrega no fechamento do pedido.", product.Name))
+	} else {
+		unitFee := config.productWeightFee(grams)
+		weightLabel := formatWeightGrams(grams)
+		if estimated {
+			weightLabel += " (peso estimado)"
+		}
+		lines = append(lines, fmt.Sprintf("⚖️ **%s** - %s: frete de **R$ %s** por unidade (R$ %s/kg).",
+			product.Name, weightLabel, formatCurrency(fmt.Sprintf("%.2f", unitFee)), formatCurrency(fmt.Sprintf("%.2f", config.PerKg))))
+		if quantity > 1 {
+			lines = append(lines, fmt.Sprintf("📦 Para %d unidades: **R$ %s**", quantity, formatCurrency(fmt.Sprintf("%.2f", unitFee*float64(quantity)))))
+		}
+	}
+
+	if config.Fee > 0 {
+		lines = append(lines, fmt.Sprintf("🚚 Além da taxa fixa de entrega de R$ %s por pedido.", formatCurrency(fmt.Sprintf("%.2f", config.Fee))))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// handleConsultarFreteProduto estima quanto um produto acrescenta ao frete e mostra o frete atual do carrinho
+func (s *AIService) handleConsultarFreteProduto(ctx context.Context, tenantID, customerID uuid.UUID, customerPhone string, args map[string]interface{}) (string, error) {
+	identifier, _ := args["identifier"].(string)
+	identifier = strings.TrimSpace(identifier)
+	if identifier == "" {
+		return "❌ Informe o produto (nome ou número da lista) para consultar o frete.", nil
+	}
+
+	quantity := 1
+	if value, ok := args["quantidade"].(float64); ok && value > 1 {
+		quantity = int(value)
+	}
+
+	product, err := s.resolveProductIdentifier(ctx, tenantID, customerPhone, identifier)
+	if err != nil || product == nil {
+		return "❌ Produto não encontrado. Use 'produtos' para ver a lista atualizada.", nil
+	}
+
+	config := s.getDeliveryFeeConfig(ctx, tenantID)
+	log.Info().
+		Str("tenant_id", tenantID.String()).
+		Str("product_id", product.ID.String()).
+		Str("weight", product.Weight).
+		Float64("per_kg", config.PerKg).
+		Msg("⚖️ Consultando frete por peso do produto")
+
+	result := formatProductShippingEstimate(config, product, quantity)
+
+	// Frete total do carrinho atual, considerando o peso de todos os itens
+	if s.cartService != nil {
+		if cart, err := s.cartService.GetOrCreateActiveCart(tenantID, customerID); err == nil {
+			if cartWithItems, err := s.cartService.GetCartWithItems(cart.ID, tenantID); err == nil && len(cartWithItems.Items) > 0 {
+				quote := s.quoteCartDeliveryFee(ctx, tenantID, cartWithItems)
+				switch {
+				case s.isPickupCart(ctx, tenantID, cartWithItems):
+					result += "\n\n🏪 Seu pedido está marcado para retirada na loja, sem frete."
+				case quote.FreeShipping:
+					result += "\n\n🎉 Seu carrinho já ganhou **frete grátis**!"
+				case quote.Fee > 0:
+					result += fmt.Sprintf("\n\n🛒 Frete estimado do seu carrinho atual: **R$ %s**", formatCurrency(fmt.Sprintf("%.2f", quote.Fee)))
+					if quote.MissingWeight != "" {
+						result += "\n" + missingWeightNote(quote.MissingWeight)
+					}
+				}
+			}
+		}
+	}
+
+	return result, nil
+}
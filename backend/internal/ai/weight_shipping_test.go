@@ -0,0 +1,122 @@
+package ai
+
+import (
+	"context"
+	"math"
+	"strings"
+	"testing"
+
+	"iafarma/pkg/models"
+
+	"github.com/google/uuid"
+)
+
+func TestParseProductWeightGrams(t *testing.T) {
+	tests := []struct {
+		weight   string
+		esperado float64
+		valido   bool
+	}{
+		{"500", 500, true},
+		{"500g", 500, true},
+		{"1,5 kg", 1500, true},
+		{"0.75KG", 750, true},
+		{"", 0, false},
+		{"0", 0, false},
+		{"pesado", 0, false},
+	}
+
+	for _, tt := range tests {
+		got, ok := parseProductWeightGrams(tt.weight)
+		if ok != tt.valido || got != tt.esperado {
+			t.Errorf("%q: esperado (%v, %v), obtido (%v, %v)", tt.weight, tt.esperado, tt.valido, got, ok)
+		}
+	}
+}
+
+func TestCartWeightFee(t *testing.T) {
+	cart := &models.Cart{Items: []models.CartItem{
+		{Quantity: 2, Price: "10.00", Product: &models.Product{Name: "Ração 1kg", Weight: "1000"}},
+		{Quantity: 1, Price: "20.00", Product: &models.Product{Name: "Areia 4kg", Weight: "4 kg"}},
+		{Quantity: 3, Price: "5.00", Product: &models.Product{Name: "Petisco"}},
+	}}
+
+	tests := []struct {
+		name     string
+		config   deliveryFeeConfig
+		fee      float64
+		faltando []string
+	}{
+		{"sem cobrança por peso", deliveryFeeConfig{Fee: 8}, 0, nil},
+		{"sem peso padrão sinaliza o produto", deliveryFeeConfig{PerKg: 2}, 12, []string{"Petisco"}},
+		{"com peso padrão estima o produto", deliveryFeeConfig{PerKg: 2, DefaultWeight: 250}, 13.5, nil},
+	}
+
+	for _, tt := range tests {
+		fee, missing := tt.config.cartWeightFee(cart)
+		if math.Abs(fee-tt.fee) > 0.001 || strings.Join(missing, ",") != strings.Join(tt.faltando, ",") {
+			t.Errorf("%s: esperado (%v, %v), obtido (%v, %v)", tt.name, tt.fee, tt.faltando, fee, missing)
+		}
+	}
+}
+
+func TestVerCarrinhoSomaFretePorPeso(t *testing.T) {
+	productID := uuid.New()
+	cart := &models.Cart{Items: []models.CartItem{
+		{ProductID: &productID, Quantity: 2, Price: "30.00", Product: &models.Product{Name: "Ração 1kg", Weight: "1kg"}},
+		{ProductID: &productID, Quantity: 1, Price: "10.00", Product: &models.Product{Name: "Petisco"}},
+	}}
+	s := &AIService{
+		cartService: &fakeCartService{cart: cart},
+		settingsService: &fakeSettingsService{values: map[string]string{
+			DeliveryFeeSettingKey:      "5",
+			ShippingFeePerKgSettingKey: "1,50",
+		}},
+	}
+
+	result, err := s.handleVerCarrinhoWithOptions(context.Background(), uuid.New(), uuid.New(), false)
+	if err != nil {
+		t.Fatalf("erro inesperado: %v", err)
+	}
+	for _, expected := range []string{"🚚 Entrega: R$ 8,00", "Total com entrega: R$ 78,00", "Sem peso cadastrado para o frete: Petisco"} {
+		if !strings.Contains(result, expected) {
+			t.Errorf("esperado %q no carrinho:\n%s", expected, result)
+		}
+	}
+}
+
+func TestConsultarFreteProduto(t *testing.T) {
+	tenantID := uuid.New()
+	s := &AIService{
+		productService: &fakeProductService{products: []models.Product{
+			{Name: "Ração 1kg", Weight: "1000"},
+			{Name: "Petisco"},
+		}},
+		memoryManager: NewMemoryManager(),
+		settingsService: &fakeSettingsService{values: map[string]string{
+			ShippingFeePerKgSettingKey: "2",
+		}},
+	}
+
+	result, err := s.handleConsultarFreteProduto(context.Background(), tenantID, uuid.New(), "5527999999999", map[string]interface{}{"identifier": "ração", "quantidade": float64(3)})
+	if err != nil {
+		t.Fatalf("erro inesperado: %v", err)
+	}
+	if !strings.Contains(result, "1 kg: frete de **R$ 2,00** por unidade") || !strings.Contains(result, "Para 3 unidades: **R$ 6,00**") {
+		t.Errorf("resposta inesperada:\n%s", result)
+	}
+
+	result, _ = s.handleConsultarFreteProduto(context.Background(), tenantID, uuid.New(), "5527999999999", map[string]interface{}{"identifier": "petisco"})
+	if !strings.Contains(result, "Não temos o peso cadastrado de **Petisco**") {
+		t.Errorf("produto sem peso deveria ser sinalizado:\n%s", result)
+	}
+
+	s.settingsService = &fakeSettingsService{values: map[string]string{
+		ShippingFeePerKgSettingKey:      "2",
+		ShippingDefaultWeightSettingKey: "500",
+	}}
+	result, _ = s.handleConsultarFreteProduto(context.Background(), tenantID, uuid.New(), "5527999999999", map[string]interface{}{"identifier": "petisco"})
+	if !strings.Contains(result, "500 g (peso estimado): frete de **R$ 1,00**") {
+		t.Errorf("produto sem peso deveria usar o peso padrão:\n%s", result)
+	}
+}
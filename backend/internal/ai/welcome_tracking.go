@@ -0,0 +1,79 @@
+package ai
+
+import (
+	"context"
+	"iafarma/pkg/models"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// RewelcomeAfterDaysSettingKey define após quantos dias sem conversar o cliente recebe a saudação novamente (0 = nunca)
+	RewelcomeAfterDaysSettingKey = "ai_rewelcome_after_days"
+
+	defaultRewelcomeAfterDays = 30
+)
+
+// shouldWelcomeCustomer decide pela boas-vindas com base no registro do cliente, e não na memória da conversa,
+// para que resets de memória e reinícios do servidor não tratem um cliente antigo como novo. O intervalo conta a
+// partir da última mensagem do cliente (lastActivityAt), não das últimas boas-vindas: quem conversa toda semana
+// não é saudado de novo só porque a última saudação foi há mais de 30 dias.
+func shouldWelcomeCustomer(lastWelcomedAt, lastActivityAt *time.Time, now time.Time, rewelcomeAfterDays int) bool {
+	if lastWelcomedAt == nil {
+		return true
+	}
+	if rewelcomeAfterDays <= 0 {
+		return false
+	}
+	reference := *lastWelcomedAt
+	if lastActivityAt != nil && lastActivityAt.After(reference) {
+		reference = *lastActivityAt
+	}
+	return now.Sub(reference) >= time.Duration(rewelcomeAfterDays)*24*time.Hour
+}
+
+// getRewelcomeAfterDays retorna o intervalo configurado pelo tenant para repetir as boas-vindas
+func (s *AIService) getRewelcomeAfterDays(ctx context.Context, tenantID uuid.UUID) int {
+	if s.settingsService == nil {
+		return defaultRewelcomeAfterDays
+	}
+
+	setting, err := s.settingsService.GetSetting(ctx, tenantID, RewelcomeAfterDaysSettingKey)
+	if err != nil || setting == nil || setting.SettingValue == nil {
+		return defaultRewelcomeAfterDays
+	}
+
+	days, err := strconv.Atoi(strings.TrimSpace(*setting.SettingValue))
+	if err != nil || days < 0 {
+		return defaultRewelcomeAfterDays
+	}
+	return days
+}
+
+// isWelcomeDue indica se a mensagem de boas-vindas deve ser enviada.
+// Só é considerada quando não há conversa em andamento na memória. lastActivityAt é a mensagem anterior do cliente,
+// lida antes de registrar a visita atual (que atualiza LastSeenAt).
+func (s *AIService) isWelcomeDue(ctx context.Context, tenantID uuid.UUID, customer *models.Customer, lastActivityAt *time.Time, historyLen int) bool {
+	if historyLen > 0 || customer == nil {
+		return false
+	}
+	return shouldWelcomeCustomer(customer.LastWelcomedAt, lastActivityAt, time.Now(), s.getRewelcomeAfterDays(ctx, tenantID))
+}
+
+// markCustomerWelcomed persiste o envio das boas-vindas no registro do cliente
+func (s *AIService) markCustomerWelcomed(tenantID uuid.UUID, customer *models.Customer) {
+	now := time.Now()
+	if err := s.customerService.MarkCustomerWelcomed(tenantID, customer.ID, now); err != nil {
+		log.Error().
+			Err(err).
+			Str("tenant_id", tenantID.String()).
+			Str("customer_id", customer.ID.String()).
+			Msg("Erro ao registrar envio da mensagem de boas-vindas")
+		return
+	}
+	customer.LastWelcomedAt = &now
+}
@@ -0,0 +1,181 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"iafarma/pkg/models"
+
+	"github.com/google/uuid"
+	"github.com/sashabaranov/go-openai"
+)
+
+// fakeSettingsService retorna configurações fixas em memória
+type fakeSettingsService struct {
+	TenantSettingsServiceInterface
+	values map[string]string
+}
+
+func (f *fakeSettingsService) GetSetting(ctx context.Context, tenantID uuid.UUID, key string) (*models.TenantSetting, error) {
+	value, ok := f.values[key]
+	if !ok {
+		return nil, errors.New("record not found")
+	}
+	return &models.TenantSetting{SettingKey: key, SettingValue: &value}, nil
+}
+
+// fakeCustomerService registra as marcações de boas-vindas e retorna o cliente configurado
+type fakeCustomerService struct {
+	CustomerServiceInterface
+	welcomed map[uuid.UUID]time.Time
+	customer *models.Customer
+}
+
+func (f *fakeCustomerService) GetCustomerByID(tenantID, customerID uuid.UUID) (*models.Customer, error) {
+	return f.customer, nil
+}
+
+func (f *fakeCustomerService) MarkCustomerWelcomed(tenantID, customerID uuid.UUID, welcomedAt time.Time) error {
+	f.welcomed[customerID] = welcomedAt
+	return nil
+}
+
+func TestShouldWelcomeCustomer(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	daysAgo := func(days int) *time.Time {
+		at := now.Add(-time.Duration(days) * 24 * time.Hour)
+		return &at
+	}
+
+	tests := []struct {
+		name           string
+		lastWelcomedAt *time.Time
+		lastActivityAt *time.Time
+		rewelcomeDays  int
+		expected       bool
+	}{
+		{"first ever", nil, nil, 30, true},
+		{"first ever with rewelcome disabled", nil, nil, 0, true},
+		{"welcomed today", daysAgo(0), daysAgo(0), 30, false},
+		{"returning before interval", daysAgo(29), daysAgo(29), 30, false},
+		{"returning after interval", daysAgo(30), daysAgo(30), 30, true},
+		{"returning after interval without recorded activity", daysAgo(30), nil, 30, true},
+		{"active customer welcomed long ago", daysAgo(90), daysAgo(2), 30, false},
+		{"inactive since last activity", daysAgo(90), daysAgo(45), 30, true},
+		{"returning long after with rewelcome disabled", daysAgo(365), daysAgo(365), 0, false},
+	}
+
+	for _, test := range tests {
+		if result := shouldWelcomeCustomer(test.lastWelcomedAt, test.lastActivityAt, now, test.rewelcomeDays); result != test.expected {
+			t.Errorf("%s: shouldWelcomeCustomer = %t, expected %t", test.name, result, test.expected)
+		}
+	}
+}
+
+func TestWelcomeFirstEverAndPostReset(t *testing.T) {
+	tenantID := uuid.New()
+	customers := &fakeCustomerService{welcomed: make(map[uuid.UUID]time.Time)}
+	s := &AIService{
+		customerService: customers,
+		settingsService: &fakeSettingsService{values: map[string]string{RewelcomeAfterDaysSettingKey: "30"}},
+		memoryManager:   NewMemoryManager(),
+	}
+
+	customer := &models.Customer{Phone: "5527999999999"}
+	customer.ID = uuid.New()
+
+	// Primeiro contato: boas-vindas devida e registrada no cliente
+	history := s.memoryManager.GetConversationHistory(tenantID, customer.Phone)
+	if !s.isWelcomeDue(context.Background(), tenantID, customer, customer.LastSeenAt, len(history)) {
+		t.Fatal("first-ever contact should be welcomed")
+	}
+	s.markCustomerWelcomed(tenantID, customer)
+	if _, ok := customers.welcomed[customer.ID]; !ok || customer.LastWelcomedAt == nil {
+		t.Fatal("welcome was not persisted on the customer record")
+	}
+	s.memoryManager.AddToConversationHistory(tenantID, customer.Phone, openai.ChatCompletionMessage{Role: openai.ChatMessageRoleUser, Content: "oi"})
+
+	// Conversa em andamento: não repetir
+	history = s.memoryManager.GetConversationHistory(tenantID, customer.Phone)
+	if s.isWelcomeDue(context.Background(), tenantID, customer, customer.LastSeenAt, len(history)) {
+		t.Error("should not welcome during an ongoing conversation")
+	}
+
+	// Após reset da memória o histórico some, mas o registro do cliente impede nova boas-vindas
+	s.memoryManager.ClearMemory(tenantID, customer.Phone)
+	history = s.memoryManager.GetConversationHistory(tenantID, customer.Phone)
+	if len(history) != 0 {
+		t.Fatalf("history after reset = %d messages, expected 0", len(history))
+	}
+	if s.isWelcomeDue(context.Background(), tenantID, customer, customer.LastSeenAt, len(history)) {
+		t.Error("returning customer should not be welcomed again after a memory reset")
+	}
+}
+
+func TestWelcomeReturningAfterDays(t *testing.T) {
+	tenantID := uuid.New()
+	lastWelcomedAt := time.Now().Add(-40 * 24 * time.Hour)
+	customer := &models.Customer{Phone: "5527988888888", LastWelcomedAt: &lastWelcomedAt}
+	customer.ID = uuid.New()
+
+	tests := []struct {
+		setting  map[string]string
+		expected bool
+	}{
+		{map[string]string{RewelcomeAfterDaysSettingKey: "30"}, true},
+		{map[string]string{RewelcomeAfterDaysSettingKey: "60"}, false},
+		{map[string]string{RewelcomeAfterDaysSettingKey: "0"}, false},
+		{map[string]string{}, true}, // padrão: 30 dias
+	}
+
+	for _, test := range tests {
+		s := &AIService{settingsService: &fakeSettingsService{values: test.setting}}
+		if result := s.isWelcomeDue(context.Background(), tenantID, customer, nil, 0); result != test.expected {
+			t.Errorf("setting %v: isWelcomeDue = %t, expected %t", test.setting, result, test.expected)
+		}
+	}
+}
+
+// seenCustomerService registra as visitas como o serviço real, além das boas-vindas
+type seenCustomerService struct {
+	fakeCustomerService
+}
+
+func (f *seenCustomerService) MarkCustomerSeen(tenantID, customerID uuid.UUID, seenAt time.Time, previousVisitAt *time.Time) error {
+	return nil
+}
+
+func TestActiveCustomerIsNotRewelcomed(t *testing.T) {
+	tenantID := uuid.New()
+	customers := &seenCustomerService{fakeCustomerService{welcomed: make(map[uuid.UUID]time.Time)}}
+	s := &AIService{
+		customerService: customers,
+		settingsService: &fakeSettingsService{values: map[string]string{RewelcomeAfterDaysSettingKey: "30"}},
+	}
+
+	tests := []struct {
+		name         string
+		lastSeenDays int
+		expected     bool
+	}{
+		{"conversa toda semana", 3, false},
+		{"sumiu há mais que o intervalo", 45, true},
+	}
+
+	for _, test := range tests {
+		// Saudado há 90 dias; a memória da conversa expirou, então o histórico está vazio
+		welcomedAt := time.Now().Add(-90 * 24 * time.Hour)
+		lastSeenAt := time.Now().Add(-time.Duration(test.lastSeenDays) * 24 * time.Hour)
+		customer := &models.Customer{Phone: "5527977777777", LastWelcomedAt: &welcomedAt, LastSeenAt: &lastSeenAt}
+		customer.ID = uuid.New()
+
+		// Mesma ordem de ProcessMessageWithConversation: a atividade anterior é lida antes de registrar a visita
+		lastActivityAt := customer.LastSeenAt
+		s.trackCustomerVisit(tenantID, customer)
+		if result := s.isWelcomeDue(context.Background(), tenantID, customer, lastActivityAt, 0); result != test.expected {
+			t.Errorf("%s: isWelcomeDue = %t, expected %t", test.name, result, test.expected)
+		}
+	}
+}
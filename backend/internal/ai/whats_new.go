@@ -0,0 +1,147 @@
+package ai
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"iafarma/pkg/models"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// newVisitAfter separa duas visitas: uma mensagem depois desse intervalo de silêncio inicia uma nova visita
+	newVisitAfter = 4 * time.Hour
+	// defaultWhatsNewWindow é o período considerado para clientes sem visita ou pedido anterior
+	defaultWhatsNewWindow = 30 * 24 * time.Hour
+	// maxWhatsNewProducts limita a lista de novidades oferecida ao cliente
+	maxWhatsNewProducts = 8
+)
+
+// previousVisitFor retorna o fim da visita anterior quando a mensagem atual inicia uma nova visita (nil se continua a mesma)
+func previousVisitFor(lastSeenAt *time.Time, now time.Time) *time.Time {
+	if lastSeenAt == nil || now.Sub(*lastSeenAt) < newVisitAfter {
+		return nil
+	}
+	previous := *lastSeenAt
+	return &previous
+}
+
+// trackCustomerVisit registra a mensagem do cliente para calcular as novidades desde a última visita
+func (s *AIService) trackCustomerVisit(tenantID uuid.UUID, customer *models.Customer) {
+	now := time.Now()
+	previousVisit := previousVisitFor(customer.LastSeenAt, now)
+	if err := s.customerService.MarkCustomerSeen(tenantID, customer.ID, now, previousVisit); err != nil {
+		log.Warn().
+			Err(err).
+			Str("tenant_id", tenantID.String()).
+			Str("customer_id", customer.ID.String()).
+			Msg("⚠️ Erro ao registrar visita do cliente")
+		return
+	}
+	customer.LastSeenAt = &now
+	if previousVisit != nil {
+		customer.PreviousVisitAt = previousVisit
+	}
+}
+
+// whatsNewReference retorna a partir de quando mostrar novidades: a última visita ou o último pedido, o que for mais recente.
+// Clientes sem histórico veem as novidades dos últimos 30 dias.
+func whatsNewReference(customer *models.Customer, orders []models.Order, now time.Time) (time.Time, bool) {
+	var reference time.Time
+	if customer != nil && customer.PreviousVisitAt != nil {
+		reference = *customer.PreviousVisitAt
+	}
+	for _, order := range orders {
+		if order.Status != "cancelled" && order.CreatedAt.After(reference) {
+			reference = order.CreatedAt
+		}
+	}
+
+	if reference.IsZero() {
+		return now.Add(-defaultWhatsNewWindow), false
+	}
+	return reference, true
+}
+
+// isOnSale indica se o produto tem preço promocional abaixo do preço normal
+func isOnSale(product *models.Product) bool {
+	salePrice, ok := parsePrice(product.SalePrice)
+	if !ok {
+		return false
+	}
+	price, ok := parsePrice(product.Price)
+	return ok && salePrice < price
+}
+
+// whatsNewProducts separa os produtos cadastrados depois de since e os que entraram em promoção depois disso
+// (a alteração do produto é usada como data da promoção). Lançamentos vêm primeiro, do mais recente ao mais antigo.
+func whatsNewProducts(products []models.Product, since time.Time) []models.Product {
+	var added, onSale []models.Product
+	for _, product := range products {
+		switch {
+		case product.CreatedAt.After(since):
+			added = append(added, product)
+		case isOnSale(&product) && product.UpdatedAt.After(since):
+			onSale = append(onSale, product)
+		}
+	}
+
+	sort.SliceStable(added, func(i, j int) bool { return added[i].CreatedAt.After(added[j].CreatedAt) })
+	sort.SliceStable(onSale, func(i, j int) bool { return onSale[i].UpdatedAt.After(onSale[j].UpdatedAt) })
+
+	news := append(added, onSale...)
+	if len(news) > maxWhatsNewProducts {
+		news = news[:maxWhatsNewProducts]
+	}
+	return news
+}
+
+// handleNovidades lista os lançamentos e promoções desde a última visita ou pedido do cliente,
+// guardando a lista na memória para seleção por número
+func (s *AIService) handleNovidades(tenantID, customerID uuid.UUID, customerPhone string) (string, error) {
+	customer, err := s.customerService.GetCustomerByID(tenantID, customerID)
+	if err != nil {
+		return "❌ Erro ao buscar seus dados.", err
+	}
+
+	orders, err := s.orderService.GetOrdersByCustomer(tenantID, customerID)
+	if err != nil {
+		log.Warn().Err(err).Str("customer_id", customerID.String()).Msg("⚠️ Não foi possível consultar o histórico de pedidos para novidades")
+	}
+
+	since, returning := whatsNewReference(customer, orders, time.Now())
+	products, err := s.productService.GetProductsChangedSince(tenantID, since, maxWhatsNewProducts*3)
+	if err != nil {
+		return "❌ Erro ao buscar as novidades.", err
+	}
+
+	news := whatsNewProducts(products, since)
+	if len(news) == 0 {
+		if returning {
+			return "😊 Ainda não temos novidades desde a sua última visita. Quer que eu te mostre nossas promoções ou busque algum produto?", nil
+		}
+		return "😊 Não temos lançamentos nem promoções novas no momento. Quer que eu busque algum produto para você?", nil
+	}
+
+	var result strings.Builder
+	if returning {
+		result.WriteString("✨ **Novidades desde a sua última visita:**\n\n")
+	} else {
+		result.WriteString("✨ **Novidades da loja:**\n\n")
+	}
+
+	productRefs := s.memoryManager.StoreProductList(tenantID, customerPhone, news)
+	for i, productRef := range productRefs {
+		label := "🆕 Novo"
+		if !news[i].CreatedAt.After(since) {
+			label = "🏷️ Em promoção"
+		}
+		result.WriteString(fmt.Sprintf("%d. **%s** (%s)\n   💰 %s\n", productRef.SequentialID, productRef.Name, label, formatListPrice(productRef.Price, productRef.SalePrice)))
+	}
+	result.WriteString("\n🛒 Para adicionar, me diga o número do item e a quantidade.")
+	return result.String(), nil
+}
@@ -0,0 +1,135 @@
+package ai
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"iafarma/pkg/models"
+
+	"github.com/google/uuid"
+)
+
+// changedProductService devolve os produtos alterados configurados, ignorando o filtro de data da consulta
+type changedProductService struct {
+	fakeProductService
+	changed []models.Product
+}
+
+func (f *changedProductService) GetProductsChangedSince(tenantID uuid.UUID, since time.Time, limit int) ([]models.Product, error) {
+	return f.changed, nil
+}
+
+func newWhatsNewTestProduct(name, price, salePrice string, createdAt, updatedAt time.Time) models.Product {
+	product := newPricedTestProduct(name, price)
+	product.SalePrice = salePrice
+	product.CreatedAt = createdAt
+	product.UpdatedAt = updatedAt
+	return product
+}
+
+func TestPreviousVisitFor(t *testing.T) {
+	now := time.Date(2024, 6, 10, 15, 0, 0, 0, time.UTC)
+	recente := now.Add(-30 * time.Minute)
+	ontem := now.Add(-24 * time.Hour)
+
+	if previousVisitFor(nil, now) != nil {
+		t.Errorf("primeira mensagem não tem visita anterior")
+	}
+	if previousVisitFor(&recente, now) != nil {
+		t.Errorf("mensagem dentro da mesma visita não deveria mudar a visita anterior")
+	}
+	if obtido := previousVisitFor(&ontem, now); obtido == nil || !obtido.Equal(ontem) {
+		t.Errorf("esperado visita anterior %v, obtido %v", ontem, obtido)
+	}
+}
+
+func TestWhatsNewReference(t *testing.T) {
+	now := time.Date(2024, 6, 10, 15, 0, 0, 0, time.UTC)
+	visita := now.Add(-72 * time.Hour)
+	pedido := models.Order{Status: "delivered"}
+	pedido.CreatedAt = now.Add(-48 * time.Hour)
+	cancelado := models.Order{Status: "cancelled"}
+	cancelado.CreatedAt = now.Add(-time.Hour)
+
+	since, returning := whatsNewReference(&models.Customer{PreviousVisitAt: &visita}, []models.Order{cancelado, pedido}, now)
+	if !returning || !since.Equal(pedido.CreatedAt) {
+		t.Errorf("esperado último pedido não cancelado como referência, obtido %v", since)
+	}
+
+	since, returning = whatsNewReference(&models.Customer{PreviousVisitAt: &visita}, nil, now)
+	if !returning || !since.Equal(visita) {
+		t.Errorf("esperado última visita como referência, obtido %v", since)
+	}
+
+	since, returning = whatsNewReference(&models.Customer{}, nil, now)
+	if returning || !since.Equal(now.Add(-defaultWhatsNewWindow)) {
+		t.Errorf("cliente sem histórico deveria usar a janela padrão, obtido %v", since)
+	}
+}
+
+func TestWhatsNewProductsDesdeUltimaVisita(t *testing.T) {
+	since := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	antes := since.Add(-48 * time.Hour)
+	depois := since.Add(24 * time.Hour)
+
+	products := []models.Product{
+		newWhatsNewTestProduct("Protetor Solar", "59.90", "", since.Add(2*time.Hour), since.Add(2*time.Hour)),
+		newWhatsNewTestProduct("Dipirona", "12.90", "9.90", antes, depois),
+		newWhatsNewTestProduct("Vitamina C", "30.00", "", depois, depois),
+		newWhatsNewTestProduct("Sabonete", "5.00", "", antes, depois),
+		newWhatsNewTestProduct("Shampoo", "20.00", "25.00", antes, depois),
+		newWhatsNewTestProduct("Fralda", "60.00", "49.90", antes, antes),
+	}
+
+	var nomes []string
+	for _, product := range whatsNewProducts(products, since) {
+		nomes = append(nomes, product.Name)
+	}
+	if obtido := strings.Join(nomes, ","); obtido != "Vitamina C,Protetor Solar,Dipirona" {
+		t.Errorf("esperado lançamentos e promoções novas, obtido %q", obtido)
+	}
+
+	var muitos []models.Product
+	for i := 0; i < maxWhatsNewProducts+3; i++ {
+		muitos = append(muitos, newWhatsNewTestProduct("Novo", "10.00", "", depois, depois))
+	}
+	if obtido := len(whatsNewProducts(muitos, since)); obtido != maxWhatsNewProducts {
+		t.Errorf("esperado no máximo %d novidades, obtido %d", maxWhatsNewProducts, obtido)
+	}
+}
+
+func TestNovidadesListaEGuardaNaMemoria(t *testing.T) {
+	tenantID := uuid.New()
+	customerID := uuid.New()
+	phone := "5527999999999"
+	visita := time.Now().Add(-72 * time.Hour)
+	vitamina := newWhatsNewTestProduct("Vitamina C", "30.00", "", time.Now().Add(-time.Hour), time.Now().Add(-time.Hour))
+	dipirona := newWhatsNewTestProduct("Dipirona", "12.90", "9.90", visita.Add(-time.Hour), time.Now().Add(-2*time.Hour))
+
+	s := &AIService{
+		customerService: &fakeCustomerService{customer: &models.Customer{PreviousVisitAt: &visita}},
+		orderService:    &fakeOrderService{},
+		productService:  &changedProductService{changed: []models.Product{dipirona, vitamina}},
+		memoryManager:   NewMemoryManager(),
+	}
+
+	obtido, err := s.handleNovidades(tenantID, customerID, phone)
+	if err != nil {
+		t.Fatalf("erro inesperado: %v", err)
+	}
+	for _, esperado := range []string{"desde a sua última visita", "1. **Vitamina C** (🆕 Novo)", "2. **Dipirona** (🏷️ Em promoção)", "R$ 9,90"} {
+		if !strings.Contains(obtido, esperado) {
+			t.Errorf("esperado %q em:\n%s", esperado, obtido)
+		}
+	}
+	if ref := s.memoryManager.GetProductBySequentialID(tenantID, phone, 2); ref == nil || ref.ProductID != dipirona.ID {
+		t.Errorf("esperado Dipirona como item 2 da lista, obtido %+v", ref)
+	}
+
+	s.productService = &changedProductService{}
+	obtido, _ = s.handleNovidades(tenantID, customerID, phone)
+	if !strings.Contains(obtido, "Ainda não temos novidades desde a sua última visita") {
+		t.Errorf("esperado aviso de que não há novidades:\n%s", obtido)
+	}
+}
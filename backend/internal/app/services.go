@@ -24,6 +24,8 @@ type Services struct {
 	ChannelRepo                  *repo.ChannelRepository
 	MessageRepo                  *repo.MessageRepository
 	MessageTemplateRepo          *repo.MessageTemplateRepository
+	FAQRepo                      *repo.FAQRepository
+	CustomToolRepo               *repo.CustomToolRepository
 	PlanRepo                     *repo.PlanRepository
 	AlertService                 *services.AlertService
 	DeliveryService              *services.DeliveryService
@@ -36,6 +38,7 @@ type Services struct {
 	PlanLimitService             *services.PlanLimitService
 	CategoryService              *services.CategoryService
 	UsageSyncService             *services.UsageSyncService
+	MediaCleanupService          *services.MediaCleanupService
 	InfrastructureMonitorService *services.InfrastructureMonitorService
 }
 
@@ -52,6 +55,8 @@ func NewServices(db *gorm.DB) *Services {
 	channelRepo := repo.NewChannelRepository(db)
 	messageRepo := repo.NewMessageRepository(db)
 	messageTemplateRepo := repo.NewMessageTemplateRepository(db)
+	faqRepo := repo.NewFAQRepository(db)
+	customToolRepo := repo.NewCustomToolRepository(db)
 	planRepo := repo.NewPlanRepository(db)
 
 	// Initialize services
@@ -142,6 +147,9 @@ func NewServices(db *gorm.DB) *Services {
 	// Initialize usage sync service
 	usageSyncService := services.NewUsageSyncService(db)
 
+	// Initialize orphaned media cleanup (only when S3 storage is configured)
+	mediaCleanupService := services.NewMediaCleanupService(db, storageService)
+
 	// Initialize Infrastructure Monitor service
 	infrastructureMonitorService, err := services.NewInfrastructureMonitorService(db, embeddingService)
 	if err != nil {
@@ -161,6 +169,8 @@ func NewServices(db *gorm.DB) *Services {
 		ChannelRepo:                  channelRepo,
 		MessageRepo:                  messageRepo,
 		MessageTemplateRepo:          messageTemplateRepo,
+		FAQRepo:                      faqRepo,
+		CustomToolRepo:               customToolRepo,
 		PlanRepo:                     planRepo,
 		AlertService:                 alertService,
 		DeliveryService:              deliveryService,
@@ -173,6 +183,7 @@ func NewServices(db *gorm.DB) *Services {
 		PlanLimitService:             planLimitService,
 		CategoryService:              categoryService,
 		UsageSyncService:             usageSyncService,
+		MediaCleanupService:          mediaCleanupService,
 		InfrastructureMonitorService: infrastructureMonitorService,
 	}
 }
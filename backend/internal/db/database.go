@@ -68,6 +68,11 @@ func AutoMigrate(db *gorm.DB) error {
 		log.Printf("Warning: Failed to create some custom indexes: %v", err)
 	}
 
+	// One order number per tenant: concurrent checkouts rely on the unique index to detect conflicts and retry
+	if err := enforceUniqueOrderNumbers(db); err != nil {
+		return err
+	}
+
 	// Import Brazilian municipalities if needed
 	if err := ImportarMunicipiosBrasileiros(db); err != nil {
 		log.Printf("Warning: Failed to import Brazilian municipalities: %v", err)
@@ -92,6 +97,12 @@ func createCustomIndexes(db *gorm.DB) error {
 		// Index for address default flag per customer
 		`CREATE INDEX IF NOT EXISTS idx_addresses_customer_default ON addresses (customer_id, is_default) WHERE is_default = true`,
 
+		// Time-range indexes for the per-tenant AI metrics
+		`CREATE INDEX IF NOT EXISTS idx_messages_tenant_created ON messages(tenant_id, created_at)`,
+		`CREATE INDEX IF NOT EXISTS idx_orders_tenant_created ON orders(tenant_id, created_at)`,
+		`CREATE INDEX IF NOT EXISTS idx_ai_tool_executions_tenant_created ON ai_tool_executions(tenant_id, created_at)`,
+		`CREATE INDEX IF NOT EXISTS idx_missing_product_demands_tenant_created ON missing_product_demands(tenant_id, created_at)`,
+
 		// Index for conversation memory unique constraint
 		`CREATE UNIQUE INDEX IF NOT EXISTS idx_conversation_memory_tenant_phone ON conversation_memories(tenant_id, customer_phone)`,
 
@@ -122,6 +133,35 @@ func createCustomIndexes(db *gorm.DB) error {
 	return nil
 }
 
+// enforceUniqueOrderNumbers renumbers orders that share a number within a tenant (created before the
+// unique index existed) and then creates the index, replacing the former non-unique lookup index.
+// The first order keeps its number and later ones get a "-2", "-3"... suffix. Any failure stops the
+// migration, since without the index concurrent checkouts could hand out the same number again.
+func enforceUniqueOrderNumbers(db *gorm.DB) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Exec(`UPDATE orders SET order_number = ranked.order_number || '-' || ranked.position
+			FROM (
+				SELECT id, order_number, ROW_NUMBER() OVER (PARTITION BY tenant_id, order_number ORDER BY created_at, id) AS position
+				FROM orders
+			) ranked
+			WHERE orders.id = ranked.id AND ranked.position > 1`)
+		if result.Error != nil {
+			return fmt.Errorf("failed to renumber duplicate order numbers: %w", result.Error)
+		}
+		if result.RowsAffected > 0 {
+			log.Printf("Renumbered %d orders with duplicate order numbers", result.RowsAffected)
+		}
+
+		if err := tx.Exec(`CREATE UNIQUE INDEX IF NOT EXISTS idx_orders_tenant_order_number_unique ON orders(tenant_id, order_number)`).Error; err != nil {
+			return fmt.Errorf("failed to create unique order number index: %w", err)
+		}
+		if err := tx.Exec(`DROP INDEX IF EXISTS idx_orders_tenant_order_number`).Error; err != nil {
+			return fmt.Errorf("failed to drop old order number index: %w", err)
+		}
+		return nil
+	})
+}
+
 // SeedInitialData creates initial system data
 func SeedInitialData(db *gorm.DB) error {
 	log.Println("Seeding initial data...")
@@ -49,17 +49,19 @@ func (h *AddressHandler) CreateAddress(c echo.Context) error {
 	}
 
 	address := &models.Address{
-		CustomerID:   req.CustomerID,
-		Label:        req.Label,
-		Street:       req.Street,
-		Number:       req.Number,
-		Complement:   req.Complement,
-		Neighborhood: req.Neighborhood,
-		City:         req.City,
-		State:        req.State,
-		ZipCode:      req.ZipCode,
-		Country:      req.Country,
-		IsDefault:    req.IsDefault,
+		CustomerID:     req.CustomerID,
+		Label:          req.Label,
+		Street:         req.Street,
+		Number:         req.Number,
+		Complement:     req.Complement,
+		Apartment:      req.Apartment,
+		ReferencePoint: req.ReferencePoint,
+		Neighborhood:   req.Neighborhood,
+		City:           req.City,
+		State:          req.State,
+		ZipCode:        req.ZipCode,
+		Country:        req.Country,
+		IsDefault:      req.IsDefault,
 	}
 
 	// Set tenant ID
@@ -154,6 +156,12 @@ func (h *AddressHandler) UpdateAddress(c echo.Context) error {
 	if req.Complement != nil {
 		address.Complement = *req.Complement
 	}
+	if req.Apartment != nil {
+		address.Apartment = *req.Apartment
+	}
+	if req.ReferencePoint != nil {
+		address.ReferencePoint = *req.ReferencePoint
+	}
 	if req.Neighborhood != nil {
 		address.Neighborhood = *req.Neighborhood
 	}
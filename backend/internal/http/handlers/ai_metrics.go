@@ -0,0 +1,264 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"iafarma/pkg/models"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+)
+
+const (
+	defaultAIMetricsRangeDays = 30
+	maxAIMetricsRangeDays     = 90
+
+	humanHandoffToolName = "solicitarAtendimentoHumano"
+)
+
+type AIMetricsHandler struct {
+	db *gorm.DB
+}
+
+func NewAIMetricsHandler(db *gorm.DB) *AIMetricsHandler {
+	return &AIMetricsHandler{db: db}
+}
+
+// AIMetricsBucket holds the AI conversation metrics for one time bucket
+type AIMetricsBucket struct {
+	Start              time.Time `json:"start"`
+	Messages           int64     `json:"messages"`
+	Conversations      int64     `json:"conversations"`
+	BotOrders          int64     `json:"bot_orders"`
+	ToolCalls          int64     `json:"tool_calls"`
+	Handoffs           int64     `json:"handoffs"`
+	ConversionRate     float64   `json:"conversion_rate"`
+	AvgToolsPerMessage float64   `json:"avg_tools_per_message"`
+	HandoffRate        float64   `json:"handoff_rate"`
+}
+
+// AIMetricsResponse is the per-tenant AI metrics time series
+type AIMetricsResponse struct {
+	TenantID uuid.UUID         `json:"tenant_id"`
+	From     time.Time         `json:"from"`
+	To       time.Time         `json:"to"`
+	Interval string            `json:"interval"`
+	Buckets  []AIMetricsBucket `json:"buckets"`
+	Totals   AIMetricsBucket   `json:"totals"`
+}
+
+// aiMetricsRange is the validated query window (To is exclusive)
+type aiMetricsRange struct {
+	From     time.Time
+	To       time.Time
+	Interval string
+}
+
+// messageBucketRow, orderBucketRow and toolBucketRow are the per-bucket aggregates read from the database
+type messageBucketRow struct {
+	Bucket        time.Time
+	Messages      int64
+	Conversations int64
+}
+
+type orderBucketRow struct {
+	Bucket    time.Time
+	BotOrders int64
+}
+
+type toolBucketRow struct {
+	Bucket    time.Time
+	ToolCalls int64
+	Handoffs  int64
+}
+
+// parseAIMetricsRange reads from/to (YYYY-MM-DD, inclusive) and interval (day or week), bounded to maxAIMetricsRangeDays
+func parseAIMetricsRange(fromParam, toParam, intervalParam string, now time.Time) (aiMetricsRange, error) {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	window := aiMetricsRange{
+		From:     today.AddDate(0, 0, -(defaultAIMetricsRangeDays - 1)),
+		To:       today.AddDate(0, 0, 1),
+		Interval: "day",
+	}
+
+	if toParam != "" {
+		to, err := time.Parse("2006-01-02", toParam)
+		if err != nil {
+			return window, fmt.Errorf("invalid to date, expected YYYY-MM-DD")
+		}
+		window.To = to.AddDate(0, 0, 1)
+		window.From = window.To.AddDate(0, 0, -defaultAIMetricsRangeDays)
+	}
+	if fromParam != "" {
+		from, err := time.Parse("2006-01-02", fromParam)
+		if err != nil {
+			return window, fmt.Errorf("invalid from date, expected YYYY-MM-DD")
+		}
+		window.From = from
+	}
+
+	if !window.From.Before(window.To) {
+		return window, fmt.Errorf("from must not be after to")
+	}
+	if window.To.Sub(window.From) > maxAIMetricsRangeDays*24*time.Hour {
+		return window, fmt.Errorf("date range cannot exceed %d days", maxAIMetricsRangeDays)
+	}
+
+	switch intervalParam {
+	case "", "day":
+	case "week":
+		window.Interval = "week"
+	default:
+		return window, fmt.Errorf("invalid interval, expected day or week")
+	}
+
+	return window, nil
+}
+
+// bucketStart truncates a time to the start of its bucket (weeks start on Monday, as date_trunc does)
+func bucketStart(t time.Time, interval string) time.Time {
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	if interval == "week" {
+		offset := (int(day.Weekday()) + 6) % 7
+		return day.AddDate(0, 0, -offset)
+	}
+	return day
+}
+
+// rate divides safely, returning 0 when there is nothing to divide by
+func rate(numerator, denominator int64) float64 {
+	if denominator == 0 {
+		return 0
+	}
+	return float64(numerator) / float64(denominator)
+}
+
+func (b *AIMetricsBucket) computeRates() {
+	b.ConversionRate = rate(b.BotOrders, b.Conversations)
+	b.AvgToolsPerMessage = rate(b.ToolCalls, b.Messages)
+	b.HandoffRate = rate(b.Handoffs, b.Conversations)
+}
+
+// buildAIMetricsSeries merges the aggregates into a continuous series (empty buckets included) plus totals
+func buildAIMetricsSeries(window aiMetricsRange, messages []messageBucketRow, orders []orderBucketRow, tools []toolBucketRow) ([]AIMetricsBucket, AIMetricsBucket) {
+	var buckets []AIMetricsBucket
+	index := make(map[time.Time]int)
+	step := 1
+	if window.Interval == "week" {
+		step = 7
+	}
+	for start := bucketStart(window.From, window.Interval); start.Before(window.To); start = start.AddDate(0, 0, step) {
+		index[start] = len(buckets)
+		buckets = append(buckets, AIMetricsBucket{Start: start})
+	}
+
+	find := func(t time.Time) *AIMetricsBucket {
+		if i, ok := index[bucketStart(t.UTC(), window.Interval)]; ok {
+			return &buckets[i]
+		}
+		return nil
+	}
+
+	var totals AIMetricsBucket
+	for _, row := range messages {
+		if bucket := find(row.Bucket); bucket != nil {
+			bucket.Messages += row.Messages
+			bucket.Conversations += row.Conversations
+		}
+		totals.Messages += row.Messages
+		totals.Conversations += row.Conversations
+	}
+	for _, row := range orders {
+		if bucket := find(row.Bucket); bucket != nil {
+			bucket.BotOrders += row.BotOrders
+		}
+		totals.BotOrders += row.BotOrders
+	}
+	for _, row := range tools {
+		if bucket := find(row.Bucket); bucket != nil {
+			bucket.ToolCalls += row.ToolCalls
+			bucket.Handoffs += row.Handoffs
+		}
+		totals.ToolCalls += row.ToolCalls
+		totals.Handoffs += row.Handoffs
+	}
+
+	for i := range buckets {
+		buckets[i].computeRates()
+	}
+	totals.Start = window.From
+	totals.computeRates()
+
+	return buckets, totals
+}
+
+// GetTenantAIMetrics returns AI conversation metrics for a tenant as a time series
+// @Summary Get tenant AI metrics
+// @Description Messages per bucket, bot order conversion rate, average tools per message and human handoff rate
+// @Tags admin
+// @Produce json
+// @Param id path string true "Tenant ID"
+// @Param from query string false "Start date (YYYY-MM-DD), defaults to 30 days ago"
+// @Param to query string false "End date (YYYY-MM-DD, inclusive), defaults to today"
+// @Param interval query string false "Bucket size: day or week" default(day)
+// @Success 200 {object} AIMetricsResponse
+// @Failure 400 {object} map[string]string
+// @Router /admin/tenants/{id}/ai-metrics [get]
+// @Security BearerAuth
+func (h *AIMetricsHandler) GetTenantAIMetrics(c echo.Context) error {
+	tenantID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid tenant ID"})
+	}
+
+	window, err := parseAIMetricsRange(c.QueryParam("from"), c.QueryParam("to"), c.QueryParam("interval"), time.Now().UTC())
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	var messages []messageBucketRow
+	err = h.db.Model(&models.Message{}).
+		Select("date_trunc(?, created_at) AS bucket, COUNT(*) AS messages, COUNT(DISTINCT conversation_id) AS conversations", window.Interval).
+		Where("tenant_id = ? AND direction = ? AND created_at >= ? AND created_at < ?", tenantID, "in", window.From, window.To).
+		Group("bucket").Scan(&messages).Error
+	if err != nil {
+		log.Error().Err(err).Str("tenant_id", tenantID.String()).Msg("Failed to aggregate AI message metrics")
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to load AI metrics"})
+	}
+
+	// Orders created by the bot are the ones linked to a conversation
+	var orders []orderBucketRow
+	err = h.db.Model(&models.Order{}).
+		Select("date_trunc(?, created_at) AS bucket, COUNT(*) AS bot_orders", window.Interval).
+		Where("tenant_id = ? AND conversation_id IS NOT NULL AND created_at >= ? AND created_at < ?", tenantID, window.From, window.To).
+		Group("bucket").Scan(&orders).Error
+	if err != nil {
+		log.Error().Err(err).Str("tenant_id", tenantID.String()).Msg("Failed to aggregate AI order metrics")
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to load AI metrics"})
+	}
+
+	var tools []toolBucketRow
+	err = h.db.Model(&models.AIToolExecution{}).
+		Select("date_trunc(?, created_at) AS bucket, COUNT(*) AS tool_calls, COUNT(DISTINCT CASE WHEN tool_name = ? THEN conversation_id END) AS handoffs", window.Interval, humanHandoffToolName).
+		Where("tenant_id = ? AND created_at >= ? AND created_at < ?", tenantID, window.From, window.To).
+		Group("bucket").Scan(&tools).Error
+	if err != nil {
+		log.Error().Err(err).Str("tenant_id", tenantID.String()).Msg("Failed to aggregate AI tool metrics")
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to load AI metrics"})
+	}
+
+	buckets, totals := buildAIMetricsSeries(window, messages, orders, tools)
+
+	return c.JSON(http.StatusOK, AIMetricsResponse{
+		TenantID: tenantID,
+		From:     window.From,
+		To:       window.To.AddDate(0, 0, -1),
+		Interval: window.Interval,
+		Buckets:  buckets,
+		Totals:   totals,
+	})
+}
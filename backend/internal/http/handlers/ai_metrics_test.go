@@ -0,0 +1,101 @@
+package handlers
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestParseAIMetricsRange(t *testing.T) {
+	now := time.Date(2024, 6, 15, 18, 30, 0, 0, time.UTC)
+	day := func(month time.Month, d int) time.Time { return time.Date(2024, month, d, 0, 0, 0, 0, time.UTC) }
+
+	tests := []struct {
+		name                 string
+		from, to, interval   string
+		expectFrom, expectTo time.Time
+		expectInterval       string
+	}{
+		{name: "defaults to last 30 days", expectFrom: day(5, 17), expectTo: day(6, 16), expectInterval: "day"},
+		{name: "explicit range is inclusive", from: "2024-06-01", to: "2024-06-07", expectFrom: day(6, 1), expectTo: day(6, 8), expectInterval: "day"},
+		{name: "only to", to: "2024-03-31", expectFrom: day(3, 2), expectTo: day(4, 1), expectInterval: "day"},
+		{name: "weekly", from: "2024-06-01", to: "2024-06-14", interval: "week", expectFrom: day(6, 1), expectTo: day(6, 15), expectInterval: "week"},
+	}
+
+	for _, test := range tests {
+		window, err := parseAIMetricsRange(test.from, test.to, test.interval, now)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", test.name, err)
+			continue
+		}
+		if !window.From.Equal(test.expectFrom) || !window.To.Equal(test.expectTo) || window.Interval != test.expectInterval {
+			t.Errorf("%s: got %s - %s (%s)", test.name, window.From, window.To, window.Interval)
+		}
+	}
+}
+
+func TestParseAIMetricsRangeRejectsInvalidParams(t *testing.T) {
+	now := time.Date(2024, 6, 15, 0, 0, 0, 0, time.UTC)
+	tests := []struct{ from, to, interval string }{
+		{"2024-13-01", "", ""},
+		{"", "yesterday", ""},
+		{"2024-06-10", "2024-06-01", ""},
+		{"2024-01-01", "2024-06-01", ""}, // more than 90 days
+		{"", "", "month"},
+	}
+
+	for _, test := range tests {
+		if _, err := parseAIMetricsRange(test.from, test.to, test.interval, now); err == nil {
+			t.Errorf("parseAIMetricsRange(%q, %q, %q) expected error", test.from, test.to, test.interval)
+		}
+	}
+}
+
+func TestBuildAIMetricsSeries(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2024, 6, d, 0, 0, 0, 0, time.UTC) }
+	window := aiMetricsRange{From: day(1), To: day(4), Interval: "day"}
+
+	buckets, totals := buildAIMetricsSeries(window,
+		[]messageBucketRow{{Bucket: day(1), Messages: 40, Conversations: 10}, {Bucket: day(3), Messages: 10, Conversations: 5}},
+		[]orderBucketRow{{Bucket: day(1), BotOrders: 3}},
+		[]toolBucketRow{{Bucket: day(1), ToolCalls: 60, Handoffs: 1}, {Bucket: day(3), ToolCalls: 5}},
+	)
+
+	if len(buckets) != 3 {
+		t.Fatalf("expected 3 daily buckets including empty days, got %d", len(buckets))
+	}
+	if buckets[1].Messages != 0 || buckets[1].ConversionRate != 0 {
+		t.Errorf("empty day should have zero metrics, got %+v", buckets[1])
+	}
+
+	first := buckets[0]
+	if first.ConversionRate != 0.3 || first.AvgToolsPerMessage != 1.5 || first.HandoffRate != 0.1 {
+		t.Errorf("unexpected rates for first day: %+v", first)
+	}
+
+	if totals.Messages != 50 || totals.Conversations != 15 || totals.BotOrders != 3 || totals.ToolCalls != 65 || totals.Handoffs != 1 {
+		t.Errorf("unexpected totals: %+v", totals)
+	}
+	if math.Abs(totals.AvgToolsPerMessage-1.3) > 1e-9 || math.Abs(totals.ConversionRate-0.2) > 1e-9 {
+		t.Errorf("unexpected total rates: %+v", totals)
+	}
+}
+
+func TestBuildAIMetricsSeriesWeekly(t *testing.T) {
+	// 2024-06-03 is a Monday
+	window := aiMetricsRange{From: time.Date(2024, 6, 5, 0, 0, 0, 0, time.UTC), To: time.Date(2024, 6, 19, 0, 0, 0, 0, time.UTC), Interval: "week"}
+	buckets, _ := buildAIMetricsSeries(window,
+		[]messageBucketRow{{Bucket: time.Date(2024, 6, 10, 0, 0, 0, 0, time.UTC), Messages: 7, Conversations: 2}},
+		nil, nil,
+	)
+
+	if len(buckets) != 3 {
+		t.Fatalf("expected 3 weekly buckets, got %d", len(buckets))
+	}
+	if !buckets[0].Start.Equal(time.Date(2024, 6, 3, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("weekly buckets should start on Monday, got %s", buckets[0].Start)
+	}
+	if buckets[1].Messages != 7 {
+		t.Errorf("expected messages in the second week, got %+v", buckets[1])
+	}
+}
@@ -11,6 +11,7 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
+	"github.com/rs/zerolog/log"
 	"gorm.io/gorm"
 )
 
@@ -307,49 +308,36 @@ func (h *AlertHandler) UpdateAlert(c echo.Context) error {
 
 // syncGroupParticipants compares old and new phone lists and syncs WhatsApp group participants
 func (h *AlertHandler) syncGroupParticipants(oldPhones, newPhones, groupID, session string) error {
-	fmt.Printf("🔄 DEBUG syncGroupParticipants - Starting sync\n")
-	fmt.Printf("🔄 DEBUG - Old phones: %s\n", oldPhones)
-	fmt.Printf("🔄 DEBUG - New phones: %s\n", newPhones)
-	fmt.Printf("🔄 DEBUG - Group ID: %s\n", groupID)
-	fmt.Printf("🔄 DEBUG - Session: %s\n", session)
-
 	// Parse phone lists
 	oldPhoneList := parsePhoneList(oldPhones)
 	newPhoneList := parsePhoneList(newPhones)
 
-	fmt.Printf("🔄 DEBUG - Old phone list: %v\n", oldPhoneList)
-	fmt.Printf("🔄 DEBUG - New phone list: %v\n", newPhoneList)
-
 	// Find phones to add (in new but not in old)
 	phonesToAdd := findPhoneDifference(newPhoneList, oldPhoneList)
 
 	// Find phones to remove (in old but not in new)
 	phonesToRemove := findPhoneDifference(oldPhoneList, newPhoneList)
 
-	fmt.Printf("🔄 DEBUG - Phones to add: %v\n", phonesToAdd)
-	fmt.Printf("🔄 DEBUG - Phones to remove: %v\n", phonesToRemove)
+	log.Debug().
+		Str("group_id", groupID).
+		Int("add", len(phonesToAdd)).
+		Int("remove", len(phonesToRemove)).
+		Msg("🔄 Sincronizando participantes do grupo de alertas")
 
 	// Add new participants
 	for _, phone := range phonesToAdd {
-		fmt.Printf("➕ DEBUG - Adding participant: %s\n", phone)
 		if err := h.alertService.AddParticipantToGroup(groupID, phone, session); err != nil {
-			fmt.Printf("❌ DEBUG - Failed to add participant %s: %v\n", phone, err)
 			return fmt.Errorf("failed to add participant %s: %w", phone, err)
 		}
-		fmt.Printf("✅ DEBUG - Successfully added participant: %s\n", phone)
 	}
 
 	// Remove old participants
 	for _, phone := range phonesToRemove {
-		fmt.Printf("➖ DEBUG - Removing participant: %s\n", phone)
 		if err := h.alertService.RemoveParticipantFromGroup(groupID, phone, session); err != nil {
-			fmt.Printf("❌ DEBUG - Failed to remove participant %s: %v\n", phone, err)
 			return fmt.Errorf("failed to remove participant %s: %w", phone, err)
 		}
-		fmt.Printf("✅ DEBUG - Successfully removed participant: %s\n", phone)
 	}
 
-	fmt.Printf("🔄 DEBUG syncGroupParticipants - Completed sync\n")
 	return nil
 }
 
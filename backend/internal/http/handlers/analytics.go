@@ -9,6 +9,7 @@ import (
 	"iafarma/internal/repo"
 
 	"github.com/labstack/echo/v4"
+	"github.com/rs/zerolog/log"
 	"gorm.io/gorm"
 )
 
@@ -466,8 +467,6 @@ func (h *AnalyticsHandler) GetReportsData(c echo.Context) error {
 		}
 
 	case "daily":
-		fmt.Printf("DEBUG: In daily case - implementing inline\n")
-
 		// Implementação melhorada para incluir products_sold
 		type PeriodResult struct {
 			Revenue      float64 `gorm:"column:revenue"`
@@ -493,7 +492,7 @@ func (h *AnalyticsHandler) GetReportsData(c echo.Context) error {
 			Scan(&result).Error
 
 		if err != nil {
-			fmt.Printf("DEBUG: Error in daily query with JOIN: %v\n", err)
+			log.Warn().Err(err).Msg("Erro no relatório diário com itens - usando consulta sem itens")
 			// Fallback para query sem JOIN
 			err = h.db.Table("orders").
 				Select("COALESCE(SUM(CAST(total_amount AS DECIMAL)), 0) as revenue, COUNT(*) as orders, COUNT(DISTINCT customer_id) as customers, 0 as products_sold").
@@ -503,7 +502,7 @@ func (h *AnalyticsHandler) GetReportsData(c echo.Context) error {
 				Scan(&result).Error
 
 			if err != nil {
-				fmt.Printf("DEBUG: Error in daily fallback query: %v\n", err)
+				log.Error().Err(err).Msg("Erro no relatório diário")
 				result = PeriodResult{Revenue: 0, Orders: 0, Customers: 0, ProductsSold: 0}
 			}
 		}
@@ -521,12 +520,15 @@ func (h *AnalyticsHandler) GetReportsData(c echo.Context) error {
 
 			if err == nil {
 				result.ProductsSold = productsSold
-				fmt.Printf("DEBUG: Alternative products_sold calculation: %d\n", productsSold)
 			}
 		}
 
-		fmt.Printf("DEBUG: Daily result - Revenue: %.2f, Orders: %d, Customers: %d, Products: %d\n",
-			result.Revenue, result.Orders, result.Customers, result.ProductsSold)
+		log.Debug().
+			Float64("revenue", result.Revenue).
+			Int64("orders", result.Orders).
+			Int64("customers", result.Customers).
+			Int64("products_sold", result.ProductsSold).
+			Msg("Relatório diário calculado")
 
 		dailyData := []ReportDataItem{
 			{
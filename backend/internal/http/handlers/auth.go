@@ -1,7 +1,6 @@
 package handlers
 
 import (
-	"fmt"
 	"net/http"
 
 	"iafarma/internal/auth"
@@ -100,8 +99,6 @@ func (h *AuthHandler) RefreshToken(c echo.Context) error {
 // @Router /auth/profile [put]
 func (h *AuthHandler) UpdateProfile(c echo.Context) error {
 	userIDRaw := c.Get("user_id")
-	fmt.Printf("DEBUG: userIDRaw type: %T, value: %v\n", userIDRaw, userIDRaw)
-
 	userID, ok := userIDRaw.(uuid.UUID)
 	if !ok {
 		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid user ID format"})
@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"iafarma/internal/ai"
+	"iafarma/pkg/models"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+)
+
+const maxCatalogPreviewLimit = 50
+
+type CatalogPreviewHandler struct {
+	db        *gorm.DB
+	aiService *ai.AIService
+}
+
+func NewCatalogPreviewHandler(db *gorm.DB) *CatalogPreviewHandler {
+	return &CatalogPreviewHandler{db: db, aiService: ai.NewCatalogPreviewService(db)}
+}
+
+// CatalogPreviewResponse is the catalog rendered exactly as the AI sends it to customers
+type CatalogPreviewResponse struct {
+	TenantID uuid.UUID `json:"tenant_id"`
+	Limit    int       `json:"limit"`
+	ai.CatalogPreview
+}
+
+// parseCatalogPreviewLimit reads how many products the generic listing shows
+func parseCatalogPreviewLimit(limitParam string) (int, error) {
+	if limitParam == "" {
+		return ai.DefaultCatalogPreviewLimit, nil
+	}
+
+	limit, err := strconv.Atoi(limitParam)
+	if err != nil || limit <= 0 {
+		return 0, fmt.Errorf("invalid limit, expected a positive number")
+	}
+	if limit > maxCatalogPreviewLimit {
+		limit = maxCatalogPreviewLimit
+	}
+	return limit, nil
+}
+
+// GetTenantCatalogPreview renders the tenant catalog with the AI product list formatting
+// @Summary Preview the AI catalog formatting
+// @Description Renders the tenant's real products as the AI lists them: the numbered search listing and the catalog grouped by category
+// @Tags admin
+// @Produce json
+// @Param id path string true "Tenant ID"
+// @Param limit query int false "Maximum number of products in the generic listing" default(10)
+// @Success 200 {object} CatalogPreviewResponse
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /admin/tenants/{id}/catalog-preview [get]
+// @Security BearerAuth
+func (h *CatalogPreviewHandler) GetTenantCatalogPreview(c echo.Context) error {
+	tenantID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid tenant ID"})
+	}
+
+	limit, err := parseCatalogPreviewLimit(c.QueryParam("limit"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	if err := h.db.Select("id").First(&models.Tenant{}, "id = ?", tenantID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return c.JSON(http.StatusNotFound, map[string]string{"error": "Tenant not found"})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to load tenant"})
+	}
+
+	preview, err := h.aiService.PreviewCatalog(c.Request().Context(), tenantID, limit)
+	if err != nil {
+		log.Error().Err(err).Str("tenant_id", tenantID.String()).Msg("Failed to render catalog preview")
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to render catalog preview"})
+	}
+
+	return c.JSON(http.StatusOK, CatalogPreviewResponse{
+		TenantID:       tenantID,
+		Limit:          limit,
+		CatalogPreview: *preview,
+	})
+}
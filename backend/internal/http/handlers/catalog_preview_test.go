@@ -0,0 +1,28 @@
+package handlers
+
+import (
+	"testing"
+
+	"iafarma/internal/ai"
+)
+
+func TestParseCatalogPreviewLimit(t *testing.T) {
+	tests := []struct {
+		param     string
+		expect    int
+		expectErr bool
+	}{
+		{"", ai.DefaultCatalogPreviewLimit, false},
+		{"5", 5, false},
+		{"500", maxCatalogPreviewLimit, false},
+		{"0", 0, true},
+		{"abc", 0, true},
+	}
+
+	for _, test := range tests {
+		limit, err := parseCatalogPreviewLimit(test.param)
+		if (err != nil) != test.expectErr || limit != test.expect {
+			t.Errorf("parseCatalogPreviewLimit(%q) = %d, %v", test.param, limit, err)
+		}
+	}
+}
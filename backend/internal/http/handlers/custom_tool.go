@@ -0,0 +1,265 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"iafarma/internal/ai"
+	"iafarma/internal/repo"
+	"iafarma/pkg/models"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+)
+
+// CustomToolHandler handles the tenant-specific AI tools dispatched to webhooks
+type CustomToolHandler struct {
+	customToolRepo *repo.CustomToolRepository
+}
+
+// NewCustomToolHandler creates a new custom tool handler
+func NewCustomToolHandler(customToolRepo *repo.CustomToolRepository) *CustomToolHandler {
+	return &CustomToolHandler{customToolRepo: customToolRepo}
+}
+
+// CustomToolRequest represents the request to create or update a custom tool
+type CustomToolRequest struct {
+	Name           string          `json:"name" validate:"required"`
+	Description    string          `json:"description" validate:"required"`
+	Parameters     json.RawMessage `json:"parameters" swaggertype:"object"`
+	WebhookURL     string          `json:"webhook_url" validate:"required"`
+	Secret         string          `json:"secret"`
+	TimeoutSeconds int             `json:"timeout_seconds"`
+	IsActive       *bool           `json:"is_active"`
+}
+
+// validate trims the request fields and checks the tool definition
+func (r *CustomToolRequest) validate() (string, error) {
+	r.Name = strings.TrimSpace(r.Name)
+	r.Description = strings.TrimSpace(r.Description)
+	r.WebhookURL = strings.TrimSpace(r.WebhookURL)
+	r.Secret = strings.TrimSpace(r.Secret)
+
+	parameters := ""
+	if trimmed := bytes.TrimSpace(r.Parameters); len(trimmed) > 0 && string(trimmed) != "null" {
+		var compact bytes.Buffer
+		if err := json.Compact(&compact, trimmed); err != nil {
+			return "", errors.New("parameters must be a valid JSON object")
+		}
+		parameters = compact.String()
+	}
+
+	if r.TimeoutSeconds < 0 || r.TimeoutSeconds > 15 {
+		return "", errors.New("timeout_seconds must be between 1 and 15")
+	}
+
+	if err := ai.ValidateCustomToolDefinition(r.Name, r.Description, parameters, r.WebhookURL); err != nil {
+		return "", err
+	}
+	return parameters, nil
+}
+
+// List godoc
+// @Summary List custom tools
+// @Description Get the custom AI tools of the tenant
+// @Tags custom-tools
+// @Produce json
+// @Success 200 {array} models.TenantCustomTool
+// @Failure 500 {object} map[string]string
+// @Router /custom-tools [get]
+// @Security BearerAuth
+func (h *CustomToolHandler) List(c echo.Context) error {
+	tenantID := c.Get("tenant_id").(uuid.UUID)
+
+	tools, err := h.customToolRepo.List(tenantID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to fetch custom tools"})
+	}
+
+	return c.JSON(http.StatusOK, tools)
+}
+
+// GetByID godoc
+// @Summary Get custom tool by ID
+// @Description Get a specific custom tool by ID
+// @Tags custom-tools
+// @Produce json
+// @Param id path string true "Custom tool ID"
+// @Success 200 {object} models.TenantCustomTool
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /custom-tools/{id} [get]
+// @Security BearerAuth
+func (h *CustomToolHandler) GetByID(c echo.Context) error {
+	tenantID := c.Get("tenant_id").(uuid.UUID)
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid custom tool ID"})
+	}
+
+	tool, err := h.customToolRepo.GetByID(tenantID, id)
+	if err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "custom tool not found"})
+	}
+
+	return c.JSON(http.StatusOK, tool)
+}
+
+// Create godoc
+// @Summary Create custom tool
+// @Description Register a tenant-specific AI tool that calls the configured webhook
+// @Tags custom-tools
+// @Accept json
+// @Produce json
+// @Param tool body CustomToolRequest true "Custom tool data"
+// @Success 201 {object} models.TenantCustomTool
+// @Failure 400 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /custom-tools [post]
+// @Security BearerAuth
+func (h *CustomToolHandler) Create(c echo.Context) error {
+	tenantID := c.Get("tenant_id").(uuid.UUID)
+
+	var req CustomToolRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request body"})
+	}
+	parameters, err := req.validate()
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	if _, err := h.customToolRepo.GetByName(tenantID, req.Name); err == nil {
+		return c.JSON(http.StatusConflict, map[string]string{"error": "a custom tool with this name already exists"})
+	}
+
+	existing, err := h.customToolRepo.List(tenantID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to create custom tool"})
+	}
+	if len(existing) >= ai.MaxCustomToolsPerTenant {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": fmt.Sprintf("a tenant can have at most %d custom tools", ai.MaxCustomToolsPerTenant)})
+	}
+
+	tool := &models.TenantCustomTool{
+		Name:           req.Name,
+		Description:    req.Description,
+		Parameters:     parameters,
+		WebhookURL:     req.WebhookURL,
+		Secret:         req.Secret,
+		TimeoutSeconds: req.TimeoutSeconds,
+		IsActive:       true,
+	}
+	tool.TenantID = tenantID
+
+	if err := h.customToolRepo.Create(tool); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to create custom tool"})
+	}
+
+	// is_active defaults to true in the database, so an explicit deactivation is applied after creation
+	if req.IsActive != nil && !*req.IsActive {
+		tool.IsActive = false
+		if err := h.customToolRepo.Update(tool); err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to create custom tool"})
+		}
+	}
+
+	return c.JSON(http.StatusCreated, tool)
+}
+
+// Update godoc
+// @Summary Update custom tool
+// @Description Update an existing custom tool (an empty secret keeps the current one)
+// @Tags custom-tools
+// @Accept json
+// @Produce json
+// @Param id path string true "Custom tool ID"
+// @Param tool body CustomToolRequest true "Custom tool data"
+// @Success 200 {object} models.TenantCustomTool
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /custom-tools/{id} [put]
+// @Security BearerAuth
+func (h *CustomToolHandler) Update(c echo.Context) error {
+	tenantID := c.Get("tenant_id").(uuid.UUID)
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid custom tool ID"})
+	}
+
+	var req CustomToolRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request body"})
+	}
+	parameters, err := req.validate()
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	tool, err := h.customToolRepo.GetByID(tenantID, id)
+	if err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "custom tool not found"})
+	}
+
+	if other, err := h.customToolRepo.GetByName(tenantID, req.Name); err == nil && other.ID != tool.ID {
+		return c.JSON(http.StatusConflict, map[string]string{"error": "a custom tool with this name already exists"})
+	}
+
+	tool.Name = req.Name
+	tool.Description = req.Description
+	tool.Parameters = parameters
+	tool.WebhookURL = req.WebhookURL
+	tool.TimeoutSeconds = req.TimeoutSeconds
+	if req.Secret != "" {
+		tool.Secret = req.Secret
+	}
+	if req.IsActive != nil {
+		tool.IsActive = *req.IsActive
+	}
+
+	if err := h.customToolRepo.Update(tool); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to update custom tool"})
+	}
+
+	return c.JSON(http.StatusOK, tool)
+}
+
+// Delete godoc
+// @Summary Delete custom tool
+// @Description Delete a custom tool
+// @Tags custom-tools
+// @Produce json
+// @Param id path string true "Custom tool ID"
+// @Success 204
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /custom-tools/{id} [delete]
+// @Security BearerAuth
+func (h *CustomToolHandler) Delete(c echo.Context) error {
+	tenantID := c.Get("tenant_id").(uuid.UUID)
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid custom tool ID"})
+	}
+
+	if err := h.customToolRepo.Delete(tenantID, id); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return c.JSON(http.StatusNotFound, map[string]string{"error": "custom tool not found"})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to delete custom tool"})
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
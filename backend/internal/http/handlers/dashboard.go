@@ -8,6 +8,7 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
+	"github.com/rs/zerolog/log"
 	"gorm.io/gorm"
 )
 
@@ -35,28 +36,22 @@ func NewDashboardHandler(messageRepo *repo.MessageRepository, db *gorm.DB) *Dash
 // @Router /dashboard/unread-messages [get]
 func (h *DashboardHandler) GetUnreadMessages(c echo.Context) error {
 	tenantIDRaw := c.Get("tenant_id")
-	// fmt.Printf("DEBUG Dashboard - tenantIDRaw: %v, type: %T\n", tenantIDRaw, tenantIDRaw)
-
 	if tenantIDRaw == nil {
-		fmt.Println("DEBUG Dashboard - Tenant ID not found")
 		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "Tenant ID not found"})
 	}
 
 	tenantID, ok := tenantIDRaw.(uuid.UUID)
 	if !ok {
-		fmt.Printf("DEBUG Dashboard - Invalid tenant ID format. Value: %v, Type: %T\n", tenantIDRaw, tenantIDRaw)
+		log.Debug().Str("type", fmt.Sprintf("%T", tenantIDRaw)).Msg("Tenant ID com formato inválido no dashboard")
 		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "Invalid tenant ID format"})
 	}
 
-	// fmt.Printf("DEBUG Dashboard - Using tenant ID: %s\n", tenantID.String())
-
 	count, err := h.messageRepo.GetUnreadCountByTenant(tenantID)
 	if err != nil {
-		// fmt.Printf("DEBUG Dashboard - Error getting unread count: %v\n", err)
+		log.Error().Err(err).Str("tenant_id", tenantID.String()).Msg("Erro ao contar mensagens não lidas")
 		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to get unread messages count"})
 	}
 
-	// fmt.Printf("DEBUG Dashboard - Unread count: %d\n", count)
 	return c.JSON(http.StatusOK, map[string]int64{"unread_count": count})
 }
 
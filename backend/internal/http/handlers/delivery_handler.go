@@ -54,6 +54,12 @@ type ValidateDeliveryRequest struct {
 	Country      string `json:"country"`
 }
 
+// AdminValidateDeliveryRequest represents a support request to simulate delivery validation for a tenant
+type AdminValidateDeliveryRequest struct {
+	TenantID string `json:"tenant_id" validate:"required"`
+	ValidateDeliveryRequest
+}
+
 // UpdateStoreLocation updates the tenant's store location and geocodes it
 func (h *DeliveryHandler) UpdateStoreLocation(c echo.Context) error {
 	tenantID, ok := c.Get("tenant_id").(uuid.UUID)
@@ -259,3 +265,65 @@ func (h *DeliveryHandler) GetStoreLocation(c echo.Context) error {
 
 	return c.JSON(http.StatusOK, response)
 }
+
+// AdminValidateDeliveryAddress simulates delivery validation for any tenant and address (system admin only).
+// It returns the validation result together with the store coordinates used, so misconfiguration is easy to spot.
+func (h *DeliveryHandler) AdminValidateDeliveryAddress(c echo.Context) error {
+	var req AdminValidateDeliveryRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	if err := c.Validate(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	tenantID, err := uuid.Parse(req.TenantID)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid tenant ID"})
+	}
+
+	tenant, err := h.deliveryService.GetTenantStoreConfiguration(tenantID)
+	if err != nil {
+		log.Error().Err(err).Str("tenant_id", tenantID.String()).Msg("Failed to get store configuration")
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "Tenant not found"})
+	}
+
+	address := models.Address{
+		Street:       req.Street,
+		Number:       req.Number,
+		Neighborhood: req.Neighborhood,
+		City:         req.City,
+		State:        req.State,
+		ZipCode:      req.ZipCode,
+		Country:      req.Country,
+	}
+
+	result, err := h.deliveryService.ValidateDeliveryAddress(c.Request().Context(), tenantID, address)
+	if err != nil {
+		log.Error().Err(err).Str("tenant_id", tenantID.String()).Msg("Failed to simulate delivery validation")
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to validate delivery address"})
+	}
+
+	store := map[string]interface{}{
+		"store_street":           tenant.StoreStreet,
+		"store_number":           tenant.StoreNumber,
+		"store_neighborhood":     tenant.StoreNeighborhood,
+		"store_city":             tenant.StoreCity,
+		"store_state":            tenant.StoreState,
+		"store_zip_code":         tenant.StoreZipCode,
+		"delivery_radius_km":     tenant.DeliveryRadiusKm,
+		"coordinates_configured": tenant.StoreLatitude != nil && tenant.StoreLongitude != nil,
+	}
+	if tenant.StoreLatitude != nil && tenant.StoreLongitude != nil {
+		store["store_latitude"] = *tenant.StoreLatitude
+		store["store_longitude"] = *tenant.StoreLongitude
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"tenant_id": tenantID,
+		"address":   address,
+		"result":    result,
+		"store":     store,
+	})
+}
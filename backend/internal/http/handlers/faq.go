@@ -0,0 +1,220 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"iafarma/internal/repo"
+	"iafarma/pkg/models"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+)
+
+// FAQHandler handles the tenant FAQ entries used by the AI assistant
+type FAQHandler struct {
+	faqRepo *repo.FAQRepository
+}
+
+// NewFAQHandler creates a new FAQ handler
+func NewFAQHandler(faqRepo *repo.FAQRepository) *FAQHandler {
+	return &FAQHandler{faqRepo: faqRepo}
+}
+
+// FAQRequest represents the request to create or update a FAQ entry
+type FAQRequest struct {
+	Question string `json:"question" validate:"required"`
+	Answer   string `json:"answer" validate:"required"`
+	Keywords string `json:"keywords"`
+	IsActive *bool  `json:"is_active"`
+}
+
+// validate trims the request fields and checks the required ones
+func (r *FAQRequest) validate() error {
+	r.Question = strings.TrimSpace(r.Question)
+	r.Answer = strings.TrimSpace(r.Answer)
+	r.Keywords = strings.TrimSpace(r.Keywords)
+
+	if r.Question == "" {
+		return errors.New("question is required")
+	}
+	if r.Answer == "" {
+		return errors.New("answer is required")
+	}
+	return nil
+}
+
+// List godoc
+// @Summary List FAQ entries
+// @Description Get the FAQ entries of the tenant
+// @Tags faqs
+// @Produce json
+// @Param search query string false "Search in question, answer and keywords"
+// @Success 200 {array} models.FAQEntry
+// @Failure 500 {object} map[string]string
+// @Router /faqs [get]
+// @Security BearerAuth
+func (h *FAQHandler) List(c echo.Context) error {
+	tenantID := c.Get("tenant_id").(uuid.UUID)
+
+	entries, err := h.faqRepo.List(tenantID, strings.TrimSpace(c.QueryParam("search")))
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to fetch FAQ entries"})
+	}
+
+	return c.JSON(http.StatusOK, entries)
+}
+
+// GetByID godoc
+// @Summary Get FAQ entry by ID
+// @Description Get a specific FAQ entry by ID
+// @Tags faqs
+// @Produce json
+// @Param id path string true "FAQ entry ID"
+// @Success 200 {object} models.FAQEntry
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /faqs/{id} [get]
+// @Security BearerAuth
+func (h *FAQHandler) GetByID(c echo.Context) error {
+	tenantID := c.Get("tenant_id").(uuid.UUID)
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid FAQ entry ID"})
+	}
+
+	entry, err := h.faqRepo.GetByID(tenantID, id)
+	if err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "FAQ entry not found"})
+	}
+
+	return c.JSON(http.StatusOK, entry)
+}
+
+// Create godoc
+// @Summary Create FAQ entry
+// @Description Create a new FAQ entry answered by the AI assistant
+// @Tags faqs
+// @Accept json
+// @Produce json
+// @Param faq body FAQRequest true "FAQ entry data"
+// @Success 201 {object} models.FAQEntry
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /faqs [post]
+// @Security BearerAuth
+func (h *FAQHandler) Create(c echo.Context) error {
+	tenantID := c.Get("tenant_id").(uuid.UUID)
+
+	var req FAQRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request body"})
+	}
+	if err := req.validate(); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	entry := &models.FAQEntry{
+		Question: req.Question,
+		Answer:   req.Answer,
+		Keywords: req.Keywords,
+		IsActive: true,
+	}
+	entry.TenantID = tenantID
+
+	if err := h.faqRepo.Create(entry); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to create FAQ entry"})
+	}
+
+	// is_active defaults to true in the database, so an explicit deactivation is applied after creation
+	if req.IsActive != nil && !*req.IsActive {
+		entry.IsActive = false
+		if err := h.faqRepo.Update(entry); err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to create FAQ entry"})
+		}
+	}
+
+	return c.JSON(http.StatusCreated, entry)
+}
+
+// Update godoc
+// @Summary Update FAQ entry
+// @Description Update an existing FAQ entry
+// @Tags faqs
+// @Accept json
+// @Produce json
+// @Param id path string true "FAQ entry ID"
+// @Param faq body FAQRequest true "FAQ entry data"
+// @Success 200 {object} models.FAQEntry
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /faqs/{id} [put]
+// @Security BearerAuth
+func (h *FAQHandler) Update(c echo.Context) error {
+	tenantID := c.Get("tenant_id").(uuid.UUID)
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid FAQ entry ID"})
+	}
+
+	var req FAQRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request body"})
+	}
+	if err := req.validate(); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	entry, err := h.faqRepo.GetByID(tenantID, id)
+	if err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "FAQ entry not found"})
+	}
+
+	entry.Question = req.Question
+	entry.Answer = req.Answer
+	entry.Keywords = req.Keywords
+	if req.IsActive != nil {
+		entry.IsActive = *req.IsActive
+	}
+
+	if err := h.faqRepo.Update(entry); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to update FAQ entry"})
+	}
+
+	return c.JSON(http.StatusOK, entry)
+}
+
+// Delete godoc
+// @Summary Delete FAQ entry
+// @Description Delete a FAQ entry
+// @Tags faqs
+// @Produce json
+// @Param id path string true "FAQ entry ID"
+// @Success 204
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /faqs/{id} [delete]
+// @Security BearerAuth
+func (h *FAQHandler) Delete(c echo.Context) error {
+	tenantID := c.Get("tenant_id").(uuid.UUID)
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid FAQ entry ID"})
+	}
+
+	if err := h.faqRepo.Delete(tenantID, id); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return c.JSON(http.StatusNotFound, map[string]string{"error": "FAQ entry not found"})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to delete FAQ entry"})
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
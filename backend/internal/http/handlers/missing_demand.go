@@ -0,0 +1,138 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"iafarma/pkg/models"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+)
+
+const (
+	defaultMissingDemandLimit = 50
+	maxMissingDemandLimit     = 200
+)
+
+type MissingDemandHandler struct {
+	db *gorm.DB
+}
+
+func NewMissingDemandHandler(db *gorm.DB) *MissingDemandHandler {
+	return &MissingDemandHandler{db: db}
+}
+
+// MissingDemandItem aggregates the requests for one product the store doesn't carry
+type MissingDemandItem struct {
+	Query                string    `json:"query"`
+	Example              string    `json:"example"`
+	Requests             int64     `json:"requests"`
+	Customers            int64     `json:"customers"`
+	AwaitingNotification int64     `json:"awaiting_notification"`
+	SpecialOrders        int64     `json:"special_orders"` // Explicit requests for the store to source the product
+	LastRequestedAt      time.Time `json:"last_requested_at"`
+}
+
+// MissingDemandTotals sums the report items
+type MissingDemandTotals struct {
+	Products             int   `json:"products"`
+	Requests             int64 `json:"requests"`
+	AwaitingNotification int64 `json:"awaiting_notification"`
+	SpecialOrders        int64 `json:"special_orders"`
+}
+
+// MissingDemandResponse is the per-tenant report of requested-but-missing products
+type MissingDemandResponse struct {
+	TenantID uuid.UUID           `json:"tenant_id"`
+	From     time.Time           `json:"from"`
+	To       time.Time           `json:"to"`
+	Items    []MissingDemandItem `json:"items"`
+	Totals   MissingDemandTotals `json:"totals"`
+}
+
+// parseMissingDemandLimit reads the maximum number of products in the report
+func parseMissingDemandLimit(limitParam string) (int, error) {
+	if limitParam == "" {
+		return defaultMissingDemandLimit, nil
+	}
+
+	limit, err := strconv.Atoi(limitParam)
+	if err != nil || limit <= 0 {
+		return 0, fmt.Errorf("invalid limit, expected a positive number")
+	}
+	if limit > maxMissingDemandLimit {
+		limit = maxMissingDemandLimit
+	}
+	return limit, nil
+}
+
+// summarizeMissingDemand computes the report totals
+func summarizeMissingDemand(items []MissingDemandItem) MissingDemandTotals {
+	totals := MissingDemandTotals{Products: len(items)}
+	for _, item := range items {
+		totals.Requests += item.Requests
+		totals.AwaitingNotification += item.AwaitingNotification
+		totals.SpecialOrders += item.SpecialOrders
+	}
+	return totals
+}
+
+// GetTenantMissingDemand returns the products customers asked for that the tenant doesn't carry
+// @Summary Get tenant missing product demand
+// @Description Products requested through the AI that were not found in the catalog or were explicitly asked for as special orders, most requested first
+// @Tags admin
+// @Produce json
+// @Param id path string true "Tenant ID"
+// @Param from query string false "Start date (YYYY-MM-DD), defaults to 30 days ago"
+// @Param to query string false "End date (YYYY-MM-DD, inclusive), defaults to today"
+// @Param limit query int false "Maximum number of products" default(50)
+// @Success 200 {object} MissingDemandResponse
+// @Failure 400 {object} map[string]string
+// @Router /admin/tenants/{id}/missing-demand [get]
+// @Security BearerAuth
+func (h *MissingDemandHandler) GetTenantMissingDemand(c echo.Context) error {
+	tenantID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid tenant ID"})
+	}
+
+	window, err := parseAIMetricsRange(c.QueryParam("from"), c.QueryParam("to"), "", time.Now().UTC())
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	limit, err := parseMissingDemandLimit(c.QueryParam("limit"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	items := []MissingDemandItem{}
+	err = h.db.Model(&models.MissingProductDemand{}).
+		Select(`normalized_query AS query, MAX(query) AS example, COUNT(*) AS requests,
+			COUNT(DISTINCT customer_id) AS customers,
+			COUNT(CASE WHEN notify_requested AND notified_at IS NULL THEN 1 END) AS awaiting_notification,
+			COUNT(CASE WHEN source = ? THEN 1 END) AS special_orders,
+			MAX(created_at) AS last_requested_at`, models.MissingDemandSourceSpecialOrder).
+		Where("tenant_id = ? AND created_at >= ? AND created_at < ?", tenantID, window.From, window.To).
+		Group("normalized_query").
+		Order("requests DESC, last_requested_at DESC").
+		Limit(limit).
+		Scan(&items).Error
+	if err != nil {
+		log.Error().Err(err).Str("tenant_id", tenantID.String()).Msg("Failed to aggregate missing product demand")
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to load missing product demand"})
+	}
+
+	return c.JSON(http.StatusOK, MissingDemandResponse{
+		TenantID: tenantID,
+		From:     window.From,
+		To:       window.To.AddDate(0, 0, -1),
+		Items:    items,
+		Totals:   summarizeMissingDemand(items),
+	})
+}
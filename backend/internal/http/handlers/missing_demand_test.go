@@ -0,0 +1,35 @@
+package handlers
+
+import "testing"
+
+func TestParseMissingDemandLimit(t *testing.T) {
+	tests := []struct {
+		param     string
+		expect    int
+		expectErr bool
+	}{
+		{"", defaultMissingDemandLimit, false},
+		{"10", 10, false},
+		{"1000", maxMissingDemandLimit, false},
+		{"0", 0, true},
+		{"abc", 0, true},
+	}
+
+	for _, test := range tests {
+		limit, err := parseMissingDemandLimit(test.param)
+		if (err != nil) != test.expectErr || limit != test.expect {
+			t.Errorf("parseMissingDemandLimit(%q) = %d, %v", test.param, limit, err)
+		}
+	}
+}
+
+func TestSummarizeMissingDemand(t *testing.T) {
+	totals := summarizeMissingDemand([]MissingDemandItem{
+		{Query: "ozempic", Requests: 7, AwaitingNotification: 3},
+		{Query: "dipirona gotas", Requests: 2, SpecialOrders: 1},
+	})
+
+	if totals.Products != 2 || totals.Requests != 9 || totals.AwaitingNotification != 3 || totals.SpecialOrders != 1 {
+		t.Errorf("unexpected totals: %+v", totals)
+	}
+}
@@ -8,6 +8,7 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
+	"github.com/rs/zerolog/log"
 	"gorm.io/gorm"
 )
 
@@ -68,10 +69,7 @@ func (h *OrderHandler) SendEmail(c echo.Context) error {
 	// TODO: Implementar integração com Amazon SES
 	// Por enquanto, vamos simular o envio do email
 	emailBody := h.generateEmailBody(order, req.Message)
-
-	// Log the email sending attempt (for debugging)
-	fmt.Printf("Sending email to %s with subject: %s\n", req.Recipient, req.Subject)
-	fmt.Printf("Email body preview: %s...\n", emailBody[:100])
+	log.Debug().Str("order_id", order.ID.String()).Int("body_length", len(emailBody)).Msg("Simulando envio do pedido por email")
 
 	// Simulate successful email sending
 	// In a real implementation, this would integrate with Amazon SES
@@ -19,8 +19,9 @@ func NewPaymentMethodHandler(db *gorm.DB) *PaymentMethodHandler {
 }
 
 type PaymentMethodRequest struct {
-	Name     string `json:"name" validate:"required"`
-	IsActive *bool  `json:"is_active"`
+	Name         string `json:"name" validate:"required"`
+	Instructions string `json:"instructions"`
+	IsActive     *bool  `json:"is_active"`
 }
 
 type PaymentMethodResponse struct {
@@ -102,8 +103,9 @@ func (h *PaymentMethodHandler) Create(c echo.Context) error {
 			ID:       uuid.New(),
 			TenantID: tenantID,
 		},
-		Name:     req.Name,
-		IsActive: isActive,
+		Name:         req.Name,
+		Instructions: req.Instructions,
+		IsActive:     isActive,
 	}
 
 	if err := h.db.Create(&paymentMethod).Error; err != nil {
@@ -159,6 +161,7 @@ func (h *PaymentMethodHandler) Update(c echo.Context) error {
 
 	// Update fields
 	paymentMethod.Name = req.Name
+	paymentMethod.Instructions = req.Instructions
 	if req.IsActive != nil {
 		paymentMethod.IsActive = *req.IsActive
 	}
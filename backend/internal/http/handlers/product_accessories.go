@@ -0,0 +1,210 @@
+package handlers
+
+import (
+	"iafarma/internal/repo"
+	"iafarma/internal/utils"
+	"iafarma/pkg/models"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+// ProductAccessoryHandler handles the curated accessories suggested with each product
+type ProductAccessoryHandler struct {
+	accessoryRepo *repo.ProductAccessoryRepository
+}
+
+// NewProductAccessoryHandler creates a new product accessory handler
+func NewProductAccessoryHandler(accessoryRepo *repo.ProductAccessoryRepository) *ProductAccessoryHandler {
+	return &ProductAccessoryHandler{accessoryRepo: accessoryRepo}
+}
+
+// GetAccessories godoc
+// @Summary Get product accessories
+// @Description Get the curated accessories for a product, in display order
+// @Tags product-accessories
+// @Accept json
+// @Produce json
+// @Param product_id path string true "Product ID"
+// @Success 200 {array} models.ProductAccessory
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /products/{product_id}/accessories [get]
+// @Security BearerAuth
+func (h *ProductAccessoryHandler) GetAccessories(c echo.Context) error {
+	productID, err := uuid.Parse(c.Param("product_id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid product ID"})
+	}
+
+	tenantID := c.Get("tenant_id").(uuid.UUID)
+
+	accessories, err := h.accessoryRepo.GetByProductID(tenantID, productID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to get accessories"})
+	}
+
+	return c.JSON(http.StatusOK, accessories)
+}
+
+// CreateAccessory godoc
+// @Summary Create product accessory
+// @Description Link an accessory to a product ("com essa impressora, leve o cartucho X")
+// @Tags product-accessories
+// @Accept json
+// @Produce json
+// @Param product_id path string true "Product ID"
+// @Param accessory body models.ProductAccessory true "Accessory data"
+// @Success 201 {object} models.ProductAccessory
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /products/{product_id}/accessories [post]
+// @Security BearerAuth
+func (h *ProductAccessoryHandler) CreateAccessory(c echo.Context) error {
+	productID, err := uuid.Parse(c.Param("product_id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid product ID"})
+	}
+
+	tenantID := c.Get("tenant_id").(uuid.UUID)
+
+	var accessory models.ProductAccessory
+	if err := c.Bind(&accessory); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request body"})
+	}
+
+	// Validate required fields
+	if err := c.Validate(&accessory); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	if status, message := h.validateRelationship(tenantID, productID, accessory.AccessoryID); status != 0 {
+		return c.JSON(status, map[string]string{"error": message})
+	}
+
+	exists, err := h.accessoryRepo.Exists(tenantID, productID, accessory.AccessoryID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to check accessory"})
+	}
+	if exists {
+		return c.JSON(http.StatusConflict, map[string]string{"error": "Accessory already linked to this product"})
+	}
+
+	// Set the tenant ID and product ID
+	accessory.ID = uuid.Nil
+	accessory.TenantID = tenantID
+	accessory.ProductID = productID
+	accessory.Accessory = nil
+
+	if err := h.accessoryRepo.Create(&accessory); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to create accessory"})
+	}
+
+	created, err := h.accessoryRepo.GetByID(tenantID, accessory.ID)
+	if err != nil {
+		return c.JSON(http.StatusCreated, accessory)
+	}
+	return c.JSON(http.StatusCreated, created)
+}
+
+// UpdateAccessory godoc
+// @Summary Update product accessory
+// @Description Update the note or display order of an accessory
+// @Tags product-accessories
+// @Accept json
+// @Produce json
+// @Param product_id path string true "Product ID"
+// @Param id path string true "Accessory relationship ID"
+// @Param accessory body models.ProductAccessory true "Accessory data"
+// @Success 200 {object} models.ProductAccessory
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /products/{product_id}/accessories/{id} [put]
+// @Security BearerAuth
+func (h *ProductAccessoryHandler) UpdateAccessory(c echo.Context) error {
+	accessoryID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid accessory ID"})
+	}
+
+	tenantID := c.Get("tenant_id").(uuid.UUID)
+
+	existing, err := h.accessoryRepo.GetByID(tenantID, accessoryID)
+	if err != nil || existing.ProductID.String() != c.Param("product_id") {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "Accessory not found"})
+	}
+
+	var input models.ProductAccessory
+	if err := c.Bind(&input); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request body"})
+	}
+
+	// The linked products stay the same; to swap the accessory delete and create the relationship again
+	existing.Note = input.Note
+	existing.SortOrder = input.SortOrder
+
+	if err := h.accessoryRepo.Update(existing); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to update accessory"})
+	}
+
+	return c.JSON(http.StatusOK, existing)
+}
+
+// DeleteAccessory godoc
+// @Summary Delete product accessory
+// @Description Remove an accessory from a product
+// @Tags product-accessories
+// @Accept json
+// @Produce json
+// @Param product_id path string true "Product ID"
+// @Param id path string true "Accessory relationship ID"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /products/{product_id}/accessories/{id} [delete]
+// @Security BearerAuth
+func (h *ProductAccessoryHandler) DeleteAccessory(c echo.Context) error {
+	accessoryID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid accessory ID"})
+	}
+
+	tenantID := c.Get("tenant_id").(uuid.UUID)
+
+	existing, err := h.accessoryRepo.GetByID(tenantID, accessoryID)
+	if err != nil || existing.ProductID.String() != c.Param("product_id") {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "Accessory not found"})
+	}
+
+	if err := h.accessoryRepo.Delete(tenantID, accessoryID); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to delete accessory"})
+	}
+
+	return c.JSON(http.StatusOK, map[string]string{"message": "Accessory deleted successfully"})
+}
+
+// validateRelationship checks that both products belong to the tenant and are different.
+// Returns the HTTP status and message of the failure, or 0 when the relationship is valid.
+func (h *ProductAccessoryHandler) validateRelationship(tenantID, productID, accessoryID uuid.UUID) (int, string) {
+	if accessoryID == uuid.Nil {
+		return http.StatusBadRequest, "Accessory product is required"
+	}
+	if accessoryID == productID {
+		return http.StatusBadRequest, "A product cannot be its own accessory"
+	}
+
+	// SECURITY: Validate both products belong to tenant
+	db := h.accessoryRepo.GetDB()
+	if err := utils.ValidateProductBelongsToTenant(db, tenantID, productID); err != nil {
+		return http.StatusForbidden, "Product access denied"
+	}
+	if err := utils.ValidateProductBelongsToTenant(db, tenantID, accessoryID); err != nil {
+		return http.StatusForbidden, "Accessory product access denied"
+	}
+	return 0, ""
+}
@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/rs/zerolog/log"
+)
+
+// maxBulkAvailabilityProducts caps how many products can be toggled in a single request
+const maxBulkAvailabilityProducts = 500
+
+// BulkAvailabilityRequest pauses or resumes many products at once (e.g. "acabou o tomate hoje")
+type BulkAvailabilityRequest struct {
+	ProductIDs []uuid.UUID `json:"product_ids"`
+	Available  *bool       `json:"available"`
+}
+
+// BulkAvailabilityResponse reports how many products had their availability changed
+type BulkAvailabilityResponse struct {
+	Updated   int64 `json:"updated"`
+	Available bool  `json:"available"`
+}
+
+// validateBulkAvailabilityRequest checks the request and returns the unique product IDs to toggle
+func validateBulkAvailabilityRequest(req BulkAvailabilityRequest) ([]uuid.UUID, error) {
+	if req.Available == nil {
+		return nil, errors.New("available is required")
+	}
+
+	seen := make(map[uuid.UUID]bool, len(req.ProductIDs))
+	ids := make([]uuid.UUID, 0, len(req.ProductIDs))
+	for _, id := range req.ProductIDs {
+		if id == uuid.Nil || seen[id] {
+			continue
+		}
+		seen[id] = true
+		ids = append(ids, id)
+	}
+
+	if len(ids) == 0 {
+		return nil, errors.New("product_ids must contain at least one product")
+	}
+	if len(ids) > maxBulkAvailabilityProducts {
+		return nil, fmt.Errorf("at most %d products can be updated at once", maxBulkAvailabilityProducts)
+	}
+	return ids, nil
+}
+
+// BulkAvailability godoc
+// @Summary Bulk toggle product availability
+// @Description Pause or resume many products at once. Unavailable products are hidden from AI searches and cannot be added to the cart
+// @Tags products
+// @Accept json
+// @Produce json
+// @Param request body BulkAvailabilityRequest true "Products and new availability"
+// @Success 200 {object} BulkAvailabilityResponse
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /products/bulk-availability [post]
+// @Security BearerAuth
+func (h *ProductHandler) BulkAvailability(c echo.Context) error {
+	tenantID, ok := c.Get("tenant_id").(uuid.UUID)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "Invalid tenant"})
+	}
+
+	var req BulkAvailabilityRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request"})
+	}
+
+	ids, err := validateBulkAvailabilityRequest(req)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	updated, err := h.productRepo.SetAvailability(tenantID, ids, *req.Available)
+	if err != nil {
+		log.Error().Err(err).Str("tenant_id", tenantID.String()).Msg("Failed to update product availability")
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to update product availability"})
+	}
+
+	log.Info().
+		Str("tenant_id", tenantID.String()).
+		Int64("updated", updated).
+		Bool("available", *req.Available).
+		Msg("Product availability updated")
+
+	return c.JSON(http.StatusOK, BulkAvailabilityResponse{Updated: updated, Available: *req.Available})
+}
@@ -0,0 +1,37 @@
+package handlers
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestValidateBulkAvailabilityRequest(t *testing.T) {
+	available := false
+	first, second := uuid.New(), uuid.New()
+
+	ids, err := validateBulkAvailabilityRequest(BulkAvailabilityRequest{ProductIDs: []uuid.UUID{first, second, first, uuid.Nil}, Available: &available})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(ids) != 2 || ids[0] != first || ids[1] != second {
+		t.Errorf("expected unique product ids [%s %s], got %v", first, second, ids)
+	}
+
+	tooMany := make([]uuid.UUID, maxBulkAvailabilityProducts+1)
+	for i := range tooMany {
+		tooMany[i] = uuid.New()
+	}
+
+	invalid := map[string]BulkAvailabilityRequest{
+		"missing available": {ProductIDs: []uuid.UUID{first}},
+		"no products":       {Available: &available},
+		"only nil ids":      {ProductIDs: []uuid.UUID{uuid.Nil}, Available: &available},
+		"too many products": {ProductIDs: tooMany, Available: &available},
+	}
+	for name, req := range invalid {
+		if _, err := validateBulkAvailabilityRequest(req); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
@@ -0,0 +1,133 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"iafarma/internal/repo"
+	"iafarma/internal/utils"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/rs/zerolog/log"
+)
+
+// maxBulkCategorizeProducts caps how many product IDs can be categorized in a single request
+const maxBulkCategorizeProducts = 500
+
+// BulkCategorizeRequest assigns a category to many products at once, either by ID or by the same
+// search and filters used by the product list (e.g. every product with "dipirona" in the name)
+type BulkCategorizeRequest struct {
+	CategoryID uuid.UUID            `json:"category_id"`
+	ProductIDs []uuid.UUID          `json:"product_ids"`
+	Search     string               `json:"search"`
+	Filters    *repo.ProductFilters `json:"filters"`
+}
+
+// BulkCategorizeResponse reports how many products matched the selection and how many changed category
+type BulkCategorizeResponse struct {
+	Matched    int64     `json:"matched"`
+	Updated    int64     `json:"updated"`
+	CategoryID uuid.UUID `json:"category_id"`
+}
+
+// hasProductFilter reports whether the filters narrow the catalog at all
+func hasProductFilter(search string, filters repo.ProductFilters) bool {
+	return search != "" ||
+		(filters.CategoryID != nil && *filters.CategoryID != "") ||
+		(filters.Brand != nil && *filters.Brand != "") ||
+		(filters.MinPrice != nil && *filters.MinPrice > 0) ||
+		(filters.MaxPrice != nil && *filters.MaxPrice > 0) ||
+		(filters.HasPromotion != nil && *filters.HasPromotion) ||
+		(filters.HasSKU != nil && *filters.HasSKU) ||
+		(filters.HasStock != nil && *filters.HasStock) ||
+		(filters.OutOfStock != nil && *filters.OutOfStock) ||
+		filters.Available != nil
+}
+
+// validateBulkCategorizeRequest checks the request and returns the product selection to categorize.
+// An empty filter is rejected so a missing field can't re-categorize the whole catalog.
+func validateBulkCategorizeRequest(req BulkCategorizeRequest) (repo.ProductSelection, error) {
+	if req.CategoryID == uuid.Nil {
+		return repo.ProductSelection{}, errors.New("category_id is required")
+	}
+
+	if len(req.ProductIDs) == 0 {
+		var filters repo.ProductFilters
+		if req.Filters != nil {
+			filters = *req.Filters
+		}
+		if !hasProductFilter(req.Search, filters) {
+			return repo.ProductSelection{}, errors.New("product_ids or a search/filter is required")
+		}
+		return repo.ProductSelection{Search: req.Search, Filters: filters}, nil
+	}
+
+	seen := make(map[uuid.UUID]bool, len(req.ProductIDs))
+	ids := make([]uuid.UUID, 0, len(req.ProductIDs))
+	for _, id := range req.ProductIDs {
+		if id == uuid.Nil || seen[id] {
+			continue
+		}
+		seen[id] = true
+		ids = append(ids, id)
+	}
+
+	if len(ids) == 0 {
+		return repo.ProductSelection{}, errors.New("product_ids must contain at least one product")
+	}
+	if len(ids) > maxBulkCategorizeProducts {
+		return repo.ProductSelection{}, fmt.Errorf("at most %d products can be categorized at once", maxBulkCategorizeProducts)
+	}
+	return repo.ProductSelection{IDs: ids}, nil
+}
+
+// BulkCategorize godoc
+// @Summary Bulk assign products to a category
+// @Description Assign a category to many products at once, selected by ID or by search/filters, in a single transaction
+// @Tags products
+// @Accept json
+// @Produce json
+// @Param request body BulkCategorizeRequest true "Category and product selection"
+// @Success 200 {object} BulkCategorizeResponse
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /products/bulk-categorize [post]
+// @Security BearerAuth
+func (h *ProductHandler) BulkCategorize(c echo.Context) error {
+	tenantID, ok := c.Get("tenant_id").(uuid.UUID)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "Invalid tenant"})
+	}
+
+	var req BulkCategorizeRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request"})
+	}
+
+	selection, err := validateBulkCategorizeRequest(req)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	matched, updated, err := h.productRepo.AssignCategory(tenantID, req.CategoryID, selection)
+	if err != nil {
+		var tenantErr *utils.TenantValidationError
+		if errors.As(err, &tenantErr) {
+			return c.JSON(http.StatusNotFound, map[string]string{"error": "Category not found"})
+		}
+		log.Error().Err(err).Str("tenant_id", tenantID.String()).Msg("Failed to categorize products")
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to categorize products"})
+	}
+
+	log.Info().
+		Str("tenant_id", tenantID.String()).
+		Str("category_id", req.CategoryID.String()).
+		Int64("matched", matched).
+		Int64("updated", updated).
+		Msg("Products categorized")
+
+	return c.JSON(http.StatusOK, BulkCategorizeResponse{Matched: matched, Updated: updated, CategoryID: req.CategoryID})
+}
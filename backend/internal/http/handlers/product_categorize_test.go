@@ -0,0 +1,49 @@
+package handlers
+
+import (
+	"testing"
+
+	"iafarma/internal/repo"
+
+	"github.com/google/uuid"
+)
+
+func TestValidateBulkCategorizeRequest(t *testing.T) {
+	categoryID := uuid.New()
+	first, second := uuid.New(), uuid.New()
+
+	selection, err := validateBulkCategorizeRequest(BulkCategorizeRequest{CategoryID: categoryID, ProductIDs: []uuid.UUID{first, second, first, uuid.Nil}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(selection.IDs) != 2 || selection.IDs[0] != first || selection.IDs[1] != second {
+		t.Errorf("expected unique product ids [%s %s], got %v", first, second, selection.IDs)
+	}
+
+	brand := "Medley"
+	selection, err = validateBulkCategorizeRequest(BulkCategorizeRequest{CategoryID: categoryID, Search: "dipirona", Filters: &repo.ProductFilters{Brand: &brand}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(selection.IDs) != 0 || selection.Search != "dipirona" || selection.Filters.Brand == nil || *selection.Filters.Brand != brand {
+		t.Errorf("expected filter selection, got %+v", selection)
+	}
+
+	tooMany := make([]uuid.UUID, maxBulkCategorizeProducts+1)
+	for i := range tooMany {
+		tooMany[i] = uuid.New()
+	}
+
+	invalid := map[string]BulkCategorizeRequest{
+		"missing category":  {ProductIDs: []uuid.UUID{first}},
+		"no selection":      {CategoryID: categoryID},
+		"empty filter":      {CategoryID: categoryID, Filters: &repo.ProductFilters{}},
+		"only nil ids":      {CategoryID: categoryID, ProductIDs: []uuid.UUID{uuid.Nil}},
+		"too many products": {CategoryID: categoryID, ProductIDs: tooMany},
+	}
+	for name, req := range invalid {
+		if _, err := validateBulkCategorizeRequest(req); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
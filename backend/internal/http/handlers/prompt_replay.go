@@ -0,0 +1,175 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"os"
+
+	"iafarma/internal/ai"
+	"iafarma/pkg/models"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+)
+
+type PromptReplayHandler struct {
+	db        *gorm.DB
+	aiService *ai.AIService
+}
+
+func NewPromptReplayHandler(db *gorm.DB) *PromptReplayHandler {
+	return &PromptReplayHandler{db: db, aiService: ai.NewPromptReplayService(db, os.Getenv("OPENAI_API_KEY"))}
+}
+
+// PromptSamplesRequest replaces the tenant's saved sample messages
+type PromptSamplesRequest struct {
+	Messages []string `json:"messages"`
+}
+
+// PromptSamplesResponse lists the tenant's saved sample messages
+type PromptSamplesResponse struct {
+	TenantID    uuid.UUID `json:"tenant_id"`
+	Messages    []string  `json:"messages"`
+	MaxMessages int       `json:"max_messages"`
+}
+
+// PromptReplayRequest is the proposed prompt template and, optionally, the messages to replay
+type PromptReplayRequest struct {
+	PromptTemplate string `json:"prompt_template"`
+	// Messages overrides the saved sample messages for this replay
+	Messages []string `json:"messages"`
+}
+
+// PromptReplayResponse compares the current and the proposed prompt for each sample message
+type PromptReplayResponse struct {
+	TenantID uuid.UUID                   `json:"tenant_id"`
+	Results  []ai.PromptReplayComparison `json:"results"`
+}
+
+// loadTenant answers 404/500 when the tenant can't be loaded; ok=false means the response was already written
+func (h *PromptReplayHandler) loadTenant(c echo.Context) (uuid.UUID, bool, error) {
+	tenantID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return uuid.Nil, false, c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid tenant ID"})
+	}
+
+	if err := h.db.Select("id").First(&models.Tenant{}, "id = ?", tenantID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return uuid.Nil, false, c.JSON(http.StatusNotFound, map[string]string{"error": "Tenant not found"})
+		}
+		return uuid.Nil, false, c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to load tenant"})
+	}
+	return tenantID, true, nil
+}
+
+// GetPromptSamples lists the sample messages used to compare prompt templates
+// @Summary List prompt sample messages
+// @Description Lists the tenant's saved sample messages used to replay prompt template changes
+// @Tags admin
+// @Produce json
+// @Param id path string true "Tenant ID"
+// @Success 200 {object} PromptSamplesResponse
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /admin/tenants/{id}/prompt-samples [get]
+// @Security BearerAuth
+func (h *PromptReplayHandler) GetPromptSamples(c echo.Context) error {
+	tenantID, ok, err := h.loadTenant(c)
+	if !ok {
+		return err
+	}
+
+	samples, err := h.aiService.GetPromptSamples(c.Request().Context(), tenantID)
+	if err != nil {
+		log.Error().Err(err).Str("tenant_id", tenantID.String()).Msg("Failed to load prompt sample messages")
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to load sample messages"})
+	}
+
+	return c.JSON(http.StatusOK, PromptSamplesResponse{TenantID: tenantID, Messages: samples, MaxMessages: ai.MaxPromptSamples})
+}
+
+// UpdatePromptSamples replaces the sample messages used to compare prompt templates
+// @Summary Replace prompt sample messages
+// @Description Saves the tenant's sample messages (up to 10) used to replay prompt template changes
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param id path string true "Tenant ID"
+// @Param request body PromptSamplesRequest true "Sample messages"
+// @Success 200 {object} PromptSamplesResponse
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /admin/tenants/{id}/prompt-samples [put]
+// @Security BearerAuth
+func (h *PromptReplayHandler) UpdatePromptSamples(c echo.Context) error {
+	tenantID, ok, err := h.loadTenant(c)
+	if !ok {
+		return err
+	}
+
+	var req PromptSamplesRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request format"})
+	}
+	if _, err := ai.NormalizePromptSamples(req.Messages); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	samples, err := h.aiService.SavePromptSamples(c.Request().Context(), tenantID, req.Messages)
+	if err != nil {
+		log.Error().Err(err).Str("tenant_id", tenantID.String()).Msg("Failed to save prompt sample messages")
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to save sample messages"})
+	}
+
+	return c.JSON(http.StatusOK, PromptSamplesResponse{TenantID: tenantID, Messages: samples, MaxMessages: ai.MaxPromptSamples})
+}
+
+// ReplayPromptChange runs the sample messages through the current and the proposed prompt template
+// @Summary Compare a prompt template change
+// @Description Dry-run: runs the sample messages through the current and the proposed ai_system_prompt_template and returns the responses and tool choices side by side. Tools are never executed and the proposed template is not saved.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param id path string true "Tenant ID"
+// @Param request body PromptReplayRequest true "Proposed prompt template"
+// @Success 200 {object} PromptReplayResponse
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /admin/tenants/{id}/prompt-replay [post]
+// @Security BearerAuth
+func (h *PromptReplayHandler) ReplayPromptChange(c echo.Context) error {
+	tenantID, ok, err := h.loadTenant(c)
+	if !ok {
+		return err
+	}
+
+	var req PromptReplayRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request format"})
+	}
+
+	if os.Getenv("OPENAI_API_KEY") == "" {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "AI service not configured"})
+	}
+
+	ctx := c.Request().Context()
+	samples := req.Messages
+	if len(samples) == 0 {
+		if samples, err = h.aiService.GetPromptSamples(ctx, tenantID); err != nil {
+			log.Error().Err(err).Str("tenant_id", tenantID.String()).Msg("Failed to load prompt sample messages")
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to load sample messages"})
+		}
+	}
+
+	results, err := h.aiService.ReplayPromptChange(ctx, tenantID, req.PromptTemplate, samples)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	return c.JSON(http.StatusOK, PromptReplayResponse{TenantID: tenantID, Results: results})
+}
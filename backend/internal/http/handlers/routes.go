@@ -49,6 +49,24 @@ func SetupRoutes(api *echo.Group, services *app.Services) {
 	admin.GET("/tenants/:id/stats", tenantHandler.GetTenantStats)
 	admin.GET("/stats", tenantHandler.GetSystemStats)
 
+	// Per-tenant AI conversation metrics for super admin
+	aiMetricsHandler := NewAIMetricsHandler(services.DB)
+	admin.GET("/tenants/:id/ai-metrics", aiMetricsHandler.GetTenantAIMetrics)
+
+	// Products customers asked for that the tenant doesn't carry
+	missingDemandHandler := NewMissingDemandHandler(services.DB)
+	admin.GET("/tenants/:id/missing-demand", missingDemandHandler.GetTenantMissingDemand)
+
+	// Preview of how the AI formats the tenant catalog
+	catalogPreviewHandler := NewCatalogPreviewHandler(services.DB)
+	admin.GET("/tenants/:id/catalog-preview", catalogPreviewHandler.GetTenantCatalogPreview)
+
+	// Dry-run comparison of prompt template changes against saved sample messages
+	promptReplayHandler := NewPromptReplayHandler(services.DB)
+	admin.GET("/tenants/:id/prompt-samples", promptReplayHandler.GetPromptSamples)
+	admin.PUT("/tenants/:id/prompt-samples", promptReplayHandler.UpdatePromptSamples)
+	admin.POST("/tenants/:id/prompt-replay", promptReplayHandler.ReplayPromptChange)
+
 	// Channel management for super admin
 	adminChannelHandler := NewAdminChannelHandler(services.ChannelRepo, services.PlanLimitService)
 	admin.GET("/tenants/:tenant_id/channels", adminChannelHandler.ListByTenant)
@@ -145,6 +163,8 @@ func SetupRoutes(api *echo.Group, services *app.Services) {
 	products.POST("/import-image", productHandler.ImportProductsFromImage)
 	products.GET("/import/template", productHandler.GetImportTemplate)
 	products.GET("/search", productHandler.Search) // Endpoint de busca semântica
+	products.POST("/bulk-availability", productHandler.BulkAvailability)
+	products.POST("/bulk-categorize", productHandler.BulkCategorize)
 
 	// Product Images
 	products.POST("/:id/upload-image", productHandler.UploadProductImage)
@@ -161,6 +181,13 @@ func SetupRoutes(api *echo.Group, services *app.Services) {
 	products.PUT("/:product_id/characteristics/:id", characteristicHandler.UpdateCharacteristic)
 	products.DELETE("/:product_id/characteristics/:id", characteristicHandler.DeleteCharacteristic)
 
+	// Product Accessories (curated "leve junto" suggestions used by the AI)
+	accessoryHandler := NewProductAccessoryHandler(repo.NewProductAccessoryRepository(services.DB))
+	products.GET("/:product_id/accessories", accessoryHandler.GetAccessories)
+	products.POST("/:product_id/accessories", accessoryHandler.CreateAccessory)
+	products.PUT("/:product_id/accessories/:id", accessoryHandler.UpdateAccessory)
+	products.DELETE("/:product_id/accessories/:id", accessoryHandler.DeleteAccessory)
+
 	// Characteristic Items
 	characteristics := tenant.Group("/characteristics")
 	characteristics.GET("/:characteristic_id/items", characteristicHandler.GetCharacteristicItems)
@@ -213,13 +240,13 @@ func SetupRoutes(api *echo.Group, services *app.Services) {
 	onboarding.POST("/dismiss", onboardingHandler.DismissOnboarding)
 
 	// Customers
-	customerHandler := NewCustomerHandler(services.CustomerRepo)
+	customerHandler := NewCustomerHandler(services.CustomerRepo, services.EmbeddingService, services.StorageService)
 	customers := tenant.Group("/customers")
 	customers.GET("", customerHandler.List)
 	customers.POST("", customerHandler.Create)
 	customers.GET("/:id", customerHandler.GetByID)
 	customers.PUT("/:id", customerHandler.Update)
-	// customers.DELETE("/:id", customerHandler.Delete) // TODO: Implement Delete method
+	customers.DELETE("/:id", customerHandler.Delete)
 
 	// Addresses
 	addressHandler := NewAddressHandler(services.AddressRepo, services.CustomerRepo)
@@ -288,6 +315,24 @@ func SetupRoutes(api *echo.Group, services *app.Services) {
 	templates.PUT("/:id", templateHandler.Update)
 	templates.DELETE("/:id", templateHandler.Delete)
 
+	// FAQ (perguntas frequentes respondidas pela IA)
+	faqHandler := NewFAQHandler(services.FAQRepo)
+	faqs := tenant.Group("/faqs")
+	faqs.GET("", faqHandler.List)
+	faqs.POST("", faqHandler.Create)
+	faqs.GET("/:id", faqHandler.GetByID)
+	faqs.PUT("/:id", faqHandler.Update)
+	faqs.DELETE("/:id", faqHandler.Delete)
+
+	// Ferramentas próprias do tenant (chamadas pela IA via webhook)
+	customToolHandler := NewCustomToolHandler(services.CustomToolRepo)
+	customTools := tenant.Group("/custom-tools", middleware.RequireTenantAdminOnly())
+	customTools.GET("", customToolHandler.List)
+	customTools.POST("", customToolHandler.Create)
+	customTools.GET("/:id", customToolHandler.GetByID)
+	customTools.PUT("/:id", customToolHandler.Update)
+	customTools.DELETE("/:id", customToolHandler.Delete)
+
 	// Dashboard
 	dashboardHandler := NewDashboardHandler(services.MessageRepo, services.DB)
 	dashboard := tenant.Group("/dashboard")
@@ -332,12 +377,18 @@ func SetupRoutes(api *echo.Group, services *app.Services) {
 	delivery.POST("/zones", deliveryHandler.ManageDeliveryZone)
 	delivery.POST("/validate", deliveryHandler.ValidateDeliveryAddress)
 
+	// Delivery validation simulator for support (system admin, any tenant)
+	admin.POST("/delivery/validate", deliveryHandler.AdminValidateDeliveryAddress)
+
 	// Conversations (standalone route)
 	conversations.GET("", whatsappHandler.ListConversations)
 	conversations.POST("/customer/:customerId", whatsappHandler.CreateOrFindConversationByCustomer)
 	conversations.POST("/:id/archive", whatsappHandler.ArchiveConversation)
 	conversations.POST("/:id/pin", whatsappHandler.PinConversation)
 	conversations.POST("/:id/toggle-ai", whatsappHandler.ToggleAIConversation)
+	conversations.POST("/:id/pause", whatsappHandler.PauseBot)
+	conversations.POST("/:id/resume", whatsappHandler.ResumeBot)
+	conversations.POST("/:id/business-initiated", whatsappHandler.MarkBusinessInitiated)
 
 	// WhatsApp endpoints
 	whatsapp := tenant.Group("/whatsapp")
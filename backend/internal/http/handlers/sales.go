@@ -9,6 +9,7 @@ import (
 	"io"
 	"log"
 	"net/http"
+	"net/url"
 	"os"
 	"regexp"
 	"strconv"
@@ -18,6 +19,7 @@ import (
 	"iafarma/internal/ai"
 	"iafarma/internal/repo"
 	"iafarma/internal/services"
+	"iafarma/internal/utils"
 	"iafarma/internal/zapplus"
 	"iafarma/pkg/models"
 
@@ -37,10 +39,9 @@ func generateOrderNumber() string {
 	)
 }
 
-// cleanPhoneNumber removes all non-numeric characters from phone number
+// cleanPhoneNumber removes formatting and normalizes Brazilian numbers to 55 + DDD + number
 func cleanPhoneNumber(phone string) string {
-	reg := regexp.MustCompile(`[^0-9]`)
-	return reg.ReplaceAllString(phone, "")
+	return utils.NormalizePhone(phone)
 }
 
 // generateUniqueSKU generates a unique SKU based on product name
@@ -116,16 +117,104 @@ func NewProductHandler(productRepo *repo.ProductRepository, categoryRepo *repo.C
 	}
 }
 
+// maxProductListLimit caps the page size of the product listing
+const maxProductListLimit = 100
+
+// productListQuery holds the parsed query parameters of the product listing
+type productListQuery struct {
+	Limit   int
+	Offset  int
+	Search  string
+	Sort    string
+	Filters repo.ProductFilters
+}
+
+// parseProductListQuery parses pagination, search, filters and sorting of GET /products.
+// in_stock filters by stock only when given: true (in stock) or false (out of stock); empty or all lists every product.
+func parseProductListQuery(params url.Values) (*productListQuery, error) {
+	query := &productListQuery{Limit: 20}
+
+	if limit, err := strconv.Atoi(params.Get("limit")); err == nil && limit > 0 {
+		query.Limit = limit
+	}
+	if query.Limit > maxProductListLimit {
+		query.Limit = maxProductListLimit
+	}
+
+	if page, err := strconv.Atoi(params.Get("page")); err == nil && page > 0 {
+		query.Offset = (page - 1) * query.Limit
+	} else if offset, err := strconv.Atoi(params.Get("offset")); err == nil && offset > 0 {
+		query.Offset = offset
+	}
+
+	// q is the preferred name; search is kept for compatibility
+	query.Search = strings.TrimSpace(params.Get("q"))
+	if query.Search == "" {
+		query.Search = strings.TrimSpace(params.Get("search"))
+	}
+
+	category := strings.TrimSpace(params.Get("category"))
+	if category == "" {
+		category = strings.TrimSpace(params.Get("category_id"))
+	}
+	if category != "" {
+		if _, err := uuid.Parse(category); err != nil {
+			return nil, fmt.Errorf("invalid category: %s", category)
+		}
+		query.Filters.CategoryID = &category
+	}
+
+	if brand := strings.TrimSpace(params.Get("brand")); brand != "" {
+		query.Filters.Brand = &brand
+	}
+
+	switch inStock := strings.ToLower(strings.TrimSpace(params.Get("in_stock"))); inStock {
+	case "true":
+		hasStock := true
+		query.Filters.HasStock = &hasStock
+	case "false":
+		outOfStock := true
+		query.Filters.OutOfStock = &outOfStock
+	case "", "all":
+	default:
+		return nil, fmt.Errorf("invalid in_stock value: %s (use true, false or all)", inStock)
+	}
+
+	switch available := strings.ToLower(strings.TrimSpace(params.Get("available"))); available {
+	case "":
+	case "true", "false":
+		value := available == "true"
+		query.Filters.Available = &value
+	default:
+		return nil, fmt.Errorf("invalid available value: %s (use true or false)", available)
+	}
+
+	query.Sort = strings.ToLower(strings.TrimSpace(params.Get("sort")))
+	if _, ok := repo.ProductOrderClause(query.Sort); !ok {
+		return nil, fmt.Errorf("invalid sort option: %s", query.Sort)
+	}
+
+	return query, nil
+}
+
 // List godoc
 // @Summary List products
-// @Description Get list of products with pagination and search
+// @Description Get paginated list of products with search, filters and sorting (only products in stock by default)
 // @Tags products
 // @Accept json
 // @Produce json
-// @Param limit query int false "Limit" default(20)
+// @Param page query int false "Page (takes precedence over offset)" default(1)
+// @Param limit query int false "Limit (max 100)" default(20)
 // @Param offset query int false "Offset" default(0)
-// @Param search query string false "Search term for name, SKU, or description"
+// @Param q query string false "Search term for name, SKU, or description"
+// @Param search query string false "Search term (deprecated, use q)"
+// @Param category query string false "Category ID"
+// @Param brand query string false "Brand"
+// @Param in_stock query string false "Stock status: true, false or all (default)"
+// @Param available query string false "Availability: true or false (default: both)"
+// @Param sort query string false "Sort: name_asc (default), name_desc, price_asc, price_desc, stock_asc, stock_desc, newest, oldest"
 // @Success 200 {object} models.ProductListResponse
+// @Failure 400 {object} map[string]string
 // @Failure 500 {object} map[string]string
 // @Router /products [get]
 // @Security BearerAuth
@@ -136,15 +225,12 @@ func (h *ProductHandler) List(c echo.Context) error {
 		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "Invalid tenant"})
 	}
 
-	limit, _ := strconv.Atoi(c.QueryParam("limit"))
-	offset, _ := strconv.Atoi(c.QueryParam("offset"))
-	search := strings.TrimSpace(c.QueryParam("search"))
-
-	if limit <= 0 {
-		limit = 20
+	query, err := parseProductListQuery(c.QueryParams())
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
 	}
 
-	result, err := h.productRepo.ListWithSearch(tenantID, limit, offset, search)
+	result, err := h.productRepo.ListPaginated(tenantID, query.Limit, query.Offset, query.Search, query.Filters, query.Sort)
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
 	}
@@ -240,9 +326,14 @@ func (h *ProductHandler) ListAdmin(c echo.Context) error {
 		filters.OutOfStock = &outOfStock
 	}
 
+	if brand := strings.TrimSpace(c.QueryParam("brand")); brand != "" {
+		filters.Brand = &brand
+	}
+
 	// Check if any filters are applied, if yes use the new method
 	hasFilters := filters.CategoryID != nil || filters.MinPrice != nil || filters.MaxPrice != nil ||
-		filters.HasPromotion != nil || filters.HasSKU != nil || filters.HasStock != nil || filters.OutOfStock != nil
+		filters.HasPromotion != nil || filters.HasSKU != nil || filters.HasStock != nil || filters.OutOfStock != nil ||
+		filters.Brand != nil
 
 	var result *repo.PaginationResult[models.Product]
 	var err error
@@ -980,6 +1071,7 @@ func (h *ProductHandler) Update(c echo.Context) error {
 	updatedProduct.CreatedAt = existingProduct.CreatedAt
 	updatedProduct.TenantID = existingProduct.TenantID
 	updatedProduct.EmbeddingHash = existingProduct.EmbeddingHash // Preserve existing hash for cache comparison
+	updatedProduct.Available = existingProduct.Available         // Changed only via POST /products/bulk-availability
 
 	// Only update if fields are actually provided (not empty)
 	if updatedProduct.Name == "" {
@@ -1138,12 +1230,38 @@ func (h *ProductHandler) updateProductEmbeddingHash(productID uuid.UUID, hash st
 
 // CustomerHandler handles customer operations
 type CustomerHandler struct {
-	customerRepo *repo.CustomerRepository
+	customerRepo     *repo.CustomerRepository
+	embeddingService *services.EmbeddingService
+	storageService   *services.StorageService
 }
 
 // NewCustomerHandler creates a new customer handler
-func NewCustomerHandler(customerRepo *repo.CustomerRepository) *CustomerHandler {
-	return &CustomerHandler{customerRepo: customerRepo}
+func NewCustomerHandler(customerRepo *repo.CustomerRepository, embeddingService *services.EmbeddingService, storageService *services.StorageService) *CustomerHandler {
+	return &CustomerHandler{customerRepo: customerRepo, embeddingService: embeddingService, storageService: storageService}
+}
+
+// erasedFileStore is the subset of the storage service used to delete files of an erased customer (mocked in tests)
+type erasedFileStore interface {
+	KeyFromURL(fileURL string) (string, bool)
+	DeleteFile(s3Key string) error
+}
+
+// deleteErasedPrescriptions removes the prescription images of an erased customer from storage, recording
+// what could not be removed as report warnings
+func deleteErasedPrescriptions(store erasedFileStore, report *repo.CustomerErasureReport) {
+	for _, fileURL := range report.PrescriptionURLs {
+		key, ok := store.KeyFromURL(fileURL)
+		if !ok {
+			report.Warnings = append(report.Warnings, "prescription image outside the storage bucket was not removed")
+			continue
+		}
+		if err := store.DeleteFile(key); err != nil {
+			log.Printf("⚠️ Could not delete prescription image %s: %v", key, err)
+			report.Warnings = append(report.Warnings, "a prescription image could not be removed from storage")
+			continue
+		}
+		report.PrescriptionsDeleted++
+	}
 }
 
 // List godoc
@@ -1283,6 +1401,14 @@ func (h *CustomerHandler) Create(c echo.Context) error {
 		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Validation failed: " + err.Error()})
 	}
 
+	// Avoid duplicates when the same number is typed in a different format
+	if existing, err := h.customerRepo.GetByPhone(tenantID, customer.Phone); err == nil {
+		return c.JSON(http.StatusConflict, map[string]interface{}{
+			"error":       "Customer with this phone already exists",
+			"customer_id": existing.ID,
+		})
+	}
+
 	if err := h.customerRepo.Create(&customer); err != nil {
 		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
 	}
@@ -1377,6 +1503,82 @@ func (h *CustomerHandler) Update(c echo.Context) error {
 	return c.JSON(http.StatusOK, customer)
 }
 
+// Delete godoc
+// @Summary Delete customer (LGPD erasure)
+// @Description Remove or anonymize a customer and all associated data (addresses, carts, subscriptions, prescription images, AI memory, RAG conversations). Orders are anonymized and kept for accounting. Messages are only removed when delete_messages=true.
+// @Tags customers
+// @Produce json
+// @Param id path string true "Customer ID"
+// @Param delete_messages query bool false "Also delete conversations and messages" default(false)
+// @Success 200 {object} repo.CustomerErasureReport
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /customers/{id} [delete]
+// @Security BearerAuth
+func (h *CustomerHandler) Delete(c echo.Context) error {
+	// Get tenant ID from context
+	tenantID, ok := c.Get("tenant_id").(uuid.UUID)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "Invalid tenant"})
+	}
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid ID format"})
+	}
+
+	deleteMessages, _ := strconv.ParseBool(c.QueryParam("delete_messages"))
+
+	// Load the customer first: the phone is needed to clean AI data after the erasure wipes it
+	customer, err := h.customerRepo.GetByID(tenantID, id)
+	if err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "Customer not found"})
+	}
+
+	report, err := h.customerRepo.Erase(tenantID, id, deleteMessages)
+	if err != nil {
+		log.Printf("❌ Failed to erase customer %s: %v", id, err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to erase customer data"})
+	}
+
+	// In-memory AI conversation context
+	if customer.Phone != "" {
+		ai.GetGlobalMemoryManager().ClearMemory(tenantID, customer.Phone)
+	}
+
+	// RAG conversation entries live outside the database transaction (Qdrant)
+	report.RAGCollectionsDeleted = []string{}
+	if h.embeddingService != nil {
+		ragKeys := []string{id.String()}
+		if customer.Phone != "" {
+			ragKeys = append(ragKeys, customer.Phone)
+		}
+		for _, key := range ragKeys {
+			if err := h.embeddingService.DeleteCustomerConversations(tenantID.String(), key); err != nil {
+				log.Printf("⚠️ Could not delete RAG conversations for customer %s: %v", id, err)
+				continue
+			}
+			report.RAGCollectionsDeleted = append(report.RAGCollectionsDeleted, h.embeddingService.GetConversationCollectionName(tenantID.String(), key))
+		}
+	} else {
+		report.Warnings = append(report.Warnings, "embedding service unavailable: RAG conversations were not removed")
+	}
+
+	// Prescription images live in object storage, outside the database transaction
+	if len(report.PrescriptionURLs) > 0 {
+		if h.storageService != nil {
+			deleteErasedPrescriptions(h.storageService, report)
+		} else {
+			report.Warnings = append(report.Warnings, "storage service unavailable: prescription images were not removed")
+		}
+	}
+
+	log.Printf("🗑️ Customer %s erased (tenant %s, delete_messages=%t)", id, tenantID, deleteMessages)
+
+	return c.JSON(http.StatusOK, report)
+}
+
 // OrderHandler handles order operations
 type OrderHandler struct {
 	orderRepo    *repo.OrderRepository
@@ -1599,6 +1801,7 @@ func (h *OrderHandler) GetByID(c echo.Context) error {
 // @Param order body models.Order true "Order data"
 // @Success 201 {object} models.Order
 // @Failure 400 {object} map[string]string
+// @Failure 409 {object} map[string]string
 // @Failure 500 {object} map[string]string
 // @Router /orders [post]
 // @Security BearerAuth
@@ -1618,8 +1821,10 @@ func (h *OrderHandler) Create(c echo.Context) error {
 	order.TenantID = tenantID
 
 	// Generate order number if not provided
-	if order.OrderNumber == "" {
-		order.OrderNumber = generateOrderNumber()
+	nextNumber := func() string { return ai.NextOrderNumber(h.db, tenantID, time.Now(), generateOrderNumber) }
+	generatedNumber := order.OrderNumber == ""
+	if generatedNumber {
+		order.OrderNumber = nextNumber()
 	}
 
 	// Populate historical data for customer
@@ -1662,7 +1867,17 @@ func (h *OrderHandler) Create(c echo.Context) error {
 	// Clean numeric fields - replace empty strings with "0" for numeric fields
 	h.cleanOrderNumericFields(&order)
 
-	if err := h.orderRepo.Create(&order); err != nil {
+	// A generated number taken meanwhile by a concurrent checkout is replaced; a number given by the client is kept
+	create := h.orderRepo.Create
+	if generatedNumber {
+		create = func(order *models.Order) error {
+			return ai.CreateWithUniqueOrderNumber(order, nextNumber, h.orderRepo.Create)
+		}
+	}
+	if err := create(&order); err != nil {
+		if ai.IsOrderNumberConflict(err) {
+			return c.JSON(http.StatusConflict, map[string]string{"error": "Order number already exists"})
+		}
 		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
 	}
 
@@ -1786,6 +2001,21 @@ func (h *OrderHandler) Update(c echo.Context) error {
 		order.Notes = updateData.Notes
 	}
 
+	// Tracking details filled in by operators when the order ships
+	if updateData.TrackingCode != "" {
+		order.TrackingCode = strings.TrimSpace(updateData.TrackingCode)
+	}
+	if updateData.TrackingURL != "" {
+		trackingURL := strings.TrimSpace(updateData.TrackingURL)
+		if !strings.HasPrefix(trackingURL, "https://") && !strings.HasPrefix(trackingURL, "http://") {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "tracking_url must be an http(s) link"})
+		}
+		order.TrackingURL = trackingURL
+	}
+	if updateData.EstimatedDeliveryAt != nil {
+		order.EstimatedDeliveryAt = updateData.EstimatedDeliveryAt
+	}
+
 	// Critical: Never allow these fields to be changed through this endpoint
 	order.ID = existingOrder.ID
 	order.TenantID = existingOrder.TenantID
@@ -1816,21 +2046,21 @@ func (h *OrderHandler) Update(c echo.Context) error {
 		}
 	}
 
-	// Store original fulfillment status to check for shipping notification
-	originalFulfillmentStatus := existingOrder.FulfillmentStatus
+	// Stage the customer should be told about (confirmed, shipped, delivered), if the order moved forward
+	notifyStatus := zapplus.OrderStatusTransitionNotification(existingOrder, &order)
+
+	// Record when the order shipped or was delivered (used by the rastrearPedido tool)
+	stampOrderStatusTransition(&order, existingOrder, time.Now())
 
 	// Perform the update
 	if err := h.orderRepo.Update(&order); err != nil {
 		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
 	}
 
-	// 📨 Enviar notificação WhatsApp se o status mudou para "shipped"
-	log.Printf("🔍 Checking fulfillment status change: original='%s', new='%s'", originalFulfillmentStatus, order.FulfillmentStatus)
-	if originalFulfillmentStatus != "shipped" && order.FulfillmentStatus == "shipped" {
-		log.Printf("🚚 TRIGGERING SHIPPING NOTIFICATION for order %s", order.OrderNumber)
-		go h.sendShippingNotification(tenantID, &order)
-	} else {
-		log.Printf("⚠️ No shipping notification triggered - original='%s', new='%s'", originalFulfillmentStatus, order.FulfillmentStatus)
+	// 📨 Avisar o cliente por WhatsApp quando o pedido avança de etapa
+	if notifyStatus != "" {
+		log.Printf("📨 Triggering %s notification for order %s", notifyStatus, order.OrderNumber)
+		go h.sendOrderStatusNotification(tenantID, &order, notifyStatus)
 	}
 
 	return c.JSON(http.StatusOK, order)
@@ -1889,8 +2119,8 @@ func (h *ProductHandler) normalizePriceString(price string) string {
 		return "0"
 	}
 
-	// Format to ensure exactly 2 decimal places
-	return fmt.Sprintf("%.2f", priceFloat)
+	// Format to ensure exactly 2 decimal places, rounding half-up
+	return utils.FormatMoney(priceFloat)
 }
 
 // normalizeWeightString converts weight strings with comma to dot decimal separator
@@ -1946,14 +2176,12 @@ func (h *OrderHandler) cleanOrderNumericFields(order *models.Order) {
 
 // calculateOrderTotals calculates order totals based on items
 func (h *OrderHandler) calculateOrderTotals(order *models.Order) {
-	var subtotal float64 = 0
+	var subtotal utils.Cents
 
 	// Calculate subtotal from items
 	for i := range order.Items {
 		// Ensure item has calculated total
-		quantity := float64(order.Items[i].Quantity)
-		price := h.parsePrice(order.Items[i].Price)
-		itemTotal := quantity * price
+		itemTotal := h.parsePrice(order.Items[i].Price).Times(order.Items[i].Quantity)
 		order.Items[i].Total = h.formatPrice(itemTotal)
 
 		subtotal += itemTotal
@@ -1975,23 +2203,18 @@ func (h *OrderHandler) calculateOrderTotals(order *models.Order) {
 	order.TotalAmount = h.formatPrice(total)
 }
 
-// parsePrice safely parses a price string to float64
-func (h *OrderHandler) parsePrice(priceStr string) float64 {
-	if priceStr == "" {
-		return 0
-	}
-
-	// Simple parsing - assume the string is already in correct format (like "49.90")
-	var price float64
-	if _, err := fmt.Sscanf(priceStr, "%f", &price); err != nil {
+// parsePrice safely parses a price string (like "49.90") to cents, so totals don't accumulate float errors
+func (h *OrderHandler) parsePrice(priceStr string) utils.Cents {
+	price, ok := utils.ParseCents(priceStr)
+	if !ok {
 		return 0
 	}
 	return price
 }
 
-// formatPrice formats a float64 as a price string
-func (h *OrderHandler) formatPrice(price float64) string {
-	return fmt.Sprintf("%.2f", price)
+// formatPrice formats cents as a price string
+func (h *OrderHandler) formatPrice(price utils.Cents) string {
+	return price.String()
 }
 
 // AddOrderItemRequest represents request to add item with attributes to order
@@ -2090,7 +2313,7 @@ func (h *OrderHandler) AddItem(c echo.Context) error {
 	basePrice := h.parsePrice(newItem.Price)
 
 	// Calculate additional price from attributes
-	var attributesPrice float64
+	var attributesPrice utils.Cents
 	for _, attr := range itemRequest.Attributes {
 		if attr.OptionPrice != "" {
 			attributesPrice += h.parsePrice(attr.OptionPrice)
@@ -2098,7 +2321,7 @@ func (h *OrderHandler) AddItem(c echo.Context) error {
 	}
 
 	// Total price per unit (base + attributes) * quantity
-	totalPrice := (basePrice + attributesPrice) * float64(newItem.Quantity)
+	totalPrice := (basePrice + attributesPrice).Times(newItem.Quantity)
 	newItem.Total = h.formatPrice(totalPrice)
 
 	// Set unit price including attributes
@@ -2127,7 +2350,7 @@ func (h *OrderHandler) AddItem(c echo.Context) error {
 		// Update existing item quantity
 		existingItem := &order.Items[existingItemIndex]
 		existingItem.Quantity += newItem.Quantity
-		existingItemTotal := h.parsePrice(existingItem.Price) * float64(existingItem.Quantity)
+		existingItemTotal := h.parsePrice(existingItem.Price).Times(existingItem.Quantity)
 		existingItem.Total = h.formatPrice(existingItemTotal)
 
 		// Update in database
@@ -2263,7 +2486,7 @@ func (h *OrderHandler) UpdateItem(c echo.Context) error {
 
 	// Recalculate item total
 	price := h.parsePrice(itemToUpdate.Price)
-	total := price * float64(itemToUpdate.Quantity)
+	total := price.Times(itemToUpdate.Quantity)
 	itemToUpdate.Total = h.formatPrice(total)
 
 	// Start transaction
@@ -2400,7 +2623,7 @@ func (h *OrderHandler) RemoveItem(c echo.Context) error {
 
 // recalculateOrderTotals recalculates order subtotal and total
 func (h *OrderHandler) recalculateOrderTotals(order *models.Order) {
-	subtotal := 0.0
+	var subtotal utils.Cents
 	for _, item := range order.Items {
 		subtotal += h.parsePrice(item.Total)
 	}
@@ -2672,15 +2895,34 @@ func (h *ProductHandler) ImportProductsFromImage(c echo.Context) error {
 	return c.JSON(http.StatusOK, result)
 }
 
-// sendShippingNotification envia notificação WhatsApp quando pedido é marcado como enviado
-func (h *OrderHandler) sendShippingNotification(tenantID uuid.UUID, order *models.Order) {
+// stampOrderStatusTransition records the moment the order first moves to shipped or delivered,
+// whether operators changed the order status or the fulfillment status
+func stampOrderStatusTransition(order, previous *models.Order, now time.Time) {
+	reached := func(o *models.Order, status string) bool {
+		return o.Status == status || o.FulfillmentStatus == status
+	}
+
+	shipped := func(o *models.Order) bool {
+		return reached(o, "shipped") || reached(o, "delivered")
+	}
+
+	if order.ShippedAt == nil && shipped(order) && !shipped(previous) {
+		order.ShippedAt = &now
+	}
+	if order.DeliveredAt == nil && reached(order, "delivered") && !reached(previous, "delivered") {
+		order.DeliveredAt = &now
+	}
+}
+
+// sendOrderStatusNotification envia ao cliente o aviso WhatsApp da nova etapa do pedido
+func (h *OrderHandler) sendOrderStatusNotification(tenantID uuid.UUID, order *models.Order, status string) {
 	notificationService := zapplus.NewNotificationService(h.db)
 
-	err := notificationService.SendShippingNotification(tenantID, order)
+	err := notificationService.SendOrderStatusNotification(tenantID, order, status)
 	if err != nil {
-		log.Printf("❌ Error sending shipping notification for order %s: %v", order.OrderNumber, err)
+		log.Printf("❌ Error sending %s notification for order %s: %v", status, order.OrderNumber, err)
 	} else {
-		log.Printf("✅ Shipping notification sent successfully for order %s", order.OrderNumber)
+		log.Printf("✅ %s notification sent successfully for order %s", status, order.OrderNumber)
 	}
 }
 
@@ -0,0 +1,161 @@
+package handlers
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"iafarma/internal/repo"
+	"iafarma/pkg/models"
+)
+
+func TestParseProductListQuery(t *testing.T) {
+	categoryID := "9b2f6c1e-3f7a-4a53-9d6e-2f1c0b7a8e11"
+
+	tests := []struct {
+		name          string
+		params        string
+		limit, offset int
+		search        string
+		hasStock      bool
+		outOfStock    bool
+		category      string
+		sort          string
+	}{
+		{name: "defaults list every stock status", params: "", limit: 20},
+		{name: "page takes precedence over offset", params: "page=3&limit=10&offset=5", limit: 10, offset: 20},
+		{name: "legacy offset and search", params: "offset=40&search=dipirona", limit: 20, offset: 40, search: "dipirona"},
+		{name: "q preferred over search", params: "q=shampoo&search=dipirona", limit: 20, search: "shampoo"},
+		{name: "limit is capped", params: "limit=5000", limit: maxProductListLimit},
+		{name: "in stock only", params: "in_stock=true", limit: 20, hasStock: true},
+		{name: "out of stock only", params: "in_stock=false", limit: 20, outOfStock: true},
+		{name: "all stock statuses", params: "in_stock=all", limit: 20},
+		{name: "category and sort", params: "category=" + categoryID + "&sort=price_asc", limit: 20, category: categoryID, sort: "price_asc"},
+	}
+
+	for _, test := range tests {
+		params, _ := url.ParseQuery(test.params)
+		query, err := parseProductListQuery(params)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", test.name, err)
+			continue
+		}
+		if query.Limit != test.limit || query.Offset != test.offset || query.Search != test.search || query.Sort != test.sort {
+			t.Errorf("%s: got limit=%d offset=%d search=%q sort=%q", test.name, query.Limit, query.Offset, query.Search, query.Sort)
+		}
+		if (query.Filters.HasStock != nil) != test.hasStock || (query.Filters.OutOfStock != nil) != test.outOfStock {
+			t.Errorf("%s: unexpected stock filters has_stock=%v out_of_stock=%v", test.name, query.Filters.HasStock, query.Filters.OutOfStock)
+		}
+		if test.category != "" && (query.Filters.CategoryID == nil || *query.Filters.CategoryID != test.category) {
+			t.Errorf("%s: category filter = %v, expected %s", test.name, query.Filters.CategoryID, test.category)
+		}
+	}
+}
+
+func TestParseProductListQueryRejectsInvalidParams(t *testing.T) {
+	for _, raw := range []string{"sort=random", "in_stock=maybe", "category=not-a-uuid", "available=maybe"} {
+		params, _ := url.ParseQuery(raw)
+		if _, err := parseProductListQuery(params); err == nil {
+			t.Errorf("parseProductListQuery(%q) expected error", raw)
+		}
+	}
+}
+
+func TestParseProductListQueryAvailability(t *testing.T) {
+	params, _ := url.ParseQuery("available=false&in_stock=all")
+	query, err := parseProductListQuery(params)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if query.Filters.Available == nil || *query.Filters.Available {
+		t.Errorf("expected filter for paused products, got %v", query.Filters.Available)
+	}
+
+	params, _ = url.ParseQuery("")
+	if query, _ := parseProductListQuery(params); query.Filters.Available != nil {
+		t.Errorf("availability should not be filtered by default, got %v", *query.Filters.Available)
+	}
+}
+
+func TestStampOrderStatusTransition(t *testing.T) {
+	now := time.Date(2026, 3, 10, 14, 30, 0, 0, time.UTC)
+	earlier := now.Add(-2 * time.Hour)
+
+	tests := []struct {
+		name          string
+		previous      models.Order
+		order         models.Order
+		wantShipped   *time.Time
+		wantDelivered *time.Time
+	}{
+		{"fulfillment moves to shipped", models.Order{FulfillmentStatus: "pending"}, models.Order{FulfillmentStatus: "shipped"}, &now, nil},
+		{"order status moves to shipped", models.Order{Status: "processing"}, models.Order{Status: "shipped"}, &now, nil},
+		{"shipped order is delivered", models.Order{FulfillmentStatus: "shipped", ShippedAt: &earlier}, models.Order{FulfillmentStatus: "delivered", ShippedAt: &earlier}, &earlier, &now},
+		{"delivered without shipping step", models.Order{Status: "confirmed"}, models.Order{Status: "delivered"}, &now, &now},
+		{"unrelated update keeps shipped order untouched", models.Order{FulfillmentStatus: "shipped"}, models.Order{FulfillmentStatus: "shipped"}, nil, nil},
+		{"pre-shipment status records nothing", models.Order{Status: "pending"}, models.Order{Status: "processing"}, nil, nil},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			order := test.order
+			stampOrderStatusTransition(&order, &test.previous, now)
+
+			if !sameTime(order.ShippedAt, test.wantShipped) {
+				t.Errorf("expected shipped_at %v, got %v", test.wantShipped, order.ShippedAt)
+			}
+			if !sameTime(order.DeliveredAt, test.wantDelivered) {
+				t.Errorf("expected delivered_at %v, got %v", test.wantDelivered, order.DeliveredAt)
+			}
+		})
+	}
+}
+
+func sameTime(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return a.Equal(*b)
+}
+
+// fakeErasedFileStore serves files from a single bucket URL and records deletions
+type fakeErasedFileStore struct {
+	deleted   []string
+	deleteErr map[string]error
+}
+
+func (f *fakeErasedFileStore) KeyFromURL(fileURL string) (string, bool) {
+	key := strings.TrimPrefix(fileURL, "https://bucket/")
+	return key, key != fileURL
+}
+
+func (f *fakeErasedFileStore) DeleteFile(s3Key string) error {
+	if err := f.deleteErr[s3Key]; err != nil {
+		return err
+	}
+	f.deleted = append(f.deleted, s3Key)
+	return nil
+}
+
+func TestDeleteErasedPrescriptions(t *testing.T) {
+	store := &fakeErasedFileStore{deleteErr: map[string]error{"tenant/conversations/c/image_3.jpg": errors.New("access denied")}}
+	report := &repo.CustomerErasureReport{PrescriptionURLs: []string{
+		"https://bucket/tenant/conversations/c/image_1.jpg",
+		"https://other-cdn.example.com/receita.jpg",
+		"https://bucket/tenant/conversations/c/image_3.jpg",
+	}}
+
+	deleteErasedPrescriptions(store, report)
+
+	if len(store.deleted) != 1 || store.deleted[0] != "tenant/conversations/c/image_1.jpg" {
+		t.Errorf("deleted = %v, expected only the bucket image", store.deleted)
+	}
+	if report.PrescriptionsDeleted != 1 {
+		t.Errorf("PrescriptionsDeleted = %d, expected 1", report.PrescriptionsDeleted)
+	}
+	if len(report.Warnings) != 2 {
+		t.Errorf("expected warnings for the foreign URL and the failed delete, got %v", report.Warnings)
+	}
+}
@@ -91,6 +91,12 @@ func (h *TenantSettingsHandler) UpdateSetting(c echo.Context) error {
 		settingType = "string"
 	}
 
+	if key == ai.OrderNumberFormatSettingKey && request.Value != nil {
+		if err := ai.ValidateOrderNumberFormat(*request.Value); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "formato de número de pedido inválido: "+err.Error())
+		}
+	}
+
 	err := h.settingsService.SetSetting(c.Request().Context(), tenantID, key, request.Value, settingType)
 	if err != nil {
 		return echo.NewHTTPError(http.StatusInternalServerError, "erro ao atualizar configuração")
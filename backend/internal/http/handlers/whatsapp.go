@@ -9,6 +9,7 @@ import (
 	"strings"
 	"time"
 
+	"iafarma/internal/repo"
 	"iafarma/internal/services"
 	"iafarma/internal/whatsapp"
 	"iafarma/internal/zapplus"
@@ -16,6 +17,7 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
+	zlog "github.com/rs/zerolog/log"
 	"gorm.io/gorm"
 )
 
@@ -85,8 +87,6 @@ func (h *WhatsAppHandler) sendViaExternalAPI(session, phone, text string) (*stri
 	// Format phone number for WhatsApp
 	cleanPhone := formatPhoneForWhatsApp(phone)
 
-	fmt.Printf("\n\n\nSending WhatsApp message to: %s via session: %s\n", cleanPhone, session)
-
 	// Use centralized ZapPlus client
 	client := zapplus.GetClient()
 	err := client.SendTextMessage(session, cleanPhone, text)
@@ -264,6 +264,12 @@ func (h *WhatsAppHandler) SendMessage(c echo.Context) error {
 	// Update conversation
 	now := time.Now()
 	conversation.LastMessageAt = &now
+	if req.ResendMessageID == nil {
+		// An agent message outside the customer's window opens a new contact
+		if err := repo.NewConversationRepository(h.db).MarkBusinessInitiatedIfOpening(&conversation, now); err != nil {
+			zlog.Warn().Err(err).Str("conversation_id", conversation.ID.String()).Msg("Failed to record conversation initiation")
+		}
+	}
 	if err := h.db.Save(&conversation).Error; err != nil {
 		// Log but don't fail the request
 		fmt.Printf("Failed to update conversation: %v\n", err)
@@ -902,6 +908,7 @@ func (h *WhatsAppHandler) CreateOrFindConversationByCustomer(c echo.Context) err
 		ChannelID:     channel.ID, // Use the existing channel ID
 		Status:        "open",
 		LastMessageAt: &now,
+		InitiatedBy:   repo.ConversationInitiatedByBusiness,
 	}
 
 	if err := h.db.Create(&newConversation).Error; err != nil {
@@ -1069,6 +1076,91 @@ func (h *WhatsAppHandler) ToggleAIConversation(c echo.Context) error {
 	})
 }
 
+// PauseBot stops the bot from replying in a conversation so a human can take over
+func (h *WhatsAppHandler) PauseBot(c echo.Context) error {
+	return h.setBotPaused(c, true)
+}
+
+// ResumeBot lets the bot reply again in a conversation after a human takeover
+func (h *WhatsAppHandler) ResumeBot(c echo.Context) error {
+	return h.setBotPaused(c, false)
+}
+
+func (h *WhatsAppHandler) setBotPaused(c echo.Context, paused bool) error {
+	conversationID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid conversation ID",
+		})
+	}
+
+	tenantID, ok := c.Get("tenant_id").(uuid.UUID)
+	if !ok {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Tenant ID not found in context",
+		})
+	}
+
+	updates := map[string]interface{}{"bot_paused": paused, "bot_paused_at": nil}
+	if paused {
+		updates["bot_paused_at"] = time.Now()
+	}
+
+	result := h.db.Model(&models.Conversation{}).
+		Where("id = ? AND tenant_id = ?", conversationID, tenantID).
+		Updates(updates)
+
+	if result.Error != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to update conversation",
+		})
+	}
+
+	if result.RowsAffected == 0 {
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": "Conversation not found",
+		})
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"success":    true,
+		"bot_paused": paused,
+	})
+}
+
+// MarkBusinessInitiated flags a conversation as opened by the business, for broadcasts and follow-ups
+// sent outside the platform, so the customer's reply is not greeted as a first contact
+func (h *WhatsAppHandler) MarkBusinessInitiated(c echo.Context) error {
+	conversationID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid conversation ID",
+		})
+	}
+
+	tenantID, ok := c.Get("tenant_id").(uuid.UUID)
+	if !ok {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Tenant ID not found in context",
+		})
+	}
+
+	var conversation models.Conversation
+	if err := h.db.Where("id = ? AND tenant_id = ?", conversationID, tenantID).First(&conversation).Error; err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": "Conversation not found",
+		})
+	}
+
+	if err := repo.NewConversationRepository(h.db).MarkBusinessInitiated(&conversation, time.Now()); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to update conversation",
+		})
+	}
+
+	return c.JSON(http.StatusOK, conversation)
+}
+
 // UpdateMessageStatusRequest represents a message status update request
 type UpdateMessageStatusRequest struct {
 	Status string `json:"status" validate:"required"`
@@ -1626,8 +1718,6 @@ func (h *WhatsAppHandler) sendImageViaZapPlus(chatID string, file struct {
 	Filename string `json:"filename"`
 	URL      string `json:"url"`
 }, caption, session string) (map[string]interface{}, error) {
-	fmt.Printf("Sending image request via ZapPlus: %s to %s\n", file.URL, chatID)
-
 	// Use centralized ZapPlus client
 	client := zapplus.GetClient()
 	response, err := client.SendImageWithResponse(session, chatID, file.URL, caption)
@@ -1643,8 +1733,6 @@ func (h *WhatsAppHandler) sendDocumentViaZapPlus(chatID string, file struct {
 	Filename string `json:"filename"`
 	URL      string `json:"url"`
 }, caption, session string) (map[string]interface{}, error) {
-	fmt.Printf("Sending document request via ZapPlus: %s to %s\n", file.URL, chatID)
-
 	// Use centralized ZapPlus client
 	client := zapplus.GetClient()
 	response, err := client.SendFileWithResponse(session, chatID, file.URL, caption)
@@ -1659,8 +1747,6 @@ func (h *WhatsAppHandler) sendAudioViaZapPlus(chatID string, file struct {
 	Mimetype string `json:"mimetype"`
 	URL      string `json:"url"`
 }, convert bool, session string) (map[string]interface{}, error) {
-	fmt.Printf("Sending audio request via ZapPlus: %s to %s\n", file.URL, chatID)
-
 	// Use centralized ZapPlus client
 	client := zapplus.GetClient()
 	response, err := client.SendVoiceWithResponse(session, chatID, file.URL)
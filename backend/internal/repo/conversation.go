@@ -0,0 +1,78 @@
+package repo
+
+import (
+	"errors"
+	"time"
+
+	"iafarma/pkg/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Conversation initiation sources
+const (
+	ConversationInitiatedByCustomer = "customer"
+	ConversationInitiatedByBusiness = "business"
+)
+
+// customerServiceWindow is how long after the customer's last message a business message still counts as a reply
+const customerServiceWindow = 24 * time.Hour
+
+// ConversationRepository handles conversation bookkeeping shared by the handlers and notification senders
+type ConversationRepository struct {
+	db *gorm.DB
+}
+
+// NewConversationRepository creates a new conversation repository
+func NewConversationRepository(db *gorm.DB) *ConversationRepository {
+	return &ConversationRepository{db: db}
+}
+
+// opensContact reports whether a business message sent at now starts a new contact instead of answering the customer
+func opensContact(lastInboundAt *time.Time, now time.Time) bool {
+	return lastInboundAt == nil || now.Sub(*lastInboundAt) >= customerServiceWindow
+}
+
+// MarkBusinessInitiated records that the business opened contact on the conversation, so the customer's
+// reply is answered in context instead of as a cold first-message greeting
+func (r *ConversationRepository) MarkBusinessInitiated(conversation *models.Conversation, now time.Time) error {
+	conversation.InitiatedBy = ConversationInitiatedByBusiness
+	conversation.BusinessInitiatedAt = &now
+
+	return r.db.Model(&models.Conversation{}).
+		Where("id = ? AND tenant_id = ?", conversation.ID, conversation.TenantID).
+		Updates(map[string]interface{}{
+			"initiated_by":          ConversationInitiatedByBusiness,
+			"business_initiated_at": now,
+		}).Error
+}
+
+// MarkBusinessInitiatedIfOpening marks the conversation as business-initiated when an outbound message sent at now
+// opens contact (broadcast, follow-up) rather than replying within the customer's service window
+func (r *ConversationRepository) MarkBusinessInitiatedIfOpening(conversation *models.Conversation, now time.Time) error {
+	lastInboundAt, err := r.lastInboundAt(conversation.TenantID, conversation.ID)
+	if err != nil {
+		return err
+	}
+	if !opensContact(lastInboundAt, now) {
+		return nil
+	}
+	return r.MarkBusinessInitiated(conversation, now)
+}
+
+// lastInboundAt returns when the customer last wrote in the conversation, nil when they never did
+func (r *ConversationRepository) lastInboundAt(tenantID, conversationID uuid.UUID) (*time.Time, error) {
+	var message models.Message
+	err := r.db.Select("created_at").
+		Where("tenant_id = ? AND conversation_id = ? AND direction = ?", tenantID, conversationID, "in").
+		Order("created_at DESC").
+		First(&message).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &message.CreatedAt, nil
+}
@@ -0,0 +1,34 @@
+package repo
+
+import (
+	"testing"
+	"time"
+)
+
+func TestOpensContact(t *testing.T) {
+	now := time.Date(2025, 3, 10, 9, 0, 0, 0, time.UTC)
+	at := func(d time.Duration) *time.Time {
+		t := now.Add(-d)
+		return &t
+	}
+
+	tests := []struct {
+		name          string
+		lastInboundAt *time.Time
+		expected      bool
+	}{
+		{"customer never wrote", nil, true},
+		{"reply within the service window", at(2 * time.Hour), false},
+		{"just before the window closes", at(23*time.Hour + 59*time.Minute), false},
+		{"follow-up after the window closed", at(24 * time.Hour), true},
+		{"broadcast weeks later", at(21 * 24 * time.Hour), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := opensContact(tt.lastInboundAt, now); got != tt.expected {
+				t.Errorf("expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
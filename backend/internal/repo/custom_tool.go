@@ -0,0 +1,59 @@
+package repo
+
+import (
+	"iafarma/pkg/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type CustomToolRepository struct {
+	db *gorm.DB
+}
+
+func NewCustomToolRepository(db *gorm.DB) *CustomToolRepository {
+	return &CustomToolRepository{db: db}
+}
+
+// Create creates a new custom tool
+func (r *CustomToolRepository) Create(tool *models.TenantCustomTool) error {
+	return r.db.Create(tool).Error
+}
+
+// GetByID gets a custom tool by ID within a tenant
+func (r *CustomToolRepository) GetByID(tenantID, id uuid.UUID) (*models.TenantCustomTool, error) {
+	var tool models.TenantCustomTool
+	err := r.db.Where("id = ? AND tenant_id = ?", id, tenantID).First(&tool).Error
+	return &tool, err
+}
+
+// GetByName gets a custom tool by name within a tenant
+func (r *CustomToolRepository) GetByName(tenantID uuid.UUID, name string) (*models.TenantCustomTool, error) {
+	var tool models.TenantCustomTool
+	err := r.db.Where("tenant_id = ? AND name = ?", tenantID, name).First(&tool).Error
+	return &tool, err
+}
+
+// List lists the custom tools of a tenant
+func (r *CustomToolRepository) List(tenantID uuid.UUID) ([]models.TenantCustomTool, error) {
+	var tools []models.TenantCustomTool
+	err := r.db.Where("tenant_id = ?", tenantID).Order("name ASC").Find(&tools).Error
+	return tools, err
+}
+
+// Update updates a custom tool
+func (r *CustomToolRepository) Update(tool *models.TenantCustomTool) error {
+	return r.db.Save(tool).Error
+}
+
+// Delete deletes a custom tool
+func (r *CustomToolRepository) Delete(tenantID, id uuid.UUID) error {
+	result := r.db.Where("id = ? AND tenant_id = ?", id, tenantID).Delete(&models.TenantCustomTool{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
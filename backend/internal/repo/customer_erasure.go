@@ -0,0 +1,270 @@
+package repo
+
+import (
+	"fmt"
+
+	"iafarma/pkg/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ErasedCustomerName is the placeholder name kept on anonymized records
+const ErasedCustomerName = "Cliente removido (LGPD)"
+
+// CustomerErasureReport summarizes what was removed or anonymized for a customer
+type CustomerErasureReport struct {
+	CustomerID                  uuid.UUID `json:"customer_id"`
+	CustomerDeleted             bool      `json:"customer_deleted"`
+	CustomerAnonymized          bool      `json:"customer_anonymized"`
+	AddressesDeleted            int64     `json:"addresses_deleted"`
+	CartsDeleted                int64     `json:"carts_deleted"`
+	CartItemsDeleted            int64     `json:"cart_items_deleted"`
+	SubscriptionsDeleted        int64     `json:"subscriptions_deleted"`
+	MissingDemandsDeleted       int64     `json:"missing_demands_deleted"`
+	ToolExecutionsDeleted       int64     `json:"tool_executions_deleted"`
+	OrdersAnonymized            int64     `json:"orders_anonymized"`
+	ReturnRequestsAnonymized    int64     `json:"return_requests_anonymized"`
+	ConversationMemoriesDeleted int64     `json:"conversation_memories_deleted"`
+	ErrorLogsAnonymized         int64     `json:"error_logs_anonymized"`
+	PrescriptionsDeleted        int       `json:"prescriptions_deleted"`
+	ConversationsDeleted        int64     `json:"conversations_deleted"`
+	MessagesDeleted             int64     `json:"messages_deleted"`
+	RAGCollectionsDeleted       []string  `json:"rag_collections_deleted"`
+	Warnings                    []string  `json:"warnings,omitempty"`
+
+	// PrescriptionURLs are the prescription images detached from carts and orders; the files live outside
+	// the database and are deleted by the caller after the transaction commits
+	PrescriptionURLs []string `json:"-"`
+}
+
+// addPrescriptionURL records a prescription image to delete, skipping empty and repeated URLs
+func (r *CustomerErasureReport) addPrescriptionURL(url string) {
+	if url == "" {
+		return
+	}
+	for _, existing := range r.PrescriptionURLs {
+		if existing == url {
+			return
+		}
+	}
+	r.PrescriptionURLs = append(r.PrescriptionURLs, url)
+}
+
+// anonymizeCustomer removes every personal field from the customer, keeping only the record identity
+func anonymizeCustomer(customer *models.Customer) {
+	customer.Phone = ""
+	customer.Name = ErasedCustomerName
+	customer.Email = ""
+	customer.Document = ""
+	customer.BirthDate = nil
+	customer.Gender = ""
+	customer.Notes = ""
+	customer.IsActive = false
+}
+
+// anonymizeOrder removes personal data from an order while keeping the financial data for accounting
+func anonymizeOrder(order *models.Order) {
+	erasedName := ErasedCustomerName
+
+	order.CustomerID = nil
+	order.AddressID = nil
+	order.Observations = ""
+	order.PrescriptionURL = ""
+
+	order.CustomerName = &erasedName
+	order.CustomerEmail = nil
+	order.CustomerPhone = nil
+	order.CustomerDocument = nil
+
+	// City, state and country are kept for fiscal/regional reporting
+	order.ShippingName = nil
+	order.ShippingStreet = nil
+	order.ShippingNumber = nil
+	order.ShippingComplement = nil
+	order.ShippingReference = nil
+	order.ShippingNeighborhood = nil
+	order.ShippingZipcode = nil
+
+	order.BillingName = nil
+	order.BillingStreet = nil
+	order.BillingNumber = nil
+	order.BillingComplement = nil
+	order.BillingNeighborhood = nil
+	order.BillingZipcode = nil
+}
+
+// Erase removes or anonymizes a customer and all associated data within a single transaction.
+// Orders are anonymized (kept for accounting). Messages and conversations are only removed when
+// deleteMessages is true; otherwise the customer record is anonymized instead of deleted because
+// the retained conversations still reference it.
+func (r *CustomerRepository) Erase(tenantID, id uuid.UUID, deleteMessages bool) (*CustomerErasureReport, error) {
+	report := &CustomerErasureReport{CustomerID: id}
+
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var customer models.Customer
+		if err := tx.Where("id = ? AND tenant_id = ?", id, tenantID).First(&customer).Error; err != nil {
+			return err
+		}
+		phone := customer.Phone
+
+		// Prescription images attached to carts (the carts are deleted below)
+		var cartPrescriptions []string
+		if err := tx.Model(&models.Cart{}).Where("tenant_id = ? AND customer_id = ? AND prescription_url <> ''", tenantID, id).
+			Pluck("prescription_url", &cartPrescriptions).Error; err != nil {
+			return fmt.Errorf("failed to load cart prescriptions: %w", err)
+		}
+		for _, url := range cartPrescriptions {
+			report.addPrescriptionURL(url)
+		}
+
+		// Carts and their items
+		cartIDs := tx.Model(&models.Cart{}).Select("id").Where("tenant_id = ? AND customer_id = ?", tenantID, id)
+		cartItemIDs := tx.Model(&models.CartItem{}).Select("id").Where("tenant_id = ? AND cart_id IN (?)", tenantID, cartIDs)
+
+		if err := tx.Unscoped().Where("tenant_id = ? AND cart_item_id IN (?)", tenantID, cartItemIDs).Delete(&models.CartItemAttribute{}).Error; err != nil {
+			return fmt.Errorf("failed to delete cart item attributes: %w", err)
+		}
+
+		result := tx.Unscoped().Where("tenant_id = ? AND cart_id IN (?)", tenantID, cartIDs).Delete(&models.CartItem{})
+		if result.Error != nil {
+			return fmt.Errorf("failed to delete cart items: %w", result.Error)
+		}
+		report.CartItemsDeleted = result.RowsAffected
+
+		result = tx.Unscoped().Where("tenant_id = ? AND customer_id = ?", tenantID, id).Delete(&models.Cart{})
+		if result.Error != nil {
+			return fmt.Errorf("failed to delete carts: %w", result.Error)
+		}
+		report.CartsDeleted = result.RowsAffected
+
+		// Recurring orders would keep generating orders for the erased customer (and block the hard delete)
+		result = tx.Unscoped().Where("tenant_id = ? AND customer_id = ?", tenantID, id).Delete(&models.Subscription{})
+		if result.Error != nil {
+			return fmt.Errorf("failed to delete subscriptions: %w", result.Error)
+		}
+		report.SubscriptionsDeleted = result.RowsAffected
+
+		// Products the customer asked for (search terms, special order notes and restock notices)
+		result = tx.Unscoped().Where("tenant_id = ? AND customer_id = ?", tenantID, id).Delete(&models.MissingProductDemand{})
+		if result.Error != nil {
+			return fmt.Errorf("failed to delete missing product demands: %w", result.Error)
+		}
+		report.MissingDemandsDeleted = result.RowsAffected
+
+		// Assistant tool usage recorded for the customer
+		result = tx.Unscoped().Where("tenant_id = ? AND customer_id = ?", tenantID, id).Delete(&models.AIToolExecution{})
+		if result.Error != nil {
+			return fmt.Errorf("failed to delete tool executions: %w", result.Error)
+		}
+		report.ToolExecutionsDeleted = result.RowsAffected
+
+		// Orders are anonymized before addresses are removed so no reference is left behind
+		var orders []models.Order
+		if err := tx.Unscoped().Where("tenant_id = ? AND customer_id = ?", tenantID, id).Find(&orders).Error; err != nil {
+			return fmt.Errorf("failed to load orders: %w", err)
+		}
+		for i := range orders {
+			report.addPrescriptionURL(orders[i].PrescriptionURL)
+			anonymizeOrder(&orders[i])
+			if deleteMessages {
+				orders[i].ConversationID = nil
+			}
+			if err := tx.Unscoped().Save(&orders[i]).Error; err != nil {
+				return fmt.Errorf("failed to anonymize order %s: %w", orders[i].OrderNumber, err)
+			}
+		}
+		report.OrdersAnonymized = int64(len(orders))
+
+		// Return requests stay with their (anonymized) orders; the customer's own words are removed
+		result = tx.Model(&models.ReturnRequest{}).Where("tenant_id = ? AND customer_id = ?", tenantID, id).
+			Updates(map[string]interface{}{"customer_id": nil, "reason": ""})
+		if result.Error != nil {
+			return fmt.Errorf("failed to anonymize return requests: %w", result.Error)
+		}
+		report.ReturnRequestsAnonymized = result.RowsAffected
+
+		result = tx.Unscoped().Where("tenant_id = ? AND customer_id = ?", tenantID, id).Delete(&models.Address{})
+		if result.Error != nil {
+			return fmt.Errorf("failed to delete addresses: %w", result.Error)
+		}
+		report.AddressesDeleted = result.RowsAffected
+
+		// AI conversation memory is keyed by phone
+		if phone != "" {
+			result = tx.Where("tenant_id = ? AND customer_phone = ?", tenantID, phone).Delete(&models.ConversationMemory{})
+			if result.Error != nil {
+				return fmt.Errorf("failed to delete conversation memory: %w", result.Error)
+			}
+			report.ConversationMemoriesDeleted = result.RowsAffected
+		}
+
+		errorLogs := tx.Unscoped().Model(&models.AIErrorLog{}).Where("tenant_id = ? AND customer_id = ?", tenantID, id)
+		if phone != "" {
+			errorLogs = tx.Unscoped().Model(&models.AIErrorLog{}).
+				Where("tenant_id = ? AND (customer_id = ? OR customer_phone = ?)", tenantID, id, phone)
+		}
+		result = errorLogs.Updates(map[string]interface{}{
+			"customer_phone": "",
+			"user_message":   "",
+			"tool_args":      "",
+		})
+		if result.Error != nil {
+			return fmt.Errorf("failed to anonymize error logs: %w", result.Error)
+		}
+		report.ErrorLogsAnonymized = result.RowsAffected
+
+		if deleteMessages {
+			conversationIDs := tx.Model(&models.Conversation{}).Select("id").Where("tenant_id = ? AND customer_id = ?", tenantID, id)
+			messageIDs := tx.Model(&models.Message{}).Select("id").Where("tenant_id = ? AND customer_id = ?", tenantID, id)
+
+			if err := tx.Unscoped().Where("tenant_id = ? AND message_id IN (?)", tenantID, messageIDs).Delete(&models.MessageMedia{}).Error; err != nil {
+				return fmt.Errorf("failed to delete message media: %w", err)
+			}
+
+			result = tx.Unscoped().Where("tenant_id = ? AND customer_id = ?", tenantID, id).Delete(&models.Message{})
+			if result.Error != nil {
+				return fmt.Errorf("failed to delete messages: %w", result.Error)
+			}
+			report.MessagesDeleted = result.RowsAffected
+
+			if err := tx.Where("tenant_id = ? AND conversation_id IN (?)", tenantID, conversationIDs).Delete(&models.ConversationUser{}).Error; err != nil {
+				return fmt.Errorf("failed to delete conversation users: %w", err)
+			}
+
+			conversationIDsText := tx.Model(&models.Conversation{}).Select("id::text").Where("tenant_id = ? AND customer_id = ?", tenantID, id)
+			if err := tx.Unscoped().Where("tenant_id = ? AND conversation_id IN (?)", tenantID, conversationIDsText).Delete(&models.ConversationTag{}).Error; err != nil {
+				return fmt.Errorf("failed to delete conversation tags: %w", err)
+			}
+
+			result = tx.Unscoped().Where("tenant_id = ? AND customer_id = ?", tenantID, id).Delete(&models.Conversation{})
+			if result.Error != nil {
+				return fmt.Errorf("failed to delete conversations: %w", result.Error)
+			}
+			report.ConversationsDeleted = result.RowsAffected
+
+			if err := tx.Unscoped().Delete(&customer).Error; err != nil {
+				return fmt.Errorf("failed to delete customer: %w", err)
+			}
+			report.CustomerDeleted = true
+			return nil
+		}
+
+		// Conversations are retained, so the customer row stays (anonymized and hidden)
+		anonymizeCustomer(&customer)
+		if err := tx.Save(&customer).Error; err != nil {
+			return fmt.Errorf("failed to anonymize customer: %w", err)
+		}
+		if err := tx.Delete(&customer).Error; err != nil {
+			return fmt.Errorf("failed to archive customer: %w", err)
+		}
+		report.CustomerAnonymized = true
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return report, nil
+}
@@ -0,0 +1,335 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"iafarma/pkg/models"
+
+	"github.com/google/uuid"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func strPtr(s string) *string {
+	return &s
+}
+
+func TestAnonymizeCustomerLeavesNoPII(t *testing.T) {
+	birthDate := time.Date(1990, 5, 10, 0, 0, 0, 0, time.UTC)
+	customer := models.Customer{
+		Phone:     "5527999999999",
+		Name:      "Maria da Silva",
+		Email:     "maria@example.com",
+		Document:  "12345678900",
+		BirthDate: &birthDate,
+		Gender:    "F",
+		Notes:     "Cliente prefere entrega à tarde",
+		IsActive:  true,
+	}
+	customer.ID = uuid.New()
+
+	anonymizeCustomer(&customer)
+
+	piiValues := []string{"5527999999999", "Maria", "maria@example.com", "12345678900", "entrega à tarde"}
+	fields := []string{customer.Phone, customer.Name, customer.Email, customer.Document, customer.Gender, customer.Notes}
+	for _, field := range fields {
+		for _, pii := range piiValues {
+			if field != "" && strings.Contains(field, pii) {
+				t.Errorf("anonymized customer still contains PII %q in %q", pii, field)
+			}
+		}
+	}
+
+	if customer.BirthDate != nil {
+		t.Errorf("BirthDate = %v, expected nil", customer.BirthDate)
+	}
+	if customer.IsActive {
+		t.Error("anonymized customer should be inactive")
+	}
+	if customer.Name != ErasedCustomerName {
+		t.Errorf("Name = %q, expected %q", customer.Name, ErasedCustomerName)
+	}
+}
+
+func TestAnonymizeOrderLeavesNoPIIButKeepsFinancialData(t *testing.T) {
+	customerID := uuid.New()
+	addressID := uuid.New()
+	order := models.Order{
+		CustomerID:           &customerID,
+		AddressID:            &addressID,
+		OrderNumber:          "PED123",
+		TotalAmount:          "59.90",
+		Subtotal:             "49.90",
+		ShippingAmount:       "10.00",
+		Observations:         "Troco para 100, apartamento da Maria",
+		CustomerName:         strPtr("Maria da Silva"),
+		CustomerEmail:        strPtr("maria@example.com"),
+		CustomerPhone:        strPtr("5527999999999"),
+		CustomerDocument:     strPtr("12345678900"),
+		PrescriptionURL:      "https://bucket/tenant/conversations/customer/image_1.jpg",
+		ShippingName:         strPtr("Maria da Silva"),
+		ShippingStreet:       strPtr("Rua das Flores"),
+		ShippingNumber:       strPtr("123"),
+		ShippingComplement:   strPtr("Apto 42"),
+		ShippingNeighborhood: strPtr("Centro"),
+		ShippingCity:         strPtr("Vitória"),
+		ShippingState:        strPtr("ES"),
+		ShippingZipcode:      strPtr("29000-000"),
+		BillingName:          strPtr("Maria da Silva"),
+		BillingStreet:        strPtr("Rua das Flores"),
+		BillingNumber:        strPtr("123"),
+		BillingComplement:    strPtr("Apto 42"),
+		BillingNeighborhood:  strPtr("Centro"),
+		BillingZipcode:       strPtr("29000-000"),
+	}
+
+	anonymizeOrder(&order)
+
+	if order.CustomerID != nil || order.AddressID != nil {
+		t.Error("order should no longer reference the customer or address")
+	}
+
+	residual := map[string]*string{
+		"CustomerEmail":        order.CustomerEmail,
+		"CustomerPhone":        order.CustomerPhone,
+		"CustomerDocument":     order.CustomerDocument,
+		"ShippingName":         order.ShippingName,
+		"ShippingStreet":       order.ShippingStreet,
+		"ShippingNumber":       order.ShippingNumber,
+		"ShippingComplement":   order.ShippingComplement,
+		"ShippingNeighborhood": order.ShippingNeighborhood,
+		"ShippingZipcode":      order.ShippingZipcode,
+		"BillingName":          order.BillingName,
+		"BillingStreet":        order.BillingStreet,
+		"BillingNumber":        order.BillingNumber,
+		"BillingComplement":    order.BillingComplement,
+		"BillingNeighborhood":  order.BillingNeighborhood,
+		"BillingZipcode":       order.BillingZipcode,
+	}
+	for field, value := range residual {
+		if value != nil {
+			t.Errorf("%s = %q, expected nil", field, *value)
+		}
+	}
+
+	if order.CustomerName == nil || *order.CustomerName != ErasedCustomerName {
+		t.Errorf("CustomerName should be the erased placeholder, got %v", order.CustomerName)
+	}
+	if order.PrescriptionURL != "" {
+		t.Errorf("PrescriptionURL = %q, expected empty", order.PrescriptionURL)
+	}
+	if strings.Contains(order.Observations, "Maria") {
+		t.Errorf("Observations still contains PII: %q", order.Observations)
+	}
+
+	// Dados financeiros e regionais devem ser preservados para contabilidade
+	if order.OrderNumber != "PED123" || order.TotalAmount != "59.90" || order.Subtotal != "49.90" || order.ShippingAmount != "10.00" {
+		t.Errorf("financial data changed: %+v", order)
+	}
+	if order.ShippingCity == nil || *order.ShippingCity != "Vitória" || order.ShippingState == nil || *order.ShippingState != "ES" {
+		t.Error("city/state should be kept for fiscal reporting")
+	}
+}
+
+// dryRunConnPool lets a dry-run db open transactions without a database connection
+type dryRunConnPool struct{}
+
+func (dryRunConnPool) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	return nil, sql.ErrConnDone
+}
+
+func (dryRunConnPool) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return nil, sql.ErrConnDone
+}
+
+func (dryRunConnPool) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	return nil, sql.ErrConnDone
+}
+
+func (dryRunConnPool) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	return nil
+}
+
+func (p dryRunConnPool) BeginTx(ctx context.Context, opts *sql.TxOptions) (gorm.ConnPool, error) {
+	return p, nil
+}
+
+func (dryRunConnPool) Commit() error   { return nil }
+func (dryRunConnPool) Rollback() error { return nil }
+
+// newDryRunErasureRepository builds a customer repository whose erasure statements are only rendered and captured.
+// The lookups return the given customer, orders and cart prescription URLs.
+func newDryRunErasureRepository(t *testing.T, customer models.Customer, orders []models.Order, cartPrescriptions []string) (*CustomerRepository, func() []string) {
+	t.Helper()
+
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: dryRunConnPool{}}), &gorm.Config{
+		DryRun:                 true,
+		DisableAutomaticPing:   true,
+		SkipDefaultTransaction: true,
+		Logger:                 logger.Discard,
+	})
+	if err != nil {
+		t.Fatalf("failed to open dry-run db: %v", err)
+	}
+
+	var mu sync.Mutex
+	var statements []string
+	capture := func(tx *gorm.DB) {
+		mu.Lock()
+		defer mu.Unlock()
+		statements = append(statements, tx.Statement.SQL.String())
+	}
+	found := func(tx *gorm.DB) {
+		switch dest := tx.Statement.Dest.(type) {
+		case *models.Customer:
+			*dest = customer
+			tx.RowsAffected = 1
+		case *[]models.Order:
+			*dest = append([]models.Order(nil), orders...)
+			tx.RowsAffected = int64(len(orders))
+		case *[]string:
+			if tx.Statement.Table == "carts" {
+				*dest = append([]string(nil), cartPrescriptions...)
+				tx.RowsAffected = int64(len(cartPrescriptions))
+			}
+		}
+	}
+	callbacks := []error{
+		db.Callback().Query().After("gorm:query").Register("test:customer_found", found),
+		db.Callback().Query().After("gorm:query").Register("test:capture_query_sql", capture),
+		db.Callback().Update().After("gorm:update").Register("test:capture_update_sql", capture),
+		db.Callback().Delete().After("gorm:delete").Register("test:capture_delete_sql", capture),
+	}
+	for _, err := range callbacks {
+		if err != nil {
+			t.Fatalf("failed to register callback: %v", err)
+		}
+	}
+
+	return NewCustomerRepository(db), func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string(nil), statements...)
+	}
+}
+
+// statementIndex returns the position of the first statement containing fragment, or -1
+func statementIndex(statements []string, fragment string) int {
+	for i, statement := range statements {
+		if strings.Contains(statement, fragment) {
+			return i
+		}
+	}
+	return -1
+}
+
+func TestEraseRemovesSubscriptions(t *testing.T) {
+	for _, deleteMessages := range []bool{true, false} {
+		customer := models.Customer{Phone: "5527999999999", Name: "Maria da Silva"}
+		customer.ID = uuid.New()
+		customer.TenantID = uuid.New()
+		customerRepo, statements := newDryRunErasureRepository(t, customer, nil, nil)
+
+		report, err := customerRepo.Erase(customer.TenantID, customer.ID, deleteMessages)
+		if err != nil {
+			t.Fatalf("delete_messages=%t: unexpected error: %v", deleteMessages, err)
+		}
+
+		captured := statements()
+		subscriptions := statementIndex(captured, `DELETE FROM "subscriptions"`)
+		if subscriptions < 0 {
+			t.Fatalf("delete_messages=%t: expected subscriptions to be deleted, got %v", deleteMessages, captured)
+		}
+		if !strings.Contains(captured[subscriptions], "customer_id = ") || !strings.Contains(captured[subscriptions], "tenant_id = ") {
+			t.Errorf("delete_messages=%t: subscription delete should be scoped to the tenant customer: %s", deleteMessages, captured[subscriptions])
+		}
+
+		// The customer row is removed (hard delete) or archived (soft delete) only after its subscriptions
+		customerStatement := `DELETE FROM "customers"`
+		if !deleteMessages {
+			customerStatement = `UPDATE "customers" SET "deleted_at"=`
+		}
+		if index := statementIndex(captured, customerStatement); index < subscriptions {
+			t.Errorf("delete_messages=%t: expected %q after the subscription delete, got %v", deleteMessages, customerStatement, captured)
+		}
+		if report.CustomerDeleted != deleteMessages || report.CustomerAnonymized == deleteMessages {
+			t.Errorf("delete_messages=%t: unexpected report %+v", deleteMessages, report)
+		}
+	}
+}
+
+func TestEraseRemovesCustomerActivity(t *testing.T) {
+	tests := []struct {
+		name      string
+		statement string
+	}{
+		{"missing product demands", `DELETE FROM "missing_product_demands"`},
+		{"tool executions", `DELETE FROM "ai_tool_executions"`},
+		{"return requests", `UPDATE "return_requests" SET "customer_id"=$1,"reason"=$2`},
+	}
+
+	for _, deleteMessages := range []bool{true, false} {
+		customer := models.Customer{Phone: "5527999999999", Name: "Maria da Silva"}
+		customer.ID = uuid.New()
+		customer.TenantID = uuid.New()
+		customerRepo, statements := newDryRunErasureRepository(t, customer, nil, nil)
+
+		if _, err := customerRepo.Erase(customer.TenantID, customer.ID, deleteMessages); err != nil {
+			t.Fatalf("delete_messages=%t: unexpected error: %v", deleteMessages, err)
+		}
+
+		captured := statements()
+		for _, tt := range tests {
+			index := statementIndex(captured, tt.statement)
+			if index < 0 {
+				t.Errorf("delete_messages=%t: expected %s to be erased, got %v", deleteMessages, tt.name, captured)
+				continue
+			}
+			if !strings.Contains(captured[index], "customer_id = ") || !strings.Contains(captured[index], "tenant_id = ") {
+				t.Errorf("delete_messages=%t: %s should be scoped to the tenant customer: %s", deleteMessages, tt.name, captured[index])
+			}
+		}
+	}
+}
+
+func TestEraseCollectsPrescriptionImages(t *testing.T) {
+	customer := models.Customer{Phone: "5527999999999", Name: "Maria da Silva"}
+	customer.ID = uuid.New()
+	customer.TenantID = uuid.New()
+
+	orderPrescription := "https://bucket/tenant/conversations/customer/image_1.jpg"
+	cartPrescription := "https://bucket/tenant/conversations/customer/image_2.jpg"
+	orders := []models.Order{
+		{OrderNumber: "PED1", PrescriptionURL: orderPrescription, CustomerID: &customer.ID},
+		{OrderNumber: "PED2", PrescriptionURL: orderPrescription, CustomerID: &customer.ID},
+		{OrderNumber: "PED3", CustomerID: &customer.ID},
+	}
+	for i := range orders {
+		orders[i].ID = uuid.New()
+	}
+	customerRepo, _ := newDryRunErasureRepository(t, customer, orders, []string{cartPrescription})
+
+	report, err := customerRepo.Erase(customer.TenantID, customer.ID, false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []string{cartPrescription, orderPrescription}
+	if len(report.PrescriptionURLs) != len(expected) {
+		t.Fatalf("PrescriptionURLs = %v, expected %v", report.PrescriptionURLs, expected)
+	}
+	for i, url := range expected {
+		if report.PrescriptionURLs[i] != url {
+			t.Errorf("PrescriptionURLs[%d] = %q, expected %q", i, report.PrescriptionURLs[i], url)
+		}
+	}
+	if report.OrdersAnonymized != 3 {
+		t.Errorf("OrdersAnonymized = %d, expected 3", report.OrdersAnonymized)
+	}
+}
@@ -0,0 +1,124 @@
+package repo
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"iafarma/internal/utils"
+	"iafarma/pkg/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ErrInvalidCustomerPhone is returned when the phone has no digits to identify the customer
+var ErrInvalidCustomerPhone = errors.New("invalid customer phone")
+
+// keyedMutex serializes work per key, dropping each mutex once nobody holds or waits for it
+type keyedMutex struct {
+	mu    sync.Mutex
+	locks map[string]*keyedLock
+}
+
+type keyedLock struct {
+	sync.Mutex
+	refs int
+}
+
+// lock blocks until the key is free and returns the function that releases it
+func (k *keyedMutex) lock(key string) func() {
+	k.mu.Lock()
+	if k.locks == nil {
+		k.locks = make(map[string]*keyedLock)
+	}
+	entry, ok := k.locks[key]
+	if !ok {
+		entry = &keyedLock{}
+		k.locks[key] = entry
+	}
+	entry.refs++
+	k.mu.Unlock()
+
+	entry.Lock()
+	return func() {
+		entry.Unlock()
+		k.mu.Lock()
+		entry.refs--
+		if entry.refs == 0 {
+			delete(k.locks, key)
+		}
+		k.mu.Unlock()
+	}
+}
+
+// customerPhoneLocks serializes find-or-create calls for the same tenant and number within this process
+var customerPhoneLocks keyedMutex
+
+// customerPhoneLockKey identifies a tenant number regardless of format or the mobile 9th digit
+func customerPhoneLockKey(tenantID uuid.UUID, phone string) string {
+	variants := utils.PhoneLookupVariants(phone)
+	sort.Strings(variants)
+	return "customer:" + tenantID.String() + ":" + strings.Join(variants, ",")
+}
+
+// GetOrCreateByPhone finds the customer by any format variation of the phone or creates it with the
+// normalized number. Simultaneous first messages from the same number create exactly one customer.
+func (r *CustomerRepository) GetOrCreateByPhone(tenantID uuid.UUID, phone string) (*models.Customer, error) {
+	return getOrCreateCustomerByPhone(tenantID, phone, r.findOrCreateByPhone)
+}
+
+// getOrCreateCustomerByPhone normalizes the phone and runs findOrCreate holding the per-number lock,
+// so concurrent callers in this process wait instead of each opening a transaction
+func getOrCreateCustomerByPhone(tenantID uuid.UUID, phone string, findOrCreate func(tenantID uuid.UUID, phone, lockKey string) (*models.Customer, error)) (*models.Customer, error) {
+	phone = utils.NormalizePhone(phone)
+	if phone == "" {
+		return nil, ErrInvalidCustomerPhone
+	}
+
+	lockKey := customerPhoneLockKey(tenantID, phone)
+	unlock := customerPhoneLocks.lock(lockKey)
+	defer unlock()
+
+	return findOrCreate(tenantID, phone, lockKey)
+}
+
+// findOrCreateByPhone looks the customer up and creates it in one transaction holding a Postgres advisory
+// lock on the number, so other instances handling the same customer wait for the first one to commit
+func (r *CustomerRepository) findOrCreateByPhone(tenantID uuid.UUID, phone, lockKey string) (*models.Customer, error) {
+	var customer models.Customer
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("SELECT pg_advisory_xact_lock(hashtext(?))", lockKey).Error; err != nil {
+			return fmt.Errorf("failed to lock customer phone: %w", err)
+		}
+
+		err := tx.Where("tenant_id = ? AND phone IN ?", tenantID, utils.PhoneLookupVariants(phone)).
+			Order("created_at ASC").First(&customer).Error
+		if err == nil {
+			return nil
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("failed to find customer: %w", err)
+		}
+
+		customer = models.Customer{
+			BaseTenantModel: models.BaseTenantModel{
+				ID:       uuid.New(),
+				TenantID: tenantID,
+			},
+			Phone:    phone,
+			Name:     "", // Nome será solicitado durante o checkout
+			IsActive: true,
+		}
+		if err := tx.Create(&customer).Error; err != nil {
+			return fmt.Errorf("failed to create customer: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &customer, nil
+}
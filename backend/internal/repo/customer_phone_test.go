@@ -0,0 +1,128 @@
+package repo
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"iafarma/internal/utils"
+	"iafarma/pkg/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// memoryCustomerStore mimics the lookup and insert of the customers table, with a gap between them
+// wide enough for a concurrent first message to slip in if callers are not serialized
+type memoryCustomerStore struct {
+	mu        sync.Mutex
+	customers []models.Customer
+}
+
+func (m *memoryCustomerStore) findOrCreate(tenantID uuid.UUID, phone, lockKey string) (*models.Customer, error) {
+	if customer := m.find(tenantID, phone); customer != nil {
+		return customer, nil
+	}
+
+	time.Sleep(20 * time.Millisecond)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	customer := models.Customer{BaseTenantModel: models.BaseTenantModel{ID: uuid.New(), TenantID: tenantID}, Phone: phone, IsActive: true}
+	m.customers = append(m.customers, customer)
+	return &customer, nil
+}
+
+func (m *memoryCustomerStore) find(tenantID uuid.UUID, phone string) *models.Customer {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, variant := range utils.PhoneLookupVariants(phone) {
+		for i := range m.customers {
+			if m.customers[i].TenantID == tenantID && m.customers[i].Phone == variant {
+				customer := m.customers[i]
+				return &customer
+			}
+		}
+	}
+	return nil
+}
+
+func TestGetOrCreateCustomerByPhoneConcurrentFirstMessages(t *testing.T) {
+	tenantID := uuid.New()
+	store := &memoryCustomerStore{}
+
+	// Same number as delivered by two simultaneous webhooks: formatted and without the mobile 9th digit
+	phones := []string{"+55 (27) 99999-0000", "552799990000"}
+
+	var wg sync.WaitGroup
+	results := make([]*models.Customer, len(phones))
+	errs := make([]error, len(phones))
+	start := make(chan struct{})
+	for i, phone := range phones {
+		wg.Add(1)
+		go func(i int, phone string) {
+			defer wg.Done()
+			<-start
+			results[i], errs[i] = getOrCreateCustomerByPhone(tenantID, phone, store.findOrCreate)
+		}(i, phone)
+	}
+	close(start)
+	wg.Wait()
+
+	for i, err := range errs {
+		if err != nil {
+			t.Fatalf("unexpected error for %q: %v", phones[i], err)
+		}
+	}
+	if len(store.customers) != 1 {
+		t.Fatalf("expected exactly one customer, got %d: %+v", len(store.customers), store.customers)
+	}
+	if results[0].ID != results[1].ID {
+		t.Errorf("expected both messages to resolve to the same customer, got %s and %s", results[0].ID, results[1].ID)
+	}
+	if phone := store.customers[0].Phone; phone != "5527999990000" && phone != "552799990000" {
+		t.Errorf("expected the customer to be stored with a normalized phone, got %q", phone)
+	}
+}
+
+func TestGetOrCreateCustomerByPhoneKeepsTenantsApart(t *testing.T) {
+	store := &memoryCustomerStore{}
+
+	first, err := getOrCreateCustomerByPhone(uuid.New(), "27 99999-0000", store.findOrCreate)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	second, err := getOrCreateCustomerByPhone(uuid.New(), "27 99999-0000", store.findOrCreate)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if first.ID == second.ID || len(store.customers) != 2 {
+		t.Errorf("expected one customer per tenant, got %d", len(store.customers))
+	}
+	if first.Phone != "5527999990000" {
+		t.Errorf("expected normalized phone 5527999990000, got %q", first.Phone)
+	}
+}
+
+func TestGetOrCreateCustomerByPhoneRejectsEmptyPhone(t *testing.T) {
+	called := false
+	_, err := getOrCreateCustomerByPhone(uuid.New(), "  ", func(uuid.UUID, string, string) (*models.Customer, error) {
+		called = true
+		return nil, gorm.ErrRecordNotFound
+	})
+	if err != ErrInvalidCustomerPhone || called {
+		t.Errorf("expected ErrInvalidCustomerPhone without touching the store, got %v (called=%v)", err, called)
+	}
+}
+
+func TestKeyedMutexReleasesKeys(t *testing.T) {
+	var locks keyedMutex
+	unlock := locks.lock("a")
+	unlockOther := locks.lock("b") // different keys never block each other
+	unlockOther()
+	unlock()
+
+	if len(locks.locks) != 0 {
+		t.Errorf("expected released keys to be dropped, got %d", len(locks.locks))
+	}
+}
@@ -0,0 +1,68 @@
+package repo
+
+import (
+	"iafarma/pkg/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type FAQRepository struct {
+	db *gorm.DB
+}
+
+func NewFAQRepository(db *gorm.DB) *FAQRepository {
+	return &FAQRepository{db: db}
+}
+
+// Create creates a new FAQ entry
+func (r *FAQRepository) Create(entry *models.FAQEntry) error {
+	return r.db.Create(entry).Error
+}
+
+// GetByID gets a FAQ entry by ID within a tenant
+func (r *FAQRepository) GetByID(tenantID, id uuid.UUID) (*models.FAQEntry, error) {
+	var entry models.FAQEntry
+	err := r.db.Where("id = ? AND tenant_id = ?", id, tenantID).First(&entry).Error
+	return &entry, err
+}
+
+// List lists FAQ entries for a tenant, optionally filtered by a search term
+func (r *FAQRepository) List(tenantID uuid.UUID, search string) ([]models.FAQEntry, error) {
+	var entries []models.FAQEntry
+	query := r.db.Where("tenant_id = ?", tenantID)
+
+	if search != "" {
+		pattern := "%" + search + "%"
+		query = query.Where("question ILIKE ? OR answer ILIKE ? OR keywords ILIKE ?", pattern, pattern, pattern)
+	}
+
+	err := query.Order("question ASC").Find(&entries).Error
+	return entries, err
+}
+
+// ListActive lists the active FAQ entries used by the AI assistant
+func (r *FAQRepository) ListActive(tenantID uuid.UUID) ([]models.FAQEntry, error) {
+	var entries []models.FAQEntry
+	err := r.db.Where("tenant_id = ? AND is_active = ?", tenantID, true).
+		Order("question ASC").
+		Find(&entries).Error
+	return entries, err
+}
+
+// Update updates a FAQ entry
+func (r *FAQRepository) Update(entry *models.FAQEntry) error {
+	return r.db.Save(entry).Error
+}
+
+// Delete deletes a FAQ entry
+func (r *FAQRepository) Delete(tenantID, id uuid.UUID) error {
+	result := r.db.Where("id = ? AND tenant_id = ?", id, tenantID).Delete(&models.FAQEntry{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
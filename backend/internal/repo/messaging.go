@@ -229,13 +229,10 @@ func (r *MessageRepository) ListByConversation(conversationID uuid.UUID, limit,
 // GetUnreadCountByTenant gets the total count of unread messages for a tenant
 func (r *MessageRepository) GetUnreadCountByTenant(tenantID uuid.UUID) (int64, error) {
 	var count int64
-	// fmt.Printf("DEBUG GetUnreadCountByTenant - Tenant ID: %s\n", tenantID)
-
 	err := r.db.Table("conversations").
 		Where("tenant_id = ?", tenantID).
 		Select("COALESCE(SUM(unread_count), 0)").
 		Scan(&count).Error
 
-	// fmt.Printf("DEBUG GetUnreadCountByTenant - Count: %d, Error: %v\n", count, err)
 	return count, err
 }
@@ -0,0 +1,67 @@
+package repo
+
+import (
+	"iafarma/pkg/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ProductAccessoryRepository handles the curated product-accessory relationships
+type ProductAccessoryRepository struct {
+	db *gorm.DB
+}
+
+// NewProductAccessoryRepository creates a new product accessory repository
+func NewProductAccessoryRepository(db *gorm.DB) *ProductAccessoryRepository {
+	return &ProductAccessoryRepository{db: db}
+}
+
+// GetDB returns the database instance for direct queries
+func (r *ProductAccessoryRepository) GetDB() *gorm.DB {
+	return r.db
+}
+
+// Create creates a new accessory relationship
+func (r *ProductAccessoryRepository) Create(accessory *models.ProductAccessory) error {
+	return r.db.Create(accessory).Error
+}
+
+// GetByID gets an accessory relationship by ID
+func (r *ProductAccessoryRepository) GetByID(tenantID, id uuid.UUID) (*models.ProductAccessory, error) {
+	var accessory models.ProductAccessory
+	err := r.db.Preload("Accessory").Where("id = ? AND tenant_id = ?", id, tenantID).First(&accessory).Error
+	if err != nil {
+		return nil, err
+	}
+	return &accessory, nil
+}
+
+// GetByProductID gets the accessories configured for a product, in display order
+func (r *ProductAccessoryRepository) GetByProductID(tenantID, productID uuid.UUID) ([]models.ProductAccessory, error) {
+	var accessories []models.ProductAccessory
+	err := r.db.Preload("Accessory").
+		Where("product_id = ? AND tenant_id = ?", productID, tenantID).
+		Order("sort_order ASC, created_at ASC").
+		Find(&accessories).Error
+	return accessories, err
+}
+
+// Exists reports whether the accessory is already linked to the product
+func (r *ProductAccessoryRepository) Exists(tenantID, productID, accessoryID uuid.UUID) (bool, error) {
+	var count int64
+	err := r.db.Model(&models.ProductAccessory{}).
+		Where("tenant_id = ? AND product_id = ? AND accessory_id = ?", tenantID, productID, accessoryID).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// Update updates an accessory relationship
+func (r *ProductAccessoryRepository) Update(accessory *models.ProductAccessory) error {
+	return r.db.Save(accessory).Error
+}
+
+// Delete deletes an accessory relationship
+func (r *ProductAccessoryRepository) Delete(tenantID, id uuid.UUID) error {
+	return r.db.Where("id = ? AND tenant_id = ?", id, tenantID).Delete(&models.ProductAccessory{}).Error
+}
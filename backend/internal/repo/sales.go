@@ -3,6 +3,8 @@ package repo
 import (
 	"crypto/rand"
 	"encoding/hex"
+	"fmt"
+	"iafarma/internal/utils"
 	"iafarma/pkg/models"
 	"regexp"
 	"strings"
@@ -20,6 +22,32 @@ type ProductFilters struct {
 	HasSKU       *bool    `json:"has_sku,omitempty"`
 	HasStock     *bool    `json:"has_stock,omitempty"`
 	OutOfStock   *bool    `json:"out_of_stock,omitempty"`
+	Available    *bool    `json:"available,omitempty"`
+	Brand        *string  `json:"brand,omitempty"`
+}
+
+// DefaultProductSort is the sort applied when none (or an empty one) is requested
+const DefaultProductSort = "name_asc"
+
+// productSortClauses maps the public sort options to ORDER BY clauses (whitelist, never user SQL)
+var productSortClauses = map[string]string{
+	"name_asc":   "name ASC",
+	"name_desc":  "name DESC",
+	"price_asc":  "price::NUMERIC ASC, name ASC",
+	"price_desc": "price::NUMERIC DESC, name ASC",
+	"stock_asc":  "stock_quantity ASC, name ASC",
+	"stock_desc": "stock_quantity DESC, name ASC",
+	"newest":     "created_at DESC",
+	"oldest":     "created_at ASC",
+}
+
+// ProductOrderClause returns the ORDER BY clause for a sort option and whether the option is valid
+func ProductOrderClause(sort string) (string, bool) {
+	if sort == "" {
+		sort = DefaultProductSort
+	}
+	clause, ok := productSortClauses[sort]
+	return clause, ok
 }
 
 // ProductRepository handles product data access
@@ -312,6 +340,76 @@ func (r *ProductRepository) ListWithSearchAndFiltersAdmin(tenantID uuid.UUID, li
 	// Build base query with tenant filter ONLY (no stock filter for admin)
 	query := r.db.Model(&models.Product{}).Where("tenant_id = ?", tenantID)
 
+	query = applyProductFilters(query, search, filters)
+
+	// Get total count with filters
+	if err := query.Count(&total).Error; err != nil {
+		return nil, err
+	}
+
+	// Get paginated data with filters, ordered by name
+	err := query.Order("name ASC").Limit(limit).Offset(offset).Find(&products).Error
+	if err != nil {
+		return nil, err
+	}
+
+	page := (offset / limit) + 1
+	if limit == 0 {
+		page = 1
+	}
+	totalPages := int((total + int64(limit) - 1) / int64(limit))
+	if limit == 0 {
+		totalPages = 1
+	}
+
+	return &PaginationResult[models.Product]{
+		Data:       products,
+		Total:      total,
+		Page:       page,
+		PerPage:    limit,
+		TotalPages: totalPages,
+	}, nil
+}
+
+// ListPaginated lists products with search, filters and sorting for the product listing API
+func (r *ProductRepository) ListPaginated(tenantID uuid.UUID, limit, offset int, search string, filters ProductFilters, sort string) (*PaginationResult[models.Product], error) {
+	orderClause, ok := ProductOrderClause(sort)
+	if !ok {
+		return nil, fmt.Errorf("invalid sort option: %s", sort)
+	}
+
+	var products []models.Product
+	var total int64
+
+	query := applyProductFilters(r.db.Model(&models.Product{}).Where("tenant_id = ?", tenantID), search, filters)
+
+	// Get total count with filters (separate session so the count does not leak into the listing query)
+	if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		return nil, err
+	}
+
+	if err := query.Order(orderClause).Limit(limit).Offset(offset).Find(&products).Error; err != nil {
+		return nil, err
+	}
+
+	page := 1
+	totalPages := 1
+	if limit > 0 {
+		page = (offset / limit) + 1
+		totalPages = int((total + int64(limit) - 1) / int64(limit))
+	}
+
+	return &PaginationResult[models.Product]{
+		Data:       products,
+		Total:      total,
+		Page:       page,
+		PerPage:    limit,
+		TotalPages: totalPages,
+	}, nil
+}
+
+// applyProductFilters adds the text search and advanced filters to a product query
+func applyProductFilters(query *gorm.DB, search string, filters ProductFilters) *gorm.DB {
 	// Add search filter if provided
 	if search != "" {
 		searchPattern := "%" + search + "%"
@@ -326,6 +424,10 @@ func (r *ProductRepository) ListWithSearchAndFiltersAdmin(tenantID uuid.UUID, li
 		query = query.Where("category_id = ?", *filters.CategoryID)
 	}
 
+	if filters.Brand != nil && *filters.Brand != "" {
+		query = query.Where("LOWER(brand) LIKE LOWER(?)", "%"+*filters.Brand+"%")
+	}
+
 	if filters.MinPrice != nil && *filters.MinPrice > 0 {
 		query = query.Where("price::NUMERIC >= ?", *filters.MinPrice)
 	}
@@ -350,33 +452,67 @@ func (r *ProductRepository) ListWithSearchAndFiltersAdmin(tenantID uuid.UUID, li
 		query = query.Where("stock_quantity IS NULL OR stock_quantity <= 0")
 	}
 
-	// Get total count with filters
-	if err := query.Count(&total).Error; err != nil {
-		return nil, err
+	if filters.Available != nil {
+		query = query.Where("available = ?", *filters.Available)
 	}
 
-	// Get paginated data with filters, ordered by name
-	err := query.Order("name ASC").Limit(limit).Offset(offset).Find(&products).Error
-	if err != nil {
-		return nil, err
+	return query
+}
+
+// SetAvailability pauses or resumes many products of a tenant at once, returning how many were changed
+func (r *ProductRepository) SetAvailability(tenantID uuid.UUID, ids []uuid.UUID, available bool) (int64, error) {
+	result := r.db.Model(&models.Product{}).
+		Where("tenant_id = ? AND id IN ?", tenantID, ids).
+		Update("available", available)
+	return result.RowsAffected, result.Error
+}
+
+// ProductSelection picks the products of a bulk operation: explicit IDs, or every product matching a search and filters
+type ProductSelection struct {
+	IDs     []uuid.UUID
+	Search  string
+	Filters ProductFilters
+}
+
+// AssignCategory moves the selected products of a tenant to a category in a single transaction.
+// It returns how many products matched the selection and how many actually changed category.
+func (r *ProductRepository) AssignCategory(tenantID, categoryID uuid.UUID, selection ProductSelection) (matched, updated int64, err error) {
+	err = r.db.Transaction(func(tx *gorm.DB) error {
+		matched, updated, err = assignCategory(tx, tenantID, categoryID, selection)
+		return err
+	})
+	return matched, updated, err
+}
+
+// assignCategory validates the category against the tenant and re-categorizes the selection using tx
+func assignCategory(tx *gorm.DB, tenantID, categoryID uuid.UUID, selection ProductSelection) (int64, int64, error) {
+	if categoryID == uuid.Nil {
+		return 0, 0, fmt.Errorf("category is required")
+	}
+	if err := utils.ValidateCategoryBelongsToTenant(tx, tenantID, categoryID); err != nil {
+		return 0, 0, err
 	}
 
-	page := (offset / limit) + 1
-	if limit == 0 {
-		page = 1
+	selected := func() *gorm.DB {
+		query := tx.Model(&models.Product{}).Where("tenant_id = ?", tenantID)
+		if len(selection.IDs) > 0 {
+			return query.Where("id IN ?", selection.IDs)
+		}
+		return applyProductFilters(query, selection.Search, selection.Filters)
 	}
-	totalPages := int((total + int64(limit) - 1) / int64(limit))
-	if limit == 0 {
-		totalPages = 1
+
+	var matched int64
+	if err := selected().Count(&matched).Error; err != nil {
+		return 0, 0, fmt.Errorf("failed to count selected products: %w", err)
 	}
 
-	return &PaginationResult[models.Product]{
-		Data:       products,
-		Total:      total,
-		Page:       page,
-		PerPage:    limit,
-		TotalPages: totalPages,
-	}, nil
+	result := selected().
+		Where("category_id IS DISTINCT FROM ?", categoryID).
+		Update("category_id", categoryID)
+	if result.Error != nil {
+		return 0, 0, fmt.Errorf("failed to assign category: %w", result.Error)
+	}
+	return matched, result.RowsAffected, nil
 }
 
 // Delete deletes a product by ID
@@ -404,10 +540,11 @@ func (r *CustomerRepository) GetByID(tenantID, id uuid.UUID) (*models.Customer,
 	return &customer, nil
 }
 
-// GetByPhone gets a customer by phone
+// GetByPhone gets a customer by phone, matching any format variation of the same number
 func (r *CustomerRepository) GetByPhone(tenantID uuid.UUID, phone string) (*models.Customer, error) {
 	var customer models.Customer
-	err := r.db.Where("phone = ? AND tenant_id = ?", phone, tenantID).First(&customer).Error
+	err := r.db.Where("phone IN ? AND tenant_id = ?", utils.PhoneLookupVariants(phone), tenantID).
+		Order("created_at ASC").First(&customer).Error
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,187 @@
+package repo
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+
+	"iafarma/internal/utils"
+
+	"github.com/google/uuid"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// newDryRunProductRepository builds a repository whose queries are only rendered and captured, never executed
+func newDryRunProductRepository(t *testing.T) (*ProductRepository, func() []string) {
+	t.Helper()
+
+	db, err := gorm.Open(postgres.New(postgres.Config{DSN: "host=localhost dbname=dryrun sslmode=disable"}), &gorm.Config{
+		DryRun:                 true,
+		DisableAutomaticPing:   true,
+		SkipDefaultTransaction: true,
+		Logger:                 logger.Discard,
+	})
+	if err != nil {
+		t.Fatalf("failed to open dry-run db: %v", err)
+	}
+
+	var mu sync.Mutex
+	var statements []string
+	capture := func(tx *gorm.DB) {
+		mu.Lock()
+		defer mu.Unlock()
+		statements = append(statements, tx.Statement.SQL.String())
+	}
+	if err := db.Callback().Query().After("gorm:query").Register("test:capture_sql", capture); err != nil {
+		t.Fatalf("failed to register callback: %v", err)
+	}
+	if err := db.Callback().Update().After("gorm:update").Register("test:capture_update_sql", capture); err != nil {
+		t.Fatalf("failed to register callback: %v", err)
+	}
+
+	return NewProductRepository(db), func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string(nil), statements...)
+	}
+}
+
+func TestProductOrderClause(t *testing.T) {
+	tests := []struct {
+		sort     string
+		expected string
+		valid    bool
+	}{
+		{"", "name ASC", true},
+		{"name_desc", "name DESC", true},
+		{"price_asc", "price::NUMERIC ASC, name ASC", true},
+		{"newest", "created_at DESC", true},
+		{"name; DROP TABLE products", "", false},
+	}
+
+	for _, test := range tests {
+		clause, ok := ProductOrderClause(test.sort)
+		if ok != test.valid || clause != test.expected {
+			t.Errorf("ProductOrderClause(%q) = (%q, %v), expected (%q, %v)", test.sort, clause, ok, test.expected, test.valid)
+		}
+	}
+}
+
+func TestListPaginatedBuildsFilteredQuery(t *testing.T) {
+	productRepo, statements := newDryRunProductRepository(t)
+
+	categoryID := uuid.New().String()
+	brand := "Medley"
+	hasStock := true
+	filters := ProductFilters{CategoryID: &categoryID, Brand: &brand, HasStock: &hasStock}
+
+	result, err := productRepo.ListPaginated(uuid.New(), 20, 40, "dipirona", filters, "price_desc")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Page != 3 || result.PerPage != 20 {
+		t.Errorf("page = %d, per_page = %d, expected 3 and 20", result.Page, result.PerPage)
+	}
+
+	captured := statements()
+	if len(captured) != 2 {
+		t.Fatalf("expected count and list queries, got %d: %v", len(captured), captured)
+	}
+
+	count, list := captured[0], captured[1]
+	for _, fragment := range []string{"tenant_id = ", "LOWER(name) LIKE LOWER(", "category_id = ", "LOWER(brand) LIKE LOWER(", "stock_quantity > 0"} {
+		if !strings.Contains(count, fragment) || !strings.Contains(list, fragment) {
+			t.Errorf("expected %q in both queries:\ncount: %s\nlist: %s", fragment, count, list)
+		}
+	}
+	if !strings.Contains(list, "ORDER BY price::NUMERIC DESC, name ASC") || !strings.Contains(list, "LIMIT $7 OFFSET $8") {
+		t.Errorf("list query missing sort or pagination: %s", list)
+	}
+}
+
+func TestListPaginatedRejectsUnknownSort(t *testing.T) {
+	productRepo, statements := newDryRunProductRepository(t)
+
+	if _, err := productRepo.ListPaginated(uuid.New(), 20, 0, "", ProductFilters{}, "random"); err == nil {
+		t.Error("expected error for unknown sort option")
+	}
+	if captured := statements(); len(captured) != 0 {
+		t.Errorf("no query should run for an invalid sort, got %v", captured)
+	}
+}
+
+func TestSetAvailabilityUpdatesOnlyTenantProducts(t *testing.T) {
+	productRepo, statements := newDryRunProductRepository(t)
+
+	if _, err := productRepo.SetAvailability(uuid.New(), []uuid.UUID{uuid.New(), uuid.New()}, false); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	captured := statements()
+	if len(captured) != 1 {
+		t.Fatalf("expected a single update, got %d: %v", len(captured), captured)
+	}
+	for _, fragment := range []string{`UPDATE "products" SET "available"=`, "tenant_id = ", "id IN ("} {
+		if !strings.Contains(captured[0], fragment) {
+			t.Errorf("expected %q in update: %s", fragment, captured[0])
+		}
+	}
+}
+
+// stubCategoryExists makes the dry-run category ownership check find the category
+func stubCategoryExists(t *testing.T, db *gorm.DB) {
+	t.Helper()
+
+	found := func(tx *gorm.DB) {
+		if count, ok := tx.Statement.Dest.(*int64); ok && tx.Statement.Table == "categories" {
+			*count = 1
+			tx.RowsAffected = 1
+		}
+	}
+	if err := db.Callback().Query().After("gorm:query").Register("test:category_exists", found); err != nil {
+		t.Fatalf("failed to register callback: %v", err)
+	}
+}
+
+func TestAssignCategoryUpdatesOnlyTenantProducts(t *testing.T) {
+	productRepo, statements := newDryRunProductRepository(t)
+	stubCategoryExists(t, productRepo.db)
+
+	selection := ProductSelection{IDs: []uuid.UUID{uuid.New(), uuid.New()}}
+	if _, _, err := assignCategory(productRepo.db, uuid.New(), uuid.New(), selection); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	captured := statements()
+	if len(captured) != 3 {
+		t.Fatalf("expected category check, count and update, got %d: %v", len(captured), captured)
+	}
+	if !strings.Contains(captured[0], `FROM "categories"`) || !strings.Contains(captured[0], "tenant_id = ") {
+		t.Errorf("expected category ownership check scoped by tenant: %s", captured[0])
+	}
+	for _, fragment := range []string{`UPDATE "products" SET "category_id"=`, "tenant_id = ", "id IN (", "category_id IS DISTINCT FROM"} {
+		if !strings.Contains(captured[2], fragment) {
+			t.Errorf("expected %q in update: %s", fragment, captured[2])
+		}
+	}
+}
+
+func TestAssignCategoryRejectsCategoryFromAnotherTenant(t *testing.T) {
+	productRepo, statements := newDryRunProductRepository(t)
+
+	search := "dipirona"
+	_, _, err := assignCategory(productRepo.db, uuid.New(), uuid.New(), ProductSelection{Search: search})
+
+	var tenantErr *utils.TenantValidationError
+	if !errors.As(err, &tenantErr) || tenantErr.ResourceType != "category" {
+		t.Fatalf("expected category tenant validation error, got %v", err)
+	}
+	for _, statement := range statements() {
+		if strings.Contains(statement, `"products"`) {
+			t.Errorf("expected no product query after rejection, got: %s", statement)
+		}
+	}
+}
@@ -3,9 +3,11 @@ package services
 import (
 	"fmt"
 	"iafarma/internal/ai"
+	"iafarma/internal/repo"
+	"iafarma/internal/utils"
 	"iafarma/pkg/models"
-	"strconv"
 	"strings"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/rs/zerolog/log"
@@ -31,8 +33,9 @@ func (s *ProductServiceImpl) SearchProducts(tenantID uuid.UUID, query string, li
 func (s *ProductServiceImpl) SearchProductsAdvanced(tenantID uuid.UUID, filters ai.ProductSearchFilters) ([]models.Product, error) {
 	var products []models.Product
 
-	// FILTRO OBRIGATÓRIO: Apenas produtos com estoque > 0 (disponíveis para venda)
-	dbQuery := s.db.Where("tenant_id = ? AND stock_quantity > 0", tenantID)
+	// Por padrão apenas produtos com estoque > 0 (disponíveis para venda)
+	baseCondition := filters.BaseCondition()
+	dbQuery := s.db.Where(baseCondition, tenantID)
 
 	// Full Text Search usando PostgreSQL FTS
 	if filters.Query != "" {
@@ -47,7 +50,7 @@ func (s *ProductServiceImpl) SearchProductsAdvanced(tenantID uuid.UUID, filters
 		log.Info().Msgf("🔍 FTS Debug: tsqueryFormat='%s'", tsqueryFormat)
 
 		// Testar FTS diretamente com operador AND
-		ftsQuery := s.db.Where("tenant_id = ? AND stock_quantity > 0", tenantID).
+		ftsQuery := s.db.Where(baseCondition, tenantID).
 			Where("search_vector @@ to_tsquery('portuguese', ?)", tsqueryFormat)
 
 		// Verificar se FTS encontrou resultados
@@ -63,7 +66,7 @@ func (s *ProductServiceImpl) SearchProductsAdvanced(tenantID uuid.UUID, filters
 				Order("rank DESC, stock_quantity DESC, name ASC")
 		} else {
 			// PRIORIDADE 2: Busca exata no nome se FTS não encontrou
-			exactNameQuery := s.db.Where("tenant_id = ? AND stock_quantity > 0", tenantID).
+			exactNameQuery := s.db.Where(baseCondition, tenantID).
 				Where("LOWER(name) LIKE LOWER(?)", "%"+searchQuery+"%")
 
 			var exactNameCount int64
@@ -92,6 +95,11 @@ func (s *ProductServiceImpl) SearchProductsAdvanced(tenantID uuid.UUID, filters
 		dbQuery = dbQuery.Where("LOWER(brand) LIKE LOWER(?)", "%"+filters.Brand+"%")
 	}
 
+	// Filtro por categoria
+	if filters.CategoryID != nil {
+		dbQuery = dbQuery.Where("category_id = ?", *filters.CategoryID)
+	}
+
 	// Filtro por tags
 	if filters.Tags != "" {
 		dbQuery = dbQuery.Where("LOWER(tags) LIKE LOWER(?)", "%"+filters.Tags+"%")
@@ -99,12 +107,12 @@ func (s *ProductServiceImpl) SearchProductsAdvanced(tenantID uuid.UUID, filters
 
 	// Filtro por preço mínimo
 	if filters.MinPrice > 0 {
-		dbQuery = dbQuery.Where("CAST(price AS DECIMAL) >= ?", filters.MinPrice)
+		dbQuery = dbQuery.Where(ai.ProductPriceSQL+" >= ?", filters.MinPrice)
 	}
 
 	// Filtro por preço máximo
 	if filters.MaxPrice > 0 {
-		dbQuery = dbQuery.Where("CAST(price AS DECIMAL) <= ?", filters.MaxPrice)
+		dbQuery = dbQuery.Where(ai.ProductPriceSQL+" <= ?", filters.MaxPrice)
 	}
 
 	// Aplicar ordenação padrão se não foi definida antes (para casos sem busca)
@@ -127,7 +135,7 @@ func (s *ProductServiceImpl) SearchProductsAdvanced(tenantID uuid.UUID, filters
 func (s *ProductServiceImpl) GetPromotionalProducts(tenantID uuid.UUID) ([]models.Product, error) {
 	var products []models.Product
 
-	err := s.db.Where("tenant_id = ? AND sale_price IS NOT NULL AND sale_price != '' AND sale_price != '0'",
+	err := s.db.Where("tenant_id = ? AND available = true AND sale_price IS NOT NULL AND sale_price != '' AND sale_price != '0'",
 		tenantID).Find(&products).Error
 	return products, err
 }
@@ -185,6 +193,9 @@ func (s *CartServiceImpl) AddItemToCart(cartID, tenantID, productID uuid.UUID, q
 		if err := s.db.Where("id = ? AND tenant_id = ?", productID, tenantID).First(&product).Error; err != nil {
 			return err
 		}
+		if !product.Available {
+			return fmt.Errorf("produto indisponível no momento")
+		}
 
 		// Criar novo item
 		item := models.CartItem{
@@ -195,7 +206,7 @@ func (s *CartServiceImpl) AddItemToCart(cartID, tenantID, productID uuid.UUID, q
 			CartID:      cartID,
 			ProductID:   &productID,
 			Quantity:    quantity,
-			Price:       product.Price,
+			Price:       ai.UnitPriceForQuantity(&product, quantity),
 			ProductName: &product.Name,
 			ProductSKU:  &product.SKU,
 		}
@@ -203,8 +214,9 @@ func (s *CartServiceImpl) AddItemToCart(cartID, tenantID, productID uuid.UUID, q
 	} else if err != nil {
 		return err
 	} else {
-		// Atualizar quantidade do item existente
+		// Atualizar quantidade do item existente (e o preço, que pode mudar de faixa de atacado)
 		existingItem.Quantity += quantity
+		s.repriceCartItem(&existingItem, tenantID)
 		return s.db.Save(&existingItem).Error
 	}
 }
@@ -227,9 +239,66 @@ func (s *CartServiceImpl) ClearCart(cartID, tenantID uuid.UUID) error {
 }
 
 func (s *CartServiceImpl) UpdateCartItemQuantity(cartID, tenantID, itemID uuid.UUID, quantity int) error {
+	var item models.CartItem
+	if err := s.db.Where("cart_id = ? AND id = ?", cartID, itemID).First(&item).Error; err != nil {
+		return err
+	}
+
+	item.Quantity = quantity
+	s.repriceCartItem(&item, tenantID)
+
 	return s.db.Model(&models.CartItem{}).
 		Where("cart_id = ? AND id = ?", cartID, itemID).
-		Update("quantity", quantity).Error
+		Updates(map[string]interface{}{"quantity": item.Quantity, "price": item.Price}).Error
+}
+
+// MergeCartItems soma nas quantidades do item mantido as linhas duplicadas do mesmo produto e remove as duplicatas
+func (s *CartServiceImpl) MergeCartItems(cartID, tenantID, keepItemID uuid.UUID, duplicateItemIDs []uuid.UUID) error {
+	if len(duplicateItemIDs) == 0 {
+		return nil
+	}
+
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		var item models.CartItem
+		if err := tx.Where("cart_id = ? AND id = ?", cartID, keepItemID).First(&item).Error; err != nil {
+			return err
+		}
+
+		var duplicates []models.CartItem
+		if err := tx.Where("cart_id = ? AND id IN ?", cartID, duplicateItemIDs).Find(&duplicates).Error; err != nil {
+			return err
+		}
+		if len(duplicates) == 0 {
+			return nil
+		}
+
+		ids := make([]uuid.UUID, 0, len(duplicates))
+		for _, duplicate := range duplicates {
+			item.Quantity += duplicate.Quantity
+			ids = append(ids, duplicate.ID)
+		}
+		s.repriceCartItem(&item, tenantID)
+
+		if err := tx.Where("cart_id = ? AND id IN ?", cartID, ids).Delete(&models.CartItem{}).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.CartItem{}).
+			Where("cart_id = ? AND id = ?", cartID, keepItemID).
+			Updates(map[string]interface{}{"quantity": item.Quantity, "price": item.Price}).Error
+	})
+}
+
+// repriceCartItem recalcula o preço unitário do item conforme a quantidade (faixas de atacado)
+func (s *CartServiceImpl) repriceCartItem(item *models.CartItem, tenantID uuid.UUID) {
+	if item.ProductID == nil {
+		return
+	}
+
+	var product models.Product
+	if err := s.db.Where("id = ? AND tenant_id = ?", *item.ProductID, tenantID).First(&product).Error; err != nil {
+		return
+	}
+	item.Price = ai.UnitPriceForQuantity(&product, item.Quantity)
 }
 
 func (s *CartServiceImpl) UpdateCartPaymentMethod(cartID, tenantID, paymentMethodID uuid.UUID) error {
@@ -250,6 +319,54 @@ func (s *CartServiceImpl) UpdateCartObservations(cartID, tenantID uuid.UUID, obs
 		Updates(updates).Error
 }
 
+// ConfirmCartAge registra a declaração de maioridade do cliente no carrinho
+func (s *CartServiceImpl) ConfirmCartAge(cartID, tenantID uuid.UUID, confirmedAt time.Time) error {
+	return s.db.Model(&models.Cart{}).
+		Where("id = ? AND tenant_id = ?", cartID, tenantID).
+		Update("age_confirmed_at", confirmedAt).Error
+}
+
+func (s *CartServiceImpl) SetCartPickup(cartID, tenantID uuid.UUID, pickup bool) error {
+	return s.db.Model(&models.Cart{}).
+		Where("id = ? AND tenant_id = ?", cartID, tenantID).
+		Update("is_pickup", pickup).Error
+}
+
+// AttachCartPrescription guarda no carrinho a foto da receita enviada pelo cliente
+func (s *CartServiceImpl) AttachCartPrescription(cartID, tenantID uuid.UUID, imageURL string) error {
+	return s.db.Model(&models.Cart{}).
+		Where("id = ? AND tenant_id = ?", cartID, tenantID).
+		Update("prescription_url", imageURL).Error
+}
+
+// UpdateCartInstallments guarda no carrinho o número de parcelas escolhido pelo cliente
+func (s *CartServiceImpl) UpdateCartInstallments(cartID, tenantID uuid.UUID, installments int) error {
+	return s.db.Model(&models.Cart{}).
+		Where("id = ? AND tenant_id = ?", cartID, tenantID).
+		Update("installment_count", installments).Error
+}
+
+// SetCartUrgent marca o carrinho como pedido urgente (prioridade na separação e entrega)
+func (s *CartServiceImpl) SetCartUrgent(cartID, tenantID uuid.UUID, urgent bool) error {
+	return s.db.Model(&models.Cart{}).
+		Where("id = ? AND tenant_id = ?", cartID, tenantID).
+		Update("is_urgent", urgent).Error
+}
+
+// SetCartDeposit marca o carrinho para pagamento com sinal (parte antecipada e o restante na entrega)
+func (s *CartServiceImpl) SetCartDeposit(cartID, tenantID uuid.UUID, payDeposit bool) error {
+	return s.db.Model(&models.Cart{}).
+		Where("id = ? AND tenant_id = ?", cartID, tenantID).
+		Update("pay_deposit", payDeposit).Error
+}
+
+// SetCartItemAddress define o endereço de entrega de um item do carrinho (nil = endereço principal do pedido)
+func (s *CartServiceImpl) SetCartItemAddress(cartID, tenantID, itemID uuid.UUID, addressID *uuid.UUID) error {
+	return s.db.Model(&models.CartItem{}).
+		Where("id = ? AND cart_id = ? AND tenant_id = ?", itemID, cartID, tenantID).
+		Update("delivery_address_id", addressID).Error
+}
+
 type OrderServiceImpl struct {
 	db *gorm.DB
 }
@@ -267,6 +384,21 @@ func (s *OrderServiceImpl) CreateOrderFromCartWithAddress(tenantID, cartID uuid.
 }
 
 func (s *OrderServiceImpl) CreateOrderFromCartWithConversation(tenantID, cartID, conversationID uuid.UUID, deliveryAddress *models.Address) (*models.Order, error) {
+	return s.CreateOrderFromCartItems(tenantID, cartID, conversationID, nil, deliveryAddress)
+}
+
+// CreateOrderFromCartItems cria o pedido com os itens informados do carrinho (itemIDs nil = todos os itens)
+func (s *OrderServiceImpl) CreateOrderFromCartItems(tenantID, cartID, conversationID uuid.UUID, itemIDs []uuid.UUID, deliveryAddress *models.Address) (*models.Order, error) {
+	orders, err := s.CreateOrdersFromCartGroups(tenantID, cartID, conversationID, []ai.CartOrderGroup{{ItemIDs: itemIDs, DeliveryAddress: deliveryAddress}})
+	if err != nil {
+		return nil, err
+	}
+	return orders[0], nil
+}
+
+// CreateOrdersFromCartGroups cria um pedido por grupo de itens do carrinho numa única transação: se algum pedido
+// falhar, nenhum é criado e o carrinho continua aberto
+func (s *OrderServiceImpl) CreateOrdersFromCartGroups(tenantID, cartID, conversationID uuid.UUID, groups []ai.CartOrderGroup) ([]*models.Order, error) {
 	// Obter carrinho com itens e cliente
 	var cart models.Cart
 	err := s.db.Preload("Items").Preload("Items.Product").Preload("Customer").
@@ -275,30 +407,83 @@ func (s *OrderServiceImpl) CreateOrderFromCartWithConversation(tenantID, cartID,
 		return nil, err
 	}
 
+	// Iniciar transação para garantir consistência
+	tx := s.db.Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	orders := make([]*models.Order, 0, len(groups))
+	for _, group := range groups {
+		order, err := s.createCartOrder(tx, tenantID, cart, conversationID, group)
+		if err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+		orders = append(orders, order)
+	}
+
+	// Marcar carrinho como processado
+	err = tx.Model(&cart).Update("status", "processed").Error
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	// Confirmar transação
+	err = tx.Commit().Error
+	if err != nil {
+		return nil, err
+	}
+
+	// Recarregar os pedidos com todos os dados
+	for _, order := range orders {
+		err = s.db.Preload("Items").Where("id = ?", order.ID).First(order).Error
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return orders, nil
+}
+
+// createCartOrder grava na transação tx o pedido com os itens do grupo, copiando do carrinho cliente e pagamento
+func (s *OrderServiceImpl) createCartOrder(tx *gorm.DB, tenantID uuid.UUID, cart models.Cart, conversationID uuid.UUID, group ai.CartOrderGroup) (*models.Order, error) {
+	if group.ItemIDs != nil {
+		cart.Items = ai.SelectCartItems(cart.Items, group.ItemIDs)
+	}
+	deliveryAddress := group.DeliveryAddress
+
 	if len(cart.Items) == 0 {
 		return nil, fmt.Errorf("carrinho vazio")
 	}
 
 	// Calcular total e subtotal
-	subtotal := 0.0
+	var subtotal utils.Cents
 	for _, item := range cart.Items {
-		price, _ := strconv.ParseFloat(item.Price, 64)
-		subtotal += price * float64(item.Quantity)
+		price, _ := utils.ParseCents(item.Price)
+		subtotal += price.Times(item.Quantity)
 	}
 
 	// Criar pedido com status pendente (não requer pagamento imediato)
+	// Número no formato configurado pelo tenant (ex: "PH-{seq}") ou no formato padrão
+	nextNumber := func() string { return ai.NextOrderNumber(s.db, tenantID, time.Now(), generateOrderNumber) }
+	orderNumber := nextNumber()
+
 	order := models.Order{
 		BaseTenantModel: models.BaseTenantModel{
 			ID:       uuid.New(),
 			TenantID: tenantID,
 		},
 		CustomerID:        &cart.CustomerID,
-		OrderNumber:       generateOrderNumber(),
+		OrderNumber:       orderNumber,
 		Status:            "pending",
 		PaymentStatus:     "pending", // Pagamento pendente - será processado depois
 		FulfillmentStatus: "pending",
-		TotalAmount:       fmt.Sprintf("%.2f", subtotal),
-		Subtotal:          fmt.Sprintf("%.2f", subtotal),
+		TotalAmount:       subtotal.String(),
+		Subtotal:          subtotal.String(),
 		TaxAmount:         "0.00",
 		ShippingAmount:    "0.00",
 		DiscountAmount:    "0.00",
@@ -324,7 +509,9 @@ func (s *OrderServiceImpl) CreateOrderFromCartWithConversation(tenantID, cartID,
 		order.ShippingName = &deliveryAddress.Name
 		order.ShippingStreet = &deliveryAddress.Street
 		order.ShippingNumber = &deliveryAddress.Number
-		order.ShippingComplement = &deliveryAddress.Complement
+		shippingComplement := deliveryAddress.DeliveryComplement()
+		order.ShippingComplement = &shippingComplement
+		order.ShippingReference = &deliveryAddress.ReferencePoint
 		order.ShippingNeighborhood = &deliveryAddress.Neighborhood
 		order.ShippingCity = &deliveryAddress.City
 		order.ShippingState = &deliveryAddress.State
@@ -342,26 +529,23 @@ func (s *OrderServiceImpl) CreateOrderFromCartWithConversation(tenantID, cartID,
 	if cart.ChangeFor != "" {
 		order.ChangeFor = cart.ChangeFor
 	}
+	if cart.AgeConfirmedAt != nil {
+		order.AgeConfirmedAt = cart.AgeConfirmedAt
+	}
+	order.IsPickup = cart.IsPickup
+	order.PrescriptionURL = cart.PrescriptionURL
+	order.PrescriptionRequired = ai.CartRequiresPrescription(&cart)
 
-	// Iniciar transação para garantir consistência
-	tx := s.db.Begin()
-	defer func() {
-		if r := recover(); r != nil {
-			tx.Rollback()
-		}
-	}()
-
-	// Criar pedido
-	err = tx.Create(&order).Error
+	// Criar pedido (com outro número se um checkout simultâneo usou o mesmo)
+	err := ai.CreateOrderWithUniqueNumber(tx, &order, nextNumber)
 	if err != nil {
-		tx.Rollback()
 		return nil, err
 	}
 
 	// Criar itens do pedido
 	for _, cartItem := range cart.Items {
-		itemPrice, _ := strconv.ParseFloat(cartItem.Price, 64)
-		itemTotal := itemPrice * float64(cartItem.Quantity)
+		itemPrice, _ := utils.ParseCents(cartItem.Price)
+		itemTotal := itemPrice.Times(cartItem.Quantity)
 
 		orderItem := models.OrderItem{
 			BaseTenantModel: models.BaseTenantModel{
@@ -372,7 +556,7 @@ func (s *OrderServiceImpl) CreateOrderFromCartWithConversation(tenantID, cartID,
 			ProductID: cartItem.ProductID,
 			Quantity:  cartItem.Quantity,
 			Price:     cartItem.Price,
-			Total:     fmt.Sprintf("%.2f", itemTotal),
+			Total:     itemTotal.String(),
 		}
 
 		// Copiar dados históricos do produto
@@ -385,43 +569,139 @@ func (s *OrderServiceImpl) CreateOrderFromCartWithConversation(tenantID, cartID,
 
 		err = tx.Create(&orderItem).Error
 		if err != nil {
-			tx.Rollback()
 			return nil, err
 		}
 	}
 
-	// Marcar carrinho como processado
-	err = tx.Model(&cart).Update("status", "processed").Error
+	return &order, nil
+}
+
+func (s *OrderServiceImpl) GetOrderByID(tenantID, orderID uuid.UUID) (*models.Order, error) {
+	var order models.Order
+	err := s.db.Preload("Items").Preload("Items.Attributes").Preload("PaymentMethod").
+		Where("id = ? AND tenant_id = ?", orderID, tenantID).First(&order).Error
 	if err != nil {
-		tx.Rollback()
 		return nil, err
 	}
+	return &order, nil
+}
 
-	// Confirmar transação
-	err = tx.Commit().Error
-	if err != nil {
+func (s *OrderServiceImpl) CancelOrder(tenantID, orderID uuid.UUID) error {
+	return s.db.Model(&models.Order{}).
+		Where("id = ? AND tenant_id = ?", orderID, tenantID).
+		Update("status", "cancelled").Error
+}
+
+// ApplyShippingAmount define a taxa de entrega do pedido e recalcula o total
+func (s *OrderServiceImpl) ApplyShippingAmount(tenantID, orderID uuid.UUID, shippingAmount float64) (*models.Order, error) {
+	var order models.Order
+	if err := s.db.Where("id = ? AND tenant_id = ?", orderID, tenantID).First(&order).Error; err != nil {
 		return nil, err
 	}
 
-	// Recarregar o pedido com todos os dados
-	err = s.db.Preload("Items").Where("id = ?", order.ID).First(&order).Error
+	subtotal, _ := utils.ParseCents(order.Subtotal)
+	tax, _ := utils.ParseCents(order.TaxAmount)
+	discount, _ := utils.ParseCents(order.DiscountAmount)
+	urgencyFee, _ := utils.ParseCents(order.UrgencyFee)
+	shipping := utils.CentsFromFloat(shippingAmount)
+
+	order.ShippingAmount = shipping.String()
+	order.TotalAmount = (subtotal + tax + shipping + urgencyFee - discount).String()
+
+	err := s.db.Model(&order).Updates(map[string]interface{}{
+		"shipping_amount": order.ShippingAmount,
+		"total_amount":    order.TotalAmount,
+	}).Error
 	if err != nil {
 		return nil, err
 	}
+	return &order, nil
+}
+
+// ApplyInstallmentPlan registra no pedido o parcelamento escolhido (os juros do cartão ficam no valor da parcela)
+func (s *OrderServiceImpl) ApplyInstallmentPlan(tenantID, orderID uuid.UUID, installments int, installmentAmount float64) (*models.Order, error) {
+	var order models.Order
+	if err := s.db.Where("id = ? AND tenant_id = ?", orderID, tenantID).First(&order).Error; err != nil {
+		return nil, err
+	}
+
+	order.InstallmentCount = installments
+	order.InstallmentAmount = utils.FormatMoney(installmentAmount)
 
+	err := s.db.Model(&order).Updates(map[string]interface{}{
+		"installment_count":  order.InstallmentCount,
+		"installment_amount": order.InstallmentAmount,
+	}).Error
+	if err != nil {
+		return nil, err
+	}
 	return &order, nil
 }
 
-func (s *OrderServiceImpl) CancelOrder(tenantID, orderID uuid.UUID) error {
+// MarkOrderForReview sinaliza que o pedido precisa da confirmação manual de um operador
+func (s *OrderServiceImpl) MarkOrderForReview(tenantID, orderID uuid.UUID) error {
 	return s.db.Model(&models.Order{}).
 		Where("id = ? AND tenant_id = ?", orderID, tenantID).
-		Update("status", "cancelled").Error
+		Update("requires_review", true).Error
+}
+
+// ApplyUrgency marca o pedido como urgente e soma a taxa expressa ao total
+func (s *OrderServiceImpl) ApplyUrgency(tenantID, orderID uuid.UUID, urgencyFee float64) (*models.Order, error) {
+	var order models.Order
+	if err := s.db.Where("id = ? AND tenant_id = ?", orderID, tenantID).First(&order).Error; err != nil {
+		return nil, err
+	}
+
+	subtotal, _ := utils.ParseCents(order.Subtotal)
+	tax, _ := utils.ParseCents(order.TaxAmount)
+	shipping, _ := utils.ParseCents(order.ShippingAmount)
+	discount, _ := utils.ParseCents(order.DiscountAmount)
+	fee := utils.CentsFromFloat(urgencyFee)
+
+	order.IsUrgent = true
+	order.UrgencyFee = fee.String()
+	order.TotalAmount = (subtotal + tax + shipping + fee - discount).String()
+
+	err := s.db.Model(&order).Updates(map[string]interface{}{
+		"is_urgent":    order.IsUrgent,
+		"urgency_fee":  order.UrgencyFee,
+		"total_amount": order.TotalAmount,
+	}).Error
+	if err != nil {
+		return nil, err
+	}
+	return &order, nil
+}
+
+// ApplyDeposit registra no pedido o sinal pago antecipadamente e o restante a receber na entrega
+func (s *OrderServiceImpl) ApplyDeposit(tenantID, orderID uuid.UUID, depositMethod string, amountPaid, amountDue float64) (*models.Order, error) {
+	var order models.Order
+	if err := s.db.Where("id = ? AND tenant_id = ?", orderID, tenantID).First(&order).Error; err != nil {
+		return nil, err
+	}
+
+	order.HasDeposit = true
+	order.DepositPaymentMethod = depositMethod
+	order.AmountPaid = utils.FormatMoney(amountPaid)
+	order.AmountDue = utils.FormatMoney(amountDue)
+
+	err := s.db.Model(&order).Updates(map[string]interface{}{
+		"has_deposit":            order.HasDeposit,
+		"deposit_payment_method": order.DepositPaymentMethod,
+		"amount_paid":            order.AmountPaid,
+		"amount_due":             order.AmountDue,
+	}).Error
+	if err != nil {
+		return nil, err
+	}
+	return &order, nil
 }
 
 func (s *OrderServiceImpl) GetOrdersByCustomer(tenantID, customerID uuid.UUID) ([]models.Order, error) {
 	var orders []models.Order
 
-	err := s.db.Where("tenant_id = ? AND customer_id = ?", tenantID, customerID).
+	err := s.db.Preload("PaymentMethod").
+		Where("tenant_id = ? AND customer_id = ?", tenantID, customerID).
 		Order("created_at DESC").Find(&orders).Error
 
 	return orders, err
@@ -444,7 +724,7 @@ func (s *OrderServiceImpl) GetPaymentOptions(tenantID uuid.UUID) ([]ai.PaymentOp
 		options[i] = ai.PaymentOption{
 			ID:           pm.ID.String(),
 			Name:         pm.Name,
-			Instructions: "",
+			Instructions: pm.Instructions,
 		}
 	}
 
@@ -459,15 +739,14 @@ func NewCustomerService(db *gorm.DB) ai.CustomerServiceInterface {
 	return &CustomerServiceImpl{db: db}
 }
 
-func (s *CustomerServiceImpl) GetCustomerByPhone(tenantID uuid.UUID, phone string) (*models.Customer, error) {
-	var customer models.Customer
-
-	err := s.db.Where("tenant_id = ? AND phone = ?", tenantID, phone).First(&customer).Error
+// GetOrCreateCustomerByPhone busca o cliente por qualquer variação do telefone ou cria com o número normalizado,
+// sem duplicar o cliente quando as primeiras mensagens chegam ao mesmo tempo
+func (s *CustomerServiceImpl) GetOrCreateCustomerByPhone(tenantID uuid.UUID, phone string) (*models.Customer, error) {
+	customer, err := repo.NewCustomerRepository(s.db).GetOrCreateByPhone(tenantID, phone)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("erro ao buscar ou criar cliente: %w", err)
 	}
-
-	return &customer, nil
+	return customer, nil
 }
 
 func (s *CustomerServiceImpl) GetCustomerByID(tenantID, customerID uuid.UUID) (*models.Customer, error) {
@@ -481,6 +760,31 @@ func (s *CustomerServiceImpl) GetCustomerByID(tenantID, customerID uuid.UUID) (*
 	return &customer, nil
 }
 
+// MarkCustomerWelcomed registra o envio da mensagem de boas-vindas para o cliente
+func (s *CustomerServiceImpl) MarkCustomerWelcomed(tenantID, customerID uuid.UUID, welcomedAt time.Time) error {
+	return s.db.Model(&models.Customer{}).
+		Where("id = ? AND tenant_id = ?", customerID, tenantID).
+		Update("last_welcomed_at", welcomedAt).Error
+}
+
+// SetCustomerLanguage grava o idioma escolhido pelo cliente
+func (s *CustomerServiceImpl) SetCustomerLanguage(tenantID, customerID uuid.UUID, language string) error {
+	return s.db.Model(&models.Customer{}).
+		Where("id = ? AND tenant_id = ?", customerID, tenantID).
+		Update("language", language).Error
+}
+
+// MarkCustomerSeen registra a mensagem mais recente do cliente e, ao iniciar uma nova visita, o fim da anterior
+func (s *CustomerServiceImpl) MarkCustomerSeen(tenantID, customerID uuid.UUID, seenAt time.Time, previousVisitAt *time.Time) error {
+	updates := map[string]interface{}{"last_seen_at": seenAt}
+	if previousVisitAt != nil {
+		updates["previous_visit_at"] = *previousVisitAt
+	}
+	return s.db.Model(&models.Customer{}).
+		Where("id = ? AND tenant_id = ?", customerID, tenantID).
+		Updates(updates).Error
+}
+
 func (s *CustomerServiceImpl) UpdateCustomerProfile(tenantID, customerID uuid.UUID, data ai.CustomerUpdateData) error {
 	updates := make(map[string]interface{})
 
@@ -559,24 +863,23 @@ func (s *AddressServiceImpl) SetDefaultAddress(tenantID, customerID, addressID u
 }
 
 func (s *AddressServiceImpl) DeleteAddress(tenantID, customerID, addressID uuid.UUID) error {
-	// Verificar se é o último endereço
-	var count int64
-	err := s.db.Model(&models.Address{}).
-		Where("customer_id = ? AND tenant_id = ?", customerID, tenantID).
-		Count(&count).Error
+	// Deletar o endereço
+	err := s.db.Where("id = ? AND customer_id = ? AND tenant_id = ?", addressID, customerID, tenantID).
+		Delete(&models.Address{}).Error
 	if err != nil {
 		return err
 	}
 
-	// Deletar o endereço
-	err = s.db.Where("id = ? AND customer_id = ? AND tenant_id = ?", addressID, customerID, tenantID).
-		Delete(&models.Address{}).Error
+	// Se não restou endereço padrão (ex: deletou o padrão), definir o mais antigo como padrão
+	var defaultCount int64
+	err = s.db.Model(&models.Address{}).
+		Where("customer_id = ? AND tenant_id = ? AND is_default = ?", customerID, tenantID, true).
+		Count(&defaultCount).Error
 	if err != nil {
 		return err
 	}
 
-	// Se deletou um endereço padrão e ainda há outros, definir o primeiro como padrão
-	if count > 1 {
+	if defaultCount == 0 {
 		var firstAddress models.Address
 		err = s.db.Where("customer_id = ? AND tenant_id = ?", customerID, tenantID).
 			Order("created_at ASC").First(&firstAddress).Error
@@ -593,6 +896,16 @@ func (s *AddressServiceImpl) DeleteAllAddresses(tenantID, customerID uuid.UUID)
 		Delete(&models.Address{}).Error
 }
 
+// GetProductsBySKU busca os produtos do catálogo pelos SKUs (usado para resolver os itens do estoque externo)
+func (s *ProductServiceImpl) GetProductsBySKU(tenantID uuid.UUID, skus []string) ([]models.Product, error) {
+	var products []models.Product
+	if len(skus) == 0 {
+		return products, nil
+	}
+	err := s.db.Where("tenant_id = ? AND sku IN ?", tenantID, skus).Find(&products).Error
+	return products, err
+}
+
 // GetAllTenants returns all tenants from database
 func (s *ProductServiceImpl) GetAllTenants() ([]models.Tenant, error) {
 	var tenants []models.Tenant
@@ -617,6 +930,71 @@ func (s *ProductServiceImpl) GetProductsByTenantID(tenantID uuid.UUID) ([]models
 	return products, err
 }
 
+// GetProductImageURLs retorna a primeira imagem (menor sort_order) de cada produto informado
+func (s *ProductServiceImpl) GetProductImageURLs(tenantID uuid.UUID, productIDs []uuid.UUID) (map[uuid.UUID]string, error) {
+	images := make(map[uuid.UUID]string)
+	if len(productIDs) == 0 {
+		return images, nil
+	}
+
+	var media []models.ProductMedia
+	err := s.db.Where("tenant_id = ? AND product_id IN ? AND type = ?", tenantID, productIDs, "image").
+		Order("sort_order ASC, created_at ASC").Find(&media).Error
+	if err != nil {
+		return nil, err
+	}
+
+	for _, item := range media {
+		if _, exists := images[item.ProductID]; !exists && item.URL != "" {
+			images[item.ProductID] = item.URL
+		}
+	}
+	return images, nil
+}
+
+// GetProductAccessories retorna os acessórios cadastrados pela loja para o produto, na ordem de exibição
+func (s *ProductServiceImpl) GetProductAccessories(tenantID, productID uuid.UUID) ([]models.ProductAccessory, error) {
+	return repo.NewProductAccessoryRepository(s.db).GetByProductID(tenantID, productID)
+}
+
+// GetProductsByCategory retorna produtos à venda (disponíveis e com estoque) de uma categoria
+func (s *ProductServiceImpl) GetProductsByCategory(tenantID, categoryID uuid.UUID, limit int) ([]models.Product, error) {
+	var products []models.Product
+	err := s.db.Where(ai.ProductSearchFilters{}.BaseCondition(), tenantID).
+		Where("category_id = ?", categoryID).
+		Order("name ASC").
+		Limit(limit).
+		Find(&products).Error
+	return products, err
+}
+
+// GetBestSellingProducts retorna os produtos vendáveis com mais unidades vendidas
+func (s *ProductServiceImpl) GetBestSellingProducts(tenantID uuid.UUID, limit int) ([]models.Product, error) {
+	var products []models.Product
+	err := s.db.Joins(ai.BestSellersJoinSQL, tenantID).
+		Where(ai.ProductSearchFilters{}.BaseCondition(), tenantID).
+		Order("sales.sold DESC, products.name ASC").
+		Limit(limit).
+		Find(&products).Error
+	return products, err
+}
+
+// GetProductSalesSince retorna as vendas por produto vendável nos pedidos não cancelados desde since
+func (s *ProductServiceImpl) GetProductSalesSince(tenantID uuid.UUID, since time.Time) ([]ai.ProductSales, error) {
+	return ai.LoadProductSales(s.db, tenantID, since)
+}
+
+// GetProductsChangedSince retorna os produtos vendáveis cadastrados ou alterados com preço promocional depois de since
+func (s *ProductServiceImpl) GetProductsChangedSince(tenantID uuid.UUID, since time.Time, limit int) ([]models.Product, error) {
+	var products []models.Product
+	err := s.db.Where(ai.ProductSearchFilters{}.BaseCondition(), tenantID).
+		Where("created_at > ? OR (sale_price IS NOT NULL AND sale_price <> '' AND updated_at > ?)", since, since).
+		Order("created_at DESC, name ASC").
+		Limit(limit).
+		Find(&products).Error
+	return products, err
+}
+
 // Funções auxiliares
 func generateOrderNumber() string {
 	return fmt.Sprintf("PED%d", uuid.New().ID())
@@ -8,9 +8,11 @@ import (
 	"sync"
 	"time"
 
+	"iafarma/internal/ai"
 	"iafarma/internal/zapplus"
 	"iafarma/pkg/models"
 
+	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
 
@@ -24,6 +26,13 @@ type ChannelMonitorService struct {
 	stopChan       chan struct{}
 	failedChannels map[string]*FailedChannel
 	zapClient      *zapplus.Client
+	settingsWarmer settingsWarmer
+	warmedChannels map[string]bool
+}
+
+// settingsWarmer preloads the settings the AI pipeline reads for a tenant
+type settingsWarmer interface {
+	WarmUp(ctx context.Context, tenantID uuid.UUID) error
 }
 
 // FailedChannel represents a channel that failed monitoring
@@ -44,6 +53,8 @@ func NewChannelMonitorService(db *gorm.DB, emailService *EmailService) *ChannelM
 		failedChannels: make(map[string]*FailedChannel),
 		stopChan:       make(chan struct{}),
 		zapClient:      zapplus.GetClient(),
+		settingsWarmer: ai.NewTenantSettingsService(db),
+		warmedChannels: make(map[string]bool),
 	}
 }
 
@@ -138,10 +149,12 @@ func (cms *ChannelMonitorService) checkAllChannels(ctx context.Context) {
 
 				// Atualizar status do canal no banco
 				cms.updateChannelStatus(&ch, "disconnected")
+				cms.forgetWarmedChannel(&ch)
 				log.Printf("❌ Canal %s (sessão: %s) falhou: %v", ch.Name, ch.Session, err)
-			} // else {
-			//log.Printf("✅ Canal %s (sessão: %s) está funcionando", ch.Name, ch.Session)
-			// }
+			} else {
+				//log.Printf("✅ Canal %s (sessão: %s) está funcionando", ch.Name, ch.Session)
+				cms.warmUpChannelTenant(ctx, &ch)
+			}
 		}(channel)
 	}
 
@@ -176,6 +189,31 @@ func (cms *ChannelMonitorService) checkChannelStatus(ctx context.Context, channe
 	return nil
 }
 
+// warmUpChannelTenant preloads the tenant settings the first time the channel is seen connected,
+// so the first customer message does not wait for them to load
+func (cms *ChannelMonitorService) warmUpChannelTenant(ctx context.Context, channel *models.Channel) {
+	cms.mutex.Lock()
+	if cms.warmedChannels[channel.ID.String()] || cms.settingsWarmer == nil {
+		cms.mutex.Unlock()
+		return
+	}
+	cms.warmedChannels[channel.ID.String()] = true
+	cms.mutex.Unlock()
+
+	if err := cms.settingsWarmer.WarmUp(ctx, channel.TenantID); err != nil {
+		log.Printf("⚠️ Erro ao pré-carregar configurações do tenant %s: %v", channel.TenantID, err)
+		// Tenta de novo na próxima verificação
+		cms.forgetWarmedChannel(channel)
+	}
+}
+
+// forgetWarmedChannel makes the next connection of the channel warm up its tenant settings again
+func (cms *ChannelMonitorService) forgetWarmedChannel(channel *models.Channel) {
+	cms.mutex.Lock()
+	defer cms.mutex.Unlock()
+	delete(cms.warmedChannels, channel.ID.String())
+}
+
 // updateChannelStatus updates the channel status in database
 func (cms *ChannelMonitorService) updateChannelStatus(channel *models.Channel, status string) {
 	err := cms.db.Model(channel).Update("status", status).Error
@@ -405,6 +443,7 @@ func (crs *ChannelReconnectionService) checkForReconnections(ctx context.Context
 
 			// Atualizar status no banco
 			crs.channelMonitor.updateChannelStatus(&channel, "connected")
+			crs.channelMonitor.warmUpChannelTenant(ctx, &channel)
 
 			// Enviar notificação de reconexão
 			crs.sendReconnectionNotification(&channel, disconnectedChannel)
@@ -512,7 +551,7 @@ func (crs *ChannelReconnectionService) sendReconnectionNotification(channel *mod
         
         <div style="background: #f8fafc; padding: 20px; border-radius: 8px; margin-bottom: 20px;">
             <h2 style="color: #16a34a; margin-top: 0;">Detalhes da Reconexão</h2>
-            <table style="width: 100%; border-collapse: collapse;">
+            <table style="width: 100%%; border-collapse: collapse;">
                 <tr>
                     <td style="padding: 8px 0; font-weight: bold; width: 120px;">Canal:</td>
                     <td style="padding: 8px 0;">%s</td>
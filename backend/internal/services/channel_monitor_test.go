@@ -0,0 +1,57 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"iafarma/pkg/models"
+
+	"github.com/google/uuid"
+)
+
+// fakeSettingsWarmer records the tenants whose settings were preloaded
+type fakeSettingsWarmer struct {
+	warmed []uuid.UUID
+	err    error
+}
+
+func (f *fakeSettingsWarmer) WarmUp(ctx context.Context, tenantID uuid.UUID) error {
+	f.warmed = append(f.warmed, tenantID)
+	return f.err
+}
+
+func TestWarmUpChannelTenantOncePerConnection(t *testing.T) {
+	warmer := &fakeSettingsWarmer{}
+	cms := &ChannelMonitorService{settingsWarmer: warmer, warmedChannels: make(map[string]bool)}
+	channel := &models.Channel{BaseTenantModel: models.BaseTenantModel{ID: uuid.New(), TenantID: uuid.New()}}
+
+	// Checks while the channel stays connected do not reload the settings
+	cms.warmUpChannelTenant(context.Background(), channel)
+	cms.warmUpChannelTenant(context.Background(), channel)
+	if len(warmer.warmed) != 1 || warmer.warmed[0] != channel.TenantID {
+		t.Fatalf("expected one warm-up for tenant %s, got %v", channel.TenantID, warmer.warmed)
+	}
+
+	// A reconnection warms the tenant up again
+	cms.forgetWarmedChannel(channel)
+	cms.warmUpChannelTenant(context.Background(), channel)
+	if len(warmer.warmed) != 2 {
+		t.Errorf("expected a new warm-up after reconnecting, got %d", len(warmer.warmed))
+	}
+}
+
+func TestWarmUpChannelTenantRetriesAfterError(t *testing.T) {
+	warmer := &fakeSettingsWarmer{err: errors.New("connection refused")}
+	cms := &ChannelMonitorService{settingsWarmer: warmer, warmedChannels: make(map[string]bool)}
+	channel := &models.Channel{BaseTenantModel: models.BaseTenantModel{ID: uuid.New(), TenantID: uuid.New()}}
+
+	cms.warmUpChannelTenant(context.Background(), channel)
+	warmer.err = nil
+	cms.warmUpChannelTenant(context.Background(), channel)
+	cms.warmUpChannelTenant(context.Background(), channel)
+
+	if len(warmer.warmed) != 2 {
+		t.Errorf("expected the failed warm-up to be retried once, got %d calls", len(warmer.warmed))
+	}
+}
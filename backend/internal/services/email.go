@@ -15,6 +15,7 @@ import (
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/ses"
 	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
 	"gorm.io/gorm"
 )
 
@@ -128,13 +129,11 @@ func (s *EmailService) sendEmailWithSES(to []string, subject, body string) error
 
 	_, err := s.sesClient.SendEmail(input)
 	if err != nil {
-		// Add more detailed error logging for SES issues
-		fmt.Printf("❌ SES Error Details - Region: %s, From: %s, To: %v, Error: %v\n",
-			*s.sesClient.Config.Region, s.fromEmail, to, err)
+		log.Error().Err(err).Str("region", *s.sesClient.Config.Region).Int("recipients", len(to)).Msg("❌ Erro ao enviar email via SES")
 		return fmt.Errorf("failed to send email via SES: %w", err)
 	}
 
-	fmt.Printf("✅ Email sent successfully via SES to: %v\n", to)
+	log.Debug().Int("recipients", len(to)).Msg("✅ Email enviado via SES")
 	return nil
 }
 
@@ -776,6 +776,21 @@ func (s *EmbeddingService) CleanupOldConversations(tenantID, customerID string,
 	return deletedCount, nil
 }
 
+// DeleteCustomerConversations remove toda a collection de conversas de um cliente (usado na exclusão LGPD)
+func (s *EmbeddingService) DeleteCustomerConversations(tenantID, customerID string) error {
+	collectionName := s.GetConversationCollectionName(tenantID, customerID)
+
+	_, err := s.qdrantClient.Delete(context.Background(), &qdrant.DeleteCollection{
+		CollectionName: collectionName,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete conversation collection %s: %w", collectionName, err)
+	}
+
+	log.Printf("🗑️ Deleted conversation collection: %s", collectionName)
+	return nil
+}
+
 // convertStringIDsToPointIds converte slice de strings para slice de PointId
 func convertStringIDsToPointIds(stringIDs []string) []*qdrant.PointId {
 	pointIds := make([]*qdrant.PointId, len(stringIDs))
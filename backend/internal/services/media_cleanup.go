@@ -0,0 +1,251 @@
+package services
+
+import (
+	"context"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"iafarma/internal/ai"
+	"iafarma/pkg/models"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	// defaultMediaCleanupDeletesPerSecond limits S3 delete calls so the cleanup never competes with uploads
+	defaultMediaCleanupDeletesPerSecond = 5
+	// mediaCleanupGracePeriod skips recent uploads whose message may still be being saved
+	mediaCleanupGracePeriod = 24 * time.Hour
+)
+
+// mediaObjectStore is the subset of the S3 client used by the cleanup (mocked in tests)
+type mediaObjectStore interface {
+	ListObjectsV2PagesWithContext(ctx aws.Context, input *s3.ListObjectsV2Input, fn func(*s3.ListObjectsV2Output, bool) bool, opts ...request.Option) error
+	DeleteObjectWithContext(ctx aws.Context, input *s3.DeleteObjectInput, opts ...request.Option) (*s3.DeleteObjectOutput, error)
+}
+
+// mediaOwnersLookup returns which of the given customers and messages still exist (not deleted/erased)
+type mediaOwnersLookup func(tenantID uuid.UUID, customerIDs, messageIDs []uuid.UUID) (customers, messages map[uuid.UUID]bool, err error)
+
+// MediaCleanupReport summarizes one cleanup run
+type MediaCleanupReport struct {
+	Scanned        int
+	Orphaned       int
+	Deleted        int
+	BytesReclaimed int64
+	DryRun         bool
+}
+
+// MediaCleanupService removes S3 conversation media (audio/image) whose message was deleted
+// or whose customer was erased (LGPD). In dry-run mode it only logs what would be removed.
+type MediaCleanupService struct {
+	store            mediaObjectStore
+	bucket           string
+	lookup           mediaOwnersLookup
+	dryRun           bool
+	deletesPerSecond int
+	checkInterval    time.Duration
+	now              func() time.Time
+}
+
+// NewMediaCleanupService creates the cleanup job from the storage bucket.
+// MEDIA_CLEANUP_DRY_RUN (default true) and MEDIA_CLEANUP_DELETES_PER_SECOND configure it.
+func NewMediaCleanupService(db *gorm.DB, storage *StorageService) *MediaCleanupService {
+	if storage == nil {
+		return nil
+	}
+
+	dryRun := true
+	if value, err := strconv.ParseBool(strings.TrimSpace(os.Getenv("MEDIA_CLEANUP_DRY_RUN"))); err == nil {
+		dryRun = value
+	}
+	deletesPerSecond, err := strconv.Atoi(os.Getenv("MEDIA_CLEANUP_DELETES_PER_SECOND"))
+	if err != nil || deletesPerSecond <= 0 {
+		deletesPerSecond = defaultMediaCleanupDeletesPerSecond
+	}
+
+	return &MediaCleanupService{
+		store:            storage.s3Client,
+		bucket:           storage.bucket,
+		lookup:           newMediaOwnersLookup(db),
+		dryRun:           dryRun,
+		deletesPerSecond: deletesPerSecond,
+		checkInterval:    24 * time.Hour,
+		now:              time.Now,
+	}
+}
+
+// newMediaOwnersLookup checks the database for live customers and messages (soft-deleted rows count as removed)
+func newMediaOwnersLookup(db *gorm.DB) mediaOwnersLookup {
+	return func(tenantID uuid.UUID, customerIDs, messageIDs []uuid.UUID) (map[uuid.UUID]bool, map[uuid.UUID]bool, error) {
+		customers := make(map[uuid.UUID]bool)
+		messages := make(map[uuid.UUID]bool)
+
+		var liveCustomers []uuid.UUID
+		if err := db.Model(&models.Customer{}).Where("tenant_id = ? AND id IN ?", tenantID, customerIDs).Pluck("id", &liveCustomers).Error; err != nil {
+			return nil, nil, err
+		}
+		for _, id := range liveCustomers {
+			customers[id] = true
+		}
+
+		var liveMessages []uuid.UUID
+		if err := db.Model(&models.Message{}).Where("tenant_id = ? AND id IN ?", tenantID, messageIDs).Pluck("id", &liveMessages).Error; err != nil {
+			return nil, nil, err
+		}
+		for _, id := range liveMessages {
+			messages[id] = true
+		}
+		return customers, messages, nil
+	}
+}
+
+// shouldDeleteConversationMedia decides whether a media object is orphaned: its customer was erased
+// or its message no longer exists. Recent uploads are kept while their message may still be saved.
+func shouldDeleteConversationMedia(customerExists, messageExists bool, lastModified, now time.Time) (bool, string) {
+	if now.Sub(lastModified) < mediaCleanupGracePeriod {
+		return false, ""
+	}
+	if !customerExists {
+		return true, "cliente removido"
+	}
+	if !messageExists {
+		return true, "mensagem removida"
+	}
+	return false, ""
+}
+
+// Start runs the cleanup periodically until the context is cancelled
+func (s *MediaCleanupService) Start(ctx context.Context) {
+	log.Printf("🧹 Limpeza de mídias órfãs iniciada (dry-run: %v, %d remoções/s)", s.dryRun, s.deletesPerSecond)
+
+	ticker := time.NewTicker(s.checkInterval)
+	defer ticker.Stop()
+
+	s.runAndLog(ctx)
+	for {
+		select {
+		case <-ticker.C:
+			s.runAndLog(ctx)
+		case <-ctx.Done():
+			log.Println("🧹 Limpeza de mídias órfãs encerrada")
+			return
+		}
+	}
+}
+
+func (s *MediaCleanupService) runAndLog(ctx context.Context) {
+	report, err := s.Run(ctx)
+	if err != nil {
+		log.Printf("❌ Erro na limpeza de mídias órfãs: %v", err)
+	}
+	if report != nil {
+		action := "removidos"
+		if report.DryRun {
+			action = "seriam removidos (dry-run)"
+		}
+		log.Printf("🧹 Limpeza de mídias: %d objetos verificados, %d órfãos, %d %s, %d bytes recuperados",
+			report.Scanned, report.Orphaned, report.Deleted, action, report.BytesReclaimed)
+	}
+}
+
+// Run scans the conversation media of every tenant once and removes the orphaned objects
+func (s *MediaCleanupService) Run(ctx context.Context) (*MediaCleanupReport, error) {
+	report := &MediaCleanupReport{DryRun: s.dryRun}
+	limiter := time.NewTicker(time.Second / time.Duration(s.deletesPerSecond))
+	defer limiter.Stop()
+
+	var runErr error
+	err := s.store.ListObjectsV2PagesWithContext(ctx, &s3.ListObjectsV2Input{Bucket: aws.String(s.bucket)},
+		func(page *s3.ListObjectsV2Output, lastPage bool) bool {
+			if runErr = s.processPage(ctx, page.Contents, report, limiter.C); runErr != nil {
+				return false
+			}
+			return ctx.Err() == nil
+		})
+	if err != nil {
+		return report, err
+	}
+	return report, runErr
+}
+
+// processPage checks one listing page, grouping lookups by tenant
+func (s *MediaCleanupService) processPage(ctx context.Context, objects []*s3.Object, report *MediaCleanupReport, limiter <-chan time.Time) error {
+	type mediaObject struct {
+		ref          ai.ConversationMediaRef
+		key          string
+		size         int64
+		lastModified time.Time
+	}
+
+	byTenant := make(map[uuid.UUID][]mediaObject)
+	for _, object := range objects {
+		key := aws.StringValue(object.Key)
+		ref, ok := ai.ParseConversationMediaKey(key)
+		if !ok {
+			continue
+		}
+		report.Scanned++
+		byTenant[ref.TenantID] = append(byTenant[ref.TenantID], mediaObject{
+			ref:          ref,
+			key:          key,
+			size:         aws.Int64Value(object.Size),
+			lastModified: aws.TimeValue(object.LastModified),
+		})
+	}
+
+	now := s.now()
+	for tenantID, tenantObjects := range byTenant {
+		customerIDs := make([]uuid.UUID, 0, len(tenantObjects))
+		messageIDs := make([]uuid.UUID, 0, len(tenantObjects))
+		for _, object := range tenantObjects {
+			customerIDs = append(customerIDs, object.ref.CustomerID)
+			messageIDs = append(messageIDs, object.ref.MessageID)
+		}
+
+		customers, messages, err := s.lookup(tenantID, customerIDs, messageIDs)
+		if err != nil {
+			return err
+		}
+
+		for _, object := range tenantObjects {
+			remove, reason := shouldDeleteConversationMedia(customers[object.ref.CustomerID], messages[object.ref.MessageID], object.lastModified, now)
+			if !remove {
+				continue
+			}
+			report.Orphaned++
+
+			if s.dryRun {
+				log.Printf("🧹 [dry-run] Mídia órfã (%s): %s (%d bytes)", reason, object.key, object.size)
+				report.Deleted++
+				report.BytesReclaimed += object.size
+				continue
+			}
+
+			select {
+			case <-limiter:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+
+			if _, err := s.store.DeleteObjectWithContext(ctx, &s3.DeleteObjectInput{
+				Bucket: aws.String(s.bucket),
+				Key:    aws.String(object.key),
+			}); err != nil {
+				log.Printf("⚠️ Falha ao remover mídia órfã %s: %v", object.key, err)
+				continue
+			}
+			log.Printf("🧹 Mídia órfã removida (%s): %s (%d bytes)", reason, object.key, object.size)
+			report.Deleted++
+			report.BytesReclaimed += object.size
+		}
+	}
+	return nil
+}
@@ -0,0 +1,162 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"iafarma/internal/ai"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/google/uuid"
+)
+
+// fakeMediaStore lists a fixed set of objects in two pages and records deletions
+type fakeMediaStore struct {
+	objects   []*s3.Object
+	deleted   []string
+	deleteErr map[string]error
+}
+
+func (f *fakeMediaStore) ListObjectsV2PagesWithContext(ctx aws.Context, input *s3.ListObjectsV2Input, fn func(*s3.ListObjectsV2Output, bool) bool, opts ...request.Option) error {
+	half := len(f.objects) / 2
+	if !fn(&s3.ListObjectsV2Output{Contents: f.objects[:half]}, false) {
+		return nil
+	}
+	fn(&s3.ListObjectsV2Output{Contents: f.objects[half:]}, true)
+	return nil
+}
+
+func (f *fakeMediaStore) DeleteObjectWithContext(ctx aws.Context, input *s3.DeleteObjectInput, opts ...request.Option) (*s3.DeleteObjectOutput, error) {
+	key := aws.StringValue(input.Key)
+	if err := f.deleteErr[key]; err != nil {
+		return nil, err
+	}
+	f.deleted = append(f.deleted, key)
+	return &s3.DeleteObjectOutput{}, nil
+}
+
+func TestShouldDeleteConversationMedia(t *testing.T) {
+	now := time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+	old := now.Add(-48 * time.Hour)
+	recent := now.Add(-time.Hour)
+
+	tests := []struct {
+		name           string
+		customerExists bool
+		messageExists  bool
+		lastModified   time.Time
+		expected       bool
+	}{
+		{"message and customer still exist", true, true, old, false},
+		{"message deleted", true, false, old, true},
+		{"customer erased", false, false, old, true},
+		{"customer erased but message row kept", false, true, old, true},
+		{"recent upload is kept during the grace period", false, false, recent, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, reason := shouldDeleteConversationMedia(tt.customerExists, tt.messageExists, tt.lastModified, now)
+			if got != tt.expected {
+				t.Fatalf("expected %v, got %v (%s)", tt.expected, got, reason)
+			}
+			if got && reason == "" {
+				t.Error("expected a reason for the deletion")
+			}
+		})
+	}
+}
+
+func newTestMediaCleanup(dryRun bool) (*MediaCleanupService, *fakeMediaStore, map[string]bool) {
+	now := time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+	old := now.Add(-72 * time.Hour)
+	tenantID := uuid.New()
+	liveCustomer := uuid.New()
+	erasedCustomer := uuid.New()
+	liveMessage := uuid.New()
+	deletedMessage := uuid.New()
+	erasedCustomerMessage := uuid.New()
+
+	keep := ai.ConversationMediaKey(tenantID.String(), liveCustomer.String(), ai.ConversationMediaAudio, liveMessage.String(), ".ogg")
+	orphanMessage := ai.ConversationMediaKey(tenantID.String(), liveCustomer.String(), ai.ConversationMediaImage, deletedMessage.String(), ".jpg")
+	orphanCustomer := ai.ConversationMediaKey(tenantID.String(), erasedCustomer.String(), ai.ConversationMediaAudio, erasedCustomerMessage.String(), ".ogg")
+	unrelated := tenantID.String() + "/products/" + uuid.New().String() + ".jpg"
+
+	store := &fakeMediaStore{objects: []*s3.Object{
+		{Key: aws.String(keep), Size: aws.Int64(100), LastModified: aws.Time(old)},
+		{Key: aws.String(orphanMessage), Size: aws.Int64(2000), LastModified: aws.Time(old)},
+		{Key: aws.String(unrelated), Size: aws.Int64(5000), LastModified: aws.Time(old)},
+		{Key: aws.String(orphanCustomer), Size: aws.Int64(300), LastModified: aws.Time(old)},
+	}}
+
+	service := &MediaCleanupService{
+		store:  store,
+		bucket: "test-bucket",
+		lookup: func(tenant uuid.UUID, customerIDs, messageIDs []uuid.UUID) (map[uuid.UUID]bool, map[uuid.UUID]bool, error) {
+			return map[uuid.UUID]bool{liveCustomer: true}, map[uuid.UUID]bool{liveMessage: true, erasedCustomerMessage: true}, nil
+		},
+		dryRun:           dryRun,
+		deletesPerSecond: 1000,
+		now:              func() time.Time { return now },
+	}
+	return service, store, map[string]bool{orphanMessage: true, orphanCustomer: true}
+}
+
+func TestMediaCleanupRunDeletesOrphans(t *testing.T) {
+	service, store, orphans := newTestMediaCleanup(false)
+
+	report, err := service.Run(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if report.Scanned != 3 || report.Orphaned != 2 || report.Deleted != 2 {
+		t.Errorf("unexpected report: %+v", report)
+	}
+	if report.BytesReclaimed != 2300 {
+		t.Errorf("expected 2300 bytes reclaimed, got %d", report.BytesReclaimed)
+	}
+	if len(store.deleted) != 2 {
+		t.Fatalf("expected 2 deletions, got %v", store.deleted)
+	}
+	for _, key := range store.deleted {
+		if !orphans[key] {
+			t.Errorf("deleted a non-orphaned object: %s", key)
+		}
+	}
+}
+
+func TestMediaCleanupDryRunDeletesNothing(t *testing.T) {
+	service, store, _ := newTestMediaCleanup(true)
+
+	report, err := service.Run(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(store.deleted) != 0 {
+		t.Errorf("dry-run must not delete objects, deleted %v", store.deleted)
+	}
+	if !report.DryRun || report.Orphaned != 2 || report.BytesReclaimed != 2300 {
+		t.Errorf("unexpected dry-run report: %+v", report)
+	}
+}
+
+func TestMediaCleanupSkipsFailedDeletes(t *testing.T) {
+	service, store, orphans := newTestMediaCleanup(false)
+	store.deleteErr = make(map[string]error)
+	for key := range orphans {
+		store.deleteErr[key] = errors.New("access denied")
+		break
+	}
+
+	report, err := service.Run(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if report.Orphaned != 2 || report.Deleted != 1 || len(store.deleted) != 1 {
+		t.Errorf("expected only one successful deletion, got %+v (%v)", report, store.deleted)
+	}
+}
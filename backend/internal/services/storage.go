@@ -2,6 +2,7 @@ package services
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"io"
 	"log"
@@ -13,6 +14,8 @@ import (
 	"strings"
 	"time"
 
+	"iafarma/internal/ai"
+
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/credentials"
 	"github.com/aws/aws-sdk-go/aws/session"
@@ -69,6 +72,13 @@ func NewStorageService() (*StorageService, error) {
 
 // UploadAudioFile downloads, converts and uploads an audio file to S3
 func (s *StorageService) UploadAudioFile(mediaURL, tenantID, customerID, messageID string) (string, error) {
+	// Limit concurrent conversions/uploads across the process
+	release, err := ai.AcquireMediaSlot(context.Background())
+	if err != nil {
+		return "", err
+	}
+	defer release()
+
 	log.Printf("Starting audio file upload process for message: %s", messageID)
 
 	// Create temporary directory
@@ -93,7 +103,7 @@ func (s *StorageService) UploadAudioFile(mediaURL, tenantID, customerID, message
 	}
 
 	// Generate S3 key with structure: tenant_id/conversations/customer_id/audio_messageID.mp3
-	s3Key := fmt.Sprintf("%s/conversations/%s/audio_%s.mp3", tenantID, customerID, messageID)
+	s3Key := ai.ConversationMediaKey(tenantID, customerID, ai.ConversationMediaAudio, messageID, ".mp3")
 
 	// Upload to S3
 	publicURL, err := s.uploadToS3(convertedPath, s3Key, "audio/mp3")
@@ -107,6 +117,13 @@ func (s *StorageService) UploadAudioFile(mediaURL, tenantID, customerID, message
 
 // UploadImageFile uploads an image file to S3
 func (s *StorageService) UploadImageFile(mediaURL, tenantID, customerID, messageID string) (string, error) {
+	// Limit concurrent conversions/uploads across the process
+	release, err := ai.AcquireMediaSlot(context.Background())
+	if err != nil {
+		return "", err
+	}
+	defer release()
+
 	log.Printf("Starting image file upload process for message: %s", messageID)
 
 	// Create temporary directory
@@ -158,7 +175,7 @@ func (s *StorageService) UploadImageFile(mediaURL, tenantID, customerID, message
 	}
 
 	// Generate S3 key with structure: tenant_id/conversations/customer_id/image_messageID.ext
-	s3Key := fmt.Sprintf("%s/conversations/%s/image_%s%s", tenantID, customerID, messageID, ext)
+	s3Key := ai.ConversationMediaKey(tenantID, customerID, ai.ConversationMediaImage, messageID, ext)
 
 	// Upload to S3
 	publicURL, err := s.uploadToS3(originalPath, s3Key, contentType)
@@ -174,11 +191,14 @@ func (s *StorageService) UploadImageFile(mediaURL, tenantID, customerID, message
 func (s *StorageService) downloadFile(url, filepath string) error {
 	log.Printf("Downloading file from: %s", url)
 
-	// Create HTTP client with timeout
-	client := &http.Client{
-		Timeout: 30 * time.Second,
+	// Reject hosts outside the media allow-list and internal addresses (SSRF)
+	if err := ai.ValidateMediaURL(url); err != nil {
+		return err
 	}
 
+	// Create HTTP client with timeout
+	client := ai.NewMediaHTTPClient(30 * time.Second)
+
 	resp, err := client.Get(url)
 	if err != nil {
 		return fmt.Errorf("failed to download file: %w", err)
@@ -351,6 +371,13 @@ func (s *StorageService) UploadMediaFile(fileHeader *multipart.FileHeader, tenan
 		return s.UploadAndConvertAudioFile(fileHeader, tenantID, messageID)
 	}
 
+	// Limit concurrent conversions/uploads across the process
+	release, err := ai.AcquireMediaSlot(context.Background())
+	if err != nil {
+		return "", err
+	}
+	defer release()
+
 	// Open the uploaded file
 	file, err := fileHeader.Open()
 	if err != nil {
@@ -406,6 +433,13 @@ func (s *StorageService) UploadMediaFile(fileHeader *multipart.FileHeader, tenan
 
 // UploadAndConvertAudioFile uploads and converts audio to OGG Opus format
 func (s *StorageService) UploadAndConvertAudioFile(fileHeader *multipart.FileHeader, tenantID, messageID string) (string, error) {
+	// Limit concurrent conversions/uploads across the process
+	release, err := ai.AcquireMediaSlot(context.Background())
+	if err != nil {
+		return "", err
+	}
+	defer release()
+
 	// Create temp directory
 	tempDir, err := os.MkdirTemp("", "audio_upload_")
 	if err != nil {
@@ -455,6 +489,15 @@ func (s *StorageService) UploadAndConvertAudioFile(fileHeader *multipart.FileHea
 	return publicURL, nil
 }
 
+// KeyFromURL returns the S3 key of a file uploaded by this service; URLs outside the bucket are rejected
+func (s *StorageService) KeyFromURL(fileURL string) (string, bool) {
+	key := strings.TrimPrefix(fileURL, s.baseURL+"/")
+	if key == fileURL || key == "" {
+		return "", false
+	}
+	return key, true
+}
+
 // DeleteFile deletes a file from S3
 func (s *StorageService) DeleteFile(s3Key string) error {
 	_, err := s.s3Client.DeleteObject(&s3.DeleteObjectInput{
@@ -0,0 +1,185 @@
+package utils
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// phoneInTextPattern finds phone-like sequences inside free text (log messages, WhatsApp JIDs)
+var phoneInTextPattern = regexp.MustCompile(`\+?\(?\d[\d\s().-]{8,}\d`)
+
+// piiNameFields hold people's names and are reduced to initials
+var piiNameFields = map[string]bool{
+	"name":          true,
+	"nome":          true,
+	"customer_name": true,
+	"updates_name":  true,
+	"existing_name": true,
+}
+
+// piiContentFields hold message content or addresses and are replaced by their length
+var piiContentFields = map[string]bool{
+	"address":          true,
+	"address_text":     true,
+	"parsed_address":   true,
+	"args":             true,
+	"user_message":     true,
+	"message_content":  true,
+	"original_content": true,
+	"new_content":      true,
+	"transcription":    true,
+	"response_preview": true,
+	"question":         true,
+	"email":            true,
+	"document":         true,
+}
+
+// PIIRedactionEnabled reports whether logs must be redacted: LOG_REDACT_PII wins when set,
+// otherwise redaction is on everywhere except ENV=development
+func PIIRedactionEnabled() bool {
+	switch strings.ToLower(strings.TrimSpace(os.Getenv("LOG_REDACT_PII"))) {
+	case "true", "1", "yes":
+		return true
+	case "false", "0", "no":
+		return false
+	}
+	return os.Getenv("ENV") != "development"
+}
+
+// PIIRedactingWriter masks phones, names, addresses and message content in zerolog JSON lines
+// before passing them on. Phones keep the last 4 digits and a short hash so logs can still be correlated.
+type PIIRedactingWriter struct {
+	out io.Writer
+}
+
+// NewPIIRedactingWriter wraps the log output with PII redaction
+func NewPIIRedactingWriter(out io.Writer) *PIIRedactingWriter {
+	return &PIIRedactingWriter{out: out}
+}
+
+// Write redacts one zerolog event; lines that aren't JSON objects only get phone masking
+func (w *PIIRedactingWriter) Write(p []byte) (int, error) {
+	var event map[string]interface{}
+	decoder := json.NewDecoder(bytes.NewReader(p))
+	decoder.UseNumber()
+	if err := decoder.Decode(&event); err != nil {
+		if _, err := io.WriteString(w.out, MaskPhonesInText(string(p))); err != nil {
+			return 0, err
+		}
+		return len(p), nil
+	}
+
+	for key, value := range event {
+		event[key] = redactLogField(key, value)
+	}
+
+	redacted, err := json.Marshal(event)
+	if err != nil {
+		return 0, err
+	}
+	if _, err := w.out.Write(append(redacted, '\n')); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// redactLogField masks a single log field according to its key
+func redactLogField(key string, value interface{}) interface{} {
+	lowerKey := strings.ToLower(key)
+	switch {
+	case strings.Contains(lowerKey, "phone"):
+		if text, ok := value.(string); ok {
+			return MaskPhone(text)
+		}
+		return MaskPhone(fmt.Sprint(value))
+	case piiNameFields[lowerKey]:
+		if text, ok := value.(string); ok {
+			return MaskName(text)
+		}
+		return "[redacted]"
+	case piiContentFields[lowerKey]:
+		if text, ok := value.(string); ok {
+			return fmt.Sprintf("[redacted %d chars]", len([]rune(text)))
+		}
+		return "[redacted]"
+	}
+
+	if text, ok := value.(string); ok {
+		return MaskPhonesInText(text)
+	}
+	return value
+}
+
+// MaskPhone replaces a phone with its last 4 digits and a hash of the normalized number,
+// e.g. "5511999998888" -> "***8888#1a2b3c4d" (the same phone always gives the same hash)
+func MaskPhone(phone string) string {
+	normalized := NormalizePhone(phone)
+	if normalized == "" {
+		return phone
+	}
+	sum := sha256.Sum256([]byte(normalized))
+	last := normalized
+	if len(last) > 4 {
+		last = last[len(last)-4:]
+	}
+	return "***" + last + "#" + hex.EncodeToString(sum[:4])
+}
+
+// MaskName keeps only the initials of each word, e.g. "Maria Silva" -> "M*** S***"
+func MaskName(name string) string {
+	words := strings.Fields(name)
+	for i, word := range words {
+		words[i] = string([]rune(word)[:1]) + "***"
+	}
+	return strings.Join(words, " ")
+}
+
+// MaskPhonesInText masks every phone-like sequence (10 to 13 digits) found in free text.
+// Digits glued to letters or dashes (UUIDs, hashes) are left untouched.
+func MaskPhonesInText(text string) string {
+	matches := phoneInTextPattern.FindAllStringIndex(text, -1)
+	if matches == nil {
+		return text
+	}
+
+	var result strings.Builder
+	last := 0
+	for _, match := range matches {
+		start, end := match[0], match[1]
+		candidate := text[start:end]
+		if digits := countDigits(candidate); digits < 10 || digits > 13 || gluedToWord(text, start, end) {
+			continue
+		}
+		result.WriteString(text[last:start])
+		result.WriteString(MaskPhone(candidate))
+		last = end
+	}
+	result.WriteString(text[last:])
+	return result.String()
+}
+
+// countDigits counts the digits of a phone candidate
+func countDigits(text string) int {
+	count := 0
+	for _, char := range text {
+		if char >= '0' && char <= '9' {
+			count++
+		}
+	}
+	return count
+}
+
+// gluedToWord reports whether the match is part of a larger token such as a UUID
+func gluedToWord(text string, start, end int) bool {
+	isWordByte := func(b byte) bool {
+		return b == '-' || b == '_' || (b >= '0' && b <= '9') || (b >= 'a' && b <= 'z') || (b >= 'A' && b <= 'Z')
+	}
+	return (start > 0 && isWordByte(text[start-1])) || (end < len(text) && isWordByte(text[end]))
+}
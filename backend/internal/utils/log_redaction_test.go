@@ -0,0 +1,94 @@
+package utils
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/rs/zerolog"
+)
+
+func TestMaskPhone(t *testing.T) {
+	masked := MaskPhone("+55 (11) 99999-8888")
+	if strings.Contains(masked, "99999") || !strings.HasPrefix(masked, "***8888#") {
+		t.Errorf("MaskPhone leaked the number: %q", masked)
+	}
+	if other := MaskPhone("5511999998888@c.us"); other != masked {
+		t.Errorf("MaskPhone should hash the normalized phone, got %q and %q", masked, other)
+	}
+	if MaskPhone("5511999997777") == masked {
+		t.Errorf("different phones must have different hashes")
+	}
+}
+
+func TestMaskPhonesInText(t *testing.T) {
+	tests := []struct {
+		input  string
+		leaked string
+	}{
+		{"Mensagem recebida de 5511999998888", "5511999998888"},
+		{"Enviando para 5527999998888@c.us", "5527999998888"},
+		{"Cliente ligou de (27) 99999-8888 ontem", "99999-8888"},
+	}
+	for _, test := range tests {
+		if result := MaskPhonesInText(test.input); strings.Contains(result, test.leaked) {
+			t.Errorf("MaskPhonesInText(%q) = %q, phone still visible", test.input, result)
+		}
+	}
+
+	untouched := []string{
+		"pedido 550e8400-e29b-41d4-a716-446655440000 criado",
+		"Total R$ 1.234,56 em 2024-06-10",
+		"sem telefone aqui",
+	}
+	for _, input := range untouched {
+		if result := MaskPhonesInText(input); result != input {
+			t.Errorf("MaskPhonesInText(%q) = %q, expected no changes", input, result)
+		}
+	}
+}
+
+func TestPIIRedactingWriter(t *testing.T) {
+	var out bytes.Buffer
+	logger := zerolog.New(NewPIIRedactingWriter(&out))
+
+	logger.Info().
+		Str("customer_phone", "5511999998888").
+		Str("customer_name", "Maria Silva").
+		Str("user_message", "quero 2 caixas de dipirona").
+		Str("tenant_id", "550e8400-e29b-41d4-a716-446655440000").
+		Msg("Mensagem de 5511999998888 processada")
+
+	line := out.String()
+	for _, leaked := range []string{"5511999998888", "Maria Silva", "dipirona"} {
+		if strings.Contains(line, leaked) {
+			t.Errorf("redacted log still contains %q: %s", leaked, line)
+		}
+	}
+	for _, kept := range []string{"***8888#", "M*** S***", "550e8400-e29b-41d4-a716-446655440000", "processada"} {
+		if !strings.Contains(line, kept) {
+			t.Errorf("redacted log should contain %q: %s", kept, line)
+		}
+	}
+}
+
+func TestPIIRedactionEnabled(t *testing.T) {
+	tests := []struct {
+		env      string
+		redact   string
+		expected bool
+	}{
+		{"development", "", false},
+		{"production", "", true},
+		{"", "", true},
+		{"development", "true", true},
+		{"production", "false", false},
+	}
+	for _, test := range tests {
+		t.Setenv("ENV", test.env)
+		t.Setenv("LOG_REDACT_PII", test.redact)
+		if result := PIIRedactionEnabled(); result != test.expected {
+			t.Errorf("PIIRedactionEnabled() with ENV=%q LOG_REDACT_PII=%q = %v, expected %v", test.env, test.redact, result, test.expected)
+		}
+	}
+}
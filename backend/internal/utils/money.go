@@ -0,0 +1,92 @@
+package utils
+
+import (
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Cents is a monetary amount in centavos. Totals are summed as integer cents so that
+// adding up float prices can't drift a cent away from what the customer sees.
+type Cents int64
+
+// ParseCents converts a stored price ("12.50" or "12,50") to cents, rounding half-up on the
+// third decimal place ("1.005" becomes 101). It reports false for anything that isn't a number.
+func ParseCents(value string) (Cents, bool) {
+	value = strings.TrimSpace(value)
+	if !strings.Contains(value, ".") {
+		value = strings.Replace(value, ",", ".", 1)
+	}
+
+	negative := strings.HasPrefix(value, "-")
+	value = strings.TrimLeft(value, "+-")
+
+	integerPart, fractionPart, _ := strings.Cut(value, ".")
+	if integerPart == "" && fractionPart == "" || !isDigits(integerPart) || !isDigits(fractionPart) {
+		return 0, false
+	}
+
+	var cents int64
+	for _, digit := range integerPart {
+		cents = cents*10 + int64(digit-'0')
+	}
+	fraction := (fractionPart + "000")[:3]
+	cents = cents*100 + int64(fraction[0]-'0')*10 + int64(fraction[1]-'0')
+	if fraction[2] >= '5' {
+		cents++
+	}
+
+	if negative {
+		cents = -cents
+	}
+	return Cents(cents), true
+}
+
+// CentsFromFloat rounds a float amount half-up to whole cents. It rounds the shortest decimal
+// representation of the float, so 1.005 becomes 101 cents even though it's stored as 1.00499...
+func CentsFromFloat(value float64) Cents {
+	if math.IsNaN(value) || math.IsInf(value, 0) {
+		return 0
+	}
+	cents, _ := ParseCents(strconv.FormatFloat(value, 'f', -1, 64))
+	return cents
+}
+
+// Times returns the amount multiplied by a quantity
+func (c Cents) Times(quantity int) Cents {
+	return c * Cents(quantity)
+}
+
+// Float returns the amount in reais
+func (c Cents) Float() float64 {
+	return float64(c) / 100
+}
+
+// String formats the amount as a stored price, e.g. "1234.50"
+func (c Cents) String() string {
+	sign := ""
+	if c < 0 {
+		sign = "-"
+		c = -c
+	}
+	return sign + strconv.FormatInt(int64(c/100), 10) + "." + strconv.FormatInt(int64(c%100)+100, 10)[1:]
+}
+
+// RoundMoney rounds an amount in reais half-up to two decimal places
+func RoundMoney(value float64) float64 {
+	return CentsFromFloat(value).Float()
+}
+
+// FormatMoney rounds an amount in reais half-up and formats it as a stored price ("12.50")
+func FormatMoney(value float64) string {
+	return CentsFromFloat(value).String()
+}
+
+func isDigits(value string) bool {
+	for _, char := range value {
+		if char < '0' || char > '9' {
+			return false
+		}
+	}
+	return true
+}
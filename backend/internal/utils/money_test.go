@@ -0,0 +1,89 @@
+package utils
+
+import (
+	"strconv"
+	"testing"
+)
+
+func TestParseCents(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected Cents
+		ok       bool
+	}{
+		{"49.90", 4990, true},
+		{"49,90", 4990, true},
+		{" 7 ", 700, true},
+		{"12.5", 1250, true},
+		{".5", 50, true},
+		{"1.005", 101, true},
+		{"1.004", 100, true},
+		{"-2.675", -268, true},
+		{"29.9999998", 3000, true},
+		{"", 0, false},
+		{"grátis", 0, false},
+		{"1.2.3", 0, false},
+		{"NaN", 0, false},
+	}
+
+	for _, test := range tests {
+		cents, ok := ParseCents(test.input)
+		if cents != test.expected || ok != test.ok {
+			t.Errorf("ParseCents(%q) = %d, %v, expected %d, %v", test.input, cents, ok, test.expected, test.ok)
+		}
+	}
+}
+
+func TestCentsFromFloat(t *testing.T) {
+	parse := func(value string) float64 {
+		parsed, _ := strconv.ParseFloat(value, 64)
+		return parsed
+	}
+	dime := parse("0.10")
+
+	tests := []struct {
+		input    float64
+		expected Cents
+	}{
+		{dime + dime + dime, 30},                     // 0.30000000000000004
+		{parse("4.35")*100 + parse("1.10")*3, 43830}, // 438.29999999999995
+		{(parse("19.99") - parse("19.89")) * 3, 30},  // 0.2999999999999936
+		{parse("1.005"), 101},                        // stored as 1.00499999999999989...
+		{parse("2.675"), 268},                        // "%.2f" gives 2.67
+		{parse("-0.125"), -13},
+	}
+
+	for _, test := range tests {
+		if cents := CentsFromFloat(test.input); cents != test.expected {
+			t.Errorf("CentsFromFloat(%v) = %d, expected %d", test.input, cents, test.expected)
+		}
+	}
+}
+
+func TestCentsString(t *testing.T) {
+	tests := []struct {
+		cents    Cents
+		expected string
+	}{
+		{0, "0.00"},
+		{5, "0.05"},
+		{4990, "49.90"},
+		{123456, "1234.56"},
+		{-1301, "-13.01"},
+	}
+
+	for _, test := range tests {
+		if result := test.cents.String(); result != test.expected {
+			t.Errorf("Cents(%d).String() = %q, expected %q", test.cents, result, test.expected)
+		}
+	}
+}
+
+func TestFormatMoney(t *testing.T) {
+	if result := FormatMoney(Cents(1999).Times(3).Float()); result != "59.97" {
+		t.Errorf("FormatMoney(19.99 x 3) = %q, expected %q", result, "59.97")
+	}
+	if result := RoundMoney(1.005); result != 1.01 {
+		t.Errorf("RoundMoney(1.005) = %v, expected 1.01", result)
+	}
+}
@@ -0,0 +1,89 @@
+package utils
+
+import (
+	"strings"
+)
+
+const brazilCountryCode = "55"
+
+// NormalizePhone converts a Brazilian phone number to digits with the country code,
+// e.g. "+55 (11) 99999-8888", "11 99999-8888" and "011999998888" all become "5511999998888".
+// Numbers that don't look Brazilian are returned with formatting stripped.
+func NormalizePhone(phone string) string {
+	var digits strings.Builder
+	for _, char := range phone {
+		if char >= '0' && char <= '9' {
+			digits.WriteRune(char)
+		}
+	}
+
+	// "+" or "00" means the country code is already present
+	international := strings.HasPrefix(strings.TrimSpace(phone), "+") || strings.HasPrefix(digits.String(), "00")
+
+	// Remove international (00) and trunk (0) prefixes
+	normalized := strings.TrimLeft(digits.String(), "0")
+
+	// DDD + number without country code (10 digits landline or old mobile, 11 digits mobile)
+	if !international && (len(normalized) == 10 || len(normalized) == 11) && isValidDDD(normalized[:2]) {
+		normalized = brazilCountryCode + normalized
+	}
+
+	return normalized
+}
+
+// IsValidBrazilianPhone reports whether the phone normalizes to a valid Brazilian number (55 + DDD + 8 or 9 digits)
+func IsValidBrazilianPhone(phone string) bool {
+	normalized := NormalizePhone(phone)
+	if !strings.HasPrefix(normalized, brazilCountryCode) || !isValidDDD(normalized[2:min(4, len(normalized))]) {
+		return false
+	}
+
+	switch len(normalized) {
+	case 12:
+		return true
+	case 13:
+		// 9-digit numbers are always mobiles starting with 9
+		return normalized[4] == '9'
+	default:
+		return false
+	}
+}
+
+// PhoneLookupVariants returns the normalized phone plus its equivalent form with or without the
+// mobile 9th digit, so lookups match customers stored before or after the 9th digit was added.
+// WhatsApp still reports some mobiles without the 9th digit, so the stored form is never rewritten.
+// Brazilian numbers also match their forms without the country code ("11999998888"), the digits-only
+// format customers registered before phone normalization were saved with.
+func PhoneLookupVariants(phone string) []string {
+	normalized := NormalizePhone(phone)
+	if normalized == "" {
+		return nil
+	}
+
+	variants := []string{normalized}
+	if !IsValidBrazilianPhone(normalized) {
+		return variants
+	}
+
+	prefix, subscriber := normalized[:4], normalized[4:]
+	switch {
+	case len(subscriber) == 9:
+		variants = append(variants, prefix+subscriber[1:])
+	case len(subscriber) == 8 && isMobileSubscriber(subscriber):
+		variants = append(variants, prefix+"9"+subscriber)
+	}
+	for _, variant := range variants {
+		variants = append(variants, strings.TrimPrefix(variant, brazilCountryCode))
+	}
+	return variants
+}
+
+// isValidDDD reports whether the area code is in the Brazilian range (11-99, no zeros)
+func isValidDDD(ddd string) bool {
+	return len(ddd) == 2 && ddd[0] >= '1' && ddd[0] <= '9' && ddd[1] >= '1' && ddd[1] <= '9'
+}
+
+// isMobileSubscriber reports whether an 8-digit number is a mobile missing the 9th digit (starts with 6-9)
+func isMobileSubscriber(subscriber string) bool {
+	return subscriber[0] >= '6' && subscriber[0] <= '9'
+}
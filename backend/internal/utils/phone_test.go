@@ -0,0 +1,120 @@
+package utils
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNormalizePhone(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"5511999998888", "5511999998888"},
+		{"+55 11 99999-8888", "5511999998888"},
+		{"+55 (11) 99999-8888", "5511999998888"},
+		{"(11) 99999-8888", "5511999998888"},
+		{"11999998888", "5511999998888"},
+		{"011 99999-8888", "5511999998888"},
+		{"0055 11 99999-8888", "5511999998888"},
+		{"5511999998888@c.us", "5511999998888"},
+		{"(27) 3333-4444", "552733334444"},
+		{"551199998888", "551199998888"},
+		{"+1 415 555 0100", "14155550100"},
+		{"", ""},
+	}
+
+	for _, test := range tests {
+		if result := NormalizePhone(test.input); result != test.expected {
+			t.Errorf("NormalizePhone(%q) = %q, expected %q", test.input, result, test.expected)
+		}
+	}
+}
+
+func TestIsValidBrazilianPhone(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected bool
+	}{
+		{"+55 11 99999-8888", true},
+		{"11 9999-8888", true},
+		{"(27) 3333-4444", true},
+		{"5511899998888", false}, // 9 digits must start with 9
+		{"5501999998888", false}, // invalid DDD
+		{"99998888", false},      // missing DDD
+		{"+1 415 555 0100", false},
+	}
+
+	for _, test := range tests {
+		if result := IsValidBrazilianPhone(test.input); result != test.expected {
+			t.Errorf("IsValidBrazilianPhone(%q) = %t, expected %t", test.input, result, test.expected)
+		}
+	}
+}
+
+func TestPhoneLookupVariants(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []string
+	}{
+		{"+55 11 99999-8888", []string{"5511999998888", "551199998888", "11999998888", "1199998888"}},
+		{"551199998888", []string{"551199998888", "5511999998888", "1199998888", "11999998888"}},
+		{"(27) 3333-4444", []string{"552733334444", "2733334444"}}, // landline has no 9th digit
+		{"+1 415 555 0100", []string{"14155550100"}},
+		{"", nil},
+	}
+
+	for _, test := range tests {
+		if result := PhoneLookupVariants(test.input); !reflect.DeepEqual(result, test.expected) {
+			t.Errorf("PhoneLookupVariants(%q) = %v, expected %v", test.input, result, test.expected)
+		}
+	}
+}
+
+func TestPhoneFormatsResolveToSameCustomer(t *testing.T) {
+	stored := NormalizePhone("5511999998888")
+	formats := []string{
+		"5511999998888",
+		"+55 11 99999-8888",
+		"(11) 99999-8888",
+		"11999998888",
+		"551199998888", // WhatsApp id without the 9th digit
+		"11 9999-8888",
+	}
+
+	for _, format := range formats {
+		found := false
+		for _, variant := range PhoneLookupVariants(format) {
+			if variant == stored {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("%q does not match stored phone %q (variants %v)", format, stored, PhoneLookupVariants(format))
+		}
+	}
+}
+
+func TestLegacyStoredPhonesStillMatch(t *testing.T) {
+	tests := []struct {
+		stored   string // digits-only format saved before phone normalization
+		incoming string
+	}{
+		{"11999998888", "5511999998888"},
+		{"11999998888", "551199998888"}, // WhatsApp id without the 9th digit
+		{"1199998888", "+55 11 99999-8888"},
+		{"2733334444", "(27) 3333-4444"},
+	}
+
+	for _, test := range tests {
+		found := false
+		for _, variant := range PhoneLookupVariants(test.incoming) {
+			if variant == test.stored {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("%q does not match legacy stored phone %q (variants %v)", test.incoming, test.stored, PhoneLookupVariants(test.incoming))
+		}
+	}
+}
@@ -11,12 +11,14 @@ import (
 	"time"
 
 	"iafarma/internal/ai"
+	"iafarma/internal/repo"
 	"iafarma/internal/services"
 	"iafarma/internal/zapplus"
 	"iafarma/pkg/models"
 
 	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
+	zlog "github.com/rs/zerolog/log"
 	"gorm.io/gorm"
 )
 
@@ -81,6 +83,7 @@ type ZapPlusWebhookHandler struct {
 	wsNotifier            WebSocketNotifier
 	aiService             *ai.AIService
 	tenantSettingsService *ai.TenantSettingsService
+	messageDebouncer      *ai.MessageDebouncer
 }
 
 // NewZapPlusWebhookHandler creates a new webhook handler
@@ -106,6 +109,7 @@ func NewZapPlusWebhookHandler(db *gorm.DB) *ZapPlusWebhookHandler {
 		db:                    db,
 		aiService:             aiService,
 		tenantSettingsService: tenantSettingsService,
+		messageDebouncer:      ai.NewMessageDebouncer(),
 	}
 }
 
@@ -311,8 +315,8 @@ func (h *ZapPlusWebhookHandler) ProcessZapPlusWebhook(c echo.Context) error {
 		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid phone number"})
 	}
 
-	// Find or create customer
-	customer, err := h.findOrCreateCustomer(tenant.ID, phone)
+	// Find or create customer (any format variation of the same number)
+	customer, err := repo.NewCustomerRepository(h.db).GetOrCreateByPhone(tenant.ID, phone)
 	if err != nil {
 		log.Printf("Failed to find/create customer: %v", err)
 		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Customer handling failed"})
@@ -419,36 +423,18 @@ func (h *ZapPlusWebhookHandler) ProcessZapPlusWebhook(c echo.Context) error {
 
 		log.Printf("AI Global Setting - enabled: %t, conversation_enabled: %t", aiGlobalEnabled, currentConversation.AIEnabled)
 
-		// Only process with AI if both global and conversation AI are enabled
-		if aiGlobalEnabled && currentConversation.AIEnabled {
+		// Only process with AI if both global and conversation AI are enabled and no human took over
+		if aiGlobalEnabled && currentConversation.AIEnabled && !currentConversation.BotPaused {
 			// Verificar se o tenant está ativo
 			if tenant.Status != "active" {
 				log.Printf("AI processing skipped - tenant is not active (status: %s): %s", tenant.Status, tenant.ID)
 				return c.JSON(http.StatusOK, map[string]string{"status": "message_processed"})
 			}
 
-			// Verificar créditos e descontar se necessário
-			if !h.checkCreditsAndDeduct(c.Request().Context(), &tenant) {
-				log.Printf("AI processing skipped - insufficient credits or credit check failed for tenant: %s", tenant.ID)
-
-				// Enviar mensagem de indisponibilidade se o tenant configurou uma
-				unavailableMsg := h.getUnavailableMessage(&tenant)
-				if unavailableMsg != "" {
-					go func() {
-						_, err := h.sendViaExternalAPI(webhook.Session, webhook.Payload.From, unavailableMsg)
-						if err != nil {
-							log.Printf("Failed to send unavailable message: %v", err)
-						}
-					}()
-				}
-
-				return c.JSON(http.StatusOK, map[string]string{"status": "message_processed"})
-			}
-
 			log.Printf("Processing message with AI for customer: %s", phone)
 
 			// Process message with AI in a goroutine to avoid blocking the webhook response
-			go func() {
+			processWithAI := func(textContent string) {
 				defer func() {
 					if r := recover(); r != nil {
 						log.Printf("Panic in AI processing goroutine: %v", r)
@@ -457,21 +443,31 @@ func (h *ZapPlusWebhookHandler) ProcessZapPlusWebhook(c echo.Context) error {
 				}()
 
 				var aiResponse string
+				var interactiveResponse *ai.AIResponse
 				var err error
 
 				log.Printf("Starting AI processing - MessageType: %s, MediaURL: %s, TenantBusinessType: %s", message.Type, message.MediaURL, tenant.BusinessType)
 
+				// Slow operations (image, audio, big searches) may send an immediate "processing" notice first
+				aiCtx := context.Background()
+				if messageSource != "chat" {
+					aiCtx = ai.WithProcessingAck(aiCtx, func(ack string) {
+						h.sendProcessingAck(webhook.Session, webhook.Payload.From, tenant.ID, conversation.ID, customer.ID, ack)
+					})
+				}
+
 				log.Printf("Using standard sales AI for tenant: %s", tenant.ID)
 				// Use standard sales AI service
 				if message.Type == "image" && message.MediaURL != "" {
 					log.Printf("Processing image message for medication analysis: %s", message.MediaURL)
-					aiResponse, err = h.aiService.ProcessImageMessage(context.Background(), tenant.ID, phone, message.MediaURL, message.ID.String())
+					aiResponse, err = h.aiService.ProcessImageMessage(aiCtx, tenant.ID, phone, message.MediaURL, message.ID.String())
 				} else if message.Type == "audio" && message.MediaURL != "" {
 					log.Printf("Processing audio message for transcription and analysis: %s", message.MediaURL)
-					aiResponse, err = h.aiService.ProcessAudioMessage(context.Background(), tenant.ID, phone, message.MediaURL, message.ID.String())
-				} else if message.Type == "text" && message.Content != "" {
-					log.Printf("Processing text message: %s", message.Content)
-					aiResponse, err = h.aiService.ProcessMessageWithConversation(context.Background(), tenant.ID, phone, message.Content, conversation.ID)
+					aiResponse, err = h.aiService.ProcessAudioMessage(aiCtx, tenant.ID, phone, message.MediaURL, message.ID.String())
+				} else if message.Type == "text" && textContent != "" {
+					log.Printf("Processing text message: %s", textContent)
+					interactiveResponse, err = h.aiService.ProcessMessageWithConversationResponse(aiCtx, tenant.ID, phone, textContent, conversation.ID)
+					aiResponse = interactiveResponse.String()
 				} else {
 					log.Printf("Skipping AI processing - no content or unsupported type: %s", message.Type)
 					return
@@ -528,7 +524,7 @@ func (h *ZapPlusWebhookHandler) ProcessZapPlusWebhook(c echo.Context) error {
 
 					// Send response back to WhatsApp via ZapPlus API (skip if source is chat)
 					if messageSource != "chat" {
-						externalID, err := h.sendViaExternalAPI(webhook.Session, webhook.Payload.From, aiResponse)
+						externalID, err := h.sendAIResponseViaExternalAPI(webhook.Session, webhook.Payload.From, tenant.ID, aiResponse, interactiveResponse)
 						if err != nil {
 							log.Printf("Failed to send AI response via ZapPlus API: %v", err)
 							log.Printf("AI to: %s, tosession: %s", webhook.Payload.From, webhook.Session)
@@ -551,10 +547,46 @@ func (h *ZapPlusWebhookHandler) ProcessZapPlusWebhook(c echo.Context) error {
 						// The WebSocket handler will send the response directly to the client
 					}
 				}
-			}()
+			}
+
+			// Text messages can be buffered so quick successive messages are answered as one
+			debounceWindow := time.Duration(0)
+			if message.Type == "text" {
+				debounceWindow = h.tenantSettingsService.GetMessageDebounceWindow(context.Background(), tenant.ID)
+			}
+
+			// Credits are charged once per AI run: messages combined by the debounce are billed as one
+			chargeAndProcess := func(textContent string) {
+				// Verificar créditos e descontar se necessário
+				if !h.checkCreditsAndDeduct(context.Background(), &tenant) {
+					log.Printf("AI processing skipped - insufficient credits or credit check failed for tenant: %s", tenant.ID)
+
+					// Enviar mensagem de indisponibilidade se o tenant configurou uma
+					if unavailableMsg := h.getUnavailableMessage(&tenant); unavailableMsg != "" {
+						if _, err := h.sendViaExternalAPI(webhook.Session, webhook.Payload.From, unavailableMsg); err != nil {
+							log.Printf("Failed to send unavailable message: %v", err)
+						}
+					}
+					return
+				}
+				processWithAI(textContent)
+			}
+
+			if debounceWindow > 0 {
+				zlog.Debug().Str("phone", phone).Dur("debounce_window", debounceWindow).Msg("Buffering text message")
+				h.messageDebouncer.Add(tenant.ID, phone, message.Content, debounceWindow, func(combined string) {
+					go chargeAndProcess(combined)
+				})
+			} else {
+				// Media or non-buffered messages flush anything still pending for this customer first
+				h.messageDebouncer.Flush(tenant.ID, phone)
+				go chargeAndProcess(message.Content)
+			}
 		} else {
 			if !aiGlobalEnabled {
 				log.Printf("AI processing skipped - AI globally disabled for tenant: %s", tenant.ID)
+			} else if currentConversation.BotPaused {
+				log.Printf("AI processing skipped - bot paused for human takeover: %s", currentConversation.ID)
 			} else {
 				log.Printf("AI processing skipped - AI disabled for conversation: %s", currentConversation.ID)
 			}
@@ -570,6 +602,38 @@ func (h *ZapPlusWebhookHandler) ProcessZapPlusWebhook(c echo.Context) error {
 	})
 }
 
+// sendProcessingAck sends the immediate "processing" notice of a slow AI operation. It is recorded with the
+// system source so it shows in the panel but is never read back as an assistant turn.
+func (h *ZapPlusWebhookHandler) sendProcessingAck(session, to string, tenantID, conversationID, customerID uuid.UUID, text string) {
+	ackMessage := models.Message{
+		BaseTenantModel: models.BaseTenantModel{
+			ID:       uuid.New(),
+			TenantID: tenantID,
+		},
+		ConversationID: conversationID,
+		CustomerID:     customerID,
+		UserName:       "Assistente IA",
+		Type:           "text",
+		Content:        text,
+		Direction:      "out",
+		Status:         "sent",
+		Source:         "system",
+		IsRead:         true,
+	}
+
+	externalID, err := h.sendViaExternalAPI(session, to, text)
+	if err != nil {
+		log.Printf("Failed to send processing notice via ZapPlus API: %v", err)
+		ackMessage.Status = "failed"
+	} else if externalID != nil {
+		ackMessage.ExternalID = *externalID
+	}
+
+	if err := h.db.Create(&ackMessage).Error; err != nil {
+		log.Printf("Failed to save processing notice message: %v", err)
+	}
+}
+
 // extractPhoneNumber extracts clean phone number from WhatsApp format
 func (h *ZapPlusWebhookHandler) extractPhoneNumber(from string) string {
 	// Remove everything after @ symbol
@@ -591,38 +655,6 @@ func (h *ZapPlusWebhookHandler) extractPhoneNumber(from string) string {
 	return cleaned.String()
 }
 
-// findOrCreateCustomer finds existing customer or creates new one
-func (h *ZapPlusWebhookHandler) findOrCreateCustomer(tenantID uuid.UUID, phone string) (*models.Customer, error) {
-	var customer models.Customer
-
-	// Try to find existing customer
-	err := h.db.Where("tenant_id = ? AND phone = ?", tenantID, phone).First(&customer).Error
-	if err == nil {
-		return &customer, nil
-	}
-
-	if err != gorm.ErrRecordNotFound {
-		return nil, err
-	}
-
-	// Create new customer
-	customer = models.Customer{
-		BaseTenantModel: models.BaseTenantModel{
-			ID:       uuid.New(),
-			TenantID: tenantID,
-		},
-		Phone:    phone,
-		Name:     "", // Nome será solicitado durante o checkout
-		IsActive: true,
-	}
-
-	if err := h.db.Create(&customer).Error; err != nil {
-		return nil, err
-	}
-
-	return &customer, nil
-}
-
 // findOrCreateConversation finds existing conversation or creates new one
 func (h *ZapPlusWebhookHandler) findOrCreateConversation(tenantID, customerID uuid.UUID) (*models.Conversation, error) {
 	var conversation models.Conversation
@@ -765,12 +797,82 @@ func (h *ZapPlusWebhookHandler) getFilename(payload *ZapPlusPayload) string {
 	return ""
 }
 
-// sendViaExternalAPI sends a message via external WhatsApp API and returns the external ID
-func (h *ZapPlusWebhookHandler) sendViaExternalAPI(session, phone, text string) (*string, error) {
+// sendAIResponseViaExternalAPI sends an AI response, using reply buttons when the response has them.
+// Long responses are split into several messages (tenant's max length); buttons go with the last one.
+// Falls back to plain text when the buttons message cannot be delivered.
+func (h *ZapPlusWebhookHandler) sendAIResponseViaExternalAPI(session, phone string, tenantID uuid.UUID, text string, response *ai.AIResponse) (*string, error) {
+	parts := h.aiService.SplitResponseMessages(context.Background(), tenantID, text)
+	if len(parts) == 0 {
+		parts = []string{text}
+	}
+	for _, part := range parts[:len(parts)-1] {
+		if _, err := h.sendViaExternalAPI(session, phone, part); err != nil {
+			return nil, err
+		}
+	}
+	text = parts[len(parts)-1]
+	if len(parts) > 1 {
+		log.Printf("AI response split into %d messages", len(parts))
+	}
+
+	var externalID *string
+	var err error
+	sent := false
+	if response.HasButtons() {
+		externalID, err = h.sendButtonsViaExternalAPI(session, phone, text, response.Buttons)
+		if err == nil {
+			sent = true
+		} else {
+			log.Printf("Failed to send AI response with buttons, falling back to text: %v", err)
+		}
+	}
+	if !sent {
+		externalID, err = h.sendViaExternalAPI(session, phone, text)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Images go after the text so the numbered list is read first; failures keep the text-only reply
+	if response.HasMedia() {
+		client := zapplus.GetClient()
+		for _, media := range response.Media() {
+			if _, err := client.SendImageWithResponse(session, externalChatID(phone), media.URL, media.Caption); err != nil {
+				log.Printf("Failed to send AI response image %s: %v", media.URL, err)
+			}
+		}
+	}
+
+	return externalID, nil
+}
+
+// sendButtonsViaExternalAPI sends a message with reply buttons via external WhatsApp API and returns the external ID
+func (h *ZapPlusWebhookHandler) sendButtonsViaExternalAPI(session, phone, text string, buttons []ai.ResponseButton) (*string, error) {
 	if session == "" {
 		return nil, fmt.Errorf("channel session not configured")
 	}
 
+	titles := make([]string, 0, len(buttons))
+	for _, button := range buttons {
+		titles = append(titles, button.Title)
+	}
+
+	client := zapplus.GetClient()
+	response, err := client.SendButtonsWithResponse(session, externalChatID(phone), strings.ReplaceAll(text, "**", "*"), titles)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send buttons message: %w", err)
+	}
+
+	externalID := response.Data.ID.ID
+	if externalID == "" {
+		return nil, fmt.Errorf("external ID not found in response")
+	}
+
+	return &externalID, nil
+}
+
+// externalChatID converts a phone number to the external API chat ID format
+func externalChatID(phone string) string {
 	// Clean phone number - remove formatting
 	cleanPhone := phone
 	// Add @c.us if not present
@@ -782,6 +884,16 @@ func (h *ZapPlusWebhookHandler) sendViaExternalAPI(session, phone, text string)
 		cleanPhone = strings.ReplaceAll(cleanPhone, "+", "")
 		cleanPhone = cleanPhone + "@c.us"
 	}
+	return cleanPhone
+}
+
+// sendViaExternalAPI sends a message via external WhatsApp API and returns the external ID
+func (h *ZapPlusWebhookHandler) sendViaExternalAPI(session, phone, text string) (*string, error) {
+	if session == "" {
+		return nil, fmt.Errorf("channel session not configured")
+	}
+
+	cleanPhone := externalChatID(phone)
 
 	text = strings.ReplaceAll(text, "**", "*")
 
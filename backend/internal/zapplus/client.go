@@ -4,7 +4,6 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
-	"io"
 	"log"
 	"net/http"
 	"net/url"
@@ -28,6 +27,20 @@ type SendTextRequest struct {
 	Session                string `json:"session"`
 }
 
+// ReplyButton representa um botão de resposta rápida
+type ReplyButton struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+// SendButtonsRequest representa a estrutura para envio de mensagem com botões de resposta
+type SendButtonsRequest struct {
+	ChatID  string        `json:"chatId"`
+	Body    string        `json:"body"`
+	Buttons []ReplyButton `json:"buttons"`
+	Session string        `json:"session"`
+}
+
 // SendTextResponse representa a resposta da API para envio de texto
 type SendTextResponse struct {
 	Success bool   `json:"success"`
@@ -106,6 +119,11 @@ func NewClient(baseURL string) *Client {
 	}
 }
 
+// BaseURL retorna a URL base da API ZapPlus usada pelo cliente
+func (c *Client) BaseURL() string {
+	return c.baseURL
+}
+
 // SendTextMessage envia uma mensagem de texto para um chat específico
 func (c *Client) SendTextMessage(session, chatID, text string) error {
 	return c.SendTextMessageWithPreview(session, chatID, text, true, false)
@@ -147,6 +165,46 @@ func (c *Client) SendTextMessageWithResponse(session, chatID, text string) (*Zap
 	return &response, nil
 }
 
+// SendButtonsWithResponse envia uma mensagem com botões de resposta rápida e retorna a resposta completa
+func (c *Client) SendButtonsWithResponse(session, chatID, body string, buttonTitles []string) (*ZapPlusTextResponse, error) {
+	buttons := make([]ReplyButton, 0, len(buttonTitles))
+	for _, title := range buttonTitles {
+		buttons = append(buttons, ReplyButton{Type: "reply", Text: title})
+	}
+
+	request := SendButtonsRequest{
+		ChatID:  chatID,
+		Body:    body,
+		Buttons: buttons,
+		Session: session,
+	}
+
+	jsonData, err := json.Marshal(request)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	url := fmt.Sprintf("%s/api/sendButtons", c.baseURL)
+	time.Sleep(1 * time.Second) // evitar rate limit
+	resp, err := http.Post(url, "application/json", bytes.NewBuffer(jsonData))
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 && resp.StatusCode != 201 {
+		return nil, fmt.Errorf("ZapPlus API returned status %d", resp.StatusCode)
+	}
+
+	var response ZapPlusTextResponse
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	log.Printf("📤 ZapPlus buttons message sent to %s via session %s (ID: %s)", chatID, session, response.Data.ID.ID)
+	return &response, nil
+}
+
 // SendTextMessageWithPreview envia uma mensagem de texto com configurações de preview
 func (c *Client) SendTextMessageWithPreview(session, chatID, text string, linkPreview, linkPreviewHQ bool) error {
 	request := SendTextRequest{
@@ -186,7 +244,6 @@ func (c *Client) SendGroupMessage(session, groupID, text string) error {
 // GetSessionStatus verifica o status de uma sessão
 func (c *Client) GetSessionStatus(session string) (*SessionResponse, error) {
 	url := fmt.Sprintf("%s/api/sessions/%s", c.baseURL, session)
-	// fmt.Println(url)
 
 	resp, err := c.httpClient.Get(url)
 	if err != nil {
@@ -282,16 +339,8 @@ func (c *Client) AddParticipantToGroup(session, groupID, participantPhone string
 	// encodedGroupID := url.QueryEscape(groupID)
 	url := fmt.Sprintf("%s/api/%s/groups/%s/participants/add", c.baseURL, session, groupID)
 
-	fmt.Println(string(jsonData))
-	fmt.Println(url)
-
 	resp, err := http.Post(url, "application/json", bytes.NewBuffer(jsonData))
 	if err != nil {
-		fmt.Println(err)
-		// print response
-		body, _ := io.ReadAll(resp.Body)
-		fmt.Println(string(body))
-
 		return fmt.Errorf("failed to add participant: %w", err)
 	}
 	defer resp.Body.Close()
@@ -468,10 +517,6 @@ func (c *Client) CreateGroup(session, name string, participants []string) (*Crea
 
 	url := fmt.Sprintf("%s/api/%s/groups", c.baseURL, session)
 
-	fmt.Println("criando grupo zapplus...")
-	fmt.Println(string(jsonData))
-	fmt.Println(url)
-
 	resp, err := http.Post(url, "application/json", bytes.NewBuffer(jsonData))
 	if err != nil {
 		return nil, fmt.Errorf("failed to create group: %w", err)
@@ -616,8 +661,6 @@ func (c *Client) SendImageWithResponse(session, chatID, imageURL, caption string
 		return nil, fmt.Errorf("failed to marshal image request: %w", err)
 	}
 
-	// fmt.Println(string(jsonData))
-
 	url := fmt.Sprintf("%s/api/sendImage", c.baseURL)
 
 	resp, err := http.Post(url, "application/json", bytes.NewBuffer(jsonData))
@@ -2,8 +2,11 @@ package zapplus
 
 import (
 	"fmt"
+	"iafarma/internal/repo"
 	"iafarma/pkg/models"
 	"log"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -24,31 +27,22 @@ func NewNotificationService(db *gorm.DB) *NotificationService {
 	}
 }
 
-// SendShippingNotification envia notificação de pedido enviado
-func (s *NotificationService) SendShippingNotification(tenantID uuid.UUID, order *models.Order) error {
-	log.Printf("📨 Sending shipping notification for order %s", order.OrderNumber)
-
-	// Buscar dados completos do pedido
-	var fullOrder models.Order
-	err := s.db.Preload("Customer").Preload("Conversation").
-		Where("id = ? AND tenant_id = ?", order.ID, tenantID).
-		First(&fullOrder).Error
-	if err != nil {
-		return fmt.Errorf("failed to load order data: %w", err)
+// formatShippingTracking mostra o rastreio e a previsão informados pelos operadores ao despachar o pedido
+func formatShippingTracking(order *models.Order) string {
+	var lines []string
+	if order.TrackingCode != "" {
+		lines = append(lines, "🔎 Código de rastreio: "+order.TrackingCode)
 	}
-
-	// Verificar se há telefone do cliente
-	if fullOrder.Customer == nil || fullOrder.Customer.Phone == "" {
-		return fmt.Errorf("no customer phone found for order %s", order.OrderNumber)
+	if order.TrackingURL != "" {
+		lines = append(lines, "🔗 Acompanhe: "+order.TrackingURL)
 	}
-
-	// Preparar mensagem
-	message := fmt.Sprintf("🚚 *Seu pedido foi enviado!*\n\n📦 Pedido: #%s\n📅 Data: %s\n\nEm breve você receberá as informações de rastreamento.\n\nObrigado pela preferência! 😊",
-		order.OrderNumber,
-		order.UpdatedAt.Format("02/01/2006 15:04"))
-
-	// Enviar mensagem
-	return s.SendDirectMessage(tenantID, fullOrder.Customer.Phone, message)
+	if order.EstimatedDeliveryAt != nil {
+		lines = append(lines, "🕒 Previsão de entrega: "+order.EstimatedDeliveryAt.Format("02/01/2006 15:04"))
+	}
+	if len(lines) == 0 {
+		return "Em breve você receberá as informações de rastreamento."
+	}
+	return strings.Join(lines, "\n")
 }
 
 // SendDirectMessage envia mensagem direta para um cliente
@@ -95,6 +89,11 @@ func (s *NotificationService) SendDirectMessage(tenantID uuid.UUID, customerPhon
 			log.Printf("✅ Message saved to conversation %s for customer %s", conversation.ID, customerPhone)
 		}
 
+		// Mensagem fora da janela do cliente (follow-up, aviso) abre um novo contato
+		if markErr := repo.NewConversationRepository(s.db).MarkBusinessInitiatedIfOpening(&conversation, time.Now()); markErr != nil {
+			log.Printf("⚠️ Failed to record conversation initiation: %v", markErr)
+		}
+
 		// Atualizar última atividade da conversa
 		updateErr := s.db.Model(&conversation).Updates(map[string]interface{}{
 			"updated_at": time.Now(),
@@ -218,6 +217,64 @@ func (s *NotificationService) SendHumanSupportAlert(tenantID uuid.UUID, customer
 	return nil
 }
 
+// SendSpecialOrderAlert envia alerta quando o cliente pede um produto que não está no catálogo.
+// Usa os alertas de pedido especial e, na falta deles, os de atendimento humano.
+func (s *NotificationService) SendSpecialOrderAlert(tenantID uuid.UUID, customerID uuid.UUID, customerPhone, description string) error {
+	return s.sendCustomerRequestAlert(tenantID, customerID, "special_order_request", "special order", func(customer *models.Customer) string {
+		return s.formatSpecialOrderAlert(customer, customerPhone, description)
+	})
+}
+
+// SendReturnRequestAlert envia alerta quando o cliente pede devolução ou troca de um pedido entregue.
+// Usa os alertas de devolução e, na falta deles, os de atendimento humano.
+func (s *NotificationService) SendReturnRequestAlert(tenantID uuid.UUID, customerID uuid.UUID, customerPhone, description string) error {
+	return s.sendCustomerRequestAlert(tenantID, customerID, "return_request", "return request", func(customer *models.Customer) string {
+		return s.formatReturnRequestAlert(customer, customerPhone, description)
+	})
+}
+
+// sendCustomerRequestAlert envia aos grupos do gatilho informado (e aos de atendimento humano) a mensagem
+// montada a partir do cliente
+func (s *NotificationService) sendCustomerRequestAlert(tenantID, customerID uuid.UUID, trigger, kind string, format func(customer *models.Customer) string) error {
+	var alerts []models.Alert
+	err := s.db.Where("tenant_id = ? AND is_active = ? AND trigger_on IN ?",
+		tenantID, true, []string{trigger, "human_support_request"}).
+		Preload("Channel").
+		Find(&alerts).Error
+	if err != nil {
+		return fmt.Errorf("failed to find alerts: %w", err)
+	}
+
+	if len(alerts) == 0 {
+		log.Printf("No %s alerts configured for tenant %s", kind, tenantID)
+		return nil
+	}
+
+	var customer models.Customer
+	err = s.db.Where("id = ? AND tenant_id = ?", customerID, tenantID).First(&customer).Error
+	if err != nil {
+		return fmt.Errorf("failed to find customer: %w", err)
+	}
+
+	message := format(&customer)
+
+	// Um mesmo grupo pode ter os dois gatilhos configurados: envia uma vez só
+	sent := make(map[string]bool)
+	for _, alert := range alerts {
+		if alert.GroupID == "" || alert.Channel == nil || alert.Channel.Session == "" || sent[alert.GroupID] {
+			continue
+		}
+		if err := s.SendGroupAlert(tenantID, alert.GroupID, message, alert.Channel.Session); err != nil {
+			log.Printf("❌ Failed to send %s alert to group %s: %v", kind, alert.GroupName, err)
+			continue
+		}
+		sent[alert.GroupID] = true
+		log.Printf("✅ %s alert sent to group %s", kind, alert.GroupName)
+	}
+
+	return nil
+}
+
 // findActiveSession encontra uma sessão ativa para o tenant/cliente
 func (s *NotificationService) findActiveSession(tenantID uuid.UUID, customerPhone string) (string, error) {
 	// Primeiro, tentar buscar conversa específica do cliente
@@ -249,51 +306,33 @@ func (s *NotificationService) findActiveSession(tenantID uuid.UUID, customerPhon
 		return "", fmt.Errorf("no active session found for tenant %s", tenantID)
 	}
 
-	// Tentar criar ou encontrar cliente
-	var customer models.Customer
-	customerErr := s.db.Where("tenant_id = ? AND phone = ?", tenantID, customerPhone).
-		First(&customer).Error
+	// Buscar ou criar cliente (qualquer formato do mesmo número)
+	customer, err := repo.NewCustomerRepository(s.db).GetOrCreateByPhone(tenantID, customerPhone)
+	if err != nil {
+		log.Printf("❌ Failed to find/create customer: %v", err)
+		return channel.Session, nil // Retorna sessão padrão em caso de erro
+	}
 
+	// Verificar se o cliente já tem conversa ativa
 	var shouldCreateConversation = false
-
-	if customerErr == gorm.ErrRecordNotFound {
-		// Criar novo cliente
-		customer = models.Customer{
-			Phone:    customerPhone,
-			IsActive: true,
-		}
-		customer.TenantID = tenantID
-
-		if err := s.db.Create(&customer).Error; err != nil {
-			log.Printf("❌ Failed to create customer: %v", err)
-			return channel.Session, nil // Retorna sessão padrão mesmo com erro
-		} else {
-			log.Printf("✅ Created new customer: %s", customerPhone)
-			shouldCreateConversation = true
-		}
-	} else if customerErr != nil {
-		log.Printf("❌ Error finding customer: %v", customerErr)
-		return channel.Session, nil // Retorna sessão padrão em caso de erro
-	} else {
-		// Cliente existe, verificar se precisa criar conversa
-		var existingConversation models.Conversation
-		convErr := s.db.Where("tenant_id = ? AND customer_id = ?", tenantID, customer.ID).
-			Where("status IN (?)", []string{"active", "open"}).
-			First(&existingConversation).Error
-
-		if convErr == gorm.ErrRecordNotFound {
-			shouldCreateConversation = true
-			log.Printf("📞 Customer %s exists but has no active conversation, creating new one", customerPhone)
-		}
+	var existingConversation models.Conversation
+	convErr := s.db.Where("tenant_id = ? AND customer_id = ?", tenantID, customer.ID).
+		Where("status IN (?)", []string{"active", "open"}).
+		First(&existingConversation).Error
+
+	if convErr == gorm.ErrRecordNotFound {
+		shouldCreateConversation = true
+		log.Printf("📞 Customer %s has no active conversation, creating new one", customerPhone)
 	}
 
 	// Criar conversa se necessário
 	if shouldCreateConversation && customer.ID != uuid.Nil {
 		newConversation := models.Conversation{
-			CustomerID: customer.ID,
-			ChannelID:  channel.ID,
-			Status:     "active",
-			AIEnabled:  true,
+			CustomerID:  customer.ID,
+			ChannelID:   channel.ID,
+			Status:      "active",
+			AIEnabled:   true,
+			InitiatedBy: repo.ConversationInitiatedByBusiness,
 		}
 		newConversation.TenantID = tenantID
 
@@ -326,7 +365,7 @@ func (s *NotificationService) findActiveSession(tenantID uuid.UUID, customerPhon
 
 // formatOrderAlert formata mensagem de alerta de pedido
 func (s *NotificationService) formatOrderAlert(order *models.Order, customer *models.Customer, customerPhone string) string {
-	return fmt.Sprintf(`🚨 *NOVO PEDIDO RECEBIDO* 🚨
+	return fmt.Sprintf(`🚨 *NOVO PEDIDO RECEBIDO* 🚨%s
 
 📋 *Pedido:* %s
 👤 *Cliente:* %s
@@ -334,18 +373,60 @@ func (s *NotificationService) formatOrderAlert(order *models.Order, customer *mo
 💰 *Valor Total:* R$ %s
 📅 *Data:* %s
 
-🔗 *Status:* %s
+🔗 *Status:* %s%s%s%s
 
 ⚡ _Este pedido foi criado através do sistema de vendas automatizado._`,
+		formatUrgencyLine(order),
 		order.OrderNumber,
 		customer.Name,
 		customerPhone,
 		order.TotalAmount,
 		order.CreatedAt.Format("02/01/2006 15:04"),
 		order.Status,
+		formatInstallmentLine(order),
+		formatDepositLine(order),
+		formatPrescriptionLine(order),
 	)
 }
 
+// formatUrgencyLine destaca no topo do alerta os pedidos urgentes (e a taxa expressa cobrada)
+func formatUrgencyLine(order *models.Order) string {
+	if !order.IsUrgent {
+		return ""
+	}
+	if fee, _ := strconv.ParseFloat(order.UrgencyFee, 64); fee > 0 {
+		return fmt.Sprintf("\n⚡ *URGENTE* - priorizar separação e entrega (taxa de urgência: R$ %s)", order.UrgencyFee)
+	}
+	return "\n⚡ *URGENTE* - priorizar separação e entrega"
+}
+
+// formatInstallmentLine mostra o parcelamento escolhido pelo cliente
+func formatInstallmentLine(order *models.Order) string {
+	if order.InstallmentCount <= 1 {
+		return ""
+	}
+	return fmt.Sprintf("\n💳 *Parcelamento:* %dx de R$ %s", order.InstallmentCount, order.InstallmentAmount)
+}
+
+// formatDepositLine mostra o sinal pago antecipadamente e o valor a receber na entrega
+func formatDepositLine(order *models.Order) string {
+	if !order.HasDeposit {
+		return ""
+	}
+	return fmt.Sprintf("\n💵 *Sinal (%s):* R$ %s\n🤝 *Receber na entrega:* R$ %s", order.DepositPaymentMethod, order.AmountPaid, order.AmountDue)
+}
+
+// formatPrescriptionLine destaca a receita anexada para a conferência da farmácia
+func formatPrescriptionLine(order *models.Order) string {
+	if order.PrescriptionURL != "" {
+		return "\n📄 *Receita:* " + order.PrescriptionURL
+	}
+	if order.PrescriptionRequired {
+		return "\n📄 *Receita:* pendente"
+	}
+	return ""
+}
+
 // formatHumanSupportAlert formata mensagem de alerta de suporte humano
 func (s *NotificationService) formatHumanSupportAlert(customer *models.Customer, customerPhone, reason string) string {
 	return fmt.Sprintf(`🙋‍♂️ *SOLICITAÇÃO DE ATENDIMENTO HUMANO* 🙋‍♂️
@@ -366,3 +447,41 @@ func (s *NotificationService) formatHumanSupportAlert(customer *models.Customer,
 		time.Now().Format("02/01/2006 15:04"),
 	)
 }
+
+// formatSpecialOrderAlert formata a mensagem de alerta de pedido especial
+func (s *NotificationService) formatSpecialOrderAlert(customer *models.Customer, customerPhone, description string) string {
+	return fmt.Sprintf(`📦 *PEDIDO ESPECIAL* 📦
+
+👤 *Cliente:* %s
+📱 *Telefone:* %s
+🔎 *Produto solicitado:* %s
+📅 *Data:* %s
+
+🚨 *AÇÃO NECESSÁRIA:* Verificar se é possível encomendar o produto e dar retorno ao cliente.
+
+⚡ _Esta solicitação foi gerada automaticamente pelo sistema de IA._`,
+		customer.Name,
+		customerPhone,
+		description,
+		time.Now().Format("02/01/2006 15:04"),
+	)
+}
+
+// formatReturnRequestAlert formata a mensagem de alerta de devolução ou troca
+func (s *NotificationService) formatReturnRequestAlert(customer *models.Customer, customerPhone, description string) string {
+	return fmt.Sprintf(`↩️ *DEVOLUÇÃO / TROCA* ↩️
+
+👤 *Cliente:* %s
+📱 *Telefone:* %s
+%s
+📅 *Data:* %s
+
+🚨 *AÇÃO NECESSÁRIA:* Analisar a solicitação e combinar a coleta ou troca com o cliente.
+
+⚡ _Esta solicitação foi gerada automaticamente pelo sistema de IA._`,
+		customer.Name,
+		customerPhone,
+		description,
+		time.Now().Format("02/01/2006 15:04"),
+	)
+}
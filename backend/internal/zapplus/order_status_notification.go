@@ -0,0 +1,160 @@
+package zapplus
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	"iafarma/pkg/models"
+
+	"github.com/google/uuid"
+)
+
+// Etapas do pedido que geram aviso ao cliente, na ordem em que o pedido avança
+const (
+	OrderStatusConfirmed = "confirmed"
+	OrderStatusShipped   = "shipped"
+	OrderStatusDelivered = "delivered"
+)
+
+// NotifiedOrderStatuses lista as etapas com aviso ao cliente
+var NotifiedOrderStatuses = []string{OrderStatusConfirmed, OrderStatusShipped, OrderStatusDelivered}
+
+// orderStatusRank ordena as etapas: pedidos só avisam quando avançam
+var orderStatusRank = map[string]int{
+	"pending":            0,
+	OrderStatusConfirmed: 1,
+	"processing":         1,
+	"preparing":          1,
+	OrderStatusShipped:   2,
+	OrderStatusDelivered: 3,
+}
+
+// OrderStatusNotificationEnabledSettingKey é a configuração (true/false) que liga o aviso da etapa
+func OrderStatusNotificationEnabledSettingKey(status string) string {
+	return "order_notification_" + status + "_enabled"
+}
+
+// OrderStatusNotificationTemplateSettingKey é a configuração com o modelo de mensagem da etapa
+func OrderStatusNotificationTemplateSettingKey(status string) string {
+	return "order_notification_" + status + "_template"
+}
+
+// DefaultOrderStatusTemplates são os modelos usados quando o tenant não personalizou a mensagem.
+// Variáveis: {{order_number}}, {{customer_name}}, {{total}}, {{date}}, {{eta}}, {{tracking_code}},
+// {{tracking_url}} e {{tracking}} (rastreio e previsão já formatados).
+var DefaultOrderStatusTemplates = map[string]string{
+	OrderStatusConfirmed: "✅ *Seu pedido foi confirmado!*\n\n📦 Pedido: #{{order_number}}\n💰 Total: R$ {{total}}\n\nJá estamos separando tudo para você. Avisaremos quando sair para entrega! 😊",
+	OrderStatusShipped:   "🚚 *Seu pedido foi enviado!*\n\n📦 Pedido: #{{order_number}}\n📅 Data: {{date}}\n\n{{tracking}}\n\nObrigado pela preferência! 😊",
+	OrderStatusDelivered: "🎉 *Seu pedido foi entregue!*\n\n📦 Pedido: #{{order_number}}\n📅 Entregue em: {{date}}\n\nEsperamos que goste! Qualquer problema, é só chamar por aqui. 😊",
+}
+
+// orderStage retorna a posição da etapa mais avançada entre o status do pedido e o da entrega (-1 = desconhecida)
+func orderStage(order *models.Order) int {
+	rank := -1
+	for _, status := range []string{order.Status, order.FulfillmentStatus} {
+		if r, ok := orderStatusRank[status]; ok && r > rank {
+			rank = r
+		}
+	}
+	return rank
+}
+
+// OrderStatusTransitionNotification retorna a etapa a avisar quando o pedido avança (ex: "shipped"), ou "" se a
+// alteração não gera aviso. Se o pedido pular etapas, só a mais recente é avisada.
+func OrderStatusTransitionNotification(previous, current *models.Order) string {
+	if current.Status == "cancelled" {
+		return ""
+	}
+	to := orderStage(current)
+	if to <= orderStage(previous) {
+		return ""
+	}
+	switch to {
+	case orderStatusRank[OrderStatusConfirmed]:
+		return OrderStatusConfirmed
+	case orderStatusRank[OrderStatusShipped]:
+		return OrderStatusShipped
+	case orderStatusRank[OrderStatusDelivered]:
+		return OrderStatusDelivered
+	}
+	return ""
+}
+
+// RenderOrderStatusTemplate preenche as variáveis do modelo com os dados do pedido
+func RenderOrderStatusTemplate(template string, order *models.Order) string {
+	customerName := ""
+	if order.Customer != nil {
+		customerName = order.Customer.Name
+	}
+	date := order.UpdatedAt
+	switch stage := orderStage(order); {
+	case stage == orderStatusRank[OrderStatusDelivered] && order.DeliveredAt != nil:
+		date = *order.DeliveredAt
+	case stage == orderStatusRank[OrderStatusShipped] && order.ShippedAt != nil:
+		date = *order.ShippedAt
+	}
+	eta := "a confirmar"
+	if order.EstimatedDeliveryAt != nil {
+		eta = order.EstimatedDeliveryAt.Format("02/01/2006 15:04")
+	}
+
+	replacer := strings.NewReplacer(
+		"{{order_number}}", order.OrderNumber,
+		"{{customer_name}}", customerName,
+		"{{total}}", strings.ReplaceAll(order.TotalAmount, ".", ","),
+		"{{date}}", date.Format("02/01/2006 15:04"),
+		"{{eta}}", eta,
+		"{{tracking_code}}", order.TrackingCode,
+		"{{tracking_url}}", order.TrackingURL,
+		"{{tracking}}", formatShippingTracking(order),
+	)
+	return replacer.Replace(template)
+}
+
+// orderStatusSetting lê a configuração do tenant; ausente ou inativa retorna ok=false
+func (s *NotificationService) orderStatusSetting(tenantID uuid.UUID, key string) (string, bool) {
+	var setting models.TenantSetting
+	err := s.db.Where("tenant_id = ? AND setting_key = ? AND is_active = true", tenantID, key).First(&setting).Error
+	if err != nil || setting.SettingValue == nil {
+		return "", false
+	}
+	return *setting.SettingValue, true
+}
+
+// SendOrderStatusNotification avisa o cliente que o pedido chegou à etapa informada, com o modelo de mensagem do
+// tenant. Etapas desligadas nas configurações não geram aviso.
+func (s *NotificationService) SendOrderStatusNotification(tenantID uuid.UUID, order *models.Order, status string) error {
+	template, ok := DefaultOrderStatusTemplates[status]
+	if !ok {
+		return fmt.Errorf("no notification for order status %q", status)
+	}
+	if value, found := s.orderStatusSetting(tenantID, OrderStatusNotificationEnabledSettingKey(status)); found {
+		if enabled, err := strconv.ParseBool(strings.TrimSpace(value)); err == nil && !enabled {
+			log.Printf("🔕 Order %s notification disabled for tenant %s", status, tenantID)
+			return nil
+		}
+	}
+	if custom, found := s.orderStatusSetting(tenantID, OrderStatusNotificationTemplateSettingKey(status)); found && strings.TrimSpace(custom) != "" {
+		template = custom
+	}
+
+	log.Printf("📨 Sending %s notification for order %s", status, order.OrderNumber)
+
+	// Buscar dados completos do pedido
+	var fullOrder models.Order
+	err := s.db.Preload("Customer").
+		Where("id = ? AND tenant_id = ?", order.ID, tenantID).
+		First(&fullOrder).Error
+	if err != nil {
+		return fmt.Errorf("failed to load order data: %w", err)
+	}
+
+	// Verificar se há telefone do cliente
+	if fullOrder.Customer == nil || fullOrder.Customer.Phone == "" {
+		return fmt.Errorf("no customer phone found for order %s", order.OrderNumber)
+	}
+
+	return s.SendDirectMessage(tenantID, fullOrder.Customer.Phone, RenderOrderStatusTemplate(template, &fullOrder))
+}
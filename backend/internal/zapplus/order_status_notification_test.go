@@ -0,0 +1,86 @@
+package zapplus
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"iafarma/pkg/models"
+)
+
+func TestOrderStatusTransitionNotification(t *testing.T) {
+	tests := []struct {
+		name     string
+		previous models.Order
+		current  models.Order
+		esperado string
+	}{
+		{"pendente para confirmado", models.Order{Status: "pending"}, models.Order{Status: "confirmed"}, OrderStatusConfirmed},
+		{"pendente para em separação", models.Order{Status: "pending"}, models.Order{Status: "processing"}, OrderStatusConfirmed},
+		{"confirmado para em separação", models.Order{Status: "confirmed"}, models.Order{Status: "processing"}, ""},
+		{"confirmado para enviado", models.Order{Status: "confirmed"}, models.Order{Status: "confirmed", FulfillmentStatus: "shipped"}, OrderStatusShipped},
+		{"enviado para entregue", models.Order{Status: "shipped"}, models.Order{Status: "delivered"}, OrderStatusDelivered},
+		{"pulando etapas avisa só a última", models.Order{Status: "pending"}, models.Order{Status: "delivered"}, OrderStatusDelivered},
+		{"sem mudança", models.Order{Status: "shipped"}, models.Order{Status: "shipped"}, ""},
+		{"voltando etapa", models.Order{Status: "shipped"}, models.Order{Status: "confirmed"}, ""},
+		{"status de entrega já enviado", models.Order{Status: "confirmed", FulfillmentStatus: "shipped"}, models.Order{Status: "shipped", FulfillmentStatus: "shipped"}, ""},
+		{"cancelado", models.Order{Status: "confirmed"}, models.Order{Status: "cancelled", FulfillmentStatus: "shipped"}, ""},
+		{"voltando para pendente", models.Order{Status: "cancelled"}, models.Order{Status: "pending"}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if obtido := OrderStatusTransitionNotification(&tt.previous, &tt.current); obtido != tt.esperado {
+				t.Errorf("esperado %q, obtido %q", tt.esperado, obtido)
+			}
+		})
+	}
+}
+
+func TestRenderOrderStatusTemplate(t *testing.T) {
+	shippedAt := time.Date(2026, 3, 10, 14, 30, 0, 0, time.UTC)
+	eta := time.Date(2026, 3, 11, 18, 0, 0, 0, time.UTC)
+	order := &models.Order{
+		OrderNumber:         "PED-001",
+		Status:              "shipped",
+		TotalAmount:         "25.80",
+		TrackingCode:        "BR123",
+		TrackingURL:         "https://rastreio.exemplo.com/BR123",
+		ShippedAt:           &shippedAt,
+		EstimatedDeliveryAt: &eta,
+		Customer:            &models.Customer{Name: "Maria"},
+	}
+
+	obtido := RenderOrderStatusTemplate("Oi {{customer_name}}! Pedido {{order_number}} (R$ {{total}}) saiu em {{date}}, chega {{eta}}. Código {{tracking_code}}: {{tracking_url}}", order)
+	esperado := "Oi Maria! Pedido PED-001 (R$ 25,80) saiu em 10/03/2026 14:30, chega 11/03/2026 18:00. Código BR123: https://rastreio.exemplo.com/BR123"
+	if obtido != esperado {
+		t.Errorf("esperado:\n%s\nobtido:\n%s", esperado, obtido)
+	}
+
+	padrao := RenderOrderStatusTemplate(DefaultOrderStatusTemplates[OrderStatusShipped], order)
+	for _, trecho := range []string{"#PED-001", "🔎 Código de rastreio: BR123", "🕒 Previsão de entrega: 11/03/2026 18:00"} {
+		if !strings.Contains(padrao, trecho) {
+			t.Errorf("esperado %q no modelo padrão:\n%s", trecho, padrao)
+		}
+	}
+	if strings.Contains(padrao, "{{") {
+		t.Errorf("modelo padrão não deveria ficar com variáveis sem preencher:\n%s", padrao)
+	}
+
+	// Sem rastreio nem previsão
+	semRastreio := RenderOrderStatusTemplate("{{eta}} | {{tracking}}", &models.Order{OrderNumber: "PED-002", Status: "confirmed"})
+	if semRastreio != "a confirmar | Em breve você receberá as informações de rastreamento." {
+		t.Errorf("esperados valores padrão sem rastreio, obtido %q", semRastreio)
+	}
+}
+
+func TestDefaultOrderStatusTemplatesCoverNotifiedStatuses(t *testing.T) {
+	for _, status := range NotifiedOrderStatuses {
+		if strings.TrimSpace(DefaultOrderStatusTemplates[status]) == "" {
+			t.Errorf("etapa %q sem modelo padrão", status)
+		}
+		if OrderStatusNotificationEnabledSettingKey(status) == OrderStatusNotificationTemplateSettingKey(status) {
+			t.Errorf("etapa %q com chaves de configuração repetidas", status)
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package models
 
 import (
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -8,45 +9,51 @@ import (
 
 type Address struct {
 	BaseTenantModel
-	CustomerID   uuid.UUID `gorm:"type:uuid;not null;constraint:OnDelete:RESTRICT" json:"customer_id"`
-	Label        string    `json:"label"` // home, work, etc.
-	Name         string    `json:"name"`  // Added field that exists in table
-	Street       string    `gorm:"not null" json:"street" validate:"required"`
-	Number       string    `json:"number"`
-	Complement   string    `json:"complement"`
-	Neighborhood string    `json:"neighborhood"`
-	City         string    `gorm:"not null" json:"city" validate:"required"`
-	State        string    `gorm:"not null" json:"state" validate:"required"`
-	ZipCode      string    `gorm:"column:zipcode;not null" json:"zip_code" validate:"required"` // Map to zipcode column
-	Country      string    `gorm:"default:'BR'" json:"country"`
-	IsDefault    bool      `gorm:"default:false" json:"is_default"`
+	CustomerID     uuid.UUID `gorm:"type:uuid;not null;constraint:OnDelete:RESTRICT" json:"customer_id"`
+	Label          string    `json:"label"` // home, work, etc.
+	Name           string    `json:"name"`  // Added field that exists in table
+	Street         string    `gorm:"not null" json:"street" validate:"required"`
+	Number         string    `json:"number"`
+	Complement     string    `json:"complement"`
+	Apartment      string    `json:"apartment"`       // bloco/apartamento
+	ReferencePoint string    `json:"reference_point"` // ponto de referência para o entregador
+	Neighborhood   string    `json:"neighborhood"`
+	City           string    `gorm:"not null" json:"city" validate:"required"`
+	State          string    `gorm:"not null" json:"state" validate:"required"`
+	ZipCode        string    `gorm:"column:zipcode;not null" json:"zip_code" validate:"required"` // Map to zipcode column
+	Country        string    `gorm:"default:'BR'" json:"country"`
+	IsDefault      bool      `gorm:"default:false" json:"is_default"`
 }
 
 type CreateAddressRequest struct {
-	CustomerID   uuid.UUID `json:"customer_id" validate:"required"`
-	Label        string    `json:"label"`
-	Street       string    `json:"street" validate:"required"`
-	Number       string    `json:"number"`
-	Complement   string    `json:"complement"`
-	Neighborhood string    `json:"neighborhood"`
-	City         string    `json:"city" validate:"required"`
-	State        string    `json:"state" validate:"required"`
-	ZipCode      string    `json:"zip_code" validate:"required"`
-	Country      string    `json:"country"`
-	IsDefault    bool      `json:"is_default"`
+	CustomerID     uuid.UUID `json:"customer_id" validate:"required"`
+	Label          string    `json:"label"`
+	Street         string    `json:"street" validate:"required"`
+	Number         string    `json:"number"`
+	Complement     string    `json:"complement"`
+	Apartment      string    `json:"apartment"`
+	ReferencePoint string    `json:"reference_point"`
+	Neighborhood   string    `json:"neighborhood"`
+	City           string    `json:"city" validate:"required"`
+	State          string    `json:"state" validate:"required"`
+	ZipCode        string    `json:"zip_code" validate:"required"`
+	Country        string    `json:"country"`
+	IsDefault      bool      `json:"is_default"`
 }
 
 type UpdateAddressRequest struct {
-	Label        *string `json:"label"`
-	Street       *string `json:"street"`
-	Number       *string `json:"number"`
-	Complement   *string `json:"complement"`
-	Neighborhood *string `json:"neighborhood"`
-	City         *string `json:"city"`
-	State        *string `json:"state"`
-	ZipCode      *string `json:"zip_code"`
-	Country      *string `json:"country"`
-	IsDefault    *bool   `json:"is_default"`
+	Label          *string `json:"label"`
+	Street         *string `json:"street"`
+	Number         *string `json:"number"`
+	Complement     *string `json:"complement"`
+	Apartment      *string `json:"apartment"`
+	ReferencePoint *string `json:"reference_point"`
+	Neighborhood   *string `json:"neighborhood"`
+	City           *string `json:"city"`
+	State          *string `json:"state"`
+	ZipCode        *string `json:"zip_code"`
+	Country        *string `json:"country"`
+	IsDefault      *bool   `json:"is_default"`
 }
 
 // MunicipioBrasileiro representa um município brasileiro
@@ -62,6 +69,19 @@ type MunicipioBrasileiro struct {
 	UpdatedAt  time.Time `gorm:"column:updated_at" json:"updated_at"`
 }
 
+// DeliveryComplement junta bloco/apartamento e complemento livre em uma única
+// linha, mantendo compatibilidade com endereços antigos que só têm Complement.
+func (a Address) DeliveryComplement() string {
+	parts := make([]string, 0, 2)
+	if apartment := strings.TrimSpace(a.Apartment); apartment != "" {
+		parts = append(parts, apartment)
+	}
+	if complement := strings.TrimSpace(a.Complement); complement != "" {
+		parts = append(parts, complement)
+	}
+	return strings.Join(parts, " - ")
+}
+
 func (MunicipioBrasileiro) TableName() string {
 	return "municipios_brasileiros"
 }
@@ -213,6 +213,34 @@ type AIErrorLog struct {
 	StackTrace    string     `json:"stack_trace"`                     // Stack trace se disponível
 }
 
+// AIToolExecution records each tool call made by the AI, feeding the per-tenant AI metrics
+type AIToolExecution struct {
+	BaseTenantModel
+	CustomerID     uuid.UUID  `gorm:"type:uuid" json:"customer_id"`
+	ConversationID *uuid.UUID `gorm:"type:uuid" json:"conversation_id"`
+	ToolName       string     `gorm:"size:100;not null" json:"tool_name"`
+	Success        bool       `json:"success"`
+}
+
+// MissingProductDemand records a product a customer asked for that the store doesn't carry, feeding the missing demand report
+type MissingProductDemand struct {
+	BaseTenantModel
+	CustomerID      uuid.UUID  `gorm:"type:uuid" json:"customer_id"`
+	ConversationID  *uuid.UUID `gorm:"type:uuid" json:"conversation_id"`
+	Query           string     `gorm:"size:255;not null" json:"query"`                  // Termo como o cliente pediu
+	NormalizedQuery string     `gorm:"size:255;not null;index" json:"normalized_query"` // Termo sem acentos/caixa, usado para agrupar
+	NotifyRequested bool       `gorm:"default:false" json:"notify_requested"`           // Cliente quer ser avisado da reposição
+	NotifiedAt      *time.Time `json:"notified_at"`
+	Source          string     `gorm:"size:20;default:'search'" json:"source"` // search (busca sem resultado) ou special_order (pedido explícito)
+	Details         string     `gorm:"size:500" json:"details"`                // Observações do pedido especial (marca, quantidade...)
+}
+
+// Origens de uma demanda não atendida
+const (
+	MissingDemandSourceSearch       = "search"
+	MissingDemandSourceSpecialOrder = "special_order"
+)
+
 // TenantSetting represents configuration settings for a tenant
 type TenantSetting struct {
 	BaseModel
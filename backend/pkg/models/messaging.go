@@ -34,9 +34,15 @@ type Conversation struct {
 	IsArchived      bool       `gorm:"default:false" json:"is_archived"`
 	IsPinned        bool       `gorm:"default:false" json:"is_pinned"`
 	AIEnabled       bool       `gorm:"default:true" json:"ai_enabled"`
+	BotPaused       bool       `gorm:"default:false" json:"bot_paused"` // human took over, bot stays silent
+	BotPausedAt     *time.Time `json:"bot_paused_at"`
 	LastMessageAt   *time.Time `json:"last_message_at"`
 	UnreadCount     int        `gorm:"default:0" json:"unread_count"`
 
+	// Who opened the current contact: customer, or business (broadcast, follow-up, agent message)
+	InitiatedBy         string     `gorm:"size:20;default:'customer'" json:"initiated_by"`
+	BusinessInitiatedAt *time.Time `json:"business_initiated_at"`
+
 	// Relations
 	Customer      *Customer `gorm:"foreignKey:CustomerID" json:"customer,omitempty"`
 	Channel       *Channel  `gorm:"foreignKey:ChannelID" json:"channel,omitempty"`
@@ -132,6 +138,27 @@ type MessageTemplate struct {
 	User *User `gorm:"foreignKey:UserID" json:"user,omitempty"`
 }
 
+// FAQEntry represents a frequently asked question answered by the AI assistant
+type FAQEntry struct {
+	BaseTenantModel
+	Question string `gorm:"not null;type:text" json:"question" validate:"required"`
+	Answer   string `gorm:"not null;type:text" json:"answer" validate:"required"`
+	Keywords string `gorm:"type:text" json:"keywords"` // Comma-separated terms that also match the question
+	IsActive bool   `gorm:"default:true" json:"is_active"`
+}
+
+// TenantCustomTool represents a tenant-specific AI tool dispatched to a configured HTTP webhook
+type TenantCustomTool struct {
+	BaseTenantModel
+	Name           string `gorm:"not null;size:64;index" json:"name" validate:"required"`
+	Description    string `gorm:"not null;type:text" json:"description" validate:"required"`
+	Parameters     string `gorm:"type:text" json:"parameters"` // JSON schema (type object) of the tool arguments
+	WebhookURL     string `gorm:"not null" json:"webhook_url" validate:"required"`
+	Secret         string `json:"-"` // Signs the webhook payload (HMAC-SHA256)
+	TimeoutSeconds int    `gorm:"default:5" json:"timeout_seconds"`
+	IsActive       bool   `gorm:"default:true" json:"is_active"`
+}
+
 // SLAPolicy represents SLA policies for conversations
 type SLAPolicy struct {
 	BaseTenantModel
@@ -237,6 +264,7 @@ type ConversationMemory struct {
 	ProductList         ProductReferenceList    `gorm:"type:jsonb;default:'[]'" json:"product_list"`
 	ConversationHistory ConversationHistoryList `gorm:"type:jsonb;default:'[]'" json:"conversation_history"`
 	SequentialNumber    int                     `gorm:"default:0" json:"sequential_number"`
+	Summary             string                  `gorm:"type:text" json:"summary"` // Resumo das mensagens antigas já removidas do histórico
 	CreatedAt           time.Time               `gorm:"not null;default:CURRENT_TIMESTAMP" json:"created_at"`
 	UpdatedAt           time.Time               `gorm:"not null;default:CURRENT_TIMESTAMP" json:"updated_at"`
 }
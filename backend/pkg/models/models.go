@@ -166,17 +166,21 @@ func GetAllModels() []interface{} {
 		&ProductMedia{},
 		&ProductCharacteristic{},
 		&CharacteristicItem{},
+		&ProductAccessory{},
 		&Inventory{},
 		&Cart{},
 		&CartItem{},
 		&CartItemAttribute{},
 		&PaymentMethod{},
 		&Order{},
+		&OrderNumberSequence{},
 		&OrderItem{},
 		&OrderItemAttribute{},
 		&Payment{},
 		&Shipment{},
 		&OrderStatusHistory{},
+		&Subscription{},
+		&ReturnRequest{},
 		&Promotion{},
 		&Coupon{},
 		&DomainEvent{},
@@ -195,6 +199,8 @@ func GetAllModels() []interface{} {
 		&ConversationTag{},
 		&QuickReply{},
 		&MessageTemplate{},
+		&FAQEntry{},
+		&TenantCustomTool{},
 		&SLAPolicy{},
 		&AgentAssignment{},
 		&Alert{},
@@ -220,6 +226,8 @@ func GetAllModels() []interface{} {
 
 		// System models
 		&AIErrorLog{},
+		&AIToolExecution{},
+		&MissingProductDemand{},
 		&TenantSetting{},
 
 		// Password reset tokens
@@ -21,6 +21,17 @@ type Customer struct {
 	Gender    string     `json:"gender"`
 	Notes     string     `json:"notes"`
 	IsActive  bool       `gorm:"default:true" json:"is_active"`
+
+	// LastWelcomedAt registra quando a IA enviou a mensagem de boas-vindas (independe da memória da conversa)
+	LastWelcomedAt *time.Time `json:"last_welcomed_at"`
+
+	// LastSeenAt registra a última mensagem do cliente; PreviousVisitAt guarda quando terminou a visita anterior
+	// (usado para mostrar as novidades desde a última visita)
+	LastSeenAt      *time.Time `json:"last_seen_at"`
+	PreviousVisitAt *time.Time `json:"previous_visit_at"`
+
+	// Language guarda o idioma escolhido pelo cliente ("pt", "en", "es"); vazio usa o padrão do tenant
+	Language string `gorm:"size:5" json:"language"`
 }
 
 // Category represents a product category
@@ -51,9 +62,48 @@ type Product struct {
 	StockQuantity     int        `gorm:"default:0" json:"stock_quantity"`
 	LowStockThreshold int        `gorm:"default:5" json:"low_stock_threshold"`
 	SortOrder         int        `gorm:"default:0" json:"sort_order"`
+	Available         bool       `gorm:"not null;default:true" json:"available"` // false = pausado temporariamente (não aparece nas buscas da IA)
 	SearchVector      string     `gorm:"type:tsvector;-" json:"-"`               // Full Text Search vector (não incluir no JSON)
 	SearchText        string     `gorm:"type:text;-" json:"-"`                   // Texto combinado para busca semântica
 	EmbeddingHash     string     `gorm:"type:varchar(64)" json:"embedding_hash"` // Hash do conteúdo para evitar reprocessamento
+
+	// Informações nutricionais e alérgenos (opcionais, usadas por lojas de alimentos e suplementos)
+	Ingredients          string          `gorm:"type:text" json:"ingredients"`
+	Allergens            string          `json:"allergens"`                                   // Lista separada por vírgula (ex: "glúten, lactose")
+	AllergenInfoVerified bool            `gorm:"default:false" json:"allergen_info_verified"` // Lista de alérgenos conferida (vazia = sem alérgenos)
+	NutritionFacts       *NutritionFacts `gorm:"type:jsonb;serializer:json" json:"nutrition_facts,omitempty"`
+
+	// AgeRestricted exige que o cliente confirme ser maior de idade antes de finalizar a compra (ex: bebidas, tabaco)
+	AgeRestricted bool `gorm:"default:false" json:"age_restricted"`
+
+	// RequiresPrescription marca medicamentos controlados: o pedido só é finalizado com a foto da receita retida
+	RequiresPrescription bool `gorm:"default:false" json:"requires_prescription"`
+
+	// PriceTiers define preços de atacado por quantidade (ex: a partir de 10 unidades, R$ 8,00 cada)
+	PriceTiers []PriceTier `gorm:"type:jsonb;serializer:json" json:"price_tiers,omitempty"`
+
+	// Attributes guarda atributos livres do produto conforme o ramo da loja (ex: "dosagem": "500mg", "tamanho": "M")
+	Attributes map[string]string `gorm:"type:jsonb;serializer:json" json:"attributes,omitempty"`
+}
+
+// PriceTier represents a wholesale unit price applied from a minimum quantity
+type PriceTier struct {
+	MinQuantity int    `json:"min_quantity"`
+	UnitPrice   string `json:"unit_price"`
+}
+
+// NutritionFacts represents the nutrition table of a product (values as printed on the label, e.g. "120 kcal")
+type NutritionFacts struct {
+	ServingSize   string `json:"serving_size,omitempty"`
+	Calories      string `json:"calories,omitempty"`
+	Carbohydrates string `json:"carbohydrates,omitempty"`
+	Sugars        string `json:"sugars,omitempty"`
+	Proteins      string `json:"proteins,omitempty"`
+	TotalFat      string `json:"total_fat,omitempty"`
+	SaturatedFat  string `json:"saturated_fat,omitempty"`
+	TransFat      string `json:"trans_fat,omitempty"`
+	Fiber         string `json:"fiber,omitempty"`
+	Sodium        string `json:"sodium,omitempty"`
 }
 
 // ProductVariant represents a product variant
@@ -100,6 +150,18 @@ type CharacteristicItem struct {
 	SortOrder        int       `gorm:"default:0" json:"sort_order"`
 }
 
+// ProductAccessory is a curated accessory suggested with a product ("com essa impressora, leve o cartucho X")
+type ProductAccessory struct {
+	BaseTenantModel
+	ProductID   uuid.UUID `gorm:"type:uuid;not null;index;constraint:OnDelete:RESTRICT" json:"product_id"`
+	AccessoryID uuid.UUID `gorm:"type:uuid;not null;constraint:OnDelete:RESTRICT" json:"accessory_id" validate:"required"`
+	Note        string    `gorm:"size:255" json:"note"` // why they go together, shown to the customer
+	SortOrder   int       `gorm:"default:0" json:"sort_order"`
+
+	// Relations
+	Accessory *Product `gorm:"foreignKey:AccessoryID" json:"accessory,omitempty"`
+}
+
 // Inventory represents product inventory
 type Inventory struct {
 	BaseTenantModel
@@ -112,15 +174,21 @@ type Inventory struct {
 // Cart represents a shopping cart
 type Cart struct {
 	BaseTenantModel
-	CustomerID      uuid.UUID  `gorm:"type:uuid;not null;constraint:OnDelete:RESTRICT" json:"customer_id"`
-	PaymentMethodID *uuid.UUID `gorm:"type:uuid;constraint:OnDelete:SET NULL" json:"payment_method_id"` // Forma de pagamento selecionada
-	Status          string     `gorm:"default:'active'" json:"status"`                                  // active, checkout, completed, abandoned
-	ExpiresAt       *time.Time `json:"expires_at"`
-	TotalAmount     string     `gorm:"default:'0'" json:"total_amount"`
-	ItemsCount      int        `gorm:"default:0" json:"items_count"`
-	DiscountCode    string     `json:"discount_code"`
-	Observations    string     `json:"observations"` // Observações do carrinho (ex: precisa de troco, sem cebola, etc)
-	ChangeFor       string     `json:"change_for"`   // Valor para troco quando pagamento em dinheiro
+	CustomerID       uuid.UUID  `gorm:"type:uuid;not null;constraint:OnDelete:RESTRICT" json:"customer_id"`
+	PaymentMethodID  *uuid.UUID `gorm:"type:uuid;constraint:OnDelete:SET NULL" json:"payment_method_id"` // Forma de pagamento selecionada
+	Status           string     `gorm:"default:'active'" json:"status"`                                  // active, checkout, completed, abandoned
+	ExpiresAt        *time.Time `json:"expires_at"`
+	TotalAmount      string     `gorm:"default:'0'" json:"total_amount"`
+	ItemsCount       int        `gorm:"default:0" json:"items_count"`
+	DiscountCode     string     `json:"discount_code"`
+	Observations     string     `json:"observations"`                       // Observações do carrinho (ex: precisa de troco, sem cebola, etc)
+	ChangeFor        string     `json:"change_for"`                         // Valor para troco quando pagamento em dinheiro
+	AgeConfirmedAt   *time.Time `json:"age_confirmed_at"`                   // Quando o cliente declarou ser maior de idade (produtos restritos)
+	IsPickup         bool       `gorm:"default:false" json:"is_pickup"`     // Cliente vai retirar na loja (sem entrega)
+	PrescriptionURL  string     `json:"prescription_url"`                   // Foto da receita enviada pelo cliente (medicamentos controlados)
+	InstallmentCount int        `gorm:"default:0" json:"installment_count"` // Parcelas escolhidas pelo cliente (0/1 = à vista)
+	IsUrgent         bool       `gorm:"default:false" json:"is_urgent"`     // Cliente pediu prioridade (pedido urgente)
+	PayDeposit       bool       `gorm:"default:false" json:"pay_deposit"`   // Cliente paga um sinal antecipado e o restante na entrega
 
 	// Relations
 	Customer      *Customer      `gorm:"foreignKey:CustomerID" json:"customer,omitempty"`
@@ -142,10 +210,14 @@ type CartItem struct {
 	ProductDescription *string `json:"product_description"`
 	ProductSKU         *string `json:"product_sku"`
 
+	// Endereço de entrega do item quando o cliente divide o pedido entre endereços (nil = endereço principal)
+	DeliveryAddressID *uuid.UUID `gorm:"type:uuid;constraint:OnDelete:SET NULL" json:"delivery_address_id"`
+
 	// Relations
-	Cart       *Cart               `gorm:"foreignKey:CartID" json:"cart,omitempty"`
-	Product    *Product            `gorm:"foreignKey:ProductID" json:"product,omitempty"`
-	Attributes []CartItemAttribute `gorm:"foreignKey:CartItemID" json:"attributes,omitempty"`
+	Cart            *Cart               `gorm:"foreignKey:CartID" json:"cart,omitempty"`
+	Product         *Product            `gorm:"foreignKey:ProductID" json:"product,omitempty"`
+	DeliveryAddress *Address            `gorm:"foreignKey:DeliveryAddressID" json:"delivery_address,omitempty"`
+	Attributes      []CartItemAttribute `gorm:"foreignKey:CartItemID" json:"attributes,omitempty"`
 }
 
 // Order represents an order
@@ -166,11 +238,39 @@ type Order struct {
 	DiscountAmount    string     `gorm:"default:'0'" json:"discount_amount"`
 	Currency          string     `gorm:"default:'BRL'" json:"currency"`
 	Notes             string     `json:"notes"`
-	Observations      string     `json:"observations"` // Campo para observações do cliente (ex: precisa de troco, sem cebola, etc)
-	ChangeFor         string     `json:"change_for"`   // Valor para troco quando pagamento em dinheiro
+	Observations      string     `json:"observations"`                   // Campo para observações do cliente (ex: precisa de troco, sem cebola, etc)
+	ChangeFor         string     `json:"change_for"`                     // Valor para troco quando pagamento em dinheiro
+	AgeConfirmedAt    *time.Time `json:"age_confirmed_at"`               // Confirmação de maioridade registrada no carrinho (compliance)
+	IsPickup          bool       `gorm:"default:false" json:"is_pickup"` // Retirada na loja: não há endereço de entrega
 	ShippedAt         *time.Time `json:"shipped_at"`
 	DeliveredAt       *time.Time `json:"delivered_at"`
 
+	// Rastreamento informado pelos operadores ao despachar o pedido (consultado pelo cliente via rastrearPedido)
+	TrackingCode        string     `json:"tracking_code"`
+	TrackingURL         string     `json:"tracking_url"`
+	EstimatedDeliveryAt *time.Time `json:"estimated_delivery_at"`
+
+	// Receita retida para medicamentos controlados (os operadores consultam a foto pelo link)
+	PrescriptionRequired bool   `gorm:"default:false" json:"prescription_required"`
+	PrescriptionURL      string `json:"prescription_url"`
+
+	// Parcelamento escolhido pelo cliente (0 = à vista)
+	InstallmentCount  int    `gorm:"default:0" json:"installment_count"`
+	InstallmentAmount string `json:"installment_amount"`
+
+	// Pedido urgente: os operadores priorizam a separação e a entrega (taxa expressa opcional, já somada ao total)
+	IsUrgent   bool   `gorm:"default:false" json:"is_urgent"`
+	UrgencyFee string `gorm:"default:'0'" json:"urgency_fee"`
+
+	// Pedido acima do valor limite do tenant: fica pendente até um operador conferir e confirmar com o cliente
+	RequiresReview bool `gorm:"default:false" json:"requires_review"`
+
+	// Pagamento com sinal: parte paga antecipadamente (ex.: PIX) e o restante na entrega com a forma de pagamento do pedido
+	HasDeposit           bool   `gorm:"default:false" json:"has_deposit"`
+	DepositPaymentMethod string `json:"deposit_payment_method"`
+	AmountPaid           string `gorm:"default:'0'" json:"amount_paid"` // Sinal pago antecipadamente
+	AmountDue            string `gorm:"default:'0'" json:"amount_due"`  // Restante a receber na entrega
+
 	// Historical customer data for order integrity
 	CustomerName     *string `json:"customer_name"`
 	CustomerEmail    *string `json:"customer_email"`
@@ -182,6 +282,7 @@ type Order struct {
 	ShippingStreet       *string `json:"shipping_street"`
 	ShippingNumber       *string `json:"shipping_number"`
 	ShippingComplement   *string `json:"shipping_complement"`
+	ShippingReference    *string `json:"shipping_reference"`
 	ShippingNeighborhood *string `json:"shipping_neighborhood"`
 	ShippingCity         *string `json:"shipping_city"`
 	ShippingState        *string `json:"shipping_state"`
@@ -208,6 +309,13 @@ type Order struct {
 	Payments      []Payment      `gorm:"foreignKey:OrderID" json:"payments,omitempty"`
 }
 
+// OrderNumberSequence holds the last sequential value used in a tenant's order numbers ({seq} in the format)
+type OrderNumberSequence struct {
+	TenantID  uuid.UUID `gorm:"type:uuid;primaryKey" json:"tenant_id"`
+	LastValue int64     `gorm:"not null;default:0" json:"last_value"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
 // OrderItem represents an item in an order
 type OrderItem struct {
 	BaseTenantModel
@@ -266,6 +374,50 @@ type OrderStatusHistory struct {
 	ChangedBy  uuid.UUID `gorm:"type:uuid;constraint:OnDelete:RESTRICT" json:"changed_by"`
 }
 
+// Subscription represents a customer's recurring order of a product ("todo mês me manda")
+type Subscription struct {
+	BaseTenantModel
+	CustomerID     uuid.UUID  `gorm:"type:uuid;not null;index;constraint:OnDelete:RESTRICT" json:"customer_id"`
+	ProductID      uuid.UUID  `gorm:"type:uuid;not null;constraint:OnDelete:RESTRICT" json:"product_id"`
+	Quantity       int        `gorm:"not null;default:1" json:"quantity"`
+	Cadence        string     `gorm:"size:20;not null" json:"cadence"`              // weekly, biweekly, monthly, days
+	IntervalDays   int        `gorm:"default:0" json:"interval_days"`               // Interval when cadence = days
+	Status         string     `gorm:"size:20;default:'active';index" json:"status"` // active, paused, cancelled
+	NextRunAt      time.Time  `gorm:"not null;index" json:"next_run_at"`
+	LastRunAt      *time.Time `json:"last_run_at"`
+	PendingOrderID *uuid.UUID `gorm:"type:uuid;constraint:OnDelete:SET NULL" json:"pending_order_id"` // Generated order awaiting the customer's confirmation
+
+	// Relations
+	Customer *Customer `gorm:"foreignKey:CustomerID" json:"customer,omitempty"`
+	Product  *Product  `gorm:"foreignKey:ProductID" json:"product,omitempty"`
+}
+
+// ReturnRequest represents a customer's request to return or exchange items of a delivered order
+type ReturnRequest struct {
+	BaseTenantModel
+	OrderID    uuid.UUID  `gorm:"type:uuid;not null;index;constraint:OnDelete:RESTRICT" json:"order_id"`
+	CustomerID *uuid.UUID `gorm:"type:uuid;index;constraint:OnDelete:SET NULL" json:"customer_id"`
+	Type       string     `gorm:"size:20;not null;default:'return'" json:"type"` // return, exchange
+	Reason     string     `gorm:"size:500;not null" json:"reason"`
+	Items      string     `gorm:"size:500" json:"items"`                           // Itens informados pelo cliente (vazio = pedido inteiro)
+	Status     string     `gorm:"size:20;default:'requested';index" json:"status"` // requested, approved, rejected, completed
+
+	// Relations
+	Order    *Order    `gorm:"foreignKey:OrderID" json:"order,omitempty"`
+	Customer *Customer `gorm:"foreignKey:CustomerID" json:"customer,omitempty"`
+}
+
+// Tipos e situações de uma solicitação de devolução
+const (
+	ReturnRequestTypeReturn   = "return"
+	ReturnRequestTypeExchange = "exchange"
+
+	ReturnRequestStatusRequested = "requested"
+	ReturnRequestStatusApproved  = "approved"
+	ReturnRequestStatusRejected  = "rejected"
+	ReturnRequestStatusCompleted = "completed"
+)
+
 // Promotion represents promotional campaigns
 type Promotion struct {
 	BaseTenantModel
@@ -444,6 +596,7 @@ type OrderItemAttribute struct {
 // PaymentMethod represents a payment method available for orders
 type PaymentMethod struct {
 	BaseTenantModel
-	Name     string `gorm:"not null" json:"name" validate:"required"`
-	IsActive bool   `gorm:"default:true" json:"is_active"`
+	Name         string `gorm:"not null" json:"name" validate:"required"`
+	Instructions string `gorm:"type:text" json:"instructions"` // Shown to the customer, e.g. the PIX key
+	IsActive     bool   `gorm:"default:true" json:"is_active"`
 }
@@ -33,6 +33,8 @@ interface AddressFormData {
   street: string;
   number: string;
   complement: string;
+  apartment: string;
+  reference_point: string;
   neighborhood: string;
   city: string;
   state: string;
@@ -50,6 +52,8 @@ export function AddressManager({ customer, isOpen, onClose }: AddressManagerProp
     street: "",
     number: "",
     complement: "",
+    apartment: "",
+    reference_point: "",
     neighborhood: "",
     city: "",
     state: "",
@@ -72,6 +76,8 @@ export function AddressManager({ customer, isOpen, onClose }: AddressManagerProp
       street: "",
       number: "",
       complement: "",
+      apartment: "",
+      reference_point: "",
       neighborhood: "",
       city: "",
       state: "",
@@ -150,6 +156,8 @@ export function AddressManager({ customer, isOpen, onClose }: AddressManagerProp
       street: address.street,
       number: address.number || "",
       complement: address.complement || "",
+      apartment: address.apartment || "",
+      reference_point: address.reference_point || "",
       neighborhood: address.neighborhood || "",
       city: address.city,
       state: address.state,
@@ -221,8 +229,14 @@ export function AddressManager({ customer, isOpen, onClose }: AddressManagerProp
                           <div className="text-sm space-y-1">
                             <p className="font-medium">
                               {address.street}, {address.number}
+                              {address.apartment && ` - ${address.apartment}`}
                               {address.complement && ` - ${address.complement}`}
                             </p>
+                            {address.reference_point && (
+                              <p className="text-muted-foreground">
+                                Referência: {address.reference_point}
+                              </p>
+                            )}
                             <p className="text-muted-foreground">
                               {address.neighborhood && `${address.neighborhood}, `}
                               {address.city} - {address.state}
@@ -308,13 +322,33 @@ export function AddressManager({ customer, isOpen, onClose }: AddressManagerProp
                 />
               </div>
             </div>
+            <div className="grid grid-cols-2 gap-4">
+              <div className="space-y-2">
+                <Label htmlFor="apartment">Bloco / Apto</Label>
+                <Input
+                  id="apartment"
+                  value={formData.apartment}
+                  onChange={(e) => setFormData({ ...formData, apartment: e.target.value })}
+                  placeholder="Bloco A, Apto 101"
+                />
+              </div>
+              <div className="space-y-2">
+                <Label htmlFor="complement">Complemento</Label>
+                <Input
+                  id="complement"
+                  value={formData.complement}
+                  onChange={(e) => setFormData({ ...formData, complement: e.target.value })}
+                  placeholder="Casa 2, fundos, etc."
+                />
+              </div>
+            </div>
             <div className="space-y-2">
-              <Label htmlFor="complement">Complemento</Label>
+              <Label htmlFor="reference_point">Ponto de referência</Label>
               <Input
-                id="complement"
-                value={formData.complement}
-                onChange={(e) => setFormData({ ...formData, complement: e.target.value })}
-                placeholder="Apto 101, Bloco A, etc."
+                id="reference_point"
+                value={formData.reference_point}
+                onChange={(e) => setFormData({ ...formData, reference_point: e.target.value })}
+                placeholder="Em frente à padaria"
               />
             </div>
             <div className="grid grid-cols-2 gap-4">
@@ -422,13 +456,33 @@ export function AddressManager({ customer, isOpen, onClose }: AddressManagerProp
                 />
               </div>
             </div>
+            <div className="grid grid-cols-2 gap-4">
+              <div className="space-y-2">
+                <Label htmlFor="edit-apartment">Bloco / Apto</Label>
+                <Input
+                  id="edit-apartment"
+                  value={formData.apartment}
+                  onChange={(e) => setFormData({ ...formData, apartment: e.target.value })}
+                  placeholder="Bloco A, Apto 101"
+                />
+              </div>
+              <div className="space-y-2">
+                <Label htmlFor="edit-complement">Complemento</Label>
+                <Input
+                  id="edit-complement"
+                  value={formData.complement}
+                  onChange={(e) => setFormData({ ...formData, complement: e.target.value })}
+                  placeholder="Casa 2, fundos, etc."
+                />
+              </div>
+            </div>
             <div className="space-y-2">
-              <Label htmlFor="edit-complement">Complemento</Label>
+              <Label htmlFor="edit-reference_point">Ponto de referência</Label>
               <Input
-                id="edit-complement"
-                value={formData.complement}
-                onChange={(e) => setFormData({ ...formData, complement: e.target.value })}
-                placeholder="Apto 101, Bloco A, etc."
+                id="edit-reference_point"
+                value={formData.reference_point}
+                onChange={(e) => setFormData({ ...formData, reference_point: e.target.value })}
+                placeholder="Em frente à padaria"
               />
             </div>
             <div className="grid grid-cols-2 gap-4">
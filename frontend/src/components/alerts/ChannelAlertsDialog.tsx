@@ -27,7 +27,7 @@ interface PhoneNumber {
 
 interface AlertForm {
   name: string;
-  trigger_on: 'order_created' | 'human_support_request';
+  trigger_on: 'order_created' | 'human_support_request' | 'special_order_request' | 'return_request';
   phones: PhoneNumber[];
 }
 
@@ -79,7 +79,7 @@ const ChannelAlertsDialog = ({ channel, isOpen, onOpenChange }: ChannelAlertsDia
   const handleStartEdit = (alert: AlertType) => {
     setAlertForm({
       name: alert.name,
-      trigger_on: alert.trigger_on as 'order_created' | 'human_support_request',
+      trigger_on: alert.trigger_on as 'order_created' | 'human_support_request' | 'special_order_request' | 'return_request',
       phones: alert.phones ? alert.phones.split(',').map((phone, index) => ({
         id: `phone-${index}`,
         number: phone.trim()
@@ -258,6 +258,10 @@ const ChannelAlertsDialog = ({ channel, isOpen, onOpenChange }: ChannelAlertsDia
         return 'Pedido Criado';
       case 'human_support_request':
         return 'Solicitação de Suporte Humano';
+      case 'special_order_request':
+        return 'Pedido Especial';
+      case 'return_request':
+        return 'Devolução/Troca';
       default:
         return trigger;
     }
@@ -269,6 +273,10 @@ const ChannelAlertsDialog = ({ channel, isOpen, onOpenChange }: ChannelAlertsDia
         return 'bg-blue-100 text-blue-800';
       case 'human_support_request':
         return 'bg-yellow-100 text-yellow-800';
+      case 'special_order_request':
+        return 'bg-purple-100 text-purple-800';
+      case 'return_request':
+        return 'bg-orange-100 text-orange-800';
       default:
         return 'bg-gray-100 text-gray-800';
     }
@@ -346,6 +354,8 @@ const ChannelAlertsDialog = ({ channel, isOpen, onOpenChange }: ChannelAlertsDia
                       <SelectContent>
                         <SelectItem value="order_created">Pedido Criado</SelectItem>
                         <SelectItem value="human_support_request">Solicitação de Suporte Humano</SelectItem>
+                        <SelectItem value="special_order_request">Pedido Especial</SelectItem>
+                        <SelectItem value="return_request">Devolução/Troca</SelectItem>
                       </SelectContent>
                     </Select>
                   </div>
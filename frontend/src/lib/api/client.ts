@@ -1654,6 +1654,7 @@ class ApiClient {
 
   async createPaymentMethod(data: {
     name: string;
+    instructions?: string;
     is_active?: boolean;
   }): Promise<PaymentMethod> {
     return this.request('/payment-methods', {
@@ -1668,6 +1669,7 @@ class ApiClient {
 
   async updatePaymentMethod(id: string, data: {
     name?: string;
+    instructions?: string;
     is_active?: boolean;
   }): Promise<PaymentMethod> {
     return this.request(`/payment-methods/${id}`, {
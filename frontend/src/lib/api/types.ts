@@ -132,6 +132,8 @@ export interface Address {
   street: string;
   number?: string;
   complement?: string;
+  apartment?: string; // bloco/apartamento
+  reference_point?: string; // ponto de referência para entrega
   neighborhood?: string;
   city: string;
   state: string;
@@ -148,6 +150,8 @@ export interface CreateAddressRequest {
   street: string;
   number?: string;
   complement?: string;
+  apartment?: string; // bloco/apartamento
+  reference_point?: string; // ponto de referência para entrega
   neighborhood?: string;
   city: string;
   state: string;
@@ -161,6 +165,8 @@ export interface UpdateAddressRequest {
   street?: string;
   number?: string;
   complement?: string;
+  apartment?: string;
+  reference_point?: string;
   neighborhood?: string;
   city?: string;
   state?: string;
@@ -397,8 +403,19 @@ export interface Order {
   discount_amount?: string;
   currency?: string;
   notes?: string;
+  is_pickup?: boolean; // Retirada na loja (sem entrega)
+  is_urgent?: boolean; // Pedido urgente (priorizar separação e entrega)
+  urgency_fee?: string; // Taxa de urgência já somada ao total
+  requires_review?: boolean; // Pedido acima do valor limite: aguarda confirmação manual
+  has_deposit?: boolean; // Pagamento com sinal antecipado e restante na entrega
+  deposit_payment_method?: string; // Forma de pagamento do sinal (ex.: PIX)
+  amount_paid?: string; // Sinal pago antecipadamente
+  amount_due?: string; // Restante a receber na entrega
   shipped_at?: string;
   delivered_at?: string;
+  tracking_code?: string; // Código de rastreio informado ao despachar
+  tracking_url?: string; // Link para o cliente acompanhar a entrega
+  estimated_delivery_at?: string; // Previsão de entrega
   created_at: string;
   updated_at: string;
   
@@ -413,6 +430,7 @@ export interface Order {
   shipping_street?: string;
   shipping_number?: string;
   shipping_complement?: string;
+  shipping_reference?: string;
   shipping_neighborhood?: string;
   shipping_city?: string;
   shipping_state?: string;
@@ -483,6 +501,7 @@ export interface OrderWithCustomer extends Order {
 export interface PaymentMethod {
   id: string;
   name: string;
+  instructions?: string;
   is_active: boolean;
   created_at: string;
   updated_at: string;
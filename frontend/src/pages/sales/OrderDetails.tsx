@@ -153,6 +153,9 @@ export default function OrderDetails() {
   const [newPaymentStatus, setNewPaymentStatus] = useState('');
   const [newPaymentMethodId, setNewPaymentMethodId] = useState('');
   const [newFulfillmentStatus, setNewFulfillmentStatus] = useState('');
+  const [trackingCode, setTrackingCode] = useState('');
+  const [trackingUrl, setTrackingUrl] = useState('');
+  const [estimatedDeliveryAt, setEstimatedDeliveryAt] = useState('');
 
   // Estados para email
   const [emailRecipient, setEmailRecipient] = useState('');
@@ -212,6 +215,9 @@ export default function OrderDetails() {
       setNewPaymentStatus(order.payment_status || '');
       setNewPaymentMethodId(order.payment_method_id || 'none');
       setNewFulfillmentStatus(order.fulfillment_status || '');
+      setTrackingCode(order.tracking_code || '');
+      setTrackingUrl(order.tracking_url || '');
+      setEstimatedDeliveryAt(order.estimated_delivery_at ? format(new Date(order.estimated_delivery_at), "yyyy-MM-dd'T'HH:mm") : '');
       
       // Configurar email padrão
       setEmailRecipient(order.customer_email || '');
@@ -383,7 +389,10 @@ export default function OrderDetails() {
           payment_status: newPaymentStatus,
           payment_method_id: newPaymentMethodId === 'none' ? null : newPaymentMethodId,
           fulfillment_status: newFulfillmentStatus,
-          discount_amount: discountAmount 
+          discount_amount: discountAmount,
+          tracking_code: trackingCode.trim() || undefined,
+          tracking_url: trackingUrl.trim() || undefined,
+          estimated_delivery_at: estimatedDeliveryAt ? new Date(estimatedDeliveryAt).toISOString() : undefined
         } 
       });
       setStatusModalOpen(false);
@@ -476,6 +485,7 @@ export default function OrderDetails() {
                   <div class="info-item"><strong>Nome:</strong> ${order?.shipping_name || order?.customer?.name || 'N/A'}</div>
                   <div class="info-item"><strong>Endereço:</strong> ${(order?.shipping_street || '') + ' ' + (order?.shipping_number || '')}</div>
                   <div class="info-item"><strong>Complemento:</strong> ${order?.shipping_complement || 'N/A'}</div>
+                  ${order?.shipping_reference ? `<div class="info-item"><strong>Referência:</strong> ${order.shipping_reference}</div>` : ''}
                   <div class="info-item"><strong>Bairro:</strong> ${order?.shipping_neighborhood || 'N/A'}</div>
                   <div class="info-item"><strong>Cidade:</strong> ${order?.shipping_city || 'N/A'} - ${order?.shipping_state || 'N/A'}</div>
                   <div class="info-item"><strong>CEP:</strong> ${order?.shipping_zipcode || 'N/A'}</div>
@@ -563,7 +573,8 @@ export default function OrderDetails() {
             shipping_name: selectedAddress.name || order.customer?.name || '',
             shipping_street: selectedAddress.street,
             shipping_number: selectedAddress.number,
-            shipping_complement: selectedAddress.complement || '',
+            shipping_complement: [selectedAddress.apartment, selectedAddress.complement].filter(Boolean).join(' - '),
+            shipping_reference: selectedAddress.reference_point || '',
             shipping_neighborhood: selectedAddress.neighborhood,
             shipping_city: selectedAddress.city,
             shipping_state: selectedAddress.state,
@@ -855,7 +866,15 @@ export default function OrderDetails() {
             Voltar
           </Button>
           <div>
-            <h1 className="text-2xl font-bold">Pedido #{order.order_number}</h1>
+            <h1 className="text-2xl font-bold flex items-center gap-2">
+              Pedido #{order.order_number}
+              {order.is_urgent && (
+                <Badge variant="destructive">Urgente</Badge>
+              )}
+              {order.requires_review && order.status === 'pending' && (
+                <Badge variant="outline">Confirmação manual</Badge>
+              )}
+            </h1>
             <p className="text-muted-foreground">
               Criado em {order.created_at ? format(new Date(order.created_at), "dd/MM/yyyy 'às' HH:mm", { locale: ptBR }) : 'Data não informada'}
             </p>
@@ -1053,6 +1072,9 @@ export default function OrderDetails() {
                 <div className="flex items-center gap-2">
                   <MapPin className="w-5 h-5 text-primary" />
                   Endereço de Entrega
+                  {order.is_pickup && (
+                    <Badge variant="outline">Retirada na loja</Badge>
+                  )}
                 </div>
                 {isPending && (
                   <Dialog open={addressModalOpen} onOpenChange={setAddressModalOpen}>
@@ -1243,6 +1265,11 @@ export default function OrderDetails() {
                     {order.shipping_street}, {order.shipping_number}
                     {order.shipping_complement && ` - ${order.shipping_complement}`}
                   </p>
+                  {order.shipping_reference && (
+                    <p className="text-sm text-muted-foreground">
+                      Referência: {order.shipping_reference}
+                    </p>
+                  )}
                   <p className="text-sm text-muted-foreground">
                     {order.shipping_neighborhood}, {order.shipping_city} - {order.shipping_state}
                   </p>
@@ -1284,6 +1311,12 @@ export default function OrderDetails() {
                   <span>R$ {parseFloat(order.shipping_amount).toFixed(2).replace('.', ',')}</span>
                 </div>
               )}
+              {order.is_urgent && order.urgency_fee && parseFloat(order.urgency_fee) > 0 && (
+                <div className="flex justify-between print-summary">
+                  <span className="text-muted-foreground">Taxa de urgência:</span>
+                  <span>R$ {parseFloat(order.urgency_fee).toFixed(2).replace('.', ',')}</span>
+                </div>
+              )}
               {order.discount_amount && parseFloat(order.discount_amount) > 0 && (
                 <div className="flex justify-between text-success print-summary">
                   <span>Desconto:</span>
@@ -1297,6 +1330,18 @@ export default function OrderDetails() {
                   R$ {parseFloat(order.total_amount || '0').toFixed(2).replace('.', ',')}
                 </span>
               </div>
+              {order.has_deposit && (
+                <>
+                  <div className="flex justify-between print-summary">
+                    <span className="text-muted-foreground">Sinal ({order.deposit_payment_method}):</span>
+                    <span>R$ {parseFloat(order.amount_paid || '0').toFixed(2).replace('.', ',')}</span>
+                  </div>
+                  <div className="flex justify-between font-medium print-summary">
+                    <span>Receber na entrega:</span>
+                    <span>R$ {parseFloat(order.amount_due || '0').toFixed(2).replace('.', ',')}</span>
+                  </div>
+                </>
+              )}
               
               {/* Observações do Pedido */}
               {(order.observations || order.change_for) && (
@@ -1497,6 +1542,37 @@ export default function OrderDetails() {
                       </Select>
                     </div>
 
+                    <div>
+                      <Label htmlFor="tracking-code">Código de Rastreio</Label>
+                      <Input
+                        id="tracking-code"
+                        value={trackingCode}
+                        onChange={(e) => setTrackingCode(e.target.value)}
+                        placeholder="Ex.: BR123456789"
+                      />
+                    </div>
+
+                    <div>
+                      <Label htmlFor="tracking-url">Link de Rastreio</Label>
+                      <Input
+                        id="tracking-url"
+                        type="url"
+                        value={trackingUrl}
+                        onChange={(e) => setTrackingUrl(e.target.value)}
+                        placeholder="https://"
+                      />
+                    </div>
+
+                    <div>
+                      <Label htmlFor="estimated-delivery">Previsão de Entrega</Label>
+                      <Input
+                        id="estimated-delivery"
+                        type="datetime-local"
+                        value={estimatedDeliveryAt}
+                        onChange={(e) => setEstimatedDeliveryAt(e.target.value)}
+                      />
+                    </div>
+
                     <div>
                       <Label htmlFor="discount">Desconto (R$)</Label>
                       <Input
@@ -342,6 +342,12 @@ export default function SalesOrders() {
                     <code className="text-sm bg-muted px-2 py-1 rounded font-mono">
                       {order.order_number || order.id}
                     </code>
+                    {order.is_urgent && (
+                      <Badge variant="destructive" className="ml-2">Urgente</Badge>
+                    )}
+                    {order.requires_review && order.status === 'pending' && (
+                      <Badge variant="outline" className="ml-2">Revisar</Badge>
+                    )}
                   </TableCell>
                   <TableCell className="font-medium text-foreground">
                     {order.customer_name || 'Cliente não identificado'}
@@ -2,6 +2,7 @@ import { useState, useEffect } from 'react';
 import { Plus, Search, Pencil, Trash2, Eye, EyeOff } from 'lucide-react';
 import { Button } from "@/components/ui/button";
 import { Input } from "@/components/ui/input";
+import { Textarea } from "@/components/ui/textarea";
 import { Badge } from "@/components/ui/badge";
 import { Card, CardContent, CardDescription, CardHeader, CardTitle } from "@/components/ui/card";
 import { Table, TableBody, TableCell, TableHead, TableHeader, TableRow } from "@/components/ui/table";
@@ -12,6 +13,7 @@ import { PaymentMethod } from "@/lib/api/types";
 
 interface PaymentMethodFormData {
   name: string;
+  instructions: string;
   is_active: boolean;
 }
 
@@ -25,6 +27,7 @@ interface PaymentMethodFormProps {
 function PaymentMethodForm({ paymentMethod, onSave, onCancel, isLoading }: PaymentMethodFormProps) {
   const [formData, setFormData] = useState<PaymentMethodFormData>({
     name: paymentMethod?.name || '',
+    instructions: paymentMethod?.instructions || '',
     is_active: paymentMethod?.is_active ?? true,
   });
 
@@ -69,6 +72,22 @@ function PaymentMethodForm({ paymentMethod, onSave, onCancel, isLoading }: Payme
         {errors.name && <p className="text-sm text-red-500">{errors.name}</p>}
       </div>
 
+      <div className="space-y-2">
+        <label htmlFor="instructions" className="text-sm font-medium">
+          Instruções de pagamento
+        </label>
+        <Textarea
+          id="instructions"
+          value={formData.instructions}
+          onChange={(e) => setFormData({ ...formData, instructions: e.target.value })}
+          placeholder="Ex: Chave PIX (CNPJ): 12.345.678/0001-90 - envie o comprovante por aqui"
+          rows={3}
+        />
+        <p className="text-xs text-muted-foreground">
+          Enviadas ao cliente quando ele pedir os dados de pagamento do pedido novamente.
+        </p>
+      </div>
+
       <div className="flex items-center space-x-2">
         <input
           type="checkbox"